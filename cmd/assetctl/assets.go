@@ -0,0 +1,52 @@
+package main
+
+import (
+	"asset/server"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+// newReassignAssetsCmd is for people leaving or changing teams, --by is the admin or asset manager the
+// new assignments are recorded against
+func newReassignAssetsCmd() *cobra.Command {
+	var from, to, by string
+	cmd := &cobra.Command{
+		Use:   "reassign-assets --from EMAIL --to EMAIL --by EMAIL",
+		Short: "Move every asset a user holds to another user",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if from == to {
+				return fmt.Errorf("--from and --to are the same user")
+			}
+			ctx := cmd.Context()
+			return withServer(ctx, func(srv *server.Server) error {
+				ids := make([]uuid.UUID, 0, 3)
+				for _, email := range []string{from, to, by} {
+					userID, err := srv.Users.GetUserIDByEmail(ctx, email)
+					if err != nil {
+						return fmt.Errorf("%s: %w", email, err)
+					}
+					ids = append(ids, userID)
+				}
+				assetIDs, err := srv.Assets.ReassignAssets(ctx, ids[0], ids[1], ids[2])
+				if err != nil {
+					return err
+				}
+				for _, assetID := range assetIDs {
+					fmt.Println(assetID)
+				}
+				fmt.Printf("%d assets moved from %s to %s\n", len(assetIDs), from, to)
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "", "email of the user holding the assets")
+	cmd.Flags().StringVar(&to, "to", "", "email of the user receiving them")
+	cmd.Flags().StringVar(&by, "by", "", "email of the admin or asset manager making the change")
+	cmd.MarkFlagRequired("from")
+	cmd.MarkFlagRequired("to")
+	cmd.MarkFlagRequired("by")
+	return cmd
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"asset/models"
+	"asset/server"
+	"asset/services/asset"
+	"asset/services/user"
+	"asset/utils"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// newExportCmd writes every employee or asset across all departments to a file, stdout carries the logs.
+// The asset export can be imported again through the bulk import
+func newExportCmd() *cobra.Command {
+	var format, out, types string
+	cmd := &cobra.Command{
+		Use:       "export employees|assets --out FILE",
+		Short:     "Write every employee or asset to a file",
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: []string{"employees", "assets"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != utils.ExportFormatCSV && format != utils.ExportFormatXLSX {
+				return fmt.Errorf("format must be csv or xlsx")
+			}
+			var filterTypes []string
+			if types != "" {
+				filterTypes = strings.Split(types, ",")
+			}
+			return exportData(cmd.Context(), args[0], format, out, filterTypes)
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", utils.ExportFormatCSV, "csv or xlsx")
+	cmd.Flags().StringVar(&out, "out", "", "file to write")
+	cmd.Flags().StringVar(&types, "type", "", "comma separated employee or asset types to keep")
+	cmd.MarkFlagRequired("out")
+	return cmd
+}
+
+func exportData(ctx context.Context, kind, format, out string, filterTypes []string) error {
+	return withServer(ctx, func(srv *server.Server) error {
+		var header []string
+		var rows [][]string
+		var err error
+		scope := models.DepartmentScope{AllDepartments: true}
+		if kind == "employees" {
+			header = userservice.EmployeeExportHeader
+			rows, err = srv.Users.ExportEmployees(ctx, userservice.EmployeeFilter{Type: filterTypes, Scope: scope})
+		} else {
+			header = assetservice.AssetExportHeader
+			rows, err = srv.Assets.ExportAssets(ctx, models.AssetFilter{Type: filterTypes, Scope: scope})
+		}
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		if err := utils.WriteExport(file, format, header, rows); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+		fmt.Printf("%d %s written to %s\n", len(rows), kind, out)
+		return nil
+	})
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"asset/models"
+	"asset/server"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// newJobsCmd runs a job on this process and waits, the run is recorded in the history as a manual one.
+// Instances of the server are left alone, a scheduled run there may overlap
+func newJobsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "jobs",
+		Short: "List the background jobs or run one here and wait for it",
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "list",
+			Short: "List the background jobs and their schedules",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return withServer(cmd.Context(), func(srv *server.Server) error {
+					w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+					fmt.Fprintln(w, "NAME\tSCHEDULE\tPER INSTANCE\tDESCRIPTION")
+					for _, job := range srv.Jobs.Jobs() {
+						fmt.Fprintf(w, "%s\t%s\t%t\t%s\n", job.Name, job.Schedule, job.PerInstance, job.Description)
+					}
+					return w.Flush()
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "run NAME",
+			Short: "Run a job here and wait for it",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				ctx := cmd.Context()
+				return withServer(ctx, func(srv *server.Server) error {
+					run, err := srv.Jobs.Run(ctx, args[0])
+					if err != nil {
+						return err
+					}
+					if run.Status == models.JobRunFailed {
+						return fmt.Errorf("%s failed after %dms: %s", run.Job, run.DurationMS, *run.Error)
+					}
+					fmt.Printf("%s %s in %dms\n", run.Job, run.Status, run.DurationMS)
+					return nil
+				})
+			},
+		},
+	)
+	return cmd
+}
@@ -0,0 +1,56 @@
+// assetctl runs operational tasks against the database and the services directly, without going
+// through the http api. It reads the same environment and config file as the server
+package main
+
+import (
+	"asset/server"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// providers get this long to flush and close once a command is done
+const closeTimeout = 30 * time.Second
+
+func main() {
+	root := &cobra.Command{
+		Use:   "assetctl",
+		Short: "Operational tasks against the database and the services, without the http api",
+		// a failed task is not a usage mistake, main prints the error once
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	root.AddCommand(
+		newCreateAdminCmd(),
+		newResetRolesCmd(),
+		newReassignAssetsCmd(),
+		newMigrateCmd(),
+		newSeedCmd(),
+		newJobsCmd(),
+		newExportCmd(),
+	)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if cmd, err := root.ExecuteContextC(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", cmd.CommandPath(), err)
+		os.Exit(1)
+	}
+}
+
+// withServer builds the services as the server does, migrations included, and closes them once fn is done
+func withServer(ctx context.Context, fn func(srv *server.Server) error) error {
+	srv := server.ServerInit()
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+		defer cancel()
+		srv.Close(closeCtx)
+		srv.Logger.SyncLogger()
+	}()
+	return fn(srv)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/providers/configProvider"
+	"asset/providers/databaseProvider"
+	secretsprovider "asset/providers/secretsProvider"
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+// openDB connects with the config and secrets of the server without building its services, so it works
+// while other settings of the server are still missing
+func openDB() (*databaseProvider.PostgresProvider, providers.ConfigProvider, error) {
+	cfg := configprovider.NewConfigProvider()
+	if err := cfg.LoadEnv(); err != nil {
+		return nil, nil, fmt.Errorf("invalid configuration for the %s profile: %w", cfg.GetProfile(), err)
+	}
+	secrets, err := secretsprovider.NewSecretsProvider(cfg.GetSecretsConfig())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize secrets provider: %w", err)
+	}
+	db, err := databaseProvider.Connect(cfg.GetDatabaseString(), cfg.GetMigrationConfig(), cfg.GetDBPoolConfig(), secrets)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to the database: %w", err)
+	}
+	return db, cfg, nil
+}
+
+// newMigrateCmd reads migrations from MIGRATIONS_DIR, relative to the working directory
+func newMigrateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Show the schema version and pending migrations, apply or roll back migrations, or mark a version applied",
+	}
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "status",
+			Short: "Show the schema version and the pending migrations",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return migrateDB(func(db *databaseProvider.PostgresProvider, status models.MigrationStatus) error {
+					fmt.Printf("schema version %d, latest %d\n", status.Version, status.Latest)
+					for _, migration := range status.Pending {
+						fmt.Printf("pending %06d %s\n", migration.Version, migration.Name)
+					}
+					return nil
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "up [N]",
+			Short: "Apply N pending migrations, all of them without N",
+			Args:  cobra.MaximumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				steps := 0
+				if len(args) == 1 {
+					n, err := parseNumber(args[0])
+					if err != nil {
+						return err
+					}
+					steps = n
+				}
+				return migrateDB(func(db *databaseProvider.PostgresProvider, status models.MigrationStatus) error {
+					version, err := db.MigrateUp(steps)
+					if err != nil {
+						return err
+					}
+					fmt.Printf("schema migrated from version %d to %d\n", status.Version, version)
+					return nil
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "down N",
+			Short: "Roll back N migrations, each needs its .down.sql file",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				steps, err := parseNumber(args[0])
+				if err != nil {
+					return err
+				}
+				return migrateDB(func(db *databaseProvider.PostgresProvider, status models.MigrationStatus) error {
+					version, err := db.MigrateDown(steps)
+					if err != nil {
+						return err
+					}
+					fmt.Printf("schema rolled back from version %d to %d\n", status.Version, version)
+					return nil
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "force VERSION",
+			Short: "Mark VERSION applied after a failed migration was finished or undone by hand",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				version, err := parseNumber(args[0])
+				if err != nil {
+					return err
+				}
+				db, _, err := openDB()
+				if err != nil {
+					return err
+				}
+				defer db.Close()
+				status, err := db.MigrationStatus()
+				if err != nil {
+					return err
+				}
+				if err := db.ForceMigrationVersion(version); err != nil {
+					return err
+				}
+				fmt.Printf("schema version forced from %d to %d\n", status.Version, version)
+				return nil
+			},
+		},
+	)
+	return cmd
+}
+
+// migrateDB hands fn the database and its migration status, only forcing the version gets a dirty
+// schema going again
+func migrateDB(fn func(db *databaseProvider.PostgresProvider, status models.MigrationStatus) error) error {
+	db, _, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	status, err := db.MigrationStatus()
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return fmt.Errorf("schema version %d is dirty, its migration failed halfway. Fix the schema by hand and run migrate force", status.Version)
+	}
+	return fn(db, status)
+}
+
+func parseNumber(arg string) (int, error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", arg)
+	}
+	return n, nil
+}
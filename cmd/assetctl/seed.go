@@ -0,0 +1,63 @@
+package main
+
+import (
+	"asset/database/seed"
+	"asset/models"
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newSeedCmd() *cobra.Command {
+	var force bool
+	var bulk int
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Add the development dataset, users of every role and assets of every type with assignments and service history",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return seedDB(cmd.Context(), force, bulk)
+		},
+	}
+	cmd.Flags().BoolVar(&force, "force", false, "seed outside the development profile")
+	cmd.Flags().IntVar(&bulk, "bulk", 0, "also add this many generated employees, each with seed.BulkAssetsPerUser assets")
+	return cmd
+}
+
+// seedDB adds the dataset of the seed package to a migrated database, outside development it asks
+// for --force since the seeded users are real accounts anyone knowing their email can sign in to.
+// --bulk adds that many generated employees with their assets for load tests
+func seedDB(ctx context.Context, force bool, bulk int) error {
+	db, cfg, err := openDB()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if cfg.GetProfile() != models.ProfileDevelopment && !force {
+		return fmt.Errorf("the %s profile is not for seed data, pass --force to seed anyway", cfg.GetProfile())
+	}
+	status, err := db.MigrationStatus()
+	if err != nil {
+		return err
+	}
+	if status.Dirty || len(status.Pending) > 0 {
+		return fmt.Errorf("schema version %d is not migrated to %d, run migrate up first", status.Version, status.Latest)
+	}
+
+	summary, err := seed.Run(ctx, db.DB())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("seeded %d departments, %d users, %d assets, %d assignments and %d services\n",
+		summary.Departments, summary.Users, summary.Assets, summary.Assignments, summary.Services)
+	if bulk <= 0 {
+		return nil
+	}
+	summary, err = seed.Bulk(ctx, db.DB(), bulk)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("seeded %d bulk users, %d assets and %d assignments\n", summary.Users, summary.Assets, summary.Assignments)
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"asset/server"
+	"asset/services/user"
+	"asset/utils"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newCreateAdminCmd() *cobra.Command {
+	req := userservice.CreateAdminReq{}
+	cmd := &cobra.Command{
+		Use:   "create-admin --email EMAIL --name NAME",
+		Short: "Add an admin, the first one of a new install is made this way",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := utils.ValidateStruct(req); err != nil {
+				return err
+			}
+			ctx := cmd.Context()
+			return withServer(ctx, func(srv *server.Server) error {
+				adminID, err := srv.Users.CreateAdmin(ctx, req)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("admin %s created with id %s\n", req.Email, adminID)
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&req.Email, "email", "", "email the admin signs in with")
+	cmd.Flags().StringVar(&req.Username, "name", "", "display name of the admin")
+	cmd.Flags().StringVar(&req.Type, "type", "full_time", "employee type, full_time, intern or freelancer")
+	cmd.MarkFlagRequired("email")
+	cmd.MarkFlagRequired("name")
+	return cmd
+}
+
+func newResetRolesCmd() *cobra.Command {
+	var email, role string
+	cmd := &cobra.Command{
+		Use:   "reset-roles --email EMAIL",
+		Short: "Replace every role of a user with one, without a second admin's approval",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			return withServer(ctx, func(srv *server.Server) error {
+				userID, err := srv.Users.GetUserIDByEmail(ctx, email)
+				if err != nil {
+					return fmt.Errorf("%s: %w", email, err)
+				}
+				if err := srv.Users.ResetUserRoles(ctx, userID, role); err != nil {
+					return err
+				}
+				fmt.Printf("%s now only has the %s role, their sessions were revoked\n", email, role)
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&email, "email", "", "email of the user")
+	cmd.Flags().StringVar(&role, "role", "admin", "the only role the user keeps")
+	cmd.MarkFlagRequired("email")
+	return cmd
+}
@@ -0,0 +1,55 @@
+CREATE TABLE IF NOT EXISTS permissions(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_permissions_name
+    ON permissions(name);
+
+CREATE TABLE IF NOT EXISTS role_permissions(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    role TEXT NOT NULL,
+    permission TEXT NOT NULL REFERENCES permissions(name),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    created_by UUID REFERENCES users(id),
+    archived_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_role_permissions_unique_active
+    ON role_permissions(role, permission)
+    WHERE archived_at IS NULL;
+
+INSERT INTO permissions (name, description) VALUES
+    ('asset.read', 'list assets and view asset timelines'),
+    ('asset.create', 'add new assets with configuration'),
+    ('asset.update', 'update asset details and configuration'),
+    ('asset.delete', 'archive assets'),
+    ('asset.assign', 'assign assets to employees'),
+    ('asset.unassign', 'retrieve assets from employees'),
+    ('asset.service', 'send assets to and receive assets from service'),
+    ('user.read', 'list employees and view employee timelines'),
+    ('user.create', 'register employees'),
+    ('user.update', 'update employee information'),
+    ('user.delete', 'archive employees'),
+    ('role.manage', 'change user roles and manage role permissions')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission)
+SELECT 'admin', name FROM permissions
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('asset_manager', 'asset.read'),
+    ('asset_manager', 'asset.create'),
+    ('asset_manager', 'asset.update'),
+    ('asset_manager', 'asset.delete'),
+    ('asset_manager', 'asset.assign'),
+    ('asset_manager', 'asset.unassign'),
+    ('asset_manager', 'asset.service'),
+    ('employee_manager', 'user.read'),
+    ('employee_manager', 'user.create'),
+    ('employee_manager', 'user.update'),
+    ('employee_manager', 'user.delete')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS roles(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL UNIQUE,
+    description TEXT,
+    is_system BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    created_by UUID REFERENCES users(id),
+    updated_at TIMESTAMP WITH TIME ZONE,
+    updated_by UUID REFERENCES users(id),
+    archived_at TIMESTAMP WITH TIME ZONE
+);
+
+INSERT INTO roles (name, description, is_system) VALUES
+    ('admin', 'full access to every resource', true),
+    ('asset_manager', 'manages inventory and asset lifecycle', true),
+    ('employee_manager', 'manages employees', true),
+    ('employee', 'default role for every employee', true)
+ON CONFLICT DO NOTHING;
+
+-- roles are rows now instead of enum values, so custom roles can be assigned
+ALTER TABLE user_roles
+    ALTER COLUMN role TYPE TEXT USING role::text;
+
+ALTER TABLE user_roles
+    ADD CONSTRAINT fk_user_roles_role FOREIGN KEY (role) REFERENCES roles(name);
+
+ALTER TABLE role_permissions
+    ADD CONSTRAINT fk_role_permissions_role FOREIGN KEY (role) REFERENCES roles(name);
+
+DROP TYPE IF EXISTS employee_role;
@@ -0,0 +1,2 @@
+ALTER TABLE user_roles
+    ADD COLUMN IF NOT EXISTS archived_by UUID REFERENCES users(id);
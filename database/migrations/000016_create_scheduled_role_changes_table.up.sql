@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS scheduled_role_changes(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    role TEXT NOT NULL REFERENCES roles(name),
+    previous_role TEXT,
+    effective_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    effective_to TIMESTAMP WITH TIME ZONE,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'applied', 'reverted', 'cancelled')),
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    applied_at TIMESTAMP WITH TIME ZONE,
+    reverted_at TIMESTAMP WITH TIME ZONE,
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    CHECK (effective_to IS NULL OR effective_to > effective_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_role_changes_status_from
+    ON scheduled_role_changes(status, effective_from);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_role_changes_user
+    ON scheduled_role_changes(user_id);
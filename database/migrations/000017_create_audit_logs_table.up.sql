@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS audit_logs(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_id UUID REFERENCES users(id),
+    action TEXT NOT NULL,
+    entity_type TEXT NOT NULL,
+    entity_id TEXT,
+    old_value JSONB,
+    new_value JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_entity
+    ON audit_logs(entity_type, entity_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor
+    ON audit_logs(actor_id, created_at DESC);
+
+INSERT INTO permissions (name, description) VALUES
+    ('audit.read', 'view the audit trail')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'audit.read')
+ON CONFLICT DO NOTHING;
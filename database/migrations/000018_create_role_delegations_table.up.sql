@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS role_delegations(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    delegator_id UUID NOT NULL REFERENCES users(id),
+    delegate_id UUID NOT NULL REFERENCES users(id),
+    role TEXT NOT NULL REFERENCES roles(name),
+    reason TEXT,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    revoked_by UUID REFERENCES users(id),
+    expired_at TIMESTAMP WITH TIME ZONE,
+    CHECK (ends_at > starts_at),
+    CHECK (delegator_id <> delegate_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_role_delegations_active
+    ON role_delegations(delegate_id, starts_at, ends_at)
+    WHERE revoked_at IS NULL;
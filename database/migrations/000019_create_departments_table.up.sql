@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS departments(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    location TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    created_by UUID REFERENCES users(id),
+    archived_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_departments_name_unique_active
+    ON departments(name)
+    WHERE archived_at IS NULL;
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS department_id UUID REFERENCES departments(id);
+
+ALTER TABLE assets
+    ADD COLUMN IF NOT EXISTS department_id UUID REFERENCES departments(id);
+
+CREATE INDEX IF NOT EXISTS idx_users_department_id ON users(department_id);
+CREATE INDEX IF NOT EXISTS idx_assets_department_id ON assets(department_id);
+
+INSERT INTO permissions (name, description) VALUES
+    ('department.manage', 'create departments and move users between them')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'department.manage')
+ON CONFLICT DO NOTHING;
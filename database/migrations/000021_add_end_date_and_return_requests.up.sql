@@ -0,0 +1,23 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS end_date DATE,
+    ADD COLUMN IF NOT EXISTS end_date_warned_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_users_end_date
+    ON users(end_date)
+    WHERE end_date IS NOT NULL AND archived_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS asset_return_requests(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    asset_id UUID NOT NULL REFERENCES assets(id),
+    employee_id UUID NOT NULL REFERENCES users(id),
+    reason TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'completed', 'cancelled')),
+    -- NULL when opened by a background job
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    resolved_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_asset_return_requests_open
+    ON asset_return_requests(asset_id, employee_id)
+    WHERE status = 'open';
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS onboarding_templates(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    employee_type employee_type NOT NULL,
+    role TEXT REFERENCES roles(name),
+    department_id UUID REFERENCES departments(id),
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    archived_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_onboarding_templates_name_unique_active
+    ON onboarding_templates(name)
+    WHERE archived_at IS NULL;
+
+-- asset kit handed out by the template, quantity assets of each type
+CREATE TABLE IF NOT EXISTS onboarding_template_items(
+    template_id UUID NOT NULL REFERENCES onboarding_templates(id) ON DELETE CASCADE,
+    asset_type asset_type NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (template_id, asset_type)
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('onboarding.manage', 'create and remove onboarding templates')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'onboarding.manage')
+ON CONFLICT DO NOTHING;
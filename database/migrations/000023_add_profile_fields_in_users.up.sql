@@ -0,0 +1,6 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS designation TEXT,
+    ADD COLUMN IF NOT EXISTS location TEXT,
+    ADD COLUMN IF NOT EXISTS date_of_joining DATE,
+    ADD COLUMN IF NOT EXISTS emergency_contact_name TEXT,
+    ADD COLUMN IF NOT EXISTS emergency_contact_no TEXT;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS notification_preferences(
+    user_id UUID NOT NULL REFERENCES users(id),
+    category TEXT NOT NULL,
+    channel TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    PRIMARY KEY (user_id, category, channel)
+);
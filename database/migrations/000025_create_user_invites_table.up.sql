@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS user_invites(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    email TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    invited_by UUID NOT NULL REFERENCES users(id),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    accepted_at TIMESTAMP WITH TIME ZONE,
+    accepted_user_id UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    archived_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_invites_pending_email
+    ON user_invites(email)
+    WHERE accepted_at IS NULL AND archived_at IS NULL;
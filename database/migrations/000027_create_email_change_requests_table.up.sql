@@ -0,0 +1,15 @@
+-- confirmed rows double as the history of a user's previous addresses
+CREATE TABLE IF NOT EXISTS email_change_requests(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    old_email TEXT NOT NULL,
+    new_email TEXT NOT NULL,
+    requested_by UUID NOT NULL REFERENCES users(id),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    confirmed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    archived_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_change_requests_user
+    ON email_change_requests(user_id, created_at DESC);
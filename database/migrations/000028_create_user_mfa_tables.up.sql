@@ -0,0 +1,26 @@
+-- secret is aes-gcm encrypted, enabled_at stays NULL until the first code is confirmed
+CREATE TABLE IF NOT EXISTS user_mfa(
+    user_id UUID PRIMARY KEY REFERENCES users(id),
+    secret TEXT NOT NULL,
+    enabled_at TIMESTAMP WITH TIME ZONE,
+    -- last accepted totp step, a code can't be replayed inside its window
+    last_used_step BIGINT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS user_mfa_backup_codes(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    code_hash TEXT NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_mfa_backup_codes_user
+    ON user_mfa_backup_codes(user_id)
+    WHERE used_at IS NULL;
+
+ALTER TABLE roles
+    ADD COLUMN IF NOT EXISTS mfa_required BOOLEAN NOT NULL DEFAULT false;
+
+UPDATE roles SET mfa_required = true WHERE name = 'admin';
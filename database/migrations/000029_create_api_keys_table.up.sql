@@ -0,0 +1,25 @@
+-- keys act on behalf of their owner but only within scopes, the full key is shown once and only its hash is kept
+CREATE TABLE IF NOT EXISTS api_keys(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    -- public part of the key, used to find the row and to tell keys apart in logs
+    prefix TEXT NOT NULL UNIQUE,
+    key_hash TEXT NOT NULL,
+    scopes TEXT[] NOT NULL,
+    owner_id UUID NOT NULL REFERENCES users(id),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    revoked_by UUID REFERENCES users(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_owner ON api_keys(owner_id);
+
+INSERT INTO permissions (name, description) VALUES
+    ('api_key.manage', 'issue and revoke api keys for integrations')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'api_key.manage')
+ON CONFLICT DO NOTHING;
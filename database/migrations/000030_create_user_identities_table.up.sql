@@ -0,0 +1,13 @@
+-- external logins are linked by issuer and subject, the email is only used to link the first login
+CREATE TABLE IF NOT EXISTS user_identities(
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    provider TEXT NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id),
+    email TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    last_login_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    PRIMARY KEY (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user ON user_identities(user_id);
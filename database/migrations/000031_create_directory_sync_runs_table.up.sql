@@ -0,0 +1,13 @@
+-- one row per ldap sync, the report lists every change made or, for dry runs, every change that would be made
+CREATE TABLE IF NOT EXISTS directory_sync_runs(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    provider TEXT NOT NULL,
+    dry_run BOOLEAN NOT NULL,
+    status TEXT NOT NULL,
+    triggered_by UUID REFERENCES users(id),
+    report JSONB NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_directory_sync_runs_started ON directory_sync_runs(started_at DESC);
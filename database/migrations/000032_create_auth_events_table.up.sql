@@ -0,0 +1,18 @@
+-- every sign in, token refresh, mfa check and logout, rows older than AUTH_EVENT_RETENTION_DAYS are purged daily
+CREATE TABLE IF NOT EXISTS auth_events(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id),
+    event_type TEXT NOT NULL,
+    method TEXT NOT NULL,
+    outcome TEXT NOT NULL,
+    -- what the caller claimed to be, e.g. the email typed into the login form
+    identifier TEXT,
+    ip_address TEXT,
+    user_agent TEXT,
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_events_created ON auth_events(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_auth_events_user ON auth_events(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_auth_events_ip ON auth_events(ip_address, created_at DESC);
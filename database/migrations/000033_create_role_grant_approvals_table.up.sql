@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS role_grant_approvals(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    role TEXT NOT NULL REFERENCES roles(name),
+    previous_role TEXT,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    requested_by UUID NOT NULL REFERENCES users(id),
+    requested_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    decided_by UUID REFERENCES users(id),
+    decided_at TIMESTAMP WITH TIME ZONE,
+    decision_note TEXT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_role_grant_approvals_pending
+    ON role_grant_approvals(user_id, role)
+    WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_role_grant_approvals_status
+    ON role_grant_approvals(status, requested_at);
@@ -0,0 +1,22 @@
+-- a service account is a users row with auth_provider 'service_account', so created_by, audit and role
+-- columns work for it unchanged. Its credentials live here, only the secret's hash is kept
+CREATE TABLE IF NOT EXISTS service_accounts(
+    user_id UUID PRIMARY KEY REFERENCES users(id),
+    description TEXT,
+    client_id TEXT NOT NULL UNIQUE,
+    secret_hash TEXT NOT NULL,
+    secret_rotated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    last_token_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    disabled_at TIMESTAMP WITH TIME ZONE,
+    disabled_by UUID REFERENCES users(id)
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('service_account.manage', 'create service accounts, bind their roles and rotate their secrets')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'service_account.manage')
+ON CONFLICT DO NOTHING;
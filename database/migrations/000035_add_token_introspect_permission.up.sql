@@ -0,0 +1,8 @@
+-- held by gateways and sidecars, usually through a service account, to validate tokens they are handed
+INSERT INTO permissions (name, description) VALUES
+    ('token.introspect', 'check whether an access token or api key is active')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'token.introspect')
+ON CONFLICT DO NOTHING;
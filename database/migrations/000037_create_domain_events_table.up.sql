@@ -0,0 +1,17 @@
+-- outbox of domain events, written in the transaction of the change they describe and published to the
+-- broker in occurred order by a background job. Published rows are kept as a record of what was sent
+CREATE TABLE IF NOT EXISTS domain_events(
+    id UUID PRIMARY KEY,
+    type TEXT NOT NULL,
+    aggregate_type TEXT NOT NULL,
+    aggregate_id UUID NOT NULL,
+    actor_id UUID,
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    published_at TIMESTAMP WITH TIME ZONE,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_domain_events_unpublished ON domain_events(occurred_at) WHERE published_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_domain_events_aggregate ON domain_events(aggregate_type, aggregate_id, occurred_at);
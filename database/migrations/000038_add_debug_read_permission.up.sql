@@ -0,0 +1,8 @@
+-- pprof and expvar expose internals, only admins profile a running instance
+INSERT INTO permissions (name, description) VALUES
+    ('debug.read', 'capture cpu, heap and goroutine profiles and read runtime metrics under /debug')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'debug.read')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,32 @@
+-- history of background job runs across instances, frequent jobs only record failures and manual runs
+CREATE TABLE IF NOT EXISTS job_runs(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    job TEXT NOT NULL,
+    trigger TEXT NOT NULL CHECK (trigger IN ('schedule', 'manual')),
+    instance TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('succeeded', 'failed')),
+    error TEXT,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    duration_ms BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_runs_job_started ON job_runs(job, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_job_runs_started ON job_runs(started_at);
+
+-- set once the scheduled alert went out so each asset and return request is only reported once
+ALTER TABLE assets
+    ADD COLUMN IF NOT EXISTS warranty_alerted_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE asset_return_requests
+    ADD COLUMN IF NOT EXISTS overdue_notified_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_domain_events_published ON domain_events(published_at) WHERE published_at IS NOT NULL;
+
+INSERT INTO permissions (name, description) VALUES
+    ('job.manage', 'list background jobs, read their run history and trigger a run')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'job.manage')
+ON CONFLICT DO NOTHING;
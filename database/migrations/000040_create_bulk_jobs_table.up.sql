@@ -0,0 +1,30 @@
+-- imports, exports and bulk assigns queued by the api and worked off by a pool on every instance.
+-- created_by is a user or a service account so it has no foreign key
+CREATE TABLE IF NOT EXISTS bulk_jobs(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'running', 'completed', 'failed')),
+    params JSONB NOT NULL,
+    created_by UUID NOT NULL,
+    total INT NOT NULL DEFAULT 0,
+    processed INT NOT NULL DEFAULT 0,
+    succeeded INT NOT NULL DEFAULT 0,
+    failed INT NOT NULL DEFAULT 0,
+    -- per item failures, capped so a bad file can't bloat the row
+    errors JSONB NOT NULL DEFAULT '[]',
+    -- why the whole job failed, item failures don't fail the job
+    error TEXT,
+    result BYTEA,
+    result_content_type TEXT,
+    result_filename TEXT,
+    instance TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    started_at TIMESTAMP WITH TIME ZONE,
+    -- bumped with every progress update, running jobs that stop moving lost their worker
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_bulk_jobs_queued ON bulk_jobs(created_at) WHERE status = 'queued';
+CREATE INDEX IF NOT EXISTS idx_bulk_jobs_created_by ON bulk_jobs(created_by, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_bulk_jobs_finished ON bulk_jobs(finished_at) WHERE finished_at IS NOT NULL;
@@ -0,0 +1,8 @@
+-- reloading only applies settings that are safe to change at runtime, still only admins trigger it
+INSERT INTO permissions (name, description) VALUES
+    ('config.manage', 'reload log level, rate limits, cache ttls and allowed email domains from the config file')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'config.manage')
+ON CONFLICT DO NOTHING;
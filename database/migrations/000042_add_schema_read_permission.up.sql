@@ -0,0 +1,8 @@
+-- the schema version and pending migrations, migrations themselves are applied with assetctl
+INSERT INTO permissions (name, description) VALUES
+    ('schema.read', 'read the database schema version and the migrations not applied yet')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'schema.read')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,107 @@
+-- the parts of a user's dashboard that need joins, kept by triggers on the tables they come from so
+-- the dashboard is one lookup by id. assigned_assets is ordered by assigned_at
+CREATE TABLE IF NOT EXISTS user_dashboard_summary(
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT,
+    roles TEXT[] NOT NULL DEFAULT '{}',
+    assigned_assets JSONB NOT NULL DEFAULT '[]',
+    refreshed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE OR REPLACE FUNCTION refresh_user_dashboard_summary(uid UUID) RETURNS void AS $$
+    INSERT INTO user_dashboard_summary (user_id, type, roles, assigned_assets, refreshed_at)
+    SELECT u.id,
+        (SELECT ut.type::text FROM user_type ut WHERE ut.user_id = u.id AND ut.archived_at IS NULL LIMIT 1),
+        COALESCE((SELECT array_agg(ur.role::text ORDER BY ur.role) FROM user_roles ur WHERE ur.user_id = u.id AND ur.archived_at IS NULL), '{}'),
+        COALESCE((
+            SELECT jsonb_agg(jsonb_build_object(
+                'id', a.id, 'brand', a.brand, 'model', a.model, 'serial_no', a.serial_no, 'type', a.type,
+                'status', a.status, 'owned_by', a.owned_by, 'assigned_at', aa.assigned_at
+            ) ORDER BY aa.assigned_at)
+            FROM asset_assign aa
+            JOIN assets a ON a.id = aa.asset_id AND a.archived_at IS NULL
+            WHERE aa.employee_id = u.id AND aa.returned_at IS NULL AND aa.archived_at IS NULL
+        ), '[]'),
+        now()
+    FROM users u
+    WHERE u.id = uid
+    ON CONFLICT (user_id) DO UPDATE SET
+        type = EXCLUDED.type,
+        roles = EXCLUDED.roles,
+        assigned_assets = EXCLUDED.assigned_assets,
+        refreshed_at = EXCLUDED.refreshed_at;
+$$ LANGUAGE sql;
+
+CREATE OR REPLACE FUNCTION user_dashboard_summary_by_user() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        PERFORM refresh_user_dashboard_summary(OLD.user_id);
+    END IF;
+    IF TG_OP <> 'DELETE' AND (TG_OP = 'INSERT' OR NEW.user_id IS DISTINCT FROM OLD.user_id) THEN
+        PERFORM refresh_user_dashboard_summary(NEW.user_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION user_dashboard_summary_by_assignment() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        PERFORM refresh_user_dashboard_summary(OLD.employee_id);
+    END IF;
+    IF TG_OP <> 'DELETE' AND (TG_OP = 'INSERT' OR NEW.employee_id IS DISTINCT FROM OLD.employee_id) THEN
+        PERFORM refresh_user_dashboard_summary(NEW.employee_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- an asset change shows on the dashboard of whoever holds it
+CREATE OR REPLACE FUNCTION user_dashboard_summary_by_asset() RETURNS trigger AS $$
+BEGIN
+    PERFORM refresh_user_dashboard_summary(aa.employee_id)
+    FROM asset_assign aa
+    WHERE aa.asset_id = NEW.id AND aa.returned_at IS NULL AND aa.archived_at IS NULL;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION user_dashboard_summary_by_new_user() RETURNS trigger AS $$
+BEGIN
+    PERFORM refresh_user_dashboard_summary(NEW.id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS user_dashboard_summary_roles ON user_roles;
+CREATE TRIGGER user_dashboard_summary_roles AFTER INSERT OR UPDATE OR DELETE ON user_roles
+    FOR EACH ROW EXECUTE FUNCTION user_dashboard_summary_by_user();
+
+DROP TRIGGER IF EXISTS user_dashboard_summary_type ON user_type;
+CREATE TRIGGER user_dashboard_summary_type AFTER INSERT OR UPDATE OR DELETE ON user_type
+    FOR EACH ROW EXECUTE FUNCTION user_dashboard_summary_by_user();
+
+DROP TRIGGER IF EXISTS user_dashboard_summary_assign ON asset_assign;
+CREATE TRIGGER user_dashboard_summary_assign AFTER INSERT OR UPDATE OR DELETE ON asset_assign
+    FOR EACH ROW EXECUTE FUNCTION user_dashboard_summary_by_assignment();
+
+DROP TRIGGER IF EXISTS user_dashboard_summary_asset ON assets;
+CREATE TRIGGER user_dashboard_summary_asset AFTER UPDATE OF brand, model, serial_no, type, status, owned_by, archived_at ON assets
+    FOR EACH ROW EXECUTE FUNCTION user_dashboard_summary_by_asset();
+
+DROP TRIGGER IF EXISTS user_dashboard_summary_user ON users;
+CREATE TRIGGER user_dashboard_summary_user AFTER INSERT ON users
+    FOR EACH ROW EXECUTE FUNCTION user_dashboard_summary_by_new_user();
+
+SELECT refresh_user_dashboard_summary(id) FROM users;
+
+-- member and asset counts of every department, refreshed by a background job and after bulk jobs.
+-- the unique index lets the refresh run concurrently with reads
+CREATE MATERIALIZED VIEW IF NOT EXISTS department_stats AS
+SELECT d.id AS department_id,
+    (SELECT count(*) FROM users u WHERE u.department_id = d.id AND u.archived_at IS NULL) AS member_count,
+    (SELECT count(*) FROM assets a WHERE a.department_id = d.id AND a.archived_at IS NULL) AS asset_count,
+    now() AS refreshed_at
+FROM departments d;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_department_stats_department ON department_stats(department_id);
@@ -0,0 +1,57 @@
+-- closed assignments and services are moved here by the archive_assignment_history job once they are
+-- older than ASSIGNMENT_HISTORY_MONTHS, the live tables then only hold open and recent rows
+CREATE TABLE IF NOT EXISTS asset_assign_history(
+    id UUID PRIMARY KEY,
+    asset_id UUID NOT NULL REFERENCES assets(id),
+    employee_id UUID NOT NULL REFERENCES users(id),
+    assigned_at TIMESTAMP WITH TIME ZONE,
+    returned_at TIMESTAMP WITH TIME ZONE,
+    return_reason TEXT,
+    archived_at TIMESTAMP WITH TIME ZONE,
+    assigned_by UUID REFERENCES users(id),
+    moved_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_asset_assign_history_employee ON asset_assign_history(employee_id, assigned_at);
+CREATE INDEX IF NOT EXISTS idx_asset_assign_history_asset ON asset_assign_history(asset_id, assigned_at);
+
+CREATE TABLE IF NOT EXISTS asset_service_history(
+    id UUID PRIMARY KEY,
+    asset_id UUID NOT NULL REFERENCES assets(id),
+    service_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    service_end TIMESTAMP WITH TIME ZONE,
+    reason TEXT NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE,
+    archived_at TIMESTAMP WITH TIME ZONE,
+    moved_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_asset_service_history_asset ON asset_service_history(asset_id, service_start);
+
+-- history reads go through these, conditions on the columns are pushed into both sides of the union
+CREATE OR REPLACE VIEW asset_assign_all AS
+    SELECT id, asset_id, employee_id, assigned_at, returned_at, return_reason, archived_at, assigned_by FROM asset_assign
+    UNION ALL
+    SELECT id, asset_id, employee_id, assigned_at, returned_at, return_reason, archived_at, assigned_by FROM asset_assign_history;
+
+CREATE OR REPLACE VIEW asset_service_all AS
+    SELECT id, asset_id, service_start, service_end, reason, created_by, created_at, archived_at FROM asset_service
+    UNION ALL
+    SELECT id, asset_id, service_start, service_end, reason, created_by, created_at, archived_at FROM asset_service_history;
+
+-- the dashboard summary only holds open assignments, moving a closed one out doesn't change it
+CREATE OR REPLACE FUNCTION user_dashboard_summary_by_assignment() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND OLD.returned_at IS NOT NULL THEN
+        RETURN NULL;
+    END IF;
+    IF TG_OP <> 'INSERT' THEN
+        PERFORM refresh_user_dashboard_summary(OLD.employee_id);
+    END IF;
+    IF TG_OP <> 'DELETE' AND (TG_OP = 'INSERT' OR NEW.employee_id IS DISTINCT FROM OLD.employee_id) THEN
+        PERFORM refresh_user_dashboard_summary(NEW.employee_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,7 @@
+-- asset types that asset managers were told are low on stock, the row goes once the stock is back
+-- above its threshold so the next shortage is alerted on again
+CREATE TABLE IF NOT EXISTS low_stock_alerts(
+    type asset_type PRIMARY KEY,
+    available INT NOT NULL,
+    alerted_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
@@ -0,0 +1,23 @@
+-- the jira or servicenow ticket opened for a service. ticket_status is pending until the ticket is
+-- opened, then the status the service desk last reported, and ticket_resolved_at is set once it is
+-- resolved
+ALTER TABLE asset_service
+    ADD COLUMN IF NOT EXISTS ticket_key TEXT,
+    ADD COLUMN IF NOT EXISTS ticket_status TEXT,
+    ADD COLUMN IF NOT EXISTS ticket_resolved_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE asset_service_history
+    ADD COLUMN IF NOT EXISTS ticket_key TEXT,
+    ADD COLUMN IF NOT EXISTS ticket_status TEXT,
+    ADD COLUMN IF NOT EXISTS ticket_resolved_at TIMESTAMP WITH TIME ZONE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_asset_service_ticket_key ON asset_service(ticket_key) WHERE ticket_key IS NOT NULL;
+
+-- the sync job only reads tickets still to open or resolve
+CREATE INDEX IF NOT EXISTS idx_asset_service_ticket_open ON asset_service(created_at)
+    WHERE ticket_status IS NOT NULL AND ticket_resolved_at IS NULL;
+
+CREATE OR REPLACE VIEW asset_service_all AS
+    SELECT id, asset_id, service_start, service_end, reason, created_by, created_at, archived_at, ticket_key, ticket_status, ticket_resolved_at FROM asset_service
+    UNION ALL
+    SELECT id, asset_id, service_start, service_end, reason, created_by, created_at, archived_at, ticket_key, ticket_status, ticket_resolved_at FROM asset_service_history;
@@ -0,0 +1,19 @@
+-- what the device management system last reported for an asset, filled by the mdm sync job. The row
+-- goes with the asset's serial number, a device that moves to another asset is reported on that one
+CREATE TABLE IF NOT EXISTS asset_mdm_status(
+    asset_id UUID PRIMARY KEY REFERENCES assets(id),
+    mdm_system TEXT NOT NULL,
+    device_id TEXT NOT NULL,
+    device_name TEXT NOT NULL DEFAULT '',
+    os TEXT NOT NULL DEFAULT '',
+    os_version TEXT NOT NULL DEFAULT '',
+    user_email TEXT NOT NULL DEFAULT '',
+    last_check_in TIMESTAMP WITH TIME ZONE,
+    synced_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    -- set while the device is enrolled for someone other than the asset's assignee
+    mismatch_since TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_asset_mdm_status_mismatch
+    ON asset_mdm_status(mismatch_since)
+    WHERE mismatch_since IS NOT NULL;
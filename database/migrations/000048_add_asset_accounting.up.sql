@@ -0,0 +1,18 @@
+-- what an asset cost and how long it is depreciated over, the fixed asset journal export leaves out
+-- assets without a cost. A deleted asset counts as disposed of on the day it was archived
+ALTER TABLE assets
+    ADD COLUMN IF NOT EXISTS purchase_cost NUMERIC(12, 2) CHECK (purchase_cost >= 0),
+    ADD COLUMN IF NOT EXISTS salvage_value NUMERIC(12, 2) NOT NULL DEFAULT 0 CHECK (salvage_value >= 0),
+    ADD COLUMN IF NOT EXISTS useful_life_months INT CHECK (useful_life_months > 0);
+
+CREATE INDEX IF NOT EXISTS idx_assets_purchase_cost
+    ON assets(purchase_date)
+    WHERE purchase_cost IS NOT NULL;
+
+INSERT INTO permissions (name, description) VALUES
+    ('accounting.export', 'export fixed asset journal entries for the accounting system')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'accounting.export')
+ON CONFLICT DO NOTHING;
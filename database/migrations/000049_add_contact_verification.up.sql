@@ -0,0 +1,2 @@
+-- set when the contact number was confirmed with a texted code, cleared whenever the number changes
+ALTER TABLE users ADD COLUMN IF NOT EXISTS contact_verified_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,12 @@
+-- a stolen asset keeps its open assignment until a manager closes it, and stays stolen after that
+ALTER TYPE asset_status ADD VALUE IF NOT EXISTS 'stolen';
+
+CREATE TABLE IF NOT EXISTS asset_theft_reports(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    asset_id UUID NOT NULL REFERENCES assets(id),
+    reported_by UUID NOT NULL REFERENCES users(id),
+    note TEXT NOT NULL DEFAULT '',
+    reported_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_asset_theft_reports_asset ON asset_theft_reports(asset_id);
@@ -0,0 +1,30 @@
+-- fcm registration tokens of the mobile and web apps. A token belongs to one install, so signing in as
+-- someone else on the same device moves it over to them
+CREATE TABLE IF NOT EXISTS device_tokens(
+    token TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id),
+    platform TEXT NOT NULL CHECK (platform IN ('android', 'ios', 'web')),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_device_tokens_user ON device_tokens(user_id, last_seen_at DESC);
+
+-- one row per recipient, written in the transaction of the change it is about and sent to every device
+-- of the recipient by the fan-out job. Rows that run out of attempts stay behind as 'dead'
+CREATE TABLE IF NOT EXISTS push_notifications(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    event_type TEXT NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    data JSONB NOT NULL DEFAULT '{}',
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'dead')),
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    sent_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_push_notifications_due ON push_notifications(next_attempt_at) WHERE status = 'pending';
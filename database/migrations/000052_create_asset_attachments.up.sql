@@ -0,0 +1,22 @@
+-- invoices, photos and warranty cards of an asset. The file itself is in object storage under
+-- storage_key, the row stays pending until the client confirms its upload went through
+CREATE TABLE IF NOT EXISTS asset_attachments(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    asset_id UUID NOT NULL REFERENCES assets(id),
+    storage_key TEXT NOT NULL UNIQUE,
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    -- what the client announced, replaced by what was stored on confirm
+    size_bytes BIGINT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'uploaded')),
+    uploaded_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    uploaded_at TIMESTAMP WITH TIME ZONE,
+    archived_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_asset_attachments_asset ON asset_attachments(asset_id, created_at) WHERE archived_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_asset_attachments_pending ON asset_attachments(created_at) WHERE status = 'pending';
+
+-- export results kept in object storage instead of the result column
+ALTER TABLE bulk_jobs ADD COLUMN IF NOT EXISTS result_key TEXT;
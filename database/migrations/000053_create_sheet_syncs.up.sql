@@ -0,0 +1,39 @@
+-- google sheets kept in step with an asset filter. The filter and the department scope of whoever set
+-- the sync up are saved with it, so the sheet shows what its creator's list would show
+CREATE TABLE IF NOT EXISTS sheet_syncs(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    spreadsheet_id TEXT NOT NULL,
+    sheet_name TEXT NOT NULL,
+    filter JSONB NOT NULL DEFAULT '{}',
+    all_departments BOOLEAN NOT NULL DEFAULT false,
+    department_id UUID REFERENCES departments(id),
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    -- set on creation, on request and after a failed run, the next run rewrites the whole tab
+    needs_full_sync BOOLEAN NOT NULL DEFAULT true,
+    last_synced_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    archived_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sheet_syncs_tab_unique_active
+    ON sheet_syncs(spreadsheet_id, sheet_name)
+    WHERE archived_at IS NULL;
+
+-- assets changed since a sync last ran, queued in the transaction of the change
+CREATE TABLE IF NOT EXISTS sheet_sync_changes(
+    sync_id UUID NOT NULL REFERENCES sheet_syncs(id),
+    asset_id UUID NOT NULL REFERENCES assets(id),
+    queued_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    PRIMARY KEY (sync_id, asset_id)
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('sheet_sync.manage', 'keep google sheets in sync with asset filters')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'sheet_sync.manage'),
+    ('asset_manager', 'sheet_sync.manage')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,56 @@
+-- purchase orders for new assets. An order waits for a second person's approval, is pushed to the
+-- e-procurement system when one is configured, and receiving it creates one asset per serial number
+CREATE SEQUENCE IF NOT EXISTS purchase_order_number_seq;
+
+CREATE TABLE IF NOT EXISTS purchase_orders(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    number TEXT NOT NULL UNIQUE DEFAULT 'PO-' || lpad(nextval('purchase_order_number_seq')::text, 6, '0'),
+    vendor TEXT NOT NULL,
+    notes TEXT,
+    status TEXT NOT NULL DEFAULT 'pending_approval'
+        CHECK (status IN ('pending_approval', 'approved', 'rejected', 'partially_received', 'received')),
+    department_id UUID REFERENCES departments(id),
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    decided_by UUID REFERENCES users(id),
+    decided_at TIMESTAMP WITH TIME ZONE,
+    decision_note TEXT,
+    -- id of the order in the e-procurement system, null until it was pushed
+    external_id TEXT,
+    push_error TEXT,
+    pushed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_status ON purchase_orders(status, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS purchase_order_lines(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    purchase_order_id UUID NOT NULL REFERENCES purchase_orders(id),
+    line_no INT NOT NULL,
+    brand TEXT NOT NULL,
+    model TEXT NOT NULL,
+    type asset_type NOT NULL,
+    owned_by ownership NOT NULL DEFAULT 'remotestate',
+    quantity INT NOT NULL CHECK (quantity > 0),
+    unit_cost NUMERIC(12, 2) NOT NULL CHECK (unit_cost >= 0),
+    warranty_months INT NOT NULL CHECK (warranty_months > 0),
+    -- configuration every asset received on the line starts with, like the laptop's processor and ram
+    config JSONB NOT NULL DEFAULT '{}',
+    received_quantity INT NOT NULL DEFAULT 0 CHECK (received_quantity <= quantity),
+    UNIQUE (purchase_order_id, line_no)
+);
+
+ALTER TABLE assets ADD COLUMN IF NOT EXISTS purchase_order_id UUID REFERENCES purchase_orders(id);
+
+CREATE INDEX IF NOT EXISTS idx_assets_purchase_order ON assets(purchase_order_id) WHERE purchase_order_id IS NOT NULL;
+
+INSERT INTO permissions (name, description) VALUES
+    ('procurement.manage', 'raise and receive purchase orders'),
+    ('procurement.approve', 'approve or reject purchase orders')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'procurement.manage'),
+    ('admin', 'procurement.approve'),
+    ('asset_manager', 'procurement.manage')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,33 @@
+-- handover and return documents sent to the employee for e-signature, one per assignment and kind.
+-- assignment_id isn't a foreign key, closed assignments move to asset_assign_history
+CREATE TABLE IF NOT EXISTS handover_signatures(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    assignment_id UUID NOT NULL,
+    asset_id UUID NOT NULL REFERENCES assets(id),
+    employee_id UUID NOT NULL REFERENCES users(id),
+    kind TEXT NOT NULL CHECK (kind IN ('handover', 'return')),
+    -- pending until the send job hands it to the signature service, failed once it gives up
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'sent', 'completed', 'declined', 'failed')),
+    envelope_id TEXT,
+    -- object storage keys of the generated and of the signed pdf
+    document_key TEXT,
+    signed_key TEXT,
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    sent_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (assignment_id, kind)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_handover_signatures_envelope
+    ON handover_signatures(envelope_id)
+    WHERE envelope_id IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_handover_signatures_asset
+    ON handover_signatures(asset_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_handover_signatures_pending
+    ON handover_signatures(next_attempt_at)
+    WHERE status = 'pending';
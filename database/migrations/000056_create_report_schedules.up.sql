@@ -0,0 +1,29 @@
+-- reports emailed on a cron schedule, evaluated in UTC. The filter narrows the assets a report covers
+CREATE TABLE IF NOT EXISTS report_schedules(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    report TEXT NOT NULL CHECK (report IN ('inventory_summary', 'overdue_returns', 'assets_in_service')),
+    format TEXT NOT NULL CHECK (format IN ('csv', 'pdf')),
+    schedule TEXT NOT NULL,
+    filter JSONB NOT NULL DEFAULT '{}',
+    recipients TEXT[] NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    next_run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    archived_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_schedules_due
+    ON report_schedules(next_run_at)
+    WHERE archived_at IS NULL;
+
+INSERT INTO permissions (name, description) VALUES
+    ('report.manage', 'schedule reports emailed to a list of recipients')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'report.manage')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,12 @@
+-- what a service cost, entered when the asset comes back. Services received before it was recorded
+-- have none and add nothing to the service report's totals
+ALTER TABLE asset_service
+    ADD COLUMN IF NOT EXISTS cost NUMERIC(12, 2) CHECK (cost >= 0);
+
+ALTER TABLE asset_service_history
+    ADD COLUMN IF NOT EXISTS cost NUMERIC(12, 2) CHECK (cost >= 0);
+
+CREATE OR REPLACE VIEW asset_service_all AS
+    SELECT id, asset_id, service_start, service_end, reason, created_by, created_at, archived_at, ticket_key, ticket_status, ticket_resolved_at, cost FROM asset_service
+    UNION ALL
+    SELECT id, asset_id, service_start, service_end, reason, created_by, created_at, archived_at, ticket_key, ticket_status, ticket_resolved_at, cost FROM asset_service_history;
@@ -0,0 +1,7 @@
+INSERT INTO permissions (name, description) VALUES
+    ('privacy.manage', 'export everything stored about a person for a subject access request')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'privacy.manage')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,7 @@
+-- set once the personal data of an archived user has been scrubbed, the row stays behind for the
+-- assignments, audit entries and everything else that refers to it
+ALTER TABLE users ADD COLUMN IF NOT EXISTS anonymized_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_users_archived_at
+    ON users(archived_at)
+    WHERE archived_at IS NOT NULL AND anonymized_at IS NULL;
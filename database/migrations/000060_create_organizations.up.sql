@@ -0,0 +1,95 @@
+-- one deployment serves several companies, every user, asset, assignment and department belongs to one.
+-- Existing rows and rows inserted without an organization go to the default one
+CREATE TABLE IF NOT EXISTS organizations(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    -- the subdomain requests for the organization come in on
+    slug TEXT NOT NULL UNIQUE CHECK (slug ~ '^[a-z0-9]([a-z0-9-]*[a-z0-9])?$'),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    archived_at TIMESTAMP WITH TIME ZONE
+);
+
+INSERT INTO organizations (id, name, slug) VALUES
+    ('00000000-0000-0000-0000-000000000001', 'Default', 'default')
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations(id);
+ALTER TABLE departments
+    ADD COLUMN IF NOT EXISTS organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations(id);
+ALTER TABLE assets
+    ADD COLUMN IF NOT EXISTS organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations(id);
+ALTER TABLE asset_assign
+    ADD COLUMN IF NOT EXISTS organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations(id);
+ALTER TABLE asset_assign_history
+    ADD COLUMN IF NOT EXISTS organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations(id);
+
+-- rows inserted without an organization join the one of whoever created them, so the inserts that
+-- predate organizations stay in the organization of the caller
+CREATE OR REPLACE FUNCTION inherit_creator_organization() RETURNS trigger AS $$
+DECLARE
+    creator UUID;
+    creator_org UUID;
+BEGIN
+    IF NEW.organization_id <> '00000000-0000-0000-0000-000000000001' THEN
+        RETURN NEW;
+    END IF;
+    IF TG_TABLE_NAME = 'assets' THEN
+        creator := NEW.added_by;
+    ELSE
+        creator := NEW.created_by;
+    END IF;
+    SELECT organization_id INTO creator_org FROM users WHERE id = creator;
+    IF creator_org IS NULL AND TG_TABLE_NAME = 'assets' THEN
+        SELECT organization_id INTO creator_org FROM departments WHERE id = NEW.department_id;
+    END IF;
+    NEW.organization_id := COALESCE(creator_org, NEW.organization_id);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- an assignment always belongs to the organization of its asset
+CREATE OR REPLACE FUNCTION inherit_asset_organization() RETURNS trigger AS $$
+BEGIN
+    SELECT organization_id INTO NEW.organization_id FROM assets WHERE id = NEW.asset_id;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS users_organization ON users;
+CREATE TRIGGER users_organization BEFORE INSERT ON users
+    FOR EACH ROW EXECUTE FUNCTION inherit_creator_organization();
+DROP TRIGGER IF EXISTS departments_organization ON departments;
+CREATE TRIGGER departments_organization BEFORE INSERT ON departments
+    FOR EACH ROW EXECUTE FUNCTION inherit_creator_organization();
+DROP TRIGGER IF EXISTS assets_organization ON assets;
+CREATE TRIGGER assets_organization BEFORE INSERT ON assets
+    FOR EACH ROW EXECUTE FUNCTION inherit_creator_organization();
+DROP TRIGGER IF EXISTS asset_assign_organization ON asset_assign;
+CREATE TRIGGER asset_assign_organization BEFORE INSERT ON asset_assign
+    FOR EACH ROW EXECUTE FUNCTION inherit_asset_organization();
+DROP TRIGGER IF EXISTS asset_assign_history_organization ON asset_assign_history;
+CREATE TRIGGER asset_assign_history_organization BEFORE INSERT ON asset_assign_history
+    FOR EACH ROW EXECUTE FUNCTION inherit_asset_organization();
+
+CREATE INDEX IF NOT EXISTS idx_users_organization ON users(organization_id) WHERE archived_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_assets_organization ON assets(organization_id) WHERE archived_at IS NULL;
+
+-- organization.manage is what lets a user reach every organization, org admins get everything else
+-- an admin has within their own
+INSERT INTO permissions (name, description) VALUES
+    ('organization.manage', 'act across organizations')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'organization.manage')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO roles (name, description, is_system) VALUES
+    ('org_admin', 'full access within their organization', true)
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission)
+SELECT 'org_admin', permission FROM role_permissions
+WHERE role = 'admin' AND permission <> 'organization.manage' AND archived_at IS NULL
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,10 @@
+-- IANA name like Asia/Kolkata, timestamps in responses are converted to it. NULL keeps them in UTC
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS timezone TEXT;
+
+-- purchase and warranty dates are days, as timestamps a date picked east of UTC was stored as the
+-- evening before. Midnight in any offset up to 12 hours rounds to the day it was picked on
+ALTER TABLE assets
+    ALTER COLUMN purchase_date TYPE DATE USING ((purchase_date AT TIME ZONE 'UTC') + INTERVAL '12 hours')::date,
+    ALTER COLUMN warranty_start TYPE DATE USING ((warranty_start AT TIME ZONE 'UTC') + INTERVAL '12 hours')::date,
+    ALTER COLUMN warranty_expire TYPE DATE USING ((warranty_expire AT TIME ZONE 'UTC') + INTERVAL '12 hours')::date;
@@ -0,0 +1,17 @@
+-- kiosks check pool equipment in and out for the employee whose badge was scanned
+ALTER TABLE users ADD COLUMN IF NOT EXISTS badge_id TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_badge_id
+    ON users(badge_id)
+    WHERE badge_id IS NOT NULL AND archived_at IS NULL;
+
+ALTER TABLE assets ADD COLUMN IF NOT EXISTS kiosk_pool BOOLEAN NOT NULL DEFAULT false;
+
+INSERT INTO permissions (name, description) VALUES
+    ('kiosk.operate', 'check pool equipment in and out from a kiosk')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'kiosk.operate'),
+    ('org_admin', 'kiosk.operate'),
+    ('asset_manager', 'kiosk.operate')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,12 @@
+-- what an assignment was for and how the asset was handed over, purpose, project, accessories and
+-- condition. Assignments made before it was recorded have none
+ALTER TABLE asset_assign
+    ADD COLUMN IF NOT EXISTS metadata JSONB;
+
+ALTER TABLE asset_assign_history
+    ADD COLUMN IF NOT EXISTS metadata JSONB;
+
+CREATE OR REPLACE VIEW asset_assign_all AS
+    SELECT id, asset_id, employee_id, assigned_at, returned_at, return_reason, archived_at, assigned_by, metadata FROM asset_assign
+    UNION ALL
+    SELECT id, asset_id, employee_id, assigned_at, returned_at, return_reason, archived_at, assigned_by, metadata FROM asset_assign_history;
@@ -0,0 +1,17 @@
+-- an asset recorded twice is merged into the record that is kept, the duplicate is archived and
+-- points at it so old links and exports can still be followed
+ALTER TABLE assets ADD COLUMN IF NOT EXISTS merged_into UUID REFERENCES assets(id);
+
+-- duplicate detection groups live assets by serial number regardless of case
+CREATE INDEX IF NOT EXISTS idx_assets_serial_no_lower
+    ON assets(lower(serial_no))
+    WHERE archived_at IS NULL;
+
+INSERT INTO permissions (name, description) VALUES
+    ('asset.merge', 'merge an asset recorded twice into the record that is kept')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'asset.merge'),
+    ('org_admin', 'asset.merge')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,12 @@
+-- the telecom plan of a sim, its monthly cost goes into the telecom cost report of its department
+ALTER TABLE sim_config
+    ADD COLUMN IF NOT EXISTS carrier TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS plan TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS monthly_cost NUMERIC(12, 2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS renewal_date DATE,
+    ADD COLUMN IF NOT EXISTS mobile_asset_id UUID REFERENCES assets(id);
+
+-- the sims that live in a mobile
+CREATE INDEX IF NOT EXISTS idx_sim_config_mobile_asset_id
+    ON sim_config(mobile_asset_id)
+    WHERE mobile_asset_id IS NOT NULL;
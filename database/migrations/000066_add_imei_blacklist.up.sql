@@ -0,0 +1,21 @@
+-- imeis of devices known to be stolen, mobiles carrying one are blocked or flagged when added when
+-- IMEI_CHECK is on. asset_id is set for the imeis of a mobile reported stolen here
+CREATE TABLE IF NOT EXISTS imei_blacklist (
+    imei TEXT PRIMARY KEY,
+    reason TEXT NOT NULL,
+    asset_id UUID REFERENCES assets(id),
+    added_by UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+-- why a mobile was flagged at intake, null when its imeis were clean
+ALTER TABLE mobile_config ADD COLUMN IF NOT EXISTS imei_flag TEXT;
+
+INSERT INTO permissions (name, description) VALUES
+    ('imei_blacklist.manage', 'add and remove imeis of stolen devices')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'imei_blacklist.manage'),
+    ('org_admin', 'imei_blacklist.manage')
+ON CONFLICT DO NOTHING;
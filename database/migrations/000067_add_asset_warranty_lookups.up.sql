@@ -0,0 +1,10 @@
+-- what the vendor answered when the warranty of an asset was looked up by its serial number as it
+-- was added, kept for reference next to the dates taken from it
+CREATE TABLE IF NOT EXISTS asset_warranty_lookups (
+    asset_id UUID PRIMARY KEY REFERENCES assets(id),
+    vendor TEXT NOT NULL,
+    warranty_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    warranty_expire TIMESTAMP WITH TIME ZONE NOT NULL,
+    response JSONB NOT NULL,
+    fetched_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
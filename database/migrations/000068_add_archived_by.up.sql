@@ -0,0 +1,10 @@
+-- who archived a record, user_roles already keeps it. null for rows archived before this and for
+-- scheduled archives with no one behind them
+ALTER TABLE assets ADD COLUMN IF NOT EXISTS archived_by UUID REFERENCES users(id);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS archived_by UUID REFERENCES users(id);
+ALTER TABLE roles ADD COLUMN IF NOT EXISTS archived_by UUID REFERENCES users(id);
+ALTER TABLE asset_assign ADD COLUMN IF NOT EXISTS archived_by UUID REFERENCES users(id);
+
+-- the trash lists archived rows newest first
+CREATE INDEX IF NOT EXISTS idx_assets_archived ON assets(archived_at DESC) WHERE archived_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_users_archived ON users(archived_at DESC) WHERE archived_at IS NOT NULL;
@@ -0,0 +1,14 @@
+-- one row per data integrity check, the report lists every inconsistent row found and whether it
+-- was repaired
+CREATE TABLE IF NOT EXISTS integrity_runs(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    repair BOOLEAN NOT NULL,
+    issues INT NOT NULL,
+    repaired INT NOT NULL,
+    triggered_by UUID REFERENCES users(id),
+    report JSONB NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    finished_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_integrity_runs_started ON integrity_runs(started_at DESC);
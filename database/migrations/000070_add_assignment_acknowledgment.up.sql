@@ -0,0 +1,11 @@
+-- the employee acknowledges receiving an assigned asset, until then they are reminded on a cadence and
+-- ack_reminders counts the reminders sent. Assignments made before acknowledgment existed count as
+-- acknowledged
+ALTER TABLE asset_assign
+    ADD COLUMN IF NOT EXISTS acknowledged_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS ack_reminders INT NOT NULL DEFAULT 0;
+
+UPDATE asset_assign SET acknowledged_at = assigned_at WHERE acknowledged_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_asset_assign_unacknowledged ON asset_assign(assigned_at)
+    WHERE acknowledged_at IS NULL AND returned_at IS NULL AND archived_at IS NULL;
@@ -0,0 +1,19 @@
+-- what was found when an asset came back, one row per inspected return. assignment_id and service_id
+-- aren't foreign keys because closed assignments and services move to the history tables. photo_ids
+-- are attachments of the asset
+CREATE TABLE IF NOT EXISTS asset_return_inspections(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    assignment_id UUID NOT NULL,
+    asset_id UUID NOT NULL REFERENCES assets(id),
+    employee_id UUID NOT NULL REFERENCES users(id),
+    checklist JSONB NOT NULL DEFAULT '[]',
+    condition_rating INT NOT NULL CHECK (condition_rating BETWEEN 1 AND 5),
+    damaged BOOLEAN NOT NULL,
+    damage_notes TEXT NOT NULL DEFAULT '',
+    photo_ids UUID[] NOT NULL DEFAULT '{}',
+    service_id UUID,
+    inspected_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_asset_return_inspections_asset ON asset_return_inspections(asset_id, created_at);
@@ -0,0 +1,29 @@
+-- what an employee is charged for equipment they lost or damaged. assignment_id isn't a foreign key
+-- because closed assignments move to asset_assign_history, asset_id is taken from it. A charge is
+-- settled once settled_at is set
+CREATE TABLE IF NOT EXISTS employee_charges(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    employee_id UUID NOT NULL REFERENCES users(id),
+    assignment_id UUID,
+    asset_id UUID REFERENCES assets(id),
+    kind TEXT NOT NULL CHECK (kind IN ('lost', 'damaged')),
+    amount NUMERIC(12, 2) NOT NULL CHECK (amount > 0),
+    reason TEXT NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    settled_at TIMESTAMP WITH TIME ZONE,
+    settled_by UUID REFERENCES users(id),
+    settlement_note TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_employee_charges_employee ON employee_charges(employee_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_employee_charges_unsettled ON employee_charges(created_at) WHERE settled_at IS NULL;
+
+INSERT INTO permissions (name, description) VALUES
+    ('charge.manage', 'charge employees for lost or damaged equipment and settle the charges')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'charge.manage'),
+    ('org_admin', 'charge.manage')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,18 @@
+-- what a retired asset was sold or bought back for. The asset is archived with its disposal,
+-- price is what was realized for it and is booked against its remaining value in the fixed asset
+-- journal. invoice_id is an uploaded attachment of the asset
+CREATE TABLE IF NOT EXISTS asset_disposals(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    asset_id UUID NOT NULL REFERENCES assets(id),
+    kind TEXT NOT NULL CHECK (kind IN ('sale', 'buyback')),
+    buyer TEXT NOT NULL,
+    price NUMERIC(12, 2) NOT NULL CHECK (price >= 0),
+    disposed_on DATE NOT NULL,
+    invoice_id UUID REFERENCES asset_attachments(id),
+    notes TEXT,
+    disposed_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_asset_disposals_asset ON asset_disposals(asset_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_asset_disposals_disposed_on ON asset_disposals(disposed_on);
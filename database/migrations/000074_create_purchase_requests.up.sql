@@ -0,0 +1,30 @@
+-- equipment an employee asks for that isn't in stock. A request is reviewed by the asset managers of
+-- the employee's department, then approved by finance with procurement.approve, and an approved
+-- request is turned into a purchase order
+CREATE SEQUENCE IF NOT EXISTS purchase_request_number_seq;
+
+CREATE TABLE IF NOT EXISTS purchase_requests(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    number TEXT NOT NULL UNIQUE DEFAULT 'PR-' || lpad(nextval('purchase_request_number_seq')::text, 6, '0'),
+    requested_by UUID NOT NULL REFERENCES users(id),
+    -- the requester's department when they asked, its asset managers review the request
+    department_id UUID REFERENCES departments(id),
+    type asset_type NOT NULL,
+    quantity INT NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    justification TEXT NOT NULL,
+    -- per unit
+    estimated_cost NUMERIC(12, 2) NOT NULL CHECK (estimated_cost >= 0),
+    status TEXT NOT NULL DEFAULT 'pending_review'
+        CHECK (status IN ('pending_review', 'pending_finance', 'approved', 'rejected', 'ordered')),
+    reviewed_by UUID REFERENCES users(id),
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    review_note TEXT,
+    decided_by UUID REFERENCES users(id),
+    decided_at TIMESTAMP WITH TIME ZONE,
+    decision_note TEXT,
+    purchase_order_id UUID REFERENCES purchase_orders(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_requests_status ON purchase_requests(status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_purchase_requests_requested_by ON purchase_requests(requested_by, created_at DESC);
@@ -0,0 +1,30 @@
+-- what a department may spend in a period, both days included. Acquisitions and service costs of
+-- the department's assets are counted against it, periods of one department don't overlap.
+-- alert_level is the highest alert sent, 1 when the department neared its limit and 2 once it was over
+CREATE TABLE IF NOT EXISTS department_budgets(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    department_id UUID NOT NULL REFERENCES departments(id),
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL CHECK (period_end >= period_start),
+    amount NUMERIC(14, 2) NOT NULL CHECK (amount > 0),
+    notes TEXT,
+    alert_level INT NOT NULL DEFAULT 0,
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    archived_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_department_budgets_department ON department_budgets(department_id, period_start) WHERE archived_at IS NULL;
+
+INSERT INTO permissions (name, description) VALUES
+    ('budget.read', 'see department budgets and how much of them was spent'),
+    ('budget.manage', 'set and remove department budgets')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'budget.read'),
+    ('admin', 'budget.manage'),
+    ('org_admin', 'budget.read'),
+    ('org_admin', 'budget.manage'),
+    ('asset_manager', 'budget.read')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,51 @@
+-- client projects shared equipment is lent to, a project belongs to the department running it and
+-- is closed once archived_at is set
+CREATE TABLE IF NOT EXISTS projects(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    client TEXT,
+    department_id UUID NOT NULL REFERENCES departments(id),
+    starts_on DATE NOT NULL,
+    ends_on DATE CHECK (ends_on >= starts_on),
+    notes TEXT,
+    created_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    archived_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_projects_name ON projects(department_id, lower(name)) WHERE archived_at IS NULL;
+
+-- an asset allocated to a project from starts_on, ends_on is when it is planned back. It is billed
+-- daily_rate a day, both days included, until it is released on released_on. The allocation doesn't
+-- touch the asset's assignment, lab equipment can be on a project with no employee holding it
+CREATE TABLE IF NOT EXISTS project_assets(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id),
+    asset_id UUID NOT NULL REFERENCES assets(id),
+    starts_on DATE NOT NULL,
+    ends_on DATE CHECK (ends_on >= starts_on),
+    daily_rate NUMERIC(12, 2) CHECK (daily_rate >= 0),
+    allocated_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    released_on DATE CHECK (released_on >= starts_on),
+    released_by UUID REFERENCES users(id)
+);
+
+-- an asset is on one project at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_project_assets_asset ON project_assets(asset_id) WHERE released_on IS NULL;
+CREATE INDEX IF NOT EXISTS idx_project_assets_project ON project_assets(project_id, starts_on);
+
+INSERT INTO permissions (name, description) VALUES
+    ('project.read', 'see projects and the assets allocated to them'),
+    ('project.manage', 'create and close projects and allocate assets to them')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'project.read'),
+    ('admin', 'project.manage'),
+    ('org_admin', 'project.read'),
+    ('org_admin', 'project.manage'),
+    ('asset_manager', 'project.read'),
+    ('asset_manager', 'project.manage'),
+    ('employee_manager', 'project.read')
+ON CONFLICT DO NOTHING;
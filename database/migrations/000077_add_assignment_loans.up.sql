@@ -0,0 +1,17 @@
+-- a loan is an assignment that has to come back by loan_until, permanent assignments have none.
+-- loan_overdue_at is when a loan past its end was flagged and its employee and managers told
+ALTER TABLE asset_assign
+    ADD COLUMN IF NOT EXISTS loan_until TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS loan_overdue_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE asset_assign_history
+    ADD COLUMN IF NOT EXISTS loan_until TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS loan_overdue_at TIMESTAMP WITH TIME ZONE;
+
+CREATE OR REPLACE VIEW asset_assign_all AS
+    SELECT id, asset_id, employee_id, assigned_at, returned_at, return_reason, archived_at, assigned_by, metadata, loan_until, loan_overdue_at FROM asset_assign
+    UNION ALL
+    SELECT id, asset_id, employee_id, assigned_at, returned_at, return_reason, archived_at, assigned_by, metadata, loan_until, loan_overdue_at FROM asset_assign_history;
+
+CREATE INDEX IF NOT EXISTS idx_asset_assign_open_loans ON asset_assign(loan_until)
+    WHERE loan_until IS NOT NULL AND returned_at IS NULL AND archived_at IS NULL;
@@ -0,0 +1,22 @@
+-- employees waiting for an asset type that had none available, first come first served by created_at.
+-- A freed asset is offered to the first one waiting until offer_expires_at, an offer that isn't
+-- claimed by then expires and the asset goes to the next in line. left is an employee who gave up
+-- their place
+CREATE TABLE IF NOT EXISTS asset_waitlist(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    employee_id UUID NOT NULL REFERENCES users(id),
+    type asset_type NOT NULL,
+    note TEXT,
+    status TEXT NOT NULL DEFAULT 'waiting' CHECK (status IN ('waiting', 'offered', 'claimed', 'expired', 'left')),
+    asset_id UUID REFERENCES assets(id),
+    offered_at TIMESTAMP WITH TIME ZONE,
+    offer_expires_at TIMESTAMP WITH TIME ZONE,
+    closed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    CHECK ((status = 'offered') = (offer_expires_at IS NOT NULL AND asset_id IS NOT NULL AND closed_at IS NULL))
+);
+
+-- an employee waits once per type, and an asset is offered to one employee at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_asset_waitlist_employee ON asset_waitlist(employee_id, type) WHERE status IN ('waiting', 'offered');
+CREATE UNIQUE INDEX IF NOT EXISTS idx_asset_waitlist_offer ON asset_waitlist(asset_id) WHERE status = 'offered';
+CREATE INDEX IF NOT EXISTS idx_asset_waitlist_queue ON asset_waitlist(type, created_at) WHERE status IN ('waiting', 'offered');
@@ -0,0 +1,28 @@
+-- how a user gets the emails of low priority categories: off mails each one as it happens, daily and
+-- weekly batch them into one digest. Users without a row get a daily digest. last_sent_at is when
+-- the last digest went out
+CREATE TABLE IF NOT EXISTS notification_digest_settings(
+    user_id UUID PRIMARY KEY REFERENCES users(id),
+    frequency TEXT NOT NULL DEFAULT 'daily' CHECK (frequency IN ('off', 'daily', 'weekly')),
+    last_sent_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+-- emails waiting to go out, queued with the in-app notification they copy. digest ones wait for the
+-- recipient's next digest, the others are sent on their own. attempts counts failed sends, a row is
+-- given up on after a few
+CREATE TABLE IF NOT EXISTS notification_emails(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id),
+    category TEXT NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    entity_type TEXT,
+    entity_id TEXT,
+    digest BOOLEAN NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    sent_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_emails_pending ON notification_emails(digest, user_id, created_at) WHERE sent_at IS NULL;
@@ -0,0 +1,32 @@
+-- a step of an escalation chain fired for an item left waiting: an open return request past its
+-- overdue date, or a purchase request or order waiting for a decision. stage is the status the item
+-- was waiting in, a request reviewed and then stuck with finance escalates again. level is the step's
+-- place in the chain, target who was told. resolved_at is set once the item leaves the stage
+CREATE TABLE IF NOT EXISTS escalations(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind TEXT NOT NULL CHECK (kind IN ('overdue_return', 'pending_approval')),
+    entity_type TEXT NOT NULL CHECK (entity_type IN ('return_request', 'purchase_request', 'purchase_order')),
+    entity_id UUID NOT NULL,
+    stage TEXT NOT NULL,
+    level INT NOT NULL CHECK (level > 0),
+    target TEXT NOT NULL CHECK (target IN ('manager', 'admin')),
+    department_id UUID REFERENCES departments(id),
+    summary TEXT NOT NULL,
+    waiting_since TIMESTAMP WITH TIME ZONE NOT NULL,
+    escalated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    resolved_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (entity_type, entity_id, stage, level)
+);
+
+CREATE INDEX IF NOT EXISTS idx_escalations_active ON escalations(escalated_at DESC) WHERE resolved_at IS NULL;
+
+INSERT INTO permissions (name, description) VALUES
+    ('escalation.read', 'see the overdue returns and pending approvals escalated to managers and admins')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'escalation.read'),
+    ('org_admin', 'escalation.read'),
+    ('asset_manager', 'escalation.read'),
+    ('employee_manager', 'escalation.read')
+ON CONFLICT DO NOTHING;
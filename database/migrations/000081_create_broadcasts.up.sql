@@ -0,0 +1,27 @@
+-- announcements an admin sent to everyone or to some roles and departments, each recipient gets an
+-- in-app notification of category announcement linked to the broadcast
+CREATE TABLE IF NOT EXISTS broadcasts(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    -- empty reaches every role and every department
+    roles TEXT[] NOT NULL DEFAULT '{}',
+    department_ids UUID[] NOT NULL DEFAULT '{}',
+    -- the organization of the sender, NULL when an admin sent it across organizations
+    organization_id UUID REFERENCES organizations(id),
+    email BOOLEAN NOT NULL DEFAULT false,
+    recipients INT NOT NULL DEFAULT 0,
+    sent_by UUID NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_broadcasts_created ON broadcasts(created_at DESC);
+
+INSERT INTO permissions (name, description) VALUES
+    ('notification.broadcast', 'send announcements to every user or to some roles and departments')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'notification.broadcast'),
+    ('org_admin', 'notification.broadcast')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,5 @@
+-- per key overrides of RATE_LIMIT_API_KEY_PER_MINUTE and API_KEY_MONTHLY_QUOTA, NULL uses the
+-- default. Requests are counted per calendar month in UTC in redis
+ALTER TABLE api_keys
+    ADD COLUMN IF NOT EXISTS rate_limit_per_minute INT CHECK (rate_limit_per_minute > 0),
+    ADD COLUMN IF NOT EXISTS monthly_quota INT CHECK (monthly_quota > 0);
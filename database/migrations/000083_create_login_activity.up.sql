@@ -0,0 +1,45 @@
+-- the devices each user signed in from, a device is its user agent without version numbers so browser
+-- updates don't make it a new one
+CREATE TABLE IF NOT EXISTS user_login_devices(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    fingerprint TEXT NOT NULL,
+    user_agent TEXT,
+    first_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now(),
+    UNIQUE (user_id, fingerprint)
+);
+
+-- completed logins with where they came from. anomalies lists why a login was flagged, a flagged login
+-- stays pending until the user confirms or denies it
+CREATE TABLE IF NOT EXISTS user_logins(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    device_id UUID REFERENCES user_login_devices(id) ON DELETE SET NULL,
+    method TEXT NOT NULL,
+    ip_address TEXT,
+    user_agent TEXT,
+    country TEXT,
+    city TEXT,
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION,
+    anomalies TEXT[] NOT NULL DEFAULT '{}',
+    -- km/h from the previous login, only set for impossible travel
+    travel_speed DOUBLE PRECISION,
+    review_status TEXT NOT NULL DEFAULT 'not_required'
+        CHECK (review_status IN ('not_required', 'pending', 'confirmed', 'denied')),
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_logins_user ON user_logins(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_user_logins_flagged ON user_logins(created_at DESC) WHERE anomalies <> '{}';
+
+INSERT INTO permissions (name, description) VALUES
+    ('login.review', 'review flagged logins of every user and get told about them')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'login.review'),
+    ('org_admin', 'login.review')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,11 @@
+-- exporting every table of the whole database, across organizations, and replacing them from an
+-- archive. restore is its own permission so it can be kept from the admins who only take backups
+INSERT INTO permissions (name, description) VALUES
+    ('snapshot.export', 'export a snapshot of every user, asset, assignment, service and audit record in the database'),
+    ('snapshot.restore', 'replace the data of the whole database, every organization in it, with a snapshot archive')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'snapshot.export'),
+    ('admin', 'snapshot.restore')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,8 @@
+-- the organization whose assets a schedule reports on, NULL covers every organization and is only
+-- set by a platform admin. Existing schedules stay within the organization of whoever created them
+ALTER TABLE report_schedules
+    ADD COLUMN IF NOT EXISTS organization_id UUID REFERENCES organizations(id);
+
+UPDATE report_schedules s SET organization_id = u.organization_id
+FROM users u
+WHERE u.id = s.created_by AND s.organization_id IS NULL;
@@ -0,0 +1,8 @@
+-- deleting a role only archives it, so its name has to be free for a new role. Names are unique among
+-- the active roles now. A foreign key needs a plain unique constraint, so the tables naming a role lose
+-- theirs and rely on the services checking the name against the active roles, as they already do
+ALTER TABLE roles DROP CONSTRAINT IF EXISTS roles_name_key CASCADE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_roles_name_unique_active
+    ON roles(name)
+    WHERE archived_at IS NULL;
@@ -0,0 +1,5 @@
+-- rotating an endpoint's secret keeps the old one until previous_secret_expires_at, deliveries are
+-- signed with both until then so the receiver can switch over without dropping any
+ALTER TABLE webhook_endpoints
+    ADD COLUMN IF NOT EXISTS previous_secret TEXT,
+    ADD COLUMN IF NOT EXISTS previous_secret_expires_at TIMESTAMP WITH TIME ZONE;
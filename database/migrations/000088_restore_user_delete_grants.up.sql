@@ -0,0 +1,8 @@
+-- 000013 moved deleting employees from asset_manager, who could before the permission matrix, to
+-- employee_manager, who never could. Put it back the way the role checks had it, admins keep it
+UPDATE role_permissions SET archived_at = now()
+WHERE role = 'employee_manager' AND permission = 'user.delete' AND archived_at IS NULL;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('asset_manager', 'user.delete')
+ON CONFLICT DO NOTHING;
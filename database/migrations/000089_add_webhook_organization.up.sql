@@ -0,0 +1,11 @@
+-- the organization whose events an endpoint receives and whose admins manage it, NULL receives the
+-- events of every organization and is only set by a platform admin. Existing endpoints stay within the
+-- organization of whoever registered them
+ALTER TABLE webhook_endpoints
+    ADD COLUMN IF NOT EXISTS organization_id UUID REFERENCES organizations(id);
+
+UPDATE webhook_endpoints e SET organization_id = u.organization_id
+FROM users u
+WHERE u.id = e.created_by AND e.organization_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_organization ON webhook_endpoints(organization_id) WHERE archived_at IS NULL;
@@ -0,0 +1,26 @@
+-- purchase orders and purchase requests belong to the organization of the user who created them, so
+-- an org admin reaching every department still only sees their own organization's
+ALTER TABLE purchase_orders
+    ADD COLUMN IF NOT EXISTS organization_id UUID REFERENCES organizations(id);
+UPDATE purchase_orders o SET organization_id = u.organization_id
+FROM users u
+WHERE u.id = o.created_by AND o.organization_id IS NULL;
+ALTER TABLE purchase_orders ALTER COLUMN organization_id SET NOT NULL;
+
+ALTER TABLE purchase_requests
+    ADD COLUMN IF NOT EXISTS organization_id UUID REFERENCES organizations(id);
+UPDATE purchase_requests r SET organization_id = u.organization_id
+FROM users u
+WHERE u.id = r.requested_by AND r.organization_id IS NULL;
+ALTER TABLE purchase_requests ALTER COLUMN organization_id SET NOT NULL;
+
+-- the organization whose assets a sync writes to its sheet, NULL covers every organization and is only
+-- set by a platform admin. Existing syncs stay within the organization of whoever created them
+ALTER TABLE sheet_syncs
+    ADD COLUMN IF NOT EXISTS organization_id UUID REFERENCES organizations(id);
+UPDATE sheet_syncs s SET organization_id = u.organization_id
+FROM users u
+WHERE u.id = s.created_by AND s.organization_id IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_organization ON purchase_orders(organization_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_requests_organization ON purchase_requests(organization_id);
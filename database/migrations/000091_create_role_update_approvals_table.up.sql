@@ -0,0 +1,21 @@
+-- giving a role that is in use role.manage, organization.manage or another privileged permission waits
+-- for a second admin, the same as granting a privileged role to a user. Roles are only named, see 000086
+CREATE TABLE IF NOT EXISTS role_update_approvals(
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    role TEXT NOT NULL,
+    permissions TEXT[] NOT NULL,
+    previous_permissions TEXT[] NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    requested_by UUID NOT NULL REFERENCES users(id),
+    requested_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
+    decided_by UUID REFERENCES users(id),
+    decided_at TIMESTAMP WITH TIME ZONE,
+    decision_note TEXT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_role_update_approvals_pending
+    ON role_update_approvals(role)
+    WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_role_update_approvals_status
+    ON role_update_approvals(status, requested_at);
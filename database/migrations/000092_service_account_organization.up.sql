@@ -0,0 +1,6 @@
+-- service accounts were inserted without created_by, so the users_organization trigger left every one
+-- in the default organization. They belong to the organization of the admin who created them
+UPDATE users u SET created_by = sa.created_by, organization_id = creator.organization_id
+FROM service_accounts sa
+JOIN users creator ON creator.id = sa.created_by
+WHERE u.id = sa.user_id AND u.auth_provider = 'service_account' AND u.created_by IS NULL;
@@ -0,0 +1,15 @@
+-- 000060 gave org_admin everything admin had except organization.manage, which included permissions
+-- that act on the whole deployment. org_admin now keeps only the permissions whose routes stay within
+-- the caller's organization. role.manage stays for changing the roles of their own users; creating,
+-- editing and deleting roles, which every organization shares, needs organization.manage
+UPDATE role_permissions SET archived_at = now()
+WHERE role = 'org_admin' AND archived_at IS NULL AND permission NOT IN (
+    'asset.read', 'asset.create', 'asset.update', 'asset.delete', 'asset.assign', 'asset.unassign',
+    'asset.service', 'asset.merge',
+    'user.read', 'user.create', 'user.update', 'user.delete',
+    'role.manage', 'department.manage', 'audit.read', 'privacy.manage', 'login.review',
+    'api_key.manage', 'service_account.manage', 'webhook.manage', 'sheet_sync.manage',
+    'onboarding.manage', 'report.manage', 'procurement.manage', 'procurement.approve',
+    'imei_blacklist.manage', 'charge.manage', 'kiosk.operate', 'budget.read', 'budget.manage',
+    'notification.broadcast', 'escalation.read', 'project.read', 'project.manage'
+);
@@ -0,0 +1,6 @@
+-- the organization a check was run for, NULL for runs over every organization (scheduled ones and
+-- platform admins') which only callers not bound to an organization may read
+ALTER TABLE integrity_runs
+    ADD COLUMN IF NOT EXISTS organization_id UUID REFERENCES organizations(id);
+
+CREATE INDEX IF NOT EXISTS idx_integrity_runs_organization ON integrity_runs(organization_id, started_at DESC);
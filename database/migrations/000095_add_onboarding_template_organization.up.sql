@@ -0,0 +1,17 @@
+-- templates belong to the organization of the admin who made them, like users and assets. Their names
+-- only have to be unique within it
+ALTER TABLE onboarding_templates
+    ADD COLUMN IF NOT EXISTS organization_id UUID NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES organizations(id);
+
+UPDATE onboarding_templates t SET organization_id = u.organization_id
+FROM users u
+WHERE u.id = t.created_by AND t.organization_id <> u.organization_id;
+
+DROP TRIGGER IF EXISTS onboarding_templates_organization ON onboarding_templates;
+CREATE TRIGGER onboarding_templates_organization BEFORE INSERT ON onboarding_templates
+    FOR EACH ROW EXECUTE FUNCTION inherit_creator_organization();
+
+DROP INDEX IF EXISTS idx_onboarding_templates_name_unique_active;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_onboarding_templates_name_unique_active
+    ON onboarding_templates(organization_id, name)
+    WHERE archived_at IS NULL;
@@ -0,0 +1,166 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// bulk rows cycle through these, so a filter on any of them matches a known share of the rows
+var (
+	bulkDesignations = []string{"Backend Developer", "Frontend Developer", "QA Engineer", "Support Engineer"}
+	bulkLocations    = []string{"Noida", "Gurugram"}
+	bulkAssetTypes   = []string{"laptop", "mouse", "monitor"}
+	bulkOwners       = []string{"remotestate", "client"}
+)
+
+// BulkAssetsPerUser is how many assets Bulk adds for every employee, all but the last are assigned to
+// the employee and the last stays available
+const BulkAssetsPerUser = 3
+
+// BulkUserEmail is the email of the n-th bulk employee, counting from 1. AUTH_MODE=fake signs them in
+// like the seeded users
+func BulkUserEmail(n int) string {
+	return fmt.Sprintf("bulk.employee%d@remotestate.com", n)
+}
+
+// bulkRows holds the rows of Bulk column by column, the inserts unnest one array per column. Ids
+// and dates are kept as RFC 3339 text
+type bulkRows struct {
+	userIDs, roleIDs, typeIDs, usernames, emails, contactNos []string
+	userDepartments, designations, locations, joined         []string
+	assetIDs, models, serials, types, owners, statuses       []string
+	assetDepartments, purchased                              []string
+	assignIDs, assignAssets, assignEmployees, assigned       []string
+}
+
+// Bulk adds size employees with BulkAssetsPerUser assets each on top of the dataset of Run, which has
+// to be there already. Bulk rows have ids like ID("user:bulk-1") and ID("asset:bulk-1-0"), running it
+// again only adds the rows a larger size is missing. It is meant for benchmarks and load tests, the
+// repository queries behave differently on a few rows than on thousands
+func Bulk(ctx context.Context, db *sqlx.DB, size int) (summary Summary, err error) {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return summary, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	rows := bulkDataset(size)
+	admin, manager := ID("user:admin"), ID("user:asset-manager")
+	summary.Users, err = insert(ctx, tx, `
+		INSERT INTO users (id, username, email, contact_no, department_id, designation, location, date_of_joining, email_verified_at, created_by)
+		SELECT id, username, email, contact_no, department_id, designation, location, joined, joined, $9
+		FROM unnest($1::uuid[], $2::text[], $3::text[], $4::text[], $5::uuid[], $6::text[], $7::text[], $8::timestamptz[])
+			AS u(id, username, email, contact_no, department_id, designation, location, joined)
+		ON CONFLICT DO NOTHING
+	`, pq.Array(rows.userIDs), pq.Array(rows.usernames), pq.Array(rows.emails), pq.Array(rows.contactNos),
+		pq.Array(rows.userDepartments), pq.Array(rows.designations), pq.Array(rows.locations), pq.Array(rows.joined), admin)
+	if err != nil {
+		return summary, fmt.Errorf("failed to seed bulk users: %w", err)
+	}
+	if _, err = tx.ExecContext(ctx, `
+		INSERT INTO user_roles (id, role, user_id, created_by)
+		SELECT id, 'employee', user_id, $3 FROM unnest($1::uuid[], $2::uuid[]) AS ur(id, user_id)
+		ON CONFLICT DO NOTHING
+	`, pq.Array(rows.roleIDs), pq.Array(rows.userIDs), admin); err != nil {
+		return summary, fmt.Errorf("failed to seed bulk user roles: %w", err)
+	}
+	if _, err = tx.ExecContext(ctx, `
+		INSERT INTO user_type (id, type, user_id, created_by)
+		SELECT id, 'full_time', user_id, $3 FROM unnest($1::uuid[], $2::uuid[]) AS ut(id, user_id)
+		ON CONFLICT DO NOTHING
+	`, pq.Array(rows.typeIDs), pq.Array(rows.userIDs), admin); err != nil {
+		return summary, fmt.Errorf("failed to seed bulk user types: %w", err)
+	}
+
+	summary.Assets, err = insert(ctx, tx, `
+		INSERT INTO assets (id, brand, model, serial_no, type, owned_by, status, department_id, purchase_date, warranty_start, warranty_expire, added_by)
+		SELECT id, 'Bulk', model, serial_no, type::asset_type, owned_by::ownership, status::asset_status, department_id,
+			purchased, purchased, purchased + interval '3 years', $9
+		FROM unnest($1::uuid[], $2::text[], $3::text[], $4::text[], $5::text[], $6::text[], $7::uuid[], $8::timestamptz[])
+			AS a(id, model, serial_no, type, owned_by, status, department_id, purchased)
+		ON CONFLICT DO NOTHING
+	`, pq.Array(rows.assetIDs), pq.Array(rows.models), pq.Array(rows.serials), pq.Array(rows.types),
+		pq.Array(rows.owners), pq.Array(rows.statuses), pq.Array(rows.assetDepartments), pq.Array(rows.purchased), manager)
+	if err != nil {
+		return summary, fmt.Errorf("failed to seed bulk assets: %w", err)
+	}
+	// the search joins the config tables, every bulk asset gets the row of its type
+	configs := map[string]string{
+		"laptop":  `INSERT INTO laptop_config (asset_id, processor, ram, storage, os) SELECT id, 'i7', '16GB', '512GB', 'linux'`,
+		"mouse":   `INSERT INTO mouse_config (asset_id, dpi) SELECT id, '1600'`,
+		"monitor": `INSERT INTO monitor_config (asset_id, display, resolution, port) SELECT id, 'lcd', '1920x1080', 'hdmi'`,
+	}
+	for _, assetType := range bulkAssetTypes {
+		query := configs[assetType] + ` FROM unnest($1::uuid[], $2::text[]) AS a(id, type) WHERE type = $3 ON CONFLICT DO NOTHING`
+		if _, err = tx.ExecContext(ctx, query, pq.Array(rows.assetIDs), pq.Array(rows.types), assetType); err != nil {
+			return summary, fmt.Errorf("failed to seed bulk %s configs: %w", assetType, err)
+		}
+	}
+
+	summary.Assignments, err = insert(ctx, tx, `
+		INSERT INTO asset_assign (id, asset_id, employee_id, assigned_at, assigned_by)
+		SELECT id, asset_id, employee_id, assigned_at, $5
+		FROM unnest($1::uuid[], $2::uuid[], $3::uuid[], $4::timestamptz[]) AS aa(id, asset_id, employee_id, assigned_at)
+		ON CONFLICT DO NOTHING
+	`, pq.Array(rows.assignIDs), pq.Array(rows.assignAssets), pq.Array(rows.assignEmployees), pq.Array(rows.assigned), manager)
+	if err != nil {
+		return summary, fmt.Errorf("failed to seed bulk assignments: %w", err)
+	}
+	return summary, nil
+}
+
+// bulkDataset derives every bulk row from its number, the same size gives the same rows
+func bulkDataset(size int) bulkRows {
+	var rows bulkRows
+	for n := 1; n <= size; n++ {
+		key := fmt.Sprintf("bulk-%d", n)
+		userID := ID("user:" + key).String()
+		department := ID("department:" + Departments[n%len(Departments)].Key).String()
+		joined := day(-n % 700)
+		rows.userIDs = append(rows.userIDs, userID)
+		rows.roleIDs = append(rows.roleIDs, ID("user_role:"+key).String())
+		rows.typeIDs = append(rows.typeIDs, ID("user_type:"+key).String())
+		rows.usernames = append(rows.usernames, fmt.Sprintf("Bulk Employee %d", n))
+		rows.emails = append(rows.emails, BulkUserEmail(n))
+		rows.contactNos = append(rows.contactNos, fmt.Sprintf("8%09d", n))
+		rows.userDepartments = append(rows.userDepartments, department)
+		rows.designations = append(rows.designations, bulkDesignations[n%len(bulkDesignations)])
+		rows.locations = append(rows.locations, bulkLocations[n%len(bulkLocations)])
+		rows.joined = append(rows.joined, joined.Format(time.RFC3339))
+
+		for i := 0; i < BulkAssetsPerUser; i++ {
+			assetKey := fmt.Sprintf("%s-%d", key, i)
+			assetID := ID("asset:" + assetKey).String()
+			status := "available"
+			if i < BulkAssetsPerUser-1 {
+				status = "assigned"
+				rows.assignIDs = append(rows.assignIDs, ID("assignment:"+assetKey).String())
+				rows.assignAssets = append(rows.assignAssets, assetID)
+				rows.assignEmployees = append(rows.assignEmployees, userID)
+				rows.assigned = append(rows.assigned, joined.AddDate(0, 0, 1).Format(time.RFC3339))
+			}
+			rows.assetIDs = append(rows.assetIDs, assetID)
+			rows.models = append(rows.models, fmt.Sprintf("Model %d", i))
+			rows.serials = append(rows.serials, "BULK-"+assetKey)
+			rows.types = append(rows.types, bulkAssetTypes[i%len(bulkAssetTypes)])
+			rows.owners = append(rows.owners, bulkOwners[n%len(bulkOwners)])
+			rows.statuses = append(rows.statuses, status)
+			rows.assetDepartments = append(rows.assetDepartments, department)
+			rows.purchased = append(rows.purchased, joined.Format(time.RFC3339))
+		}
+	}
+	return rows
+}
@@ -0,0 +1,398 @@
+// Package seed fills a development database with the same data on every machine. Every row has an id
+// derived from a fixed key, so running it again adds nothing and code can refer to seeded rows by ID
+package seed
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// namespace the ids of seeded rows are derived in
+var namespace = uuid.MustParse("6f1c2a7e-3b0d-4e52-9a8f-5c1d2e3f4a5b")
+
+// ID returns the id of the seeded row with key, like ID("user:admin") or ID("asset:laptop-1")
+func ID(key string) uuid.UUID {
+	return uuid.NewSHA1(namespace, []byte(key))
+}
+
+// dates are fixed too, day(0) is 2024-01-01 UTC
+func day(n int) time.Time {
+	return time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, n)
+}
+
+type Department struct {
+	Key      string
+	Name     string
+	Location string
+}
+
+type User struct {
+	Key         string
+	Name        string
+	Email       string
+	ContactNo   string
+	Role        string
+	Type        string
+	Department  string
+	Designation string
+	Joined      time.Time
+	// last working day, set for interns and freelancers only
+	EndDate *time.Time
+}
+
+type Asset struct {
+	Key        string
+	Brand      string
+	Model      string
+	SerialNo   string
+	Type       string
+	OwnedBy    string
+	Department string
+	Purchased  time.Time
+	// columns of the type's config table
+	Config map[string]string
+}
+
+// Assignment is open while Returned is nil
+type Assignment struct {
+	Key          string
+	Asset        string
+	Employee     string
+	AssignedBy   string
+	Assigned     time.Time
+	Returned     *time.Time
+	ReturnReason string
+}
+
+// Service is open while Ended is nil, the asset then shows as sent for service
+type Service struct {
+	Key     string
+	Asset   string
+	Reason  string
+	By      string
+	Started time.Time
+	Ended   *time.Time
+}
+
+var Departments = []Department{
+	{Key: "engineering", Name: "Engineering", Location: "Noida"},
+	{Key: "operations", Name: "Operations", Location: "Gurugram"},
+}
+
+var Users = []User{
+	{Key: "admin", Name: "Asha Admin", Email: "asha.admin@remotestate.com", ContactNo: "9000000001", Role: "admin", Type: "full_time", Department: "operations", Designation: "IT Lead", Joined: day(-700)},
+	{Key: "asset-manager", Name: "Arun Assets", Email: "arun.assets@remotestate.com", ContactNo: "9000000002", Role: "asset_manager", Type: "full_time", Department: "engineering", Designation: "Asset Manager", Joined: day(-500)},
+	{Key: "employee-manager", Name: "Esha Manager", Email: "esha.manager@remotestate.com", ContactNo: "9000000003", Role: "employee_manager", Type: "full_time", Department: "engineering", Designation: "Engineering Manager", Joined: day(-400)},
+	{Key: "developer", Name: "Dev Sharma", Email: "dev.sharma@remotestate.com", ContactNo: "9000000004", Role: "employee", Type: "full_time", Department: "engineering", Designation: "Backend Developer", Joined: day(-300)},
+	{Key: "designer", Name: "Diya Kapoor", Email: "diya.kapoor@remotestate.com", ContactNo: "9000000005", Role: "employee", Type: "full_time", Department: "engineering", Designation: "Product Designer", Joined: day(-200)},
+	{Key: "intern", Name: "Ishan Verma", Email: "ishan.verma@remotestate.com", ContactNo: "9000000006", Role: "employee", Type: "intern", Department: "engineering", Designation: "Intern", Joined: day(0), EndDate: timePtr(day(180))},
+	{Key: "freelancer", Name: "Farah Khan", Email: "farah.khan@remotestate.com", ContactNo: "9000000007", Role: "employee", Type: "freelancer", Department: "operations", Designation: "Consultant", Joined: day(30), EndDate: timePtr(day(395))},
+}
+
+var Assets = []Asset{
+	{Key: "laptop-1", Brand: "Apple", Model: "MacBook Pro 14", SerialNo: "SEED-LAP-0001", Type: "laptop", OwnedBy: "remotestate", Department: "engineering", Purchased: day(-320),
+		Config: map[string]string{"processor": "M3 Pro", "ram": "18GB", "storage": "512GB", "os": "macos"}},
+	{Key: "laptop-2", Brand: "Lenovo", Model: "ThinkPad T14", SerialNo: "SEED-LAP-0002", Type: "laptop", OwnedBy: "remotestate", Department: "engineering", Purchased: day(-250),
+		Config: map[string]string{"processor": "i7-1365U", "ram": "32GB", "storage": "1TB", "os": "linux"}},
+	{Key: "laptop-3", Brand: "Dell", Model: "Latitude 5440", SerialNo: "SEED-LAP-0003", Type: "laptop", OwnedBy: "client", Department: "operations", Purchased: day(-40),
+		Config: map[string]string{"processor": "i5-1345U", "ram": "16GB", "storage": "512GB", "os": "windows"}},
+	{Key: "mouse-1", Brand: "Logitech", Model: "MX Master 3S", SerialNo: "SEED-MOU-0001", Type: "mouse", OwnedBy: "remotestate", Department: "engineering", Purchased: day(-300),
+		Config: map[string]string{"dpi": "8000"}},
+	{Key: "monitor-1", Brand: "Dell", Model: "U2723QE", SerialNo: "SEED-MON-0001", Type: "monitor", OwnedBy: "remotestate", Department: "engineering", Purchased: day(-280),
+		Config: map[string]string{"display": "lcd", "resolution": "3840x2160", "port": "usb-c"}},
+	{Key: "hard-disk-1", Brand: "Seagate", Model: "Expansion", SerialNo: "SEED-HDD-0001", Type: "hard_disk", OwnedBy: "remotestate", Department: "operations", Purchased: day(-260),
+		Config: map[string]string{"type": "HDD", "storage": "2TB"}},
+	{Key: "pen-drive-1", Brand: "SanDisk", Model: "Ultra Flair", SerialNo: "SEED-PEN-0001", Type: "pen_drive", OwnedBy: "remotestate", Department: "operations", Purchased: day(-200),
+		Config: map[string]string{"version": "3.0", "storage": "64GB"}},
+	{Key: "mobile-1", Brand: "Google", Model: "Pixel 8", SerialNo: "SEED-MOB-0001", Type: "mobile", OwnedBy: "remotestate", Department: "engineering", Purchased: day(-150),
+		Config: map[string]string{"processor": "Tensor G3", "ram": "8GB", "storage": "128GB", "os": "android", "imei_1": "356000000000011", "imei_2": "356000000000029"}},
+	{Key: "sim-1", Brand: "Airtel", Model: "Postpaid", SerialNo: "SEED-SIM-0001", Type: "sim", OwnedBy: "remotestate", Department: "engineering", Purchased: day(-150),
+		Config: map[string]string{"number": "9100000001", "carrier": "Airtel", "plan": "Postpaid 599", "monthly_cost": "599",
+			"renewal_date": day(20).Format(time.DateOnly), "mobile_asset_id": ID("asset:mobile-1").String()}},
+	{Key: "accessory-1", Brand: "Anker", Model: "USB-C Hub", SerialNo: "SEED-ACC-0001", Type: "accessory", OwnedBy: "remotestate", Department: "engineering", Purchased: day(-100),
+		Config: map[string]string{"type": "hub", "additional_info": "7 ports, 100W passthrough"}},
+}
+
+var Assignments = []Assignment{
+	{Key: "laptop-1-developer", Asset: "laptop-1", Employee: "developer", AssignedBy: "asset-manager", Assigned: day(-290)},
+	{Key: "mouse-1-developer", Asset: "mouse-1", Employee: "developer", AssignedBy: "asset-manager", Assigned: day(-290)},
+	{Key: "laptop-2-designer", Asset: "laptop-2", Employee: "designer", AssignedBy: "asset-manager", Assigned: day(-190), Returned: timePtr(day(-20)), ReturnReason: "upgraded"},
+	{Key: "laptop-2-intern", Asset: "laptop-2", Employee: "intern", AssignedBy: "asset-manager", Assigned: day(-10)},
+	{Key: "mobile-1-employee-manager", Asset: "mobile-1", Employee: "employee-manager", AssignedBy: "admin", Assigned: day(-140)},
+	{Key: "sim-1-employee-manager", Asset: "sim-1", Employee: "employee-manager", AssignedBy: "admin", Assigned: day(-140)},
+	{Key: "laptop-3-freelancer", Asset: "laptop-3", Employee: "freelancer", AssignedBy: "admin", Assigned: day(30)},
+}
+
+var Services = []Service{
+	{Key: "monitor-1-dead-pixels", Asset: "monitor-1", Reason: "dead pixels along the left edge", By: "asset-manager", Started: day(-120), Ended: timePtr(day(-95))},
+	{Key: "hard-disk-1-bad-sectors", Asset: "hard-disk-1", Reason: "bad sectors reported by smart", By: "admin", Started: day(-5)},
+}
+
+// configTables maps asset types to the table their config goes to
+var configTables = map[string]string{
+	"laptop":    "laptop_config",
+	"mouse":     "mouse_config",
+	"monitor":   "monitor_config",
+	"hard_disk": "hard_disk_config",
+	"pen_drive": "pendrive_config",
+	"mobile":    "mobile_config",
+	"sim":       "sim_config",
+	"accessory": "accessories_config",
+}
+
+// Summary counts the rows a run added, all zero when the data was already there
+type Summary struct {
+	Departments int `json:"departments"`
+	Users       int `json:"users"`
+	Assets      int `json:"assets"`
+	Assignments int `json:"assignments"`
+	Services    int `json:"services"`
+}
+
+// Run adds the dataset in one transaction, rows already there are left as they are. It refuses a
+// database where a seeded email, serial number or department name belongs to a row it didn't add
+func Run(ctx context.Context, db *sqlx.DB) (summary Summary, err error) {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return summary, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	if err = checkConflicts(ctx, tx); err != nil {
+		return summary, err
+	}
+	admin := ID("user:admin")
+	for _, d := range Departments {
+		added, err := insert(ctx, tx, `
+			INSERT INTO departments (id, name, location, created_by)
+			VALUES ($1, $2, $3, NULL)
+			ON CONFLICT DO NOTHING
+		`, ID("department:"+d.Key), d.Name, d.Location)
+		if err != nil {
+			return summary, fmt.Errorf("failed to seed department %s: %w", d.Key, err)
+		}
+		summary.Departments += added
+	}
+	for _, u := range Users {
+		added, err := seedUser(ctx, tx, u, admin)
+		if err != nil {
+			return summary, fmt.Errorf("failed to seed user %s: %w", u.Key, err)
+		}
+		summary.Users += added
+	}
+	// the departments were created before their creator existed
+	if _, err = tx.ExecContext(ctx, `UPDATE departments SET created_by = $1 WHERE id = ANY($2::uuid[]) AND created_by IS NULL`, admin, departmentIDs()); err != nil {
+		return summary, fmt.Errorf("failed to set department creator: %w", err)
+	}
+	for _, a := range Assets {
+		added, err := seedAsset(ctx, tx, a, ID("user:asset-manager"))
+		if err != nil {
+			return summary, fmt.Errorf("failed to seed asset %s: %w", a.Key, err)
+		}
+		summary.Assets += added
+	}
+	for _, a := range Assignments {
+		added, err := insert(ctx, tx, `
+			INSERT INTO asset_assign (id, asset_id, employee_id, assigned_by, assigned_at, returned_at, return_reason)
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+			ON CONFLICT DO NOTHING
+		`, ID("assignment:"+a.Key), ID("asset:"+a.Asset), ID("user:"+a.Employee), ID("user:"+a.AssignedBy), a.Assigned, a.Returned, a.ReturnReason)
+		if err != nil {
+			return summary, fmt.Errorf("failed to seed assignment %s: %w", a.Key, err)
+		}
+		summary.Assignments += added
+	}
+	for _, s := range Services {
+		added, err := insert(ctx, tx, `
+			INSERT INTO asset_service (id, asset_id, service_start, service_end, reason, created_by)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT DO NOTHING
+		`, ID("service:"+s.Key), ID("asset:"+s.Asset), s.Started, s.Ended, s.Reason, ID("user:"+s.By))
+		if err != nil {
+			return summary, fmt.Errorf("failed to seed service %s: %w", s.Key, err)
+		}
+		summary.Services += added
+	}
+	if err = syncAssetStatus(ctx, tx); err != nil {
+		return summary, err
+	}
+	return summary, nil
+}
+
+func seedUser(ctx context.Context, tx *sqlx.Tx, u User, admin uuid.UUID) (int, error) {
+	userID := ID("user:" + u.Key)
+	added, err := insert(ctx, tx, `
+		INSERT INTO users (
+			id, username, email, contact_no, created_by, department_id, designation, date_of_joining,
+			end_date, email_verified_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT DO NOTHING
+	`, userID, u.Name, u.Email, u.ContactNo, admin, ID("department:"+u.Department), u.Designation, u.Joined, u.EndDate, u.Joined)
+	if err != nil || added == 0 {
+		return added, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO user_roles (id, role, user_id, created_by) VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING
+	`, ID("user_role:"+u.Key), u.Role, userID, admin); err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO user_type (id, type, user_id, created_by) VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING
+	`, ID("user_type:"+u.Key), u.Type, userID, admin); err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+// seedAsset adds the asset with its config row, warranties run three years from the purchase
+func seedAsset(ctx context.Context, tx *sqlx.Tx, a Asset, addedBy uuid.UUID) (int, error) {
+	assetID := ID("asset:" + a.Key)
+	added, err := insert(ctx, tx, `
+		INSERT INTO assets (
+			id, brand, model, serial_no, type, owned_by, purchase_date, warranty_start, warranty_expire,
+			added_by, department_id
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7, $8, $9, $10)
+		ON CONFLICT DO NOTHING
+	`, assetID, a.Brand, a.Model, a.SerialNo, a.Type, a.OwnedBy, a.Purchased, a.Purchased.AddDate(3, 0, 0), addedBy, ID("department:"+a.Department))
+	if err != nil || added == 0 {
+		return added, err
+	}
+	// config keys are fixed above, so they are safe to use as column names
+	columns, placeholders := "id, asset_id", "$1, $2"
+	args := []interface{}{ID("config:" + a.Key), assetID}
+	for _, column := range sortedKeys(a.Config) {
+		args = append(args, a.Config[column])
+		columns += ", " + column
+		placeholders += fmt.Sprintf(", $%d", len(args))
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s) ON CONFLICT DO NOTHING`, configTables[a.Type], columns, placeholders)
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return 0, err
+	}
+	return added, nil
+}
+
+// syncAssetStatus derives the status of seeded assets from their open assignments and services, as
+// the services leave it after assigning or sending one for service
+func syncAssetStatus(ctx context.Context, tx *sqlx.Tx) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE assets a SET status = CASE
+			WHEN EXISTS (SELECT 1 FROM asset_service s WHERE s.asset_id = a.id AND s.service_end IS NULL AND s.archived_at IS NULL)
+				THEN 'sent_for_service'::asset_status
+			WHEN EXISTS (SELECT 1 FROM asset_assign aa WHERE aa.asset_id = a.id AND aa.returned_at IS NULL AND aa.archived_at IS NULL)
+				THEN 'assigned'::asset_status
+			ELSE 'available'::asset_status
+		END
+		WHERE a.id = ANY($1::uuid[])
+	`, assetIDs())
+	if err != nil {
+		return fmt.Errorf("failed to set seeded asset status: %w", err)
+	}
+	return nil
+}
+
+// checkConflicts finds seeded emails, serial numbers and department names taken by rows with other ids, seeding would
+// otherwise fail halfway on the unique indexes
+func checkConflicts(ctx context.Context, tx *sqlx.Tx) error {
+	emails := make([]string, 0, len(Users))
+	for _, u := range Users {
+		emails = append(emails, u.Email)
+	}
+	serials := make([]string, 0, len(Assets))
+	for _, a := range Assets {
+		serials = append(serials, a.SerialNo)
+	}
+	names := make([]string, 0, len(Departments))
+	for _, d := range Departments {
+		names = append(names, d.Name)
+	}
+	var taken []string
+	err := tx.SelectContext(ctx, &taken, `
+		SELECT email FROM users WHERE email = ANY($1) AND archived_at IS NULL AND NOT id = ANY($2::uuid[])
+		UNION ALL
+		SELECT serial_no FROM assets WHERE serial_no = ANY($3) AND archived_at IS NULL AND NOT id = ANY($4::uuid[])
+		UNION ALL
+		SELECT name FROM departments WHERE name = ANY($5) AND archived_at IS NULL AND NOT id = ANY($6::uuid[])
+	`, pq.Array(emails), userIDs(), pq.Array(serials), assetIDs(), pq.Array(names), departmentIDs())
+	if err != nil {
+		return fmt.Errorf("failed to check for conflicting rows: %w", err)
+	}
+	if len(taken) > 0 {
+		return fmt.Errorf("the database already has rows for %v, seed a fresh database", taken)
+	}
+	return nil
+}
+
+func insert(ctx context.Context, tx *sqlx.Tx, query string, args ...interface{}) (int, error) {
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := res.RowsAffected()
+	return int(rows), err
+}
+
+func departmentIDs() interface{} {
+	keys := make([]string, 0, len(Departments))
+	for _, d := range Departments {
+		keys = append(keys, "department:"+d.Key)
+	}
+	return ids(keys)
+}
+
+func userIDs() interface{} {
+	keys := make([]string, 0, len(Users))
+	for _, u := range Users {
+		keys = append(keys, "user:"+u.Key)
+	}
+	return ids(keys)
+}
+
+func assetIDs() interface{} {
+	keys := make([]string, 0, len(Assets))
+	for _, a := range Assets {
+		keys = append(keys, "asset:"+a.Key)
+	}
+	return ids(keys)
+}
+
+// ids is a text array the queries cast to uuid[]
+func ids(keys []string) interface{} {
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, ID(key).String())
+	}
+	return pq.Array(values)
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,77 @@
+package testdb
+
+import (
+	redisprovider "asset/providers/redisProvider"
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// ScriptCall is one Eval of a script, Keys are named without the prefix ScriptParity adds
+type ScriptCall struct {
+	Keys []string
+	Args []interface{}
+}
+
+// ScriptParity runs the calls in order on the redis at TEST_REDIS_ADDR and on a fresh in-memory provider,
+// and fails on the first call whose result, or the value it leaves in a key, differs. That keeps a lua
+// script in step with the go version registered for it with RegisterScript. seed is set on both first.
+// Keys get a prefix of the test's own so runs don't share state. It skips without TEST_REDIS_ADDR,
+// the in-memory provider would only be compared with itself
+func ScriptParity(t *testing.T, script string, seed map[string]string, calls ...ScriptCall) {
+	t.Helper()
+	if os.Getenv("TEST_REDIS_ADDR") == "" {
+		t.Skip("TEST_REDIS_ADDR is not set")
+	}
+	ctx := context.Background()
+	server := Redis(t)
+	memory := redisprovider.NewMemoryRedisProvider()
+	prefix := "parity:" + uuid.NewString() + ":"
+	prefixed := func(keys []string) []string {
+		out := make([]string, len(keys))
+		for i, key := range keys {
+			out[i] = prefix + key
+		}
+		return out
+	}
+	t.Cleanup(func() {
+		for key := range seed {
+			server.Del(ctx, prefix+key)
+		}
+		for _, call := range calls {
+			server.Del(ctx, prefixed(call.Keys)...)
+		}
+	})
+
+	for key, value := range seed {
+		if err := server.Set(ctx, prefix+key, value, 0); err != nil {
+			t.Fatalf("failed to seed %s: %v", key, err)
+		}
+		memory.Set(ctx, prefix+key, value, 0)
+	}
+	for i, call := range calls {
+		keys := prefixed(call.Keys)
+		want, wantErr := server.Eval(ctx, script, keys, call.Args...)
+		got, gotErr := memory.Eval(ctx, script, keys, call.Args...)
+		if (wantErr == nil) != (gotErr == nil) || !reflect.DeepEqual(want, got) {
+			t.Fatalf("call %d %v %v: redis returned %#v (%v), in-memory %#v (%v)", i, call.Keys, call.Args, want, wantErr, got, gotErr)
+		}
+		for j, key := range keys {
+			want, wantErr := server.Get(ctx, key)
+			// hashes are only compared through what the script returns
+			if wantErr != nil && strings.HasPrefix(wantErr.Error(), "WRONGTYPE") {
+				continue
+			}
+			got, gotErr := memory.Get(ctx, key)
+			if want != got || errors.Is(wantErr, redis.Nil) != errors.Is(gotErr, redis.Nil) {
+				t.Fatalf("after call %d key %s: redis holds %q (%v), in-memory %q (%v)", i, call.Keys[j], want, wantErr, got, gotErr)
+			}
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package jobs
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/providers/redisProvider"
+	"context"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Job is a unit of background work, run every Interval or on a cron Schedule in UTC, one of the two
+// is set. Unless PerInstance, each run is claimed through redis so only one instance of the cluster
+// executes it
+type Job struct {
+	Name        string
+	Description string
+	Interval    time.Duration
+	Schedule    string
+	// PerInstance jobs run on every instance, they keep local state fresh or hold a local connection
+	PerInstance bool
+	// Blocking jobs run until they are stopped, like listeners, shutdown cancels them at once instead
+	// of waiting for them to finish
+	Blocking bool
+	Run      func(ctx context.Context) error
+}
+
+// RunRecorder keeps the history of runs, failed or triggered runs are always recorded and successful
+// ones of jobs that run at most once a minute
+type RunRecorder interface {
+	RecordRun(ctx context.Context, run models.JobRun) error
+}
+
+type Runner struct {
+	jobs   []*scheduledJob
+	logger providers.ZapLoggerProvider
+	// failed runs are reported when set
+	reporter providers.ErrorReporter
+	redis    providers.RedisProvider
+	recorder RunRecorder
+	instance string
+	// ctx ends when the runner stops taking new runs, runCtx when runs in progress are cancelled
+	ctx        context.Context
+	cancel     context.CancelFunc
+	runCtx     context.Context
+	cancelRuns context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+type scheduledJob struct {
+	Job
+	schedule *Schedule
+
+	mu      sync.Mutex
+	running bool
+	nextRun time.Time
+	lastRun *models.JobRun
+}
+
+// claimScript sets the slot key only when no instance has claimed the slot yet
+const claimScript = `if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then return 1 end return 0`
+
+func init() {
+	redisprovider.RegisterScript(claimScript, func(store *redisprovider.MemoryStore, keys []string, args []interface{}) (interface{}, error) {
+		ttl, err := redisprovider.ArgFloat(args[1])
+		if err != nil {
+			return nil, err
+		}
+		if store.SetNX(keys[0], redisprovider.ArgString(args[0]), time.Duration(ttl)*time.Millisecond) {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	})
+}
+
+func NewRunner(logger providers.ZapLoggerProvider, reporter providers.ErrorReporter, redis providers.RedisProvider, recorder RunRecorder) *Runner {
+	instance, err := os.Hostname()
+	if err != nil || instance == "" {
+		instance = "unknown"
+	}
+	return &Runner{logger: logger, reporter: reporter, redis: redis, recorder: recorder, instance: fmt.Sprintf("%s-%d", instance, os.Getpid())}
+}
+
+// Register panics on an invalid schedule, jobs are registered at startup from constants
+func (r *Runner) Register(job Job) {
+	scheduled := &scheduledJob{Job: job}
+	if job.Schedule != "" {
+		schedule, err := ParseSchedule(job.Schedule)
+		if err != nil {
+			panic(fmt.Sprintf("job %s: %v", job.Name, err))
+		}
+		scheduled.schedule = &schedule
+	} else if job.Interval <= 0 {
+		panic(fmt.Sprintf("job %s has neither an interval nor a schedule", job.Name))
+	}
+	r.jobs = append(r.jobs, scheduled)
+}
+
+// Start launches one goroutine per registered job, interval jobs run once immediately and then on
+// their interval, scheduled jobs wait for their next fire time
+func (r *Runner) Start() {
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	r.runCtx, r.cancelRuns = context.WithCancel(context.Background())
+	for _, job := range r.jobs {
+		r.wg.Add(1)
+		go r.loop(job)
+	}
+	r.logger.GetLogger().Info("background jobs started", zap.Int("count", len(r.jobs)), zap.String("instance", r.instance))
+}
+
+// Stop takes no new runs and waits for the ones in progress, runs still going when ctx ends are
+// cancelled. Blocking jobs are cancelled right away
+func (r *Runner) Stop(ctx context.Context) {
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		r.logger.GetLogger().Warn("background jobs still running at the shutdown deadline, cancelling them", zap.Strings("jobs", r.running()))
+		r.cancelRuns()
+		<-done
+	}
+	r.cancelRuns()
+	r.logger.GetLogger().Info("background jobs stopped")
+}
+
+// Jobs lists the registered jobs sorted by name, LastRun is the latest run on this instance
+func (r *Runner) Jobs() []models.JobInfo {
+	infos := make([]models.JobInfo, 0, len(r.jobs))
+	for _, job := range r.jobs {
+		job.mu.Lock()
+		info := models.JobInfo{
+			Name:        job.Name,
+			Description: job.Description,
+			Schedule:    job.describeSchedule(),
+			PerInstance: job.PerInstance,
+			Running:     job.running,
+			LastRun:     job.lastRun,
+		}
+		if !job.nextRun.IsZero() {
+			next := job.nextRun
+			info.NextRun = &next
+		}
+		job.mu.Unlock()
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// Trigger runs the job now on this instance without waiting for the run to finish, the slot lock is
+// skipped since the run is asked for explicitly
+func (r *Runner) Trigger(name string) error {
+	job := r.find(name)
+	if job == nil {
+		return models.ErrJobNotFound
+	}
+	if r.ctx == nil || r.ctx.Err() != nil {
+		return fmt.Errorf("background jobs are not running")
+	}
+	if !job.begin() {
+		return models.ErrJobRunning
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer job.end()
+		r.execute(r.runContext(job), job, models.JobTriggerManual)
+	}()
+	return nil
+}
+
+// Run executes the job on the calling goroutine and returns the finished run, it works on a runner that
+// was never started, which is how assetctl runs jobs. Like Trigger it skips the slot lock
+func (r *Runner) Run(ctx context.Context, name string) (models.JobRun, error) {
+	job := r.find(name)
+	if job == nil {
+		return models.JobRun{}, models.ErrJobNotFound
+	}
+	if !job.begin() {
+		return models.JobRun{}, models.ErrJobRunning
+	}
+	defer job.end()
+	return r.execute(ctx, job, models.JobTriggerManual), nil
+}
+
+func (r *Runner) running() []string {
+	var names []string
+	for _, job := range r.jobs {
+		job.mu.Lock()
+		if job.running {
+			names = append(names, job.Name)
+		}
+		job.mu.Unlock()
+	}
+	return names
+}
+
+func (r *Runner) find(name string) *scheduledJob {
+	for _, job := range r.jobs {
+		if job.Name == name {
+			return job
+		}
+	}
+	return nil
+}
+
+func (r *Runner) loop(job *scheduledJob) {
+	defer r.wg.Done()
+	next := time.Now()
+	if job.schedule != nil {
+		next = job.schedule.Next(next)
+	}
+	for {
+		job.setNext(next)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-r.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		r.run(job, next)
+		if job.schedule != nil {
+			next = job.schedule.Next(time.Now())
+			continue
+		}
+		// like a ticker, a run longer than the interval delays the next one instead of queueing it
+		next = next.Add(job.Interval)
+		if now := time.Now(); next.Before(now) {
+			next = now
+		}
+	}
+}
+
+// run executes a scheduled firing, slot identifies it across the cluster
+func (r *Runner) run(job *scheduledJob, slot time.Time) {
+	if !job.begin() {
+		r.logger.GetLogger().Info("background job still running, skipping this run", zap.String("job", job.Name))
+		return
+	}
+	defer job.end()
+	if !job.PerInstance && !r.claim(job, slot) {
+		r.logger.GetLogger().Debug("background job run claimed by another instance", zap.String("job", job.Name))
+		return
+	}
+	r.execute(r.runContext(job), job, models.JobTriggerSchedule)
+}
+
+// runContext is what a run of job gets, blocking jobs end as soon as the runner stops
+func (r *Runner) runContext(job *scheduledJob) context.Context {
+	if job.Blocking {
+		return r.ctx
+	}
+	return r.runCtx
+}
+
+// claim takes the slot for this instance, when redis can't be reached the job runs anyway since a
+// duplicate run is cheaper than a missed one
+func (r *Runner) claim(job *scheduledJob, slot time.Time) bool {
+	if r.redis == nil {
+		return true
+	}
+	ttl := job.Interval
+	if job.schedule != nil {
+		slot = slot.Truncate(time.Minute)
+		ttl = job.schedule.Next(slot).Sub(slot)
+	} else {
+		slot = slot.Truncate(job.Interval)
+	}
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+	key := fmt.Sprintf("jobs:slot:%s:%d", job.Name, slot.Unix())
+	res, err := r.redis.Eval(r.ctx, claimScript, []string{key}, r.instance, ttl.Milliseconds())
+	if err != nil && r.ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		r.logger.GetLogger().Warn("failed to claim background job run, running it here", zap.String("job", job.Name), zap.Error(err))
+		return true
+	}
+	claimed, _ := res.(int64)
+	return claimed == 1
+}
+
+func (r *Runner) execute(ctx context.Context, job *scheduledJob, trigger string) models.JobRun {
+	run := models.JobRun{Job: job.Name, Trigger: trigger, Instance: r.instance, StartedAt: time.Now().UTC()}
+	stack, err := r.call(ctx, job)
+	run.FinishedAt = time.Now().UTC()
+	run.DurationMS = run.FinishedAt.Sub(run.StartedAt).Milliseconds()
+	run.Status = models.JobRunSucceeded
+	if err != nil {
+		run.Status = models.JobRunFailed
+		message := err.Error()
+		run.Error = &message
+		r.logger.GetLogger().Error("background job failed", zap.String("job", job.Name), zap.String("trigger", trigger), zap.Error(err))
+		// a job stopped by shutdown hasn't failed
+		if ctx.Err() == nil {
+			r.report(job, err, stack)
+		}
+	} else {
+		r.logger.GetLogger().Debug("background job finished", zap.String("job", job.Name), zap.String("trigger", trigger), zap.Int64("duration_ms", run.DurationMS))
+	}
+
+	job.mu.Lock()
+	job.lastRun = &run
+	job.mu.Unlock()
+	r.record(job, run)
+	return run
+}
+
+func (r *Runner) call(ctx context.Context, job *scheduledJob) (stack []byte, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			r.logger.GetLogger().Error("panic recovered in background job", zap.String("job", job.Name), zap.Any("recover_info", rec))
+			stack, err = debug.Stack(), fmt.Errorf("panic: %v", rec)
+		}
+	}()
+	return nil, job.Run(ctx)
+}
+
+// record keeps history out of the hot path of jobs that run every few seconds, only their failures
+// and manual runs are written
+func (r *Runner) record(job *scheduledJob, run models.JobRun) {
+	if r.recorder == nil {
+		return
+	}
+	frequent := job.schedule == nil && job.Interval < time.Minute
+	if frequent && run.Status == models.JobRunSucceeded && run.Trigger == models.JobTriggerSchedule {
+		return
+	}
+	// the run is recorded even when it ended because of shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := r.recorder.RecordRun(ctx, run); err != nil {
+		r.logger.GetLogger().Warn("failed to record background job run", zap.String("job", job.Name), zap.Error(err))
+	}
+}
+
+func (r *Runner) report(job *scheduledJob, err error, stack []byte) {
+	if r.reporter == nil {
+		return
+	}
+	r.reporter.Report(models.ErrorReport{Err: err, Stack: stack, Tags: map[string]string{"job": job.Name}})
+}
+
+func (j *scheduledJob) begin() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.running {
+		return false
+	}
+	j.running = true
+	return true
+}
+
+func (j *scheduledJob) end() {
+	j.mu.Lock()
+	j.running = false
+	j.mu.Unlock()
+}
+
+func (j *scheduledJob) setNext(next time.Time) {
+	j.mu.Lock()
+	j.nextRun = next
+	j.mu.Unlock()
+}
+
+func (j *scheduledJob) describeSchedule() string {
+	if j.schedule != nil {
+		return j.schedule.String()
+	}
+	return "every " + j.Interval.String()
+}
@@ -0,0 +1,16 @@
+//go:build integration
+
+package jobs
+
+import (
+	"asset/database/testdb"
+	"testing"
+)
+
+func TestClaimScriptParity(t *testing.T) {
+	testdb.ScriptParity(t, claimScript, nil,
+		testdb.ScriptCall{Keys: []string{"slot-1"}, Args: []interface{}{"instance-a", 60_000}},
+		testdb.ScriptCall{Keys: []string{"slot-1"}, Args: []interface{}{"instance-b", 60_000}},
+		testdb.ScriptCall{Keys: []string{"slot-2"}, Args: []interface{}{"instance-b", 60_000}},
+	)
+}
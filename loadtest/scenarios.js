@@ -0,0 +1,110 @@
+// k6 scenarios for the read paths whose sql grows with the data: the asset search, the employee list
+// and dashboards. They run against a server seeded with assetctl seed --bulk, see make loadtest:
+//
+//   AUTH_MODE=fake RATE_LIMIT_AUTH_PER_MINUTE=0 RATE_LIMIT_USER_PER_MINUTE=0 RATE_LIMIT_IP_PER_MINUTE=0 go run ./cmd
+//   k6 run -e BASE_URL=http://localhost:8080 -e BULK_USERS=2000 loadtest/scenarios.js
+//
+// AUTH_MODE=fake takes the email as the id token, so the seeded admin and bulk employees sign in
+// without firebase. Thresholds fail the run when a scenario's p95 goes over its budget
+import http from 'k6/http';
+import { check, fail } from 'k6';
+
+const BASE_URL = __ENV.BASE_URL || 'http://localhost:8080';
+// employees added by assetctl seed --bulk, emails as seed.BulkUserEmail
+const BULK_USERS = parseInt(__ENV.BULK_USERS || '2000', 10);
+// bulk employees that sign in for the dashboard scenario
+const DASHBOARD_USERS = parseInt(__ENV.DASHBOARD_USERS || '20', 10);
+const RATE = parseInt(__ENV.RATE || '20', 10);
+const DURATION = __ENV.DURATION || '1m';
+
+const statuses = ['available', 'assigned', 'sent_for_service', 'waiting for repair', 'damaged'];
+const types = ['laptop', 'mouse', 'monitor'];
+const designations = ['Backend Developer', 'Frontend Developer', 'QA Engineer', 'Support Engineer'];
+
+function scenario(exec) {
+    return {
+        executor: 'constant-arrival-rate',
+        exec: exec,
+        rate: RATE,
+        timeUnit: '1s',
+        duration: DURATION,
+        preAllocatedVUs: RATE,
+        maxVUs: RATE * 5,
+    };
+}
+
+export const options = {
+    scenarios: {
+        assets: scenario('searchAssets'),
+        employees: scenario('searchEmployees'),
+        dashboards: scenario('dashboard'),
+    },
+    thresholds: {
+        'http_req_failed': ['rate<0.01'],
+        'http_req_duration{scenario:assets}': ['p(95)<300'],
+        'http_req_duration{scenario:employees}': ['p(95)<400'],
+        'http_req_duration{scenario:dashboards}': ['p(95)<100'],
+    },
+};
+
+function login(email) {
+    const res = http.post(`${BASE_URL}/api/v2/user/login`, null, {
+        headers: { Authorization: `Bearer ${email}` },
+        tags: { name: 'login' },
+    });
+    if (res.status !== 200 || !res.json('access_token')) {
+        fail(`login as ${email} answered ${res.status}, is the server in AUTH_MODE=fake and seeded?`);
+    }
+    return res.json('access_token');
+}
+
+export function setup() {
+    const employees = [];
+    for (let i = 0; i < DASHBOARD_USERS; i++) {
+        const n = Math.floor((i * BULK_USERS) / DASHBOARD_USERS) + 1;
+        employees.push(login(`bulk.employee${n}@remotestate.com`));
+    }
+    return { admin: login('asha.admin@remotestate.com'), employees: employees };
+}
+
+function pick(values) {
+    return values[Math.floor(Math.random() * values.length)];
+}
+
+// a random page of the first half, deep offsets are what makes the list queries slow
+function page(rows) {
+    return Math.floor(Math.random() * Math.max(1, rows / 50 / 2)) + 1;
+}
+
+function get(path, token, name) {
+    const res = http.get(`${BASE_URL}${path}`, {
+        headers: { Authorization: `Bearer ${token}` },
+        tags: { name: name },
+    });
+    check(res, { [`${name} is 200`]: (r) => r.status === 200 });
+}
+
+export function searchAssets(data) {
+    const filters = [
+        '',
+        `&status=${encodeURIComponent(pick(statuses))}`,
+        `&type=${pick(types)}&owned_by=client`,
+        `&search=BULK-${Math.floor(Math.random() * BULK_USERS) + 1}`,
+    ];
+    get(`/api/v2/inventory/assets?limit=50&page=${page(BULK_USERS * 3)}${pick(filters)}`, data.admin, 'assets');
+}
+
+export function searchEmployees(data) {
+    const filters = [
+        '',
+        '&type=full_time&role=employee',
+        '&asset_status=assigned',
+        `&designation=${encodeURIComponent(pick(designations))}&location=Noida`,
+        `&search=employee${Math.floor(Math.random() * BULK_USERS) + 1}`,
+    ];
+    get(`/api/v2/employee/employees?limit=50&page=${page(BULK_USERS)}${pick(filters)}`, data.admin, 'employees');
+}
+
+export function dashboard(data) {
+    get('/api/v2/users/dashboard', pick(data.employees), 'dashboards');
+}
@@ -6,6 +6,7 @@ type AssetFilter struct {
 	Status       []string
 	OwnedBy      []string
 	Type         []string
+	Scope        DepartmentScope
 	Limit        int
 	Offset       int
 }
@@ -29,3 +30,18 @@ type EmployeeFilter struct {
 //}
 
 // models/filter.go
+
+// LoanFilter narrows the open loans to the assets in Scope, Overdue keeps the ones past their end
+type LoanFilter struct {
+	Overdue bool
+	Scope   DepartmentScope
+	Limit   int
+	Offset  int
+}
+
+type ReturnRequestFilter struct {
+	Status string
+	Scope  DepartmentScope
+	Limit  int
+	Offset int
+}
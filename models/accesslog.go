@@ -0,0 +1,14 @@
+package models
+
+import "go.uber.org/zap/zapcore"
+
+// AccessLogConfig controls the http access log. Requests are logged at info, client errors at warn
+// and server errors at error, anything below Level is dropped
+type AccessLogConfig struct {
+	Enabled bool
+	Level   zapcore.Level
+	// successful requests logged each second before sampling starts, 0 logs every one
+	SampleFirst int
+	// once sampling starts, one in SampleThereafter successful requests is logged for the rest of the second
+	SampleThereafter int
+}
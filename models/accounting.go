@@ -0,0 +1,31 @@
+package models
+
+// formats a journal export is written in, both are the csv journal entry imports of the system
+const (
+	AccountingFormatQuickBooks = "quickbooks"
+	AccountingFormatNetSuite   = "netsuite"
+)
+
+// AccountingConfig names the ledger accounts fixed asset journals post to and how assets without a
+// useful life of their own are depreciated
+type AccountingConfig struct {
+	Currency string
+	// straight line depreciation over this many months unless the asset has its own useful life
+	DefaultUsefulLifeMonths int
+	// NetSuite books every journal to a subsidiary, QuickBooks has none
+	NetSuiteSubsidiary string
+	Accounts           LedgerAccounts
+}
+
+// LedgerAccounts are account names as the accounting system knows them
+type LedgerAccounts struct {
+	FixedAssets             string
+	AccumulatedDepreciation string
+	DepreciationExpense     string
+	// credited for purchases, usually accounts payable or a clearing account
+	Acquisition  string
+	DisposalLoss string
+	DisposalGain string
+	// debited with what a sold asset was sold for, usually accounts receivable or a clearing account
+	DisposalProceeds string
+}
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type Laptop_config_res struct {
 	Processor string `json:"processor" db:"processor"`
@@ -34,10 +38,18 @@ type Mobile_config_res struct {
 	Os        string `json:"os" db:"os"`
 	IMEI1     string `json:"imei_1" db:"imei_1"`
 	IMEI2     string `json:"imei_2" db:"imei_2"`
+	// IMEIFlag says why the mobile was flagged at intake, empty when its imeis were clean
+	IMEIFlag string `json:"imei_flag,omitempty" db:"imei_flag"`
 }
 
+// Sim_config_res lists its fields in column order, the graphql config loader scans them that way
 type Sim_config_res struct {
-	Number int `json:"number" db:"number"`
+	Number        int        `json:"number" db:"number"`
+	Carrier       string     `json:"carrier" db:"carrier"`
+	Plan          string     `json:"plan" db:"plan"`
+	MonthlyCost   float64    `json:"monthly_cost" db:"monthly_cost"`
+	RenewalDate   *time.Time `json:"renewal_date,omitempty" db:"renewal_date"`
+	MobileAssetID *uuid.UUID `json:"mobile_asset_id,omitempty" db:"mobile_asset_id"`
 }
 
 type Accessories_config_res struct {
@@ -46,15 +58,18 @@ type Accessories_config_res struct {
 }
 
 type AssetWithConfigRes struct {
-	ID            string      `json:"id" db:"id"`
-	Brand         string      `json:"brand" db:"brand"`
-	Model         string      `json:"model" db:"model"`
-	SerialNo      string      `json:"serial_no" db:"serial_no"`
-	Type          string      `json:"type" db:"type"`
-	OwnedBy       string      `json:"owned_by" db:"owned_by"`
-	Status        string      `json:"status" db:"status"`
-	PurchaseDate  time.Time   `json:"purchase_date" db:"purchase_date"`
-	WarrantyStart time.Time   `json:"warranty_start" db:"warranty_start"`
-	WarrantyEnd   time.Time   `json:"warranty_expire" db:"warranty_expire"`
-	Config        interface{} `json:"config"`
+	ID            string    `json:"id" db:"id"`
+	Brand         string    `json:"brand" db:"brand"`
+	Model         string    `json:"model" db:"model"`
+	SerialNo      string    `json:"serial_no" db:"serial_no"`
+	Type          string    `json:"type" db:"type"`
+	OwnedBy       string    `json:"owned_by" db:"owned_by"`
+	Status        string    `json:"status" db:"status"`
+	PurchaseDate  time.Time `json:"purchase_date" db:"purchase_date"`
+	WarrantyStart time.Time `json:"warranty_start" db:"warranty_start"`
+	WarrantyEnd   time.Time `json:"warranty_expire" db:"warranty_expire"`
+	DepartmentID  *string   `json:"department_id,omitempty" db:"department_id"`
+	// order the asset was received on
+	PurchaseOrderID *string     `json:"purchase_order_id,omitempty" db:"purchase_order_id"`
+	Config          interface{} `json:"config"`
 }
@@ -0,0 +1,29 @@
+package models
+
+// AssetTypeConfigs is the config each asset type is added and updated with, new configs are checked
+// against the struct's validate tags and GET /api/meta/asset-types describes its fields
+var AssetTypeConfigs = map[string]interface{}{
+	"laptop":    Laptop_config_req{},
+	"mouse":     Mouse_config_req{},
+	"monitor":   Monitor_config_req{},
+	"hard_disk": Hard_disk_config_req{},
+	"pen_drive": Pen_drive_config_req{},
+	"mobile":    Mobile_config_req{},
+	"sim":       Sim_config_req{},
+	"accessory": Accessories_config_req{},
+}
+
+// AssetTypeSchema is the form of one asset type's config
+type AssetTypeSchema struct {
+	Type   string        `json:"type"`
+	Fields []ConfigField `json:"fields"`
+}
+
+// ConfigField describes one config value, Type is string, integer, number or boolean and Constraints
+// are the validate rules besides required, like max=100
+type ConfigField struct {
+	Name        string            `json:"name"`
+	Type        string            `json:"type"`
+	Required    bool              `json:"required"`
+	Constraints map[string]string `json:"constraints,omitempty"`
+}
@@ -6,58 +6,73 @@ import (
 	"time"
 )
 
+// AssetReq is an asset being added, the warranty dates left out are looked up by serial number when
+// a vendor of the brand is in WARRANTY_LOOKUP
 type AssetReq struct {
-	Brand          string    `json:"brand" validate:"required"`
-	Model          string    `json:"model" validate:"required"`
-	SerialNo       string    `json:"serial_no" validate:"required"`
-	PurchaseDate   time.Time `json:"purchase_date" validate:"required"`
-	OwnedBy        string    `json:"owned_by" validate:"required"`
-	Type           string    `json:"type" validate:"required"`
-	WarrantyStart  time.Time `json:"warranty" validate:"required"`
-	WarrantyExpire time.Time `json:"warranty_expire" validate:"required,gtfield=WarrantyStart"`
+	Brand          string     `json:"brand" validate:"required"`
+	Model          string     `json:"model" validate:"required"`
+	SerialNo       string     `json:"serial_no" validate:"required"`
+	PurchaseDate   time.Time  `json:"purchase_date" validate:"required"`
+	OwnedBy        string     `json:"owned_by" validate:"required"`
+	Type           string     `json:"type" validate:"required"`
+	WarrantyStart  time.Time  `json:"warranty"`
+	WarrantyExpire time.Time  `json:"warranty_expire" validate:"omitempty,gtfield=WarrantyStart"`
+	DepartmentID   *uuid.UUID `json:"department_id,omitempty"`
+	// what the asset cost, it is left out of the accounting export without one
+	PurchaseCost *float64 `json:"purchase_cost,omitempty" validate:"omitempty,gte=0"`
+	SalvageValue *float64 `json:"salvage_value,omitempty" validate:"omitempty,gte=0"`
+	// depreciation period, ACCOUNTING_USEFUL_LIFE_MONTHS when left out
+	UsefulLifeMonths *int `json:"useful_life_months,omitempty" validate:"omitempty,gt=0"`
+	// order the asset was received on, only set by procurement
+	PurchaseOrderID *uuid.UUID `json:"-"`
 }
 
-// Assets request model
+// Assets request model, the validate tags are also what GET /api/meta/asset-types describes
 type Laptop_config_req struct {
-	Processor string `json:"processor"`
-	Ram       string `json:"ram"`
-	Os        string `json:"os"`
+	Processor string `json:"processor" validate:"max=100"`
+	Ram       string `json:"ram" validate:"max=50"`
+	Os        string `json:"os" validate:"max=100"`
 }
 type Mouse_config_req struct {
-	DPI string `json:"dpi"`
+	DPI string `json:"dpi" validate:"max=20"`
 }
 
 type Monitor_config_req struct {
-	Display    string `json:"display"`
-	Resolution string `json:"resolution"`
-	Port       string `json:"port"`
+	Display    string `json:"display" validate:"max=50"`
+	Resolution string `json:"resolution" validate:"max=50"`
+	Port       string `json:"port" validate:"max=50"`
 }
 
 type Hard_disk_config_req struct {
-	Type    string `json:"type"`
-	Storage string `json:"storage"`
+	Type    string `json:"type" validate:"max=50"`
+	Storage string `json:"storage" validate:"max=50"`
 }
 
 type Pen_drive_config_req struct {
-	Version string `json:"version"`
-	Storage string `json:"storage"`
+	Version string `json:"version" validate:"max=20"`
+	Storage string `json:"storage" validate:"max=50"`
 }
 
 type Mobile_config_req struct {
-	Processor string `json:"processor"`
-	Ram       string `json:"ram"`
-	Os        string `json:"os"`
-	IMEI1     string `json:"imei"`
-	IMEI2     string `json:"ime2"`
+	Processor string `json:"processor" validate:"max=100"`
+	Ram       string `json:"ram" validate:"max=50"`
+	Os        string `json:"os" validate:"max=100"`
+	IMEI1     string `json:"imei" validate:"max=20"`
+	IMEI2     string `json:"ime2" validate:"max=20"`
 }
 
 type Sim_config_req struct {
-	Number int `json:"number"`
+	Number        int     `json:"number" validate:"gte=0"`
+	Carrier       string  `json:"carrier" validate:"max=100"`
+	Plan          string  `json:"plan" validate:"max=100"`
+	MonthlyCost   float64 `json:"monthly_cost" validate:"gte=0"`
+	RenewalDate   string  `json:"renewal_date" validate:"omitempty,datetime=2006-01-02"`
+	MobileAssetID string  `json:"mobile_asset_id" validate:"omitempty,uuid"`
 }
 
 type Accessories_config_req struct {
-	Type           string `json:"type"`
-	AdditionalInfo string `json:"additional_info"`
+	Type           string `json:"type" validate:"max=50"`
+	AdditionalInfo string `json:"additional_info" validate:"max=500"`
 }
 
 type AddAssetWithConfigReq struct {
@@ -65,11 +80,20 @@ type AddAssetWithConfigReq struct {
 	Config json.RawMessage `json:"config" `
 }
 
+// AssetAssignReq assigns an asset for good by default, a loan has to come back by LoanUntil
 type AssetAssignReq struct {
-	UserID  string `json:"user_id"`
-	AssetID string `json:"asset_id"`
+	UserID    string              `json:"user_id"`
+	AssetID   string              `json:"asset_id"`
+	Metadata  *AssignmentMetadata `json:"metadata,omitempty"`
+	Mode      string              `json:"mode,omitempty" validate:"omitempty,oneof=permanent loan"`
+	LoanUntil *time.Time          `json:"loan_until,omitempty" validate:"required_if=Mode loan,excluded_unless=Mode loan"`
 }
 
+const (
+	AssignmentPermanent = "permanent"
+	AssignmentLoan      = "loan"
+)
+
 type AssetRes struct {
 	ID       string `json:"id" db:"id"`
 	Brand    string `json:"brand" db:"brand"`
@@ -84,6 +108,10 @@ type AssetReturnReq struct {
 	AssetID      string `json:"asset_id" validate:"required,uuid"`
 	EmployeeID   string `json:"employee_id" validate:"required,uuid"`
 	ReturnReason string `json:"return_reason"`
+	// what was found when the asset came back, optional
+	Inspection *ReturnInspection `json:"inspection,omitempty"`
+	// who took the asset back, stored with the inspection
+	ReturnedBy *uuid.UUID `json:"-"`
 }
 
 type AssetServiceReq struct {
@@ -91,15 +119,135 @@ type AssetServiceReq struct {
 	Reason  string    `json:"reason" validate:"required"`
 }
 
+// ReceiveFromServiceReq is the optional body of receiving an asset back from service
+type ReceiveFromServiceReq struct {
+	// what the service cost, counted in the service report
+	Cost *float64 `json:"cost,omitempty" validate:"omitempty,gte=0"`
+}
+
+// ReportStolenReq is sent by the employee holding the asset
+type ReportStolenReq struct {
+	AssetID uuid.UUID `json:"asset_id" validate:"required"`
+	// where and when it went missing, passed on to the asset managers
+	Note string `json:"note" validate:"max=300"`
+}
+
 type UpdateAssetReq struct {
-	ID             uuid.UUID       `json:"id" validate:"required"`
-	Brand          string          `json:"brand,omitempty"`
-	Model          string          `json:"model,omitempty"`
-	SerialNo       string          `json:"serial_no,omitempty"`
-	PurchaseDate   *time.Time      `json:"purchase_date,omitempty"`
-	OwnedBy        string          `json:"owned_by,omitempty"`
-	WarrantyStart  *time.Time      `json:"warranty_start,omitempty"`
-	WarrantyExpire *time.Time      `json:"warranty_expire,omitempty"`
-	Type           string          `json:"type,omitempty"` // For validation only
-	Config         json.RawMessage `json:"config,omitempty"`
+	ID               uuid.UUID       `json:"id" validate:"required"`
+	Brand            string          `json:"brand,omitempty"`
+	Model            string          `json:"model,omitempty"`
+	SerialNo         string          `json:"serial_no,omitempty"`
+	PurchaseDate     *time.Time      `json:"purchase_date,omitempty"`
+	OwnedBy          string          `json:"owned_by,omitempty"`
+	WarrantyStart    *time.Time      `json:"warranty_start,omitempty"`
+	WarrantyExpire   *time.Time      `json:"warranty_expire,omitempty"`
+	Type             string          `json:"type,omitempty"` // For validation only
+	Config           json.RawMessage `json:"config,omitempty"`
+	DepartmentID     *uuid.UUID      `json:"department_id,omitempty"`
+	PurchaseCost     *float64        `json:"purchase_cost,omitempty" validate:"omitempty,gte=0"`
+	SalvageValue     *float64        `json:"salvage_value,omitempty" validate:"omitempty,gte=0"`
+	UsefulLifeMonths *int            `json:"useful_life_months,omitempty" validate:"omitempty,gt=0"`
+	// pool equipment can be checked out and returned by employees themselves at a kiosk
+	KioskPool *bool `json:"kiosk_pool,omitempty"`
+}
+
+// open return requests are completed when the asset is retrieved from the employee
+type ReturnRequestRes struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	AssetID      uuid.UUID  `json:"asset_id" db:"asset_id"`
+	Brand        string     `json:"brand" db:"brand"`
+	Model        string     `json:"model" db:"model"`
+	SerialNo     string     `json:"serial_no" db:"serial_no"`
+	EmployeeID   uuid.UUID  `json:"employee_id" db:"employee_id"`
+	EmployeeName string     `json:"employee_name" db:"employee_name"`
+	Reason       string     `json:"reason" db:"reason"`
+	Status       string     `json:"status" db:"status"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+}
+
+// WarrantyAlertRes is an asset whose warranty is about to expire
+type WarrantyAlertRes struct {
+	AssetID        uuid.UUID  `db:"asset_id"`
+	Brand          string     `db:"brand"`
+	Model          string     `db:"model"`
+	SerialNo       string     `db:"serial_no"`
+	WarrantyExpire time.Time  `db:"warranty_expire"`
+	DepartmentID   *uuid.UUID `db:"department_id"`
+	OrganizationID uuid.UUID  `db:"organization_id"`
+}
+
+// AssetBrief names an asset in messages
+type AssetBrief struct {
+	ID       uuid.UUID `db:"id"`
+	Brand    string    `db:"brand"`
+	Model    string    `db:"model"`
+	SerialNo string    `db:"serial_no"`
+}
+
+// StolenAsset is an asset just reported stolen, with who to alert about it
+type StolenAsset struct {
+	AssetBrief
+	DepartmentID   *uuid.UUID `db:"department_id"`
+	OrganizationID uuid.UUID  `db:"organization_id"`
+	Reporter       string     `db:"reporter"`
+}
+
+// StockLevel is how many assets of a type are available to hand out
+type StockLevel struct {
+	Type      string `db:"type"`
+	Available int    `db:"available"`
+}
+
+// OverdueReturnRes is a return request that stayed open for too long
+type OverdueReturnRes struct {
+	ID             uuid.UUID  `db:"id"`
+	AssetID        uuid.UUID  `db:"asset_id"`
+	Brand          string     `db:"brand"`
+	Model          string     `db:"model"`
+	SerialNo       string     `db:"serial_no"`
+	EmployeeID     uuid.UUID  `db:"employee_id"`
+	EmployeeName   string     `db:"employee_name"`
+	DepartmentID   *uuid.UUID `db:"department_id"`
+	OrganizationID uuid.UUID  `db:"organization_id"`
+	CreatedAt      time.Time  `db:"created_at"`
+}
+
+// LoanRes is an open loan, Overdue once it is past LoanUntil. FlaggedAt is when the job told the
+// employee and their managers
+type LoanRes struct {
+	AssignmentID   uuid.UUID  `json:"assignment_id" db:"id"`
+	AssetID        uuid.UUID  `json:"asset_id" db:"asset_id"`
+	Brand          string     `json:"brand" db:"brand"`
+	Model          string     `json:"model" db:"model"`
+	SerialNo       string     `json:"serial_no" db:"serial_no"`
+	EmployeeID     uuid.UUID  `json:"employee_id" db:"employee_id"`
+	EmployeeName   string     `json:"employee_name" db:"employee_name"`
+	DepartmentID   *uuid.UUID `json:"-" db:"department_id"`
+	OrganizationID uuid.UUID  `json:"-" db:"organization_id"`
+	AssignedAt     time.Time  `json:"assigned_at" db:"assigned_at"`
+	LoanUntil      time.Time  `json:"loan_until" db:"loan_until"`
+	Overdue        bool       `json:"overdue" db:"overdue"`
+	FlaggedAt      *time.Time `json:"flagged_at,omitempty" db:"loan_overdue_at"`
+}
+
+// AcknowledgeAssignmentReq is sent by the employee once they received an assigned asset
+type AcknowledgeAssignmentReq struct {
+	AssetID uuid.UUID `json:"asset_id" validate:"required"`
+}
+
+// PendingAcknowledgment is an open assignment the employee hasn't acknowledged, Reminders is how many
+// reminders they were sent
+type PendingAcknowledgment struct {
+	ID             uuid.UUID  `db:"id"`
+	AssetID        uuid.UUID  `db:"asset_id"`
+	Brand          string     `db:"brand"`
+	Model          string     `db:"model"`
+	SerialNo       string     `db:"serial_no"`
+	EmployeeID     uuid.UUID  `db:"employee_id"`
+	EmployeeName   string     `db:"employee_name"`
+	DepartmentID   *uuid.UUID `db:"department_id"`
+	OrganizationID uuid.UUID  `db:"organization_id"`
+	AssignedAt     time.Time  `db:"assigned_at"`
+	Reminders      int        `db:"reminders"`
 }
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// AssignmentMetadata is stored with an assignment and shown in the asset and employee timelines
+type AssignmentMetadata struct {
+	Purpose           string   `json:"purpose,omitempty" validate:"max=500"`
+	Project           string   `json:"project,omitempty" validate:"max=200"`
+	Accessories       []string `json:"accessories,omitempty" validate:"max=20,dive,required,max=100"`
+	HandoverCondition string   `json:"handover_condition,omitempty" validate:"omitempty,handover_condition"`
+}
+
+func (m AssignmentMetadata) Value() (driver.Value, error) {
+	payload, err := json.Marshal(m)
+	return string(payload), err
+}
+
+func (m *AssignmentMetadata) Scan(src interface{}) error {
+	data, ok := src.([]byte)
+	if !ok {
+		return errors.New("assignment metadata is not jsonb")
+	}
+	return json.Unmarshal(data, m)
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// an attachment is pending while the client uploads it to the presigned url and uploaded once the
+// upload is confirmed
+const (
+	AttachmentPending  = "pending"
+	AttachmentUploaded = "uploaded"
+)
+
+// AttachmentUploadReq announces a file, size is checked against the limit before a url is signed and
+// again against what arrived
+type AttachmentUploadReq struct {
+	AssetID     uuid.UUID `json:"asset_id" validate:"required"`
+	Filename    string    `json:"filename" validate:"required,max=255"`
+	ContentType string    `json:"content_type" validate:"required,max=100"`
+	Size        int64     `json:"size" validate:"required,min=1"`
+}
+
+// AttachmentUploadRes is where the client sends the file, with the headers it has to set
+type AttachmentUploadRes struct {
+	ID        uuid.UUID         `json:"id"`
+	UploadURL string            `json:"upload_url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+}
+
+type AttachmentConfirmReq struct {
+	ID uuid.UUID `json:"id" validate:"required"`
+}
+
+// Attachment is a file of an asset, DownloadURL is signed when attachments are listed
+type Attachment struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	AssetID     uuid.UUID  `json:"asset_id" db:"asset_id"`
+	StorageKey  string     `json:"-" db:"storage_key"`
+	Filename    string     `json:"filename" db:"filename"`
+	ContentType string     `json:"content_type" db:"content_type"`
+	SizeBytes   int64      `json:"size_bytes" db:"size_bytes"`
+	Status      string     `json:"status" db:"status"`
+	UploadedBy  uuid.UUID  `json:"uploaded_by" db:"uploaded_by"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UploadedAt  *time.Time `json:"uploaded_at,omitempty" db:"uploaded_at"`
+	DownloadURL string     `json:"download_url,omitempty" db:"-"`
+}
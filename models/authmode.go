@@ -0,0 +1,8 @@
+package models
+
+// modes AUTH_MODE selects. Fake replaces firebase and email with providers in the process and signs in
+// anyone by email, so a laptop without accounts can run the server. It is only allowed in development
+const (
+	AuthModeFirebase = "firebase"
+	AuthModeFake     = "fake"
+)
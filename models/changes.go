@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// event types of the inventory changelog
+const (
+	InventoryChangeCreated        = "created"
+	InventoryChangeAssigned       = "assigned"
+	InventoryChangeReturned       = "returned"
+	InventoryChangeServiceStarted = "service_started"
+	InventoryChangeServiceEnded   = "service_ended"
+	InventoryChangeUpdated        = "updated"
+)
+
+var InventoryChangeTypes = []string{
+	InventoryChangeCreated,
+	InventoryChangeAssigned,
+	InventoryChangeReturned,
+	InventoryChangeServiceStarted,
+	InventoryChangeServiceEnded,
+	InventoryChangeUpdated,
+}
+
+// InventoryChangeFilter narrows the changelog, From is included and To is not
+type InventoryChangeFilter struct {
+	Type    string
+	AssetID *uuid.UUID
+	From    *time.Time
+	To      *time.Time
+	Limit   int
+	Offset  int
+	Scope   DepartmentScope
+}
+
+// InventoryChange is one event of the changelog. Actor is who made the change when it was recorded,
+// returns and ended services don't keep it. Employee is set on assigned and returned events
+type InventoryChange struct {
+	EventType    string     `json:"event_type" db:"event_type"`
+	OccurredAt   time.Time  `json:"occurred_at" db:"occurred_at"`
+	AssetID      uuid.UUID  `json:"asset_id" db:"asset_id"`
+	Brand        string     `json:"brand" db:"brand"`
+	Model        string     `json:"model" db:"model"`
+	SerialNo     string     `json:"serial_no" db:"serial_no"`
+	ActorID      *uuid.UUID `json:"actor_id,omitempty" db:"actor_id"`
+	ActorName    *string    `json:"actor_name,omitempty" db:"actor_name"`
+	EmployeeID   *uuid.UUID `json:"employee_id,omitempty" db:"employee_id"`
+	EmployeeName *string    `json:"employee_name,omitempty" db:"employee_name"`
+	Details      string     `json:"details,omitempty" db:"details"`
+}
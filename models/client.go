@@ -0,0 +1,7 @@
+package models
+
+// ClientInfo is where a request came from, it is recorded with every authentication event
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
@@ -0,0 +1,13 @@
+package models
+
+import "net/http"
+
+// AuthCookieConfig turns on cookie delivery of tokens for browser clients, tokens then never reach
+// javascript and cookie authenticated requests need the csrf header
+type AuthCookieConfig struct {
+	Enabled bool
+	Domain  string
+	Secure  bool
+	// SameSiteNoneMode needs Secure, browsers drop such cookies otherwise
+	SameSite http.SameSite
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// DBPoolConfig bounds the connections the server keeps to Postgres. Every instance opens up to
+// MaxOpenConns, so their sum has to stay below max_connections of the database
+type DBPoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// DepartmentScope limits what a manager can see and change to their own department.
+// Admins get AllDepartments, a manager without a department only reaches unassigned records.
+// OrganizationID limits the caller to one organization, nil reaches every organization.
+type DepartmentScope struct {
+	AllDepartments bool
+	DepartmentID   *uuid.UUID
+	OrganizationID *uuid.UUID
+}
+
+// Unrestricted reports whether the scope reaches every record, in any department of any organization
+func (s DepartmentScope) Unrestricted() bool {
+	return s.AllDepartments && s.OrganizationID == nil
+}
+
+var ErrOutOfScope = NewServiceError(http.StatusForbidden, "out_of_scope", "resource belongs to another department")
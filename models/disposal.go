@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DisposeAssetReq retires an asset that was sold or bought back. Price is what was realized for it,
+// 0 when it was given away. InvoiceID is an uploaded attachment of the asset
+type DisposeAssetReq struct {
+	AssetID    uuid.UUID  `json:"asset_id" validate:"required"`
+	Kind       string     `json:"kind" validate:"required,oneof=sale buyback"`
+	Buyer      string     `json:"buyer" validate:"required,max=200"`
+	Price      float64    `json:"price" validate:"gte=0"`
+	DisposedOn string     `json:"disposed_on" validate:"required,datetime=2006-01-02"`
+	InvoiceID  *uuid.UUID `json:"invoice_id,omitempty"`
+	Notes      string     `json:"notes,omitempty" validate:"max=1000"`
+}
+
+type AssetDisposal struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	AssetID    uuid.UUID  `json:"asset_id" db:"asset_id"`
+	Kind       string     `json:"kind" db:"kind"`
+	Buyer      string     `json:"buyer" db:"buyer"`
+	Price      float64    `json:"price" db:"price"`
+	DisposedOn time.Time  `json:"disposed_on" db:"disposed_on"`
+	InvoiceID  *uuid.UUID `json:"invoice_id,omitempty" db:"invoice_id"`
+	Notes      string     `json:"notes,omitempty" db:"notes"`
+	DisposedBy uuid.UUID  `json:"disposed_by" db:"disposed_by"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// what the assets of a duplicate group have in common
+const (
+	DuplicateBySerialNo = "serial_no"
+	DuplicateByIMEI     = "imei"
+)
+
+type DuplicateFilter struct {
+	Scope  DepartmentScope
+	Limit  int
+	Offset int
+}
+
+// DuplicateGroup is live assets sharing a serial number, compared without case, or an imei of a mobile
+type DuplicateGroup struct {
+	Match  string          `json:"match" db:"match"`
+	Value  string          `json:"value" db:"value"`
+	Assets DuplicateAssets `json:"assets" db:"assets"`
+}
+
+type DuplicateAsset struct {
+	ID       uuid.UUID `json:"id"`
+	Brand    string    `json:"brand"`
+	Model    string    `json:"model"`
+	SerialNo string    `json:"serial_no"`
+	Type     string    `json:"type"`
+	Status   string    `json:"status"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+// DuplicateAssets is read from a json_agg, oldest asset first
+type DuplicateAssets []DuplicateAsset
+
+func (a *DuplicateAssets) Scan(src interface{}) error {
+	data, ok := src.([]byte)
+	if !ok {
+		return errors.New("duplicate assets are not json")
+	}
+	return json.Unmarshal(data, a)
+}
+
+// MergeAssetsReq folds duplicate_id into keep_id, the duplicate is archived afterwards
+type MergeAssetsReq struct {
+	KeepID      uuid.UUID `json:"keep_id" validate:"required"`
+	DuplicateID uuid.UUID `json:"duplicate_id" validate:"required"`
+}
+
+// MergeAssetsRes counts the rows moved over to the kept asset per table
+type MergeAssetsRes struct {
+	KeepID      uuid.UUID        `json:"keep_id"`
+	DuplicateID uuid.UUID        `json:"duplicate_id"`
+	Moved       map[string]int64 `json:"moved"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// EmailAttachment is a file sent along with an email, like a scheduled report
+type EmailAttachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
@@ -0,0 +1,22 @@
+package models
+
+// the values of the database enums, request validation and GET /api/meta/enums both read these so
+// a value added in a migration only has to be added here too
+var (
+	EmployeeTypes   = []string{"full_time", "intern", "freelancer"}
+	AssetTypes      = []string{"laptop", "mouse", "monitor", "hard_disk", "pen_drive", "mobile", "sim", "accessory"}
+	AssetStatuses   = []string{"available", "assigned", "waiting for repair", "sent_for_service", "damaged", "stolen"}
+	AssetOwnerships = []string{"remotestate", "client"}
+	// condition of an asset when it is handed over with an assignment
+	HandoverConditions = []string{"new", "good", "fair", "damaged"}
+)
+
+// EnumsRes lists the values a client can pick from, roles come from the roles table
+type EnumsRes struct {
+	Roles              []string `json:"roles"`
+	EmployeeTypes      []string `json:"employee_types"`
+	AssetTypes         []string `json:"asset_types"`
+	AssetStatuses      []string `json:"asset_statuses"`
+	Ownerships         []string `json:"ownerships"`
+	HandoverConditions []string `json:"handover_conditions"`
+}
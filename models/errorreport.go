@@ -0,0 +1,25 @@
+package models
+
+// ErrorReportingConfig points at the sentry project unexpected errors are sent to, reporting is off
+// without a DSN
+type ErrorReportingConfig struct {
+	DSN         string
+	Environment string
+	Release     string
+}
+
+// ErrorReport is one unexpected error, the request fields stay empty for background jobs
+type ErrorReport struct {
+	Err error
+	// goroutine stack at the time of a panic
+	Stack []byte
+	Tags  map[string]string
+
+	Method    string
+	URL       string
+	Route     string
+	Status    int
+	RequestID string
+	UserID    string
+	RemoteIP  string
+}
@@ -0,0 +1,57 @@
+package models
+
+import "net/http"
+
+// codes shared by handlers and errors that don't have a more specific one, clients switch on the code
+// and show the message
+const (
+	CodeBadRequest       = "bad_request"
+	CodeValidationFailed = "validation_failed"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeAlreadyExists    = "already_exists"
+	CodeRateLimited      = "rate_limited"
+	CodePayloadTooLarge  = "payload_too_large"
+	CodeInternal         = "internal_error"
+	CodeUnavailable      = "service_unavailable"
+	CodeTimeout          = "timeout"
+)
+
+// ServiceError is a failure the caller caused or can act on. Services return it, or wrap it with %w,
+// and utils.RespondError answers with its status, code and message whatever status the handler passed
+type ServiceError struct {
+	Status  int
+	Code    string
+	Message string
+	Details interface{}
+}
+
+func NewServiceError(status int, code, message string) *ServiceError {
+	return &ServiceError{Status: status, Code: code, Message: message}
+}
+
+func (e *ServiceError) Error() string {
+	return e.Message
+}
+
+// Is matches on the code, so a copy made by WithDetails still satisfies errors.Is against the original
+func (e *ServiceError) Is(target error) bool {
+	t, ok := target.(*ServiceError)
+	return ok && t.Code == e.Code
+}
+
+// WithDetails returns a copy carrying details for the response, like the fields that failed
+func (e *ServiceError) WithDetails(details interface{}) *ServiceError {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+// the failures of a repository that don't belong to one domain. utils.RespondError answers a missing
+// row or a duplicate key with these when they reach it as server errors
+var (
+	ErrNotFound = NewServiceError(http.StatusNotFound, CodeNotFound, "resource not found")
+	ErrConflict = NewServiceError(http.StatusConflict, CodeAlreadyExists, "resource already exists")
+)
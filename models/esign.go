@@ -0,0 +1,58 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// e-signature services handover and return documents are sent through, an empty ESIGN_PROVIDER
+// sends none
+const (
+	ESignProviderDocuSign    = "docusign"
+	ESignProviderDropboxSign = "dropboxsign"
+)
+
+// states of an envelope as the signature service reports them
+const (
+	SignatureStatusSent      = "sent"
+	SignatureStatusCompleted = "completed"
+	SignatureStatusDeclined  = "declined"
+)
+
+var ErrSignatureWebhookUnauthorized = errors.New("e-signature webhook signature doesn't match")
+
+// ESignConfig points at the e-signature service. DocuSign signs in with a jwt grant for UserID, made
+// with the integration key and the rsa private key that is a secret, and its connect callbacks are
+// checked with the hmac key that is another. Dropbox Sign takes an api key that is a secret and signs
+// its callbacks with it
+type ESignConfig struct {
+	Provider string
+	// api base, like https://demo.docusign.net/restapi or https://api.hellosign.com
+	URL string
+	// docusign only, AuthURL is account-d.docusign.com for the demo environment
+	AccountID      string
+	IntegrationKey string
+	UserID         string
+	AuthURL        string
+	// dropbox sign only, requests are sent in test mode and aren't legally binding
+	TestMode bool
+	Timeout  time.Duration
+}
+
+// SignatureRequest is one pdf sent to one signer, the signature goes where the document reads
+// "Signature:"
+type SignatureRequest struct {
+	Title       string
+	Message     string
+	SignerName  string
+	SignerEmail string
+	Filename    string
+	Document    []byte
+}
+
+// SignatureEvent is the state a webhook reports for an envelope, Status is one of the
+// SignatureStatus values or empty for events that don't change it
+type SignatureEvent struct {
+	EnvelopeID string
+	Status     string
+}
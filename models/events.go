@@ -0,0 +1,31 @@
+package models
+
+// message brokers domain events can be published to, an empty EVENT_BROKER turns publishing off
+const (
+	EventBrokerKafka    = "kafka"
+	EventBrokerNATS     = "nats"
+	EventBrokerRabbitMQ = "rabbitmq"
+)
+
+// EventBrokerConfig picks the broker domain events go to. Kafka is reached through a REST proxy and
+// RabbitMQ through its management api, so neither needs a client library, NATS is spoken natively
+type EventBrokerConfig struct {
+	Driver string
+	// kafka topic, and the prefix of the NATS subject and RabbitMQ routing key, "<topic>.<event type>"
+	Topic    string
+	URL      string
+	Username string
+	Password string
+
+	RabbitMQExchange string
+	RabbitMQVHost    string
+}
+
+// BrokerMessage is one published event, Key keeps the events of one aggregate in order on brokers
+// that partition
+type BrokerMessage struct {
+	ID   string
+	Type string
+	Key  string
+	Body []byte
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"net/http"
+	"time"
+)
+
+// FirebaseConfig bounds the calls to firebase. Each attempt gets Timeout, a transient failure is
+// retried up to MaxAttempts in total waiting RetryBackoff, then twice that and so on. After
+// BreakerFailures transient failures in a row calls fail at once for BreakerCooldown, then one call
+// is let through to see whether firebase is back
+type FirebaseConfig struct {
+	Timeout         time.Duration
+	MaxAttempts     int
+	RetryBackoff    time.Duration
+	BreakerFailures int
+	BreakerCooldown time.Duration
+}
+
+// ErrFirebaseUnavailable is returned while the breaker is open, sign in through other providers and
+// everything not touching firebase keeps working
+var ErrFirebaseUnavailable = NewServiceError(http.StatusServiceUnavailable, CodeUnavailable, "firebase is unavailable, retry shortly")
@@ -0,0 +1,22 @@
+package models
+
+const (
+	HealthStatusUp   = "up"
+	HealthStatusDown = "down"
+	// an instance shutting down reports draining until it stops serving
+	HealthStatusDraining = "draining"
+)
+
+// ComponentHealth is the result of probing one dependency, Error is a short reason and never the
+// raw driver error, the probes are public
+type ComponentHealth struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// HealthRes is the body of /healthz and /readyz, Status is up only when every component is
+type HealthRes struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentHealth `json:"components,omitempty"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// what happens when a mobile's imei is on the blacklist at intake, no check is made without a mode
+const (
+	IMEICheckFlag  = "flag"
+	IMEICheckBlock = "block"
+)
+
+// IMEICheckConfig turns the blacklist check of mobiles on. LookupURL is an external service asked
+// after the internal blacklist, its api key is a secret
+type IMEICheckConfig struct {
+	Mode      string
+	LookupURL string
+	Timeout   time.Duration
+}
+
+// IMEILookup is what the external lookup knows about an imei
+type IMEILookup struct {
+	Blacklisted bool   `json:"blacklisted"`
+	Reason      string `json:"reason"`
+}
+
+// BlacklistedIMEI is an entry of the internal blacklist, AssetID is set for the imeis of a mobile
+// that was reported stolen
+type BlacklistedIMEI struct {
+	IMEI      string     `json:"imei" db:"imei"`
+	Reason    string     `json:"reason" db:"reason"`
+	AssetID   *uuid.UUID `json:"asset_id,omitempty" db:"asset_id"`
+	AddedBy   *uuid.UUID `json:"added_by,omitempty" db:"added_by"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+type BlacklistIMEIReq struct {
+	IMEI   string `json:"imei" validate:"required,numeric,min=14,max=16"`
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+type IMEIBlacklistFilter struct {
+	Search string
+	Limit  int
+	Offset int
+}
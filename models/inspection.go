@@ -0,0 +1,79 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReturnInspection is filled in by whoever takes an asset back. The return is damaged when Damaged or
+// any checklist item is set, the asset is then left damaged instead of available. OpenService sends
+// the asset to service right away with the damage notes as the reason
+type ReturnInspection struct {
+	Checklist InspectionChecklist `json:"checklist,omitempty" validate:"max=30,dive"`
+	// 1 is unusable, 5 is as good as new
+	ConditionRating int         `json:"condition_rating" validate:"required,min=1,max=5"`
+	Damaged         bool        `json:"damaged"`
+	DamageNotes     string      `json:"damage_notes,omitempty" validate:"max=1000"`
+	PhotoIDs        []uuid.UUID `json:"photo_ids,omitempty" validate:"max=20"`
+	OpenService     bool        `json:"open_service"`
+}
+
+// IsDamaged reports whether the inspection or any item of its checklist found damage
+func (i ReturnInspection) IsDamaged() bool {
+	if i.Damaged {
+		return true
+	}
+	for _, item := range i.Checklist {
+		if item.Damaged {
+			return true
+		}
+	}
+	return false
+}
+
+// InspectionItem is one point of the checklist, like "screen" or "charger included"
+type InspectionItem struct {
+	Item    string `json:"item" validate:"required,max=100"`
+	Passed  bool   `json:"passed"`
+	Damaged bool   `json:"damaged"`
+	Note    string `json:"note,omitempty" validate:"max=300"`
+}
+
+type InspectionChecklist []InspectionItem
+
+func (c InspectionChecklist) Value() (driver.Value, error) {
+	if c == nil {
+		c = InspectionChecklist{}
+	}
+	payload, err := json.Marshal(c)
+	return string(payload), err
+}
+
+func (c *InspectionChecklist) Scan(src interface{}) error {
+	data, ok := src.([]byte)
+	if !ok {
+		return errors.New("inspection checklist is not jsonb")
+	}
+	return json.Unmarshal(data, c)
+}
+
+// ReturnInspectionRes is a stored inspection, ServiceID is the service it opened
+type ReturnInspectionRes struct {
+	ID              uuid.UUID           `json:"id" db:"id"`
+	AssignmentID    uuid.UUID           `json:"assignment_id" db:"assignment_id"`
+	AssetID         uuid.UUID           `json:"asset_id" db:"asset_id"`
+	EmployeeID      uuid.UUID           `json:"employee_id" db:"employee_id"`
+	EmployeeName    string              `json:"employee_name" db:"employee_name"`
+	Checklist       InspectionChecklist `json:"checklist" db:"checklist"`
+	ConditionRating int                 `json:"condition_rating" db:"condition_rating"`
+	Damaged         bool                `json:"damaged" db:"damaged"`
+	DamageNotes     string              `json:"damage_notes,omitempty" db:"damage_notes"`
+	PhotoIDs        []uuid.UUID         `json:"photo_ids" db:"-"`
+	ServiceID       *uuid.UUID          `json:"service_id,omitempty" db:"service_id"`
+	InspectedBy     uuid.UUID           `json:"inspected_by" db:"inspected_by"`
+	CreatedAt       time.Time           `json:"created_at" db:"created_at"`
+}
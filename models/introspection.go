@@ -0,0 +1,16 @@
+package models
+
+// TokenIntrospection follows RFC 7662, every field but Active is left out for inactive tokens
+type TokenIntrospection struct {
+	Active bool `json:"active"`
+	// access_token or api_key
+	TokenType string `json:"token_type,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	// user or service_account
+	Principal string   `json:"principal,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+	// space separated api key scopes, access tokens aren't limited by scopes
+	Scope     string `json:"scope,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+}
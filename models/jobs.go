@@ -0,0 +1,108 @@
+package models
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	JobTriggerSchedule = "schedule"
+	JobTriggerManual   = "manual"
+
+	JobRunSucceeded = "succeeded"
+	JobRunFailed    = "failed"
+)
+
+var (
+	ErrJobNotFound = NewServiceError(http.StatusNotFound, "job_not_found", "no background job with that name")
+	ErrJobRunning  = NewServiceError(http.StatusConflict, "job_running", "the job is already running on this instance")
+)
+
+// JobsConfig holds the thresholds and retention periods of the scheduled jobs
+type JobsConfig struct {
+	// assets are alerted on once, this many days before the warranty expires
+	WarrantyAlertDays int
+	// return requests still open after this many days are reported as overdue
+	ReturnOverdueDays int
+	// days after an assignment its employee is reminded to acknowledge it, ascending. The last
+	// reminder is also reported to their managers
+	AckReminderDays      []int
+	JobRunRetention      time.Duration
+	DomainEventRetention time.Duration
+	// workers per instance taking queued bulk imports, exports and assigns
+	BulkWorkers      int
+	BulkJobRetention time.Duration
+	// assignments and services closed this many months ago move to the history tables, 0 keeps them
+	AssignmentHistoryMonths int
+	// available assets per type below which asset managers are alerted, types left out aren't checked
+	LowStockThresholds map[string]int
+	Retention          RetentionPolicy
+	// the check_data_integrity job repairs the issues that are safe to repair instead of only reporting them
+	IntegrityAutoRepair bool
+	// percent of a department budget spent at which its managers are alerted it is nearly used up
+	BudgetAlertPercent int
+	// steps after which open return requests are escalated, days counted from when they became overdue
+	OverdueReturnEscalations []EscalationStep
+	// steps after which purchase requests and orders waiting for a decision are escalated, days
+	// counted from when they started waiting
+	PendingApprovalEscalations []EscalationStep
+	// how long an employee has to claim an asset offered from the waitlist before it goes to the next in line
+	WaitlistClaimWindow time.Duration
+}
+
+// who an escalation step tells, manager is the employee managers of the item's department
+const (
+	EscalateManager = "manager"
+	EscalateAdmin   = "admin"
+)
+
+// EscalationStep tells Notify once an item has waited AfterDays
+type EscalationStep struct {
+	AfterDays int
+	Notify    string
+}
+
+const (
+	RetentionAnonymize = "anonymize"
+	RetentionDelete    = "delete"
+)
+
+// RetentionPolicy is how many years archived users, audit entries and closed assignments are kept
+// before the purge_expired_records job removes them, 0 keeps them forever
+type RetentionPolicy struct {
+	ArchivedUserYears int
+	// anonymize scrubs the personal data of the user and keeps the row, delete removes the row when
+	// nothing but personal data refers to it and anonymizes it otherwise
+	ArchivedUserAction    string
+	AuditLogYears         int
+	ClosedAssignmentYears int
+	// the job only logs what it would purge
+	DryRun bool
+}
+
+// JobRun is one execution of a background job, Instance is the host that ran it
+type JobRun struct {
+	ID         uuid.UUID `json:"id" db:"id"`
+	Job        string    `json:"job" db:"job"`
+	Trigger    string    `json:"trigger" db:"trigger"`
+	Instance   string    `json:"instance" db:"instance"`
+	Status     string    `json:"status" db:"status"`
+	Error      *string   `json:"error,omitempty" db:"error"`
+	StartedAt  time.Time `json:"started_at" db:"started_at"`
+	FinishedAt time.Time `json:"finished_at" db:"finished_at"`
+	DurationMS int64     `json:"duration_ms" db:"duration_ms"`
+}
+
+// JobInfo describes a registered job as this instance sees it, LastRun is the latest run anywhere in
+// the cluster when history is available
+type JobInfo struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Schedule    string     `json:"schedule"`
+	PerInstance bool       `json:"per_instance"`
+	Running     bool       `json:"running"`
+	NextRun     *time.Time `json:"next_run,omitempty"`
+	LastRun     *JobRun    `json:"last_run,omitempty"`
+}
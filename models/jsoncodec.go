@@ -0,0 +1,8 @@
+package models
+
+// codecs JSON_CODEC selects for request and response bodies and cached values. Both read and write the
+// same json, std is there to rule the codec out when a body looks wrong
+const (
+	JSONCodecJsoniter = "jsoniter"
+	JSONCodecStd      = "std"
+)
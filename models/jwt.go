@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// JWTConfig picks how access tokens are signed and how long tokens live. HS256 keeps signing with
+// SECRET_KEY, RS256 signs with PrivateKeyFile and publishes its public key, and the keys in
+// PreviousKeyFiles, on the jwks endpoint
+type JWTConfig struct {
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	Algorithm      string
+	PrivateKeyFile string
+	// set from the secrets provider, they win over SECRET_KEY and PrivateKeyFile
+	SecretKey     []byte
+	PrivateKeyPEM []byte
+	// signs refresh tokens and nothing else, read from REFRESH_TOKEN or the secrets provider
+	RefreshKey []byte
+	// retired keys still trusted until the tokens they signed have expired, pem public or private keys
+	PreviousKeyFiles []string
+}
+
+// JWK is an rsa public key in the RFC 7517 format
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	Modulus   string `json:"n"`
+	Exponent  string `json:"e"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
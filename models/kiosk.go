@@ -0,0 +1,42 @@
+package models
+
+import "github.com/google/uuid"
+
+const (
+	KioskCheckedOut = "checked_out"
+	KioskReturned   = "returned"
+)
+
+// what a kiosk sends after scanning the employee's badge and the asset's label
+type KioskScanReq struct {
+	BadgeID  string `json:"badge_id" validate:"required,max=64"`
+	SerialNo string `json:"serial_no" validate:"required,max=100"`
+}
+
+// KioskEmployee is the employee a scanned badge belongs to
+type KioskEmployee struct {
+	ID       uuid.UUID `db:"id"`
+	Username string    `db:"username"`
+}
+
+// KioskAsset is a scanned asset, only pool equipment can be checked out or returned at a kiosk
+type KioskAsset struct {
+	ID        uuid.UUID `db:"id"`
+	Brand     string    `db:"brand"`
+	Model     string    `db:"model"`
+	Type      string    `db:"type"`
+	Status    string    `db:"status"`
+	KioskPool bool      `db:"kiosk_pool"`
+}
+
+// KioskRes is shown on the kiosk screen, action is checked_out or returned
+type KioskRes struct {
+	Action       string    `json:"action"`
+	AssetID      uuid.UUID `json:"asset_id"`
+	SerialNo     string    `json:"serial_no"`
+	Brand        string    `json:"brand"`
+	Model        string    `json:"model"`
+	Type         string    `json:"type"`
+	EmployeeID   uuid.UUID `json:"employee_id"`
+	EmployeeName string    `json:"employee_name"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// LDAPConfig describes the directory users are synced from
+type LDAPConfig struct {
+	// ldap:// or ldaps://
+	URL          string
+	StartTLS     bool
+	BindDN       string
+	BindPassword string
+	BaseDN       string
+	UserFilter   string
+
+	// IDAttribute identifies a user across renames, entryUUID for openldap, objectGUID for active directory
+	IDAttribute    string
+	EmailAttribute string
+	NameAttribute  string
+	GroupAttribute string
+	// ClaimValue is a group dn or just its cn
+	RoleMappings []OIDCRoleMapping
+
+	SyncInterval time.Duration
+	// scheduled runs only report what they would change
+	DryRun bool
+}
+
+// DirectoryUser is one user as the directory sees them, Role is only set when one of their groups maps to a role
+type DirectoryUser struct {
+	ID       string
+	DN       string
+	Email    string
+	Name     string
+	Groups   []string
+	Role     string
+	Disabled bool
+}
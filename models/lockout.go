@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// LockoutPolicy locks logins after repeated failures, each lockout within LockoutDecay doubles
+// the previous one up to MaxLockout
+type LockoutPolicy struct {
+	MaxFailures      int
+	MaxFailuresPerIP int
+	FailureWindow    time.Duration
+	BaseLockout      time.Duration
+	MaxLockout       time.Duration
+	LockoutDecay     time.Duration
+}
@@ -0,0 +1,23 @@
+package models
+
+import "go.uber.org/zap/zapcore"
+
+const (
+	LogFormatJSON    = "json"
+	LogFormatConsole = "console"
+)
+
+// LogConfig picks how the application logger encodes entries and where it writes them
+type LogConfig struct {
+	// json in production and staging, console with colours elsewhere
+	Format string
+	Level  zapcore.Level
+	// also write to stdout, on unless logging to a file and turned off
+	Stdout bool
+	// File, when set, receives the logs too and is rotated once it grows past MaxSizeMB. Rotated files
+	// older than MaxAgeDays or beyond the newest MaxBackups are deleted, 0 keeps them
+	File       string
+	MaxSizeMB  int
+	MaxAgeDays int
+	MaxBackups int
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// LoginAnomalyConfig tunes how completed logins are compared to the user's earlier ones. Without
+// GeoLookupURL logins have no location, so only new devices are flagged
+type LoginAnomalyConfig struct {
+	GeoLookupURL     string
+	GeoLookupTimeout time.Duration
+	// km/h, two logins further apart than this allows are impossible travel
+	MaxTravelSpeed float64
+	// flagged and denied logins are also sent to the holders of login.review
+	NotifySecurity bool
+}
+
+// GeoLocation is roughly where an ip address is, Country is empty when the lookup didn't know
+type GeoLocation struct {
+	Country   string  `json:"country"`
+	City      string  `json:"city"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
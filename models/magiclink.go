@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// MagicLinkPolicy bounds passwordless sign-in links, at most MaxPerEmail links are sent to one
+// address within RequestWindow
+type MagicLinkPolicy struct {
+	TTL           time.Duration
+	MaxPerEmail   int
+	RequestWindow time.Duration
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// device management systems managed devices are pulled from, an empty MDM_SYSTEM pulls none
+const (
+	MDMSystemIntune = "intune"
+	MDMSystemJamf   = "jamf"
+)
+
+// MDMConfig points at the device management system. Intune signs in to the tenant with an app
+// registration, Jamf Pro with an api client, the client secret of both is a secret
+type MDMConfig struct {
+	System   string
+	URL      string
+	TenantID string
+	ClientID string
+	Timeout  time.Duration
+	// warranty given to assets created for discovered devices, managers correct it afterwards
+	WarrantyMonths int
+}
+
+// MDMDevice is a managed device as the device management system last saw it
+type MDMDevice struct {
+	ExternalID   string
+	SerialNo     string
+	Manufacturer string
+	Model        string
+	DeviceName   string
+	OS           string
+	OSVersion    string
+	LastCheckIn  time.Time
+	EnrolledAt   time.Time
+	// email of the user the device is enrolled for, empty for shared devices
+	UserEmail string
+}
+
+// MDMAsset is the asset a device's serial number matches, with the email of its current assignee
+type MDMAsset struct {
+	ID            uuid.UUID `db:"id"`
+	Archived      bool      `db:"archived"`
+	AssigneeEmail *string   `db:"assignee_email"`
+}
+
+// MDMMismatch is an asset whose device is enrolled for someone other than the employee it is assigned to
+type MDMMismatch struct {
+	AssetID       uuid.UUID  `json:"asset_id" db:"asset_id"`
+	Brand         string     `json:"brand" db:"brand"`
+	Model         string     `json:"model" db:"model"`
+	SerialNo      string     `json:"serial_no" db:"serial_no"`
+	DeviceName    string     `json:"device_name" db:"device_name"`
+	MDMUserEmail  string     `json:"mdm_user_email" db:"user_email"`
+	AssigneeEmail *string    `json:"assignee_email" db:"assignee_email"`
+	LastCheckIn   *time.Time `json:"last_check_in" db:"last_check_in"`
+	MismatchSince time.Time  `json:"mismatch_since" db:"mismatch_since"`
+}
+
+type MDMMismatchFilter struct {
+	Scope  DepartmentScope
+	Limit  int
+	Offset int
+}
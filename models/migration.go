@@ -0,0 +1,23 @@
+package models
+
+// MigrationConfig says where the migration files are and whether the server applies pending ones when
+// it starts. Without OnStart they are applied with assetctl migrate before a deploy
+type MigrationConfig struct {
+	Dir     string
+	OnStart bool
+}
+
+// Migration is one file of the migrations directory, Name is the part after the version
+type Migration struct {
+	Version uint   `json:"version"`
+	Name    string `json:"name"`
+}
+
+// MigrationStatus compares the schema with the migrations this build ships. Dirty is set when a
+// migration failed halfway, the schema then has to be fixed by hand and the version forced
+type MigrationStatus struct {
+	Version uint        `json:"version"`
+	Dirty   bool        `json:"dirty"`
+	Latest  uint        `json:"latest"`
+	Pending []Migration `json:"pending"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// backends OBJECT_STORAGE_BACKEND selects, an empty one keeps files in the database and disables
+// attachments. Local writes under a directory and serves it from this api, for development only
+const (
+	ObjectStorageS3    = "s3"
+	ObjectStorageGCS   = "gcs"
+	ObjectStorageLocal = "local"
+)
+
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectStorageConfig points at the bucket uploads and generated files are kept in. S3 signs in with
+// an access key id and OBJECT_STORAGE_SECRET_KEY, GCS with OBJECT_STORAGE_SERVICE_ACCOUNT or the
+// application default credentials
+type ObjectStorageConfig struct {
+	Backend string
+	Bucket  string
+	Region  string
+	// s3 compatible gateway such as minio, addressed path style. Empty uses aws
+	Endpoint    string
+	AccessKeyID string
+	// directory the local backend writes to
+	Dir string
+	// base url the local backend's signed links point at, this api's own address
+	PublicURL string
+	Timeout   time.Duration
+	// how long a presigned link works
+	URLTTL time.Duration
+	// largest upload an attachment may be
+	MaxUploadBytes int64
+}
@@ -0,0 +1,40 @@
+package models
+
+// OIDCIdentity is what a verified id token tells about the user, Role is only set when
+// the provider maps one of its claims to a role
+type OIDCIdentity struct {
+	Provider      string
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Role          string
+}
+
+// OIDCRoleMapping maps a claim value (group, app role) to a role, the first mapping that matches wins
+type OIDCRoleMapping struct {
+	ClaimValue string
+	Role       string
+}
+
+type OIDCProviderConfig struct {
+	Name     string
+	Issuer   string
+	ClientID string
+	// claim holding the user's groups or roles, e.g. groups or roles
+	RoleClaim    string
+	RoleMappings []OIDCRoleMapping
+	// treat the email claim as verified, for directories that own their users' mailboxes
+	TrustEmail bool
+}
+
+// SocialLoginConfig enables the microsoft and github logins next to google, a provider is off while
+// its client id is empty
+type SocialLoginConfig struct {
+	MicrosoftClientID string
+	// tenant id, or common, organizations or consumers
+	MicrosoftTenant    string
+	GitHubClientID     string
+	GitHubClientSecret string
+}
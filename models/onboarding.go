@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OnboardingKitItem is a line of an onboarding kit, quantity assets of the given type
+type OnboardingKitItem struct {
+	AssetType string `json:"asset_type" db:"asset_type" validate:"required,asset_type"`
+	Quantity  int    `json:"quantity" db:"quantity" validate:"required,min=1"`
+}
+
+// OnboardingTemplate maps an employee type to the role, department and asset kit a new employee gets
+type OnboardingTemplate struct {
+	ID             uuid.UUID           `json:"id" db:"id"`
+	Name           string              `json:"name" db:"name"`
+	EmployeeType   string              `json:"employee_type" db:"employee_type"`
+	Role           *string             `json:"role,omitempty" db:"role"`
+	DepartmentID   *uuid.UUID          `json:"department_id,omitempty" db:"department_id"`
+	OrganizationID uuid.UUID           `json:"organization_id" db:"organization_id"`
+	CreatedAt      time.Time           `json:"created_at" db:"created_at"`
+	Items          []OnboardingKitItem `json:"items"`
+}
+
+type KitShortfall struct {
+	AssetType string `json:"asset_type"`
+	Requested int    `json:"requested"`
+	Assigned  int    `json:"assigned"`
+}
+
+// OnboardingRes reports what was handed out from a template and what couldn't be fulfilled from available stock
+type OnboardingRes struct {
+	TemplateID     uuid.UUID      `json:"template_id"`
+	Role           string         `json:"role,omitempty"`
+	AssignedAssets []uuid.UUID    `json:"assigned_assets"`
+	Unfulfilled    []KitShortfall `json:"unfulfilled"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// DefaultOrganizationID holds every row created before organizations existed
+var DefaultOrganizationID = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+var ErrOrganizationMismatch = NewServiceError(http.StatusForbidden, "organization_mismatch", "account belongs to another organization")
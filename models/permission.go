@@ -0,0 +1,98 @@
+package models
+
+type Permission string
+
+const (
+	AssetReadPermission     Permission = "asset.read"
+	AssetCreatePermission   Permission = "asset.create"
+	AssetUpdatePermission   Permission = "asset.update"
+	AssetDeletePermission   Permission = "asset.delete"
+	AssetAssignPermission   Permission = "asset.assign"
+	AssetUnassignPermission Permission = "asset.unassign"
+	AssetServicePermission  Permission = "asset.service"
+	AssetMergePermission    Permission = "asset.merge"
+
+	UserReadPermission   Permission = "user.read"
+	UserCreatePermission Permission = "user.create"
+	UserUpdatePermission Permission = "user.update"
+	UserDeletePermission Permission = "user.delete"
+
+	RoleManagePermission Permission = "role.manage"
+
+	AuditReadPermission Permission = "audit.read"
+
+	DepartmentManagePermission Permission = "department.manage"
+
+	OnboardingManagePermission Permission = "onboarding.manage"
+
+	APIKeyManagePermission Permission = "api_key.manage"
+
+	ServiceAccountManagePermission Permission = "service_account.manage"
+
+	TokenIntrospectPermission Permission = "token.introspect"
+
+	WebhookManagePermission Permission = "webhook.manage"
+
+	DebugReadPermission Permission = "debug.read"
+
+	JobManagePermission Permission = "job.manage"
+
+	ConfigManagePermission Permission = "config.manage"
+
+	SchemaReadPermission Permission = "schema.read"
+
+	AccountingExportPermission Permission = "accounting.export"
+
+	SheetSyncManagePermission Permission = "sheet_sync.manage"
+
+	ProcurementManagePermission  Permission = "procurement.manage"
+	ProcurementApprovePermission Permission = "procurement.approve"
+
+	ReportManagePermission Permission = "report.manage"
+
+	PrivacyManagePermission Permission = "privacy.manage"
+
+	OrganizationManagePermission Permission = "organization.manage"
+
+	KioskOperatePermission Permission = "kiosk.operate"
+
+	IMEIBlacklistManagePermission Permission = "imei_blacklist.manage"
+
+	ChargeManagePermission Permission = "charge.manage"
+
+	BudgetReadPermission   Permission = "budget.read"
+	BudgetManagePermission Permission = "budget.manage"
+
+	ProjectReadPermission   Permission = "project.read"
+	ProjectManagePermission Permission = "project.manage"
+
+	EscalationReadPermission Permission = "escalation.read"
+
+	NotificationBroadcastPermission Permission = "notification.broadcast"
+
+	LoginReviewPermission Permission = "login.review"
+
+	SnapshotExportPermission  Permission = "snapshot.export"
+	SnapshotRestorePermission Permission = "snapshot.restore"
+)
+
+// PrivilegedPermissions let their holder hand out roles or replace the data wholesale. A role
+// holding any of them is granted only with a second admin's approval and is never delegated
+var PrivilegedPermissions = []Permission{
+	RoleManagePermission,
+	OrganizationManagePermission,
+	SnapshotRestorePermission,
+	ConfigManagePermission,
+}
+
+// HasPrivilegedPermission reports whether any of the permissions is privileged
+func HasPrivilegedPermission(permissions []string) bool {
+	for _, permission := range permissions {
+		for _, privileged := range PrivilegedPermissions {
+			if permission == string(privileged) {
+				return true
+			}
+		}
+	}
+	return false
+}
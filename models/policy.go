@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// PolicySummary describes the role → permission policy currently loaded in memory
+type PolicySummary struct {
+	Roles    int       `json:"roles"`
+	Grants   int       `json:"grants"`
+	LoadedAt time.Time `json:"loaded_at"`
+}
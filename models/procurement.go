@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// e-procurement systems approved purchase orders are pushed to, an empty PROCUREMENT_SYSTEM keeps
+// them in the asset manager only
+const ProcurementSystemCoupa = "coupa"
+
+// ProcurementConfig points at the e-procurement system. Coupa signs in with an oauth client whose
+// secret is a secret, the client needs the core.purchase_order.write scope
+type ProcurementConfig struct {
+	System   string
+	URL      string
+	ClientID string
+	Currency string
+	Timeout  time.Duration
+}
+
+// PurchaseOrderPush is an approved purchase order as it is sent to the e-procurement system
+type PurchaseOrderPush struct {
+	Number string
+	Vendor string
+	Lines  []PurchaseOrderPushLine
+}
+
+type PurchaseOrderPushLine struct {
+	Description string
+	PartNumber  string
+	Quantity    int
+	UnitCost    float64
+}
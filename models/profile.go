@@ -0,0 +1,8 @@
+package models
+
+// profiles APP_ENV selects, each brings defaults for the settings a deployment leaves unset
+const (
+	ProfileDevelopment = "development"
+	ProfileStaging     = "staging"
+	ProfileProduction  = "production"
+)
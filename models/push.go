@@ -0,0 +1,9 @@
+package models
+
+// PushMessage is one notification for all devices of a user, Data reaches the app with it so a tap can
+// open what the notification is about
+type PushMessage struct {
+	Title string
+	Body  string
+	Data  map[string]string
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// QueryStatsConfig controls the statement instrumentation of the database pool. Statements running
+// longer than SlowThreshold are logged with their normalized sql and duration. Duration histograms
+// are kept for up to MaxStatements distinct normalized statements, later ones are counted together
+type QueryStatsConfig struct {
+	SlowThreshold time.Duration
+	MaxStatements int
+}
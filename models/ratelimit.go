@@ -0,0 +1,29 @@
+package models
+
+// RateLimit is a token bucket refilled at PerMinute tokens a minute and holding at most Burst,
+// a zero PerMinute turns the limit off
+type RateLimit struct {
+	PerMinute int
+	Burst     int
+}
+
+type RateLimitConfig struct {
+	// per ip on the unauthenticated login and registration endpoints
+	Auth RateLimit
+	// per ip on every request
+	IP RateLimit
+	// per signed in user, requests made with an api key count against the key instead
+	User RateLimit
+	// per api key, the key's own rate_limit_per_minute replaces it
+	APIKey RateLimit
+	// requests an api key may make per calendar month in UTC, the key's own monthly_quota replaces
+	// it. 0 leaves keys without a quota
+	APIKeyMonthlyQuota int
+}
+
+// APIKeyCaller is the api key a request authenticated with, nil limits use the RateLimitConfig ones
+type APIKeyCaller struct {
+	ID                 string
+	RateLimitPerMinute *int
+	MonthlyQuota       *int
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// RequestLimits bounds what one request may cost. Reads get ReadTimeout and writes WriteTimeout, the
+// few routes that import or export in bulk get LongTimeout
+type RequestLimits struct {
+	MaxBodyBytes int64
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	LongTimeout  time.Duration
+}
@@ -6,4 +6,7 @@ const (
 	AdminRole          Role = "admin"
 	EmployeeMangerRole Role = "employee_manager"
 	AssetManagerRole   Role = "asset_manager"
+	EmployeeRole       Role = "employee"
+	// administers a single organization, holds every admin permission except organization.manage
+	OrgAdminRole Role = "org_admin"
 )
@@ -0,0 +1,115 @@
+package models
+
+import "time"
+
+// CacheClass is a kind of cached read of the user repository, each has its own ttl and can be
+// switched off on its own
+type CacheClass int
+
+const (
+	CacheDashboard CacheClass = iota
+	CacheTimeline
+	CacheUserRole
+	CacheUserEmail
+	CacheUserExists
+	// CacheNotFound remembers lookups that found nothing, creating the entity drops the entry
+	CacheNotFound
+	// CacheResponses holds whole bodies of the GET routes behind the response cache
+	CacheResponses
+)
+
+// CacheClassNames are the names CACHE_DISABLED takes
+var CacheClassNames = map[string]CacheClass{
+	"dashboard":   CacheDashboard,
+	"timeline":    CacheTimeline,
+	"user_role":   CacheUserRole,
+	"user_email":  CacheUserEmail,
+	"user_exists": CacheUserExists,
+	"not_found":   CacheNotFound,
+	"responses":   CacheResponses,
+}
+
+func (c CacheClass) String() string {
+	for name, class := range CacheClassNames {
+		if class == c {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// CacheClassSet holds one bit per CacheClass
+type CacheClassSet uint32
+
+func (s CacheClassSet) Has(class CacheClass) bool {
+	return s&(1<<class) != 0
+}
+
+func (s CacheClassSet) With(class CacheClass) CacheClassSet {
+	return s | 1<<class
+}
+
+// CacheTTLs is the cache policy of the user repository, how long redis keeps each class of read and
+// which classes skip redis altogether
+type CacheTTLs struct {
+	Dashboard  time.Duration
+	Timeline   time.Duration
+	UserRole   time.Duration
+	UserEmail  time.Duration
+	UserExists time.Duration
+	NotFound   time.Duration
+	// Responses is also the max-age clients may keep a cached response for
+	Responses time.Duration
+	// Disabled classes are neither read from nor written to redis, for debugging stale reads
+	Disabled CacheClassSet
+}
+
+// TTL returns how long entries of the class live
+func (c CacheTTLs) TTL(class CacheClass) time.Duration {
+	switch class {
+	case CacheDashboard:
+		return c.Dashboard
+	case CacheTimeline:
+		return c.Timeline
+	case CacheUserRole:
+		return c.UserRole
+	case CacheUserEmail:
+		return c.UserEmail
+	case CacheUserExists:
+		return c.UserExists
+	case CacheNotFound:
+		return c.NotFound
+	case CacheResponses:
+		return c.Responses
+	}
+	return 0
+}
+
+func (c CacheTTLs) Enabled(class CacheClass) bool {
+	return !c.Disabled.Has(class)
+}
+
+// DefaultCacheTTLs apply when CACHE_TTL_* is unset
+var DefaultCacheTTLs = CacheTTLs{
+	Dashboard:  5 * time.Minute,
+	Timeline:   5 * time.Minute,
+	UserRole:   5 * time.Minute,
+	UserEmail:  5 * time.Minute,
+	UserExists: 10 * time.Minute,
+	NotFound:   time.Minute,
+	Responses:  5 * time.Minute,
+}
+
+// names of the settings a config reload applies at runtime, everything else needs a restart
+const (
+	SettingLogLevel            = "log_level"
+	SettingRateLimits          = "rate_limits"
+	SettingCacheTTLs           = "cache_ttls"
+	SettingAllowedEmailDomains = "allowed_email_domains"
+)
+
+// ConfigReloadRes lists the runtime settings a reload changed on the instance that ran it
+type ConfigReloadRes struct {
+	Changed    []string  `json:"changed"`
+	ReloadedAt time.Time `json:"reloaded_at"`
+}
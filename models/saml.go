@@ -0,0 +1,18 @@
+package models
+
+// SAMLConfig describes this service provider and the one identity provider it trusts
+type SAMLConfig struct {
+	EntityID string
+	ACSURL   string
+
+	IDPEntityID string
+	IDPSSOURL   string
+	// pem, or the bare base64 certificate as it appears in the idp metadata
+	IDPCertificate string
+
+	// attribute names, the email falls back to the NameID
+	EmailAttribute string
+	NameAttribute  string
+	RoleAttribute  string
+	RoleMappings   []OIDCRoleMapping
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// names the secrets are looked up by, the env backend reads them as environment variables
+const (
+	SecretDatabasePassword       = "DB_PASSWORD"
+	SecretRedisPassword          = "REDIS_PASSWORD"
+	SecretJWTKey                 = "SECRET_KEY"
+	SecretJWTPrivateKey          = "JWT_PRIVATE_KEY"
+	SecretJWTRefreshKey          = "REFRESH_TOKEN"
+	SecretFirebaseServiceAccount = "FIREBASE_SERVICE_ACCOUNT"
+	SecretSlackBotToken          = "SLACK_BOT_TOKEN"
+	SecretTicketAPIToken         = "TICKET_API_TOKEN"
+	SecretTicketWebhookToken     = "TICKET_WEBHOOK_TOKEN"
+	SecretMDMClientSecret        = "MDM_CLIENT_SECRET"
+	SecretSMSAuthToken           = "SMS_AUTH_TOKEN"
+	SecretIMEILookupAPIKey       = "IMEI_LOOKUP_API_KEY"
+	SecretWarrantyDellSecret     = "WARRANTY_DELL_CLIENT_SECRET"
+	SecretWarrantyLenovoToken    = "WARRANTY_LENOVO_TOKEN"
+	// s3 secret access key, the local backend signs its links with it or SECRET_KEY
+	SecretObjectStorageKey            = "OBJECT_STORAGE_SECRET_KEY"
+	SecretObjectStorageServiceAccount = "OBJECT_STORAGE_SERVICE_ACCOUNT"
+	SecretSheetsServiceAccount        = "SHEETS_SERVICE_ACCOUNT"
+	SecretProcurementClientSecret     = "PROCUREMENT_CLIENT_SECRET"
+	// docusign rsa private key in pem, or the dropbox sign api key
+	SecretESignKey = "ESIGN_KEY"
+	// docusign connect hmac key, dropbox sign callbacks are checked with its api key
+	SecretESignWebhookSecret = "ESIGN_WEBHOOK_SECRET"
+)
+
+// secrets backends, env keeps reading the process environment like before
+const (
+	SecretsBackendEnv   = "env"
+	SecretsBackendVault = "vault"
+	SecretsBackendAWS   = "aws"
+)
+
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretsConfig picks where credentials come from. Vault reads one KV v2 secret, AWS one Secrets
+// Manager secret holding a json object, and each key of it is a secret name
+type SecretsConfig struct {
+	Backend string
+	// how long fetched values are reused before the backend is asked again, rotations show up after it
+	RefreshInterval time.Duration
+
+	VaultAddr  string
+	VaultToken string
+	VaultMount string
+	VaultPath  string
+
+	AWSRegion          string
+	AWSSecretID        string
+	AWSAccessKeyID     string
+	AWSSecretAccessKey string
+	AWSSessionToken    string
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// SheetsConfig turns on syncing asset filters to google sheets. The sheets are shared with the
+// service account in SHEETS_SERVICE_ACCOUNT, whose email the api shows when a sync is created
+type SheetsConfig struct {
+	Enabled bool
+	Timeout time.Duration
+	// most rows a synced sheet may get, a filter matching more fails its sync
+	MaxRows int
+}
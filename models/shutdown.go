@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// ShutdownConfig bounds a graceful shutdown. For DrainDelay the instance keeps serving while /readyz
+// reports it draining, so load balancers stop routing to it before it stops taking requests. Timeout
+// covers the whole shutdown, drain delay included, work still running when it ends is cancelled
+type ShutdownConfig struct {
+	Timeout    time.Duration
+	DrainDelay time.Duration
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// events that can be posted to slack, SLACK_ROUTE_<EVENT> lists the channels each one goes to
+const (
+	SlackEventAssetService = "asset_service"
+	SlackEventOverdue      = "overdue"
+	SlackEventLowStock     = "low_stock"
+	SlackEventMDMMismatch  = "mdm_mismatch"
+	SlackEventAssetStolen  = "asset_stolen"
+	SlackEventIMEIFlagged  = "imei_flagged"
+)
+
+var SlackEvents = []string{SlackEventAssetService, SlackEventOverdue, SlackEventLowStock, SlackEventMDMMismatch, SlackEventAssetStolen, SlackEventIMEIFlagged}
+
+// SlackConfig routes events to channels, slack is off when no event has a channel. A channel with an
+// incoming webhook is posted to through it, any other through chat.postMessage with SLACK_BOT_TOKEN
+type SlackConfig struct {
+	// channels per event, an event without any isn't posted
+	Routes map[string][]string
+	// incoming webhook url per channel
+	Webhooks map[string]string
+	Timeout  time.Duration
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"net/http"
+	"time"
+)
+
+// gateways text messages are sent through, an empty SMS_PROVIDER sends none
+const (
+	SMSProviderTwilio = "twilio"
+	SMSProviderSNS    = "sns"
+)
+
+// templates the app sends, SMS_TEMPLATE_<NAME> replaces the built in text
+const (
+	SMSTemplateOTP         = "otp"
+	SMSTemplateAssetStolen = "asset_stolen"
+)
+
+var SMSTemplates = []string{SMSTemplateOTP, SMSTemplateAssetStolen}
+
+var ErrSMSRateLimited = NewServiceError(http.StatusTooManyRequests, "sms_rate_limited", "too many text messages to this number, try again later")
+
+// SMSConfig points at the gateway. Twilio signs in with the account sid and SMS_AUTH_TOKEN, SNS with
+// an access key id and its secret access key as SMS_AUTH_TOKEN
+type SMSConfig struct {
+	Provider string
+	// twilio account sid or aws access key id
+	AccountID string
+	// twilio number or messaging service sid, for sns the sender id where carriers support one
+	From    string
+	Region  string
+	Timeout time.Duration
+	// messages per number within an hour, more are refused so a form can't be used to flood a phone
+	PerNumberPerHour int
+	// text/template sources by template name, missing ones use the built in text
+	Templates map[string]string
+	// contact numbers entered when accepting an invite have to be confirmed with a texted code
+	VerifyContactNo bool
+	OTPTTL          time.Duration
+}
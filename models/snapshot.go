@@ -0,0 +1,9 @@
+package models
+
+// SnapshotConfig guards the restore of snapshot archives. Restore replaces every user, asset and
+// audit record in the whole database, of every organization, so it stays off unless SNAPSHOT_RESTORE_ENABLED is set
+type SnapshotConfig struct {
+	RestoreEnabled bool
+	// archives larger than this are refused before they are read
+	MaxArchiveBytes int64
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// topologies REDIS_MODE selects. Sentinel follows the master named by MasterName through a failover,
+// cluster spreads keys over the shards found from any of Addrs. Memory keeps the keys in the process
+// of a single local server and is only allowed in development
+const (
+	RedisModeSingle   = "single"
+	RedisModeSentinel = "sentinel"
+	RedisModeCluster  = "cluster"
+	RedisModeMemory   = "memory"
+)
+
+// RedisConfig is how the external redis is reached. Addrs are the server, the sentinels or the
+// cluster seed nodes depending on Mode. The password is read from the secrets backend as
+// REDIS_PASSWORD on every new connection, so a rotation needs no restart
+type RedisConfig struct {
+	Mode       string
+	Addrs      []string
+	MasterName string
+	Username   string
+	// DB is ignored by a cluster, which only has db 0
+	DB int
+
+	SentinelUsername string
+	SentinelPassword string
+
+	TLS                   bool
+	TLSServerName         string
+	TLSInsecureSkipVerify bool
+
+	// zero leaves the go-redis default
+	PoolSize     int
+	MinIdleConns int
+	PoolTimeout  time.Duration
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TelecomCostFilter picks the month starting at Month, a sim counts for it when it was bought before
+// the month ended and not deleted before it began
+type TelecomCostFilter struct {
+	Month time.Time
+	Scope DepartmentScope
+}
+
+// DepartmentTelecomCost adds up the monthly cost of a department's sims, sims without a department
+// come back with no department id
+type DepartmentTelecomCost struct {
+	DepartmentID *uuid.UUID `json:"department_id,omitempty" db:"department_id"`
+	Department   string     `json:"department" db:"department"`
+	Sims         int        `json:"sims" db:"sims"`
+	MonthlyCost  float64    `json:"monthly_cost" db:"monthly_cost"`
+	// RenewalsDue counts the sims whose plan renews within the month
+	RenewalsDue int `json:"renewals_due" db:"renewals_due"`
+}
+
+type TelecomCostReportRes struct {
+	Month       string                  `json:"month"`
+	Sims        int                     `json:"sims"`
+	TotalCost   float64                 `json:"total_cost"`
+	Departments []DepartmentTelecomCost `json:"departments"`
+}
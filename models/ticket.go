@@ -0,0 +1,63 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// service desks a ticket can be opened in for an asset sent to service, an empty TICKET_SYSTEM opens none
+const (
+	TicketSystemJira       = "jira"
+	TicketSystemServiceNow = "servicenow"
+)
+
+// TicketStatusPending marks a service whose ticket still has to be opened, the sync job retries it
+const TicketStatusPending = "pending"
+
+var (
+	ErrTicketNotFound            = errors.New("ticket not found")
+	ErrTicketWebhookUnauthorized = errors.New("ticket webhook token doesn't match")
+)
+
+// TicketingConfig points at the service desk. Jira takes the issue project and type, ServiceNow the
+// table and the assignment group new tickets are queued to. The api token and the webhook token are
+// secrets
+type TicketingConfig struct {
+	System   string
+	URL      string
+	Username string
+	Timeout  time.Duration
+
+	JiraProject   string
+	JiraIssueType string
+
+	ServiceNowTable           string
+	ServiceNowAssignmentGroup string
+}
+
+// TicketReq is the ticket opened for a service
+type TicketReq struct {
+	Summary     string
+	Description string
+}
+
+// Ticket is a ticket as the service desk last reported it
+type Ticket struct {
+	Key      string
+	Status   string
+	Resolved bool
+}
+
+// ServiceTicket is a service whose ticket is still to be opened or not resolved yet
+type ServiceTicket struct {
+	ID        uuid.UUID `db:"id"`
+	AssetID   uuid.UUID `db:"asset_id"`
+	Brand     string    `db:"brand"`
+	Model     string    `db:"model"`
+	SerialNo  string    `db:"serial_no"`
+	Reason    string    `db:"reason"`
+	TicketKey *string   `db:"ticket_key"`
+	CreatedAt time.Time `db:"created_at"`
+}
@@ -21,4 +21,6 @@ type AssetTimelineEvent struct {
 	EndTime   *time.Time `json:"end_time,omitempty" db:"end_time"`
 	Details   string     `json:"details,omitempty" db:"details"`
 	AssetID   uuid.UUID  `json:"asset_id" db:"asset_id"`
+	// only on assigned events
+	Metadata *AssignmentMetadata `json:"metadata,omitempty" db:"metadata"`
 }
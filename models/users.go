@@ -13,7 +13,7 @@ type ManagerRegisterReq struct {
 	Username  string `json:"username" validate:"required"`
 	Email     string `json:"email" validate:"required,email"`
 	ContactNo string `json:"contact_no" validate:"required"`
-	Type      string `json:"type" validate:"required,oneof=full_time intern freelancer"`
+	Type      string `json:"type" validate:"required,employee_type"`
 }
 
 type EmployeeResponseModel struct {
@@ -0,0 +1,167 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UtilizationFilter is the window utilization is measured over, From inclusive and To exclusive
+type UtilizationFilter struct {
+	From   time.Time
+	To     time.Time
+	Type   string
+	Scope  DepartmentScope
+	Limit  int
+	Offset int
+}
+
+// AssetUtilization splits the days of the window an asset existed in into assigned, in service and
+// idle. Utilization is the share of them it was assigned
+type AssetUtilization struct {
+	AssetID       uuid.UUID `json:"asset_id" db:"asset_id"`
+	Brand         string    `json:"brand" db:"brand"`
+	Model         string    `json:"model" db:"model"`
+	SerialNo      string    `json:"serial_no" db:"serial_no"`
+	Type          string    `json:"type" db:"type"`
+	Status        string    `json:"status" db:"status"`
+	DaysTracked   float64   `json:"days_tracked" db:"days_tracked"`
+	DaysAssigned  float64   `json:"days_assigned" db:"days_assigned"`
+	DaysInService float64   `json:"days_in_service" db:"days_in_service"`
+	DaysIdle      float64   `json:"days_idle" db:"days_idle"`
+	Utilization   float64   `json:"utilization" db:"utilization"`
+}
+
+// TypeUtilization adds up AssetUtilization over the assets of a type
+type TypeUtilization struct {
+	Type          string  `json:"type" db:"type"`
+	Assets        int     `json:"assets" db:"assets"`
+	DaysTracked   float64 `json:"days_tracked" db:"days_tracked"`
+	DaysAssigned  float64 `json:"days_assigned" db:"days_assigned"`
+	DaysInService float64 `json:"days_in_service" db:"days_in_service"`
+	DaysIdle      float64 `json:"days_idle" db:"days_idle"`
+	Utilization   float64 `json:"utilization" db:"utilization"`
+}
+
+type UtilizationRes struct {
+	From   time.Time          `json:"from"`
+	To     time.Time          `json:"to"`
+	ByType []TypeUtilization  `json:"by_type"`
+	Assets []AssetUtilization `json:"assets"`
+}
+
+// ChurnFilter picks the assignments that started in the window From inclusive to To exclusive
+type ChurnFilter struct {
+	From  time.Time
+	To    time.Time
+	Type  string
+	Scope DepartmentScope
+}
+
+// AssignmentStats is how long assignments of an asset type or department lasted and how often its
+// assets changed hands. Durations only cover returned assignments, Reassignments counts every
+// assignment of an asset after its first one in the window
+type AssignmentStats struct {
+	Group                 string  `json:"group" db:"grp"`
+	Assignments           int     `json:"assignments" db:"assignments"`
+	Returned              int     `json:"returned" db:"returned"`
+	Assets                int     `json:"assets" db:"assets"`
+	AvgDays               float64 `json:"avg_days" db:"avg_days"`
+	MedianDays            float64 `json:"median_days" db:"median_days"`
+	Reassignments         int     `json:"reassignments" db:"reassignments"`
+	ReassignmentsPerAsset float64 `json:"reassignments_per_asset" db:"reassignments_per_asset"`
+}
+
+// ReturnReasonCount groups reasons case and space insensitively, an empty reason is a return
+// without one
+type ReturnReasonCount struct {
+	Reason string  `json:"reason" db:"reason"`
+	Count  int     `json:"count" db:"count"`
+	Share  float64 `json:"share" db:"share"`
+}
+
+type ChurnRes struct {
+	From          time.Time           `json:"from"`
+	To            time.Time           `json:"to"`
+	ByType        []AssignmentStats   `json:"by_type"`
+	ByDepartment  []AssignmentStats   `json:"by_department"`
+	ReturnReasons []ReturnReasonCount `json:"return_reasons"`
+}
+
+// ServiceReportFilter picks the services started in the window From inclusive to To exclusive, Top
+// is how many of the most serviced assets are listed
+type ServiceReportFilter struct {
+	From  time.Time
+	To    time.Time
+	Type  string
+	Top   int
+	Scope DepartmentScope
+}
+
+// AssetServiceStats is the servicing of one asset in the window. Days in service stop at the end of
+// the window, the mean time between services is the average gap between service starts and is left
+// out with fewer than two services. Cost adds up the services a cost was recorded for
+type AssetServiceStats struct {
+	AssetID             uuid.UUID `json:"asset_id" db:"asset_id"`
+	Brand               string    `json:"brand" db:"brand"`
+	Model               string    `json:"model" db:"model"`
+	SerialNo            string    `json:"serial_no" db:"serial_no"`
+	Type                string    `json:"type" db:"type"`
+	Services            int       `json:"services" db:"services"`
+	DaysInService       float64   `json:"days_in_service" db:"days_in_service"`
+	MeanDaysBetween     *float64  `json:"mean_days_between_services,omitempty" db:"mean_days_between"`
+	TotalCost           float64   `json:"total_cost" db:"total_cost"`
+	ServicesWithoutCost int       `json:"services_without_cost" db:"services_without_cost"`
+}
+
+// TypeServiceStats adds up AssetServiceStats over the serviced assets of a type, the mean time
+// between services is pooled over the assets serviced more than once
+type TypeServiceStats struct {
+	Type                string   `json:"type" db:"type"`
+	Assets              int      `json:"assets" db:"assets"`
+	Services            int      `json:"services" db:"services"`
+	DaysInService       float64  `json:"days_in_service" db:"days_in_service"`
+	MeanDaysBetween     *float64 `json:"mean_days_between_services,omitempty" db:"mean_days_between"`
+	TotalCost           float64  `json:"total_cost" db:"total_cost"`
+	ServicesWithoutCost int      `json:"services_without_cost" db:"services_without_cost"`
+}
+
+type ServiceReportRes struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// ByType counts every serviced asset, TopAssets only the most serviced ones
+	ByType    []TypeServiceStats  `json:"by_type"`
+	TopAssets []AssetServiceStats `json:"top_assets"`
+}
+
+const (
+	TrendDaily  = "day"
+	TrendWeekly = "week"
+)
+
+// TrendFilter buckets the events in the window From inclusive to To exclusive by Interval, a day or a
+// week starting on monday, both in UTC
+type TrendFilter struct {
+	From     time.Time
+	To       time.Time
+	Interval string
+	Type     string
+	Scope    DepartmentScope
+}
+
+// TrendPoint counts what happened in one bucket, buckets without events are there with zeros
+type TrendPoint struct {
+	Bucket          time.Time `json:"bucket" db:"bucket"`
+	AssetsAdded     int       `json:"assets_added" db:"assets_added"`
+	Assignments     int       `json:"assignments" db:"assignments"`
+	Returns         int       `json:"returns" db:"returns"`
+	ServicesStarted int       `json:"services_started" db:"services_started"`
+	ServicesEnded   int       `json:"services_ended" db:"services_ended"`
+}
+
+type TrendRes struct {
+	From     time.Time    `json:"from"`
+	To       time.Time    `json:"to"`
+	Interval string       `json:"interval"`
+	Points   []TrendPoint `json:"points"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// vendors whose warranty api can be asked, WARRANTY_LOOKUP lists the ones that are on. Apple has no
+// public warranty api, its GSX service is only open to authorized service providers
+const (
+	WarrantyVendorDell   = "dell"
+	WarrantyVendorLenovo = "lenovo"
+)
+
+var WarrantyVendors = []string{WarrantyVendorDell, WarrantyVendorLenovo}
+
+var (
+	ErrWarrantyNotFound          = errors.New("the vendor doesn't know the serial number")
+	ErrWarrantyVendorUnsupported = errors.New("no warranty lookup for the brand")
+)
+
+// WarrantyConfig points at the vendor apis. The dell client secret and the lenovo client id, which
+// lenovo hands out as a token, are secrets
+type WarrantyConfig struct {
+	Vendors      []string
+	DellURL      string
+	DellClientID string
+	LenovoURL    string
+	Timeout      time.Duration
+}
+
+// WarrantyInfo is the coverage a vendor reports for a serial number, from the start of the earliest
+// entitlement to the end of the latest. Response is the vendor's answer as it came
+type WarrantyInfo struct {
+	Vendor         string          `json:"vendor" db:"vendor"`
+	WarrantyStart  time.Time       `json:"warranty_start" db:"warranty_start"`
+	WarrantyExpire time.Time       `json:"warranty_expire" db:"warranty_expire"`
+	Response       json.RawMessage `json:"response" db:"response"`
+}
+
+// WarrantyLookup is the vendor answer kept for an asset whose warranty was looked up when it was added
+type WarrantyLookup struct {
+	AssetID uuid.UUID `json:"asset_id" db:"asset_id"`
+	WarrantyInfo
+	FetchedAt time.Time `json:"fetched_at" db:"fetched_at"`
+}
@@ -0,0 +1,177 @@
+// Package cacheprovider names the redis keys caching reads of a user, the misses of asset lookups
+// and the bodies of cached routes, and drops them when the user or what the route shows changes. Readers build their keys here and the services call the invalidation hooks after each
+// commit touching a user, so a key cannot be added without the mutations knowing about it
+package cacheprovider
+
+import (
+	"asset/providers"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// DashboardKey caches GetUserDashboardById: the user, their role, department and assigned assets
+func DashboardKey(userID uuid.UUID) string {
+	return fmt.Sprintf("user:dashboard:%s", userID.String())
+}
+
+// UserRoleKey caches GetUserRoleById
+func UserRoleKey(userID uuid.UUID) string {
+	return fmt.Sprintf("user:GetUserRoleById:%s", userID.String())
+}
+
+// UserEmailKey caches GetEmailByUserID
+func UserEmailKey(userID uuid.UUID) string {
+	return fmt.Sprintf("user:GetEmailByUserID:%s", userID.String())
+}
+
+// UserExistsKey caches IsUserExists, it is keyed by the address so the user id is not known on a miss
+func UserExistsKey(email string) string {
+	return fmt.Sprintf("user:IsUserExists:%s", email)
+}
+
+// UserByEmailNotFoundKey remembers that GetUserByEmail found no user for the address
+func UserByEmailNotFoundKey(email string) string {
+	return fmt.Sprintf("user:GetUserByEmail:notfound:%s", email)
+}
+
+// AssetNotFoundKey remembers that no live asset has the id. Ids are handed out by the insert, so
+// a created asset never has a cached miss, the entry only stops repeated probes of a wrong id
+func AssetNotFoundKey(assetID uuid.UUID) string {
+	return fmt.Sprintf("asset:notfound:%s", assetID.String())
+}
+
+// timelineVersionKey holds a token that is part of every timeline key of the user. The pages of a
+// timeline are keyed by limit and offset, deleting the token retires all of them at once
+func timelineVersionKey(userID uuid.UUID) string {
+	return fmt.Sprintf("user:GetUserTimeline:version:%s", userID.String())
+}
+
+// routes behind the response cache, the name is part of their keys
+const (
+	ResponseEnums      = "enums"
+	ResponseAssetTypes = "asset_types"
+	// ResponseDirectory is dropped with every user invalidation, it lists names, departments and
+	// designations of everyone
+	ResponseDirectory = "directory"
+)
+
+// responseVersionKey holds a token that is part of every key of the route, deleting it retires every
+// variant at once
+func responseVersionKey(route string) string {
+	return fmt.Sprintf("response:version:%s", route)
+}
+
+// versionToken reads the token stored at versionKey, or stores a new one when there is none. It has no
+// expiry, the entries keyed by an old token expire with their ttl
+func versionToken(ctx context.Context, redisProvider providers.RedisProvider, logger providers.ZapLoggerProvider, versionKey string) string {
+	version, err := redisProvider.Get(ctx, versionKey)
+	if err != nil || version == "" {
+		if err != nil && !errors.Is(err, redis.Nil) {
+			logger.GetLogger().Warn("failed to read cache version", zap.String("key", versionKey), zap.Error(err))
+		}
+		version = uuid.NewString()
+		if err := redisProvider.Set(ctx, versionKey, version, 0); err != nil {
+			logger.GetLogger().Warn("failed to store cache version", zap.String("key", versionKey), zap.Error(err))
+		}
+	}
+	return version
+}
+
+type userCacheProvider struct {
+	redis  providers.RedisProvider
+	logger providers.ZapLoggerProvider
+}
+
+func NewUserCacheProvider(redis providers.RedisProvider, logger providers.ZapLoggerProvider) providers.UserCacheProvider {
+	return &userCacheProvider{redis: redis, logger: logger}
+}
+
+// TimelineKey caches a page of GetUserTimeline. The version token is created on the first read after
+// an invalidation and has no expiry, the pages keyed by an old token expire with their ttl
+func (c *userCacheProvider) TimelineKey(ctx context.Context, userID uuid.UUID, limit, offset int) string {
+	version := versionToken(ctx, c.redis, c.logger, timelineVersionKey(userID))
+	return fmt.Sprintf("user:GetUserTimeline:%s:%s:%d:%d", userID.String(), version, limit, offset)
+}
+
+// InvalidateUsers drops every cached read of the users and the cached directory. Failures are only
+// logged, the entries then live until their ttl runs out
+func (c *userCacheProvider) InvalidateUsers(ctx context.Context, userIDs ...uuid.UUID) {
+	if len(userIDs) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(userIDs)*4+1)
+	for _, userID := range userIDs {
+		keys = append(keys, DashboardKey(userID), UserRoleKey(userID), UserEmailKey(userID), timelineVersionKey(userID))
+	}
+	keys = append(keys, responseVersionKey(ResponseDirectory))
+	if err := c.redis.Del(ctx, keys...); err != nil {
+		c.logger.GetLogger().Warn("failed to invalidate user cache", zap.Int("users", len(userIDs)), zap.Error(err))
+	}
+}
+
+// InvalidateEmails drops the cached lookups keyed by the addresses, a created user or a changed
+// address would be reported missing otherwise
+func (c *userCacheProvider) InvalidateEmails(ctx context.Context, emails ...string) {
+	if len(emails) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(emails)*2+1)
+	for _, email := range emails {
+		keys = append(keys, UserExistsKey(email), UserByEmailNotFoundKey(email))
+	}
+	// a created user joins the directory
+	keys = append(keys, responseVersionKey(ResponseDirectory))
+	if err := c.redis.Del(ctx, keys...); err != nil {
+		c.logger.GetLogger().Warn("failed to invalidate user cache by email", zap.Int("emails", len(emails)), zap.Error(err))
+	}
+}
+
+func (c *userCacheProvider) Entries(ctx context.Context, userID uuid.UUID, email string) (map[string]string, error) {
+	keys := []string{DashboardKey(userID), UserRoleKey(userID), UserEmailKey(userID), timelineVersionKey(userID),
+		UserExistsKey(email), UserByEmailNotFoundKey(email)}
+	values, err := c.redis.MGet(ctx, keys...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached entries: %w", err)
+	}
+	entries := make(map[string]string, len(keys))
+	for i, value := range values {
+		if value != "" {
+			entries[keys[i]] = value
+		}
+	}
+	return entries, nil
+}
+
+type responseCacheProvider struct {
+	redis  providers.RedisProvider
+	logger providers.ZapLoggerProvider
+}
+
+func NewResponseCacheProvider(redis providers.RedisProvider, logger providers.ZapLoggerProvider) providers.ResponseCacheProvider {
+	return &responseCacheProvider{redis: redis, logger: logger}
+}
+
+func (c *responseCacheProvider) Key(ctx context.Context, route, variant string) string {
+	version := versionToken(ctx, c.redis, c.logger, responseVersionKey(route))
+	return fmt.Sprintf("response:%s:%s:%s", route, version, variant)
+}
+
+// Invalidate drops every cached variant of the routes, failures are only logged like the user
+// invalidations
+func (c *responseCacheProvider) Invalidate(ctx context.Context, routes ...string) {
+	if len(routes) == 0 {
+		return
+	}
+	keys := make([]string, len(routes))
+	for i, route := range routes {
+		keys[i] = responseVersionKey(route)
+	}
+	if err := c.redis.Del(ctx, keys...); err != nil {
+		c.logger.GetLogger().Warn("failed to invalidate cached responses", zap.Strings("routes", routes), zap.Error(err))
+	}
+}
@@ -0,0 +1,41 @@
+package cacheprovider
+
+import (
+	"asset/models"
+	"expvar"
+	"time"
+)
+
+// cache reads per key class, read them from /debug/vars. The mean latency of a class is
+// cache_read_micros divided by its hits plus misses
+var (
+	cacheHits       = expvar.NewMap("cache_hits")
+	cacheMisses     = expvar.NewMap("cache_misses")
+	cacheReadMicros = expvar.NewMap("cache_read_micros")
+	// cache_rebuilds counts the misses that went to the database, cache_rebuilds_shared the requests
+	// served by a rebuild others were waiting on too. A cold key read by many requests at once
+	// shows up as one rebuild and many shared
+	cacheRebuilds       = expvar.NewMap("cache_rebuilds")
+	cacheRebuildsShared = expvar.NewMap("cache_rebuilds_shared")
+)
+
+// RecordRead counts one read of the class from redis
+func RecordRead(class models.CacheClass, hit bool, elapsed time.Duration) {
+	name := class.String()
+	if hit {
+		cacheHits.Add(name, 1)
+	} else {
+		cacheMisses.Add(name, 1)
+	}
+	cacheReadMicros.Add(name, elapsed.Microseconds())
+}
+
+// RecordRebuild counts a rebuild of the class, or with shared a request served by a rebuild
+// more than one request waited on
+func RecordRebuild(class models.CacheClass, shared bool) {
+	if shared {
+		cacheRebuildsShared.Add(class.String(), 1)
+		return
+	}
+	cacheRebuilds.Add(class.String(), 1)
+}
@@ -1,36 +1,1016 @@
 package configprovider
 
 import (
+	"asset/models"
 	"asset/providers"
+	"crypto/sha256"
 	"fmt"
-	"github.com/joho/godotenv"
+	"go.uber.org/zap/zapcore"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func NewConfigProvider() providers.ConfigProvider {
 	return &EnvConfigProvider{}
 }
 
+// LoadEnv reads the settings with the defaults of the APP_ENV profile and fails when the profile
+// is unknown or a setting it requires is missing, the server then refuses to start
 func (e *EnvConfigProvider) LoadEnv() error {
-	if err := godotenv.Load(); err != nil {
-		log.Println("Warning: .env file not loaded, using system envs")
-	}
+	e.loadConfigFile()
+	profile, profileErr := parseProfile()
+	e.profile = profile
+	profile.applyDefaults()
 
 	e.dbUser = os.Getenv("DB_USER")
-	e.dbPassword = os.Getenv("DB_PASSWORD")
 	e.dbHost = os.Getenv("DB_HOST")
 	e.dbPort = os.Getenv("DB_PORT")
 	e.dbName = os.Getenv("DB_NAME")
+	e.dbSSLMode = os.Getenv("DB_SSLMODE")
 	e.serverPort = os.Getenv("SERVER_PORT")
-	return nil
+	e.redisConfig = parseRedisConfig()
+	e.authMode = envOrDefault("AUTH_MODE", models.AuthModeFirebase)
+	// fake auth is for a laptop without accounts, redis then runs in memory unless REDIS_MODE says otherwise
+	if e.authMode == models.AuthModeFake && os.Getenv("REDIS_MODE") == "" {
+		e.redisConfig.Mode = models.RedisModeMemory
+	}
+	e.jsonCodec = envOrDefault("JSON_CODEC", models.JSONCodecJsoniter)
+	e.endDateWarningDays = 7
+	if days, err := strconv.Atoi(os.Getenv("END_DATE_WARNING_DAYS")); err == nil && days > 0 {
+		e.endDateWarningDays = days
+	}
+	e.inviteTTL = 72 * time.Hour
+	if hours, err := strconv.Atoi(os.Getenv("INVITE_TTL_HOURS")); err == nil && hours > 0 {
+		e.inviteTTL = time.Duration(hours) * time.Hour
+	}
+	e.appBaseURL = strings.TrimSuffix(os.Getenv("APP_BASE_URL"), "/")
+	e.organizationDomain = strings.ToLower(strings.Trim(os.Getenv("ORGANIZATION_DOMAIN"), "."))
+	e.requireEmailVerification, _ = strconv.ParseBool(os.Getenv("REQUIRE_EMAIL_VERIFICATION"))
+	e.allowedEmailDomains = parseEmailDomains(os.Getenv("ALLOWED_EMAIL_DOMAINS"))
+	e.defaultCountryCode = strings.TrimPrefix(os.Getenv("DEFAULT_COUNTRY_CODE"), "+")
+	if e.defaultCountryCode == "" {
+		e.defaultCountryCode = "91"
+	}
+	e.emailVerificationTTL = 24 * time.Hour
+	if hours, err := strconv.Atoi(os.Getenv("EMAIL_VERIFICATION_TTL_HOURS")); err == nil && hours > 0 {
+		e.emailVerificationTTL = time.Duration(hours) * time.Hour
+	}
+	e.mfaIssuer = os.Getenv("MFA_ISSUER")
+	if e.mfaIssuer == "" {
+		e.mfaIssuer = "Asset Manager"
+	}
+	// any string works as the key, it is hashed to 32 bytes, falling back to the jwt secret keeps local setups working
+	mfaKey := os.Getenv("MFA_ENCRYPTION_KEY")
+	if mfaKey == "" {
+		mfaKey = os.Getenv("SECRET_KEY")
+	}
+	sum := sha256.Sum256([]byte(mfaKey))
+	e.mfaEncryptionKey = sum[:]
+	e.oidcProviders = parseOIDCProviders(os.Getenv("OIDC_PROVIDERS"))
+	e.socialLogin = models.SocialLoginConfig{
+		MicrosoftClientID:  os.Getenv("MICROSOFT_CLIENT_ID"),
+		MicrosoftTenant:    os.Getenv("MICROSOFT_TENANT"),
+		GitHubClientID:     os.Getenv("GITHUB_CLIENT_ID"),
+		GitHubClientSecret: os.Getenv("GITHUB_CLIENT_SECRET"),
+	}
+	if e.socialLogin.GitHubClientID != "" && e.socialLogin.GitHubClientSecret == "" {
+		log.Printf("Warning: github login disabled, GITHUB_CLIENT_SECRET is required with GITHUB_CLIENT_ID")
+		e.socialLogin.GitHubClientID = ""
+	}
+	e.samlConfig, e.samlEnabled = parseSAMLConfig()
+	e.ldapConfig, e.ldapEnabled = parseLDAPConfig()
+	e.rateLimits = parseRateLimits()
+	e.trustProxyHeaders, _ = strconv.ParseBool(os.Getenv("TRUST_PROXY_HEADERS"))
+	e.lockoutPolicy = models.LockoutPolicy{
+		MaxFailures:      envInt("LOGIN_MAX_FAILURES", 5),
+		MaxFailuresPerIP: envInt("LOGIN_MAX_FAILURES_PER_IP", 20),
+		FailureWindow:    time.Duration(envInt("LOGIN_FAILURE_WINDOW_MINUTES", 15)) * time.Minute,
+		BaseLockout:      time.Duration(envInt("LOGIN_LOCKOUT_MINUTES", 1)) * time.Minute,
+		MaxLockout:       time.Duration(envInt("LOGIN_MAX_LOCKOUT_MINUTES", 60)) * time.Minute,
+		LockoutDecay:     24 * time.Hour,
+	}
+	e.jwtConfig = parseJWTConfig()
+	e.authEventRetention = time.Duration(envInt("AUTH_EVENT_RETENTION_DAYS", 90)) * 24 * time.Hour
+	e.authCookies = parseAuthCookieConfig()
+	e.secretsConfig = parseSecretsConfig()
+	e.eventBroker = parseEventBrokerConfig()
+	e.accessLog = parseAccessLogConfig()
+	e.debugAddr = os.Getenv("DEBUG_ADDR")
+	e.logConfig = parseLogConfig()
+	// REQUEST_TIMEOUT_* take durations like 10s or 2m
+	e.requestLimits = models.RequestLimits{
+		MaxBodyBytes: int64(envInt("REQUEST_MAX_BODY_BYTES", 1<<20)),
+		ReadTimeout:  envDuration("REQUEST_TIMEOUT_READ", 10*time.Second),
+		WriteTimeout: envDuration("REQUEST_TIMEOUT_WRITE", 30*time.Second),
+		LongTimeout:  envDuration("REQUEST_TIMEOUT_LONG", 90*time.Second),
+	}
+	e.jobsConfig = models.JobsConfig{
+		WarrantyAlertDays:       envInt("WARRANTY_ALERT_DAYS", 30),
+		ReturnOverdueDays:       envInt("RETURN_OVERDUE_DAYS", 7),
+		AckReminderDays:         parseReminderDays("ACK_REMINDER_DAYS", []int{1, 3, 7}),
+		JobRunRetention:         time.Duration(envInt("JOB_RUN_RETENTION_DAYS", 30)) * 24 * time.Hour,
+		DomainEventRetention:    time.Duration(envInt("DOMAIN_EVENT_RETENTION_DAYS", 7)) * 24 * time.Hour,
+		BulkWorkers:             envInt("BULK_WORKERS", 2),
+		BulkJobRetention:        time.Duration(envInt("BULK_JOB_RETENTION_DAYS", 7)) * 24 * time.Hour,
+		AssignmentHistoryMonths: envInt("ASSIGNMENT_HISTORY_MONTHS", 12),
+		LowStockThresholds:      parseLowStockThresholds(os.Getenv("LOW_STOCK_THRESHOLDS")),
+		Retention:               parseRetentionPolicy(),
+		BudgetAlertPercent:      envInt("BUDGET_ALERT_PERCENT", 80),
+		WaitlistClaimWindow:     time.Duration(envInt("WAITLIST_CLAIM_HOURS", 48)) * time.Hour,
+		// like 3=manager,7=admin
+		OverdueReturnEscalations:   parseEscalationSteps("ESCALATION_OVERDUE_RETURNS", "3=manager,7=admin"),
+		PendingApprovalEscalations: parseEscalationSteps("ESCALATION_PENDING_APPROVALS", "2=manager,5=admin"),
+	}
+	e.jobsConfig.IntegrityAutoRepair, _ = strconv.ParseBool(os.Getenv("INTEGRITY_AUTO_REPAIR"))
+	// SHUTDOWN_* take durations like 30s, the drain delay counts towards the timeout
+	e.cacheTTLs = parseCacheTTLs()
+	e.shutdown = models.ShutdownConfig{
+		Timeout:    envDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		DrainDelay: envDuration("SHUTDOWN_DRAIN_DELAY", 5*time.Second),
+	}
+	// DB_CONN_MAX_LIFETIME takes a duration like 30m, connections are recycled that often so they
+	// reconnect with a rotated password in good time
+	e.dbPool = models.DBPoolConfig{
+		MaxOpenConns:    envInt("DB_MAX_OPEN_CONNS", 25),
+		MaxIdleConns:    envInt("DB_MAX_IDLE_CONNS", 10),
+		ConnMaxLifetime: envDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+	}
+	if e.dbPool.MaxIdleConns > e.dbPool.MaxOpenConns {
+		log.Printf("Warning: DB_MAX_IDLE_CONNS %d is above DB_MAX_OPEN_CONNS, using %d", e.dbPool.MaxIdleConns, e.dbPool.MaxOpenConns)
+		e.dbPool.MaxIdleConns = e.dbPool.MaxOpenConns
+	}
+	// DB_SLOW_QUERY_THRESHOLD takes a duration like 500ms, slower statements are logged
+	e.queryStats = models.QueryStatsConfig{
+		SlowThreshold: envDuration("DB_SLOW_QUERY_THRESHOLD", 500*time.Millisecond),
+		MaxStatements: envInt("DB_QUERY_STATS_MAX", 200),
+	}
+	// FIREBASE_TIMEOUT and FIREBASE_BREAKER_COOLDOWN take durations like 5s
+	e.firebase = models.FirebaseConfig{
+		Timeout:         envDuration("FIREBASE_TIMEOUT", 5*time.Second),
+		MaxAttempts:     envInt("FIREBASE_MAX_ATTEMPTS", 3),
+		RetryBackoff:    envDuration("FIREBASE_RETRY_BACKOFF", 200*time.Millisecond),
+		BreakerFailures: envInt("FIREBASE_BREAKER_FAILURES", 5),
+		BreakerCooldown: envDuration("FIREBASE_BREAKER_COOLDOWN", 30*time.Second),
+	}
+	// MIGRATIONS_DIR is relative to the working directory
+	e.migrations = models.MigrationConfig{Dir: envOrDefault("MIGRATIONS_DIR", "database/migrations")}
+	e.migrations.OnStart, _ = strconv.ParseBool(os.Getenv("MIGRATE_ON_START"))
+	// SENTRY_ENVIRONMENT defaults to APP_ENV so issues can be filtered by deployment
+	e.errorReporting = models.ErrorReportingConfig{
+		DSN:         os.Getenv("SENTRY_DSN"),
+		Environment: envOrDefault("SENTRY_ENVIRONMENT", os.Getenv("APP_ENV")),
+		Release:     os.Getenv("SENTRY_RELEASE"),
+	}
+	e.slack = parseSlackConfig()
+	e.ticketing = parseTicketingConfig()
+	e.mdm = parseMDMConfig()
+	e.sms = parseSMSConfig()
+	e.imeiCheck = models.IMEICheckConfig{
+		Mode:      strings.ToLower(strings.TrimSpace(os.Getenv("IMEI_CHECK"))),
+		LookupURL: os.Getenv("IMEI_LOOKUP_URL"),
+		Timeout:   envDuration("IMEI_LOOKUP_TIMEOUT", 5*time.Second),
+	}
+	// a login is on the way of the lookup, the timeout is short and a failed lookup only loses the location
+	e.loginAnomaly = models.LoginAnomalyConfig{
+		GeoLookupURL:     os.Getenv("LOGIN_GEO_LOOKUP_URL"),
+		GeoLookupTimeout: envDuration("LOGIN_GEO_LOOKUP_TIMEOUT", 2*time.Second),
+		MaxTravelSpeed:   float64(envInt("LOGIN_MAX_TRAVEL_KMH", 900)),
+	}
+	e.loginAnomaly.NotifySecurity, _ = strconv.ParseBool(os.Getenv("LOGIN_ALERT_NOTIFY_SECURITY"))
+	e.warranty = parseWarrantyConfig()
+	e.objectStorage = parseObjectStorageConfig()
+	e.sheets = models.SheetsConfig{
+		Timeout: envDuration("SHEETS_TIMEOUT", 20*time.Second),
+		MaxRows: envInt("SHEETS_MAX_ROWS", 5000),
+	}
+	e.sheets.Enabled, _ = strconv.ParseBool(os.Getenv("SHEETS_SYNC_ENABLED"))
+	e.snapshot = models.SnapshotConfig{MaxArchiveBytes: int64(envInt("SNAPSHOT_MAX_ARCHIVE_MB", 512)) << 20}
+	e.snapshot.RestoreEnabled, _ = strconv.ParseBool(os.Getenv("SNAPSHOT_RESTORE_ENABLED"))
+	e.accounting = parseAccountingConfig()
+	e.procurement = models.ProcurementConfig{
+		System:   strings.ToLower(strings.TrimSpace(os.Getenv("PROCUREMENT_SYSTEM"))),
+		URL:      os.Getenv("PROCUREMENT_URL"),
+		ClientID: os.Getenv("PROCUREMENT_CLIENT_ID"),
+		Currency: envOrDefault("PROCUREMENT_CURRENCY", "USD"),
+		Timeout:  envDuration("PROCUREMENT_TIMEOUT", 30*time.Second),
+	}
+	e.esign = models.ESignConfig{
+		Provider:       strings.ToLower(strings.TrimSpace(os.Getenv("ESIGN_PROVIDER"))),
+		URL:            os.Getenv("ESIGN_URL"),
+		AccountID:      os.Getenv("ESIGN_ACCOUNT_ID"),
+		IntegrationKey: os.Getenv("ESIGN_INTEGRATION_KEY"),
+		UserID:         os.Getenv("ESIGN_USER_ID"),
+		AuthURL:        envOrDefault("ESIGN_AUTH_URL", "https://account.docusign.com"),
+		Timeout:        envDuration("ESIGN_TIMEOUT", 30*time.Second),
+	}
+	e.esign.TestMode, _ = strconv.ParseBool(os.Getenv("ESIGN_TEST_MODE"))
+	if sunset := os.Getenv("API_LEGACY_SUNSET"); sunset != "" {
+		parsed, err := time.Parse("2006-01-02", sunset)
+		if err != nil {
+			log.Printf("Warning: ignoring API_LEGACY_SUNSET=%q, expected YYYY-MM-DD", sunset)
+		} else {
+			e.legacyAPISunset = parsed
+		}
+	}
+	e.magicLinkPolicy = models.MagicLinkPolicy{
+		TTL:           time.Duration(envInt("MAGIC_LINK_TTL_MINUTES", 15)) * time.Minute,
+		MaxPerEmail:   envInt("MAGIC_LINK_MAX_PER_EMAIL", 5),
+		RequestWindow: time.Duration(envInt("MAGIC_LINK_WINDOW_MINUTES", 60)) * time.Minute,
+	}
+	if profileErr != nil {
+		return profileErr
+	}
+	return profile.validate()
+}
+
+// parseOIDCProviders reads OIDC_PROVIDERS=azure,okta and for each name the OIDC_<NAME>_* variables,
+// role maps look like OIDC_AZURE_ROLE_MAP=IT-Admins=admin,Stores=asset_manager
+func parseOIDCProviders(value string) []models.OIDCProviderConfig {
+	configs := make([]models.OIDCProviderConfig, 0)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		cfg := models.OIDCProviderConfig{
+			Name:      name,
+			Issuer:    strings.TrimSuffix(os.Getenv(prefix+"ISSUER"), "/"),
+			ClientID:  os.Getenv(prefix + "CLIENT_ID"),
+			RoleClaim: os.Getenv(prefix + "ROLE_CLAIM"),
+		}
+		cfg.TrustEmail, _ = strconv.ParseBool(os.Getenv(prefix + "TRUST_EMAIL"))
+		if cfg.Issuer == "" || cfg.ClientID == "" {
+			log.Printf("Warning: oidc provider %s skipped, %sISSUER and %sCLIENT_ID are required", name, prefix, prefix)
+			continue
+		}
+		cfg.RoleMappings = parseRoleMap(os.Getenv(prefix + "ROLE_MAP"))
+		configs = append(configs, cfg)
+	}
+	return configs
+}
+
+// parseSAMLConfig reads the SAML_* variables, saml login is off unless the idp sso url is set
+func parseSAMLConfig() (models.SAMLConfig, bool) {
+	cfg := models.SAMLConfig{
+		EntityID:       os.Getenv("SAML_SP_ENTITY_ID"),
+		ACSURL:         os.Getenv("SAML_ACS_URL"),
+		IDPEntityID:    os.Getenv("SAML_IDP_ENTITY_ID"),
+		IDPSSOURL:      os.Getenv("SAML_IDP_SSO_URL"),
+		IDPCertificate: os.Getenv("SAML_IDP_CERT"),
+		EmailAttribute: os.Getenv("SAML_EMAIL_ATTRIBUTE"),
+		NameAttribute:  os.Getenv("SAML_NAME_ATTRIBUTE"),
+		RoleAttribute:  os.Getenv("SAML_ROLE_ATTRIBUTE"),
+		RoleMappings:   parseRoleMap(os.Getenv("SAML_ROLE_MAP")),
+	}
+	if cfg.IDPSSOURL == "" {
+		return cfg, false
+	}
+	if cfg.EntityID == "" || cfg.ACSURL == "" || cfg.IDPEntityID == "" || cfg.IDPCertificate == "" {
+		log.Println("Warning: saml login disabled, SAML_SP_ENTITY_ID, SAML_ACS_URL, SAML_IDP_ENTITY_ID and SAML_IDP_CERT are required")
+		return cfg, false
+	}
+	return cfg, true
+}
+
+// parseLDAPConfig reads the LDAP_* variables, the sync is off unless LDAP_URL is set. Defaults suit openldap,
+// active directory wants LDAP_ID_ATTRIBUTE=objectGUID and LDAP_NAME_ATTRIBUTE=displayName
+func parseLDAPConfig() (models.LDAPConfig, bool) {
+	cfg := models.LDAPConfig{
+		URL:            strings.TrimSuffix(os.Getenv("LDAP_URL"), "/"),
+		BindDN:         os.Getenv("LDAP_BIND_DN"),
+		BindPassword:   os.Getenv("LDAP_BIND_PASSWORD"),
+		BaseDN:         os.Getenv("LDAP_BASE_DN"),
+		UserFilter:     envOrDefault("LDAP_USER_FILTER", "(&(objectClass=person)(mail=*))"),
+		IDAttribute:    envOrDefault("LDAP_ID_ATTRIBUTE", "entryUUID"),
+		EmailAttribute: envOrDefault("LDAP_EMAIL_ATTRIBUTE", "mail"),
+		NameAttribute:  envOrDefault("LDAP_NAME_ATTRIBUTE", "cn"),
+		GroupAttribute: envOrDefault("LDAP_GROUP_ATTRIBUTE", "memberOf"),
+		RoleMappings:   parseRoleMap(os.Getenv("LDAP_ROLE_MAP")),
+		SyncInterval:   time.Hour,
+	}
+	cfg.StartTLS, _ = strconv.ParseBool(os.Getenv("LDAP_START_TLS"))
+	cfg.DryRun, _ = strconv.ParseBool(os.Getenv("LDAP_SYNC_DRY_RUN"))
+	if minutes, err := strconv.Atoi(os.Getenv("LDAP_SYNC_INTERVAL_MINUTES")); err == nil && minutes > 0 {
+		cfg.SyncInterval = time.Duration(minutes) * time.Minute
+	}
+	if cfg.URL == "" {
+		return cfg, false
+	}
+	if cfg.BaseDN == "" {
+		log.Println("Warning: ldap sync disabled, LDAP_BASE_DN is required")
+		return cfg, false
+	}
+	return cfg, true
+}
+
+// parseJWTConfig reads JWT_ACCESS_TOKEN_TTL and JWT_REFRESH_TOKEN_TTL as durations like 15m or 168h,
+// and JWT_SIGNING_ALG, JWT_PRIVATE_KEY_FILE, JWT_PREVIOUS_KEY_FILES and the REFRESH_TOKEN key. Each can be overridden for one
+// environment by suffixing it with APP_ENV, e.g. JWT_ACCESS_TOKEN_TTL_STAGING.
+// To rotate, move the current key file to JWT_PREVIOUS_KEY_FILES, point JWT_PRIVATE_KEY_FILE at the
+// new key and restart, the old entry can go once the longest lived access token has expired
+func parseJWTConfig() models.JWTConfig {
+	cfg := models.JWTConfig{
+		AccessTokenTTL:  envDuration(envForAppEnv("JWT_ACCESS_TOKEN_TTL"), 5*time.Minute),
+		RefreshTokenTTL: envDuration(envForAppEnv("JWT_REFRESH_TOKEN_TTL"), 7*24*time.Hour),
+		Algorithm:       strings.ToUpper(envOrDefault(envForAppEnv("JWT_SIGNING_ALG"), "HS256")),
+		PrivateKeyFile:  os.Getenv(envForAppEnv("JWT_PRIVATE_KEY_FILE")),
+		RefreshKey:      []byte(os.Getenv(models.SecretJWTRefreshKey)),
+	}
+	for _, file := range strings.Split(os.Getenv(envForAppEnv("JWT_PREVIOUS_KEY_FILES")), ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			cfg.PreviousKeyFiles = append(cfg.PreviousKeyFiles, file)
+		}
+	}
+	return cfg
+}
+
+// envForAppEnv returns the APP_ENV specific name of key when that variable is set, key otherwise
+func envForAppEnv(key string) string {
+	appEnv := strings.ToUpper(strings.TrimSpace(os.Getenv("APP_ENV")))
+	if appEnv == "" {
+		return key
+	}
+	if _, ok := os.LookupEnv(key + "_" + appEnv); ok {
+		return key + "_" + appEnv
+	}
+	return key
+}
+
+// parseSecretsConfig reads SECRETS_BACKEND (env, vault or aws) and SECRETS_REFRESH_INTERVAL, plus
+// VAULT_ADDR, VAULT_TOKEN, VAULT_MOUNT and VAULT_SECRET_PATH for vault, or AWS_REGION, AWS_SECRET_ID
+// and the usual AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN for aws
+func parseSecretsConfig() models.SecretsConfig {
+	return models.SecretsConfig{
+		Backend:            strings.ToLower(strings.TrimSpace(os.Getenv("SECRETS_BACKEND"))),
+		RefreshInterval:    envDuration("SECRETS_REFRESH_INTERVAL", 5*time.Minute),
+		VaultAddr:          os.Getenv("VAULT_ADDR"),
+		VaultToken:         os.Getenv("VAULT_TOKEN"),
+		VaultMount:         envOrDefault("VAULT_MOUNT", "secret"),
+		VaultPath:          os.Getenv("VAULT_SECRET_PATH"),
+		AWSRegion:          os.Getenv("AWS_REGION"),
+		AWSSecretID:        os.Getenv("AWS_SECRET_ID"),
+		AWSAccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		AWSSecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		AWSSessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+// parseRedisConfig reads REDIS_MODE (single, sentinel, cluster or memory) and REDIS_ADDRS, a comma separated
+// list of host:port that falls back to REDIS_HOST:REDIS_PORT. The REDIS_*_TIMEOUT take durations like
+// 500ms or 3s
+func parseRedisConfig() models.RedisConfig {
+	cfg := models.RedisConfig{
+		Mode:             strings.ToLower(envOrDefault("REDIS_MODE", models.RedisModeSingle)),
+		MasterName:       os.Getenv("REDIS_MASTER_NAME"),
+		Username:         os.Getenv("REDIS_USERNAME"),
+		DB:               envInt("REDIS_DB", 0),
+		SentinelUsername: os.Getenv("REDIS_SENTINEL_USERNAME"),
+		SentinelPassword: os.Getenv("REDIS_SENTINEL_PASSWORD"),
+		TLSServerName:    os.Getenv("REDIS_TLS_SERVER_NAME"),
+		PoolSize:         envInt("REDIS_POOL_SIZE", 0),
+		MinIdleConns:     envInt("REDIS_MIN_IDLE_CONNS", 0),
+		PoolTimeout:      envDuration("REDIS_POOL_TIMEOUT", 0),
+		DialTimeout:      envDuration("REDIS_DIAL_TIMEOUT", 0),
+		ReadTimeout:      envDuration("REDIS_READ_TIMEOUT", 0),
+		WriteTimeout:     envDuration("REDIS_WRITE_TIMEOUT", 0),
+	}
+	cfg.TLS, _ = strconv.ParseBool(os.Getenv("REDIS_TLS"))
+	cfg.TLSInsecureSkipVerify, _ = strconv.ParseBool(os.Getenv("REDIS_TLS_INSECURE_SKIP_VERIFY"))
+	for _, addr := range strings.Split(os.Getenv("REDIS_ADDRS"), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			cfg.Addrs = append(cfg.Addrs, addr)
+		}
+	}
+	if len(cfg.Addrs) == 0 {
+		cfg.Addrs = []string{net.JoinHostPort(os.Getenv("REDIS_HOST"), os.Getenv("REDIS_PORT"))}
+	}
+	return cfg
+}
+
+// parseEventBrokerConfig reads EVENT_BROKER (kafka, nats or rabbitmq), EVENT_BROKER_URL, EVENT_TOPIC and
+// the optional EVENT_BROKER_USERNAME and EVENT_BROKER_PASSWORD. For kafka the url is the REST proxy, for
+// rabbitmq the management api, where EVENT_RABBITMQ_EXCHANGE and EVENT_RABBITMQ_VHOST pick the exchange
+func parseEventBrokerConfig() models.EventBrokerConfig {
+	return models.EventBrokerConfig{
+		Driver:           strings.ToLower(strings.TrimSpace(os.Getenv("EVENT_BROKER"))),
+		Topic:            envOrDefault("EVENT_TOPIC", "asset-manager.events"),
+		URL:              os.Getenv("EVENT_BROKER_URL"),
+		Username:         os.Getenv("EVENT_BROKER_USERNAME"),
+		Password:         os.Getenv("EVENT_BROKER_PASSWORD"),
+		RabbitMQExchange: envOrDefault("EVENT_RABBITMQ_EXCHANGE", "amq.topic"),
+		RabbitMQVHost:    envOrDefault("EVENT_RABBITMQ_VHOST", "/"),
+	}
+}
+
+// parseSlackConfig reads SLACK_ROUTE_<EVENT>=#it-assets,#procurement for every slack event and
+// SLACK_WEBHOOKS=#it-assets=https://hooks.slack.com/services/...,#ops=... for the channels posted to
+// through an incoming webhook. SLACK_TIMEOUT takes a duration like 5s
+func parseSlackConfig() models.SlackConfig {
+	cfg := models.SlackConfig{
+		Routes:   make(map[string][]string),
+		Webhooks: make(map[string]string),
+		Timeout:  envDuration("SLACK_TIMEOUT", 5*time.Second),
+	}
+	for _, event := range models.SlackEvents {
+		for _, channel := range strings.Split(os.Getenv("SLACK_ROUTE_"+strings.ToUpper(event)), ",") {
+			if channel = strings.TrimSpace(channel); channel != "" {
+				cfg.Routes[event] = append(cfg.Routes[event], channel)
+			}
+		}
+	}
+	for _, pair := range strings.Split(os.Getenv("SLACK_WEBHOOKS"), ",") {
+		channel, url, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || channel == "" || url == "" {
+			continue
+		}
+		cfg.Webhooks[channel] = url
+	}
+	return cfg
+}
+
+// parseTicketingConfig reads TICKET_SYSTEM (jira or servicenow), TICKET_URL, TICKET_USERNAME and
+// the TICKET_JIRA_* or TICKET_SERVICENOW_* settings of the system. TICKET_TIMEOUT takes a duration like 10s
+func parseTicketingConfig() models.TicketingConfig {
+	return models.TicketingConfig{
+		System:                    strings.ToLower(strings.TrimSpace(os.Getenv("TICKET_SYSTEM"))),
+		URL:                       os.Getenv("TICKET_URL"),
+		Username:                  os.Getenv("TICKET_USERNAME"),
+		Timeout:                   envDuration("TICKET_TIMEOUT", 10*time.Second),
+		JiraProject:               os.Getenv("TICKET_JIRA_PROJECT"),
+		JiraIssueType:             envOrDefault("TICKET_JIRA_ISSUE_TYPE", "Task"),
+		ServiceNowTable:           envOrDefault("TICKET_SERVICENOW_TABLE", "incident"),
+		ServiceNowAssignmentGroup: os.Getenv("TICKET_SERVICENOW_ASSIGNMENT_GROUP"),
+	}
+}
+
+// parseMDMConfig reads MDM_SYSTEM (intune or jamf), MDM_URL, MDM_TENANT_ID and MDM_CLIENT_ID. Intune
+// defaults to the graph api, Jamf needs the url of its instance
+func parseMDMConfig() models.MDMConfig {
+	system := strings.ToLower(strings.TrimSpace(os.Getenv("MDM_SYSTEM")))
+	url := os.Getenv("MDM_URL")
+	if url == "" && system == models.MDMSystemIntune {
+		url = "https://graph.microsoft.com"
+	}
+	return models.MDMConfig{
+		System:         system,
+		URL:            url,
+		TenantID:       os.Getenv("MDM_TENANT_ID"),
+		ClientID:       os.Getenv("MDM_CLIENT_ID"),
+		Timeout:        envDuration("MDM_TIMEOUT", 30*time.Second),
+		WarrantyMonths: envInt("MDM_WARRANTY_MONTHS", 12),
+	}
+}
+
+// parseWarrantyConfig reads WARRANTY_LOOKUP, a comma separated list of the vendors to ask (dell,
+// lenovo), and WARRANTY_DELL_CLIENT_ID. The api urls default to the vendors' production ones
+func parseWarrantyConfig() models.WarrantyConfig {
+	cfg := models.WarrantyConfig{
+		DellURL:      envOrDefault("WARRANTY_DELL_URL", "https://apigtwb2c.us.dell.com"),
+		DellClientID: os.Getenv("WARRANTY_DELL_CLIENT_ID"),
+		LenovoURL:    envOrDefault("WARRANTY_LENOVO_URL", "https://supportapi.lenovo.com"),
+		Timeout:      envDuration("WARRANTY_TIMEOUT", 10*time.Second),
+	}
+	for _, vendor := range strings.Split(os.Getenv("WARRANTY_LOOKUP"), ",") {
+		if vendor = strings.ToLower(strings.TrimSpace(vendor)); vendor != "" {
+			cfg.Vendors = append(cfg.Vendors, vendor)
+		}
+	}
+	return cfg
+}
+
+// parseSMSConfig reads SMS_PROVIDER (twilio or sns), SMS_ACCOUNT_ID, SMS_FROM and SMS_REGION for sns.
+// SMS_TEMPLATE_<NAME> replaces the text of a template, SMS_VERIFY_CONTACT_NO asks for a texted code
+// when an invite is accepted
+func parseSMSConfig() models.SMSConfig {
+	cfg := models.SMSConfig{
+		Provider:         strings.ToLower(strings.TrimSpace(os.Getenv("SMS_PROVIDER"))),
+		AccountID:        os.Getenv("SMS_ACCOUNT_ID"),
+		From:             os.Getenv("SMS_FROM"),
+		Region:           envOrDefault("SMS_REGION", os.Getenv("AWS_REGION")),
+		Timeout:          envDuration("SMS_TIMEOUT", 10*time.Second),
+		PerNumberPerHour: envInt("SMS_PER_NUMBER_PER_HOUR", 5),
+		Templates:        make(map[string]string),
+		OTPTTL:           envDuration("SMS_OTP_TTL", 10*time.Minute),
+	}
+	cfg.VerifyContactNo, _ = strconv.ParseBool(os.Getenv("SMS_VERIFY_CONTACT_NO"))
+	for _, name := range models.SMSTemplates {
+		if text := os.Getenv("SMS_TEMPLATE_" + strings.ToUpper(name)); text != "" {
+			cfg.Templates[name] = text
+		}
+	}
+	return cfg
+}
+
+// parseObjectStorageConfig reads OBJECT_STORAGE_BACKEND (s3, gcs or local), OBJECT_STORAGE_BUCKET,
+// OBJECT_STORAGE_REGION and OBJECT_STORAGE_ENDPOINT for s3 compatible gateways. The local backend
+// writes under OBJECT_STORAGE_DIR and links to OBJECT_STORAGE_PUBLIC_URL, this server by default
+func parseObjectStorageConfig() models.ObjectStorageConfig {
+	return models.ObjectStorageConfig{
+		Backend:        strings.ToLower(strings.TrimSpace(os.Getenv("OBJECT_STORAGE_BACKEND"))),
+		Bucket:         os.Getenv("OBJECT_STORAGE_BUCKET"),
+		Region:         envOrDefault("OBJECT_STORAGE_REGION", os.Getenv("AWS_REGION")),
+		Endpoint:       strings.TrimSuffix(os.Getenv("OBJECT_STORAGE_ENDPOINT"), "/"),
+		AccessKeyID:    os.Getenv("OBJECT_STORAGE_ACCESS_KEY_ID"),
+		Dir:            envOrDefault("OBJECT_STORAGE_DIR", "storage"),
+		PublicURL:      strings.TrimSuffix(envOrDefault("OBJECT_STORAGE_PUBLIC_URL", "http://localhost:"+os.Getenv("SERVER_PORT")), "/"),
+		Timeout:        envDuration("OBJECT_STORAGE_TIMEOUT", 30*time.Second),
+		URLTTL:         envDuration("OBJECT_STORAGE_URL_TTL", 15*time.Minute),
+		MaxUploadBytes: int64(envInt("OBJECT_STORAGE_MAX_UPLOAD_MB", 25)) << 20,
+	}
+}
+
+// parseAccountingConfig reads ACCOUNTING_CURRENCY, ACCOUNTING_USEFUL_LIFE_MONTHS, the
+// ACCOUNTING_ACCOUNT_* account names and ACCOUNTING_NETSUITE_SUBSIDIARY
+func parseAccountingConfig() models.AccountingConfig {
+	return models.AccountingConfig{
+		Currency:                envOrDefault("ACCOUNTING_CURRENCY", "INR"),
+		DefaultUsefulLifeMonths: envInt("ACCOUNTING_USEFUL_LIFE_MONTHS", 36),
+		NetSuiteSubsidiary:      os.Getenv("ACCOUNTING_NETSUITE_SUBSIDIARY"),
+		Accounts: models.LedgerAccounts{
+			FixedAssets:             envOrDefault("ACCOUNTING_ACCOUNT_FIXED_ASSETS", "Computer Equipment"),
+			AccumulatedDepreciation: envOrDefault("ACCOUNTING_ACCOUNT_ACCUMULATED_DEPRECIATION", "Accumulated Depreciation"),
+			DepreciationExpense:     envOrDefault("ACCOUNTING_ACCOUNT_DEPRECIATION_EXPENSE", "Depreciation Expense"),
+			Acquisition:             envOrDefault("ACCOUNTING_ACCOUNT_ACQUISITION", "Accounts Payable"),
+			DisposalLoss:            envOrDefault("ACCOUNTING_ACCOUNT_DISPOSAL_LOSS", "Loss on Disposal of Assets"),
+			DisposalGain:            envOrDefault("ACCOUNTING_ACCOUNT_DISPOSAL_GAIN", "Gain on Disposal of Assets"),
+			DisposalProceeds:        envOrDefault("ACCOUNTING_ACCOUNT_DISPOSAL_PROCEEDS", "Accounts Receivable"),
+		},
+	}
+}
+
+// parseLowStockThresholds reads type=count pairs like laptop=5,monitor=2
+func parseLowStockThresholds(value string) map[string]int {
+	thresholds := make(map[string]int)
+	for _, pair := range strings.Split(value, ",") {
+		assetType, count, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || assetType == "" {
+			continue
+		}
+		n, err := strconv.Atoi(count)
+		if err != nil || n <= 0 {
+			log.Printf("Warning: ignoring LOW_STOCK_THRESHOLDS entry %q, expected type=count", pair)
+			continue
+		}
+		thresholds[assetType] = n
+	}
+	return thresholds
+}
+
+// parseEscalationSteps reads steps like 3=manager,7=admin from key, def when it isn't set. Days must
+// ascend and each step tells manager or admin
+func parseEscalationSteps(key, def string) []models.EscalationStep {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		value = def
+	}
+	var steps []models.EscalationStep
+	for _, pair := range strings.Split(value, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		days, notify, _ := strings.Cut(strings.TrimSpace(pair), "=")
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 || (len(steps) > 0 && n <= steps[len(steps)-1].AfterDays) ||
+			(notify != models.EscalateManager && notify != models.EscalateAdmin) {
+			log.Printf("Warning: ignoring %s entry %q, expected ascending days=manager or days=admin", key, pair)
+			continue
+		}
+		steps = append(steps, models.EscalationStep{AfterDays: n, Notify: notify})
+	}
+	return steps
+}
+
+// parseReminderDays reads ascending days like 1,3,7 from key, def is used when it has none
+func parseReminderDays(key string, def []int) []int {
+	var days []int
+	for _, field := range strings.Split(os.Getenv(key), ",") {
+		if strings.TrimSpace(field) == "" {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || n <= 0 || (len(days) > 0 && n <= days[len(days)-1]) {
+			log.Printf("Warning: ignoring %s entry %q, expected ascending days", key, field)
+			continue
+		}
+		days = append(days, n)
+	}
+	if len(days) == 0 {
+		return def
+	}
+	return days
+}
+
+// parseRetentionPolicy reads RETENTION_ARCHIVED_USER_YEARS, RETENTION_ARCHIVED_USER_ACTION (anonymize
+// or delete), RETENTION_AUDIT_LOG_YEARS, RETENTION_CLOSED_ASSIGNMENT_YEARS and RETENTION_DRY_RUN.
+// Nothing is purged unless a number of years is set
+func parseRetentionPolicy() models.RetentionPolicy {
+	policy := models.RetentionPolicy{
+		ArchivedUserYears:     envInt("RETENTION_ARCHIVED_USER_YEARS", 0),
+		ArchivedUserAction:    models.RetentionAnonymize,
+		AuditLogYears:         envInt("RETENTION_AUDIT_LOG_YEARS", 0),
+		ClosedAssignmentYears: envInt("RETENTION_CLOSED_ASSIGNMENT_YEARS", 0),
+	}
+	switch action := strings.ToLower(os.Getenv("RETENTION_ARCHIVED_USER_ACTION")); action {
+	case "", models.RetentionAnonymize:
+	case models.RetentionDelete:
+		policy.ArchivedUserAction = action
+	default:
+		log.Printf("Warning: unknown RETENTION_ARCHIVED_USER_ACTION %q, anonymizing instead", action)
+	}
+	policy.DryRun, _ = strconv.ParseBool(os.Getenv("RETENTION_DRY_RUN"))
+	return policy
+}
+
+// parseLogConfig reads LOG_FORMAT (json or console), LOG_LEVEL, LOG_FILE, LOG_STDOUT, LOG_MAX_SIZE_MB,
+// LOG_MAX_AGE_DAYS and LOG_MAX_BACKUPS, each can be overridden per APP_ENV like the jwt settings.
+// The profile defaults to json at info in staging and production and to console at debug otherwise
+func parseLogConfig() models.LogConfig {
+	cfg := models.LogConfig{Format: models.LogFormatConsole, Level: zapcore.DebugLevel, Stdout: true}
+	switch format := strings.ToLower(os.Getenv(envForAppEnv("LOG_FORMAT"))); format {
+	case "":
+	case models.LogFormatJSON, models.LogFormatConsole:
+		cfg.Format = format
+	default:
+		log.Printf("Warning: invalid LOG_FORMAT %q, using %s", format, cfg.Format)
+	}
+	if level := os.Getenv(envForAppEnv("LOG_LEVEL")); level != "" {
+		if err := cfg.Level.UnmarshalText([]byte(strings.ToLower(level))); err != nil {
+			log.Printf("Warning: invalid LOG_LEVEL %q, using %s", level, cfg.Level)
+		}
+	}
+	cfg.File = os.Getenv(envForAppEnv("LOG_FILE"))
+	if stdout, err := strconv.ParseBool(os.Getenv(envForAppEnv("LOG_STDOUT"))); err == nil {
+		cfg.Stdout = stdout
+	}
+	cfg.MaxSizeMB = envInt(envForAppEnv("LOG_MAX_SIZE_MB"), 100)
+	cfg.MaxAgeDays = envInt(envForAppEnv("LOG_MAX_AGE_DAYS"), 14)
+	cfg.MaxBackups = envInt(envForAppEnv("LOG_MAX_BACKUPS"), 10)
+	return cfg
+}
+
+// parseAccessLogConfig reads ACCESS_LOG_LEVEL (debug, info, warn, error or off), ACCESS_LOG_SAMPLE_FIRST
+// and ACCESS_LOG_SAMPLE_THEREAFTER, a first of 0 turns sampling off. Failed requests are never sampled
+func parseAccessLogConfig() models.AccessLogConfig {
+	cfg := models.AccessLogConfig{Enabled: true, Level: zapcore.InfoLevel, SampleFirst: 100, SampleThereafter: 10}
+	switch level := strings.ToLower(strings.TrimSpace(os.Getenv("ACCESS_LOG_LEVEL"))); level {
+	case "":
+	case "off":
+		cfg.Enabled = false
+	default:
+		if err := cfg.Level.UnmarshalText([]byte(level)); err != nil {
+			log.Printf("Warning: invalid ACCESS_LOG_LEVEL %q, using info", level)
+			cfg.Level = zapcore.InfoLevel
+		}
+	}
+	if value, err := strconv.Atoi(os.Getenv("ACCESS_LOG_SAMPLE_FIRST")); err == nil && value >= 0 {
+		cfg.SampleFirst = value
+	}
+	cfg.SampleThereafter = envInt("ACCESS_LOG_SAMPLE_THEREAFTER", cfg.SampleThereafter)
+	return cfg
+}
+
+// parseAuthCookieConfig reads AUTH_COOKIE_MODE, AUTH_COOKIE_DOMAIN, AUTH_COOKIE_SECURE (on unless set to false)
+// and AUTH_COOKIE_SAMESITE (lax, strict or none), none is only allowed with secure cookies
+func parseAuthCookieConfig() models.AuthCookieConfig {
+	cfg := models.AuthCookieConfig{
+		Domain:   os.Getenv("AUTH_COOKIE_DOMAIN"),
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	cfg.Enabled, _ = strconv.ParseBool(os.Getenv("AUTH_COOKIE_MODE"))
+	if secure, err := strconv.ParseBool(os.Getenv("AUTH_COOKIE_SECURE")); err == nil {
+		cfg.Secure = secure
+	}
+	switch strings.ToLower(os.Getenv("AUTH_COOKIE_SAMESITE")) {
+	case "strict":
+		cfg.SameSite = http.SameSiteStrictMode
+	case "none":
+		cfg.SameSite = http.SameSiteNoneMode
+		if !cfg.Secure {
+			log.Println("Warning: AUTH_COOKIE_SAMESITE=none needs secure cookies, AUTH_COOKIE_SECURE is ignored")
+			cfg.Secure = true
+		}
+	}
+	return cfg
+}
+
+func parseRateLimits() models.RateLimitConfig {
+	return models.RateLimitConfig{
+		Auth:   parseRateLimit("RATE_LIMIT_AUTH", 10),
+		IP:     parseRateLimit("RATE_LIMIT_IP", 300),
+		User:   parseRateLimit("RATE_LIMIT_USER", 120),
+		APIKey: parseRateLimit("RATE_LIMIT_API_KEY", 60),
+		// unset leaves keys without a quota
+		APIKeyMonthlyQuota: envInt("API_KEY_MONTHLY_QUOTA", 0),
+	}
+}
+
+// parseRateLimit reads <PREFIX>_PER_MINUTE and <PREFIX>_BURST, a per minute of 0 turns the limit off
+func parseRateLimit(prefix string, defaultPerMinute int) models.RateLimit {
+	limit := models.RateLimit{PerMinute: defaultPerMinute}
+	if value, err := strconv.Atoi(os.Getenv(prefix + "_PER_MINUTE")); err == nil && value >= 0 {
+		limit.PerMinute = value
+	}
+	limit.Burst = limit.PerMinute
+	if value, err := strconv.Atoi(os.Getenv(prefix + "_BURST")); err == nil && value > 0 {
+		limit.Burst = value
+	}
+	return limit
+}
+
+func envInt(key string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Printf("Warning: invalid %s %q, using %s", key, value, fallback)
+		return fallback
+	}
+	return duration
+}
+
+func envOrDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// parseRoleMap reads claim=role pairs separated by commas
+func parseRoleMap(value string) []models.OIDCRoleMapping {
+	var mappings []models.OIDCRoleMapping
+	for _, pair := range strings.Split(value, ",") {
+		claimValue, role, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || claimValue == "" || role == "" {
+			continue
+		}
+		mappings = append(mappings, models.OIDCRoleMapping{ClaimValue: claimValue, Role: role})
+	}
+	return mappings
+}
+
+// parseEmailDomains reads a comma separated list, unset keeps the original remotestate.com only rule and * allows any domain
+func parseEmailDomains(value string) []string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return []string{"remotestate.com"}
+	}
+	if value == "*" {
+		return nil
+	}
+	domains := make([]string, 0)
+	for _, domain := range strings.Split(value, ",") {
+		if domain = strings.ToLower(strings.TrimSpace(domain)); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	return domains
 }
 
 func (e *EnvConfigProvider) GetServerPort() string {
 	return e.serverPort
 }
 
+// GetDatabaseString leaves the password out, the database provider asks the secrets provider for it
+// on every new connection so a rotated password is picked up without a restart
 func (e *EnvConfigProvider) GetDatabaseString() string {
-	return fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=disable",
-		e.dbUser, e.dbPassword, e.dbHost, e.dbPort, e.dbName)
+	return fmt.Sprintf("user=%s host=%s port=%s dbname=%s sslmode=%s",
+		e.dbUser, e.dbHost, e.dbPort, e.dbName, e.dbSSLMode)
+}
+
+func (e *EnvConfigProvider) GetProfile() string {
+	return e.profile.name
+}
+
+// GetJSONCodec is jsoniter or std, the codec request and response bodies and cached values go through
+func (e *EnvConfigProvider) GetJSONCodec() string {
+	return e.jsonCodec
+}
+
+// GetAuthMode is firebase or fake, validation keeps fake to the development profile
+func (e *EnvConfigProvider) GetAuthMode() string {
+	return e.authMode
+}
+
+func (e *EnvConfigProvider) GetRedisConfig() models.RedisConfig {
+	return e.redisConfig
+}
+
+func (e *EnvConfigProvider) GetEndDateWarningDays() int {
+	return e.endDateWarningDays
+}
+
+func (e *EnvConfigProvider) GetInviteTTL() time.Duration {
+	return e.inviteTTL
+}
+
+func (e *EnvConfigProvider) GetAppBaseURL() string {
+	return e.appBaseURL
+}
+
+func (e *EnvConfigProvider) GetOrganizationDomain() string {
+	return e.organizationDomain
+}
+
+func (e *EnvConfigProvider) RequireEmailVerification() bool {
+	return e.requireEmailVerification
+}
+
+func (e *EnvConfigProvider) GetEmailVerificationTTL() time.Duration {
+	return e.emailVerificationTTL
+}
+
+func (e *EnvConfigProvider) GetAllowedEmailDomains() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.allowedEmailDomains
+}
+
+func (e *EnvConfigProvider) GetDefaultCountryCode() string {
+	return e.defaultCountryCode
+}
+
+func (e *EnvConfigProvider) GetMFAIssuer() string {
+	return e.mfaIssuer
+}
+
+func (e *EnvConfigProvider) GetMFAEncryptionKey() []byte {
+	return e.mfaEncryptionKey
+}
+
+func (e *EnvConfigProvider) GetOIDCProviders() []models.OIDCProviderConfig {
+	return e.oidcProviders
+}
+
+func (e *EnvConfigProvider) GetSocialLoginConfig() models.SocialLoginConfig {
+	return e.socialLogin
+}
+
+func (e *EnvConfigProvider) GetSAMLConfig() (models.SAMLConfig, bool) {
+	return e.samlConfig, e.samlEnabled
+}
+
+func (e *EnvConfigProvider) GetLDAPConfig() (models.LDAPConfig, bool) {
+	return e.ldapConfig, e.ldapEnabled
+}
+
+func (e *EnvConfigProvider) GetRateLimits() models.RateLimitConfig {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.rateLimits
+}
+
+func (e *EnvConfigProvider) TrustProxyHeaders() bool {
+	return e.trustProxyHeaders
+}
+
+func (e *EnvConfigProvider) GetLockoutPolicy() models.LockoutPolicy {
+	return e.lockoutPolicy
+}
+
+func (e *EnvConfigProvider) GetMagicLinkPolicy() models.MagicLinkPolicy {
+	return e.magicLinkPolicy
+}
+
+func (e *EnvConfigProvider) GetJWTConfig() models.JWTConfig {
+	return e.jwtConfig
+}
+
+func (e *EnvConfigProvider) GetAuthEventRetention() time.Duration {
+	return e.authEventRetention
+}
+
+func (e *EnvConfigProvider) GetAuthCookieConfig() models.AuthCookieConfig {
+	return e.authCookies
+}
+
+func (e *EnvConfigProvider) GetSecretsConfig() models.SecretsConfig {
+	return e.secretsConfig
+}
+
+func (e *EnvConfigProvider) GetLegacyAPISunset() time.Time {
+	return e.legacyAPISunset
+}
+
+func (e *EnvConfigProvider) GetEventBrokerConfig() models.EventBrokerConfig {
+	return e.eventBroker
+}
+
+func (e *EnvConfigProvider) GetAccessLogConfig() models.AccessLogConfig {
+	return e.accessLog
+}
+
+func (e *EnvConfigProvider) GetDebugAddr() string {
+	return e.debugAddr
+}
+
+func (e *EnvConfigProvider) GetLogConfig() models.LogConfig {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.logConfig
+}
+
+func (e *EnvConfigProvider) GetErrorReportingConfig() models.ErrorReportingConfig {
+	return e.errorReporting
+}
+
+func (e *EnvConfigProvider) GetSlackConfig() models.SlackConfig {
+	return e.slack
+}
+
+func (e *EnvConfigProvider) GetTicketingConfig() models.TicketingConfig {
+	return e.ticketing
+}
+
+func (e *EnvConfigProvider) GetMDMConfig() models.MDMConfig {
+	return e.mdm
+}
+
+func (e *EnvConfigProvider) GetSMSConfig() models.SMSConfig {
+	return e.sms
+}
+
+func (e *EnvConfigProvider) GetIMEICheckConfig() models.IMEICheckConfig {
+	return e.imeiCheck
+}
+
+func (e *EnvConfigProvider) GetLoginAnomalyConfig() models.LoginAnomalyConfig {
+	return e.loginAnomaly
+}
+
+func (e *EnvConfigProvider) GetWarrantyConfig() models.WarrantyConfig {
+	return e.warranty
+}
+
+func (e *EnvConfigProvider) GetObjectStorageConfig() models.ObjectStorageConfig {
+	return e.objectStorage
+}
+
+func (e *EnvConfigProvider) GetProcurementConfig() models.ProcurementConfig {
+	return e.procurement
+}
+
+func (e *EnvConfigProvider) GetESignConfig() models.ESignConfig {
+	return e.esign
+}
+
+func (e *EnvConfigProvider) GetSheetsConfig() models.SheetsConfig {
+	return e.sheets
+}
+
+func (e *EnvConfigProvider) GetSnapshotConfig() models.SnapshotConfig {
+	return e.snapshot
+}
+
+func (e *EnvConfigProvider) GetAccountingConfig() models.AccountingConfig {
+	return e.accounting
+}
+
+func (e *EnvConfigProvider) GetRequestLimits() models.RequestLimits {
+	return e.requestLimits
+}
+
+func (e *EnvConfigProvider) GetJobsConfig() models.JobsConfig {
+	return e.jobsConfig
+}
+
+func (e *EnvConfigProvider) GetShutdownConfig() models.ShutdownConfig {
+	return e.shutdown
+}
+
+func (e *EnvConfigProvider) GetDBPoolConfig() models.DBPoolConfig {
+	return e.dbPool
+}
+
+func (e *EnvConfigProvider) GetQueryStatsConfig() models.QueryStatsConfig {
+	return e.queryStats
+}
+
+func (e *EnvConfigProvider) GetFirebaseConfig() models.FirebaseConfig {
+	return e.firebase
+}
+
+func (e *EnvConfigProvider) GetMigrationConfig() models.MigrationConfig {
+	return e.migrations
+}
+
+func (e *EnvConfigProvider) GetCacheTTLs() models.CacheTTLs {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.cacheTTLs
 }
@@ -1,10 +1,118 @@
 package configprovider
 
+import (
+	"asset/models"
+	"sync"
+	"time"
+)
+
+// EnvConfigProvider reads its settings once in LoadEnv. The runtime settings, the log level, rate
+// limits, cache ttls and allowed email domains, are replaced by Reload and read under mu
 type EnvConfigProvider struct {
-	dbUser     string
-	dbPassword string
-	dbHost     string
-	dbPort     string
-	dbName     string
-	serverPort string
+	mu sync.RWMutex
+	// the env file LoadEnv read, CONFIG_FILE or .env, and its modification time then
+	configFile    string
+	configModTime time.Time
+	// variables the process was started with, they win over the file on every reload
+	processEnv map[string]bool
+	// variables the file set, a reload unsets those the file no longer has
+	fileKeys map[string]bool
+	dbUser   string
+	dbHost   string
+	dbPort   string
+	dbName   string
+	// disable, require, verify-full... defaulted by the profile
+	dbSSLMode   string
+	serverPort  string
+	redisConfig models.RedisConfig
+	// jsoniter, or std for encoding/json
+	jsonCodec string
+	// firebase, or fake to sign in by email and only log emails
+	authMode string
+	// the APP_ENV profile whose defaults filled the unset variables
+	profile profile
+	// days before an employee end date that managers get warned
+	endDateWarningDays int
+	// how long an employee invite link stays valid
+	inviteTTL time.Duration
+	// frontend url used to build links sent by email
+	appBaseURL string
+	// requests on <slug>.<organizationDomain> belong to that organization, empty turns subdomains off
+	organizationDomain string
+	// blocks login for users who haven't confirmed their email
+	requireEmailVerification bool
+	emailVerificationTTL     time.Duration
+	// domains that may self register, empty allows any domain
+	allowedEmailDomains []string
+	// country calling code assumed for contact numbers entered without one
+	defaultCountryCode string
+	// name shown in authenticator apps
+	mfaIssuer string
+	// aes key for totp secrets at rest
+	mfaEncryptionKey []byte
+	// extra oidc issuers accepted for login next to google
+	oidcProviders []models.OIDCProviderConfig
+	// microsoft and github sign in on the v2 login
+	socialLogin models.SocialLoginConfig
+	// saml idp for sso, only used when samlEnabled
+	samlConfig  models.SAMLConfig
+	samlEnabled bool
+	// directory synced on a schedule, only used when ldapEnabled
+	ldapConfig  models.LDAPConfig
+	ldapEnabled bool
+	rateLimits  models.RateLimitConfig
+	// take the client ip from X-Forwarded-For, only safe behind a proxy that sets it
+	trustProxyHeaders bool
+	lockoutPolicy     models.LockoutPolicy
+	magicLinkPolicy   models.MagicLinkPolicy
+	jwtConfig         models.JWTConfig
+	// how long auth_events rows are kept
+	authEventRetention time.Duration
+	authCookies        models.AuthCookieConfig
+	// where database, jwt and firebase credentials are read from
+	secretsConfig models.SecretsConfig
+	// date the unversioned /api paths stop answering, zero when none is announced
+	legacyAPISunset time.Time
+	// broker domain events are published to, publishing is off when its driver is empty
+	eventBroker models.EventBrokerConfig
+	accessLog   models.AccessLogConfig
+	// separate listener for /debug, e.g. 127.0.0.1:6060, off when empty
+	debugAddr string
+	logConfig models.LogConfig
+	// sentry project 5xx responses, panics and failed jobs are reported to, off without a dsn
+	errorReporting models.ErrorReportingConfig
+	// channels asset manager alerts are posted to, off when no event is routed
+	slack models.SlackConfig
+	// service desk tickets are opened in for assets sent to service, off without TICKET_SYSTEM
+	ticketing models.TicketingConfig
+	// device management system assets are discovered from, off without MDM_SYSTEM
+	mdm models.MDMConfig
+	// gateway otp codes and urgent alerts are texted through, off without SMS_PROVIDER
+	sms models.SMSConfig
+	// blacklist check of mobile imeis at intake, off without IMEI_CHECK
+	imeiCheck models.IMEICheckConfig
+	// how logins are compared to earlier ones, locations need LOGIN_GEO_LOOKUP_URL
+	loginAnomaly models.LoginAnomalyConfig
+	// vendor apis warranties are looked up in by serial number, off without WARRANTY_LOOKUP
+	warranty models.WarrantyConfig
+	// bucket attachments and export results are kept in, off without OBJECT_STORAGE_BACKEND
+	objectStorage models.ObjectStorageConfig
+	// google sheets kept in sync with asset filters, off unless SHEETS_SYNC_ENABLED
+	sheets models.SheetsConfig
+	// restore of snapshot archives, off unless SNAPSHOT_RESTORE_ENABLED
+	snapshot models.SnapshotConfig
+	// e-procurement system approved purchase orders are pushed to, off without PROCUREMENT_SYSTEM
+	procurement models.ProcurementConfig
+	// e-signature service handover and return documents are signed through, off without ESIGN_PROVIDER
+	esign models.ESignConfig
+	// ledger accounts and depreciation of the fixed asset journal export
+	accounting    models.AccountingConfig
+	requestLimits models.RequestLimits
+	jobsConfig    models.JobsConfig
+	shutdown      models.ShutdownConfig
+	dbPool        models.DBPoolConfig
+	queryStats    models.QueryStatsConfig
+	migrations    models.MigrationConfig
+	cacheTTLs     models.CacheTTLs
+	firebase      models.FirebaseConfig
 }
@@ -0,0 +1,182 @@
+package configprovider
+
+import (
+	"asset/models"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+)
+
+// profile is the set of defaults APP_ENV selects, a variable that is set always wins over them.
+// Required lists the variables the profile has no usable default for
+type profile struct {
+	name     string
+	defaults map[string]string
+	required []string
+}
+
+// defaults every profile shares
+var commonDefaults = map[string]string{
+	"SERVER_PORT": "8080",
+	"DB_PORT":     "5432",
+	"REDIS_PORT":  "6379",
+}
+
+var profiles = map[string]profile{
+	// services on the local machine over plain http
+	models.ProfileDevelopment: {
+		name: models.ProfileDevelopment,
+		defaults: map[string]string{
+			"DB_HOST":            "localhost",
+			"DB_SSLMODE":         "disable",
+			"REDIS_HOST":         "localhost",
+			"AUTH_COOKIE_SECURE": "false",
+			"LOG_FORMAT":         models.LogFormatConsole,
+			"LOG_LEVEL":          "debug",
+			// a local database follows the checked out migrations, shared ones are migrated before a deploy
+			"MIGRATE_ON_START": "true",
+			// attachments and exports land in ./storage, served by the api itself
+			"OBJECT_STORAGE_BACKEND": models.ObjectStorageLocal,
+		},
+		required: []string{"DB_USER", "DB_NAME"},
+	},
+	models.ProfileStaging: {
+		name: models.ProfileStaging,
+		defaults: map[string]string{
+			"DB_SSLMODE":                 "require",
+			"REQUIRE_EMAIL_VERIFICATION": "true",
+			"LOG_FORMAT":                 models.LogFormatJSON,
+			"LOG_LEVEL":                  "info",
+		},
+		required: []string{"DB_HOST", "DB_USER", "DB_NAME", "REDIS_HOST", "APP_BASE_URL"},
+	},
+	models.ProfileProduction: {
+		name: models.ProfileProduction,
+		defaults: map[string]string{
+			"DB_SSLMODE":                 "require",
+			"REQUIRE_EMAIL_VERIFICATION": "true",
+			"LOG_FORMAT":                 models.LogFormatJSON,
+			"LOG_LEVEL":                  "info",
+		},
+		required: []string{"DB_HOST", "DB_USER", "DB_NAME", "REDIS_HOST", "APP_BASE_URL"},
+	},
+}
+
+// profileNames maps the APP_ENV values in use to their profile, an unset APP_ENV is development
+var profileNames = map[string]string{
+	"":            models.ProfileDevelopment,
+	"dev":         models.ProfileDevelopment,
+	"development": models.ProfileDevelopment,
+	"local":       models.ProfileDevelopment,
+	"stage":       models.ProfileStaging,
+	"staging":     models.ProfileStaging,
+	"prod":        models.ProfileProduction,
+	"production":  models.ProfileProduction,
+}
+
+func parseProfile() (profile, error) {
+	appEnv := strings.ToLower(strings.TrimSpace(os.Getenv("APP_ENV")))
+	name, ok := profileNames[appEnv]
+	if !ok {
+		return profiles[models.ProfileDevelopment], fmt.Errorf("APP_ENV %q is not a profile, use development, staging or production", appEnv)
+	}
+	return profiles[name], nil
+}
+
+// applyDefaults sets the profile's defaults for the variables that are unset, they then read like
+// any other setting
+func (p profile) applyDefaults() {
+	for _, defaults := range []map[string]string{commonDefaults, p.defaults} {
+		for key, value := range defaults {
+			if os.Getenv(key) == "" {
+				os.Setenv(key, value)
+			}
+		}
+	}
+}
+
+// validate reports every missing or invalid setting at once, so a bad deploy is fixed in one go
+func (p profile) validate() error {
+	var problems []string
+	for _, key := range p.required {
+		if strings.TrimSpace(os.Getenv(key)) == "" {
+			problems = append(problems, fmt.Sprintf("%s is required in %s", key, p.name))
+		}
+	}
+	if baseURL := os.Getenv("APP_BASE_URL"); baseURL != "" {
+		if parsed, err := url.Parse(baseURL); err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			problems = append(problems, fmt.Sprintf("APP_BASE_URL %q is not an absolute url", baseURL))
+		}
+	}
+	// instances would each keep their own job claims, rate limits and sessions
+	if mode := strings.ToLower(os.Getenv("REDIS_MODE")); mode == models.RedisModeMemory && p.name != models.ProfileDevelopment {
+		problems = append(problems, fmt.Sprintf("REDIS_MODE %s is only allowed in %s", mode, models.ProfileDevelopment))
+	}
+	switch system := strings.ToLower(strings.TrimSpace(os.Getenv("TICKET_SYSTEM"))); system {
+	case "", models.TicketSystemJira, models.TicketSystemServiceNow:
+	default:
+		problems = append(problems, fmt.Sprintf("TICKET_SYSTEM %q is not supported, use jira or servicenow", system))
+	}
+	switch system := strings.ToLower(strings.TrimSpace(os.Getenv("MDM_SYSTEM"))); system {
+	case "", models.MDMSystemIntune, models.MDMSystemJamf:
+	default:
+		problems = append(problems, fmt.Sprintf("MDM_SYSTEM %q is not supported, use intune or jamf", system))
+	}
+	switch mode := strings.ToLower(strings.TrimSpace(os.Getenv("IMEI_CHECK"))); mode {
+	case "", models.IMEICheckFlag, models.IMEICheckBlock:
+	default:
+		problems = append(problems, fmt.Sprintf("IMEI_CHECK %q is not a mode, use flag or block", mode))
+	}
+	for _, vendor := range strings.Split(os.Getenv("WARRANTY_LOOKUP"), ",") {
+		if vendor = strings.ToLower(strings.TrimSpace(vendor)); vendor != "" && !slices.Contains(models.WarrantyVendors, vendor) {
+			problems = append(problems, fmt.Sprintf("WARRANTY_LOOKUP vendor %q is not supported, use dell or lenovo", vendor))
+		}
+	}
+	switch system := strings.ToLower(strings.TrimSpace(os.Getenv("PROCUREMENT_SYSTEM"))); system {
+	case "", models.ProcurementSystemCoupa:
+	default:
+		problems = append(problems, fmt.Sprintf("PROCUREMENT_SYSTEM %q is not supported, use coupa", system))
+	}
+	switch provider := strings.ToLower(strings.TrimSpace(os.Getenv("ESIGN_PROVIDER"))); provider {
+	case "", models.ESignProviderDocuSign, models.ESignProviderDropboxSign:
+	default:
+		problems = append(problems, fmt.Sprintf("ESIGN_PROVIDER %q is not supported, use docusign or dropboxsign", provider))
+	}
+	switch provider := strings.ToLower(strings.TrimSpace(os.Getenv("SMS_PROVIDER"))); provider {
+	case "", models.SMSProviderTwilio, models.SMSProviderSNS:
+	default:
+		problems = append(problems, fmt.Sprintf("SMS_PROVIDER %q is not supported, use twilio or sns", provider))
+	}
+	switch backend := strings.ToLower(strings.TrimSpace(os.Getenv("OBJECT_STORAGE_BACKEND"))); backend {
+	case "", models.ObjectStorageS3, models.ObjectStorageGCS:
+	case models.ObjectStorageLocal:
+		// files on one instance's disk are missing on the others
+		if p.name != models.ProfileDevelopment {
+			problems = append(problems, fmt.Sprintf("OBJECT_STORAGE_BACKEND %s is only allowed in %s", backend, models.ProfileDevelopment))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("OBJECT_STORAGE_BACKEND %q is not supported, use s3, gcs or local", backend))
+	}
+	switch codec := os.Getenv("JSON_CODEC"); codec {
+	case "", models.JSONCodecJsoniter, models.JSONCodecStd:
+	default:
+		problems = append(problems, fmt.Sprintf("JSON_CODEC %q is not a codec, use jsoniter or std", codec))
+	}
+	switch mode := os.Getenv("AUTH_MODE"); mode {
+	case "", models.AuthModeFirebase:
+	case models.AuthModeFake:
+		// the fake firebase signs anyone in with just an email
+		if p.name != models.ProfileDevelopment {
+			problems = append(problems, fmt.Sprintf("AUTH_MODE %s is only allowed in %s", mode, models.ProfileDevelopment))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("AUTH_MODE %q is not a mode, use firebase or fake", mode))
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(problems, "; "))
+}
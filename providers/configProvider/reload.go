@@ -0,0 +1,150 @@
+package configprovider
+
+import (
+	"asset/models"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"go.uber.org/zap/zapcore"
+)
+
+// loadConfigFile sets the variables of the env file that the process environment doesn't already
+// set, like godotenv.Load, and remembers which ones it set so Reload can tell them apart
+func (e *EnvConfigProvider) loadConfigFile() {
+	e.configFile = envOrDefault("CONFIG_FILE", ".env")
+	e.processEnv = make(map[string]bool)
+	for _, entry := range os.Environ() {
+		key, _, _ := strings.Cut(entry, "=")
+		e.processEnv[key] = true
+	}
+	e.fileKeys = make(map[string]bool)
+	if err := e.applyConfigFile(); err != nil {
+		log.Printf("Warning: %s not loaded, using system envs", e.configFile)
+	}
+}
+
+func (e *EnvConfigProvider) applyConfigFile() error {
+	info, err := os.Stat(e.configFile)
+	if err != nil {
+		return err
+	}
+	values, err := godotenv.Read(e.configFile)
+	if err != nil {
+		return err
+	}
+	for key := range e.fileKeys {
+		if _, ok := values[key]; !ok {
+			os.Unsetenv(key)
+			delete(e.fileKeys, key)
+		}
+	}
+	for key, value := range values {
+		if e.processEnv[key] {
+			continue
+		}
+		os.Setenv(key, value)
+		e.fileKeys[key] = true
+	}
+	e.configModTime = info.ModTime()
+	return nil
+}
+
+// runtimeSettings are what Reload may change, everything else is read once at startup
+type runtimeSettings struct {
+	logLevel            zapcore.Level
+	rateLimits          models.RateLimitConfig
+	cacheTTLs           models.CacheTTLs
+	allowedEmailDomains []string
+}
+
+func parseRuntimeSettings() runtimeSettings {
+	return runtimeSettings{
+		logLevel:            parseLogConfig().Level,
+		rateLimits:          parseRateLimits(),
+		cacheTTLs:           parseCacheTTLs(),
+		allowedEmailDomains: parseEmailDomains(os.Getenv("ALLOWED_EMAIL_DOMAINS")),
+	}
+}
+
+// CACHE_TTL_* take durations like 5m, CACHE_DISABLED a comma separated list of classes like
+// dashboard,timeline that then skip redis
+func parseCacheTTLs() models.CacheTTLs {
+	defaults := models.DefaultCacheTTLs
+	return models.CacheTTLs{
+		Dashboard:  envDuration("CACHE_TTL_DASHBOARD", defaults.Dashboard),
+		Timeline:   envDuration("CACHE_TTL_TIMELINE", defaults.Timeline),
+		UserRole:   envDuration("CACHE_TTL_USER_ROLE", defaults.UserRole),
+		UserEmail:  envDuration("CACHE_TTL_USER_EMAIL", defaults.UserEmail),
+		UserExists: envDuration("CACHE_TTL_USER_EXISTS", defaults.UserExists),
+		NotFound:   envDuration("CACHE_TTL_NOT_FOUND", defaults.NotFound),
+		Responses:  envDuration("CACHE_TTL_RESPONSES", defaults.Responses),
+		Disabled:   parseCacheDisabled(os.Getenv("CACHE_DISABLED")),
+	}
+}
+
+func parseCacheDisabled(value string) models.CacheClassSet {
+	var disabled models.CacheClassSet
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if name == "all" {
+			for _, class := range models.CacheClassNames {
+				disabled = disabled.With(class)
+			}
+			continue
+		}
+		class, ok := models.CacheClassNames[name]
+		if !ok {
+			log.Printf("Warning: unknown cache class %q in CACHE_DISABLED, ignoring it", name)
+			continue
+		}
+		disabled = disabled.With(class)
+	}
+	return disabled
+}
+
+func (e *EnvConfigProvider) Reload() ([]string, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err := e.applyConfigFile(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", e.configFile, err)
+	}
+	// variables the file no longer sets fall back to the profile again
+	e.profile.applyDefaults()
+	next := parseRuntimeSettings()
+
+	changed := make([]string, 0, 4)
+	if next.logLevel != e.logConfig.Level {
+		e.logConfig.Level = next.logLevel
+		changed = append(changed, models.SettingLogLevel)
+	}
+	if next.rateLimits != e.rateLimits {
+		e.rateLimits = next.rateLimits
+		changed = append(changed, models.SettingRateLimits)
+	}
+	if next.cacheTTLs != e.cacheTTLs {
+		e.cacheTTLs = next.cacheTTLs
+		changed = append(changed, models.SettingCacheTTLs)
+	}
+	if !slices.Equal(next.allowedEmailDomains, e.allowedEmailDomains) {
+		e.allowedEmailDomains = next.allowedEmailDomains
+		changed = append(changed, models.SettingAllowedEmailDomains)
+	}
+	return changed, nil
+}
+
+func (e *EnvConfigProvider) ConfigFileChanged() bool {
+	info, err := os.Stat(e.configFile)
+	if err != nil {
+		return false
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return !info.ModTime().Equal(e.configModTime)
+}
@@ -1,56 +1,190 @@
 package databaseProvider
 
 import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"expvar"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
-	"github.com/golang-migrate/migrate/v4"
-	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+)
+
+const (
+	listenerMinReconnect = time.Second
+	listenerMaxReconnect = time.Minute
+	// an idle listener pings this often, a dead connection is otherwise only noticed on the next notify
+	listenerPingInterval = 90 * time.Second
+	listenerBuffer       = 64
 )
 
 type PostgresProvider struct {
-	db *sqlx.DB
+	db        *sqlx.DB
+	connector *secretConnector
+	// directory the migration files are read from
+	migrations string
 }
 
-func NewDBProvider(connectionStr string) *PostgresProvider {
-	db, err := sqlx.Connect("postgres", connectionStr)
+// NewDBProvider connects and leaves the schema as it is, migrations are applied by the server when
+// MIGRATE_ON_START is set or with assetctl migrate. The pool is published as db_pool on /debug/vars,
+// the statement histograms as db_queries, and statements slower than queries.SlowThreshold are logged
+func NewDBProvider(connectionStr string, cfg models.MigrationConfig, pool models.DBPoolConfig, queries models.QueryStatsConfig, secrets providers.SecretsProvider, logger providers.ZapLoggerProvider) *PostgresProvider {
+	stats := newQueryStats(queries, logger)
+	p, err := connect(connectionStr, cfg, pool, secrets, stats)
 	if err != nil {
 		log.Fatalf("failed to connect to Postgres<>: %+v", err)
 	}
+	expvar.Publish("db_pool", expvar.Func(p.poolStats))
+	expvar.Publish("db_queries", expvar.Func(stats.snapshot))
 	fmt.Println("Connected to PostgreSQL...")
+	return p
+}
 
-	if err := migrateUp(db); err != nil {
-		log.Fatalf("migration failed: %+v", err)
+// Connect is NewDBProvider returning the error and without statement timing, for assetctl
+func Connect(connectionStr string, cfg models.MigrationConfig, pool models.DBPoolConfig, secrets providers.SecretsProvider) (*PostgresProvider, error) {
+	return connect(connectionStr, cfg, pool, secrets, nil)
+}
+
+func connect(connectionStr string, cfg models.MigrationConfig, pool models.DBPoolConfig, secrets providers.SecretsProvider, stats *queryStats) (*PostgresProvider, error) {
+	connector := &secretConnector{dsn: connectionStr, secrets: secrets, stats: stats}
+	db := sqlx.NewDb(sql.OpenDB(connector), "postgres")
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
 	}
-	return &PostgresProvider{db: db}
+	return &PostgresProvider{db: db, connector: connector, migrations: cfg.Dir}, nil
 }
 
 func (p *PostgresProvider) DB() *sqlx.DB {
 	return p.db
 }
 
+// poolStats reports how full the pool is. Saturation is the share of MaxOpenConns in use, while it
+// stays at 1 queries queue for a connection and wait_count and wait_ms grow
+func (p *PostgresProvider) poolStats() any {
+	stats := p.db.Stats()
+	saturation := 0.0
+	if stats.MaxOpenConnections > 0 {
+		saturation = float64(stats.InUse) / float64(stats.MaxOpenConnections)
+	}
+	return map[string]any{
+		"max_open":            stats.MaxOpenConnections,
+		"open":                stats.OpenConnections,
+		"in_use":              stats.InUse,
+		"idle":                stats.Idle,
+		"saturation":          saturation,
+		"wait_count":          stats.WaitCount,
+		"wait_ms":             stats.WaitDuration.Milliseconds(),
+		"max_idle_closed":     stats.MaxIdleClosed,
+		"max_lifetime_closed": stats.MaxLifetimeClosed,
+	}
+}
+
 func (p *PostgresProvider) Close() error {
 	return p.db.Close()
 }
 
-func migrateUp(db *sqlx.DB) error {
-	driver, err := postgres.WithInstance(db.DB, &postgres.Config{})
+// Listen opens a dedicated connection that LISTENs on channel. It reconnects with the password read
+// when Listen was called, after a rotation the caller has to listen again
+func (p *PostgresProvider) Listen(ctx context.Context, channel string) (<-chan string, error) {
+	dsn, err := p.connector.resolveDSN(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	listener := pq.NewListener(dsn, listenerMinReconnect, listenerMaxReconnect, nil)
+	if err := listener.Listen(channel); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to listen on %s: %w", channel, err)
+	}
+
+	payloads := make(chan string, listenerBuffer)
+	go func() {
+		defer close(payloads)
+		defer listener.Close()
+		ping := time.NewTicker(listenerPingInterval)
+		defer ping.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ping.C:
+				go listener.Ping()
+			case n := <-listener.Notify:
+				// pq sends nil after reconnecting, notifications from while it was down are lost
+				payload := ""
+				if n != nil {
+					payload = n.Extra
+				}
+				select {
+				case payloads <- payload:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return payloads, nil
+}
+
+// secretConnector reads the password for each new connection, so old connections keep working while
+// new ones use the rotated password
+type secretConnector struct {
+	dsn     string
+	secrets providers.SecretsProvider
+	// times the statements of every connection when set
+	stats *queryStats
+}
 
-	m, err := migrate.NewWithDatabaseInstance("file://database/migrations", "postgres", driver)
+func (c *secretConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	dsn, err := c.resolveDSN(ctx)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	connector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := connector.Connect(ctx)
+	if err != nil || c.stats == nil {
+		return conn, err
 	}
+	pqConn, ok := conn.(pqConn)
+	if !ok {
+		return conn, nil
+	}
+	return &timedConn{pqConn: pqConn, stats: c.stats}, nil
+}
 
-	if err := m.Up(); err != nil && err.Error() != "no change" {
-		return err
+// resolveDSN adds the current password from the secrets backend, the dsn is used as is when it holds none
+func (c *secretConnector) resolveDSN(ctx context.Context) (string, error) {
+	password, err := c.secrets.GetSecret(ctx, models.SecretDatabasePassword)
+	switch {
+	case err == nil:
+		return c.dsn + " password=" + quoteDSNValue(password), nil
+	case errors.Is(err, models.ErrSecretNotFound):
+		return c.dsn, nil
+	default:
+		return "", err
 	}
+}
+
+func (c *secretConnector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
 
-	fmt.Println("Migration complete.")
-	return nil
+// quoteDSNValue quotes a value for a key=value connection string, passwords may contain spaces or quotes
+func quoteDSNValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
 }
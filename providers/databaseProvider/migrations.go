@@ -0,0 +1,166 @@
+package databaseProvider
+
+import (
+	"asset/models"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+)
+
+// MigrateUp applies steps pending migrations, all of them when steps is 0, and returns the version
+// the schema is at afterwards
+func (p *PostgresProvider) MigrateUp(steps int) (uint, error) {
+	m, err := p.migrator()
+	if err != nil {
+		return 0, err
+	}
+	defer m.Close()
+	if steps > 0 {
+		err = m.Steps(steps)
+	} else {
+		err = m.Up()
+	}
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return 0, err
+	}
+	return p.version(m)
+}
+
+// MigrateDown rolls back steps migrations, each needs its .down.sql file. Nothing is rolled back when
+// one of them has none, migrate would lower the version without undoing anything
+func (p *PostgresProvider) MigrateDown(steps int) (uint, error) {
+	if steps <= 0 {
+		return 0, fmt.Errorf("steps must be positive")
+	}
+	m, err := p.migrator()
+	if err != nil {
+		return 0, err
+	}
+	defer m.Close()
+	version, err := p.version(m)
+	if err != nil {
+		return 0, err
+	}
+	files, err := source.Open("file://" + p.migrations)
+	if err != nil {
+		return 0, err
+	}
+	defer files.Close()
+	if err := checkDownMigrations(files, version, steps); err != nil {
+		return 0, err
+	}
+	if err := m.Steps(-steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return 0, err
+	}
+	return p.version(m)
+}
+
+// checkDownMigrations makes sure the steps migrations up to version can be rolled back
+func checkDownMigrations(files source.Driver, version uint, steps int) error {
+	if version == 0 {
+		return nil
+	}
+	for ; steps > 0; steps-- {
+		body, _, err := files.ReadDown(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("migration %d has no .down.sql file, it can't be rolled back", version)
+		}
+		if err != nil {
+			return err
+		}
+		body.Close()
+		version, err = files.Prev(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ForceMigrationVersion records version as applied and clears the dirty flag without running anything,
+// it is for after a failed migration was finished or undone by hand. -1 means no migration applied
+func (p *PostgresProvider) ForceMigrationVersion(version int) error {
+	m, err := p.migrator()
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	return m.Force(version)
+}
+
+// MigrationStatus compares the applied version with the migration files, Pending lists the files
+// newer than the applied version
+func (p *PostgresProvider) MigrationStatus() (models.MigrationStatus, error) {
+	status := models.MigrationStatus{Pending: []models.Migration{}}
+	m, err := p.migrator()
+	if err != nil {
+		return status, err
+	}
+	defer m.Close()
+	version, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return status, err
+	}
+	status.Version, status.Dirty = version, dirty
+
+	files, err := source.Open("file://" + p.migrations)
+	if err != nil {
+		return status, err
+	}
+	defer files.Close()
+	next, err := files.First()
+	for err == nil {
+		status.Latest = next
+		if next > version {
+			migration := models.Migration{Version: next}
+			if body, name, readErr := files.ReadUp(next); readErr == nil {
+				body.Close()
+				migration.Name = name
+			}
+			status.Pending = append(status.Pending, migration)
+		}
+		next, err = files.Next(next)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return status, err
+	}
+	return status, nil
+}
+
+// version is 0 on a database no migration ran on
+func (p *PostgresProvider) version(m *migrate.Migrate) (uint, error) {
+	version, _, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, nil
+	}
+	return version, err
+}
+
+// migrator works on one connection of the pool, closing it hands the connection back
+func (p *PostgresProvider) migrator() (*migrate.Migrate, error) {
+	ctx := context.Background()
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	m, err := migrate.NewWithDatabaseInstance("file://"+p.migrations, "postgres", driver)
+	if err != nil {
+		driver.Close()
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,34 @@
+package databaseProvider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckDownMigrations(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"000001_create_users.up.sql", "000001_create_users.down.sql",
+		"000002_create_assets.up.sql",
+		"000003_add_asset_status.up.sql", "000003_add_asset_status.down.sql",
+		"000004_add_user_type.up.sql", "000004_add_user_type.down.sql",
+	} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("SELECT 1;"), 0o644))
+	}
+	files, err := source.Open("file://" + dir)
+	require.NoError(t, err)
+	defer files.Close()
+
+	assert.NoError(t, checkDownMigrations(files, 4, 2))
+	assert.EqualError(t, checkDownMigrations(files, 4, 3), "migration 2 has no .down.sql file, it can't be rolled back")
+	assert.EqualError(t, checkDownMigrations(files, 2, 1), "migration 2 has no .down.sql file, it can't be rolled back")
+	// more steps than migrations stops at the first one
+	assert.NoError(t, checkDownMigrations(files, 1, 5))
+	// nothing applied, nothing to roll back
+	assert.NoError(t, checkDownMigrations(files, 0, 1))
+}
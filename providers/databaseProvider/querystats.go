@@ -0,0 +1,184 @@
+package databaseProvider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// queryBuckets are the upper bounds of the duration histograms, a statement slower than the last
+// one only counts towards +Inf
+var queryBuckets = []time.Duration{
+	5 * time.Millisecond, 25 * time.Millisecond, 100 * time.Millisecond, 250 * time.Millisecond,
+	time.Second, 5 * time.Second,
+}
+
+// statements past MaxStatements are counted under this key
+const otherStatements = "other"
+
+// maxLoggedSQL cuts the normalized sql in logs and stats, bulk inserts can run to megabytes
+const maxLoggedSQL = 2000
+
+var (
+	sqlStringLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+	// numbers that aren't part of a name or a $n placeholder
+	sqlNumberLiteral = regexp.MustCompile(`([^$\w.])\d+(?:\.\d+)?`)
+	sqlWhitespace    = regexp.MustCompile(`\s+`)
+)
+
+// normalizeSQL replaces literals with ? and collapses whitespace, so the same statement with other
+// values or other indentation is counted as one
+func normalizeSQL(query string) string {
+	query = sqlStringLiteral.ReplaceAllString(query, "?")
+	query = sqlNumberLiteral.ReplaceAllString(query, "${1}?")
+	query = strings.TrimSpace(sqlWhitespace.ReplaceAllString(query, " "))
+	if len(query) > maxLoggedSQL {
+		query = query[:maxLoggedSQL] + "..."
+	}
+	return query
+}
+
+// statementStats is the histogram of one normalized statement
+type statementStats struct {
+	sql     string
+	count   int64
+	errors  int64
+	total   time.Duration
+	max     time.Duration
+	buckets []int64
+}
+
+// queryStats times every statement sent through the pool, logs the slow ones and keeps per statement
+// histograms that are published as db_queries on /debug/vars
+type queryStats struct {
+	cfg    models.QueryStatsConfig
+	logger providers.ZapLoggerProvider
+
+	mu         sync.Mutex
+	statements map[string]*statementStats
+}
+
+func newQueryStats(cfg models.QueryStatsConfig, logger providers.ZapLoggerProvider) *queryStats {
+	return &queryStats{cfg: cfg, logger: logger, statements: make(map[string]*statementStats)}
+}
+
+func (q *queryStats) observe(ctx context.Context, query string, elapsed time.Duration, err error) {
+	if errors.Is(err, driver.ErrSkip) {
+		// database/sql runs it again another way, that run is the one that counts
+		return
+	}
+	normalized := normalizeSQL(query)
+	hash := fnv.New64a()
+	hash.Write([]byte(normalized))
+	key := fmt.Sprintf("%016x", hash.Sum64())
+
+	q.mu.Lock()
+	stats, ok := q.statements[key]
+	if !ok {
+		if len(q.statements) >= q.cfg.MaxStatements {
+			key, normalized = otherStatements, otherStatements
+			stats = q.statements[key]
+		}
+		if stats == nil {
+			stats = &statementStats{sql: normalized, buckets: make([]int64, len(queryBuckets)+1)}
+			q.statements[key] = stats
+		}
+	}
+	stats.count++
+	stats.total += elapsed
+	if elapsed > stats.max {
+		stats.max = elapsed
+	}
+	if err != nil {
+		stats.errors++
+	}
+	bucket := len(queryBuckets)
+	for i, bound := range queryBuckets {
+		if elapsed <= bound {
+			bucket = i
+			break
+		}
+	}
+	stats.buckets[bucket]++
+	q.mu.Unlock()
+
+	if elapsed >= q.cfg.SlowThreshold {
+		fields := []zap.Field{zap.String("statement", key), zap.String("sql", normalizeSQL(query)), zap.Duration("duration", elapsed)}
+		if err != nil {
+			fields = append(fields, zap.Error(err))
+		}
+		if ctx.Err() != nil {
+			fields = append(fields, zap.NamedError("ctx_err", ctx.Err()))
+		}
+		q.logger.GetLogger().Warn("slow query", fields...)
+	}
+}
+
+// snapshot is what /debug/vars shows, keyed by the statement hash the slow query log also prints
+func (q *queryStats) snapshot() any {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := make(map[string]any, len(q.statements))
+	for key, stats := range q.statements {
+		buckets := make(map[string]int64, len(stats.buckets))
+		for i, count := range stats.buckets {
+			label := "+Inf"
+			if i < len(queryBuckets) {
+				label = queryBuckets[i].String()
+			}
+			buckets[label] = count
+		}
+		out[key] = map[string]any{
+			"sql":      stats.sql,
+			"count":    stats.count,
+			"errors":   stats.errors,
+			"total_ms": stats.total.Milliseconds(),
+			"max_ms":   stats.max.Milliseconds(),
+			"buckets":  buckets,
+		}
+	}
+	return out
+}
+
+// pqConn is what a lib/pq connection implements, timedConn passes all of it through
+type pqConn interface {
+	driver.Conn
+	driver.ConnBeginTx
+	driver.ConnPrepareContext
+	driver.QueryerContext
+	driver.ExecerContext
+	driver.Pinger
+	driver.SessionResetter
+	driver.Validator
+}
+
+// timedConn times the statements sqlx sends, transactions included since they run on the same
+// connection. A query is timed until its first rows arrive, not while they are read
+type timedConn struct {
+	pqConn
+	stats *queryStats
+}
+
+func (c *timedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := c.pqConn.QueryContext(ctx, query, args)
+	c.stats.observe(ctx, query, time.Since(start), err)
+	return rows, err
+}
+
+func (c *timedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	result, err := c.pqConn.ExecContext(ctx, query, args)
+	c.stats.observe(ctx, query, time.Since(start), err)
+	return result, err
+}
@@ -0,0 +1,72 @@
+package databaseProvider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNormalizeSQL(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT id FROM users\n\t WHERE email = $1", "SELECT id FROM users WHERE email = $1"},
+		{"SELECT * FROM assets WHERE brand = 'it''s' AND status = 'available'", "SELECT * FROM assets WHERE brand = ? AND status = ?"},
+		{"SELECT * FROM assets LIMIT 10 OFFSET 20", "SELECT * FROM assets LIMIT ? OFFSET ?"},
+		{"SELECT col2, $12 FROM t1 WHERE x > 1.5", "SELECT col2, $12 FROM t1 WHERE x > ?"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, normalizeSQL(tt.query))
+	}
+}
+
+func TestQueryStats(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	core, logs := observer.New(zapcore.WarnLevel)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.New(core)).AnyTimes()
+	stats := newQueryStats(models.QueryStatsConfig{SlowThreshold: time.Second, MaxStatements: 2}, logger)
+
+	stats.observe(ctx, "SELECT 1", time.Millisecond, nil)
+	stats.observe(ctx, "SELECT  2", 2*time.Second, errors.New("boom"))
+	stats.observe(ctx, "SELECT 3", time.Millisecond, driver.ErrSkip)
+	stats.observe(ctx, "UPDATE users SET name = 'a'", time.Millisecond, nil)
+	stats.observe(ctx, "DELETE FROM users", time.Millisecond, nil)
+	stats.observe(ctx, "DELETE FROM assets", time.Millisecond, nil)
+
+	snapshot := stats.snapshot().(map[string]any)
+	// the two statements seen first and other
+	assert.Len(t, snapshot, 3)
+	bySQL := make(map[string]map[string]any)
+	for _, value := range snapshot {
+		statement := value.(map[string]any)
+		bySQL[statement["sql"].(string)] = statement
+	}
+	selects, other := bySQL["SELECT ?"], bySQL[otherStatements]
+	assert.Equal(t, int64(1), bySQL["UPDATE users SET name = ?"]["count"])
+	assert.Equal(t, int64(2), selects["count"])
+	assert.Equal(t, int64(1), selects["errors"])
+	assert.Equal(t, int64(2000), selects["max_ms"])
+	buckets := selects["buckets"].(map[string]int64)
+	assert.Equal(t, int64(1), buckets["5ms"])
+	assert.Equal(t, int64(1), buckets["5s"])
+	assert.Equal(t, int64(2), other["count"])
+
+	assert.Equal(t, 1, logs.Len())
+	entry := logs.All()[0]
+	assert.Equal(t, "slow query", entry.Message)
+	assert.Equal(t, "SELECT ?", entry.ContextMap()["sql"])
+}
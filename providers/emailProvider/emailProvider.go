@@ -0,0 +1,134 @@
+package emailprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+type smtpEmailProvider struct {
+	cfg    SMTPConfig
+	logger providers.ZapLoggerProvider
+}
+
+// NewEmailProvider sends mail through the given smtp server, when no host is configured
+// messages are only logged so local setups don't need a mail server
+func NewEmailProvider(cfg SMTPConfig, logger providers.ZapLoggerProvider) providers.EmailProvider {
+	return &smtpEmailProvider{cfg: cfg, logger: logger}
+}
+
+func (e *smtpEmailProvider) Send(ctx context.Context, to, subject, body string) error {
+	if e.cfg.Host == "" {
+		e.logger.GetLogger().Info("smtp not configured, email not sent", zap.String("to", to), zap.String("subject", subject), zap.String("body", body))
+		return nil
+	}
+
+	msg := strings.Join([]string{
+		"From: " + e.cfg.From,
+		"To: " + to,
+		"Subject: " + subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=\"UTF-8\"",
+		"",
+		body,
+	}, "\r\n")
+	return e.send(to, subject, []byte(msg))
+}
+
+func (e *smtpEmailProvider) SendWithAttachments(ctx context.Context, to, subject, body string, attachments []models.EmailAttachment) error {
+	if e.cfg.Host == "" {
+		e.logger.GetLogger().Info("smtp not configured, email not sent", zap.String("to", to), zap.String("subject", subject), zap.String("body", body), zap.Strings("attachments", attachmentNames(attachments)))
+		return nil
+	}
+	msg, err := multipartMessage(e.cfg.From, to, subject, body, attachments)
+	if err != nil {
+		return fmt.Errorf("failed to build email: %w", err)
+	}
+	return e.send(to, subject, msg)
+}
+
+func (e *smtpEmailProvider) send(to, subject string, msg []byte) error {
+	var auth smtp.Auth
+	if e.cfg.Username != "" {
+		auth = smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)
+	}
+	if err := smtp.SendMail(net.JoinHostPort(e.cfg.Host, e.cfg.Port), auth, e.cfg.From, []string{to}, msg); err != nil {
+		e.logger.GetLogger().Error("failed to send email", zap.String("to", to), zap.String("subject", subject), zap.Error(err))
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// multipartMessage builds a multipart/mixed message, attachments are base64 in lines of 76 characters
+func multipartMessage(from, to, subject, body string, attachments []models.EmailAttachment) ([]byte, error) {
+	var parts bytes.Buffer
+	writer := multipart.NewWriter(&parts)
+	text, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {`text/plain; charset="UTF-8"`}})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := text.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+	for _, attachment := range attachments {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+		for len(encoded) > 76 {
+			if _, err := part.Write([]byte(encoded[:76] + "\r\n")); err != nil {
+				return nil, err
+			}
+			encoded = encoded[76:]
+		}
+		if _, err := part.Write([]byte(encoded + "\r\n")); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	header := strings.Join([]string{
+		"From: " + from,
+		"To: " + to,
+		"Subject: " + mime.QEncoding.Encode("UTF-8", subject),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/mixed; boundary=\"" + writer.Boundary() + "\"",
+		"",
+		"",
+	}, "\r\n")
+	return append([]byte(header), parts.Bytes()...), nil
+}
+
+func attachmentNames(attachments []models.EmailAttachment) []string {
+	names := make([]string, len(attachments))
+	for i, attachment := range attachments {
+		names[i] = attachment.Filename
+	}
+	return names
+}
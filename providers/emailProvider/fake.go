@@ -0,0 +1,29 @@
+package emailprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type fakeEmailProvider struct {
+	logger providers.ZapLoggerProvider
+}
+
+// NewFakeEmailProvider logs every message with its body for AUTH_MODE=fake, magic links and invites
+// are then copied from the log. Nothing is sent even when SMTP_HOST is set
+func NewFakeEmailProvider(logger providers.ZapLoggerProvider) providers.EmailProvider {
+	return &fakeEmailProvider{logger: logger}
+}
+
+func (e *fakeEmailProvider) Send(ctx context.Context, to, subject, body string) error {
+	e.logger.GetLogger().Warn("fake email", zap.String("to", to), zap.String("subject", subject), zap.String("body", body))
+	return nil
+}
+
+func (e *fakeEmailProvider) SendWithAttachments(ctx context.Context, to, subject, body string, attachments []models.EmailAttachment) error {
+	e.logger.GetLogger().Warn("fake email", zap.String("to", to), zap.String("subject", subject), zap.String("body", body), zap.Strings("attachments", attachmentNames(attachments)))
+	return nil
+}
@@ -0,0 +1,237 @@
+package errorreporterprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	sendTimeout = 10 * time.Second
+	// reports waiting to be sent, more are dropped so a burst of 5xx can't pile up in memory
+	queueSize = 100
+	// the same error is sent at most once a minute, a failing dependency would otherwise flood the project
+	dedupeWindow = time.Minute
+)
+
+// sentryReporter posts events to sentry's envelope endpoint from a single goroutine, so reporting
+// never slows the request that failed
+type sentryReporter struct {
+	endpoint    string
+	auth        string
+	environment string
+	release     string
+	serverName  string
+	client      *http.Client
+	logger      providers.ZapLoggerProvider
+
+	queue chan models.ErrorReport
+	done  chan struct{}
+	once  sync.Once
+
+	mu       sync.Mutex
+	lastSent map[string]time.Time
+}
+
+// NewErrorReporter returns a sentry reporter for cfg.DSN, nil when no DSN is configured
+func NewErrorReporter(cfg models.ErrorReportingConfig, logger providers.ZapLoggerProvider) (providers.ErrorReporter, error) {
+	if cfg.DSN == "" {
+		return nil, nil
+	}
+	endpoint, publicKey, err := parseDSN(cfg.DSN)
+	if err != nil {
+		return nil, err
+	}
+	serverName, _ := os.Hostname()
+	r := &sentryReporter{
+		endpoint:    endpoint,
+		auth:        "Sentry sentry_version=7, sentry_client=asset-manager/1.0, sentry_key=" + publicKey,
+		environment: cfg.Environment,
+		release:     cfg.Release,
+		serverName:  serverName,
+		client:      &http.Client{Timeout: sendTimeout},
+		logger:      logger,
+		queue:       make(chan models.ErrorReport, queueSize),
+		done:        make(chan struct{}),
+		lastSent:    make(map[string]time.Time),
+	}
+	go r.run()
+	return r, nil
+}
+
+// parseDSN turns https://<key>@<host>/<project> into the project's envelope url
+func parseDSN(dsn string) (string, string, error) {
+	parsed, err := url.Parse(dsn)
+	if err != nil || parsed.User == nil || parsed.User.Username() == "" {
+		return "", "", fmt.Errorf("invalid SENTRY_DSN, expected https://<key>@<host>/<project>")
+	}
+	path := strings.Trim(parsed.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	projectID := path[slash+1:]
+	if projectID == "" {
+		return "", "", fmt.Errorf("invalid SENTRY_DSN, the project id is missing")
+	}
+	prefix := ""
+	if slash >= 0 {
+		prefix = "/" + path[:slash]
+	}
+	endpoint := fmt.Sprintf("%s://%s%s/api/%s/envelope/", parsed.Scheme, parsed.Host, prefix, projectID)
+	return endpoint, parsed.User.Username(), nil
+}
+
+func (r *sentryReporter) Report(report models.ErrorReport) {
+	if report.Err == nil || r.duplicate(report) {
+		return
+	}
+	select {
+	case r.queue <- report:
+	default:
+		r.logger.GetLogger().Warn("error report dropped, queue is full", zap.Error(report.Err))
+	}
+}
+
+func (r *sentryReporter) Close(ctx context.Context) error {
+	r.once.Do(func() { close(r.queue) })
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *sentryReporter) duplicate(report models.ErrorReport) bool {
+	key := report.Route + "|" + report.Err.Error()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	if last, ok := r.lastSent[key]; ok && now.Sub(last) < dedupeWindow {
+		return true
+	}
+	r.lastSent[key] = now
+	// forget old keys so distinct errors can't grow the map forever
+	if len(r.lastSent) > 1000 {
+		for k, at := range r.lastSent {
+			if now.Sub(at) >= dedupeWindow {
+				delete(r.lastSent, k)
+			}
+		}
+	}
+	return false
+}
+
+func (r *sentryReporter) run() {
+	defer close(r.done)
+	for report := range r.queue {
+		if err := r.send(report); err != nil {
+			r.logger.GetLogger().Warn("failed to send error report", zap.Error(err), zap.NamedError("reported", report.Err))
+		}
+	}
+}
+
+func (r *sentryReporter) send(report models.ErrorReport) error {
+	eventID := newEventID()
+	event, err := json.Marshal(r.event(eventID, report))
+	if err != nil {
+		return fmt.Errorf("failed to encode sentry event: %w", err)
+	}
+	header, _ := json.Marshal(map[string]string{"event_id": eventID, "sent_at": time.Now().UTC().Format(time.RFC3339)})
+	item, _ := json.Marshal(map[string]interface{}{"type": "event", "length": len(event)})
+
+	var body bytes.Buffer
+	body.Write(header)
+	body.WriteByte('\n')
+	body.Write(item)
+	body.WriteByte('\n')
+	body.Write(event)
+	body.WriteByte('\n')
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+	res, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("sentry answered %s", res.Status)
+	}
+	return nil
+}
+
+func (r *sentryReporter) event(eventID string, report models.ErrorReport) map[string]interface{} {
+	tags := map[string]string{}
+	for k, v := range report.Tags {
+		tags[k] = v
+	}
+	if report.RequestID != "" {
+		tags["request_id"] = report.RequestID
+	}
+	if report.Route != "" {
+		tags["route"] = report.Route
+	}
+	if report.Status != 0 {
+		tags["status"] = fmt.Sprint(report.Status)
+	}
+
+	event := map[string]interface{}{
+		"event_id":    eventID,
+		"timestamp":   time.Now().UTC().Format(time.RFC3339Nano),
+		"platform":    "go",
+		"level":       "error",
+		"server_name": r.serverName,
+		"exception": map[string]interface{}{
+			"values": []map[string]string{{"type": fmt.Sprintf("%T", report.Err), "value": report.Err.Error()}},
+		},
+		"tags": tags,
+	}
+	if r.environment != "" {
+		event["environment"] = r.environment
+	}
+	if r.release != "" {
+		event["release"] = r.release
+	}
+	if report.Method != "" {
+		// headers and bodies are left out, they carry tokens and personal data
+		event["request"] = map[string]string{"method": report.Method, "url": report.URL}
+	}
+	user := map[string]string{}
+	if report.UserID != "" {
+		user["id"] = report.UserID
+	}
+	if report.RemoteIP != "" {
+		user["ip_address"] = report.RemoteIP
+	}
+	if len(user) > 0 {
+		event["user"] = user
+	}
+	if len(report.Stack) > 0 {
+		event["extra"] = map[string]string{"stack": string(report.Stack)}
+	}
+	return event
+}
+
+func newEventID() string {
+	id := make([]byte, 16)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
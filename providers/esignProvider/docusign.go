@@ -0,0 +1,236 @@
+package esignprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// docuSignScope lets the integration send envelopes on behalf of the impersonated user, who has to
+// have granted consent to the integration key once
+const docuSignScope = "signature impersonation"
+
+// docuSignAnchor is where the signer's signature is placed, the generated documents end with it
+const docuSignAnchor = "Signature:"
+
+type docuSignProvider struct {
+	url            string
+	authURL        string
+	accountID      string
+	integrationKey string
+	userID         string
+	secrets        providers.SecretsProvider
+	client         *http.Client
+	tokens         utils.TokenCache
+}
+
+type docuSignDocument struct {
+	DocumentBase64 string `json:"documentBase64"`
+	DocumentID     string `json:"documentId"`
+	Name           string `json:"name"`
+	FileExtension  string `json:"fileExtension"`
+}
+
+type docuSignSignHere struct {
+	AnchorString  string `json:"anchorString"`
+	AnchorUnits   string `json:"anchorUnits"`
+	AnchorXOffset string `json:"anchorXOffset"`
+	AnchorYOffset string `json:"anchorYOffset"`
+}
+
+type docuSignSigner struct {
+	Email        string `json:"email"`
+	Name         string `json:"name"`
+	RecipientID  string `json:"recipientId"`
+	RoutingOrder string `json:"routingOrder"`
+	Tabs         struct {
+		SignHereTabs []docuSignSignHere `json:"signHereTabs"`
+	} `json:"tabs"`
+}
+
+type docuSignEnvelope struct {
+	EmailSubject string             `json:"emailSubject"`
+	EmailBlurb   string             `json:"emailBlurb,omitempty"`
+	Documents    []docuSignDocument `json:"documents"`
+	Recipients   struct {
+		Signers []docuSignSigner `json:"signers"`
+	} `json:"recipients"`
+	Status string `json:"status"`
+}
+
+func (d *docuSignProvider) Send(ctx context.Context, req models.SignatureRequest) (string, error) {
+	signer := docuSignSigner{Email: req.SignerEmail, Name: req.SignerName, RecipientID: "1", RoutingOrder: "1"}
+	signer.Tabs.SignHereTabs = []docuSignSignHere{{AnchorString: docuSignAnchor, AnchorUnits: "pixels", AnchorXOffset: "70", AnchorYOffset: "-4"}}
+	envelope := docuSignEnvelope{
+		EmailSubject: req.Title,
+		EmailBlurb:   req.Message,
+		Documents: []docuSignDocument{{
+			DocumentBase64: base64.StdEncoding.EncodeToString(req.Document),
+			DocumentID:     "1",
+			Name:           req.Filename,
+			FileExtension:  "pdf",
+		}},
+		Status: "sent",
+	}
+	envelope.Recipients.Signers = []docuSignSigner{signer}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return "", err
+	}
+	body, err := d.do(ctx, http.MethodPost, "/envelopes", payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to create docusign envelope: %w", err)
+	}
+	var created struct {
+		EnvelopeID string `json:"envelopeId"`
+	}
+	if err := json.Unmarshal(body, &created); err != nil {
+		return "", fmt.Errorf("failed to decode docusign response: %w", err)
+	}
+	return created.EnvelopeID, nil
+}
+
+// ParseWebhook reads a connect message in the json sim format, connect signs the body with hmac
+// sha256 and sends it base64 in X-DocuSign-Signature-1. A key being rotated is sent as -2 as well
+func (d *docuSignProvider) ParseWebhook(ctx context.Context, header http.Header, body []byte) (models.SignatureEvent, error) {
+	secret, err := webhookSecret(ctx, d.secrets)
+	if err != nil {
+		return models.SignatureEvent{}, err
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+	signed := false
+	for _, name := range []string{"X-DocuSign-Signature-1", "X-DocuSign-Signature-2"} {
+		signature, err := base64.StdEncoding.DecodeString(header.Get(name))
+		if err == nil && hmac.Equal(signature, expected) {
+			signed = true
+			break
+		}
+	}
+	if !signed {
+		return models.SignatureEvent{}, models.ErrSignatureWebhookUnauthorized
+	}
+
+	var message struct {
+		Event string `json:"event"`
+		Data  struct {
+			EnvelopeID string `json:"envelopeId"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &message); err != nil {
+		return models.SignatureEvent{}, fmt.Errorf("failed to decode docusign connect message: %w", err)
+	}
+	event := models.SignatureEvent{EnvelopeID: message.Data.EnvelopeID}
+	switch message.Event {
+	case "envelope-sent":
+		event.Status = models.SignatureStatusSent
+	case "envelope-completed":
+		event.Status = models.SignatureStatusCompleted
+	case "envelope-declined", "envelope-voided":
+		event.Status = models.SignatureStatusDeclined
+	}
+	return event, nil
+}
+
+// DownloadSigned returns the documents of the envelope merged into one pdf
+func (d *docuSignProvider) DownloadSigned(ctx context.Context, envelopeID string) ([]byte, error) {
+	document, err := d.do(ctx, http.MethodGet, "/envelopes/"+url.PathEscape(envelopeID)+"/documents/combined", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download docusign envelope %s: %w", envelopeID, err)
+	}
+	return document, nil
+}
+
+// do calls the account's part of the esignature api
+func (d *docuSignProvider) do(ctx context.Context, method, path string, payload []byte) ([]byte, error) {
+	token, err := d.accessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, d.url+"/v2.1/accounts/"+url.PathEscape(d.accountID)+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return do(d.client, req, "docusign")
+}
+
+// accessToken makes a jwt grant, the assertion is signed with the rsa key of the integration. It
+// isn't the client credentials grant but the token is kept the same way
+func (d *docuSignProvider) accessToken(ctx context.Context) (string, error) {
+	return d.tokens.Token(ctx, func(ctx context.Context) (utils.OAuthToken, error) {
+		var token utils.OAuthToken
+		pemKey, err := d.secrets.GetSecret(ctx, models.SecretESignKey)
+		if err != nil {
+			return token, fmt.Errorf("failed to read %s: %w", models.SecretESignKey, err)
+		}
+		key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(pemKey))
+		if err != nil {
+			return token, fmt.Errorf("failed to parse %s: %w", models.SecretESignKey, err)
+		}
+		authHost := d.authURL
+		if parsed, err := url.Parse(d.authURL); err == nil && parsed.Host != "" {
+			authHost = parsed.Host
+		}
+		now := time.Now()
+		assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+			"iss":   d.integrationKey,
+			"sub":   d.userID,
+			"aud":   authHost,
+			"iat":   now.Unix(),
+			"exp":   now.Add(time.Hour).Unix(),
+			"scope": docuSignScope,
+		}).SignedString(key)
+		if err != nil {
+			return token, fmt.Errorf("failed to sign docusign assertion: %w", err)
+		}
+
+		form := url.Values{
+			"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+			"assertion":  {assertion},
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.authURL+"/oauth/token", strings.NewReader(form.Encode()))
+		if err != nil {
+			return token, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		body, err := do(d.client, req, "docusign")
+		if err != nil {
+			return token, fmt.Errorf("failed to sign in to docusign: %w", err)
+		}
+		if err := json.Unmarshal(body, &token); err != nil {
+			return token, fmt.Errorf("failed to decode docusign token: %w", err)
+		}
+		return token, nil
+	})
+}
+
+// webhookSecret reads ESIGN_WEBHOOK_SECRET, callbacks are refused while it is unset
+func webhookSecret(ctx context.Context, secrets providers.SecretsProvider) (string, error) {
+	secret, err := secrets.GetSecret(ctx, models.SecretESignWebhookSecret)
+	if errors.Is(err, models.ErrSecretNotFound) || (err == nil && secret == "") {
+		return "", models.ErrSignatureWebhookUnauthorized
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", models.SecretESignWebhookSecret, err)
+	}
+	return secret, nil
+}
@@ -0,0 +1,148 @@
+package esignprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+)
+
+// dropboxSignProvider sends signature requests without form fields, the signer places the
+// signature on the "Signature:" line of the document themselves
+type dropboxSignProvider struct {
+	url      string
+	testMode bool
+	secrets  providers.SecretsProvider
+	client   *http.Client
+}
+
+func (d *dropboxSignProvider) Send(ctx context.Context, req models.SignatureRequest) (string, error) {
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	fields := [][2]string{
+		{"title", req.Title},
+		{"subject", req.Title},
+		{"message", req.Message},
+		{"signers[0][email_address]", req.SignerEmail},
+		{"signers[0][name]", req.SignerName},
+	}
+	if d.testMode {
+		fields = append(fields, [2]string{"test_mode", "1"})
+	}
+	for _, field := range fields {
+		if err := writer.WriteField(field[0], field[1]); err != nil {
+			return "", err
+		}
+	}
+	file, err := writer.CreateFormFile("files[0]", req.Filename)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Write(req.Document); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+
+	httpReq, err := d.request(ctx, http.MethodPost, "/v3/signature_request/send", &form)
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", writer.FormDataContentType())
+	httpReq.Header.Set("Accept", "application/json")
+	body, err := do(d.client, httpReq, "dropbox sign")
+	if err != nil {
+		return "", fmt.Errorf("failed to send dropbox sign request: %w", err)
+	}
+	var created struct {
+		SignatureRequest struct {
+			SignatureRequestID string `json:"signature_request_id"`
+		} `json:"signature_request"`
+	}
+	if err := json.Unmarshal(body, &created); err != nil {
+		return "", fmt.Errorf("failed to decode dropbox sign response: %w", err)
+	}
+	return created.SignatureRequest.SignatureRequestID, nil
+}
+
+// ParseWebhook reads the json form field of a callback. Its event_hash is the hex hmac sha256 of
+// event_time and event_type keyed with the api key
+func (d *dropboxSignProvider) ParseWebhook(ctx context.Context, header http.Header, body []byte) (models.SignatureEvent, error) {
+	form, err := http.NewRequestWithContext(ctx, http.MethodPost, "/", bytes.NewReader(body))
+	if err != nil {
+		return models.SignatureEvent{}, err
+	}
+	form.Header.Set("Content-Type", header.Get("Content-Type"))
+	var callback struct {
+		Event struct {
+			EventTime string `json:"event_time"`
+			EventType string `json:"event_type"`
+			EventHash string `json:"event_hash"`
+		} `json:"event"`
+		SignatureRequest struct {
+			SignatureRequestID string `json:"signature_request_id"`
+		} `json:"signature_request"`
+	}
+	if err := json.Unmarshal([]byte(form.FormValue("json")), &callback); err != nil {
+		return models.SignatureEvent{}, fmt.Errorf("failed to decode dropbox sign callback: %w", err)
+	}
+
+	apiKey, err := d.secrets.GetSecret(ctx, models.SecretESignKey)
+	if err != nil {
+		return models.SignatureEvent{}, fmt.Errorf("failed to read %s: %w", models.SecretESignKey, err)
+	}
+	mac := hmac.New(sha256.New, []byte(apiKey))
+	mac.Write([]byte(callback.Event.EventTime + callback.Event.EventType))
+	hash, err := hex.DecodeString(callback.Event.EventHash)
+	if err != nil || !hmac.Equal(hash, mac.Sum(nil)) {
+		return models.SignatureEvent{}, models.ErrSignatureWebhookUnauthorized
+	}
+
+	event := models.SignatureEvent{EnvelopeID: callback.SignatureRequest.SignatureRequestID}
+	switch callback.Event.EventType {
+	case "signature_request_sent":
+		event.Status = models.SignatureStatusSent
+	// the signed pdf can be fetched once dropbox sign has rendered it, all_signed may come sooner
+	case "signature_request_downloadable":
+		event.Status = models.SignatureStatusCompleted
+	case "signature_request_declined", "signature_request_canceled":
+		event.Status = models.SignatureStatusDeclined
+	}
+	return event, nil
+}
+
+func (d *dropboxSignProvider) DownloadSigned(ctx context.Context, envelopeID string) ([]byte, error) {
+	req, err := d.request(ctx, http.MethodGet, "/v3/signature_request/files/"+url.PathEscape(envelopeID)+"?file_type=pdf", nil)
+	if err != nil {
+		return nil, err
+	}
+	document, err := do(d.client, req, "dropbox sign")
+	if err != nil {
+		return nil, fmt.Errorf("failed to download dropbox sign request %s: %w", envelopeID, err)
+	}
+	return document, nil
+}
+
+// request authenticates with the api key as the basic auth user and no password
+func (d *dropboxSignProvider) request(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	apiKey, err := d.secrets.GetSecret(ctx, models.SecretESignKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", models.SecretESignKey, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, d.url+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(apiKey, "")
+	return req, nil
+}
@@ -0,0 +1,67 @@
+package esignprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// NewESignProvider returns the e-signature service picked by ESIGN_PROVIDER, nil when documents
+// aren't sent for signature. Keys are read from secrets whenever they are needed so a rotated one is
+// picked up
+func NewESignProvider(cfg models.ESignConfig, secrets providers.SecretsProvider) (providers.ESignProvider, error) {
+	if cfg.Provider == "" {
+		return nil, nil
+	}
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("ESIGN_URL is required for %s", cfg.Provider)
+	}
+	client := &http.Client{Timeout: cfg.Timeout}
+	switch cfg.Provider {
+	case models.ESignProviderDocuSign:
+		if cfg.AccountID == "" || cfg.IntegrationKey == "" || cfg.UserID == "" {
+			return nil, errors.New("ESIGN_ACCOUNT_ID, ESIGN_INTEGRATION_KEY and ESIGN_USER_ID are required for docusign")
+		}
+		return &docuSignProvider{
+			url:            strings.TrimRight(cfg.URL, "/"),
+			authURL:        strings.TrimRight(cfg.AuthURL, "/"),
+			accountID:      cfg.AccountID,
+			integrationKey: cfg.IntegrationKey,
+			userID:         cfg.UserID,
+			secrets:        secrets,
+			client:         client,
+		}, nil
+	case models.ESignProviderDropboxSign:
+		return &dropboxSignProvider{
+			url:      strings.TrimRight(cfg.URL, "/"),
+			testMode: cfg.TestMode,
+			secrets:  secrets,
+			client:   client,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown e-signature provider %q", cfg.Provider)
+	}
+}
+
+// do sends req and returns the body of a successful response, name says who failed in the error
+func do(client *http.Client, req *http.Request, name string) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 500))
+		return nil, fmt.Errorf("%s returned %d: %s", name, resp.StatusCode, bytes.TrimSpace(message))
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s response: %w", name, err)
+	}
+	return body, nil
+}
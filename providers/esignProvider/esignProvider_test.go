@@ -0,0 +1,197 @@
+package esignprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var handover = models.SignatureRequest{
+	Title:       "Asset handover: Dell XPS (SN-1)",
+	Message:     "Please sign to confirm you received the asset",
+	SignerName:  "Asha Rao",
+	SignerEmail: "asha@example.com",
+	Filename:    "handover.pdf",
+	Document:    []byte("%PDF-1.4 handover"),
+}
+
+func TestDocuSignProvider(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	var signIns atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth/token":
+			signIns.Add(1)
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "urn:ietf:params:oauth:grant-type:jwt-bearer", r.PostForm.Get("grant_type"))
+			claims := jwt.MapClaims{}
+			_, err := jwt.ParseWithClaims(r.PostForm.Get("assertion"), claims, func(*jwt.Token) (interface{}, error) { return &key.PublicKey, nil })
+			require.NoError(t, err)
+			assert.Equal(t, "integration-key", claims["iss"])
+			assert.Equal(t, "user-id", claims["sub"])
+			assert.Equal(t, r.Host, claims["aud"])
+			assert.Equal(t, docuSignScope, claims["scope"])
+			w.Write([]byte(`{"access_token":"docusign-token","expires_in":3600}`))
+		case "/restapi/v2.1/accounts/account-id/envelopes":
+			assert.Equal(t, "Bearer docusign-token", r.Header.Get("Authorization"))
+			var envelope docuSignEnvelope
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&envelope))
+			assert.Equal(t, "sent", envelope.Status)
+			assert.Equal(t, handover.Title, envelope.EmailSubject)
+			require.Len(t, envelope.Documents, 1)
+			assert.Equal(t, base64.StdEncoding.EncodeToString(handover.Document), envelope.Documents[0].DocumentBase64)
+			require.Len(t, envelope.Recipients.Signers, 1)
+			assert.Equal(t, "asha@example.com", envelope.Recipients.Signers[0].Email)
+			assert.Equal(t, docuSignAnchor, envelope.Recipients.Signers[0].Tabs.SignHereTabs[0].AnchorString)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"envelopeId":"env-1","status":"sent"}`))
+		case "/restapi/v2.1/accounts/account-id/envelopes/env-1/documents/combined":
+			w.Write([]byte("%PDF-1.4 signed"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	secrets := providers.NewMockSecretsProvider(ctrl)
+	secrets.EXPECT().GetSecret(gomock.Any(), models.SecretESignKey).Return(string(pemKey), nil).AnyTimes()
+	secrets.EXPECT().GetSecret(gomock.Any(), models.SecretESignWebhookSecret).Return("connect-key", nil).AnyTimes()
+	docusign, err := NewESignProvider(models.ESignConfig{
+		Provider: models.ESignProviderDocuSign, URL: server.URL + "/restapi/", AuthURL: server.URL,
+		AccountID: "account-id", IntegrationKey: "integration-key", UserID: "user-id", Timeout: time.Second,
+	}, secrets)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		id, err := docusign.Send(ctx, handover)
+		require.NoError(t, err)
+		assert.Equal(t, "env-1", id)
+	}
+	// the token is reused until it is about to expire
+	assert.Equal(t, int32(1), signIns.Load())
+
+	signed, err := docusign.DownloadSigned(ctx, "env-1")
+	require.NoError(t, err)
+	assert.Equal(t, "%PDF-1.4 signed", string(signed))
+
+	body := []byte(`{"event":"envelope-completed","data":{"envelopeId":"env-1"}}`)
+	mac := hmac.New(sha256.New, []byte("connect-key"))
+	mac.Write(body)
+	header := http.Header{}
+	header.Set("X-DocuSign-Signature-1", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	event, err := docusign.ParseWebhook(ctx, header, body)
+	require.NoError(t, err)
+	assert.Equal(t, models.SignatureEvent{EnvelopeID: "env-1", Status: models.SignatureStatusCompleted}, event)
+
+	_, err = docusign.ParseWebhook(ctx, header, []byte(`{"event":"envelope-completed","data":{"envelopeId":"env-2"}}`))
+	assert.ErrorIs(t, err, models.ErrSignatureWebhookUnauthorized)
+}
+
+func TestDropboxSignProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "api-key", user)
+		assert.Empty(t, password)
+		switch r.URL.Path {
+		case "/v3/signature_request/send":
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+			assert.Equal(t, handover.Title, r.FormValue("title"))
+			assert.Equal(t, "asha@example.com", r.FormValue("signers[0][email_address]"))
+			assert.Equal(t, "1", r.FormValue("test_mode"))
+			file, header, err := r.FormFile("files[0]")
+			require.NoError(t, err)
+			document, _ := io.ReadAll(file)
+			assert.Equal(t, handover.Document, document)
+			assert.Equal(t, "handover.pdf", header.Filename)
+			w.Write([]byte(`{"signature_request":{"signature_request_id":"sr-1"}}`))
+		case "/v3/signature_request/files/sr-1":
+			assert.Equal(t, "pdf", r.URL.Query().Get("file_type"))
+			w.Write([]byte("%PDF-1.4 signed"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	secrets := providers.NewMockSecretsProvider(ctrl)
+	secrets.EXPECT().GetSecret(gomock.Any(), models.SecretESignKey).Return("api-key", nil).AnyTimes()
+	dropbox, err := NewESignProvider(models.ESignConfig{Provider: models.ESignProviderDropboxSign, URL: server.URL, TestMode: true, Timeout: time.Second}, secrets)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	id, err := dropbox.Send(ctx, handover)
+	require.NoError(t, err)
+	assert.Equal(t, "sr-1", id)
+
+	signed, err := dropbox.DownloadSigned(ctx, "sr-1")
+	require.NoError(t, err)
+	assert.Equal(t, "%PDF-1.4 signed", string(signed))
+
+	callback := func(eventType, hash string) (http.Header, []byte) {
+		payload, _ := json.Marshal(map[string]interface{}{
+			"event":             map[string]string{"event_time": "1760000000", "event_type": eventType, "event_hash": hash},
+			"signature_request": map[string]string{"signature_request_id": "sr-1"},
+		})
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		writer.WriteField("json", string(payload))
+		writer.Close()
+		header := http.Header{}
+		header.Set("Content-Type", writer.FormDataContentType())
+		return header, body.Bytes()
+	}
+	mac := hmac.New(sha256.New, []byte("api-key"))
+	mac.Write([]byte("1760000000signature_request_downloadable"))
+	header, body := callback("signature_request_downloadable", hex.EncodeToString(mac.Sum(nil)))
+	event, err := dropbox.ParseWebhook(ctx, header, body)
+	require.NoError(t, err)
+	assert.Equal(t, models.SignatureEvent{EnvelopeID: "sr-1", Status: models.SignatureStatusCompleted}, event)
+
+	header, body = callback("signature_request_declined", hex.EncodeToString(mac.Sum(nil)))
+	_, err = dropbox.ParseWebhook(ctx, header, body)
+	assert.ErrorIs(t, err, models.ErrSignatureWebhookUnauthorized)
+}
+
+func TestNewESignProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	secrets := providers.NewMockSecretsProvider(ctrl)
+
+	provider, err := NewESignProvider(models.ESignConfig{}, secrets)
+	assert.NoError(t, err)
+	assert.Nil(t, provider)
+
+	_, err = NewESignProvider(models.ESignConfig{Provider: models.ESignProviderDocuSign, URL: "https://demo.docusign.net/restapi"}, secrets)
+	assert.ErrorContains(t, err, "ESIGN_ACCOUNT_ID")
+
+	_, err = NewESignProvider(models.ESignConfig{Provider: "adobesign", URL: "https://api.adobesign.com"}, secrets)
+	assert.ErrorContains(t, err, "unknown e-signature provider")
+}
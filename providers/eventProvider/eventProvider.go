@@ -0,0 +1,57 @@
+package eventprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const publishTimeout = 10 * time.Second
+
+// NewEventPublisher returns the publisher picked by EVENT_BROKER, nil when publishing is off
+func NewEventPublisher(cfg models.EventBrokerConfig) (providers.EventPublisher, error) {
+	if cfg.Driver == "" {
+		return nil, nil
+	}
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("EVENT_BROKER_URL is required for the %s event broker", cfg.Driver)
+	}
+	client := &http.Client{Timeout: publishTimeout}
+
+	switch cfg.Driver {
+	case models.EventBrokerKafka:
+		return &kafkaPublisher{
+			url:      strings.TrimRight(cfg.URL, "/"),
+			topic:    cfg.Topic,
+			username: cfg.Username,
+			password: cfg.Password,
+			client:   client,
+		}, nil
+	case models.EventBrokerRabbitMQ:
+		return &rabbitMQPublisher{
+			url:      strings.TrimRight(cfg.URL, "/"),
+			vhost:    cfg.RabbitMQVHost,
+			exchange: cfg.RabbitMQExchange,
+			prefix:   cfg.Topic,
+			username: cfg.Username,
+			password: cfg.Password,
+			client:   client,
+		}, nil
+	case models.EventBrokerNATS:
+		return newNATSPublisher(cfg)
+	default:
+		return nil, fmt.Errorf("unknown event broker %q", cfg.Driver)
+	}
+}
+
+// subject joins the configured topic and the event type, "asset-manager.events.asset.assigned" lets
+// consumers subscribe to one type or a whole family with wildcards
+func subject(prefix, eventType string) string {
+	if prefix == "" {
+		return eventType
+	}
+	return prefix + "." + eventType
+}
@@ -0,0 +1,72 @@
+package eventprovider
+
+import (
+	"asset/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const kafkaJSONContentType = "application/vnd.kafka.json.v2+json"
+
+// kafkaPublisher produces through the Confluent REST proxy (v2 api), every event goes to one topic
+// keyed by its aggregate id so the events of one asset or user stay in partition order
+type kafkaPublisher struct {
+	url      string
+	topic    string
+	username string
+	password string
+	client   *http.Client
+}
+
+func (k *kafkaPublisher) Publish(ctx context.Context, msg models.BrokerMessage) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"records": []map[string]interface{}{{"key": msg.Key, "value": json.RawMessage(msg.Body)}},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.url+"/topics/"+url.PathEscape(k.topic), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", kafkaJSONContentType)
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	if k.username != "" {
+		req.SetBasicAuth(k.username, k.password)
+	}
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach kafka rest proxy: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 500))
+		return fmt.Errorf("kafka rest proxy returned %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+
+	// the proxy answers 200 for the request, records that couldn't be written carry their own error
+	var result struct {
+		Offsets []struct {
+			Error *string `json:"error"`
+		} `json:"offsets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode kafka rest proxy response: %w", err)
+	}
+	for _, offset := range result.Offsets {
+		if offset.Error != nil {
+			return fmt.Errorf("kafka rejected event %s: %s", msg.ID, *offset.Error)
+		}
+	}
+	return nil
+}
+
+func (k *kafkaPublisher) Close() error {
+	return nil
+}
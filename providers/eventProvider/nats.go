@@ -0,0 +1,211 @@
+package eventprovider
+
+import (
+	"asset/models"
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultNATSPort = "4222"
+
+// natsPublisher speaks the NATS text protocol over one connection. Each publish is followed by a PING
+// and waits for the PONG, the server answers in order so the PONG confirms it took the message. Servers
+// with headers get the event id as Nats-Msg-Id, which JetStream streams dedupe on
+type natsPublisher struct {
+	addr     string
+	useTLS   bool
+	username string
+	password string
+	token    string
+	prefix   string
+
+	mu      sync.Mutex
+	conn    net.Conn
+	reader  *bufio.Reader
+	headers bool
+}
+
+type natsServerInfo struct {
+	TLSRequired bool `json:"tls_required"`
+	Headers     bool `json:"headers"`
+}
+
+func newNATSPublisher(cfg models.EventBrokerConfig) (*natsPublisher, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid EVENT_BROKER_URL: %w", err)
+	}
+	n := &natsPublisher{prefix: cfg.Topic, username: cfg.Username, password: cfg.Password}
+	switch u.Scheme {
+	case "nats":
+	case "tls":
+		n.useTLS = true
+	default:
+		return nil, fmt.Errorf("nats url must start with nats:// or tls://, got %q", cfg.URL)
+	}
+	n.addr = u.Host
+	if u.Port() == "" {
+		n.addr = net.JoinHostPort(u.Hostname(), defaultNATSPort)
+	}
+	// credentials in the url fill in what the env leaves out, a user without password is a token
+	if u.User != nil && n.username == "" {
+		if password, ok := u.User.Password(); ok {
+			n.username, n.password = u.User.Username(), password
+		} else {
+			n.token = u.User.Username()
+		}
+	}
+	return n, nil
+}
+
+func (n *natsPublisher) Publish(ctx context.Context, msg models.BrokerMessage) (err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	// a broken connection is dropped and dialled again on the next publish
+	defer func() {
+		if err != nil {
+			n.closeConn()
+		}
+	}()
+	if n.conn == nil {
+		if err = n.connect(ctx); err != nil {
+			return fmt.Errorf("failed to connect to nats: %w", err)
+		}
+	}
+	n.setDeadline(ctx)
+
+	subj := subject(n.prefix, msg.Type)
+	var frame strings.Builder
+	if n.headers {
+		header := "NATS/1.0\r\nNats-Msg-Id: " + msg.ID + "\r\n\r\n"
+		fmt.Fprintf(&frame, "HPUB %s %d %d\r\n%s", subj, len(header), len(header)+len(msg.Body), header)
+	} else {
+		fmt.Fprintf(&frame, "PUB %s %d\r\n", subj, len(msg.Body))
+	}
+	frame.Write(msg.Body)
+	frame.WriteString("\r\nPING\r\n")
+	if _, err = n.conn.Write([]byte(frame.String())); err != nil {
+		return fmt.Errorf("failed to publish to nats: %w", err)
+	}
+	return n.awaitPong()
+}
+
+func (n *natsPublisher) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.closeConn()
+	return nil
+}
+
+func (n *natsPublisher) connect(ctx context.Context) error {
+	dialer := &net.Dialer{Timeout: publishTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return err
+	}
+	n.conn = conn
+	n.reader = bufio.NewReader(conn)
+	n.setDeadline(ctx)
+
+	line, err := n.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("unexpected greeting from nats: %q", line)
+	}
+	var info natsServerInfo
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info); err != nil {
+		return fmt.Errorf("invalid nats server info: %w", err)
+	}
+	if n.useTLS || info.TLSRequired {
+		host, _, _ := net.SplitHostPort(n.addr)
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return fmt.Errorf("nats tls handshake failed: %w", err)
+		}
+		n.conn = tlsConn
+		n.reader = bufio.NewReader(tlsConn)
+		n.setDeadline(ctx)
+	}
+	n.headers = info.Headers
+
+	options := map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "asset-manager",
+		"lang":     "go",
+		"version":  "1.0.0",
+		"protocol": 1,
+		"headers":  info.Headers,
+	}
+	if n.username != "" {
+		options["user"], options["pass"] = n.username, n.password
+	}
+	if n.token != "" {
+		options["auth_token"] = n.token
+	}
+	connect, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	if _, err := n.conn.Write([]byte("CONNECT " + string(connect) + "\r\nPING\r\n")); err != nil {
+		return err
+	}
+	return n.awaitPong()
+}
+
+// awaitPong reads until the PONG answering our PING, the server may interleave its own PINGs and INFO
+// updates, an -ERR means it refused the connection or the message
+func (n *natsPublisher) awaitPong() error {
+	for {
+		line, err := n.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := n.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New("nats: " + strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")), "'"))
+		}
+	}
+}
+
+func (n *natsPublisher) readLine() (string, error) {
+	line, err := n.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (n *natsPublisher) setDeadline(ctx context.Context) {
+	deadline := time.Now().Add(publishTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	n.conn.SetDeadline(deadline)
+}
+
+func (n *natsPublisher) closeConn() {
+	if n.conn != nil {
+		n.conn.Close()
+		n.conn = nil
+		n.reader = nil
+	}
+}
@@ -0,0 +1,75 @@
+package eventprovider
+
+import (
+	"asset/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// rabbitMQPublisher publishes through the management api to a topic exchange, the routing key is
+// "<topic>.<event type>". Messages are persistent, and a message no queue is bound for counts as a
+// failure so it is retried instead of silently dropped
+type rabbitMQPublisher struct {
+	url      string
+	vhost    string
+	exchange string
+	prefix   string
+	username string
+	password string
+	client   *http.Client
+}
+
+func (r *rabbitMQPublisher) Publish(ctx context.Context, msg models.BrokerMessage) error {
+	routingKey := subject(r.prefix, msg.Type)
+	payload, err := json.Marshal(map[string]interface{}{
+		"properties": map[string]interface{}{
+			"content_type":  "application/json",
+			"delivery_mode": 2,
+			"message_id":    msg.ID,
+			"type":          msg.Type,
+		},
+		"routing_key":      routingKey,
+		"payload":          string(msg.Body),
+		"payload_encoding": "string",
+	})
+	if err != nil {
+		return err
+	}
+	endpoint := r.url + "/api/exchanges/" + url.PathEscape(r.vhost) + "/" + url.PathEscape(r.exchange) + "/publish"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(r.username, r.password)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach rabbitmq: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 500))
+		return fmt.Errorf("rabbitmq returned %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+
+	var result struct {
+		Routed bool `json:"routed"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("failed to decode rabbitmq response: %w", err)
+	}
+	if !result.Routed {
+		return fmt.Errorf("rabbitmq routed event %s to no queue, routing key %s", msg.ID, routingKey)
+	}
+	return nil
+}
+
+func (r *rabbitMQPublisher) Close() error {
+	return nil
+}
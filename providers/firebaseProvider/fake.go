@@ -0,0 +1,169 @@
+package firebaseprovider
+
+import (
+	"asset/providers"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	firebaseauth "firebase.google.com/go/v4/auth"
+	"github.com/google/uuid"
+)
+
+// fakeIssuer marks tokens of the fake provider, no real issuer uses it
+const fakeIssuer = "fake"
+
+type fakeFirebaseService struct {
+	mu    sync.Mutex
+	users map[string]*firebaseauth.UserRecord
+}
+
+// NewFakeFirebaseProvider keeps accounts in the process for AUTH_MODE=fake. Any id token is taken as
+// the email to sign in as, so it is only allowed in the development profile
+func NewFakeFirebaseProvider() providers.FirebaseProvider {
+	return &fakeFirebaseService{users: map[string]*firebaseauth.UserRecord{}}
+}
+
+// VerifyIDToken signs in as the email the token holds and creates its account when it has none
+func (f *fakeFirebaseService) VerifyIDToken(ctx context.Context, idToken string) (*firebaseauth.Token, error) {
+	email := strings.ToLower(strings.TrimSpace(idToken))
+	if !strings.Contains(email, "@") {
+		return nil, fmt.Errorf("fake firebase takes the email to sign in as for the id token")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	record := f.byEmail(email)
+	if record == nil {
+		record = f.create(email)
+	}
+	now := time.Now()
+	return &firebaseauth.Token{
+		AuthTime: now.Unix(),
+		Issuer:   fakeIssuer,
+		IssuedAt: now.Unix(),
+		Expires:  now.Add(time.Hour).Unix(),
+		Subject:  record.UID,
+		UID:      record.UID,
+		Claims: map[string]interface{}{
+			"email":          record.Email,
+			"email_verified": true,
+			"name":           record.DisplayName,
+		},
+	}, nil
+}
+
+func (f *fakeFirebaseService) GetUserByUID(ctx context.Context, uid string) (*firebaseauth.UserRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	record, ok := f.users[uid]
+	if !ok {
+		return nil, fmt.Errorf("no user with uid %s", uid)
+	}
+	return record, nil
+}
+
+func (f *fakeFirebaseService) GetUserByEmail(ctx context.Context, email string) (*firebaseauth.UserRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	record := f.byEmail(email)
+	if record == nil {
+		return nil, fmt.Errorf("no user with email %s", email)
+	}
+	return record, nil
+}
+
+func (f *fakeFirebaseService) CreateUser(ctx context.Context, email string) (*firebaseauth.UserRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if email != "" && f.byEmail(email) != nil {
+		return nil, fmt.Errorf("email %s is taken", email)
+	}
+	return f.create(email), nil
+}
+
+func (f *fakeFirebaseService) DeleteAuthUser(ctx context.Context, uid string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.users, uid)
+	return nil
+}
+
+// GetAuthUserID returns an empty uid for an unknown email, the user-not-found error of the sdk can't be
+// built outside of it
+func (f *fakeFirebaseService) GetAuthUserID(ctx context.Context, email string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if record := f.byEmail(email); record != nil {
+		return record.UID, nil
+	}
+	return "", nil
+}
+
+func (f *fakeFirebaseService) UpdateUserEmail(ctx context.Context, uid, email string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	record, ok := f.users[uid]
+	if !ok {
+		return fmt.Errorf("no user with uid %s", uid)
+	}
+	record.Email = strings.ToLower(email)
+	record.EmailVerified = true
+	return nil
+}
+
+func (f *fakeFirebaseService) GetUsersByUID(ctx context.Context, uids []string) ([]*firebaseauth.UserRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	records := make([]*firebaseauth.UserRecord, 0, len(uids))
+	for _, uid := range uids {
+		if record, ok := f.users[uid]; ok {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (f *fakeFirebaseService) SetCustomUserClaims(ctx context.Context, uid string, claims map[string]interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	record, ok := f.users[uid]
+	if !ok {
+		return fmt.Errorf("no user with uid %s", uid)
+	}
+	record.CustomClaims = claims
+	return nil
+}
+
+func (f *fakeFirebaseService) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (f *fakeFirebaseService) byEmail(email string) *firebaseauth.UserRecord {
+	email = strings.ToLower(email)
+	for _, record := range f.users {
+		if record.Email == email {
+			return record
+		}
+	}
+	return nil
+}
+
+// create names the account after the part of the email before the @, jane.doe becomes jane doe
+func (f *fakeFirebaseService) create(email string) *firebaseauth.UserRecord {
+	email = strings.ToLower(email)
+	name, _, _ := strings.Cut(email, "@")
+	record := &firebaseauth.UserRecord{
+		UserInfo: &firebaseauth.UserInfo{
+			UID:         uuid.NewString(),
+			Email:       email,
+			DisplayName: strings.ReplaceAll(name, ".", " "),
+			ProviderID:  "firebase",
+		},
+		EmailVerified: true,
+		UserMetadata:  &firebaseauth.UserMetadata{CreationTimestamp: time.Now().UnixMilli()},
+	}
+	f.users[record.UID] = record
+	return record
+}
@@ -46,13 +46,50 @@ func (f *firebaseService) CreateUser(ctx context.Context, email string) (*fireba
 	if email != "" {
 		params = params.Email(email)
 	}
-	userRecords, err := f.client.CreateUser(context.Background(), params)
+	userRecords, err := f.client.CreateUser(ctx, params)
 	if err != nil {
 		return nil, err
 	}
 	return userRecords, nil
 }
 
+// UpdateUserEmail moves the firebase account to a new address, the caller has already verified it
+func (f *firebaseService) UpdateUserEmail(ctx context.Context, uid, email string) error {
+	params := (&firebaseauth.UserToUpdate{}).Email(email).EmailVerified(true)
+	_, err := f.client.UpdateUser(ctx, uid, params)
+	return err
+}
+
+// GetUsersByUID looks up to 100 users in one call, uids without a firebase account are left out
+func (f *firebaseService) GetUsersByUID(ctx context.Context, uids []string) ([]*firebaseauth.UserRecord, error) {
+	identifiers := make([]firebaseauth.UserIdentifier, 0, len(uids))
+	for _, uid := range uids {
+		identifiers = append(identifiers, firebaseauth.UIDIdentifier{UID: uid})
+	}
+	result, err := f.client.GetUsers(ctx, identifiers)
+	if err != nil {
+		return nil, err
+	}
+	return result.Users, nil
+}
+
+// SetCustomUserClaims replaces every custom claim of the user, clients see them after their next token refresh
+func (f *firebaseService) SetCustomUserClaims(ctx context.Context, uid string, claims map[string]interface{}) error {
+	return f.client.SetCustomUserClaims(ctx, uid, claims)
+}
+
+// pingUID never belongs to an account, looking it up proves the credentials and the network path
+// without touching real users
+const pingUID = "readiness-probe"
+
+func (f *firebaseService) Ping(ctx context.Context) error {
+	_, err := f.client.GetUser(ctx, pingUID)
+	if err == nil || firebaseauth.IsUserNotFound(err) {
+		return nil
+	}
+	return err
+}
+
 func (f *firebaseService) DeleteAuthUser(ctx context.Context, uid string) error {
 	return f.client.DeleteUser(ctx, uid)
 }
@@ -0,0 +1,58 @@
+package firebaseprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+
+	firebase "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/messaging"
+	"google.golang.org/api/option"
+)
+
+type pushService struct {
+	client *messaging.Client
+}
+
+// NewPushProvider sends through cloud messaging of the project the service account belongs to
+func NewPushProvider(serviceAccountJSON []byte) (providers.PushProvider, error) {
+	app, err := firebase.NewApp(context.Background(), nil, option.WithCredentialsJSON(serviceAccountJSON))
+	if err != nil {
+		return nil, err
+	}
+	client, err := app.Messaging(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return &pushService{client: client}, nil
+}
+
+// Send makes one multicast, fcm takes up to 500 tokens and a user keeps far fewer devices
+func (p *pushService) Send(ctx context.Context, tokens []string, msg models.PushMessage) ([]string, error) {
+	res, err := p.client.SendEachForMulticast(ctx, &messaging.MulticastMessage{
+		Tokens:       tokens,
+		Data:         msg.Data,
+		Notification: &messaging.Notification{Title: msg.Title, Body: msg.Body},
+		Android:      &messaging.AndroidConfig{Priority: "high"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	invalid := make([]string, 0)
+	var lastErr error
+	for i, sent := range res.Responses {
+		if sent.Success {
+			continue
+		}
+		// the app was uninstalled or the token was issued for another project
+		if messaging.IsUnregistered(sent.Error) || messaging.IsSenderIDMismatch(sent.Error) {
+			invalid = append(invalid, tokens[i])
+			continue
+		}
+		lastErr = sent.Error
+	}
+	if res.SuccessCount == 0 && lastErr != nil {
+		return invalid, lastErr
+	}
+	return invalid, nil
+}
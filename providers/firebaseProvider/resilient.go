@@ -0,0 +1,206 @@
+package firebaseprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	firebaseauth "firebase.google.com/go/v4/auth"
+	"firebase.google.com/go/v4/errorutils"
+	"go.uber.org/zap"
+)
+
+// resilientFirebase bounds every call with a timeout, retries transient failures of the calls that
+// are safe to repeat and stops calling firebase for a while once it keeps failing, so an outage
+// fails registration and deletion fast instead of holding requests until the write timeout
+type resilientFirebase struct {
+	next   providers.FirebaseProvider
+	cfg    models.FirebaseConfig
+	logger providers.ZapLoggerProvider
+
+	mu sync.Mutex
+	// transient failures in a row
+	failures int
+	// the breaker is open while this is set, calls before it fail at once
+	openUntil time.Time
+	// the one call let through after the cooldown is running, the rest keep failing until it returns
+	probing bool
+}
+
+func NewResilientFirebaseProvider(next providers.FirebaseProvider, cfg models.FirebaseConfig, logger providers.ZapLoggerProvider) providers.FirebaseProvider {
+	return &resilientFirebase{next: next, cfg: cfg, logger: logger}
+}
+
+// isTransient tells failures worth retrying, firebase being slow, unreachable or failing itself,
+// from answers like an unknown user or a bad token that would come back the same
+func isTransient(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) ||
+		errors.As(err, &netErr) ||
+		errorutils.IsUnavailable(err) ||
+		errorutils.IsInternal(err) ||
+		errorutils.IsDeadlineExceeded(err)
+}
+
+func (f *resilientFirebase) allow() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.openUntil.IsZero() {
+		return true
+	}
+	if f.probing || time.Now().Before(f.openUntil) {
+		return false
+	}
+	f.probing = true
+	return true
+}
+
+// record updates the breaker with the outcome of a call, any answer from firebase closes it
+func (f *resilientFirebase) record(op string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !isTransient(err) {
+		if !f.openUntil.IsZero() {
+			f.logger.GetLogger().Info("firebase answers again, closing the breaker", zap.String("op", op))
+		}
+		f.failures, f.openUntil, f.probing = 0, time.Time{}, false
+		return
+	}
+	f.failures++
+	if f.probing || f.failures >= f.cfg.BreakerFailures {
+		f.openUntil = time.Now().Add(f.cfg.BreakerCooldown)
+		f.probing = false
+		f.logger.GetLogger().Warn("firebase keeps failing, opening the breaker", zap.String("op", op), zap.Int("failures", f.failures), zap.Duration("cooldown", f.cfg.BreakerCooldown), zap.Error(err))
+	}
+}
+
+// abandon is for a call whose caller went away, it says nothing about firebase
+func (f *resilientFirebase) abandon() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.probing = false
+}
+
+// call runs fn with a timeout per attempt. With retry a transient failure is tried again up to
+// MaxAttempts, waiting RetryBackoff, then twice that and so on
+func (f *resilientFirebase) call(ctx context.Context, op string, retry bool, fn func(ctx context.Context) error) error {
+	attempts := 1
+	if retry && f.cfg.MaxAttempts > 1 {
+		attempts = f.cfg.MaxAttempts
+	}
+	backoff := f.cfg.RetryBackoff
+	for attempt := 1; ; attempt++ {
+		if !f.allow() {
+			return models.ErrFirebaseUnavailable
+		}
+		attemptCtx, cancel := context.WithTimeout(ctx, f.cfg.Timeout)
+		err := fn(attemptCtx)
+		cancel()
+		if ctx.Err() != nil {
+			f.abandon()
+			return err
+		}
+		f.record(op, err)
+		if err == nil || !isTransient(err) || attempt >= attempts {
+			return err
+		}
+		wait := backoff
+		if backoff > 0 {
+			wait += time.Duration(rand.Int63n(int64(backoff/2) + 1))
+		}
+		f.logger.GetLogger().Warn("firebase call failed, retrying", zap.String("op", op), zap.Int("attempt", attempt), zap.Duration("wait", wait), zap.Error(err))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		backoff *= 2
+	}
+}
+
+func (f *resilientFirebase) VerifyIDToken(ctx context.Context, idToken string) (token *firebaseauth.Token, err error) {
+	err = f.call(ctx, "verify_id_token", true, func(ctx context.Context) (err error) {
+		token, err = f.next.VerifyIDToken(ctx, idToken)
+		return err
+	})
+	return token, err
+}
+
+func (f *resilientFirebase) GetUserByUID(ctx context.Context, uid string) (record *firebaseauth.UserRecord, err error) {
+	err = f.call(ctx, "get_user", true, func(ctx context.Context) (err error) {
+		record, err = f.next.GetUserByUID(ctx, uid)
+		return err
+	})
+	return record, err
+}
+
+func (f *resilientFirebase) GetUserByEmail(ctx context.Context, email string) (record *firebaseauth.UserRecord, err error) {
+	err = f.call(ctx, "get_user_by_email", true, func(ctx context.Context) (err error) {
+		record, err = f.next.GetUserByEmail(ctx, email)
+		return err
+	})
+	return record, err
+}
+
+// CreateUser is not retried, a create that timed out may still have gone through and a second one
+// would then fail on the taken address
+func (f *resilientFirebase) CreateUser(ctx context.Context, email string) (record *firebaseauth.UserRecord, err error) {
+	err = f.call(ctx, "create_user", false, func(ctx context.Context) (err error) {
+		record, err = f.next.CreateUser(ctx, email)
+		return err
+	})
+	return record, err
+}
+
+func (f *resilientFirebase) DeleteAuthUser(ctx context.Context, uid string) error {
+	retried := false
+	return f.call(ctx, "delete_user", true, func(ctx context.Context) error {
+		err := f.next.DeleteAuthUser(ctx, uid)
+		if retried && firebaseauth.IsUserNotFound(err) {
+			// the attempt that timed out went through after all
+			return nil
+		}
+		retried = true
+		return err
+	})
+}
+
+func (f *resilientFirebase) GetAuthUserID(ctx context.Context, email string) (uid string, err error) {
+	err = f.call(ctx, "get_user_by_email", true, func(ctx context.Context) (err error) {
+		uid, err = f.next.GetAuthUserID(ctx, email)
+		return err
+	})
+	return uid, err
+}
+
+func (f *resilientFirebase) UpdateUserEmail(ctx context.Context, uid, email string) error {
+	return f.call(ctx, "update_user_email", true, func(ctx context.Context) error {
+		return f.next.UpdateUserEmail(ctx, uid, email)
+	})
+}
+
+func (f *resilientFirebase) GetUsersByUID(ctx context.Context, uids []string) (records []*firebaseauth.UserRecord, err error) {
+	err = f.call(ctx, "get_users", true, func(ctx context.Context) (err error) {
+		records, err = f.next.GetUsersByUID(ctx, uids)
+		return err
+	})
+	return records, err
+}
+
+func (f *resilientFirebase) SetCustomUserClaims(ctx context.Context, uid string, claims map[string]interface{}) error {
+	return f.call(ctx, "set_custom_claims", true, func(ctx context.Context) error {
+		return f.next.SetCustomUserClaims(ctx, uid, claims)
+	})
+}
+
+// Ping skips the breaker, readiness should see whether firebase answers rather than the breaker state
+func (f *resilientFirebase) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, f.cfg.Timeout)
+	defer cancel()
+	return f.next.Ping(ctx)
+}
@@ -0,0 +1,73 @@
+package firebaseprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestResilientFirebase(t *testing.T) {
+	ctx := context.Background()
+	cfg := models.FirebaseConfig{
+		Timeout:         time.Second,
+		MaxAttempts:     3,
+		RetryBackoff:    time.Millisecond,
+		BreakerFailures: 3,
+		BreakerCooldown: 20 * time.Millisecond,
+	}
+	newProvider := func(ctrl *gomock.Controller) (*providers.MockFirebaseProvider, providers.FirebaseProvider) {
+		logger := providers.NewMockZapLoggerProvider(ctrl)
+		logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+		next := providers.NewMockFirebaseProvider(ctrl)
+		return next, NewResilientFirebaseProvider(next, cfg, logger)
+	}
+
+	t.Run("transient failure is retried", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		next, firebase := newProvider(ctrl)
+		gomock.InOrder(
+			next.EXPECT().GetAuthUserID(gomock.Any(), "a@remotestate.com").Return("", context.DeadlineExceeded),
+			next.EXPECT().GetAuthUserID(gomock.Any(), "a@remotestate.com").Return("uid-1", nil),
+		)
+
+		uid, err := firebase.GetAuthUserID(ctx, "a@remotestate.com")
+		assert.NoError(t, err)
+		assert.Equal(t, "uid-1", uid)
+	})
+
+	t.Run("other errors and creates are not retried", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		next, firebase := newProvider(ctrl)
+		next.EXPECT().GetUserByUID(gomock.Any(), "uid-1").Return(nil, errors.New("no user record found"))
+		next.EXPECT().CreateUser(gomock.Any(), "a@remotestate.com").Return(nil, context.DeadlineExceeded)
+
+		_, err := firebase.GetUserByUID(ctx, "uid-1")
+		assert.Error(t, err)
+		_, err = firebase.CreateUser(ctx, "a@remotestate.com")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("breaker opens, fails fast and closes after a good probe", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		next, firebase := newProvider(ctrl)
+		next.EXPECT().DeleteAuthUser(gomock.Any(), "uid-1").Return(context.DeadlineExceeded).Times(3)
+
+		err := firebase.DeleteAuthUser(ctx, "uid-1")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		err = firebase.DeleteAuthUser(ctx, "uid-1")
+		assert.ErrorIs(t, err, models.ErrFirebaseUnavailable)
+
+		time.Sleep(cfg.BreakerCooldown)
+		next.EXPECT().DeleteAuthUser(gomock.Any(), "uid-1").Return(nil)
+		next.EXPECT().UpdateUserEmail(gomock.Any(), "uid-1", "b@remotestate.com").Return(nil)
+		assert.NoError(t, firebase.DeleteAuthUser(ctx, "uid-1"))
+		assert.NoError(t, firebase.UpdateUserEmail(ctx, "uid-1", "b@remotestate.com"))
+	})
+}
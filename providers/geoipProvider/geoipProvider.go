@@ -0,0 +1,68 @@
+package geoipprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// NewGeoIPProvider returns the lookup at LOGIN_GEO_LOOKUP_URL, nil when logins aren't located. A
+// service that wants an api key takes it in the url
+func NewGeoIPProvider(cfg models.LoginAnomalyConfig) (providers.GeoIPProvider, error) {
+	if cfg.GeoLookupURL == "" {
+		return nil, nil
+	}
+	lookupURL, err := url.Parse(cfg.GeoLookupURL)
+	if err != nil || lookupURL.Host == "" {
+		return nil, fmt.Errorf("LOGIN_GEO_LOOKUP_URL %q is not a url", cfg.GeoLookupURL)
+	}
+	return &httpLookup{url: lookupURL, client: &http.Client{Timeout: cfg.GeoLookupTimeout}}, nil
+}
+
+// httpLookup asks GET <url>?ip=<ip>, the service answers
+// {"country": "IN", "city": "Pune", "latitude": 18.52, "longitude": 73.85}
+type httpLookup struct {
+	url    *url.URL
+	client *http.Client
+}
+
+func (h *httpLookup) Locate(ctx context.Context, ip string) (models.GeoLocation, error) {
+	var location models.GeoLocation
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return location, fmt.Errorf("%q is not an ip address", ip)
+	}
+	// office and vpn addresses are nowhere in particular
+	if addr.IsPrivate() || addr.IsLoopback() || addr.IsLinkLocalUnicast() || addr.IsUnspecified() {
+		return location, nil
+	}
+	u := *h.url
+	query := u.Query()
+	query.Set("ip", addr.String())
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return location, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return location, fmt.Errorf("failed to reach the geo ip lookup: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 500))
+		return location, fmt.Errorf("geo ip lookup returned %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&location); err != nil {
+		return location, fmt.Errorf("failed to decode geo ip lookup response: %w", err)
+	}
+	return location, nil
+}
@@ -0,0 +1,53 @@
+package geoipprovider
+
+import (
+	"asset/models"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPLookup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "key-1", r.URL.Query().Get("key"))
+		switch r.URL.Query().Get("ip") {
+		case "49.36.10.1":
+			w.Write([]byte(`{"country": "IN", "city": "Pune", "latitude": 18.52, "longitude": 73.85}`))
+		default:
+			http.Error(w, "unknown ip", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	lookup, err := NewGeoIPProvider(models.LoginAnomalyConfig{GeoLookupURL: server.URL + "/lookup?key=key-1", GeoLookupTimeout: time.Second})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	location, err := lookup.Locate(ctx, "49.36.10.1")
+	require.NoError(t, err)
+	assert.Equal(t, models.GeoLocation{Country: "IN", City: "Pune", Latitude: 18.52, Longitude: 73.85}, location)
+
+	location, err = lookup.Locate(ctx, "10.0.0.4")
+	require.NoError(t, err)
+	assert.Empty(t, location, "private addresses aren't looked up")
+
+	_, err = lookup.Locate(ctx, "8.8.8.8")
+	assert.ErrorContains(t, err, "geo ip lookup returned 404")
+
+	_, err = lookup.Locate(ctx, "localhost")
+	assert.Error(t, err)
+}
+
+func TestNewGeoIPProvider(t *testing.T) {
+	lookup, err := NewGeoIPProvider(models.LoginAnomalyConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, lookup)
+
+	_, err = NewGeoIPProvider(models.LoginAnomalyConfig{GeoLookupURL: "geo"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,65 @@
+package imeiprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// NewIMEILookupProvider returns the external registry at IMEI_LOOKUP_URL, nil when only the internal
+// blacklist is checked. The api key is read from secrets for every lookup so a rotated one is picked up
+func NewIMEILookupProvider(cfg models.IMEICheckConfig, secrets providers.SecretsProvider) (providers.IMEILookupProvider, error) {
+	if cfg.LookupURL == "" {
+		return nil, nil
+	}
+	lookupURL, err := url.Parse(cfg.LookupURL)
+	if err != nil || lookupURL.Host == "" {
+		return nil, fmt.Errorf("IMEI_LOOKUP_URL %q is not a url", cfg.LookupURL)
+	}
+	return &httpLookup{url: lookupURL, secrets: secrets, client: &http.Client{Timeout: cfg.Timeout}}, nil
+}
+
+// httpLookup asks GET <url>?imei=<imei> with the api key as a bearer token, the registry answers
+// {"blacklisted": true, "reason": "..."}
+type httpLookup struct {
+	url     *url.URL
+	secrets providers.SecretsProvider
+	client  *http.Client
+}
+
+func (h *httpLookup) Lookup(ctx context.Context, imei string) (models.IMEILookup, error) {
+	var result models.IMEILookup
+	key, err := h.secrets.GetSecret(ctx, models.SecretIMEILookupAPIKey)
+	if err != nil {
+		return result, fmt.Errorf("failed to read %s: %w", models.SecretIMEILookupAPIKey, err)
+	}
+	u := *h.url
+	query := u.Query()
+	query.Set("imei", imei)
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return result, err
+	}
+	req.Header.Set("Authorization", "Bearer "+key)
+	req.Header.Set("Accept", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return result, fmt.Errorf("failed to reach the imei registry: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 500))
+		return result, fmt.Errorf("imei registry returned %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return result, fmt.Errorf("failed to decode imei registry response: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,58 @@
+package imeiprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPLookup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer api-key", r.Header.Get("Authorization"))
+		assert.Equal(t, "tenant-1", r.URL.Query().Get("tenant"))
+		switch r.URL.Query().Get("imei") {
+		case "356000000000011":
+			w.Write([]byte(`{"blacklisted": true, "reason": "reported stolen to the carrier"}`))
+		case "356000000000029":
+			w.Write([]byte(`{"blacklisted": false}`))
+		default:
+			http.Error(w, "unknown imei", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	secrets := providers.NewMockSecretsProvider(ctrl)
+	secrets.EXPECT().GetSecret(gomock.Any(), models.SecretIMEILookupAPIKey).Return("api-key", nil).AnyTimes()
+	lookup, err := NewIMEILookupProvider(models.IMEICheckConfig{LookupURL: server.URL + "/check?tenant=tenant-1", Timeout: time.Second}, secrets)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	result, err := lookup.Lookup(ctx, "356000000000011")
+	require.NoError(t, err)
+	assert.Equal(t, models.IMEILookup{Blacklisted: true, Reason: "reported stolen to the carrier"}, result)
+
+	result, err = lookup.Lookup(ctx, "356000000000029")
+	require.NoError(t, err)
+	assert.False(t, result.Blacklisted)
+
+	_, err = lookup.Lookup(ctx, "1")
+	assert.ErrorContains(t, err, "imei registry returned 400")
+}
+
+func TestNewIMEILookupProvider(t *testing.T) {
+	lookup, err := NewIMEILookupProvider(models.IMEICheckConfig{}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, lookup)
+
+	_, err = NewIMEILookupProvider(models.IMEICheckConfig{LookupURL: "registry"}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,337 @@
+package ldapprovider
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// just enough BER for ldap v3: definite lengths and single byte tags
+const (
+	tagBoolean     = 0x01
+	tagInteger     = 0x02
+	tagOctetString = 0x04
+	tagEnumerated  = 0x0a
+	tagSequence    = 0x30
+	tagSet         = 0x31
+)
+
+// maxPacketSize bounds what a server may announce before the content is read, every search result
+// entry is a packet of its own so a few MiB is far more than one needs
+const maxPacketSize = 8 << 20
+
+var errMalformedPacket = errors.New("malformed ldap packet")
+
+type packet struct {
+	tag      byte
+	value    []byte
+	children []*packet
+}
+
+func isConstructed(tag byte) bool {
+	return tag&0x20 != 0
+}
+
+func newPrimitive(tag byte, value []byte) *packet {
+	return &packet{tag: tag, value: value}
+}
+
+func newString(tag byte, value string) *packet {
+	return newPrimitive(tag, []byte(value))
+}
+
+func newInteger(tag byte, value int64) *packet {
+	// two's complement, shortest form
+	var out []byte
+	for {
+		out = append([]byte{byte(value)}, out...)
+		if (value < 0x80 && value >= -0x80) || len(out) == 8 {
+			break
+		}
+		value >>= 8
+	}
+	return newPrimitive(tag, out)
+}
+
+func newBoolean(value bool) *packet {
+	if value {
+		return newPrimitive(tagBoolean, []byte{0xff})
+	}
+	return newPrimitive(tagBoolean, []byte{0x00})
+}
+
+func newConstructed(tag byte, children ...*packet) *packet {
+	return &packet{tag: tag, children: children}
+}
+
+func (p *packet) add(children ...*packet) *packet {
+	p.children = append(p.children, children...)
+	return p
+}
+
+func (p *packet) bytes() []byte {
+	content := p.value
+	if isConstructed(p.tag) {
+		content = nil
+		for _, child := range p.children {
+			content = append(content, child.bytes()...)
+		}
+	}
+	out := []byte{p.tag}
+	out = append(out, encodeLength(len(content))...)
+	return append(out, content...)
+}
+
+func encodeLength(n int) []byte {
+	if n < 0x80 {
+		return []byte{byte(n)}
+	}
+	var out []byte
+	for n > 0 {
+		out = append([]byte{byte(n)}, out...)
+		n >>= 8
+	}
+	return append([]byte{0x80 | byte(len(out))}, out...)
+}
+
+// readPacket reads one complete element from the connection
+func readPacket(r *bufio.Reader) (*packet, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	first, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	length := int(first)
+	if first&0x80 != 0 {
+		n := int(first & 0x7f)
+		if n == 0 || n > 4 {
+			return nil, errMalformedPacket
+		}
+		length = 0
+		for i := 0; i < n; i++ {
+			b, err := r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			length = length<<8 | int(b)
+		}
+	}
+	if length > maxPacketSize {
+		return nil, errMalformedPacket
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return nil, err
+	}
+	return decodePacket(tag, content)
+}
+
+func decodePacket(tag byte, content []byte) (*packet, error) {
+	p := &packet{tag: tag, value: content}
+	if !isConstructed(tag) {
+		return p, nil
+	}
+	for len(content) > 0 {
+		child, rest, err := parseElement(content)
+		if err != nil {
+			return nil, err
+		}
+		p.children = append(p.children, child)
+		content = rest
+	}
+	return p, nil
+}
+
+func parseElement(data []byte) (*packet, []byte, error) {
+	if len(data) < 2 {
+		return nil, nil, errMalformedPacket
+	}
+	tag, first := data[0], data[1]
+	data = data[2:]
+	length := int(first)
+	if first&0x80 != 0 {
+		n := int(first & 0x7f)
+		if n == 0 || n > 4 || len(data) < n {
+			return nil, nil, errMalformedPacket
+		}
+		length = 0
+		for _, b := range data[:n] {
+			length = length<<8 | int(b)
+		}
+		data = data[n:]
+	}
+	if length < 0 || len(data) < length {
+		return nil, nil, errMalformedPacket
+	}
+	p, err := decodePacket(tag, data[:length])
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, data[length:], nil
+}
+
+func (p *packet) integer() int64 {
+	var v int64
+	for i, b := range p.value {
+		if i == 0 && b&0x80 != 0 {
+			v = -1
+		}
+		v = v<<8 | int64(b)
+	}
+	return v
+}
+
+func (p *packet) child(i int) *packet {
+	if i < 0 || i >= len(p.children) {
+		return &packet{}
+	}
+	return p.children[i]
+}
+
+// parseFilter turns an rfc 4515 string filter into its BER form
+func parseFilter(filter string) (*packet, error) {
+	filter = strings.TrimSpace(filter)
+	if !strings.HasPrefix(filter, "(") {
+		filter = "(" + filter + ")"
+	}
+	p, rest, err := parseFilterExpr(filter)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(rest) != "" {
+		return nil, fmt.Errorf("invalid ldap filter %q: trailing characters", filter)
+	}
+	return p, nil
+}
+
+func parseFilterExpr(s string) (*packet, string, error) {
+	if len(s) < 3 || s[0] != '(' {
+		return nil, "", fmt.Errorf("invalid ldap filter near %q", s)
+	}
+	s = s[1:]
+	switch s[0] {
+	case '&', '|', '!':
+		tag := map[byte]byte{'&': 0xa0, '|': 0xa1, '!': 0xa2}[s[0]]
+		op := newConstructed(tag)
+		s = s[1:]
+		for strings.HasPrefix(s, "(") {
+			child, rest, err := parseFilterExpr(s)
+			if err != nil {
+				return nil, "", err
+			}
+			op.add(child)
+			s = rest
+		}
+		if !strings.HasPrefix(s, ")") || len(op.children) == 0 || (tag == 0xa2 && len(op.children) != 1) {
+			return nil, "", fmt.Errorf("invalid ldap filter near %q", s)
+		}
+		return op, s[1:], nil
+	}
+	end := strings.IndexByte(s, ')')
+	if end < 0 {
+		return nil, "", errors.New("invalid ldap filter: unbalanced parentheses")
+	}
+	item, err := parseFilterItem(s[:end])
+	if err != nil {
+		return nil, "", err
+	}
+	return item, s[end+1:], nil
+}
+
+func parseFilterItem(item string) (*packet, error) {
+	eq := strings.IndexByte(item, '=')
+	if eq <= 0 {
+		return nil, fmt.Errorf("invalid ldap filter item %q", item)
+	}
+	attr, value := item[:eq], item[eq+1:]
+	switch {
+	case strings.HasSuffix(attr, ":"):
+		// extensible match, e.g. userAccountControl:1.2.840.113556.1.4.803:=2
+		parts := strings.Split(strings.TrimSuffix(attr, ":"), ":")
+		decoded, err := unescapeFilterValue(value)
+		if err != nil {
+			return nil, err
+		}
+		match := newConstructed(0xa9)
+		dnAttributes := false
+		var rule string
+		for _, part := range parts[1:] {
+			if strings.EqualFold(part, "dn") {
+				dnAttributes = true
+			} else {
+				rule = part
+			}
+		}
+		if rule != "" {
+			match.add(newString(0x81, rule))
+		}
+		if parts[0] != "" {
+			match.add(newString(0x82, parts[0]))
+		}
+		match.add(newPrimitive(0x83, decoded))
+		if dnAttributes {
+			match.add(newPrimitive(0x84, []byte{0xff}))
+		}
+		return match, nil
+	case strings.HasSuffix(attr, ">"), strings.HasSuffix(attr, "<"), strings.HasSuffix(attr, "~"):
+		tag := map[byte]byte{'>': 0xa5, '<': 0xa6, '~': 0xa8}[attr[len(attr)-1]]
+		decoded, err := unescapeFilterValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return newConstructed(tag, newString(tagOctetString, attr[:len(attr)-1]), newPrimitive(tagOctetString, decoded)), nil
+	case value == "*":
+		return newString(0x87, attr), nil
+	case strings.Contains(value, "*"):
+		parts := strings.Split(value, "*")
+		subs := newConstructed(tagSequence)
+		for i, part := range parts {
+			if part == "" {
+				continue
+			}
+			decoded, err := unescapeFilterValue(part)
+			if err != nil {
+				return nil, err
+			}
+			tag := byte(0x81)
+			if i == 0 {
+				tag = 0x80
+			} else if i == len(parts)-1 {
+				tag = 0x82
+			}
+			subs.add(newPrimitive(tag, decoded))
+		}
+		return newConstructed(0xa4, newString(tagOctetString, attr), subs), nil
+	}
+	decoded, err := unescapeFilterValue(value)
+	if err != nil {
+		return nil, err
+	}
+	return newConstructed(0xa3, newString(tagOctetString, attr), newPrimitive(tagOctetString, decoded)), nil
+}
+
+func unescapeFilterValue(value string) ([]byte, error) {
+	out := make([]byte, 0, len(value))
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' {
+			out = append(out, value[i])
+			continue
+		}
+		if i+2 >= len(value) {
+			return nil, fmt.Errorf("invalid escape in ldap filter value %q", value)
+		}
+		b, err := hex.DecodeString(value[i+1 : i+3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid escape in ldap filter value %q", value)
+		}
+		out = append(out, b[0])
+		i += 2
+	}
+	return out, nil
+}
@@ -0,0 +1,71 @@
+package ldapprovider
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func read(data []byte) (*packet, error) {
+	return readPacket(bufio.NewReader(bytes.NewReader(data)))
+}
+
+func TestPacketRoundTrip(t *testing.T) {
+	// a bind request with a long password, so the outer length takes the long form
+	password := strings.Repeat("x", 300)
+	msg := newConstructed(tagSequence,
+		newInteger(tagInteger, 7),
+		newConstructed(0x60,
+			newInteger(tagInteger, 3),
+			newString(tagOctetString, "cn=admin,dc=example,dc=com"),
+			newString(0x80, password),
+		),
+		newBoolean(true),
+	)
+
+	decoded, err := read(msg.bytes())
+	require.NoError(t, err)
+	assert.Equal(t, byte(tagSequence), decoded.tag)
+	require.Len(t, decoded.children, 3)
+	assert.Equal(t, int64(7), decoded.child(0).integer())
+	bind := decoded.child(1)
+	assert.Equal(t, int64(3), bind.child(0).integer())
+	assert.Equal(t, "cn=admin,dc=example,dc=com", string(bind.child(1).value))
+	assert.Equal(t, password, string(bind.child(2).value))
+	assert.Equal(t, []byte{0xff}, decoded.child(2).value)
+	assert.Equal(t, msg.bytes(), decoded.bytes())
+}
+
+func TestIntegerRoundTrip(t *testing.T) {
+	for _, v := range []int64{0, 1, 127, 128, 255, 256, -1, -128, -129, 1 << 31, -(1 << 40)} {
+		assert.Equal(t, v, newInteger(tagInteger, v).integer(), "%d", v)
+	}
+}
+
+func TestReadPacketMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"indefinite length", []byte{tagSequence, 0x80}},
+		{"length of five bytes", []byte{tagSequence, 0x85, 0, 0, 0, 0, 1}},
+		{"length over the cap", []byte{tagSequence, 0x84, 0x7f, 0xff, 0xff, 0xff}},
+		{"child longer than its parent", []byte{tagSequence, 0x03, tagOctetString, 0x05, 'a'}},
+		{"child without a length", []byte{tagSequence, 0x01, tagOctetString}},
+		{"child with a truncated long length", []byte{tagSequence, 0x03, tagOctetString, 0x82, 0x01}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := read(tt.data)
+			assert.ErrorIs(t, err, errMalformedPacket)
+		})
+	}
+
+	// the announced content never arrives
+	_, err := read([]byte{tagOctetString, 0x05, 'a', 'b'})
+	assert.Error(t, err)
+}
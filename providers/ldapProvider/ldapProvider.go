@@ -0,0 +1,367 @@
+package ldapprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProviderName is the provider recorded on identities linked by the sync
+const ProviderName = "ldap"
+
+const (
+	appBindRequest         = 0x60
+	appBindResponse        = 0x61
+	appUnbindRequest       = 0x42
+	appSearchRequest       = 0x63
+	appSearchResultEntry   = 0x64
+	appSearchResultDone    = 0x65
+	appSearchResultRef     = 0x73
+	appExtendedRequest     = 0x77
+	appExtendedResponse    = 0x78
+	ctxControls            = 0xa0
+	pagedResultsControlOID = "1.2.840.113556.1.4.319"
+	startTLSOID            = "1.3.6.1.4.1.1466.20037"
+
+	pageSize       = 500
+	requestTimeout = 2 * time.Minute
+	// active directory userAccountControl ACCOUNTDISABLE flag
+	adAccountDisabled = 0x2
+)
+
+var ErrLDAPResult = errors.New("ldap request failed")
+
+type ldapService struct {
+	cfg models.LDAPConfig
+}
+
+func NewLDAPProvider(cfg models.LDAPConfig) providers.DirectoryProvider {
+	return &ldapService{cfg: cfg}
+}
+
+func (l *ldapService) Name() string {
+	return ProviderName
+}
+
+// Issuer scopes the synced ids to this directory
+func (l *ldapService) Issuer() string {
+	return l.cfg.URL + "/" + l.cfg.BaseDN
+}
+
+// ListUsers binds with the service account and pages through every user matching the filter
+func (l *ldapService) ListUsers(ctx context.Context) ([]models.DirectoryUser, error) {
+	conn, err := l.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.close()
+
+	if err := conn.bind(l.cfg.BindDN, l.cfg.BindPassword); err != nil {
+		return nil, err
+	}
+	filter, err := parseFilter(l.cfg.UserFilter)
+	if err != nil {
+		return nil, err
+	}
+	attributes := []string{l.cfg.IDAttribute, l.cfg.EmailAttribute, l.cfg.NameAttribute, l.cfg.GroupAttribute, "userAccountControl"}
+
+	users := make([]models.DirectoryUser, 0)
+	var cookie []byte
+	for {
+		entries, next, err := conn.search(l.cfg.BaseDN, filter, attributes, cookie)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			users = append(users, l.toUser(entry))
+		}
+		if len(next) == 0 {
+			return users, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		cookie = next
+	}
+}
+
+func (l *ldapService) toUser(entry ldapEntry) models.DirectoryUser {
+	user := models.DirectoryUser{
+		DN:     entry.dn,
+		Email:  strings.ToLower(strings.TrimSpace(entry.first(l.cfg.EmailAttribute))),
+		Name:   strings.TrimSpace(entry.first(l.cfg.NameAttribute)),
+		Groups: entry.values(l.cfg.GroupAttribute),
+	}
+	user.ID = entry.first(l.cfg.IDAttribute)
+	if strings.EqualFold(l.cfg.IDAttribute, "objectGUID") {
+		// binary in active directory
+		user.ID = hex.EncodeToString([]byte(user.ID))
+	}
+	if user.ID == "" {
+		user.ID = strings.ToLower(entry.dn)
+	}
+	if uac, err := strconv.ParseInt(entry.first("userAccountControl"), 10, 64); err == nil && uac&adAccountDisabled != 0 {
+		user.Disabled = true
+	}
+	for _, mapping := range l.cfg.RoleMappings {
+		if hasGroup(user.Groups, mapping.ClaimValue) {
+			user.Role = mapping.Role
+			break
+		}
+	}
+	return user
+}
+
+// hasGroup matches a full group dn or only its cn
+func hasGroup(groups []string, want string) bool {
+	for _, group := range groups {
+		if strings.EqualFold(group, want) {
+			return true
+		}
+		rdn, _, _ := strings.Cut(group, ",")
+		if _, cn, ok := strings.Cut(rdn, "="); ok && strings.EqualFold(strings.TrimSpace(cn), want) {
+			return true
+		}
+	}
+	return false
+}
+
+type ldapEntry struct {
+	dn         string
+	attributes map[string][]string
+}
+
+func (e ldapEntry) values(name string) []string {
+	return e.attributes[strings.ToLower(name)]
+}
+
+func (e ldapEntry) first(name string) string {
+	if v := e.values(name); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+type ldapConn struct {
+	conn      net.Conn
+	reader    *bufio.Reader
+	messageID int64
+}
+
+func (l *ldapService) dial(ctx context.Context) (*ldapConn, error) {
+	u, err := url.Parse(l.cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ldap url: %w", err)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "ldaps" {
+			host = net.JoinHostPort(u.Hostname(), "636")
+		} else {
+			host = net.JoinHostPort(u.Hostname(), "389")
+		}
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var conn net.Conn
+	switch u.Scheme {
+	case "ldaps":
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: u.Hostname()}}).DialContext(ctx, "tcp", host)
+	case "ldap":
+		conn, err = dialer.DialContext(ctx, "tcp", host)
+	default:
+		return nil, fmt.Errorf("unsupported ldap url scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ldap: %w", err)
+	}
+	deadline := time.Now().Add(requestTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+	c := &ldapConn{conn: conn, reader: bufio.NewReader(conn)}
+
+	if u.Scheme == "ldap" && l.cfg.StartTLS {
+		if err := c.startTLS(u.Hostname()); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (c *ldapConn) close() {
+	c.send(newPrimitive(appUnbindRequest, nil), nil)
+	c.conn.Close()
+}
+
+func (c *ldapConn) send(op *packet, controls *packet) (int64, error) {
+	c.messageID++
+	msg := newConstructed(tagSequence, newInteger(tagInteger, c.messageID), op)
+	if controls != nil {
+		msg.add(controls)
+	}
+	if _, err := c.conn.Write(msg.bytes()); err != nil {
+		return 0, fmt.Errorf("failed to write ldap request: %w", err)
+	}
+	return c.messageID, nil
+}
+
+// receive returns the protocol op and controls of the next message for id
+func (c *ldapConn) receive(id int64) (*packet, *packet, error) {
+	for {
+		msg, err := readPacket(c.reader)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read ldap response: %w", err)
+		}
+		if msg.tag != tagSequence || len(msg.children) < 2 {
+			return nil, nil, errMalformedPacket
+		}
+		if msg.child(0).integer() != id {
+			continue
+		}
+		var controls *packet
+		if len(msg.children) > 2 && msg.child(2).tag == ctxControls {
+			controls = msg.child(2)
+		}
+		return msg.child(1), controls, nil
+	}
+}
+
+func checkResult(op *packet, name string) error {
+	if len(op.children) < 3 {
+		return errMalformedPacket
+	}
+	if code := op.child(0).integer(); code != 0 {
+		return fmt.Errorf("%w: %s returned code %d: %s", ErrLDAPResult, name, code, string(op.child(2).value))
+	}
+	return nil
+}
+
+func (c *ldapConn) startTLS(serverName string) error {
+	id, err := c.send(newConstructed(appExtendedRequest, newString(0x80, startTLSOID)), nil)
+	if err != nil {
+		return err
+	}
+	op, _, err := c.receive(id)
+	if err != nil {
+		return err
+	}
+	if op.tag != appExtendedResponse {
+		return errMalformedPacket
+	}
+	if err := checkResult(op, "starttls"); err != nil {
+		return err
+	}
+	tlsConn := tls.Client(c.conn, &tls.Config{ServerName: serverName})
+	if err := tlsConn.Handshake(); err != nil {
+		return fmt.Errorf("ldap starttls handshake failed: %w", err)
+	}
+	c.conn = tlsConn
+	c.reader = bufio.NewReader(tlsConn)
+	return nil
+}
+
+func (c *ldapConn) bind(dn, password string) error {
+	id, err := c.send(newConstructed(appBindRequest,
+		newInteger(tagInteger, 3),
+		newString(tagOctetString, dn),
+		newString(0x80, password),
+	), nil)
+	if err != nil {
+		return err
+	}
+	op, _, err := c.receive(id)
+	if err != nil {
+		return err
+	}
+	if op.tag != appBindResponse {
+		return errMalformedPacket
+	}
+	return checkResult(op, "bind")
+}
+
+// search fetches one page, the returned cookie is empty on the last page
+func (c *ldapConn) search(baseDN string, filter *packet, attributes []string, cookie []byte) ([]ldapEntry, []byte, error) {
+	attrs := newConstructed(tagSequence)
+	for _, a := range attributes {
+		if a != "" {
+			attrs.add(newString(tagOctetString, a))
+		}
+	}
+	request := newConstructed(appSearchRequest,
+		newString(tagOctetString, baseDN),
+		newInteger(tagEnumerated, 2), // whole subtree
+		newInteger(tagEnumerated, 0), // never deref aliases
+		newInteger(tagInteger, 0),
+		newInteger(tagInteger, 0),
+		newBoolean(false),
+		filter,
+		attrs,
+	)
+	paging := newConstructed(tagSequence, newInteger(tagInteger, pageSize), newPrimitive(tagOctetString, cookie))
+	controls := newConstructed(ctxControls, newConstructed(tagSequence,
+		newString(tagOctetString, pagedResultsControlOID),
+		newPrimitive(tagOctetString, paging.bytes()),
+	))
+	id, err := c.send(request, controls)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries := make([]ldapEntry, 0)
+	for {
+		op, respControls, err := c.receive(id)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch op.tag {
+		case appSearchResultEntry:
+			entry := ldapEntry{dn: string(op.child(0).value), attributes: make(map[string][]string)}
+			for _, attr := range op.child(1).children {
+				name := strings.ToLower(string(attr.child(0).value))
+				for _, v := range attr.child(1).children {
+					entry.attributes[name] = append(entry.attributes[name], string(v.value))
+				}
+			}
+			entries = append(entries, entry)
+		case appSearchResultRef:
+			// referrals to other servers are not followed
+		case appSearchResultDone:
+			if err := checkResult(op, "search"); err != nil {
+				return nil, nil, err
+			}
+			return entries, pagingCookie(respControls), nil
+		default:
+			return nil, nil, errMalformedPacket
+		}
+	}
+}
+
+func pagingCookie(controls *packet) []byte {
+	if controls == nil {
+		return nil
+	}
+	for _, control := range controls.children {
+		if string(control.child(0).value) != pagedResultsControlOID {
+			continue
+		}
+		value := control.child(len(control.children) - 1)
+		paging, _, err := parseElement(value.value)
+		if err != nil {
+			return nil
+		}
+		return paging.child(1).value
+	}
+	return nil
+}
@@ -1,25 +1,62 @@
 package loggerProvider
 
 import (
+	"asset/models"
 	"asset/providers"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"log"
+	"os"
 )
 
 type LogProvider struct {
+	config models.LogConfig
+	// shared by every core so SetLevel applies to all of them
+	level  zap.AtomicLevel
 	logger *zap.Logger
 }
 
-func NewLogProvider() providers.ZapLoggerProvider {
-	return &LogProvider{}
+func NewLogProvider(config models.LogConfig) providers.ZapLoggerProvider {
+	return &LogProvider{config: config, level: zap.NewAtomicLevelAt(config.Level)}
 }
 
+// InitLogger builds the logger from the config, json entries for log collectors in production and
+// readable console lines in development, written to stdout, a rotated file or both
 func (l *LogProvider) InitLogger() {
-	var err error
-	l.logger, err = zap.NewDevelopment()
-	if err != nil {
-		log.Fatalf("Failed to initialize zap logger: %v", err)
+	var encoderConfig zapcore.EncoderConfig
+	var encoder zapcore.Encoder
+	options := []zap.Option{zap.AddCaller()}
+	if l.config.Format == models.LogFormatJSON {
+		encoderConfig = zap.NewProductionEncoderConfig()
+		encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+		options = append(options, zap.AddStacktrace(zapcore.ErrorLevel))
+	} else {
+		encoderConfig = zap.NewDevelopmentEncoderConfig()
+		encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+		options = append(options, zap.Development(), zap.AddStacktrace(zapcore.WarnLevel))
 	}
+
+	var cores []zapcore.Core
+	if l.config.Stdout || l.config.File == "" {
+		cores = append(cores, zapcore.NewCore(encoder, zapcore.Lock(os.Stdout), l.level))
+	}
+	if l.config.File != "" {
+		file, err := newRotatingFile(l.config.File, l.config.MaxSizeMB, l.config.MaxAgeDays, l.config.MaxBackups)
+		if err != nil {
+			log.Fatalf("Failed to initialize zap logger: %v", err)
+		}
+		// the console encoder's colour codes would end up as escape sequences in the file
+		fileEncoder := encoder
+		if l.config.Format != models.LogFormatJSON {
+			fileConfig := encoderConfig
+			fileConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+			fileEncoder = zapcore.NewConsoleEncoder(fileConfig)
+		}
+		cores = append(cores, zapcore.NewCore(fileEncoder, file, l.level))
+	}
+	l.logger = zap.New(zapcore.NewTee(cores...), options...)
 	zap.ReplaceGlobals(l.logger)
 }
 
@@ -32,3 +69,7 @@ func (l *LogProvider) SyncLogger() {
 func (l *LogProvider) GetLogger() *zap.Logger {
 	return l.logger
 }
+
+func (l *LogProvider) SetLevel(level zapcore.Level) {
+	l.level.SetLevel(level)
+}
@@ -0,0 +1,64 @@
+package mdmprovider
+
+import (
+	"asset/models"
+	"context"
+	"time"
+)
+
+// intuneProvider reads managed devices through microsoft graph, the app registration needs the
+// DeviceManagementManagedDevices.Read.All application permission
+type intuneProvider struct {
+	*apiClient
+}
+
+const intuneDevicesPath = "/v1.0/deviceManagement/managedDevices?$select=id,serialNumber,manufacturer,model,deviceName," +
+	"operatingSystem,osVersion,lastSyncDateTime,enrolledDateTime,emailAddress,userPrincipalName"
+
+type intuneDevice struct {
+	ID                string    `json:"id"`
+	SerialNumber      string    `json:"serialNumber"`
+	Manufacturer      string    `json:"manufacturer"`
+	Model             string    `json:"model"`
+	DeviceName        string    `json:"deviceName"`
+	OperatingSystem   string    `json:"operatingSystem"`
+	OSVersion         string    `json:"osVersion"`
+	LastSyncDateTime  time.Time `json:"lastSyncDateTime"`
+	EnrolledDateTime  time.Time `json:"enrolledDateTime"`
+	EmailAddress      string    `json:"emailAddress"`
+	UserPrincipalName string    `json:"userPrincipalName"`
+}
+
+func (i *intuneProvider) ListDevices(ctx context.Context) ([]models.MDMDevice, error) {
+	var devices []models.MDMDevice
+	// graph pages the list, each page links to the next until the last
+	for next := intuneDevicesPath; next != ""; {
+		var page struct {
+			Value    []intuneDevice `json:"value"`
+			NextLink string         `json:"@odata.nextLink"`
+		}
+		if err := i.get(ctx, next, &page); err != nil {
+			return nil, err
+		}
+		for _, d := range page.Value {
+			email := d.EmailAddress
+			if email == "" {
+				email = d.UserPrincipalName
+			}
+			devices = append(devices, models.MDMDevice{
+				ExternalID:   d.ID,
+				SerialNo:     d.SerialNumber,
+				Manufacturer: d.Manufacturer,
+				Model:        d.Model,
+				DeviceName:   d.DeviceName,
+				OS:           d.OperatingSystem,
+				OSVersion:    d.OSVersion,
+				LastCheckIn:  d.LastSyncDateTime,
+				EnrolledAt:   d.EnrolledDateTime,
+				UserEmail:    email,
+			})
+		}
+		next = page.NextLink
+	}
+	return devices, nil
+}
@@ -0,0 +1,68 @@
+package mdmprovider
+
+import (
+	"asset/models"
+	"context"
+	"fmt"
+	"time"
+)
+
+// jamfProvider reads computers from the jamf pro inventory, the api client needs the Read Computers
+// privilege. Macs are the only devices read, phones enrolled in jamf aren't discovered
+type jamfProvider struct {
+	*apiClient
+}
+
+const jamfPageSize = 100
+
+type jamfComputer struct {
+	ID      string `json:"id"`
+	General struct {
+		Name             string    `json:"name"`
+		LastContactTime  time.Time `json:"lastContactTime"`
+		LastEnrolledDate time.Time `json:"lastEnrolledDate"`
+	} `json:"general"`
+	Hardware struct {
+		Make         string `json:"make"`
+		Model        string `json:"model"`
+		SerialNumber string `json:"serialNumber"`
+	} `json:"hardware"`
+	UserAndLocation struct {
+		Email string `json:"email"`
+	} `json:"userAndLocation"`
+	OperatingSystem struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"operatingSystem"`
+}
+
+func (j *jamfProvider) ListDevices(ctx context.Context) ([]models.MDMDevice, error) {
+	var devices []models.MDMDevice
+	for page := 0; ; page++ {
+		var result struct {
+			TotalCount int            `json:"totalCount"`
+			Results    []jamfComputer `json:"results"`
+		}
+		path := fmt.Sprintf("/api/v1/computers-inventory?section=GENERAL&section=HARDWARE&section=USER_AND_LOCATION&section=OPERATING_SYSTEM&page=%d&page-size=%d&sort=id", page, jamfPageSize)
+		if err := j.get(ctx, path, &result); err != nil {
+			return nil, err
+		}
+		for _, c := range result.Results {
+			devices = append(devices, models.MDMDevice{
+				ExternalID:   c.ID,
+				SerialNo:     c.Hardware.SerialNumber,
+				Manufacturer: c.Hardware.Make,
+				Model:        c.Hardware.Model,
+				DeviceName:   c.General.Name,
+				OS:           c.OperatingSystem.Name,
+				OSVersion:    c.OperatingSystem.Version,
+				LastCheckIn:  c.General.LastContactTime,
+				EnrolledAt:   c.General.LastEnrolledDate,
+				UserEmail:    c.UserAndLocation.Email,
+			})
+		}
+		if len(result.Results) < jamfPageSize || len(devices) >= result.TotalCount {
+			return devices, nil
+		}
+	}
+}
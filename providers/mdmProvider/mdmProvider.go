@@ -0,0 +1,115 @@
+package mdmprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// NewMDMProvider returns the device management system picked by MDM_SYSTEM, nil when discovery is
+// off. The client secret is read from secrets whenever a token is fetched so a rotated one is picked up
+func NewMDMProvider(cfg models.MDMConfig, secrets providers.SecretsProvider) (providers.MDMProvider, error) {
+	if cfg.System == "" {
+		return nil, nil
+	}
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("MDM_URL is required for %s", cfg.System)
+	}
+	if cfg.ClientID == "" {
+		return nil, fmt.Errorf("MDM_CLIENT_ID is required for %s", cfg.System)
+	}
+	client := &apiClient{
+		url:      strings.TrimRight(cfg.URL, "/"),
+		clientID: cfg.ClientID,
+		secrets:  secrets,
+		client:   &http.Client{Timeout: cfg.Timeout},
+	}
+	switch cfg.System {
+	case models.MDMSystemIntune:
+		if cfg.TenantID == "" {
+			return nil, errors.New("MDM_TENANT_ID is required for intune")
+		}
+		client.tokenURL = "https://login.microsoftonline.com/" + url.PathEscape(cfg.TenantID) + "/oauth2/v2.0/token"
+		client.scope = "https://graph.microsoft.com/.default"
+		return &intuneProvider{apiClient: client}, nil
+	case models.MDMSystemJamf:
+		client.tokenURL = client.url + "/api/oauth/token"
+		return &jamfProvider{apiClient: client}, nil
+	default:
+		return nil, fmt.Errorf("unknown mdm system %q", cfg.System)
+	}
+}
+
+// apiClient signs in with the client credentials grant both systems support and keeps the token
+// until it is about to expire
+type apiClient struct {
+	url      string
+	tokenURL string
+	// intune asks for the graph scope, jamf grants the roles of its api client
+	scope    string
+	clientID string
+	secrets  providers.SecretsProvider
+	client   *http.Client
+	tokens   utils.TokenCache
+}
+
+func (c *apiClient) accessToken(ctx context.Context) (string, error) {
+	return c.tokens.Token(ctx, func(ctx context.Context) (utils.OAuthToken, error) {
+		var token utils.OAuthToken
+		secret, err := c.secrets.GetSecret(ctx, models.SecretMDMClientSecret)
+		if err != nil {
+			return token, fmt.Errorf("failed to read %s: %w", models.SecretMDMClientSecret, err)
+		}
+		req, err := utils.NewClientCredentialsRequest(ctx, c.tokenURL, c.clientID, secret, c.scope)
+		if err != nil {
+			return token, err
+		}
+		if err := c.send(req, &token); err != nil {
+			return token, fmt.Errorf("failed to sign in to the mdm: %w", err)
+		}
+		return token, nil
+	})
+}
+
+// get reads url, relative to the api unless it is absolute like a next page link, into out
+func (c *apiClient) get(ctx context.Context, path string, out interface{}) error {
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(path, "http") {
+		path = c.url + path
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return c.send(req, out)
+}
+
+func (c *apiClient) send(req *http.Request, out interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach the mdm: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 500))
+		return fmt.Errorf("mdm returned %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode mdm response: %w", err)
+	}
+	return nil
+}
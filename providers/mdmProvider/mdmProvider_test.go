@@ -0,0 +1,128 @@
+package mdmprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSecrets(ctrl *gomock.Controller) *providers.MockSecretsProvider {
+	secrets := providers.NewMockSecretsProvider(ctrl)
+	secrets.EXPECT().GetSecret(gomock.Any(), models.SecretMDMClientSecret).Return("client-secret", nil).AnyTimes()
+	return secrets
+}
+
+func TestIntuneProvider(t *testing.T) {
+	var signIns atomic.Int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			signIns.Add(1)
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+			assert.Equal(t, "app-id", r.PostForm.Get("client_id"))
+			assert.Equal(t, "client-secret", r.PostForm.Get("client_secret"))
+			assert.Equal(t, "https://graph.microsoft.com/.default", r.PostForm.Get("scope"))
+			w.Write([]byte(`{"access_token":"graph-token","expires_in":3600}`))
+		case "/v1.0/deviceManagement/managedDevices":
+			assert.Equal(t, "Bearer graph-token", r.Header.Get("Authorization"))
+			if r.URL.Query().Get("$skiptoken") == "" {
+				w.Write([]byte(`{"value":[{"id":"d1","serialNumber":"SN-1","manufacturer":"Dell","model":"Latitude 5440","deviceName":"LAP-1","operatingSystem":"Windows","osVersion":"10.0.22631","lastSyncDateTime":"2026-10-01T09:30:00Z","enrolledDateTime":"2025-01-15T00:00:00Z","emailAddress":"asha@remotestate.com"}],"@odata.nextLink":"` + server.URL + `/v1.0/deviceManagement/managedDevices?$skiptoken=2"}`))
+				return
+			}
+			w.Write([]byte(`{"value":[{"id":"d2","serialNumber":"SN-2","manufacturer":"Google","model":"Pixel 8","operatingSystem":"Android","userPrincipalName":"ravi@remotestate.com"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	cfg := models.MDMConfig{System: models.MDMSystemIntune, URL: server.URL, TenantID: "tenant", ClientID: "app-id", Timeout: time.Second}
+	intune, err := NewMDMProvider(cfg, newSecrets(ctrl))
+	require.NoError(t, err)
+	intune.(*intuneProvider).tokenURL = server.URL + "/token"
+
+	devices, err := intune.ListDevices(context.Background())
+	require.NoError(t, err)
+	require.Len(t, devices, 2)
+	assert.Equal(t, models.MDMDevice{
+		ExternalID:   "d1",
+		SerialNo:     "SN-1",
+		Manufacturer: "Dell",
+		Model:        "Latitude 5440",
+		DeviceName:   "LAP-1",
+		OS:           "Windows",
+		OSVersion:    "10.0.22631",
+		LastCheckIn:  time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC),
+		EnrolledAt:   time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC),
+		UserEmail:    "asha@remotestate.com",
+	}, devices[0])
+	assert.Equal(t, "ravi@remotestate.com", devices[1].UserEmail)
+
+	// the token is kept until it is about to expire
+	_, err = intune.ListDevices(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), signIns.Load())
+}
+
+func TestJamfProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/oauth/token":
+			require.NoError(t, r.ParseForm())
+			assert.Empty(t, r.PostForm.Get("scope"))
+			w.Write([]byte(`{"access_token":"jamf-token","expires_in":60}`))
+		case "/api/v1/computers-inventory":
+			assert.Equal(t, "Bearer jamf-token", r.Header.Get("Authorization"))
+			assert.Equal(t, []string{"GENERAL", "HARDWARE", "USER_AND_LOCATION", "OPERATING_SYSTEM"}, r.URL.Query()["section"])
+			assert.Equal(t, "0", r.URL.Query().Get("page"))
+			w.Write([]byte(`{"totalCount":1,"results":[{"id":"7","general":{"name":"Asha's MacBook","lastContactTime":"2026-10-02T08:00:00Z"},"hardware":{"make":"Apple","model":"MacBook Pro (14-inch, 2023)","serialNumber":"C02XL0GHJGH5"},"userAndLocation":{"email":"asha@remotestate.com"},"operatingSystem":{"name":"macOS","version":"14.6"}}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	jamf, err := NewMDMProvider(models.MDMConfig{System: models.MDMSystemJamf, URL: server.URL + "/", ClientID: "api-client", Timeout: time.Second}, newSecrets(ctrl))
+	require.NoError(t, err)
+
+	devices, err := jamf.ListDevices(context.Background())
+	require.NoError(t, err)
+	require.Len(t, devices, 1)
+	assert.Equal(t, "C02XL0GHJGH5", devices[0].SerialNo)
+	assert.Equal(t, "Apple", devices[0].Manufacturer)
+	assert.Equal(t, "macOS", devices[0].OS)
+	assert.Equal(t, time.Date(2026, 10, 2, 8, 0, 0, 0, time.UTC), devices[0].LastCheckIn)
+	assert.True(t, devices[0].EnrolledAt.IsZero())
+}
+
+func TestNewMDMProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	secrets := providers.NewMockSecretsProvider(ctrl)
+
+	mdm, err := NewMDMProvider(models.MDMConfig{}, secrets)
+	assert.NoError(t, err)
+	assert.Nil(t, mdm)
+
+	_, err = NewMDMProvider(models.MDMConfig{System: models.MDMSystemIntune, URL: "https://graph.microsoft.com", ClientID: "app-id"}, secrets)
+	assert.ErrorContains(t, err, "MDM_TENANT_ID")
+
+	// a failed sign in is reported, not answered with an empty device list
+	secrets.EXPECT().GetSecret(gomock.Any(), models.SecretMDMClientSecret).Return("", models.ErrSecretNotFound)
+	jamf, err := NewMDMProvider(models.MDMConfig{System: models.MDMSystemJamf, URL: "https://jamf.example.com", ClientID: "api-client"}, secrets)
+	require.NoError(t, err)
+	_, err = jamf.ListDevices(context.Background())
+	assert.ErrorIs(t, err, models.ErrSecretNotFound)
+}
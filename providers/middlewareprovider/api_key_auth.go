@@ -0,0 +1,157 @@
+package middlewareprovider
+
+import (
+	"asset/models"
+	"asset/utils"
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// APIKeyHeader carries an integration key instead of the Authorization jwt
+const APIKeyHeader = "X-API-Key"
+
+var ErrInvalidAPIKey = models.NewServiceError(http.StatusUnauthorized, "invalid_api_key", "invalid api key")
+
+type apiKeyRow struct {
+	ID        string         `db:"id"`
+	KeyHash   string         `db:"key_hash"`
+	Scopes    pq.StringArray `db:"scopes"`
+	OwnerID   string         `db:"owner_id"`
+	ExpiresAt time.Time      `db:"expires_at"`
+	// nil uses the default rate limit and quota
+	RateLimitPerMinute *int `db:"rate_limit_per_minute"`
+	MonthlyQuota       *int `db:"monthly_quota"`
+}
+
+// serveAPIKey authenticates the request as the key's owner, RequirePermission then also
+// checks the key's scopes so the key can never do more than it was issued for
+func (a *DefaultAuthMiddleware) serveAPIKey(w http.ResponseWriter, r *http.Request, next http.Handler, key string) {
+	row, roles, err := a.lookupAPIKey(r.Context(), key)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidAPIKey):
+			utils.RespondError(w, http.StatusUnauthorized, err, "invalid api key")
+		case errors.Is(err, errAPIKeyExpired):
+			utils.RespondError(w, http.StatusUnauthorized, err, "api key expired")
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, err, "failed to verify api key")
+		}
+		return
+	}
+
+	// written at most once a minute, busy integrations shouldn't turn every read into a write
+	a.db.ExecContext(r.Context(), `
+		UPDATE api_keys SET last_used_at = now()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - INTERVAL '1 minute')
+	`, row.ID)
+
+	recordRequestUser(r.Context(), row.OwnerID)
+	ctx := context.WithValue(r.Context(), UserContextKey, row.OwnerID)
+	ctx = context.WithValue(ctx, RolesContextKey, roles)
+	ctx = context.WithValue(ctx, TokenExpiryContextKey, row.ExpiresAt)
+	ctx = utils.ContextWithLocation(ctx, a.userLocation(ctx, row.OwnerID))
+	ctx = context.WithValue(ctx, APIKeyIDContextKey, row.ID)
+	ctx = context.WithValue(ctx, APIKeyScopesContextKey, []string(row.Scopes))
+	ctx = context.WithValue(ctx, APIKeyLimitsContextKey, models.APIKeyCaller{ID: row.ID, RateLimitPerMinute: row.RateLimitPerMinute, MonthlyQuota: row.MonthlyQuota})
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+var errAPIKeyExpired = models.NewServiceError(http.StatusUnauthorized, "api_key_expired", "api key expired")
+
+// lookupAPIKey checks the key against its stored hash and returns it with its owner's roles
+func (a *DefaultAuthMiddleware) lookupAPIKey(ctx context.Context, key string) (apiKeyRow, []string, error) {
+	prefix, ok := utils.ParseAPIKeyPrefix(key)
+	if !ok {
+		return apiKeyRow{}, nil, ErrInvalidAPIKey
+	}
+
+	// keys of archived users stop working with the account
+	var row apiKeyRow
+	err := a.db.GetContext(ctx, &row, `
+		SELECT k.id, k.key_hash, k.scopes, k.owner_id, k.expires_at, k.rate_limit_per_minute, k.monthly_quota
+		FROM api_keys k
+		JOIN users u ON u.id = k.owner_id AND u.archived_at IS NULL
+		WHERE k.prefix = $1 AND k.revoked_at IS NULL
+	`, prefix)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apiKeyRow{}, nil, ErrInvalidAPIKey
+		}
+		return apiKeyRow{}, nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(row.KeyHash), []byte(utils.HashAPIKey(key))) != 1 {
+		return apiKeyRow{}, nil, ErrInvalidAPIKey
+	}
+	if time.Now().After(row.ExpiresAt) {
+		return apiKeyRow{}, nil, errAPIKeyExpired
+	}
+
+	var roles []string
+	err = a.db.SelectContext(ctx, &roles, `SELECT role FROM user_roles WHERE user_id = $1 AND archived_at IS NULL`, row.OwnerID)
+	if err != nil {
+		return apiKeyRow{}, nil, fmt.Errorf("failed to fetch roles: %w", err)
+	}
+	return row, roles, nil
+}
+
+// RequireUserSession keeps api keys and service accounts away from routes that act on the caller's
+// own account (mfa, email, delegations), those aren't covered by any scope or role
+func (a *DefaultAuthMiddleware) RequireUserSession() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := r.Context().Value(APIKeyIDContextKey).(string); ok {
+				utils.RespondError(w, http.StatusForbidden, errors.New("api key used on a session route"), "this route can't be used with an api key")
+				return
+			}
+			if isService, _ := r.Context().Value(ServiceAccountContextKey).(bool); isService {
+				utils.RespondError(w, http.StatusForbidden, errors.New("service account used on a session route"), "this route can't be used with a service account")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireAPIKey is the opposite of RequireUserSession, for routes only a device holding a scoped key
+// should call (kiosks), a signed in user can't reach them with their session
+func (a *DefaultAuthMiddleware) RequireAPIKey() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := r.Context().Value(APIKeyIDContextKey).(string); !ok {
+				utils.RespondError(w, http.StatusForbidden, errors.New("api key route used without an api key"), "this route can only be used with an api key")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// GetAPIKeyFromContext returns the api key the request authenticated with and its own limits, false
+// for requests signed in with a jwt
+func (a *DefaultAuthMiddleware) GetAPIKeyFromContext(r *http.Request) (models.APIKeyCaller, bool) {
+	caller, ok := r.Context().Value(APIKeyLimitsContextKey).(models.APIKeyCaller)
+	return caller, ok
+}
+
+// hasScope reports whether an api key authenticated request may use any of the permissions,
+// requests authenticated with a jwt are not limited by scopes
+func hasScope(r *http.Request, permissions []string) bool {
+	scopes, ok := r.Context().Value(APIKeyScopesContextKey).([]string)
+	if !ok {
+		return true
+	}
+	for _, permission := range permissions {
+		if slices.Contains(scopes, permission) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package middlewareprovider
+
+import (
+	"asset/models"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"time"
+)
+
+const (
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+	// readable by the spa, which echoes it in CSRFHeader (double submit)
+	CSRFCookie = "csrf_token"
+	CSRFHeader = "X-CSRF-Token"
+
+	// the refresh token is only needed by the refresh and logout endpoints, both under /api
+	refreshCookiePath = "/api"
+)
+
+// SetAuthCookies hands the session to the browser and returns the csrf token the client must echo
+func SetAuthCookies(w http.ResponseWriter, cfg models.AuthCookieConfig, accessToken, refreshToken string) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	csrfToken := base64.RawURLEncoding.EncodeToString(raw)
+	settings := currentJWTSettings()
+
+	http.SetCookie(w, authCookie(cfg, AccessTokenCookie, accessToken, "/", settings.accessTokenTTL, true))
+	http.SetCookie(w, authCookie(cfg, RefreshTokenCookie, refreshToken, refreshCookiePath, settings.refreshTokenTTL, true))
+	http.SetCookie(w, authCookie(cfg, CSRFCookie, csrfToken, "/", settings.refreshTokenTTL, false))
+	return csrfToken, nil
+}
+
+func ClearAuthCookies(w http.ResponseWriter, cfg models.AuthCookieConfig) {
+	http.SetCookie(w, authCookie(cfg, AccessTokenCookie, "", "/", -1, true))
+	http.SetCookie(w, authCookie(cfg, RefreshTokenCookie, "", refreshCookiePath, -1, true))
+	http.SetCookie(w, authCookie(cfg, CSRFCookie, "", "/", -1, false))
+}
+
+func authCookie(cfg models.AuthCookieConfig, name, value, path string, ttl time.Duration, httpOnly bool) *http.Cookie {
+	maxAge := int(ttl.Seconds())
+	if ttl < 0 {
+		maxAge = -1
+	}
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   cfg.Domain,
+		MaxAge:   maxAge,
+		Secure:   cfg.Secure,
+		HttpOnly: httpOnly,
+		SameSite: cfg.SameSite,
+	}
+}
+
+// ValidCSRF checks a cookie authenticated request, safe methods don't change anything and pass
+func ValidCSRF(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	cookie, err := r.Cookie(CSRFCookie)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+	header := r.Header.Get(CSRFHeader)
+	return subtle.ConstantTimeCompare([]byte(header), []byte(cookie.Value)) == 1
+}
+
+// CookieValue returns the named cookie or an empty string
+func CookieValue(r *http.Request, name string) string {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
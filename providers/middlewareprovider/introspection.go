@@ -0,0 +1,58 @@
+package middlewareprovider
+
+import (
+	"asset/models"
+	"context"
+	"errors"
+	"strings"
+)
+
+// IntrospectToken reports whether an access token or api key would be accepted right now, applying the
+// same checks as JWTAuthMiddleware. The error is only set when the check itself failed
+func (a *DefaultAuthMiddleware) IntrospectToken(ctx context.Context, token string) (models.TokenIntrospection, error) {
+	inactive := models.TokenIntrospection{}
+	if strings.HasPrefix(token, "ak_") {
+		row, roles, err := a.lookupAPIKey(ctx, token)
+		if err != nil {
+			if errors.Is(err, ErrInvalidAPIKey) || errors.Is(err, errAPIKeyExpired) {
+				return inactive, nil
+			}
+			return inactive, err
+		}
+		return models.TokenIntrospection{
+			Active:    true,
+			TokenType: "api_key",
+			Subject:   row.OwnerID,
+			Principal: "user",
+			Roles:     roles,
+			Scope:     strings.Join(row.Scopes, " "),
+			ExpiresAt: row.ExpiresAt.Unix(),
+		}, nil
+	}
+
+	subject, roles, err := ParseJWT(token)
+	if err != nil {
+		return inactive, nil
+	}
+	issuedAt, err := GetTokenIssuedAt(token)
+	if err != nil || a.isRevoked(ctx, subject, issuedAt) {
+		return inactive, nil
+	}
+	expiresAt, err := GetTokenExpiry(token)
+	if err != nil {
+		return inactive, nil
+	}
+	principal := "user"
+	if IsServiceAccountToken(token) {
+		principal = servicePrincipal
+	}
+	return models.TokenIntrospection{
+		Active:    true,
+		TokenType: "access_token",
+		Subject:   subject,
+		Principal: principal,
+		Roles:     roles,
+		ExpiresAt: expiresAt.Unix(),
+		IssuedAt:  issuedAt.Unix(),
+	}, nil
+}
@@ -0,0 +1,83 @@
+package middlewareprovider
+
+import (
+	"asset/providers"
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang/mock/gomock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntrospectToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ctx := context.Background()
+
+	userToken, err := GenerateJWT("user-1", []string{"employee"})
+	require.NoError(t, err)
+	serviceToken, err := GenerateServiceAccountJWT("account-1", []string{"asset_manager"})
+	require.NoError(t, err)
+
+	t.Run("active user token", func(t *testing.T) {
+		redis := providers.NewMockRedisProvider(ctrl)
+		redis.EXPECT().Get(ctx, revokedBeforeKey("user-1")).Return("", nil)
+		auth := &DefaultAuthMiddleware{redis: redis}
+
+		res, err := auth.IntrospectToken(ctx, userToken)
+		require.NoError(t, err)
+		assert.True(t, res.Active)
+		assert.Equal(t, "access_token", res.TokenType)
+		assert.Equal(t, "user-1", res.Subject)
+		assert.Equal(t, "user", res.Principal)
+		assert.Equal(t, []string{"employee"}, res.Roles)
+		assert.Greater(t, res.ExpiresAt, time.Now().Unix())
+	})
+
+	t.Run("service account token", func(t *testing.T) {
+		redis := providers.NewMockRedisProvider(ctrl)
+		redis.EXPECT().Get(ctx, revokedBeforeKey("account-1")).Return("", nil)
+		auth := &DefaultAuthMiddleware{redis: redis}
+
+		res, err := auth.IntrospectToken(ctx, serviceToken)
+		require.NoError(t, err)
+		assert.True(t, res.Active)
+		assert.Equal(t, "service_account", res.Principal)
+	})
+
+	t.Run("revoked token is inactive", func(t *testing.T) {
+		redis := providers.NewMockRedisProvider(ctrl)
+		redis.EXPECT().Get(ctx, revokedBeforeKey("user-1")).Return(strconv.FormatInt(time.Now().Unix(), 10), nil)
+		auth := &DefaultAuthMiddleware{redis: redis}
+
+		res, err := auth.IntrospectToken(ctx, userToken)
+		require.NoError(t, err)
+		assert.Equal(t, false, res.Active)
+		assert.Empty(t, res.Subject)
+	})
+
+	t.Run("garbage is inactive", func(t *testing.T) {
+		auth := &DefaultAuthMiddleware{}
+		res, err := auth.IntrospectToken(ctx, "not-a-token")
+		require.NoError(t, err)
+		assert.False(t, res.Active)
+	})
+
+	t.Run("unknown api key is inactive", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+		mock.ExpectQuery("FROM api_keys").WithArgs("0a1b2c3d").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		auth := &DefaultAuthMiddleware{db: sqlx.NewDb(db, "postgres")}
+
+		res, err := auth.IntrospectToken(ctx, "ak_0a1b2c3d_secret")
+		require.NoError(t, err)
+		assert.False(t, res.Active)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,287 @@
+package middlewareprovider
+
+import (
+	"asset/models"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"maps"
+	"math/big"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+)
+
+// access tokens are the only tokens other services verify, so they are the only ones signed with the
+// rsa key when RS256 is on. refresh and single purpose tokens never leave this service and stay HS256
+type accessKeySet struct {
+	method jwt.SigningMethod
+	// set for RS256 only
+	signingKeyID string
+	signingKey   *rsa.PrivateKey
+	// the current key and the retired ones, by kid
+	verifyKeys map[string]*rsa.PublicKey
+}
+
+// jwtSettings is everything that decides how tokens are issued, it only changes through ConfigureJWT
+type jwtSettings struct {
+	keys accessKeySet
+	// hs256 key for single purpose tokens, and access tokens outside RS256
+	secretKey []byte
+	// hs256 key for refresh tokens only
+	refreshKey      []byte
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+}
+
+const (
+	defaultAccessTokenTTL  = 5 * time.Minute
+	defaultRefreshTokenTTL = 7 * 24 * time.Hour
+)
+
+var (
+	jwtSettingsMu sync.RWMutex
+	settings      = jwtSettings{
+		keys:            accessKeySet{method: jwt.SigningMethodHS256},
+		secretKey:       jwtSecretKey,
+		accessTokenTTL:  defaultAccessTokenTTL,
+		refreshTokenTTL: defaultRefreshTokenTTL,
+	}
+)
+
+// ConfigureJWT loads the signing keys and token lifetimes, it runs once at startup before any token is issued
+func ConfigureJWT(cfg models.JWTConfig) error {
+	next := jwtSettings{secretKey: jwtSecretKey, accessTokenTTL: defaultAccessTokenTTL, refreshTokenTTL: defaultRefreshTokenTTL}
+	if len(cfg.SecretKey) > 0 {
+		next.secretKey = cfg.SecretKey
+	}
+	// jwt accepts an empty hmac key, anyone could sign tokens with one
+	if len(next.secretKey) == 0 {
+		return errors.New("SECRET_KEY is required, set it or store it in the secrets backend")
+	}
+	next.refreshKey = cfg.RefreshKey
+	if len(next.refreshKey) == 0 {
+		return errors.New("REFRESH_TOKEN is required, set it or store it in the secrets backend")
+	}
+	if cfg.AccessTokenTTL > 0 {
+		next.accessTokenTTL = cfg.AccessTokenTTL
+	}
+	if cfg.RefreshTokenTTL > 0 {
+		next.refreshTokenTTL = cfg.RefreshTokenTTL
+	}
+	if next.refreshTokenTTL <= next.accessTokenTTL {
+		return fmt.Errorf("refresh token lifetime %s must be longer than the access token lifetime %s", next.refreshTokenTTL, next.accessTokenTTL)
+	}
+
+	keys, err := loadAccessKeys(cfg)
+	if err != nil {
+		return err
+	}
+	next.keys = keys
+
+	jwtSettingsMu.Lock()
+	settings = next
+	jwtSettingsMu.Unlock()
+	return nil
+}
+
+func currentJWTSettings() jwtSettings {
+	jwtSettingsMu.RLock()
+	defer jwtSettingsMu.RUnlock()
+	return settings
+}
+
+func loadAccessKeys(cfg models.JWTConfig) (accessKeySet, error) {
+	keys := accessKeySet{method: jwt.SigningMethodHS256}
+	switch cfg.Algorithm {
+	case "", jwt.SigningMethodHS256.Alg():
+	case jwt.SigningMethodRS256.Alg():
+		var private *rsa.PrivateKey
+		var err error
+		switch {
+		case len(cfg.PrivateKeyPEM) > 0:
+			private, err = parsePrivateKey(cfg.PrivateKeyPEM, "from secrets")
+		case cfg.PrivateKeyFile != "":
+			private, err = readPrivateKey(cfg.PrivateKeyFile)
+		default:
+			return keys, errors.New("JWT_PRIVATE_KEY_FILE or the JWT_PRIVATE_KEY secret is required for RS256")
+		}
+		if err != nil {
+			return keys, err
+		}
+		keys.method = jwt.SigningMethodRS256
+		keys.signingKey = private
+		keys.signingKeyID = keyID(&private.PublicKey)
+		keys.verifyKeys = map[string]*rsa.PublicKey{keys.signingKeyID: &private.PublicKey}
+		for _, file := range cfg.PreviousKeyFiles {
+			public, err := readPublicKey(file)
+			if err != nil {
+				return keys, err
+			}
+			keys.verifyKeys[keyID(public)] = public
+		}
+	default:
+		return keys, fmt.Errorf("unsupported jwt signing algorithm %q", cfg.Algorithm)
+	}
+	return keys, nil
+}
+
+func signAccessToken(claims jwt.MapClaims) (string, error) {
+	keys := currentJWTSettings().keys
+	if keys.signingKey == nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(currentJWTSettings().secretKey)
+	}
+	token := jwt.NewWithClaims(keys.method, claims)
+	token.Header["kid"] = keys.signingKeyID
+	return token.SignedString(keys.signingKey)
+}
+
+// parseAccessToken only accepts the configured algorithm, an HS256 token left over from before a switch
+// to RS256 fails like an expired one and the caller falls back to the refresh token. Single purpose
+// tokens share the secret with HS256 access tokens, so only typ tells them apart
+func parseAccessToken(tokenStr string) (*jwt.Token, error) {
+	current := currentJWTSettings()
+	keys := current.keys
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		if keys.signingKey == nil {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, fmt.Errorf("unexpected signing method")
+			}
+			return current.secretKey, nil
+		}
+		kid, _ := token.Header["kid"].(string)
+		key, ok := keys.verifyKeys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		return key, nil
+	}, jwt.WithValidMethods([]string{keys.method.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+	if claims, ok := token.Claims.(jwt.MapClaims); !ok || claims["typ"] != "access" {
+		return nil, errors.New("token is not an access token")
+	}
+	return token, nil
+}
+
+// JWKS lists the public keys access tokens may be signed with, empty in HS256 mode
+func (a *DefaultAuthMiddleware) JWKS() models.JWKSet {
+	keys := currentJWTSettings().keys
+	set := models.JWKSet{Keys: make([]models.JWK, 0, len(keys.verifyKeys))}
+	// current key first, some clients only look at the first entry
+	if keys.signingKey != nil {
+		set.Keys = append(set.Keys, toJWK(keys.signingKeyID, &keys.signingKey.PublicKey))
+	}
+	for _, kid := range slices.Sorted(maps.Keys(keys.verifyKeys)) {
+		if kid != keys.signingKeyID {
+			set.Keys = append(set.Keys, toJWK(kid, keys.verifyKeys[kid]))
+		}
+	}
+	return set
+}
+
+func toJWK(kid string, public *rsa.PublicKey) models.JWK {
+	return models.JWK{
+		KeyType:   "RSA",
+		Use:       "sig",
+		Algorithm: jwt.SigningMethodRS256.Alg(),
+		KeyID:     kid,
+		Modulus:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+		Exponent:  base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+	}
+}
+
+// keyID is the RFC 7638 thumbprint, so the same key always gets the same kid across restarts
+func keyID(public *rsa.PublicKey) string {
+	jwk := toJWK("", public)
+	canonical := fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`, jwk.Exponent, jwk.Modulus)
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func readPEMBlock(file string) (*pem.Block, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jwt key %s: %w", file, err)
+	}
+	return decodePEMBlock(data, file)
+}
+
+// decodePEMBlock takes the key source, a file name or where else the key came from, for the errors
+func decodePEMBlock(data []byte, source string) (*pem.Block, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("jwt key %s is not pem encoded", source)
+	}
+	return block, nil
+}
+
+func readPrivateKey(file string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jwt key %s: %w", file, err)
+	}
+	return parsePrivateKey(data, file)
+}
+
+func parsePrivateKey(data []byte, source string) (*rsa.PrivateKey, error) {
+	block, err := decodePEMBlock(data, source)
+	if err != nil {
+		return nil, err
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse jwt private key %s: %w", source, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("jwt private key %s is not an rsa key", source)
+	}
+	return key, nil
+}
+
+// readPublicKey takes a public key, a certificate or a retired private key
+func readPublicKey(file string) (*rsa.PublicKey, error) {
+	block, err := readPEMBlock(file)
+	if err != nil {
+		return nil, err
+	}
+	var parsed interface{}
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, certErr := x509.ParseCertificate(block.Bytes)
+		if certErr != nil {
+			return nil, fmt.Errorf("failed to parse jwt key certificate %s: %w", file, certErr)
+		}
+		parsed = cert.PublicKey
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		private, privateErr := readPrivateKey(file)
+		if privateErr != nil {
+			return nil, privateErr
+		}
+		parsed = &private.PublicKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse jwt public key %s: %w", file, err)
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("jwt public key %s is not an rsa key", file)
+	}
+	return key, nil
+}
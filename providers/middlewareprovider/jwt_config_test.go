@@ -0,0 +1,288 @@
+package middlewareprovider
+
+import (
+	"asset/models"
+	redisprovider "asset/providers/redisProvider"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useJWTConfig configures the package for one test and puts the previous settings back after it
+func useJWTConfig(t *testing.T, cfg models.JWTConfig) {
+	t.Helper()
+	previous := currentJWTSettings()
+	t.Cleanup(func() {
+		jwtSettingsMu.Lock()
+		settings = previous
+		jwtSettingsMu.Unlock()
+	})
+	require.NoError(t, ConfigureJWT(cfg))
+}
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return key
+}
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return file
+}
+
+func accessClaims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": "user-1", "typ": "access", "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+func TestRS256AccessTokens(t *testing.T) {
+	retired := newRSAKey(t)
+	current := newRSAKey(t)
+	secret := []byte("test-secret")
+
+	// tokens signed before the rotation, with the key that is now retired
+	useJWTConfig(t, models.JWTConfig{
+		Algorithm:     jwt.SigningMethodRS256.Alg(),
+		SecretKey:     secret,
+		RefreshKey:    []byte("test-refresh-secret"),
+		PrivateKeyPEM: pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(retired)}),
+	})
+	before, err := signAccessToken(accessClaims())
+	require.NoError(t, err)
+	token, err := parseAccessToken(before)
+	require.NoError(t, err)
+	assert.Equal(t, keyID(&retired.PublicKey), token.Header["kid"])
+
+	currentDER, err := x509.MarshalPKCS8PrivateKey(current)
+	require.NoError(t, err)
+	retiredDER, err := x509.MarshalPKIXPublicKey(&retired.PublicKey)
+	require.NoError(t, err)
+	useJWTConfig(t, models.JWTConfig{
+		Algorithm:        jwt.SigningMethodRS256.Alg(),
+		SecretKey:        secret,
+		RefreshKey:       []byte("test-refresh-secret"),
+		PrivateKeyFile:   writePEM(t, "PRIVATE KEY", currentDER),
+		PreviousKeyFiles: []string{writePEM(t, "PUBLIC KEY", retiredDER)},
+	})
+	after, err := signAccessToken(accessClaims())
+	require.NoError(t, err)
+	token, err = parseAccessToken(after)
+	require.NoError(t, err)
+	assert.Equal(t, keyID(&current.PublicKey), token.Header["kid"])
+
+	// a token from before the rotation keeps verifying until it expires
+	_, err = parseAccessToken(before)
+	assert.NoError(t, err)
+
+	// the current key is listed first, then the retired one
+	jwks := (&DefaultAuthMiddleware{}).JWKS()
+	require.Len(t, jwks.Keys, 2)
+	assert.Equal(t, keyID(&current.PublicKey), jwks.Keys[0].KeyID)
+	assert.Equal(t, keyID(&retired.PublicKey), jwks.Keys[1].KeyID)
+
+	stranger := newRSAKey(t)
+	tests := []struct {
+		name  string
+		token func(t *testing.T) string
+	}{
+		{
+			name: "unknown kid",
+			token: func(t *testing.T) string {
+				token := jwt.NewWithClaims(jwt.SigningMethodRS256, accessClaims())
+				token.Header["kid"] = keyID(&stranger.PublicKey)
+				signed, err := token.SignedString(stranger)
+				require.NoError(t, err)
+				return signed
+			},
+		},
+		{
+			name: "known kid signed by another key",
+			token: func(t *testing.T) string {
+				token := jwt.NewWithClaims(jwt.SigningMethodRS256, accessClaims())
+				token.Header["kid"] = keyID(&current.PublicKey)
+				signed, err := token.SignedString(stranger)
+				require.NoError(t, err)
+				return signed
+			},
+		},
+		{
+			name: "no kid",
+			token: func(t *testing.T) string {
+				signed, err := jwt.NewWithClaims(jwt.SigningMethodRS256, accessClaims()).SignedString(current)
+				require.NoError(t, err)
+				return signed
+			},
+		},
+		{
+			name: "hs256 with the secret key",
+			token: func(t *testing.T) string {
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims())
+				token.Header["kid"] = keyID(&current.PublicKey)
+				signed, err := token.SignedString(secret)
+				require.NoError(t, err)
+				return signed
+			},
+		},
+		{
+			// the classic confusion, the public key used as an hmac secret
+			name: "hs256 with the public key",
+			token: func(t *testing.T) string {
+				der, err := x509.MarshalPKIXPublicKey(&current.PublicKey)
+				require.NoError(t, err)
+				token := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims())
+				token.Header["kid"] = keyID(&current.PublicKey)
+				signed, err := token.SignedString(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+				require.NoError(t, err)
+				return signed
+			},
+		},
+		{
+			name: "alg none",
+			token: func(t *testing.T) string {
+				token := jwt.NewWithClaims(jwt.SigningMethodNone, accessClaims())
+				token.Header["kid"] = keyID(&current.PublicKey)
+				signed, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+				require.NoError(t, err)
+				return signed
+			},
+		},
+		{
+			name: "expired",
+			token: func(t *testing.T) string {
+				token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "user-1", "typ": "access", "exp": time.Now().Add(-time.Minute).Unix()})
+				token.Header["kid"] = keyID(&current.PublicKey)
+				signed, err := token.SignedString(current)
+				require.NoError(t, err)
+				return signed
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseAccessToken(tc.token(t))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestHS256AccessTokens(t *testing.T) {
+	useJWTConfig(t, models.JWTConfig{SecretKey: []byte("test-secret"), RefreshKey: []byte("test-refresh-secret")})
+
+	signed, err := signAccessToken(accessClaims())
+	require.NoError(t, err)
+	_, err = parseAccessToken(signed)
+	require.NoError(t, err)
+	assert.Empty(t, (&DefaultAuthMiddleware{}).JWKS().Keys)
+
+	// an rs256 token is refused while the service signs with the secret
+	key := newRSAKey(t)
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, accessClaims())
+	token.Header["kid"] = keyID(&key.PublicKey)
+	signed, err = token.SignedString(key)
+	require.NoError(t, err)
+	_, err = parseAccessToken(signed)
+	assert.Error(t, err)
+
+	signed, err = jwt.NewWithClaims(jwt.SigningMethodNone, accessClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	require.NoError(t, err)
+	_, err = parseAccessToken(signed)
+	assert.Error(t, err)
+}
+
+// single purpose tokens are signed with the same secret as HS256 access tokens, none of them may
+// stand in for one
+func TestJWTAuthMiddlewareRefusesSinglePurposeTokens(t *testing.T) {
+	useJWTConfig(t, models.JWTConfig{SecretKey: []byte("test-secret"), RefreshKey: []byte("test-refresh-secret")})
+	expiresAt := time.Now().Add(time.Hour)
+	sign := func(token string, err error) string {
+		require.NoError(t, err)
+		return token
+	}
+
+	auth := &DefaultAuthMiddleware{redis: redisprovider.NewMemoryRedisProvider()}
+	handler := auth.JWTAuthMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/users/me", nil)
+		req.Header.Set("Authorization", token)
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res.Code
+	}
+
+	require.Equal(t, http.StatusOK, serve(sign(GenerateJWT("user-1", []string{"employee"}))))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "mfa challenge", token: sign(GenerateMFAChallengeToken("user-1", "user-1", expiresAt))},
+		{name: "magic link", token: sign(GenerateMagicLinkToken("user-1", "user@example.com", "link-1", expiresAt))},
+		{name: "invite", token: sign(GenerateInviteToken("invite-1", expiresAt))},
+		{name: "email verification", token: sign(GenerateEmailVerificationToken("user-1", "user@example.com", expiresAt))},
+		{name: "email change", token: sign(GenerateEmailChangeToken("request-1", expiresAt))},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, http.StatusUnauthorized, serve(tc.token))
+		})
+	}
+}
+
+func TestConfigureJWTRejects(t *testing.T) {
+	valid := newRSAKey(t)
+	tests := []struct {
+		name string
+		cfg  models.JWTConfig
+	}{
+		{name: "no refresh key", cfg: models.JWTConfig{SecretKey: []byte("s")}},
+		{name: "unsupported algorithm", cfg: models.JWTConfig{SecretKey: []byte("s"), RefreshKey: []byte("r"), Algorithm: "ES256"}},
+		{name: "rs256 without a key", cfg: models.JWTConfig{SecretKey: []byte("s"), RefreshKey: []byte("r"), Algorithm: "RS256"}},
+		{name: "key that is not pem", cfg: models.JWTConfig{SecretKey: []byte("s"), RefreshKey: []byte("r"), Algorithm: "RS256", PrivateKeyPEM: []byte("not a key")}},
+		{
+			name: "missing previous key file",
+			cfg: models.JWTConfig{
+				SecretKey:        []byte("s"),
+				RefreshKey:       []byte("r"),
+				Algorithm:        "RS256",
+				PrivateKeyPEM:    pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(valid)}),
+				PreviousKeyFiles: []string{filepath.Join(t.TempDir(), "missing.pem")},
+			},
+		},
+		{name: "refresh lifetime shorter than access", cfg: models.JWTConfig{SecretKey: []byte("s"), RefreshKey: []byte("r"), AccessTokenTTL: time.Hour, RefreshTokenTTL: time.Minute}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			previous := currentJWTSettings()
+			assert.Error(t, ConfigureJWT(tc.cfg))
+			// a failed configure leaves what was there
+			assert.Equal(t, previous, currentJWTSettings())
+		})
+	}
+}
+
+// the example key and thumbprint from RFC 7638 section 3.1
+func TestKeyIDThumbprint(t *testing.T) {
+	modulus, err := base64.RawURLEncoding.DecodeString("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+	require.NoError(t, err)
+	public := &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: 65537}
+
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", keyID(public))
+}
@@ -8,40 +8,46 @@ import (
 	"time"
 )
 
-var (
-	jwtSecretKey          = []byte(os.Getenv("SECRET_KEY"))
-	refreshTokenSecretKey = []byte(os.Getenv("REFRESH_TOKEN"))
-)
+var jwtSecretKey = []byte(os.Getenv("SECRET_KEY"))
 
 func GenerateJWT(userID string, roles []string) (string, error) {
 	claims := jwt.MapClaims{
 		"sub":   userID,
 		"roles": roles,
 		"typ":   "access",
-		"exp":   time.Now().Add(5 * time.Minute).Unix(),
+		"exp":   time.Now().Add(currentJWTSettings().accessTokenTTL).Unix(),
 		"iat":   time.Now().Unix(),
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecretKey)
+	return signAccessToken(claims)
 }
 
-func GenerateRefreshToken(userID string) (string, error) {
+// GenerateServiceAccountJWT signs an access token for a service account, the principal claim keeps
+// it off the routes that act on a person's own account
+func GenerateServiceAccountJWT(accountID string, roles []string) (string, error) {
 	claims := jwt.MapClaims{
-		"sub": userID,
-		"typ": "refresh",
-		"exp": time.Now().Add(7 * 24 * time.Hour).Unix(), // 7 days
+		"sub":       accountID,
+		"roles":     roles,
+		"typ":       "access",
+		"principal": servicePrincipal,
+		"exp":       time.Now().Add(currentJWTSettings().accessTokenTTL).Unix(),
+		"iat":       time.Now().Unix(),
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(refreshTokenSecretKey)
+	return signAccessToken(claims)
+}
+
+const servicePrincipal = "service_account"
+
+// IsServiceAccountToken reads the principal claim of an access token that has already been verified by ParseJWT
+func IsServiceAccountToken(tokenStr string) bool {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenStr, claims); err != nil {
+		return false
+	}
+	return claims["principal"] == servicePrincipal
 }
 
 func ParseJWT(tokenStr string) (string, []string, error) {
-	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method")
-		}
-		return jwtSecretKey, nil
-	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	token, err := parseAccessToken(tokenStr)
 
 	if err != nil || !token.Valid {
 		return "", nil, fmt.Errorf("invalid or expired token: %w", err)
@@ -70,31 +76,207 @@ func ParseJWT(tokenStr string) (string, []string, error) {
 	return sub, roles, nil
 }
 
-func ParseRefreshToken(tokenStr string) (string, error) {
+// GetTokenExpiry reads the exp claim of an access token that has already been verified by ParseJWT
+func GetTokenExpiry(tokenStr string) (time.Time, error) {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenStr, claims); err != nil {
+		return time.Time{}, fmt.Errorf("failed to read token claims: %w", err)
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return time.Time{}, errors.New("invalid 'exp' claim")
+	}
+	return exp.Time, nil
+}
+
+// GetTokenIssuedAt reads the iat claim of a token that has already been verified
+func GetTokenIssuedAt(tokenStr string) (time.Time, error) {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(tokenStr, claims); err != nil {
+		return time.Time{}, fmt.Errorf("failed to read token claims: %w", err)
+	}
+	iat, err := claims.GetIssuedAt()
+	if err != nil || iat == nil {
+		return time.Time{}, errors.New("invalid 'iat' claim")
+	}
+	return iat.Time, nil
+}
+
+// RefreshClaims is what the token store needs from a verified refresh token
+type RefreshClaims struct {
+	Subject   string
+	ID        string
+	Family    string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+func ParseRefreshToken(tokenStr string) (RefreshClaims, error) {
 	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method")
 		}
-		return refreshTokenSecretKey, nil
+		return refreshKey()
 	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 	if err != nil || !token.Valid {
-		return "", errors.New("invalid or expired refresh token")
+		return RefreshClaims{}, ErrInvalidRefreshToken
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return "", errors.New("invalid token claims")
+		return RefreshClaims{}, errors.New("invalid token claims")
 	}
 
 	if claims["typ"] != "refresh" {
-		return "", errors.New("token is not a refresh token")
+		return RefreshClaims{}, ErrInvalidRefreshToken
 	}
 
 	sub, ok := claims["sub"].(string)
 	if !ok {
-		return "", errors.New("invalid 'sub' claim")
+		return RefreshClaims{}, errors.New("invalid 'sub' claim")
+	}
+
+	res := RefreshClaims{Subject: sub}
+	res.ID, _ = claims["jti"].(string)
+	res.Family, _ = claims["fam"].(string)
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		res.IssuedAt = iat.Time
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		res.ExpiresAt = exp.Time
+	}
+	return res, nil
+}
+
+// refreshKey refuses to hand out an empty key, ConfigureJWT not having run must not make refresh
+// tokens forgeable
+func refreshKey() ([]byte, error) {
+	key := currentJWTSettings().refreshKey
+	if len(key) == 0 {
+		return nil, errors.New("refresh token key is not configured")
+	}
+	return key, nil
+}
+
+// generateSignedToken signs a single purpose token, typ keeps one kind of token from being accepted as another
+func generateSignedToken(typ, sub string, expiresAt time.Time, extra jwt.MapClaims) (string, error) {
+	claims := jwt.MapClaims{
+		"sub": sub,
+		"typ": typ,
+		"exp": expiresAt.Unix(),
+		"iat": time.Now().Unix(),
+	}
+	for k, v := range extra {
+		claims[k] = v
 	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(currentJWTSettings().secretKey)
+}
+
+func parseSignedToken(tokenStr, typ string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method")
+		}
+		return currentJWTSettings().secretKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
-	return sub, nil
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("invalid or expired %s token: %w", typ, err)
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid token claims")
+	}
+	if claims["typ"] != typ {
+		return nil, fmt.Errorf("token is not a %s token", typ)
+	}
+	if _, ok := claims["sub"].(string); !ok {
+		return nil, errors.New("invalid 'sub' claim")
+	}
+	return claims, nil
+}
+
+// GenerateInviteToken signs an employee invite, the invite row stays the source of truth for whether it can still be used
+func GenerateInviteToken(inviteID string, expiresAt time.Time) (string, error) {
+	return generateSignedToken("invite", inviteID, expiresAt, nil)
+}
+
+func ParseInviteToken(tokenStr string) (string, error) {
+	claims, err := parseSignedToken(tokenStr, "invite")
+	if err != nil {
+		return "", err
+	}
+	return claims["sub"].(string), nil
+}
+
+// GenerateEmailVerificationToken binds the token to the address it was sent to, so it stops working if the email changes
+func GenerateEmailVerificationToken(userID, email string, expiresAt time.Time) (string, error) {
+	return generateSignedToken("email_verification", userID, expiresAt, jwt.MapClaims{"email": email})
+}
+
+func ParseEmailVerificationToken(tokenStr string) (string, string, error) {
+	claims, err := parseSignedToken(tokenStr, "email_verification")
+	if err != nil {
+		return "", "", err
+	}
+	email, ok := claims["email"].(string)
+	if !ok {
+		return "", "", errors.New("invalid 'email' claim")
+	}
+	return claims["sub"].(string), email, nil
+}
+
+// GenerateEmailChangeToken is sent to the new address, confirming it proves the user controls that address
+func GenerateEmailChangeToken(requestID string, expiresAt time.Time) (string, error) {
+	return generateSignedToken("email_change", requestID, expiresAt, nil)
+}
+
+func ParseEmailChangeToken(tokenStr string) (string, error) {
+	claims, err := parseSignedToken(tokenStr, "email_change")
+	if err != nil {
+		return "", err
+	}
+	return claims["sub"].(string), nil
+}
+
+// GenerateMagicLinkToken signs a passwordless sign-in link. linkID is stored in redis when the link is
+// sent and removed on first use, so the link works once even though the signature stays valid until expiry
+func GenerateMagicLinkToken(userID, email, linkID string, expiresAt time.Time) (string, error) {
+	return generateSignedToken("magic_link", userID, expiresAt, jwt.MapClaims{"email": email, "jti": linkID})
+}
+
+func ParseMagicLinkToken(tokenStr string) (userID, email, linkID string, err error) {
+	claims, err := parseSignedToken(tokenStr, "magic_link")
+	if err != nil {
+		return "", "", "", err
+	}
+	email, ok := claims["email"].(string)
+	if !ok {
+		return "", "", "", errors.New("invalid 'email' claim")
+	}
+	linkID, ok = claims["jti"].(string)
+	if !ok || linkID == "" {
+		return "", "", "", errors.New("invalid 'jti' claim")
+	}
+	return claims["sub"].(string), email, linkID, nil
+}
+
+// GenerateMFAChallengeToken is handed out after the first login step, subject is the token subject to issue once the code checks out
+func GenerateMFAChallengeToken(userID, subject string, expiresAt time.Time) (string, error) {
+	return generateSignedToken("mfa_challenge", userID, expiresAt, jwt.MapClaims{"subject": subject})
+}
+
+func ParseMFAChallengeToken(tokenStr string) (string, string, error) {
+	claims, err := parseSignedToken(tokenStr, "mfa_challenge")
+	if err != nil {
+		return "", "", err
+	}
+	subject, ok := claims["subject"].(string)
+	if !ok {
+		return "", "", errors.New("invalid 'subject' claim")
+	}
+	return claims["sub"].(string), subject, nil
 }
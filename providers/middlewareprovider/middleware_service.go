@@ -5,36 +5,87 @@ import (
 	"asset/providers"
 	"asset/utils"
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"net"
 	"net/http"
 	"strings"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 )
 
 type contextKey string
 
 const (
-	UserContextKey  contextKey = "user_key"
-	RolesContextKey contextKey = "roles_key"
+	UserContextKey        contextKey = "user_key"
+	RolesContextKey       contextKey = "roles_key"
+	TokenExpiryContextKey contextKey = "token_expiry_key"
+	// only set for requests authenticated with an api key
+	APIKeyIDContextKey     contextKey = "api_key_id_key"
+	APIKeyScopesContextKey contextKey = "api_key_scopes_key"
+	APIKeyLimitsContextKey contextKey = "api_key_limits_key"
+	// only set for requests authenticated with a service account token
+	ServiceAccountContextKey contextKey = "service_account_key"
+	// set by TrackRequestUser, holds the user id once authentication succeeds
+	requestUserContextKey contextKey = "request_user_key"
 )
 
+// TrackRequestUser lets middleware running before authentication, like the access log, learn who the
+// request was authenticated as, the returned func reads it once the handler has returned. Nested
+// callers share the first tracker
+func TrackRequestUser(r *http.Request) (*http.Request, func() string) {
+	if tracked, ok := r.Context().Value(requestUserContextKey).(*string); ok {
+		return r, func() string { return *tracked }
+	}
+	var userID string
+	r = r.WithContext(context.WithValue(r.Context(), requestUserContextKey, &userID))
+	return r, func() string { return userID }
+}
+
+func recordRequestUser(ctx context.Context, userID string) {
+	if tracked, ok := ctx.Value(requestUserContextKey).(*string); ok {
+		*tracked = userID
+	}
+}
+
 type DefaultAuthMiddleware struct {
-	db *sqlx.DB
+	db      *sqlx.DB
+	redis   providers.RedisProvider
+	cookies models.AuthCookieConfig
+	policy  *policyEngine
+	// domain organization subdomains hang off, empty when organizations are told apart by account only
+	organizationDomain string
 }
 
-func NewAuthMiddlewareService(db *sqlx.DB) providers.AuthMiddlewareService {
+func NewAuthMiddlewareService(db *sqlx.DB, redis providers.RedisProvider, cookies models.AuthCookieConfig, organizationDomain string) providers.AuthMiddlewareService {
 	return &DefaultAuthMiddleware{
-		db: db,
+		db:                 db,
+		redis:              redis,
+		cookies:            cookies,
+		policy:             &policyEngine{},
+		organizationDomain: organizationDomain,
 	}
 }
 
 func (a *DefaultAuthMiddleware) JWTAuthMiddleware() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if apiKey := r.Header.Get(APIKeyHeader); apiKey != "" {
+				a.serveAPIKey(w, r, next, apiKey)
+				return
+			}
+
 			accessToken := r.Header.Get("Authorization")
+			if accessToken == "" && a.cookies.Enabled {
+				// the browser attaches cookies to any request, including ones forged by other sites
+				if accessToken = CookieValue(r, AccessTokenCookie); accessToken != "" && !ValidCSRF(r) {
+					utils.RespondError(w, http.StatusForbidden, errors.New("missing or invalid csrf token"), "missing or invalid csrf token")
+					return
+				}
+			}
 
 			if accessToken == "" {
 				utils.RespondError(w, http.StatusUnauthorized, errors.New("missing access token"), "missing access token")
@@ -42,76 +93,132 @@ func (a *DefaultAuthMiddleware) JWTAuthMiddleware() func(http.Handler) http.Hand
 			}
 
 			userID, roles, err := ParseJWT(accessToken)
-			if err != nil && strings.Contains(err.Error(), "invalid or expired token") {
-				refreshToken := r.Header.Get("refresh_token")
-				if refreshToken == "" {
-					utils.RespondError(w, http.StatusUnauthorized, errors.New("missing refresh token"), "access token expired, and refresh token missing")
-					return
-				}
-				userID, err = ParseRefreshToken(refreshToken)
-				if err != nil {
-					utils.RespondError(w, http.StatusUnauthorized, err, "invalid or expired refresh token")
-					return
-				}
-
-				var dbRoles []string
-				err = a.db.Select(&dbRoles, `SELECT role FROM user_roles WHERE user_id = $1 AND archived_at IS NULL`, userID)
-				if err != nil {
-					utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch roles")
-					return
-				}
-				roles = dbRoles
+			if err != nil {
+				// an expired token is traded for a new pair at POST /api/auth/refresh, then the request is retried
+				utils.RespondError(w, http.StatusUnauthorized, err, "invalid or expired access token")
+				return
+			}
+			if issuedAt, iatErr := GetTokenIssuedAt(accessToken); iatErr != nil || a.isRevoked(r.Context(), userID, issuedAt) {
+				utils.RespondError(w, http.StatusUnauthorized, ErrTokenRevoked, "token has been revoked")
+				return
+			}
 
-				//generate new token
-				newAccessToken, err := GenerateJWT(userID, roles)
-				if err != nil {
-					utils.RespondError(w, http.StatusInternalServerError, err, "failed to generate access token")
-					return
-				}
-				//generate new refresh token
-				newRefreshToken, err := GenerateRefreshToken(userID)
-				if err != nil {
-					utils.RespondError(w, http.StatusInternalServerError, err, "failed to generate refresh token")
-					return
-				}
-				w.Header().Set("Authorization", newAccessToken)
-				w.Header().Set("Refresh_token", newRefreshToken)
-			} else if err != nil {
+			expiresAt, err := GetTokenExpiry(accessToken)
+			if err != nil {
 				utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
 				return
 			}
 
+			recordRequestUser(r.Context(), userID)
 			ctx := context.WithValue(r.Context(), UserContextKey, userID)
 			ctx = context.WithValue(ctx, RolesContextKey, roles)
+			ctx = context.WithValue(ctx, TokenExpiryContextKey, expiresAt)
+			ctx = utils.ContextWithLocation(ctx, a.userLocation(ctx, userID))
+			if IsServiceAccountToken(accessToken) {
+				ctx = context.WithValue(ctx, ServiceAccountContextKey, true)
+			}
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
-func (a *DefaultAuthMiddleware) RequireRole(allowedRoles ...models.Role) func(http.Handler) http.Handler {
-	allowed := make(map[models.Role]bool)
-	for _, role := range allowedRoles {
-		allowed[role] = true
-	}
+// RequirePermission allows the request through when the policy grants at least one of the
+// given permissions to any of the caller's roles, or to a role currently delegated to them.
+func (a *DefaultAuthMiddleware) RequirePermission(permissions ...models.Permission) func(http.Handler) http.Handler {
+	required := permissionNames(permissions)
 
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			_, roles, err := a.GetUserAndRolesFromContext(r)
+			userID, roles, err := a.GetUserAndRolesFromContext(r)
 			if err != nil {
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+				return
+			}
+			if !hasScope(r, required) {
+				utils.RespondError(w, http.StatusForbidden, errors.New("missing api key scope"), "api key is missing the required scope")
 				return
 			}
-			for _, role := range roles {
-				if allowed[models.Role(role)] {
-					next.ServeHTTP(w, r)
+
+			granted, err := a.HasPermission(r.Context(), roles, permissions...)
+			if err != nil {
+				utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch permissions")
+				return
+			}
+			if !granted {
+				// roles delegated to the caller count only while the delegation window is open, both
+				// users are still active and the delegator still holds the role themselves
+				var delegated []string
+				err = a.db.SelectContext(r.Context(), &delegated, `
+					SELECT d.role FROM role_delegations d
+					JOIN users delegator ON delegator.id = d.delegator_id AND delegator.archived_at IS NULL
+					JOIN users delegate ON delegate.id = d.delegate_id AND delegate.archived_at IS NULL
+					WHERE d.delegate_id::text = $1 AND d.revoked_at IS NULL
+					AND now() BETWEEN d.starts_at AND d.ends_at
+					AND EXISTS (
+						SELECT 1 FROM user_roles ur
+						WHERE ur.user_id = d.delegator_id AND ur.role = d.role AND ur.archived_at IS NULL
+					)
+				`, userID)
+				if err != nil {
+					utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch permissions")
 					return
 				}
+				granted = a.policy.allows(delegated, required)
+			}
+			if !granted {
+				utils.RespondError(w, http.StatusForbidden, errors.New("missing permission"), "permission denied")
+				return
 			}
-			http.Error(w, "forbidden", http.StatusForbidden)
+			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+// HasPermission asks the policy whether any of the roles holds one of the permissions,
+// delegations are left to RequirePermission
+func (a *DefaultAuthMiddleware) HasPermission(ctx context.Context, roles []string, permissions ...models.Permission) (bool, error) {
+	if !a.policy.loaded() {
+		if _, err := a.ReloadPolicies(ctx); err != nil {
+			return false, err
+		}
+	}
+	return a.policy.allows(roles, permissionNames(permissions)), nil
+}
+
+// CallerHasPermission is HasPermission for the caller of the request, an api key only holds the
+// permissions its scopes name. Handlers that widen what they return by permission use it, since
+// they run past RequirePermission without the scope check
+func (a *DefaultAuthMiddleware) CallerHasPermission(r *http.Request, permissions ...models.Permission) (bool, error) {
+	_, roles, err := a.GetUserAndRolesFromContext(r)
+	if err != nil {
+		return false, err
+	}
+	scoped := make([]models.Permission, 0, len(permissions))
+	for _, permission := range permissions {
+		if hasScope(r, []string{string(permission)}) {
+			scoped = append(scoped, permission)
+		}
+	}
+	if len(scoped) == 0 {
+		return false, nil
+	}
+	return a.HasPermission(r.Context(), roles, scoped...)
+}
+
+// ReloadPolicies reads role_permissions again. Runs after role changes on this instance and
+// periodically so changes made through other instances are picked up too
+func (a *DefaultAuthMiddleware) ReloadPolicies(ctx context.Context) (models.PolicySummary, error) {
+	return a.policy.reload(ctx, a.db)
+}
+
+func permissionNames(permissions []models.Permission) []string {
+	names := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		names = append(names, string(permission))
+	}
+	return names
+}
+
 func (a *DefaultAuthMiddleware) GetUserAndRolesFromContext(r *http.Request) (string, []string, error) {
 	userID, ok := r.Context().Value(UserContextKey).(string)
 	if !ok {
@@ -124,24 +231,152 @@ func (a *DefaultAuthMiddleware) GetUserAndRolesFromContext(r *http.Request) (str
 	return userID, roles, nil
 }
 
-func (a *DefaultAuthMiddleware) GenerateJWT(userID string, roles []string) (string, error) {
-	claims := jwt.MapClaims{
-		"sub":   userID,
-		"roles": roles,
-		"typ":   "access",
-		"exp":   time.Now().Add(5 * time.Minute).Unix(),
-		"iat":   time.Now().Unix(),
+// GetTokenExpiryFromContext returns when the access token used for this request expires
+func (a *DefaultAuthMiddleware) GetTokenExpiryFromContext(r *http.Request) (time.Time, error) {
+	expiresAt, ok := r.Context().Value(TokenExpiryContextKey).(time.Time)
+	if !ok {
+		return time.Time{}, errors.New("token expiry not found in context")
+	}
+	return expiresAt, nil
+}
+
+// userLocation looks up the timezone the user picked, nil when they haven't or it no longer loads
+func (a *DefaultAuthMiddleware) userLocation(ctx context.Context, userID string) func() *time.Location {
+	return func() *time.Location {
+		var name *string
+		err := a.db.GetContext(ctx, &name, `SELECT timezone FROM users WHERE id = $1`, userID)
+		if err != nil || name == nil {
+			return nil
+		}
+		loc, err := time.LoadLocation(*name)
+		if err != nil {
+			return nil
+		}
+		return loc
+	}
+}
+
+// GetDepartmentScope resolves which department and organization the caller may act on. Admins are
+// not scoped to a department, only holders of organization.manage reach other organizations
+func (a *DefaultAuthMiddleware) GetDepartmentScope(r *http.Request) (models.DepartmentScope, error) {
+	userID, roles, err := a.GetUserAndRolesFromContext(r)
+	if err != nil {
+		return models.DepartmentScope{}, err
+	}
+	// whoever may move users between departments isn't bound to one
+	allDepartments, err := a.HasPermission(r.Context(), roles, models.DepartmentManagePermission)
+	if err != nil {
+		return models.DepartmentScope{}, err
+	}
+	allOrganizations, err := a.HasPermission(r.Context(), roles, models.OrganizationManagePermission)
+	if err != nil {
+		return models.DepartmentScope{}, err
+	}
+	requested, err := a.requestOrganization(r)
+	if err != nil {
+		return models.DepartmentScope{}, err
+	}
+	if allOrganizations {
+		// on an organization's subdomain even a platform admin only sees that organization
+		return models.DepartmentScope{AllDepartments: true, OrganizationID: requested}, nil
+	}
+
+	// a service account has a users row too, it works in the organization it was created in
+	var caller struct {
+		DepartmentID   *uuid.UUID `db:"department_id"`
+		OrganizationID uuid.UUID  `db:"organization_id"`
+	}
+	err = a.db.GetContext(r.Context(), &caller, `
+		SELECT department_id, organization_id FROM users WHERE id = $1 AND archived_at IS NULL
+	`, userID)
+	if err != nil {
+		return models.DepartmentScope{}, err
+	}
+	if requested != nil && *requested != caller.OrganizationID {
+		return models.DepartmentScope{}, models.ErrOrganizationMismatch
+	}
+
+	scope := models.DepartmentScope{OrganizationID: &caller.OrganizationID}
+	if allDepartments {
+		scope.AllDepartments = true
+	} else {
+		scope.DepartmentID = caller.DepartmentID
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecretKey)
+	return scope, nil
 }
 
-func (a *DefaultAuthMiddleware) GenerateRefreshToken(userID string) (string, error) {
-	claims := jwt.MapClaims{
-		"sub": userID,
-		"typ": "refresh",
-		"exp": time.Now().Add(7 * 24 * time.Hour).Unix(), // 7 days
+// requestOrganization returns the organization whose subdomain the request came in on, nil when the
+// request didn't use one
+func (a *DefaultAuthMiddleware) requestOrganization(r *http.Request) (*uuid.UUID, error) {
+	slug, ok := OrganizationSlug(r.Host, a.organizationDomain)
+	if !ok {
+		return nil, nil
+	}
+	var id uuid.UUID
+	err := a.db.GetContext(r.Context(), &id, `
+		SELECT id FROM organizations WHERE slug = $1 AND archived_at IS NULL
+	`, slug)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUnknownOrganization
+	}
+	if err != nil {
+		return nil, err
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(refreshTokenSecretKey)
+	return &id, nil
+}
+
+var ErrUnknownOrganization = models.NewServiceError(http.StatusNotFound, "unknown_organization", "organization not found")
+
+// OrganizationSlug takes the organization slug out of a host like acme.assets.example.com, the bare
+// domain and hosts outside it have none
+func OrganizationSlug(host, domain string) (string, bool) {
+	if domain == "" {
+		return "", false
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	slug, found := strings.CutSuffix(strings.ToLower(host), "."+domain)
+	if !found || slug == "" || strings.Contains(slug, ".") {
+		return "", false
+	}
+	return slug, true
+}
+
+func (a *DefaultAuthMiddleware) GenerateJWT(userID string, roles []string) (string, error) {
+	return GenerateJWT(userID, roles)
+}
+
+func (a *DefaultAuthMiddleware) GenerateServiceAccountJWT(accountID string, roles []string) (string, error) {
+	return GenerateServiceAccountJWT(accountID, roles)
+}
+
+// RefreshTokens spends a refresh token and returns its subject with a new access and refresh token pair,
+// roles are read again so a role change takes effect on the next refresh
+func (a *DefaultAuthMiddleware) RefreshTokens(ctx context.Context, refreshToken string) (string, string, string, error) {
+	userID, newRefreshToken, err := a.rotateRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	var roles []string
+	err = a.db.SelectContext(ctx, &roles, `SELECT role FROM user_roles WHERE user_id = $1 AND archived_at IS NULL`, userID)
+	if err != nil {
+		return userID, "", "", fmt.Errorf("failed to fetch roles: %w", err)
+	}
+	if len(roles) == 0 {
+		// archived since the token was issued
+		return userID, "", "", ErrTokenRevoked
+	}
+
+	accessToken, err := GenerateJWT(userID, roles)
+	if err != nil {
+		return userID, "", "", fmt.Errorf("failed to generate access token: %w", err)
+	}
+	return userID, accessToken, newRefreshToken, nil
+}
+
+// GenerateRefreshToken starts a new refresh token family, later refreshes rotate within it
+func (a *DefaultAuthMiddleware) GenerateRefreshToken(ctx context.Context, userID string) (string, error) {
+	return a.issueRefreshToken(ctx, userID, "")
 }
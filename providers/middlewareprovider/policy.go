@@ -0,0 +1,78 @@
+package middlewareprovider
+
+import (
+	"asset/models"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// policyEngine keeps the role → permission grants of role_permissions in memory, every
+// authorization decision goes through it instead of a query or a hard coded role name.
+// Reload swaps in a fresh copy, requests in flight keep using the one they started with
+type policyEngine struct {
+	mu       sync.RWMutex
+	grants   map[string]map[string]bool
+	loadedAt time.Time
+}
+
+type policyRow struct {
+	Role       string `db:"role"`
+	Permission string `db:"permission"`
+}
+
+func newPolicyEngine(rows []policyRow) *policyEngine {
+	p := &policyEngine{}
+	p.replace(rows)
+	return p
+}
+
+func (p *policyEngine) replace(rows []policyRow) models.PolicySummary {
+	grants := make(map[string]map[string]bool)
+	for _, row := range rows {
+		if grants[row.Role] == nil {
+			grants[row.Role] = make(map[string]bool)
+		}
+		grants[row.Role][row.Permission] = true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.grants = grants
+	p.loadedAt = time.Now()
+	return models.PolicySummary{Roles: len(grants), Grants: len(rows), LoadedAt: p.loadedAt}
+}
+
+func (p *policyEngine) loaded() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return !p.loadedAt.IsZero()
+}
+
+// reload reads the active grants from the policy store
+func (p *policyEngine) reload(ctx context.Context, db *sqlx.DB) (models.PolicySummary, error) {
+	var rows []policyRow
+	err := db.SelectContext(ctx, &rows, `
+		SELECT role, permission FROM role_permissions WHERE archived_at IS NULL
+	`)
+	if err != nil {
+		return models.PolicySummary{}, err
+	}
+	return p.replace(rows), nil
+}
+
+// allows reports whether any of the roles holds at least one of the permissions
+func (p *policyEngine) allows(roles []string, permissions []string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, role := range roles {
+		for _, permission := range permissions {
+			if p.grants[role][permission] {
+				return true
+			}
+		}
+	}
+	return false
+}
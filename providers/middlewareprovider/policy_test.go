@@ -0,0 +1,298 @@
+package middlewareprovider
+
+import (
+	"asset/models"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testPolicy = []policyRow{
+	{Role: "admin", Permission: "role.manage"},
+	{Role: "admin", Permission: "asset.read"},
+	{Role: "asset_manager", Permission: "asset.read"},
+	{Role: "asset_manager", Permission: "asset.create"},
+	{Role: "employee_manager", Permission: "user.read"},
+}
+
+func TestPolicyAllows(t *testing.T) {
+	policy := newPolicyEngine(testPolicy)
+
+	tests := []struct {
+		name        string
+		roles       []string
+		permissions []string
+		expected    bool
+	}{
+		{name: "role holds the permission", roles: []string{"asset_manager"}, permissions: []string{"asset.create"}, expected: true},
+		{name: "role lacks the permission", roles: []string{"asset_manager"}, permissions: []string{"role.manage"}, expected: false},
+		{name: "any of the roles is enough", roles: []string{"employee", "employee_manager"}, permissions: []string{"user.read"}, expected: true},
+		{name: "any of the permissions is enough", roles: []string{"employee_manager"}, permissions: []string{"asset.read", "user.read"}, expected: true},
+		{name: "role name alone grants nothing", roles: []string{"admin"}, permissions: []string{"user.delete"}, expected: false},
+		{name: "unknown role", roles: []string{"contractor"}, permissions: []string{"asset.read"}, expected: false},
+		{name: "no roles", roles: nil, permissions: []string{"asset.read"}, expected: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, policy.allows(tc.roles, tc.permissions))
+		})
+	}
+}
+
+func TestPolicyReload(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	sqlxDB := sqlx.NewDb(db, "postgres")
+
+	policy := newPolicyEngine(testPolicy)
+	assert.False(t, policy.allows([]string{"employee_manager"}, []string{"asset.read"}))
+
+	mock.ExpectQuery("SELECT role, permission FROM role_permissions").
+		WillReturnRows(sqlmock.NewRows([]string{"role", "permission"}).
+			AddRow("employee_manager", "asset.read").
+			AddRow("employee_manager", "user.read"))
+	summary, err := policy.reload(context.Background(), sqlxDB)
+	require.NoError(t, err)
+	assert.Equal(t, 1, summary.Roles)
+	assert.Equal(t, 2, summary.Grants)
+	assert.True(t, policy.allows([]string{"employee_manager"}, []string{"asset.read"}))
+	// grants that are gone from the store are gone from the policy
+	assert.False(t, policy.allows([]string{"admin"}, []string{"role.manage"}))
+
+	// a failed reload keeps the previous policy
+	mock.ExpectQuery("SELECT role, permission FROM role_permissions").WillReturnError(errors.New("db down"))
+	_, err = policy.reload(context.Background(), sqlxDB)
+	assert.Error(t, err)
+	assert.True(t, policy.allows([]string{"employee_manager"}, []string{"user.read"}))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// delegatedRolesQuery only counts delegations whose users are active and whose delegator still holds the role
+const delegatedRolesQuery = `(?s)SELECT d.role FROM role_delegations d` +
+	`.*JOIN users delegator ON delegator.id = d.delegator_id AND delegator.archived_at IS NULL` +
+	`.*JOIN users delegate ON delegate.id = d.delegate_id AND delegate.archived_at IS NULL` +
+	`.*ur.user_id = d.delegator_id AND ur.role = d.role AND ur.archived_at IS NULL`
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name        string
+		roles       []string
+		permissions []models.Permission
+		// scopes of the api key making the request, nil for a signed in user
+		scopes []string
+		// policyErr leaves the policy unloaded and fails loading it on the first request
+		policyErr          error
+		delegatedRoles     []string
+		delegationsErr     error
+		expectDelegations  bool
+		expectedStatusCode int
+	}{
+		{name: "granted by own role", roles: []string{"asset_manager"}, expectedStatusCode: http.StatusOK},
+		{name: "granted by delegated role", roles: []string{"employee"}, delegatedRoles: []string{"asset_manager"}, expectDelegations: true, expectedStatusCode: http.StatusOK},
+		{name: "denied", roles: []string{"employee"}, expectDelegations: true, expectedStatusCode: http.StatusForbidden},
+		{name: "no caller in context", expectedStatusCode: http.StatusUnauthorized},
+		{
+			name:               "any of the permissions is enough",
+			roles:              []string{"employee_manager"},
+			permissions:        []models.Permission{models.AssetCreatePermission, models.UserReadPermission},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "delegated role lacking the permission",
+			roles:              []string{"employee"},
+			delegatedRoles:     []string{"employee_manager"},
+			expectDelegations:  true,
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{name: "api key with the scope", roles: []string{"asset_manager"}, scopes: []string{"asset.read", "asset.create"}, expectedStatusCode: http.StatusOK},
+		{
+			// the role would allow it, the key was not given the scope
+			name:               "api key without the scope",
+			roles:              []string{"asset_manager"},
+			scopes:             []string{"asset.read"},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{name: "policy fails to load", roles: []string{"asset_manager"}, policyErr: errors.New("db down"), expectedStatusCode: http.StatusInternalServerError},
+		{
+			name:               "delegations fail to load",
+			roles:              []string{"employee"},
+			delegationsErr:     errors.New("db down"),
+			expectDelegations:  true,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			policy := newPolicyEngine(testPolicy)
+			if tc.policyErr != nil {
+				policy = &policyEngine{}
+				mock.ExpectQuery("SELECT role, permission FROM role_permissions").WillReturnError(tc.policyErr)
+			}
+			if tc.expectDelegations {
+				rows := sqlmock.NewRows([]string{"role"})
+				for _, role := range tc.delegatedRoles {
+					rows.AddRow(role)
+				}
+				query := mock.ExpectQuery(delegatedRolesQuery).WithArgs("user-1")
+				if tc.delegationsErr != nil {
+					query.WillReturnError(tc.delegationsErr)
+				} else {
+					query.WillReturnRows(rows)
+				}
+			}
+
+			permissions := tc.permissions
+			if permissions == nil {
+				permissions = []models.Permission{models.AssetCreatePermission}
+			}
+			auth := &DefaultAuthMiddleware{db: sqlx.NewDb(db, "postgres"), policy: policy}
+			handler := auth.RequirePermission(permissions...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/asset", nil)
+			ctx := req.Context()
+			if tc.roles != nil {
+				ctx = context.WithValue(ctx, UserContextKey, "user-1")
+				ctx = context.WithValue(ctx, RolesContextKey, tc.roles)
+			}
+			if tc.scopes != nil {
+				ctx = context.WithValue(ctx, APIKeyScopesContextKey, tc.scopes)
+			}
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req.WithContext(ctx))
+
+			assert.Equal(t, tc.expectedStatusCode, res.Code)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCallerHasPermission(t *testing.T) {
+	tests := []struct {
+		name        string
+		roles       []string
+		permissions []models.Permission
+		// scopes of the api key making the request, nil for a signed in user
+		scopes   []string
+		expected bool
+		wantErr  bool
+	}{
+		{name: "signed in user holding the permission", roles: []string{"employee_manager"}, permissions: []models.Permission{models.UserReadPermission}, expected: true},
+		{name: "signed in user lacking the permission", roles: []string{"employee"}, permissions: []models.Permission{models.UserReadPermission}},
+		{name: "api key with the scope", roles: []string{"employee_manager"}, permissions: []models.Permission{models.UserReadPermission}, scopes: []string{"user.read"}, expected: true},
+		{
+			// the role would allow it, the key was not given the scope
+			name:        "api key without the scope",
+			roles:       []string{"employee_manager"},
+			permissions: []models.Permission{models.UserReadPermission},
+			scopes:      []string{"asset.read"},
+		},
+		{
+			// the key's scope and the owner's role have to meet on the same permission
+			name:        "scope and role on different permissions",
+			roles:       []string{"employee_manager"},
+			permissions: []models.Permission{models.AssetReadPermission, models.UserReadPermission},
+			scopes:      []string{"asset.read"},
+		},
+		{name: "no caller in context", permissions: []models.Permission{models.UserReadPermission}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			auth := &DefaultAuthMiddleware{policy: newPolicyEngine(testPolicy)}
+			req := httptest.NewRequest(http.MethodGet, "/api/search", nil)
+			ctx := req.Context()
+			if tc.roles != nil {
+				ctx = context.WithValue(ctx, UserContextKey, "user-1")
+				ctx = context.WithValue(ctx, RolesContextKey, tc.roles)
+			}
+			if tc.scopes != nil {
+				ctx = context.WithValue(ctx, APIKeyScopesContextKey, tc.scopes)
+			}
+
+			granted, err := auth.CallerHasPermission(req.WithContext(ctx), tc.permissions...)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, granted)
+		})
+	}
+}
+
+func TestRequireUserSession(t *testing.T) {
+	serviceToken, err := GenerateServiceAccountJWT("account-1", []string{"asset_manager"})
+	require.NoError(t, err)
+	userToken, err := GenerateJWT("user-1", []string{"asset_manager"})
+	require.NoError(t, err)
+	assert.True(t, IsServiceAccountToken(serviceToken))
+	assert.False(t, IsServiceAccountToken(userToken))
+
+	tests := []struct {
+		name               string
+		key                contextKey
+		value              interface{}
+		expectedStatusCode int
+	}{
+		{name: "person", expectedStatusCode: http.StatusOK},
+		{name: "api key", key: APIKeyIDContextKey, value: "key-1", expectedStatusCode: http.StatusForbidden},
+		{name: "service account", key: ServiceAccountContextKey, value: true, expectedStatusCode: http.StatusForbidden},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			auth := &DefaultAuthMiddleware{}
+			handler := auth.RequireUserSession()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/users/mfa/enroll", nil)
+			if tc.key != "" {
+				req = req.WithContext(context.WithValue(req.Context(), tc.key, tc.value))
+			}
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+
+			assert.Equal(t, tc.expectedStatusCode, res.Code)
+		})
+	}
+}
+
+func TestOrganizationSlug(t *testing.T) {
+	tests := []struct {
+		name     string
+		host     string
+		domain   string
+		expected string
+		found    bool
+	}{
+		{name: "subdomain", host: "acme.assets.example.com", domain: "assets.example.com", expected: "acme", found: true},
+		{name: "with port", host: "Acme.assets.example.com:8080", domain: "assets.example.com", expected: "acme", found: true},
+		{name: "bare domain", host: "assets.example.com", domain: "assets.example.com"},
+		{name: "nested subdomain", host: "a.acme.assets.example.com", domain: "assets.example.com"},
+		{name: "other domain", host: "acme.example.org", domain: "assets.example.com"},
+		{name: "subdomains off", host: "acme.assets.example.com"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			slug, found := OrganizationSlug(tc.host, tc.domain)
+			assert.Equal(t, tc.found, found)
+			assert.Equal(t, tc.expected, slug)
+		})
+	}
+}
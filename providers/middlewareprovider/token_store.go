@@ -0,0 +1,204 @@
+package middlewareprovider
+
+import (
+	"asset/models"
+	redisprovider "asset/providers/redisProvider"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// refresh tokens are tracked in redis by jti:
+//
+//	auth:refresh:<jti>                 <family> while usable, used:<family>:<unix> once rotated
+//	auth:refresh_family_revoked:<fam>  set when a rotated token is presented again
+//	auth:revoked_before:<subject>      unix time, every token of the subject issued up to then is dead
+const (
+	// a rotated token presented again this soon is a client racing itself, not a stolen token
+	refreshReuseGrace = 10 * time.Second
+)
+
+// spendRefreshScript marks the refresh token in KEYS[1] used as ARGV[1] for ARGV[2] ms unless it is used
+// already, and returns the state it had. Two requests racing with the same token can't both see it unused
+const spendRefreshScript = `
+local state = redis.call('GET', KEYS[1])
+if not state then
+	return ''
+end
+if string.sub(state, 1, 5) ~= 'used:' then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+end
+return state
+`
+
+func init() {
+	redisprovider.RegisterScript(spendRefreshScript, func(store *redisprovider.MemoryStore, keys []string, args []interface{}) (interface{}, error) {
+		state, ok := store.Get(keys[0])
+		if !ok {
+			return "", nil
+		}
+		if !strings.HasPrefix(state, "used:") {
+			ttl, err := redisprovider.ArgFloat(args[1])
+			if err != nil {
+				return nil, err
+			}
+			store.Set(keys[0], redisprovider.ArgString(args[0]), time.Duration(ttl)*time.Millisecond)
+		}
+		return state, nil
+	})
+}
+
+var (
+	ErrInvalidRefreshToken = models.NewServiceError(http.StatusUnauthorized, "invalid_refresh_token", "invalid or expired refresh token")
+	ErrTokenRevoked        = models.NewServiceError(http.StatusUnauthorized, "token_revoked", "token has been revoked")
+	ErrRefreshTokenReused  = models.NewServiceError(http.StatusUnauthorized, "refresh_token_reused", "refresh token reuse detected")
+)
+
+func refreshTokenKey(jti string) string {
+	return fmt.Sprintf("auth:refresh:%s", jti)
+}
+
+func refreshFamilyRevokedKey(family string) string {
+	return fmt.Sprintf("auth:refresh_family_revoked:%s", family)
+}
+
+func revokedBeforeKey(subject string) string {
+	return fmt.Sprintf("auth:revoked_before:%s", subject)
+}
+
+// issueRefreshToken signs a refresh token and registers its jti, an empty family starts a new login session
+func (a *DefaultAuthMiddleware) issueRefreshToken(ctx context.Context, subject, family string) (string, error) {
+	if family == "" {
+		family = uuid.NewString()
+	}
+	jti := uuid.NewString()
+	now := time.Now()
+	refreshTokenTTL := currentJWTSettings().refreshTokenTTL
+	claims := jwt.MapClaims{
+		"sub": subject,
+		"typ": "refresh",
+		"jti": jti,
+		"fam": family,
+		"iat": now.Unix(),
+		"exp": now.Add(refreshTokenTTL).Unix(),
+	}
+	key, err := refreshKey()
+	if err != nil {
+		return "", err
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+	if err != nil {
+		return "", err
+	}
+	if err := a.redis.Set(ctx, refreshTokenKey(jti), family, refreshTokenTTL); err != nil {
+		return "", fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return token, nil
+}
+
+// rotateRefreshToken spends the presented token and returns its subject with a replacement in the same family.
+// Presenting an already rotated token again revokes the whole family, since one of the two holders is not the user.
+func (a *DefaultAuthMiddleware) rotateRefreshToken(ctx context.Context, tokenStr string) (string, string, error) {
+	claims, err := ParseRefreshToken(tokenStr)
+	if err != nil {
+		return "", "", err
+	}
+	if claims.ID == "" || claims.Family == "" {
+		// issued before rotation existed
+		return "", "", ErrTokenRevoked
+	}
+	if a.isRevoked(ctx, claims.Subject, claims.IssuedAt) {
+		return "", "", ErrTokenRevoked
+	}
+	if revoked, err := a.redis.Get(ctx, refreshFamilyRevokedKey(claims.Family)); err == nil && revoked != "" {
+		return "", "", ErrTokenRevoked
+	}
+
+	used := fmt.Sprintf("used:%s:%d", claims.Family, time.Now().Unix())
+	result, err := a.redis.Eval(ctx, spendRefreshScript, []string{refreshTokenKey(claims.ID)}, used, time.Until(claims.ExpiresAt).Milliseconds())
+	state, _ := result.(string)
+	if err != nil || state == "" {
+		// expired from the store or redis is unreachable, either way the token can't be trusted
+		return "", "", ErrTokenRevoked
+	}
+	if strings.HasPrefix(state, "used:") {
+		if usedAt, ok := parseUsedAt(state); ok && time.Since(usedAt) < refreshReuseGrace {
+			return "", "", ErrTokenRevoked
+		}
+		if err := a.redis.Set(ctx, refreshFamilyRevokedKey(claims.Family), "1", currentJWTSettings().refreshTokenTTL); err != nil {
+			return "", "", fmt.Errorf("failed to revoke refresh token family: %w", err)
+		}
+		return "", "", ErrRefreshTokenReused
+	}
+
+	next, err := a.issueRefreshToken(ctx, claims.Subject, claims.Family)
+	if err != nil {
+		return "", "", err
+	}
+	return claims.Subject, next, nil
+}
+
+// RevokeRefreshToken ends the session the refresh token belongs to, every token rotated from the same
+// login stops working. A token that is already invalid needs no revoking
+func (a *DefaultAuthMiddleware) RevokeRefreshToken(ctx context.Context, subject, tokenStr string) error {
+	claims, err := ParseRefreshToken(tokenStr)
+	if err != nil || claims.Family == "" {
+		return nil
+	}
+	if claims.Subject != subject {
+		return ErrInvalidRefreshToken
+	}
+	// the newest token of the family may outlive the one presented
+	if err := a.redis.Set(ctx, refreshFamilyRevokedKey(claims.Family), "1", currentJWTSettings().refreshTokenTTL); err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+	return nil
+}
+
+func parseUsedAt(state string) (time.Time, bool) {
+	idx := strings.LastIndex(state, ":")
+	if idx < 0 {
+		return time.Time{}, false
+	}
+	unix, err := strconv.ParseInt(state[idx+1:], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(unix, 0), true
+}
+
+// RevokeUserTokens kills every access and refresh token issued so far to the given subjects,
+// older google sessions use the firebase uid as subject so callers pass both ids for such users
+func (a *DefaultAuthMiddleware) RevokeUserTokens(ctx context.Context, subjects ...string) error {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	// the marker has to outlive every token it covers, refresh tokens always live the longest
+	ttl := currentJWTSettings().refreshTokenTTL
+	for _, subject := range subjects {
+		if subject == "" {
+			continue
+		}
+		if err := a.redis.Set(ctx, revokedBeforeKey(subject), now, ttl); err != nil {
+			return fmt.Errorf("failed to revoke tokens: %w", err)
+		}
+	}
+	return nil
+}
+
+// isRevoked fails open when redis can't be read, access tokens are short lived and refresh has its own check
+func (a *DefaultAuthMiddleware) isRevoked(ctx context.Context, subject string, issuedAt time.Time) bool {
+	value, err := a.redis.Get(ctx, revokedBeforeKey(subject))
+	if err != nil || value == "" {
+		return false
+	}
+	revokedBefore, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return false
+	}
+	return issuedAt.Unix() <= revokedBefore
+}
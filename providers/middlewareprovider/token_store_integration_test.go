@@ -0,0 +1,19 @@
+//go:build integration
+
+package middlewareprovider
+
+import (
+	"asset/database/testdb"
+	"testing"
+)
+
+func TestSpendRefreshScriptParity(t *testing.T) {
+	keys := []string{"refresh"}
+	testdb.ScriptParity(t, spendRefreshScript, map[string]string{"refresh": "family-1"},
+		// the first spend returns the family and marks the token used, the second sees it used
+		testdb.ScriptCall{Keys: keys, Args: []interface{}{"used:next-1", 60_000}},
+		testdb.ScriptCall{Keys: keys, Args: []interface{}{"used:next-2", 60_000}},
+		// a token that isn't there
+		testdb.ScriptCall{Keys: []string{"missing"}, Args: []interface{}{"used:next-3", 60_000}},
+	)
+}
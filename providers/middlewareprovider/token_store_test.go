@@ -0,0 +1,109 @@
+package middlewareprovider
+
+import (
+	"asset/models"
+	redisprovider "asset/providers/redisProvider"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateRefreshTokenConcurrently(t *testing.T) {
+	useJWTConfig(t, models.JWTConfig{SecretKey: []byte("test-secret"), RefreshKey: []byte("test-refresh-secret")})
+
+	ctx := context.Background()
+	auth := &DefaultAuthMiddleware{redis: redisprovider.NewMemoryRedisProvider()}
+	token, err := auth.issueRefreshToken(ctx, "user-1", "")
+	require.NoError(t, err)
+
+	// a client retrying a refresh races itself, only one of the requests may get a new token
+	const requests = 20
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		rotated  []string
+		failures []error
+	)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			subject, next, err := auth.rotateRefreshToken(ctx, token)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failures = append(failures, err)
+				return
+			}
+			assert.Equal(t, "user-1", subject)
+			rotated = append(rotated, next)
+		}()
+	}
+	wg.Wait()
+
+	require.Len(t, rotated, 1)
+	for _, err := range failures {
+		// inside the grace period the losers are turned away without revoking the family
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+	}
+	_, next, err := auth.rotateRefreshToken(ctx, rotated[0])
+	require.NoError(t, err)
+	assert.NotEmpty(t, next)
+}
+
+func TestRotateRefreshTokenReuse(t *testing.T) {
+	useJWTConfig(t, models.JWTConfig{SecretKey: []byte("test-secret"), RefreshKey: []byte("test-refresh-secret")})
+
+	ctx := context.Background()
+	redis := redisprovider.NewMemoryRedisProvider()
+	auth := &DefaultAuthMiddleware{redis: redis}
+	token, err := auth.issueRefreshToken(ctx, "user-1", "")
+	require.NoError(t, err)
+	_, next, err := auth.rotateRefreshToken(ctx, token)
+	require.NoError(t, err)
+
+	// the token was rotated long ago, presenting it again means someone else holds it
+	claims, err := ParseRefreshToken(token)
+	require.NoError(t, err)
+	require.NoError(t, redis.Set(ctx, refreshTokenKey(claims.ID), "used:"+claims.Family+":1", 0))
+	_, _, err = auth.rotateRefreshToken(ctx, token)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	// and the whole family is gone with it
+	_, _, err = auth.rotateRefreshToken(ctx, next)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+}
+
+// the jti of a real token is still live, re-signing it for another subject with the access token key
+// or an empty key must not pass as a refresh token
+func TestRefreshTokenRefusesOtherKeys(t *testing.T) {
+	useJWTConfig(t, models.JWTConfig{SecretKey: []byte("test-secret"), RefreshKey: []byte("test-refresh-secret")})
+
+	ctx := context.Background()
+	auth := &DefaultAuthMiddleware{redis: redisprovider.NewMemoryRedisProvider()}
+	token, err := auth.issueRefreshToken(ctx, "user-1", "")
+	require.NoError(t, err)
+	claims, err := ParseRefreshToken(token)
+	require.NoError(t, err)
+
+	for name, key := range map[string][]byte{"empty key": {}, "access token key": []byte("test-secret")} {
+		t.Run(name, func(t *testing.T) {
+			forged, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+				"sub": "admin-1",
+				"typ": "refresh",
+				"jti": claims.ID,
+				"fam": claims.Family,
+				"iat": time.Now().Unix(),
+				"exp": time.Now().Add(time.Hour).Unix(),
+			}).SignedString(key)
+			require.NoError(t, err)
+			_, _, err = auth.rotateRefreshToken(ctx, forged)
+			assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package oidcprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// GoogleProviderName is the provider behind the original /v2/user/login google sign in
+const GoogleProviderName = "google"
+
+type firebaseOIDCService struct {
+	firebase providers.FirebaseProvider
+}
+
+// NewFirebaseOIDCProvider puts firebase id tokens behind the same interface as any other issuer
+func NewFirebaseOIDCProvider(firebase providers.FirebaseProvider) providers.OIDCProvider {
+	return &firebaseOIDCService{firebase: firebase}
+}
+
+func (f *firebaseOIDCService) Name() string {
+	return GoogleProviderName
+}
+
+func (f *firebaseOIDCService) VerifyIDToken(ctx context.Context, rawToken string) (models.OIDCIdentity, error) {
+	token, err := f.firebase.VerifyIDToken(ctx, rawToken)
+	if errors.Is(err, models.ErrFirebaseUnavailable) {
+		return models.OIDCIdentity{}, err
+	}
+	if err != nil {
+		return models.OIDCIdentity{}, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	record, err := f.firebase.GetUserByUID(ctx, token.UID)
+	if err != nil {
+		return models.OIDCIdentity{}, fmt.Errorf("failed to get user info from firebase: %w", err)
+	}
+	return models.OIDCIdentity{
+		Provider:      GoogleProviderName,
+		Issuer:        token.Issuer,
+		Subject:       record.UID,
+		Email:         record.Email,
+		EmailVerified: record.EmailVerified,
+		Name:          record.DisplayName,
+	}, nil
+}
@@ -0,0 +1,127 @@
+package oidcprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	GitHubProviderName = "github"
+	// github has no id tokens, identities are keyed by this fixed issuer and the numeric user id
+	githubIssuer = "https://github.com"
+	githubAPIURL = "https://api.github.com"
+)
+
+type githubService struct {
+	clientID     string
+	clientSecret string
+	client       *http.Client
+}
+
+// NewGitHubProvider signs in with a github oauth access token in place of an id token. The token is checked
+// against the oauth app, a token issued to some other app is rejected, and it needs the user:email scope
+func NewGitHubProvider(clientID, clientSecret string) providers.OIDCProvider {
+	return &githubService{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		client:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (g *githubService) Name() string {
+	return GitHubProviderName
+}
+
+func (g *githubService) VerifyIDToken(ctx context.Context, accessToken string) (models.OIDCIdentity, error) {
+	var check struct {
+		User struct {
+			ID    int64  `json:"id"`
+			Login string `json:"login"`
+			Name  string `json:"name"`
+		} `json:"user"`
+	}
+	body, _ := json.Marshal(map[string]string{"access_token": accessToken})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, githubAPIURL+"/applications/"+g.clientID+"/token", bytes.NewReader(body))
+	if err != nil {
+		return models.OIDCIdentity{}, err
+	}
+	req.SetBasicAuth(g.clientID, g.clientSecret)
+	status, err := g.do(req, &check)
+	if err != nil {
+		return models.OIDCIdentity{}, err
+	}
+	// github answers 404 for tokens that are unknown, revoked or belong to another app
+	if status == http.StatusNotFound || status == http.StatusUnprocessableEntity {
+		return models.OIDCIdentity{}, fmt.Errorf("%w: github token is not valid for this app", ErrInvalidIDToken)
+	}
+	if status != http.StatusOK || check.User.ID == 0 {
+		return models.OIDCIdentity{}, fmt.Errorf("unexpected status %d from github token check", status)
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, githubAPIURL+"/user/emails", nil)
+	if err != nil {
+		return models.OIDCIdentity{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	status, err = g.do(req, &emails)
+	if err != nil {
+		return models.OIDCIdentity{}, err
+	}
+	if status == http.StatusForbidden || status == http.StatusNotFound {
+		return models.OIDCIdentity{}, fmt.Errorf("%w: github token lacks the user:email scope", ErrInvalidIDToken)
+	}
+	if status != http.StatusOK {
+		return models.OIDCIdentity{}, fmt.Errorf("unexpected status %d from github emails", status)
+	}
+
+	identity := models.OIDCIdentity{
+		Provider: GitHubProviderName,
+		Issuer:   githubIssuer,
+		Subject:  strconv.FormatInt(check.User.ID, 10),
+		Name:     check.User.Name,
+	}
+	if identity.Name == "" {
+		identity.Name = check.User.Login
+	}
+	for _, email := range emails {
+		if email.Primary {
+			identity.Email = strings.ToLower(email.Email)
+			identity.EmailVerified = email.Verified
+			break
+		}
+	}
+	return identity, nil
+}
+
+// do decodes the body of a 200 response into out and returns the status either way
+func (g *githubService) do(req *http.Request, out interface{}) (int, error) {
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if req.Body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := g.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return res.StatusCode, nil
+	}
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return res.StatusCode, fmt.Errorf("failed to decode github response: %w", err)
+	}
+	return res.StatusCode, nil
+}
@@ -0,0 +1,81 @@
+package oidcprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	MicrosoftProviderName = "microsoft"
+	microsoftLoginURL     = "https://login.microsoftonline.com/"
+	// tenant of personal microsoft accounts, their mailbox is owned by microsoft
+	microsoftConsumerTenant = "9188040d-6c67-4c5b-b112-36a304b66dad"
+)
+
+type microsoftService struct {
+	tenant string
+	oidc   *oidcService
+}
+
+// NewMicrosoftProvider verifies microsoft identity platform id tokens. The tenant is a tenant id, or common,
+// organizations or consumers to accept users of any tenant of that kind
+func NewMicrosoftProvider(clientID, tenant string) providers.OIDCProvider {
+	if tenant == "" {
+		tenant = "common"
+	}
+	return &microsoftService{
+		tenant: tenant,
+		oidc: &oidcService{
+			cfg: models.OIDCProviderConfig{
+				Name:     MicrosoftProviderName,
+				Issuer:   microsoftLoginURL + tenant + "/v2.0",
+				ClientID: clientID,
+			},
+			client:  &http.Client{Timeout: 10 * time.Second},
+			jwksURI: microsoftLoginURL + tenant + "/discovery/v2.0/keys",
+		},
+	}
+}
+
+func (m *microsoftService) Name() string {
+	return MicrosoftProviderName
+}
+
+// VerifyIDToken accepts the issuer of the tenant named in the token's tid claim, so users of every
+// tenant allowed by the configured one sign in with the same client id
+func (m *microsoftService) VerifyIDToken(ctx context.Context, rawToken string) (models.OIDCIdentity, error) {
+	claims, err := m.oidc.parseToken(ctx, rawToken)
+	if err != nil {
+		return models.OIDCIdentity{}, err
+	}
+	tenantID := stringClaim(claims, "tid")
+	issuer := stringClaim(claims, "iss")
+	if tenantID == "" || issuer != microsoftLoginURL+tenantID+"/v2.0" {
+		return models.OIDCIdentity{}, fmt.Errorf("%w: issuer %q doesn't match tenant %q", ErrInvalidIDToken, issuer, tenantID)
+	}
+	if !m.allowsTenant(tenantID) {
+		return models.OIDCIdentity{}, fmt.Errorf("%w: tenant %s is not allowed", ErrInvalidIDToken, tenantID)
+	}
+
+	identity := m.oidc.identity(claims, issuer)
+	// work account emails are editable by tenant admins, they only count as verified when microsoft
+	// says the tenant owns the domain
+	identity.EmailVerified = identity.Email != "" && (tenantID == microsoftConsumerTenant || boolClaim(claims, "xms_edov"))
+	return identity, nil
+}
+
+func (m *microsoftService) allowsTenant(tenantID string) bool {
+	switch m.tenant {
+	case "common":
+		return true
+	case "organizations":
+		return tenantID != microsoftConsumerTenant
+	case "consumers":
+		return tenantID == microsoftConsumerTenant
+	}
+	return tenantID == m.tenant
+}
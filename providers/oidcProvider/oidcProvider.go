@@ -0,0 +1,293 @@
+package oidcprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// keys are refetched after this, or sooner when a token is signed with an unknown kid
+	jwksCacheTTL = time.Hour
+	// an unknown kid can't make us hammer the issuer
+	jwksMinRefresh = time.Minute
+	clockLeeway    = 30 * time.Second
+)
+
+var ErrInvalidIDToken = errors.New("invalid id token")
+
+type oidcService struct {
+	cfg    models.OIDCProviderConfig
+	client *http.Client
+
+	mu        sync.RWMutex
+	jwksURI   string
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// NewOIDCProvider verifies tokens of any issuer that publishes openid discovery metadata,
+// the signing keys are loaded lazily so a down issuer doesn't stop the server from starting
+func NewOIDCProvider(cfg models.OIDCProviderConfig) providers.OIDCProvider {
+	return &oidcService{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (o *oidcService) Name() string {
+	return o.cfg.Name
+}
+
+func (o *oidcService) VerifyIDToken(ctx context.Context, rawToken string) (models.OIDCIdentity, error) {
+	claims, err := o.parseToken(ctx, rawToken, jwt.WithIssuer(o.cfg.Issuer))
+	if err != nil {
+		return models.OIDCIdentity{}, err
+	}
+	return o.identity(claims, o.cfg.Issuer), nil
+}
+
+// parseToken checks the signature, audience and lifetime, the issuer check is left to the caller
+// because multi tenant issuers don't have a single issuer value
+func (o *oidcService) parseToken(ctx context.Context, rawToken string, opts ...jwt.ParserOption) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	opts = append(opts,
+		jwt.WithAudience(o.cfg.ClientID),
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockLeeway),
+	)
+	_, err := jwt.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return o.signingKey(ctx, kid)
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if stringClaim(claims, "sub") == "" {
+		return nil, fmt.Errorf("%w: missing sub claim", ErrInvalidIDToken)
+	}
+	return claims, nil
+}
+
+func (o *oidcService) identity(claims jwt.MapClaims, issuer string) models.OIDCIdentity {
+	identity := models.OIDCIdentity{
+		Provider: o.cfg.Name,
+		Issuer:   issuer,
+		Subject:  stringClaim(claims, "sub"),
+		Email:    strings.ToLower(stringClaim(claims, "email")),
+		Name:     stringClaim(claims, "name"),
+	}
+	// azure ad puts the mailbox in preferred_username when the email claim isn't configured
+	if identity.Email == "" {
+		if username := stringClaim(claims, "preferred_username"); strings.Contains(username, "@") {
+			identity.Email = strings.ToLower(username)
+		}
+	}
+	identity.EmailVerified = o.cfg.TrustEmail || boolClaim(claims, "email_verified")
+	identity.Role = o.mapRole(claims)
+	return identity
+}
+
+// mapRole walks the mappings in configured order so the first listed mapping wins when the
+// user is in several mapped groups
+func (o *oidcService) mapRole(claims jwt.MapClaims) string {
+	if o.cfg.RoleClaim == "" {
+		return ""
+	}
+	values := make(map[string]bool)
+	switch v := claims[o.cfg.RoleClaim].(type) {
+	case string:
+		values[v] = true
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values[s] = true
+			}
+		}
+	}
+	for _, mapping := range o.cfg.RoleMappings {
+		if values[mapping.ClaimValue] {
+			return mapping.Role
+		}
+	}
+	return ""
+}
+
+func stringClaim(claims jwt.MapClaims, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+// some issuers send email_verified as the string "true"
+func boolClaim(claims jwt.MapClaims, name string) bool {
+	switch v := claims[name].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+func (o *oidcService) signingKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	o.mu.RLock()
+	key, ok := o.keys[kid]
+	stale := time.Since(o.fetchedAt) > jwksCacheTTL
+	canRefresh := time.Since(o.fetchedAt) > jwksMinRefresh
+	o.mu.RUnlock()
+	if ok && !stale {
+		return key, nil
+	}
+	if !ok && !stale && !canRefresh {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	if err := o.refreshKeys(ctx); err != nil {
+		if ok {
+			// keep using a known key while the issuer is unreachable
+			return key, nil
+		}
+		return nil, err
+	}
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	key, ok = o.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (o *oidcService) refreshKeys(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	// another request refreshed while this one waited for the lock
+	if time.Since(o.fetchedAt) < jwksMinRefresh {
+		return nil
+	}
+
+	// multi tenant issuers publish a templated issuer in discovery, their key set url is filled in up front
+	if o.jwksURI == "" {
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := o.getJSON(ctx, o.cfg.Issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+			return fmt.Errorf("failed to load openid configuration: %w", err)
+		}
+		if strings.TrimSuffix(discovery.Issuer, "/") != o.cfg.Issuer || discovery.JWKSURI == "" {
+			return fmt.Errorf("openid configuration of %s doesn't match the configured issuer", o.cfg.Issuer)
+		}
+		o.jwksURI = discovery.JWKSURI
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := o.getJSON(ctx, o.jwksURI, &set); err != nil {
+		return fmt.Errorf("failed to load signing keys: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	o.keys = keys
+	o.fetchedAt = time.Now()
+	return nil
+}
+
+func (o *oidcService) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", res.StatusCode, url)
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
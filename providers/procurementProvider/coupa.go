@@ -0,0 +1,125 @@
+package procurementprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// coupaScope lets the oauth client create purchase orders and nothing else
+const coupaScope = "core.purchase_order.write"
+
+// coupaProvider creates external purchase orders, ones raised outside coupa's requisitions that
+// suppliers and receiving still see there
+type coupaProvider struct {
+	url      string
+	clientID string
+	currency string
+	secrets  providers.SecretsProvider
+	client   *http.Client
+	tokens   utils.TokenCache
+}
+
+type coupaOrderLine struct {
+	LineNum       int     `json:"line-num"`
+	Type          string  `json:"type"`
+	Description   string  `json:"description"`
+	SourcePartNum string  `json:"source-part-num,omitempty"`
+	Quantity      int     `json:"quantity"`
+	Price         float64 `json:"price"`
+}
+
+type coupaName struct {
+	Name string `json:"name,omitempty"`
+	Code string `json:"code,omitempty"`
+}
+
+type coupaOrder struct {
+	Type       string           `json:"type"`
+	PONumber   string           `json:"po-number"`
+	Supplier   coupaName        `json:"supplier"`
+	Currency   coupaName        `json:"currency"`
+	OrderLines []coupaOrderLine `json:"order-lines"`
+}
+
+func (c *coupaProvider) CreatePurchaseOrder(ctx context.Context, po models.PurchaseOrderPush) (string, error) {
+	order := coupaOrder{
+		Type:     "ExternalOrderHeader",
+		PONumber: po.Number,
+		Supplier: coupaName{Name: po.Vendor},
+		Currency: coupaName{Code: c.currency},
+	}
+	for i, line := range po.Lines {
+		order.OrderLines = append(order.OrderLines, coupaOrderLine{
+			LineNum:       i + 1,
+			Type:          "OrderQuantityLine",
+			Description:   line.Description,
+			SourcePartNum: line.PartNumber,
+			Quantity:      line.Quantity,
+			Price:         line.UnitCost,
+		})
+	}
+	payload, err := json.Marshal(order)
+	if err != nil {
+		return "", err
+	}
+	token, err := c.accessToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/api/purchase_orders", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	var created struct {
+		ID int64 `json:"id"`
+	}
+	if err := c.send(req, &created); err != nil {
+		return "", fmt.Errorf("failed to create coupa purchase order %s: %w", po.Number, err)
+	}
+	return strconv.FormatInt(created.ID, 10), nil
+}
+
+func (c *coupaProvider) accessToken(ctx context.Context) (string, error) {
+	return c.tokens.Token(ctx, func(ctx context.Context) (utils.OAuthToken, error) {
+		var token utils.OAuthToken
+		secret, err := c.secrets.GetSecret(ctx, models.SecretProcurementClientSecret)
+		if err != nil {
+			return token, fmt.Errorf("failed to read %s: %w", models.SecretProcurementClientSecret, err)
+		}
+		req, err := utils.NewClientCredentialsRequest(ctx, c.url+"/oauth2/token", c.clientID, secret, coupaScope)
+		if err != nil {
+			return token, err
+		}
+		if err := c.send(req, &token); err != nil {
+			return token, fmt.Errorf("failed to sign in to coupa: %w", err)
+		}
+		return token, nil
+	})
+}
+
+func (c *coupaProvider) send(req *http.Request, out interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach coupa: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 500))
+		return fmt.Errorf("coupa returned %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode coupa response: %w", err)
+	}
+	return nil
+}
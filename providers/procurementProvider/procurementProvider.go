@@ -0,0 +1,40 @@
+package procurementprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// NewProcurementProvider returns the e-procurement system picked by PROCUREMENT_SYSTEM, nil when
+// purchase orders stay in the asset manager. The client secret is read from secrets whenever a token
+// is fetched so a rotated one is picked up
+func NewProcurementProvider(cfg models.ProcurementConfig, secrets providers.SecretsProvider) (providers.ProcurementProvider, error) {
+	if cfg.System == "" {
+		return nil, nil
+	}
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("PROCUREMENT_URL is required for %s", cfg.System)
+	}
+	if cfg.ClientID == "" {
+		return nil, fmt.Errorf("PROCUREMENT_CLIENT_ID is required for %s", cfg.System)
+	}
+	switch cfg.System {
+	case models.ProcurementSystemCoupa:
+		if cfg.Currency == "" {
+			return nil, errors.New("PROCUREMENT_CURRENCY is required for coupa")
+		}
+		return &coupaProvider{
+			url:      strings.TrimRight(cfg.URL, "/"),
+			clientID: cfg.ClientID,
+			currency: cfg.Currency,
+			secrets:  secrets,
+			client:   &http.Client{Timeout: cfg.Timeout},
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown procurement system %q", cfg.System)
+	}
+}
@@ -0,0 +1,83 @@
+package procurementprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoupaProvider(t *testing.T) {
+	var signIns atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/oauth2/token":
+			signIns.Add(1)
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+			assert.Equal(t, "asset-manager", r.PostForm.Get("client_id"))
+			assert.Equal(t, "client-secret", r.PostForm.Get("client_secret"))
+			assert.Equal(t, coupaScope, r.PostForm.Get("scope"))
+			w.Write([]byte(`{"access_token":"coupa-token","expires_in":86400}`))
+		case "/api/purchase_orders":
+			assert.Equal(t, "Bearer coupa-token", r.Header.Get("Authorization"))
+			var order coupaOrder
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&order))
+			assert.Equal(t, coupaOrder{
+				Type:     "ExternalOrderHeader",
+				PONumber: "PO-000042",
+				Supplier: coupaName{Name: "Dell India"},
+				Currency: coupaName{Code: "INR"},
+				OrderLines: []coupaOrderLine{
+					{LineNum: 1, Type: "OrderQuantityLine", Description: "Dell Latitude 5440 laptop", SourcePartNum: "Latitude 5440", Quantity: 5, Price: 84000},
+				},
+			}, order)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":3117,"status":"issued"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	secrets := providers.NewMockSecretsProvider(ctrl)
+	secrets.EXPECT().GetSecret(gomock.Any(), models.SecretProcurementClientSecret).Return("client-secret", nil).AnyTimes()
+	coupa, err := NewProcurementProvider(models.ProcurementConfig{System: models.ProcurementSystemCoupa, URL: server.URL + "/", ClientID: "asset-manager", Currency: "INR", Timeout: time.Second}, secrets)
+	require.NoError(t, err)
+
+	po := models.PurchaseOrderPush{Number: "PO-000042", Vendor: "Dell India", Lines: []models.PurchaseOrderPushLine{
+		{Description: "Dell Latitude 5440 laptop", PartNumber: "Latitude 5440", Quantity: 5, UnitCost: 84000},
+	}}
+	for i := 0; i < 2; i++ {
+		id, err := coupa.CreatePurchaseOrder(context.Background(), po)
+		require.NoError(t, err)
+		assert.Equal(t, "3117", id)
+	}
+	// the token is reused until it is about to expire
+	assert.Equal(t, int32(1), signIns.Load())
+}
+
+func TestNewProcurementProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	secrets := providers.NewMockSecretsProvider(ctrl)
+
+	provider, err := NewProcurementProvider(models.ProcurementConfig{}, secrets)
+	assert.NoError(t, err)
+	assert.Nil(t, provider)
+
+	_, err = NewProcurementProvider(models.ProcurementConfig{System: models.ProcurementSystemCoupa, URL: "https://acme.coupahost.com"}, secrets)
+	assert.ErrorContains(t, err, "PROCUREMENT_CLIENT_ID")
+
+	_, err = NewProcurementProvider(models.ProcurementConfig{System: "ariba", URL: "https://acme.ariba.com", ClientID: "id"}, secrets)
+	assert.ErrorContains(t, err, "unknown procurement system")
+}
@@ -3,37 +3,243 @@ package providers
 import (
 	"asset/models"
 	"context"
+	"io"
 	"net/http"
 	"time"
 
 	firebaseauth "firebase.google.com/go/v4/auth"
+	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 type AuthMiddlewareService interface {
 	JWTAuthMiddleware() func(http.Handler) http.Handler
-	RequireRole(roles ...models.Role) func(http.Handler) http.Handler
+	RequirePermission(permissions ...models.Permission) func(http.Handler) http.Handler
+	HasPermission(ctx context.Context, roles []string, permissions ...models.Permission) (bool, error)
+	// CallerHasPermission also applies the scopes of the api key the request came with
+	CallerHasPermission(r *http.Request, permissions ...models.Permission) (bool, error)
+	ReloadPolicies(ctx context.Context) (models.PolicySummary, error)
+	RequireUserSession() func(http.Handler) http.Handler
+	RequireAPIKey() func(http.Handler) http.Handler
 	GetUserAndRolesFromContext(r *http.Request) (string, []string, error)
+	GetTokenExpiryFromContext(r *http.Request) (time.Time, error)
+	GetDepartmentScope(r *http.Request) (models.DepartmentScope, error)
+	// GetAPIKeyFromContext returns the api key the request authenticated with, false for jwt requests
+	GetAPIKeyFromContext(r *http.Request) (models.APIKeyCaller, bool)
 	GenerateJWT(userID string, roles []string) (string, error)
-	GenerateRefreshToken(userID string) (string, error)
+	GenerateServiceAccountJWT(accountID string, roles []string) (string, error)
+	GenerateRefreshToken(ctx context.Context, userID string) (string, error)
+	RefreshTokens(ctx context.Context, refreshToken string) (string, string, string, error)
+	RevokeRefreshToken(ctx context.Context, subject, refreshToken string) error
+	RevokeUserTokens(ctx context.Context, subjects ...string) error
+	IntrospectToken(ctx context.Context, token string) (models.TokenIntrospection, error)
+	JWKS() models.JWKSet
 }
 
 type ConfigProvider interface {
 	LoadEnv() error
+	// GetProfile is the APP_ENV profile, development, staging or production
+	GetProfile() string
 	GetDatabaseString() string
 	GetServerPort() string
+	GetRedisConfig() models.RedisConfig
+	// GetJSONCodec is jsoniter, or std for encoding/json
+	GetJSONCodec() string
+	// GetAuthMode is firebase, or fake when sign in takes an email and emails are only logged
+	GetAuthMode() string
+	GetEndDateWarningDays() int
+	GetInviteTTL() time.Duration
+	GetAppBaseURL() string
+	// GetOrganizationDomain is the domain organization subdomains hang off, empty when not used
+	GetOrganizationDomain() string
+	RequireEmailVerification() bool
+	GetEmailVerificationTTL() time.Duration
+	GetAllowedEmailDomains() []string
+	GetDefaultCountryCode() string
+	GetMFAIssuer() string
+	GetMFAEncryptionKey() []byte
+	GetOIDCProviders() []models.OIDCProviderConfig
+	GetSocialLoginConfig() models.SocialLoginConfig
+	GetSAMLConfig() (models.SAMLConfig, bool)
+	GetLDAPConfig() (models.LDAPConfig, bool)
+	GetRateLimits() models.RateLimitConfig
+	TrustProxyHeaders() bool
+	GetLockoutPolicy() models.LockoutPolicy
+	GetMagicLinkPolicy() models.MagicLinkPolicy
+	GetJWTConfig() models.JWTConfig
+	GetAuthEventRetention() time.Duration
+	GetAuthCookieConfig() models.AuthCookieConfig
+	GetSecretsConfig() models.SecretsConfig
+	GetLegacyAPISunset() time.Time
+	GetEventBrokerConfig() models.EventBrokerConfig
+	GetAccessLogConfig() models.AccessLogConfig
+	// GetDebugAddr is where pprof and expvar are served without auth, empty keeps them on the api only
+	GetDebugAddr() string
+	GetLogConfig() models.LogConfig
+	GetErrorReportingConfig() models.ErrorReportingConfig
+	GetSlackConfig() models.SlackConfig
+	GetTicketingConfig() models.TicketingConfig
+	GetMDMConfig() models.MDMConfig
+	GetSMSConfig() models.SMSConfig
+	GetIMEICheckConfig() models.IMEICheckConfig
+	GetLoginAnomalyConfig() models.LoginAnomalyConfig
+	GetWarrantyConfig() models.WarrantyConfig
+	GetObjectStorageConfig() models.ObjectStorageConfig
+	GetSheetsConfig() models.SheetsConfig
+	GetSnapshotConfig() models.SnapshotConfig
+	GetProcurementConfig() models.ProcurementConfig
+	GetESignConfig() models.ESignConfig
+	GetAccountingConfig() models.AccountingConfig
+	GetRequestLimits() models.RequestLimits
+	GetJobsConfig() models.JobsConfig
+	GetShutdownConfig() models.ShutdownConfig
+	GetDBPoolConfig() models.DBPoolConfig
+	GetQueryStatsConfig() models.QueryStatsConfig
+	GetFirebaseConfig() models.FirebaseConfig
+	GetMigrationConfig() models.MigrationConfig
+	GetCacheTTLs() models.CacheTTLs
+	// Reload reads the config file again and applies the runtime settings, the log level, rate
+	// limits, cache ttls and allowed email domains. It returns the names of those that changed
+	Reload() ([]string, error)
+	// ConfigFileChanged reports whether the config file was modified since it was last read
+	ConfigFileChanged() bool
+}
+
+// SecretsProvider hands out credentials kept outside the env files, values may change between calls
+// when they are rotated in the backend
+type SecretsProvider interface {
+	GetSecret(ctx context.Context, name string) (string, error)
+}
+
+// EventPublisher hands domain events to a message broker. Publish returns once the broker has
+// accepted the message, so a nil error means the event won't be lost
+type EventPublisher interface {
+	Publish(ctx context.Context, msg models.BrokerMessage) error
+	Close() error
+}
+
+// ErrorReporter sends unexpected errors to an error tracker. Report never blocks, reports are dropped
+// when the tracker can't keep up
+type ErrorReporter interface {
+	Report(report models.ErrorReport)
+	// Close sends what is still queued until ctx ends
+	Close(ctx context.Context) error
+}
+
+// SlackProvider posts alerts to the slack channels routed for their event. Notify never blocks,
+// messages are dropped when slack can't keep up
+type SlackProvider interface {
+	Notify(event, text string)
+	// Close sends what is still queued until ctx ends
+	Close(ctx context.Context) error
+}
+
+// TicketProvider opens service tickets in jira or servicenow and reads back how they stand
+type TicketProvider interface {
+	CreateTicket(ctx context.Context, req models.TicketReq) (models.Ticket, error)
+	// GetTicket returns models.ErrTicketNotFound for a key the service desk doesn't know
+	GetTicket(ctx context.Context, key string) (models.Ticket, error)
+	// ParseWebhook reads the ticket a service desk webhook reports on, token is the one the webhook
+	// was called with and models.ErrTicketWebhookUnauthorized is returned when it is wrong
+	ParseWebhook(ctx context.Context, token string, body []byte) (models.Ticket, error)
+}
+
+// MDMProvider lists the devices enrolled in intune or jamf
+type MDMProvider interface {
+	ListDevices(ctx context.Context) ([]models.MDMDevice, error)
+}
+
+// SMSProvider texts a template rendered with data to a number in E.164, models.ErrSMSRateLimited is
+// returned when the number already got its share of messages
+type SMSProvider interface {
+	Send(ctx context.Context, to, template string, data map[string]string) error
+}
+
+// IMEILookupProvider asks an external stolen device registry about an imei
+type IMEILookupProvider interface {
+	Lookup(ctx context.Context, imei string) (models.IMEILookup, error)
+}
+
+// GeoIPProvider tells roughly where an ip address is, private addresses get an empty location
+type GeoIPProvider interface {
+	Locate(ctx context.Context, ip string) (models.GeoLocation, error)
+}
+
+// WarrantyProvider asks the vendor of a brand for the warranty of a serial number. It returns
+// models.ErrWarrantyVendorUnsupported for a brand without a lookup and models.ErrWarrantyNotFound for
+// a serial number the vendor doesn't know
+type WarrantyProvider interface {
+	Lookup(ctx context.Context, brand, serialNo string) (models.WarrantyInfo, error)
+}
+
+// PushProvider shows a notification on the devices behind tokens. Tokens firebase no longer knows come
+// back in invalid so they can be forgotten, err is only set when no device got the message
+type PushProvider interface {
+	Send(ctx context.Context, tokens []string, msg models.PushMessage) (invalid []string, err error)
+}
+
+// StorageProvider keeps files in a bucket under keys like attachments/<id>/<name>. Clients move the
+// bytes themselves through presigned urls, which work for the configured ttl without other auth.
+// Open and Stat return models.ErrObjectNotFound for a missing key, Delete ignores one
+type StorageProvider interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Stat returns the size of the object
+	Stat(ctx context.Context, key string) (int64, error)
+	Delete(ctx context.Context, key string) error
+	// PresignUpload returns a url the client PUTs the file to with the given content type
+	PresignUpload(ctx context.Context, key, contentType string) (string, error)
+	// PresignDownload returns a url the client GETs the file from, saved as filename
+	PresignDownload(ctx context.Context, key, filename string) (string, error)
+}
+
+// SheetsProvider writes to one tab of a google sheet shared with the service account, rows are 1
+// based like the sheet's own numbering
+type SheetsProvider interface {
+	// Account is the service account email the sheets have to be shared with
+	Account() string
+	// ReadKeys returns column A of the tab, one value per row and empty for blank cells
+	ReadKeys(ctx context.Context, spreadsheetID, sheet string) ([]string, error)
+	UpdateRows(ctx context.Context, spreadsheetID, sheet string, rows map[int][]string) error
+	AppendRows(ctx context.Context, spreadsheetID, sheet string, rows [][]string) error
+	// ReplaceAll clears the tab and writes rows from the top
+	ReplaceAll(ctx context.Context, spreadsheetID, sheet string, rows [][]string) error
+}
+
+// ProcurementProvider pushes approved purchase orders to coupa
+type ProcurementProvider interface {
+	// CreatePurchaseOrder returns the id the system gave the order
+	CreatePurchaseOrder(ctx context.Context, po models.PurchaseOrderPush) (string, error)
+}
+
+// ESignProvider sends documents to docusign or dropbox sign for signature and fetches them back signed
+type ESignProvider interface {
+	// Send returns the id of the envelope the document went out in
+	Send(ctx context.Context, req models.SignatureRequest) (string, error)
+	// ParseWebhook reads the envelope a callback reports on, models.ErrSignatureWebhookUnauthorized
+	// is returned when the callback isn't signed with the webhook secret
+	ParseWebhook(ctx context.Context, header http.Header, body []byte) (models.SignatureEvent, error)
+	// DownloadSigned returns the completed pdf with the signatures on it
+	DownloadSigned(ctx context.Context, envelopeID string) ([]byte, error)
 }
 
 type DBProvider interface {
 	DB() *sqlx.DB
 	Close() error
+	// Listen delivers what is NOTIFYed on channel until ctx ends. An empty payload means the connection
+	// dropped and came back, anything notified in between was missed
+	Listen(ctx context.Context, channel string) (<-chan string, error)
+	MigrationStatus() (models.MigrationStatus, error)
 }
 
 type ZapLoggerProvider interface {
 	InitLogger()
 	SyncLogger()
 	GetLogger() *zap.Logger
+	// SetLevel changes the level of the running logger, a config reload applies LOG_LEVEL with it
+	SetLevel(level zapcore.Level)
 }
 
 type FirebaseProvider interface {
@@ -43,11 +249,83 @@ type FirebaseProvider interface {
 	CreateUser(ctx context.Context, email string) (*firebaseauth.UserRecord, error)
 	DeleteAuthUser(ctx context.Context, uid string) error
 	GetAuthUserID(ctx context.Context, email string) (string, error)
+	UpdateUserEmail(ctx context.Context, uid, email string) error
+	GetUsersByUID(ctx context.Context, uids []string) ([]*firebaseauth.UserRecord, error)
+	SetCustomUserClaims(ctx context.Context, uid string, claims map[string]interface{}) error
+	// Ping makes an authenticated call to firebase, readiness uses it to tell whether sign in can work
+	Ping(ctx context.Context) error
+}
+
+// OIDCProvider verifies id tokens of one identity provider
+type OIDCProvider interface {
+	Name() string
+	VerifyIDToken(ctx context.Context, rawToken string) (models.OIDCIdentity, error)
+}
+
+// SAMLProvider logs in through the shared oidc flow, the raw token being the posted SAMLResponse
+type SAMLProvider interface {
+	OIDCProvider
+	Metadata() ([]byte, error)
+	LoginURL(ctx context.Context, relayState string) (string, error)
+}
+
+// DirectoryProvider lists the users of an external directory so they can be synced
+type DirectoryProvider interface {
+	Name() string
+	Issuer() string
+	ListUsers(ctx context.Context) ([]models.DirectoryUser, error)
+}
+
+// RateLimiter throttles requests with redis backed token buckets
+// RateLimiter reads the limit on every request, so a config reload applies to the next one
+type RateLimiter interface {
+	LimitByIP(name string, limit func() models.RateLimit) func(http.Handler) http.Handler
+	LimitByUser(name string, limit func() models.RateLimit) func(http.Handler) http.Handler
+	// LimitByAPIKey throttles each api key on its own and refuses its requests past its monthly quota,
+	// it has to run after the jwt middleware
+	LimitByAPIKey(name string, limits func() models.RateLimitConfig) func(http.Handler) http.Handler
+}
+
+type EmailProvider interface {
+	Send(ctx context.Context, to, subject, body string) error
+	// SendWithAttachments sends body as the text part of a multipart message with the files after it
+	SendWithAttachments(ctx context.Context, to, subject, body string, attachments []models.EmailAttachment) error
+}
+
+// RedisEntry is one key SetMany writes
+type RedisEntry struct {
+	Key        string
+	Value      interface{}
+	Expiration time.Duration
 }
 
 type RedisProvider interface {
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
 	Get(ctx context.Context, key string) (string, error)
+	// MGet reads the keys in one round trip, a missing key comes back as an empty string
+	MGet(ctx context.Context, keys ...string) ([]string, error)
+	// SetMany writes the entries in one pipelined round trip, each with its own expiration
+	SetMany(ctx context.Context, entries ...RedisEntry) error
+	Del(ctx context.Context, keys ...string) error
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
 	Ping(ctx context.Context) error
 	Close() error
 }
+
+// UserCacheProvider keys the cached reads of a user and drops them when the user changes
+type UserCacheProvider interface {
+	TimelineKey(ctx context.Context, userID uuid.UUID, limit, offset int) string
+	InvalidateUsers(ctx context.Context, userIDs ...uuid.UUID)
+	InvalidateEmails(ctx context.Context, emails ...string)
+	// Entries reads what is cached about the user by id and by address, keyed by the cache key. Timeline
+	// pages aren't listed, they are keyed by page
+	Entries(ctx context.Context, userID uuid.UUID, email string) (map[string]string, error)
+}
+
+// ResponseCacheProvider keys the cached bodies of the GET routes behind the response cache and drops
+// them when what a route shows changes
+type ResponseCacheProvider interface {
+	// Key names the cached body of the route for a variant, like the caller's organization and query
+	Key(ctx context.Context, route, variant string) string
+	Invalidate(ctx context.Context, routes ...string)
+}
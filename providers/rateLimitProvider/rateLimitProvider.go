@@ -0,0 +1,350 @@
+package ratelimitprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/providers/redisProvider"
+	"asset/utils"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// tokenBucketScript refills the bucket for the time passed since the last request and takes one token,
+// it returns whether the request is allowed and the tokens left as a string since redis truncates numbers
+const tokenBucketScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`
+
+// quotaScript counts a request of an api key in its month, KEYS[1] holds the requests served and
+// KEYS[2] those refused for the quota. A quota of 0 is no quota. It returns whether the request is
+// within the quota and the requests served so far
+const quotaScript = `
+local quota = tonumber(ARGV[1])
+local used = tonumber(redis.call('GET', KEYS[1]) or '0')
+local key = KEYS[1]
+local allowed = 1
+if quota > 0 and used >= quota then
+	key = KEYS[2]
+	allowed = 0
+end
+local count = redis.call('INCR', key)
+if count == 1 then
+	redis.call('PEXPIRE', key, ARGV[2])
+end
+if allowed == 1 then
+	used = count
+end
+return {allowed, used}
+`
+
+// throttledScript counts a request of an api key refused by its rate limit in KEYS[1]
+const throttledScript = `
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return count
+`
+
+// api key usage counters are kept this long after the first request of their month, the usage
+// report reaches a year back
+const usageRetention = 400 * 24 * time.Hour
+
+// counters of an api key's month, see APIKeyUsageKey
+const (
+	UsageRequests  = "requests"
+	UsageThrottled = "throttled"
+	UsageOverQuota = "over_quota"
+)
+
+// APIKeyUsageKey names a counter of the key in month, formatted 2006-01
+func APIKeyUsageKey(keyID, month, counter string) string {
+	return fmt.Sprintf("apikey:usage:%s:%s:%s", keyID, month, counter)
+}
+
+func init() {
+	redisprovider.RegisterScript(quotaScript, func(store *redisprovider.MemoryStore, keys []string, args []interface{}) (interface{}, error) {
+		quota, err := redisprovider.ArgFloat(args[0])
+		if err != nil {
+			return nil, err
+		}
+		ttl, err := redisprovider.ArgFloat(args[1])
+		if err != nil {
+			return nil, err
+		}
+		var used int64
+		if value, ok := store.Get(keys[0]); ok {
+			used, _ = strconv.ParseInt(value, 10, 64)
+		}
+		key, allowed := keys[0], int64(1)
+		if quota > 0 && float64(used) >= quota {
+			key, allowed = keys[1], 0
+		}
+		count, err := store.Incr(key)
+		if err != nil {
+			return nil, err
+		}
+		if count == 1 {
+			store.PExpire(key, time.Duration(ttl)*time.Millisecond)
+		}
+		if allowed == 1 {
+			used = count
+		}
+		return []interface{}{allowed, used}, nil
+	})
+	redisprovider.RegisterScript(throttledScript, func(store *redisprovider.MemoryStore, keys []string, args []interface{}) (interface{}, error) {
+		ttl, err := redisprovider.ArgFloat(args[0])
+		if err != nil {
+			return nil, err
+		}
+		count, err := store.Incr(keys[0])
+		if err == nil && count == 1 {
+			store.PExpire(keys[0], time.Duration(ttl)*time.Millisecond)
+		}
+		return count, err
+	})
+	redisprovider.RegisterScript(tokenBucketScript, func(store *redisprovider.MemoryStore, keys []string, args []interface{}) (interface{}, error) {
+		var numbers [3]float64
+		for i := range numbers {
+			n, err := redisprovider.ArgFloat(args[i])
+			if err != nil {
+				return nil, err
+			}
+			numbers[i] = n
+		}
+		rate, burst, now := numbers[0], numbers[1], numbers[2]
+		tokens, ts := burst, now
+		if value, ok := store.HGet(keys[0], "tokens"); ok {
+			tokens, _ = strconv.ParseFloat(value, 64)
+		}
+		if value, ok := store.HGet(keys[0], "ts"); ok {
+			ts, _ = strconv.ParseFloat(value, 64)
+		}
+		tokens = math.Min(burst, tokens+math.Max(0, now-ts)*rate/1000)
+		allowed := int64(0)
+		if tokens >= 1 {
+			tokens--
+			allowed = 1
+		}
+		// lua formats numbers with %.14g
+		tokensStr := strconv.FormatFloat(tokens, 'g', 14, 64)
+		store.HSet(keys[0], "tokens", tokensStr)
+		store.HSet(keys[0], "ts", strconv.FormatFloat(now, 'f', -1, 64))
+		store.PExpire(keys[0], time.Duration(math.Ceil(burst/rate*1000)+1000)*time.Millisecond)
+		return []interface{}{allowed, tokensStr}, nil
+	})
+}
+
+type rateLimiter struct {
+	redis  providers.RedisProvider
+	auth   providers.AuthMiddlewareService
+	logger providers.ZapLoggerProvider
+	// time.Now, tests move it to a month's end
+	now func() time.Time
+}
+
+func NewRateLimiter(redis providers.RedisProvider, auth providers.AuthMiddlewareService, logger providers.ZapLoggerProvider) providers.RateLimiter {
+	return &rateLimiter{redis: redis, auth: auth, logger: logger, now: time.Now}
+}
+
+type bucketState struct {
+	allowed   bool
+	remaining int
+	// until the bucket is full again
+	reset time.Duration
+	// until the next token, only set when the request was refused
+	retryAfter time.Duration
+}
+
+// LimitByIP throttles every request of a client address
+func (l *rateLimiter) LimitByIP(name string, limit func() models.RateLimit) func(http.Handler) http.Handler {
+	return l.limit(name, limit, func(r *http.Request) string {
+		return utils.ClientIP(r)
+	})
+}
+
+// LimitByUser throttles per signed in user, it has to run after the jwt middleware and lets
+// anonymous requests through since those are covered by the ip limit. Requests made with an api key
+// are left to LimitByAPIKey, a busy integration doesn't use up its owner's limit
+func (l *rateLimiter) LimitByUser(name string, limit func() models.RateLimit) func(http.Handler) http.Handler {
+	return l.limit(name, limit, func(r *http.Request) string {
+		if _, ok := l.auth.GetAPIKeyFromContext(r); ok {
+			return ""
+		}
+		userID, _, err := l.auth.GetUserAndRolesFromContext(r)
+		if err != nil {
+			return ""
+		}
+		return userID
+	})
+}
+
+// limit looks the limit up per request, turning it off or changing it takes effect without rebuilding
+// the router
+func (l *rateLimiter) limit(name string, current func() models.RateLimit, identify func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limit := current()
+			if limit.PerMinute <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			burst := limit.Burst
+			if burst <= 0 {
+				burst = limit.PerMinute
+			}
+			subject := identify(r)
+			if subject == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			state, err := l.take(r.Context(), fmt.Sprintf("ratelimit:%s:%s", name, subject), limit.PerMinute, burst)
+			if err != nil {
+				// redis being down shouldn't take the api down with it
+				l.logger.GetLogger().Warn("rate limit check failed, allowing request", zap.String("limit", name), zap.Error(err))
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(burst))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(state.remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(state.reset)))
+			if !state.allowed {
+				l.logger.GetLogger().Warn("rate limit exceeded", zap.String("limit", name), zap.String("subject", subject), zap.String("path", r.URL.Path))
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(state.retryAfter)))
+				utils.RespondError(w, http.StatusTooManyRequests, errors.New("rate limit exceeded"), "too many requests, try again later")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (l *rateLimiter) LimitByAPIKey(name string, limits func() models.RateLimitConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := l.auth.GetAPIKeyFromContext(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			config := limits()
+			limit, quota := config.APIKey, config.APIKeyMonthlyQuota
+			if key.RateLimitPerMinute != nil {
+				limit = models.RateLimit{PerMinute: *key.RateLimitPerMinute, Burst: *key.RateLimitPerMinute}
+			}
+			if key.MonthlyQuota != nil {
+				quota = *key.MonthlyQuota
+			}
+			now := l.now().UTC()
+			month := now.Format("2006-01")
+
+			if limit.PerMinute > 0 {
+				burst := limit.Burst
+				if burst <= 0 {
+					burst = limit.PerMinute
+				}
+				state, err := l.take(r.Context(), fmt.Sprintf("ratelimit:%s:%s", name, key.ID), limit.PerMinute, burst)
+				if err != nil {
+					l.logger.GetLogger().Warn("api key rate limit check failed, allowing request", zap.String("api_key_id", key.ID), zap.Error(err))
+				} else {
+					w.Header().Set("RateLimit-Limit", strconv.Itoa(burst))
+					w.Header().Set("RateLimit-Remaining", strconv.Itoa(state.remaining))
+					w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(state.reset)))
+					if !state.allowed {
+						if _, err := l.redis.Eval(r.Context(), throttledScript, []string{APIKeyUsageKey(key.ID, month, UsageThrottled)}, usageRetention.Milliseconds()); err != nil {
+							l.logger.GetLogger().Warn("failed to count throttled api key request", zap.String("api_key_id", key.ID), zap.Error(err))
+						}
+						l.logger.GetLogger().Warn("api key rate limit exceeded", zap.String("api_key_id", key.ID), zap.String("path", r.URL.Path))
+						w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(state.retryAfter)))
+						utils.RespondError(w, http.StatusTooManyRequests, errors.New("api key rate limit exceeded"), "too many requests, try again later")
+						return
+					}
+				}
+			}
+
+			result, err := l.redis.Eval(r.Context(), quotaScript,
+				[]string{APIKeyUsageKey(key.ID, month, UsageRequests), APIKeyUsageKey(key.ID, month, UsageOverQuota)},
+				quota, usageRetention.Milliseconds())
+			if err != nil {
+				l.logger.GetLogger().Warn("api key quota check failed, allowing request", zap.String("api_key_id", key.ID), zap.Error(err))
+				next.ServeHTTP(w, r)
+				return
+			}
+			values, _ := result.([]interface{})
+			if len(values) != 2 {
+				l.logger.GetLogger().Warn("unexpected api key quota result, allowing request", zap.Any("result", result))
+				next.ServeHTTP(w, r)
+				return
+			}
+			allowed, _ := values[0].(int64)
+			used, _ := values[1].(int64)
+			if quota > 0 {
+				nextMonth := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+				w.Header().Set("Quota-Limit", strconv.Itoa(quota))
+				w.Header().Set("Quota-Remaining", strconv.FormatInt(max(int64(quota)-used, 0), 10))
+				w.Header().Set("Quota-Reset", strconv.Itoa(ceilSeconds(nextMonth.Sub(now))))
+				if allowed != 1 {
+					l.logger.GetLogger().Warn("api key monthly quota exceeded", zap.String("api_key_id", key.ID), zap.Int("quota", quota))
+					w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(nextMonth.Sub(now))))
+					utils.RespondError(w, http.StatusTooManyRequests, errors.New("api key monthly quota exceeded"), "the monthly request quota of this api key is used up")
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (l *rateLimiter) take(ctx context.Context, key string, perMinute, burst int) (bucketState, error) {
+	ratePerMs := float64(perMinute) / 60000
+	result, err := l.redis.Eval(ctx, tokenBucketScript, []string{key},
+		strconv.FormatFloat(ratePerMs*1000, 'f', -1, 64), burst, l.now().UnixMilli())
+	if err != nil {
+		return bucketState{}, err
+	}
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 2 {
+		return bucketState{}, fmt.Errorf("unexpected rate limit script result %v", result)
+	}
+	allowed, _ := values[0].(int64)
+	tokensStr, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return bucketState{}, fmt.Errorf("unexpected rate limit tokens %q", tokensStr)
+	}
+
+	state := bucketState{
+		allowed:   allowed == 1,
+		remaining: int(math.Floor(tokens)),
+		reset:     time.Duration((float64(burst) - tokens) / ratePerMs * float64(time.Millisecond)),
+	}
+	if !state.allowed {
+		state.retryAfter = time.Duration((1 - tokens) / ratePerMs * float64(time.Millisecond))
+	}
+	return state, nil
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
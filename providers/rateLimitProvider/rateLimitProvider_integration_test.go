@@ -0,0 +1,48 @@
+//go:build integration
+
+package ratelimitprovider
+
+import (
+	"asset/database/testdb"
+	"testing"
+)
+
+func TestScriptParity(t *testing.T) {
+	t.Run("quota", func(t *testing.T) {
+		keys := []string{UsageRequests, UsageOverQuota}
+		ttl := usageRetention.Milliseconds()
+		testdb.ScriptParity(t, quotaScript, nil,
+			testdb.ScriptCall{Keys: keys, Args: []interface{}{2, ttl}},
+			testdb.ScriptCall{Keys: keys, Args: []interface{}{2, ttl}},
+			// over the quota the refusal is counted instead
+			testdb.ScriptCall{Keys: keys, Args: []interface{}{2, ttl}},
+			testdb.ScriptCall{Keys: keys, Args: []interface{}{2, ttl}},
+			// no quota
+			testdb.ScriptCall{Keys: keys, Args: []interface{}{0, ttl}},
+		)
+	})
+	t.Run("throttled", func(t *testing.T) {
+		keys := []string{UsageThrottled}
+		ttl := usageRetention.Milliseconds()
+		testdb.ScriptParity(t, throttledScript, nil,
+			testdb.ScriptCall{Keys: keys, Args: []interface{}{ttl}},
+			testdb.ScriptCall{Keys: keys, Args: []interface{}{ttl}},
+		)
+	})
+	t.Run("token bucket", func(t *testing.T) {
+		// take passes the rate per second as a formatted float, a third of a token per second shows
+		// the tokens are formatted as lua formats numbers
+		keys := []string{"bucket"}
+		now := int64(1_700_000_000_000)
+		testdb.ScriptParity(t, tokenBucketScript, nil,
+			testdb.ScriptCall{Keys: keys, Args: []interface{}{"0.3333333333333333", 2, now}},
+			testdb.ScriptCall{Keys: keys, Args: []interface{}{"0.3333333333333333", 2, now}},
+			testdb.ScriptCall{Keys: keys, Args: []interface{}{"0.3333333333333333", 2, now}},
+			testdb.ScriptCall{Keys: keys, Args: []interface{}{"0.3333333333333333", 2, now + 1500}},
+			testdb.ScriptCall{Keys: keys, Args: []interface{}{"0.3333333333333333", 2, now + 4000}},
+			// a clock going back doesn't take tokens away
+			testdb.ScriptCall{Keys: keys, Args: []interface{}{"0.3333333333333333", 2, now}},
+			testdb.ScriptCall{Keys: keys, Args: []interface{}{"2", 2, now + 60_000}},
+		)
+	})
+}
@@ -0,0 +1,224 @@
+package ratelimitprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	redisprovider "asset/providers/redisProvider"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newTestLimiter returns a limiter on an in-memory redis whose clock stands at *now
+func newTestLimiter(t *testing.T, auth providers.AuthMiddlewareService, now *time.Time) (*rateLimiter, providers.RedisProvider) {
+	ctrl := gomock.NewController(t)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+	redis := redisprovider.NewMemoryRedisProvider()
+	limiter := NewRateLimiter(redis, auth, logger).(*rateLimiter)
+	limiter.now = func() time.Time { return *now }
+	return limiter, redis
+}
+
+func apiKeyAuth(t *testing.T, key models.APIKeyCaller) providers.AuthMiddlewareService {
+	auth := providers.NewMockAuthMiddlewareService(gomock.NewController(t))
+	auth.EXPECT().GetAPIKeyFromContext(gomock.Any()).Return(key, true).AnyTimes()
+	return auth
+}
+
+func serve(handler http.Handler) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/assets", nil))
+	return rec
+}
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+
+func intPtr(n int) *int { return &n }
+
+func TestLimitByAPIKeyQuota(t *testing.T) {
+	now := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+	limiter, redis := newTestLimiter(t, apiKeyAuth(t, models.APIKeyCaller{ID: "key-1"}), &now)
+	handler := limiter.LimitByAPIKey("apikey", func() models.RateLimitConfig {
+		return models.RateLimitConfig{APIKeyMonthlyQuota: 2}
+	})(okHandler)
+
+	for _, remaining := range []string{"1", "0"} {
+		rec := serve(handler)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("Quota-Limit"))
+		assert.Equal(t, remaining, rec.Header().Get("Quota-Remaining"))
+		assert.Empty(t, rec.Header().Get("Retry-After"))
+	}
+
+	rec := serve(handler)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("Quota-Remaining"))
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+	// the refusal is counted apart from the requests served
+	ctx := context.Background()
+	served, err := redis.Get(ctx, APIKeyUsageKey("key-1", "2026-03", UsageRequests))
+	require.NoError(t, err)
+	assert.Equal(t, "2", served)
+	refused, err := redis.Get(ctx, APIKeyUsageKey("key-1", "2026-03", UsageOverQuota))
+	require.NoError(t, err)
+	assert.Equal(t, "1", refused)
+}
+
+func TestLimitByAPIKeyOverride(t *testing.T) {
+	defaults := models.RateLimitConfig{APIKey: models.RateLimit{PerMinute: 1}, APIKeyMonthlyQuota: 1}
+
+	tests := []struct {
+		name string
+		key  models.APIKeyCaller
+		// requests served before the first refusal, -1 when none is refused
+		allowed     int
+		limitHeader string
+		quotaHeader string
+	}{
+		{name: "defaults", key: models.APIKeyCaller{ID: "key-1"}, allowed: 1, limitHeader: "1", quotaHeader: "1"},
+		{
+			name:        "own rate limit",
+			key:         models.APIKeyCaller{ID: "key-1", RateLimitPerMinute: intPtr(3), MonthlyQuota: intPtr(10)},
+			allowed:     3,
+			limitHeader: "3",
+			quotaHeader: "10",
+		},
+		{
+			name:        "own quota",
+			key:         models.APIKeyCaller{ID: "key-1", RateLimitPerMinute: intPtr(10), MonthlyQuota: intPtr(2)},
+			allowed:     2,
+			limitHeader: "10",
+			quotaHeader: "2",
+		},
+		{
+			// a zero of the key's own turns the default off
+			name:        "own zero limits",
+			key:         models.APIKeyCaller{ID: "key-1", RateLimitPerMinute: intPtr(0), MonthlyQuota: intPtr(0)},
+			allowed:     -1,
+			limitHeader: "",
+			quotaHeader: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			now := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+			limiter, _ := newTestLimiter(t, apiKeyAuth(t, tc.key), &now)
+			handler := limiter.LimitByAPIKey("apikey", func() models.RateLimitConfig { return defaults })(okHandler)
+
+			for i := 0; i < 5; i++ {
+				rec := serve(handler)
+				assert.Equal(t, tc.limitHeader, rec.Header().Get("RateLimit-Limit"))
+				if tc.allowed >= 0 && i >= tc.allowed {
+					assert.Equal(t, http.StatusTooManyRequests, rec.Code, "request %d", i+1)
+					return
+				}
+				assert.Equal(t, http.StatusOK, rec.Code, "request %d", i+1)
+				// a request refused by the rate limit never reaches the quota
+				assert.Equal(t, tc.quotaHeader, rec.Header().Get("Quota-Limit"))
+			}
+		})
+	}
+}
+
+func TestLimitByAPIKeyThrottled(t *testing.T) {
+	now := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+	limiter, redis := newTestLimiter(t, apiKeyAuth(t, models.APIKeyCaller{ID: "key-1"}), &now)
+	handler := limiter.LimitByAPIKey("apikey", func() models.RateLimitConfig {
+		return models.RateLimitConfig{APIKey: models.RateLimit{PerMinute: 60, Burst: 1}}
+	})(okHandler)
+
+	require.Equal(t, http.StatusOK, serve(handler).Code)
+	rec := serve(handler)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+	// a token a second
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	throttled, err := redis.Get(context.Background(), APIKeyUsageKey("key-1", "2026-03", UsageThrottled))
+	require.NoError(t, err)
+	assert.Equal(t, "1", throttled)
+
+	now = now.Add(time.Second)
+	assert.Equal(t, http.StatusOK, serve(handler).Code)
+}
+
+func TestLimitByAPIKeyMonthRollover(t *testing.T) {
+	now := time.Date(2026, time.January, 31, 23, 59, 30, 0, time.UTC)
+	limiter, _ := newTestLimiter(t, apiKeyAuth(t, models.APIKeyCaller{ID: "key-1", MonthlyQuota: intPtr(1)}), &now)
+	handler := limiter.LimitByAPIKey("apikey", func() models.RateLimitConfig { return models.RateLimitConfig{} })(okHandler)
+
+	rec := serve(handler)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("Quota-Limit"))
+	assert.Equal(t, "0", rec.Header().Get("Quota-Remaining"))
+	assert.Equal(t, "30", rec.Header().Get("Quota-Reset"))
+
+	// used up, the key may retry when february starts
+	rec = serve(handler)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "30", rec.Header().Get("Quota-Reset"))
+	assert.Equal(t, "30", rec.Header().Get("Retry-After"))
+
+	now = time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)
+	rec = serve(handler)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("Quota-Remaining"))
+	assert.Equal(t, "2419200", rec.Header().Get("Quota-Reset"), "28 days to march")
+	assert.Empty(t, rec.Header().Get("Retry-After"))
+}
+
+func TestLimitByUser(t *testing.T) {
+	limit := func() models.RateLimit { return models.RateLimit{PerMinute: 1} }
+
+	t.Run("api key requests are left to the key's limit", func(t *testing.T) {
+		auth := apiKeyAuth(t, models.APIKeyCaller{ID: "key-1"})
+		now := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+		limiter, _ := newTestLimiter(t, auth, &now)
+		handler := limiter.LimitByUser("user", limit)(okHandler)
+
+		for i := 0; i < 3; i++ {
+			rec := serve(handler)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+		}
+	})
+
+	t.Run("signed in user", func(t *testing.T) {
+		auth := providers.NewMockAuthMiddlewareService(gomock.NewController(t))
+		auth.EXPECT().GetAPIKeyFromContext(gomock.Any()).Return(models.APIKeyCaller{}, false).AnyTimes()
+		auth.EXPECT().GetUserAndRolesFromContext(gomock.Any()).Return("user-1", []string{"employee"}, nil).AnyTimes()
+		now := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+		limiter, _ := newTestLimiter(t, auth, &now)
+		handler := limiter.LimitByUser("user", limit)(okHandler)
+
+		rec := serve(handler)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "1", rec.Header().Get("RateLimit-Limit"))
+		rec = serve(handler)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+	})
+
+	t.Run("anonymous", func(t *testing.T) {
+		auth := providers.NewMockAuthMiddlewareService(gomock.NewController(t))
+		auth.EXPECT().GetAPIKeyFromContext(gomock.Any()).Return(models.APIKeyCaller{}, false).AnyTimes()
+		auth.EXPECT().GetUserAndRolesFromContext(gomock.Any()).Return("", nil, errors.New("no user")).AnyTimes()
+		now := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+		limiter, _ := newTestLimiter(t, auth, &now)
+		handler := limiter.LimitByUser("user", limit)(okHandler)
+
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusOK, serve(handler).Code)
+		}
+	})
+}
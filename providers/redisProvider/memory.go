@@ -0,0 +1,227 @@
+package redisprovider
+
+import (
+	"asset/providers"
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ScriptFunc is the go version of a lua script for the in-memory provider. It runs with the store
+// locked, so it is atomic as the script is on redis, and returns what the script would through go-redis:
+// int64 for integers, string for strings and []interface{} for tables
+type ScriptFunc func(store *MemoryStore, keys []string, args []interface{}) (interface{}, error)
+
+var (
+	scriptsMu sync.RWMutex
+	scripts   = map[string]ScriptFunc{}
+)
+
+// RegisterScript gives the in-memory provider the go version of a script passed to Eval, the package
+// owning the script registers it next to the lua
+func RegisterScript(script string, fn ScriptFunc) {
+	scriptsMu.Lock()
+	defer scriptsMu.Unlock()
+	scripts[script] = fn
+}
+
+type memoryEntry struct {
+	value   string
+	hash    map[string]string
+	expires time.Time
+}
+
+// MemoryStore holds the keys of the in-memory provider, scripts get it locked
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+type memoryRedisProvider struct {
+	store *MemoryStore
+}
+
+// NewMemoryRedisProvider keeps keys in the process for REDIS_MODE=memory. Nothing is shared between
+// instances, so it only fits a single local server
+func NewMemoryRedisProvider() providers.RedisProvider {
+	return &memoryRedisProvider{store: &MemoryStore{entries: map[string]*memoryEntry{}}}
+}
+
+func (r *memoryRedisProvider) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	r.store.Set(key, ArgString(value), expiration)
+	return nil
+}
+
+// Get returns redis.Nil for a missing key as the redis client does
+func (r *memoryRedisProvider) Get(ctx context.Context, key string) (string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	value, ok := r.store.Get(key)
+	if !ok {
+		return "", redis.Nil
+	}
+	return value, nil
+}
+
+func (r *memoryRedisProvider) MGet(ctx context.Context, keys ...string) ([]string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		values[i], _ = r.store.Get(key)
+	}
+	return values, nil
+}
+
+func (r *memoryRedisProvider) SetMany(ctx context.Context, entries ...providers.RedisEntry) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, entry := range entries {
+		r.store.Set(entry.Key, ArgString(entry.Value), entry.Expiration)
+	}
+	return nil
+}
+
+func (r *memoryRedisProvider) Del(ctx context.Context, keys ...string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	for _, key := range keys {
+		r.store.Del(key)
+	}
+	return nil
+}
+
+func (r *memoryRedisProvider) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	scriptsMu.RLock()
+	fn, ok := scripts[script]
+	scriptsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("script has no in-memory version, register one with RegisterScript")
+	}
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+	return fn(r.store, keys, args)
+}
+
+func (r *memoryRedisProvider) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (r *memoryRedisProvider) Close() error {
+	return nil
+}
+
+// entry returns the live entry of key, dropping it once expired
+func (s *MemoryStore) entry(key string) *memoryEntry {
+	e, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	if !e.expires.IsZero() && !time.Now().Before(e.expires) {
+		delete(s.entries, key)
+		return nil
+	}
+	return e
+}
+
+func (s *MemoryStore) Get(key string) (string, bool) {
+	e := s.entry(key)
+	if e == nil || e.hash != nil {
+		return "", false
+	}
+	return e.value, true
+}
+
+// Set keeps the value forever when ttl is 0, like SET without PX
+func (s *MemoryStore) Set(key, value string, ttl time.Duration) {
+	e := &memoryEntry{value: value}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+	s.entries[key] = e
+}
+
+// SetNX is SET NX PX, it reports whether the key was free
+func (s *MemoryStore) SetNX(key, value string, ttl time.Duration) bool {
+	if s.entry(key) != nil {
+		return false
+	}
+	s.Set(key, value, ttl)
+	return true
+}
+
+func (s *MemoryStore) Del(key string) bool {
+	if s.entry(key) == nil {
+		return false
+	}
+	delete(s.entries, key)
+	return true
+}
+
+// Incr keeps the expiry of the key, a missing key starts at 0 without one
+func (s *MemoryStore) Incr(key string) (int64, error) {
+	e := s.entry(key)
+	if e == nil {
+		e = &memoryEntry{value: "0"}
+		s.entries[key] = e
+	}
+	n, err := strconv.ParseInt(e.value, 10, 64)
+	if err != nil || e.hash != nil {
+		return 0, fmt.Errorf("value of %s is not an integer", key)
+	}
+	n++
+	e.value = strconv.FormatInt(n, 10)
+	return n, nil
+}
+
+func (s *MemoryStore) PExpire(key string, ttl time.Duration) {
+	if e := s.entry(key); e != nil {
+		e.expires = time.Now().Add(ttl)
+	}
+}
+
+func (s *MemoryStore) HGet(key, field string) (string, bool) {
+	e := s.entry(key)
+	if e == nil || e.hash == nil {
+		return "", false
+	}
+	value, ok := e.hash[field]
+	return value, ok
+}
+
+func (s *MemoryStore) HSet(key, field, value string) {
+	e := s.entry(key)
+	if e == nil || e.hash == nil {
+		e = &memoryEntry{hash: map[string]string{}}
+		s.entries[key] = e
+	}
+	e.hash[field] = value
+}
+
+// ArgString formats an argument as the redis client sends it
+func ArgString(arg interface{}) string {
+	switch v := arg.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ArgFloat reads a numeric argument the way tonumber does in a script
+func ArgFloat(arg interface{}) (float64, error) {
+	return strconv.ParseFloat(ArgString(arg), 64)
+}
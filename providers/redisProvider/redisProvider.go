@@ -1,26 +1,81 @@
 package redisprovider
 
 import (
+	"asset/models"
 	"asset/providers"
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"github.com/redis/go-redis/v9"
 	"time"
 )
 
 type RedisDbProvider struct {
-	client *redis.Client
+	client redis.UniversalClient
+	// a cluster rejects commands whose keys live on different shards
+	cluster bool
 }
 
-func NewRedisProvider(addr string) providers.RedisProvider {
-	rdb := redis.NewClient(&redis.Options{
-		Addr: addr,
-		DB:   0,
-	})
+// NewRedisProvider connects to a single server, a sentinel managed master or a cluster as cfg.Mode
+// says. The password comes from secrets on every new connection, none is sent when it holds no
+// REDIS_PASSWORD
+func NewRedisProvider(cfg models.RedisConfig, secrets providers.SecretsProvider) (providers.RedisProvider, error) {
+	if len(cfg.Addrs) == 0 {
+		return nil, errors.New("redis needs at least one address")
+	}
+	opts := &redis.UniversalOptions{
+		Addrs:            cfg.Addrs,
+		DB:               cfg.DB,
+		SentinelUsername: cfg.SentinelUsername,
+		SentinelPassword: cfg.SentinelPassword,
+		PoolSize:         cfg.PoolSize,
+		MinIdleConns:     cfg.MinIdleConns,
+		PoolTimeout:      cfg.PoolTimeout,
+		DialTimeout:      cfg.DialTimeout,
+		ReadTimeout:      cfg.ReadTimeout,
+		WriteTimeout:     cfg.WriteTimeout,
+		// a command gives up when the request it serves runs out of time, not only after its own timeouts
+		ContextTimeoutEnabled: true,
+		CredentialsProviderContext: func(ctx context.Context) (string, string, error) {
+			password, err := secrets.GetSecret(ctx, models.SecretRedisPassword)
+			if errors.Is(err, models.ErrSecretNotFound) {
+				return cfg.Username, "", nil
+			}
+			return cfg.Username, password, err
+		},
+	}
+	if cfg.TLS {
+		opts.TLSConfig = &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			ServerName:         cfg.TLSServerName,
+			InsecureSkipVerify: cfg.TLSInsecureSkipVerify,
+		}
+	}
 
-	return &RedisDbProvider{
-		client: rdb,
+	var client redis.UniversalClient
+	switch cfg.Mode {
+	case models.RedisModeSingle:
+		if len(cfg.Addrs) > 1 {
+			return nil, fmt.Errorf("redis mode %s takes one address, got %d", cfg.Mode, len(cfg.Addrs))
+		}
+		client = redis.NewClient(opts.Simple())
+	case models.RedisModeSentinel:
+		if cfg.MasterName == "" {
+			return nil, fmt.Errorf("redis mode %s needs REDIS_MASTER_NAME", cfg.Mode)
+		}
+		opts.MasterName = cfg.MasterName
+		client = redis.NewFailoverClient(opts.Failover())
+	case models.RedisModeCluster:
+		client = redis.NewClusterClient(opts.Cluster())
+	default:
+		return nil, fmt.Errorf("unknown redis mode %q", cfg.Mode)
 	}
+
+	return &RedisDbProvider{
+		client:  client,
+		cluster: cfg.Mode == models.RedisModeCluster,
+	}, nil
 }
 
 func (r *RedisDbProvider) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
@@ -31,6 +86,71 @@ func (r *RedisDbProvider) Get(ctx context.Context, key string) (string, error) {
 	return r.client.Get(ctx, key).Result()
 }
 
+// MGet sends one MGET, or on a cluster a pipeline of GETs since one MGET can't span shards
+func (r *RedisDbProvider) MGet(ctx context.Context, keys ...string) ([]string, error) {
+	values := make([]string, len(keys))
+	if len(keys) == 0 {
+		return values, nil
+	}
+	if !r.cluster {
+		results, err := r.client.MGet(ctx, keys...).Result()
+		if err != nil {
+			return nil, err
+		}
+		for i, result := range results {
+			if value, ok := result.(string); ok {
+				values[i] = value
+			}
+		}
+		return values, nil
+	}
+	cmds := make([]*redis.StringCmd, len(keys))
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Get(ctx, key)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	for i, cmd := range cmds {
+		values[i] = cmd.Val()
+	}
+	return values, nil
+}
+
+func (r *RedisDbProvider) SetMany(ctx context.Context, entries ...providers.RedisEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, entry := range entries {
+			pipe.Set(ctx, entry.Key, entry.Value, entry.Expiration)
+		}
+		return nil
+	})
+	return err
+}
+
+// Del removes the keys one by one in a pipeline on a cluster, where one DEL can't span shards
+func (r *RedisDbProvider) Del(ctx context.Context, keys ...string) error {
+	if !r.cluster || len(keys) < 2 {
+		return r.client.Del(ctx, keys...).Err()
+	}
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Del(ctx, key)
+		}
+		return nil
+	})
+	return err
+}
+
+func (r *RedisDbProvider) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	return r.client.Eval(ctx, script, keys, args...).Result()
+}
+
 func (r *RedisDbProvider) Ping(ctx context.Context) error {
 	pong, err := r.client.Ping(ctx).Result()
 	if err != nil {
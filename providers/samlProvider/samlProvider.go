@@ -0,0 +1,331 @@
+package samlprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ProviderName is how saml logins show up next to the oidc providers
+const ProviderName = "saml"
+
+const (
+	nsProtocol  = "urn:oasis:names:tc:SAML:2.0:protocol"
+	nsAssertion = "urn:oasis:names:tc:SAML:2.0:assertion"
+
+	statusSuccess     = "urn:oasis:names:tc:SAML:2.0:status:Success"
+	bearerMethod      = "urn:oasis:names:tc:SAML:2.0:cm:bearer"
+	bindingPOST       = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	nameIDEmailFormat = "urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress"
+
+	// how long a login started here may take to come back through the acs
+	requestTTL = 10 * time.Minute
+	clockSkew  = 90 * time.Second
+)
+
+var ErrInvalidResponse = errors.New("invalid saml response")
+
+type samlService struct {
+	cfg   models.SAMLConfig
+	cert  *x509.Certificate
+	redis providers.RedisProvider
+}
+
+func NewSAMLProvider(cfg models.SAMLConfig, redis providers.RedisProvider) (providers.SAMLProvider, error) {
+	cert, err := parseCertificate(cfg.IDPCertificate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse idp certificate: %w", err)
+	}
+	return &samlService{cfg: cfg, cert: cert, redis: redis}, nil
+}
+
+func parseCertificate(value string) (*x509.Certificate, error) {
+	value = strings.TrimSpace(value)
+	if block, _ := pem.Decode([]byte(value)); block != nil {
+		return x509.ParseCertificate(block.Bytes)
+	}
+	der, err := decodeBase64(value)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
+
+func (s *samlService) Name() string {
+	return ProviderName
+}
+
+func requestKey(id string) string {
+	return fmt.Sprintf("saml:request:%s", id)
+}
+
+func assertionKey(id string) string {
+	return fmt.Sprintf("saml:assertion:%s", id)
+}
+
+// Metadata is what the idp admin imports to register this service provider
+func (s *samlService) Metadata() ([]byte, error) {
+	type acs struct {
+		Binding  string `xml:"Binding,attr"`
+		Location string `xml:"Location,attr"`
+		Index    int    `xml:"index,attr"`
+	}
+	metadata := struct {
+		XMLName    xml.Name `xml:"urn:oasis:names:tc:SAML:2.0:metadata EntityDescriptor"`
+		EntityID   string   `xml:"entityID,attr"`
+		Descriptor struct {
+			AuthnRequestsSigned  bool   `xml:"AuthnRequestsSigned,attr"`
+			WantAssertionsSigned bool   `xml:"WantAssertionsSigned,attr"`
+			Protocol             string `xml:"protocolSupportEnumeration,attr"`
+			NameIDFormat         string `xml:"NameIDFormat"`
+			ACS                  acs    `xml:"AssertionConsumerService"`
+		} `xml:"SPSSODescriptor"`
+	}{EntityID: s.cfg.EntityID}
+	metadata.Descriptor.WantAssertionsSigned = true
+	metadata.Descriptor.Protocol = nsProtocol
+	metadata.Descriptor.NameIDFormat = nameIDEmailFormat
+	metadata.Descriptor.ACS = acs{Binding: bindingPOST, Location: s.cfg.ACSURL}
+
+	out, err := xml.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// LoginURL starts a login with the redirect binding, the request id is remembered so only
+// responses to logins started here are accepted
+func (s *samlService) LoginURL(ctx context.Context, relayState string) (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	id := "_" + hex.EncodeToString(raw)
+
+	var request bytes.Buffer
+	fmt.Fprintf(&request, `<samlp:AuthnRequest xmlns:samlp="%s" xmlns:saml="%s" ID="%s" Version="2.0" IssueInstant="%s" Destination="%s" AssertionConsumerServiceURL="%s" ProtocolBinding="%s">`,
+		nsProtocol, nsAssertion, id, time.Now().UTC().Format(time.RFC3339), escapeAttr(s.cfg.IDPSSOURL), escapeAttr(s.cfg.ACSURL), bindingPOST)
+	fmt.Fprintf(&request, `<saml:Issuer>%s</saml:Issuer><samlp:NameIDPolicy Format="%s" AllowCreate="true"/></samlp:AuthnRequest>`,
+		escapeText(s.cfg.EntityID), nameIDEmailFormat)
+
+	var deflated bytes.Buffer
+	w, err := flate.NewWriter(&deflated, flate.BestCompression)
+	if err != nil {
+		return "", err
+	}
+	if _, err := w.Write(request.Bytes()); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	if err := s.redis.Set(ctx, requestKey(id), "1", requestTTL); err != nil {
+		return "", fmt.Errorf("failed to store saml request: %w", err)
+	}
+
+	params := url.Values{}
+	params.Set("SAMLRequest", base64.StdEncoding.EncodeToString(deflated.Bytes()))
+	if relayState != "" {
+		params.Set("RelayState", relayState)
+	}
+	separator := "?"
+	if strings.Contains(s.cfg.IDPSSOURL, "?") {
+		separator = "&"
+	}
+	return s.cfg.IDPSSOURL + separator + params.Encode(), nil
+}
+
+type samlAssertion struct {
+	XMLName xml.Name `xml:"urn:oasis:names:tc:SAML:2.0:assertion Assertion"`
+	ID      string   `xml:"ID,attr"`
+	Issuer  string   `xml:"urn:oasis:names:tc:SAML:2.0:assertion Issuer"`
+	Subject struct {
+		NameID struct {
+			Format string `xml:"Format,attr"`
+			Value  string `xml:",chardata"`
+		} `xml:"urn:oasis:names:tc:SAML:2.0:assertion NameID"`
+		Confirmations []struct {
+			Method string `xml:"Method,attr"`
+			Data   struct {
+				Recipient    string    `xml:"Recipient,attr"`
+				InResponseTo string    `xml:"InResponseTo,attr"`
+				NotOnOrAfter time.Time `xml:"NotOnOrAfter,attr"`
+			} `xml:"urn:oasis:names:tc:SAML:2.0:assertion SubjectConfirmationData"`
+		} `xml:"urn:oasis:names:tc:SAML:2.0:assertion SubjectConfirmation"`
+	} `xml:"urn:oasis:names:tc:SAML:2.0:assertion Subject"`
+	Conditions struct {
+		NotBefore    time.Time `xml:"NotBefore,attr"`
+		NotOnOrAfter time.Time `xml:"NotOnOrAfter,attr"`
+		Audiences    []string  `xml:"urn:oasis:names:tc:SAML:2.0:assertion AudienceRestriction>Audience"`
+	} `xml:"urn:oasis:names:tc:SAML:2.0:assertion Conditions"`
+	Attributes []struct {
+		Name   string   `xml:"Name,attr"`
+		Values []string `xml:"urn:oasis:names:tc:SAML:2.0:assertion AttributeValue"`
+	} `xml:"urn:oasis:names:tc:SAML:2.0:assertion AttributeStatement>Attribute"`
+}
+
+// VerifyIDToken takes the base64 SAMLResponse posted to the acs, which lets saml share the oidc login flow.
+// Either the assertion or the whole response must be signed by the configured idp certificate
+func (s *samlService) VerifyIDToken(ctx context.Context, rawToken string) (models.OIDCIdentity, error) {
+	data, err := decodeBase64(rawToken)
+	if err != nil {
+		return models.OIDCIdentity{}, fmt.Errorf("%w: not base64", ErrInvalidResponse)
+	}
+	root, err := parseXML(data)
+	if err != nil {
+		return models.OIDCIdentity{}, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	if root.Local != "Response" || root.Space() != nsProtocol {
+		return models.OIDCIdentity{}, fmt.Errorf("%w: not a saml response", ErrInvalidResponse)
+	}
+	if status := root.Child(nsProtocol, "Status"); status == nil || status.Child(nsProtocol, "StatusCode") == nil ||
+		status.Child(nsProtocol, "StatusCode").Attr("Value") != statusSuccess {
+		return models.OIDCIdentity{}, fmt.Errorf("%w: idp did not report success", ErrInvalidResponse)
+	}
+	if root.Child(nsAssertion, "EncryptedAssertion") != nil {
+		return models.OIDCIdentity{}, fmt.Errorf("%w: encrypted assertions are not supported", ErrInvalidResponse)
+	}
+	assertions := root.ChildrenNamed(nsAssertion, "Assertion")
+	if len(assertions) != 1 {
+		return models.OIDCIdentity{}, fmt.Errorf("%w: expected exactly one assertion", ErrInvalidResponse)
+	}
+
+	assertion, err := s.verifiedAssertion(root, assertions[0])
+	if err != nil {
+		return models.OIDCIdentity{}, err
+	}
+	if err := s.validateAssertion(ctx, assertion); err != nil {
+		return models.OIDCIdentity{}, err
+	}
+	return s.identity(assertion), nil
+}
+
+// verifiedAssertion decodes the assertion from the signed bytes only
+func (s *samlService) verifiedAssertion(root, assertionEl *xmlElement) (samlAssertion, error) {
+	var assertion samlAssertion
+	if assertionEl.Child(nsDSig, "Signature") != nil {
+		signed, err := verifyEnveloped(root, assertionEl, s.cert)
+		if err != nil {
+			return assertion, err
+		}
+		if err := xml.Unmarshal(signed, &assertion); err != nil {
+			return assertion, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+		}
+		return assertion, nil
+	}
+
+	signed, err := verifyEnveloped(root, root, s.cert)
+	if err != nil {
+		return assertion, err
+	}
+	var response struct {
+		Assertions []samlAssertion `xml:"urn:oasis:names:tc:SAML:2.0:assertion Assertion"`
+	}
+	if err := xml.Unmarshal(signed, &response); err != nil {
+		return assertion, fmt.Errorf("%w: %v", ErrInvalidResponse, err)
+	}
+	if len(response.Assertions) != 1 {
+		return assertion, fmt.Errorf("%w: expected exactly one assertion", ErrInvalidResponse)
+	}
+	return response.Assertions[0], nil
+}
+
+func (s *samlService) validateAssertion(ctx context.Context, a samlAssertion) error {
+	now := time.Now()
+	if strings.TrimSpace(a.Issuer) != s.cfg.IDPEntityID {
+		return fmt.Errorf("%w: unexpected issuer", ErrInvalidResponse)
+	}
+	if !a.Conditions.NotBefore.IsZero() && now.Add(clockSkew).Before(a.Conditions.NotBefore) {
+		return fmt.Errorf("%w: assertion is not valid yet", ErrInvalidResponse)
+	}
+	if a.Conditions.NotOnOrAfter.IsZero() || !now.Add(-clockSkew).Before(a.Conditions.NotOnOrAfter) {
+		return fmt.Errorf("%w: assertion has expired", ErrInvalidResponse)
+	}
+	audience := false
+	for _, aud := range a.Conditions.Audiences {
+		if strings.TrimSpace(aud) == s.cfg.EntityID {
+			audience = true
+		}
+	}
+	if !audience {
+		return fmt.Errorf("%w: assertion is for a different audience", ErrInvalidResponse)
+	}
+
+	// idp initiated logins are refused, a bearer confirmation must answer a request started here
+	confirmed := false
+	for _, c := range a.Subject.Confirmations {
+		if c.Method != bearerMethod || c.Data.Recipient != s.cfg.ACSURL || c.Data.InResponseTo == "" {
+			continue
+		}
+		if !now.Add(-clockSkew).Before(c.Data.NotOnOrAfter) {
+			continue
+		}
+		pending, err := s.redis.Get(ctx, requestKey(c.Data.InResponseTo))
+		if err != nil || pending == "" {
+			continue
+		}
+		s.redis.Del(ctx, requestKey(c.Data.InResponseTo))
+		confirmed = true
+		break
+	}
+	if !confirmed {
+		return fmt.Errorf("%w: no valid subject confirmation for a pending login", ErrInvalidResponse)
+	}
+
+	if used, err := s.redis.Get(ctx, assertionKey(a.ID)); err == nil && used != "" {
+		return fmt.Errorf("%w: assertion was already used", ErrInvalidResponse)
+	}
+	ttl := time.Until(a.Conditions.NotOnOrAfter) + clockSkew
+	if err := s.redis.Set(ctx, assertionKey(a.ID), "1", ttl); err != nil {
+		return fmt.Errorf("failed to record saml assertion: %w", err)
+	}
+	return nil
+}
+
+// identity treats the email as verified, the idp is configured by an admin and signed the assertion
+func (s *samlService) identity(a samlAssertion) models.OIDCIdentity {
+	identity := models.OIDCIdentity{
+		Provider:      ProviderName,
+		Issuer:        s.cfg.IDPEntityID,
+		Subject:       strings.TrimSpace(a.Subject.NameID.Value),
+		EmailVerified: true,
+	}
+	values := make(map[string][]string)
+	for _, attr := range a.Attributes {
+		values[attr.Name] = append(values[attr.Name], attr.Values...)
+	}
+	if v := values[s.cfg.EmailAttribute]; s.cfg.EmailAttribute != "" && len(v) > 0 {
+		identity.Email = strings.ToLower(strings.TrimSpace(v[0]))
+	} else if a.Subject.NameID.Format == nameIDEmailFormat || strings.Contains(identity.Subject, "@") {
+		identity.Email = strings.ToLower(identity.Subject)
+	}
+	if v := values[s.cfg.NameAttribute]; s.cfg.NameAttribute != "" && len(v) > 0 {
+		identity.Name = strings.TrimSpace(v[0])
+	}
+	if s.cfg.RoleAttribute != "" {
+		held := make(map[string]bool)
+		for _, v := range values[s.cfg.RoleAttribute] {
+			held[strings.TrimSpace(v)] = true
+		}
+		for _, mapping := range s.cfg.RoleMappings {
+			if held[mapping.ClaimValue] {
+				identity.Role = mapping.Role
+				break
+			}
+		}
+	}
+	return identity
+}
@@ -0,0 +1,327 @@
+package samlprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	redisprovider "asset/providers/redisProvider"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRequestID   = "_request-1"
+	testAssertionID = "_assertion-1"
+	testResponseID  = "_response-1"
+	// where the signature goes in a fixture, it is filled in by sign
+	signaturePlaceholder = "<!--signature-->"
+)
+
+var testConfig = models.SAMLConfig{
+	EntityID:    "https://assets.example.com/saml",
+	ACSURL:      "https://assets.example.com/saml/acs",
+	IDPEntityID: "https://idp.example.com",
+	IDPSSOURL:   "https://idp.example.com/sso",
+}
+
+type testIDP struct {
+	key     *rsa.PrivateKey
+	certPEM string
+}
+
+func newTestIDP(t *testing.T) testIDP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "idp.example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return testIDP{key: key, certPEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))}
+}
+
+type assertionFixture struct {
+	ID        string
+	Audience  string
+	Recipient string
+	Subject   string
+	Signed    bool
+}
+
+func validAssertion() assertionFixture {
+	return assertionFixture{
+		ID:        testAssertionID,
+		Audience:  testConfig.EntityID,
+		Recipient: testConfig.ACSURL,
+		Subject:   "alice@example.com",
+		Signed:    true,
+	}
+}
+
+func (a assertionFixture) xml() string {
+	now := time.Now().UTC()
+	placeholder := ""
+	if a.Signed {
+		placeholder = signaturePlaceholder
+	}
+	return fmt.Sprintf(`<saml:Assertion xmlns:saml="%s" ID="%s" Version="2.0" IssueInstant="%s">`+
+		`<saml:Issuer>%s</saml:Issuer>%s`+
+		`<saml:Subject><saml:NameID Format="%s">%s</saml:NameID>`+
+		`<saml:SubjectConfirmation Method="%s"><saml:SubjectConfirmationData Recipient="%s" InResponseTo="%s" NotOnOrAfter="%s"/></saml:SubjectConfirmation></saml:Subject>`+
+		`<saml:Conditions NotBefore="%s" NotOnOrAfter="%s"><saml:AudienceRestriction><saml:Audience>%s</saml:Audience></saml:AudienceRestriction></saml:Conditions>`+
+		`</saml:Assertion>`,
+		nsAssertion, a.ID, now.Format(time.RFC3339), testConfig.IDPEntityID, placeholder,
+		nameIDEmailFormat, a.Subject, bearerMethod, a.Recipient, testRequestID, now.Add(5*time.Minute).Format(time.RFC3339),
+		now.Add(-time.Minute).Format(time.RFC3339), now.Add(5*time.Minute).Format(time.RFC3339), a.Audience)
+}
+
+// response wraps the assertions, the response carries the placeholder itself when it is the signed element
+func response(signed bool, assertions ...string) string {
+	placeholder := ""
+	if signed {
+		placeholder = signaturePlaceholder
+	}
+	return fmt.Sprintf(`<samlp:Response xmlns:samlp="%s" ID="%s" Version="2.0" InResponseTo="%s">%s`+
+		`<samlp:Status><samlp:StatusCode Value="%s"/></samlp:Status>%s</samlp:Response>`,
+		nsProtocol, testResponseID, testRequestID, placeholder, statusSuccess, strings.Join(assertions, ""))
+}
+
+// sign fills the first placeholder with an enveloped signature over the element with the given id,
+// the same exclusive c14n and rsa-sha256 an idp produces
+func (idp testIDP) sign(t *testing.T, doc, id string) string {
+	t.Helper()
+	unsigned, err := parseXML([]byte(strings.Replace(doc, signaturePlaceholder, "", 1)))
+	require.NoError(t, err)
+	var target *xmlElement
+	unsigned.walk(func(e *xmlElement) bool {
+		if e.Attr("ID") == id {
+			target = e
+			return false
+		}
+		return true
+	})
+	require.NotNil(t, target, "no element with id %s", id)
+	digest := sha256.Sum256(canonicalize(target, nil, nil))
+
+	signature := fmt.Sprintf(`<ds:Signature xmlns:ds="%s"><ds:SignedInfo>`+
+		`<ds:CanonicalizationMethod Algorithm="%s"/><ds:SignatureMethod Algorithm="%s"/>`+
+		`<ds:Reference URI="#%s"><ds:Transforms><ds:Transform Algorithm="%s"/><ds:Transform Algorithm="%s"/></ds:Transforms>`+
+		`<ds:DigestMethod Algorithm="%s"/><ds:DigestValue>%s</ds:DigestValue></ds:Reference>`+
+		`</ds:SignedInfo><ds:SignatureValue></ds:SignatureValue></ds:Signature>`,
+		nsDSig, algExc, algRS2, id, algEnv, algExc, algSHA2, base64.StdEncoding.EncodeToString(digest[:]))
+	withSignature := strings.Replace(doc, signaturePlaceholder, signature, 1)
+
+	root, err := parseXML([]byte(withSignature))
+	require.NoError(t, err)
+	var signedInfo *xmlElement
+	root.walk(func(e *xmlElement) bool {
+		if e.Local == "SignedInfo" && e.Space() == nsDSig {
+			signedInfo = e
+			return false
+		}
+		return true
+	})
+	require.NotNil(t, signedInfo)
+	hashed := sha256.Sum256(canonicalize(signedInfo, nil, nil))
+	value, err := rsa.SignPKCS1v15(rand.Reader, idp.key, crypto.SHA256, hashed[:])
+	require.NoError(t, err)
+	return strings.Replace(withSignature, "<ds:SignatureValue></ds:SignatureValue>",
+		"<ds:SignatureValue>"+base64.StdEncoding.EncodeToString(value)+"</ds:SignatureValue>", 1)
+}
+
+// newTestProvider trusts the given certificate and has a login pending for testRequestID
+func newTestProvider(t *testing.T, certPEM string) (providers.SAMLProvider, providers.RedisProvider) {
+	t.Helper()
+	cfg := testConfig
+	cfg.IDPCertificate = certPEM
+	redis := redisprovider.NewMemoryRedisProvider()
+	provider, err := NewSAMLProvider(cfg, redis)
+	require.NoError(t, err)
+	require.NoError(t, redis.Set(context.Background(), requestKey(testRequestID), "1", requestTTL))
+	return provider, redis
+}
+
+func encode(doc string) string {
+	return base64.StdEncoding.EncodeToString([]byte(doc))
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	idp := newTestIDP(t)
+	other := newTestIDP(t)
+	signedAssertion := func(a assertionFixture) string {
+		return response(false, idp.sign(t, a.xml(), a.ID))
+	}
+
+	tests := []struct {
+		name    string
+		cert    string
+		doc     func() string
+		wantErr string
+	}{
+		{
+			name: "signed assertion",
+			doc:  func() string { return signedAssertion(validAssertion()) },
+		},
+		{
+			name: "signed response",
+			doc: func() string {
+				a := validAssertion()
+				a.Signed = false
+				return idp.sign(t, response(true, a.xml()), testResponseID)
+			},
+		},
+		{
+			name: "digest mismatch",
+			doc: func() string {
+				return strings.Replace(signedAssertion(validAssertion()), "alice@example.com", "mallory@example.com", 1)
+			},
+			wantErr: "digest mismatch",
+		},
+		{
+			name: "whitespace between attributes is not part of the signed content",
+			doc: func() string {
+				signed := signedAssertion(validAssertion())
+				return strings.Replace(signed, `Version="2.0" IssueInstant`, `Version="2.0"   IssueInstant`, 1)
+			},
+		},
+		{
+			name:    "signed by another certificate",
+			cert:    other.certPEM,
+			doc:     func() string { return signedAssertion(validAssertion()) },
+			wantErr: "verification error",
+		},
+		{
+			name: "unsigned assertion next to the signed one",
+			doc: func() string {
+				evil := validAssertion()
+				evil.ID = "_evil"
+				evil.Subject = "mallory@example.com"
+				evil.Signed = false
+				a := validAssertion()
+				return response(false, evil.xml(), idp.sign(t, a.xml(), a.ID))
+			},
+			wantErr: "expected exactly one assertion",
+		},
+		{
+			name: "unsigned assertion wrapping the signed one",
+			doc: func() string {
+				// the signed assertion is hidden in the evil one's extensions and the evil one takes its id
+				a := validAssertion()
+				signed := idp.sign(t, a.xml(), a.ID)
+				evil := validAssertion()
+				evil.Subject = "mallory@example.com"
+				evil.Signed = false
+				wrapped := strings.Replace(evil.xml(), "</saml:Assertion>",
+					`<saml:Advice>`+signed+`</saml:Advice></saml:Assertion>`, 1)
+				return response(false, wrapped)
+			},
+			wantErr: "element is not signed",
+		},
+		{
+			name: "duplicate id",
+			doc: func() string {
+				// the response signature covers an element sharing the assertion's id
+				a := validAssertion()
+				a.Signed = false
+				evil := validAssertion()
+				evil.ID = testResponseID
+				evil.Subject = "mallory@example.com"
+				evil.Signed = false
+				doc := idp.sign(t, response(true, a.xml()), testResponseID)
+				return strings.Replace(doc, "<samlp:Status>", `<samlp:Extensions>`+evil.xml()+`</samlp:Extensions><samlp:Status>`, 1)
+			},
+			wantErr: "not unique",
+		},
+		{
+			name: "reference without uri",
+			doc: func() string {
+				return strings.Replace(signedAssertion(validAssertion()), `URI="#`+testAssertionID+`"`, `URI=""`, 1)
+			},
+			wantErr: "does not reference the element",
+		},
+		{
+			name: "reference to an unknown id",
+			doc: func() string {
+				return strings.Replace(signedAssertion(validAssertion()), `URI="#`+testAssertionID+`"`, `URI="#_unknown"`, 1)
+			},
+			wantErr: "does not reference the element",
+		},
+		{
+			name: "wrong audience",
+			doc: func() string {
+				a := validAssertion()
+				a.Audience = "https://other.example.com/saml"
+				return signedAssertion(a)
+			},
+			wantErr: "different audience",
+		},
+		{
+			name: "wrong recipient",
+			doc: func() string {
+				a := validAssertion()
+				a.Recipient = "https://other.example.com/saml/acs"
+				return signedAssertion(a)
+			},
+			wantErr: "no valid subject confirmation",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert := idp.certPEM
+			if tt.cert != "" {
+				cert = tt.cert
+			}
+			provider, _ := newTestProvider(t, cert)
+			identity, err := provider.VerifyIDToken(context.Background(), encode(tt.doc()))
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				assert.Equal(t, "alice@example.com", identity.Email)
+				assert.Equal(t, ProviderName, identity.Provider)
+				assert.Equal(t, testConfig.IDPEntityID, identity.Issuer)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestVerifyIDTokenReplay(t *testing.T) {
+	idp := newTestIDP(t)
+	provider, redis := newTestProvider(t, idp.certPEM)
+	ctx := context.Background()
+	a := validAssertion()
+	doc := encode(response(false, idp.sign(t, a.xml(), a.ID)))
+
+	_, err := provider.VerifyIDToken(ctx, doc)
+	require.NoError(t, err)
+
+	// the pending login is spent with the first use
+	_, err = provider.VerifyIDToken(ctx, doc)
+	require.ErrorIs(t, err, ErrInvalidResponse)
+	assert.Contains(t, err.Error(), "no valid subject confirmation")
+
+	// and the assertion itself can't be used again even for another pending login with the same id
+	require.NoError(t, redis.Set(ctx, requestKey(testRequestID), "1", requestTTL))
+	_, err = provider.VerifyIDToken(ctx, doc)
+	require.ErrorIs(t, err, ErrInvalidResponse)
+	assert.Contains(t, err.Error(), "already used")
+}
@@ -0,0 +1,390 @@
+package samlprovider
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	nsXML   = "http://www.w3.org/XML/1998/namespace"
+	nsDSig  = "http://www.w3.org/2000/09/xmldsig#"
+	nsExcC  = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	algEnv  = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	algExc  = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	algSHA2 = "http://www.w3.org/2001/04/xmlenc#sha256"
+	algSHA5 = "http://www.w3.org/2001/04/xmlenc#sha512"
+	algRS2  = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	algRS5  = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha512"
+)
+
+var ErrInvalidSignature = errors.New("invalid xml signature")
+
+// xmlElement keeps prefixes as written, which canonicalization needs and encoding/xml's
+// resolved names lose
+type xmlElement struct {
+	Prefix   string
+	Local    string
+	Attrs    []xml.Attr
+	NSDecls  map[string]string
+	Scope    map[string]string
+	Children []xmlNode
+	Parent   *xmlElement
+}
+
+type xmlNode struct {
+	Elem *xmlElement
+	Text string
+}
+
+func (e *xmlElement) Space() string {
+	return e.Scope[e.Prefix]
+}
+
+func (e *xmlElement) Attr(local string) string {
+	for _, a := range e.Attrs {
+		if a.Name.Space == "" && a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func (e *xmlElement) Child(space, local string) *xmlElement {
+	for _, c := range e.Children {
+		if c.Elem != nil && c.Elem.Local == local && c.Elem.Space() == space {
+			return c.Elem
+		}
+	}
+	return nil
+}
+
+func (e *xmlElement) ChildrenNamed(space, local string) []*xmlElement {
+	out := make([]*xmlElement, 0)
+	for _, c := range e.Children {
+		if c.Elem != nil && c.Elem.Local == local && c.Elem.Space() == space {
+			out = append(out, c.Elem)
+		}
+	}
+	return out
+}
+
+func (e *xmlElement) Text() string {
+	var b strings.Builder
+	for _, c := range e.Children {
+		if c.Elem == nil {
+			b.WriteString(c.Text)
+		}
+	}
+	return b.String()
+}
+
+// walk visits every element of the subtree, stopping early when fn returns false
+func (e *xmlElement) walk(fn func(*xmlElement) bool) bool {
+	if !fn(e) {
+		return false
+	}
+	for _, c := range e.Children {
+		if c.Elem != nil && !c.Elem.walk(fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseXML builds the tree, documents with a DTD are refused outright
+func parseXML(data []byte) (*xmlElement, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var root, current *xmlElement
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			el := &xmlElement{Prefix: t.Name.Space, Local: t.Name.Local, NSDecls: map[string]string{}, Parent: current}
+			scope := map[string]string{"xml": nsXML}
+			if current != nil {
+				for k, v := range current.Scope {
+					scope[k] = v
+				}
+			}
+			for _, a := range t.Attr {
+				switch {
+				case a.Name.Space == "" && a.Name.Local == "xmlns":
+					el.NSDecls[""] = a.Value
+					scope[""] = a.Value
+				case a.Name.Space == "xmlns":
+					el.NSDecls[a.Name.Local] = a.Value
+					scope[a.Name.Local] = a.Value
+				default:
+					el.Attrs = append(el.Attrs, a)
+				}
+			}
+			el.Scope = scope
+			if current == nil {
+				if root != nil {
+					return nil, errors.New("multiple root elements")
+				}
+				root = el
+			} else {
+				current.Children = append(current.Children, xmlNode{Elem: el})
+			}
+			current = el
+		case xml.EndElement:
+			if current == nil {
+				return nil, errors.New("unbalanced xml")
+			}
+			current = current.Parent
+		case xml.CharData:
+			if current != nil {
+				current.Children = append(current.Children, xmlNode{Text: string(t)})
+			}
+		case xml.Directive:
+			return nil, errors.New("xml directives are not allowed")
+		}
+	}
+	if root == nil || current != nil {
+		return nil, errors.New("incomplete xml document")
+	}
+	return root, nil
+}
+
+// canonicalize renders the subtree with exclusive c14n (without comments), leaving out exclude,
+// which is how the enveloped signature transform is applied
+func canonicalize(el, exclude *xmlElement, inclusivePrefixes []string) []byte {
+	var buf bytes.Buffer
+	writeCanonical(&buf, el, exclude, map[string]string{}, inclusivePrefixes)
+	return buf.Bytes()
+}
+
+func writeCanonical(buf *bytes.Buffer, el, exclude *xmlElement, rendered map[string]string, inclusive []string) {
+	needed := map[string]bool{el.Prefix: true}
+	for _, a := range el.Attrs {
+		if a.Name.Space != "" && a.Name.Space != "xml" {
+			needed[a.Name.Space] = true
+		}
+	}
+	for _, p := range inclusive {
+		if p == "#default" {
+			p = ""
+		}
+		if _, ok := el.Scope[p]; ok {
+			needed[p] = true
+		}
+	}
+
+	next := make(map[string]string, len(rendered))
+	for k, v := range rendered {
+		next[k] = v
+	}
+	prefixes := make([]string, 0, len(needed))
+	for p := range needed {
+		uri := el.Scope[p]
+		if p != "" && uri == "" {
+			continue
+		}
+		if prev, ok := rendered[p]; (ok && prev == uri) || (!ok && p == "" && uri == "") {
+			continue
+		}
+		prefixes = append(prefixes, p)
+		next[p] = uri
+	}
+	sort.Strings(prefixes)
+
+	attrs := make([]xml.Attr, len(el.Attrs))
+	copy(attrs, el.Attrs)
+	sort.Slice(attrs, func(i, j int) bool {
+		si, sj := attrSpace(el, attrs[i]), attrSpace(el, attrs[j])
+		if si != sj {
+			return si < sj
+		}
+		return attrs[i].Name.Local < attrs[j].Name.Local
+	})
+
+	name := qname(el.Prefix, el.Local)
+	buf.WriteString("<" + name)
+	for _, p := range prefixes {
+		if p == "" {
+			buf.WriteString(` xmlns="` + escapeAttr(next[p]) + `"`)
+		} else {
+			buf.WriteString(" xmlns:" + p + `="` + escapeAttr(next[p]) + `"`)
+		}
+	}
+	for _, a := range attrs {
+		buf.WriteString(" " + qname(a.Name.Space, a.Name.Local) + `="` + escapeAttr(a.Value) + `"`)
+	}
+	buf.WriteString(">")
+	for _, c := range el.Children {
+		if c.Elem == nil {
+			buf.WriteString(escapeText(c.Text))
+		} else if c.Elem != exclude {
+			writeCanonical(buf, c.Elem, exclude, next, inclusive)
+		}
+	}
+	buf.WriteString("</" + name + ">")
+}
+
+func attrSpace(el *xmlElement, a xml.Attr) string {
+	if a.Name.Space == "" {
+		return ""
+	}
+	if a.Name.Space == "xml" {
+		return nsXML
+	}
+	return el.Scope[a.Name.Space]
+}
+
+func qname(prefix, local string) string {
+	if prefix == "" {
+		return local
+	}
+	return prefix + ":" + local
+}
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
+
+func escapeText(s string) string { return textEscaper.Replace(s) }
+func escapeAttr(s string) string { return attrEscaper.Replace(s) }
+
+// verifyEnveloped checks the signature that is a direct child of el and returns the signed content,
+// callers must only read data from these bytes so nothing outside the signature can be slipped in
+func verifyEnveloped(root, el *xmlElement, cert *x509.Certificate) ([]byte, error) {
+	sig := el.Child(nsDSig, "Signature")
+	if sig == nil {
+		return nil, fmt.Errorf("%w: element is not signed", ErrInvalidSignature)
+	}
+	signedInfo := sig.Child(nsDSig, "SignedInfo")
+	if signedInfo == nil {
+		return nil, fmt.Errorf("%w: missing SignedInfo", ErrInvalidSignature)
+	}
+
+	id := el.Attr("ID")
+	if id == "" || countIDs(root, id) != 1 {
+		return nil, fmt.Errorf("%w: signed element id is missing or not unique", ErrInvalidSignature)
+	}
+	refs := signedInfo.ChildrenNamed(nsDSig, "Reference")
+	if len(refs) != 1 || refs[0].Attr("URI") != "#"+id {
+		return nil, fmt.Errorf("%w: signature does not reference the element", ErrInvalidSignature)
+	}
+	ref := refs[0]
+
+	var prefixes []string
+	if transforms := ref.Child(nsDSig, "Transforms"); transforms != nil {
+		for _, t := range transforms.ChildrenNamed(nsDSig, "Transform") {
+			switch t.Attr("Algorithm") {
+			case algEnv:
+			case algExc:
+				prefixes = inclusivePrefixes(t)
+			default:
+				return nil, fmt.Errorf("%w: unsupported transform %s", ErrInvalidSignature, t.Attr("Algorithm"))
+			}
+		}
+	}
+	signed := canonicalize(el, sig, prefixes)
+
+	digestMethod := ref.Child(nsDSig, "DigestMethod")
+	digestValue := ref.Child(nsDSig, "DigestValue")
+	if digestMethod == nil || digestValue == nil {
+		return nil, fmt.Errorf("%w: missing digest", ErrInvalidSignature)
+	}
+	digestHash, err := hashFor(digestMethod.Attr("Algorithm"))
+	if err != nil {
+		return nil, err
+	}
+	expected, err := decodeBase64(digestValue.Text())
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad digest value", ErrInvalidSignature)
+	}
+	h := digestHash.New()
+	h.Write(signed)
+	if subtle.ConstantTimeCompare(h.Sum(nil), expected) != 1 {
+		return nil, fmt.Errorf("%w: digest mismatch", ErrInvalidSignature)
+	}
+
+	c14nMethod := signedInfo.Child(nsDSig, "CanonicalizationMethod")
+	if c14nMethod == nil || c14nMethod.Attr("Algorithm") != algExc {
+		return nil, fmt.Errorf("%w: unsupported canonicalization", ErrInvalidSignature)
+	}
+	signatureMethod := signedInfo.Child(nsDSig, "SignatureMethod")
+	if signatureMethod == nil {
+		return nil, fmt.Errorf("%w: missing signature method", ErrInvalidSignature)
+	}
+	var sigHash crypto.Hash
+	switch signatureMethod.Attr("Algorithm") {
+	case algRS2:
+		sigHash = crypto.SHA256
+	case algRS5:
+		sigHash = crypto.SHA512
+	default:
+		return nil, fmt.Errorf("%w: unsupported signature method %s", ErrInvalidSignature, signatureMethod.Attr("Algorithm"))
+	}
+	signatureValue := sig.Child(nsDSig, "SignatureValue")
+	if signatureValue == nil {
+		return nil, fmt.Errorf("%w: missing signature value", ErrInvalidSignature)
+	}
+	signature, err := decodeBase64(signatureValue.Text())
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad signature value", ErrInvalidSignature)
+	}
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: idp certificate is not an rsa key", ErrInvalidSignature)
+	}
+	sh := sigHash.New()
+	sh.Write(canonicalize(signedInfo, nil, inclusivePrefixes(c14nMethod)))
+	if err := rsa.VerifyPKCS1v15(pub, sigHash, sh.Sum(nil), signature); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return signed, nil
+}
+
+func inclusivePrefixes(method *xmlElement) []string {
+	if incl := method.Child(nsExcC, "InclusiveNamespaces"); incl != nil {
+		return strings.Fields(incl.Attr("PrefixList"))
+	}
+	return nil
+}
+
+func hashFor(algorithm string) (crypto.Hash, error) {
+	switch algorithm {
+	case algSHA2:
+		return crypto.SHA256, nil
+	case algSHA5:
+		return crypto.SHA512, nil
+	}
+	return 0, fmt.Errorf("%w: unsupported digest method %s", ErrInvalidSignature, algorithm)
+}
+
+func countIDs(root *xmlElement, id string) int {
+	count := 0
+	root.walk(func(e *xmlElement) bool {
+		if e.Attr("ID") == id {
+			count++
+		}
+		return true
+	})
+	return count
+}
+
+func decodeBase64(value string) ([]byte, error) {
+	return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(value), ""))
+}
@@ -0,0 +1,93 @@
+package secretsprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const defaultRefreshInterval = 5 * time.Minute
+
+// NewSecretsProvider returns the backend picked by SECRETS_BACKEND, the env backend when it is empty
+func NewSecretsProvider(cfg models.SecretsConfig) (providers.SecretsProvider, error) {
+	refresh := cfg.RefreshInterval
+	if refresh <= 0 {
+		refresh = defaultRefreshInterval
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	switch cfg.Backend {
+	case "", models.SecretsBackendEnv:
+		return envSecrets{}, nil
+	case models.SecretsBackendVault:
+		if cfg.VaultAddr == "" || cfg.VaultToken == "" || cfg.VaultPath == "" {
+			return nil, fmt.Errorf("VAULT_ADDR, VAULT_TOKEN and VAULT_SECRET_PATH are required for the vault secrets backend")
+		}
+		vault := &vaultSource{addr: cfg.VaultAddr, token: cfg.VaultToken, mount: cfg.VaultMount, path: cfg.VaultPath, client: client}
+		return &cachedSecrets{fetch: vault.fetch, refresh: refresh}, nil
+	case models.SecretsBackendAWS:
+		if cfg.AWSRegion == "" || cfg.AWSSecretID == "" || cfg.AWSAccessKeyID == "" || cfg.AWSSecretAccessKey == "" {
+			return nil, fmt.Errorf("AWS_REGION, AWS_SECRET_ID, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required for the aws secrets backend")
+		}
+		aws := &awsSource{
+			region:       cfg.AWSRegion,
+			secretID:     cfg.AWSSecretID,
+			accessKeyID:  cfg.AWSAccessKeyID,
+			secretKey:    cfg.AWSSecretAccessKey,
+			sessionToken: cfg.AWSSessionToken,
+			client:       client,
+		}
+		return &cachedSecrets{fetch: aws.fetch, refresh: refresh}, nil
+	default:
+		return nil, fmt.Errorf("unknown secrets backend %q", cfg.Backend)
+	}
+}
+
+// envSecrets keeps deployments that set everything in the environment working unchanged
+type envSecrets struct{}
+
+func (envSecrets) GetSecret(_ context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return "", fmt.Errorf("%w: %s", models.ErrSecretNotFound, name)
+	}
+	return value, nil
+}
+
+// cachedSecrets reads the whole secret document from the backend at most once per refresh interval.
+// When the backend is unreachable the last values keep being served, a rotation then shows up late
+// but running connections don't start failing
+type cachedSecrets struct {
+	fetch   func(ctx context.Context) (map[string]string, error)
+	refresh time.Duration
+
+	mu        sync.Mutex
+	values    map[string]string
+	fetchedAt time.Time
+}
+
+func (c *cachedSecrets) GetSecret(ctx context.Context, name string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.values == nil || time.Since(c.fetchedAt) > c.refresh {
+		values, err := c.fetch(ctx)
+		if err != nil && c.values == nil {
+			return "", err
+		}
+		if err == nil {
+			c.values = values
+			c.fetchedAt = time.Now()
+		}
+	}
+	value, ok := c.values[name]
+	if !ok || value == "" {
+		return "", fmt.Errorf("%w: %s", models.ErrSecretNotFound, name)
+	}
+	return value, nil
+}
@@ -0,0 +1,68 @@
+package secretsprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// vaultSource reads a KV v2 secret, every key of it is a secret name
+type vaultSource struct {
+	addr   string
+	token  string
+	mount  string
+	path   string
+	client *http.Client
+}
+
+func (v *vaultSource) fetch(ctx context.Context) (map[string]string, error) {
+	mount := strings.Trim(v.mount, "/")
+	if mount == "" {
+		mount = "secret"
+	}
+	url := strings.TrimSuffix(v.addr, "/") + "/v1/" + mount + "/data/" + strings.Trim(v.path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach vault: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault returned %d for %s", resp.StatusCode, v.path)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode vault secret: %w", err)
+	}
+	return stringValues(body.Data.Data), nil
+}
+
+// stringValues keeps nested values such as a service account json as their json text
+func stringValues(data map[string]interface{}) map[string]string {
+	values := make(map[string]string, len(data))
+	for key, value := range data {
+		switch v := value.(type) {
+		case string:
+			values[key] = v
+		case nil:
+		default:
+			raw, err := json.Marshal(v)
+			if err == nil {
+				values[key] = string(raw)
+			}
+		}
+	}
+	return values
+}
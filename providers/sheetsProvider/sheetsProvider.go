@@ -0,0 +1,144 @@
+package sheetsprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+)
+
+// values are written as they are, a serial number like 00123 mustn't become a number
+const valueInput = "RAW"
+
+type sheetsClient struct {
+	service *sheets.Service
+	account string
+	timeout time.Duration
+}
+
+// NewSheetsProvider signs in as the service account in SHEETS_SERVICE_ACCOUNT, nil when syncing is off
+func NewSheetsProvider(cfg models.SheetsConfig, secrets providers.SecretsProvider, opts ...option.ClientOption) (providers.SheetsProvider, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	serviceAccount, err := secrets.GetSecret(context.Background(), models.SecretSheetsServiceAccount)
+	if err != nil {
+		return nil, fmt.Errorf("sheets sync needs %s: %w", models.SecretSheetsServiceAccount, err)
+	}
+	var credentials struct {
+		ClientEmail string `json:"client_email"`
+	}
+	if err := json.Unmarshal([]byte(serviceAccount), &credentials); err != nil || credentials.ClientEmail == "" {
+		return nil, errors.New(models.SecretSheetsServiceAccount + " is not a service account key")
+	}
+	opts = append([]option.ClientOption{option.WithCredentialsJSON([]byte(serviceAccount)), option.WithScopes(sheets.SpreadsheetsScope)}, opts...)
+	service, err := sheets.NewService(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sheets client: %w", err)
+	}
+	return &sheetsClient{service: service, account: credentials.ClientEmail, timeout: cfg.Timeout}, nil
+}
+
+func (c *sheetsClient) Account() string {
+	return c.account
+}
+
+func (c *sheetsClient) ReadKeys(ctx context.Context, spreadsheetID, sheet string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	resp, err := c.service.Spreadsheets.Values.Get(spreadsheetID, sheetRange(sheet, "A:A")).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sheet %s: %w", sheet, err)
+	}
+	keys := make([]string, len(resp.Values))
+	for i, row := range resp.Values {
+		if len(row) > 0 {
+			keys[i] = fmt.Sprint(row[0])
+		}
+	}
+	return keys, nil
+}
+
+func (c *sheetsClient) UpdateRows(ctx context.Context, spreadsheetID, sheet string, rows map[int][]string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	numbers := make([]int, 0, len(rows))
+	for number := range rows {
+		numbers = append(numbers, number)
+	}
+	sort.Ints(numbers)
+	req := &sheets.BatchUpdateValuesRequest{ValueInputOption: valueInput}
+	for _, number := range numbers {
+		req.Data = append(req.Data, &sheets.ValueRange{
+			Range:  sheetRange(sheet, "A"+strconv.Itoa(number)),
+			Values: cells([][]string{rows[number]}),
+		})
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	if _, err := c.service.Spreadsheets.Values.BatchUpdate(spreadsheetID, req).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("failed to update rows of sheet %s: %w", sheet, err)
+	}
+	return nil
+}
+
+func (c *sheetsClient) AppendRows(ctx context.Context, spreadsheetID, sheet string, rows [][]string) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	_, err := c.service.Spreadsheets.Values.Append(spreadsheetID, sheetRange(sheet, "A1"), &sheets.ValueRange{Values: cells(rows)}).
+		ValueInputOption(valueInput).InsertDataOption("INSERT_ROWS").Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("failed to append rows to sheet %s: %w", sheet, err)
+	}
+	return nil
+}
+
+func (c *sheetsClient) ReplaceAll(ctx context.Context, spreadsheetID, sheet string, rows [][]string) error {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	if _, err := c.service.Spreadsheets.Values.Clear(spreadsheetID, sheetRange(sheet, ""), &sheets.ClearValuesRequest{}).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("failed to clear sheet %s: %w", sheet, err)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	_, err := c.service.Spreadsheets.Values.Update(spreadsheetID, sheetRange(sheet, "A1"), &sheets.ValueRange{Values: cells(rows)}).
+		ValueInputOption(valueInput).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("failed to write sheet %s: %w", sheet, err)
+	}
+	return nil
+}
+
+// sheetRange is a range in a1 notation, the tab name quoted so spaces and dashes in it are fine
+func sheetRange(sheet, cells string) string {
+	quoted := "'" + strings.ReplaceAll(sheet, "'", "''") + "'"
+	if cells == "" {
+		return quoted
+	}
+	return quoted + "!" + cells
+}
+
+func cells(rows [][]string) [][]interface{} {
+	values := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		values[i] = make([]interface{}, len(row))
+		for j, cell := range row {
+			values[i][j] = cell
+		}
+	}
+	return values
+}
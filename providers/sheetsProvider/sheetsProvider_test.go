@@ -0,0 +1,92 @@
+package sheetsprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+)
+
+const testServiceAccount = `{"type":"service_account","client_email":"sync@assets.iam.gserviceaccount.com"}`
+
+func TestSheetsClient(t *testing.T) {
+	type call struct {
+		method string
+		path   string
+		query  string
+		body   map[string]interface{}
+	}
+	var calls []call
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := call{method: r.Method, path: r.URL.Path, query: r.URL.RawQuery}
+		if body, _ := io.ReadAll(r.Body); len(body) > 0 {
+			json.Unmarshal(body, &c.body)
+		}
+		calls = append(calls, c)
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"range":"'Assets'!A1:A3","values":[["Asset ID"],[],["a-1"]]}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	secrets := providers.NewMockSecretsProvider(ctrl)
+	secrets.EXPECT().GetSecret(gomock.Any(), models.SecretSheetsServiceAccount).Return(testServiceAccount, nil)
+
+	client, err := NewSheetsProvider(models.SheetsConfig{Enabled: true, Timeout: time.Second}, secrets,
+		option.WithEndpoint(server.URL), option.WithHTTPClient(server.Client()))
+	require.NoError(t, err)
+	assert.Equal(t, "sync@assets.iam.gserviceaccount.com", client.Account())
+	ctx := context.Background()
+
+	keys, err := client.ReadKeys(ctx, "sheet-1", "Assets")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Asset ID", "", "a-1"}, keys)
+	assert.Equal(t, "/v4/spreadsheets/sheet-1/values/'Assets'!A:A", calls[0].path)
+
+	require.NoError(t, client.UpdateRows(ctx, "sheet-1", "Q1 stock", map[int][]string{3: {"a-1", "Dell"}, 2: {"", ""}}))
+	assert.Equal(t, "/v4/spreadsheets/sheet-1/values:batchUpdate", calls[1].path)
+	assert.Equal(t, "RAW", calls[1].body["valueInputOption"])
+	data := calls[1].body["data"].([]interface{})
+	require.Len(t, data, 2)
+	assert.Equal(t, "'Q1 stock'!A2", data[0].(map[string]interface{})["range"])
+	assert.Equal(t, []interface{}{[]interface{}{"a-1", "Dell"}}, data[1].(map[string]interface{})["values"])
+
+	require.NoError(t, client.AppendRows(ctx, "sheet-1", "Assets", [][]string{{"a-2", "HP"}}))
+	assert.Equal(t, "/v4/spreadsheets/sheet-1/values/'Assets'!A1:append", calls[2].path)
+	assert.Contains(t, calls[2].query, "insertDataOption=INSERT_ROWS")
+
+	require.NoError(t, client.ReplaceAll(ctx, "sheet-1", "Assets", [][]string{{"Asset ID"}}))
+	assert.Equal(t, "/v4/spreadsheets/sheet-1/values/'Assets':clear", calls[3].path)
+	assert.Equal(t, http.MethodPut, calls[4].method)
+
+	// nothing to write is no request
+	require.NoError(t, client.AppendRows(ctx, "sheet-1", "Assets", nil))
+	require.NoError(t, client.UpdateRows(ctx, "sheet-1", "Assets", nil))
+	assert.Len(t, calls, 5)
+}
+
+func TestNewSheetsProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	secrets := providers.NewMockSecretsProvider(ctrl)
+
+	client, err := NewSheetsProvider(models.SheetsConfig{}, secrets)
+	assert.NoError(t, err)
+	assert.Nil(t, client)
+
+	secrets.EXPECT().GetSecret(gomock.Any(), models.SecretSheetsServiceAccount).Return(`{"token":"x"}`, nil)
+	_, err = NewSheetsProvider(models.SheetsConfig{Enabled: true}, secrets)
+	assert.ErrorContains(t, err, "not a service account key")
+}
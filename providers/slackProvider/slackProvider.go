@@ -0,0 +1,179 @@
+package slackprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	postMessageURL = "https://slack.com/api/chat.postMessage"
+	// messages waiting to be posted, more are dropped so a slow slack can't pile them up in memory
+	queueSize = 200
+	// a rate limited post is retried once after Retry-After, a longer wait drops it
+	maxRetryAfter = 30 * time.Second
+)
+
+type message struct {
+	event   string
+	channel string
+	text    string
+}
+
+// slackNotifier posts from a single goroutine, so an alert never slows the request or job raising it
+type slackNotifier struct {
+	routes   map[string][]string
+	webhooks map[string]string
+	secrets  providers.SecretsProvider
+	client   *http.Client
+	logger   providers.ZapLoggerProvider
+	// chat.postMessage, a test points it elsewhere
+	apiURL string
+
+	queue chan message
+	done  chan struct{}
+	once  sync.Once
+}
+
+// NewSlackProvider returns nil when no event is routed to a channel. A channel without a webhook needs
+// SLACK_BOT_TOKEN in secrets, it is read again for every post so a rotated token is picked up
+func NewSlackProvider(cfg models.SlackConfig, secrets providers.SecretsProvider, logger providers.ZapLoggerProvider) (providers.SlackProvider, error) {
+	needsToken := false
+	routed := false
+	for _, channels := range cfg.Routes {
+		for _, channel := range channels {
+			routed = true
+			if cfg.Webhooks[channel] == "" {
+				needsToken = true
+			}
+		}
+	}
+	if !routed {
+		return nil, nil
+	}
+	if needsToken {
+		if _, err := secrets.GetSecret(context.Background(), models.SecretSlackBotToken); err != nil {
+			return nil, fmt.Errorf("slack channels without a webhook need %s: %w", models.SecretSlackBotToken, err)
+		}
+	}
+	s := &slackNotifier{
+		routes:   cfg.Routes,
+		webhooks: cfg.Webhooks,
+		secrets:  secrets,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		logger:   logger,
+		apiURL:   postMessageURL,
+		queue:    make(chan message, queueSize),
+		done:     make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+func (s *slackNotifier) Notify(event, text string) {
+	for _, channel := range s.routes[event] {
+		select {
+		case s.queue <- message{event: event, channel: channel, text: text}:
+		default:
+			s.logger.GetLogger().Warn("slack message dropped, queue is full", zap.String("event", event), zap.String("channel", channel))
+		}
+	}
+}
+
+func (s *slackNotifier) Close(ctx context.Context) error {
+	s.once.Do(func() { close(s.queue) })
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *slackNotifier) run() {
+	defer close(s.done)
+	for msg := range s.queue {
+		if err := s.post(msg); err != nil {
+			s.logger.GetLogger().Warn("failed to post slack message", zap.String("event", msg.event), zap.String("channel", msg.channel), zap.Error(err))
+		}
+	}
+}
+
+// post sends one message, retrying once when slack asks to slow down
+func (s *slackNotifier) post(msg message) error {
+	retryAfter, err := s.send(msg)
+	if err == nil || retryAfter == 0 {
+		return err
+	}
+	if retryAfter > maxRetryAfter {
+		return fmt.Errorf("%w, retry after %s is too long", err, retryAfter)
+	}
+	time.Sleep(retryAfter)
+	_, err = s.send(msg)
+	return err
+}
+
+// send returns how long to wait when slack rate limited the post
+func (s *slackNotifier) send(msg message) (time.Duration, error) {
+	url := s.webhooks[msg.channel]
+	payload := map[string]string{"text": msg.text}
+	token := ""
+	if url == "" {
+		var err error
+		if token, err = s.secrets.GetSecret(context.Background(), models.SecretSlackBotToken); err != nil {
+			return 0, fmt.Errorf("failed to read %s: %w", models.SecretSlackBotToken, err)
+		}
+		url = s.apiURL
+		payload["channel"] = msg.channel
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusTooManyRequests {
+		seconds, _ := strconv.Atoi(res.Header.Get("Retry-After"))
+		return time.Duration(max(seconds, 1)) * time.Second, errors.New("slack rate limited the post")
+	}
+	if res.StatusCode >= http.StatusMultipleChoices {
+		return 0, fmt.Errorf("slack answered %s", res.Status)
+	}
+	if token == "" {
+		return 0, nil
+	}
+	// the web api answers 200 and reports failures like channel_not_found in the body
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("failed to decode slack response: %w", err)
+	}
+	if !result.OK {
+		return 0, fmt.Errorf("slack refused the message: %s", result.Error)
+	}
+	return 0, nil
+}
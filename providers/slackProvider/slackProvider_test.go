@@ -0,0 +1,99 @@
+package slackprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type received struct {
+	path    string
+	auth    string
+	payload map[string]string
+}
+
+func TestSlackNotifier(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		posts []received
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]string
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		posts = append(posts, received{path: r.URL.Path, auth: r.Header.Get("Authorization"), payload: payload})
+		mu.Unlock()
+		switch {
+		case r.URL.Path == "/hook":
+			w.Write([]byte("ok"))
+		case payload["channel"] == "#archived":
+			w.Write([]byte(`{"ok":false,"error":"is_archived"}`))
+		default:
+			w.Write([]byte(`{"ok":true}`))
+		}
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+	secrets := providers.NewMockSecretsProvider(ctrl)
+	secrets.EXPECT().GetSecret(gomock.Any(), models.SecretSlackBotToken).Return("xoxb-test", nil).AnyTimes()
+
+	cfg := models.SlackConfig{
+		Routes: map[string][]string{
+			models.SlackEventAssetService: {"#it-assets", "#ops"},
+			models.SlackEventLowStock:     {"#archived"},
+		},
+		Webhooks: map[string]string{"#ops": server.URL + "/hook"},
+		Timeout:  time.Second,
+	}
+	slack, err := NewSlackProvider(cfg, secrets, logger)
+	require.NoError(t, err)
+	slack.(*slackNotifier).apiURL = server.URL + "/api/chat.postMessage"
+
+	slack.Notify(models.SlackEventAssetService, "Dell Latitude (SN-1) was sent to service: fan")
+	slack.Notify(models.SlackEventOverdue, "not routed")
+	slack.Notify(models.SlackEventLowStock, "only 1 laptop assets are available")
+	require.NoError(t, slack.Close(context.Background()))
+	// the web api reports a refused message in a 200 response
+	_, err = slack.(*slackNotifier).send(message{channel: "#archived", text: "x"})
+	assert.ErrorContains(t, err, "is_archived")
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, posts, 4)
+	assert.Equal(t, "/api/chat.postMessage", posts[0].path)
+	assert.Equal(t, "Bearer xoxb-test", posts[0].auth)
+	assert.Equal(t, "#it-assets", posts[0].payload["channel"])
+	assert.Equal(t, "/hook", posts[1].path)
+	assert.Empty(t, posts[1].auth)
+	assert.Empty(t, posts[1].payload["channel"])
+	assert.Equal(t, "Dell Latitude (SN-1) was sent to service: fan", posts[1].payload["text"])
+	assert.Equal(t, "#archived", posts[2].payload["channel"])
+}
+
+func TestNewSlackProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	secrets := providers.NewMockSecretsProvider(ctrl)
+
+	slack, err := NewSlackProvider(models.SlackConfig{Routes: map[string][]string{}}, secrets, logger)
+	assert.NoError(t, err)
+	assert.Nil(t, slack)
+
+	secrets.EXPECT().GetSecret(gomock.Any(), models.SecretSlackBotToken).Return("", models.ErrSecretNotFound)
+	_, err = NewSlackProvider(models.SlackConfig{Routes: map[string][]string{models.SlackEventOverdue: {"#it-assets"}}}, secrets, logger)
+	assert.ErrorIs(t, err, models.ErrSecretNotFound)
+}
@@ -0,0 +1,130 @@
+package smsprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	redisprovider "asset/providers/redisProvider"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// built in texts, kept short so a message fits one segment
+var defaultTemplates = map[string]string{
+	models.SMSTemplateOTP:         "{{.code}} is your asset manager verification code, it expires in {{.minutes}} minutes.",
+	models.SMSTemplateAssetStolen: "Asset manager: {{.asset}} was reported stolen by {{.reporter}}. {{.note}}",
+}
+
+// the per number count restarts an hour after the first message of the window
+const (
+	rateLimitWindow = time.Hour
+	sentCountScript = `
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return count
+`
+)
+
+func init() {
+	redisprovider.RegisterScript(sentCountScript, func(store *redisprovider.MemoryStore, keys []string, args []interface{}) (interface{}, error) {
+		ttl, err := redisprovider.ArgFloat(args[0])
+		if err != nil {
+			return nil, err
+		}
+		count, err := store.Incr(keys[0])
+		if err == nil && count == 1 {
+			store.PExpire(keys[0], time.Duration(ttl)*time.Millisecond)
+		}
+		return count, err
+	})
+}
+
+// gateway delivers a rendered message
+type gateway interface {
+	send(ctx context.Context, to, body string) error
+}
+
+type smsSender struct {
+	gateway   gateway
+	templates map[string]*template.Template
+	redis     providers.RedisProvider
+	limit     int
+}
+
+// NewSMSProvider returns the gateway picked by SMS_PROVIDER, nil when texting is off. The auth token
+// is read from secrets for every message so a rotated one is picked up
+func NewSMSProvider(cfg models.SMSConfig, secrets providers.SecretsProvider, redis providers.RedisProvider) (providers.SMSProvider, error) {
+	if cfg.Provider == "" {
+		return nil, nil
+	}
+	if cfg.AccountID == "" {
+		return nil, fmt.Errorf("SMS_ACCOUNT_ID is required for %s", cfg.Provider)
+	}
+	if _, err := secrets.GetSecret(context.Background(), models.SecretSMSAuthToken); err != nil {
+		return nil, fmt.Errorf("%s needs %s: %w", cfg.Provider, models.SecretSMSAuthToken, err)
+	}
+	client := &http.Client{Timeout: cfg.Timeout}
+	s := &smsSender{templates: make(map[string]*template.Template), redis: redis, limit: cfg.PerNumberPerHour}
+	switch cfg.Provider {
+	case models.SMSProviderTwilio:
+		if cfg.From == "" {
+			return nil, fmt.Errorf("SMS_FROM is required for twilio")
+		}
+		s.gateway = &twilioGateway{url: twilioURL, accountSID: cfg.AccountID, from: cfg.From, secrets: secrets, client: client}
+	case models.SMSProviderSNS:
+		if cfg.Region == "" {
+			return nil, fmt.Errorf("SMS_REGION is required for sns")
+		}
+		s.gateway = &snsGateway{region: cfg.Region, accessKeyID: cfg.AccountID, senderID: cfg.From, secrets: secrets, client: client}
+	default:
+		return nil, fmt.Errorf("unknown sms provider %q", cfg.Provider)
+	}
+	for _, name := range models.SMSTemplates {
+		text := defaultTemplates[name]
+		if override := cfg.Templates[name]; override != "" {
+			text = override
+		}
+		// a missing key renders empty instead of <no value>
+		tmpl, err := template.New(name).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SMS_TEMPLATE_%s: %w", strings.ToUpper(name), err)
+		}
+		s.templates[name] = tmpl
+	}
+	return s, nil
+}
+
+func (s *smsSender) Send(ctx context.Context, to, name string, data map[string]string) error {
+	tmpl, ok := s.templates[name]
+	if !ok {
+		return fmt.Errorf("unknown sms template %q", name)
+	}
+	var body strings.Builder
+	if err := tmpl.Execute(&body, data); err != nil {
+		return fmt.Errorf("failed to render sms template %s: %w", name, err)
+	}
+	if err := s.allow(ctx, to); err != nil {
+		return err
+	}
+	return s.gateway.send(ctx, to, strings.TrimSpace(body.String()))
+}
+
+// allow counts the message against the number's hourly share, a failing redis lets it through
+func (s *smsSender) allow(ctx context.Context, to string) error {
+	if s.limit <= 0 {
+		return nil
+	}
+	result, err := s.redis.Eval(ctx, sentCountScript, []string{"sms:sent:" + to}, rateLimitWindow.Milliseconds())
+	if err != nil {
+		return nil
+	}
+	if count, _ := result.(int64); count > int64(s.limit) {
+		return models.ErrSMSRateLimited
+	}
+	return nil
+}
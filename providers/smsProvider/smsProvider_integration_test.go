@@ -0,0 +1,18 @@
+//go:build integration
+
+package smsprovider
+
+import (
+	"asset/database/testdb"
+	"testing"
+)
+
+func TestSentCountScriptParity(t *testing.T) {
+	keys := []string{"sent"}
+	ttl := rateLimitWindow.Milliseconds()
+	testdb.ScriptParity(t, sentCountScript, nil,
+		testdb.ScriptCall{Keys: keys, Args: []interface{}{ttl}},
+		testdb.ScriptCall{Keys: keys, Args: []interface{}{ttl}},
+		testdb.ScriptCall{Keys: keys, Args: []interface{}{ttl}},
+	)
+}
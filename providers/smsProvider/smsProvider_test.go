@@ -0,0 +1,127 @@
+package smsprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	redisprovider "asset/providers/redisProvider"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTwilioSend(t *testing.T) {
+	var (
+		path string
+		form url.Values
+		user string
+		pass string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		user, pass, _ = r.BasicAuth()
+		r.ParseForm()
+		form = r.PostForm
+		if form.Get("To") == "+15550000000" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":21211,"message":"The 'To' number is not a valid phone number."}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"sid":"SM1"}`))
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	secrets := providers.NewMockSecretsProvider(ctrl)
+	secrets.EXPECT().GetSecret(gomock.Any(), models.SecretSMSAuthToken).Return("auth-token", nil).AnyTimes()
+
+	cfg := models.SMSConfig{
+		Provider:         models.SMSProviderTwilio,
+		AccountID:        "AC123",
+		From:             "MG456",
+		Timeout:          time.Second,
+		PerNumberPerHour: 2,
+		Templates:        map[string]string{models.SMSTemplateAssetStolen: "STOLEN {{.asset}}"},
+	}
+	sms, err := NewSMSProvider(cfg, secrets, redisprovider.NewMemoryRedisProvider())
+	require.NoError(t, err)
+	sms.(*smsSender).gateway.(*twilioGateway).url = server.URL
+
+	ctx := context.Background()
+	require.NoError(t, sms.Send(ctx, "+919876543210", models.SMSTemplateOTP, map[string]string{"code": "123456", "minutes": "10"}))
+	assert.Equal(t, "/Accounts/AC123/Messages.json", path)
+	assert.Equal(t, "AC123", user)
+	assert.Equal(t, "auth-token", pass)
+	assert.Equal(t, "MG456", form.Get("MessagingServiceSid"))
+	assert.Equal(t, "123456 is your asset manager verification code, it expires in 10 minutes.", form.Get("Body"))
+
+	// the override replaces the built in text
+	require.NoError(t, sms.Send(ctx, "+919876543210", models.SMSTemplateAssetStolen, map[string]string{"asset": "Dell XPS (SN-1)"}))
+	assert.Equal(t, "STOLEN Dell XPS (SN-1)", form.Get("Body"))
+
+	// the third message within the hour is refused before reaching twilio
+	form = nil
+	assert.ErrorIs(t, sms.Send(ctx, "+919876543210", models.SMSTemplateOTP, nil), models.ErrSMSRateLimited)
+	assert.Nil(t, form)
+
+	err = sms.Send(ctx, "+15550000000", models.SMSTemplateOTP, nil)
+	assert.ErrorContains(t, err, "21211")
+	assert.ErrorContains(t, sms.Send(ctx, "+15550000001", "welcome", nil), "unknown sms template")
+}
+
+func TestSNSSend(t *testing.T) {
+	var (
+		form url.Values
+		auth string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		r.ParseForm()
+		form = r.PostForm
+		w.Write([]byte(`<PublishResponse><PublishResult><MessageId>1</MessageId></PublishResult></PublishResponse>`))
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	secrets := providers.NewMockSecretsProvider(ctrl)
+	secrets.EXPECT().GetSecret(gomock.Any(), models.SecretSMSAuthToken).Return("aws-secret", nil).AnyTimes()
+
+	cfg := models.SMSConfig{Provider: models.SMSProviderSNS, AccountID: "AKIA1", From: "ASSETS", Region: "ap-south-1", Timeout: time.Second}
+	sms, err := NewSMSProvider(cfg, secrets, redisprovider.NewMemoryRedisProvider())
+	require.NoError(t, err)
+	sms.(*smsSender).gateway.(*snsGateway).endpoint = server.URL
+
+	require.NoError(t, sms.Send(context.Background(), "+919876543210", models.SMSTemplateOTP, map[string]string{"code": "654321", "minutes": "5"}))
+	assert.Equal(t, "Publish", form.Get("Action"))
+	assert.Equal(t, "+919876543210", form.Get("PhoneNumber"))
+	assert.Equal(t, "ASSETS", form.Get("MessageAttributes.entry.2.Value.StringValue"))
+	assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIA1/"), auth)
+	assert.Contains(t, auth, "/ap-south-1/sns/aws4_request")
+}
+
+func TestNewSMSProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	secrets := providers.NewMockSecretsProvider(ctrl)
+	secrets.EXPECT().GetSecret(gomock.Any(), models.SecretSMSAuthToken).Return("token", nil).AnyTimes()
+
+	sms, err := NewSMSProvider(models.SMSConfig{}, secrets, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, sms)
+
+	_, err = NewSMSProvider(models.SMSConfig{Provider: models.SMSProviderTwilio, AccountID: "AC1"}, secrets, nil)
+	assert.ErrorContains(t, err, "SMS_FROM")
+
+	_, err = NewSMSProvider(models.SMSConfig{
+		Provider: models.SMSProviderTwilio, AccountID: "AC1", From: "+15551234567",
+		Templates: map[string]string{models.SMSTemplateOTP: "{{.code"},
+	}, secrets, nil)
+	assert.ErrorContains(t, err, "SMS_TEMPLATE_OTP")
+}
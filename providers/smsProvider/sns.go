@@ -0,0 +1,77 @@
+package smsprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const snsService = "sns"
+
+// snsGateway publishes straight to a phone number, the account's sms spend limit and sandbox apply
+type snsGateway struct {
+	region      string
+	accessKeyID string
+	senderID    string
+	secrets     providers.SecretsProvider
+	client      *http.Client
+	// the regional endpoint unless a test points it elsewhere
+	endpoint string
+}
+
+func (g *snsGateway) send(ctx context.Context, to, body string) error {
+	secretKey, err := g.secrets.GetSecret(ctx, models.SecretSMSAuthToken)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", models.SecretSMSAuthToken, err)
+	}
+	form := url.Values{
+		"Action":      {"Publish"},
+		"Version":     {"2010-03-31"},
+		"PhoneNumber": {to},
+		"Message":     {body},
+		// codes and alerts are transactional, sns routes them for delivery over cost
+		"MessageAttributes.entry.1.Name":              {"AWS.SNS.SMS.SMSType"},
+		"MessageAttributes.entry.1.Value.DataType":    {"String"},
+		"MessageAttributes.entry.1.Value.StringValue": {"Transactional"},
+	}
+	if g.senderID != "" {
+		form.Set("MessageAttributes.entry.2.Name", "AWS.SNS.SMS.SenderID")
+		form.Set("MessageAttributes.entry.2.Value.DataType", "String")
+		form.Set("MessageAttributes.entry.2.Value.StringValue", g.senderID)
+	}
+	payload := []byte(form.Encode())
+
+	host := snsService + "." + g.region + ".amazonaws.com"
+	endpoint := g.endpoint
+	if endpoint == "" {
+		endpoint = "https://" + host + "/"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	payloadHash := sha256.Sum256(payload)
+	signer := utils.AWSSigner{AccessKeyID: g.accessKeyID, SecretKey: secretKey, Region: g.region, Service: snsService}
+	signer.Sign(req, hex.EncodeToString(payloadHash[:]), time.Now())
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach sns: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 500))
+		return fmt.Errorf("sns returned %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package smsprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const twilioURL = "https://api.twilio.com/2010-04-01"
+
+// twilioGateway creates a message through the programmable messaging api
+type twilioGateway struct {
+	url        string
+	accountSID string
+	// a number in E.164, or a messaging service sid that picks the number itself
+	from    string
+	secrets providers.SecretsProvider
+	client  *http.Client
+}
+
+func (t *twilioGateway) send(ctx context.Context, to, body string) error {
+	token, err := t.secrets.GetSecret(ctx, models.SecretSMSAuthToken)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", models.SecretSMSAuthToken, err)
+	}
+	form := url.Values{"To": {to}, "Body": {body}}
+	if strings.HasPrefix(t.from, "MG") {
+		form.Set("MessagingServiceSid", t.from)
+	} else {
+		form.Set("From", t.from)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		t.url+"/Accounts/"+url.PathEscape(t.accountSID)+"/Messages.json", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(t.accountSID, token)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach twilio: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		var failure struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&failure)
+		return fmt.Errorf("twilio returned %d: %d %s", resp.StatusCode, failure.Code, failure.Message)
+	}
+	return nil
+}
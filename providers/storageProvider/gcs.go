@@ -0,0 +1,107 @@
+package storageprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/option"
+)
+
+// gcsStorage signs its urls with the service account's key, or through the iam api when running on
+// application default credentials
+type gcsStorage struct {
+	bucket  *storage.BucketHandle
+	timeout time.Duration
+	ttl     time.Duration
+}
+
+func newGCSStorage(cfg models.ObjectStorageConfig, secrets providers.SecretsProvider) (*gcsStorage, error) {
+	var opts []option.ClientOption
+	serviceAccount, err := secrets.GetSecret(context.Background(), models.SecretObjectStorageServiceAccount)
+	switch {
+	case err == nil:
+		opts = append(opts, option.WithCredentialsJSON([]byte(serviceAccount)))
+	case !errors.Is(err, models.ErrSecretNotFound):
+		return nil, fmt.Errorf("failed to read %s: %w", models.SecretObjectStorageServiceAccount, err)
+	}
+	client, err := storage.NewClient(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gcs client: %w", err)
+	}
+	return &gcsStorage{bucket: client.Bucket(cfg.Bucket), timeout: cfg.Timeout, ttl: cfg.URLTTL}, nil
+}
+
+func (g *gcsStorage) Put(ctx context.Context, key, contentType string, data []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+	w := g.bucket.Object(key).NewWriter(ctx)
+	w.ContentType = contentType
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return fmt.Errorf("failed to write %s to gcs: %w", key, err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to write %s to gcs: %w", key, err)
+	}
+	return nil
+}
+
+func (g *gcsStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	r, err := g.bucket.Object(key).NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, models.ErrObjectNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s from gcs: %w", key, err)
+	}
+	return r, nil
+}
+
+func (g *gcsStorage) Stat(ctx context.Context, key string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+	attrs, err := g.bucket.Object(key).Attrs(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return 0, models.ErrObjectNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat %s in gcs: %w", key, err)
+	}
+	return attrs.Size, nil
+}
+
+func (g *gcsStorage) Delete(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, g.timeout)
+	defer cancel()
+	if err := g.bucket.Object(key).Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("failed to delete %s from gcs: %w", key, err)
+	}
+	return nil
+}
+
+// PresignUpload signs the content type, the client has to send the same one
+func (g *gcsStorage) PresignUpload(ctx context.Context, key, contentType string) (string, error) {
+	return g.bucket.SignedURL(key, &storage.SignedURLOptions{
+		Scheme:      storage.SigningSchemeV4,
+		Method:      http.MethodPut,
+		Expires:     time.Now().Add(g.ttl),
+		ContentType: contentType,
+	})
+}
+
+func (g *gcsStorage) PresignDownload(ctx context.Context, key, filename string) (string, error) {
+	return g.bucket.SignedURL(key, &storage.SignedURLOptions{
+		Scheme:          storage.SigningSchemeV4,
+		Method:          http.MethodGet,
+		Expires:         time.Now().Add(g.ttl),
+		QueryParameters: url.Values{"response-content-disposition": {contentDisposition(filename)}},
+	})
+}
@@ -0,0 +1,209 @@
+package storageprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// localPrefix is where the api serves the local backend's links
+const localPrefix = "/storage/"
+
+// localStorage keeps objects as files under dir, for development without a bucket. Its links are
+// signed with an hmac the api checks when they are followed
+type localStorage struct {
+	dir       string
+	publicURL string
+	key       []byte
+	ttl       time.Duration
+	maxUpload int64
+}
+
+// newLocalStorage signs with OBJECT_STORAGE_SECRET_KEY, SECRET_KEY or failing both a random key,
+// links then stop working on restart
+func newLocalStorage(cfg models.ObjectStorageConfig, secrets providers.SecretsProvider) (*localStorage, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", cfg.Dir, err)
+	}
+	s := &localStorage{dir: cfg.Dir, publicURL: cfg.PublicURL, ttl: cfg.URLTTL, maxUpload: cfg.MaxUploadBytes}
+	for _, name := range []string{models.SecretObjectStorageKey, models.SecretJWTKey} {
+		if value, err := secrets.GetSecret(context.Background(), name); err == nil && value != "" {
+			s.key = []byte(value)
+			return s, nil
+		}
+	}
+	s.key = make([]byte, 32)
+	if _, err := rand.Read(s.key); err != nil {
+		return nil, fmt.Errorf("failed to generate storage signing key: %w", err)
+	}
+	return s, nil
+}
+
+// path maps key into dir, keys that would climb out of it are refused
+func (s *localStorage) path(key string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(key)) || strings.Contains(key, `\`) {
+		return "", fmt.Errorf("invalid object key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// write replaces the file in one rename so a reader never sees half of it
+func (s *localStorage) write(key string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", key, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", key, err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *localStorage) Put(ctx context.Context, key, contentType string, data []byte) error {
+	return s.write(key, bytes.NewReader(data))
+}
+
+func (s *localStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, models.ErrObjectNotFound
+	}
+	return f, err
+}
+
+func (s *localStorage) Stat(ctx context.Context, key string) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, models.ErrObjectNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func (s *localStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *localStorage) PresignUpload(ctx context.Context, key, contentType string) (string, error) {
+	return s.presign(http.MethodPut, key, "")
+}
+
+func (s *localStorage) PresignDownload(ctx context.Context, key, filename string) (string, error) {
+	return s.presign(http.MethodGet, key, filename)
+}
+
+func (s *localStorage) presign(method, key, filename string) (string, error) {
+	if _, err := s.path(key); err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(time.Now().Add(s.ttl).Unix(), 10)
+	query := url.Values{"expires": {expires}, "signature": {s.signature(method, key, expires, filename)}}
+	if filename != "" {
+		query.Set("filename", filename)
+	}
+	u := url.URL{Path: localPrefix + key}
+	return s.publicURL + u.EscapedPath() + "?" + query.Encode(), nil
+}
+
+func (s *localStorage) signature(method, key, expires, filename string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(method + "\n" + key + "\n" + expires + "\n" + filename))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ServeHTTP follows the links presign hands out, a PUT stores the body and a GET or HEAD returns the file
+func (s *localStorage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := strings.TrimPrefix(r.URL.Path, localPrefix)
+	method := r.Method
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	query := r.URL.Query()
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	signature := s.signature(method, key, query.Get("expires"), query.Get("filename"))
+	if err != nil || time.Now().Unix() > expires || !hmac.Equal([]byte(signature), []byte(query.Get("signature"))) {
+		http.Error(w, "link is invalid or expired", http.StatusForbidden)
+		return
+	}
+
+	switch method {
+	case http.MethodPut:
+		if err := s.write(key, http.MaxBytesReader(w, r.Body, s.maxUpload)); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "file is too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "failed to store file", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		path, err := s.path(key)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil || info.IsDir() {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Disposition", contentDisposition(query.Get("filename")))
+		http.ServeContent(w, r, path, info.ModTime(), f)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,164 @@
+package storageprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// sha256 of an empty body, sent with requests that have none
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// s3Storage talks to the s3 rest api directly, signed with sigv4. It also works against s3
+// compatible gateways like minio through endpoint
+type s3Storage struct {
+	bucket      string
+	region      string
+	endpoint    string
+	accessKeyID string
+	secrets     providers.SecretsProvider
+	client      *http.Client
+	ttl         time.Duration
+}
+
+// objectURL addresses the bucket virtual hosted on aws and path style on a gateway
+func (s *s3Storage) objectURL(key string) *url.URL {
+	u := &url.URL{Scheme: "https", Host: s.bucket + ".s3." + s.region + ".amazonaws.com", Path: "/" + key}
+	if s.endpoint != "" {
+		if endpoint, err := url.Parse(s.endpoint); err == nil {
+			u.Scheme, u.Host = endpoint.Scheme, endpoint.Host
+			u.Path = strings.TrimSuffix(endpoint.Path, "/") + "/" + s.bucket + "/" + key
+		}
+	}
+	u.RawPath = escapePath(u.Path)
+	return u
+}
+
+// escapePath encodes everything but the unreserved characters and slashes, s3 compares the path it
+// was sent against one encoded this way
+func escapePath(path string) string {
+	var escaped strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			escaped.WriteByte(c)
+		} else {
+			fmt.Fprintf(&escaped, "%%%02X", c)
+		}
+	}
+	return escaped.String()
+}
+
+func (s *s3Storage) signer(ctx context.Context) (utils.AWSSigner, error) {
+	secretKey, err := s.secrets.GetSecret(ctx, models.SecretObjectStorageKey)
+	if err != nil {
+		return utils.AWSSigner{}, fmt.Errorf("failed to read %s: %w", models.SecretObjectStorageKey, err)
+	}
+	return utils.AWSSigner{AccessKeyID: s.accessKeyID, SecretKey: secretKey, Region: s.region, Service: "s3"}, nil
+}
+
+// do signs and sends a request for key, a 404 comes back as models.ErrObjectNotFound and any other
+// failure with what s3 said
+func (s *s3Storage) do(ctx context.Context, method, key, contentType string, body []byte) (*http.Response, error) {
+	signer, err := s.signer(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	payloadHash := emptyPayloadHash
+	if body != nil {
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	signer.Sign(req, payloadHash, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach s3: %w", err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, models.ErrObjectNotFound
+	}
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 500))
+	return nil, fmt.Errorf("s3 returned %d for %s %s: %s", resp.StatusCode, method, key, bytes.TrimSpace(message))
+}
+
+func (s *s3Storage) Put(ctx context.Context, key, contentType string, data []byte) error {
+	if data == nil {
+		data = []byte{}
+	}
+	resp, err := s.do(ctx, http.MethodPut, key, contentType, data)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (s *s3Storage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func (s *s3Storage) Stat(ctx context.Context, key string) (int64, error) {
+	resp, err := s.do(ctx, http.MethodHead, key, "", nil)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.ContentLength, nil
+}
+
+func (s *s3Storage) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodDelete, key, "", nil)
+	if errors.Is(err, models.ErrObjectNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// PresignUpload only signs the host, the content type the client sends is stored as is
+func (s *s3Storage) PresignUpload(ctx context.Context, key, contentType string) (string, error) {
+	signer, err := s.signer(ctx)
+	if err != nil {
+		return "", err
+	}
+	return signer.Presign(http.MethodPut, s.objectURL(key), s.ttl, time.Now()), nil
+}
+
+func (s *s3Storage) PresignDownload(ctx context.Context, key, filename string) (string, error) {
+	signer, err := s.signer(ctx)
+	if err != nil {
+		return "", err
+	}
+	u := s.objectURL(key)
+	u.RawQuery = url.Values{"response-content-disposition": {contentDisposition(filename)}}.Encode()
+	return signer.Presign(http.MethodGet, u, s.ttl, time.Now()), nil
+}
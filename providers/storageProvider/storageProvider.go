@@ -0,0 +1,60 @@
+package storageprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+// NewStorageProvider returns the bucket picked by OBJECT_STORAGE_BACKEND, nil when object storage is
+// off. The local backend is also an http.Handler serving its signed links under /storage/
+func NewStorageProvider(cfg models.ObjectStorageConfig, secrets providers.SecretsProvider) (providers.StorageProvider, error) {
+	switch cfg.Backend {
+	case "":
+		return nil, nil
+	case models.ObjectStorageS3:
+		if cfg.Bucket == "" {
+			return nil, errors.New("OBJECT_STORAGE_BUCKET is required for s3")
+		}
+		if cfg.Region == "" {
+			return nil, errors.New("OBJECT_STORAGE_REGION is required for s3")
+		}
+		if cfg.AccessKeyID == "" {
+			return nil, errors.New("OBJECT_STORAGE_ACCESS_KEY_ID is required for s3")
+		}
+		if _, err := secrets.GetSecret(context.Background(), models.SecretObjectStorageKey); err != nil {
+			return nil, fmt.Errorf("s3 needs %s: %w", models.SecretObjectStorageKey, err)
+		}
+		return &s3Storage{
+			bucket:      cfg.Bucket,
+			region:      cfg.Region,
+			endpoint:    cfg.Endpoint,
+			accessKeyID: cfg.AccessKeyID,
+			secrets:     secrets,
+			client:      &http.Client{Timeout: cfg.Timeout},
+			ttl:         cfg.URLTTL,
+		}, nil
+	case models.ObjectStorageGCS:
+		if cfg.Bucket == "" {
+			return nil, errors.New("OBJECT_STORAGE_BUCKET is required for gcs")
+		}
+		return newGCSStorage(cfg, secrets)
+	case models.ObjectStorageLocal:
+		return newLocalStorage(cfg, secrets)
+	default:
+		return nil, fmt.Errorf("unknown object storage backend %q", cfg.Backend)
+	}
+}
+
+// contentDisposition makes the browser save a download as filename, names outside ascii are
+// encoded as rfc 2231 asks
+func contentDisposition(filename string) string {
+	if filename == "" {
+		return "attachment"
+	}
+	return mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+}
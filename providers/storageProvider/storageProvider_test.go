@@ -0,0 +1,157 @@
+package storageprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStorage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	secrets := providers.NewMockSecretsProvider(ctrl)
+	secrets.EXPECT().GetSecret(gomock.Any(), models.SecretObjectStorageKey).Return("", models.ErrSecretNotFound)
+	secrets.EXPECT().GetSecret(gomock.Any(), models.SecretJWTKey).Return("jwt-secret", nil)
+
+	var handler http.Handler
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { handler.ServeHTTP(w, r) }))
+	defer server.Close()
+
+	cfg := models.ObjectStorageConfig{Backend: models.ObjectStorageLocal, Dir: t.TempDir(), PublicURL: server.URL, URLTTL: time.Minute, MaxUploadBytes: 16}
+	storage, err := NewStorageProvider(cfg, secrets)
+	require.NoError(t, err)
+	handler = storage.(http.Handler)
+	ctx := context.Background()
+
+	require.NoError(t, storage.Put(ctx, "jobs/1/assets.csv", "text/csv", []byte("a,b\n")))
+	size, err := storage.Stat(ctx, "jobs/1/assets.csv")
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), size)
+
+	// the client uploads through the signed link and downloads it again under its name
+	uploadURL, err := storage.PresignUpload(ctx, "attachments/2/invoice scan.pdf", "application/pdf")
+	require.NoError(t, err)
+	req, _ := http.NewRequest(http.MethodPut, uploadURL, strings.NewReader("%PDF-1.7"))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	downloadURL, err := storage.PresignDownload(ctx, "attachments/2/invoice scan.pdf", "invoice.pdf")
+	require.NoError(t, err)
+	resp, err = http.Get(downloadURL)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "%PDF-1.7", string(body))
+	assert.Equal(t, `attachment; filename=invoice.pdf`, resp.Header.Get("Content-Disposition"))
+
+	// a download link doesn't upload and a changed name breaks the signature
+	req, _ = http.NewRequest(http.MethodPut, downloadURL, strings.NewReader("x"))
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp, err = http.Get(strings.Replace(downloadURL, "invoice.pdf", "other.pdf", 1))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	// uploads over the limit are cut off
+	uploadURL, _ = storage.PresignUpload(ctx, "attachments/3/big.bin", "application/octet-stream")
+	req, _ = http.NewRequest(http.MethodPut, uploadURL, strings.NewReader(strings.Repeat("x", 17)))
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	_, err = storage.Stat(ctx, "attachments/3/big.bin")
+	assert.ErrorIs(t, err, models.ErrObjectNotFound)
+
+	require.NoError(t, storage.Delete(ctx, "jobs/1/assets.csv"))
+	require.NoError(t, storage.Delete(ctx, "jobs/1/assets.csv"))
+	_, err = storage.Open(ctx, "jobs/1/assets.csv")
+	assert.ErrorIs(t, err, models.ErrObjectNotFound)
+	assert.Error(t, storage.Put(ctx, "../escape.txt", "text/plain", nil))
+}
+
+func TestS3Storage(t *testing.T) {
+	objects := map[string]string{}
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		key := r.URL.EscapedPath()
+		switch r.Method {
+		case http.MethodPut:
+			body, _ := io.ReadAll(r.Body)
+			objects[key] = string(body)
+		case http.MethodGet, http.MethodHead:
+			body, ok := objects[key]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Length", "4")
+			w.Write([]byte(body))
+		case http.MethodDelete:
+			delete(objects, key)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	secrets := providers.NewMockSecretsProvider(ctrl)
+	secrets.EXPECT().GetSecret(gomock.Any(), models.SecretObjectStorageKey).Return("s3-secret", nil).AnyTimes()
+
+	cfg := models.ObjectStorageConfig{Backend: models.ObjectStorageS3, Bucket: "assets", Region: "ap-south-1", Endpoint: server.URL, AccessKeyID: "AKIA1", Timeout: time.Second, URLTTL: time.Minute}
+	storage, err := NewStorageProvider(cfg, secrets)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// gateways are addressed path style, keys escaped the way s3 signs them
+	require.NoError(t, storage.Put(ctx, "jobs/1/q1 (all).csv", "text/csv", []byte("a,b\n")))
+	assert.Contains(t, objects, "/assets/jobs/1/q1%20%28all%29.csv")
+	assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIA1/"), auth)
+	assert.Contains(t, auth, "/ap-south-1/s3/aws4_request")
+
+	size, err := storage.Stat(ctx, "jobs/1/q1 (all).csv")
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), size)
+	_, err = storage.Open(ctx, "jobs/2/missing.csv")
+	assert.ErrorIs(t, err, models.ErrObjectNotFound)
+	require.NoError(t, storage.Delete(ctx, "jobs/1/q1 (all).csv"))
+	assert.Empty(t, objects)
+
+	downloadURL, err := storage.PresignDownload(ctx, "jobs/1/q1.csv", "assets.csv")
+	require.NoError(t, err)
+	parsed, err := url.Parse(downloadURL)
+	require.NoError(t, err)
+	assert.Equal(t, "/assets/jobs/1/q1.csv", parsed.Path)
+	assert.Equal(t, "attachment; filename=assets.csv", parsed.Query().Get("response-content-disposition"))
+	assert.Equal(t, "60", parsed.Query().Get("X-Amz-Expires"))
+	assert.NotEmpty(t, parsed.Query().Get("X-Amz-Signature"))
+}
+
+func TestNewStorageProvider(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	secrets := providers.NewMockSecretsProvider(ctrl)
+
+	storage, err := NewStorageProvider(models.ObjectStorageConfig{}, secrets)
+	assert.NoError(t, err)
+	assert.Nil(t, storage)
+
+	_, err = NewStorageProvider(models.ObjectStorageConfig{Backend: models.ObjectStorageS3, Bucket: "assets", Region: "ap-south-1"}, secrets)
+	assert.ErrorContains(t, err, "OBJECT_STORAGE_ACCESS_KEY_ID")
+	_, err = NewStorageProvider(models.ObjectStorageConfig{Backend: "azure"}, secrets)
+	assert.ErrorContains(t, err, "unknown object storage backend")
+}
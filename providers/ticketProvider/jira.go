@@ -0,0 +1,80 @@
+package ticketprovider
+
+import (
+	"asset/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// jiraProvider opens issues through the jira rest api v2, an issue counts as resolved once its
+// status is in the done category whatever the workflow calls it
+type jiraProvider struct {
+	serviceDesk
+	project   string
+	issueType string
+}
+
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Status struct {
+			Name           string `json:"name"`
+			StatusCategory struct {
+				Key string `json:"key"`
+			} `json:"statusCategory"`
+		} `json:"status"`
+	} `json:"fields"`
+}
+
+func (i jiraIssue) ticket() models.Ticket {
+	return models.Ticket{Key: i.Key, Status: i.Fields.Status.Name, Resolved: i.Fields.Status.StatusCategory.Key == "done"}
+}
+
+func (j *jiraProvider) CreateTicket(ctx context.Context, req models.TicketReq) (models.Ticket, error) {
+	body := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"project":     map[string]string{"key": j.project},
+			"issuetype":   map[string]string{"name": j.issueType},
+			"summary":     req.Summary,
+			"description": req.Description,
+		},
+	}
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := j.do(ctx, http.MethodPost, "/rest/api/2/issue", body, &created); err != nil {
+		return models.Ticket{}, err
+	}
+	// a new issue is in the first status of the workflow, the next sync reads its name
+	return models.Ticket{Key: created.Key}, nil
+}
+
+func (j *jiraProvider) GetTicket(ctx context.Context, key string) (models.Ticket, error) {
+	var issue jiraIssue
+	if err := j.do(ctx, http.MethodGet, "/rest/api/2/issue/"+url.PathEscape(key)+"?fields=status", nil, &issue); err != nil {
+		return models.Ticket{}, err
+	}
+	return issue.ticket(), nil
+}
+
+// ParseWebhook reads the issue_updated payload jira posts, the webhook url carries the token as
+// ?token= since jira webhooks can't set headers
+func (j *jiraProvider) ParseWebhook(ctx context.Context, token string, body []byte) (models.Ticket, error) {
+	if err := j.checkWebhookToken(ctx, token); err != nil {
+		return models.Ticket{}, err
+	}
+	var payload struct {
+		Issue jiraIssue `json:"issue"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return models.Ticket{}, fmt.Errorf("invalid jira webhook: %w", err)
+	}
+	if payload.Issue.Key == "" {
+		return models.Ticket{}, errors.New("invalid jira webhook: no issue key")
+	}
+	return payload.Issue.ticket(), nil
+}
@@ -0,0 +1,92 @@
+package ticketprovider
+
+import (
+	"asset/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// serviceNowStates names the incident states, resolved, closed and canceled end a ticket
+var serviceNowStates = map[string]string{
+	"1": "New",
+	"2": "In Progress",
+	"3": "On Hold",
+	"6": "Resolved",
+	"7": "Closed",
+	"8": "Canceled",
+}
+
+// serviceNowProvider opens records through the table api, the ticket key is the record number
+type serviceNowProvider struct {
+	serviceDesk
+	table           string
+	assignmentGroup string
+}
+
+type serviceNowRecord struct {
+	Number string `json:"number"`
+	State  string `json:"state"`
+}
+
+func (r serviceNowRecord) ticket() models.Ticket {
+	status, ok := serviceNowStates[r.State]
+	if !ok {
+		status = r.State
+	}
+	return models.Ticket{Key: r.Number, Status: status, Resolved: r.State == "6" || r.State == "7" || r.State == "8"}
+}
+
+func (s *serviceNowProvider) CreateTicket(ctx context.Context, req models.TicketReq) (models.Ticket, error) {
+	body := map[string]string{
+		"short_description": req.Summary,
+		"description":       req.Description,
+	}
+	if s.assignmentGroup != "" {
+		body["assignment_group"] = s.assignmentGroup
+	}
+	var created struct {
+		Result serviceNowRecord `json:"result"`
+	}
+	if err := s.do(ctx, http.MethodPost, "/api/now/table/"+url.PathEscape(s.table)+"?sysparm_fields=number,state", body, &created); err != nil {
+		return models.Ticket{}, err
+	}
+	return created.Result.ticket(), nil
+}
+
+func (s *serviceNowProvider) GetTicket(ctx context.Context, key string) (models.Ticket, error) {
+	query := url.Values{
+		"sysparm_query":  {"number=" + key},
+		"sysparm_fields": {"number,state"},
+		"sysparm_limit":  {"1"},
+	}
+	var found struct {
+		Result []serviceNowRecord `json:"result"`
+	}
+	if err := s.do(ctx, http.MethodGet, "/api/now/table/"+url.PathEscape(s.table)+"?"+query.Encode(), nil, &found); err != nil {
+		return models.Ticket{}, err
+	}
+	if len(found.Result) == 0 {
+		return models.Ticket{}, models.ErrTicketNotFound
+	}
+	return found.Result[0].ticket(), nil
+}
+
+// ParseWebhook reads {"number": "INC0010001", "state": "6"}, what a business rule on the table posts
+// with the token in the X-Ticket-Token header
+func (s *serviceNowProvider) ParseWebhook(ctx context.Context, token string, body []byte) (models.Ticket, error) {
+	if err := s.checkWebhookToken(ctx, token); err != nil {
+		return models.Ticket{}, err
+	}
+	var record serviceNowRecord
+	if err := json.Unmarshal(body, &record); err != nil {
+		return models.Ticket{}, fmt.Errorf("invalid servicenow webhook: %w", err)
+	}
+	if record.Number == "" {
+		return models.Ticket{}, errors.New("invalid servicenow webhook: no record number")
+	}
+	return record.ticket(), nil
+}
@@ -0,0 +1,108 @@
+package ticketprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// NewTicketProvider returns the service desk picked by TICKET_SYSTEM, nil when tickets are off. The
+// api token is read from secrets on every call so a rotated one is picked up
+func NewTicketProvider(cfg models.TicketingConfig, secrets providers.SecretsProvider) (providers.TicketProvider, error) {
+	if cfg.System == "" {
+		return nil, nil
+	}
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("TICKET_URL is required for %s tickets", cfg.System)
+	}
+	desk := serviceDesk{
+		url:      strings.TrimRight(cfg.URL, "/"),
+		username: cfg.Username,
+		secrets:  secrets,
+		client:   &http.Client{Timeout: cfg.Timeout},
+	}
+	switch cfg.System {
+	case models.TicketSystemJira:
+		if cfg.JiraProject == "" {
+			return nil, errors.New("TICKET_JIRA_PROJECT is required for jira tickets")
+		}
+		return &jiraProvider{serviceDesk: desk, project: cfg.JiraProject, issueType: cfg.JiraIssueType}, nil
+	case models.TicketSystemServiceNow:
+		return &serviceNowProvider{serviceDesk: desk, table: cfg.ServiceNowTable, assignmentGroup: cfg.ServiceNowAssignmentGroup}, nil
+	default:
+		return nil, fmt.Errorf("unknown ticket system %q", cfg.System)
+	}
+}
+
+// serviceDesk is the rest client both systems share, they take basic auth with a user and api token
+type serviceDesk struct {
+	url      string
+	username string
+	secrets  providers.SecretsProvider
+	client   *http.Client
+}
+
+// do sends body as json and decodes the answer into out, a 404 is models.ErrTicketNotFound
+func (d serviceDesk) do(ctx context.Context, method, path string, body, out interface{}) error {
+	token, err := d.secrets.GetSecret(ctx, models.SecretTicketAPIToken)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", models.SecretTicketAPIToken, err)
+	}
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, d.url+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.SetBasicAuth(d.username, token)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach the service desk: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return models.ErrTicketNotFound
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 500))
+		return fmt.Errorf("service desk returned %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode service desk response: %w", err)
+	}
+	return nil
+}
+
+// checkWebhookToken compares token with TICKET_WEBHOOK_TOKEN, webhooks are refused while it is unset
+func (d serviceDesk) checkWebhookToken(ctx context.Context, token string) error {
+	expected, err := d.secrets.GetSecret(ctx, models.SecretTicketWebhookToken)
+	if errors.Is(err, models.ErrSecretNotFound) || (err == nil && expected == "") {
+		return models.ErrTicketWebhookUnauthorized
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", models.SecretTicketWebhookToken, err)
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+		return models.ErrTicketWebhookUnauthorized
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package ticketprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSecrets(ctrl *gomock.Controller) *providers.MockSecretsProvider {
+	secrets := providers.NewMockSecretsProvider(ctrl)
+	secrets.EXPECT().GetSecret(gomock.Any(), models.SecretTicketAPIToken).Return("api-token", nil).AnyTimes()
+	secrets.EXPECT().GetSecret(gomock.Any(), models.SecretTicketWebhookToken).Return("hook-token", nil).AnyTimes()
+	return secrets
+}
+
+func TestJiraProvider(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, token, _ := r.BasicAuth()
+		assert.Equal(t, "it@remotestate.com", user)
+		assert.Equal(t, "api-token", token)
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/rest/api/2/issue":
+			var body struct {
+				Fields map[string]interface{} `json:"fields"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			assert.Equal(t, map[string]interface{}{"key": "IT"}, body.Fields["project"])
+			assert.Equal(t, "Service Dell Latitude (SN-1)", body.Fields["summary"])
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id":"10001","key":"IT-7"}`))
+		case r.URL.Path == "/rest/api/2/issue/IT-7":
+			w.Write([]byte(`{"key":"IT-7","fields":{"status":{"name":"Fixed","statusCategory":{"key":"done"}}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	cfg := models.TicketingConfig{System: models.TicketSystemJira, URL: server.URL + "/", Username: "it@remotestate.com", Timeout: time.Second, JiraProject: "IT", JiraIssueType: "Task"}
+	jira, err := NewTicketProvider(cfg, newSecrets(ctrl))
+	require.NoError(t, err)
+
+	ticket, err := jira.CreateTicket(ctx, models.TicketReq{Summary: "Service Dell Latitude (SN-1)", Description: "fan"})
+	require.NoError(t, err)
+	assert.Equal(t, "IT-7", ticket.Key)
+
+	ticket, err = jira.GetTicket(ctx, "IT-7")
+	require.NoError(t, err)
+	assert.Equal(t, models.Ticket{Key: "IT-7", Status: "Fixed", Resolved: true}, ticket)
+
+	_, err = jira.GetTicket(ctx, "IT-8")
+	assert.ErrorIs(t, err, models.ErrTicketNotFound)
+
+	payload := []byte(`{"webhookEvent":"jira:issue_updated","issue":{"key":"IT-7","fields":{"status":{"name":"In Progress","statusCategory":{"key":"indeterminate"}}}}}`)
+	ticket, err = jira.ParseWebhook(ctx, "hook-token", payload)
+	require.NoError(t, err)
+	assert.Equal(t, models.Ticket{Key: "IT-7", Status: "In Progress"}, ticket)
+
+	_, err = jira.ParseWebhook(ctx, "wrong", payload)
+	assert.ErrorIs(t, err, models.ErrTicketWebhookUnauthorized)
+}
+
+func TestServiceNowProvider(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/now/table/incident":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			assert.Equal(t, "Hardware", body["assignment_group"])
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"result":{"number":"INC0010001","state":"1"}}`))
+		case r.URL.Path == "/api/now/table/incident" && r.URL.Query().Get("sysparm_query") == "number=INC0010001":
+			w.Write([]byte(`{"result":[{"number":"INC0010001","state":"6"}]}`))
+		default:
+			w.Write([]byte(`{"result":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	cfg := models.TicketingConfig{System: models.TicketSystemServiceNow, URL: server.URL, Timeout: time.Second, ServiceNowTable: "incident", ServiceNowAssignmentGroup: "Hardware"}
+	serviceNow, err := NewTicketProvider(cfg, newSecrets(ctrl))
+	require.NoError(t, err)
+
+	ticket, err := serviceNow.CreateTicket(ctx, models.TicketReq{Summary: "Service Dell Latitude (SN-1)"})
+	require.NoError(t, err)
+	assert.Equal(t, models.Ticket{Key: "INC0010001", Status: "New"}, ticket)
+
+	ticket, err = serviceNow.GetTicket(ctx, "INC0010001")
+	require.NoError(t, err)
+	assert.Equal(t, models.Ticket{Key: "INC0010001", Status: "Resolved", Resolved: true}, ticket)
+
+	_, err = serviceNow.GetTicket(ctx, "INC0010002")
+	assert.ErrorIs(t, err, models.ErrTicketNotFound)
+
+	ticket, err = serviceNow.ParseWebhook(ctx, "hook-token", []byte(`{"number":"INC0010001","state":"7"}`))
+	require.NoError(t, err)
+	assert.Equal(t, models.Ticket{Key: "INC0010001", Status: "Closed", Resolved: true}, ticket)
+}
+
+func TestWebhookWithoutToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	secrets := providers.NewMockSecretsProvider(ctrl)
+	secrets.EXPECT().GetSecret(gomock.Any(), models.SecretTicketWebhookToken).Return("", models.ErrSecretNotFound)
+	jira, err := NewTicketProvider(models.TicketingConfig{System: models.TicketSystemJira, URL: "https://jira.example.com", JiraProject: "IT"}, secrets)
+	require.NoError(t, err)
+
+	_, err = jira.ParseWebhook(context.Background(), "", []byte(`{"issue":{"key":"IT-7"}}`))
+	assert.ErrorIs(t, err, models.ErrTicketWebhookUnauthorized)
+}
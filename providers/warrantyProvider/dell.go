@@ -0,0 +1,94 @@
+package warrantyprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// dellVendor reads entitlements from the dell techdirect warranty api, the client signs in with the
+// client credentials grant
+type dellVendor struct {
+	url      string
+	clientID string
+	secrets  providers.SecretsProvider
+	client   *http.Client
+	tokens   utils.TokenCache
+}
+
+type dellAsset struct {
+	ServiceTag   string `json:"serviceTag"`
+	Invalid      bool   `json:"invalid"`
+	Entitlements []struct {
+		StartDate time.Time `json:"startDate"`
+		EndDate   time.Time `json:"endDate"`
+	} `json:"entitlements"`
+}
+
+func (d *dellVendor) lookup(ctx context.Context, serialNo string) (models.WarrantyInfo, error) {
+	var info models.WarrantyInfo
+	token, err := d.accessToken(ctx)
+	if err != nil {
+		return info, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.url+"/PROD/sbil/eapi/v5/asset-entitlements?servicetags="+url.QueryEscape(serialNo), nil)
+	if err != nil {
+		return info, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	body, err := send(d.client, req)
+	if err != nil {
+		return info, err
+	}
+	var assets []dellAsset
+	if err := json.Unmarshal(body, &assets); err != nil {
+		return info, fmt.Errorf("failed to decode dell entitlements: %w", err)
+	}
+	for _, asset := range assets {
+		if asset.Invalid || !strings.EqualFold(asset.ServiceTag, serialNo) {
+			continue
+		}
+		for _, e := range asset.Entitlements {
+			if info.WarrantyStart.IsZero() || e.StartDate.Before(info.WarrantyStart) {
+				info.WarrantyStart = e.StartDate
+			}
+			if e.EndDate.After(info.WarrantyExpire) {
+				info.WarrantyExpire = e.EndDate
+			}
+		}
+	}
+	if info.WarrantyExpire.IsZero() {
+		return info, models.ErrWarrantyNotFound
+	}
+	info.Response = body
+	return info, nil
+}
+
+func (d *dellVendor) accessToken(ctx context.Context) (string, error) {
+	return d.tokens.Token(ctx, func(ctx context.Context) (utils.OAuthToken, error) {
+		var token utils.OAuthToken
+		secret, err := d.secrets.GetSecret(ctx, models.SecretWarrantyDellSecret)
+		if err != nil {
+			return token, fmt.Errorf("failed to read %s: %w", models.SecretWarrantyDellSecret, err)
+		}
+		req, err := utils.NewClientCredentialsRequest(ctx, d.url+"/auth/oauth/v2/token", d.clientID, secret, "")
+		if err != nil {
+			return token, err
+		}
+		body, err := send(d.client, req)
+		if err != nil {
+			return token, fmt.Errorf("failed to sign in to dell: %w", err)
+		}
+		if err := json.Unmarshal(body, &token); err != nil {
+			return token, fmt.Errorf("failed to decode dell token: %w", err)
+		}
+		return token, nil
+	})
+}
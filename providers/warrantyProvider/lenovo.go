@@ -0,0 +1,60 @@
+package warrantyprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// lenovoVendor reads the lenovo support api, its token goes in the ClientID header
+type lenovoVendor struct {
+	url     string
+	secrets providers.SecretsProvider
+	client  *http.Client
+}
+
+type lenovoWarranty struct {
+	Warranty []struct {
+		Start time.Time `json:"Start"`
+		End   time.Time `json:"End"`
+	} `json:"Warranty"`
+}
+
+func (l *lenovoVendor) lookup(ctx context.Context, serialNo string) (models.WarrantyInfo, error) {
+	var info models.WarrantyInfo
+	token, err := l.secrets.GetSecret(ctx, models.SecretWarrantyLenovoToken)
+	if err != nil {
+		return info, fmt.Errorf("failed to read %s: %w", models.SecretWarrantyLenovoToken, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.url+"/v2.5/warranty?Serial="+url.QueryEscape(serialNo), nil)
+	if err != nil {
+		return info, err
+	}
+	req.Header.Set("ClientID", token)
+	body, err := send(l.client, req)
+	if err != nil {
+		return info, err
+	}
+	var warranty lenovoWarranty
+	if err := json.Unmarshal(body, &warranty); err != nil {
+		return info, fmt.Errorf("failed to decode lenovo warranty: %w", err)
+	}
+	for _, w := range warranty.Warranty {
+		if info.WarrantyStart.IsZero() || w.Start.Before(info.WarrantyStart) {
+			info.WarrantyStart = w.Start
+		}
+		if w.End.After(info.WarrantyExpire) {
+			info.WarrantyExpire = w.End
+		}
+	}
+	if info.WarrantyExpire.IsZero() {
+		return info, models.ErrWarrantyNotFound
+	}
+	info.Response = body
+	return info, nil
+}
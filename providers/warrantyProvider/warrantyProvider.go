@@ -0,0 +1,86 @@
+package warrantyprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// NewWarrantyProvider returns a lookup asking the vendors listed in WARRANTY_LOOKUP, nil when it is
+// empty. Credentials are read from secrets for every token or lookup so rotated ones are picked up
+func NewWarrantyProvider(cfg models.WarrantyConfig, secrets providers.SecretsProvider) (providers.WarrantyProvider, error) {
+	if len(cfg.Vendors) == 0 {
+		return nil, nil
+	}
+	client := &http.Client{Timeout: cfg.Timeout}
+	lookup := &vendorLookup{vendors: make(map[string]vendor, len(cfg.Vendors))}
+	for _, name := range cfg.Vendors {
+		switch name {
+		case models.WarrantyVendorDell:
+			if cfg.DellClientID == "" {
+				return nil, fmt.Errorf("WARRANTY_DELL_CLIENT_ID is required for dell")
+			}
+			lookup.vendors[name] = &dellVendor{url: strings.TrimRight(cfg.DellURL, "/"), clientID: cfg.DellClientID, secrets: secrets, client: client}
+		case models.WarrantyVendorLenovo:
+			lookup.vendors[name] = &lenovoVendor{url: strings.TrimRight(cfg.LenovoURL, "/"), secrets: secrets, client: client}
+		default:
+			return nil, fmt.Errorf("unknown warranty vendor %q", name)
+		}
+	}
+	return lookup, nil
+}
+
+// vendor asks one vendor's api about a serial number
+type vendor interface {
+	lookup(ctx context.Context, serialNo string) (models.WarrantyInfo, error)
+}
+
+// vendorLookup picks the vendor by the asset's brand, "Dell Inc." goes to dell as well
+type vendorLookup struct {
+	vendors map[string]vendor
+}
+
+func (v *vendorLookup) Lookup(ctx context.Context, brand, serialNo string) (models.WarrantyInfo, error) {
+	brand = strings.ToLower(brand)
+	for name, vendor := range v.vendors {
+		if strings.Contains(brand, name) {
+			info, err := vendor.lookup(ctx, strings.TrimSpace(serialNo))
+			info.Vendor = name
+			return info, err
+		}
+	}
+	return models.WarrantyInfo{}, models.ErrWarrantyVendorUnsupported
+}
+
+// send does req and returns the body of a successful response
+func send(client *http.Client, req *http.Request) ([]byte, error) {
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach the warranty api: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the warranty api response: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, models.ErrWarrantyNotFound
+	}
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		if len(body) > 500 {
+			body = body[:500]
+		}
+		return nil, fmt.Errorf("warranty api returned %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	if !json.Valid(body) {
+		return nil, fmt.Errorf("warranty api returned something other than json")
+	}
+	return body, nil
+}
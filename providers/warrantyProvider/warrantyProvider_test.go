@@ -0,0 +1,116 @@
+package warrantyprovider
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSecrets(ctrl *gomock.Controller) *providers.MockSecretsProvider {
+	secrets := providers.NewMockSecretsProvider(ctrl)
+	secrets.EXPECT().GetSecret(gomock.Any(), models.SecretWarrantyDellSecret).Return("dell-secret", nil).AnyTimes()
+	secrets.EXPECT().GetSecret(gomock.Any(), models.SecretWarrantyLenovoToken).Return("lenovo-token", nil).AnyTimes()
+	return secrets
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestDellLookup(t *testing.T) {
+	var signIns atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/oauth/v2/token":
+			signIns.Add(1)
+			require.NoError(t, r.ParseForm())
+			assert.Equal(t, "dell-client", r.Form.Get("client_id"))
+			assert.Equal(t, "dell-secret", r.Form.Get("client_secret"))
+			w.Write([]byte(`{"access_token": "dell-token", "expires_in": 3600}`))
+		case "/PROD/sbil/eapi/v5/asset-entitlements":
+			assert.Equal(t, "Bearer dell-token", r.Header.Get("Authorization"))
+			switch r.URL.Query().Get("servicetags") {
+			case "ABC1234":
+				w.Write([]byte(`[{"serviceTag": "ABC1234", "invalid": false, "entitlements": [
+					{"startDate": "2024-02-01T00:00:00Z", "endDate": "2025-02-01T23:59:59Z"},
+					{"startDate": "2024-01-15T00:00:00Z", "endDate": "2027-01-15T23:59:59Z"}]}]`))
+			default:
+				w.Write([]byte(`[{"serviceTag": "NOPE", "invalid": true, "entitlements": []}]`))
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	lookup, err := NewWarrantyProvider(models.WarrantyConfig{Vendors: []string{"dell"}, DellURL: server.URL, DellClientID: "dell-client", Timeout: time.Second}, newSecrets(gomock.NewController(t)))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	info, err := lookup.Lookup(ctx, "Dell Inc.", "ABC1234")
+	require.NoError(t, err)
+	assert.Equal(t, "dell", info.Vendor)
+	assert.Equal(t, date(2024, time.January, 15), info.WarrantyStart)
+	assert.Equal(t, date(2027, time.January, 15).Add(24*time.Hour-time.Second), info.WarrantyExpire)
+	assert.Contains(t, string(info.Response), "ABC1234")
+
+	_, err = lookup.Lookup(ctx, "DELL", "NOPE")
+	assert.ErrorIs(t, err, models.ErrWarrantyNotFound)
+	assert.Equal(t, int32(1), signIns.Load(), "the token is kept until it is about to expire")
+
+	_, err = lookup.Lookup(ctx, "HP", "5CD1234")
+	assert.ErrorIs(t, err, models.ErrWarrantyVendorUnsupported)
+}
+
+func TestLenovoLookup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2.5/warranty", r.URL.Path)
+		assert.Equal(t, "lenovo-token", r.Header.Get("ClientID"))
+		switch r.URL.Query().Get("Serial") {
+		case "PF123456":
+			w.Write([]byte(`{"Serial": "PF123456", "InWarranty": true, "Warranty": [
+				{"Name": "Base", "Start": "2024-03-01T00:00:00Z", "End": "2026-03-01T00:00:00Z"},
+				{"Name": "Premier", "Start": "2024-03-01T00:00:00Z", "End": "2027-03-01T00:00:00Z"}]}`))
+		case "GONE":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.Write([]byte(`{"Serial": "", "Warranty": []}`))
+		}
+	}))
+	defer server.Close()
+
+	lookup, err := NewWarrantyProvider(models.WarrantyConfig{Vendors: []string{"lenovo"}, LenovoURL: server.URL, Timeout: time.Second}, newSecrets(gomock.NewController(t)))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	info, err := lookup.Lookup(ctx, "Lenovo", "PF123456")
+	require.NoError(t, err)
+	assert.Equal(t, models.WarrantyInfo{
+		Vendor: "lenovo", WarrantyStart: date(2024, time.March, 1), WarrantyExpire: date(2027, time.March, 1), Response: info.Response,
+	}, info)
+
+	_, err = lookup.Lookup(ctx, "Lenovo", "UNKNOWN")
+	assert.ErrorIs(t, err, models.ErrWarrantyNotFound)
+	_, err = lookup.Lookup(ctx, "Lenovo", "GONE")
+	assert.ErrorIs(t, err, models.ErrWarrantyNotFound)
+}
+
+func TestNewWarrantyProvider(t *testing.T) {
+	lookup, err := NewWarrantyProvider(models.WarrantyConfig{}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, lookup)
+
+	_, err = NewWarrantyProvider(models.WarrantyConfig{Vendors: []string{"dell"}}, nil)
+	assert.ErrorContains(t, err, "WARRANTY_DELL_CLIENT_ID")
+	_, err = NewWarrantyProvider(models.WarrantyConfig{Vendors: []string{"apple"}}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"asset/models"
+	"asset/providers/middlewareprovider"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// accessLog writes one structured line per request. Server errors are logged at error and client
+// errors at warn, both unsampled, successful requests at info are sampled and probes drop to debug
+func accessLog(logger *zap.Logger, cfg models.AccessLogConfig) func(http.Handler) http.Handler {
+	if !cfg.Enabled {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	logger = logger.Named("http").WithOptions(zap.IncreaseLevel(cfg.Level))
+	sampled := logger
+	if cfg.SampleFirst > 0 {
+		sampled = logger.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewSamplerWithOptions(core, time.Second, cfg.SampleFirst, cfg.SampleThereafter)
+		}))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			r, userID := middlewareprovider.TrackRequestUser(r)
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			fields := []zap.Field{
+				zap.String("method", r.Method),
+				zap.String("path", r.URL.Path),
+				zap.Int("status", status),
+				zap.Duration("latency", time.Since(start)),
+				zap.Int("bytes", ww.BytesWritten()),
+				zap.String("request_id", middleware.GetReqID(r.Context())),
+				zap.String("remote_ip", r.RemoteAddr),
+			}
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				fields = append(fields, zap.String("route", rctx.RoutePattern()))
+			}
+			if id := userID(); id != "" {
+				fields = append(fields, zap.String("user_id", id))
+			}
+
+			switch {
+			case status >= http.StatusInternalServerError:
+				logger.Error("request", fields...)
+			case status >= http.StatusBadRequest:
+				logger.Warn("request", fields...)
+			case r.URL.Path == "/healthz" || r.URL.Path == "/readyz":
+				sampled.Debug("request", fields...)
+			default:
+				sampled.Info("request", fields...)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"asset/models"
+	"asset/utils"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// configWatchInterval is how often every instance looks for a modified config file
+const configWatchInterval = 30 * time.Second
+
+// ReloadConfig applies the config file to the instance serving the request, the other instances pick
+// the change up from the file on their next watch
+func (srv *Server) ReloadConfig(w http.ResponseWriter, r *http.Request) {
+	srv.Logger.GetLogger().Info("ReloadConfig request received")
+	changed, err := srv.reloadConfig()
+	if err != nil {
+		srv.Logger.GetLogger().Error("Failed to reload config", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to reload config")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, models.ConfigReloadRes{Changed: changed, ReloadedAt: time.Now().UTC()})
+}
+
+// reloadConfig reloads the runtime settings and hands the log level to the logger, the other
+// settings are read from the config provider where they are used
+func (srv *Server) reloadConfig() ([]string, error) {
+	changed, err := srv.Config.Reload()
+	if err != nil {
+		return nil, err
+	}
+	for _, setting := range changed {
+		if setting == models.SettingLogLevel {
+			srv.Logger.SetLevel(srv.Config.GetLogConfig().Level)
+		}
+	}
+	if len(changed) > 0 {
+		srv.Logger.GetLogger().Info("config reloaded", zap.Strings("changed", changed))
+	}
+	return changed, nil
+}
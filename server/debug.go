@@ -0,0 +1,38 @@
+package server
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// debugRoutes mounts net/http/pprof and expvar, the paths match what go tool pprof expects so
+// `go tool pprof https://host/debug/pprof/heap` works with an Authorization header
+func debugRoutes(r chi.Router) {
+	r.Get("/pprof/", pprof.Index)
+	// named profiles like heap, goroutine, allocs, block and mutex are served by Index
+	r.Get("/pprof/*", pprof.Index)
+	r.Get("/pprof/cmdline", pprof.Cmdline)
+	r.Get("/pprof/profile", pprof.Profile)
+	r.Get("/pprof/symbol", pprof.Symbol)
+	r.Post("/pprof/symbol", pprof.Symbol)
+	r.Get("/pprof/trace", pprof.Trace)
+	r.Handle("/vars", expvar.Handler())
+}
+
+// debugServer serves the debug routes without authentication on a separate address, meant for a
+// loopback or cluster internal port that is never exposed publicly
+func debugServer(addr string) *http.Server {
+	r := chi.NewRouter()
+	r.Route("/debug", debugRoutes)
+	return &http.Server{
+		Addr:    addr,
+		Handler: r,
+		// cpu profiles and traces run for ?seconds=, 30 by default
+		ReadTimeout:  time.Minute,
+		WriteTimeout: 5 * time.Minute,
+	}
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/providers/middlewareprovider"
+	"net"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// serverErrorResponse picks up the error utils.RespondError answers a 5xx with
+type serverErrorResponse struct {
+	http.ResponseWriter
+	err error
+}
+
+func (w *serverErrorResponse) RecordServerError(err error) {
+	w.err = err
+}
+
+func (w *serverErrorResponse) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Flush keeps streaming handlers like the live asset feed working behind the wrapper
+func (w *serverErrorResponse) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// reportServerErrors sends the cause of every 5xx response to the error reporter with the request
+// and user it happened to, 4xx are the client's problem and aren't reported
+func reportServerErrors(reporter providers.ErrorReporter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if reporter == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res := &serverErrorResponse{ResponseWriter: w}
+			r, userID := middlewareprovider.TrackRequestUser(r)
+			next.ServeHTTP(res, r)
+			if res.err == nil {
+				return
+			}
+			report := requestErrorReport(r, userID())
+			report.Err = res.err
+			report.Status = http.StatusInternalServerError
+			if ww, ok := w.(middleware.WrapResponseWriter); ok && ww.Status() != 0 {
+				report.Status = ww.Status()
+			}
+			reporter.Report(report)
+		})
+	}
+}
+
+// requestErrorReport fills the request side of a report, the url leaves out the query since it can
+// carry tokens
+func requestErrorReport(r *http.Request, userID string) models.ErrorReport {
+	report := models.ErrorReport{
+		Method:    r.Method,
+		URL:       r.URL.Path,
+		RequestID: middleware.GetReqID(r.Context()),
+		UserID:    userID,
+		RemoteIP:  r.RemoteAddr,
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		report.RemoteIP = host
+	}
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		report.Route = rctx.RoutePattern()
+	}
+	return report
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// etagResponse holds the body back so its hash can go in the ETag header before anything is sent,
+// withResponseCache holds it back the same way to store it
+type etagResponse struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *etagResponse) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *etagResponse) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *etagResponse) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(p)
+}
+
+// withETag gives successful GET responses a weak ETag from the hash of their body and answers 304
+// when the client's If-None-Match still matches, pollers then skip the download. The body is still
+// built every time, only put it on json routes small enough to buffer
+func withETag(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		res := &etagResponse{ResponseWriter: w}
+		next.ServeHTTP(res, r)
+		if res.status == 0 {
+			res.status = http.StatusOK
+		}
+		if res.status != http.StatusOK {
+			w.WriteHeader(res.status)
+			w.Write(res.body.Bytes())
+			return
+		}
+
+		sum := sha256.Sum256(res.body.Bytes())
+		etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+		w.Header().Set("ETag", etag)
+		// responses depend on the caller, shared caches must not keep them and clients revalidate,
+		// unless the handler already picked its own policy
+		if w.Header().Get("Cache-Control") == "" {
+			w.Header().Set("Cache-Control", "private, no-cache")
+		}
+		w.Header().Add("Vary", "Authorization, Cookie")
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.Header().Del("Content-Type")
+			w.Header().Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write(res.body.Bytes())
+	})
+}
+
+// etagMatches compares weakly as If-None-Match requires, W/"x" and "x" are the same tag
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"asset/models"
+	"asset/utils"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// readinessTimeout bounds each dependency probe, probes run in parallel so it also bounds /readyz
+const readinessTimeout = 2 * time.Second
+
+// Healthz answers as long as the process serves http, it checks nothing so a slow database never
+// gets the instance restarted
+func (srv *Server) Healthz(w http.ResponseWriter, r *http.Request) {
+	utils.RespondJSON(w, http.StatusOK, models.HealthRes{Status: models.HealthStatusUp})
+}
+
+// Readyz probes the database, redis and firebase and answers 503 when any of them is down, so load
+// balancers stop routing to an instance that can't serve requests. A shutting down instance answers
+// 503 draining without probing
+func (srv *Server) Readyz(w http.ResponseWriter, r *http.Request) {
+	if srv.draining.Load() {
+		w.Header().Set("Cache-Control", "no-store")
+		utils.RespondJSON(w, http.StatusServiceUnavailable, models.HealthRes{Status: models.HealthStatusDraining})
+		return
+	}
+	checks := map[string]func(ctx context.Context) error{
+		"database": func(ctx context.Context) error {
+			return srv.DB.DB().PingContext(ctx)
+		},
+		"redis": srv.Redis.Ping,
+		"firebase": func(ctx context.Context) error {
+			if srv.Firebase == nil {
+				return errors.New("firebase is not configured")
+			}
+			return srv.Firebase.Ping(ctx)
+		},
+	}
+
+	res := models.HealthRes{Status: models.HealthStatusUp, Components: make(map[string]models.ComponentHealth, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(ctx context.Context) error) {
+			defer wg.Done()
+			component, err := probe(r.Context(), check)
+			if err != nil {
+				srv.Logger.GetLogger().Warn("readiness check failed", zap.String("component", name), zap.Error(err))
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			res.Components[name] = component
+			if component.Status != models.HealthStatusUp {
+				res.Status = models.HealthStatusDown
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	if res.Status != models.HealthStatusUp {
+		status = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	utils.RespondJSON(w, status, res)
+}
+
+// probe runs one check, the error is for the logs and the component is what the client sees
+func probe(ctx context.Context, check func(ctx context.Context) error) (models.ComponentHealth, error) {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+	start := time.Now()
+	err := check(ctx)
+	component := models.ComponentHealth{Status: models.HealthStatusUp, LatencyMS: time.Since(start).Milliseconds()}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil:
+		component.Status = models.HealthStatusDown
+		component.Error = "timed out"
+	case err != nil:
+		component.Status = models.HealthStatusDown
+		component.Error = "unreachable"
+	}
+	return component, err
+}
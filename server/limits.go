@@ -0,0 +1,75 @@
+package server
+
+import (
+	"asset/models"
+	"asset/utils"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// longRoutes import or export in bulk and get the long timeout, keyed like apiOperations
+var longRoutes = map[string]bool{
+	"/api/employee/employees/export": true,
+	"/api/employee/employees/stream": true,
+	"/api/inventory/assets/stream":   true,
+	"/api/admin/directory-sync":      true,
+	"/api/admin/snapshots/restore":   true,
+}
+
+// streamingRoutes hold the connection open on purpose and get no timeout
+var streamingRoutes = map[string]bool{
+	"/api/inventory/assets/live": true,
+	"/debug/pprof/profile":       true,
+	"/debug/pprof/trace":         true,
+}
+
+// limitRequests rejects bodies over the size limit with a 413 and gives every request a deadline,
+// handlers that are still working when it passes see their context cancelled and the client gets a 504
+func limitRequests(limits models.RequestLimits) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if limits.MaxBodyBytes > 0 {
+				if r.ContentLength > limits.MaxBodyBytes {
+					utils.RespondError(w, http.StatusRequestEntityTooLarge, &http.MaxBytesError{Limit: limits.MaxBodyBytes}, "request body is too large")
+					return
+				}
+				// chunked bodies don't announce their length, the reader stops them at the limit
+				r.Body = http.MaxBytesReader(w, r.Body, limits.MaxBodyBytes)
+			}
+
+			timeout := requestTimeout(r, limits)
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+			// handlers that answered with their own error already sent it, RespondError maps a
+			// deadline error to 504 too
+			if ww.Status() == 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				utils.RespondError(ww, http.StatusGatewayTimeout, ctx.Err(), "request timed out")
+			}
+		})
+	}
+}
+
+func requestTimeout(r *http.Request, limits models.RequestLimits) time.Duration {
+	route, _ := unversionedRoute(strings.TrimSuffix(r.URL.Path, "/"))
+	switch {
+	case streamingRoutes[route]:
+		return 0
+	case longRoutes[route]:
+		return limits.LongTimeout
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		return limits.ReadTimeout
+	default:
+		return limits.WriteTimeout
+	}
+}
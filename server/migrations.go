@@ -0,0 +1,51 @@
+package server
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/providers/databaseProvider"
+	"asset/utils"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// checkMigrations applies pending migrations when the profile or MIGRATE_ON_START asks for it, a
+// failure then stops the server. Otherwise a schema behind this build is only reported, it is
+// migrated with assetctl migrate
+func checkMigrations(db *databaseProvider.PostgresProvider, cfg models.MigrationConfig, logs providers.ZapLoggerProvider) {
+	if cfg.OnStart {
+		version, err := db.MigrateUp(0)
+		if err != nil {
+			logs.GetLogger().Fatal("failed to apply migrations on start", zap.String("dir", cfg.Dir), zap.Error(err))
+		}
+		logs.GetLogger().Info("migrations applied on start", zap.Uint("version", version))
+		return
+	}
+	status, err := db.MigrationStatus()
+	if err != nil {
+		logs.GetLogger().Warn("failed to read the migration status", zap.String("dir", cfg.Dir), zap.Error(err))
+		return
+	}
+	switch {
+	case status.Dirty:
+		logs.GetLogger().Error("database schema is dirty, a migration failed halfway", zap.Uint("version", status.Version))
+	case len(status.Pending) > 0:
+		logs.GetLogger().Warn("database schema is behind this build, run assetctl migrate up",
+			zap.Uint("version", status.Version), zap.Uint("latest", status.Latest), zap.Int("pending", len(status.Pending)))
+	default:
+		logs.GetLogger().Info("database schema is up to date", zap.Uint("version", status.Version))
+	}
+}
+
+// GetMigrationStatus reports the schema version against the migrations this instance was built with
+func (srv *Server) GetMigrationStatus(w http.ResponseWriter, r *http.Request) {
+	srv.Logger.GetLogger().Info("GetMigrationStatus request received")
+	status, err := srv.DB.MigrationStatus()
+	if err != nil {
+		srv.Logger.GetLogger().Error("Failed to read migration status", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to read migration status")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, status)
+}
@@ -0,0 +1,376 @@
+package server
+
+import (
+	"asset/models"
+	"asset/providers/middlewareprovider"
+	"asset/utils"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// apiOperation documents one route. Request and Response are zero values whose types are turned
+// into json schemas, obj describes the ad hoc maps handlers answer with
+type apiOperation struct {
+	Summary    string
+	Tag        string
+	Query      []apiParam
+	Request    interface{}
+	Response   interface{}
+	Status     int
+	Public     bool
+	Permission models.Permission
+	// set for responses that aren't json, like exports and saml metadata
+	ContentType string
+	// set for routes behind withETag, documents If-None-Match and the 304
+	ETag bool
+	// set for routes behind withResponseCache, documents Cache-Control and X-Cache
+	Cached bool
+	// set for request bodies that aren't json, like csv imports
+	RequestContentType string
+}
+
+// pathParamPattern finds chi url params like {id} in a route
+var pathParamPattern = regexp.MustCompile(`\{([^}/:]+)(:[^}]*)?\}`)
+
+type apiParam struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// obj is an inline response object, each value's type becomes the property schema
+type obj map[string]interface{}
+
+// apiDocs serves the spec built from the router, so every mounted route shows up even before it
+// gets an entry in apiOperations
+type apiDocs struct {
+	router chi.Routes
+	once   sync.Once
+	spec   []byte
+	err    error
+}
+
+func (d *apiDocs) ServeSpec(w http.ResponseWriter, r *http.Request) {
+	d.once.Do(func() {
+		d.spec, d.err = json.Marshal(buildOpenAPISpec(d.router))
+	})
+	if d.err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, d.err, "failed to build api spec")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(d.spec)
+}
+
+func (d *apiDocs) ServeUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUIPage))
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Asset Manager API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/api/docs/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>`
+
+var protectedSecurity = []map[string][]string{{"bearerAuth": {}}, {"apiKeyAuth": {}}, {"cookieAuth": {}}}
+
+func buildOpenAPISpec(router chi.Routes) map[string]interface{} {
+	schemas := newSchemaRegistry()
+	errorRef := schemas.schemaFor(reflect.TypeOf(utils.ClientError{}))
+	paths := map[string]map[string]interface{}{}
+
+	chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if len(route) > 1 {
+			route = strings.TrimSuffix(route, "/")
+		}
+		key, versioned := unversionedRoute(route)
+		op, ok := apiOperations[method+" "+route]
+		if !ok {
+			op, ok = apiOperations[method+" "+key]
+		}
+		if !ok {
+			op = apiOperation{Summary: "Undocumented route", Tag: "undocumented"}
+		}
+		if paths[route] == nil {
+			paths[route] = map[string]interface{}{}
+		}
+		spec := op.spec(route, schemas, errorRef)
+		// the unversioned paths are kept for old clients, new ones should use a version
+		if !versioned && strings.HasPrefix(route, "/api/") && !strings.HasPrefix(route, "/api/docs") {
+			spec["deprecated"] = true
+		}
+		paths[route][strings.ToLower(method)] = spec
+		return nil
+	})
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Asset Manager API",
+			"version":     "1.0.0",
+			"description": "Errors share the ClientError envelope: a stable code to switch on, a message, optional details and the request_id also sent as X-Request-ID. Protected routes take a bearer access token, an api key or the session cookies. Paths without a version serve v1 and are deprecated in favour of /api/v1.",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas.components,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"apiKeyAuth": map[string]interface{}{"type": "apiKey", "in": "header", "name": middlewareprovider.APIKeyHeader},
+				"cookieAuth": map[string]interface{}{"type": "apiKey", "in": "cookie", "name": middlewareprovider.AccessTokenCookie},
+			},
+		},
+	}
+}
+
+func (op apiOperation) spec(route string, schemas *schemaRegistry, errorRef map[string]interface{}) map[string]interface{} {
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	contentType := op.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	success := map[string]interface{}{"description": http.StatusText(status)}
+	if op.Response != nil {
+		success["content"] = map[string]interface{}{contentType: map[string]interface{}{"schema": schemas.valueSchema(op.Response)}}
+	} else if op.ContentType != "" {
+		success["content"] = map[string]interface{}{contentType: map[string]interface{}{"schema": map[string]interface{}{"type": "string", "format": "binary"}}}
+	}
+	errorBody := map[string]interface{}{"content": map[string]interface{}{"application/json": map[string]interface{}{"schema": errorRef}}}
+	responses := map[string]interface{}{
+		strconv.Itoa(status): success,
+		"default":            withDescription(errorBody, "Error"),
+	}
+
+	spec := map[string]interface{}{
+		"summary":   op.Summary,
+		"tags":      []string{op.Tag},
+		"responses": responses,
+	}
+	if op.Public {
+		spec["security"] = []map[string][]string{}
+	} else {
+		spec["security"] = protectedSecurity
+		responses["401"] = withDescription(errorBody, "Missing or invalid credentials")
+		if op.Permission != "" {
+			spec["description"] = "Requires the " + string(op.Permission) + " permission."
+			responses["403"] = withDescription(errorBody, "Permission denied")
+		}
+	}
+	params := make([]map[string]interface{}, 0, len(op.Query)+1)
+	if op.ETag {
+		success["headers"] = map[string]interface{}{"ETag": map[string]interface{}{"description": "weak tag of the body", "schema": map[string]interface{}{"type": "string"}}}
+		responses["304"] = map[string]interface{}{"description": "Unchanged since the tag in If-None-Match"}
+		params = append(params, map[string]interface{}{
+			"name":        "If-None-Match",
+			"in":          "header",
+			"required":    false,
+			"description": "ETag of the copy the client holds",
+			"schema":      map[string]interface{}{"type": "string"},
+		})
+	}
+	if op.Cached {
+		headers, _ := success["headers"].(map[string]interface{})
+		if headers == nil {
+			headers = map[string]interface{}{}
+		}
+		headers["Cache-Control"] = map[string]interface{}{"description": "private, max-age of the response cache ttl", "schema": map[string]interface{}{"type": "string"}}
+		headers["X-Cache"] = map[string]interface{}{"description": "HIT when served from the response cache, MISS when built, absent when the request bypassed it", "schema": map[string]interface{}{"type": "string"}}
+		success["headers"] = headers
+	}
+	for _, match := range pathParamPattern.FindAllStringSubmatch(route, -1) {
+		params = append(params, map[string]interface{}{
+			"name":     match[1],
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+	for _, p := range op.Query {
+		params = append(params, map[string]interface{}{
+			"name":        p.Name,
+			"in":          "query",
+			"required":    p.Required,
+			"description": p.Description,
+			"schema":      map[string]interface{}{"type": "string"},
+		})
+	}
+	if len(params) > 0 {
+		spec["parameters"] = params
+	}
+	if op.Request != nil {
+		spec["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": schemas.valueSchema(op.Request)}},
+		}
+	} else if op.RequestContentType != "" {
+		spec["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  map[string]interface{}{op.RequestContentType: map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}},
+		}
+	}
+	return spec
+}
+
+func withDescription(body map[string]interface{}, description string) map[string]interface{} {
+	out := map[string]interface{}{"description": description}
+	for k, v := range body {
+		out[k] = v
+	}
+	return out
+}
+
+// schemaRegistry turns go types into json schemas, named structs go to components once and are
+// referenced from then on
+type schemaRegistry struct {
+	components map[string]interface{}
+	names      map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{components: map[string]interface{}{}, names: map[reflect.Type]string{}}
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(uuid.UUID{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+func (s *schemaRegistry) valueSchema(value interface{}) map[string]interface{} {
+	if o, ok := value.(obj); ok {
+		properties := map[string]interface{}{}
+		for name, v := range o {
+			properties[name] = s.valueSchema(v)
+		}
+		return map[string]interface{}{"type": "object", "properties": properties}
+	}
+	if value == nil {
+		return map[string]interface{}{}
+	}
+	return s.schemaFor(reflect.TypeOf(value))
+}
+
+func (s *schemaRegistry) schemaFor(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case uuidType:
+		return map[string]interface{}{"type": "string", "format": "uuid"}
+	case rawType:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := s.schemaFor(t.Elem())
+		if _, isRef := schema["$ref"]; isRef {
+			return map[string]interface{}{"allOf": []interface{}{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": s.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		return s.ref(t)
+	}
+	return map[string]interface{}{}
+}
+
+func (s *schemaRegistry) ref(t reflect.Type) map[string]interface{} {
+	name, ok := s.names[t]
+	if !ok {
+		name = t.Name()
+		if _, taken := s.components[name]; taken {
+			pkg := t.PkgPath()
+			name = pkg[strings.LastIndex(pkg, "/")+1:] + name
+		}
+		s.names[t] = name
+		// registered before filling so self referencing types end
+		s.components[name] = map[string]interface{}{}
+		s.components[name] = s.structSchema(t)
+	}
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+func (s *schemaRegistry) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	s.addFields(t, properties, &required)
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (s *schemaRegistry) addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.addFields(embedded, properties, required)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = s.schemaFor(field.Type)
+		if strings.Contains(field.Tag.Get("validate"), "required") {
+			*required = append(*required, name)
+		}
+	}
+}
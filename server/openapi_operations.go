@@ -0,0 +1,367 @@
+package server
+
+import (
+	"asset/models"
+	"asset/services/apikey"
+	"asset/services/audit"
+	"asset/services/budget"
+	"asset/services/bulk"
+	"asset/services/charge"
+	"asset/services/department"
+	"asset/services/escalation"
+	"asset/services/esign"
+	"asset/services/graphql"
+	"asset/services/integrity"
+	"asset/services/loginactivity"
+	"asset/services/notification"
+	"asset/services/onboarding"
+	"asset/services/permission"
+	"asset/services/privacy"
+	"asset/services/procurement"
+	"asset/services/project"
+	"asset/services/push"
+	"asset/services/report"
+	"asset/services/scheduler"
+	"asset/services/search"
+	"asset/services/serviceaccount"
+	"asset/services/sheets"
+	"asset/services/snapshot"
+	"asset/services/trash"
+	"asset/services/user"
+	"asset/services/waitlist"
+	"asset/services/webhook"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// message is the body of handlers that only confirm what they did
+var message = obj{"message": ""}
+
+var (
+	paginationParams = []apiParam{
+		{Name: "page", Description: "page number, starts at 1"},
+		{Name: "limit", Description: "page size, 10 by default"},
+	}
+	loginFilterParams = append([]apiParam{
+		{Name: "flagged", Description: "true lists only logins from a new device, a new country or impossible travel"},
+		{Name: "status", Description: "not_required, pending, confirmed or denied"},
+	}, paginationParams...)
+	idParam     = apiParam{Name: "id", Description: "id of the resource", Required: true}
+	assetParam  = apiParam{Name: "asset_id", Description: "asset id", Required: true}
+	userIDParam = apiParam{Name: "user_id", Description: "user id", Required: true}
+)
+
+// apiOperations describes every route by "METHOD path", keep it next to routes.go when adding routes
+var apiOperations = map[string]apiOperation{
+	"GET /test":                  {Summary: "Health check", Tag: "system", Public: true, ContentType: "text/plain"},
+	"GET /healthz":               {Summary: "Liveness, up while the process serves http", Tag: "system", Public: true, Response: models.HealthRes{}},
+	"GET /readyz":                {Summary: "Readiness, probes the database, redis and firebase, 503 when one is down or the instance is shutting down", Tag: "system", Public: true, Response: models.HealthRes{}},
+	"GET /.well-known/jwks.json": {Summary: "Public keys access tokens are signed with", Tag: "auth", Public: true, Response: models.JWKSet{}},
+
+	// profiling, also served without auth on DEBUG_ADDR when set
+	"GET /debug/pprof":           {Summary: "Index of the available runtime profiles", Tag: "debug", Permission: models.DebugReadPermission, ContentType: "text/html"},
+	"GET /debug/pprof/*":         {Summary: "Named profile, e.g. heap, goroutine, allocs, block or mutex, ?debug=1 for text", Tag: "debug", Permission: models.DebugReadPermission, ContentType: "application/octet-stream"},
+	"GET /debug/pprof/cmdline":   {Summary: "Command line of the running process", Tag: "debug", Permission: models.DebugReadPermission, ContentType: "text/plain"},
+	"GET /debug/pprof/profile":   {Summary: "CPU profile over ?seconds=, 30 by default", Tag: "debug", Permission: models.DebugReadPermission, ContentType: "application/octet-stream", Query: []apiParam{{Name: "seconds", Description: "profiling duration"}}},
+	"GET /debug/pprof/symbol":    {Summary: "Look up program counters", Tag: "debug", Permission: models.DebugReadPermission, ContentType: "text/plain"},
+	"POST /debug/pprof/symbol":   {Summary: "Look up the program counters in the body", Tag: "debug", Permission: models.DebugReadPermission, ContentType: "text/plain"},
+	"GET /debug/pprof/trace":     {Summary: "Execution trace over ?seconds=, 1 by default", Tag: "debug", Permission: models.DebugReadPermission, ContentType: "application/octet-stream", Query: []apiParam{{Name: "seconds", Description: "tracing duration"}}},
+	"GET /debug/vars":            {Summary: "expvar runtime metrics, memstats and cmdline", Tag: "debug", Permission: models.DebugReadPermission, Response: obj{}},
+	"GET /api/docs":              {Summary: "Swagger UI", Tag: "system", Public: true, ContentType: "text/html"},
+	"GET /api/docs/openapi.json": {Summary: "This OpenAPI document", Tag: "system", Public: true, Response: obj{}},
+
+	// sign in and sign up
+	"POST /api/user/register":    {Summary: "Register with email and password", Tag: "auth", Public: true, Request: userservice.PublicUserReq{}, Status: http.StatusCreated, Response: obj{"message": "", "userId": uuid.UUID{}, "firebaseUID": ""}},
+	"POST /api/v2/user/register": {Summary: "Register with a firebase id token in the Authorization header", Tag: "auth", Public: true, Status: http.StatusCreated, Response: obj{"message": "", "userId": "", "firebaseUID": ""}},
+	"POST /api/user/login":       {Summary: "Sign in with email and password", Tag: "auth", Public: true, Request: userservice.PublicUserReq{}, Response: userservice.LoginRes{}},
+	"POST /api/v2/user/login": {Summary: "Sign in with a google, microsoft or github token in the Authorization header", Tag: "auth", Public: true, Response: userservice.LoginRes{},
+		Query: []apiParam{{Name: "provider", Description: "google (default), microsoft or github"}}},
+	"POST /api/user/magic-link":       {Summary: "Email a single use sign-in link", Tag: "auth", Public: true, Request: userservice.PublicUserReq{}, Status: http.StatusAccepted, Response: message},
+	"POST /api/user/magic-link/login": {Summary: "Sign in with a magic link token", Tag: "auth", Public: true, Request: userservice.MagicLinkLoginReq{}, Response: userservice.LoginRes{}},
+	"POST /api/user/oidc/login": {Summary: "Sign in with an id token from a configured oidc issuer", Tag: "auth", Public: true, Response: userservice.LoginRes{},
+		Query: []apiParam{{Name: "provider", Description: "name from OIDC_PROVIDERS", Required: true}}},
+	"GET /api/user/saml/metadata": {Summary: "SAML service provider metadata", Tag: "auth", Public: true, ContentType: "application/samlmetadata+xml"},
+	"GET /api/user/saml/login": {Summary: "Redirect to the SAML identity provider", Tag: "auth", Public: true, Status: http.StatusFound,
+		Query: []apiParam{{Name: "relay_state", Description: "opaque value returned to the acs"}}},
+	"POST /api/user/saml/acs":             {Summary: "SAML assertion consumer, takes the form posted by the identity provider", Tag: "auth", Public: true, Response: userservice.LoginRes{}},
+	"POST /api/user/invite/accept":        {Summary: "Accept an employee invite and set a password", Tag: "auth", Public: true, Request: userservice.AcceptInviteReq{}, Status: http.StatusCreated, Response: obj{"user UUID": uuid.UUID{}, "onboarding": (*models.OnboardingRes)(nil)}},
+	"POST /api/user/invite/otp":           {Summary: "Text a code confirming the contact number of an invite, when SMS_VERIFY_CONTACT_NO is on", Tag: "auth", Public: true, Request: userservice.ContactOTPReq{}, Status: http.StatusAccepted, Response: message},
+	"POST /api/user/verify-email/confirm": {Summary: "Confirm an email address", Tag: "auth", Public: true, Request: userservice.ConfirmEmailReq{}, Response: message},
+	"POST /api/user/verify-email/resend":  {Summary: "Send a new verification link", Tag: "auth", Public: true, Request: userservice.PublicUserReq{}, Response: message},
+	"POST /api/user/change-email/confirm": {Summary: "Confirm an email change", Tag: "auth", Public: true, Request: userservice.ConfirmEmailReq{}, Response: message},
+	"POST /api/user/mfa/verify":           {Summary: "Answer the mfa challenge of a sign in", Tag: "auth", Public: true, Request: userservice.MFAChallengeReq{}, Response: userservice.LoginRes{}},
+	"POST /api/user/mfa/enroll":           {Summary: "Enroll mfa during a sign in that requires it", Tag: "auth", Public: true, Request: userservice.MFATokenReq{}, Response: userservice.MFAEnrollmentRes{}},
+	"POST /api/auth/token":                {Summary: "Issue a service account access token from client credentials", Tag: "auth", Public: true, Request: serviceaccountservice.TokenReq{}, Response: serviceaccountservice.TokenRes{}},
+	"POST /api/auth/refresh":              {Summary: "Exchange a refresh token, read from the cookie in cookie mode", Tag: "auth", Public: true, Request: userservice.RefreshTokenReq{}, Response: userservice.RefreshTokenRes{}},
+	"POST /api/integrations/tickets":      {Summary: "Service desk webhook reporting a ticket's status, authenticated by TICKET_WEBHOOK_TOKEN in X-Ticket-Token or ?token=", Tag: "inventory", Public: true, Request: obj{}, Response: message},
+	"POST /api/integrations/esign":        {Summary: "DocuSign connect or Dropbox Sign callback reporting an envelope's status, checked against its signature", Tag: "inventory", Public: true, Request: obj{}, ContentType: "text/plain"},
+	"POST /api/auth/introspect":           {Summary: "Introspect an access token or api key", Tag: "auth", Permission: models.TokenIntrospectPermission, Request: userservice.IntrospectTokenReq{}, Response: models.TokenIntrospection{}},
+	"GET /api/me":                         {Summary: "Caller profile, roles and permissions", Tag: "me", Response: permissionservice.MeRes{}},
+	"GET /api/directory": {Summary: "Company directory of the caller's organization, open to every employee", Tag: "me", ETag: true, Cached: true, Response: obj{"employees": []userservice.DirectoryEntry{}},
+		Query: append([]apiParam{{Name: "search", Description: "matches name, email or designation"}, {Name: "department_id", Description: "only this department"}}, paginationParams...)},
+	"GET /api/search": {Summary: "Search employees and assets from one box, results are typed and ranked by how well they match", Tag: "me", Response: searchservice.SearchRes{},
+		Query: []apiParam{{Name: "q", Description: "at least 2 characters, matched against name, email, contact number, brand, model, serial number and asset id", Required: true}, {Name: "limit", Description: "at most 50, 20 by default"}}},
+	"GET /api/meta/enums":                      {Summary: "Values accepted for roles, employee types, asset types, asset statuses and ownership", Tag: "me", ETag: true, Cached: true, Response: models.EnumsRes{}},
+	"GET /api/meta/asset-types":                {Summary: "Config fields of every asset type with their type, whether they are required and their constraints", Tag: "me", ETag: true, Cached: true, Response: obj{"asset_types": []models.AssetTypeSchema{}}},
+	"GET /api/users/dashboard":                 {Summary: "Caller's assets and profile", Tag: "me", ETag: true, Response: userservice.UserDashboardRes{}},
+	"GET /api/users/dashboard/summary":         {Summary: "Counts and a minimal asset list for mobile, cacheable for a minute", Tag: "me", ETag: true, Response: userservice.DashboardSummaryRes{}},
+	"POST /api/users/logout":                   {Summary: "Revoke the session's refresh token", Tag: "me", Request: userservice.RefreshTokenReq{}, Response: message},
+	"POST /api/users/change-email":             {Summary: "Start changing the caller's email", Tag: "me", Request: userservice.ChangeEmailReq{}, Status: http.StatusAccepted, Response: userservice.EmailChangeRes{}},
+	"PUT /api/users/timezone":                  {Summary: "Set the caller's timezone, an empty one goes back to UTC", Tag: "me", Request: userservice.TimezoneReq{}, Response: userservice.TimezoneRes{}},
+	"POST /api/users/mfa/enroll":               {Summary: "Start mfa enrollment", Tag: "me", Response: userservice.MFAEnrollmentRes{}},
+	"POST /api/users/mfa/activate":             {Summary: "Activate mfa with a code from the authenticator", Tag: "me", Request: userservice.MFACodeReq{}, Response: message},
+	"POST /api/users/mfa/disable":              {Summary: "Disable mfa", Tag: "me", Request: userservice.MFACodeReq{}, Response: message},
+	"POST /api/users/mfa/backup-codes":         {Summary: "Regenerate mfa backup codes", Tag: "me", Request: userservice.MFACodeReq{}, Response: obj{"backup_codes": []string{}}},
+	"POST /api/users/assets/report-stolen":     {Summary: "Report an asset the caller holds as stolen", Tag: "me", Request: models.ReportStolenReq{}, Response: message},
+	"POST /api/users/assets/acknowledge":       {Summary: "Acknowledge receiving an asset assigned to the caller, which stops the reminders", Tag: "me", Request: models.AcknowledgeAssignmentReq{}, Response: message},
+	"GET /api/users/purchase-requests":         {Summary: "The caller's purchase requests newest first", Tag: "me", Query: append([]apiParam{{Name: "status", Description: "pending_review, pending_finance, approved, rejected or ordered"}}, paginationParams...), Response: obj{"requests": []procurementservice.PurchaseRequest{}}},
+	"POST /api/users/purchase-requests":        {Summary: "Ask for equipment that isn't in stock, the asset managers of the caller's department review it and finance approves it", Tag: "me", Request: procurementservice.CreatePurchaseRequestReq{}, Status: http.StatusCreated, Response: obj{"message": "", "request": procurementservice.PurchaseRequest{}}},
+	"GET /api/users/waitlist":                  {Summary: "The caller's open waitlist entries with their place in line or the asset offered to them", Tag: "me", Response: obj{"entries": []waitlistservice.WaitlistEntry{}}},
+	"POST /api/users/waitlist":                 {Summary: "Wait for an asset type none of which the caller could be given now, 409 when one is available", Tag: "me", Request: waitlistservice.JoinWaitlistReq{}, Status: http.StatusCreated, Response: obj{"message": "", "entry": waitlistservice.WaitlistEntry{}}},
+	"POST /api/users/waitlist/claim":           {Summary: "Take the asset offered on a waitlist entry before the offer expires, it is assigned to the caller", Tag: "me", Request: waitlistservice.ClaimOfferReq{}, Response: obj{"message": "", "entry": waitlistservice.WaitlistEntry{}}},
+	"DELETE /api/users/waitlist/leave":         {Summary: "Give up a place in line, an asset offered on it goes to the next one", Tag: "me", Query: []apiParam{idParam}, Response: message},
+	"GET /api/users/permissions":               {Summary: "Permissions granted to the caller", Tag: "me", Response: permissionservice.UserPermissionsRes{}},
+	"GET /api/users/delegations":               {Summary: "Delegations made by or to the caller", Tag: "me", Response: obj{"delegations": []permissionservice.DelegationRes{}}},
+	"POST /api/users/delegations":              {Summary: "Delegate a role for a time window", Tag: "me", Request: permissionservice.CreateDelegationReq{}, Status: http.StatusCreated, Response: obj{"message": "", "id": uuid.UUID{}}},
+	"DELETE /api/users/delegations/revoke":     {Summary: "Revoke one of the caller's delegations", Tag: "me", Query: []apiParam{idParam}, Response: message},
+	"GET /api/users/notifications":             {Summary: "Caller's notifications", Tag: "me", ETag: true, Query: []apiParam{{Name: "unread", Description: "true to only list unread ones"}}, Response: obj{"notifications": []notificationservice.NotificationRes{}}},
+	"PUT /api/users/notifications/read":        {Summary: "Mark one or all notifications read", Tag: "me", Query: []apiParam{{Name: "id", Description: "notification id, all when left out"}}, Response: obj{"message": "", "updated": int64(0)}},
+	"GET /api/users/notifications/preferences": {Summary: "Caller's notification preferences", Tag: "me", Response: obj{"preferences": []notificationservice.PreferenceRes{}}},
+	"PUT /api/users/notifications/preferences": {Summary: "Update notification preferences", Tag: "me", Request: notificationservice.UpdatePreferencesReq{}, Response: message},
+	"GET /api/users/notifications/digest":      {Summary: "Whether the caller gets the emails of low priority categories as a daily or weekly digest or one by one", Tag: "me", Response: notificationservice.DigestSettingsRes{}},
+	"PUT /api/users/notifications/digest":      {Summary: "Batch the emails of low priority categories into a daily or weekly digest, or switch digests off", Tag: "me", Request: notificationservice.UpdateDigestSettingsReq{}, Response: message},
+	"POST /api/users/devices":                  {Summary: "Register a device for push notifications", Tag: "me", Request: pushservice.RegisterDeviceReq{}, Response: message},
+	"DELETE /api/users/devices/remove":         {Summary: "Stop push notifications to a device", Tag: "me", Request: pushservice.UnregisterDeviceReq{}, Response: message},
+	"GET /api/users/logins":                    {Summary: "The caller's recent logins with where they came from and why they were flagged", Tag: "me", Query: loginFilterParams, Response: obj{"logins": []loginactivityservice.Login{}, "limit": 0, "offset": 0}},
+	"POST /api/users/logins/confirm":           {Summary: "Confirm a login was the caller's", Tag: "me", Query: []apiParam{idParam}, Response: message},
+	"POST /api/users/logins/deny":              {Summary: "Deny a login, signs the caller out everywhere", Tag: "me", Query: []apiParam{idParam}, Response: message},
+
+	// inventory
+	"POST /api/inventory/asset":                  {Summary: "Add an asset with its configuration", Tag: "inventory", Permission: models.AssetCreatePermission, Request: models.AddAssetWithConfigReq{}, Status: http.StatusCreated, Response: obj{"msg": "", "asset": models.AddAssetWithConfigReq{}}},
+	"POST /api/inventory/asset/assign":           {Summary: "Assign an asset to an employee for good, or as a loan until loan_until", Tag: "inventory", Permission: models.AssetAssignPermission, Request: models.AssetAssignReq{}, Status: http.StatusCreated, Response: obj{"message": "", "user_id": uuid.UUID{}, "asset_id": uuid.UUID{}, "assigned_by": uuid.UUID{}, "mode": "", "loan_until": ""}},
+	"POST /api/inventory/asset/unassign":         {Summary: "Take an asset back from an employee, with an optional inspection that leaves a damaged asset damaged or sends it to service", Tag: "inventory", Permission: models.AssetUnassignPermission, Request: models.AssetReturnReq{}, Response: message},
+	"POST /api/inventory/asset/service/send":     {Summary: "Send an asset for servicing", Tag: "inventory", Permission: models.AssetServicePermission, Request: models.AssetServiceReq{}, Response: message},
+	"POST /api/inventory/asset/service/received": {Summary: "Mark an asset back from servicing", Tag: "inventory", Permission: models.AssetServicePermission, Query: []apiParam{assetParam}, Request: models.ReceiveFromServiceReq{}, Response: obj{"message": "", "asset_id": uuid.UUID{}}},
+	"PUT /api/inventory/asset/update":            {Summary: "Update an asset and its configuration", Tag: "inventory", Permission: models.AssetUpdatePermission, Request: models.UpdateAssetReq{}, Response: message},
+	"GET /api/inventory/assets": {Summary: "List assets", Tag: "inventory", ETag: true, Permission: models.AssetReadPermission, Response: obj{"assets": []models.AssetWithConfigRes{}},
+		Query: append([]apiParam{{Name: "search", Description: "matches brand, model or serial"}, {Name: "status", Description: "comma separated statuses"}, {Name: "owned_by", Description: "comma separated owners"}, {Name: "type", Description: "comma separated asset types"}}, paginationParams...)},
+	"GET /api/inventory/assets/stream": {Summary: "Every asset matching the list filters as newline delimited json, a last line with an error means the download is incomplete", Tag: "inventory", Permission: models.AssetReadPermission, ContentType: "application/x-ndjson",
+		Query: []apiParam{{Name: "search", Description: "matches brand, model or serial"}, {Name: "status", Description: "comma separated statuses"}, {Name: "owned_by", Description: "comma separated owners"}, {Name: "type", Description: "comma separated asset types"}}},
+	"GET /api/inventory/assets/live":    {Summary: "Server-sent events of asset and assignment changes in the caller's department, an event named resync asks to fetch the assets again", Tag: "inventory", Permission: models.AssetReadPermission, ContentType: "text/event-stream"},
+	"GET /api/inventory/asset/warranty": {Summary: "What the vendor answered when the warranty of an asset was looked up as it was added", Tag: "inventory", Permission: models.AssetReadPermission, Query: []apiParam{assetParam}, Response: models.WarrantyLookup{}},
+	"GET /api/inventory/assets/warranty-lookup": {Summary: "Ask the vendor of a brand for the warranty of a serial number, to fill in a new asset's dates", Tag: "inventory", Permission: models.AssetCreatePermission, Response: models.WarrantyInfo{},
+		Query: []apiParam{{Name: "brand", Description: "brand of the asset, dell or lenovo", Required: true}, {Name: "serial_no", Description: "serial number or service tag", Required: true}}},
+	"GET /api/inventory/asset/timeline":              {Summary: "History of an asset", Tag: "inventory", Permission: models.AssetReadPermission, Query: []apiParam{assetParam}, Response: obj{"asset_id": uuid.UUID{}, "timeline": []models.AssetTimelineEvent{}}},
+	"GET /api/inventory/asset/inspections":           {Summary: "Inspections of an asset's returns, newest first", Tag: "inventory", Permission: models.AssetReadPermission, Query: []apiParam{assetParam}, Response: obj{"asset_id": uuid.UUID{}, "inspections": []models.ReturnInspectionRes{}}},
+	"POST /api/inventory/asset/attachments":          {Summary: "Start an attachment upload, the file is PUT to the returned url and then confirmed", Tag: "inventory", Permission: models.AssetUpdatePermission, Request: models.AttachmentUploadReq{}, Status: http.StatusCreated, Response: models.AttachmentUploadRes{}},
+	"POST /api/inventory/asset/attachments/confirm":  {Summary: "Confirm an attachment's file was uploaded", Tag: "inventory", Permission: models.AssetUpdatePermission, Request: models.AttachmentConfirmReq{}, Response: models.Attachment{}},
+	"GET /api/inventory/asset/attachments":           {Summary: "Attachments of an asset with download links", Tag: "inventory", Permission: models.AssetReadPermission, Query: []apiParam{assetParam}, Response: obj{"asset_id": uuid.UUID{}, "attachments": []models.Attachment{}}},
+	"GET /api/inventory/asset/signatures":            {Summary: "Handover and return signatures of an asset with links to the sent and signed documents", Tag: "inventory", Permission: models.AssetReadPermission, Query: []apiParam{assetParam}, Response: obj{"asset_id": uuid.UUID{}, "signatures": []esignservice.HandoverSignature{}}},
+	"POST /api/inventory/asset/signatures/resend":    {Summary: "Send a failed or declined document for signature again", Tag: "inventory", Permission: models.AssetAssignPermission, Query: []apiParam{idParam}, Status: http.StatusAccepted, Response: message},
+	"DELETE /api/inventory/asset/attachments/remove": {Summary: "Delete an attachment and its file", Tag: "inventory", Permission: models.AssetUpdatePermission, Query: []apiParam{idParam}, Response: message},
+	"GET /api/inventory/loans":                       {Summary: "Open loans of assets in scope, due first first", Tag: "inventory", Permission: models.AssetReadPermission, Query: append([]apiParam{{Name: "overdue", Description: "true for loans past their end only"}}, paginationParams...), Response: obj{"loans": []models.LoanRes{}, "limit": 0, "offset": 0}},
+	"GET /api/inventory/waitlist":                    {Summary: "Employees in scope waiting for an asset type, in the order they are served", Tag: "inventory", Permission: models.AssetReadPermission, Query: append([]apiParam{{Name: "type", Description: "only this asset type"}}, paginationParams...), Response: obj{"entries": []waitlistservice.WaitlistEntry{}, "limit": 0, "offset": 0}},
+	"GET /api/inventory/return-requests":             {Summary: "Open and closed asset return requests", Tag: "inventory", ETag: true, Permission: models.AssetReadPermission, Query: []apiParam{{Name: "status", Description: "open or closed"}}, Response: obj{"return_requests": []models.ReturnRequestRes{}}},
+	"GET /api/inventory/mdm/mismatches":              {Summary: "Assets whose device the mdm reports enrolled for someone other than their assignee", Tag: "inventory", Permission: models.AssetReadPermission, Query: paginationParams, Response: obj{"mismatches": []models.MDMMismatch{}}},
+	"GET /api/inventory/assets/utilization": {Summary: "Days assigned, in service and idle over a window per asset type and per asset, least used assets first", Tag: "inventory", Permission: models.AssetReadPermission, Response: models.UtilizationRes{},
+		Query: append([]apiParam{{Name: "from", Description: "first day like 2026-01-01, 90 days before to by default"}, {Name: "to", Description: "last day, included, today by default"}, {Name: "type", Description: "only assets of this type"}}, paginationParams...)},
+	"GET /api/inventory/assets/churn": {Summary: "Assignment duration, reassignments per asset type and department and the most common return reasons of assignments started in a window", Tag: "inventory", Permission: models.AssetReadPermission, Response: models.ChurnRes{},
+		Query: []apiParam{{Name: "from", Description: "first day like 2026-01-01, a year before to by default"}, {Name: "to", Description: "last day, included, today by default"}, {Name: "type", Description: "only assets of this type"}}},
+	"GET /api/inventory/assets/trends": {Summary: "Assets added, assignments, returns and services started and ended per day or week, for charts", Tag: "inventory", Permission: models.AssetReadPermission, Response: models.TrendRes{},
+		Query: []apiParam{{Name: "from", Description: "first day like 2026-01-01, 90 days before to by default"}, {Name: "to", Description: "last day, included, today by default"}, {Name: "interval", Description: "day (default) or week, weeks start on monday"}, {Name: "type", Description: "only assets of this type"}}},
+	"GET /api/inventory/assets/service-report": {Summary: "Days in service, mean time between services and service cost per asset type, with the most serviced assets", Tag: "inventory", Permission: models.AssetReadPermission, Response: models.ServiceReportRes{},
+		Query: []apiParam{{Name: "from", Description: "first day like 2026-01-01, a year before to by default"}, {Name: "to", Description: "last day, included, today by default"}, {Name: "type", Description: "only assets of this type"}, {Name: "top", Description: "how many of the most serviced assets to list, 10 by default and at most 100"}}},
+	"GET /api/inventory/assets/telecom-report": {Summary: "Monthly cost of sims per department, with the plans renewing that month", Tag: "inventory", Permission: models.AssetReadPermission, Response: models.TelecomCostReportRes{},
+		Query: []apiParam{{Name: "month", Description: "month like 2026-01, the current month by default"}}},
+	"GET /api/inventory/changes": {Summary: "Assets created, assigned, returned, serviced and updated across the inventory, oldest first", Tag: "inventory", Permission: models.AssetReadPermission, Response: obj{"changes": []models.InventoryChange{}, "limit": 0, "offset": 0},
+		Query: append([]apiParam{{Name: "type", Description: "only changes of this type: created, assigned, returned, service_started, service_ended or updated"}, {Name: "asset_id", Description: "only changes of this asset"}, {Name: "from", Description: "RFC3339 time, included"}, {Name: "to", Description: "RFC3339 time, excluded"}}, paginationParams...)},
+	"GET /api/inventory/assets/duplicates":  {Summary: "Live assets sharing a serial number, ignoring case, or a mobile's imei", Tag: "inventory", Permission: models.AssetReadPermission, Query: paginationParams, Response: obj{"duplicates": []models.DuplicateGroup{}}},
+	"POST /api/inventory/assets/merge":      {Summary: "Move a duplicate's history to the kept asset and archive the duplicate", Tag: "inventory", Permission: models.AssetMergePermission, Request: models.MergeAssetsReq{}, Response: models.MergeAssetsRes{}},
+	"DELETE /api/inventory/asset/remove":    {Summary: "Delete an unassigned asset", Tag: "inventory", Permission: models.AssetDeletePermission, Query: []apiParam{assetParam}, Response: message},
+	"POST /api/inventory/asset/dispose":     {Summary: "Retire an unassigned asset that was sold or bought back, the price is booked as disposal proceeds", Tag: "inventory", Permission: models.AssetDeletePermission, Request: models.DisposeAssetReq{}, Status: http.StatusCreated, Response: models.AssetDisposal{}},
+	"GET /api/inventory/asset/disposal":     {Summary: "Who a retired asset was sold to and for how much", Tag: "inventory", Permission: models.AssetReadPermission, Query: []apiParam{assetParam}, Response: models.AssetDisposal{}},
+	"POST /api/inventory/assets/import":     {Summary: "Queue an import of assets from a csv, one asset per row with a header row", Tag: "inventory", Permission: models.AssetCreatePermission, RequestContentType: "text/csv", Status: http.StatusAccepted, Response: bulkservice.SubmitRes{}},
+	"POST /api/inventory/asset/assign/bulk": {Summary: "Queue assigning many assets at once", Tag: "inventory", Permission: models.AssetAssignPermission, Request: bulkservice.AssetAssignReq{}, Status: http.StatusAccepted, Response: bulkservice.SubmitRes{}},
+
+	// employees
+	"POST /api/employee/register":     {Summary: "Register an employee", Tag: "employees", Permission: models.UserCreatePermission, Request: userservice.ManagerRegisterReq{}, Status: http.StatusCreated, Response: obj{"user UUID": uuid.UUID{}, "onboarding": (*models.OnboardingRes)(nil)}},
+	"POST /api/employee/invite":       {Summary: "Invite an employee by email", Tag: "employees", Permission: models.UserCreatePermission, Request: userservice.InviteEmployeeReq{}, Status: http.StatusCreated, Response: userservice.InviteRes{}},
+	"PUT /api/employee/update":        {Summary: "Update an employee", Tag: "employees", Permission: models.UserUpdatePermission, Request: userservice.UpdateEmployeeReq{}, Response: obj{"message": "", "employee": userservice.EmployeeRes{}}},
+	"POST /api/employee/change-email": {Summary: "Start changing an employee's email", Tag: "employees", Permission: models.UserUpdatePermission, Request: userservice.ManagerChangeEmailReq{}, Status: http.StatusAccepted, Response: userservice.EmailChangeRes{}},
+	"PUT /api/employee/department":    {Summary: "Move a user to a department", Tag: "employees", Permission: models.DepartmentManagePermission, Request: departmentservice.SetUserDepartmentReq{}, Response: message},
+	"GET /api/employee/employees":     {Summary: "List employees", Tag: "employees", ETag: true, Permission: models.UserReadPermission, Query: employeeFilterParams(), Response: obj{"employees": []userservice.EmployeeResponseModel{}}},
+	"GET /api/employee/employees/export": {Summary: "Export employees as csv or xlsx", Tag: "employees", Permission: models.UserReadPermission, ContentType: "text/csv",
+		Query: append(employeeFilterParams(), apiParam{Name: "format", Description: "csv (default) or xlsx"})},
+	"GET /api/employee/employees/stream": {Summary: "Export employees as newline delimited json, a last line with an error means the download is incomplete", Tag: "employees", Permission: models.UserReadPermission, ContentType: "application/x-ndjson", Query: employeeFilterParams()},
+	"POST /api/employee/employees/export/async": {Summary: "Queue an employee export, the file is downloaded from the job once it completes", Tag: "employees", Permission: models.UserReadPermission, Status: http.StatusAccepted, Response: bulkservice.SubmitRes{},
+		Query: append(employeeFilterParams(), apiParam{Name: "format", Description: "csv (default) or xlsx"})},
+	"GET /api/employee/timeline":     {Summary: "History of an employee", Tag: "employees", Permission: models.UserReadPermission, Query: append([]apiParam{userIDParam}, paginationParams...), Response: obj{"user_id": "", "timeline": []userservice.UserTimelineRes{}, "limit": 0, "offset": 0}},
+	"GET /api/employee/role-history": {Summary: "Role changes of an employee", Tag: "employees", Permission: models.UserReadPermission, Query: []apiParam{userIDParam}, Response: obj{"user_id": "", "history": []userservice.RoleHistoryRes{}}},
+	"GET /api/employee/charges":      {Summary: "Charges of an employee for lost or damaged equipment, with what is still owed", Tag: "employees", Permission: models.UserReadPermission, Query: []apiParam{userIDParam}, Response: chargeservice.EmployeeChargesRes{}},
+	"GET /api/employee/data-export": {Summary: "Download everything stored about a user for a subject access request, the export is audited", Tag: "employees", Permission: models.PrivacyManagePermission, ContentType: "application/json",
+		Query: []apiParam{{Name: "user_id", Required: true, Description: "the user to export"}, {Name: "format", Description: "json (default) or zip with a json file per section"}}},
+	"GET /api/employee/retention-preview": {Summary: "Count what the retention policies would purge if the job ran now", Tag: "employees", Permission: models.PrivacyManagePermission, Response: privacyservice.RetentionReport{}},
+	"POST /api/employee/anonymize":        {Summary: "Scrub the personal data of an archived user and delete their firebase account, assignments stay under the user id", Tag: "employees", Permission: models.PrivacyManagePermission, Query: []apiParam{userIDParam}, Response: message},
+	"DELETE /api/employee/remove":         {Summary: "Delete an employee, holders of a privileged role go through the admin route", Tag: "employees", Permission: models.UserDeletePermission, Query: []apiParam{userIDParam}, Response: message},
+
+	// audit and departments
+	"GET /api/audit-logs": {Summary: "Audit log", Tag: "audit", ETag: true, Permission: models.AuditReadPermission, Response: obj{"audit_logs": []auditservice.AuditLogRes{}},
+		Query: append([]apiParam{{Name: "entity_type"}, {Name: "entity_id"}, {Name: "actor_id"}, {Name: "action"}}, paginationParams...)},
+	"GET /api/audit-logs/changes": {Summary: "Field by field changes of one entity, newest first", Tag: "audit", ETag: true, Permission: models.AuditReadPermission, Response: obj{"entity_type": "", "entity_id": "", "changes": []auditservice.EntityChange{}},
+		Query: append([]apiParam{{Name: "entity_type", Required: true}, {Name: "entity_id", Required: true}, {Name: "actor_id"}, {Name: "action"}}, paginationParams...)},
+	"GET /api/auth-events": {Summary: "Sign in, refresh, mfa and logout events", Tag: "audit", ETag: true, Permission: models.AuditReadPermission, Response: obj{"auth_events": []auditservice.AuthEventRes{}},
+		Query: append([]apiParam{{Name: "user_id"}, {Name: "ip"}, {Name: "event_type"}, {Name: "outcome"}, {Name: "from", Description: "RFC 3339 time"}, {Name: "to", Description: "RFC 3339 time"}}, paginationParams...)},
+	"GET /api/logins": {Summary: "Logins of the users in the caller's departments, flagged=true for the ones to review", Tag: "audit", Permission: models.LoginReviewPermission, Response: obj{"logins": []loginactivityservice.Login{}, "limit": 0, "offset": 0},
+		Query: append([]apiParam{{Name: "user_id"}}, loginFilterParams...)},
+	"GET /api/departments":  {Summary: "List departments", Tag: "departments", ETag: true, Permission: models.DepartmentManagePermission, Response: obj{"departments": []departmentservice.DepartmentRes{}}},
+	"POST /api/departments": {Summary: "Create a department", Tag: "departments", Permission: models.DepartmentManagePermission, Request: departmentservice.CreateDepartmentReq{}, Status: http.StatusCreated, Response: obj{"message": "", "department_id": uuid.UUID{}}},
+
+	// administration
+	"POST /api/admin/employee/change-permissions":            {Summary: "Change a user's role, roles holding role.manage, organization.manage, config.manage or snapshot.restore wait for a second admin", Tag: "admin", Permission: models.RoleManagePermission, Request: userservice.UpdateUserRoleReq{}, Response: obj{"message": "", "status": "", "approval_id": uuid.UUID{}}},
+	"GET /api/admin/employee/role-approvals":                 {Summary: "Role grants waiting for approval", Tag: "admin", Permission: models.RoleManagePermission, Query: append([]apiParam{{Name: "status", Description: "pending, approved or rejected"}}, paginationParams...), Response: obj{"approvals": []userservice.RoleGrantApprovalRes{}, "limit": 0, "offset": 0}},
+	"POST /api/admin/employee/role-approvals/approve":        {Summary: "Approve a role grant, the approver also needs organization.manage", Tag: "admin", Permission: models.RoleManagePermission, Request: userservice.RoleGrantDecisionReq{}, Response: message},
+	"POST /api/admin/employee/role-approvals/reject":         {Summary: "Reject a role grant", Tag: "admin", Permission: models.RoleManagePermission, Request: userservice.RoleGrantDecisionReq{}, Response: message},
+	"POST /api/admin/employee/schedule-role-change":          {Summary: "Schedule a temporary role change", Tag: "admin", Permission: models.RoleManagePermission, Request: userservice.ScheduleRoleChangeReq{}, Status: http.StatusCreated, Response: obj{"message": "", "id": uuid.UUID{}}},
+	"GET /api/admin/employee/scheduled-role-changes":         {Summary: "Pending role changes and the ids to cancel them by", Tag: "admin", Permission: models.RoleManagePermission, Query: append([]apiParam{{Name: "user_id", Description: "only this user's changes"}}, paginationParams...), Response: obj{"changes": []userservice.ScheduledRoleChangeRes{}, "limit": 0, "offset": 0}},
+	"DELETE /api/admin/employee/schedule-role-change/cancel": {Summary: "Cancel a scheduled role change", Tag: "admin", Permission: models.RoleManagePermission, Query: []apiParam{idParam}, Response: message},
+	"POST /api/admin/employee/reset-mfa":                     {Summary: "Reset a user's mfa", Tag: "admin", Permission: models.RoleManagePermission, Request: userservice.ResetMFAReq{}, Response: message},
+	"POST /api/admin/employee/force-logout":                  {Summary: "Revoke all of a user's sessions", Tag: "admin", Permission: models.RoleManagePermission, Request: userservice.ForceLogoutReq{}, Response: message},
+	"POST /api/admin/employee/unlock-login":                  {Summary: "Clear a login lockout", Tag: "admin", Permission: models.RoleManagePermission, Request: userservice.UnlockLoginReq{}, Response: message},
+	"DELETE /api/admin/employee/remove":                      {Summary: "Delete any user including holders of a privileged role", Tag: "admin", Permission: models.RoleManagePermission, Query: []apiParam{userIDParam}, Response: message},
+	"DELETE /api/admin/delegations/revoke":                   {Summary: "Revoke anyone's delegation", Tag: "admin", Permission: models.RoleManagePermission, Query: []apiParam{idParam}, Response: message},
+	"POST /api/admin/directory-sync":                         {Summary: "Run the directory sync now", Tag: "admin", Permission: models.RoleManagePermission, Query: []apiParam{{Name: "dry_run", Description: "true to only report changes"}}, Response: userservice.DirectorySyncReport{}},
+	"GET /api/admin/directory-sync/reports":                  {Summary: "Past directory sync reports", Tag: "admin", Permission: models.RoleManagePermission, Query: paginationParams, Response: obj{"reports": []userservice.DirectorySyncReport{}, "limit": 0, "offset": 0}},
+	"GET /api/admin/permissions":                             {Summary: "Role to permission matrix", Tag: "admin", Permission: models.RoleManagePermission, Response: permissionservice.PermissionMatrixRes{}},
+	"GET /api/admin/roles":                                   {Summary: "List roles", Tag: "admin", ETag: true, Permission: models.RoleManagePermission, Response: obj{"roles": []permissionservice.RoleRes{}}},
+	"POST /api/admin/roles":                                  {Summary: "Create a role", Tag: "admin", Permission: models.OrganizationManagePermission, Request: permissionservice.CreateRoleReq{}, Status: http.StatusCreated, Response: obj{"message": "", "role_id": uuid.UUID{}}},
+	"PUT /api/admin/roles/update":                            {Summary: "Update a role's permissions. Giving a role in use a privileged permission answers 202 and waits for another admin's approval", Tag: "admin", Permission: models.OrganizationManagePermission, Request: permissionservice.UpdateRoleReq{}, Response: obj{"message": "", "status": "", "approval_id": uuid.UUID{}}},
+	"GET /api/admin/roles/approvals":                         {Summary: "Role updates waiting for approval", Tag: "admin", Permission: models.RoleManagePermission, Query: append([]apiParam{{Name: "status", Description: "pending, approved or rejected"}}, paginationParams...), Response: obj{"approvals": []permissionservice.RoleUpdateApprovalRes{}, "limit": 0, "offset": 0}},
+	"POST /api/admin/roles/approvals/approve":                {Summary: "Approve a role update, the approver also needs organization.manage", Tag: "admin", Permission: models.RoleManagePermission, Request: permissionservice.RoleUpdateDecisionReq{}, Response: message},
+	"POST /api/admin/roles/approvals/reject":                 {Summary: "Reject a role update, the requester may withdraw their own", Tag: "admin", Permission: models.RoleManagePermission, Request: permissionservice.RoleUpdateDecisionReq{}, Response: message},
+	"DELETE /api/admin/roles/remove":                         {Summary: "Delete a custom role", Tag: "admin", Permission: models.OrganizationManagePermission, Query: []apiParam{{Name: "name", Description: "role name", Required: true}}, Response: message},
+	"POST /api/admin/policies/reload":                        {Summary: "Reload authorization policies", Tag: "admin", Permission: models.OrganizationManagePermission, Response: models.PolicySummary{}},
+	"GET /api/admin/trash/{kind}": {Summary: "Archived assets, users, roles or assignments with who archived them and when, newest first", Tag: "admin", Permission: models.RoleManagePermission, Response: trashservice.TrashRes{},
+		Query: append([]apiParam{{Name: "search", Description: "matches the name or details"}, {Name: "archived_by", Description: "user id of who archived them"}, {Name: "from", Description: "RFC 3339 time, archived at or after"}, {Name: "to", Description: "RFC 3339 time, archived before"}}, paginationParams...)},
+	"POST /api/admin/integrity-check":        {Summary: "Look for orphaned configs, open assignments of archived users or assets and users missing a role or type", Tag: "admin", Permission: models.RoleManagePermission, Query: []apiParam{{Name: "repair", Description: "true to also fix the issues safe to fix"}}, Response: integrityservice.IntegrityReport{}},
+	"GET /api/admin/integrity-check/reports": {Summary: "Past integrity check reports, scheduled and manual", Tag: "admin", Permission: models.RoleManagePermission, Query: paginationParams, Response: obj{"reports": []integrityservice.IntegrityReport{}, "limit": 0, "offset": 0}},
+	"POST /api/admin/snapshots":              {Summary: "Queue a zip of every organization, department, user, role, asset, assignment, service and audit record read at one moment, downloaded as the job result. Secrets and sessions are left out", Tag: "admin", Permission: models.SnapshotExportPermission, Status: http.StatusAccepted, Response: bulkservice.SubmitRes{}},
+	"POST /api/admin/snapshots/uploads":      {Summary: "Start the upload of an archive to restore, the zip is PUT to the returned url. Needs SNAPSHOT_RESTORE_ENABLED and object storage", Tag: "admin", Permission: models.SnapshotRestorePermission, Status: http.StatusCreated, Response: snapshotservice.UploadRes{}},
+	"POST /api/admin/snapshots/restore":      {Summary: "Check an uploaded archive against its manifest and the schema version and, unless dry_run, replace the whole database with it. The dry run lists the cascaded_tables outside the archive the restore empties, each has to be named in discard and confirm must repeat the snapshot id", Tag: "admin", Permission: models.SnapshotRestorePermission, Request: snapshotservice.RestoreReq{}, Response: snapshotservice.RestoreRes{}},
+
+	// onboarding, api keys and service accounts
+	"GET /api/onboarding-templates":            {Summary: "List onboarding templates", Tag: "onboarding", ETag: true, Permission: models.UserCreatePermission, Response: obj{"templates": []models.OnboardingTemplate{}}},
+	"POST /api/onboarding-templates":           {Summary: "Create an onboarding template", Tag: "onboarding", Permission: models.OnboardingManagePermission, Request: onboardingservice.CreateTemplateReq{}, Status: http.StatusCreated, Response: obj{"message": "", "template_id": uuid.UUID{}}},
+	"DELETE /api/onboarding-templates/remove":  {Summary: "Delete an onboarding template", Tag: "onboarding", Permission: models.OnboardingManagePermission, Query: []apiParam{idParam}, Response: message},
+	"GET /api/api-keys":                        {Summary: "List api keys", Tag: "api keys", ETag: true, Permission: models.APIKeyManagePermission, Response: obj{"api_keys": []apikeyservice.APIKeyRes{}}},
+	"POST /api/api-keys":                       {Summary: "Create an api key, the key is only shown once", Tag: "api keys", Permission: models.APIKeyManagePermission, Request: apikeyservice.CreateAPIKeyReq{}, Status: http.StatusCreated, Response: obj{"message": "", "api_key": apikeyservice.CreateAPIKeyRes{}}},
+	"DELETE /api/api-keys/revoke":              {Summary: "Revoke an api key", Tag: "api keys", Permission: models.APIKeyManagePermission, Query: []apiParam{idParam}, Response: message},
+	"PUT /api/api-keys/limits":                 {Summary: "Set the rate limit and monthly quota of an api key", Tag: "api keys", Permission: models.APIKeyManagePermission, Query: []apiParam{idParam}, Request: apikeyservice.UpdateAPIKeyLimitsReq{}, Response: message},
+	"GET /api/api-keys/usage":                  {Summary: "Requests, throttled and over quota requests per api key for a month", Tag: "api keys", Permission: models.APIKeyManagePermission, Query: []apiParam{{Name: "month", Description: "month as 2006-01 in UTC, defaults to the current month"}}, Response: obj{"usage": []apikeyservice.APIKeyUsage{}}},
+	"POST /api/kiosk/checkout":                 {Summary: "Check a pool asset out to the employee whose badge was scanned, api keys only", Tag: "kiosk", Permission: models.KioskOperatePermission, Request: models.KioskScanReq{}, Response: models.KioskRes{}},
+	"POST /api/kiosk/return":                   {Summary: "Return a pool asset the scanned employee holds, api keys only", Tag: "kiosk", Permission: models.KioskOperatePermission, Request: models.KioskScanReq{}, Response: models.KioskRes{}},
+	"GET /api/service-accounts":                {Summary: "List service accounts", Tag: "service accounts", ETag: true, Permission: models.ServiceAccountManagePermission, Response: obj{"service_accounts": []serviceaccountservice.ServiceAccountRes{}}},
+	"POST /api/service-accounts":               {Summary: "Create a service account, the secret is only shown once", Tag: "service accounts", Permission: models.ServiceAccountManagePermission, Request: serviceaccountservice.CreateServiceAccountReq{}, Status: http.StatusCreated, Response: obj{"message": "", "service_account": serviceaccountservice.ServiceAccountSecretRes{}}},
+	"PUT /api/service-accounts/roles":          {Summary: "Replace a service account's roles", Tag: "service accounts", Permission: models.ServiceAccountManagePermission, Query: []apiParam{idParam}, Request: serviceaccountservice.UpdateServiceAccountRolesReq{}, Response: message},
+	"POST /api/service-accounts/rotate-secret": {Summary: "Rotate a service account's secret", Tag: "service accounts", Permission: models.ServiceAccountManagePermission, Query: []apiParam{idParam}, Response: obj{"message": "", "service_account": serviceaccountservice.ServiceAccountSecretRes{}}},
+	"DELETE /api/service-accounts/disable":     {Summary: "Disable a service account", Tag: "service accounts", Permission: models.ServiceAccountManagePermission, Query: []apiParam{idParam}, Response: message},
+
+	// outbound webhooks
+	"GET /api/webhooks":                       {Summary: "List webhook endpoints and the events they can subscribe to", Tag: "webhooks", ETag: true, Permission: models.WebhookManagePermission, Response: obj{"endpoints": []webhookservice.EndpointRes{}, "events": []string{}}},
+	"POST /api/webhooks":                      {Summary: "Register a webhook endpoint, the signing secret is only shown once", Tag: "webhooks", Permission: models.WebhookManagePermission, Request: webhookservice.CreateEndpointReq{}, Status: http.StatusCreated, Response: obj{"message": "", "endpoint": webhookservice.CreateEndpointRes{}}},
+	"DELETE /api/webhooks/remove":             {Summary: "Delete a webhook endpoint and drop its pending deliveries", Tag: "webhooks", Permission: models.WebhookManagePermission, Query: []apiParam{idParam}, Response: message},
+	"POST /api/webhooks/rotate-secret":        {Summary: "Give an endpoint a new signing secret, shown once. Deliveries carry a signature with the old one too for a day", Tag: "webhooks", Permission: models.WebhookManagePermission, Query: []apiParam{idParam}, Response: obj{"message": "", "rotation": webhookservice.RotateSecretRes{}}},
+	"GET /api/webhooks/deliveries":            {Summary: "List webhook deliveries, status=dead lists the dead letters", Tag: "webhooks", ETag: true, Permission: models.WebhookManagePermission, Query: append([]apiParam{{Name: "endpoint_id"}, {Name: "status", Description: "pending, delivered or dead"}, {Name: "event_type"}}, paginationParams...), Response: obj{"deliveries": []webhookservice.DeliveryRes{}}},
+	"POST /api/webhooks/deliveries/redeliver": {Summary: "Queue a dead-lettered delivery again", Tag: "webhooks", Permission: models.WebhookManagePermission, Query: []apiParam{idParam}, Response: message},
+
+	// procurement
+	"GET /api/procurement/orders":            {Summary: "List purchase orders of the caller's department newest first", Tag: "procurement", Permission: models.ProcurementManagePermission, Query: append([]apiParam{{Name: "status", Description: "pending_approval, approved, rejected, partially_received or received"}}, paginationParams...), Response: obj{"orders": []procurementservice.Order{}}},
+	"POST /api/procurement/orders":           {Summary: "Raise a purchase order, it waits for the approval of someone else", Tag: "procurement", Permission: models.ProcurementManagePermission, Request: procurementservice.CreateOrderReq{}, Status: http.StatusCreated, Response: obj{"message": "", "order": procurementservice.Order{}}},
+	"POST /api/procurement/orders/approve":   {Summary: "Approve a purchase order, it is pushed to the e-procurement system when one is configured", Tag: "procurement", Permission: models.ProcurementApprovePermission, Query: []apiParam{idParam}, Request: procurementservice.DecideOrderReq{}, Response: message},
+	"POST /api/procurement/orders/reject":    {Summary: "Reject a purchase order", Tag: "procurement", Permission: models.ProcurementApprovePermission, Query: []apiParam{idParam}, Request: procurementservice.DecideOrderReq{}, Response: message},
+	"POST /api/procurement/orders/receive":   {Summary: "Receive units of an approved purchase order, each serial number becomes an asset pre-filled from its line", Tag: "procurement", Permission: models.ProcurementManagePermission, Request: procurementservice.ReceiveOrderReq{}, Response: procurementservice.ReceiveOrderRes{}},
+	"GET /api/procurement/requests":          {Summary: "List purchase requests of the caller's department newest first, for callers with procurement.manage or procurement.approve", Tag: "procurement", Query: append([]apiParam{{Name: "status", Description: "pending_review, pending_finance, approved, rejected or ordered"}}, paginationParams...), Response: obj{"requests": []procurementservice.PurchaseRequest{}}},
+	"POST /api/procurement/requests/approve": {Summary: "Approve the stage a purchase request is at, the review needs procurement.manage and finance's approval procurement.approve", Tag: "procurement", Query: []apiParam{idParam}, Request: procurementservice.DecideOrderReq{}, Response: obj{"request": procurementservice.PurchaseRequest{}}},
+	"POST /api/procurement/requests/reject":  {Summary: "Reject a purchase request at its review or at finance's approval", Tag: "procurement", Query: []apiParam{idParam}, Request: procurementservice.DecideOrderReq{}, Response: obj{"request": procurementservice.PurchaseRequest{}}},
+	"POST /api/procurement/requests/convert": {Summary: "Raise the purchase order of an approved purchase request, the order waits for its own approval", Tag: "procurement", Permission: models.ProcurementManagePermission, Request: procurementservice.ConvertRequestReq{}, Status: http.StatusCreated, Response: obj{"message": "", "order": procurementservice.Order{}}},
+
+	// charges
+	"GET /api/charges":         {Summary: "List charges for lost or damaged equipment of employees in scope, newest first", Tag: "charges", Permission: models.ChargeManagePermission, Query: append([]apiParam{{Name: "user_id", Description: "only charges of this employee"}, {Name: "settled", Description: "true or false, both by default"}}, paginationParams...), Response: obj{"charges": []chargeservice.Charge{}, "limit": 0, "offset": 0}},
+	"POST /api/charges":        {Summary: "Charge an employee for lost or damaged equipment, optionally linked to one of their assignments", Tag: "charges", Permission: models.ChargeManagePermission, Request: chargeservice.CreateChargeReq{}, Status: http.StatusCreated, Response: chargeservice.Charge{}},
+	"POST /api/charges/settle": {Summary: "Mark a charge settled", Tag: "charges", Permission: models.ChargeManagePermission, Request: chargeservice.SettleChargeReq{}, Response: chargeservice.Charge{}},
+
+	// department budgets
+	"GET /api/budgets": {Summary: "List department budgets in scope with how much was spent on buying and servicing assets", Tag: "budgets", Permission: models.BudgetReadPermission, Response: obj{"budgets": []budgetservice.Budget{}, "limit": 0, "offset": 0},
+		Query: append([]apiParam{{Name: "department_id", Description: "only budgets of this department"}, {Name: "on", Description: "YYYY-MM-DD, only budgets whose period covers that day"}}, paginationParams...)},
+	"POST /api/budgets":          {Summary: "Set a department's budget for a period, periods of a department can't overlap", Tag: "budgets", Permission: models.BudgetManagePermission, Request: budgetservice.CreateBudgetReq{}, Status: http.StatusCreated, Response: budgetservice.Budget{}},
+	"DELETE /api/budgets/remove": {Summary: "Remove a department budget", Tag: "budgets", Permission: models.BudgetManagePermission, Query: []apiParam{idParam}, Response: message},
+
+	// projects
+	"GET /api/projects": {Summary: "List the projects of departments in scope, newest first", Tag: "projects", Permission: models.ProjectReadPermission, Response: obj{"projects": []projectservice.Project{}, "limit": 0, "offset": 0},
+		Query: append([]apiParam{{Name: "search", Description: "part of the name or client"}, {Name: "closed", Description: "true to list closed projects too"}}, paginationParams...)},
+	"POST /api/projects":       {Summary: "Open a client project for a department", Tag: "projects", Permission: models.ProjectManagePermission, Request: projectservice.CreateProjectReq{}, Status: http.StatusCreated, Response: projectservice.Project{}},
+	"POST /api/projects/close": {Summary: "Close a project once its assets are released", Tag: "projects", Permission: models.ProjectManagePermission, Query: []apiParam{idParam}, Response: message},
+	"GET /api/projects/assets": {Summary: "Assets a project held or holds, by start", Tag: "projects", Permission: models.ProjectReadPermission, Response: obj{"project_id": uuid.UUID{}, "allocations": []projectservice.Allocation{}},
+		Query: []apiParam{idParam, {Name: "active", Description: "true for the assets it holds now only"}}},
+	"POST /api/projects/assets":         {Summary: "Lend an asset to a project, with or without an employee holding it", Tag: "projects", Permission: models.ProjectManagePermission, Request: projectservice.AllocateAssetReq{}, Status: http.StatusCreated, Response: projectservice.Allocation{}},
+	"POST /api/projects/assets/release": {Summary: "Bring an asset back from a project", Tag: "projects", Permission: models.ProjectManagePermission, Request: projectservice.ReleaseAssetReq{}, Response: projectservice.Allocation{}},
+	"GET /api/projects/billing": {Summary: "Bill a project's client for the days its rated assets were lent in the window", Tag: "projects", Permission: models.ProjectReadPermission, Response: projectservice.ProjectBillingRes{},
+		Query: []apiParam{idParam, {Name: "from", Description: "YYYY-MM-DD, the first of this month by default"}, {Name: "to", Description: "YYYY-MM-DD, today by default"}}},
+
+	// broadcasts
+	"GET /api/broadcasts": {Summary: "Announcements sent in the caller's organization, latest first", Tag: "broadcasts", Permission: models.NotificationBroadcastPermission, Query: paginationParams,
+		Response: obj{"broadcasts": []notificationservice.Broadcast{}, "limit": 0, "offset": 0}},
+	"POST /api/broadcasts": {Summary: "Send an announcement in-app, and by email when asked, to the users holding the roles in the departments given, everyone when both are empty. 422 when nobody matches", Tag: "broadcasts", Permission: models.NotificationBroadcastPermission,
+		Request: notificationservice.BroadcastReq{}, Response: obj{"message": "", "broadcast": notificationservice.Broadcast{}}},
+
+	// escalations
+	"GET /api/escalations": {Summary: "Overdue returns and pending approvals escalated to managers and admins in scope, latest first", Tag: "escalations", Permission: models.EscalationReadPermission, Response: obj{"escalations": []escalationservice.Escalation{}, "limit": 0, "offset": 0},
+		Query: append([]apiParam{{Name: "kind", Description: "overdue_return or pending_approval"}, {Name: "resolved", Description: "true to list resolved escalations too"}}, paginationParams...)},
+
+	// google sheets sync
+	"GET /api/sheet-syncs":           {Summary: "List the sheet syncs of the caller's department and the account to share spreadsheets with", Tag: "sheets", Permission: models.SheetSyncManagePermission, Response: obj{"syncs": []sheetsservice.SheetSync{}, "share_with": ""}},
+	"POST /api/sheet-syncs":          {Summary: "Keep a google sheet tab in sync with an asset filter, the tab is rewritten on the next run", Tag: "sheets", Permission: models.SheetSyncManagePermission, Request: sheetsservice.CreateSheetSyncReq{}, Status: http.StatusCreated, Response: obj{"message": "", "sync": sheetsservice.SheetSync{}}},
+	"POST /api/sheet-syncs/resync":   {Summary: "Rewrite the whole sheet on the next run", Tag: "sheets", Permission: models.SheetSyncManagePermission, Query: []apiParam{idParam}, Status: http.StatusAccepted, Response: message},
+	"DELETE /api/sheet-syncs/remove": {Summary: "Stop syncing a sheet, it keeps its rows", Tag: "sheets", Permission: models.SheetSyncManagePermission, Query: []apiParam{idParam}, Response: message},
+
+	// scheduled reports
+	"GET /api/imei-blacklist":              {Summary: "List the imeis of stolen devices, newest first", Tag: "inventory", Permission: models.IMEIBlacklistManagePermission, Query: append([]apiParam{{Name: "search", Description: "start of an imei"}}, paginationParams...), Response: obj{"blacklist": []models.BlacklistedIMEI{}}},
+	"POST /api/imei-blacklist":             {Summary: "Blacklist the imei of a stolen device", Tag: "inventory", Permission: models.IMEIBlacklistManagePermission, Request: models.BlacklistIMEIReq{}, Status: http.StatusCreated, Response: message},
+	"DELETE /api/imei-blacklist/remove":    {Summary: "Take an imei off the blacklist", Tag: "inventory", Permission: models.IMEIBlacklistManagePermission, Query: []apiParam{{Name: "imei", Description: "the imei to remove"}}, Response: message},
+	"GET /api/reports/schedules":           {Summary: "List the report schedules", Tag: "reports", Permission: models.ReportManagePermission, Response: obj{"schedules": []reportservice.ReportSchedule{}}},
+	"POST /api/reports/schedules":          {Summary: "Email a weekly inventory summary, overdue returns or assets long in service on a cron schedule in UTC", Tag: "reports", Permission: models.ReportManagePermission, Request: reportservice.ReportScheduleReq{}, Status: http.StatusCreated, Response: obj{"message": "", "schedule": reportservice.ReportSchedule{}}},
+	"PUT /api/reports/schedules":           {Summary: "Replace a report schedule, its next run is counted from now", Tag: "reports", Permission: models.ReportManagePermission, Query: []apiParam{idParam}, Request: reportservice.ReportScheduleReq{}, Response: obj{"message": "", "schedule": reportservice.ReportSchedule{}}},
+	"DELETE /api/reports/schedules/remove": {Summary: "Stop sending a scheduled report", Tag: "reports", Permission: models.ReportManagePermission, Query: []apiParam{idParam}, Response: message},
+
+	// runtime config
+	"POST /api/config/reload": {Summary: "Reload the log level, rate limits, cache ttls and allowed email domains from the config file on this instance", Tag: "admin", Permission: models.ConfigManagePermission, Response: models.ConfigReloadRes{}},
+
+	// accounting
+	"POST /api/accounting/exports": {Summary: "Queue the fixed asset journal of acquisitions, depreciation and disposals as a quickbooks or netsuite journal import csv", Tag: "admin", Permission: models.AccountingExportPermission, Status: http.StatusAccepted, Response: bulkservice.SubmitRes{},
+		Query: []apiParam{{Name: "format", Description: "quickbooks (default) or netsuite"}, {Name: "from", Description: "first month like 2026-07, the previous month by default"}, {Name: "to", Description: "last month like 2026-09, from by default"}}},
+
+	// schema
+	"GET /api/schema/migrations": {Summary: "Schema version, whether a migration failed halfway and the migrations not applied yet", Tag: "admin", Permission: models.SchemaReadPermission, Response: models.MigrationStatus{}},
+
+	// background jobs
+	"GET /api/jobs":             {Summary: "List background jobs with their schedule, next run and latest runs", Tag: "jobs", Permission: models.JobManagePermission, Response: obj{"jobs": []schedulerservice.JobRes{}}},
+	"POST /api/jobs/run":        {Summary: "Start a run of a job on the instance serving the request", Tag: "jobs", Permission: models.JobManagePermission, Query: []apiParam{{Name: "name", Description: "job name", Required: true}}, Status: http.StatusAccepted, Response: message},
+	"GET /api/jobs/runs":        {Summary: "Job run history of every instance, newest first", Tag: "jobs", ETag: true, Permission: models.JobManagePermission, Query: append([]apiParam{{Name: "job"}, {Name: "status", Description: "succeeded or failed"}}, paginationParams...), Response: obj{"runs": []models.JobRun{}}},
+	"GET /api/jobs/{id}":        {Summary: "Status, progress and item errors of a bulk job, visible to its creator and job managers", Tag: "jobs", Response: bulkservice.BulkJobRes{}},
+	"GET /api/jobs/{id}/result": {Summary: "Download the export or the per item outcomes of a completed bulk job, results kept in object storage redirect to a presigned link", Tag: "jobs", ContentType: "application/octet-stream"},
+
+	// graphql, the route also requires asset.read
+	"POST /api/graphql":       {Summary: "Run a graphql query over employees, assets, assignments and timelines", Tag: "graphql", Permission: models.UserReadPermission, Request: graphqlservice.GraphQLRequest{}, Response: graphqlservice.GraphQLResponse{}},
+	"GET /api/graphql/schema": {Summary: "Graphql schema in SDL", Tag: "graphql", Permission: models.UserReadPermission, ContentType: "text/plain"},
+}
+
+func employeeFilterParams() []apiParam {
+	return append([]apiParam{
+		{Name: "search", Description: "matches name, email or contact"},
+		{Name: "type", Description: "comma separated employee types"},
+		{Name: "role", Description: "comma separated roles"},
+		{Name: "asset_status", Description: "comma separated asset statuses"},
+		{Name: "designation"},
+		{Name: "location"},
+	}, paginationParams...)
+}
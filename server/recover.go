@@ -0,0 +1,62 @@
+package server
+
+import (
+	"asset/providers"
+	"asset/providers/middlewareprovider"
+	"asset/utils"
+	"expvar"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.uber.org/zap"
+)
+
+// panicsRecovered counts handler panics, read it from /debug/vars
+var panicsRecovered = expvar.NewInt("http_panics_recovered")
+
+// recoverPanics turns a panicking handler into a 500 with the usual error body instead of a dropped
+// connection, and logs and reports the stack with the request it happened on
+func recoverPanics(logger *zap.Logger, reporter providers.ErrorReporter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			r, userID := middlewareprovider.TrackRequestUser(r)
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				// the server aborts the response on purpose with this one, let it through
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+				panicsRecovered.Add(1)
+				stack := debug.Stack()
+				err := fmt.Errorf("panic: %v", recovered)
+				if reporter != nil {
+					report := requestErrorReport(r, userID())
+					report.Err = err
+					report.Status = http.StatusInternalServerError
+					report.Stack = stack
+					reporter.Report(report)
+				}
+				logger.Error("handler panicked",
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+					zap.String("request_id", middleware.GetReqID(r.Context())),
+					zap.String("remote_ip", r.RemoteAddr),
+					zap.Any("panic", recovered),
+					zap.ByteString("stack", stack),
+				)
+				// once the status went out the client already has a partial reply, nothing can fix it
+				if ww.Status() != 0 {
+					return
+				}
+				utils.RespondError(ww, http.StatusInternalServerError, err, "internal server error")
+			}()
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
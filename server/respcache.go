@@ -0,0 +1,95 @@
+package server
+
+import (
+	"asset/models"
+	cacheprovider "asset/providers/cacheProvider"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// cachedResponse is what the cache keeps of a response, the headers are only those the route set
+// itself, ones set before it like the request id belong to the request that stored it
+type cachedResponse struct {
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+// withResponseCache serves successful GET responses of the route from redis while they are fresh
+// and lets clients keep them for as long, dashboards polling them then skip the database. Entries
+// are keyed by the caller's organization and the query, the mutations changing what the route shows
+// drop them through the ResponseCacheProvider. Only put it on json routes whose body depends on
+// nothing else about the caller. A platform admin outside an organization's subdomain has no
+// organization in scope, routes like the directory fall back to the admin's own one, so those
+// requests always reach the route
+func (srv *Server) withResponseCache(route string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			policy := srv.Config.GetCacheTTLs()
+			if r.Method != http.MethodGet || !policy.Enabled(models.CacheResponses) || policy.Responses <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			scope, err := srv.Middleware.GetDepartmentScope(r)
+			if err != nil || scope.OrganizationID == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key := srv.ResponseCache.Key(r.Context(), route, scope.OrganizationID.String()+"?"+r.URL.Query().Encode())
+			cacheControl := fmt.Sprintf("private, max-age=%d", int(policy.Responses.Seconds()))
+
+			start := time.Now()
+			var entry cachedResponse
+			cached, err := srv.Redis.Get(r.Context(), key)
+			if err != nil && !errors.Is(err, redis.Nil) {
+				srv.Logger.GetLogger().Warn("failed to read cached response", zap.String("route", route), zap.Error(err))
+			}
+			// an entry that doesn't decode is served again from the route and overwritten
+			hit := err == nil && cached != "" && json.Unmarshal([]byte(cached), &entry) == nil
+			cacheprovider.RecordRead(models.CacheResponses, hit, time.Since(start))
+			if hit {
+				w.Header().Set("Content-Type", "application/json")
+				for name, values := range entry.Header {
+					w.Header()[name] = values
+				}
+				w.Header().Set("Cache-Control", cacheControl)
+				w.Header().Set("X-Cache", "HIT")
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(entry.Body))
+				return
+			}
+
+			before := w.Header().Clone()
+			res := &etagResponse{ResponseWriter: w}
+			next.ServeHTTP(res, r)
+			if res.status == 0 {
+				res.status = http.StatusOK
+			}
+			if res.status == http.StatusOK {
+				entry := cachedResponse{Header: http.Header{}, Body: res.body.String()}
+				for name, values := range w.Header() {
+					if !slices.Equal(before[name], values) {
+						entry.Header[name] = values
+					}
+				}
+				data, err := json.Marshal(entry)
+				if err == nil {
+					err = srv.Redis.Set(r.Context(), key, string(data), policy.Responses)
+				}
+				if err != nil {
+					srv.Logger.GetLogger().Warn("failed to cache response", zap.String("route", route), zap.Error(err))
+				}
+				w.Header().Set("Cache-Control", cacheControl)
+				w.Header().Set("X-Cache", "MISS")
+			}
+			w.WriteHeader(res.status)
+			w.Write(res.body.Bytes())
+		})
+	}
+}
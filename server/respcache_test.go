@@ -0,0 +1,146 @@
+package server
+
+import (
+	"asset/models"
+	"asset/providers"
+	cacheprovider "asset/providers/cacheProvider"
+	redisprovider "asset/providers/redisProvider"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestResponseCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+	config := providers.NewMockConfigProvider(ctrl)
+	policy := models.CacheTTLs{Responses: time.Minute}
+	config.EXPECT().GetCacheTTLs().DoAndReturn(func() models.CacheTTLs { return policy }).AnyTimes()
+
+	orgA, orgB := uuid.New(), uuid.New()
+	auth := providers.NewMockAuthMiddlewareService(ctrl)
+	// the caller's organization comes in the X-Org header of the test requests, none is a platform admin
+	auth.EXPECT().GetDepartmentScope(gomock.Any()).DoAndReturn(func(r *http.Request) (models.DepartmentScope, error) {
+		scope := models.DepartmentScope{AllDepartments: true}
+		if org := r.Header.Get("X-Org"); org != "" {
+			id := uuid.MustParse(org)
+			scope.OrganizationID = &id
+		}
+		return scope, nil
+	}).AnyTimes()
+
+	redis := redisprovider.NewMemoryRedisProvider()
+	srv := &Server{
+		Config:        config,
+		Middleware:    auth,
+		Logger:        logger,
+		Redis:         redis,
+		ResponseCache: cacheprovider.NewResponseCacheProvider(redis, logger),
+	}
+
+	calls := 0
+	status := http.StatusOK
+	route := srv.withResponseCache(cacheprovider.ResponseDirectory)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("ETag", `W/"v1"`)
+		w.WriteHeader(status)
+		w.Write([]byte(`{"employees":[]}`))
+	}))
+	// the request id stands for what middleware before the cache sets, it belongs to one request
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-ID", r.Header.Get("X-Request-ID"))
+		route.ServeHTTP(w, r)
+	})
+	get := func(org uuid.UUID, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/directory"+query, nil)
+		if org != uuid.Nil {
+			req.Header.Set("X-Org", org.String())
+		}
+		req.Header.Set("X-Request-ID", uuid.NewString())
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, req.Header.Get("X-Request-ID"), rec.Header().Get("X-Request-ID"))
+		return rec
+	}
+
+	t.Run("miss then hit", func(t *testing.T) {
+		rec := get(orgA, "?search=ana")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+		assert.Equal(t, "private, max-age=60", rec.Header().Get("Cache-Control"))
+		assert.Equal(t, 1, calls)
+
+		rec = get(orgA, "?search=ana")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "HIT", rec.Header().Get("X-Cache"))
+		assert.Equal(t, `{"employees":[]}`, rec.Body.String())
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("headers of the route are replayed", func(t *testing.T) {
+		rec := get(orgA, "?search=ana")
+		assert.Equal(t, "HIT", rec.Header().Get("X-Cache"))
+		assert.Equal(t, `W/"v1"`, rec.Header().Get("ETag"))
+		assert.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+	})
+
+	t.Run("variants", func(t *testing.T) {
+		calls = 0
+		assert.Equal(t, "MISS", get(orgA, "?search=bob").Header().Get("X-Cache"))
+		assert.Equal(t, "MISS", get(orgB, "?search=ana").Header().Get("X-Cache"))
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("invalidation", func(t *testing.T) {
+		srv.ResponseCache.Invalidate(context.Background(), cacheprovider.ResponseDirectory)
+		calls = 0
+		assert.Equal(t, "MISS", get(orgA, "?search=ana").Header().Get("X-Cache"))
+		assert.Equal(t, "HIT", get(orgA, "?search=ana").Header().Get("X-Cache"))
+		assert.Equal(t, 1, calls)
+
+		// other routes keep theirs
+		srv.ResponseCache.Invalidate(context.Background(), cacheprovider.ResponseEnums)
+		assert.Equal(t, "HIT", get(orgA, "?search=ana").Header().Get("X-Cache"))
+	})
+
+	t.Run("platform admin outside an organization", func(t *testing.T) {
+		calls = 0
+		for i := 0; i < 2; i++ {
+			rec := get(uuid.Nil, "?search=ana")
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Empty(t, rec.Header().Get("X-Cache"))
+		}
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		status = http.StatusInternalServerError
+		defer func() { status = http.StatusOK }()
+		calls = 0
+		for i := 0; i < 2; i++ {
+			rec := get(orgA, "?search=carol")
+			assert.Equal(t, http.StatusInternalServerError, rec.Code)
+			assert.Empty(t, rec.Header().Get("X-Cache"))
+		}
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		policy.Disabled = policy.Disabled.With(models.CacheResponses)
+		defer func() { policy.Disabled = 0 }()
+		calls = 0
+		assert.Empty(t, get(orgA, "?search=ana").Header().Get("X-Cache"))
+		assert.Equal(t, 1, calls)
+	})
+}
@@ -2,6 +2,8 @@ package server
 
 import (
 	"asset/models"
+	cacheprovider "asset/providers/cacheProvider"
+	"asset/utils"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"net/http"
@@ -9,73 +11,441 @@ import (
 
 func (srv *Server) InjectRoutes() *chi.Mux {
 	r := chi.NewRouter()
+	docs := &apiDocs{router: r}
 
-	r.Use(middleware.Logger)
-	r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte("connection established..."))
+	if srv.Config.TrustProxyHeaders() {
+		r.Use(middleware.RealIP)
+	}
+	r.Use(middleware.RequestID)
+	r.Use(echoRequestID)
+	r.Use(accessLog(srv.Logger.GetLogger(), srv.Config.GetAccessLogConfig()))
+	r.Use(recoverPanics(srv.Logger.GetLogger(), srv.ErrorReporter))
+	r.Use(reportServerErrors(srv.ErrorReporter))
+	r.Use(limitRequests(srv.Config.GetRequestLimits()))
+
+	// probes skip the ip limit, a throttled probe would get a healthy instance restarted
+	r.Get("/healthz", srv.Healthz)
+	r.Get("/readyz", srv.Readyz)
+
+	r.Group(func(r chi.Router) {
+		r.Use(srv.RateLimiter.LimitByIP("ip", func() models.RateLimit { return srv.Config.GetRateLimits().IP }))
+		r.Get("/test", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("connection established..."))
+		})
+		r.Get("/.well-known/jwks.json", srv.UserHandler.JWKS)
+		// the local storage backend's presigned links, they carry their own signature
+		if storage, ok := srv.Storage.(http.Handler); ok {
+			r.Handle("/storage/*", storage)
+		}
+
+		// profiles and runtime metrics for admins signed in as themselves, DEBUG_ADDR serves the
+		// same routes without auth on a private port
+		r.Route("/debug", func(debug chi.Router) {
+			debug.Use(srv.Middleware.JWTAuthMiddleware())
+			debug.Use(srv.Middleware.RequireUserSession())
+			debug.Use(srv.Middleware.RequirePermission(models.DebugReadPermission))
+			debugRoutes(debug)
+		})
+
+		r.Route("/api", func(api chi.Router) {
+			// the spec is built from this router, describe new routes in apiOperations
+			api.Get("/docs", docs.ServeUI)
+			api.Get("/docs/openapi.json", docs.ServeSpec)
+
+			for _, version := range apiVersions {
+				api.Route("/"+version.name, func(versioned chi.Router) {
+					versioned.Use(versionHeaders(version))
+					srv.apiRoutes(versioned, version)
+				})
+			}
+			// the unversioned paths predate /api/v1 and serve it until the sunset date
+			api.Group(func(legacy chi.Router) {
+				legacy.Use(versionHeaders(legacyAPIVersion(srv.Config.GetLegacyAPISunset())))
+				srv.apiRoutes(legacy, apiV1)
+			})
+		})
 	})
 
-	//public routes
-	r.Route("/api", func(api chi.Router) {
-		api.Post("/user/register", srv.UserHandler.PublicRegister)
-		api.Post("/v2/user/register", srv.UserHandler.PublicRegisterThroughFirebase)
-		api.Post("/user/login", srv.UserHandler.UserLogin)
-		api.Post("/v2/user/login", srv.UserHandler.GoogleAuth)
-		//api.Post("/createadmin", srv.UserHandler.CreateAdmin)
+	return r
+}
 
-		//protected
-		api.Group(func(protected chi.Router) {
-			protected.Use(srv.Middleware.JWTAuthMiddleware())
+// apiRoutes mounts the api once per version, handlers are shared and a version only branches where
+// its requests or behaviour differ
+func (srv *Server) apiRoutes(api chi.Router, version apiVersion) {
+	// sign in and sign up endpoints get a tighter per ip limit against credential stuffing
+	api.Group(func(auth chi.Router) {
+		auth.Use(srv.RateLimiter.LimitByIP("auth", func() models.RateLimit { return srv.Config.GetRateLimits().Auth }))
+		// v2 signs up and in through firebase, v1 keeps the password flow
+		if version.since(2) {
+			auth.Post("/user/register", srv.UserHandler.PublicRegisterThroughFirebase)
+			auth.Post("/user/login", srv.UserHandler.GoogleAuth)
+		} else {
+			auth.Post("/user/register", srv.UserHandler.PublicRegister)
+			auth.Post("/user/login", srv.UserHandler.UserLogin)
+		}
+		auth.Post("/user/magic-link", srv.UserHandler.RequestMagicLink)
+		auth.Post("/user/magic-link/login", srv.UserHandler.MagicLinkLogin)
+		auth.Post("/user/oidc/login", srv.UserHandler.OIDCLogin)
+		auth.Get("/user/saml/metadata", srv.UserHandler.SAMLMetadata)
+		auth.Get("/user/saml/login", srv.UserHandler.SAMLLogin)
+		auth.Post("/user/saml/acs", srv.UserHandler.SAMLACS)
+		auth.Post("/user/invite/accept", srv.UserHandler.AcceptInvite)
+		auth.Post("/user/invite/otp", srv.UserHandler.SendContactOTP)
+		auth.Post("/user/verify-email/confirm", srv.UserHandler.ConfirmEmail)
+		auth.Post("/user/verify-email/resend", srv.UserHandler.ResendVerification)
+		auth.Post("/user/change-email/confirm", srv.UserHandler.ConfirmEmailChange)
+		auth.Post("/user/mfa/verify", srv.UserHandler.VerifyMFA)
+		auth.Post("/user/mfa/enroll", srv.UserHandler.EnrollMFAChallenge)
+		auth.Post("/auth/token", srv.ServiceAccountHandler.Token)
+	})
+	// refresh tokens can't be guessed, so refreshing only counts against the general ip limit
+	api.Post("/auth/refresh", srv.UserHandler.RefreshToken)
+	// the service desk authenticates with the webhook token
+	api.Post("/integrations/tickets", srv.AssetHandler.TicketWebhook)
+	// docusign connect and dropbox sign callbacks are signed, the provider checks them
+	api.Post("/integrations/esign", srv.ESignHandler.Webhook)
 
-			protected.Get("/users/dashboard", srv.UserHandler.GetUserDashboard)
+	//protected
+	api.Group(func(protected chi.Router) {
+		protected.Use(srv.Middleware.JWTAuthMiddleware())
+		protected.Use(srv.RateLimiter.LimitByUser("user", func() models.RateLimit { return srv.Config.GetRateLimits().User }))
+		protected.Use(srv.RateLimiter.LimitByAPIKey("api_key", srv.Config.GetRateLimits))
 
-			//asset_manage and admin routes
-			protected.Route("/inventory", func(inventory chi.Router) {
-				inventory.Use(srv.Middleware.RequireRole(models.AssetManagerRole, models.AdminRole))
+		protected.Get("/me", srv.PermissionHandler.GetMe)
+		// read on every page load, served from the response cache and kept by clients for its ttl
+		protected.With(withETag, srv.withResponseCache(cacheprovider.ResponseEnums)).Get("/meta/enums", srv.PermissionHandler.GetEnums)
+		protected.With(withETag, srv.withResponseCache(cacheprovider.ResponseAssetTypes)).Get("/meta/asset-types", srv.AssetHandler.GetAssetTypes)
+		// names, emails, departments and designations only, asset data stays behind user.read
+		protected.With(withETag, srv.withResponseCache(cacheprovider.ResponseDirectory)).Get("/directory", srv.UserHandler.GetDirectory)
+		protected.Get("/search", srv.SearchHandler.Search)
+		protected.With(srv.Middleware.RequirePermission(models.TokenIntrospectPermission)).Post("/auth/introspect", srv.UserHandler.IntrospectToken)
 
-				//post methods
-				inventory.Post("/asset", srv.AssetHandler.AddNewAssetWithConfig)
-				inventory.Post("/asset/assign", srv.AssetHandler.AssignAssetToUser)
-				inventory.Post("/asset/unassign", srv.AssetHandler.RetrieveAsset)
-				inventory.Post("/asset/service/send", srv.AssetHandler.SendAssetToService)
-				inventory.Post("/asset/service/received", srv.AssetHandler.ReceivedFromService)
+		// the caller's own account, only reachable when signed in as a person. Dashboards and lists
+		// that clients poll carry a weak ETag and answer 304 to a matching If-None-Match
+		protected.Group(func(self chi.Router) {
+			self.Use(srv.Middleware.RequireUserSession())
+			self.With(withETag).Get("/users/dashboard", srv.UserHandler.GetUserDashboard)
+			self.With(withETag).Get("/users/dashboard/summary", srv.UserHandler.GetUserDashboardSummary)
+			self.Post("/users/logout", srv.UserHandler.Logout)
+			self.Post("/users/change-email", srv.UserHandler.ChangeMyEmail)
+			self.Put("/users/timezone", srv.UserHandler.SetMyTimezone)
+			self.Post("/users/mfa/enroll", srv.UserHandler.EnrollMyMFA)
+			self.Post("/users/mfa/activate", srv.UserHandler.ActivateMyMFA)
+			self.Post("/users/mfa/disable", srv.UserHandler.DisableMyMFA)
+			self.Post("/users/mfa/backup-codes", srv.UserHandler.RegenerateMyBackupCodes)
+			self.Post("/users/assets/report-stolen", srv.AssetHandler.ReportStolen)
+			self.Post("/users/assets/acknowledge", srv.AssetHandler.AcknowledgeAssignment)
+			self.Get("/users/purchase-requests", srv.ProcurementHandler.GetMyRequests)
+			self.Post("/users/purchase-requests", srv.ProcurementHandler.CreateRequest)
+			self.Get("/users/waitlist", srv.WaitlistHandler.GetMyEntries)
+			self.Post("/users/waitlist", srv.WaitlistHandler.JoinWaitlist)
+			self.Post("/users/waitlist/claim", srv.WaitlistHandler.ClaimOffer)
+			self.Delete("/users/waitlist/leave", srv.WaitlistHandler.LeaveWaitlist)
+			self.Get("/users/permissions", srv.PermissionHandler.GetMyPermissions)
+			self.Get("/users/delegations", srv.PermissionHandler.GetMyDelegations)
+			self.Post("/users/delegations", srv.PermissionHandler.CreateDelegation)
+			self.Delete("/users/delegations/revoke", srv.PermissionHandler.RevokeDelegation)
+			self.With(withETag).Get("/users/notifications", srv.NotificationHandler.GetMyNotifications)
+			self.Put("/users/notifications/read", srv.NotificationHandler.MarkNotificationsRead)
+			self.Get("/users/notifications/preferences", srv.NotificationHandler.GetMyPreferences)
+			self.Put("/users/notifications/preferences", srv.NotificationHandler.UpdateMyPreferences)
+			self.Get("/users/notifications/digest", srv.NotificationHandler.GetMyDigestSettings)
+			self.Put("/users/notifications/digest", srv.NotificationHandler.UpdateMyDigestSettings)
+			self.Post("/users/devices", srv.PushHandler.RegisterDevice)
+			self.Delete("/users/devices/remove", srv.PushHandler.UnregisterDevice)
+			self.Get("/users/logins", srv.LoginActivityHandler.GetMyLogins)
+			self.Post("/users/logins/confirm", srv.LoginActivityHandler.ConfirmLogin)
+			self.Post("/users/logins/deny", srv.LoginActivityHandler.DenyLogin)
+		})
 
-				//put methods
-				inventory.Put("/asset/update", srv.AssetHandler.UpdateAssetWithConfigHandler)
+		//asset routes, access is resolved from role_permissions
+		protected.Route("/inventory", func(inventory chi.Router) {
+			//post methods
+			inventory.With(srv.Middleware.RequirePermission(models.AssetCreatePermission)).Post("/asset", srv.AssetHandler.AddNewAssetWithConfig)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetAssignPermission)).Post("/asset/assign", srv.AssetHandler.AssignAssetToUser)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetAssignPermission)).Post("/asset/assign/bulk", srv.BulkHandler.SubmitAssetAssign)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetCreatePermission)).Post("/assets/import", srv.BulkHandler.SubmitAssetImport)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetUnassignPermission)).Post("/asset/unassign", srv.AssetHandler.RetrieveAsset)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetServicePermission)).Post("/asset/service/send", srv.AssetHandler.SendAssetToService)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetServicePermission)).Post("/asset/service/received", srv.AssetHandler.ReceivedFromService)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetUpdatePermission)).Post("/asset/attachments", srv.AssetHandler.CreateAttachmentUpload)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetUpdatePermission)).Post("/asset/attachments/confirm", srv.AssetHandler.ConfirmAttachment)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetAssignPermission)).Post("/asset/signatures/resend", srv.ESignHandler.ResendSignature)
 
-				//get methods
-				inventory.Get("/assets", srv.AssetHandler.GetAllAssetsWithFilters)
-				inventory.Get("/asset/timeline", srv.AssetHandler.GetAssetTimeline)
+			//put methods
+			inventory.With(srv.Middleware.RequirePermission(models.AssetUpdatePermission)).Put("/asset/update", srv.AssetHandler.UpdateAssetWithConfigHandler)
 
-				//delete methods
-				inventory.Delete("/asset/remove", srv.AssetHandler.DeleteAsset)
-			})
+			//get methods
+			inventory.With(srv.Middleware.RequirePermission(models.AssetReadPermission), withETag).Get("/assets", srv.AssetHandler.GetAllAssetsWithFilters)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetReadPermission)).Get("/assets/live", srv.LiveHandler.StreamAssetUpdates)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetReadPermission)).Get("/assets/stream", srv.AssetHandler.StreamAssets)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetReadPermission)).Get("/asset/timeline", srv.AssetHandler.GetAssetTimeline)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetReadPermission)).Get("/asset/inspections", srv.AssetHandler.GetReturnInspections)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetReadPermission)).Get("/asset/disposal", srv.AssetHandler.GetAssetDisposal)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetReadPermission)).Get("/asset/attachments", srv.AssetHandler.GetAttachments)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetReadPermission)).Get("/asset/signatures", srv.ESignHandler.GetSignatures)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetReadPermission)).Get("/asset/warranty", srv.AssetHandler.GetWarrantyLookup)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetCreatePermission)).Get("/assets/warranty-lookup", srv.AssetHandler.LookupWarranty)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetReadPermission), withETag).Get("/return-requests", srv.AssetHandler.GetReturnRequests)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetReadPermission)).Get("/loans", srv.AssetHandler.GetLoans)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetReadPermission)).Get("/waitlist", srv.WaitlistHandler.GetWaitlist)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetReadPermission)).Get("/mdm/mismatches", srv.AssetHandler.GetMDMMismatches)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetReadPermission)).Get("/assets/duplicates", srv.AssetHandler.GetDuplicateAssets)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetReadPermission)).Get("/assets/utilization", srv.AssetHandler.GetUtilization)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetReadPermission)).Get("/assets/churn", srv.AssetHandler.GetAssignmentChurn)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetReadPermission)).Get("/assets/trends", srv.AssetHandler.GetTrends)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetReadPermission)).Get("/assets/service-report", srv.AssetHandler.GetServiceReport)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetReadPermission)).Get("/assets/telecom-report", srv.AssetHandler.GetTelecomCostReport)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetReadPermission)).Get("/changes", srv.AssetHandler.GetInventoryChanges)
 
-			//employee_manager and admin routes
-			protected.Route("/employee", func(employee chi.Router) {
-				employee.Use(srv.Middleware.RequireRole(models.EmployeeMangerRole, models.AdminRole))
+			//delete methods
+			inventory.With(srv.Middleware.RequirePermission(models.AssetDeletePermission)).Delete("/asset/remove", srv.AssetHandler.DeleteAsset)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetDeletePermission)).Post("/asset/dispose", srv.AssetHandler.DisposeAsset)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetMergePermission)).Post("/assets/merge", srv.AssetHandler.MergeAssets)
+			inventory.With(srv.Middleware.RequirePermission(models.AssetUpdatePermission)).Delete("/asset/attachments/remove", srv.AssetHandler.DeleteAttachment)
+		})
 
-				//post methods
-				employee.Post("/register", srv.UserHandler.RegisterEmployeeByManager)
+		//employee routes
+		protected.Route("/employee", func(employee chi.Router) {
+			//post methods
+			employee.With(srv.Middleware.RequirePermission(models.UserCreatePermission)).Post("/register", srv.UserHandler.RegisterEmployeeByManager)
+			employee.With(srv.Middleware.RequirePermission(models.UserCreatePermission)).Post("/invite", srv.UserHandler.InviteEmployee)
+			employee.With(srv.Middleware.RequirePermission(models.UserReadPermission)).Post("/employees/export/async", srv.BulkHandler.SubmitEmployeeExport)
 
-				//put methods
-				employee.Put("/update", srv.UserHandler.UpdateEmployee)
+			//put methods
+			employee.With(srv.Middleware.RequirePermission(models.UserUpdatePermission)).Put("/update", srv.UserHandler.UpdateEmployee)
+			employee.With(srv.Middleware.RequirePermission(models.UserUpdatePermission)).Post("/change-email", srv.UserHandler.ChangeEmployeeEmail)
+			employee.With(srv.Middleware.RequirePermission(models.DepartmentManagePermission)).Put("/department", srv.DepartmentHandler.SetUserDepartment)
 
-				//get methods
-				employee.Get("/employees", srv.UserHandler.GetEmployeesWithFilters)
-				employee.Get("/timeline", srv.UserHandler.GetEmployeeTimeline)
+			//get methods
+			employee.With(srv.Middleware.RequirePermission(models.UserReadPermission), withETag).Get("/employees", srv.UserHandler.GetEmployeesWithFilters)
+			employee.With(srv.Middleware.RequirePermission(models.UserReadPermission)).Get("/employees/export", srv.UserHandler.ExportEmployees)
+			employee.With(srv.Middleware.RequirePermission(models.UserReadPermission)).Get("/employees/stream", srv.UserHandler.StreamEmployees)
+			employee.With(srv.Middleware.RequirePermission(models.UserReadPermission)).Get("/timeline", srv.UserHandler.GetEmployeeTimeline)
+			employee.With(srv.Middleware.RequirePermission(models.UserReadPermission)).Get("/role-history", srv.UserHandler.GetRoleHistory)
+			employee.With(srv.Middleware.RequirePermission(models.UserReadPermission)).Get("/charges", srv.ChargeHandler.GetEmployeeCharges)
+			employee.With(srv.Middleware.RequirePermission(models.PrivacyManagePermission)).Get("/data-export", srv.PrivacyHandler.ExportUserData)
+			employee.With(srv.Middleware.RequirePermission(models.PrivacyManagePermission)).Get("/retention-preview", srv.PrivacyHandler.GetRetentionPreview)
+			employee.With(srv.Middleware.RequirePermission(models.PrivacyManagePermission)).Post("/anonymize", srv.PrivacyHandler.AnonymizeUser)
 
-				//delete methods
-				employee.Delete("/remove", srv.UserHandler.DeleteUser)
-			})
+			//delete methods
+			employee.With(srv.Middleware.RequirePermission(models.UserDeletePermission)).Delete("/remove", srv.UserHandler.DeleteUser)
+		})
+
+		protected.With(srv.Middleware.RequirePermission(models.AuditReadPermission), withETag).Get("/audit-logs", srv.AuditHandler.GetAuditLogs)
+		protected.With(srv.Middleware.RequirePermission(models.AuditReadPermission), withETag).Get("/audit-logs/changes", srv.AuditHandler.GetEntityChanges)
+		protected.With(srv.Middleware.RequirePermission(models.AuditReadPermission), withETag).Get("/auth-events", srv.AuditHandler.GetAuthEvents)
+		protected.With(srv.Middleware.RequirePermission(models.LoginReviewPermission)).Get("/logins", srv.LoginActivityHandler.GetLogins)
+		protected.With(srv.Middleware.RequirePermission(models.DepartmentManagePermission), withETag).Get("/departments", srv.DepartmentHandler.GetDepartments)
+		protected.With(srv.Middleware.RequirePermission(models.DepartmentManagePermission)).Post("/departments", srv.DepartmentHandler.CreateDepartment)
+
+		// dashboards read employees with their assets in one round trip, the graph joins both
+		// domains so it needs both read permissions
+		protected.Route("/graphql", func(graphql chi.Router) {
+			graphql.Use(srv.Middleware.RequirePermission(models.UserReadPermission))
+			graphql.Use(srv.Middleware.RequirePermission(models.AssetReadPermission))
+			graphql.Post("/", srv.GraphQLHandler.Query)
+			graphql.Get("/schema", srv.GraphQLHandler.Schema)
+		})
+
+		// role and permission management
+		protected.Route("/admin", func(admin chi.Router) {
+			admin.Use(srv.Middleware.RequirePermission(models.RoleManagePermission))
+			admin.Post("/employee/change-permissions", srv.UserHandler.ChangeUserRole)
+			admin.Get("/employee/role-approvals", srv.UserHandler.GetRoleGrantApprovals)
+			admin.Post("/employee/role-approvals/approve", srv.UserHandler.ApproveRoleGrant)
+			admin.Post("/employee/role-approvals/reject", srv.UserHandler.RejectRoleGrant)
+			admin.Post("/employee/schedule-role-change", srv.UserHandler.ScheduleRoleChange)
+			admin.Get("/employee/scheduled-role-changes", srv.UserHandler.GetScheduledRoleChanges)
+			admin.Delete("/employee/schedule-role-change/cancel", srv.UserHandler.CancelScheduledRoleChange)
+			admin.Post("/employee/reset-mfa", srv.UserHandler.ResetUserMFA)
+			admin.Post("/employee/force-logout", srv.UserHandler.ForceLogoutUser)
+			admin.Post("/employee/unlock-login", srv.UserHandler.UnlockLogin)
+			admin.With(srv.Middleware.RequirePermission(models.UserDeletePermission)).Delete("/employee/remove", srv.UserHandler.DeletePrivilegedUser)
+			admin.Delete("/delegations/revoke", srv.PermissionHandler.RevokeAnyDelegation)
+			admin.Post("/directory-sync", srv.UserHandler.SyncDirectory)
+			admin.Get("/directory-sync/reports", srv.UserHandler.GetDirectorySyncReports)
+			admin.Get("/permissions", srv.PermissionHandler.GetPermissionMatrix)
+			admin.With(withETag).Get("/roles", srv.PermissionHandler.GetRoles)
+			// roles are shared by every organization, defining them is up to platform admins
+			admin.With(srv.Middleware.RequirePermission(models.OrganizationManagePermission)).Post("/roles", srv.PermissionHandler.CreateRole)
+			admin.With(srv.Middleware.RequirePermission(models.OrganizationManagePermission)).Put("/roles/update", srv.PermissionHandler.UpdateRole)
+			admin.Get("/roles/approvals", srv.PermissionHandler.GetRoleUpdateApprovals)
+			admin.Post("/roles/approvals/approve", srv.PermissionHandler.ApproveRoleUpdate)
+			admin.Post("/roles/approvals/reject", srv.PermissionHandler.RejectRoleUpdate)
+			admin.With(srv.Middleware.RequirePermission(models.OrganizationManagePermission)).Delete("/roles/remove", srv.PermissionHandler.DeleteRole)
+			admin.With(srv.Middleware.RequirePermission(models.OrganizationManagePermission)).Post("/policies/reload", srv.PermissionHandler.ReloadPolicies)
+			admin.Get("/trash/{kind}", srv.TrashHandler.GetTrash)
+			admin.Post("/integrity-check", srv.IntegrityHandler.RunCheck)
+			admin.Get("/integrity-check/reports", srv.IntegrityHandler.GetReports)
+			// an archive of the whole tenant is downloaded from the bulk job, restoring one is only
+			// done by people signed in as themselves
+			admin.With(srv.Middleware.RequirePermission(models.SnapshotExportPermission)).Post("/snapshots", srv.BulkHandler.SubmitSnapshotExport)
+			admin.With(srv.Middleware.RequireUserSession(), srv.Middleware.RequirePermission(models.SnapshotRestorePermission)).Post("/snapshots/uploads", srv.SnapshotHandler.CreateUpload)
+			admin.With(srv.Middleware.RequireUserSession(), srv.Middleware.RequirePermission(models.SnapshotRestorePermission)).Post("/snapshots/restore", srv.SnapshotHandler.Restore)
+		})
+
+		// managers who register employees can pick a template, only admins maintain them
+		protected.With(srv.Middleware.RequirePermission(models.UserCreatePermission), withETag).Get("/onboarding-templates", srv.OnboardingHandler.GetTemplates)
+		protected.With(srv.Middleware.RequirePermission(models.OnboardingManagePermission)).Post("/onboarding-templates", srv.OnboardingHandler.CreateTemplate)
+		protected.With(srv.Middleware.RequirePermission(models.OnboardingManagePermission)).Delete("/onboarding-templates/remove", srv.OnboardingHandler.DeleteTemplate)
+
+		// api_key.manage is never grantable as a scope, so keys can't mint or revoke keys
+		protected.With(srv.Middleware.RequirePermission(models.APIKeyManagePermission), withETag).Get("/api-keys", srv.APIKeyHandler.GetAPIKeys)
+		protected.With(srv.Middleware.RequirePermission(models.APIKeyManagePermission)).Post("/api-keys", srv.APIKeyHandler.CreateAPIKey)
+		protected.With(srv.Middleware.RequirePermission(models.APIKeyManagePermission)).Delete("/api-keys/revoke", srv.APIKeyHandler.RevokeAPIKey)
+		protected.With(srv.Middleware.RequirePermission(models.APIKeyManagePermission)).Put("/api-keys/limits", srv.APIKeyHandler.UpdateAPIKeyLimits)
+		protected.With(srv.Middleware.RequirePermission(models.APIKeyManagePermission)).Get("/api-keys/usage", srv.APIKeyHandler.GetAPIKeyUsage)
+
+		// machine clients get their own principal, the token comes from POST /api/auth/token. Only
+		// people manage them, so an account can't mint more accounts
+		protected.Route("/service-accounts", func(accounts chi.Router) {
+			accounts.Use(srv.Middleware.RequireUserSession())
+			accounts.Use(srv.Middleware.RequirePermission(models.ServiceAccountManagePermission))
+			accounts.With(withETag).Get("/", srv.ServiceAccountHandler.GetServiceAccounts)
+			accounts.Post("/", srv.ServiceAccountHandler.CreateServiceAccount)
+			accounts.Put("/roles", srv.ServiceAccountHandler.UpdateServiceAccountRoles)
+			accounts.Post("/rotate-secret", srv.ServiceAccountHandler.RotateServiceAccountSecret)
+			accounts.Delete("/disable", srv.ServiceAccountHandler.DisableServiceAccount)
+		})
+
+		// badge scanning kiosks check pool equipment in and out, only with a key scoped to kiosk.operate
+		protected.Route("/kiosk", func(kiosk chi.Router) {
+			kiosk.Use(srv.Middleware.RequireAPIKey())
+			kiosk.Use(srv.Middleware.RequirePermission(models.KioskOperatePermission))
+			kiosk.Post("/checkout", srv.AssetHandler.KioskCheckout)
+			kiosk.Post("/return", srv.AssetHandler.KioskReturn)
+		})
 
-			// Admin-only routes
-			protected.Route("/admin", func(admin chi.Router) {
-				admin.Use(srv.Middleware.RequireRole(models.AdminRole))
-				admin.Post("/employee/change-permissions", srv.UserHandler.ChangeUserRole)
+		// endpoints downstream systems register to receive asset and user events, status=dead on
+		// /deliveries lists the dead letters that /deliveries/redeliver puts back in the queue
+		protected.Route("/webhooks", func(webhooks chi.Router) {
+			webhooks.Use(srv.Middleware.RequirePermission(models.WebhookManagePermission))
+			webhooks.With(withETag).Get("/", srv.WebhookHandler.GetEndpoints)
+			webhooks.Post("/", srv.WebhookHandler.CreateEndpoint)
+			webhooks.Delete("/remove", srv.WebhookHandler.DeleteEndpoint)
+			webhooks.Post("/rotate-secret", srv.WebhookHandler.RotateSecret)
+			webhooks.With(withETag).Get("/deliveries", srv.WebhookHandler.GetDeliveries)
+			webhooks.Post("/deliveries/redeliver", srv.WebhookHandler.RedeliverDelivery)
+		})
+
+		// purchase orders for new assets, raised and received by managers and approved by someone else
+		// with procurement.approve. Receiving one creates its assets
+		protected.Route("/procurement/orders", func(orders chi.Router) {
+			orders.Use(srv.Middleware.RequirePermission(models.ProcurementManagePermission))
+			orders.Get("/", srv.ProcurementHandler.GetOrders)
+			orders.Post("/", srv.ProcurementHandler.CreateOrder)
+			orders.Post("/receive", srv.ProcurementHandler.ReceiveOrder)
+			orders.With(srv.Middleware.RequirePermission(models.ProcurementApprovePermission)).Post("/approve", srv.ProcurementHandler.ApproveOrder)
+			orders.With(srv.Middleware.RequirePermission(models.ProcurementApprovePermission)).Post("/reject", srv.ProcurementHandler.RejectOrder)
+		})
+
+		// equipment employees ask for, reviewed by asset managers with procurement.manage and then
+		// approved by finance with procurement.approve, the handlers work out which stage the caller
+		// may decide. An approved request is converted into a purchase order
+		protected.Route("/procurement/requests", func(requests chi.Router) {
+			requests.Get("/", srv.ProcurementHandler.GetRequests)
+			requests.Post("/approve", srv.ProcurementHandler.ApproveRequest)
+			requests.Post("/reject", srv.ProcurementHandler.RejectRequest)
+			requests.With(srv.Middleware.RequirePermission(models.ProcurementManagePermission)).Post("/convert", srv.ProcurementHandler.ConvertRequest)
+		})
+
+		// what employees owe for lost or damaged equipment, recorded and settled by finance
+		protected.Route("/charges", func(charges chi.Router) {
+			charges.Use(srv.Middleware.RequirePermission(models.ChargeManagePermission))
+			charges.Get("/", srv.ChargeHandler.GetCharges)
+			charges.Post("/", srv.ChargeHandler.CreateCharge)
+			charges.Post("/settle", srv.ChargeHandler.SettleCharge)
+		})
+
+		// budgets per department and period with what was spent of them, spending is read from the
+		// assets and services so nothing has to be booked against a budget
+		protected.Route("/budgets", func(budgets chi.Router) {
+			budgets.With(srv.Middleware.RequirePermission(models.BudgetReadPermission)).Get("/", srv.BudgetHandler.GetBudgets)
+			budgets.With(srv.Middleware.RequirePermission(models.BudgetManagePermission)).Post("/", srv.BudgetHandler.CreateBudget)
+			budgets.With(srv.Middleware.RequirePermission(models.BudgetManagePermission)).Delete("/remove", srv.BudgetHandler.DeleteBudget)
+		})
+
+		// client projects and the shared equipment lent to them, apart from employee assignments
+		protected.Route("/projects", func(projects chi.Router) {
+			projects.With(srv.Middleware.RequirePermission(models.ProjectReadPermission)).Get("/", srv.ProjectHandler.GetProjects)
+			projects.With(srv.Middleware.RequirePermission(models.ProjectManagePermission)).Post("/", srv.ProjectHandler.CreateProject)
+			projects.With(srv.Middleware.RequirePermission(models.ProjectManagePermission)).Post("/close", srv.ProjectHandler.CloseProject)
+			projects.With(srv.Middleware.RequirePermission(models.ProjectReadPermission)).Get("/assets", srv.ProjectHandler.GetAllocations)
+			projects.With(srv.Middleware.RequirePermission(models.ProjectManagePermission)).Post("/assets", srv.ProjectHandler.AllocateAsset)
+			projects.With(srv.Middleware.RequirePermission(models.ProjectManagePermission)).Post("/assets/release", srv.ProjectHandler.ReleaseAsset)
+			projects.With(srv.Middleware.RequirePermission(models.ProjectReadPermission)).Get("/billing", srv.ProjectHandler.GetBilling)
+		})
+
+		// overdue returns and pending approvals escalated along their chains, see ESCALATION_*
+		protected.With(srv.Middleware.RequirePermission(models.EscalationReadPermission)).Get("/escalations", srv.EscalationHandler.GetEscalations)
+
+		// announcements to everyone or to some roles and departments of the caller's organization
+		protected.Route("/broadcasts", func(broadcasts chi.Router) {
+			broadcasts.Use(srv.Middleware.RequirePermission(models.NotificationBroadcastPermission))
+			broadcasts.Get("/", srv.NotificationHandler.GetBroadcasts)
+			broadcasts.Post("/", srv.NotificationHandler.SendBroadcast)
+		})
+
+		// google sheets kept in step with an asset filter, spreadsheets are shared with the account
+		// GET returns as share_with. Managers see and change the syncs of their own department
+		protected.Route("/sheet-syncs", func(syncs chi.Router) {
+			syncs.Use(srv.Middleware.RequirePermission(models.SheetSyncManagePermission))
+			syncs.Get("/", srv.SheetsHandler.GetSyncs)
+			syncs.Post("/", srv.SheetsHandler.CreateSync)
+			syncs.Post("/resync", srv.SheetsHandler.Resync)
+			syncs.Delete("/remove", srv.SheetsHandler.DeleteSync)
+		})
+
+		// imeis of stolen devices, mobiles carrying one are blocked or flagged at intake when IMEI_CHECK is on
+		protected.Route("/imei-blacklist", func(blacklist chi.Router) {
+			blacklist.Use(srv.Middleware.RequirePermission(models.IMEIBlacklistManagePermission))
+			blacklist.Get("/", srv.AssetHandler.GetIMEIBlacklist)
+			blacklist.Post("/", srv.AssetHandler.BlacklistIMEI)
+			blacklist.Delete("/remove", srv.AssetHandler.RemoveBlacklistedIMEI)
+		})
+
+		// reports emailed to a list of addresses on a cron schedule in UTC, as csv or pdf
+		protected.Route("/reports/schedules", func(schedules chi.Router) {
+			schedules.Use(srv.Middleware.RequirePermission(models.ReportManagePermission))
+			schedules.Get("/", srv.ReportHandler.GetSchedules)
+			schedules.Post("/", srv.ReportHandler.CreateSchedule)
+			schedules.Put("/", srv.ReportHandler.UpdateSchedule)
+			schedules.Delete("/remove", srv.ReportHandler.DeleteSchedule)
+		})
+
+		// applies the config file's log level, rate limits, cache ttls and allowed email domains on this
+		// instance, the others reload once they see the file changed
+		protected.With(srv.Middleware.RequirePermission(models.ConfigManagePermission)).Post("/config/reload", srv.ReloadConfig)
+
+		// fixed asset journals for the accounting system, queued as a bulk job and downloaded from
+		// /jobs/{id}/result once it completes
+		protected.With(srv.Middleware.RequirePermission(models.AccountingExportPermission)).Post("/accounting/exports", srv.BulkHandler.SubmitAccountingExport)
+
+		// the schema version against the migrations this instance ships, migrating is left to assetctl
+		protected.With(srv.Middleware.RequirePermission(models.SchemaReadPermission)).Get("/schema/migrations", srv.GetMigrationStatus)
+
+		// background jobs of the instance serving the request, /run starts one there right away and
+		// /runs is the history recorded by every instance. /{id} follows a bulk job, its creator or a
+		// job manager can read it
+		protected.Route("/jobs", func(jobs chi.Router) {
+			jobs.Group(func(scheduler chi.Router) {
+				scheduler.Use(srv.Middleware.RequirePermission(models.JobManagePermission))
+				scheduler.Get("/", srv.SchedulerHandler.GetJobs)
+				scheduler.Post("/run", srv.SchedulerHandler.TriggerJob)
+				scheduler.With(withETag).Get("/runs", srv.SchedulerHandler.GetRuns)
 			})
+			jobs.Get("/{id}", srv.BulkHandler.GetJob)
+			jobs.Get("/{id}/result", srv.BulkHandler.DownloadResult)
 		})
 	})
+}
 
-	return r
+// echoRequestID returns the request id on the response so clients can quote it, error bodies
+// pick it up from there
+func echoRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(utils.RequestIDHeader, middleware.GetReqID(r.Context()))
+		next.ServeHTTP(w, r)
+	})
 }
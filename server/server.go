@@ -1,87 +1,801 @@
 package server
 
 import (
+	"asset/jobs"
+	"asset/models"
 	"asset/providers"
+	cacheprovider "asset/providers/cacheProvider"
 	"asset/providers/configProvider"
 	"asset/providers/databaseProvider"
+	emailprovider "asset/providers/emailProvider"
+	errorreporterprovider "asset/providers/errorReporterProvider"
+	esignprovider "asset/providers/esignProvider"
+	eventprovider "asset/providers/eventProvider"
 	firebaseprovider "asset/providers/firebaseProvider"
+	geoipprovider "asset/providers/geoipProvider"
+	imeiprovider "asset/providers/imeiProvider"
+	ldapprovider "asset/providers/ldapProvider"
 	"asset/providers/loggerProvider"
+	mdmprovider "asset/providers/mdmProvider"
 	"asset/providers/middlewareprovider"
+	oidcprovider "asset/providers/oidcProvider"
+	procurementprovider "asset/providers/procurementProvider"
+	ratelimitprovider "asset/providers/rateLimitProvider"
 	redisprovider "asset/providers/redisProvider"
+	samlprovider "asset/providers/samlProvider"
+	secretsprovider "asset/providers/secretsProvider"
+	sheetsprovider "asset/providers/sheetsProvider"
+	slackprovider "asset/providers/slackProvider"
+	smsprovider "asset/providers/smsProvider"
+	storageprovider "asset/providers/storageProvider"
+	ticketprovider "asset/providers/ticketProvider"
+	warrantyprovider "asset/providers/warrantyProvider"
+	"asset/services/accounting"
+	"asset/services/apikey"
 	"asset/services/asset"
+	"asset/services/audit"
+	"asset/services/budget"
+	"asset/services/bulk"
+	"asset/services/charge"
+	"asset/services/department"
+	"asset/services/escalation"
+	"asset/services/esign"
+	"asset/services/event"
+	"asset/services/graphql"
+	"asset/services/integrity"
+	"asset/services/live"
+	"asset/services/loginactivity"
+	"asset/services/notification"
+	"asset/services/onboarding"
+	"asset/services/permission"
+	"asset/services/privacy"
+	"asset/services/procurement"
+	"asset/services/project"
+	"asset/services/push"
+	"asset/services/report"
+	"asset/services/scheduler"
+	"asset/services/search"
+	"asset/services/serviceaccount"
+	"asset/services/sheets"
+	"asset/services/snapshot"
+	"asset/services/trash"
 	"asset/services/user"
+	"asset/services/waitlist"
+	"asset/services/webhook"
+	"asset/utils"
 	"context"
+	"errors"
 	"fmt"
 	"go.uber.org/zap"
 	"log"
 	"net/http"
 	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Server struct {
-	Config       providers.ConfigProvider
-	DB           providers.DBProvider
-	Middleware   providers.AuthMiddlewareService
-	UserHandler  *userservice.UserHandler
-	AssetHandler *assetservice.AssetHandler
-	httpServer   *http.Server
-	Logger       providers.ZapLoggerProvider
-	Firebase     providers.FirebaseProvider
-	Redis        providers.RedisProvider
+	Config                providers.ConfigProvider
+	DB                    providers.DBProvider
+	Middleware            providers.AuthMiddlewareService
+	RateLimiter           providers.RateLimiter
+	UserHandler           *userservice.UserHandler
+	AssetHandler          *assetservice.AssetHandler
+	PermissionHandler     *permissionservice.PermissionHandler
+	AuditHandler          *auditservice.AuditHandler
+	DepartmentHandler     *departmentservice.DepartmentHandler
+	NotificationHandler   *notificationservice.NotificationHandler
+	OnboardingHandler     *onboardingservice.OnboardingHandler
+	APIKeyHandler         *apikeyservice.APIKeyHandler
+	ServiceAccountHandler *serviceaccountservice.ServiceAccountHandler
+	GraphQLHandler        *graphqlservice.GraphQLHandler
+	WebhookHandler        *webhookservice.WebhookHandler
+	PushHandler           *pushservice.PushHandler
+	SheetsHandler         *sheetsservice.SheetsHandler
+	LiveHandler           *liveservice.LiveHandler
+	SchedulerHandler      *schedulerservice.SchedulerHandler
+	BulkHandler           *bulkservice.BulkHandler
+	ProcurementHandler    *procurementservice.ProcurementHandler
+	ESignHandler          *esignservice.ESignHandler
+	ReportHandler         *reportservice.ReportHandler
+	PrivacyHandler        *privacyservice.PrivacyHandler
+	SearchHandler         *searchservice.SearchHandler
+	TrashHandler          *trashservice.TrashHandler
+	IntegrityHandler      *integrityservice.IntegrityHandler
+	ChargeHandler         *chargeservice.ChargeHandler
+	BudgetHandler         *budgetservice.BudgetHandler
+	ProjectHandler        *projectservice.ProjectHandler
+	WaitlistHandler       *waitlistservice.WaitlistHandler
+	EscalationHandler     *escalationservice.EscalationHandler
+	LoginActivityHandler  *loginactivityservice.LoginActivityHandler
+	SnapshotHandler       *snapshotservice.SnapshotHandler
+	httpServer            *http.Server
+	debugServer           *http.Server
+	Jobs                  *jobs.Runner
+	Users                 userservice.UserService
+	Assets                assetservice.AssetService
+	Bulk                  bulkservice.BulkService
+	Live                  liveservice.LiveService
+	Outbox                eventservice.EventService
+	Logger                providers.ZapLoggerProvider
+	Firebase              providers.FirebaseProvider
+	Redis                 providers.RedisProvider
+	ResponseCache         providers.ResponseCacheProvider
+	Events                providers.EventPublisher
+	ErrorReporter         providers.ErrorReporter
+	Slack                 providers.SlackProvider
+	// nil without OBJECT_STORAGE_BACKEND, the local backend also serves /storage/
+	Storage providers.StorageProvider
+	// set once shutdown starts, /readyz then reports the instance draining
+	draining atomic.Bool
 }
 
 func ServerInit() *Server {
 	cfg := configprovider.NewConfigProvider()
-	cfg.LoadEnv()
+	if err := cfg.LoadEnv(); err != nil {
+		log.Fatalf("invalid configuration for the %s profile: %v", cfg.GetProfile(), err)
+	}
 
 	//zap logger
-	logs := loggerProvider.NewLogProvider()
+	logs := loggerProvider.NewLogProvider(cfg.GetLogConfig())
 	logs.InitLogger()
-	logs.GetLogger().Info("inside serverInit")
+	logs.GetLogger().Info("inside serverInit", zap.String("profile", cfg.GetProfile()))
+	if err := utils.SetJSONCodec(cfg.GetJSONCodec()); err != nil {
+		logs.GetLogger().Fatal("failed to select the json codec", zap.Error(err))
+	}
 
-	//firebase
-	serviceAccountJSON, err := os.ReadFile(os.Getenv("FIREBASE_CONFIG"))
+	//error reporting, nil when SENTRY_DSN is unset and errors then only reach the logs
+	reporter, err := errorreporterprovider.NewErrorReporter(cfg.GetErrorReportingConfig(), logs)
 	if err != nil {
-		logs.GetLogger().Error("failed to read service account json file ::", zap.Error(err))
+		logs.GetLogger().Fatal("failed to configure error reporting", zap.Error(err))
 	}
-	firebase, err := firebaseprovider.NewFirebaseProvider(serviceAccountJSON)
+
+	//secrets provider
+	secrets, err := secretsprovider.NewSecretsProvider(cfg.GetSecretsConfig())
 	if err != nil {
-		logs.GetLogger().Error("failed to initialize firebase provider ::", zap.Error(err))
+		logs.GetLogger().Fatal("failed to initialize secrets provider", zap.Error(err))
 	}
 
-	//redis provider
-	redisPort := ":" + os.Getenv("REDIS_PORT")
-	redis := redisprovider.NewRedisProvider(redisPort)
-	logs.GetLogger().Info("redis initialized")
-	redis.Ping(context.Background())
+	//firebase, AUTH_MODE=fake signs in by email without a firebase project
+	//push notifications go through cloud messaging of the same project, fake auth sends none
+	var firebase providers.FirebaseProvider
+	var push providers.PushProvider
+	if cfg.GetAuthMode() == models.AuthModeFake {
+		firebase = firebaseprovider.NewFakeFirebaseProvider()
+		logs.GetLogger().Warn("fake auth, google sign in takes any email as the id token and emails are only logged")
+	} else {
+		serviceAccountJSON := loadFirebaseServiceAccount(secrets, logs)
+		realFirebase, err := firebaseprovider.NewFirebaseProvider(serviceAccountJSON)
+		if err != nil {
+			logs.GetLogger().Fatal("failed to initialize firebase provider", zap.Error(err))
+		}
+		firebase = firebaseprovider.NewResilientFirebaseProvider(realFirebase, cfg.GetFirebaseConfig(), logs)
+		if push, err = firebaseprovider.NewPushProvider(serviceAccountJSON); err != nil {
+			logs.GetLogger().Fatal("failed to initialize firebase cloud messaging", zap.Error(err))
+		}
+	}
+
+	//redis provider, REDIS_MODE=memory keeps it in the process for local development
+	var redis providers.RedisProvider
+	redisCfg := cfg.GetRedisConfig()
+	if redisCfg.Mode == models.RedisModeMemory {
+		redis = redisprovider.NewMemoryRedisProvider()
+		logs.GetLogger().Warn("redis runs in memory")
+	} else {
+		if redis, err = redisprovider.NewRedisProvider(redisCfg, secrets); err != nil {
+			logs.GetLogger().Fatal("failed to configure redis", zap.Error(err))
+		}
+		logs.GetLogger().Info("redis initialized", zap.String("mode", redisCfg.Mode), zap.Strings("addrs", redisCfg.Addrs))
+		redis.Ping(context.Background())
+	}
+
+	//email provider
+	mailer := emailprovider.NewEmailProvider(emailprovider.SMTPConfig{
+		Host:     os.Getenv("SMTP_HOST"),
+		Port:     os.Getenv("SMTP_PORT"),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}, logs)
+	if cfg.GetAuthMode() == models.AuthModeFake {
+		mailer = emailprovider.NewFakeEmailProvider(logs)
+	}
 
 	//database provider
-	db := databaseProvider.NewDBProvider(cfg.GetDatabaseString())
-	middleware := middlewareprovider.NewAuthMiddlewareService(db.DB())
+	db := databaseProvider.NewDBProvider(cfg.GetDatabaseString(), cfg.GetMigrationConfig(), cfg.GetDBPoolConfig(), cfg.GetQueryStatsConfig(), secrets, logs)
+	checkMigrations(db, cfg.GetMigrationConfig(), logs)
+	jwtConfig := cfg.GetJWTConfig()
+	if jwtConfig.SecretKey, err = loadSecret(secrets, models.SecretJWTKey); err != nil {
+		logs.GetLogger().Fatal("failed to read jwt key from secrets", zap.Error(err))
+	}
+	if jwtConfig.PrivateKeyPEM, err = loadSecret(secrets, models.SecretJWTPrivateKey); err != nil {
+		logs.GetLogger().Fatal("failed to read jwt private key from secrets", zap.Error(err))
+	}
+	refreshKey, err := loadSecret(secrets, models.SecretJWTRefreshKey)
+	if err != nil {
+		logs.GetLogger().Fatal("failed to read refresh token key from secrets", zap.Error(err))
+	}
+	if refreshKey != nil {
+		jwtConfig.RefreshKey = refreshKey
+	}
+	if err := middlewareprovider.ConfigureJWT(jwtConfig); err != nil {
+		logs.GetLogger().Fatal("failed to configure jwt", zap.Error(err))
+	}
+	middleware := middlewareprovider.NewAuthMiddlewareService(db.DB(), redis, cfg.GetAuthCookieConfig(), cfg.GetOrganizationDomain())
+	if _, err := middleware.ReloadPolicies(context.Background()); err != nil {
+		logs.GetLogger().Fatal("failed to load authorization policies", zap.Error(err))
+	}
+	rateLimiter := ratelimitprovider.NewRateLimiter(redis, middleware, logs)
+	userCache := cacheprovider.NewUserCacheProvider(redis, logs)
+	responseCache := cacheprovider.NewResponseCacheProvider(redis, logs)
+
+	//event broker, nil when EVENT_BROKER is unset and events then only reach webhooks
+	publisher, err := eventprovider.NewEventPublisher(cfg.GetEventBrokerConfig())
+	if err != nil {
+		logs.GetLogger().Fatal("failed to configure event broker", zap.Error(err))
+	}
+	if publisher != nil {
+		logs.GetLogger().Info("event broker configured", zap.String("broker", cfg.GetEventBrokerConfig().Driver), zap.String("topic", cfg.GetEventBrokerConfig().Topic))
+	}
+
+	//slack, nil when no event is routed to a channel
+	slack, err := slackprovider.NewSlackProvider(cfg.GetSlackConfig(), secrets, logs)
+	if err != nil {
+		logs.GetLogger().Fatal("failed to configure slack", zap.Error(err))
+	}
+
+	//service desk, nil when TICKET_SYSTEM is unset
+	tickets, err := ticketprovider.NewTicketProvider(cfg.GetTicketingConfig(), secrets)
+	if err != nil {
+		logs.GetLogger().Fatal("failed to configure service desk tickets", zap.Error(err))
+	}
+
+	//device management, nil when MDM_SYSTEM is unset
+	mdm, err := mdmprovider.NewMDMProvider(cfg.GetMDMConfig(), secrets)
+	if err != nil {
+		logs.GetLogger().Fatal("failed to configure mdm device discovery", zap.Error(err))
+	}
+
+	//text messages for contact otp and urgent alerts, nil when SMS_PROVIDER is unset
+	sms, err := smsprovider.NewSMSProvider(cfg.GetSMSConfig(), secrets, redis)
+	if err != nil {
+		logs.GetLogger().Fatal("failed to configure sms", zap.Error(err))
+	}
+
+	//external stolen device registry mobiles are checked against, nil when IMEI_LOOKUP_URL is unset
+	imeiCheck := cfg.GetIMEICheckConfig()
+	imeiLookup, err := imeiprovider.NewIMEILookupProvider(imeiCheck, secrets)
+	if err != nil {
+		logs.GetLogger().Fatal("failed to configure imei lookup", zap.Error(err))
+	}
+
+	//geo ip lookup logins are located with for impossible travel, nil when LOGIN_GEO_LOOKUP_URL is unset
+	loginAnomaly := cfg.GetLoginAnomalyConfig()
+	geoIP, err := geoipprovider.NewGeoIPProvider(loginAnomaly)
+	if err != nil {
+		logs.GetLogger().Fatal("failed to configure geo ip lookup", zap.Error(err))
+	}
+
+	//vendor apis warranties are looked up in by serial number, nil when WARRANTY_LOOKUP is unset
+	warranty, err := warrantyprovider.NewWarrantyProvider(cfg.GetWarrantyConfig(), secrets)
+	if err != nil {
+		logs.GetLogger().Fatal("failed to configure warranty lookup", zap.Error(err))
+	}
+
+	//bucket for attachments and export results, nil when OBJECT_STORAGE_BACKEND is unset
+	storageCfg := cfg.GetObjectStorageConfig()
+	storage, err := storageprovider.NewStorageProvider(storageCfg, secrets)
+	if err != nil {
+		logs.GetLogger().Fatal("failed to configure object storage", zap.Error(err))
+	}
+
+	//service account writing synced google sheets, nil when SHEETS_SYNC_ENABLED is off
+	sheetsCfg := cfg.GetSheetsConfig()
+	sheets, err := sheetsprovider.NewSheetsProvider(sheetsCfg, secrets)
+	if err != nil {
+		logs.GetLogger().Fatal("failed to configure google sheets sync", zap.Error(err))
+	}
+
+	//e-procurement system approved purchase orders are pushed to, nil when PROCUREMENT_SYSTEM is unset
+	procurement, err := procurementprovider.NewProcurementProvider(cfg.GetProcurementConfig(), secrets)
+	if err != nil {
+		logs.GetLogger().Fatal("failed to configure procurement", zap.Error(err))
+	}
+
+	//e-signature service handover and return documents are sent through, nil when ESIGN_PROVIDER is unset
+	esign, err := esignprovider.NewESignProvider(cfg.GetESignConfig(), secrets)
+	if err != nil {
+		logs.GetLogger().Fatal("failed to configure e-signature", zap.Error(err))
+	}
+	if esign != nil && storage == nil {
+		logs.GetLogger().Fatal("e-signature keeps its documents in object storage, set OBJECT_STORAGE_BACKEND")
+	}
 
 	//repositories
-	userRepo := userservice.NewUserRepository(db.DB(), logs, firebase, redis)
-	assetRepo := assetservice.NewAssetRepository(db.DB())
+	userRepo := userservice.NewUserRepository(db.DB(), logs, firebase, redis, cfg)
+	assetRepo := assetservice.NewAssetRepository(db.DB(), redis, cfg)
+	permissionRepo := permissionservice.NewPermissionRepository(db.DB(), logs)
+	auditRepo := auditservice.NewAuditRepository(db.DB(), logs)
+	departmentRepo := departmentservice.NewDepartmentRepository(db.DB(), logs)
+	notificationRepo := notificationservice.NewNotificationRepository(db.DB(), logs)
+	onboardingRepo := onboardingservice.NewOnboardingRepository(db.DB(), logs)
+	apiKeyRepo := apikeyservice.NewAPIKeyRepository(db.DB(), logs)
+	serviceAccountRepo := serviceaccountservice.NewServiceAccountRepository(db.DB(), logs)
+	graphqlRepo := graphqlservice.NewGraphQLRepository(db.DB(), logs)
+	webhookRepo := webhookservice.NewWebhookRepository(db.DB(), logs)
+	pushRepo := pushservice.NewPushRepository(db.DB(), logs)
+	eventRepo := eventservice.NewEventRepository(db.DB(), logs)
+	liveRepo := liveservice.NewLiveRepository(db.DB(), logs)
+	sheetsRepo := sheetsservice.NewSheetsRepository(db.DB(), logs)
+	esignRepo := esignservice.NewESignRepository(db.DB(), logs)
+	reportRepo := reportservice.NewReportRepository(db.DB(), logs)
 
 	//services
-	userService := userservice.NewUserService(userRepo, db.DB(), logs, firebase)
-	assetService := assetservice.NewAssetService(assetRepo, db.DB())
+	auditService := auditservice.NewAuditService(auditRepo, logs)
+	notificationService := notificationservice.NewNotificationService(notificationRepo, db.DB(), mailer, logs)
+	webhookService := webhookservice.NewWebhookService(webhookRepo, db.DB(), logs, auditService)
+	pushService := pushservice.NewPushService(pushRepo, logs, push)
+	sheetsService := sheetsservice.NewSheetsService(sheetsRepo, sheetsCfg, logs, sheets)
+	esignService := esignservice.NewESignService(esignRepo, logs, esign, storage)
+	reportService := reportservice.NewReportService(reportRepo, logs, mailer)
+	eventService := eventservice.NewEventService(eventRepo, db.DB(), logs, publisher, webhookService, pushService, sheetsService, esignService)
+	//firebase keeps serving google sign in, microsoft and github are built in and any other issuer comes from OIDC_PROVIDERS
+	oidcProviders := []providers.OIDCProvider{oidcprovider.NewFirebaseOIDCProvider(firebase)}
+	social := cfg.GetSocialLoginConfig()
+	if social.MicrosoftClientID != "" {
+		oidcProviders = append(oidcProviders, oidcprovider.NewMicrosoftProvider(social.MicrosoftClientID, social.MicrosoftTenant))
+		logs.GetLogger().Info("microsoft login configured", zap.String("tenant", social.MicrosoftTenant))
+	}
+	if social.GitHubClientID != "" {
+		oidcProviders = append(oidcProviders, oidcprovider.NewGitHubProvider(social.GitHubClientID, social.GitHubClientSecret))
+		logs.GetLogger().Info("github login configured")
+	}
+	for _, providerCfg := range cfg.GetOIDCProviders() {
+		oidcProviders = append(oidcProviders, oidcprovider.NewOIDCProvider(providerCfg))
+		logs.GetLogger().Info("oidc provider configured", zap.String("provider", providerCfg.Name), zap.String("issuer", providerCfg.Issuer))
+	}
+	var samlProvider providers.SAMLProvider
+	if samlCfg, ok := cfg.GetSAMLConfig(); ok {
+		var err error
+		if samlProvider, err = samlprovider.NewSAMLProvider(samlCfg, redis); err != nil {
+			logs.GetLogger().Fatal("failed to configure saml provider", zap.Error(err))
+		}
+		oidcProviders = append(oidcProviders, samlProvider)
+		logs.GetLogger().Info("saml provider configured", zap.String("idp", samlCfg.IDPEntityID))
+	}
+	var directory providers.DirectoryProvider
+	ldapCfg, ldapEnabled := cfg.GetLDAPConfig()
+	if ldapEnabled {
+		directory = ldapprovider.NewLDAPProvider(ldapCfg)
+		logs.GetLogger().Info("ldap directory sync configured", zap.String("url", ldapCfg.URL), zap.Bool("dryRun", ldapCfg.DryRun))
+	}
+	loginActivityService := loginactivityservice.NewLoginActivityService(loginactivityservice.NewLoginActivityRepository(db.DB(), logs), db.DB(), geoIP, middleware, notificationService, auditService, logs, loginAnomaly)
+	userService := userservice.NewUserService(userRepo, db.DB(), logs, firebase, middleware, notificationService, mailer, cfg, auditService, eventService, oidcProviders, directory, sms, loginActivityService)
+	assetService := assetservice.NewAssetService(assetRepo, db.DB(), eventService, notificationService, userCache, slack, tickets, mdm, sms, storage, storageCfg.MaxUploadBytes, imeiCheck.Mode, imeiLookup, warranty, logs)
+	permissionService := permissionservice.NewPermissionService(permissionRepo, db.DB(), logs, auditService, responseCache)
+	departmentService := departmentservice.NewDepartmentService(departmentRepo, db.DB(), logs, auditService, eventService, userCache)
+	onboardingService := onboardingservice.NewOnboardingService(onboardingRepo, db.DB(), logs)
+	apiKeyService := apikeyservice.NewAPIKeyService(apiKeyRepo, db.DB(), logs, auditService, redis, cfg)
+	serviceAccountService := serviceaccountservice.NewServiceAccountService(serviceAccountRepo, db.DB(), logs, middleware, auditService)
+	graphqlService := graphqlservice.NewGraphQLService(graphqlRepo, userService, assetService, logs)
+	liveService := liveservice.NewLiveService(liveRepo, db, logs)
+	accountingService := accountingservice.NewAccountingService(accountingservice.NewAccountingRepository(db.DB(), logs), cfg.GetAccountingConfig(), logs)
+	procurementService := procurementservice.NewProcurementService(procurementservice.NewProcurementRepository(db.DB(), logs), db.DB(), assetService, notificationService, logs, procurement)
+	privacyService := privacyservice.NewPrivacyService(privacyservice.NewPrivacyRepository(db.DB(), logs), userService, userCache, auditService, firebase, logs, cfg.GetJobsConfig().Retention)
+	searchService := searchservice.NewSearchService(searchservice.NewSearchRepository(db.DB(), logs), logs)
+	trashService := trashservice.NewTrashService(trashservice.NewTrashRepository(db.DB(), logs), logs)
+	chargeService := chargeservice.NewChargeService(chargeservice.NewChargeRepository(db.DB(), logs), auditService, notificationService, logs)
+	budgetService := budgetservice.NewBudgetService(budgetservice.NewBudgetRepository(db.DB(), logs), auditService, notificationService, logs, cfg.GetJobsConfig().BudgetAlertPercent)
+	projectService := projectservice.NewProjectService(projectservice.NewProjectRepository(db.DB(), logs), auditService, logs)
+	escalationService := escalationservice.NewEscalationService(escalationservice.NewEscalationRepository(db.DB(), logs), db.DB(), notificationService, logs, cfg.GetJobsConfig())
+	waitlistService := waitlistservice.NewWaitlistService(waitlistservice.NewWaitlistRepository(db.DB(), logs), db.DB(), assetService, notificationService, logs, cfg.GetJobsConfig().WaitlistClaimWindow)
+	integrityService := integrityservice.NewIntegrityService(integrityservice.NewIntegrityRepository(db.DB(), logs), auditService, logs, cfg.GetJobsConfig().IntegrityAutoRepair)
+	snapshotService := snapshotservice.NewSnapshotService(snapshotservice.NewSnapshotRepository(db.DB(), logs), departmentService, userCache, responseCache, auditService, storage, logs, cfg.GetSnapshotConfig())
+	bulkService := bulkservice.NewBulkService(bulkservice.NewBulkRepository(db.DB(), logs), userService, assetService, departmentService, accountingService, snapshotService, storage, logs)
 
 	//handlers
-	userHandler := userservice.NewUserHandler(userService, middleware, logs, firebase)
+	userHandler := userservice.NewUserHandler(userService, middleware, logs, firebase, samlProvider, cfg.GetAuthCookieConfig())
 	assetHandler := assetservice.NewAssetHandler(assetService, middleware)
+	permissionHandler := permissionservice.NewPermissionHandler(permissionService, middleware, logs)
+	auditHandler := auditservice.NewAuditHandler(auditService, middleware, logs)
+	departmentHandler := departmentservice.NewDepartmentHandler(departmentService, middleware, logs)
+	notificationHandler := notificationservice.NewNotificationHandler(notificationService, middleware, logs)
+	onboardingHandler := onboardingservice.NewOnboardingHandler(onboardingService, middleware, logs)
+	apiKeyHandler := apikeyservice.NewAPIKeyHandler(apiKeyService, middleware, logs)
+	serviceAccountHandler := serviceaccountservice.NewServiceAccountHandler(serviceAccountService, middleware, logs)
+	graphqlHandler := graphqlservice.NewGraphQLHandler(graphqlService, middleware, logs)
+	webhookHandler := webhookservice.NewWebhookHandler(webhookService, middleware, logs)
+	pushHandler := pushservice.NewPushHandler(pushService, middleware, logs)
+	sheetsHandler := sheetsservice.NewSheetsHandler(sheetsService, middleware, logs)
+	liveHandler := liveservice.NewLiveHandler(liveService, middleware, logs)
+	bulkHandler := bulkservice.NewBulkHandler(bulkService, middleware, logs)
+	procurementHandler := procurementservice.NewProcurementHandler(procurementService, middleware, logs)
+	esignHandler := esignservice.NewESignHandler(esignService, middleware, logs)
+	reportHandler := reportservice.NewReportHandler(reportService, middleware, logs)
+	privacyHandler := privacyservice.NewPrivacyHandler(privacyService, middleware, logs)
+	searchHandler := searchservice.NewSearchHandler(searchService, middleware, logs)
+	trashHandler := trashservice.NewTrashHandler(trashService, middleware, logs)
+	integrityHandler := integrityservice.NewIntegrityHandler(integrityService, middleware, logs)
+	chargeHandler := chargeservice.NewChargeHandler(chargeService, middleware, logs)
+	budgetHandler := budgetservice.NewBudgetHandler(budgetService, middleware, logs)
+	projectHandler := projectservice.NewProjectHandler(projectService, middleware, logs)
+	waitlistHandler := waitlistservice.NewWaitlistHandler(waitlistService, middleware, logs)
+	escalationHandler := escalationservice.NewEscalationHandler(escalationService, middleware, logs)
+	loginActivityHandler := loginactivityservice.NewLoginActivityHandler(loginActivityService, middleware, logs)
+	snapshotHandler := snapshotservice.NewSnapshotHandler(snapshotService, middleware, logs)
+
+	//background jobs, scheduled ones are in UTC and each run happens on one instance unless PerInstance
+	schedulerRepo := schedulerservice.NewSchedulerRepository(db.DB(), logs)
+	jobRunner := jobs.NewRunner(logs, reporter, redis, schedulerRepo)
+	schedulerService := schedulerservice.NewSchedulerService(schedulerRepo, jobRunner, db.DB(), logs, auditService)
+	schedulerHandler := schedulerservice.NewSchedulerHandler(schedulerService, middleware, logs)
+	jobsCfg := cfg.GetJobsConfig()
+	jobRunner.Register(jobs.Job{
+		Name:        "apply_scheduled_role_changes",
+		Description: "apply role changes whose effective time has passed",
+		Interval:    time.Minute,
+		Run:         userService.ApplyScheduledRoleChanges,
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "reconcile_firebase_claims",
+		Description: "bring firebase custom claims in line with roles",
+		Interval:    time.Hour,
+		Run:         userService.ReconcileFirebaseClaims,
+	})
+	// every instance evaluates policies from its own copy
+	jobRunner.Register(jobs.Job{
+		Name:        "reload_policies",
+		Description: "reload access policies into this instance",
+		Interval:    time.Minute,
+		PerInstance: true,
+		Run: func(ctx context.Context) error {
+			_, err := middleware.ReloadPolicies(ctx)
+			return err
+		},
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "expire_role_delegations",
+		Description: "end role delegations past their expiry",
+		Interval:    time.Minute,
+		Run:         permissionService.ExpireDelegations,
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "process_employee_end_dates",
+		Description: "warn about employees leaving soon and open return requests once they left",
+		Interval:    time.Hour,
+		Run: func(ctx context.Context) error {
+			return userService.ProcessEmployeeEndDates(ctx, cfg.GetEndDateWarningDays())
+		},
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "send_warranty_alerts",
+		Description: "alert asset managers about warranties expiring soon",
+		Schedule:    "0 6 * * *",
+		Run: func(ctx context.Context) error {
+			return assetService.SendWarrantyAlerts(ctx, jobsCfg.WarrantyAlertDays)
+		},
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "check_overdue_returns",
+		Description: "remind employees and managers of return requests left open too long and escalate the ones still open",
+		Schedule:    "15 * * * *",
+		Run: func(ctx context.Context) error {
+			if err := assetService.NotifyOverdueReturns(ctx, jobsCfg.ReturnOverdueDays); err != nil {
+				return err
+			}
+			return escalationService.EscalateOverdueReturns(ctx)
+		},
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "check_pending_approvals",
+		Description: "escalate purchase requests and orders waiting too long for a decision",
+		Schedule:    "20 * * * *",
+		Run:         escalationService.EscalatePendingApprovals,
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "flag_overdue_loans",
+		Description: "flag loans past their end and tell the employee and their managers",
+		Schedule:    "5 * * * *",
+		Run:         assetService.FlagOverdueLoans,
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "process_asset_waitlist",
+		Description: "expire unclaimed waitlist offers and offer freed assets to the first employee in line",
+		Schedule:    "*/10 * * * *",
+		Run:         waitlistService.ProcessWaitlist,
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "send_notification_emails",
+		Description: "email queued notifications that don't wait for a digest",
+		Schedule:    "*/5 * * * *",
+		Run:         notificationService.SendPendingEmails,
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "send_notification_digests",
+		Description: "email each user due one a digest of their low priority notifications",
+		Schedule:    "15 * * * *",
+		Run:         notificationService.SendDigests,
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "send_acknowledgment_reminders",
+		Description: "remind employees to acknowledge assets assigned to them, and their managers when they don't",
+		Schedule:    "30 * * * *",
+		Run: func(ctx context.Context) error {
+			return assetService.SendAcknowledgmentReminders(ctx, jobsCfg.AckReminderDays)
+		},
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "check_low_stock",
+		Description: "alert asset managers on slack about asset types running out",
+		Schedule:    "45 * * * *",
+		Run: func(ctx context.Context) error {
+			return assetService.CheckLowStock(ctx, jobsCfg.LowStockThresholds)
+		},
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "sync_service_tickets",
+		Description: "open missing service desk tickets and read the status of unresolved ones",
+		Interval:    10 * time.Minute,
+		Run:         assetService.SyncServiceTickets,
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "sync_mdm_devices",
+		Description: "add assets for devices enrolled in the mdm and flag devices enrolled for someone other than their assignee",
+		Interval:    time.Hour,
+		Run: func(ctx context.Context) error {
+			return assetService.SyncMDMDevices(ctx, cfg.GetMDMConfig())
+		},
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "archive_assignment_history",
+		Description: "move assignments and services closed long ago to the history tables",
+		Schedule:    "30 2 * * *",
+		Run: func(ctx context.Context) error {
+			return assetService.ArchiveHistory(ctx, jobsCfg.AssignmentHistoryMonths)
+		},
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "warm_dashboard_cache",
+		Description: "rebuild cached dashboards of recently active users",
+		Schedule:    "*/5 * * * *",
+		Run:         userService.WarmDashboardCache,
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "refresh_department_stats",
+		Description: "recompute the member and asset counts of departments",
+		Schedule:    "*/5 * * * *",
+		Run:         departmentService.RefreshStats,
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "fail_stale_bulk_jobs",
+		Description: "fail bulk jobs whose instance stopped mid run",
+		Schedule:    "*/5 * * * *",
+		Run:         bulkService.FailStaleJobs,
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "purge_bulk_jobs",
+		Description: "delete finished bulk jobs and their results past their retention",
+		Schedule:    "0 3 * * *",
+		Run: func(ctx context.Context) error {
+			return bulkService.PurgeJobs(ctx, jobsCfg.BulkJobRetention)
+		},
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "purge_unconfirmed_attachments",
+		Description: "delete attachment uploads that were never confirmed and their files",
+		Schedule:    "0 * * * *",
+		Run: func(ctx context.Context) error {
+			// upload links expire long before this, the client gave up
+			return assetService.PurgePendingAttachments(ctx, 24*time.Hour)
+		},
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "deliver_webhooks",
+		Description: "deliver pending webhook events",
+		Interval:    15 * time.Second,
+		Run:         webhookService.DeliverPending,
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "deliver_push_notifications",
+		Description: "send queued push notifications to user devices",
+		Interval:    15 * time.Second,
+		Run:         pushService.DeliverPending,
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "push_purchase_orders",
+		Description: "push approved purchase orders to the e-procurement system",
+		Interval:    5 * time.Minute,
+		Run:         procurementService.PushApproved,
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "sync_google_sheets",
+		Description: "write changed assets to synced google sheets",
+		Interval:    30 * time.Second,
+		Run:         sheetsService.SyncPending,
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "send_signature_requests",
+		Description: "send handover and return documents for e-signature",
+		Interval:    time.Minute,
+		Run:         esignService.SendPending,
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "send_scheduled_reports",
+		Description: "email the reports of due report schedules",
+		Interval:    time.Minute,
+		Run:         reportService.SendDue,
+	})
+	// blocks while listening, the interval only spaces out retries after the connection is lost. Every
+	// instance streams to its own clients
+	jobRunner.Register(jobs.Job{
+		Name:        "stream_asset_changes",
+		Description: "forward asset changes to live clients of this instance",
+		Interval:    5 * time.Second,
+		PerInstance: true,
+		Blocking:    true,
+		Run:         liveService.Listen,
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "publish_domain_events",
+		Description: "publish outbox events to the broker",
+		Interval:    5 * time.Second,
+		Run:         eventService.PublishPending,
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "purge_auth_events",
+		Description: "delete auth events past their retention",
+		Schedule:    "30 2 * * *",
+		Run: func(ctx context.Context) error {
+			return auditService.PurgeAuthEvents(ctx, cfg.GetAuthEventRetention())
+		},
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "purge_job_runs",
+		Description: "delete job run history past its retention",
+		Schedule:    "40 2 * * *",
+		Run: func(ctx context.Context) error {
+			return schedulerService.PurgeRuns(ctx, jobsCfg.JobRunRetention)
+		},
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "purge_published_domain_events",
+		Description: "delete domain events published longer ago than their retention",
+		Schedule:    "50 2 * * *",
+		Run: func(ctx context.Context) error {
+			return eventService.PurgePublished(ctx, jobsCfg.DomainEventRetention)
+		},
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "purge_expired_records",
+		Description: "delete or anonymize archived users, audit entries and closed assignments past their retention",
+		Schedule:    "0 4 * * *",
+		Run:         privacyService.ApplyRetention,
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "check_data_integrity",
+		Description: "report rows left inconsistent by partial failures and, with INTEGRITY_AUTO_REPAIR, fix the safe ones",
+		Schedule:    "20 3 * * *",
+		Run:         integrityService.RunScheduled,
+	})
+	jobRunner.Register(jobs.Job{
+		Name:        "check_department_budgets",
+		Description: "alert departments nearing or over their budget, each level once per budget",
+		Schedule:    "40 6 * * *",
+		Run:         budgetService.CheckBudgets,
+	})
+	if ldapEnabled {
+		jobRunner.Register(jobs.Job{
+			Name:        "sync_ldap_directory",
+			Description: "sync users and roles from the ldap directory",
+			Interval:    ldapCfg.SyncInterval,
+			Run: func(ctx context.Context) error {
+				_, err := userService.SyncDirectory(ctx, ldapCfg.DryRun, nil)
+				return err
+			},
+		})
+	}
+
+	srv := &Server{
+		Config:                cfg,
+		DB:                    db,
+		Middleware:            middleware,
+		RateLimiter:           rateLimiter,
+		UserHandler:           userHandler,
+		AssetHandler:          assetHandler,
+		PermissionHandler:     permissionHandler,
+		AuditHandler:          auditHandler,
+		DepartmentHandler:     departmentHandler,
+		NotificationHandler:   notificationHandler,
+		OnboardingHandler:     onboardingHandler,
+		APIKeyHandler:         apiKeyHandler,
+		ServiceAccountHandler: serviceAccountHandler,
+		GraphQLHandler:        graphqlHandler,
+		WebhookHandler:        webhookHandler,
+		PushHandler:           pushHandler,
+		SheetsHandler:         sheetsHandler,
+		LiveHandler:           liveHandler,
+		SchedulerHandler:      schedulerHandler,
+		BulkHandler:           bulkHandler,
+		ProcurementHandler:    procurementHandler,
+		ESignHandler:          esignHandler,
+		ReportHandler:         reportHandler,
+		PrivacyHandler:        privacyHandler,
+		SearchHandler:         searchHandler,
+		TrashHandler:          trashHandler,
+		IntegrityHandler:      integrityHandler,
+		ChargeHandler:         chargeHandler,
+		BudgetHandler:         budgetHandler,
+		ProjectHandler:        projectHandler,
+		WaitlistHandler:       waitlistHandler,
+		EscalationHandler:     escalationHandler,
+		LoginActivityHandler:  loginActivityHandler,
+		SnapshotHandler:       snapshotHandler,
+		Jobs:                  jobRunner,
+		Users:                 userService,
+		Assets:                assetService,
+		Bulk:                  bulkService,
+		Storage:               storage,
+		Live:                  liveService,
+		Outbox:                eventService,
+		Logger:                logs,
+		Firebase:              firebase,
+		Redis:                 redis,
+		ResponseCache:         responseCache,
+		Events:                publisher,
+		ErrorReporter:         reporter,
+		Slack:                 slack,
+	}
+
+	// every instance watches its own config file
+	jobRunner.Register(jobs.Job{
+		Name:        "reload_config",
+		Description: "apply runtime settings from a modified config file",
+		Interval:    configWatchInterval,
+		PerInstance: true,
+		Run: func(ctx context.Context) error {
+			if !cfg.ConfigFileChanged() {
+				return nil
+			}
+			_, err := srv.reloadConfig()
+			return err
+		},
+	})
 
 	logs.GetLogger().Info("\nall provider and services initialized...")
-	return &Server{
-		Config:       cfg,
-		DB:           db,
-		Middleware:   middleware,
-		UserHandler:  userHandler,
-		AssetHandler: assetHandler,
-		Logger:       logs,
-		Redis:        redis,
+	return srv
+}
+
+// loadSecret returns nil without an error when the backend doesn't hold the secret, the caller then
+// falls back to its env or file based setting
+func loadSecret(secrets providers.SecretsProvider, name string) ([]byte, error) {
+	value, err := secrets.GetSecret(context.Background(), name)
+	if errors.Is(err, models.ErrSecretNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []byte(value), nil
+}
+
+// loadFirebaseServiceAccount reads the service account json from the secrets backend when it holds one
+// and from the FIREBASE_CONFIG file otherwise
+func loadFirebaseServiceAccount(secrets providers.SecretsProvider, logs providers.ZapLoggerProvider) []byte {
+	serviceAccountJSON, err := loadSecret(secrets, models.SecretFirebaseServiceAccount)
+	if err != nil {
+		logs.GetLogger().Fatal("failed to read firebase service account from secrets", zap.Error(err))
+	}
+	if serviceAccountJSON == nil {
+		if os.Getenv("FIREBASE_CONFIG") == "" {
+			logs.GetLogger().Fatal("FIREBASE_CONFIG or the firebase service account secret is required")
+		}
+		serviceAccountJSON, err = os.ReadFile(os.Getenv("FIREBASE_CONFIG"))
+		if err != nil {
+			logs.GetLogger().Fatal("failed to read service account json file", zap.String("path", os.Getenv("FIREBASE_CONFIG")), zap.Error(err))
+		}
 	}
+	return serviceAccountJSON
 }
 
 func (s *Server) Start() {
@@ -94,26 +808,101 @@ func (s *Server) Start() {
 		WriteTimeout: 2 * time.Minute,
 		IdleTimeout:  2 * time.Minute,
 	}
+	// streams last until the client leaves, shutdown ends them instead of waiting
+	s.httpServer.RegisterOnShutdown(s.Live.CloseStreams)
 
+	if debugAddr := s.Config.GetDebugAddr(); debugAddr != "" {
+		s.debugServer = debugServer(debugAddr)
+		go func() {
+			s.Logger.GetLogger().Info("debug endpoints listening", zap.String("addr", debugAddr))
+			if err := s.debugServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				s.Logger.GetLogger().Error("debug server stopped", zap.Error(err))
+			}
+		}()
+	}
+
+	s.Jobs.Start()
+	s.Bulk.StartWorkers(s.Config.GetJobsConfig().BulkWorkers)
 	fmt.Println("server running on", addr)
 	if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		s.Logger.GetLogger().Fatal("failed to start server", zap.Error(err))
 	}
 }
 
+// Stop drains the instance within the shutdown timeout. It reports itself draining for the drain
+// delay, then stops taking requests and new background work while what is in flight finishes,
+// publishes the events that work left in the outbox and closes providers, those others depend on last
 func (s *Server) Stop() {
-	s.Logger.GetLogger().Info("shutting down server...")
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	cfg := s.Config.GetShutdownConfig()
+	s.Logger.GetLogger().Info("shutting down server...", zap.Duration("timeout", cfg.Timeout), zap.Duration("drainDelay", cfg.DrainDelay))
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
 	defer cancel()
+
+	s.draining.Store(true)
+	select {
+	case <-time.After(cfg.DrainDelay):
+	case <-ctx.Done():
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		if err := s.httpServer.Shutdown(ctx); err != nil {
+			s.Logger.GetLogger().Error("error shutting down server, requests still in flight were cut off", zap.Error(err))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		s.Jobs.Stop(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		s.Bulk.StopWorkers(ctx)
+	}()
+	wg.Wait()
+	if s.debugServer != nil {
+		if err := s.debugServer.Shutdown(ctx); err != nil {
+			s.Logger.GetLogger().Error("error shutting down debug server", zap.Error(err))
+		}
+	}
+
+	s.Close(ctx)
+	s.Logger.GetLogger().Info("Server shutdown gracefully")
 	s.Logger.SyncLogger()
-	if err := s.httpServer.Shutdown(ctx); err != nil {
-		log.Printf("error shutting down server: %v", err)
+}
+
+// Close publishes the events left in the outbox and closes the providers, Stop ends with it and assetctl
+// calls it on its own since it never starts the server
+func (s *Server) Close(ctx context.Context) {
+	// another instance publishes what is left when the outbox can't be flushed in time
+	if err := s.Outbox.PublishPending(ctx); err != nil {
+		s.Logger.GetLogger().Error("error flushing the domain event outbox", zap.Error(err))
 	}
-	if err := s.DB.Close(); err != nil {
-		log.Printf("error closing DB: %v", err)
+	if s.Events != nil {
+		if err := s.Events.Close(); err != nil {
+			s.Logger.GetLogger().Error("error closing event broker connection", zap.Error(err))
+		}
 	}
 	if err := s.Redis.Close(); err != nil {
-		s.Logger.GetLogger().Error("error closing redis connection")
+		s.Logger.GetLogger().Error("error closing redis connection", zap.Error(err))
+	}
+	if err := s.DB.Close(); err != nil {
+		s.Logger.GetLogger().Error("error closing DB", zap.Error(err))
+	}
+	if s.Slack != nil {
+		flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelFlush()
+		if err := s.Slack.Close(flushCtx); err != nil {
+			s.Logger.GetLogger().Error("error flushing slack messages", zap.Error(err))
+		}
+	}
+	if s.ErrorReporter != nil {
+		// the flush gets its own deadline, failures of the shutdown itself are worth reporting
+		flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancelFlush()
+		if err := s.ErrorReporter.Close(flushCtx); err != nil {
+			s.Logger.GetLogger().Error("error flushing error reports", zap.Error(err))
+		}
 	}
-	s.Logger.GetLogger().Info("Server shutdown gracefully")
 }
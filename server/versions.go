@@ -0,0 +1,74 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// APIVersionHeader names the version that answered, clients can check it against the one they
+// asked for
+const APIVersionHeader = "API-Version"
+
+// apiVersion is one mounted version of the api. A breaking change gets a new version that branches
+// in apiRoutes, the previous one keeps answering until it is deprecated and sunset
+type apiVersion struct {
+	name   string
+	number int
+	// deprecated versions answer with Deprecation, Sunset and a successor Link
+	deprecated bool
+	sunset     time.Time
+	// path prefix the request path moves to in the successor version
+	successor string
+}
+
+var (
+	apiV1 = apiVersion{name: "v1", number: 1}
+	// v2 moves sign up and sign in to firebase
+	apiV2       = apiVersion{name: "v2", number: 2}
+	apiVersions = []apiVersion{apiV1, apiV2}
+)
+
+// legacyAPIVersion describes the unversioned /api paths, they serve v1 and point clients at /api/v1
+func legacyAPIVersion(sunset time.Time) apiVersion {
+	legacy := apiV1
+	legacy.deprecated = true
+	legacy.sunset = sunset
+	legacy.successor = "/api/" + apiV1.name
+	return legacy
+}
+
+func (v apiVersion) since(number int) bool {
+	return v.number >= number
+}
+
+func versionHeaders(v apiVersion) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(APIVersionHeader, v.name)
+			if v.deprecated {
+				w.Header().Set("Deprecation", "true")
+				if !v.sunset.IsZero() {
+					w.Header().Set("Sunset", v.sunset.UTC().Format(http.TimeFormat))
+				}
+				if v.successor != "" {
+					successor := v.successor + strings.TrimPrefix(r.URL.Path, "/api")
+					w.Header().Add("Link", "<"+successor+`>; rel="successor-version"`)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// unversionedRoute maps a mounted route to its apiOperations key, /api/v1/me and /api/me share one
+// entry unless a version documents its own
+func unversionedRoute(route string) (string, bool) {
+	for _, v := range apiVersions {
+		prefix := "/api/" + v.name
+		if route == prefix || strings.HasPrefix(route, prefix+"/") {
+			return "/api" + strings.TrimPrefix(route, prefix), true
+		}
+	}
+	return route, false
+}
@@ -0,0 +1,57 @@
+package accountingservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type AccountingRepository interface {
+	GetAssetsForPeriod(ctx context.Context, period Period) ([]AccountingAsset, error)
+}
+
+type PostgresAccountingRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewAccountingRepository(db *sqlx.DB, log providers.ZapLoggerProvider) AccountingRepository {
+	return &PostgresAccountingRepository{DB: db, Logger: log}
+}
+
+// GetAssetsForPeriod returns the company owned assets with a cost that were bought before the period
+// ends and not disposed of before it starts, client owned assets aren't on the books. A sold asset
+// is disposed of on the day of its sale with the price as proceeds
+func (r *PostgresAccountingRepository) GetAssetsForPeriod(ctx context.Context, period Period) ([]AccountingAsset, error) {
+	assets := []AccountingAsset{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &assets, `
+		SELECT
+			a.id, a.brand, a.model, a.serial_no, a.purchase_date,
+			CASE WHEN a.archived_at IS NOT NULL THEN COALESCE(d.disposed_on::timestamptz, a.archived_at) END AS archived_at,
+			round(a.purchase_cost * 100)::bigint AS cost,
+			round(a.salvage_value * 100)::bigint AS salvage_value,
+			a.useful_life_months,
+			COALESCE(round(d.price * 100)::bigint, 0) AS proceeds
+		FROM assets a
+		LEFT JOIN LATERAL (
+			SELECT disposed_on, price FROM asset_disposals
+			WHERE asset_id = a.id
+			ORDER BY created_at DESC
+			LIMIT 1
+		) d ON a.archived_at IS NOT NULL
+		WHERE a.purchase_cost IS NOT NULL
+		AND a.purchase_date IS NOT NULL
+		AND a.owned_by = 'remotestate'
+		AND a.purchase_date < $2
+		AND (a.archived_at IS NULL OR COALESCE(d.disposed_on::timestamptz, a.archived_at) >= $1)
+		ORDER BY a.purchase_date, a.id
+	`, period.From.Format(time.DateOnly), period.To.Format(time.DateOnly))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch assets for the accounting export: %w", err)
+	}
+	return assets, nil
+}
@@ -0,0 +1,81 @@
+package accountingservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type AccountingService interface {
+	// ExportJournal returns the period's fixed asset journal as the csv header and rows of format
+	ExportJournal(ctx context.Context, period Period, format string) ([]string, [][]string, error)
+}
+
+var (
+	ErrInvalidPeriod = models.NewServiceError(http.StatusBadRequest, "invalid_period", "from and to must be months like 2026-09 with from not after to")
+	ErrInvalidFormat = models.NewServiceError(http.StatusBadRequest, "invalid_format", "format must be quickbooks or netsuite")
+)
+
+// periods longer than this are refused, a journal is exported a month or a quarter at a time
+const maxPeriodMonths = 24
+
+type accountingServiceStruct struct {
+	repo   AccountingRepository
+	cfg    models.AccountingConfig
+	logger providers.ZapLoggerProvider
+}
+
+func NewAccountingService(repo AccountingRepository, cfg models.AccountingConfig, logger providers.ZapLoggerProvider) AccountingService {
+	return &accountingServiceStruct{repo: repo, cfg: cfg, logger: logger}
+}
+
+// ParsePeriod reads the months from and to, both included. Left out they are the previous month,
+// the one usually being closed
+func ParsePeriod(from, to string, now time.Time) (Period, error) {
+	previous := monthStart(now).AddDate(0, -1, 0)
+	first, last := previous, previous
+	var err error
+	if from != "" {
+		if first, err = time.Parse("2006-01", from); err != nil {
+			return Period{}, ErrInvalidPeriod
+		}
+		last = first
+	}
+	if to != "" {
+		if last, err = time.Parse("2006-01", to); err != nil {
+			return Period{}, ErrInvalidPeriod
+		}
+	}
+	if last.Before(first) || monthsBetween(first, last) >= maxPeriodMonths {
+		return Period{}, ErrInvalidPeriod
+	}
+	return Period{From: first, To: last.AddDate(0, 1, 0)}, nil
+}
+
+// ParseFormat defaults to quickbooks
+func ParseFormat(format string) (string, error) {
+	switch format {
+	case "":
+		return models.AccountingFormatQuickBooks, nil
+	case models.AccountingFormatQuickBooks, models.AccountingFormatNetSuite:
+		return format, nil
+	default:
+		return "", ErrInvalidFormat
+	}
+}
+
+func (s *accountingServiceStruct) ExportJournal(ctx context.Context, period Period, format string) ([]string, [][]string, error) {
+	assets, err := s.repo.GetAssetsForPeriod(ctx, period)
+	if err != nil {
+		return nil, nil, err
+	}
+	entries := BuildJournal(assets, period, s.cfg)
+	header, rows := JournalRows(format, entries, s.cfg)
+	s.logger.GetLogger().Info("fixed asset journal exported", zap.String("format", format), zap.Time("from", period.From), zap.Time("to", period.To),
+		zap.Int("assets", len(assets)), zap.Int("journals", len(entries)))
+	return header, rows, nil
+}
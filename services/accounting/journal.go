@@ -0,0 +1,168 @@
+package accountingservice
+
+import (
+	"asset/models"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// journals are dated like the accounting systems' csv imports expect them
+const journalDateLayout = "01/02/2006"
+
+var (
+	quickBooksHeader = []string{"Journal No", "Journal Date", "Currency", "Memo", "Account Name", "Debits", "Credits", "Description"}
+	netSuiteHeader   = []string{"External ID", "Date", "Subsidiary", "Currency", "Memo", "Account", "Debit", "Credit", "Line Memo"}
+)
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()-from.Month())
+}
+
+// depreciatedAfter is how much of the depreciable amount is written off after months of a straight
+// line over life months. Rounding is carried into later months, so the months add up exactly
+func depreciatedAfter(depreciable int64, months, life int) int64 {
+	months = min(max(months, 0), life)
+	return depreciable * int64(months) / int64(life)
+}
+
+// BuildJournal books the period's acquisitions, monthly depreciation and disposals. An asset is
+// depreciated from the month it was bought and not in the month it was disposed of, the disposal
+// books its proceeds against what was left of its cost as a gain or a loss
+func BuildJournal(assets []AccountingAsset, period Period, cfg models.AccountingConfig) []JournalEntry {
+	accounts := cfg.Accounts
+	var entries []JournalEntry
+	depreciation := make(map[time.Time]*JournalEntry)
+	for _, asset := range assets {
+		life := cfg.DefaultUsefulLifeMonths
+		if asset.UsefulLifeMonths != nil {
+			life = *asset.UsefulLifeMonths
+		}
+		if life <= 0 {
+			life = 1
+		}
+		depreciable := max(asset.Cost-asset.SalvageValue, 0)
+		purchased := monthStart(asset.PurchaseDate)
+		name := fmt.Sprintf("%s %s (%s)", asset.Brand, asset.Model, asset.SerialNo)
+		short := asset.ID.String()[:8]
+
+		if !asset.PurchaseDate.Before(period.From) && asset.PurchaseDate.Before(period.To) {
+			entries = append(entries, JournalEntry{
+				Number: "FA-ACQ-" + short,
+				Kind:   JournalAcquisition,
+				Date:   asset.PurchaseDate,
+				Memo:   "Acquisition of " + name,
+				Lines: []JournalLine{
+					{Account: accounts.FixedAssets, Debit: asset.Cost, Memo: name},
+					{Account: accounts.Acquisition, Credit: asset.Cost, Memo: name},
+				},
+			})
+		}
+
+		for month := maxTime(period.From, purchased); month.Before(period.To); month = month.AddDate(0, 1, 0) {
+			if asset.DisposedAt != nil && !month.Before(monthStart(*asset.DisposedAt)) {
+				break
+			}
+			n := monthsBetween(purchased, month) + 1
+			if n > life {
+				break
+			}
+			amount := depreciatedAfter(depreciable, n, life) - depreciatedAfter(depreciable, n-1, life)
+			if amount == 0 {
+				continue
+			}
+			entry, ok := depreciation[month]
+			if !ok {
+				entry = &JournalEntry{
+					Number: "FA-DEP-" + month.Format("200601"),
+					Kind:   JournalDepreciation,
+					Date:   month.AddDate(0, 1, -1),
+					Memo:   "Depreciation for " + month.Format("January 2006"),
+				}
+				depreciation[month] = entry
+			}
+			entry.Lines = append(entry.Lines,
+				JournalLine{Account: accounts.DepreciationExpense, Debit: amount, Memo: name},
+				JournalLine{Account: accounts.AccumulatedDepreciation, Credit: amount, Memo: name},
+			)
+		}
+
+		if asset.DisposedAt != nil && !asset.DisposedAt.Before(period.From) && asset.DisposedAt.Before(period.To) {
+			accumulated := depreciatedAfter(depreciable, monthsBetween(purchased, monthStart(*asset.DisposedAt)), life)
+			entry := JournalEntry{
+				Number: "FA-DSP-" + short,
+				Kind:   JournalDisposal,
+				Date:   *asset.DisposedAt,
+				Memo:   "Disposal of " + name,
+			}
+			if accumulated > 0 {
+				entry.Lines = append(entry.Lines, JournalLine{Account: accounts.AccumulatedDepreciation, Debit: accumulated, Memo: name})
+			}
+			if asset.Proceeds > 0 {
+				entry.Lines = append(entry.Lines, JournalLine{Account: accounts.DisposalProceeds, Debit: asset.Proceeds, Memo: name})
+			}
+			if loss := asset.Cost - accumulated - asset.Proceeds; loss > 0 {
+				entry.Lines = append(entry.Lines, JournalLine{Account: accounts.DisposalLoss, Debit: loss, Memo: name})
+			}
+			if asset.Cost > 0 {
+				entry.Lines = append(entry.Lines, JournalLine{Account: accounts.FixedAssets, Credit: asset.Cost, Memo: name})
+			}
+			if gain := accumulated + asset.Proceeds - asset.Cost; gain > 0 {
+				entry.Lines = append(entry.Lines, JournalLine{Account: accounts.DisposalGain, Credit: gain, Memo: name})
+			}
+			if asset.Cost > 0 || asset.Proceeds > 0 {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	for _, entry := range depreciation {
+		entries = append(entries, *entry)
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].Date.Equal(entries[j].Date) {
+			return entries[i].Date.Before(entries[j].Date)
+		}
+		return entries[i].Number < entries[j].Number
+	})
+	return entries
+}
+
+// JournalRows writes entries as one csv row per line in the journal import of format
+func JournalRows(format string, entries []JournalEntry, cfg models.AccountingConfig) ([]string, [][]string) {
+	rows := make([][]string, 0)
+	for _, entry := range entries {
+		date := entry.Date.Format(journalDateLayout)
+		for _, line := range entry.Lines {
+			switch format {
+			case models.AccountingFormatNetSuite:
+				rows = append(rows, []string{entry.Number, date, cfg.NetSuiteSubsidiary, cfg.Currency, entry.Memo, line.Account, amount(line.Debit), amount(line.Credit), line.Memo})
+			default:
+				rows = append(rows, []string{entry.Number, date, cfg.Currency, entry.Memo, line.Account, amount(line.Debit), amount(line.Credit), line.Memo})
+			}
+		}
+	}
+	if format == models.AccountingFormatNetSuite {
+		return netSuiteHeader, rows
+	}
+	return quickBooksHeader, rows
+}
+
+// amount formats minor units as a decimal, the side of a line without an amount stays empty
+func amount(minor int64) string {
+	if minor == 0 {
+		return ""
+	}
+	return strconv.FormatInt(minor/100, 10) + "." + fmt.Sprintf("%02d", minor%100)
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
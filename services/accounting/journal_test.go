@@ -0,0 +1,143 @@
+package accountingservice
+
+import (
+	"asset/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testConfig = models.AccountingConfig{
+	Currency:                "INR",
+	DefaultUsefulLifeMonths: 36,
+	NetSuiteSubsidiary:      "RemoteState India",
+	Accounts: models.LedgerAccounts{
+		FixedAssets:             "Computer Equipment",
+		AccumulatedDepreciation: "Accumulated Depreciation",
+		DepreciationExpense:     "Depreciation Expense",
+		Acquisition:             "Accounts Payable",
+		DisposalLoss:            "Loss on Disposal of Assets",
+		DisposalGain:            "Gain on Disposal of Assets",
+		DisposalProceeds:        "Accounts Receivable",
+	},
+}
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func months(n int) *int {
+	return &n
+}
+
+func TestBuildJournal(t *testing.T) {
+	disposedB, disposedC := date(2026, 8, 20), date(2026, 9, 5)
+	assets := []AccountingAsset{
+		// bought in the period, depreciated over the default 36 months
+		{ID: uuid.MustParse("aaaaaaaa-0000-4000-8000-000000000001"), Brand: "Dell", Model: "Latitude", SerialNo: "SN-A", PurchaseDate: date(2026, 7, 15), Cost: 3600000},
+		// fully depreciated long ago, only its disposal is booked
+		{ID: uuid.MustParse("bbbbbbbb-0000-4000-8000-000000000002"), Brand: "Apple", Model: "iPhone", SerialNo: "SN-B", PurchaseDate: date(2025, 1, 10), DisposedAt: &disposedB, Cost: 100000, UsefulLifeMonths: months(3)},
+		// disposed of a month after it was bought, the rest of its cost is a loss
+		{ID: uuid.MustParse("cccccccc-0000-4000-8000-000000000003"), Brand: "Logitech", Model: "MX", SerialNo: "SN-C", PurchaseDate: date(2026, 8, 1), DisposedAt: &disposedC, Cost: 10000, UsefulLifeMonths: months(3)},
+	}
+	period, err := ParsePeriod("2026-07", "2026-09", time.Now())
+	require.NoError(t, err)
+
+	entries := BuildJournal(assets, period, testConfig)
+	numbers := make([]string, len(entries))
+	for i, entry := range entries {
+		numbers[i] = entry.Number
+		var debits, credits int64
+		for _, line := range entry.Lines {
+			debits += line.Debit
+			credits += line.Credit
+		}
+		assert.Equal(t, debits, credits, "%s is balanced", entry.Number)
+	}
+	assert.Equal(t, []string{"FA-ACQ-aaaaaaaa", "FA-DEP-202607", "FA-ACQ-cccccccc", "FA-DSP-bbbbbbbb", "FA-DEP-202608", "FA-DSP-cccccccc", "FA-DEP-202609"}, numbers)
+
+	assert.Equal(t, []JournalLine{
+		{Account: "Depreciation Expense", Debit: 100000, Memo: "Dell Latitude (SN-A)"},
+		{Account: "Accumulated Depreciation", Credit: 100000, Memo: "Dell Latitude (SN-A)"},
+		{Account: "Depreciation Expense", Debit: 3333, Memo: "Logitech MX (SN-C)"},
+		{Account: "Accumulated Depreciation", Credit: 3333, Memo: "Logitech MX (SN-C)"},
+	}, entries[4].Lines)
+	assert.Equal(t, date(2026, 8, 31), entries[4].Date)
+	assert.Equal(t, []JournalLine{
+		{Account: "Accumulated Depreciation", Debit: 3333, Memo: "Logitech MX (SN-C)"},
+		{Account: "Loss on Disposal of Assets", Debit: 6667, Memo: "Logitech MX (SN-C)"},
+		{Account: "Computer Equipment", Credit: 10000, Memo: "Logitech MX (SN-C)"},
+	}, entries[5].Lines)
+	assert.Len(t, entries[3].Lines, 2, "nothing is lost on a fully depreciated asset")
+	assert.Len(t, entries[6].Lines, 2, "the disposed asset isn't depreciated in its disposal month")
+}
+
+func TestBuildJournalBooksProceeds(t *testing.T) {
+	sold := date(2026, 7, 15)
+	assets := []AccountingAsset{
+		// half depreciated and sold for more than what was left of its cost
+		{ID: uuid.MustParse("dddddddd-0000-4000-8000-000000000004"), Brand: "Dell", Model: "XPS", SerialNo: "SN-D", PurchaseDate: date(2026, 1, 10), DisposedAt: &sold, Cost: 120000, UsefulLifeMonths: months(12), Proceeds: 70000},
+		// bought back for less
+		{ID: uuid.MustParse("eeeeeeee-0000-4000-8000-000000000005"), Brand: "Apple", Model: "iPad", SerialNo: "SN-E", PurchaseDate: date(2026, 1, 10), DisposedAt: &sold, Cost: 120000, UsefulLifeMonths: months(12), Proceeds: 20000},
+	}
+	period, err := ParsePeriod("2026-07", "", time.Now())
+	require.NoError(t, err)
+
+	entries := BuildJournal(assets, period, testConfig)
+	require.Len(t, entries, 2)
+	assert.Equal(t, []JournalLine{
+		{Account: "Accumulated Depreciation", Debit: 60000, Memo: "Dell XPS (SN-D)"},
+		{Account: "Accounts Receivable", Debit: 70000, Memo: "Dell XPS (SN-D)"},
+		{Account: "Computer Equipment", Credit: 120000, Memo: "Dell XPS (SN-D)"},
+		{Account: "Gain on Disposal of Assets", Credit: 10000, Memo: "Dell XPS (SN-D)"},
+	}, entries[0].Lines)
+	assert.Equal(t, []JournalLine{
+		{Account: "Accumulated Depreciation", Debit: 60000, Memo: "Apple iPad (SN-E)"},
+		{Account: "Accounts Receivable", Debit: 20000, Memo: "Apple iPad (SN-E)"},
+		{Account: "Loss on Disposal of Assets", Debit: 40000, Memo: "Apple iPad (SN-E)"},
+		{Account: "Computer Equipment", Credit: 120000, Memo: "Apple iPad (SN-E)"},
+	}, entries[1].Lines)
+}
+
+func TestDepreciationAddsUp(t *testing.T) {
+	var total int64
+	for n := 1; n <= 7; n++ {
+		total += depreciatedAfter(100000, n, 7) - depreciatedAfter(100000, n-1, 7)
+	}
+	assert.Equal(t, int64(100000), total)
+	assert.Equal(t, int64(100000), depreciatedAfter(100000, 9, 7))
+}
+
+func TestJournalRows(t *testing.T) {
+	entries := []JournalEntry{{Number: "FA-DEP-202609", Date: date(2026, 9, 30), Memo: "Depreciation for September 2026", Lines: []JournalLine{
+		{Account: "Depreciation Expense", Debit: 123405, Memo: "Dell Latitude (SN-A)"},
+		{Account: "Accumulated Depreciation", Credit: 123405, Memo: "Dell Latitude (SN-A)"},
+	}}}
+
+	header, rows := JournalRows(models.AccountingFormatQuickBooks, entries, testConfig)
+	assert.Equal(t, quickBooksHeader, header)
+	assert.Equal(t, []string{"FA-DEP-202609", "09/30/2026", "INR", "Depreciation for September 2026", "Depreciation Expense", "1234.05", "", "Dell Latitude (SN-A)"}, rows[0])
+
+	header, rows = JournalRows(models.AccountingFormatNetSuite, entries, testConfig)
+	assert.Equal(t, netSuiteHeader, header)
+	assert.Equal(t, []string{"FA-DEP-202609", "09/30/2026", "RemoteState India", "INR", "Depreciation for September 2026", "Accumulated Depreciation", "", "1234.05", "Dell Latitude (SN-A)"}, rows[1])
+}
+
+func TestParsePeriod(t *testing.T) {
+	now := date(2026, 10, 18)
+	period, err := ParsePeriod("", "", now)
+	require.NoError(t, err)
+	assert.Equal(t, Period{From: date(2026, 9, 1), To: date(2026, 10, 1)}, period)
+
+	period, err = ParsePeriod("2026-01", "2026-03", now)
+	require.NoError(t, err)
+	assert.Equal(t, Period{From: date(2026, 1, 1), To: date(2026, 4, 1)}, period)
+
+	for _, bad := range [][2]string{{"2026-03", "2026-01"}, {"2026-13", ""}, {"2023-01", "2026-01"}} {
+		_, err = ParsePeriod(bad[0], bad[1], now)
+		assert.ErrorIs(t, err, ErrInvalidPeriod, bad)
+	}
+}
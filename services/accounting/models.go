@@ -0,0 +1,54 @@
+package accountingservice
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// kinds of fixed asset journals
+const (
+	JournalAcquisition  = "acquisition"
+	JournalDepreciation = "depreciation"
+	JournalDisposal     = "disposal"
+)
+
+// AccountingAsset is an asset with a cost, amounts are in the currency's minor unit so depreciation
+// adds up to the cent
+type AccountingAsset struct {
+	ID               uuid.UUID  `db:"id"`
+	Brand            string     `db:"brand"`
+	Model            string     `db:"model"`
+	SerialNo         string     `db:"serial_no"`
+	PurchaseDate     time.Time  `db:"purchase_date"`
+	DisposedAt       *time.Time `db:"archived_at"`
+	Cost             int64      `db:"cost"`
+	SalvageValue     int64      `db:"salvage_value"`
+	UsefulLifeMonths *int       `db:"useful_life_months"`
+	// what the asset was sold or bought back for, 0 when it was written off
+	Proceeds int64 `db:"proceeds"`
+}
+
+// JournalEntry is one balanced journal, Number is stable so importing the same period twice is caught
+// as a duplicate by the accounting system
+type JournalEntry struct {
+	Number string
+	Kind   string
+	Date   time.Time
+	Memo   string
+	Lines  []JournalLine
+}
+
+// JournalLine debits or credits one account, the other amount is 0
+type JournalLine struct {
+	Account string
+	Debit   int64
+	Credit  int64
+	Memo    string
+}
+
+// Period is the months from From up to but not including To
+type Period struct {
+	From time.Time
+	To   time.Time
+}
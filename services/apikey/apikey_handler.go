@@ -0,0 +1,207 @@
+package apikeyservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type APIKeyHandler struct {
+	Service        APIKeyService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewAPIKeyHandler(service APIKeyService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *APIKeyHandler {
+	return &APIKeyHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+func (h *APIKeyHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetAPIKeys request received")
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve scope in GetAPIKeys", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve organization")
+		return
+	}
+	keys, err := h.Service.GetAPIKeys(r.Context(), scope.OrganizationID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch api keys", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch api keys")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"api_keys": keys})
+}
+
+// CreateAPIKey issues a key owned by the caller, the full key is only in this response
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("CreateAPIKey request received")
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in CreateAPIKey", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	var req CreateAPIKeyReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in CreateAPIKey", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in CreateAPIKey", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in CreateAPIKey", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	key, err := h.Service.CreateAPIKey(r.Context(), req, userUUID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to create api key", zap.String("name", req.Name), zap.Error(err))
+		switch {
+		case errors.Is(err, ErrScopeNotAllowed), errors.Is(err, ErrScopeNotGranted):
+			utils.RespondError(w, http.StatusForbidden, err, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, err, "failed to create api key")
+		}
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+		"message": "api key created, store it now as it won't be shown again",
+		"api_key": key,
+	})
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("RevokeAPIKey request received")
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in RevokeAPIKey", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	id := r.URL.Query().Get("id")
+	keyID, err := uuid.Parse(id)
+	if err != nil {
+		h.Logger.GetLogger().Error("Invalid id in RevokeAPIKey", zap.String("id", id), zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid id")
+		return
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in RevokeAPIKey", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve scope in RevokeAPIKey", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve organization")
+		return
+	}
+	if err := h.Service.RevokeAPIKey(r.Context(), keyID, userUUID, scope.OrganizationID); err != nil {
+		h.Logger.GetLogger().Error("Failed to revoke api key", zap.String("id", id), zap.Error(err))
+		switch {
+		case errors.Is(err, ErrAPIKeyNotFound):
+			utils.RespondError(w, http.StatusNotFound, err, err.Error())
+		case errors.Is(err, ErrAPIKeyAlreadyRevoked):
+			utils.RespondError(w, http.StatusConflict, err, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, err, "failed to revoke api key")
+		}
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "api key revoked successfully"})
+}
+
+// UpdateAPIKeyLimits overrides the per minute rate limit and monthly quota of a key, null falls back to the defaults
+func (h *APIKeyHandler) UpdateAPIKeyLimits(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("UpdateAPIKeyLimits request received")
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in UpdateAPIKeyLimits", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	id := r.URL.Query().Get("id")
+	keyID, err := uuid.Parse(id)
+	if err != nil {
+		h.Logger.GetLogger().Error("Invalid id in UpdateAPIKeyLimits", zap.String("id", id), zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid id")
+		return
+	}
+
+	var req UpdateAPIKeyLimitsReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in UpdateAPIKeyLimits", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in UpdateAPIKeyLimits", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in UpdateAPIKeyLimits", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve scope in UpdateAPIKeyLimits", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve organization")
+		return
+	}
+	if err := h.Service.UpdateAPIKeyLimits(r.Context(), keyID, req, userUUID, scope.OrganizationID); err != nil {
+		h.Logger.GetLogger().Error("Failed to update api key limits", zap.String("id", id), zap.Error(err))
+		switch {
+		case errors.Is(err, ErrAPIKeyNotFound):
+			utils.RespondError(w, http.StatusNotFound, err, err.Error())
+		case errors.Is(err, ErrAPIKeyAlreadyRevoked):
+			utils.RespondError(w, http.StatusConflict, err, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, err, "failed to update api key limits")
+		}
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "api key limits updated successfully"})
+}
+
+// GetAPIKeyUsage reports requests, throttled requests and rejected requests per key for ?month=2006-01, defaults to this month
+func (h *APIKeyHandler) GetAPIKeyUsage(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetAPIKeyUsage request received")
+	month := r.URL.Query().Get("month")
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve scope in GetAPIKeyUsage", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve organization")
+		return
+	}
+	usage, err := h.Service.GetUsage(r.Context(), month, scope.OrganizationID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch api key usage", zap.String("month", month), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch api key usage")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"usage": usage})
+}
@@ -0,0 +1,123 @@
+package apikeyservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type APIKeyRepository interface {
+	InsertAPIKey(ctx context.Context, key newAPIKey) (uuid.UUID, error)
+	// organizationID leaves out keys owned by users of other organizations, nil reads every organization
+	GetAPIKeys(ctx context.Context, organizationID *uuid.UUID) ([]APIKeyRes, error)
+	GetAPIKeyByID(ctx context.Context, id uuid.UUID, organizationID *uuid.UUID) (APIKeyRes, error)
+	RevokeAPIKey(ctx context.Context, id, revokedBy uuid.UUID) error
+	UpdateAPIKeyLimits(ctx context.Context, id uuid.UUID, req UpdateAPIKeyLimitsReq) error
+	GetUserPermissions(ctx context.Context, userID uuid.UUID) ([]string, error)
+}
+
+type PostgresAPIKeyRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewAPIKeyRepository(db *sqlx.DB, log providers.ZapLoggerProvider) APIKeyRepository {
+	return &PostgresAPIKeyRepository{DB: db, Logger: log}
+}
+
+func (r *PostgresAPIKeyRepository) InsertAPIKey(ctx context.Context, key newAPIKey) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &id, `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, owner_id, expires_at, rate_limit_per_minute, monthly_quota)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, key.Name, key.Prefix, key.KeyHash, pq.Array(key.Scopes), key.OwnerID, key.ExpiresAt, key.RateLimitPerMinute, key.MonthlyQuota)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert api key", zap.String("prefix", key.Prefix), zap.Error(err))
+		return uuid.Nil, fmt.Errorf("failed to insert api key: %w", err)
+	}
+	return id, nil
+}
+
+const apiKeyColumns = `
+	SELECT k.id, k.name, k.prefix, k.scopes, k.owner_id, u.username AS owner_name,
+		k.expires_at, k.last_used_at, k.created_at, k.revoked_at, k.rate_limit_per_minute, k.monthly_quota
+	FROM api_keys k
+	JOIN users u ON u.id = k.owner_id
+`
+
+func (r *PostgresAPIKeyRepository) GetAPIKeys(ctx context.Context, organizationID *uuid.UUID) ([]APIKeyRes, error) {
+	keys := make([]APIKeyRes, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &keys, apiKeyColumns+`
+		WHERE ($1::uuid IS NULL OR u.organization_id = $1)
+		ORDER BY k.created_at DESC
+	`, organizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch api keys", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch api keys: %w", err)
+	}
+	return keys, nil
+}
+
+func (r *PostgresAPIKeyRepository) GetAPIKeyByID(ctx context.Context, id uuid.UUID, organizationID *uuid.UUID) (APIKeyRes, error) {
+	var key APIKeyRes
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &key, apiKeyColumns+`
+		WHERE k.id = $1 AND ($2::uuid IS NULL OR u.organization_id = $2)
+	`, id, organizationID)
+	if err != nil {
+		return APIKeyRes{}, fmt.Errorf("failed to fetch api key: %w", err)
+	}
+	return key, nil
+}
+
+func (r *PostgresAPIKeyRepository) RevokeAPIKey(ctx context.Context, id, revokedBy uuid.UUID) error {
+	result, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = now(), revoked_by = $2
+		WHERE id = $1 AND revoked_at IS NULL
+	`, id, revokedBy)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to revoke api key", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrAPIKeyAlreadyRevoked
+	}
+	return nil
+}
+
+func (r *PostgresAPIKeyRepository) UpdateAPIKeyLimits(ctx context.Context, id uuid.UUID, req UpdateAPIKeyLimitsReq) error {
+	result, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE api_keys SET rate_limit_per_minute = $2, monthly_quota = $3
+		WHERE id = $1 AND revoked_at IS NULL
+	`, id, req.RateLimitPerMinute, req.MonthlyQuota)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to update api key limits", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to update api key limits: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrAPIKeyAlreadyRevoked
+	}
+	return nil
+}
+
+// GetUserPermissions only counts the user's own roles, a delegation ends but a key would outlive it
+func (r *PostgresAPIKeyRepository) GetUserPermissions(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	permissions := make([]string, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &permissions, `
+		SELECT DISTINCT rp.permission
+		FROM role_permissions rp
+		JOIN user_roles ur ON ur.role = rp.role AND ur.archived_at IS NULL
+		WHERE ur.user_id = $1 AND rp.archived_at IS NULL
+	`, userID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch user permissions", zap.String("userID", userID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch user permissions: %w", err)
+	}
+	return permissions, nil
+}
@@ -0,0 +1,255 @@
+package apikeyservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	ratelimitprovider "asset/providers/rateLimitProvider"
+	"asset/services/audit"
+	"asset/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, req CreateAPIKeyReq, ownerID uuid.UUID) (CreateAPIKeyRes, error)
+	// organizationID is the caller's scope.OrganizationID, keys owned in other organizations look missing
+	GetAPIKeys(ctx context.Context, organizationID *uuid.UUID) ([]APIKeyRes, error)
+	RevokeAPIKey(ctx context.Context, id, adminID uuid.UUID, organizationID *uuid.UUID) error
+	UpdateAPIKeyLimits(ctx context.Context, id uuid.UUID, req UpdateAPIKeyLimitsReq, adminID uuid.UUID, organizationID *uuid.UUID) error
+	// GetUsage reports the requests of every key that existed in month, formatted 2006-01 in UTC
+	GetUsage(ctx context.Context, month string, organizationID *uuid.UUID) ([]APIKeyUsage, error)
+}
+
+type apiKeyServiceStruct struct {
+	repo   APIKeyRepository
+	db     *sqlx.DB
+	logger providers.ZapLoggerProvider
+	audit  auditservice.AuditService
+	// usage counters are kept in redis by the rate limiter, the defaults of the limits come from config
+	redis  providers.RedisProvider
+	config providers.ConfigProvider
+}
+
+func NewAPIKeyService(repo APIKeyRepository, db *sqlx.DB, logger providers.ZapLoggerProvider, audit auditservice.AuditService, redis providers.RedisProvider, config providers.ConfigProvider) APIKeyService {
+	return &apiKeyServiceStruct{repo: repo, db: db, logger: logger, audit: audit, redis: redis, config: config}
+}
+
+const defaultAPIKeyLifetime = 90 * 24 * time.Hour
+
+var (
+	ErrAPIKeyNotFound       = models.NewServiceError(http.StatusNotFound, "api_key_not_found", "api key not found")
+	ErrAPIKeyAlreadyRevoked = models.NewServiceError(http.StatusConflict, "api_key_already_revoked", "api key is already revoked")
+	// a key that could issue keys would outlive its own revocation
+	ErrScopeNotAllowed = models.NewServiceError(http.StatusForbidden, "scope_not_allowed", "scope can't be granted to an api key")
+	ErrScopeNotGranted = models.NewServiceError(http.StatusForbidden, "scope_not_granted", "you can only grant scopes you hold yourself")
+	ErrInvalidMonth    = models.NewServiceError(http.StatusBadRequest, "invalid_month", "month must look like 2006-01")
+)
+
+func (s *apiKeyServiceStruct) CreateAPIKey(ctx context.Context, req CreateAPIKeyReq, ownerID uuid.UUID) (res CreateAPIKeyRes, err error) {
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if slices.Contains(scopes, string(models.APIKeyManagePermission)) {
+		return CreateAPIKeyRes{}, fmt.Errorf("%w: %s", ErrScopeNotAllowed, models.APIKeyManagePermission)
+	}
+	held, err := s.repo.GetUserPermissions(ctx, ownerID)
+	if err != nil {
+		return CreateAPIKeyRes{}, err
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(held, scope) {
+			return CreateAPIKeyRes{}, fmt.Errorf("%w: %s", ErrScopeNotGranted, scope)
+		}
+	}
+
+	key, prefix, err := utils.GenerateAPIKey()
+	if err != nil {
+		return CreateAPIKeyRes{}, fmt.Errorf("failed to generate api key: %w", err)
+	}
+	lifetime := defaultAPIKeyLifetime
+	if req.ExpiresInDays > 0 {
+		lifetime = time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	}
+	expiresAt := time.Now().Add(lifetime)
+
+	var id uuid.UUID
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		var err error
+		id, err = s.repo.InsertAPIKey(ctx, newAPIKey{
+			Name:      req.Name,
+			Prefix:    prefix,
+			KeyHash:   utils.HashAPIKey(key),
+			Scopes:    scopes,
+			OwnerID:   ownerID,
+			ExpiresAt: expiresAt,
+
+			RateLimitPerMinute: req.RateLimitPerMinute,
+			MonthlyQuota:       req.MonthlyQuota,
+		})
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, auditservice.AuditEntry{
+			ActorID:    &ownerID,
+			Action:     "api_key.created",
+			EntityType: "api_key",
+			EntityID:   id.String(),
+			NewValue: map[string]interface{}{"name": req.Name, "prefix": prefix, "scopes": scopes, "expires_at": expiresAt,
+				"rate_limit_per_minute": req.RateLimitPerMinute, "monthly_quota": req.MonthlyQuota},
+		})
+	})
+	if err != nil {
+		return CreateAPIKeyRes{}, err
+	}
+	s.logger.GetLogger().Info("api key created", zap.String("id", id.String()), zap.String("prefix", prefix))
+
+	return CreateAPIKeyRes{
+		APIKeyRes: APIKeyRes{
+			ID:        id,
+			Name:      req.Name,
+			Prefix:    prefix,
+			Scopes:    scopes,
+			OwnerID:   ownerID,
+			ExpiresAt: expiresAt,
+			CreatedAt: time.Now(),
+
+			RateLimitPerMinute: req.RateLimitPerMinute,
+			MonthlyQuota:       req.MonthlyQuota,
+		},
+		Key: key,
+	}, nil
+}
+
+func (s *apiKeyServiceStruct) GetAPIKeys(ctx context.Context, organizationID *uuid.UUID) ([]APIKeyRes, error) {
+	return s.repo.GetAPIKeys(ctx, organizationID)
+}
+
+func (s *apiKeyServiceStruct) RevokeAPIKey(ctx context.Context, id, adminID uuid.UUID, organizationID *uuid.UUID) (err error) {
+	key, err := s.repo.GetAPIKeyByID(ctx, id, organizationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAPIKeyNotFound
+		}
+		return err
+	}
+
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		if err := s.repo.RevokeAPIKey(ctx, id, adminID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, auditservice.AuditEntry{
+			ActorID:    &adminID,
+			Action:     "api_key.revoked",
+			EntityType: "api_key",
+			EntityID:   id.String(),
+			OldValue:   key,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("api key revoked", zap.String("id", id.String()), zap.String("prefix", key.Prefix))
+	return nil
+}
+
+// UpdateAPIKeyLimits applies from the key's next request, requests already counted this month stay
+// counted against a new quota
+func (s *apiKeyServiceStruct) UpdateAPIKeyLimits(ctx context.Context, id uuid.UUID, req UpdateAPIKeyLimitsReq, adminID uuid.UUID, organizationID *uuid.UUID) (err error) {
+	key, err := s.repo.GetAPIKeyByID(ctx, id, organizationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAPIKeyNotFound
+		}
+		return err
+	}
+
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		if err := s.repo.UpdateAPIKeyLimits(ctx, id, req); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, auditservice.AuditEntry{
+			ActorID:    &adminID,
+			Action:     "api_key.limits_changed",
+			EntityType: "api_key",
+			EntityID:   id.String(),
+			OldValue:   map[string]interface{}{"rate_limit_per_minute": key.RateLimitPerMinute, "monthly_quota": key.MonthlyQuota},
+			NewValue:   map[string]interface{}{"rate_limit_per_minute": req.RateLimitPerMinute, "monthly_quota": req.MonthlyQuota},
+		})
+	})
+	if err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("api key limits changed", zap.String("id", id.String()), zap.String("prefix", key.Prefix))
+	return nil
+}
+
+func (s *apiKeyServiceStruct) GetUsage(ctx context.Context, month string, organizationID *uuid.UUID) ([]APIKeyUsage, error) {
+	if month == "" {
+		month = time.Now().UTC().Format("2006-01")
+	}
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return nil, ErrInvalidMonth
+	}
+	end := start.AddDate(0, 1, 0)
+	keys, err := s.repo.GetAPIKeys(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := s.config.GetRateLimits()
+	usage := make([]APIKeyUsage, 0, len(keys))
+	counters := make([]string, 0, len(keys)*3)
+	for _, key := range keys {
+		if !key.CreatedAt.Before(end) || (key.RevokedAt != nil && key.RevokedAt.Before(start)) {
+			continue
+		}
+		row := APIKeyUsage{ID: key.ID, Name: key.Name, Prefix: key.Prefix, OwnerName: key.OwnerName, RevokedAt: key.RevokedAt,
+			RateLimitPerMinute: limits.APIKey.PerMinute, MonthlyQuota: limits.APIKeyMonthlyQuota}
+		if key.RateLimitPerMinute != nil {
+			row.RateLimitPerMinute = *key.RateLimitPerMinute
+		}
+		if key.MonthlyQuota != nil {
+			row.MonthlyQuota = *key.MonthlyQuota
+		}
+		usage = append(usage, row)
+		id := key.ID.String()
+		counters = append(counters,
+			ratelimitprovider.APIKeyUsageKey(id, month, ratelimitprovider.UsageRequests),
+			ratelimitprovider.APIKeyUsageKey(id, month, ratelimitprovider.UsageThrottled),
+			ratelimitprovider.APIKeyUsageKey(id, month, ratelimitprovider.UsageOverQuota))
+	}
+	if len(usage) == 0 {
+		return usage, nil
+	}
+
+	values, err := s.redis.MGet(ctx, counters...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read api key usage: %w", err)
+	}
+	count := func(i int) int64 {
+		n, _ := strconv.ParseInt(values[i], 10, 64)
+		return n
+	}
+	for i := range usage {
+		usage[i].Requests, usage[i].Throttled, usage[i].OverQuota = count(i*3), count(i*3+1), count(i*3+2)
+		if usage[i].MonthlyQuota > 0 {
+			remaining := max(int64(usage[i].MonthlyQuota)-usage[i].Requests, 0)
+			usage[i].QuotaRemaining = &remaining
+		}
+	}
+	return usage, nil
+}
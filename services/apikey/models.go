@@ -0,0 +1,74 @@
+package apikeyservice
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type CreateAPIKeyReq struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,required"`
+	// keys always expire, defaults to defaultAPIKeyLifetime
+	ExpiresInDays int `json:"expires_in_days" validate:"omitempty,min=1,max=365"`
+	// unset keys get RATE_LIMIT_API_KEY_PER_MINUTE and API_KEY_MONTHLY_QUOTA
+	RateLimitPerMinute *int `json:"rate_limit_per_minute" validate:"omitempty,min=1,max=100000"`
+	MonthlyQuota       *int `json:"monthly_quota" validate:"omitempty,min=1"`
+}
+
+// UpdateAPIKeyLimitsReq replaces both limits of a key, null puts one back to the default
+type UpdateAPIKeyLimitsReq struct {
+	RateLimitPerMinute *int `json:"rate_limit_per_minute" validate:"omitempty,min=1,max=100000"`
+	MonthlyQuota       *int `json:"monthly_quota" validate:"omitempty,min=1"`
+}
+
+type APIKeyRes struct {
+	ID         uuid.UUID      `json:"id" db:"id"`
+	Name       string         `json:"name" db:"name"`
+	Prefix     string         `json:"prefix" db:"prefix"`
+	Scopes     pq.StringArray `json:"scopes" db:"scopes"`
+	OwnerID    uuid.UUID      `json:"owner_id" db:"owner_id"`
+	OwnerName  string         `json:"owner_name" db:"owner_name"`
+	ExpiresAt  time.Time      `json:"expires_at" db:"expires_at"`
+	LastUsedAt *time.Time     `json:"last_used_at,omitempty" db:"last_used_at"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+	RevokedAt  *time.Time     `json:"revoked_at,omitempty" db:"revoked_at"`
+	// nil when the key uses the default
+	RateLimitPerMinute *int `json:"rate_limit_per_minute,omitempty" db:"rate_limit_per_minute"`
+	MonthlyQuota       *int `json:"monthly_quota,omitempty" db:"monthly_quota"`
+}
+
+// CreateAPIKeyRes is the only response that carries the full key
+type CreateAPIKeyRes struct {
+	APIKeyRes
+	Key string `json:"key"`
+}
+
+type newAPIKey struct {
+	Name      string
+	Prefix    string
+	KeyHash   string
+	Scopes    []string
+	OwnerID   uuid.UUID
+	ExpiresAt time.Time
+
+	RateLimitPerMinute *int
+	MonthlyQuota       *int
+}
+
+// APIKeyUsage is what a key did in a calendar month in UTC with the limits it has now. MonthlyQuota is
+// 0 for keys without a quota, QuotaRemaining is only set for the others
+type APIKeyUsage struct {
+	ID                 uuid.UUID  `json:"id"`
+	Name               string     `json:"name"`
+	Prefix             string     `json:"prefix"`
+	OwnerName          string     `json:"owner_name"`
+	RevokedAt          *time.Time `json:"revoked_at,omitempty"`
+	RateLimitPerMinute int        `json:"rate_limit_per_minute"`
+	MonthlyQuota       int        `json:"monthly_quota"`
+	Requests           int64      `json:"requests"`
+	Throttled          int64      `json:"throttled"`
+	OverQuota          int64      `json:"over_quota"`
+	QuotaRemaining     *int64     `json:"quota_remaining,omitempty"`
+}
@@ -4,11 +4,13 @@ import (
 	"asset/models"
 	"asset/providers"
 	"asset/utils"
-	"encoding/json"
-	"github.com/go-playground/validator/v10"
+	"errors"
 	"github.com/google/uuid"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type AssetHandler struct {
@@ -36,14 +38,19 @@ func (h *AssetHandler) AddNewAssetWithConfig(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	if err := validator.New().Struct(req); err != nil {
+	if err := utils.ValidateStruct(req); err != nil {
 		utils.RespondError(w, http.StatusBadRequest, err, "validation error")
 		return
 	}
 
 	userID, _ := uuid.Parse(userIDStr)
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
 
-	err = h.Service.AddAssetWithConfig(r.Context(), req, userID)
+	err = h.Service.AddAssetWithConfig(r.Context(), req, userID, scope)
 	if err != nil {
 		utils.RespondError(w, http.StatusInternalServerError, err, "failed to add asset")
 		return
@@ -56,9 +63,9 @@ func (h *AssetHandler) AddNewAssetWithConfig(w http.ResponseWriter, r *http.Requ
 }
 
 func (h *AssetHandler) AssignAssetToUser(w http.ResponseWriter, r *http.Request) {
-	managerID, roles, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
-	if err != nil || (roles[0] != "admin" && roles[0] != "asset_manager") {
-		utils.RespondError(w, http.StatusForbidden, err, "permission denied")
+	managerID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
 		return
 	}
 
@@ -67,14 +74,27 @@ func (h *AssetHandler) AssignAssetToUser(w http.ResponseWriter, r *http.Request)
 		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
 		return
 	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "validation error")
+		return
+	}
 
 	assetID, _ := uuid.Parse(req.AssetID)
 	userID, _ := uuid.Parse(req.UserID)
 	managerUUID, _ := uuid.Parse(managerID)
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
 
-	err = h.Service.AssignAsset(r.Context(), assetID, userID, managerUUID)
+	err = h.Service.AssignAsset(r.Context(), assetID, userID, managerUUID, req.Metadata, req.LoanUntil, scope)
 	if err != nil {
-		if strings.Contains(err.Error(), "already assigned") {
+		if errors.Is(err, models.ErrOutOfScope) {
+			utils.RespondError(w, http.StatusForbidden, err, "asset belongs to another department")
+			return
+		}
+		if errors.Is(err, ErrAssetAlreadyAssigned) {
 			utils.RespondError(w, http.StatusConflict, err, "asset already assigned")
 			return
 		}
@@ -82,20 +102,26 @@ func (h *AssetHandler) AssignAssetToUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+	res := map[string]interface{}{
 		"message":     "asset assigned successfully",
 		"user_id":     userID,
 		"asset_id":    assetID,
 		"assigned_by": managerUUID,
-	})
+		"mode":        models.AssignmentPermanent,
+	}
+	if req.LoanUntil != nil {
+		res["mode"], res["loan_until"] = models.AssignmentLoan, req.LoanUntil
+	}
+	utils.RespondJSON(w, http.StatusCreated, res)
 }
 
 func (h *AssetHandler) DeleteAsset(w http.ResponseWriter, r *http.Request) {
-	_, roles, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
-	if err != nil || (roles[0] != "admin" && roles[0] != "asset_manager") {
-		utils.RespondError(w, http.StatusForbidden, err, "permission denied")
+	userIDStr, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
 		return
 	}
+	userID, _ := uuid.Parse(userIDStr)
 
 	assetIDStr := r.URL.Query().Get("asset_id")
 	assetID, err := uuid.Parse(assetIDStr)
@@ -104,9 +130,19 @@ func (h *AssetHandler) DeleteAsset(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.Service.DeleteAsset(r.Context(), assetID)
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	err = h.Service.DeleteAsset(r.Context(), assetID, userID, scope)
 	if err != nil {
-		if err.Error() == "asset currently assigned to a user" {
+		if errors.Is(err, models.ErrOutOfScope) {
+			utils.RespondError(w, http.StatusForbidden, err, "asset belongs to another department")
+			return
+		}
+		if errors.Is(err, ErrAssetAssigned) {
 			utils.RespondError(w, http.StatusConflict, err, "asset is currently assigned")
 			return
 		}
@@ -117,13 +153,79 @@ func (h *AssetHandler) DeleteAsset(w http.ResponseWriter, r *http.Request) {
 	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "asset deleted successfully"})
 }
 
-func (h *AssetHandler) GetAllAssetsWithFilters(w http.ResponseWriter, r *http.Request) {
-	_, roles, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
-	if err != nil || (roles[0] != "admin" && roles[0] != "asset_manager") {
-		utils.RespondError(w, http.StatusForbidden, err, "permission denied")
+// DisposeAsset retires an asset that was sold or bought back and records what was realized for it
+func (h *AssetHandler) DisposeAsset(w http.ResponseWriter, r *http.Request) {
+	userIDStr, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	userID, _ := uuid.Parse(userIDStr)
+
+	var req models.DisposeAssetReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "validation failed")
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	disposal, err := h.Service.DisposeAsset(r.Context(), req, userID, scope)
+	if err != nil {
+		if errors.Is(err, models.ErrOutOfScope) {
+			utils.RespondError(w, http.StatusForbidden, err, "asset belongs to another department")
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to dispose of asset")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusCreated, disposal)
+}
+
+// GetAssetDisposal returns who a retired asset was sold to and for how much
+func (h *AssetHandler) GetAssetDisposal(w http.ResponseWriter, r *http.Request) {
+	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	assetID, err := uuid.Parse(r.URL.Query().Get("asset_id"))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid asset id")
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	disposal, err := h.Service.GetAssetDisposal(r.Context(), assetID, scope)
+	if err != nil {
+		if errors.Is(err, models.ErrOutOfScope) {
+			utils.RespondError(w, http.StatusForbidden, err, "asset belongs to another department")
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch asset disposal")
 		return
 	}
 
+	utils.RespondJSON(w, http.StatusOK, disposal)
+}
+
+// parseAssetFilter reads the filters shared by the asset list and its stream from the query
+func parseAssetFilter(r *http.Request) models.AssetFilter {
 	var filter models.AssetFilter
 	filter.SearchText = r.URL.Query().Get("search")
 	if filter.SearchText != "" {
@@ -139,8 +241,23 @@ func (h *AssetHandler) GetAllAssetsWithFilters(w http.ResponseWriter, r *http.Re
 	if val := r.URL.Query().Get("type"); val != "" {
 		filter.Type = strings.Split(val, ",")
 	}
+	return filter
+}
+
+func (h *AssetHandler) GetAllAssetsWithFilters(w http.ResponseWriter, r *http.Request) {
+	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
 
+	filter := parseAssetFilter(r)
 	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+	filter.Scope, err = h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
 
 	assets, err := h.Service.GetAllAssetsWithFilters(r.Context(), filter)
 	if err != nil {
@@ -151,6 +268,34 @@ func (h *AssetHandler) GetAllAssetsWithFilters(w http.ResponseWriter, r *http.Re
 	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"assets": assets})
 }
 
+// StreamAssets writes every asset the list filters match as newline delimited json, a page at a time,
+// for exports too large to hold
+func (h *AssetHandler) StreamAssets(w http.ResponseWriter, r *http.Request) {
+	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	filter := parseAssetFilter(r)
+	filter.Scope, err = h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	stream := utils.NewNDJSONWriter(w, "assets_"+time.Now().Format("20060102"))
+	err = h.Service.StreamAssets(r.Context(), filter, func(asset models.AssetWithConfigRes) error {
+		return stream.Write(asset)
+	})
+	stream.Close(err, "failed to export assets")
+}
+
+// GetAssetTypes describes the config fields of every asset type, clients build the add and edit forms from it
+func (h *AssetHandler) GetAssetTypes(w http.ResponseWriter, r *http.Request) {
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"asset_types": assetTypeSchemas()})
+}
+
 func (h *AssetHandler) GetAssetTimeline(w http.ResponseWriter, r *http.Request) {
 	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
 	if err != nil {
@@ -165,8 +310,18 @@ func (h *AssetHandler) GetAssetTimeline(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	timeline, err := h.Service.GetAssetTimeline(r.Context(), assetID)
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	timeline, err := h.Service.GetAssetTimeline(r.Context(), assetID, scope)
 	if err != nil {
+		if errors.Is(err, models.ErrOutOfScope) {
+			utils.RespondError(w, http.StatusForbidden, err, "asset belongs to another department")
+			return
+		}
 		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch asset timeline")
 		return
 	}
@@ -178,9 +333,9 @@ func (h *AssetHandler) GetAssetTimeline(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *AssetHandler) ReceivedFromService(w http.ResponseWriter, r *http.Request) {
-	_, roles, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
-	if err != nil || (roles[0] != "admin" && roles[0] != "asset_manager") {
-		utils.RespondError(w, http.StatusForbidden, err, "permission denied")
+	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
 		return
 	}
 
@@ -191,9 +346,32 @@ func (h *AssetHandler) ReceivedFromService(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	err = h.Service.ReceiveAssetFromService(r.Context(), assetID)
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	// the cost is optional, so is the body
+	var req models.ReceiveFromServiceReq
+	if r.ContentLength != 0 {
+		if err := utils.ParseJSONBody(r, &req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+			return
+		}
+		if err := utils.ValidateStruct(req); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, "validation failed")
+			return
+		}
+	}
+
+	err = h.Service.ReceiveAssetFromService(r.Context(), assetID, req.Cost, scope)
 	if err != nil {
-		utils.RespondError(w, http.StatusBadRequest, err, err.Error())
+		if errors.Is(err, models.ErrOutOfScope) {
+			utils.RespondError(w, http.StatusForbidden, err, "asset belongs to another department")
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to receive asset from service")
 		return
 	}
 
@@ -203,10 +381,11 @@ func (h *AssetHandler) ReceivedFromService(w http.ResponseWriter, r *http.Reques
 	})
 }
 
+// RetrieveAsset closes an assignment, the optional inspection is stored against the return
 func (h *AssetHandler) RetrieveAsset(w http.ResponseWriter, r *http.Request) {
-	_, roles, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
-	if err != nil || (roles[0] != "admin" && roles[0] != "asset_manager") {
-		utils.RespondError(w, http.StatusForbidden, err, "permission denied")
+	userIDStr, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
 		return
 	}
 
@@ -215,10 +394,26 @@ func (h *AssetHandler) RetrieveAsset(w http.ResponseWriter, r *http.Request) {
 		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
 		return
 	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "validation failed")
+		return
+	}
+	userID, _ := uuid.Parse(userIDStr)
+	req.ReturnedBy = &userID
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
 
-	err = h.Service.RetrieveAsset(r.Context(), req)
+	err = h.Service.RetrieveAsset(r.Context(), req, scope)
 	if err != nil {
-		if strings.Contains(err.Error(), "no matching asset assignment found") {
+		if errors.Is(err, models.ErrOutOfScope) {
+			utils.RespondError(w, http.StatusForbidden, err, "asset belongs to another department")
+			return
+		}
+		if errors.Is(err, ErrAssignmentNotFound) {
 			utils.RespondError(w, http.StatusNotFound, err, "no such asset or already returned")
 			return
 		}
@@ -229,65 +424,837 @@ func (h *AssetHandler) RetrieveAsset(w http.ResponseWriter, r *http.Request) {
 	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "asset returned successfully"})
 }
 
+// GetReturnInspections lists what was found each time the asset came back, newest first
+func (h *AssetHandler) GetReturnInspections(w http.ResponseWriter, r *http.Request) {
+	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	assetID, err := uuid.Parse(r.URL.Query().Get("asset_id"))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid asset id")
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	inspections, err := h.Service.GetReturnInspections(r.Context(), assetID, scope)
+	if err != nil {
+		if errors.Is(err, models.ErrOutOfScope) {
+			utils.RespondError(w, http.StatusForbidden, err, "asset belongs to another department")
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch return inspections")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"asset_id":    assetID,
+		"inspections": inspections,
+	})
+}
+
 func (h *AssetHandler) SendAssetToService(w http.ResponseWriter, r *http.Request) {
-	managerIDStr, roles, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
-	if err != nil || (roles[0] != "admin" && roles[0] != "asset_manager") {
-		utils.RespondError(w, http.StatusForbidden, err, "permission denied")
+	managerIDStr, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
 		return
 	}
 
 	var req models.AssetServiceReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err := utils.JSON.NewDecoder(r.Body).Decode(&req); err != nil {
 		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
 		return
 	}
 
-	if err := validator.New().Struct(req); err != nil {
+	if err := utils.ValidateStruct(req); err != nil {
 		utils.RespondError(w, http.StatusBadRequest, err, "validation failed")
 		return
 	}
 
 	managerID, _ := uuid.Parse(managerIDStr)
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
 
-	if err := h.Service.SendAssetToService(r.Context(), req, managerID); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, err, err.Error())
+	if err := h.Service.SendAssetToService(r.Context(), req, managerID, scope); err != nil {
+		if errors.Is(err, models.ErrOutOfScope) {
+			utils.RespondError(w, http.StatusForbidden, err, "asset belongs to another department")
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to send asset for service")
 		return
 	}
 
 	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "asset sent for servicing"})
 }
 
-func (h *AssetHandler) UpdateAssetWithConfig(w http.ResponseWriter, r *http.Request) {
-	var req models.UpdateAssetReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+// ReportStolen takes the caller's report of an asset they hold going missing
+func (h *AssetHandler) ReportStolen(w http.ResponseWriter, r *http.Request) {
+	userIDStr, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	var req models.ReportStolenReq
+	if err := utils.JSON.NewDecoder(r.Body).Decode(&req); err != nil {
 		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
 		return
 	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "validation failed")
+		return
+	}
+
+	userID, _ := uuid.Parse(userIDStr)
+	if err := h.Service.ReportStolen(r.Context(), req, userID); err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to report asset stolen")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "asset reported stolen, the asset managers have been alerted"})
+}
 
-	err := h.Service.UpdateAsset(r.Context(), req)
+// AcknowledgeAssignment is called by the employee to confirm they received an asset assigned to them
+func (h *AssetHandler) AcknowledgeAssignment(w http.ResponseWriter, r *http.Request) {
+	userIDStr, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err, "failed to update asset")
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
 		return
 	}
 
-	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "asset updated successfully"})
+	var req models.AcknowledgeAssignmentReq
+	if err := utils.JSON.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "validation failed")
+		return
+	}
+
+	userID, _ := uuid.Parse(userIDStr)
+	if err := h.Service.AcknowledgeAssignment(r.Context(), req, userID); err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to acknowledge assignment")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "assignment acknowledged"})
 }
 
-func (h *AssetHandler) UpdateAssetWithConfigHandler(w http.ResponseWriter, r *http.Request) {
-	var req models.UpdateAssetReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, err, "invalid request")
+// KioskCheckout is called by a kiosk after an employee scanned their badge and a pool asset
+func (h *AssetHandler) KioskCheckout(w http.ResponseWriter, r *http.Request) {
+	req, kioskOwnerID, scope, ok := h.kioskScan(w, r)
+	if !ok {
+		return
+	}
+	res, err := h.Service.KioskCheckout(r.Context(), req, kioskOwnerID, scope)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to check out asset")
 		return
 	}
+	utils.RespondJSON(w, http.StatusOK, res)
+}
 
-	err := h.Service.UpdateAssetWithConfig(r.Context(), req)
+// KioskReturn is called by a kiosk when an employee brings a pool asset back
+func (h *AssetHandler) KioskReturn(w http.ResponseWriter, r *http.Request) {
+	req, _, scope, ok := h.kioskScan(w, r)
+	if !ok {
+		return
+	}
+	res, err := h.Service.KioskReturn(r.Context(), req, scope)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, err, "failed to update asset")
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to return asset")
 		return
 	}
+	utils.RespondJSON(w, http.StatusOK, res)
+}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "asset updated successfully",
-	})
+// kioskScan reads the scan and who the kiosk's key belongs to, it has already responded when ok is false
+func (h *AssetHandler) kioskScan(w http.ResponseWriter, r *http.Request) (models.KioskScanReq, uuid.UUID, models.DepartmentScope, bool) {
+	userIDStr, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return models.KioskScanReq{}, uuid.Nil, models.DepartmentScope{}, false
+	}
+
+	var req models.KioskScanReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return models.KioskScanReq{}, uuid.Nil, models.DepartmentScope{}, false
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "validation failed")
+		return models.KioskScanReq{}, uuid.Nil, models.DepartmentScope{}, false
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return models.KioskScanReq{}, uuid.Nil, models.DepartmentScope{}, false
+	}
+	userID, _ := uuid.Parse(userIDStr)
+	return req, userID, scope, true
+}
+
+// CreateAttachmentUpload answers with a presigned url the client puts the file to, then it calls
+// ConfirmAttachment
+func (h *AssetHandler) CreateAttachmentUpload(w http.ResponseWriter, r *http.Request) {
+	userIDStr, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	var req models.AttachmentUploadReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "validation failed")
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	userID, _ := uuid.Parse(userIDStr)
+	upload, err := h.Service.CreateAttachmentUpload(r.Context(), req, userID, scope)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to create attachment upload")
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, upload)
+}
+
+func (h *AssetHandler) ConfirmAttachment(w http.ResponseWriter, r *http.Request) {
+	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	var req models.AttachmentConfirmReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "validation failed")
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	attachment, err := h.Service.ConfirmAttachment(r.Context(), req.ID, scope)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to confirm attachment")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, attachment)
+}
+
+func (h *AssetHandler) GetAttachments(w http.ResponseWriter, r *http.Request) {
+	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	assetID, err := uuid.Parse(r.URL.Query().Get("asset_id"))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid asset id")
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	attachments, err := h.Service.GetAttachments(r.Context(), assetID, scope)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch attachments")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"asset_id":    assetID,
+		"attachments": attachments,
+	})
+}
+
+func (h *AssetHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid attachment id")
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	if err := h.Service.DeleteAttachment(r.Context(), id, scope); err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to delete attachment")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "attachment deleted"})
+}
+
+// TicketWebhook takes status changes from the service desk. It is called without a session, the
+// token comes in the X-Ticket-Token header or as ?token= for desks that can't set headers
+func (h *AssetHandler) TicketWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	token := r.Header.Get("X-Ticket-Token")
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	if err := h.Service.ApplyTicketWebhook(r.Context(), token, body); err != nil {
+		if errors.Is(err, models.ErrTicketWebhookUnauthorized) {
+			utils.RespondError(w, http.StatusUnauthorized, err, "invalid webhook token")
+			return
+		}
+		utils.RespondError(w, http.StatusBadRequest, err, "failed to apply ticket update")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "ticket update applied"})
+}
+
+func (h *AssetHandler) UpdateAssetWithConfig(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateAssetReq
+	if err := utils.JSON.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	err = h.Service.UpdateAsset(r.Context(), req, scope)
+	if err != nil {
+		if errors.Is(err, models.ErrOutOfScope) {
+			utils.RespondError(w, http.StatusForbidden, err, "asset belongs to another department")
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to update asset")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "asset updated successfully"})
+}
+
+func (h *AssetHandler) UpdateAssetWithConfigHandler(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateAssetReq
+	if err := utils.JSON.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request")
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	err = h.Service.UpdateAssetWithConfig(r.Context(), req, scope)
+	if err != nil {
+		if errors.Is(err, models.ErrOutOfScope) {
+			utils.RespondError(w, http.StatusForbidden, err, "asset belongs to another department")
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to update asset")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	utils.JSON.NewEncoder(w).Encode(map[string]string{
+		"message": "asset updated successfully",
+	})
+}
+
+func (h *AssetHandler) GetReturnRequests(w http.ResponseWriter, r *http.Request) {
+	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	filter := models.ReturnRequestFilter{Status: r.URL.Query().Get("status")}
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+	filter.Scope, err = h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	requests, err := h.Service.GetReturnRequests(r.Context(), filter)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch return requests")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"return_requests": requests})
+}
+
+// GetLoans lists the open loans of assets in scope, due first first. overdue=true keeps the ones past
+// their end
+func (h *AssetHandler) GetLoans(w http.ResponseWriter, r *http.Request) {
+	var filter models.LoanFilter
+	if val := r.URL.Query().Get("overdue"); val != "" {
+		overdue, err := strconv.ParseBool(val)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, "invalid overdue")
+			return
+		}
+		filter.Overdue = overdue
+	}
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+	filter.Scope = scope
+
+	loans, err := h.Service.GetLoans(r.Context(), filter)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch loans")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"loans": loans, "limit": filter.Limit, "offset": filter.Offset})
+}
+
+func (h *AssetHandler) GetMDMMismatches(w http.ResponseWriter, r *http.Request) {
+	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	var filter models.MDMMismatchFilter
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+	filter.Scope, err = h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	mismatches, err := h.Service.GetMDMMismatches(r.Context(), filter)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch mdm mismatches")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"mismatches": mismatches})
+}
+
+// GetDuplicateAssets lists groups of live assets that look like the same device recorded twice
+func (h *AssetHandler) GetDuplicateAssets(w http.ResponseWriter, r *http.Request) {
+	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	var filter models.DuplicateFilter
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+	filter.Scope, err = h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	groups, err := h.Service.GetDuplicateAssets(r.Context(), filter)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch duplicate assets")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"duplicates": groups})
+}
+
+// MergeAssets folds a duplicate into the asset that is kept and archives the duplicate
+func (h *AssetHandler) MergeAssets(w http.ResponseWriter, r *http.Request) {
+	userIDStr, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	var req models.MergeAssetsReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "validation failed")
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	userID, _ := uuid.Parse(userIDStr)
+	res, err := h.Service.MergeAssets(r.Context(), req, userID, scope)
+	if err != nil {
+		if errors.Is(err, models.ErrOutOfScope) {
+			utils.RespondError(w, http.StatusForbidden, err, "asset belongs to another department")
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to merge assets")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, res)
+}
+
+// LookupWarranty asks the vendor for the warranty of a serial number so a form can fill in the dates
+// before the asset is added
+func (h *AssetHandler) LookupWarranty(w http.ResponseWriter, r *http.Request) {
+	brand, serialNo := strings.TrimSpace(r.URL.Query().Get("brand")), strings.TrimSpace(r.URL.Query().Get("serial_no"))
+	if brand == "" || serialNo == "" {
+		utils.RespondError(w, http.StatusBadRequest, errors.New("brand and serial_no are required"), "brand and serial_no are required")
+		return
+	}
+	info, err := h.Service.LookupWarranty(r.Context(), brand, serialNo)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadGateway, err, "failed to look up warranty")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, info)
+}
+
+// GetWarrantyLookup returns what the vendor answered when the warranty of the asset was looked up
+func (h *AssetHandler) GetWarrantyLookup(w http.ResponseWriter, r *http.Request) {
+	assetID, err := uuid.Parse(r.URL.Query().Get("asset_id"))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid asset id")
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	lookup, err := h.Service.GetWarrantyLookup(r.Context(), assetID, scope)
+	if err != nil {
+		if errors.Is(err, models.ErrOutOfScope) {
+			utils.RespondError(w, http.StatusForbidden, err, "asset belongs to another department")
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch warranty lookup")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, lookup)
+}
+
+// GetIMEIBlacklist lists the imeis of stolen devices, search matches the start of an imei
+func (h *AssetHandler) GetIMEIBlacklist(w http.ResponseWriter, r *http.Request) {
+	filter := models.IMEIBlacklistFilter{Search: strings.TrimSpace(r.URL.Query().Get("search"))}
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+	entries, err := h.Service.ListIMEIBlacklist(r.Context(), filter)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch imei blacklist")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"blacklist": entries})
+}
+
+func (h *AssetHandler) BlacklistIMEI(w http.ResponseWriter, r *http.Request) {
+	userIDStr, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	var req models.BlacklistIMEIReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "validation failed")
+		return
+	}
+
+	userID, _ := uuid.Parse(userIDStr)
+	if err := h.Service.BlacklistIMEI(r.Context(), req, userID); err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to blacklist imei")
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, map[string]string{"message": "imei blacklisted"})
+}
+
+func (h *AssetHandler) RemoveBlacklistedIMEI(w http.ResponseWriter, r *http.Request) {
+	imei := strings.TrimSpace(r.URL.Query().Get("imei"))
+	if imei == "" {
+		utils.RespondError(w, http.StatusBadRequest, errors.New("imei is required"), "imei is required")
+		return
+	}
+	if err := h.Service.RemoveBlacklistedIMEI(r.Context(), imei); err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to remove imei from blacklist")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "imei removed from blacklist"})
+}
+
+// GetUtilization splits the days of a window into assigned, in service and idle per type and per asset,
+// from and to are dates with to included
+func (h *AssetHandler) GetUtilization(w http.ResponseWriter, r *http.Request) {
+	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := models.UtilizationFilter{Type: query.Get("type")}
+	if filter.From, filter.To, err = parseDateWindow(query.Get("from"), query.Get("to")); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "from and to must be dates like 2026-01-31")
+		return
+	}
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+	filter.Scope, err = h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	utilization, err := h.Service.GetUtilization(r.Context(), filter)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch asset utilization")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, utilization)
+}
+
+// GetAssignmentChurn reports how long assignments lasted, why assets came back and how often they
+// changed hands per asset type and department, from and to are dates with to included
+func (h *AssetHandler) GetAssignmentChurn(w http.ResponseWriter, r *http.Request) {
+	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := models.ChurnFilter{Type: query.Get("type")}
+	if filter.From, filter.To, err = parseDateWindow(query.Get("from"), query.Get("to")); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "from and to must be dates like 2026-01-31")
+		return
+	}
+	filter.Scope, err = h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	churn, err := h.Service.GetAssignmentChurn(r.Context(), filter)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch assignment churn")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, churn)
+}
+
+// GetTrends counts assets added, assignments, returns and services per day or week for charts, from
+// and to are dates with to included
+func (h *AssetHandler) GetTrends(w http.ResponseWriter, r *http.Request) {
+	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := models.TrendFilter{Interval: query.Get("interval"), Type: query.Get("type")}
+	if filter.From, filter.To, err = parseDateWindow(query.Get("from"), query.Get("to")); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "from and to must be dates like 2026-01-31")
+		return
+	}
+	filter.Scope, err = h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	trends, err := h.Service.GetTrends(r.Context(), filter)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch trends")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, trends)
+}
+
+// GetServiceReport sums up days in service, time between services and service cost per asset type
+// and lists the most serviced assets, from and to are dates with to included
+func (h *AssetHandler) GetServiceReport(w http.ResponseWriter, r *http.Request) {
+	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := models.ServiceReportFilter{Type: query.Get("type")}
+	if filter.From, filter.To, err = parseDateWindow(query.Get("from"), query.Get("to")); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "from and to must be dates like 2026-01-31")
+		return
+	}
+	if top := query.Get("top"); top != "" {
+		if filter.Top, err = strconv.Atoi(top); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, "top must be a number")
+			return
+		}
+	}
+	filter.Scope, err = h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	report, err := h.Service.GetServiceReport(r.Context(), filter)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch service report")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, report)
+}
+
+// GetTelecomCostReport sums the monthly cost of sims per department, month is like 2026-01 and the
+// current month by default
+func (h *AssetHandler) GetTelecomCostReport(w http.ResponseWriter, r *http.Request) {
+	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	var filter models.TelecomCostFilter
+	if month := r.URL.Query().Get("month"); month != "" {
+		if filter.Month, err = time.Parse(telecomMonthLayout, month); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, ErrTelecomReportMonth, "month must be like 2026-01")
+			return
+		}
+	}
+	filter.Scope, err = h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	report, err := h.Service.GetTelecomCostReport(r.Context(), filter)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch telecom cost report")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, report)
+}
+
+// GetInventoryChanges lists the changes to all assets in scope oldest first, from and to are RFC 3339
+// times and to isn't included
+func (h *AssetHandler) GetInventoryChanges(w http.ResponseWriter, r *http.Request) {
+	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := models.InventoryChangeFilter{Type: query.Get("type")}
+	if val := query.Get("asset_id"); val != "" {
+		assetID, err := uuid.Parse(val)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, "invalid asset id")
+			return
+		}
+		filter.AssetID = &assetID
+	}
+	for param, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, param+" must be an RFC 3339 time")
+			return
+		}
+		*target = &parsed
+	}
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+	filter.Scope, err = h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	changes, err := h.Service.GetInventoryChanges(r.Context(), filter)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch inventory changes")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"changes": changes,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+	})
+}
+
+// parseDateWindow reads optional from and to dates, to is included so the window ends the day after
+func parseDateWindow(from, to string) (time.Time, time.Time, error) {
+	var start, end time.Time
+	var err error
+	if from != "" {
+		if start, err = time.Parse(time.DateOnly, from); err != nil {
+			return start, end, err
+		}
+	}
+	if to != "" {
+		if end, err = time.Parse(time.DateOnly, to); err != nil {
+			return start, end, err
+		}
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, nil
 }
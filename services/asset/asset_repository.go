@@ -2,61 +2,147 @@ package assetservice
 
 import (
 	"asset/models"
+	"asset/providers"
+	cacheprovider "asset/providers/cacheProvider"
+	"asset/utils"
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/pkg/errors"
-	"strings"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
-	"log"
 )
 
 type AssetRepository interface {
-	AddAsset(ctx context.Context, tx *sqlx.Tx, req models.AddAssetWithConfigReq, addedBy uuid.UUID) (uuid.UUID, error)
-
-	AddLaptopConfig(ctx context.Context, tx *sqlx.Tx, cfg models.Laptop_config_req, assetID uuid.UUID) error
-	AddMouseConfig(ctx context.Context, tx *sqlx.Tx, cfg models.Mouse_config_req, assetID uuid.UUID) error
-	AddMonitorConfig(ctx context.Context, tx *sqlx.Tx, cfg models.Monitor_config_req, assetID uuid.UUID) error
-	AddHardDiskConfig(ctx context.Context, tx *sqlx.Tx, cfg models.Hard_disk_config_req, assetID uuid.UUID) error
-	AddPenDriveConfig(ctx context.Context, tx *sqlx.Tx, cfg models.Pen_drive_config_req, assetID uuid.UUID) error
-	AddMobileConfig(ctx context.Context, tx *sqlx.Tx, cfg models.Mobile_config_req, assetID uuid.UUID) error
-	AddSimConfig(ctx context.Context, tx *sqlx.Tx, cfg models.Sim_config_req, assetID uuid.UUID) error
-	AddAccessoryConfig(ctx context.Context, tx *sqlx.Tx, cfg models.Accessories_config_req, assetID uuid.UUID) error
-	AssignAssetByID(ctx context.Context, tx *sqlx.Tx, assetID, employeeID, managerID uuid.UUID) error
-	DeleteAssetByID(ctx context.Context, assetID uuid.UUID) error
+	AddAsset(ctx context.Context, req models.AddAssetWithConfigReq, addedBy *uuid.UUID) (uuid.UUID, error)
+
+	AddLaptopConfig(ctx context.Context, cfg models.Laptop_config_req, assetID uuid.UUID) error
+	AddMouseConfig(ctx context.Context, cfg models.Mouse_config_req, assetID uuid.UUID) error
+	AddMonitorConfig(ctx context.Context, cfg models.Monitor_config_req, assetID uuid.UUID) error
+	AddHardDiskConfig(ctx context.Context, cfg models.Hard_disk_config_req, assetID uuid.UUID) error
+	AddPenDriveConfig(ctx context.Context, cfg models.Pen_drive_config_req, assetID uuid.UUID) error
+	AddMobileConfig(ctx context.Context, cfg models.Mobile_config_req, assetID uuid.UUID) error
+	AddSimConfig(ctx context.Context, cfg models.Sim_config_req, assetID uuid.UUID) error
+	AddAccessoryConfig(ctx context.Context, cfg models.Accessories_config_req, assetID uuid.UUID) error
+	// AssignAssetByID assigns for good when loanUntil is nil and lends the asset until then otherwise
+	AssignAssetByID(ctx context.Context, assetID, employeeID, managerID uuid.UUID, metadata *models.AssignmentMetadata, loanUntil *time.Time) error
+	DeleteAssetByID(ctx context.Context, assetID, archivedBy uuid.UUID) error
 	SearchAssetsWithFilter(ctx context.Context, filter models.AssetFilter) ([]models.AssetWithConfigRes, error)
 	GetAssetTimeline(ctx context.Context, assetID uuid.UUID) ([]models.AssetTimelineEvent, error)
-	RecivedAssetFromService(ctx context.Context, assetID uuid.UUID) error
-	RetrieveAsset(ctx context.Context, tx *sqlx.Tx, assetID, employeeID uuid.UUID, reason string) error
-	SendAssetForService(ctx context.Context, req models.AssetServiceReq, managerID uuid.UUID) error
+	RecivedAssetFromService(ctx context.Context, assetID uuid.UUID, cost *float64) error
+	RetrieveAsset(ctx context.Context, assetID, employeeID uuid.UUID, reason string) error
+	SendAssetForService(ctx context.Context, req models.AssetServiceReq, managerID uuid.UUID, ticketed bool) (uuid.UUID, error)
 	UpdateAssetWithConfig(ctx context.Context, req models.UpdateAssetReq) error
+	IsAssetInScope(ctx context.Context, assetID uuid.UUID, scope models.DepartmentScope) (bool, error)
+	IsEmployeeInScope(ctx context.Context, employeeID uuid.UUID, scope models.DepartmentScope) (bool, error)
+	GetReturnRequests(ctx context.Context, filter models.ReturnRequestFilter) ([]models.ReturnRequestRes, error)
+	GetAssetsWarrantyExpiringWithin(ctx context.Context, days int) ([]models.WarrantyAlertRes, error)
+	MarkWarrantyAlerted(ctx context.Context, assetID uuid.UUID) error
+	GetOverdueReturnRequests(ctx context.Context, days int) ([]models.OverdueReturnRes, error)
+	MarkReturnOverdueNotified(ctx context.Context, requestID uuid.UUID) error
+	GetLoans(ctx context.Context, filter models.LoanFilter) ([]models.LoanRes, error)
+	// GetOverdueLoans returns the open loans past their end that weren't flagged yet
+	GetOverdueLoans(ctx context.Context) ([]models.LoanRes, error)
+	MarkLoanOverdue(ctx context.Context, assignmentID uuid.UUID) error
+	AcknowledgeAssignment(ctx context.Context, assetID, employeeID uuid.UUID) error
+	InsertReturnInspection(ctx context.Context, assetID, employeeID uuid.UUID, inspection models.ReturnInspection, serviceID *uuid.UUID, inspectedBy uuid.UUID) error
+	MarkAssetDamaged(ctx context.Context, assetID uuid.UUID) error
+	GetReturnInspections(ctx context.Context, assetID uuid.UUID) ([]models.ReturnInspectionRes, error)
+	DisposeAsset(ctx context.Context, req models.DisposeAssetReq, disposedBy uuid.UUID) (models.AssetDisposal, error)
+	GetAssetDisposal(ctx context.Context, assetID uuid.UUID) (models.AssetDisposal, error)
+	GetPendingAcknowledgments(ctx context.Context, days, maxReminders int) ([]models.PendingAcknowledgment, error)
+	MarkAcknowledgmentReminded(ctx context.Context, assignmentID uuid.UUID, reminders int) error
+	GetEmployeeManagerIDs(ctx context.Context, organizationID uuid.UUID, departmentID *uuid.UUID) ([]uuid.UUID, error)
+	GetAssetManagerIDs(ctx context.Context, organizationID uuid.UUID, departmentID *uuid.UUID) ([]uuid.UUID, error)
+	GetAssetManagerContacts(ctx context.Context, organizationID uuid.UUID, departmentID *uuid.UUID) ([]string, error)
+	ReportAssetStolen(ctx context.Context, assetID, employeeID uuid.UUID, note string) (models.StolenAsset, error)
+	GetAssetBrief(ctx context.Context, assetID uuid.UUID) (models.AssetBrief, error)
+	GetServicesAwaitingTicket(ctx context.Context, limit int) ([]models.ServiceTicket, error)
+	GetUnresolvedServiceTickets(ctx context.Context, limit int) ([]models.ServiceTicket, error)
+	SetServiceTicket(ctx context.Context, serviceID uuid.UUID, ticket models.Ticket) error
+	UpdateServiceTicket(ctx context.Context, ticket models.Ticket) (bool, error)
+	GetStockLevels(ctx context.Context, types []string) ([]models.StockLevel, error)
+	MarkLowStockAlerted(ctx context.Context, level models.StockLevel) (bool, error)
+	ClearLowStockAlerts(ctx context.Context, types []string) error
+	GetMDMAsset(ctx context.Context, serialNo string) (models.MDMAsset, error)
+	GetKioskEmployee(ctx context.Context, badgeID string) (models.KioskEmployee, error)
+	GetKioskAsset(ctx context.Context, serialNo string) (models.KioskAsset, error)
+	SaveMDMStatus(ctx context.Context, assetID uuid.UUID, system string, device models.MDMDevice, mismatch bool) (bool, error)
+	GetMDMMismatches(ctx context.Context, filter models.MDMMismatchFilter) ([]models.MDMMismatch, error)
+	GetDuplicateAssets(ctx context.Context, filter models.DuplicateFilter) ([]models.DuplicateGroup, error)
+	MergeAssets(ctx context.Context, keepID, duplicateID, mergedBy uuid.UUID) (map[string]int64, error)
+	GetBlacklistedIMEIs(ctx context.Context, imeis []string) ([]models.BlacklistedIMEI, error)
+	ListIMEIBlacklist(ctx context.Context, filter models.IMEIBlacklistFilter) ([]models.BlacklistedIMEI, error)
+	AddBlacklistedIMEI(ctx context.Context, entry models.BlacklistedIMEI) error
+	RemoveBlacklistedIMEI(ctx context.Context, imei string) error
+	SetIMEIFlag(ctx context.Context, assetID uuid.UUID, flag *string) error
+	SaveWarrantyLookup(ctx context.Context, assetID uuid.UUID, info models.WarrantyInfo) error
+	GetWarrantyLookup(ctx context.Context, assetID uuid.UUID) (models.WarrantyLookup, error)
+	GetAssetUtilization(ctx context.Context, filter models.UtilizationFilter) ([]models.AssetUtilization, error)
+	GetTypeUtilization(ctx context.Context, filter models.UtilizationFilter) ([]models.TypeUtilization, error)
+	GetAssignmentStats(ctx context.Context, filter models.ChurnFilter, groupBy string) ([]models.AssignmentStats, error)
+	GetTrends(ctx context.Context, filter models.TrendFilter) ([]models.TrendPoint, error)
+	GetReturnReasons(ctx context.Context, filter models.ChurnFilter, limit int) ([]models.ReturnReasonCount, error)
+	GetAssetServiceStats(ctx context.Context, filter models.ServiceReportFilter) ([]models.AssetServiceStats, error)
+	GetTypeServiceStats(ctx context.Context, filter models.ServiceReportFilter) ([]models.TypeServiceStats, error)
+	GetTelecomCosts(ctx context.Context, filter models.TelecomCostFilter) ([]models.DepartmentTelecomCost, error)
+	GetInventoryChanges(ctx context.Context, filter models.InventoryChangeFilter) ([]models.InventoryChange, error)
+	LockAssignedAssetIDs(ctx context.Context, employeeID uuid.UUID) ([]uuid.UUID, error)
+	GetAssetEmployeeIDs(ctx context.Context, assetID uuid.UUID) ([]uuid.UUID, error)
+	MoveClosedAssignmentsBefore(ctx context.Context, before time.Time, limit int) (int64, error)
+	MoveClosedServicesBefore(ctx context.Context, before time.Time, limit int) (int64, error)
+	InsertAttachment(ctx context.Context, attachment models.Attachment) error
+	GetAttachment(ctx context.Context, id uuid.UUID) (models.Attachment, error)
+	MarkAttachmentUploaded(ctx context.Context, id uuid.UUID, size int64) error
+	GetAssetAttachments(ctx context.Context, assetID uuid.UUID) ([]models.Attachment, error)
+	ArchiveAttachment(ctx context.Context, id uuid.UUID) error
+	DeletePendingAttachmentsBefore(ctx context.Context, before time.Time) ([]string, error)
 }
 
 type PostgresAssetRepository struct {
-	DB *sqlx.DB
+	DB     *sqlx.DB
+	Redis  providers.RedisProvider
+	Config providers.ConfigProvider
+}
+
+func NewAssetRepository(db *sqlx.DB, redis providers.RedisProvider, cfg providers.ConfigProvider) AssetRepository {
+	return &PostgresAssetRepository{DB: db, Redis: redis, Config: cfg}
 }
 
-func NewAssetRepository(db *sqlx.DB) AssetRepository {
-	return &PostgresAssetRepository{DB: db}
+// notFoundTTL is how long a miss is remembered, 0 when there is no redis or the class is disabled
+func (r *PostgresAssetRepository) notFoundTTL() time.Duration {
+	if r.Redis == nil {
+		return 0
+	}
+	policy := models.DefaultCacheTTLs
+	if r.Config != nil {
+		policy = r.Config.GetCacheTTLs()
+	}
+	if !policy.Enabled(models.CacheNotFound) {
+		return 0
+	}
+	return policy.NotFound
 }
 
-func (r *PostgresAssetRepository) AddAsset(ctx context.Context, tx *sqlx.Tx, assetReq models.AddAssetWithConfigReq, addedBy uuid.UUID) (uuid.UUID, error) {
+// AddAsset inserts the asset, addedBy is nil for one the mdm sync discovered
+func (r *PostgresAssetRepository) AddAsset(ctx context.Context, assetReq models.AddAssetWithConfigReq, addedBy *uuid.UUID) (uuid.UUID, error) {
 	var assetID uuid.UUID
-	err := tx.GetContext(ctx, &assetID, `
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &assetID, `
 		INSERT INTO assets (
 			brand, model, serial_no, purchase_date, 
 			owned_by, type, warranty_start, warranty_expire, 
-			added_by
+			added_by, department_id, purchase_cost, salvage_value, useful_life_months, purchase_order_id
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, COALESCE($12, 0), $13, $14)
 		RETURNING id`,
 		assetReq.Brand, assetReq.Model, assetReq.SerialNo, assetReq.PurchaseDate,
 		assetReq.OwnedBy, assetReq.Type, assetReq.WarrantyStart, assetReq.WarrantyExpire,
-		addedBy)
+		addedBy, assetReq.DepartmentID, assetReq.PurchaseCost, assetReq.SalvageValue, assetReq.UsefulLifeMonths, assetReq.PurchaseOrderID)
 
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("failed to insert asset: %w", err)
@@ -64,8 +150,8 @@ func (r *PostgresAssetRepository) AddAsset(ctx context.Context, tx *sqlx.Tx, ass
 	return assetID, nil
 }
 
-func (r *PostgresAssetRepository) AddLaptopConfig(ctx context.Context, tx *sqlx.Tx, config models.Laptop_config_req, assetID uuid.UUID) error {
-	_, err := tx.ExecContext(ctx, `
+func (r *PostgresAssetRepository) AddLaptopConfig(ctx context.Context, config models.Laptop_config_req, assetID uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
 		INSERT INTO laptop_config (asset_id, processor, ram, os)
 		VALUES ($1, $2, $3, $4)`,
 		assetID, config.Processor, config.Ram, config.Os)
@@ -75,8 +161,8 @@ func (r *PostgresAssetRepository) AddLaptopConfig(ctx context.Context, tx *sqlx.
 	return nil
 }
 
-func (r *PostgresAssetRepository) AddMouseConfig(ctx context.Context, tx *sqlx.Tx, config models.Mouse_config_req, assetID uuid.UUID) error {
-	_, err := tx.ExecContext(ctx, `
+func (r *PostgresAssetRepository) AddMouseConfig(ctx context.Context, config models.Mouse_config_req, assetID uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
 		INSERT INTO mouse_config (asset_id, dpi)
 		VALUES ($1, $2)`,
 		assetID, config.DPI)
@@ -86,8 +172,8 @@ func (r *PostgresAssetRepository) AddMouseConfig(ctx context.Context, tx *sqlx.T
 	return nil
 }
 
-func (r *PostgresAssetRepository) AddMonitorConfig(ctx context.Context, tx *sqlx.Tx, config models.Monitor_config_req, assetID uuid.UUID) error {
-	_, err := tx.ExecContext(ctx, `
+func (r *PostgresAssetRepository) AddMonitorConfig(ctx context.Context, config models.Monitor_config_req, assetID uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
 		INSERT INTO monitor_config (asset_id, display, resolution, port)
 		VALUES ($1, $2, $3, $4)`,
 		assetID, config.Display, config.Resolution, config.Port)
@@ -97,8 +183,8 @@ func (r *PostgresAssetRepository) AddMonitorConfig(ctx context.Context, tx *sqlx
 	return nil
 }
 
-func (r *PostgresAssetRepository) AddHardDiskConfig(ctx context.Context, tx *sqlx.Tx, config models.Hard_disk_config_req, assetID uuid.UUID) error {
-	_, err := tx.ExecContext(ctx, `
+func (r *PostgresAssetRepository) AddHardDiskConfig(ctx context.Context, config models.Hard_disk_config_req, assetID uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
 		INSERT INTO hard_disk_config (asset_id, type, storage)
 		VALUES ($1, $2, $3)`,
 		assetID, config.Type, config.Storage)
@@ -108,9 +194,9 @@ func (r *PostgresAssetRepository) AddHardDiskConfig(ctx context.Context, tx *sql
 	return nil
 }
 
-func (r *PostgresAssetRepository) AddPenDriveConfig(ctx context.Context, tx *sqlx.Tx, config models.Pen_drive_config_req, assetID uuid.UUID) error {
+func (r *PostgresAssetRepository) AddPenDriveConfig(ctx context.Context, config models.Pen_drive_config_req, assetID uuid.UUID) error {
 
-	_, err := tx.ExecContext(ctx, `
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
 		INSERT INTO pendrive_config (asset_id, version, storage)
 		VALUES ($1, $2, $3)`,
 		assetID, config.Version, config.Storage)
@@ -120,8 +206,8 @@ func (r *PostgresAssetRepository) AddPenDriveConfig(ctx context.Context, tx *sql
 	return nil
 }
 
-func (r *PostgresAssetRepository) AddMobileConfig(ctx context.Context, tx *sqlx.Tx, config models.Mobile_config_req, assetID uuid.UUID) error {
-	_, err := tx.ExecContext(ctx, `
+func (r *PostgresAssetRepository) AddMobileConfig(ctx context.Context, config models.Mobile_config_req, assetID uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
 		INSERT INTO mobile_config (asset_id, processor, ram, os, imei_1, imei_2)
 		VALUES ($1, $2, $3, $4, $5, $6)
 	`, assetID, config.Processor, config.Ram, config.Os, config.IMEI1, config.IMEI2)
@@ -131,19 +217,45 @@ func (r *PostgresAssetRepository) AddMobileConfig(ctx context.Context, tx *sqlx.
 	return nil
 }
 
-func (r *PostgresAssetRepository) AddSimConfig(ctx context.Context, tx *sqlx.Tx, config models.Sim_config_req, assetID uuid.UUID) error {
-	_, err := tx.ExecContext(ctx, `
-		INSERT INTO sim_config (asset_id, number)
-		VALUES ($1, $2)`,
-		assetID, config.Number)
+func (r *PostgresAssetRepository) AddSimConfig(ctx context.Context, config models.Sim_config_req, assetID uuid.UUID) error {
+	if err := r.checkSimMobile(ctx, config.MobileAssetID, assetID); err != nil {
+		return err
+	}
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO sim_config (asset_id, number, carrier, plan, monthly_cost, renewal_date, mobile_asset_id)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::date, NULLIF($7, '')::uuid)`,
+		assetID, config.Number, config.Carrier, config.Plan, config.MonthlyCost, config.RenewalDate, config.MobileAssetID)
 	if err != nil {
 		return fmt.Errorf("failed to insert sim config: %w", err)
 	}
 	return nil
 }
 
-func (r *PostgresAssetRepository) AddAccessoryConfig(ctx context.Context, tx *sqlx.Tx, config models.Accessories_config_req, assetID uuid.UUID) error {
-	_, err := tx.ExecContext(ctx, `
+// checkSimMobile makes sure the mobile a sim is put in is a live mobile of the sim's organization,
+// an empty id leaves the sim outside any mobile
+func (r *PostgresAssetRepository) checkSimMobile(ctx context.Context, mobileAssetID string, simID uuid.UUID) error {
+	if mobileAssetID == "" {
+		return nil
+	}
+	var ok bool
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &ok, `
+		SELECT EXISTS (
+			SELECT 1 FROM assets m
+			JOIN assets s ON s.id = $2
+			WHERE m.id = $1 AND m.type = 'mobile' AND m.archived_at IS NULL
+			AND m.organization_id = s.organization_id
+		)`, mobileAssetID, simID)
+	if err != nil {
+		return fmt.Errorf("failed to check sim mobile: %w", err)
+	}
+	if !ok {
+		return ErrSimMobileNotFound
+	}
+	return nil
+}
+
+func (r *PostgresAssetRepository) AddAccessoryConfig(ctx context.Context, config models.Accessories_config_req, assetID uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
 		INSERT INTO accessories_config (asset_id, type, additional_info)
 		VALUES ($1, $2, $3)`,
 		assetID, config.Type, config.AdditionalInfo)
@@ -153,9 +265,22 @@ func (r *PostgresAssetRepository) AddAccessoryConfig(ctx context.Context, tx *sq
 	return nil
 }
 
-func (r *PostgresAssetRepository) AssignAssetByID(ctx context.Context, tx *sqlx.Tx, assetID uuid.UUID, employeeID uuid.UUID, assignedBy uuid.UUID) error {
+// AssignAssetByID locks the asset row first, so concurrent assignments of one asset queue up and each
+// sees whether the one before it committed. idx_asset_assignment still allows one open assignment
+// only, a write that skips the lock is refused by it with the same error
+func (r *PostgresAssetRepository) AssignAssetByID(ctx context.Context, assetID uuid.UUID, employeeID uuid.UUID, assignedBy uuid.UUID, metadata *models.AssignmentMetadata, loanUntil *time.Time) error {
+	var locked uuid.UUID
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &locked, `
+		SELECT id FROM assets
+		WHERE id = $1 AND archived_at IS NULL
+		FOR UPDATE
+	`, assetID)
+	if err != nil {
+		return fmt.Errorf("failed to lock asset: %w", err)
+	}
+
 	var exists int
-	err := tx.GetContext(ctx, &exists, `
+	err = utils.Conn(ctx, r.DB).GetContext(ctx, &exists, `
 		SELECT 1 FROM asset_assign 
 		WHERE asset_id = $1 AND returned_at IS NULL AND archived_at IS NULL
 		LIMIT 1
@@ -167,17 +292,21 @@ func (r *PostgresAssetRepository) AssignAssetByID(ctx context.Context, tx *sqlx.
 			return fmt.Errorf("failed to check existing assignment: %w", err)
 		}
 	} else {
-		return fmt.Errorf("asset already assigned")
+		return ErrAssetAlreadyAssigned
 	}
 
-	_, err = tx.ExecContext(ctx, `
-		INSERT INTO asset_assign (asset_id, employee_id, assigned_by)
-		VALUES ($1, $2, $3)
-	`, assetID, employeeID, assignedBy)
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO asset_assign (asset_id, employee_id, assigned_by, metadata, loan_until)
+		VALUES ($1, $2, $3, $4, $5)
+	`, assetID, employeeID, assignedBy, metadata, loanUntil)
 	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_asset_assignment" {
+			return ErrAssetAlreadyAssigned
+		}
 		return fmt.Errorf("failed to insert into asset_assign table: %w", err)
 	}
-	_, err = tx.ExecContext(ctx, `
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
 		UPDATE assets SET status = 'assigned' WHERE id = $1
 	`, assetID)
 	if err != nil {
@@ -186,41 +315,29 @@ func (r *PostgresAssetRepository) AssignAssetByID(ctx context.Context, tx *sqlx.
 	return nil
 }
 
-func (r *PostgresAssetRepository) DeleteAssetByID(ctx context.Context, assetID uuid.UUID) (err error) {
-	tx, err := r.DB.BeginTxx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed to start transaction: %w", err)
-	}
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			panic(p)
-		} else if err != nil {
-			tx.Rollback()
-		} else {
-			err = tx.Commit()
+func (r *PostgresAssetRepository) DeleteAssetByID(ctx context.Context, assetID, archivedBy uuid.UUID) error {
+	return utils.WithTransaction(ctx, r.DB, func(ctx context.Context) error {
+		var err error
+		var exists bool
+		err = utils.Conn(ctx, r.DB).GetContext(ctx, &exists, `
+			SELECT EXISTS (
+				SELECT 1 FROM asset_assign 
+				WHERE asset_id = $1 AND archived_at IS NULL AND returned_at IS NULL
+			)
+		`, assetID)
+		if err != nil {
+			return fmt.Errorf("failed to check asset assignment: %w", err)
+		}
+		if exists {
+			return ErrAssetAssigned
 		}
-	}()
-
-	var exists bool
-	err = tx.GetContext(ctx, &exists, `
-		SELECT EXISTS (
-			SELECT 1 FROM asset_assign 
-			WHERE asset_id = $1 AND archived_at IS NULL AND returned_at IS NULL
-		)
-	`, assetID)
-	if err != nil {
-		return fmt.Errorf("failed to check asset assignment: %w", err)
-	}
-	if exists {
-		return fmt.Errorf("asset currently assigned to a user")
-	}
 
-	_, err = tx.ExecContext(ctx, `UPDATE assets SET archived_at = now() WHERE id = $1`, assetID)
-	if err != nil {
-		return fmt.Errorf("failed to archive asset: %w", err)
-	}
-	return nil
+		_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `UPDATE assets SET archived_at = now(), archived_by = $2 WHERE id = $1`, assetID, archivedBy)
+		if err != nil {
+			return fmt.Errorf("failed to archive asset: %w", err)
+		}
+		return nil
+	})
 }
 
 func (r *PostgresAssetRepository) GetAssetTimeline(ctx context.Context, assetUUID uuid.UUID) ([]models.AssetTimelineEvent, error) {
@@ -231,9 +348,11 @@ func (r *PostgresAssetRepository) GetAssetTimeline(ctx context.Context, assetUUI
 			'assigned' AS event_type,
 			assigned_at AS start_time,
 			returned_at AS end_time,
-			'Assigned to employee' AS details,
-			asset_id
-		FROM asset_assign
+			CASE WHEN loan_until IS NULL THEN 'Assigned to employee'
+				ELSE 'Loaned to employee until ' || to_char(loan_until AT TIME ZONE 'UTC', 'YYYY-MM-DD HH24:MI') || ' UTC' END AS details,
+			asset_id,
+			metadata
+		FROM asset_assign_all
 		WHERE asset_id = $1 AND archived_at IS NULL
 
 		UNION ALL
@@ -242,15 +361,29 @@ func (r *PostgresAssetRepository) GetAssetTimeline(ctx context.Context, assetUUI
 			'went_for_service' AS event_type,
 			service_start AS start_time,
 			service_end AS end_time,
-			reason AS details,
-			asset_id
-		FROM asset_service
+			reason || COALESCE(' (ticket ' || ticket_key || ')', '') AS details,
+			asset_id,
+			NULL::jsonb AS metadata
+		FROM asset_service_all
 		WHERE asset_id = $1 AND archived_at IS NULL
 
+		UNION ALL
+
+		SELECT
+			'allocated_to_project' AS event_type,
+			pa.starts_on::timestamptz AS start_time,
+			pa.released_on::timestamptz AS end_time,
+			'Allocated to project ' || p.name AS details,
+			pa.asset_id,
+			NULL::jsonb AS metadata
+		FROM project_assets pa
+		JOIN projects p ON p.id = pa.project_id
+		WHERE pa.asset_id = $1
+
 		ORDER BY start_time ASC
 	`
 
-	err := r.DB.SelectContext(ctx, &timeline, query, assetUUID)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &timeline, query, assetUUID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch asset timeline: %w", err)
 	}
@@ -258,375 +391,1794 @@ func (r *PostgresAssetRepository) GetAssetTimeline(ctx context.Context, assetUUI
 	return timeline, nil
 }
 
-func (r *PostgresAssetRepository) RecivedAssetFromService(ctx context.Context, assetID uuid.UUID) (err error) {
-	tx, err := r.DB.BeginTxx(ctx, nil)
+func (r *PostgresAssetRepository) RecivedAssetFromService(ctx context.Context, assetID uuid.UUID, cost *float64) error {
+	return utils.WithTransaction(ctx, r.DB, func(ctx context.Context) error {
+		var err error
+		var count int
+		err = utils.Conn(ctx, r.DB).GetContext(ctx, &count, `
+			SELECT COUNT(*) FROM asset_service
+			WHERE asset_id = $1 AND archived_at IS NULL AND service_end IS NULL
+		`, assetID)
+		if err != nil {
+			return fmt.Errorf("failed to check service record: %w", err)
+		}
+		if count == 0 {
+			return ErrAssetNotInService
+		}
+
+		_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+			UPDATE assets
+			SET status = 'available'
+			WHERE id = $1
+		`, assetID)
+		if err != nil {
+			return fmt.Errorf("failed to update asset status: %w", err)
+		}
+
+		_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+			UPDATE asset_service
+			SET service_end = now(), cost = $2
+			WHERE asset_id = $1 AND archived_at IS NULL AND service_end IS NULL
+		`, assetID, cost)
+		if err != nil {
+			return fmt.Errorf("failed to update asset_service end_date: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (r *PostgresAssetRepository) RetrieveAsset(ctx context.Context, assetID uuid.UUID, employeeID uuid.UUID, reason string) error {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE asset_assign 
+		SET returned_at = now(), return_reason = $1
+		WHERE asset_id = $2 AND employee_id = $3 AND returned_at IS NULL AND archived_at IS NULL
+	`, reason, assetID, employeeID)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return fmt.Errorf("failed to update asset_assign: %w", err)
 	}
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			panic(p)
-		} else if err != nil {
-			tx.Rollback()
-		} else {
-			err = tx.Commit()
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to fetch rows affected: %w", err)
+	}
+	fmt.Println("Rows affected (asset_assign):", rowsAffected)
+
+	if rowsAffected == 0 {
+		return ErrAssignmentNotFound
+	}
+
+	// a stolen asset stays stolen when its assignment is closed
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE assets SET status = CASE WHEN status = 'stolen' THEN status ELSE 'available' END
+		WHERE id = $1 AND archived_at IS NULL
+	`, assetID)
+	if err != nil {
+		return fmt.Errorf("failed to update asset status: %w", err)
+	}
+	fmt.Println("Asset status updated to 'available'")
+
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE asset_return_requests SET status = 'completed', resolved_at = now()
+		WHERE asset_id = $1 AND employee_id = $2 AND status = 'open'
+	`, assetID, employeeID)
+	if err != nil {
+		return fmt.Errorf("failed to complete return request: %w", err)
+	}
+	return nil
+}
+
+// InsertReturnInspection stores the inspection of the assignment of employeeID that was closed last,
+// it runs in the transaction of the return. ErrInspectionPhotos when a photo isn't an uploaded
+// attachment of the asset
+func (r *PostgresAssetRepository) InsertReturnInspection(ctx context.Context, assetID, employeeID uuid.UUID, inspection models.ReturnInspection, serviceID *uuid.UUID, inspectedBy uuid.UUID) error {
+	photoIDs := make([]string, 0, len(inspection.PhotoIDs))
+	for _, id := range inspection.PhotoIDs {
+		photoIDs = append(photoIDs, id.String())
+	}
+	slices.Sort(photoIDs)
+	photoIDs = slices.Compact(photoIDs)
+	if len(photoIDs) > 0 {
+		var found int
+		err := utils.Conn(ctx, r.DB).GetContext(ctx, &found, `
+			SELECT count(*) FROM asset_attachments
+			WHERE id = ANY($1::uuid[]) AND asset_id = $2 AND status = 'uploaded' AND archived_at IS NULL
+		`, pq.Array(photoIDs), assetID)
+		if err != nil {
+			return fmt.Errorf("failed to check inspection photos: %w", err)
 		}
-	}()
+		if found != len(photoIDs) {
+			return ErrInspectionPhotos
+		}
+	}
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO asset_return_inspections (assignment_id, asset_id, employee_id, checklist, condition_rating,
+			damaged, damage_notes, photo_ids, service_id, inspected_by)
+		SELECT aa.id, aa.asset_id, aa.employee_id, $3, $4, $5, $6, $7::uuid[], $8, $9
+		FROM asset_assign aa
+		WHERE aa.asset_id = $1 AND aa.employee_id = $2 AND aa.returned_at IS NOT NULL AND aa.archived_at IS NULL
+		ORDER BY aa.returned_at DESC
+		LIMIT 1
+	`, assetID, employeeID, inspection.Checklist, inspection.ConditionRating, inspection.IsDamaged(),
+		inspection.DamageNotes, pq.Array(photoIDs), serviceID, inspectedBy)
+	if err != nil {
+		return fmt.Errorf("failed to insert return inspection: %w", err)
+	}
+	return nil
+}
 
-	var count int
-	err = tx.GetContext(ctx, &count, `
-		SELECT COUNT(*) FROM asset_service
-		WHERE asset_id = $1 AND archived_at IS NULL AND service_end IS NULL
+// MarkAssetDamaged is used after a damaged return, a stolen asset stays stolen
+func (r *PostgresAssetRepository) MarkAssetDamaged(ctx context.Context, assetID uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE assets SET status = 'damaged' WHERE id = $1 AND archived_at IS NULL AND status <> 'stolen'
 	`, assetID)
 	if err != nil {
-		return fmt.Errorf("failed to check service record: %w", err)
+		return fmt.Errorf("failed to mark asset damaged: %w", err)
 	}
-	if count == 0 {
-		return fmt.Errorf("asset is not currently under service")
+	return nil
+}
+
+// GetReturnInspections returns the inspections of an asset's returns, newest first
+func (r *PostgresAssetRepository) GetReturnInspections(ctx context.Context, assetID uuid.UUID) ([]models.ReturnInspectionRes, error) {
+	rows := []struct {
+		models.ReturnInspectionRes
+		PhotoIDs pq.StringArray `db:"photo_ids"`
+	}{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &rows, `
+		SELECT ri.id, ri.assignment_id, ri.asset_id, ri.employee_id, u.username AS employee_name, ri.checklist,
+			ri.condition_rating, ri.damaged, ri.damage_notes, ri.photo_ids::text[] AS photo_ids, ri.service_id,
+			ri.inspected_by, ri.created_at
+		FROM asset_return_inspections ri
+		JOIN users u ON u.id = ri.employee_id
+		WHERE ri.asset_id = $1
+		ORDER BY ri.created_at DESC
+	`, assetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch return inspections: %w", err)
+	}
+	inspections := make([]models.ReturnInspectionRes, 0, len(rows))
+	for _, row := range rows {
+		inspection := row.ReturnInspectionRes
+		inspection.PhotoIDs = make([]uuid.UUID, 0, len(row.PhotoIDs))
+		for _, id := range row.PhotoIDs {
+			inspection.PhotoIDs = append(inspection.PhotoIDs, uuid.MustParse(id))
+		}
+		inspections = append(inspections, inspection)
 	}
+	return inspections, nil
+}
 
-	_, err = tx.ExecContext(ctx, `
-		UPDATE assets
-		SET status = 'available'
-		WHERE id = $1
+// DisposeAsset records the sale or buyback of a live asset and archives it in one transaction.
+// ErrAssetNotFound when the asset is already archived, ErrAssetAssigned while it is assigned and
+// ErrDisposalInvoice when the invoice isn't an uploaded attachment of the asset
+func (r *PostgresAssetRepository) DisposeAsset(ctx context.Context, req models.DisposeAssetReq, disposedBy uuid.UUID) (models.AssetDisposal, error) {
+	var disposal models.AssetDisposal
+	err := utils.WithTransaction(ctx, r.DB, func(ctx context.Context) error {
+		if req.InvoiceID != nil {
+			var uploaded bool
+			err := utils.Conn(ctx, r.DB).GetContext(ctx, &uploaded, `
+				SELECT EXISTS (
+					SELECT 1 FROM asset_attachments
+					WHERE id = $1 AND asset_id = $2 AND status = 'uploaded' AND archived_at IS NULL
+				)
+			`, req.InvoiceID, req.AssetID)
+			if err != nil {
+				return fmt.Errorf("failed to check disposal invoice: %w", err)
+			}
+			if !uploaded {
+				return ErrDisposalInvoice
+			}
+		}
+		err := utils.Conn(ctx, r.DB).GetContext(ctx, &disposal, `
+			INSERT INTO asset_disposals (asset_id, kind, buyer, price, disposed_on, invoice_id, notes, disposed_by)
+			SELECT a.id, $2, $3, $4, $5::date, $6, NULLIF($7, ''), $8
+			FROM assets a
+			WHERE a.id = $1 AND a.archived_at IS NULL
+			RETURNING id, asset_id, kind, buyer, price, disposed_on, invoice_id, COALESCE(notes, '') AS notes, disposed_by, created_at
+		`, req.AssetID, req.Kind, req.Buyer, req.Price, req.DisposedOn, req.InvoiceID, req.Notes, disposedBy)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAssetNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to record asset disposal: %w", err)
+		}
+		return r.DeleteAssetByID(ctx, req.AssetID, disposedBy)
+	})
+	if err != nil {
+		return models.AssetDisposal{}, err
+	}
+	return disposal, nil
+}
+
+// GetAssetDisposal returns the latest disposal of the asset, ErrDisposalNotFound when it has none
+func (r *PostgresAssetRepository) GetAssetDisposal(ctx context.Context, assetID uuid.UUID) (models.AssetDisposal, error) {
+	var disposal models.AssetDisposal
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &disposal, `
+		SELECT id, asset_id, kind, buyer, price, disposed_on, invoice_id, COALESCE(notes, '') AS notes, disposed_by, created_at
+		FROM asset_disposals
+		WHERE asset_id = $1
+		ORDER BY created_at DESC
+		LIMIT 1
 	`, assetID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.AssetDisposal{}, ErrDisposalNotFound
+	}
 	if err != nil {
-		return fmt.Errorf("failed to update asset status: %w", err)
+		return models.AssetDisposal{}, fmt.Errorf("failed to fetch asset disposal: %w", err)
 	}
+	return disposal, nil
+}
 
-	_, err = tx.ExecContext(ctx, `
-		UPDATE asset_service
-		SET service_end = now()
-		WHERE asset_id = $1 AND archived_at IS NULL AND service_end IS NULL
+func (r *PostgresAssetRepository) GetReturnRequests(ctx context.Context, filter models.ReturnRequestFilter) ([]models.ReturnRequestRes, error) {
+	requests := []models.ReturnRequestRes{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &requests, `
+		SELECT
+			rr.id, rr.asset_id, a.brand, a.model, a.serial_no,
+			rr.employee_id, u.username AS employee_name,
+			rr.reason, rr.status, rr.created_at, rr.resolved_at
+		FROM asset_return_requests rr
+		JOIN assets a ON a.id = rr.asset_id
+		JOIN users u ON u.id = rr.employee_id
+		WHERE ($1 = '' OR rr.status = $1)
+		AND ($2 OR a.department_id IS NOT DISTINCT FROM $3)
+		AND ($6::uuid IS NULL OR a.organization_id = $6)
+		ORDER BY rr.created_at DESC
+		LIMIT $4 OFFSET $5
+	`, filter.Status, filter.Scope.AllDepartments, filter.Scope.DepartmentID, filter.Limit, filter.Offset, filter.Scope.OrganizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch return requests: %w", err)
+	}
+	return requests, nil
+}
+
+// GetAssetsWarrantyExpiringWithin returns assets whose warranty ends in the next days and that were
+// not alerted on yet, archived and already expired assets are skipped
+func (r *PostgresAssetRepository) GetAssetsWarrantyExpiringWithin(ctx context.Context, days int) ([]models.WarrantyAlertRes, error) {
+	assets := []models.WarrantyAlertRes{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &assets, `
+		SELECT id AS asset_id, brand, model, serial_no, warranty_expire, department_id, organization_id
+		FROM assets
+		WHERE archived_at IS NULL
+		AND warranty_alerted_at IS NULL
+		AND warranty_expire > now()
+		AND warranty_expire <= now() + make_interval(days => $1)
+		ORDER BY warranty_expire
+	`, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch assets with expiring warranty: %w", err)
+	}
+	return assets, nil
+}
+
+func (r *PostgresAssetRepository) MarkWarrantyAlerted(ctx context.Context, assetID uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE assets SET warranty_alerted_at = now() WHERE id = $1
 	`, assetID)
 	if err != nil {
-		return fmt.Errorf("failed to update asset_service end_date: %w", err)
+		return fmt.Errorf("failed to mark warranty alerted: %w", err)
+	}
+	return nil
+}
+
+// GetOverdueReturnRequests returns return requests open for more than days that nobody was told
+// about yet
+func (r *PostgresAssetRepository) GetOverdueReturnRequests(ctx context.Context, days int) ([]models.OverdueReturnRes, error) {
+	requests := []models.OverdueReturnRes{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &requests, `
+		SELECT
+			rr.id, rr.asset_id, a.brand, a.model, a.serial_no,
+			rr.employee_id, u.username AS employee_name, a.department_id, a.organization_id, rr.created_at
+		FROM asset_return_requests rr
+		JOIN assets a ON a.id = rr.asset_id
+		JOIN users u ON u.id = rr.employee_id
+		WHERE rr.status = 'open'
+		AND rr.overdue_notified_at IS NULL
+		AND rr.created_at <= now() - make_interval(days => $1)
+		ORDER BY rr.created_at
+	`, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch overdue return requests: %w", err)
 	}
+	return requests, nil
+}
 
+func (r *PostgresAssetRepository) MarkReturnOverdueNotified(ctx context.Context, requestID uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE asset_return_requests SET overdue_notified_at = now() WHERE id = $1
+	`, requestID)
+	if err != nil {
+		return fmt.Errorf("failed to mark return request overdue notified: %w", err)
+	}
 	return nil
 }
 
-func (r *PostgresAssetRepository) RetrieveAsset(ctx context.Context, tx *sqlx.Tx, assetID uuid.UUID, employeeID uuid.UUID, reason string) error {
-	res, err := tx.ExecContext(ctx, `
-		UPDATE asset_assign 
-		SET returned_at = now(), return_reason = $1
-		WHERE asset_id = $2 AND employee_id = $3 AND returned_at IS NULL AND archived_at IS NULL
-	`, reason, assetID, employeeID)
+const loanQuery = `
+	SELECT
+		aa.id, aa.asset_id, a.brand, a.model, a.serial_no,
+		aa.employee_id, u.username AS employee_name, a.department_id, a.organization_id,
+		aa.assigned_at::timestamptz AS assigned_at, aa.loan_until, aa.loan_until <= now() AS overdue, aa.loan_overdue_at
+	FROM asset_assign aa
+	JOIN assets a ON a.id = aa.asset_id
+	JOIN users u ON u.id = aa.employee_id
+	WHERE aa.loan_until IS NOT NULL AND aa.returned_at IS NULL AND aa.archived_at IS NULL`
+
+// GetLoans lists the open loans of assets in scope, the ones due first first
+func (r *PostgresAssetRepository) GetLoans(ctx context.Context, filter models.LoanFilter) ([]models.LoanRes, error) {
+	loans := []models.LoanRes{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &loans, loanQuery+`
+		AND (NOT $1 OR aa.loan_until <= now())
+		AND ($2 OR a.department_id IS NOT DISTINCT FROM $3)
+		AND ($4::uuid IS NULL OR a.organization_id = $4)
+		ORDER BY aa.loan_until
+		LIMIT $5 OFFSET $6
+	`, filter.Overdue, filter.Scope.AllDepartments, filter.Scope.DepartmentID, filter.Scope.OrganizationID, filter.Limit, filter.Offset)
 	if err != nil {
-		return fmt.Errorf("failed to update asset_assign: %w", err)
+		return nil, fmt.Errorf("failed to fetch loans: %w", err)
 	}
+	return loans, nil
+}
 
-	rowsAffected, err := res.RowsAffected()
+func (r *PostgresAssetRepository) GetOverdueLoans(ctx context.Context) ([]models.LoanRes, error) {
+	loans := []models.LoanRes{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &loans, loanQuery+`
+		AND aa.loan_until <= now() AND aa.loan_overdue_at IS NULL
+		ORDER BY aa.loan_until
+	`)
 	if err != nil {
-		return fmt.Errorf("failed to fetch rows affected: %w", err)
+		return nil, fmt.Errorf("failed to fetch overdue loans: %w", err)
 	}
-	fmt.Println("Rows affected (asset_assign):", rowsAffected)
+	return loans, nil
+}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("no matching asset assignment found or already returned")
+func (r *PostgresAssetRepository) MarkLoanOverdue(ctx context.Context, assignmentID uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE asset_assign SET loan_overdue_at = now() WHERE id = $1
+	`, assignmentID)
+	if err != nil {
+		return fmt.Errorf("failed to flag overdue loan: %w", err)
 	}
+	return nil
+}
 
-	_, err = tx.ExecContext(ctx, `
-		UPDATE assets SET status = 'available' WHERE id = $1 AND archived_at IS NULL
+// AcknowledgeAssignment records that the employee received the asset they hold, acknowledging twice
+// keeps the first time
+func (r *PostgresAssetRepository) AcknowledgeAssignment(ctx context.Context, assetID, employeeID uuid.UUID) error {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE asset_assign SET acknowledged_at = COALESCE(acknowledged_at, now())
+		WHERE asset_id = $1 AND employee_id = $2 AND returned_at IS NULL AND archived_at IS NULL
+	`, assetID, employeeID)
+	if err != nil {
+		return fmt.Errorf("failed to acknowledge assignment: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAssignmentNotFound
+	}
+	return nil
+}
+
+// GetPendingAcknowledgments returns the open assignments made at least days ago that their employee
+// hasn't acknowledged and was reminded of fewer than maxReminders times
+func (r *PostgresAssetRepository) GetPendingAcknowledgments(ctx context.Context, days, maxReminders int) ([]models.PendingAcknowledgment, error) {
+	pending := []models.PendingAcknowledgment{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &pending, `
+		SELECT
+			aa.id, aa.asset_id, a.brand, a.model, a.serial_no,
+			aa.employee_id, u.username AS employee_name, u.department_id, u.organization_id,
+			aa.assigned_at::timestamptz AS assigned_at, aa.ack_reminders AS reminders
+		FROM asset_assign aa
+		JOIN assets a ON a.id = aa.asset_id
+		JOIN users u ON u.id = aa.employee_id
+		WHERE aa.acknowledged_at IS NULL AND aa.returned_at IS NULL AND aa.archived_at IS NULL
+		AND u.archived_at IS NULL
+		AND aa.ack_reminders < $2
+		AND aa.assigned_at <= now() - make_interval(days => $1)
+		ORDER BY aa.assigned_at
+	`, days, maxReminders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pending acknowledgments: %w", err)
+	}
+	return pending, nil
+}
+
+func (r *PostgresAssetRepository) MarkAcknowledgmentReminded(ctx context.Context, assignmentID uuid.UUID, reminders int) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE asset_assign SET ack_reminders = $2 WHERE id = $1
+	`, assignmentID, reminders)
+	if err != nil {
+		return fmt.Errorf("failed to mark acknowledgment reminded: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresAssetRepository) GetAssetBrief(ctx context.Context, assetID uuid.UUID) (models.AssetBrief, error) {
+	var asset models.AssetBrief
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &asset, `
+		SELECT id, brand, model, serial_no FROM assets WHERE id = $1
 	`, assetID)
 	if err != nil {
-		return fmt.Errorf("failed to update asset status: %w", err)
+		return asset, fmt.Errorf("failed to fetch asset: %w", err)
+	}
+	return asset, nil
+}
+
+// serviceTicketColumns are the columns of models.ServiceTicket, read from asset_service s joined to assets a
+const serviceTicketColumns = `s.id, s.asset_id, a.brand, a.model, a.serial_no, s.reason, s.ticket_key, s.created_at`
+
+// GetServicesAwaitingTicket returns services whose ticket couldn't be opened yet, oldest first
+func (r *PostgresAssetRepository) GetServicesAwaitingTicket(ctx context.Context, limit int) ([]models.ServiceTicket, error) {
+	services := []models.ServiceTicket{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &services, `
+		SELECT `+serviceTicketColumns+`
+		FROM asset_service s
+		JOIN assets a ON a.id = s.asset_id
+		WHERE s.ticket_status = $1 AND s.ticket_key IS NULL AND s.ticket_resolved_at IS NULL
+		ORDER BY s.created_at
+		LIMIT $2
+	`, models.TicketStatusPending, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch services awaiting a ticket: %w", err)
+	}
+	return services, nil
+}
+
+// GetUnresolvedServiceTickets returns opened tickets that weren't resolved yet, the least recently
+// synced first so every ticket gets its turn
+func (r *PostgresAssetRepository) GetUnresolvedServiceTickets(ctx context.Context, limit int) ([]models.ServiceTicket, error) {
+	services := []models.ServiceTicket{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &services, `
+		SELECT `+serviceTicketColumns+`
+		FROM asset_service s
+		JOIN assets a ON a.id = s.asset_id
+		WHERE s.ticket_key IS NOT NULL AND s.ticket_status IS NOT NULL AND s.ticket_resolved_at IS NULL
+		ORDER BY s.created_at
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch unresolved service tickets: %w", err)
+	}
+	return services, nil
+}
+
+// SetServiceTicket stores the ticket opened for a service, open stands in for a status the service
+// desk didn't report on creation
+func (r *PostgresAssetRepository) SetServiceTicket(ctx context.Context, serviceID uuid.UUID, ticket models.Ticket) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE asset_service
+		SET ticket_key = $2, ticket_status = COALESCE(NULLIF($3, ''), 'open'), ticket_resolved_at = CASE WHEN $4 THEN now() END
+		WHERE id = $1
+	`, serviceID, ticket.Key, ticket.Status, ticket.Resolved)
+	if err != nil {
+		return fmt.Errorf("failed to store service ticket: %w", err)
+	}
+	return nil
+}
+
+// UpdateServiceTicket stores the status the service desk reports for a ticket, false when no service
+// has the ticket
+func (r *PostgresAssetRepository) UpdateServiceTicket(ctx context.Context, ticket models.Ticket) (bool, error) {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE asset_service
+		SET ticket_status = $2,
+			ticket_resolved_at = CASE WHEN $3 THEN COALESCE(ticket_resolved_at, now()) END
+		WHERE ticket_key = $1
+	`, ticket.Key, ticket.Status, ticket.Resolved)
+	if err != nil {
+		return false, fmt.Errorf("failed to update service ticket: %w", err)
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update service ticket: %w", err)
+	}
+	return updated > 0, nil
+}
+
+// GetStockLevels counts the available assets of every type in types, a type without any is counted 0
+func (r *PostgresAssetRepository) GetStockLevels(ctx context.Context, types []string) ([]models.StockLevel, error) {
+	levels := []models.StockLevel{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &levels, `
+		SELECT t.type, count(a.id) AS available
+		FROM unnest($1::asset_type[]) AS t(type)
+		LEFT JOIN assets a ON a.type = t.type AND a.status = 'available' AND a.archived_at IS NULL
+		GROUP BY t.type
+		ORDER BY t.type
+	`, pq.Array(types))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count available assets: %w", err)
+	}
+	return levels, nil
+}
+
+// MarkLowStockAlerted records the shortage of a type, false when it was already alerted on
+func (r *PostgresAssetRepository) MarkLowStockAlerted(ctx context.Context, level models.StockLevel) (bool, error) {
+	result, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO low_stock_alerts (type, available) VALUES ($1, $2)
+		ON CONFLICT (type) DO NOTHING
+	`, level.Type, level.Available)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark low stock alerted: %w", err)
+	}
+	inserted, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to mark low stock alerted: %w", err)
+	}
+	return inserted > 0, nil
+}
+
+// ClearLowStockAlerts forgets the shortages of types that are back in stock
+func (r *PostgresAssetRepository) ClearLowStockAlerts(ctx context.Context, types []string) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		DELETE FROM low_stock_alerts WHERE type = ANY($1::asset_type[])
+	`, pq.Array(types))
+	if err != nil {
+		return fmt.Errorf("failed to clear low stock alerts: %w", err)
 	}
-	fmt.Println("Asset status updated to 'available'")
 	return nil
 }
 
-func (r *PostgresAssetRepository) SearchAssetsWithFilter(ctx context.Context, filter models.AssetFilter) (assets []models.AssetWithConfigRes, err error) {
-	tx, err := r.DB.BeginTxx(ctx, nil)
+// GetMDMAsset returns the asset with serialNo, preferring a live one over archived ones, and the email
+// of its current assignee. sql.ErrNoRows when no asset has it
+func (r *PostgresAssetRepository) GetMDMAsset(ctx context.Context, serialNo string) (models.MDMAsset, error) {
+	var asset models.MDMAsset
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &asset, `
+		SELECT a.id, a.archived_at IS NOT NULL AS archived, u.email AS assignee_email
+		FROM assets a
+		LEFT JOIN asset_assign aa ON aa.asset_id = a.id AND aa.returned_at IS NULL AND aa.archived_at IS NULL
+		LEFT JOIN users u ON u.id = aa.employee_id
+		WHERE a.serial_no = $1
+		ORDER BY a.archived_at IS NOT NULL, a.added_at DESC
+		LIMIT 1
+	`, serialNo)
 	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		return models.MDMAsset{}, fmt.Errorf("failed to fetch asset by serial number: %w", err)
 	}
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			panic(p)
-		} else if err != nil {
-			tx.Rollback()
-		} else {
-			err = tx.Commit()
+	return asset, nil
+}
+
+// GetKioskEmployee finds the active employee a badge was given to, ErrBadgeNotFound when nobody has it
+func (r *PostgresAssetRepository) GetKioskEmployee(ctx context.Context, badgeID string) (models.KioskEmployee, error) {
+	var employee models.KioskEmployee
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &employee, `
+		SELECT id, username FROM users
+		WHERE badge_id = $1 AND archived_at IS NULL
+	`, badgeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.KioskEmployee{}, ErrBadgeNotFound
 		}
-	}()
+		return models.KioskEmployee{}, fmt.Errorf("failed to fetch employee by badge: %w", err)
+	}
+	return employee, nil
+}
 
-	args := []interface{}{
-		!filter.IsSearchText,
-		filter.SearchText,
-		pq.Array(filter.Status),
-		pq.Array(filter.OwnedBy),
-		pq.Array(filter.Type),
-		filter.Limit,
-		filter.Offset,
+// GetKioskAsset finds a live asset by the serial number on its label
+func (r *PostgresAssetRepository) GetKioskAsset(ctx context.Context, serialNo string) (models.KioskAsset, error) {
+	var asset models.KioskAsset
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &asset, `
+		SELECT id, brand, model, type, status, kiosk_pool FROM assets
+		WHERE serial_no = $1 AND archived_at IS NULL
+	`, serialNo)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.KioskAsset{}, ErrAssetNotFound
+		}
+		return models.KioskAsset{}, fmt.Errorf("failed to fetch asset by serial number: %w", err)
 	}
+	return asset, nil
+}
 
-	query := `
-		SELECT id, brand, model, serial_no, type, owned_by, status, purchase_date, warranty_start, warranty_expire
-		FROM assets
-		WHERE archived_at IS NULL
-		AND (
-			$1 OR (
-				brand ILIKE $2 OR 
-				model ILIKE $2 OR 
-				serial_no ILIKE $2
-			)
+// SaveMDMStatus stores what the mdm reported for an asset, true when its mismatch is new and wasn't
+// reported by an earlier sync
+func (r *PostgresAssetRepository) SaveMDMStatus(ctx context.Context, assetID uuid.UUID, system string, device models.MDMDevice, mismatch bool) (bool, error) {
+	var lastCheckIn *time.Time
+	if !device.LastCheckIn.IsZero() {
+		lastCheckIn = &device.LastCheckIn
+	}
+	var newMismatch bool
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &newMismatch, `
+		WITH previous AS (
+			SELECT mismatch_since FROM asset_mdm_status WHERE asset_id = $1
 		)
-		AND status = ANY($3)
-		AND owned_by = ANY($4)
-		AND type = ANY($5)
-		ORDER BY added_at DESC
-		LIMIT $6 OFFSET $7
-	`
+		INSERT INTO asset_mdm_status (asset_id, mdm_system, device_id, device_name, os, os_version, user_email, last_check_in, mismatch_since)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, CASE WHEN $9 THEN now() END)
+		ON CONFLICT (asset_id) DO UPDATE SET
+			mdm_system = EXCLUDED.mdm_system,
+			device_id = EXCLUDED.device_id,
+			device_name = EXCLUDED.device_name,
+			os = EXCLUDED.os,
+			os_version = EXCLUDED.os_version,
+			user_email = EXCLUDED.user_email,
+			last_check_in = EXCLUDED.last_check_in,
+			synced_at = now(),
+			mismatch_since = CASE WHEN $9 THEN COALESCE(asset_mdm_status.mismatch_since, now()) END
+		RETURNING $9 AND NOT EXISTS (SELECT 1 FROM previous WHERE mismatch_since IS NOT NULL)
+	`, assetID, system, device.ExternalID, device.DeviceName, device.OS, device.OSVersion, device.UserEmail, lastCheckIn, mismatch)
+	if err != nil {
+		return false, fmt.Errorf("failed to save mdm status: %w", err)
+	}
+	return newMismatch, nil
+}
 
-	// Use SelectContext to fetch assets.
-	err = tx.SelectContext(ctx, &assets, query, args...)
+// GetMDMMismatches returns the assets whose device is enrolled for someone other than their assignee,
+// longest standing first
+func (r *PostgresAssetRepository) GetMDMMismatches(ctx context.Context, filter models.MDMMismatchFilter) ([]models.MDMMismatch, error) {
+	mismatches := []models.MDMMismatch{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &mismatches, `
+		SELECT
+			m.asset_id, a.brand, a.model, a.serial_no, m.device_name, m.user_email,
+			u.email AS assignee_email, m.last_check_in, m.mismatch_since
+		FROM asset_mdm_status m
+		JOIN assets a ON a.id = m.asset_id AND a.archived_at IS NULL
+		LEFT JOIN asset_assign aa ON aa.asset_id = a.id AND aa.returned_at IS NULL AND aa.archived_at IS NULL
+		LEFT JOIN users u ON u.id = aa.employee_id
+		WHERE m.mismatch_since IS NOT NULL
+		AND ($1 OR a.department_id IS NOT DISTINCT FROM $2)
+		AND ($5::uuid IS NULL OR a.organization_id = $5)
+		ORDER BY m.mismatch_since, m.asset_id
+		LIMIT $3 OFFSET $4
+	`, filter.Scope.AllDepartments, filter.Scope.DepartmentID, filter.Limit, filter.Offset, filter.Scope.OrganizationID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch assets: %w", err)
+		return nil, fmt.Errorf("failed to fetch mdm mismatches: %w", err)
+	}
+	return mismatches, nil
+}
+
+// GetDuplicateAssets groups live assets by lowercased serial number and by the imeis of mobiles,
+// only groups with more than one asset are returned
+func (r *PostgresAssetRepository) GetDuplicateAssets(ctx context.Context, filter models.DuplicateFilter) ([]models.DuplicateGroup, error) {
+	groups := []models.DuplicateGroup{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &groups, `
+		WITH live AS (
+			SELECT id, brand, model, serial_no, type, status, added_at FROM assets
+			WHERE archived_at IS NULL
+			AND ($1 OR department_id IS NOT DISTINCT FROM $2)
+			AND ($3::uuid IS NULL OR organization_id = $3)
+		), keys AS (
+			SELECT 'serial_no' AS match, lower(serial_no) AS value, id FROM live
+			UNION
+			SELECT 'imei', lower(i.imei), m.asset_id
+			FROM mobile_config m
+			CROSS JOIN LATERAL (VALUES (m.imei_1), (m.imei_2)) AS i(imei)
+			JOIN live l ON l.id = m.asset_id
+			WHERE m.archived_at IS NULL AND COALESCE(i.imei, '') <> ''
+		)
+		SELECT k.match, k.value,
+			json_agg(json_build_object(
+				'id', l.id, 'brand', l.brand, 'model', l.model, 'serial_no', l.serial_no,
+				'type', l.type, 'status', l.status, 'added_at', l.added_at
+			) ORDER BY l.added_at, l.id) AS assets
+		FROM keys k
+		JOIN live l ON l.id = k.id
+		GROUP BY k.match, k.value
+		HAVING count(*) > 1
+		ORDER BY k.match DESC, k.value
+		LIMIT $4 OFFSET $5
+	`, filter.Scope.AllDepartments, filter.Scope.DepartmentID, filter.Scope.OrganizationID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch duplicate assets: %w", err)
+	}
+	return groups, nil
+}
+
+// mergedTables hold the history of an asset and are moved to the kept asset on a merge. Configs and
+// the mdm status stay with the duplicate, the kept asset has its own
+var mergedTables = []string{
+	"asset_assign", "asset_assign_history", "asset_service", "asset_service_history",
+	"asset_return_requests", "asset_theft_reports", "asset_attachments", "handover_signatures",
+}
+
+// MergeAssets moves everything recorded against duplicateID to keepID and archives the duplicate
+// with merged_into set, it has to run in a transaction. At most one of the two may be assigned and
+// at most one in service, the kept asset takes over the state of the one that is
+func (r *PostgresAssetRepository) MergeAssets(ctx context.Context, keepID, duplicateID, mergedBy uuid.UUID) (map[string]int64, error) {
+	var organizations []*uuid.UUID
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &organizations, `
+		SELECT organization_id FROM assets
+		WHERE id IN ($1, $2) AND archived_at IS NULL
+		ORDER BY id
+		FOR UPDATE
+	`, keepID, duplicateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock assets: %w", err)
+	}
+	if len(organizations) != 2 {
+		return nil, ErrAssetNotFound
+	}
+	if (organizations[0] == nil) != (organizations[1] == nil) || (organizations[0] != nil && *organizations[0] != *organizations[1]) {
+		return nil, ErrMergeAcrossOrganizations
+	}
+
+	var assigned, inService int
+	err = utils.Conn(ctx, r.DB).GetContext(ctx, &assigned, `
+		SELECT count(DISTINCT asset_id) FROM asset_assign
+		WHERE asset_id IN ($1, $2) AND returned_at IS NULL AND archived_at IS NULL
+	`, keepID, duplicateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check assignments: %w", err)
+	}
+	if assigned > 1 {
+		return nil, ErrMergeBothAssigned
+	}
+	err = utils.Conn(ctx, r.DB).GetContext(ctx, &inService, `
+		SELECT count(DISTINCT asset_id) FROM asset_service
+		WHERE asset_id IN ($1, $2) AND service_end IS NULL AND archived_at IS NULL
+	`, keepID, duplicateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check services: %w", err)
+	}
+	if inService > 1 {
+		return nil, ErrMergeBothInService
+	}
+
+	// only one open return request per asset and employee may exist
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE asset_return_requests SET status = 'cancelled', resolved_at = now()
+		WHERE asset_id = $2 AND status = 'open' AND employee_id IN (
+			SELECT employee_id FROM asset_return_requests WHERE asset_id = $1 AND status = 'open'
+		)
+	`, keepID, duplicateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel duplicate return requests: %w", err)
 	}
 
-	for i, asset := range assets {
-		var config interface{}
-		switch asset.Type {
-		case "laptop":
-			var configTemp models.Laptop_config_res
-			err = tx.GetContext(ctx, &configTemp, `SELECT processor, ram, os FROM laptop_config WHERE asset_id = $1`, asset.ID)
-			config = configTemp
-		case "mouse":
-			var configTemp models.Mouse_config_res
-			err = tx.GetContext(ctx, &configTemp, `SELECT dpi FROM mouse_config WHERE asset_id = $1`, asset.ID)
-			config = configTemp
-		case "monitor":
-			var configTemp models.Monitor_config_res
-			err = tx.GetContext(ctx, &configTemp, `SELECT display, resolution, port FROM monitor_config WHERE asset_id = $1`, asset.ID)
-			config = configTemp
-		case "mobile":
-			var configTemp models.Mobile_config_res
-			err = tx.GetContext(ctx, &configTemp, `SELECT processor, ram, os, imei_1, imei_2 FROM mobile_config WHERE asset_id = $1`, asset.ID)
-			config = configTemp
-		case "hard_disk":
-			var configTemp models.Hard_disk_config_res
-			err = tx.GetContext(ctx, &configTemp, `SELECT type, storage FROM hard_disk_config WHERE asset_id = $1`, asset.ID)
-			config = configTemp
-		case "pen_drive":
-			var configTemp models.Pen_drive_config_res
-			err = tx.GetContext(ctx, &configTemp, `SELECT version, storage FROM pendrive_config WHERE asset_id = $1`, asset.ID)
-			config = configTemp
-		case "sim":
-			var configTemp models.Sim_config_res
-			err = tx.GetContext(ctx, &configTemp, `SELECT number FROM sim_config WHERE asset_id = $1`, asset.ID)
-			config = configTemp
-		case "accessory":
-			var configTemp models.Accessories_config_res
-			err = tx.GetContext(ctx, &configTemp, `SELECT type, additional_info FROM accessories_config WHERE asset_id = $1`, asset.ID)
-			config = configTemp
+	moved := map[string]int64{}
+	for _, table := range mergedTables {
+		res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `UPDATE `+table+` SET asset_id = $1 WHERE asset_id = $2`, keepID, duplicateID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to move %s: %w", table, err)
 		}
-		if err != nil && !errors.Is(err, sql.ErrNoRows) { // Check for sql.ErrNoRows specifically
-			return nil, fmt.Errorf("failed to fetch config for asset %s: %w", asset.ID, err)
+		if n, _ := res.RowsAffected(); n > 0 {
+			moved[table] = n
 		}
+	}
+	// sims put in the duplicate of a mobile are in the kept one
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `UPDATE sim_config SET mobile_asset_id = $1 WHERE mobile_asset_id = $2`, keepID, duplicateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move sims: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		moved["sim_config"] = n
+	}
+
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE assets k SET
+			status = CASE WHEN k.status = 'available' THEN d.status ELSE k.status END,
+			department_id = COALESCE(k.department_id, d.department_id),
+			purchase_cost = COALESCE(k.purchase_cost, d.purchase_cost),
+			salvage_value = COALESCE(k.salvage_value, d.salvage_value),
+			useful_life_months = COALESCE(k.useful_life_months, d.useful_life_months),
+			kiosk_pool = k.kiosk_pool OR d.kiosk_pool
+		FROM assets d
+		WHERE k.id = $1 AND d.id = $2
+	`, keepID, duplicateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update kept asset: %w", err)
+	}
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE assets SET archived_at = now(), archived_by = $3, merged_into = $1 WHERE id = $2
+	`, keepID, duplicateID, mergedBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive duplicate asset: %w", err)
+	}
+	return moved, nil
+}
+
+// utilizationUsage has a row per asset that existed during part of the window $1 to $2, with the
+// seconds of that part it was assigned and in service. An assignment or service without an end runs
+// until it was archived or the window closes. Both read the history views, so moved rows still count
+const utilizationUsage = `
+	WITH tracked AS (
+		SELECT a.id, a.brand, a.model, a.serial_no, COALESCE(a.type::text, '') AS type, a.status::text AS status,
+			tstzrange(GREATEST($1, COALESCE(a.added_at, $1)), LEAST($2, COALESCE(a.archived_at, $2))) AS span
+		FROM assets a
+		WHERE COALESCE(a.added_at, $1) < $2 AND (a.archived_at IS NULL OR a.archived_at > $1)
+		AND ($3 = '' OR a.type::text = $3)
+		AND ($4 OR a.department_id IS NOT DISTINCT FROM $5)
+		AND ($6::uuid IS NULL OR a.organization_id = $6)
+	),
+	usage AS (
+		SELECT t.id, t.brand, t.model, t.serial_no, t.type, t.status,
+			extract(epoch FROM upper(t.span) - lower(t.span)) AS tracked_secs,
+			COALESCE((
+				SELECT sum(extract(epoch FROM upper(r) - lower(r)))
+				FROM (
+					SELECT t.span * tstzrange(aa.assigned_at, GREATEST(aa.assigned_at, COALESCE(aa.returned_at, aa.archived_at, $2))) AS r
+					FROM asset_assign_all aa
+					WHERE aa.asset_id = t.id AND aa.assigned_at < upper(t.span)
+				) assigned
+				WHERE NOT isempty(r)
+			), 0) AS assigned_secs,
+			COALESCE((
+				SELECT sum(extract(epoch FROM upper(r) - lower(r)))
+				FROM (
+					SELECT t.span * tstzrange(s.service_start, GREATEST(s.service_start, COALESCE(s.service_end, s.archived_at, $2))) AS r
+					FROM asset_service_all s
+					WHERE s.asset_id = t.id AND s.service_start < upper(t.span)
+				) serviced
+				WHERE NOT isempty(r)
+			), 0) AS service_secs
+		FROM tracked t
+	)`
+
+func utilizationArgs(filter models.UtilizationFilter) []interface{} {
+	return []interface{}{filter.From, filter.To, filter.Type, filter.Scope.AllDepartments, filter.Scope.DepartmentID, filter.Scope.OrganizationID}
+}
+
+// GetBlacklistedIMEIs returns the entries of the blacklist among imeis
+func (r *PostgresAssetRepository) GetBlacklistedIMEIs(ctx context.Context, imeis []string) ([]models.BlacklistedIMEI, error) {
+	entries := []models.BlacklistedIMEI{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &entries, `
+		SELECT imei, reason, asset_id, added_by, created_at FROM imei_blacklist
+		WHERE imei = ANY($1)
+		ORDER BY imei
+	`, pq.Array(imeis))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check imei blacklist: %w", err)
+	}
+	return entries, nil
+}
+
+// ListIMEIBlacklist returns the blacklist newest first, search matches the start of an imei
+func (r *PostgresAssetRepository) ListIMEIBlacklist(ctx context.Context, filter models.IMEIBlacklistFilter) ([]models.BlacklistedIMEI, error) {
+	entries := []models.BlacklistedIMEI{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &entries, `
+		SELECT imei, reason, asset_id, added_by, created_at FROM imei_blacklist
+		WHERE ($1 = '' OR imei LIKE $1 || '%')
+		ORDER BY created_at DESC, imei
+		LIMIT $2 OFFSET $3
+	`, filter.Search, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch imei blacklist: %w", err)
+	}
+	return entries, nil
+}
+
+func (r *PostgresAssetRepository) AddBlacklistedIMEI(ctx context.Context, entry models.BlacklistedIMEI) error {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO imei_blacklist (imei, reason, asset_id, added_by) VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING
+	`, entry.IMEI, entry.Reason, entry.AssetID, entry.AddedBy)
+	if err != nil {
+		return fmt.Errorf("failed to blacklist imei: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrIMEIAlreadyBlacklisted
+	}
+	return nil
+}
 
-		assets[i].Config = config
+func (r *PostgresAssetRepository) RemoveBlacklistedIMEI(ctx context.Context, imei string) error {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `DELETE FROM imei_blacklist WHERE imei = $1`, imei)
+	if err != nil {
+		return fmt.Errorf("failed to remove imei from blacklist: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrIMEINotBlacklisted
 	}
+	return nil
+}
 
+// SetIMEIFlag records why a mobile was flagged at intake, nil clears the flag
+func (r *PostgresAssetRepository) SetIMEIFlag(ctx context.Context, assetID uuid.UUID, flag *string) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `UPDATE mobile_config SET imei_flag = $2 WHERE asset_id = $1`, assetID, flag)
+	if err != nil {
+		return fmt.Errorf("failed to flag mobile: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresAssetRepository) SaveWarrantyLookup(ctx context.Context, assetID uuid.UUID, info models.WarrantyInfo) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO asset_warranty_lookups (asset_id, vendor, warranty_start, warranty_expire, response)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (asset_id) DO UPDATE SET vendor = $2, warranty_start = $3, warranty_expire = $4, response = $5, fetched_at = now()
+	`, assetID, info.Vendor, info.WarrantyStart, info.WarrantyExpire, []byte(info.Response))
+	if err != nil {
+		return fmt.Errorf("failed to save warranty lookup: %w", err)
+	}
+	return nil
+}
+
+// GetWarrantyLookup returns ErrWarrantyLookupNotFound for an asset whose warranty was entered by hand
+func (r *PostgresAssetRepository) GetWarrantyLookup(ctx context.Context, assetID uuid.UUID) (models.WarrantyLookup, error) {
+	var lookup models.WarrantyLookup
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &lookup, `
+		SELECT asset_id, vendor, warranty_start, warranty_expire, response, fetched_at
+		FROM asset_warranty_lookups WHERE asset_id = $1
+	`, assetID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return lookup, ErrWarrantyLookupNotFound
+	}
+	if err != nil {
+		return lookup, fmt.Errorf("failed to fetch warranty lookup: %w", err)
+	}
+	return lookup, nil
+}
+
+// GetAssetUtilization lists the assets of the window least used first, days are rounded to one decimal
+func (r *PostgresAssetRepository) GetAssetUtilization(ctx context.Context, filter models.UtilizationFilter) ([]models.AssetUtilization, error) {
+	assets := []models.AssetUtilization{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &assets, utilizationUsage+`
+		SELECT id AS asset_id, brand, model, serial_no, type, status,
+			round((tracked_secs / 86400)::numeric, 1)::float8 AS days_tracked,
+			round((assigned_secs / 86400)::numeric, 1)::float8 AS days_assigned,
+			round((service_secs / 86400)::numeric, 1)::float8 AS days_in_service,
+			round((GREATEST(tracked_secs - assigned_secs - service_secs, 0) / 86400)::numeric, 1)::float8 AS days_idle,
+			CASE WHEN tracked_secs > 0 THEN round((assigned_secs / tracked_secs)::numeric, 3)::float8 ELSE 0 END AS utilization
+		FROM usage
+		ORDER BY utilization, serial_no, id
+		LIMIT $7 OFFSET $8
+	`, append(utilizationArgs(filter), filter.Limit, filter.Offset)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch asset utilization: %w", err)
+	}
 	return assets, nil
 }
 
-func (r *PostgresAssetRepository) SendAssetForService(ctx context.Context, req models.AssetServiceReq, managerUUID uuid.UUID) (err error) {
-	tx, err := r.DB.BeginTxx(ctx, nil)
+// GetTypeUtilization adds up the window per asset type, utilization is of the summed days so long
+// lived assets weigh more than ones bought late in the window
+func (r *PostgresAssetRepository) GetTypeUtilization(ctx context.Context, filter models.UtilizationFilter) ([]models.TypeUtilization, error) {
+	types := []models.TypeUtilization{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &types, utilizationUsage+`
+		SELECT type, count(*) AS assets,
+			round((sum(tracked_secs) / 86400)::numeric, 1)::float8 AS days_tracked,
+			round((sum(assigned_secs) / 86400)::numeric, 1)::float8 AS days_assigned,
+			round((sum(service_secs) / 86400)::numeric, 1)::float8 AS days_in_service,
+			round((sum(GREATEST(tracked_secs - assigned_secs - service_secs, 0)) / 86400)::numeric, 1)::float8 AS days_idle,
+			CASE WHEN sum(tracked_secs) > 0 THEN round((sum(assigned_secs) / sum(tracked_secs))::numeric, 3)::float8 ELSE 0 END AS utilization
+		FROM usage
+		GROUP BY type
+		ORDER BY type
+	`, utilizationArgs(filter)...)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to fetch type utilization: %w", err)
 	}
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			panic(p)
-		} else if err != nil {
-			tx.Rollback()
-		} else {
-			err = tx.Commit()
-		}
-	}()
+	return types, nil
+}
 
-	var inService bool
-	err = tx.GetContext(ctx, &inService, `
-		SELECT EXISTS (
-			SELECT 1 FROM asset_service 
-			WHERE asset_id = $1 AND service_end IS NULL AND archived_at IS NULL
-		)
-	`, req.AssetID)
+// churnGroups are what assignment stats can be grouped by, the asset's type or department
+var churnGroups = map[string]string{
+	"type":       `COALESCE(a.type::text, '')`,
+	"department": `COALESCE(d.name, '')`,
+}
+
+// churnAssignments has the assignments that started in the window $1 to $2, with their asset and its
+// department. Closed ones are read through the history view too
+const churnAssignments = `
+	WITH window_assignments AS (
+		SELECT aa.asset_id, aa.assigned_at, aa.returned_at, aa.return_reason, %s AS grp
+		FROM asset_assign_all aa
+		JOIN assets a ON a.id = aa.asset_id
+		LEFT JOIN departments d ON d.id = a.department_id
+		WHERE aa.assigned_at >= $1 AND aa.assigned_at < $2 AND aa.archived_at IS NULL
+		AND ($3 = '' OR a.type::text = $3)
+		AND ($4 OR a.department_id IS NOT DISTINCT FROM $5)
+		AND ($6::uuid IS NULL OR a.organization_id = $6)
+	)`
+
+func churnArgs(filter models.ChurnFilter) []interface{} {
+	return []interface{}{filter.From, filter.To, filter.Type, filter.Scope.AllDepartments, filter.Scope.DepartmentID, filter.Scope.OrganizationID}
+}
+
+// GetAssignmentStats aggregates the window's assignments by the asset's type or department, groupBy
+// is a key of churnGroups
+func (r *PostgresAssetRepository) GetAssignmentStats(ctx context.Context, filter models.ChurnFilter, groupBy string) ([]models.AssignmentStats, error) {
+	group, ok := churnGroups[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown assignment stats group %q", groupBy)
+	}
+	stats := []models.AssignmentStats{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &stats, fmt.Sprintf(churnAssignments, group)+`
+		SELECT grp,
+			count(*) AS assignments,
+			count(returned_at) AS returned,
+			count(DISTINCT asset_id) AS assets,
+			COALESCE(round((avg(extract(epoch FROM returned_at - assigned_at)) / 86400)::numeric, 1)::float8, 0) AS avg_days,
+			COALESCE(round((percentile_cont(0.5) WITHIN GROUP (ORDER BY extract(epoch FROM returned_at - assigned_at)) / 86400)::numeric, 1)::float8, 0) AS median_days,
+			count(*) - count(DISTINCT asset_id) AS reassignments,
+			round(((count(*) - count(DISTINCT asset_id))::numeric / count(DISTINCT asset_id)), 2)::float8 AS reassignments_per_asset
+		FROM window_assignments
+		GROUP BY grp
+		ORDER BY grp
+	`, churnArgs(filter)...)
 	if err != nil {
-		return fmt.Errorf("failed to check service status: %w", err)
+		return nil, fmt.Errorf("failed to fetch assignment stats: %w", err)
 	}
-	if inService {
-		return fmt.Errorf("asset is already under service")
+	return stats, nil
+}
+
+// GetReturnReasons counts the reasons the window's assignments were returned for, most common first
+func (r *PostgresAssetRepository) GetReturnReasons(ctx context.Context, filter models.ChurnFilter, limit int) ([]models.ReturnReasonCount, error) {
+	reasons := []models.ReturnReasonCount{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &reasons, fmt.Sprintf(churnAssignments, "''")+`
+		SELECT lower(btrim(COALESCE(return_reason, ''))) AS reason,
+			count(*) AS count,
+			round((count(*)::numeric / sum(count(*)) OVER ()), 3)::float8 AS share
+		FROM window_assignments
+		WHERE returned_at IS NOT NULL
+		GROUP BY 1
+		ORDER BY count DESC, reason
+		LIMIT $7
+	`, append(churnArgs(filter), limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch return reasons: %w", err)
 	}
+	return reasons, nil
+}
 
-	var currentStatus string
-	err = tx.GetContext(ctx, &currentStatus, `
-		SELECT status FROM assets 
-		WHERE id = $1 AND archived_at IS NULL
-	`, req.AssetID)
+// GetTrends counts assets added, assignments made and returned and services started and ended per
+// bucket of the window. Archived assignments and services are left out, assets added count even when
+// archived since
+func (r *PostgresAssetRepository) GetTrends(ctx context.Context, filter models.TrendFilter) ([]models.TrendPoint, error) {
+	points := []models.TrendPoint{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &points, `
+		WITH buckets AS (
+			SELECT generate_series(
+				date_trunc($3, $1::timestamptz, 'UTC'),
+				$2::timestamptz - interval '1 microsecond',
+				('1 ' || $3)::interval
+			) AS bucket
+		), scoped_assets AS (
+			SELECT a.id, a.added_at FROM assets a
+			WHERE ($4 = '' OR a.type::text = $4)
+			AND ($5 OR a.department_id IS NOT DISTINCT FROM $6)
+			AND ($7::uuid IS NULL OR a.organization_id = $7)
+		), events AS (
+			SELECT 'added' AS kind, added_at AS at FROM scoped_assets
+			UNION ALL
+			SELECT 'assigned', aa.assigned_at FROM asset_assign_all aa JOIN scoped_assets a ON a.id = aa.asset_id WHERE aa.archived_at IS NULL
+			UNION ALL
+			SELECT 'returned', aa.returned_at FROM asset_assign_all aa JOIN scoped_assets a ON a.id = aa.asset_id WHERE aa.archived_at IS NULL
+			UNION ALL
+			SELECT 'service_started', s.service_start FROM asset_service_all s JOIN scoped_assets a ON a.id = s.asset_id WHERE s.archived_at IS NULL
+			UNION ALL
+			SELECT 'service_ended', s.service_end FROM asset_service_all s JOIN scoped_assets a ON a.id = s.asset_id WHERE s.archived_at IS NULL
+		), window_events AS (
+			SELECT kind, date_trunc($3, at, 'UTC') AS bucket FROM events WHERE at >= $1 AND at < $2
+		)
+		SELECT b.bucket,
+			count(e.kind) FILTER (WHERE e.kind = 'added') AS assets_added,
+			count(e.kind) FILTER (WHERE e.kind = 'assigned') AS assignments,
+			count(e.kind) FILTER (WHERE e.kind = 'returned') AS returns,
+			count(e.kind) FILTER (WHERE e.kind = 'service_started') AS services_started,
+			count(e.kind) FILTER (WHERE e.kind = 'service_ended') AS services_ended
+		FROM buckets b
+		LEFT JOIN window_events e ON e.bucket = b.bucket
+		GROUP BY b.bucket
+		ORDER BY b.bucket
+	`, filter.From, filter.To, filter.Interval, filter.Type, filter.Scope.AllDepartments, filter.Scope.DepartmentID, filter.Scope.OrganizationID)
 	if err != nil {
-		return fmt.Errorf("failed to get asset status: %w", err)
+		return nil, fmt.Errorf("failed to fetch trends: %w", err)
 	}
+	return points, nil
+}
+
+// servicedAssets has a row per asset with a service started in the window $1 to $2. Open services
+// count up to the window's end, archived ones until they were archived
+const servicedAssets = `
+	WITH serviced AS (
+		SELECT a.id, a.brand, a.model, a.serial_no, COALESCE(a.type::text, '') AS type,
+			count(*) AS services,
+			sum(extract(epoch FROM LEAST(COALESCE(s.service_end, s.archived_at, $2), $2) - s.service_start)) AS service_secs,
+			extract(epoch FROM max(s.service_start) - min(s.service_start)) AS span_secs,
+			COALESCE(sum(s.cost), 0) AS total_cost,
+			count(*) - count(s.cost) AS services_without_cost
+		FROM asset_service_all s
+		JOIN assets a ON a.id = s.asset_id
+		WHERE s.service_start >= $1 AND s.service_start < $2
+		AND ($3 = '' OR a.type::text = $3)
+		AND ($4 OR a.department_id IS NOT DISTINCT FROM $5)
+		AND ($6::uuid IS NULL OR a.organization_id = $6)
+		GROUP BY a.id
+	)`
+
+func serviceReportArgs(filter models.ServiceReportFilter) []interface{} {
+	return []interface{}{filter.From, filter.To, filter.Type, filter.Scope.AllDepartments, filter.Scope.DepartmentID, filter.Scope.OrganizationID}
+}
 
-	if currentStatus != "available" && currentStatus != "waiting_for_service" {
-		return fmt.Errorf("only assets with status 'available' or 'waiting_for_service' can be sent for service")
+// GetAssetServiceStats returns the filter.Top assets serviced most often in the window, the longest
+// and then the costliest servicing breaks ties
+func (r *PostgresAssetRepository) GetAssetServiceStats(ctx context.Context, filter models.ServiceReportFilter) ([]models.AssetServiceStats, error) {
+	stats := []models.AssetServiceStats{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &stats, servicedAssets+`
+		SELECT id AS asset_id, brand, model, serial_no, type, services,
+			round((service_secs / 86400)::numeric, 1)::float8 AS days_in_service,
+			CASE WHEN services > 1 THEN round((span_secs / 86400 / (services - 1))::numeric, 1)::float8 END AS mean_days_between,
+			total_cost::float8 AS total_cost, services_without_cost
+		FROM serviced
+		ORDER BY services DESC, service_secs DESC, total_cost DESC, serial_no
+		LIMIT $7
+	`, append(serviceReportArgs(filter), filter.Top)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch asset service stats: %w", err)
 	}
+	return stats, nil
+}
 
-	_, err = tx.ExecContext(ctx, `
-		INSERT INTO asset_service (asset_id, reason, created_by)
-		VALUES ($1, $2, $3)
-	`, req.AssetID, req.Reason, managerUUID)
+func (r *PostgresAssetRepository) GetTypeServiceStats(ctx context.Context, filter models.ServiceReportFilter) ([]models.TypeServiceStats, error) {
+	stats := []models.TypeServiceStats{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &stats, servicedAssets+`
+		SELECT type, count(*) AS assets, sum(services) AS services,
+			round((sum(service_secs) / 86400)::numeric, 1)::float8 AS days_in_service,
+			round((sum(span_secs) FILTER (WHERE services > 1) / 86400 / NULLIF(sum(services - 1), 0))::numeric, 1)::float8 AS mean_days_between,
+			sum(total_cost)::float8 AS total_cost, sum(services_without_cost) AS services_without_cost
+		FROM serviced
+		GROUP BY type
+		ORDER BY type
+	`, serviceReportArgs(filter)...)
 	if err != nil {
-		return fmt.Errorf("failed to insert service record: %w", err)
+		return nil, fmt.Errorf("failed to fetch type service stats: %w", err)
 	}
-	_, err = tx.ExecContext(ctx, `
-		UPDATE assets SET status = 'sent_for_service'
-		WHERE id = $1 AND archived_at IS NULL
-	`, req.AssetID)
+	return stats, nil
+}
+
+// GetTelecomCosts sums the monthly cost of the sims held in the filter's month per department of
+// the sim, the costliest department first
+func (r *PostgresAssetRepository) GetTelecomCosts(ctx context.Context, filter models.TelecomCostFilter) ([]models.DepartmentTelecomCost, error) {
+	costs := []models.DepartmentTelecomCost{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &costs, `
+		SELECT a.department_id, COALESCE(d.name, '') AS department, count(*) AS sims,
+			sum(sc.monthly_cost)::float8 AS monthly_cost,
+			count(*) FILTER (WHERE sc.renewal_date >= $1::date AND sc.renewal_date < $2::date) AS renewals_due
+		FROM assets a
+		JOIN sim_config sc ON sc.asset_id = a.id
+		LEFT JOIN departments d ON d.id = a.department_id
+		WHERE a.type = 'sim' AND a.merged_into IS NULL
+		AND COALESCE(a.purchase_date, a.added_at) < $2
+		AND (a.archived_at IS NULL OR a.archived_at >= $1)
+		AND ($3 OR a.department_id IS NOT DISTINCT FROM $4)
+		AND ($5::uuid IS NULL OR a.organization_id = $5)
+		GROUP BY a.department_id, d.name
+		ORDER BY monthly_cost DESC, department
+	`, filter.Month, filter.Month.AddDate(0, 1, 0), filter.Scope.AllDepartments, filter.Scope.DepartmentID, filter.Scope.OrganizationID)
 	if err != nil {
-		return fmt.Errorf("failed to update asset status: %w", err)
+		return nil, fmt.Errorf("failed to fetch telecom costs: %w", err)
 	}
+	return costs, nil
+}
 
-	return nil
+// GetInventoryChanges merges the lifecycle of every asset in scope into one feed, oldest first. Updates
+// come from the asset.updated domain events and so only go back as far as events are kept
+func (r *PostgresAssetRepository) GetInventoryChanges(ctx context.Context, filter models.InventoryChangeFilter) ([]models.InventoryChange, error) {
+	changes := []models.InventoryChange{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &changes, `
+		WITH scoped_assets AS (
+			SELECT a.id, a.brand, a.model, a.serial_no, a.added_at, a.added_by
+			FROM assets a
+			WHERE ($1 OR a.department_id IS NOT DISTINCT FROM $2)
+			AND ($3::uuid IS NULL OR a.organization_id = $3)
+			AND ($4::uuid IS NULL OR a.id = $4)
+		), changes AS (
+			SELECT 'created' AS event_type, a.added_at::timestamptz AS occurred_at, a.id AS asset_id,
+				a.added_by AS actor_id, NULL::uuid AS employee_id, '' AS details
+			FROM scoped_assets a
+			UNION ALL
+			SELECT 'assigned', aa.assigned_at::timestamptz, aa.asset_id, aa.assigned_by, aa.employee_id, ''
+			FROM asset_assign_all aa JOIN scoped_assets a ON a.id = aa.asset_id
+			WHERE aa.archived_at IS NULL
+			UNION ALL
+			SELECT 'returned', aa.returned_at::timestamptz, aa.asset_id, NULL, aa.employee_id, COALESCE(aa.return_reason, '')
+			FROM asset_assign_all aa JOIN scoped_assets a ON a.id = aa.asset_id
+			WHERE aa.archived_at IS NULL AND aa.returned_at IS NOT NULL
+			UNION ALL
+			SELECT 'service_started', s.service_start::timestamptz, s.asset_id, s.created_by, NULL, COALESCE(s.reason, '')
+			FROM asset_service_all s JOIN scoped_assets a ON a.id = s.asset_id
+			WHERE s.archived_at IS NULL
+			UNION ALL
+			SELECT 'service_ended', s.service_end::timestamptz, s.asset_id, NULL, NULL, COALESCE(s.reason, '')
+			FROM asset_service_all s JOIN scoped_assets a ON a.id = s.asset_id
+			WHERE s.archived_at IS NULL AND s.service_end IS NOT NULL
+			UNION ALL
+			SELECT 'updated', e.occurred_at::timestamptz, e.aggregate_id, e.actor_id, NULL, COALESCE(e.payload #>> '{data,status}', '')
+			FROM domain_events e JOIN scoped_assets a ON a.id = e.aggregate_id
+			WHERE e.aggregate_type = 'asset' AND e.type = 'asset.updated'
+		)
+		SELECT c.event_type, c.occurred_at, c.asset_id, a.brand, a.model, a.serial_no,
+			c.actor_id, actor.username AS actor_name, c.employee_id, employee.username AS employee_name, c.details
+		FROM changes c
+		JOIN scoped_assets a ON a.id = c.asset_id
+		LEFT JOIN users actor ON actor.id = c.actor_id
+		LEFT JOIN users employee ON employee.id = c.employee_id
+		WHERE ($5 = '' OR c.event_type = $5)
+		AND ($6::timestamptz IS NULL OR c.occurred_at >= $6)
+		AND ($7::timestamptz IS NULL OR c.occurred_at < $7)
+		ORDER BY c.occurred_at, c.event_type, c.asset_id
+		LIMIT $8 OFFSET $9
+	`, filter.Scope.AllDepartments, filter.Scope.DepartmentID, filter.Scope.OrganizationID, filter.AssetID,
+		filter.Type, filter.From, filter.To, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch inventory changes: %w", err)
+	}
+	return changes, nil
 }
 
-func (r *PostgresAssetRepository) UpdateAssetWithConfig(ctx context.Context, req models.UpdateAssetReq) (err error) {
-	tx, err := r.DB.BeginTxx(ctx, nil)
+// GetAssetManagerIDs returns the asset managers of a department along with the admins of its organization
+func (r *PostgresAssetRepository) GetAssetManagerIDs(ctx context.Context, organizationID uuid.UUID, departmentID *uuid.UUID) ([]uuid.UUID, error) {
+	managerIDs := []uuid.UUID{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &managerIDs, `
+		SELECT DISTINCT u.id
+		FROM users u
+		JOIN user_roles ur ON ur.user_id = u.id AND ur.archived_at IS NULL
+		WHERE u.archived_at IS NULL AND u.organization_id = $2
+		AND (
+			ur.role = 'admin'
+			OR (ur.role = 'asset_manager' AND u.department_id IS NOT DISTINCT FROM $1)
+		)
+	`, departmentID, organizationID)
 	if err != nil {
-		log.Println("transaction failed", err)
-		return err
+		return nil, fmt.Errorf("failed to fetch asset managers: %w", err)
 	}
-	// Defer a rollback or commit based on the outcome of the function.
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			panic(p)
-		} else if err != nil {
-			tx.Rollback()
-		} else {
-			err = tx.Commit()
-		}
-	}()
+	return managerIDs, nil
+}
 
-	updateFields := []string{}
-	args := []interface{}{}
-	argPos := 1
+// GetEmployeeManagerIDs returns the employee managers of a department along with the admins of its organization
+func (r *PostgresAssetRepository) GetEmployeeManagerIDs(ctx context.Context, organizationID uuid.UUID, departmentID *uuid.UUID) ([]uuid.UUID, error) {
+	managerIDs := []uuid.UUID{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &managerIDs, `
+		SELECT DISTINCT u.id
+		FROM users u
+		JOIN user_roles ur ON ur.user_id = u.id AND ur.archived_at IS NULL
+		WHERE u.archived_at IS NULL AND u.organization_id = $2
+		AND (
+			ur.role = 'admin'
+			OR (ur.role = 'employee_manager' AND u.department_id IS NOT DISTINCT FROM $1)
+		)
+	`, departmentID, organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch employee managers: %w", err)
+	}
+	return managerIDs, nil
+}
 
-	if req.Brand != "" {
-		updateFields = append(updateFields, fmt.Sprintf("brand = $%d", argPos))
-		args = append(args, req.Brand)
-		argPos++
+// GetAssetManagerContacts returns the contact numbers of the managers GetAssetManagerIDs returns
+func (r *PostgresAssetRepository) GetAssetManagerContacts(ctx context.Context, organizationID uuid.UUID, departmentID *uuid.UUID) ([]string, error) {
+	numbers := []string{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &numbers, `
+		SELECT DISTINCT u.contact_no
+		FROM users u
+		JOIN user_roles ur ON ur.user_id = u.id AND ur.archived_at IS NULL
+		WHERE u.archived_at IS NULL AND u.organization_id = $2 AND u.contact_no IS NOT NULL AND u.contact_no <> ''
+		AND (
+			ur.role = 'admin'
+			OR (ur.role = 'asset_manager' AND u.department_id IS NOT DISTINCT FROM $1)
+		)
+	`, departmentID, organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch asset manager contacts: %w", err)
 	}
-	if req.Model != "" {
-		updateFields = append(updateFields, fmt.Sprintf("model = $%d", argPos))
-		args = append(args, req.Model)
-		argPos++
+	return numbers, nil
+}
+
+// ReportAssetStolen marks an asset the employee currently holds stolen and records the report,
+// ErrAssignmentNotFound is returned for an asset they don't hold or that is already reported
+func (r *PostgresAssetRepository) ReportAssetStolen(ctx context.Context, assetID, employeeID uuid.UUID, note string) (models.StolenAsset, error) {
+	var asset models.StolenAsset
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &asset, `
+		UPDATE assets a SET status = 'stolen'
+		FROM asset_assign aa, users u
+		WHERE a.id = $1 AND a.archived_at IS NULL AND a.status <> 'stolen'
+			AND aa.asset_id = a.id AND aa.employee_id = $2 AND aa.returned_at IS NULL AND aa.archived_at IS NULL
+			AND u.id = aa.employee_id
+		RETURNING a.id, a.brand, a.model, a.serial_no, a.department_id, a.organization_id, u.username AS reporter
+	`, assetID, employeeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return asset, ErrAssignmentNotFound
+		}
+		return asset, fmt.Errorf("failed to mark asset stolen: %w", err)
 	}
-	if req.SerialNo != "" {
-		updateFields = append(updateFields, fmt.Sprintf("serial_no = $%d", argPos))
-		args = append(args, req.SerialNo)
-		argPos++
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO asset_theft_reports (asset_id, reported_by, note) VALUES ($1, $2, $3)
+	`, assetID, employeeID, note)
+	if err != nil {
+		return asset, fmt.Errorf("failed to record theft report: %w", err)
 	}
-	if req.PurchaseDate != nil {
-		updateFields = append(updateFields, fmt.Sprintf("purchase_date = $%d", argPos))
-		args = append(args, *req.PurchaseDate)
-		argPos++
+	// a stolen mobile is refused or flagged when it turns up for intake again
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO imei_blacklist (imei, reason, asset_id, added_by)
+		SELECT imei, 'reported stolen', $1, $2
+		FROM mobile_config mc, unnest(ARRAY[mc.imei_1, mc.imei_2]) AS imei
+		WHERE mc.asset_id = $1 AND imei <> ''
+		ON CONFLICT DO NOTHING
+	`, assetID, employeeID)
+	if err != nil {
+		return asset, fmt.Errorf("failed to blacklist stolen imeis: %w", err)
 	}
-	if req.OwnedBy != "" {
-		updateFields = append(updateFields, fmt.Sprintf("owned_by = $%d", argPos))
-		args = append(args, req.OwnedBy)
-		argPos++
+	return asset, nil
+}
+
+// LockAssignedAssetIDs returns the assets the employee holds, locking their assignments until the transaction of ctx ends
+func (r *PostgresAssetRepository) LockAssignedAssetIDs(ctx context.Context, employeeID uuid.UUID) ([]uuid.UUID, error) {
+	assetIDs := []uuid.UUID{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &assetIDs, `
+		SELECT asset_id FROM asset_assign
+		WHERE employee_id = $1 AND returned_at IS NULL AND archived_at IS NULL
+		ORDER BY assigned_at
+		FOR UPDATE
+	`, employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch assigned assets: %w", err)
 	}
-	if req.WarrantyStart != nil {
-		updateFields = append(updateFields, fmt.Sprintf("warranty_start = $%d", argPos))
-		args = append(args, *req.WarrantyStart)
-		argPos++
+	return assetIDs, nil
+}
+
+// GetAssetEmployeeIDs returns everyone the asset was assigned to, the current holder included. Their
+// dashboards and timelines show the asset
+func (r *PostgresAssetRepository) GetAssetEmployeeIDs(ctx context.Context, assetID uuid.UUID) ([]uuid.UUID, error) {
+	employeeIDs := []uuid.UUID{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &employeeIDs, `
+		SELECT DISTINCT employee_id FROM asset_assign
+		WHERE asset_id = $1 AND archived_at IS NULL
+	`, assetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch asset employees: %w", err)
 	}
-	if req.WarrantyExpire != nil {
-		updateFields = append(updateFields, fmt.Sprintf("warranty_expire = $%d", argPos))
-		args = append(args, *req.WarrantyExpire)
-		argPos++
+	return employeeIDs, nil
+}
+
+// assetSearchRow is an asset with the columns of every config table, config picks those of its type
+type assetSearchRow struct {
+	models.AssetWithConfigRes
+	LaptopProcessor         string     `db:"laptop_processor"`
+	LaptopRam               string     `db:"laptop_ram"`
+	LaptopOs                string     `db:"laptop_os"`
+	MouseDPI                string     `db:"mouse_dpi"`
+	MonitorDisplay          string     `db:"monitor_display"`
+	MonitorResolution       string     `db:"monitor_resolution"`
+	MonitorPort             string     `db:"monitor_port"`
+	MobileProcessor         string     `db:"mobile_processor"`
+	MobileRam               string     `db:"mobile_ram"`
+	MobileOs                string     `db:"mobile_os"`
+	MobileIMEI1             string     `db:"mobile_imei_1"`
+	MobileIMEI2             string     `db:"mobile_imei_2"`
+	MobileIMEIFlag          string     `db:"mobile_imei_flag"`
+	HardDiskType            string     `db:"hard_disk_type"`
+	HardDiskStorage         string     `db:"hard_disk_storage"`
+	PenDriveVersion         string     `db:"pen_drive_version"`
+	PenDriveStorage         string     `db:"pen_drive_storage"`
+	SimNumber               int        `db:"sim_number"`
+	SimCarrier              string     `db:"sim_carrier"`
+	SimPlan                 string     `db:"sim_plan"`
+	SimMonthlyCost          float64    `db:"sim_monthly_cost"`
+	SimRenewalDate          *time.Time `db:"sim_renewal_date"`
+	SimMobileAssetID        *uuid.UUID `db:"sim_mobile_asset_id"`
+	AccessoryType           string     `db:"accessory_type"`
+	AccessoryAdditionalInfo string     `db:"accessory_additional_info"`
+}
+
+func (row assetSearchRow) config() interface{} {
+	switch row.Type {
+	case "laptop":
+		return models.Laptop_config_res{Processor: row.LaptopProcessor, Ram: row.LaptopRam, Os: row.LaptopOs}
+	case "mouse":
+		return models.Mouse_config_res{DPI: row.MouseDPI}
+	case "monitor":
+		return models.Monitor_config_res{Display: row.MonitorDisplay, Resolution: row.MonitorResolution, Port: row.MonitorPort}
+	case "mobile":
+		return models.Mobile_config_res{Processor: row.MobileProcessor, Ram: row.MobileRam, Os: row.MobileOs, IMEI1: row.MobileIMEI1, IMEI2: row.MobileIMEI2, IMEIFlag: row.MobileIMEIFlag}
+	case "hard_disk":
+		return models.Hard_disk_config_res{Type: row.HardDiskType, Storage: row.HardDiskStorage}
+	case "pen_drive":
+		return models.Pen_drive_config_res{Version: row.PenDriveVersion, Storage: row.PenDriveStorage}
+	case "sim":
+		return models.Sim_config_res{Number: row.SimNumber, Carrier: row.SimCarrier, Plan: row.SimPlan, MonthlyCost: row.SimMonthlyCost,
+			RenewalDate: row.SimRenewalDate, MobileAssetID: row.SimMobileAssetID}
+	case "accessory":
+		return models.Accessories_config_res{Type: row.AccessoryType, AdditionalInfo: row.AccessoryAdditionalInfo}
 	}
+	return nil
+}
 
-	if len(updateFields) > 0 {
-		query := fmt.Sprintf("UPDATE assets SET %s WHERE id = $%d AND archived_at IS NULL", strings.Join(updateFields, ", "), argPos)
-		args = append(args, req.ID)
+func (r *PostgresAssetRepository) SearchAssetsWithFilter(ctx context.Context, filter models.AssetFilter) ([]models.AssetWithConfigRes, error) {
+	args := []interface{}{
+		!filter.IsSearchText,
+		filter.SearchText,
+		pq.Array(filter.Status),
+		pq.Array(filter.OwnedBy),
+		pq.Array(filter.Type),
+		filter.Limit,
+		filter.Offset,
+		filter.Scope.AllDepartments,
+		filter.Scope.DepartmentID,
+		filter.Scope.OrganizationID,
+	}
+
+	// every config table is joined so a page takes one round trip, asset_id is unique in each of them.
+	// Columns of the tables that don't match the type come back empty
+	query := `
+		SELECT a.id, a.brand, a.model, a.serial_no, a.type, a.owned_by, a.status, a.purchase_date,
+			a.warranty_start, a.warranty_expire, a.department_id, a.purchase_order_id,
+			COALESCE(lc.processor, '') AS laptop_processor, COALESCE(lc.ram, '') AS laptop_ram, COALESCE(lc.os, '') AS laptop_os,
+			COALESCE(mc.dpi, '') AS mouse_dpi,
+			COALESCE(mon.display, '') AS monitor_display, COALESCE(mon.resolution, '') AS monitor_resolution, COALESCE(mon.port, '') AS monitor_port,
+			COALESCE(mob.processor, '') AS mobile_processor, COALESCE(mob.ram, '') AS mobile_ram, COALESCE(mob.os, '') AS mobile_os,
+			COALESCE(mob.imei_1, '') AS mobile_imei_1, COALESCE(mob.imei_2, '') AS mobile_imei_2, COALESCE(mob.imei_flag, '') AS mobile_imei_flag,
+			COALESCE(hd.type, '') AS hard_disk_type, COALESCE(hd.storage, '') AS hard_disk_storage,
+			COALESCE(pd.version, '') AS pen_drive_version, COALESCE(pd.storage, '') AS pen_drive_storage,
+			COALESCE(sc.number, '0') AS sim_number, COALESCE(sc.carrier, '') AS sim_carrier, COALESCE(sc.plan, '') AS sim_plan,
+			COALESCE(sc.monthly_cost, 0)::float8 AS sim_monthly_cost, sc.renewal_date AS sim_renewal_date, sc.mobile_asset_id AS sim_mobile_asset_id,
+			COALESCE(ac.type, '') AS accessory_type, COALESCE(ac.additional_info, '') AS accessory_additional_info
+		FROM assets a
+		LEFT JOIN laptop_config lc ON lc.asset_id = a.id AND a.type = 'laptop'
+		LEFT JOIN mouse_config mc ON mc.asset_id = a.id AND a.type = 'mouse'
+		LEFT JOIN monitor_config mon ON mon.asset_id = a.id AND a.type = 'monitor'
+		LEFT JOIN mobile_config mob ON mob.asset_id = a.id AND a.type = 'mobile'
+		LEFT JOIN hard_disk_config hd ON hd.asset_id = a.id AND a.type = 'hard_disk'
+		LEFT JOIN pendrive_config pd ON pd.asset_id = a.id AND a.type = 'pen_drive'
+		LEFT JOIN sim_config sc ON sc.asset_id = a.id AND a.type = 'sim'
+		LEFT JOIN accessories_config ac ON ac.asset_id = a.id AND a.type = 'accessory'
+		WHERE a.archived_at IS NULL
+		AND (
+			$1 OR (
+				a.brand ILIKE $2 OR 
+				a.model ILIKE $2 OR 
+				a.serial_no ILIKE $2
+			)
+		)
+		AND a.status = ANY($3)
+		AND a.owned_by = ANY($4)
+		AND a.type = ANY($5)
+		AND ($8 OR a.department_id IS NOT DISTINCT FROM $9)
+		AND ($10::uuid IS NULL OR a.organization_id = $10)
+		ORDER BY a.added_at DESC
+		LIMIT $6 OFFSET $7
+	`
+
+	var rows []assetSearchRow
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &rows, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch assets: %w", err)
+	}
+
+	assets := make([]models.AssetWithConfigRes, len(rows))
+	for i, row := range rows {
+		assets[i] = row.AssetWithConfigRes
+		assets[i].Config = row.config()
+	}
 
-		_, err := tx.ExecContext(ctx, query, args...)
+	return assets, nil
+}
+
+// SendAssetForService opens a service of the asset and returns its id, ticketed marks its service
+// desk ticket as still to be opened
+func (r *PostgresAssetRepository) SendAssetForService(ctx context.Context, req models.AssetServiceReq, managerUUID uuid.UUID, ticketed bool) (serviceID uuid.UUID, err error) {
+	err = utils.WithTransaction(ctx, r.DB, func(ctx context.Context) error {
+		var err error
+		var inService bool
+		err = utils.Conn(ctx, r.DB).GetContext(ctx, &inService, `
+			SELECT EXISTS (
+				SELECT 1 FROM asset_service 
+				WHERE asset_id = $1 AND service_end IS NULL AND archived_at IS NULL
+			)
+		`, req.AssetID)
+		if err != nil {
+			return fmt.Errorf("failed to check service status: %w", err)
+		}
+		if inService {
+			return ErrAssetInService
+		}
+
+		var currentStatus string
+		err = utils.Conn(ctx, r.DB).GetContext(ctx, &currentStatus, `
+			SELECT status FROM assets 
+			WHERE id = $1 AND archived_at IS NULL
+		`, req.AssetID)
+		if err != nil {
+			return fmt.Errorf("failed to get asset status: %w", err)
+		}
+
+		if currentStatus != "available" && currentStatus != "waiting_for_service" {
+			return ErrAssetNotServiceable
+		}
+
+		err = utils.Conn(ctx, r.DB).GetContext(ctx, &serviceID, `
+			INSERT INTO asset_service (asset_id, reason, created_by, ticket_status)
+			VALUES ($1, $2, $3, CASE WHEN $4 THEN $5 END)
+			RETURNING id
+		`, req.AssetID, req.Reason, managerUUID, ticketed, models.TicketStatusPending)
+		if err != nil {
+			return fmt.Errorf("failed to insert service record: %w", err)
+		}
+		_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+			UPDATE assets SET status = 'sent_for_service'
+			WHERE id = $1 AND archived_at IS NULL
+		`, req.AssetID)
+		if err != nil {
+			return fmt.Errorf("failed to update asset status: %w", err)
+		}
+
+		return nil
+	})
+	return serviceID, err
+}
+
+// updateAssetQuery is one fixed statement for every update, an empty or NULL value leaves the column
+// as it is. A new warranty expiry is alerted on again
+const updateAssetQuery = `
+	UPDATE assets SET
+		brand = COALESCE(NULLIF($2, ''), brand),
+		model = COALESCE(NULLIF($3, ''), model),
+		serial_no = COALESCE(NULLIF($4, ''), serial_no),
+		purchase_date = COALESCE($5::date, purchase_date),
+		owned_by = COALESCE(NULLIF($6, '')::ownership, owned_by),
+		warranty_start = COALESCE($7::date, warranty_start),
+		warranty_expire = COALESCE($8::date, warranty_expire),
+		warranty_alerted_at = CASE WHEN $8::date IS NOT NULL THEN NULL ELSE warranty_alerted_at END,
+		department_id = COALESCE($9::uuid, department_id),
+		purchase_cost = COALESCE($10::numeric, purchase_cost),
+		salvage_value = COALESCE($11::numeric, salvage_value),
+		useful_life_months = COALESCE($12::int, useful_life_months),
+		kiosk_pool = COALESCE($13::boolean, kiosk_pool)
+	WHERE id = $1 AND archived_at IS NULL`
+
+func (r *PostgresAssetRepository) UpdateAssetWithConfig(ctx context.Context, req models.UpdateAssetReq) error {
+	return utils.WithTransaction(ctx, r.DB, func(ctx context.Context) error {
+		_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, updateAssetQuery,
+			req.ID, req.Brand, req.Model, req.SerialNo, req.PurchaseDate, req.OwnedBy,
+			req.WarrantyStart, req.WarrantyExpire, req.DepartmentID,
+			req.PurchaseCost, req.SalvageValue, req.UsefulLifeMonths, req.KioskPool)
 		if err != nil {
 			return fmt.Errorf("failed to update asset: %w", err)
 		}
-	}
 
-	if req.Config != nil && req.Type != "" {
-		switch req.Type {
-		case "laptop":
-			var cfg models.Laptop_config_req
-			if err := json.Unmarshal(req.Config, &cfg); err != nil {
-				return fmt.Errorf("invalid laptop config: %w", err)
-			}
-			_, err = tx.ExecContext(ctx, `UPDATE laptop_config SET processor = $1, ram = $2, os = $3 WHERE asset_id = $4`,
-				cfg.Processor, cfg.Ram, cfg.Os, req.ID)
-		case "mouse":
-			var cfg models.Mouse_config_req
-			if err := json.Unmarshal(req.Config, &cfg); err != nil {
-				return fmt.Errorf("invalid mouse config: %w", err)
+		if req.Config != nil && req.Type != "" {
+			switch req.Type {
+			case "laptop":
+				var cfg models.Laptop_config_req
+				if err := json.Unmarshal(req.Config, &cfg); err != nil {
+					return invalidConfig("laptop", err)
+				}
+				_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `UPDATE laptop_config SET processor = $1, ram = $2, os = $3 WHERE asset_id = $4`,
+					cfg.Processor, cfg.Ram, cfg.Os, req.ID)
+			case "mouse":
+				var cfg models.Mouse_config_req
+				if err := json.Unmarshal(req.Config, &cfg); err != nil {
+					return invalidConfig("mouse", err)
+				}
+				_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `UPDATE mouse_config SET dpi = $1 WHERE asset_id = $2`, cfg.DPI, req.ID)
+			case "monitor":
+				var cfg models.Monitor_config_req
+				if err := json.Unmarshal(req.Config, &cfg); err != nil {
+					return invalidConfig("monitor", err)
+				}
+				_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `UPDATE monitor_config SET display = $1, resolution = $2, port = $3 WHERE asset_id = $4`,
+					cfg.Display, cfg.Resolution, cfg.Port, req.ID)
+			case "mobile":
+				var cfg models.Mobile_config_req
+				if err := json.Unmarshal(req.Config, &cfg); err != nil {
+					return invalidConfig("mobile", err)
+				}
+				_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `UPDATE mobile_config SET processor = $1, ram = $2, os = $3, imei_1 = $4, imei_2 = $5 WHERE asset_id = $6`,
+					cfg.Processor, cfg.Ram, cfg.Os, cfg.IMEI1, cfg.IMEI2, req.ID)
+			case "hard_disk":
+				var cfg models.Hard_disk_config_req
+				if err := json.Unmarshal(req.Config, &cfg); err != nil {
+					return invalidConfig("hard disk", err)
+				}
+				_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `UPDATE hard_disk_config SET type = $1, storage = $2 WHERE asset_id = $3`,
+					cfg.Type, cfg.Storage, req.ID)
+			case "pen_drive":
+				var cfg models.Pen_drive_config_req
+				if err := json.Unmarshal(req.Config, &cfg); err != nil {
+					return invalidConfig("pen drive", err)
+				}
+				_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `UPDATE pendrive_config SET version = $1, storage = $2 WHERE asset_id = $3`,
+					cfg.Version, cfg.Storage, req.ID)
+			case "sim":
+				var cfg models.Sim_config_req
+				if err := json.Unmarshal(req.Config, &cfg); err != nil {
+					return invalidConfig("sim", err)
+				}
+				if err := r.checkSimMobile(ctx, cfg.MobileAssetID, req.ID); err != nil {
+					return err
+				}
+				_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+					UPDATE sim_config SET number = $1, carrier = $2, plan = $3, monthly_cost = $4,
+						renewal_date = NULLIF($5, '')::date, mobile_asset_id = NULLIF($6, '')::uuid
+					WHERE asset_id = $7`,
+					cfg.Number, cfg.Carrier, cfg.Plan, cfg.MonthlyCost, cfg.RenewalDate, cfg.MobileAssetID, req.ID)
+			case "accessory":
+				var cfg models.Accessories_config_req
+				if err := json.Unmarshal(req.Config, &cfg); err != nil {
+					return invalidConfig("accessory", err)
+				}
+				_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `UPDATE accessories_config SET type = $1, additional_info = $2 WHERE asset_id = $3`,
+					cfg.Type, cfg.AdditionalInfo, req.ID)
+			default:
+				return ErrUnsupportedAssetType
 			}
-			_, err = tx.ExecContext(ctx, `UPDATE mouse_config SET dpi = $1 WHERE asset_id = $2`, cfg.DPI, req.ID)
-		case "monitor":
-			var cfg models.Monitor_config_req
-			if err := json.Unmarshal(req.Config, &cfg); err != nil {
-				return fmt.Errorf("invalid monitor config: %w", err)
-			}
-			_, err = tx.ExecContext(ctx, `UPDATE monitor_config SET display = $1, resolution = $2, port = $3 WHERE asset_id = $4`,
-				cfg.Display, cfg.Resolution, cfg.Port, req.ID)
-		case "mobile":
-			var cfg models.Mobile_config_req
-			if err := json.Unmarshal(req.Config, &cfg); err != nil {
-				return fmt.Errorf("invalid mobile config: %w", err)
-			}
-			_, err = tx.ExecContext(ctx, `UPDATE mobile_config SET processor = $1, ram = $2, os = $3, imei_1 = $4, imei_2 = $5 WHERE asset_id = $6`,
-				cfg.Processor, cfg.Ram, cfg.Os, cfg.IMEI1, cfg.IMEI2, req.ID)
-		case "hard_disk":
-			var cfg models.Hard_disk_config_req
-			if err := json.Unmarshal(req.Config, &cfg); err != nil {
-				return fmt.Errorf("invalid hard disk config: %w", err)
-			}
-			_, err = tx.ExecContext(ctx, `UPDATE hard_disk_config SET type = $1, storage = $2 WHERE asset_id = $3`,
-				cfg.Type, cfg.Storage, req.ID)
-		case "pen_drive":
-			var cfg models.Pen_drive_config_req
-			if err := json.Unmarshal(req.Config, &cfg); err != nil {
-				return fmt.Errorf("invalid pen drive config: %w", err)
-			}
-			_, err = tx.ExecContext(ctx, `UPDATE pendrive_config SET version = $1, storage = $2 WHERE asset_id = $3`,
-				cfg.Version, cfg.Storage, req.ID)
-		case "sim":
-			var cfg models.Sim_config_req
-			if err := json.Unmarshal(req.Config, &cfg); err != nil {
-				return fmt.Errorf("invalid sim config: %w", err)
-			}
-			_, err = tx.ExecContext(ctx, `UPDATE sim_config SET number = $1 WHERE asset_id = $2`,
-				cfg.Number, req.ID)
-		case "accessory":
-			var cfg models.Accessories_config_req
-			if err := json.Unmarshal(req.Config, &cfg); err != nil {
-				return fmt.Errorf("invalid accessory config: %w", err)
+
+			if err != nil {
+				return fmt.Errorf("failed to update config: %w", err)
 			}
-			_, err = tx.ExecContext(ctx, `UPDATE accessories_config SET type = $1, additional_info = $2 WHERE asset_id = $3`,
-				cfg.Type, cfg.AdditionalInfo, req.ID)
-		default:
-			return fmt.Errorf("unsupported asset type for config update")
 		}
 
-		if err != nil {
-			return fmt.Errorf("failed to update config: %w", err)
+		return nil
+	})
+}
+
+// IsAssetInScope runs before every change of an asset, a missing asset is remembered for a short
+// while so repeated requests for a wrong id don't each reach the database
+func (r *PostgresAssetRepository) IsAssetInScope(ctx context.Context, assetID uuid.UUID, scope models.DepartmentScope) (bool, error) {
+	ttl := r.notFoundTTL()
+	notFoundKey := cacheprovider.AssetNotFoundKey(assetID)
+	if ttl > 0 {
+		start := time.Now()
+		cached, err := r.Redis.Get(ctx, notFoundKey)
+		hit := err == nil && cached != ""
+		cacheprovider.RecordRead(models.CacheNotFound, hit, time.Since(start))
+		if hit {
+			return false, nil
+		}
+	}
+
+	var asset struct {
+		DepartmentID   *uuid.UUID `db:"department_id"`
+		OrganizationID uuid.UUID  `db:"organization_id"`
+	}
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &asset, `
+		SELECT department_id, organization_id FROM assets
+		WHERE id = $1 AND archived_at IS NULL
+	`, assetID)
+	if errors.Is(err, sql.ErrNoRows) {
+		if ttl > 0 {
+			_ = r.Redis.Set(ctx, notFoundKey, "1", ttl)
 		}
+		return false, nil
 	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check asset department: %w", err)
+	}
+	if scope.OrganizationID != nil && asset.OrganizationID != *scope.OrganizationID {
+		return false, nil
+	}
+	departmentID := asset.DepartmentID
+	if scope.AllDepartments {
+		return true, nil
+	}
+	if departmentID == nil || scope.DepartmentID == nil {
+		return departmentID == nil && scope.DepartmentID == nil, nil
+	}
+	return *departmentID == *scope.DepartmentID, nil
+}
 
+func (r *PostgresAssetRepository) IsEmployeeInScope(ctx context.Context, employeeID uuid.UUID, scope models.DepartmentScope) (bool, error) {
+	var inScope bool
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &inScope, `
+		SELECT EXISTS (
+			SELECT 1 FROM users
+			WHERE id = $1 AND archived_at IS NULL
+			AND ($2 OR department_id IS NOT DISTINCT FROM $3)
+			AND ($4::uuid IS NULL OR organization_id = $4)
+		)
+	`, employeeID, scope.AllDepartments, scope.DepartmentID, scope.OrganizationID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check employee department: %w", err)
+	}
+	return inScope, nil
+}
+
+// MoveClosedAssignmentsBefore moves up to limit assignments returned or archived before before to
+// asset_assign_history, readers of asset_assign_all still see them
+func (r *PostgresAssetRepository) MoveClosedAssignmentsBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		WITH moved AS (
+			DELETE FROM asset_assign
+			WHERE id IN (
+				SELECT id FROM asset_assign
+				WHERE COALESCE(returned_at, archived_at) < $1
+				LIMIT $2
+			)
+			RETURNING id, asset_id, employee_id, assigned_at, returned_at, return_reason, archived_at, assigned_by, metadata,
+				loan_until, loan_overdue_at
+		)
+		INSERT INTO asset_assign_history (id, asset_id, employee_id, assigned_at, returned_at, return_reason, archived_at, assigned_by, metadata,
+			loan_until, loan_overdue_at)
+		SELECT id, asset_id, employee_id, assigned_at, returned_at, return_reason, archived_at, assigned_by, metadata,
+			loan_until, loan_overdue_at FROM moved
+	`, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to move closed assignments to history: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// MoveClosedServicesBefore moves up to limit services ended or archived before before to
+// asset_service_history, readers of asset_service_all still see them
+func (r *PostgresAssetRepository) MoveClosedServicesBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		WITH moved AS (
+			DELETE FROM asset_service
+			WHERE id IN (
+				SELECT id FROM asset_service
+				WHERE COALESCE(service_end, archived_at) < $1
+				LIMIT $2
+			)
+			RETURNING id, asset_id, service_start, service_end, reason, created_by, created_at, archived_at, ticket_key, ticket_status, ticket_resolved_at, cost
+		)
+		INSERT INTO asset_service_history (id, asset_id, service_start, service_end, reason, created_by, created_at, archived_at, ticket_key, ticket_status, ticket_resolved_at, cost)
+		SELECT id, asset_id, service_start, service_end, reason, created_by, created_at, archived_at, ticket_key, ticket_status, ticket_resolved_at, cost FROM moved
+	`, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to move closed services to history: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+const attachmentColumns = `id, asset_id, storage_key, filename, content_type, size_bytes, status, uploaded_by, created_at, uploaded_at`
+
+// InsertAttachment records a pending upload, ErrAssetNotFound is returned for a deleted or unknown asset
+func (r *PostgresAssetRepository) InsertAttachment(ctx context.Context, attachment models.Attachment) error {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO asset_attachments (id, asset_id, storage_key, filename, content_type, size_bytes, uploaded_by)
+		SELECT $1, id, $3, $4, $5, $6, $7 FROM assets WHERE id = $2 AND archived_at IS NULL
+	`, attachment.ID, attachment.AssetID, attachment.StorageKey, attachment.Filename, attachment.ContentType, attachment.SizeBytes, attachment.UploadedBy)
+	if err != nil {
+		return fmt.Errorf("failed to insert attachment: %w", err)
+	}
+	if inserted, _ := res.RowsAffected(); inserted == 0 {
+		return ErrAssetNotFound
+	}
+	return nil
+}
+
+func (r *PostgresAssetRepository) GetAttachment(ctx context.Context, id uuid.UUID) (models.Attachment, error) {
+	var attachment models.Attachment
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &attachment, `
+		SELECT `+attachmentColumns+` FROM asset_attachments WHERE id = $1 AND archived_at IS NULL
+	`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return attachment, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return attachment, fmt.Errorf("failed to fetch attachment: %w", err)
+	}
+	return attachment, nil
+}
+
+func (r *PostgresAssetRepository) MarkAttachmentUploaded(ctx context.Context, id uuid.UUID, size int64) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE asset_attachments SET status = 'uploaded', size_bytes = $2, uploaded_at = now()
+		WHERE id = $1 AND archived_at IS NULL
+	`, id, size)
+	if err != nil {
+		return fmt.Errorf("failed to mark attachment uploaded: %w", err)
+	}
 	return nil
 }
+
+// GetAssetAttachments returns the confirmed attachments of the asset, oldest first
+func (r *PostgresAssetRepository) GetAssetAttachments(ctx context.Context, assetID uuid.UUID) ([]models.Attachment, error) {
+	attachments := []models.Attachment{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &attachments, `
+		SELECT `+attachmentColumns+` FROM asset_attachments
+		WHERE asset_id = $1 AND status = 'uploaded' AND archived_at IS NULL
+		ORDER BY created_at
+	`, assetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch attachments: %w", err)
+	}
+	return attachments, nil
+}
+
+func (r *PostgresAssetRepository) ArchiveAttachment(ctx context.Context, id uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE asset_attachments SET archived_at = now() WHERE id = $1 AND archived_at IS NULL
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to archive attachment: %w", err)
+	}
+	return nil
+}
+
+// DeletePendingAttachmentsBefore forgets uploads that were never confirmed and returns their keys,
+// the client may still have put a file there
+func (r *PostgresAssetRepository) DeletePendingAttachmentsBefore(ctx context.Context, before time.Time) ([]string, error) {
+	keys := []string{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &keys, `
+		DELETE FROM asset_attachments WHERE status = 'pending' AND created_at < $1 RETURNING storage_key
+	`, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete pending attachments: %w", err)
+	}
+	return keys, nil
+}
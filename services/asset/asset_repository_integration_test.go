@@ -0,0 +1,1178 @@
+//go:build integration
+
+package assetservice
+
+import (
+	"asset/database/seed"
+	"asset/database/testdb"
+	"asset/models"
+	"asset/providers"
+	cacheprovider "asset/providers/cacheProvider"
+	"asset/utils"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// racers is how many transactions go for the same asset at once
+const racers = 8
+
+var (
+	allStatuses = []string{"available", "assigned", "waiting for repair", "sent_for_service", "damaged"}
+	allTypes    = []string{"laptop", "mouse", "monitor", "hard_disk", "pen_drive", "mobile", "sim", "accessory"}
+	allOwners   = []string{"remotestate", "client"}
+)
+
+func TestAssignAssetByIDRace(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+	assetID := seed.ID("asset:pen-drive-1")
+	employees := []uuid.UUID{seed.ID("user:developer"), seed.ID("user:designer"), seed.ID("user:intern"), seed.ID("user:freelancer")}
+
+	errs := race(t, db, func(i int, tx *sqlx.Tx) error {
+		return repo.AssignAssetByID(utils.ContextWithTx(ctx, tx), assetID, employees[i%len(employees)], seed.ID("user:asset-manager"), nil, nil)
+	})
+
+	assert.Equal(t, 1, countNil(errs), "exactly one assignment should win: %v", errs)
+	for _, err := range errs {
+		if err != nil {
+			assert.True(t, errors.Is(err, ErrAssetAlreadyAssigned), "losers should see the asset assigned, got %v", err)
+		}
+	}
+	var open int
+	require.NoError(t, db.Get(&open, `SELECT count(*) FROM asset_assign WHERE asset_id = $1 AND returned_at IS NULL AND archived_at IS NULL`, assetID))
+	assert.Equal(t, 1, open)
+	var status string
+	require.NoError(t, db.Get(&status, `SELECT status FROM assets WHERE id = $1`, assetID))
+	assert.Equal(t, "assigned", status)
+}
+
+// the lock is per asset, assignments of different assets go through side by side
+func TestAssignAssetByIDDifferentAssets(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+	employeeID := seed.ID("user:intern")
+
+	var assetIDs []uuid.UUID
+	require.NoError(t, db.Select(&assetIDs, `
+		SELECT id FROM assets a
+		WHERE archived_at IS NULL AND NOT EXISTS (
+			SELECT 1 FROM asset_assign aa WHERE aa.asset_id = a.id AND aa.returned_at IS NULL AND aa.archived_at IS NULL
+		)
+		ORDER BY serial_no
+		LIMIT $1`, racers))
+	require.NotEmpty(t, assetIDs)
+
+	errs := race(t, db, func(i int, tx *sqlx.Tx) error {
+		if i >= len(assetIDs) {
+			return nil
+		}
+		return repo.AssignAssetByID(utils.ContextWithTx(ctx, tx), assetIDs[i], employeeID, seed.ID("user:asset-manager"), nil, nil)
+	})
+
+	assert.Equal(t, racers, countNil(errs), "every asset should be assigned: %v", errs)
+	var open int
+	require.NoError(t, db.Get(&open, `SELECT count(*) FROM asset_assign WHERE asset_id = ANY($1) AND returned_at IS NULL AND archived_at IS NULL`, pq.Array(assetIDs)))
+	assert.Equal(t, len(assetIDs), open)
+}
+
+func TestRetrieveAssetRace(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+	assetID, employeeID := seed.ID("asset:laptop-1"), seed.ID("user:developer")
+
+	errs := race(t, db, func(i int, tx *sqlx.Tx) error {
+		return repo.RetrieveAsset(utils.ContextWithTx(ctx, tx), assetID, employeeID, "leaving")
+	})
+
+	assert.Equal(t, 1, countNil(errs), "exactly one return should win: %v", errs)
+	for _, err := range errs {
+		if err != nil {
+			assert.True(t, errors.Is(err, ErrAssignmentNotFound), "losers should see the assignment gone, got %v", err)
+		}
+	}
+	var status string
+	require.NoError(t, db.Get(&status, `SELECT status FROM assets WHERE id = $1`, assetID))
+	assert.Equal(t, "available", status)
+}
+
+// a missing asset is answered from redis the second time, a live one always reaches the database
+func TestIsAssetInScopeCachesMiss(t *testing.T) {
+	db := testdb.Seeded(t)
+	redis := testdb.Redis(t)
+	repo := NewAssetRepository(db, redis, nil)
+	ctx := context.Background()
+	all := models.DepartmentScope{AllDepartments: true}
+
+	missing := uuid.New()
+	inScope, err := repo.IsAssetInScope(ctx, missing, all)
+	require.NoError(t, err)
+	assert.False(t, inScope)
+	cached, err := redis.Get(ctx, cacheprovider.AssetNotFoundKey(missing))
+	require.NoError(t, err)
+	assert.Equal(t, "1", cached)
+
+	inScope, err = repo.IsAssetInScope(ctx, seed.ID("asset:laptop-1"), all)
+	require.NoError(t, err)
+	assert.True(t, inScope)
+	_, err = redis.Get(ctx, cacheprovider.AssetNotFoundKey(seed.ID("asset:laptop-1")))
+	assert.Error(t, err)
+}
+
+func TestIsAssetInScopeOtherOrganization(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+
+	var other uuid.UUID
+	require.NoError(t, db.GetContext(ctx, &other, `
+		INSERT INTO organizations (name, slug) VALUES ('Other', 'other') RETURNING id
+	`))
+	_, err := db.ExecContext(ctx, `UPDATE assets SET organization_id = $1 WHERE id = $2`, other, seed.ID("asset:laptop-1"))
+	require.NoError(t, err)
+
+	inScope, err := repo.IsAssetInScope(ctx, seed.ID("asset:laptop-1"), models.DepartmentScope{AllDepartments: true, OrganizationID: &models.DefaultOrganizationID})
+	require.NoError(t, err)
+	assert.False(t, inScope)
+
+	inScope, err = repo.IsAssetInScope(ctx, seed.ID("asset:laptop-1"), models.DepartmentScope{AllDepartments: true, OrganizationID: &other})
+	require.NoError(t, err)
+	assert.True(t, inScope)
+
+	inScope, err = repo.IsAssetInScope(ctx, seed.ID("asset:laptop-1"), models.DepartmentScope{AllDepartments: true})
+	require.NoError(t, err)
+	assert.True(t, inScope)
+}
+
+// an admin reaching every department only sees their own organization in the reports, and managers
+// are only looked up among the users of the asset's organization
+func TestOrganizationScopedQueries(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2020, 3, d, 12, 0, 0, 0, time.UTC) }
+
+	var other uuid.UUID
+	require.NoError(t, db.GetContext(ctx, &other, `
+		INSERT INTO organizations (name, slug) VALUES ('Other', 'other') RETURNING id
+	`))
+	var assetID uuid.UUID
+	require.NoError(t, db.GetContext(ctx, &assetID, `
+		INSERT INTO assets (brand, model, serial_no, type, added_at, organization_id)
+		VALUES ('LG', '27UL', 'ORG-1', 'monitor', $1, $2) RETURNING id`, day(2), other))
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO asset_assign (asset_id, employee_id, assigned_at, returned_at) VALUES ($1, $2, $3, $4)`,
+		assetID, seed.ID("user:developer"), day(3), day(6))
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `INSERT INTO asset_return_requests (asset_id, employee_id, reason) VALUES ($1, $2, 'leaving')`,
+		assetID, seed.ID("user:developer"))
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO asset_mdm_status (asset_id, mdm_system, device_id, user_email, mismatch_since)
+		VALUES ($1, 'jamf', 'ORG-1', 'someone@other.com', now())`, assetID)
+	require.NoError(t, err)
+
+	own := models.DepartmentScope{AllDepartments: true, OrganizationID: &models.DefaultOrganizationID}
+	theirs := models.DepartmentScope{AllDepartments: true, OrganizationID: &other}
+
+	for _, tc := range []struct {
+		scope models.DepartmentScope
+		found bool
+	}{{own, false}, {theirs, true}} {
+		requests, err := repo.GetReturnRequests(ctx, models.ReturnRequestFilter{Scope: tc.scope, Limit: 1000})
+		require.NoError(t, err)
+		assert.Equal(t, tc.found, slices.ContainsFunc(requests, func(r models.ReturnRequestRes) bool { return r.AssetID == assetID }), "return requests")
+
+		mismatches, err := repo.GetMDMMismatches(ctx, models.MDMMismatchFilter{Scope: tc.scope, Limit: 1000})
+		require.NoError(t, err)
+		assert.Equal(t, tc.found, slices.ContainsFunc(mismatches, func(m models.MDMMismatch) bool { return m.AssetID == assetID }), "mdm mismatches")
+
+		utilization := models.UtilizationFilter{From: day(1), To: day(11), Type: "monitor", Scope: tc.scope, Limit: 1000}
+		assets, err := repo.GetAssetUtilization(ctx, utilization)
+		require.NoError(t, err)
+		assert.Equal(t, tc.found, slices.ContainsFunc(assets, func(a models.AssetUtilization) bool { return a.AssetID == assetID }), "asset utilization")
+		types, err := repo.GetTypeUtilization(ctx, utilization)
+		require.NoError(t, err)
+		tracked := 0
+		for _, typ := range types {
+			tracked += typ.Assets
+		}
+		assert.Equal(t, len(assets), tracked, "type utilization")
+
+		trends, err := repo.GetTrends(ctx, models.TrendFilter{From: day(2).Truncate(24 * time.Hour), To: day(9).Truncate(24 * time.Hour), Interval: models.TrendDaily, Type: "monitor", Scope: tc.scope})
+		require.NoError(t, err)
+		added, assigned := 0, 0
+		for _, point := range trends {
+			added += point.AssetsAdded
+			assigned += point.Assignments
+		}
+		if tc.found {
+			assert.Equal(t, []int{1, 1}, []int{added, assigned}, "trends")
+		} else {
+			assert.Equal(t, []int{0, 0}, []int{added, assigned}, "trends")
+		}
+	}
+
+	admin := seed.ID("user:admin")
+	_, err = db.ExecContext(ctx, `UPDATE users SET organization_id = $1 WHERE id = $2`, other, admin)
+	require.NoError(t, err)
+	engineering := seed.ID("department:engineering")
+
+	assetManagers, err := repo.GetAssetManagerIDs(ctx, models.DefaultOrganizationID, &engineering)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{seed.ID("user:asset-manager")}, assetManagers)
+	employeeManagers, err := repo.GetEmployeeManagerIDs(ctx, models.DefaultOrganizationID, &engineering)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{seed.ID("user:employee-manager")}, employeeManagers)
+	contacts, err := repo.GetAssetManagerContacts(ctx, models.DefaultOrganizationID, &engineering)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"9000000002"}, contacts)
+
+	otherManagers, err := repo.GetAssetManagerIDs(ctx, other, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{admin}, otherManagers)
+}
+
+// moved rows leave the live tables but timelines still show them through the views
+func TestMoveClosedHistory(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+	assetID := seed.ID("asset:laptop-1")
+
+	before, err := repo.GetAssetTimeline(ctx, assetID)
+	require.NoError(t, err)
+	var total int
+	require.NoError(t, db.Get(&total, `SELECT count(*) FROM asset_assign`))
+
+	cutoff := time.Now().Add(time.Hour)
+	var moved int64
+	for {
+		n, err := repo.MoveClosedAssignmentsBefore(ctx, cutoff, 2)
+		require.NoError(t, err)
+		moved += n
+		if n < 2 {
+			break
+		}
+	}
+	_, err = repo.MoveClosedServicesBefore(ctx, cutoff, 1000)
+	require.NoError(t, err)
+	assert.Positive(t, moved, "the seed has returned assignments")
+
+	var live, all int
+	require.NoError(t, db.Get(&live, `SELECT count(*) FROM asset_assign WHERE returned_at IS NOT NULL OR archived_at IS NOT NULL`))
+	require.NoError(t, db.Get(&all, `SELECT count(*) FROM asset_assign_all`))
+	assert.Zero(t, live)
+	assert.Equal(t, total, all)
+
+	after, err := repo.GetAssetTimeline(ctx, assetID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, before, after)
+}
+
+// metadata given at handover stays on the assigned event, also once the assignment moved to history
+func TestAssignmentMetadata(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+
+	var assetID uuid.UUID
+	require.NoError(t, db.Get(&assetID, `
+		INSERT INTO assets (brand, model, serial_no, type) VALUES ('Dell', 'XPS 13', 'META-1', 'laptop') RETURNING id`))
+	metadata := &models.AssignmentMetadata{Purpose: "client demo", Project: "Atlas", Accessories: []string{"charger", "bag"}, HandoverCondition: "good"}
+	require.NoError(t, repo.AssignAssetByID(ctx, assetID, seed.ID("user:developer"), seed.ID("user:asset-manager"), metadata, nil))
+
+	timeline, err := repo.GetAssetTimeline(ctx, assetID)
+	require.NoError(t, err)
+	require.Len(t, timeline, 1)
+	assert.Equal(t, metadata, timeline[0].Metadata)
+
+	require.NoError(t, repo.RetrieveAsset(ctx, assetID, seed.ID("user:developer"), "done"))
+	_, err = repo.MoveClosedAssignmentsBefore(ctx, time.Now().Add(time.Hour), 1000)
+	require.NoError(t, err)
+	timeline, err = repo.GetAssetTimeline(ctx, assetID)
+	require.NoError(t, err)
+	require.Len(t, timeline, 1)
+	assert.Equal(t, metadata, timeline[0].Metadata)
+}
+
+func TestDuplicateAssetsAndMerge(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+	all := models.DuplicateFilter{Scope: models.DepartmentScope{AllDepartments: true}, Limit: 10}
+	keepID := seed.ID("asset:laptop-1")
+
+	var duplicateID uuid.UUID
+	require.NoError(t, db.Get(&duplicateID, `
+		INSERT INTO assets (brand, model, serial_no, type) VALUES ('Apple', 'MacBook Pro 14', 'seed-lap-0001', 'laptop') RETURNING id`))
+	_, err := db.Exec(`
+		INSERT INTO asset_service (asset_id, service_start, service_end, reason, created_by)
+		VALUES ($1, now() - INTERVAL '2 days', now() - INTERVAL '1 day', 'screen', $2)`, duplicateID, seed.ID("user:asset-manager"))
+	require.NoError(t, err)
+
+	groups, err := repo.GetDuplicateAssets(ctx, all)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	assert.Equal(t, models.DuplicateBySerialNo, groups[0].Match)
+	assert.Equal(t, "seed-lap-0001", groups[0].Value)
+	require.Len(t, groups[0].Assets, 2)
+	assert.Equal(t, keepID, groups[0].Assets[0].ID)
+	assert.Equal(t, duplicateID, groups[0].Assets[1].ID)
+
+	before, err := repo.GetAssetTimeline(ctx, keepID)
+	require.NoError(t, err)
+	var moved map[string]int64
+	require.NoError(t, utils.WithTransaction(ctx, db, func(ctx context.Context) error {
+		moved, err = repo.MergeAssets(ctx, keepID, duplicateID, seed.ID("user:admin"))
+		return err
+	}))
+	assert.Equal(t, map[string]int64{"asset_service": 1}, moved)
+
+	after, err := repo.GetAssetTimeline(ctx, keepID)
+	require.NoError(t, err)
+	assert.Len(t, after, len(before)+1)
+	var mergedInto *uuid.UUID
+	require.NoError(t, db.Get(&mergedInto, `SELECT merged_into FROM assets WHERE id = $1 AND archived_at IS NOT NULL`, duplicateID))
+	assert.Equal(t, &keepID, mergedInto)
+
+	groups, err = repo.GetDuplicateAssets(ctx, all)
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+}
+
+func TestMergeAssetsBothAssigned(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+
+	var duplicateID uuid.UUID
+	require.NoError(t, db.Get(&duplicateID, `
+		INSERT INTO assets (brand, model, serial_no, type) VALUES ('Apple', 'MacBook Pro 14', 'seed-lap-0001', 'laptop') RETURNING id`))
+	require.NoError(t, repo.AssignAssetByID(ctx, duplicateID, seed.ID("user:designer"), seed.ID("user:asset-manager"), nil, nil))
+
+	err := utils.WithTransaction(ctx, db, func(ctx context.Context) error {
+		_, err := repo.MergeAssets(ctx, seed.ID("asset:laptop-1"), duplicateID, seed.ID("user:admin"))
+		return err
+	})
+	assert.ErrorIs(t, err, ErrMergeBothAssigned)
+}
+
+func TestSearchAssetsWithFilter(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	engineering := seed.ID("department:engineering")
+
+	tests := []struct {
+		name   string
+		filter models.AssetFilter
+		want   func(a seed.Asset) bool
+	}{
+		{
+			name:   "every asset",
+			filter: models.AssetFilter{Status: allStatuses, Type: allTypes, OwnedBy: allOwners},
+			want:   func(a seed.Asset) bool { return true },
+		},
+		{
+			name:   "laptops",
+			filter: models.AssetFilter{Status: allStatuses, Type: []string{"laptop"}, OwnedBy: allOwners},
+			want:   func(a seed.Asset) bool { return a.Type == "laptop" },
+		},
+		{
+			name:   "available",
+			filter: models.AssetFilter{Status: []string{"available"}, Type: allTypes, OwnedBy: allOwners},
+			want:   func(a seed.Asset) bool { return seededStatus(a.Key) == "available" },
+		},
+		{
+			name:   "under service",
+			filter: models.AssetFilter{Status: []string{"sent_for_service"}, Type: allTypes, OwnedBy: allOwners},
+			want:   func(a seed.Asset) bool { return seededStatus(a.Key) == "sent_for_service" },
+		},
+		{
+			name:   "client owned",
+			filter: models.AssetFilter{Status: allStatuses, Type: allTypes, OwnedBy: []string{"client"}},
+			want:   func(a seed.Asset) bool { return a.OwnedBy == "client" },
+		},
+		{
+			name:   "search matches serial numbers",
+			filter: models.AssetFilter{IsSearchText: true, SearchText: "%SEED-LAP%", Status: allStatuses, Type: allTypes, OwnedBy: allOwners},
+			want:   func(a seed.Asset) bool { return a.Type == "laptop" },
+		},
+		{
+			name:   "search matches brands case insensitively",
+			filter: models.AssetFilter{IsSearchText: true, SearchText: "%dell%", Status: allStatuses, Type: allTypes, OwnedBy: allOwners},
+			want:   func(a seed.Asset) bool { return a.Brand == "Dell" },
+		},
+		{
+			name:   "one department",
+			filter: models.AssetFilter{Status: allStatuses, Type: allTypes, OwnedBy: allOwners, Scope: models.DepartmentScope{DepartmentID: &engineering}},
+			want:   func(a seed.Asset) bool { return a.Department == "engineering" },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.filter.Limit = 100
+			if tt.filter.Scope.DepartmentID == nil {
+				tt.filter.Scope.AllDepartments = true
+			}
+			assets, err := repo.SearchAssetsWithFilter(context.Background(), tt.filter)
+			require.NoError(t, err)
+
+			var want, got []string
+			for _, a := range seed.Assets {
+				if tt.want(a) {
+					want = append(want, seed.ID("asset:"+a.Key).String())
+				}
+			}
+			for _, a := range assets {
+				got = append(got, a.ID)
+				assert.NotNil(t, a.Config, "asset %s came without its config", a.SerialNo)
+			}
+			assert.ElementsMatch(t, want, got)
+		})
+	}
+}
+
+// ten days of window, three assigned, three in a service still open and four idle. The asset was
+// added before the window and only the window counts
+func TestGetAssetUtilization(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	var assetID uuid.UUID
+	require.NoError(t, db.Get(&assetID, `
+		INSERT INTO assets (brand, model, serial_no, type, added_at, status)
+		VALUES ('Lenovo', 'T14', 'UTIL-1', 'laptop', $1, 'sent_for_service') RETURNING id`, day(1).AddDate(0, -1, 0)))
+	_, err := db.Exec(`INSERT INTO asset_assign (asset_id, employee_id, assigned_at, returned_at) VALUES ($1, $2, $3, $4)`,
+		assetID, seed.ID("user:developer"), day(3), day(6))
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO asset_service (asset_id, service_start, reason, created_by) VALUES ($1, $2, 'screen', $3)`,
+		assetID, day(8), seed.ID("user:asset-manager"))
+	require.NoError(t, err)
+
+	filter := models.UtilizationFilter{From: day(1), To: day(11), Type: "laptop", Scope: models.DepartmentScope{AllDepartments: true}, Limit: 1000}
+	assets, err := repo.GetAssetUtilization(ctx, filter)
+	require.NoError(t, err)
+	var found *models.AssetUtilization
+	for i := range assets {
+		assert.Equal(t, "laptop", assets[i].Type)
+		if assets[i].AssetID == assetID {
+			found = &assets[i]
+		}
+	}
+	require.NotNil(t, found)
+	assert.Equal(t, 10.0, found.DaysTracked)
+	assert.Equal(t, 3.0, found.DaysAssigned)
+	assert.Equal(t, 3.0, found.DaysInService)
+	assert.Equal(t, 4.0, found.DaysIdle)
+	assert.Equal(t, 0.3, found.Utilization)
+
+	types, err := repo.GetTypeUtilization(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, types, 1)
+	assert.Equal(t, "laptop", types[0].Type)
+	assert.Equal(t, len(assets), types[0].Assets)
+}
+
+// two monitors of a window long before the seed, one handed out twice. Only returned assignments have a
+// duration and reasons are grouped regardless of case
+func TestGetAssignmentStats(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2020, 3, d, 0, 0, 0, 0, time.UTC) }
+
+	var assetIDs []uuid.UUID
+	require.NoError(t, db.Select(&assetIDs, `
+		INSERT INTO assets (brand, model, serial_no, type, department_id)
+		VALUES ('LG', '27UL', 'CHURN-1', 'monitor', $1), ('LG', '27UL', 'CHURN-2', 'monitor', $1) RETURNING id`,
+		seed.ID("department:engineering")))
+	employee := seed.ID("user:developer")
+	_, err := db.Exec(`
+		INSERT INTO asset_assign (asset_id, employee_id, assigned_at, returned_at, return_reason) VALUES
+			($1, $3, $4, $5, 'Upgrade'), ($1, $3, $6, $7, ' upgrade '), ($2, $3, $6, NULL, NULL)`,
+		assetIDs[0], assetIDs[1], employee, day(1), day(3), day(10), day(14))
+	require.NoError(t, err)
+
+	filter := models.ChurnFilter{From: day(1), To: day(31), Scope: models.DepartmentScope{AllDepartments: true}}
+	byType, err := repo.GetAssignmentStats(ctx, filter, "type")
+	require.NoError(t, err)
+	assert.Equal(t, []models.AssignmentStats{{
+		Group: "monitor", Assignments: 3, Returned: 2, Assets: 2, AvgDays: 3, MedianDays: 3, Reassignments: 1, ReassignmentsPerAsset: 0.5,
+	}}, byType)
+
+	byDepartment, err := repo.GetAssignmentStats(ctx, filter, "department")
+	require.NoError(t, err)
+	require.Len(t, byDepartment, 1)
+	assert.Equal(t, 3, byDepartment[0].Assignments)
+
+	reasons, err := repo.GetReturnReasons(ctx, filter, 20)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ReturnReasonCount{{Reason: "upgrade", Count: 2, Share: 1}}, reasons)
+}
+
+// a monitor added on a monday, assigned, returned and serviced over the next two weeks
+func TestGetTrends(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2020, 3, d, 12, 0, 0, 0, time.UTC) }
+
+	var assetID uuid.UUID
+	require.NoError(t, db.Get(&assetID, `
+		INSERT INTO assets (brand, model, serial_no, type, department_id, added_at)
+		VALUES ('LG', '27UL', 'TREND-1', 'monitor', $1, $2) RETURNING id`,
+		seed.ID("department:engineering"), day(2)))
+	_, err := db.Exec(`
+		INSERT INTO asset_assign (asset_id, employee_id, assigned_at, returned_at, return_reason)
+		VALUES ($1, $2, $3, $4, 'flicker')`, assetID, seed.ID("user:developer"), day(3), day(10))
+	require.NoError(t, err)
+	_, err = db.Exec(`
+		INSERT INTO asset_service (asset_id, service_start, service_end, reason, created_by)
+		VALUES ($1, $2, $3, 'flicker', $4)`, assetID, day(10), day(12), seed.ID("user:asset-manager"))
+	require.NoError(t, err)
+
+	filter := models.TrendFilter{From: day(2).Truncate(24 * time.Hour), To: day(16).Truncate(24 * time.Hour), Interval: models.TrendWeekly, Type: "monitor", Scope: models.DepartmentScope{AllDepartments: true}}
+	weekly, err := repo.GetTrends(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, weekly, 2)
+	assert.True(t, weekly[0].Bucket.Equal(time.Date(2020, 3, 2, 0, 0, 0, 0, time.UTC)), "weeks start on monday, got %s", weekly[0].Bucket)
+	assert.Equal(t, models.TrendPoint{Bucket: weekly[0].Bucket, AssetsAdded: 1, Assignments: 1}, weekly[0])
+	assert.Equal(t, models.TrendPoint{Bucket: weekly[1].Bucket, Returns: 1, ServicesStarted: 1, ServicesEnded: 1}, weekly[1])
+
+	filter.Interval = models.TrendDaily
+	daily, err := repo.GetTrends(ctx, filter)
+	require.NoError(t, err)
+	assert.Len(t, daily, 14)
+}
+
+// a mouse serviced three times ten days apart, the last service still open at the end of the window
+// and one without a cost
+func TestGetServiceStats(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2020, 6, d, 0, 0, 0, 0, time.UTC) }
+
+	var assetID uuid.UUID
+	require.NoError(t, db.Get(&assetID, `
+		INSERT INTO assets (brand, model, serial_no, type) VALUES ('Logitech', 'M90', 'SERVICE-1', 'mouse') RETURNING id`))
+	manager := seed.ID("user:asset-manager")
+	_, err := db.Exec(`
+		INSERT INTO asset_service (asset_id, service_start, service_end, reason, created_by, cost) VALUES
+			($1, $3, $4, 'scroll', $2, 20), ($1, $5, $6, 'click', $2, NULL), ($1, $6, NULL, 'cable', $2, NULL)`,
+		assetID, manager, day(1), day(3), day(11), day(21))
+	require.NoError(t, err)
+
+	filter := models.ServiceReportFilter{From: day(1), To: day(26), Type: "mouse", Top: 5, Scope: models.DepartmentScope{AllDepartments: true}}
+	top, err := repo.GetAssetServiceStats(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, top, 1)
+	between := 10.0
+	assert.Equal(t, models.AssetServiceStats{
+		AssetID: assetID, Brand: "Logitech", Model: "M90", SerialNo: "SERVICE-1", Type: "mouse",
+		Services: 3, DaysInService: 17, MeanDaysBetween: &between, TotalCost: 20, ServicesWithoutCost: 2,
+	}, top[0])
+
+	byType, err := repo.GetTypeServiceStats(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []models.TypeServiceStats{{
+		Type: "mouse", Assets: 1, Services: 3, DaysInService: 17, MeanDaysBetween: &between, TotalCost: 20, ServicesWithoutCost: 2,
+	}}, byType)
+}
+
+func TestKioskLookups(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+
+	_, err := repo.GetKioskEmployee(ctx, "BADGE-1")
+	assert.ErrorIs(t, err, ErrBadgeNotFound)
+	_, err = db.Exec(`UPDATE users SET badge_id = 'BADGE-1' WHERE id = $1`, seed.ID("user:developer"))
+	require.NoError(t, err)
+	employee, err := repo.GetKioskEmployee(ctx, "BADGE-1")
+	require.NoError(t, err)
+	assert.Equal(t, seed.ID("user:developer"), employee.ID)
+
+	_, err = repo.GetKioskAsset(ctx, "KIOSK-1")
+	assert.ErrorIs(t, err, ErrAssetNotFound)
+	var assetID uuid.UUID
+	require.NoError(t, db.Get(&assetID, `
+		INSERT INTO assets (brand, model, serial_no, type, kiosk_pool) VALUES ('Logitech', 'M90', 'KIOSK-1', 'mouse', true) RETURNING id`))
+	asset, err := repo.GetKioskAsset(ctx, "KIOSK-1")
+	require.NoError(t, err)
+	assert.Equal(t, models.KioskAsset{ID: assetID, Brand: "Logitech", Model: "M90", Type: "mouse", Status: "available", KioskPool: true}, asset)
+}
+
+// BenchmarkSearchAssetsWithFilter pages through 10k laptops and mice, joined reads the configs with the
+// page and per_asset the way the search did before, with one query per asset
+func BenchmarkSearchAssetsWithFilter(b *testing.B) {
+	db := testdb.New(b)
+	_, err := db.Exec(`
+		INSERT INTO assets (brand, model, serial_no, type, purchase_date, warranty_start, warranty_expire)
+		SELECT 'Bench', 'Model ' || n, 'BENCH-' || n, (ARRAY['laptop', 'mouse'])[n % 2 + 1]::asset_type,
+			now(), now(), now() + interval '1 year'
+		FROM generate_series(1, 10000) n`)
+	require.NoError(b, err)
+	_, err = db.Exec(`INSERT INTO laptop_config (asset_id, processor, ram, os) SELECT id, 'i7', '16GB', 'linux' FROM assets WHERE type = 'laptop'`)
+	require.NoError(b, err)
+	_, err = db.Exec(`INSERT INTO mouse_config (asset_id, dpi) SELECT id, '1600' FROM assets WHERE type = 'mouse'`)
+	require.NoError(b, err)
+
+	repo := NewAssetRepository(db, testdb.Redis(b), nil)
+	ctx := context.Background()
+	filter := models.AssetFilter{Status: allStatuses, Type: allTypes, OwnedBy: allOwners, Scope: models.DepartmentScope{AllDepartments: true}}
+	for _, pageSize := range []int{50, 500} {
+		filter.Limit = pageSize
+		b.Run(fmt.Sprintf("joined/page=%d", pageSize), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				filter.Offset = (i * pageSize) % 10000
+				if _, err := repo.SearchAssetsWithFilter(ctx, filter); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("per_asset/page=%d", pageSize), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				filter.Offset = (i * pageSize) % 10000
+				if err := searchConfigPerAsset(ctx, db, filter); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// benchEmployees is the size of the bulk dataset the filter benchmarks run on, it has three assets
+// per employee
+const benchEmployees = 2000
+
+// BenchmarkSearchAssetsFilters runs the filter combinations the inventory screen sends over the bulk
+// seed, one page of 50 at a time, so a change to the query or its indexes shows in the combination it hurts
+func BenchmarkSearchAssetsFilters(b *testing.B) {
+	db := testdb.Bulk(b, benchEmployees)
+	repo := NewAssetRepository(db, testdb.Redis(b), nil)
+	ctx := context.Background()
+	engineering := seed.ID("department:engineering")
+	all := models.DepartmentScope{AllDepartments: true}
+	filters := []struct {
+		name   string
+		filter models.AssetFilter
+	}{
+		{"all", models.AssetFilter{Status: allStatuses, Type: allTypes, OwnedBy: allOwners, Scope: all}},
+		{"status", models.AssetFilter{Status: []string{"available"}, Type: allTypes, OwnedBy: allOwners, Scope: all}},
+		{"type_owner", models.AssetFilter{Status: allStatuses, Type: []string{"laptop"}, OwnedBy: []string{"client"}, Scope: all}},
+		{"search", models.AssetFilter{IsSearchText: true, SearchText: "%BULK-1%", Status: allStatuses, Type: allTypes, OwnedBy: allOwners, Scope: all}},
+		{"department", models.AssetFilter{Status: allStatuses, Type: allTypes, OwnedBy: allOwners, Scope: models.DepartmentScope{DepartmentID: &engineering}}},
+	}
+	for _, f := range filters {
+		filter := f.filter
+		filter.Limit = 50
+		b.Run(f.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				filter.Offset = (i * filter.Limit) % (benchEmployees * seed.BulkAssetsPerUser)
+				if _, err := repo.SearchAssetsWithFilter(ctx, filter); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// searchConfigPerAsset reads a page the way SearchAssetsWithFilter did before it joined the config tables
+func searchConfigPerAsset(ctx context.Context, db *sqlx.DB, filter models.AssetFilter) error {
+	var assets []models.AssetWithConfigRes
+	err := db.SelectContext(ctx, &assets, `
+		SELECT id, brand, model, serial_no, type, owned_by, status, purchase_date, warranty_start, warranty_expire, department_id
+		FROM assets
+		WHERE archived_at IS NULL AND status = ANY($1) AND owned_by = ANY($2) AND type = ANY($3)
+		ORDER BY added_at DESC
+		LIMIT $4 OFFSET $5`,
+		pq.Array(filter.Status), pq.Array(filter.OwnedBy), pq.Array(filter.Type), filter.Limit, filter.Offset)
+	if err != nil {
+		return err
+	}
+	for i, asset := range assets {
+		switch asset.Type {
+		case "laptop":
+			var config models.Laptop_config_res
+			err = db.GetContext(ctx, &config, `SELECT processor, ram, os FROM laptop_config WHERE asset_id = $1`, asset.ID)
+			assets[i].Config = config
+		case "mouse":
+			var config models.Mouse_config_res
+			err = db.GetContext(ctx, &config, `SELECT dpi FROM mouse_config WHERE asset_id = $1`, asset.ID)
+			assets[i].Config = config
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// race runs fn in racers transactions that start together, each commits when fn succeeds
+func race(t *testing.T, db *sqlx.DB, fn func(i int, tx *sqlx.Tx) error) []error {
+	t.Helper()
+	errs := make([]error, racers)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < racers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tx, err := db.Beginx()
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			<-start
+			if errs[i] = fn(i, tx); errs[i] != nil {
+				tx.Rollback()
+				return
+			}
+			errs[i] = tx.Commit()
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	return errs
+}
+
+func countNil(errs []error) int {
+	n := 0
+	for _, err := range errs {
+		if err == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// seededStatus is the status the seed data implies, an open service wins over an open assignment
+func seededStatus(assetKey string) string {
+	for _, s := range seed.Services {
+		if s.Asset == assetKey && s.Ended == nil {
+			return "sent_for_service"
+		}
+	}
+	for _, a := range seed.Assignments {
+		if a.Asset == assetKey && a.Returned == nil {
+			return "assigned"
+		}
+	}
+	return "available"
+}
+
+// a sim bought in june 2020 counts from june on, one deleted in june is gone by july
+func TestGetTelecomCosts(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+	operations := seed.ID("department:operations")
+	month := func(m time.Month) models.TelecomCostFilter {
+		return models.TelecomCostFilter{Month: time.Date(2020, m, 1, 0, 0, 0, 0, time.UTC), Scope: models.DepartmentScope{AllDepartments: true}}
+	}
+
+	var simID, deletedID uuid.UUID
+	require.NoError(t, db.Get(&simID, `
+		INSERT INTO assets (brand, model, serial_no, type, department_id, purchase_date)
+		VALUES ('Jio', 'Postpaid', 'TELECOM-1', 'sim', $1, '2020-06-10') RETURNING id`, operations))
+	require.NoError(t, repo.AddSimConfig(ctx, models.Sim_config_req{
+		Number: 9100000002, Carrier: "Jio", Plan: "Business", MonthlyCost: 10.5, RenewalDate: "2020-07-05", MobileAssetID: seed.ID("asset:mobile-1").String(),
+	}, simID))
+	require.NoError(t, db.Get(&deletedID, `
+		INSERT INTO assets (brand, model, serial_no, type, department_id, purchase_date, archived_at)
+		VALUES ('Jio', 'Postpaid', 'TELECOM-2', 'sim', $1, '2020-06-01', '2020-06-20') RETURNING id`, operations))
+	require.NoError(t, repo.AddSimConfig(ctx, models.Sim_config_req{MonthlyCost: 4}, deletedID))
+
+	costs, err := repo.GetTelecomCosts(ctx, month(time.May))
+	require.NoError(t, err)
+	assert.Empty(t, costs)
+
+	costs, err = repo.GetTelecomCosts(ctx, month(time.June))
+	require.NoError(t, err)
+	assert.Equal(t, []models.DepartmentTelecomCost{{DepartmentID: &operations, Department: "Operations", Sims: 2, MonthlyCost: 14.5}}, costs)
+
+	costs, err = repo.GetTelecomCosts(ctx, month(time.July))
+	require.NoError(t, err)
+	assert.Equal(t, []models.DepartmentTelecomCost{{DepartmentID: &operations, Department: "Operations", Sims: 1, MonthlyCost: 10.5, RenewalsDue: 1}}, costs)
+}
+
+func TestAddSimConfigMobile(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+
+	var simID uuid.UUID
+	require.NoError(t, db.Get(&simID, `INSERT INTO assets (brand, model, serial_no, type) VALUES ('Jio', 'Prepaid', 'SIM-LINK-1', 'sim') RETURNING id`))
+	err := repo.AddSimConfig(ctx, models.Sim_config_req{MobileAssetID: seed.ID("asset:laptop-1").String()}, simID)
+	assert.ErrorIs(t, err, ErrSimMobileNotFound)
+
+	require.NoError(t, repo.AddSimConfig(ctx, models.Sim_config_req{Carrier: "Jio", MobileAssetID: seed.ID("asset:mobile-1").String()}, simID))
+	assets, err := repo.SearchAssetsWithFilter(ctx, models.AssetFilter{
+		IsSearchText: true, SearchText: "SIM-LINK-1", Status: allStatuses, OwnedBy: allOwners, Type: allTypes, Limit: 10,
+		Scope: models.DepartmentScope{AllDepartments: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, assets, 1)
+	mobileID := seed.ID("asset:mobile-1")
+	assert.Equal(t, models.Sim_config_res{Carrier: "Jio", MobileAssetID: &mobileID}, assets[0].Config)
+}
+
+func TestIMEIBlacklist(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+	admin := seed.ID("user:admin")
+
+	require.NoError(t, repo.AddBlacklistedIMEI(ctx, models.BlacklistedIMEI{IMEI: "490154203237518", Reason: "lost in transit", AddedBy: &admin}))
+	assert.ErrorIs(t, repo.AddBlacklistedIMEI(ctx, models.BlacklistedIMEI{IMEI: "490154203237518", Reason: "again"}), ErrIMEIAlreadyBlacklisted)
+	entries, err := repo.ListIMEIBlacklist(ctx, models.IMEIBlacklistFilter{Search: "4901", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "lost in transit", entries[0].Reason)
+
+	ctrl := gomock.NewController(t)
+	lookup := providers.NewMockIMEILookupProvider(ctrl)
+	svc := &assetService{repo: repo, imeiLookup: lookup}
+	flag, err := svc.checkIMEIs(ctx, "490154203237518", "356938035643809")
+	require.NoError(t, err)
+	assert.Empty(t, flag, "nothing is checked while the check is off")
+
+	// the registry is only asked about imeis the blacklist doesn't have
+	lookup.EXPECT().Lookup(gomock.Any(), "356938035643809").Return(models.IMEILookup{Blacklisted: true, Reason: "carrier report"}, nil).Times(2)
+	svc.imeiCheck = models.IMEICheckFlag
+	flag, err = svc.checkIMEIs(ctx, "490154203237518", " 356938035643809 ", "")
+	require.NoError(t, err)
+	assert.Equal(t, "490154203237518 lost in transit; 356938035643809 carrier report", flag)
+
+	svc.imeiCheck = models.IMEICheckBlock
+	_, err = svc.checkIMEIs(ctx, "490154203237518", "356938035643809")
+	var serviceErr *models.ServiceError
+	require.ErrorAs(t, err, &serviceErr)
+	assert.Equal(t, "imei_blacklisted", serviceErr.Code)
+
+	require.NoError(t, repo.RemoveBlacklistedIMEI(ctx, "490154203237518"))
+	assert.ErrorIs(t, repo.RemoveBlacklistedIMEI(ctx, "490154203237518"), ErrIMEINotBlacklisted)
+}
+
+func TestReportStolenBlacklistsIMEIs(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+	mobileID := seed.ID("asset:mobile-1")
+
+	_, err := repo.ReportAssetStolen(ctx, mobileID, seed.ID("user:employee-manager"), "left in a cab")
+	require.NoError(t, err)
+	entries, err := repo.GetBlacklistedIMEIs(ctx, []string{"356000000000011", "356000000000029"})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	for _, entry := range entries {
+		assert.Equal(t, "reported stolen", entry.Reason)
+		assert.Equal(t, &mobileID, entry.AssetID)
+	}
+
+	flag := "356000000000011 reported stolen"
+	require.NoError(t, repo.SetIMEIFlag(ctx, mobileID, &flag))
+	assets, err := repo.SearchAssetsWithFilter(ctx, models.AssetFilter{
+		IsSearchText: true, SearchText: "SEED-MOB-0001", Status: append(allStatuses, "stolen"), OwnedBy: allOwners, Type: allTypes, Limit: 10,
+		Scope: models.DepartmentScope{AllDepartments: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, assets, 1)
+	assert.Equal(t, flag, assets[0].Config.(models.Mobile_config_res).IMEIFlag)
+}
+
+func TestWarrantyLookup(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+	assetID := seed.ID("asset:laptop-1")
+
+	_, err := repo.GetWarrantyLookup(ctx, assetID)
+	assert.ErrorIs(t, err, ErrWarrantyLookupNotFound)
+
+	info := models.WarrantyInfo{
+		Vendor: "dell", WarrantyStart: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), WarrantyExpire: time.Date(2027, 1, 15, 0, 0, 0, 0, time.UTC),
+		Response: []byte(`[{"serviceTag": "SEED-LAP-0001"}]`),
+	}
+	require.NoError(t, repo.SaveWarrantyLookup(ctx, assetID, info))
+	lookup, err := repo.GetWarrantyLookup(ctx, assetID)
+	require.NoError(t, err)
+	assert.Equal(t, "dell", lookup.Vendor)
+	assert.True(t, info.WarrantyExpire.Equal(lookup.WarrantyExpire))
+	assert.JSONEq(t, string(info.Response), string(lookup.Response))
+
+	ctrl := gomock.NewController(t)
+	vendors := providers.NewMockWarrantyProvider(ctrl)
+	svc := &assetService{repo: repo}
+	_, err = svc.LookupWarranty(ctx, "Dell", "SEED-LAP-0001")
+	assert.ErrorIs(t, err, ErrWarrantyLookupOff)
+	svc.warranty = vendors
+	vendors.EXPECT().Lookup(gomock.Any(), "HP", "5CD1234").Return(models.WarrantyInfo{}, models.ErrWarrantyVendorUnsupported)
+	_, err = svc.LookupWarranty(ctx, "HP", "5CD1234")
+	assert.ErrorIs(t, err, ErrWarrantyVendor)
+	vendors.EXPECT().Lookup(gomock.Any(), "Dell", "NOPE").Return(models.WarrantyInfo{}, models.ErrWarrantyNotFound)
+	_, err = svc.LookupWarranty(ctx, "Dell", "NOPE")
+	assert.ErrorIs(t, err, ErrWarrantyNotFound)
+}
+
+// one asset's whole life comes back in order, returns and ended services without an actor
+func TestGetInventoryChanges(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	admin, manager, developer := seed.ID("user:admin"), seed.ID("user:asset-manager"), seed.ID("user:developer")
+
+	var assetID uuid.UUID
+	require.NoError(t, db.Get(&assetID, `
+		INSERT INTO assets (brand, model, serial_no, type, added_at, added_by)
+		VALUES ('Lenovo', 'T14', 'CHANGES-1', 'laptop', $1, $2) RETURNING id`, day(1), manager))
+	_, err := db.Exec(`
+		INSERT INTO asset_assign (asset_id, employee_id, assigned_at, returned_at, return_reason, assigned_by)
+		VALUES ($1, $2, $3, $4, 'upgrade', $5)`, assetID, developer, day(3), day(6), admin)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO asset_service (asset_id, service_start, service_end, reason, created_by) VALUES ($1, $2, $3, 'screen', $4)`,
+		assetID, day(8), day(9), manager)
+	require.NoError(t, err)
+	_, err = db.Exec(`
+		INSERT INTO domain_events (id, type, aggregate_type, aggregate_id, actor_id, payload, occurred_at)
+		VALUES ($1, 'asset.updated', 'asset', $2, $3, '{"data": {"status": "stolen"}}', $4)`, uuid.New(), assetID, developer, day(10))
+	require.NoError(t, err)
+
+	filter := models.InventoryChangeFilter{AssetID: &assetID, Scope: models.DepartmentScope{AllDepartments: true}, Limit: 100}
+	changes, err := repo.GetInventoryChanges(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, changes, 6)
+	types := make([]string, 0, len(changes))
+	for _, change := range changes {
+		types = append(types, change.EventType)
+		assert.Equal(t, "CHANGES-1", change.SerialNo)
+	}
+	assert.Equal(t, []string{"created", "assigned", "returned", "service_started", "service_ended", "updated"}, types)
+	assert.Equal(t, &manager, changes[0].ActorID)
+	assert.Equal(t, &admin, changes[1].ActorID)
+	require.NotNil(t, changes[1].EmployeeName)
+	assert.Equal(t, "Dev Sharma", *changes[1].EmployeeName)
+	assert.Nil(t, changes[2].ActorID)
+	assert.Equal(t, "upgrade", changes[2].Details)
+	assert.Nil(t, changes[4].ActorID)
+	assert.Equal(t, "stolen", changes[5].Details)
+
+	from, to := day(3), day(8)
+	filter.From, filter.To = &from, &to
+	changes, err = repo.GetInventoryChanges(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "assigned", changes[0].EventType)
+	assert.Equal(t, "returned", changes[1].EventType)
+
+	filter.From, filter.To, filter.Type = nil, nil, models.InventoryChangeServiceStarted
+	changes, err = repo.GetInventoryChanges(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	assert.Equal(t, day(8), changes[0].OccurredAt.UTC())
+
+	operations := seed.ID("department:operations")
+	filter.Type, filter.Scope = "", models.DepartmentScope{DepartmentID: &operations}
+	changes, err = repo.GetInventoryChanges(ctx, filter)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+// an unacknowledged assignment is pending until it was reminded of enough times or acknowledged
+func TestPendingAcknowledgments(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+	developer := seed.ID("user:developer")
+
+	var assetID, assignmentID uuid.UUID
+	require.NoError(t, db.Get(&assetID, `
+		INSERT INTO assets (brand, model, serial_no, type, status) VALUES ('Dell', 'P2422', 'ACK-1', 'monitor', 'assigned') RETURNING id`))
+	require.NoError(t, db.Get(&assignmentID, `
+		INSERT INTO asset_assign (asset_id, employee_id, assigned_at) VALUES ($1, $2, now() - INTERVAL '2 days') RETURNING id`, assetID, developer))
+
+	pendingIDs := func(days int) []uuid.UUID {
+		pending, err := repo.GetPendingAcknowledgments(ctx, days, 3)
+		require.NoError(t, err)
+		ids := make([]uuid.UUID, 0, len(pending))
+		for _, p := range pending {
+			ids = append(ids, p.ID)
+		}
+		return ids
+	}
+	assert.Contains(t, pendingIDs(1), assignmentID)
+	assert.NotContains(t, pendingIDs(3), assignmentID)
+
+	require.NoError(t, repo.MarkAcknowledgmentReminded(ctx, assignmentID, 3))
+	assert.NotContains(t, pendingIDs(1), assignmentID)
+	require.NoError(t, repo.MarkAcknowledgmentReminded(ctx, assignmentID, 1))
+	assert.Contains(t, pendingIDs(1), assignmentID)
+
+	err := repo.AcknowledgeAssignment(ctx, assetID, seed.ID("user:designer"))
+	assert.ErrorIs(t, err, ErrAssignmentNotFound)
+	require.NoError(t, repo.AcknowledgeAssignment(ctx, assetID, developer))
+	require.NoError(t, repo.AcknowledgeAssignment(ctx, assetID, developer))
+	assert.NotContains(t, pendingIDs(1), assignmentID)
+}
+
+// a damaged return is stored against the closed assignment, photos have to be uploaded files of the asset
+func TestReturnInspection(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+	developer, manager := seed.ID("user:developer"), seed.ID("user:asset-manager")
+
+	var assetID, photoID, pendingID uuid.UUID
+	require.NoError(t, db.Get(&assetID, `
+		INSERT INTO assets (brand, model, serial_no, type) VALUES ('Dell', 'Latitude', 'INSPECT-1', 'laptop') RETURNING id`))
+	require.NoError(t, db.Get(&photoID, `
+		INSERT INTO asset_attachments (asset_id, storage_key, filename, content_type, size_bytes, status, uploaded_by)
+		VALUES ($1, 'inspect-1/screen.jpg', 'screen.jpg', 'image/jpeg', 10, 'uploaded', $2) RETURNING id`, assetID, manager))
+	require.NoError(t, db.Get(&pendingID, `
+		INSERT INTO asset_attachments (asset_id, storage_key, filename, content_type, size_bytes, uploaded_by)
+		VALUES ($1, 'inspect-1/lid.jpg', 'lid.jpg', 'image/jpeg', 10, $2) RETURNING id`, assetID, manager))
+	require.NoError(t, repo.AssignAssetByID(ctx, assetID, developer, manager, nil, nil))
+	require.NoError(t, repo.RetrieveAsset(ctx, assetID, developer, "leaving"))
+
+	inspection := models.ReturnInspection{
+		Checklist:       models.InspectionChecklist{{Item: "screen", Damaged: true, Note: "cracked"}, {Item: "charger", Passed: true}},
+		ConditionRating: 2,
+		DamageNotes:     "cracked screen",
+		PhotoIDs:        []uuid.UUID{photoID, pendingID},
+	}
+	err := repo.InsertReturnInspection(ctx, assetID, developer, inspection, nil, manager)
+	assert.ErrorIs(t, err, ErrInspectionPhotos)
+
+	inspection.PhotoIDs = []uuid.UUID{photoID, photoID}
+	require.NoError(t, repo.InsertReturnInspection(ctx, assetID, developer, inspection, nil, manager))
+	require.NoError(t, repo.MarkAssetDamaged(ctx, assetID))
+
+	var status string
+	require.NoError(t, db.Get(&status, `SELECT status FROM assets WHERE id = $1`, assetID))
+	assert.Equal(t, "damaged", status)
+
+	inspections, err := repo.GetReturnInspections(ctx, assetID)
+	require.NoError(t, err)
+	require.Len(t, inspections, 1)
+	assert.True(t, inspections[0].Damaged)
+	assert.Equal(t, 2, inspections[0].ConditionRating)
+	assert.Equal(t, inspection.Checklist, inspections[0].Checklist)
+	assert.Equal(t, []uuid.UUID{photoID}, inspections[0].PhotoIDs)
+	assert.Equal(t, "Dev Sharma", inspections[0].EmployeeName)
+	assert.Equal(t, manager, inspections[0].InspectedBy)
+}
+
+func TestDisposeAsset(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+	developer, manager := seed.ID("user:developer"), seed.ID("user:asset-manager")
+
+	var assetID, otherID, invoiceID uuid.UUID
+	require.NoError(t, db.Get(&assetID, `
+		INSERT INTO assets (brand, model, serial_no, type) VALUES ('Dell', 'Latitude', 'DISPOSE-1', 'laptop') RETURNING id`))
+	require.NoError(t, db.Get(&otherID, `
+		INSERT INTO assets (brand, model, serial_no, type) VALUES ('Dell', 'Latitude', 'DISPOSE-2', 'laptop') RETURNING id`))
+	require.NoError(t, db.Get(&invoiceID, `
+		INSERT INTO asset_attachments (asset_id, storage_key, filename, content_type, size_bytes, status, uploaded_by)
+		VALUES ($1, 'dispose-1/invoice.pdf', 'invoice.pdf', 'application/pdf', 10, 'uploaded', $2) RETURNING id`, assetID, manager))
+	require.NoError(t, repo.AssignAssetByID(ctx, assetID, developer, manager, nil, nil))
+
+	req := models.DisposeAssetReq{AssetID: assetID, Kind: "buyback", Buyer: "Dev Sharma", Price: 25000.5, DisposedOn: "2026-10-01", InvoiceID: &invoiceID}
+	_, err := repo.DisposeAsset(ctx, req, manager)
+	assert.ErrorIs(t, err, ErrAssetAssigned)
+	require.NoError(t, repo.RetrieveAsset(ctx, assetID, developer, "bought back"))
+
+	_, err = repo.DisposeAsset(ctx, models.DisposeAssetReq{AssetID: otherID, Kind: "sale", Buyer: "Recycler", DisposedOn: "2026-10-01", InvoiceID: &invoiceID}, manager)
+	assert.ErrorIs(t, err, ErrDisposalInvoice)
+
+	disposal, err := repo.DisposeAsset(ctx, req, manager)
+	require.NoError(t, err)
+	assert.Equal(t, 25000.5, disposal.Price)
+	assert.Equal(t, "2026-10-01", disposal.DisposedOn.Format(time.DateOnly))
+
+	var archivedBy *uuid.UUID
+	require.NoError(t, db.Get(&archivedBy, `SELECT archived_by FROM assets WHERE id = $1`, assetID))
+	assert.Equal(t, &manager, archivedBy)
+
+	_, err = repo.DisposeAsset(ctx, req, manager)
+	assert.ErrorIs(t, err, ErrAssetNotFound, "an archived asset can't be disposed of twice")
+
+	got, err := repo.GetAssetDisposal(ctx, assetID)
+	require.NoError(t, err)
+	assert.Equal(t, disposal.ID, got.ID)
+	assert.Equal(t, &invoiceID, got.InvoiceID)
+
+	_, err = repo.GetAssetDisposal(ctx, otherID)
+	assert.ErrorIs(t, err, ErrDisposalNotFound)
+}
+
+// a loan past its end is overdue until returned and flagged once, permanent assignments never are
+func TestOverdueLoans(t *testing.T) {
+	db := testdb.Seeded(t)
+	repo := NewAssetRepository(db, testdb.Redis(t), nil)
+	ctx := context.Background()
+	developer, designer, manager := seed.ID("user:developer"), seed.ID("user:designer"), seed.ID("user:asset-manager")
+	all := models.DepartmentScope{AllDepartments: true}
+
+	var loanedID, dueID, permanentID uuid.UUID
+	for serial, id := range map[string]*uuid.UUID{"LOAN-1": &loanedID, "LOAN-2": &dueID, "LOAN-3": &permanentID} {
+		require.NoError(t, db.Get(id, `INSERT INTO assets (brand, model, serial_no, type) VALUES ('Dell', 'Latitude', $1, 'laptop') RETURNING id`, serial))
+	}
+	later := time.Now().Add(48 * time.Hour)
+	require.NoError(t, repo.AssignAssetByID(ctx, loanedID, developer, manager, nil, &later))
+	require.NoError(t, repo.AssignAssetByID(ctx, dueID, designer, manager, nil, &later))
+	require.NoError(t, repo.AssignAssetByID(ctx, permanentID, developer, manager, nil, nil))
+	_, err := db.Exec(`UPDATE asset_assign SET loan_until = now() - INTERVAL '1 hour' WHERE asset_id = $1`, dueID)
+	require.NoError(t, err)
+
+	loans, err := repo.GetLoans(ctx, models.LoanFilter{Scope: all, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, loans, 2)
+	assert.Equal(t, dueID, loans[0].AssetID, "the loan due first comes first")
+	assert.True(t, loans[0].Overdue)
+	assert.False(t, loans[1].Overdue)
+
+	overdue, err := repo.GetOverdueLoans(ctx)
+	require.NoError(t, err)
+	require.Len(t, overdue, 1)
+	assert.Equal(t, "Diya Kapoor", overdue[0].EmployeeName)
+	require.NoError(t, repo.MarkLoanOverdue(ctx, overdue[0].AssignmentID))
+	overdue, err = repo.GetOverdueLoans(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, overdue)
+
+	loans, err = repo.GetLoans(ctx, models.LoanFilter{Overdue: true, Scope: all, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, loans, 1)
+	assert.NotNil(t, loans[0].FlaggedAt)
+
+	require.NoError(t, repo.RetrieveAsset(ctx, dueID, designer, "loan over"))
+	loans, err = repo.GetLoans(ctx, models.LoanFilter{Overdue: true, Scope: all, Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, loans)
+
+	timeline, err := repo.GetAssetTimeline(ctx, loanedID)
+	require.NoError(t, err)
+	require.Len(t, timeline, 1)
+	assert.Contains(t, timeline[0].Details, "Loaned to employee until ")
+}
@@ -2,203 +2,1766 @@ package assetservice
 
 import (
 	"asset/models"
+	"asset/providers"
+	"asset/services/event"
+	"asset/services/notification"
+	"asset/utils"
 	"context"
+	"database/sql"
 	"encoding/json"
-
 	"errors"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+	"net/http"
 )
 
 type AssetService interface {
-	AddAssetWithConfig(ctx context.Context, req models.AddAssetWithConfigReq, userID uuid.UUID) error
-	AssignAsset(ctx context.Context, assetID, userID, managerUUID uuid.UUID) error
-	DeleteAsset(ctx context.Context, assetID uuid.UUID) error
+	AddAssetWithConfig(ctx context.Context, req models.AddAssetWithConfigReq, userID uuid.UUID, scope models.DepartmentScope) error
+	// AssignAsset assigns for good when loanUntil is nil, a loan has to end in the future
+	AssignAsset(ctx context.Context, assetID, userID, managerUUID uuid.UUID, metadata *models.AssignmentMetadata, loanUntil *time.Time, scope models.DepartmentScope) error
+	DeleteAsset(ctx context.Context, assetID, actorID uuid.UUID, scope models.DepartmentScope) error
+	DisposeAsset(ctx context.Context, req models.DisposeAssetReq, disposedBy uuid.UUID, scope models.DepartmentScope) (models.AssetDisposal, error)
+	GetAssetDisposal(ctx context.Context, assetID uuid.UUID, scope models.DepartmentScope) (models.AssetDisposal, error)
 	GetAllAssetsWithFilters(ctx context.Context, filter models.AssetFilter) ([]models.AssetWithConfigRes, error)
-	GetAssetTimeline(ctx context.Context, assetID uuid.UUID) ([]models.AssetTimelineEvent, error)
-	ReceiveAssetFromService(ctx context.Context, assetID uuid.UUID) error
-	RetrieveAsset(ctx context.Context, req models.AssetReturnReq) error
-	SendAssetToService(ctx context.Context, req models.AssetServiceReq, managerID uuid.UUID) error
-	UpdateAsset(ctx context.Context, req models.UpdateAssetReq) error
-	UpdateAssetWithConfig(ctx context.Context, req models.UpdateAssetReq) error
+	GetAssetTimeline(ctx context.Context, assetID uuid.UUID, scope models.DepartmentScope) ([]models.AssetTimelineEvent, error)
+	ReceiveAssetFromService(ctx context.Context, assetID uuid.UUID, cost *float64, scope models.DepartmentScope) error
+	RetrieveAsset(ctx context.Context, req models.AssetReturnReq, scope models.DepartmentScope) error
+	SendAssetToService(ctx context.Context, req models.AssetServiceReq, managerID uuid.UUID, scope models.DepartmentScope) error
+	UpdateAsset(ctx context.Context, req models.UpdateAssetReq, scope models.DepartmentScope) error
+	UpdateAssetWithConfig(ctx context.Context, req models.UpdateAssetReq, scope models.DepartmentScope) error
+	GetReturnRequests(ctx context.Context, filter models.ReturnRequestFilter) ([]models.ReturnRequestRes, error)
+	SendWarrantyAlerts(ctx context.Context, days int) error
+	NotifyOverdueReturns(ctx context.Context, days int) error
+	GetLoans(ctx context.Context, filter models.LoanFilter) ([]models.LoanRes, error)
+	FlagOverdueLoans(ctx context.Context) error
+	ReassignAssets(ctx context.Context, fromID, toID, managerID uuid.UUID) ([]uuid.UUID, error)
+	ExportAssets(ctx context.Context, filter models.AssetFilter) ([][]string, error)
+	StreamAssets(ctx context.Context, filter models.AssetFilter, fn func(models.AssetWithConfigRes) error) error
+	ArchiveHistory(ctx context.Context, months int) error
+	CheckLowStock(ctx context.Context, thresholds map[string]int) error
+	SyncServiceTickets(ctx context.Context) error
+	ApplyTicketWebhook(ctx context.Context, token string, body []byte) error
+	SyncMDMDevices(ctx context.Context, cfg models.MDMConfig) error
+	GetMDMMismatches(ctx context.Context, filter models.MDMMismatchFilter) ([]models.MDMMismatch, error)
+	GetDuplicateAssets(ctx context.Context, filter models.DuplicateFilter) ([]models.DuplicateGroup, error)
+	MergeAssets(ctx context.Context, req models.MergeAssetsReq, mergedBy uuid.UUID, scope models.DepartmentScope) (models.MergeAssetsRes, error)
+	GetUtilization(ctx context.Context, filter models.UtilizationFilter) (models.UtilizationRes, error)
+	GetAssignmentChurn(ctx context.Context, filter models.ChurnFilter) (models.ChurnRes, error)
+	GetTrends(ctx context.Context, filter models.TrendFilter) (models.TrendRes, error)
+	GetServiceReport(ctx context.Context, filter models.ServiceReportFilter) (models.ServiceReportRes, error)
+	GetTelecomCostReport(ctx context.Context, filter models.TelecomCostFilter) (models.TelecomCostReportRes, error)
+	GetInventoryChanges(ctx context.Context, filter models.InventoryChangeFilter) ([]models.InventoryChange, error)
+	ReportStolen(ctx context.Context, req models.ReportStolenReq, employeeID uuid.UUID) error
+	GetReturnInspections(ctx context.Context, assetID uuid.UUID, scope models.DepartmentScope) ([]models.ReturnInspectionRes, error)
+	AcknowledgeAssignment(ctx context.Context, req models.AcknowledgeAssignmentReq, employeeID uuid.UUID) error
+	SendAcknowledgmentReminders(ctx context.Context, cadence []int) error
+	CreateAttachmentUpload(ctx context.Context, req models.AttachmentUploadReq, uploadedBy uuid.UUID, scope models.DepartmentScope) (models.AttachmentUploadRes, error)
+	ConfirmAttachment(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) (models.Attachment, error)
+	GetAttachments(ctx context.Context, assetID uuid.UUID, scope models.DepartmentScope) ([]models.Attachment, error)
+	DeleteAttachment(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) error
+	PurgePendingAttachments(ctx context.Context, olderThan time.Duration) error
+	KioskCheckout(ctx context.Context, req models.KioskScanReq, kioskOwnerID uuid.UUID, scope models.DepartmentScope) (models.KioskRes, error)
+	KioskReturn(ctx context.Context, req models.KioskScanReq, scope models.DepartmentScope) (models.KioskRes, error)
+	ListIMEIBlacklist(ctx context.Context, filter models.IMEIBlacklistFilter) ([]models.BlacklistedIMEI, error)
+	BlacklistIMEI(ctx context.Context, req models.BlacklistIMEIReq, addedBy uuid.UUID) error
+	RemoveBlacklistedIMEI(ctx context.Context, imei string) error
+	LookupWarranty(ctx context.Context, brand, serialNo string) (models.WarrantyInfo, error)
+	GetWarrantyLookup(ctx context.Context, assetID uuid.UUID, scope models.DepartmentScope) (models.WarrantyLookup, error)
 }
 
+var (
+	ErrAssetAlreadyAssigned     = models.NewServiceError(http.StatusConflict, "asset_already_assigned", "asset already assigned")
+	ErrAssetAssigned            = models.NewServiceError(http.StatusConflict, "asset_assigned", "asset currently assigned to a user")
+	ErrAssignmentNotFound       = models.NewServiceError(http.StatusNotFound, "assignment_not_found", "no matching asset assignment found or already returned")
+	ErrAssetNotInService        = models.NewServiceError(http.StatusConflict, "asset_not_in_service", "asset is not currently under service")
+	ErrAssetInService           = models.NewServiceError(http.StatusConflict, "asset_in_service", "asset is already under service")
+	ErrAssetNotServiceable      = models.NewServiceError(http.StatusConflict, "asset_not_serviceable", "only assets with status 'available' or 'waiting_for_service' can be sent for service")
+	ErrUnsupportedAssetType     = models.NewServiceError(http.StatusBadRequest, "unsupported_asset_type", "unsupported asset type")
+	ErrTicketingOff             = models.NewServiceError(http.StatusNotFound, "ticketing_off", "service desk tickets are not configured")
+	ErrAssetNotFound            = models.NewServiceError(http.StatusNotFound, "asset_not_found", "asset not found")
+	ErrAttachmentsOff           = models.NewServiceError(http.StatusNotFound, "attachments_off", "object storage for attachments is not configured")
+	ErrAttachmentNotFound       = models.NewServiceError(http.StatusNotFound, "attachment_not_found", "attachment not found")
+	ErrAttachmentTooLarge       = models.NewServiceError(http.StatusRequestEntityTooLarge, "attachment_too_large", "the file is larger than attachments may be")
+	ErrAttachmentMissing        = models.NewServiceError(http.StatusConflict, "attachment_not_uploaded", "nothing was uploaded for the attachment yet")
+	ErrUtilizationWindow        = models.NewServiceError(http.StatusBadRequest, "invalid_utilization_window", "from must be before to and the window at most three years")
+	ErrChurnWindow              = models.NewServiceError(http.StatusBadRequest, "invalid_churn_window", "from must be before to and the window at most three years")
+	ErrServiceReportWindow      = models.NewServiceError(http.StatusBadRequest, "invalid_service_report_window", "from must be before to and the window at most three years")
+	ErrTrendWindow              = models.NewServiceError(http.StatusBadRequest, "invalid_trend_window", "from must be before to and the window at most three years")
+	ErrTrendInterval            = models.NewServiceError(http.StatusBadRequest, "invalid_trend_interval", "interval must be day or week")
+	ErrBadgeNotFound            = models.NewServiceError(http.StatusNotFound, "badge_not_found", "no employee has this badge")
+	ErrNotKioskAsset            = models.NewServiceError(http.StatusConflict, "not_kiosk_asset", "asset is not pool equipment that can be handled at a kiosk")
+	ErrAssetNotAvailable        = models.NewServiceError(http.StatusConflict, "asset_not_available", "asset is not available to check out")
+	ErrMergeSameAsset           = models.NewServiceError(http.StatusBadRequest, "merge_same_asset", "an asset can't be merged into itself")
+	ErrMergeBothAssigned        = models.NewServiceError(http.StatusConflict, "merge_both_assigned", "both assets are assigned, return one of them before merging")
+	ErrMergeBothInService       = models.NewServiceError(http.StatusConflict, "merge_both_in_service", "both assets are in service, receive one of them before merging")
+	ErrMergeAcrossOrganizations = models.NewServiceError(http.StatusConflict, "merge_across_organizations", "assets of different organizations can't be merged")
+	ErrSimMobileNotFound        = models.NewServiceError(http.StatusBadRequest, "sim_mobile_not_found", "mobile_asset_id must be a live mobile asset of the same organization")
+	ErrTelecomReportMonth       = models.NewServiceError(http.StatusBadRequest, "invalid_telecom_report_month", "month must be like 2026-01")
+	ErrInventoryChangeType      = models.NewServiceError(http.StatusBadRequest, "invalid_inventory_change_type", "type must be one of "+strings.Join(models.InventoryChangeTypes, ", "))
+	ErrInventoryChangeWindow    = models.NewServiceError(http.StatusBadRequest, "invalid_inventory_change_window", "from must be before to")
+	ErrInspectionPhotos         = models.NewServiceError(http.StatusBadRequest, "invalid_inspection_photos", "inspection photos must be uploaded attachments of the asset")
+	ErrDisposalInvoice          = models.NewServiceError(http.StatusBadRequest, "invalid_disposal_invoice", "invoice_id must be an uploaded attachment of the asset")
+	ErrDisposalDate             = models.NewServiceError(http.StatusBadRequest, "invalid_disposal_date", "disposed_on can't be in the future")
+	ErrDisposalNotFound         = models.NewServiceError(http.StatusNotFound, "disposal_not_found", "the asset wasn't sold or bought back")
+	ErrLoanEnd                  = models.NewServiceError(http.StatusBadRequest, "invalid_loan_end", "loan_until has to be in the future")
+	ErrIMEIAlreadyBlacklisted   = models.NewServiceError(http.StatusConflict, "imei_already_blacklisted", "imei is already on the blacklist")
+	ErrIMEINotBlacklisted       = models.NewServiceError(http.StatusNotFound, "imei_not_blacklisted", "imei is not on the blacklist")
+	ErrWarrantyRequired         = models.NewServiceError(http.StatusBadRequest, "warranty_required", "warranty and warranty_expire are required, the warranty couldn't be looked up by serial number")
+	ErrWarrantyLookupOff        = models.NewServiceError(http.StatusNotFound, "warranty_lookup_off", "no vendor warranty lookup is configured")
+	ErrWarrantyVendor           = models.NewServiceError(http.StatusBadRequest, "warranty_vendor_unsupported", "the warranty of this brand can't be looked up")
+	ErrWarrantyNotFound         = models.NewServiceError(http.StatusNotFound, "warranty_not_found", "the vendor doesn't know the serial number")
+	ErrWarrantyLookupNotFound   = models.NewServiceError(http.StatusNotFound, "warranty_lookup_not_found", "the warranty of the asset wasn't looked up")
+)
+
 type assetService struct {
-	repo AssetRepository
-	db   *sqlx.DB
+	repo     AssetRepository
+	db       *sqlx.DB
+	events   eventservice.EventService
+	notifier notificationservice.NotificationService
+	cache    providers.UserCacheProvider
+	// nil when no slack channel is routed, alerts then only reach the in app notifications
+	slack providers.SlackProvider
+	// nil when TICKET_SYSTEM is unset, services then get no service desk ticket
+	tickets providers.TicketProvider
+	// nil when MDM_SYSTEM is unset, assets are then only added by hand or import
+	mdm providers.MDMProvider
+	// nil when SMS_PROVIDER is unset, urgent alerts then skip the managers' phones
+	sms providers.SMSProvider
+	// nil when OBJECT_STORAGE_BACKEND is unset, assets then take no attachments
+	storage         providers.StorageProvider
+	attachmentLimit int64
+	// mode of the imei check of mobiles at intake, empty when it is off
+	imeiCheck string
+	// nil when IMEI_LOOKUP_URL is unset, only the internal blacklist is checked then
+	imeiLookup providers.IMEILookupProvider
+	// nil when WARRANTY_LOOKUP is unset, warranties then have to be entered
+	warranty providers.WarrantyProvider
+	logger   providers.ZapLoggerProvider
 }
 
-func NewAssetService(repo AssetRepository, db *sqlx.DB) AssetService {
-	return &assetService{repo: repo, db: db}
+func NewAssetService(repo AssetRepository, db *sqlx.DB, events eventservice.EventService, notifier notificationservice.NotificationService, cache providers.UserCacheProvider, slack providers.SlackProvider, tickets providers.TicketProvider, mdm providers.MDMProvider, sms providers.SMSProvider, storage providers.StorageProvider, attachmentLimit int64, imeiCheck string, imeiLookup providers.IMEILookupProvider, warranty providers.WarrantyProvider, logger providers.ZapLoggerProvider) AssetService {
+	return &assetService{repo: repo, db: db, events: events, notifier: notifier, cache: cache, slack: slack, tickets: tickets, mdm: mdm, sms: sms, storage: storage, attachmentLimit: attachmentLimit, imeiCheck: imeiCheck, imeiLookup: imeiLookup, warranty: warranty, logger: logger}
 }
 
-func (s *assetService) AddAssetWithConfig(ctx context.Context, req models.AddAssetWithConfigReq, addedBy uuid.UUID) (err error) {
-	tx, err := s.db.BeginTxx(ctx, nil)
+// alert posts text to the slack channels routed for event
+func (s *assetService) alert(event, text string) {
+	if s.slack != nil {
+		s.slack.Notify(event, text)
+	}
+}
+
+// emit reports a change of one asset, exec is the transaction of the change when it has one
+func (s *assetService) emit(ctx context.Context, eventType string, assetID uuid.UUID, actorID *uuid.UUID, data map[string]interface{}) error {
+	data["asset_id"] = assetID
+	return s.events.Emit(ctx, eventservice.DomainEvent{
+		Type:          eventType,
+		AggregateType: eventservice.AggregateAsset,
+		AggregateID:   assetID,
+		ActorID:       actorID,
+		Data:          data,
+	})
+}
+
+// checkAssetScope returns models.ErrOutOfScope when the asset is outside the caller's department or organization
+func (s *assetService) checkAssetScope(ctx context.Context, assetID uuid.UUID, scope models.DepartmentScope) error {
+	if scope.Unrestricted() {
+		return nil
+	}
+	inScope, err := s.repo.IsAssetInScope(ctx, assetID, scope)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return err
 	}
+	if !inScope {
+		return models.ErrOutOfScope
+	}
+	return nil
+}
 
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			panic(p)
-		} else if err != nil {
-			tx.Rollback()
-		} else {
-			err = tx.Commit()
+func (s *assetService) AddAssetWithConfig(ctx context.Context, req models.AddAssetWithConfigReq, addedBy uuid.UUID, scope models.DepartmentScope) error {
+	// scoped managers can only add assets to their own department
+	if !scope.AllDepartments {
+		req.DepartmentID = scope.DepartmentID
+	}
+
+	// the vendor is asked before the transaction, its api can be slow
+	var warranty *models.WarrantyInfo
+	if req.WarrantyStart.IsZero() || req.WarrantyExpire.IsZero() {
+		info, err := s.LookupWarranty(ctx, req.Brand, req.SerialNo)
+		if err != nil {
+			s.logger.GetLogger().Info("warranty not looked up", zap.String("serialNo", req.SerialNo), zap.Error(err))
+			return ErrWarrantyRequired
+		}
+		if req.WarrantyStart.IsZero() {
+			req.WarrantyStart = info.WarrantyStart
 		}
-	}()
+		if req.WarrantyExpire.IsZero() {
+			req.WarrantyExpire = info.WarrantyExpire
+		}
+		warranty = &info
+	}
+
+	return utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		assetID, err := s.addAssetWithConfig(ctx, req, &addedBy)
+		if err != nil || warranty == nil {
+			return err
+		}
+		return s.repo.SaveWarrantyLookup(ctx, assetID, *warranty)
+	})
+}
+
+// LookupWarranty asks the vendor of the brand for the warranty of a serial number
+func (s *assetService) LookupWarranty(ctx context.Context, brand, serialNo string) (models.WarrantyInfo, error) {
+	if s.warranty == nil {
+		return models.WarrantyInfo{}, ErrWarrantyLookupOff
+	}
+	info, err := s.warranty.Lookup(ctx, brand, serialNo)
+	switch {
+	case errors.Is(err, models.ErrWarrantyVendorUnsupported):
+		return info, ErrWarrantyVendor
+	case errors.Is(err, models.ErrWarrantyNotFound):
+		return info, ErrWarrantyNotFound
+	case err != nil:
+		return info, fmt.Errorf("failed to look up warranty: %w", err)
+	}
+	return info, nil
+}
+
+// GetWarrantyLookup returns what the vendor answered when the asset was added
+func (s *assetService) GetWarrantyLookup(ctx context.Context, assetID uuid.UUID, scope models.DepartmentScope) (models.WarrantyLookup, error) {
+	if err := s.checkAssetScope(ctx, assetID, scope); err != nil {
+		return models.WarrantyLookup{}, err
+	}
+	return s.repo.GetWarrantyLookup(ctx, assetID)
+}
 
-	assetID, err := s.repo.AddAsset(ctx, tx, req, addedBy)
+// addAssetWithConfig adds the asset and the configuration of its type, addedBy is nil for an asset
+// the mdm sync discovered
+func (s *assetService) addAssetWithConfig(ctx context.Context, req models.AddAssetWithConfigReq, addedBy *uuid.UUID) (uuid.UUID, error) {
+	// the days are the ones the caller picked in their own timezone
+	loc := utils.LocationFromContext(ctx)
+	req.PurchaseDate = utils.CalendarDate(req.PurchaseDate, loc)
+	req.WarrantyStart = utils.CalendarDate(req.WarrantyStart, loc)
+	req.WarrantyExpire = utils.CalendarDate(req.WarrantyExpire, loc)
+	if err := validateConfig(req.Type, req.Config); err != nil {
+		return uuid.Nil, err
+	}
+
+	assetID, err := s.repo.AddAsset(ctx, req, addedBy)
 	if err != nil {
-		return fmt.Errorf("failed to add asset: %w", err)
+		return uuid.Nil, fmt.Errorf("failed to add asset: %w", err)
 	}
 
 	switch req.Type {
 	case "laptop":
 		var cfg models.Laptop_config_req
 		if err = json.Unmarshal(req.Config, &cfg); err != nil {
-			return fmt.Errorf("invalid laptop config: %w", err)
+			return uuid.Nil, invalidConfig("laptop", err)
 		}
-		err = s.repo.AddLaptopConfig(ctx, tx, cfg, assetID)
+		err = s.repo.AddLaptopConfig(ctx, cfg, assetID)
 	case "mouse":
 		var cfg models.Mouse_config_req
 		if err = json.Unmarshal(req.Config, &cfg); err != nil {
-			return fmt.Errorf("invalid mouse config: %w", err)
+			return uuid.Nil, invalidConfig("mouse", err)
 		}
-		err = s.repo.AddMouseConfig(ctx, tx, cfg, assetID)
+		err = s.repo.AddMouseConfig(ctx, cfg, assetID)
 	case "monitor":
 		var cfg models.Monitor_config_req
 		if err = json.Unmarshal(req.Config, &cfg); err != nil {
-			return fmt.Errorf("invalid monitor config: %w", err)
+			return uuid.Nil, invalidConfig("monitor", err)
 		}
-		err = s.repo.AddMonitorConfig(ctx, tx, cfg, assetID)
+		err = s.repo.AddMonitorConfig(ctx, cfg, assetID)
 	case "hard_disk":
 		var cfg models.Hard_disk_config_req
 		if err = json.Unmarshal(req.Config, &cfg); err != nil {
-			return fmt.Errorf("invalid hard disk config: %w", err)
+			return uuid.Nil, invalidConfig("hard disk", err)
 		}
 
-		err = s.repo.AddHardDiskConfig(ctx, tx, cfg, assetID)
+		err = s.repo.AddHardDiskConfig(ctx, cfg, assetID)
 	case "pen_drive":
 		var cfg models.Pen_drive_config_req
 		if err = json.Unmarshal(req.Config, &cfg); err != nil {
-			return fmt.Errorf("invalid pen drive config: %w", err)
+			return uuid.Nil, invalidConfig("pen drive", err)
 		}
 
-		err = s.repo.AddPenDriveConfig(ctx, tx, cfg, assetID)
+		err = s.repo.AddPenDriveConfig(ctx, cfg, assetID)
 	case "mobile":
 		var cfg models.Mobile_config_req
 		if err = json.Unmarshal(req.Config, &cfg); err != nil {
-			return fmt.Errorf("invalid mobile config: %w", err)
+			return uuid.Nil, invalidConfig("mobile", err)
 		}
 
-		err = s.repo.AddMobileConfig(ctx, tx, cfg, assetID)
+		var flag string
+		if flag, err = s.checkIMEIs(ctx, cfg.IMEI1, cfg.IMEI2); err != nil {
+			return uuid.Nil, err
+		}
+		if err = s.repo.AddMobileConfig(ctx, cfg, assetID); err == nil && flag != "" {
+			err = s.flagMobile(ctx, assetID, flag)
+		}
 	case "sim":
 		var cfg models.Sim_config_req
 		if err = json.Unmarshal(req.Config, &cfg); err != nil {
-			return fmt.Errorf("invalid sim config: %w", err)
+			return uuid.Nil, invalidConfig("sim", err)
 		}
 
-		err = s.repo.AddSimConfig(ctx, tx, cfg, assetID)
+		err = s.repo.AddSimConfig(ctx, cfg, assetID)
 	case "accessory":
 		var cfg models.Accessories_config_req
 		if err = json.Unmarshal(req.Config, &cfg); err != nil {
-			return fmt.Errorf("invalid accessory config: %w", err)
+			return uuid.Nil, invalidConfig("accessory", err)
 		}
 
-		err = s.repo.AddAccessoryConfig(ctx, tx, cfg, assetID)
+		err = s.repo.AddAccessoryConfig(ctx, cfg, assetID)
 	default:
-		return errors.New("unsupported asset type")
+		return uuid.Nil, ErrUnsupportedAssetType
 	}
 
 	if err != nil {
-		return fmt.Errorf("failed to add asset configuration: %w", err)
+		return uuid.Nil, fmt.Errorf("failed to add asset configuration: %w", err)
+	}
+	return assetID, s.emit(ctx, eventservice.AssetCreated, assetID, addedBy, map[string]interface{}{
+		"brand":         req.Brand,
+		"model":         req.Model,
+		"serial_no":     req.SerialNo,
+		"type":          req.Type,
+		"owned_by":      req.OwnedBy,
+		"department_id": req.DepartmentID,
+	})
+}
+
+func (s *assetService) AssignAsset(ctx context.Context, assetID, employeeID, managerID uuid.UUID, metadata *models.AssignmentMetadata, loanUntil *time.Time, scope models.DepartmentScope) error {
+	if loanUntil != nil && !loanUntil.After(time.Now()) {
+		return ErrLoanEnd
+	}
+	if err := s.checkAssetScope(ctx, assetID, scope); err != nil {
+		return err
 	}
+	if !scope.Unrestricted() {
+		inScope, err := s.repo.IsEmployeeInScope(ctx, employeeID, scope)
+		if err != nil {
+			return err
+		}
+		if !inScope {
+			return models.ErrOutOfScope
+		}
+	}
+
+	err := utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		if err := s.repo.AssignAssetByID(ctx, assetID, employeeID, managerID, metadata, loanUntil); err != nil {
+			return fmt.Errorf("failed to assign asset: %w", err)
+		}
+		data := map[string]interface{}{"employee_id": employeeID, "assigned_by": managerID}
+		if metadata != nil {
+			data["metadata"] = metadata
+		}
+		if loanUntil != nil {
+			data["loan_until"] = loanUntil
+		}
+		return s.emit(ctx, eventservice.AssetAssigned, assetID, &managerID, data)
+	})
+	if err != nil {
+		return err
+	}
+	s.cache.InvalidateUsers(ctx, employeeID)
 	return nil
 }
 
-func (s *assetService) AssignAsset(ctx context.Context, assetID, employeeID, managerID uuid.UUID) (err error) {
+func (s *assetService) DeleteAsset(ctx context.Context, assetID, actorID uuid.UUID, scope models.DepartmentScope) error {
+	if err := s.checkAssetScope(ctx, assetID, scope); err != nil {
+		return err
+	}
+	employeeIDs, err := s.repo.GetAssetEmployeeIDs(ctx, assetID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteAssetByID(ctx, assetID, actorID); err != nil {
+		return err
+	}
+	s.cache.InvalidateUsers(ctx, employeeIDs...)
+	return s.emit(ctx, eventservice.AssetDeleted, assetID, nil, map[string]interface{}{})
+}
 
-	tx, err := s.db.BeginTxx(ctx, nil)
+// DisposeAsset retires an asset that was sold or bought back, the asset is archived with the
+// disposal and its price is booked as proceeds in the fixed asset journal
+func (s *assetService) DisposeAsset(ctx context.Context, req models.DisposeAssetReq, disposedBy uuid.UUID, scope models.DepartmentScope) (models.AssetDisposal, error) {
+	disposedOn, err := time.Parse(time.DateOnly, req.DisposedOn)
+	if err != nil || disposedOn.After(time.Now()) {
+		return models.AssetDisposal{}, ErrDisposalDate
+	}
+	if err := s.checkAssetScope(ctx, req.AssetID, scope); err != nil {
+		return models.AssetDisposal{}, err
+	}
+	employeeIDs, err := s.repo.GetAssetEmployeeIDs(ctx, req.AssetID)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return models.AssetDisposal{}, err
 	}
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			panic(p)
-		} else if err != nil {
-			tx.Rollback()
-		} else {
-			err = tx.Commit()
+	req.Price = math.Round(req.Price*100) / 100
+	disposal, err := s.repo.DisposeAsset(ctx, req, disposedBy)
+	if err != nil {
+		return models.AssetDisposal{}, err
+	}
+	s.cache.InvalidateUsers(ctx, employeeIDs...)
+	if err := s.emit(ctx, eventservice.AssetDeleted, req.AssetID, &disposedBy, map[string]interface{}{
+		"disposal_id": disposal.ID,
+		"kind":        disposal.Kind,
+		"price":       disposal.Price,
+	}); err != nil {
+		return models.AssetDisposal{}, err
+	}
+	disposal.CreatedAt = utils.InLocation(disposal.CreatedAt, utils.LocationFromContext(ctx))
+	return disposal, nil
+}
+
+func (s *assetService) GetAssetDisposal(ctx context.Context, assetID uuid.UUID, scope models.DepartmentScope) (models.AssetDisposal, error) {
+	if err := s.checkAssetScope(ctx, assetID, scope); err != nil {
+		return models.AssetDisposal{}, err
+	}
+	disposal, err := s.repo.GetAssetDisposal(ctx, assetID)
+	if err != nil {
+		return models.AssetDisposal{}, err
+	}
+	disposal.CreatedAt = utils.InLocation(disposal.CreatedAt, utils.LocationFromContext(ctx))
+	return disposal, nil
+}
+
+func (s *assetService) GetAllAssets(ctx context.Context, filter models.AssetFilter) ([]models.AssetWithConfigRes, error) {
+
+	return s.repo.SearchAssetsWithFilter(ctx, filter)
+}
+
+func (s *assetService) GetAssetTimeline(ctx context.Context, assetID uuid.UUID, scope models.DepartmentScope) ([]models.AssetTimelineEvent, error) {
+	if err := s.checkAssetScope(ctx, assetID, scope); err != nil {
+		return nil, err
+	}
+	timeline, err := s.repo.GetAssetTimeline(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+	loc := utils.LocationFromContext(ctx)
+	for i := range timeline {
+		timeline[i].StartTime = utils.InLocation(timeline[i].StartTime, loc)
+		if timeline[i].EndTime != nil {
+			end := utils.InLocation(*timeline[i].EndTime, loc)
+			timeline[i].EndTime = &end
+		}
+	}
+	return timeline, nil
+}
+
+func (s *assetService) ReceiveAssetFromService(ctx context.Context, assetID uuid.UUID, cost *float64, scope models.DepartmentScope) error {
+	if err := s.checkAssetScope(ctx, assetID, scope); err != nil {
+		return err
+	}
+	if err := s.repo.RecivedAssetFromService(ctx, assetID, cost); err != nil {
+		return err
+	}
+	return s.emit(ctx, eventservice.AssetServiced, assetID, nil, map[string]interface{}{"state": "received"})
+}
+
+// RetrieveAsset closes the employee's assignment. With an inspection a damaged asset is left damaged,
+// or sent to service when the inspection asks for it
+func (s *assetService) RetrieveAsset(ctx context.Context, req models.AssetReturnReq, scope models.DepartmentScope) error {
+	assetID, err := uuid.Parse(req.AssetID)
+	if err != nil {
+		return fmt.Errorf("invalid asset id: %w", err)
+	}
+	if err = s.checkAssetScope(ctx, assetID, scope); err != nil {
+		return err
+	}
+	if req.Inspection != nil && req.ReturnedBy == nil {
+		return errors.New("an inspected return needs who took the asset back")
+	}
+
+	employeeID := uuid.MustParse(req.EmployeeID)
+
+	var service *models.AssetServiceReq
+	var serviceID uuid.UUID
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		service = nil
+		if err := s.repo.RetrieveAsset(ctx, assetID, employeeID, req.ReturnReason); err != nil {
+			return fmt.Errorf("failed to retrieve asset: %w", err)
+		}
+		if err := s.emit(ctx, eventservice.AssetReturned, assetID, nil, map[string]interface{}{"employee_id": employeeID, "return_reason": req.ReturnReason}); err != nil {
+			return err
 		}
-	}()
+		if req.Inspection == nil {
+			return nil
+		}
+		inspection := *req.Inspection
+		var inspectionServiceID *uuid.UUID
+		switch {
+		case inspection.OpenService:
+			service = &models.AssetServiceReq{AssetID: assetID, Reason: inspection.DamageNotes}
+			if service.Reason == "" {
+				service.Reason = "inspected on return"
+			}
+			var err error
+			if serviceID, err = s.repo.SendAssetForService(ctx, *service, *req.ReturnedBy, s.tickets != nil); err != nil {
+				return err
+			}
+			inspectionServiceID = &serviceID
+			if err := s.emit(ctx, eventservice.AssetServiced, assetID, req.ReturnedBy, map[string]interface{}{"state": "sent_for_service", "reason": service.Reason, "sent_by": *req.ReturnedBy}); err != nil {
+				return err
+			}
+		case inspection.IsDamaged():
+			if err := s.repo.MarkAssetDamaged(ctx, assetID); err != nil {
+				return err
+			}
+		}
+		return s.repo.InsertReturnInspection(ctx, assetID, employeeID, inspection, inspectionServiceID, *req.ReturnedBy)
+	})
+	if err != nil {
+		return err
+	}
+	s.cache.InvalidateUsers(ctx, employeeID)
+	if service != nil {
+		s.announceService(ctx, *service, serviceID)
+	}
+	return nil
+}
+
+func (s *assetService) GetReturnInspections(ctx context.Context, assetID uuid.UUID, scope models.DepartmentScope) ([]models.ReturnInspectionRes, error) {
+	if err := s.checkAssetScope(ctx, assetID, scope); err != nil {
+		return nil, err
+	}
+	inspections, err := s.repo.GetReturnInspections(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+	loc := utils.LocationFromContext(ctx)
+	for i := range inspections {
+		inspections[i].CreatedAt = utils.InLocation(inspections[i].CreatedAt, loc)
+	}
+	return inspections, nil
+}
+
+func (s *assetService) SendAssetToService(ctx context.Context, req models.AssetServiceReq, managerID uuid.UUID, scope models.DepartmentScope) error {
+	if err := s.checkAssetScope(ctx, req.AssetID, scope); err != nil {
+		return err
+	}
 
-	err = s.repo.AssignAssetByID(ctx, tx, assetID, employeeID, managerID)
+	serviceID, err := s.repo.SendAssetForService(ctx, req, managerID, s.tickets != nil)
 	if err != nil {
-		return fmt.Errorf("failed to assign asset: %w", err)
+		return err
 	}
+	if err := s.emit(ctx, eventservice.AssetServiced, req.AssetID, &managerID, map[string]interface{}{"state": "sent_for_service", "reason": req.Reason, "sent_by": managerID}); err != nil {
+		return err
+	}
+	s.announceService(ctx, req, serviceID)
 	return nil
 }
 
-func (s *assetService) DeleteAsset(ctx context.Context, assetID uuid.UUID) error {
+// announceService alerts slack and opens the service desk ticket of a committed service, a failure is
+// retried by the sync job or only logged
+func (s *assetService) announceService(ctx context.Context, req models.AssetServiceReq, serviceID uuid.UUID) {
+	if s.slack == nil && s.tickets == nil {
+		return
+	}
+	asset, err := s.repo.GetAssetBrief(ctx, req.AssetID)
+	if err != nil {
+		s.logger.GetLogger().Warn("asset sent to service not announced", zap.String("assetID", req.AssetID.String()), zap.Error(err))
+		return
+	}
+	s.alert(models.SlackEventAssetService, fmt.Sprintf("%s %s (%s) was sent to service: %s", asset.Brand, asset.Model, asset.SerialNo, req.Reason))
+	if s.tickets != nil {
+		service := models.ServiceTicket{ID: serviceID, AssetID: asset.ID, Brand: asset.Brand, Model: asset.Model, SerialNo: asset.SerialNo, Reason: req.Reason}
+		if err := s.openTicket(ctx, service); err != nil {
+			s.logger.GetLogger().Warn("failed to open service ticket, the sync job retries", zap.String("serviceID", serviceID.String()), zap.Error(err))
+		}
+	}
+}
 
-	return s.repo.DeleteAssetByID(ctx, assetID)
+// openTicket opens the service desk ticket of a service. A ticket opened but not stored is opened
+// again by the next try
+func (s *assetService) openTicket(ctx context.Context, service models.ServiceTicket) error {
+	ticket, err := s.tickets.CreateTicket(ctx, models.TicketReq{
+		Summary:     fmt.Sprintf("Service %s %s (%s)", service.Brand, service.Model, service.SerialNo),
+		Description: fmt.Sprintf("%s\n\nasset id: %s\nservice id: %s", service.Reason, service.AssetID, service.ID),
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.repo.SetServiceTicket(ctx, service.ID, ticket); err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("service ticket opened", zap.String("serviceID", service.ID.String()), zap.String("ticket", ticket.Key))
+	return nil
 }
 
-func (s *assetService) GetAllAssets(ctx context.Context, filter models.AssetFilter) ([]models.AssetWithConfigRes, error) {
+func (s *assetService) UpdateAsset(ctx context.Context, req models.UpdateAssetReq, scope models.DepartmentScope) error {
+	return s.UpdateAssetWithConfig(ctx, req, scope)
+}
 
+func (s *assetService) UpdateAssetWithConfig(ctx context.Context, req models.UpdateAssetReq, scope models.DepartmentScope) error {
+	if err := s.checkAssetScope(ctx, req.ID, scope); err != nil {
+		return err
+	}
+	// moving an asset between departments is reserved for unscoped callers
+	if !scope.AllDepartments && req.DepartmentID != nil {
+		return models.ErrOutOfScope
+	}
+	if req.Config != nil && req.Type != "" {
+		if err := validateConfig(req.Type, req.Config); err != nil {
+			return err
+		}
+	}
+	loc := utils.LocationFromContext(ctx)
+	for _, date := range []*time.Time{req.PurchaseDate, req.WarrantyStart, req.WarrantyExpire} {
+		if date != nil {
+			*date = utils.CalendarDate(*date, loc)
+		}
+	}
+	// the imeis are looked up before the transaction, the external registry can be slow
+	checkIMEIs := req.Type == "mobile" && req.Config != nil && s.imeiCheck != ""
+	var flag string
+	if checkIMEIs {
+		var cfg models.Mobile_config_req
+		if err := json.Unmarshal(req.Config, &cfg); err != nil {
+			return invalidConfig("mobile", err)
+		}
+		var err error
+		if flag, err = s.checkIMEIs(ctx, cfg.IMEI1, cfg.IMEI2); err != nil {
+			return err
+		}
+	}
+	employeeIDs, err := s.repo.GetAssetEmployeeIDs(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		if err := s.repo.UpdateAssetWithConfig(ctx, req); err != nil {
+			return err
+		}
+		switch {
+		case flag != "":
+			return s.flagMobile(ctx, req.ID, flag)
+		case checkIMEIs:
+			// the imeis were corrected, the mobile is clean now
+			return s.repo.SetIMEIFlag(ctx, req.ID, nil)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.cache.InvalidateUsers(ctx, employeeIDs...)
+	return s.emit(ctx, eventservice.AssetUpdated, req.ID, nil, map[string]interface{}{"changes": req})
+}
+
+func (s *assetService) GetAllAssetsWithFilters(ctx context.Context, filter models.AssetFilter) ([]models.AssetWithConfigRes, error) {
 	return s.repo.SearchAssetsWithFilter(ctx, filter)
 }
 
-func (s *assetService) GetAssetTimeline(ctx context.Context, assetID uuid.UUID) ([]models.AssetTimelineEvent, error) {
-	return s.repo.GetAssetTimeline(ctx, assetID)
+// ReassignAssets hands every asset fromID holds over to toID in one transaction, each one is returned
+// and assigned again so both show in the asset's timeline. It returns the assets that moved
+func (s *assetService) ReassignAssets(ctx context.Context, fromID, toID, managerID uuid.UUID) ([]uuid.UUID, error) {
+	var assetIDs []uuid.UUID
+	err := utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		var err error
+		if assetIDs, err = s.repo.LockAssignedAssetIDs(ctx, fromID); err != nil {
+			return err
+		}
+		reason := "reassigned to " + toID.String()
+		for _, assetID := range assetIDs {
+			if err = s.repo.RetrieveAsset(ctx, assetID, fromID, reason); err != nil {
+				return fmt.Errorf("failed to return asset %s: %w", assetID, err)
+			}
+			if err = s.emit(ctx, eventservice.AssetReturned, assetID, &managerID, map[string]interface{}{"employee_id": fromID, "return_reason": reason}); err != nil {
+				return err
+			}
+			if err = s.repo.AssignAssetByID(ctx, assetID, toID, managerID, nil, nil); err != nil {
+				return fmt.Errorf("failed to assign asset %s: %w", assetID, err)
+			}
+			if err = s.emit(ctx, eventservice.AssetAssigned, assetID, &managerID, map[string]interface{}{"employee_id": toID, "assigned_by": managerID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.cache.InvalidateUsers(ctx, fromID, toID)
+	s.logger.GetLogger().Info("assets reassigned", zap.String("from", fromID.String()), zap.String("to", toID.String()), zap.Int("count", len(assetIDs)))
+	return assetIDs, nil
+}
+
+// column order of the asset export, it matches the columns an asset import reads
+var AssetExportHeader = []string{
+	"id", "brand", "model", "serial_no", "type", "owned_by", "status",
+	"purchase_date", "warranty_start", "warranty_expire", "department_id", "config",
+}
+
+// assets are read this many at a time for an export
+const exportPageSize = 500
+
+// ExportAssets pages through every asset matching filter, its Limit and Offset are ignored
+func (s *assetService) ExportAssets(ctx context.Context, filter models.AssetFilter) ([][]string, error) {
+	rows := [][]string{}
+	err := s.StreamAssets(ctx, filter, func(a models.AssetWithConfigRes) error {
+		config := ""
+		if a.Config != nil {
+			encoded, err := json.Marshal(a.Config)
+			if err != nil {
+				return fmt.Errorf("failed to encode config of asset %s: %w", a.ID, err)
+			}
+			config = string(encoded)
+		}
+		department := ""
+		if a.DepartmentID != nil {
+			department = *a.DepartmentID
+		}
+		rows = append(rows, []string{
+			a.ID, a.Brand, a.Model, a.SerialNo, a.Type, a.OwnedBy, a.Status,
+			a.PurchaseDate.Format(time.DateOnly), a.WarrantyStart.Format(time.DateOnly), a.WarrantyEnd.Format(time.DateOnly),
+			department, config,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// StreamAssets hands every asset matching filter to fn, reading exportPageSize at a time so only one
+// page is held. Its Limit and Offset are ignored, an error from fn stops it
+func (s *assetService) StreamAssets(ctx context.Context, filter models.AssetFilter, fn func(models.AssetWithConfigRes) error) error {
+	filter.Limit, filter.Offset = exportPageSize, 0
+	for {
+		assets, err := s.repo.SearchAssetsWithFilter(ctx, filter)
+		if err != nil {
+			return err
+		}
+		for _, a := range assets {
+			if err := fn(a); err != nil {
+				return err
+			}
+		}
+		if len(assets) < exportPageSize {
+			return nil
+		}
+		filter.Offset += exportPageSize
+	}
 }
 
-func (s *assetService) ReceiveAssetFromService(ctx context.Context, assetID uuid.UUID) error {
-	return s.repo.RecivedAssetFromService(ctx, assetID)
+func (s *assetService) GetReturnRequests(ctx context.Context, filter models.ReturnRequestFilter) ([]models.ReturnRequestRes, error) {
+	return s.repo.GetReturnRequests(ctx, filter)
 }
 
-func (s *assetService) RetrieveAsset(ctx context.Context, req models.AssetReturnReq) (err error) {
+// SendWarrantyAlerts is run by the background job, it tells the department's asset managers and the
+// admins about every asset whose warranty expires within days. Each asset is alerted on once, a
+// failure is logged and retried on the next run
+func (s *assetService) SendWarrantyAlerts(ctx context.Context, days int) error {
+	assets, err := s.repo.GetAssetsWarrantyExpiringWithin(ctx, days)
+	if err != nil {
+		return err
+	}
+	for _, asset := range assets {
+		if err := s.alertWarranty(ctx, asset); err != nil {
+			s.logger.GetLogger().Error("failed to send warranty alert", zap.String("assetID", asset.AssetID.String()), zap.Error(err))
+		}
+	}
+	return nil
+}
 
-	tx, err := s.db.BeginTxx(ctx, nil)
+func (s *assetService) alertWarranty(ctx context.Context, asset models.WarrantyAlertRes) error {
+	managerIDs, err := s.repo.GetAssetManagerIDs(ctx, asset.OrganizationID, asset.DepartmentID)
+	if err != nil {
+		return err
+	}
+	return s.notifyOnce(ctx, func(ctx context.Context) error {
+		if err := s.repo.MarkWarrantyAlerted(ctx, asset.AssetID); err != nil {
+			return err
+		}
+		return s.notifier.Notify(ctx, managerIDs, notificationservice.Notification{
+			Category:   notificationservice.CategoryWarranty,
+			Title:      "Warranty expiring soon",
+			Body:       fmt.Sprintf("the warranty of %s %s (%s) expires on %s", asset.Brand, asset.Model, asset.SerialNo, asset.WarrantyExpire.Format(time.DateOnly)),
+			EntityType: "asset",
+			EntityID:   asset.AssetID.String(),
+		})
+	})
+}
+
+// NotifyOverdueReturns is run by the background job, it reminds the employee, the department's asset
+// managers and the admins of return requests still open after days. Each request is reported once
+func (s *assetService) NotifyOverdueReturns(ctx context.Context, days int) error {
+	requests, err := s.repo.GetOverdueReturnRequests(ctx, days)
+	if err != nil {
+		return err
+	}
+	for _, request := range requests {
+		if err := s.notifyOverdueReturn(ctx, request); err != nil {
+			s.logger.GetLogger().Error("failed to notify overdue return", zap.String("requestID", request.ID.String()), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (s *assetService) notifyOverdueReturn(ctx context.Context, request models.OverdueReturnRes) error {
+	managerIDs, err := s.repo.GetAssetManagerIDs(ctx, request.OrganizationID, request.DepartmentID)
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf("%s has not returned %s %s (%s), requested on %s", request.EmployeeName, request.Brand, request.Model, request.SerialNo, request.CreatedAt.Format(time.DateOnly))
+	err = s.notifyOnce(ctx, func(ctx context.Context) error {
+		if err := s.repo.MarkReturnOverdueNotified(ctx, request.ID); err != nil {
+			return err
+		}
+		return s.notifier.Notify(ctx, append(managerIDs, request.EmployeeID), notificationservice.Notification{
+			Category:   notificationservice.CategoryOverdue,
+			Title:      "Asset return overdue",
+			Body:       body,
+			EntityType: "asset",
+			EntityID:   request.AssetID.String(),
+		})
+	})
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return err
 	}
+	s.alert(models.SlackEventOverdue, body)
+	return nil
+}
+
+func (s *assetService) GetLoans(ctx context.Context, filter models.LoanFilter) ([]models.LoanRes, error) {
+	loans, err := s.repo.GetLoans(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	loc := utils.LocationFromContext(ctx)
+	for i := range loans {
+		loans[i].AssignedAt = utils.InLocation(loans[i].AssignedAt, loc)
+		loans[i].LoanUntil = utils.InLocation(loans[i].LoanUntil, loc)
+		if loans[i].FlaggedAt != nil {
+			flagged := utils.InLocation(*loans[i].FlaggedAt, loc)
+			loans[i].FlaggedAt = &flagged
+		}
+	}
+	return loans, nil
+}
+
+// FlagOverdueLoans is run by the background job, a loan past its end is flagged once and its employee,
+// the department's asset managers and the admins are told. The asset stays assigned until it is
+// returned and inspected like any other
+func (s *assetService) FlagOverdueLoans(ctx context.Context) error {
+	loans, err := s.repo.GetOverdueLoans(ctx)
+	if err != nil {
+		return err
+	}
+	for _, loan := range loans {
+		if err := s.flagOverdueLoan(ctx, loan); err != nil {
+			s.logger.GetLogger().Error("failed to flag overdue loan", zap.String("assignmentID", loan.AssignmentID.String()), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (s *assetService) flagOverdueLoan(ctx context.Context, loan models.LoanRes) error {
+	managerIDs, err := s.repo.GetAssetManagerIDs(ctx, loan.OrganizationID, loan.DepartmentID)
+	if err != nil {
+		return err
+	}
+	body := fmt.Sprintf("%s has not returned %s %s (%s), loaned until %s", loan.EmployeeName, loan.Brand, loan.Model, loan.SerialNo, loan.LoanUntil.UTC().Format("2006-01-02 15:04 MST"))
+	err = s.notifyOnce(ctx, func(ctx context.Context) error {
+		if err := s.repo.MarkLoanOverdue(ctx, loan.AssignmentID); err != nil {
+			return err
+		}
+		return s.notifier.Notify(ctx, append(managerIDs, loan.EmployeeID), notificationservice.Notification{
+			Category:   notificationservice.CategoryOverdue,
+			Title:      "Loan overdue",
+			Body:       body,
+			EntityType: "asset",
+			EntityID:   loan.AssetID.String(),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	s.alert(models.SlackEventOverdue, body)
+	return nil
+}
+
+// AcknowledgeAssignment is called by the employee holding the asset once they received it, it stops the
+// reminders
+func (s *assetService) AcknowledgeAssignment(ctx context.Context, req models.AcknowledgeAssignmentReq, employeeID uuid.UUID) error {
+	if err := s.repo.AcknowledgeAssignment(ctx, req.AssetID, employeeID); err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("assignment acknowledged", zap.String("assetID", req.AssetID.String()), zap.String("employeeID", employeeID.String()))
+	return nil
+}
+
+// SendAcknowledgmentReminders is run by the background job. An employee who hasn't acknowledged an
+// assignment is reminded once per day of cadence that passed since it was made, a late run sends one
+// reminder for the days it missed. With the last reminder the employee managers of their department
+// and the admins are told as well
+func (s *assetService) SendAcknowledgmentReminders(ctx context.Context, cadence []int) error {
+	if len(cadence) == 0 {
+		return nil
+	}
+	pending, err := s.repo.GetPendingAcknowledgments(ctx, cadence[0], len(cadence))
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, assignment := range pending {
+		due := 0
+		for _, days := range cadence {
+			if !now.Before(assignment.AssignedAt.AddDate(0, 0, days)) {
+				due++
+			}
+		}
+		if due <= assignment.Reminders {
+			continue
+		}
+		if err := s.remindAcknowledgment(ctx, assignment, due, due == len(cadence)); err != nil {
+			s.logger.GetLogger().Error("failed to send acknowledgment reminder", zap.String("assignmentID", assignment.ID.String()), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (s *assetService) remindAcknowledgment(ctx context.Context, assignment models.PendingAcknowledgment, reminders int, last bool) error {
+	name := fmt.Sprintf("%s %s (%s)", assignment.Brand, assignment.Model, assignment.SerialNo)
+	var managerIDs []uuid.UUID
+	if last {
+		var err error
+		if managerIDs, err = s.repo.GetEmployeeManagerIDs(ctx, assignment.OrganizationID, assignment.DepartmentID); err != nil {
+			return err
+		}
+	}
+	return s.notifyOnce(ctx, func(ctx context.Context) error {
+		if err := s.repo.MarkAcknowledgmentReminded(ctx, assignment.ID, reminders); err != nil {
+			return err
+		}
+		err := s.notifier.Notify(ctx, []uuid.UUID{assignment.EmployeeID}, notificationservice.Notification{
+			Category:   notificationservice.CategoryAssignment,
+			Title:      "Please acknowledge your asset",
+			Body:       fmt.Sprintf("%s was assigned to you on %s, let us know you received it", name, assignment.AssignedAt.Format(time.DateOnly)),
+			EntityType: "asset",
+			EntityID:   assignment.AssetID.String(),
+		})
+		if err != nil || !last {
+			return err
+		}
+		return s.notifier.Notify(ctx, managerIDs, notificationservice.Notification{
+			Category:   notificationservice.CategoryAssignment,
+			Title:      "Asset not acknowledged",
+			Body:       fmt.Sprintf("%s hasn't acknowledged %s assigned on %s after %d reminders", assignment.EmployeeName, name, assignment.AssignedAt.Format(time.DateOnly), reminders),
+			EntityType: "asset",
+			EntityID:   assignment.AssetID.String(),
+		})
+	})
+}
+
+// CheckLowStock is run by the background job, it alerts asset managers on slack when the available
+// assets of a type drop below its threshold. A shortage is alerted on once, again only after the type
+// was back in stock
+func (s *assetService) CheckLowStock(ctx context.Context, thresholds map[string]int) error {
+	types := make([]string, 0, len(thresholds))
+	for assetType := range thresholds {
+		if !IsAssetTypeValid(assetType) {
+			s.logger.GetLogger().Warn("low stock threshold of an unknown asset type ignored", zap.String("type", assetType))
+			continue
+		}
+		types = append(types, assetType)
+	}
+	if len(types) == 0 {
+		return nil
+	}
+	levels, err := s.repo.GetStockLevels(ctx, types)
+	if err != nil {
+		return err
+	}
+	var restocked []string
+	for _, level := range levels {
+		threshold := thresholds[level.Type]
+		if level.Available >= threshold {
+			restocked = append(restocked, level.Type)
+			continue
+		}
+		alerted, err := s.repo.MarkLowStockAlerted(ctx, level)
+		if err != nil {
+			return err
+		}
+		if alerted {
+			s.alert(models.SlackEventLowStock, fmt.Sprintf("only %d %s assets are available, below the threshold of %d", level.Available, level.Type, threshold))
+		}
+	}
+	if len(restocked) == 0 {
+		return nil
+	}
+	return s.repo.ClearLowStockAlerts(ctx, restocked)
+}
+
+// services whose tickets are opened and synced per run of the sync job
+const (
+	ticketOpenBatchSize = 50
+	ticketSyncBatchSize = 100
+)
 
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			panic(p)
+// SyncServiceTickets is run by the background job, it opens the tickets that failed to open when
+// their service started and reads the status of unresolved ones from the service desk, for desks
+// whose webhooks can't reach this server
+func (s *assetService) SyncServiceTickets(ctx context.Context) error {
+	if s.tickets == nil {
+		return nil
+	}
+	awaiting, err := s.repo.GetServicesAwaitingTicket(ctx, ticketOpenBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, service := range awaiting {
+		if err := s.openTicket(ctx, service); err != nil {
+			s.logger.GetLogger().Warn("failed to open service ticket", zap.String("serviceID", service.ID.String()), zap.Error(err))
+		}
+	}
+
+	unresolved, err := s.repo.GetUnresolvedServiceTickets(ctx, ticketSyncBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, service := range unresolved {
+		ticket, err := s.tickets.GetTicket(ctx, *service.TicketKey)
+		if err != nil {
+			s.logger.GetLogger().Warn("failed to read service ticket", zap.String("ticket", *service.TicketKey), zap.Error(err))
+			continue
+		}
+		if _, err := s.repo.UpdateServiceTicket(ctx, ticket); err != nil {
+			return err
+		}
+		if ticket.Resolved {
+			s.logger.GetLogger().Info("service ticket resolved", zap.String("ticket", ticket.Key), zap.String("status", ticket.Status))
+		}
+	}
+	return nil
+}
+
+// ApplyTicketWebhook stores the status a service desk webhook reports. Webhooks fire for every ticket
+// of the project or table, one that belongs to no service is ignored
+func (s *assetService) ApplyTicketWebhook(ctx context.Context, token string, body []byte) error {
+	if s.tickets == nil {
+		return ErrTicketingOff
+	}
+	ticket, err := s.tickets.ParseWebhook(ctx, token, body)
+	if err != nil {
+		return err
+	}
+	updated, err := s.repo.UpdateServiceTicket(ctx, ticket)
+	if err != nil {
+		return err
+	}
+	if updated {
+		s.logger.GetLogger().Info("service ticket updated", zap.String("ticket", ticket.Key), zap.String("status", ticket.Status), zap.Bool("resolved", ticket.Resolved))
+	}
+	return nil
+}
+
+// mismatches listed in one slack alert, the rest are counted
+const mdmAlertListSize = 10
+
+// SyncMDMDevices is run by the background job, it matches the devices enrolled in the mdm to assets
+// by serial number. A device without an asset gets one, a device enrolled for someone other than
+// the asset's assignee is flagged, and what the mdm last saw is stored with the asset. A deleted
+// asset isn't brought back by its device
+func (s *assetService) SyncMDMDevices(ctx context.Context, cfg models.MDMConfig) error {
+	if s.mdm == nil {
+		return nil
+	}
+	devices, err := s.mdm.ListDevices(ctx)
+	if err != nil {
+		return err
+	}
+	var created, skipped int
+	var mismatches []string
+	for _, device := range devices {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		device.SerialNo = strings.TrimSpace(device.SerialNo)
+		if device.SerialNo == "" {
+			skipped++
+			continue
+		}
+		asset, err := s.repo.GetMDMAsset(ctx, device.SerialNo)
+		if errors.Is(err, sql.ErrNoRows) {
+			asset.ID, err = s.addMDMAsset(ctx, device, cfg.WarrantyMonths)
+			if err != nil {
+				s.logger.GetLogger().Warn("failed to add asset for mdm device", zap.String("serialNo", device.SerialNo), zap.Error(err))
+				skipped++
+				continue
+			}
+			if asset.ID == uuid.Nil {
+				skipped++
+				continue
+			}
+			created++
 		} else if err != nil {
-			tx.Rollback()
-		} else {
-			err = tx.Commit()
+			return err
+		}
+		if asset.Archived {
+			skipped++
+			continue
+		}
+
+		mismatch := device.UserEmail != "" && (asset.AssigneeEmail == nil || !strings.EqualFold(*asset.AssigneeEmail, device.UserEmail))
+		newMismatch, err := s.repo.SaveMDMStatus(ctx, asset.ID, cfg.System, device, mismatch)
+		if err != nil {
+			return err
+		}
+		if newMismatch {
+			assignee := "nobody"
+			if asset.AssigneeEmail != nil {
+				assignee = *asset.AssigneeEmail
+			}
+			mismatches = append(mismatches, fmt.Sprintf("%s %s (%s) is enrolled for %s but assigned to %s", device.Manufacturer, device.Model, device.SerialNo, device.UserEmail, assignee))
+		}
+	}
+	s.logger.GetLogger().Info("synced mdm devices", zap.String("system", cfg.System), zap.Int("devices", len(devices)), zap.Int("created", created), zap.Int("skipped", skipped), zap.Int("newMismatches", len(mismatches)))
+
+	if len(mismatches) > 0 {
+		text := strings.Join(mismatches[:min(len(mismatches), mdmAlertListSize)], "\n")
+		if len(mismatches) > mdmAlertListSize {
+			text += fmt.Sprintf("\nand %d more", len(mismatches)-mdmAlertListSize)
+		}
+		s.alert(models.SlackEventMDMMismatch, text)
+	}
+	return nil
+}
+
+// addMDMAsset adds an unassigned asset for a device no asset has, uuid.Nil when the device's os
+// doesn't tell which asset type it is. The enrollment date stands in for the purchase date
+func (s *assetService) addMDMAsset(ctx context.Context, device models.MDMDevice, warrantyMonths int) (uuid.UUID, error) {
+	assetType := mdmAssetType(device.OS)
+	if assetType == "" {
+		s.logger.GetLogger().Info("mdm device of an unknown os skipped", zap.String("serialNo", device.SerialNo), zap.String("os", device.OS))
+		return uuid.Nil, nil
+	}
+	os := strings.TrimSpace(device.OS + " " + device.OSVersion)
+	var config interface{} = models.Laptop_config_req{Os: os}
+	if assetType == "mobile" {
+		config = models.Mobile_config_req{Os: os}
+	}
+	rawConfig, err := json.Marshal(config)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	purchased := device.EnrolledAt
+	if purchased.IsZero() {
+		purchased = time.Now()
+	}
+	req := models.AddAssetWithConfigReq{
+		AssetReq: models.AssetReq{
+			Brand:          orUnknown(device.Manufacturer),
+			Model:          orUnknown(device.Model),
+			SerialNo:       device.SerialNo,
+			PurchaseDate:   purchased,
+			OwnedBy:        "remotestate",
+			Type:           assetType,
+			WarrantyStart:  purchased,
+			WarrantyExpire: purchased.AddDate(0, warrantyMonths, 0),
+		},
+		Config: rawConfig,
+	}
+	var assetID uuid.UUID
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		assetID, err = s.addAssetWithConfig(ctx, req, nil)
+		return err
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	s.logger.GetLogger().Info("added asset for mdm device", zap.String("assetID", assetID.String()), zap.String("serialNo", device.SerialNo), zap.String("type", assetType))
+	return assetID, nil
+}
+
+func (s *assetService) GetMDMMismatches(ctx context.Context, filter models.MDMMismatchFilter) ([]models.MDMMismatch, error) {
+	return s.repo.GetMDMMismatches(ctx, filter)
+}
+
+func (s *assetService) GetDuplicateAssets(ctx context.Context, filter models.DuplicateFilter) ([]models.DuplicateGroup, error) {
+	return s.repo.GetDuplicateAssets(ctx, filter)
+}
+
+// MergeAssets folds the duplicate's timeline, attachments and reports into the kept asset and
+// archives the duplicate, both have to be in the caller's scope
+func (s *assetService) MergeAssets(ctx context.Context, req models.MergeAssetsReq, mergedBy uuid.UUID, scope models.DepartmentScope) (models.MergeAssetsRes, error) {
+	if req.KeepID == req.DuplicateID {
+		return models.MergeAssetsRes{}, ErrMergeSameAsset
+	}
+	for _, assetID := range []uuid.UUID{req.KeepID, req.DuplicateID} {
+		if err := s.checkAssetScope(ctx, assetID, scope); err != nil {
+			return models.MergeAssetsRes{}, err
+		}
+	}
+
+	var moved map[string]int64
+	err := utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		var err error
+		if moved, err = s.repo.MergeAssets(ctx, req.KeepID, req.DuplicateID, mergedBy); err != nil {
+			return err
+		}
+		if err = s.emit(ctx, eventservice.AssetMerged, req.KeepID, &mergedBy, map[string]interface{}{"duplicate_id": req.DuplicateID, "moved": moved}); err != nil {
+			return err
+		}
+		return s.emit(ctx, eventservice.AssetDeleted, req.DuplicateID, &mergedBy, map[string]interface{}{"merged_into": req.KeepID})
+	})
+	if err != nil {
+		return models.MergeAssetsRes{}, err
+	}
+	// dashboards of everyone who held either asset show the kept one now
+	if employeeIDs, err := s.repo.GetAssetEmployeeIDs(ctx, req.KeepID); err == nil {
+		s.cache.InvalidateUsers(ctx, employeeIDs...)
+	}
+	s.logger.GetLogger().Info("assets merged", zap.String("keep", req.KeepID.String()), zap.String("duplicate", req.DuplicateID.String()), zap.Any("moved", moved))
+	return models.MergeAssetsRes{KeepID: req.KeepID, DuplicateID: req.DuplicateID, Moved: moved}, nil
+}
+
+const (
+	// the window utilization is measured over when the caller gives none, and the longest one allowed
+	defaultUtilizationWindow = 90 * 24 * time.Hour
+	maxUtilizationWindow     = 3 * 366 * 24 * time.Hour
+)
+
+// GetUtilization measures the window up to now by default, a window reaching into the future ends now
+// so days that haven't happened don't count as idle
+func (s *assetService) GetUtilization(ctx context.Context, filter models.UtilizationFilter) (models.UtilizationRes, error) {
+	now := time.Now().UTC()
+	if filter.To.IsZero() || filter.To.After(now) {
+		filter.To = now
+	}
+	if filter.From.IsZero() {
+		filter.From = filter.To.Add(-defaultUtilizationWindow)
+	}
+	if !filter.From.Before(filter.To) || filter.To.Sub(filter.From) > maxUtilizationWindow {
+		return models.UtilizationRes{}, ErrUtilizationWindow
+	}
+	byType, err := s.repo.GetTypeUtilization(ctx, filter)
+	if err != nil {
+		return models.UtilizationRes{}, err
+	}
+	assets, err := s.repo.GetAssetUtilization(ctx, filter)
+	if err != nil {
+		return models.UtilizationRes{}, err
+	}
+	return models.UtilizationRes{From: filter.From, To: filter.To, ByType: byType, Assets: assets}, nil
+}
+
+// returnReasonLimit is how many of the most common return reasons churn lists
+const returnReasonLimit = 20
+
+// GetAssignmentChurn covers the assignments started in the last year by default
+func (s *assetService) GetAssignmentChurn(ctx context.Context, filter models.ChurnFilter) (models.ChurnRes, error) {
+	now := time.Now().UTC()
+	if filter.To.IsZero() || filter.To.After(now) {
+		filter.To = now
+	}
+	if filter.From.IsZero() {
+		filter.From = filter.To.AddDate(-1, 0, 0)
+	}
+	if !filter.From.Before(filter.To) || filter.To.Sub(filter.From) > maxUtilizationWindow {
+		return models.ChurnRes{}, ErrChurnWindow
+	}
+	res := models.ChurnRes{From: filter.From, To: filter.To}
+	var err error
+	if res.ByType, err = s.repo.GetAssignmentStats(ctx, filter, "type"); err != nil {
+		return models.ChurnRes{}, err
+	}
+	if res.ByDepartment, err = s.repo.GetAssignmentStats(ctx, filter, "department"); err != nil {
+		return models.ChurnRes{}, err
+	}
+	if res.ReturnReasons, err = s.repo.GetReturnReasons(ctx, filter, returnReasonLimit); err != nil {
+		return models.ChurnRes{}, err
+	}
+	return res, nil
+}
+
+// GetTrends covers the last 90 days a day at a time by default
+func (s *assetService) GetTrends(ctx context.Context, filter models.TrendFilter) (models.TrendRes, error) {
+	switch filter.Interval {
+	case "":
+		filter.Interval = models.TrendDaily
+	case models.TrendDaily, models.TrendWeekly:
+	default:
+		return models.TrendRes{}, ErrTrendInterval
+	}
+	now := time.Now().UTC()
+	if filter.To.IsZero() || filter.To.After(now) {
+		filter.To = now
+	}
+	if filter.From.IsZero() {
+		filter.From = filter.To.AddDate(0, 0, -90)
+	}
+	if !filter.From.Before(filter.To) || filter.To.Sub(filter.From) > maxUtilizationWindow {
+		return models.TrendRes{}, ErrTrendWindow
+	}
+	points, err := s.repo.GetTrends(ctx, filter)
+	if err != nil {
+		return models.TrendRes{}, err
+	}
+	return models.TrendRes{From: filter.From, To: filter.To, Interval: filter.Interval, Points: points}, nil
+}
+
+const (
+	// most serviced assets the service report lists by default and at most
+	defaultServiceReportTop = 10
+	maxServiceReportTop     = 100
+)
+
+// GetServiceReport covers the services started in the last year by default
+func (s *assetService) GetServiceReport(ctx context.Context, filter models.ServiceReportFilter) (models.ServiceReportRes, error) {
+	now := time.Now().UTC()
+	if filter.To.IsZero() || filter.To.After(now) {
+		filter.To = now
+	}
+	if filter.From.IsZero() {
+		filter.From = filter.To.AddDate(-1, 0, 0)
+	}
+	if !filter.From.Before(filter.To) || filter.To.Sub(filter.From) > maxUtilizationWindow {
+		return models.ServiceReportRes{}, ErrServiceReportWindow
+	}
+	if filter.Top <= 0 {
+		filter.Top = defaultServiceReportTop
+	}
+	filter.Top = min(filter.Top, maxServiceReportTop)
+	byType, err := s.repo.GetTypeServiceStats(ctx, filter)
+	if err != nil {
+		return models.ServiceReportRes{}, err
+	}
+	top, err := s.repo.GetAssetServiceStats(ctx, filter)
+	if err != nil {
+		return models.ServiceReportRes{}, err
+	}
+	return models.ServiceReportRes{From: filter.From, To: filter.To, ByType: byType, TopAssets: top}, nil
+}
+
+// telecomMonthLayout is how the telecom cost report names its month
+const telecomMonthLayout = "2006-01"
+
+// GetTelecomCostReport covers the current month by default, filter.Month is moved to the first of
+// its month
+func (s *assetService) GetTelecomCostReport(ctx context.Context, filter models.TelecomCostFilter) (models.TelecomCostReportRes, error) {
+	if filter.Month.IsZero() {
+		filter.Month = time.Now().UTC()
+	}
+	filter.Month = time.Date(filter.Month.Year(), filter.Month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	departments, err := s.repo.GetTelecomCosts(ctx, filter)
+	if err != nil {
+		return models.TelecomCostReportRes{}, err
+	}
+	report := models.TelecomCostReportRes{Month: filter.Month.Format(telecomMonthLayout), Departments: departments}
+	for _, department := range departments {
+		report.Sims += department.Sims
+		report.TotalCost += department.MonthlyCost
+	}
+	report.TotalCost = math.Round(report.TotalCost*100) / 100
+	return report, nil
+}
+
+// GetInventoryChanges returns a page of the changelog in the caller's time zone
+func (s *assetService) GetInventoryChanges(ctx context.Context, filter models.InventoryChangeFilter) ([]models.InventoryChange, error) {
+	if filter.Type != "" && !slices.Contains(models.InventoryChangeTypes, filter.Type) {
+		return nil, ErrInventoryChangeType
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, ErrInventoryChangeWindow
+	}
+	changes, err := s.repo.GetInventoryChanges(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	loc := utils.LocationFromContext(ctx)
+	for i := range changes {
+		changes[i].OccurredAt = utils.InLocation(changes[i].OccurredAt, loc)
+	}
+	return changes, nil
+}
+
+// historyBatchSize rows are moved per transaction so the archival never holds locks for long
+const historyBatchSize = 1000
+
+// ReportStolen is how an employee reports an asset they hold as stolen. The asset managers of its
+// department and the admins are alerted in the app, on slack and by text message, a failed alert is
+// only logged since the report itself is stored
+func (s *assetService) ReportStolen(ctx context.Context, req models.ReportStolenReq, employeeID uuid.UUID) error {
+	var asset models.StolenAsset
+	err := utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		var err error
+		if asset, err = s.repo.ReportAssetStolen(ctx, req.AssetID, employeeID, req.Note); err != nil {
+			return err
 		}
-	}()
+		return s.emit(ctx, eventservice.AssetUpdated, req.AssetID, &employeeID, map[string]interface{}{"status": "stolen", "note": req.Note})
+	})
+	if err != nil {
+		return err
+	}
+	s.cache.InvalidateUsers(ctx, employeeID)
+	s.logger.GetLogger().Warn("asset reported stolen", zap.String("assetID", req.AssetID.String()), zap.String("employeeID", employeeID.String()))
 
-	err = s.repo.RetrieveAsset(ctx, tx, uuid.MustParse(req.AssetID), uuid.MustParse(req.EmployeeID), req.ReturnReason)
+	name := fmt.Sprintf("%s %s (%s)", asset.Brand, asset.Model, asset.SerialNo)
+	body := fmt.Sprintf("%s was reported stolen by %s", name, asset.Reporter)
+	if req.Note != "" {
+		body += ": " + req.Note
+	}
+	if managerIDs, err := s.repo.GetAssetManagerIDs(ctx, asset.OrganizationID, asset.DepartmentID); err != nil {
+		s.logger.GetLogger().Error("stolen asset not notified", zap.String("assetID", req.AssetID.String()), zap.Error(err))
+	} else if err := s.notifier.Notify(ctx, managerIDs, notificationservice.Notification{
+		Category:   notificationservice.CategoryIncident,
+		Title:      "Asset reported stolen",
+		Body:       body,
+		EntityType: "asset",
+		EntityID:   req.AssetID.String(),
+	}); err != nil {
+		s.logger.GetLogger().Error("stolen asset not notified", zap.String("assetID", req.AssetID.String()), zap.Error(err))
+	}
+	s.alert(models.SlackEventAssetStolen, body)
+	if s.sms != nil {
+		s.textManagers(ctx, asset.OrganizationID, asset.DepartmentID, models.SMSTemplateAssetStolen, map[string]string{"asset": name, "reporter": asset.Reporter, "note": req.Note})
+	}
+	return nil
+}
+
+// checkIMEIs looks the imeis of a mobile up in the blacklist and then in the external registry, it
+// returns why the mobile is flagged or "" when it is clean or checking is off. In block mode a
+// blacklisted imei is an error instead. A registry that can't be reached doesn't hold up intake
+func (s *assetService) checkIMEIs(ctx context.Context, imeis ...string) (string, error) {
+	if s.imeiCheck == "" {
+		return "", nil
+	}
+	var check []string
+	for _, imei := range imeis {
+		if imei = strings.TrimSpace(imei); imei != "" {
+			check = append(check, imei)
+		}
+	}
+	if len(check) == 0 {
+		return "", nil
+	}
+	entries, err := s.repo.GetBlacklistedIMEIs(ctx, check)
 	if err != nil {
-		return fmt.Errorf("failed to retrieve asset: %w", err)
+		return "", err
+	}
+	var reasons []string
+	listed := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		listed[entry.IMEI] = true
+		reasons = append(reasons, fmt.Sprintf("%s %s", entry.IMEI, entry.Reason))
+	}
+	if s.imeiLookup != nil {
+		for _, imei := range check {
+			if listed[imei] {
+				continue
+			}
+			result, err := s.imeiLookup.Lookup(ctx, imei)
+			if err != nil {
+				s.logger.GetLogger().Warn("imei registry lookup failed", zap.Error(err))
+				continue
+			}
+			if result.Blacklisted {
+				reasons = append(reasons, fmt.Sprintf("%s %s", imei, result.Reason))
+			}
+		}
+	}
+	if len(reasons) == 0 {
+		return "", nil
 	}
+	flag := strings.Join(reasons, "; ")
+	if s.imeiCheck == models.IMEICheckBlock {
+		return "", imeiBlacklisted(flag)
+	}
+	return flag, nil
+}
+
+// flagMobile records why a mobile was flagged and tells the asset managers in slack
+func (s *assetService) flagMobile(ctx context.Context, assetID uuid.UUID, flag string) error {
+	if err := s.repo.SetIMEIFlag(ctx, assetID, &flag); err != nil {
+		return err
+	}
+	s.logger.GetLogger().Warn("blacklisted mobile taken in", zap.String("assetID", assetID.String()), zap.String("flag", flag))
+	s.alert(models.SlackEventIMEIFlagged, fmt.Sprintf("Mobile %s was taken in with a blacklisted imei: %s", assetID, flag))
 	return nil
 }
 
-func (s *assetService) SendAssetToService(ctx context.Context, req models.AssetServiceReq, managerID uuid.UUID) error {
+// ListIMEIBlacklist returns the blacklist newest first
+func (s *assetService) ListIMEIBlacklist(ctx context.Context, filter models.IMEIBlacklistFilter) ([]models.BlacklistedIMEI, error) {
+	return s.repo.ListIMEIBlacklist(ctx, filter)
+}
+
+func (s *assetService) BlacklistIMEI(ctx context.Context, req models.BlacklistIMEIReq, addedBy uuid.UUID) error {
+	if err := s.repo.AddBlacklistedIMEI(ctx, models.BlacklistedIMEI{IMEI: req.IMEI, Reason: req.Reason, AddedBy: &addedBy}); err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("imei blacklisted", zap.String("imei", req.IMEI), zap.String("addedBy", addedBy.String()))
+	return nil
+}
 
-	return s.repo.SendAssetForService(ctx, req, managerID)
+func (s *assetService) RemoveBlacklistedIMEI(ctx context.Context, imei string) error {
+	return s.repo.RemoveBlacklistedIMEI(ctx, imei)
 }
 
-func (s *assetService) UpdateAsset(ctx context.Context, req models.UpdateAssetReq) error {
-	return s.repo.UpdateAssetWithConfig(ctx, req)
+// textManagers sends an urgent alert to the phones of the managers of a department, a number that
+// can't be texted doesn't keep the others from it
+func (s *assetService) textManagers(ctx context.Context, organizationID uuid.UUID, departmentID *uuid.UUID, template string, data map[string]string) {
+	numbers, err := s.repo.GetAssetManagerContacts(ctx, organizationID, departmentID)
+	if err != nil {
+		s.logger.GetLogger().Error("failed to read asset manager numbers", zap.Error(err))
+		return
+	}
+	for _, number := range numbers {
+		if err := s.sms.Send(ctx, number, template, data); err != nil {
+			s.logger.GetLogger().Warn("failed to text asset manager", zap.String("template", template), zap.Error(err))
+		}
+	}
 }
 
-func (s *assetService) UpdateAssetWithConfig(ctx context.Context, req models.UpdateAssetReq) error {
-	return s.repo.UpdateAssetWithConfig(ctx, req)
+// CreateAttachmentUpload records a pending attachment and signs the url its file is uploaded to. The
+// client confirms the upload afterwards, until then the attachment isn't listed
+func (s *assetService) CreateAttachmentUpload(ctx context.Context, req models.AttachmentUploadReq, uploadedBy uuid.UUID, scope models.DepartmentScope) (models.AttachmentUploadRes, error) {
+	if s.storage == nil {
+		return models.AttachmentUploadRes{}, ErrAttachmentsOff
+	}
+	if req.Size > s.attachmentLimit {
+		return models.AttachmentUploadRes{}, ErrAttachmentTooLarge
+	}
+	if err := s.checkAssetScope(ctx, req.AssetID, scope); err != nil {
+		return models.AttachmentUploadRes{}, err
+	}
+	attachment := models.Attachment{
+		ID:          uuid.New(),
+		AssetID:     req.AssetID,
+		Filename:    req.Filename,
+		ContentType: req.ContentType,
+		SizeBytes:   req.Size,
+		UploadedBy:  uploadedBy,
+	}
+	attachment.StorageKey = fmt.Sprintf("attachments/%s/%s/%s", req.AssetID, attachment.ID, safeFilename(req.Filename))
+	if err := s.repo.InsertAttachment(ctx, attachment); err != nil {
+		return models.AttachmentUploadRes{}, err
+	}
+	uploadURL, err := s.storage.PresignUpload(ctx, attachment.StorageKey, req.ContentType)
+	if err != nil {
+		return models.AttachmentUploadRes{}, fmt.Errorf("failed to sign attachment upload: %w", err)
+	}
+	return models.AttachmentUploadRes{
+		ID:        attachment.ID,
+		UploadURL: uploadURL,
+		Method:    http.MethodPut,
+		Headers:   map[string]string{"Content-Type": req.ContentType},
+	}, nil
 }
 
-func (s *assetService) GetAllAssetsWithFilters(ctx context.Context, filter models.AssetFilter) ([]models.AssetWithConfigRes, error) {
-	return s.repo.SearchAssetsWithFilter(ctx, filter)
+// ConfirmAttachment checks the file arrived and is within the limit, an oversized file is deleted
+// with its attachment since a presigned put can't be capped
+func (s *assetService) ConfirmAttachment(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) (models.Attachment, error) {
+	if s.storage == nil {
+		return models.Attachment{}, ErrAttachmentsOff
+	}
+	attachment, err := s.repo.GetAttachment(ctx, id)
+	if err != nil {
+		return attachment, err
+	}
+	if err := s.checkAssetScope(ctx, attachment.AssetID, scope); err != nil {
+		return attachment, err
+	}
+	if attachment.Status == models.AttachmentUploaded {
+		return attachment, nil
+	}
+	size, err := s.storage.Stat(ctx, attachment.StorageKey)
+	if errors.Is(err, models.ErrObjectNotFound) {
+		return attachment, ErrAttachmentMissing
+	}
+	if err != nil {
+		return attachment, fmt.Errorf("failed to check attachment upload: %w", err)
+	}
+	if size > s.attachmentLimit {
+		if err := s.repo.ArchiveAttachment(ctx, id); err != nil {
+			return attachment, err
+		}
+		if err := s.storage.Delete(ctx, attachment.StorageKey); err != nil {
+			s.logger.GetLogger().Warn("failed to delete oversized attachment", zap.String("key", attachment.StorageKey), zap.Error(err))
+		}
+		return attachment, ErrAttachmentTooLarge
+	}
+	if err := s.repo.MarkAttachmentUploaded(ctx, id, size); err != nil {
+		return attachment, err
+	}
+	now := time.Now()
+	attachment.Status, attachment.SizeBytes, attachment.UploadedAt = models.AttachmentUploaded, size, &now
+	return attachment, nil
+}
+
+// GetAttachments lists the uploaded attachments of an asset with a download url for each
+func (s *assetService) GetAttachments(ctx context.Context, assetID uuid.UUID, scope models.DepartmentScope) ([]models.Attachment, error) {
+	if s.storage == nil {
+		return nil, ErrAttachmentsOff
+	}
+	if err := s.checkAssetScope(ctx, assetID, scope); err != nil {
+		return nil, err
+	}
+	attachments, err := s.repo.GetAssetAttachments(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range attachments {
+		url, err := s.storage.PresignDownload(ctx, attachments[i].StorageKey, attachments[i].Filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign attachment download: %w", err)
+		}
+		attachments[i].DownloadURL = url
+	}
+	return attachments, nil
+}
+
+// DeleteAttachment archives the attachment and deletes its file, a file that fails to delete is
+// only logged since nothing links to it anymore
+func (s *assetService) DeleteAttachment(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) error {
+	if s.storage == nil {
+		return ErrAttachmentsOff
+	}
+	attachment, err := s.repo.GetAttachment(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.checkAssetScope(ctx, attachment.AssetID, scope); err != nil {
+		return err
+	}
+	if err := s.repo.ArchiveAttachment(ctx, id); err != nil {
+		return err
+	}
+	if err := s.storage.Delete(ctx, attachment.StorageKey); err != nil {
+		s.logger.GetLogger().Warn("failed to delete attachment file", zap.String("key", attachment.StorageKey), zap.Error(err))
+	}
+	return nil
+}
+
+// PurgePendingAttachments is run by the background job, it drops uploads that were never confirmed
+// and whatever file they left behind
+func (s *assetService) PurgePendingAttachments(ctx context.Context, olderThan time.Duration) error {
+	if s.storage == nil {
+		return nil
+	}
+	keys, err := s.repo.DeletePendingAttachmentsBefore(ctx, time.Now().Add(-olderThan))
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			s.logger.GetLogger().Warn("failed to delete unconfirmed attachment", zap.String("key", key), zap.Error(err))
+		}
+	}
+	if len(keys) > 0 {
+		s.logger.GetLogger().Info("purged unconfirmed attachments", zap.Int("attachments", len(keys)))
+	}
+	return nil
+}
+
+// ArchiveHistory is run by the background job, it moves assignments and services closed more than
+// months ago to the history tables. Timelines read both through the asset_assign_all and
+// asset_service_all views
+// kioskScan resolves the scanned badge and serial number, the asset has to be pool equipment
+func (s *assetService) kioskScan(ctx context.Context, req models.KioskScanReq) (models.KioskEmployee, models.KioskAsset, error) {
+	employee, err := s.repo.GetKioskEmployee(ctx, req.BadgeID)
+	if err != nil {
+		return models.KioskEmployee{}, models.KioskAsset{}, err
+	}
+	asset, err := s.repo.GetKioskAsset(ctx, req.SerialNo)
+	if err != nil {
+		return models.KioskEmployee{}, models.KioskAsset{}, err
+	}
+	if !asset.KioskPool {
+		return models.KioskEmployee{}, models.KioskAsset{}, ErrNotKioskAsset
+	}
+	return employee, asset, nil
+}
+
+func kioskRes(action string, req models.KioskScanReq, employee models.KioskEmployee, asset models.KioskAsset) models.KioskRes {
+	return models.KioskRes{
+		Action:       action,
+		AssetID:      asset.ID,
+		SerialNo:     req.SerialNo,
+		Brand:        asset.Brand,
+		Model:        asset.Model,
+		Type:         asset.Type,
+		EmployeeID:   employee.ID,
+		EmployeeName: employee.Username,
+	}
+}
+
+// KioskCheckout assigns an available pool asset to the employee whose badge was scanned, the
+// assignment goes through AssignAsset with the kiosk key's owner as the one who assigned it
+func (s *assetService) KioskCheckout(ctx context.Context, req models.KioskScanReq, kioskOwnerID uuid.UUID, scope models.DepartmentScope) (models.KioskRes, error) {
+	employee, asset, err := s.kioskScan(ctx, req)
+	if err != nil {
+		return models.KioskRes{}, err
+	}
+	if asset.Status != "available" {
+		return models.KioskRes{}, ErrAssetNotAvailable
+	}
+	if err := s.AssignAsset(ctx, asset.ID, employee.ID, kioskOwnerID, nil, nil, scope); err != nil {
+		return models.KioskRes{}, err
+	}
+	return kioskRes(models.KioskCheckedOut, req, employee, asset), nil
+}
+
+// KioskReturn closes the scanned employee's assignment of a pool asset, ErrAssignmentNotFound
+// when they don't hold it
+func (s *assetService) KioskReturn(ctx context.Context, req models.KioskScanReq, scope models.DepartmentScope) (models.KioskRes, error) {
+	employee, asset, err := s.kioskScan(ctx, req)
+	if err != nil {
+		return models.KioskRes{}, err
+	}
+	err = s.RetrieveAsset(ctx, models.AssetReturnReq{
+		AssetID:      asset.ID.String(),
+		EmployeeID:   employee.ID.String(),
+		ReturnReason: "returned at kiosk",
+	}, scope)
+	if err != nil {
+		return models.KioskRes{}, err
+	}
+	return kioskRes(models.KioskReturned, req, employee, asset), nil
+}
+
+func (s *assetService) ArchiveHistory(ctx context.Context, months int) error {
+	if months <= 0 {
+		return nil
+	}
+	before := time.Now().AddDate(0, -months, 0)
+	moves := []struct {
+		name string
+		move func(ctx context.Context, before time.Time, limit int) (int64, error)
+	}{
+		{"assignments", s.repo.MoveClosedAssignmentsBefore},
+		{"services", s.repo.MoveClosedServicesBefore},
+	}
+	for _, m := range moves {
+		var total int64
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			moved, err := m.move(ctx, before, historyBatchSize)
+			if err != nil {
+				return err
+			}
+			total += moved
+			if moved < historyBatchSize {
+				break
+			}
+		}
+		s.logger.GetLogger().Info("moved closed rows to history", zap.String("table", m.name), zap.Int64("moved", total), zap.Time("before", before))
+	}
+	return nil
+}
+
+// notifyOnce runs fn in a transaction, marking an item as notified commits together with its
+// notifications so neither happens without the other
+func (s *assetService) notifyOnce(ctx context.Context, fn func(ctx context.Context) error) error {
+	return utils.WithTransaction(ctx, s.db, fn)
 }
@@ -12,7 +12,7 @@ type AssetReq struct {
 	SerialNo       string    `json:"serial_no" validate:"required"`
 	PurchaseDate   time.Time `json:"purchase_date" validate:"required"`
 	OwnedBy        string    `json:"owned_by" validate:"required"`
-	Type           string    `json:"type" validate:"required, oneof=laptop mouse mobile monitor hard_disk pen_drive sim accessory"`
+	Type           string    `json:"type" validate:"required,asset_type"`
 	WarrantyStart  time.Time `json:"warranty" validate:"required"`
 	WarrantyExpire time.Time `json:"warranty_expire" validate:"required,gtfield=WarrantyStart"`
 }
@@ -52,7 +52,12 @@ type Mobile_config_req struct {
 }
 
 type Sim_config_req struct {
-	Number int `json:"number"`
+	Number        int     `json:"number"`
+	Carrier       string  `json:"carrier"`
+	Plan          string  `json:"plan"`
+	MonthlyCost   float64 `json:"monthly_cost"`
+	RenewalDate   string  `json:"renewal_date"`
+	MobileAssetID string  `json:"mobile_asset_id"`
 }
 
 type Accessories_config_req struct {
@@ -139,7 +144,12 @@ type Mobile_config_res struct {
 }
 
 type Sim_config_res struct {
-	Number int `json:"number" db:"number"`
+	Number        int        `json:"number" db:"number"`
+	Carrier       string     `json:"carrier" db:"carrier"`
+	Plan          string     `json:"plan" db:"plan"`
+	MonthlyCost   float64    `json:"monthly_cost" db:"monthly_cost"`
+	RenewalDate   *time.Time `json:"renewal_date,omitempty" db:"renewal_date"`
+	MobileAssetID *uuid.UUID `json:"mobile_asset_id,omitempty" db:"mobile_asset_id"`
 }
 
 type Accessories_config_res struct {
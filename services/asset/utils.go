@@ -1,47 +1,129 @@
 package assetservice
 
 import (
+	"asset/models"
+	"asset/utils"
+	"encoding/json"
 	"fmt"
-	"github.com/pkg/errors"
+	"net/http"
+	"reflect"
+	"slices"
 	"strings"
 	"time"
+	"unicode"
 )
 
 func IsAssetTypeValid(assetType string) bool {
-	return assetType == "laptop" || assetType == "mouse" || assetType == "monitor" || assetType == "hard_disk" ||
-		assetType == "pen_drive" ||
-		assetType == "mobile" ||
-		assetType == "sim" ||
-		assetType == "accessory"
+	return slices.Contains(models.AssetTypes, assetType)
 }
 
 func IsOwnershipValid(ownership string) bool {
-	return ownership == "remotestate" || ownership == "client"
+	return slices.Contains(models.AssetOwnerships, ownership)
 }
 
 func AssetValidityCheck(reqModel AddAssetWithConfigReq) error {
 	if strings.TrimSpace(reqModel.Brand) == "" {
-		return errors.New("brand is required")
+		return invalidAsset("brand is required")
 	}
 
 	if strings.TrimSpace(reqModel.Model) == "" {
-		return errors.New("model is required")
+		return invalidAsset("model is required")
 	}
 
 	if strings.TrimSpace(reqModel.SerialNo) == "" {
-		return errors.New("serial number is required")
+		return invalidAsset("serial number is required")
 	}
 
 	if reqModel.PurchaseDate.After(time.Now()) {
-		return errors.New("purchase date cannot be in the future")
+		return invalidAsset("purchase date cannot be in the future")
 	}
 
 	if !IsAssetTypeValid(reqModel.Type) {
-		return fmt.Errorf("invalid asset type")
+		return invalidAsset("invalid asset type")
 	}
 
 	if !IsOwnershipValid(reqModel.OwnedBy) {
-		return fmt.Errorf("invalid owner ship")
+		return invalidAsset("invalid owner ship")
 	}
 	return nil
 }
+
+func invalidAsset(message string) error {
+	return models.NewServiceError(http.StatusBadRequest, models.CodeValidationFailed, message)
+}
+
+// validateConfig checks a config against the validate tags of its type in models.AssetTypeConfigs
+func validateConfig(assetType string, config json.RawMessage) error {
+	sample, ok := models.AssetTypeConfigs[assetType]
+	if !ok {
+		return ErrUnsupportedAssetType
+	}
+	cfg := reflect.New(reflect.TypeOf(sample)).Interface()
+	if err := json.Unmarshal(config, cfg); err != nil {
+		return invalidConfig(assetType, err)
+	}
+	return utils.ValidateStruct(cfg)
+}
+
+// assetTypeSchemas describes the config of every asset type in the order of models.AssetTypes
+func assetTypeSchemas() []models.AssetTypeSchema {
+	schemas := make([]models.AssetTypeSchema, 0, len(models.AssetTypes))
+	for _, assetType := range models.AssetTypes {
+		if sample, ok := models.AssetTypeConfigs[assetType]; ok {
+			schemas = append(schemas, models.AssetTypeSchema{Type: assetType, Fields: utils.DescribeFields(sample)})
+		}
+	}
+	return schemas
+}
+
+// imeiBlacklisted refuses a mobile whose imeis are blacklisted, reasons says which and why
+func imeiBlacklisted(reasons string) error {
+	return models.NewServiceError(http.StatusConflict, "imei_blacklisted", "the device is blacklisted: "+reasons)
+}
+
+// invalidConfig reports a config that doesn't decode for the asset type, the json error names the field
+func invalidConfig(assetType string, err error) error {
+	return models.NewServiceError(http.StatusBadRequest, "invalid_asset_config", fmt.Sprintf("invalid %s config: %v", assetType, err))
+}
+
+// mdmAssetType is the asset type of a managed device by its os, empty when the os isn't a laptop or phone one
+func mdmAssetType(os string) string {
+	os = strings.ToLower(os)
+	switch {
+	case strings.Contains(os, "ios"), strings.Contains(os, "android"):
+		return "mobile"
+	case strings.Contains(os, "windows"), strings.Contains(os, "mac"), strings.Contains(os, "linux"), strings.Contains(os, "chrome"):
+		return "laptop"
+	default:
+		return ""
+	}
+}
+
+// orUnknown stands in for a detail the mdm didn't report, brand and model can't be empty
+func orUnknown(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return "unknown"
+	}
+	return value
+}
+
+// safeFilename keeps the last path element of a client's filename with anything but letters, digits,
+// dots, dashes and underscores replaced, it ends up in an object key
+func safeFilename(name string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	safe := []rune(strings.Map(func(r rune) rune {
+		if r < 128 && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' || r == '_') {
+			return r
+		}
+		return '_'
+	}, name))
+	if len(safe) > 100 {
+		safe = safe[len(safe)-100:]
+	}
+	if cleaned := strings.Trim(string(safe), "."); cleaned != "" {
+		return cleaned
+	}
+	return "file"
+}
@@ -0,0 +1,118 @@
+package auditservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type AuditHandler struct {
+	Service        AuditService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewAuditHandler(service AuditService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *AuditHandler {
+	return &AuditHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+func (h *AuditHandler) GetAuditLogs(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetAuditLogs request received")
+	filter := AuditLogFilter{
+		EntityType: r.URL.Query().Get("entity_type"),
+		EntityID:   r.URL.Query().Get("entity_id"),
+		ActorID:    r.URL.Query().Get("actor_id"),
+		Action:     r.URL.Query().Get("action"),
+	}
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve scope in GetAuditLogs", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve organization")
+		return
+	}
+	filter.OrganizationID = scope.OrganizationID
+
+	logs, err := h.Service.GetAuditLogs(r.Context(), filter)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch audit logs", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch audit logs")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"audit_logs": logs})
+}
+
+// GetEntityChanges lists what each audit entry of one entity changed, entity_type and entity_id are
+// required
+func (h *AuditHandler) GetEntityChanges(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetEntityChanges request received")
+	filter := AuditLogFilter{
+		EntityType: r.URL.Query().Get("entity_type"),
+		EntityID:   r.URL.Query().Get("entity_id"),
+		ActorID:    r.URL.Query().Get("actor_id"),
+		Action:     r.URL.Query().Get("action"),
+	}
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve scope in GetEntityChanges", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve organization")
+		return
+	}
+	filter.OrganizationID = scope.OrganizationID
+
+	changes, err := h.Service.GetEntityChanges(r.Context(), filter)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch entity changes", zap.String("entityType", filter.EntityType), zap.String("entityID", filter.EntityID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch entity changes")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"entity_type": filter.EntityType, "entity_id": filter.EntityID, "changes": changes})
+}
+
+// GetAuthEvents lists sign ins, refreshes, mfa checks and logouts, from and to are RFC 3339 times
+func (h *AuditHandler) GetAuthEvents(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetAuthEvents request received")
+	query := r.URL.Query()
+	filter := AuthEventFilter{
+		UserID:    query.Get("user_id"),
+		IPAddress: query.Get("ip"),
+		EventType: query.Get("event_type"),
+		Outcome:   query.Get("outcome"),
+	}
+	for param, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, param+" must be an RFC 3339 time")
+			return
+		}
+		*target = &parsed
+	}
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve scope in GetAuthEvents", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve organization")
+		return
+	}
+	filter.OrganizationID = scope.OrganizationID
+
+	events, err := h.Service.GetAuthEvents(r.Context(), filter)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch auth events", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch auth events")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"auth_events": events})
+}
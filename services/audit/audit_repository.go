@@ -0,0 +1,113 @@
+package auditservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type AuditRepository interface {
+	InsertAuditLog(ctx context.Context, entry AuditEntry, oldValue, newValue *string) error
+	GetAuditLogs(ctx context.Context, filter AuditLogFilter) ([]AuditLogRes, error)
+	InsertAuthEvent(ctx context.Context, event AuthEvent) error
+	GetAuthEvents(ctx context.Context, filter AuthEventFilter) ([]AuthEventRes, error)
+	DeleteAuthEventsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type PostgresAuditRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewAuditRepository(db *sqlx.DB, log providers.ZapLoggerProvider) AuditRepository {
+	return &PostgresAuditRepository{DB: db, Logger: log}
+}
+
+// InsertAuditLog runs in the transaction of ctx when there is one, so the audit row commits with the change it describes
+func (r *PostgresAuditRepository) InsertAuditLog(ctx context.Context, entry AuditEntry, oldValue, newValue *string) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO audit_logs (actor_id, action, entity_type, entity_id, old_value, new_value)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5::jsonb, $6::jsonb)
+	`, entry.ActorID, entry.Action, entry.EntityType, entry.EntityID, oldValue, newValue)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert audit log", zap.String("action", entry.Action), zap.String("entity_type", entry.EntityType), zap.Error(err))
+		return fmt.Errorf("failed to insert audit log: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresAuditRepository) GetAuditLogs(ctx context.Context, filter AuditLogFilter) ([]AuditLogRes, error) {
+	r.Logger.GetLogger().Info("fetching audit logs", zap.Any("filter", filter))
+	logs := make([]AuditLogRes, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &logs, `
+		SELECT
+			al.id, al.actor_id, u.username AS actor_name, al.action, al.entity_type,
+			al.entity_id, al.old_value, al.new_value, al.created_at
+		FROM audit_logs al
+		LEFT JOIN users u ON u.id = al.actor_id
+		WHERE ($1 = '' OR al.entity_type = $1)
+		AND ($2 = '' OR al.entity_id = $2)
+		AND ($3 = '' OR al.actor_id::text = $3)
+		AND ($4 = '' OR al.action = $4)
+		AND ($7::uuid IS NULL OR u.organization_id = $7)
+		ORDER BY al.created_at DESC
+		LIMIT $5 OFFSET $6
+	`, filter.EntityType, filter.EntityID, filter.ActorID, filter.Action, filter.Limit, filter.Offset, filter.OrganizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch audit logs", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch audit logs: %w", err)
+	}
+	return logs, nil
+}
+
+func (r *PostgresAuditRepository) InsertAuthEvent(ctx context.Context, event AuthEvent) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO auth_events (user_id, event_type, method, outcome, identifier, ip_address, user_agent, reason)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''))
+	`, event.UserID, event.EventType, event.Method, event.Outcome, event.Identifier, event.IPAddress, event.UserAgent, event.Reason)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert auth event", zap.String("event_type", event.EventType), zap.String("outcome", event.Outcome), zap.Error(err))
+		return fmt.Errorf("failed to insert auth event: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresAuditRepository) GetAuthEvents(ctx context.Context, filter AuthEventFilter) ([]AuthEventRes, error) {
+	r.Logger.GetLogger().Info("fetching auth events", zap.Any("filter", filter))
+	events := make([]AuthEventRes, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &events, `
+		SELECT
+			ae.id, ae.user_id, u.username, ae.event_type, ae.method, ae.outcome,
+			ae.identifier, ae.ip_address, ae.user_agent, ae.reason, ae.created_at
+		FROM auth_events ae
+		LEFT JOIN users u ON u.id = ae.user_id
+		WHERE ($1 = '' OR ae.user_id::text = $1)
+		AND ($2 = '' OR ae.ip_address = $2)
+		AND ($3 = '' OR ae.event_type = $3)
+		AND ($4 = '' OR ae.outcome = $4)
+		AND ($5::timestamptz IS NULL OR ae.created_at >= $5)
+		AND ($6::timestamptz IS NULL OR ae.created_at < $6)
+		AND ($9::uuid IS NULL OR u.organization_id = $9)
+		ORDER BY ae.created_at DESC
+		LIMIT $7 OFFSET $8
+	`, filter.UserID, filter.IPAddress, filter.EventType, filter.Outcome, filter.From, filter.To, filter.Limit, filter.Offset, filter.OrganizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch auth events", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch auth events: %w", err)
+	}
+	return events, nil
+}
+
+func (r *PostgresAuditRepository) DeleteAuthEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `DELETE FROM auth_events WHERE created_at < $1`, before)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to purge auth events", zap.Time("before", before), zap.Error(err))
+		return 0, fmt.Errorf("failed to purge auth events: %w", err)
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,144 @@
+package auditservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type AuditService interface {
+	Record(ctx context.Context, entry AuditEntry) error
+	GetAuditLogs(ctx context.Context, filter AuditLogFilter) ([]AuditLogRes, error)
+	// GetEntityChanges reads the audit log of one entity as field by field changes, newest first
+	GetEntityChanges(ctx context.Context, filter AuditLogFilter) ([]EntityChange, error)
+	RecordAuthEvent(ctx context.Context, event AuthEvent) error
+	GetAuthEvents(ctx context.Context, filter AuthEventFilter) ([]AuthEventRes, error)
+	PurgeAuthEvents(ctx context.Context, retention time.Duration) error
+}
+
+type auditServiceStruct struct {
+	repo   AuditRepository
+	logger providers.ZapLoggerProvider
+}
+
+func NewAuditService(repo AuditRepository, logger providers.ZapLoggerProvider) AuditService {
+	return &auditServiceStruct{repo: repo, logger: logger}
+}
+
+func (s *auditServiceStruct) Record(ctx context.Context, entry AuditEntry) error {
+	oldValue, err := marshalValue(entry.OldValue)
+	if err != nil {
+		return err
+	}
+	newValue, err := marshalValue(entry.NewValue)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.InsertAuditLog(ctx, entry, oldValue, newValue); err != nil {
+		s.logger.GetLogger().Error("failed to record audit entry", zap.String("action", entry.Action), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s *auditServiceStruct) GetAuditLogs(ctx context.Context, filter AuditLogFilter) ([]AuditLogRes, error) {
+	return s.repo.GetAuditLogs(ctx, filter)
+}
+
+var ErrEntityRequired = models.NewServiceError(http.StatusBadRequest, "entity_required", "entity_type and entity_id are required")
+
+func (s *auditServiceStruct) GetEntityChanges(ctx context.Context, filter AuditLogFilter) ([]EntityChange, error) {
+	if filter.EntityType == "" || filter.EntityID == "" {
+		return nil, ErrEntityRequired
+	}
+	logs, err := s.repo.GetAuditLogs(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	changes := make([]EntityChange, 0, len(logs))
+	for _, log := range logs {
+		fields, err := diffValues(log.OldValue, log.NewValue)
+		if err != nil {
+			s.logger.GetLogger().Error("failed to diff audit entry", zap.String("auditID", log.ID.String()), zap.Error(err))
+			return nil, err
+		}
+		changes = append(changes, EntityChange{
+			AuditID:   log.ID,
+			Action:    log.Action,
+			ActorID:   log.ActorID,
+			ActorName: log.ActorName,
+			ChangedAt: log.CreatedAt,
+			Summary:   summarize(log, fields),
+			Fields:    fields,
+		})
+	}
+	return changes, nil
+}
+
+// summarize reads like "warranty_expire changed from A to B by Asha Admin", entries without values
+// only name the action
+func summarize(log AuditLogRes, fields []FieldChange) string {
+	actor := "the system"
+	if log.ActorName != nil {
+		actor = *log.ActorName
+	} else if log.ActorID != nil {
+		actor = log.ActorID.String()
+	}
+	descriptions := make([]string, 0, len(fields))
+	for _, field := range fields {
+		descriptions = append(descriptions, field.Description)
+	}
+	if len(descriptions) == 0 {
+		return fmt.Sprintf("%s by %s", log.Action, actor)
+	}
+	return fmt.Sprintf("%s: %s by %s", log.Action, strings.Join(descriptions, ", "), actor)
+}
+
+// marshalValue returns nil for a nil value so the column stays NULL instead of the json literal null,
+// a string is returned since lib/pq sends []byte as bytea which jsonb can't parse
+// authEventFieldLimit keeps a verbose error or user agent from bloating the table
+const authEventFieldLimit = 500
+
+func (s *auditServiceStruct) RecordAuthEvent(ctx context.Context, event AuthEvent) error {
+	if len(event.Reason) > authEventFieldLimit {
+		event.Reason = event.Reason[:authEventFieldLimit]
+	}
+	if len(event.UserAgent) > authEventFieldLimit {
+		event.UserAgent = event.UserAgent[:authEventFieldLimit]
+	}
+	return s.repo.InsertAuthEvent(ctx, event)
+}
+
+func (s *auditServiceStruct) GetAuthEvents(ctx context.Context, filter AuthEventFilter) ([]AuthEventRes, error) {
+	return s.repo.GetAuthEvents(ctx, filter)
+}
+
+// PurgeAuthEvents deletes events older than the retention period
+func (s *auditServiceStruct) PurgeAuthEvents(ctx context.Context, retention time.Duration) error {
+	before := time.Now().Add(-retention)
+	deleted, err := s.repo.DeleteAuthEventsBefore(ctx, before)
+	if err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("purged auth events", zap.Int64("deleted", deleted), zap.Time("before", before))
+	return nil
+}
+
+func marshalValue(value interface{}) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit value: %w", err)
+	}
+	str := string(b)
+	return &str, nil
+}
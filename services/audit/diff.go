@@ -0,0 +1,106 @@
+package auditservice
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// wholeValue names the change when an old or new value isn't a json object
+const wholeValue = "value"
+
+// diffValues compares the old and new value of an entry field by field, nested objects are compared
+// by their dotted paths like config.ram and arrays as a whole
+func diffValues(oldValue, newValue json.RawMessage) ([]FieldChange, error) {
+	before, err := flattenValue(oldValue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read old value: %w", err)
+	}
+	after, err := flattenValue(newValue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read new value: %w", err)
+	}
+
+	fields := make([]string, 0, len(before)+len(after))
+	for field := range before {
+		fields = append(fields, field)
+	}
+	for field := range after {
+		if _, ok := before[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	changes := make([]FieldChange, 0, len(fields))
+	for _, field := range fields {
+		old, hadOld := before[field]
+		updated, hasNew := after[field]
+		if hadOld && hasNew && reflect.DeepEqual(old, updated) {
+			continue
+		}
+		change := FieldChange{Field: field, Old: old, New: updated}
+		switch {
+		case !hadOld:
+			change.Description = fmt.Sprintf("%s set to %s", field, describeValue(updated))
+		case !hasNew:
+			change.Description = fmt.Sprintf("%s cleared, was %s", field, describeValue(old))
+		default:
+			change.Description = fmt.Sprintf("%s changed from %s to %s", field, describeValue(old), describeValue(updated))
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// flattenValue maps every leaf of a json object to its dotted path, any other json value is kept
+// under wholeValue. An empty or null value has no fields
+func flattenValue(raw json.RawMessage) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	if len(raw) == 0 {
+		return fields, nil
+	}
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		if value != nil {
+			fields[wholeValue] = value
+		}
+		return fields, nil
+	}
+	flattenInto(fields, "", object)
+	return fields, nil
+}
+
+func flattenInto(fields map[string]interface{}, prefix string, object map[string]interface{}) {
+	for key, value := range object {
+		if nested, ok := value.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenInto(fields, prefix+key+".", nested)
+			continue
+		}
+		fields[prefix+key] = value
+	}
+}
+
+// describeValue renders a value for a description, strings as they are and the rest as compact json
+func describeValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "empty"
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return "empty"
+		}
+		return v
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,69 @@
+package auditservice
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, new string
+		want     []string
+	}{
+		{
+			name: "changed field",
+			old:  `{"warranty_expire": "2025-01-01", "brand": "Dell"}`,
+			new:  `{"warranty_expire": "2026-01-01", "brand": "Dell"}`,
+			want: []string{"warranty_expire changed from 2025-01-01 to 2026-01-01"},
+		},
+		{
+			name: "created",
+			new:  `{"brand": "Dell", "ram": 16}`,
+			want: []string{"brand set to Dell", "ram set to 16"},
+		},
+		{
+			name: "cleared and nested",
+			old:  `{"config": {"ram": "8GB", "os": "linux"}, "note": "spare"}`,
+			new:  `{"config": {"ram": "16GB", "os": "linux"}}`,
+			want: []string{"config.ram changed from 8GB to 16GB", "note cleared, was spare"},
+		},
+		{
+			name: "not an object",
+			old:  `"assigned"`,
+			new:  `"available"`,
+			want: []string{"value changed from assigned to available"},
+		},
+		{
+			name: "null to empty",
+			old:  `null`,
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := diffValues(json.RawMessage(tt.old), json.RawMessage(tt.new))
+			require.NoError(t, err)
+			descriptions := make([]string, 0, len(changes))
+			for _, change := range changes {
+				descriptions = append(descriptions, change.Description)
+			}
+			assert.Equal(t, tt.want, descriptions)
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	name := "Asha Admin"
+	log := AuditLogRes{ID: uuid.New(), ActorName: &name, Action: "asset.updated", CreatedAt: time.Now()}
+	fields := []FieldChange{{Field: "warranty_expire", Description: "warranty_expire changed from A to B"}}
+	assert.Equal(t, "asset.updated: warranty_expire changed from A to B by Asha Admin", summarize(log, fields))
+
+	log.ActorName = nil
+	assert.Equal(t, "asset.updated by the system", summarize(log, nil))
+}
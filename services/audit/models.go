@@ -0,0 +1,112 @@
+package auditservice
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEntry is what other services record, old and new values are marshalled to JSONB
+type AuditEntry struct {
+	ActorID    *uuid.UUID
+	Action     string
+	EntityType string
+	EntityID   string
+	OldValue   interface{}
+	NewValue   interface{}
+}
+
+type AuditLogRes struct {
+	ID         uuid.UUID       `json:"id" db:"id"`
+	ActorID    *uuid.UUID      `json:"actor_id,omitempty" db:"actor_id"`
+	ActorName  *string         `json:"actor_name,omitempty" db:"actor_name"`
+	Action     string          `json:"action" db:"action"`
+	EntityType string          `json:"entity_type" db:"entity_type"`
+	EntityID   *string         `json:"entity_id,omitempty" db:"entity_id"`
+	OldValue   json.RawMessage `json:"old_value,omitempty" db:"old_value"`
+	NewValue   json.RawMessage `json:"new_value,omitempty" db:"new_value"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+}
+
+// EntityChange is one audit entry of an entity read as the fields it changed, Summary says who did
+// what in a sentence
+type EntityChange struct {
+	AuditID   uuid.UUID     `json:"audit_id"`
+	Action    string        `json:"action"`
+	ActorID   *uuid.UUID    `json:"actor_id,omitempty"`
+	ActorName *string       `json:"actor_name,omitempty"`
+	ChangedAt time.Time     `json:"changed_at"`
+	Summary   string        `json:"summary"`
+	Fields    []FieldChange `json:"fields"`
+}
+
+// FieldChange is one field an entry changed, Old is left out when the field was set and New when it
+// was cleared
+type FieldChange struct {
+	Field       string      `json:"field"`
+	Old         interface{} `json:"old,omitempty"`
+	New         interface{} `json:"new,omitempty"`
+	Description string      `json:"description"`
+}
+
+type AuditLogFilter struct {
+	EntityType string
+	EntityID   string
+	ActorID    string
+	Action     string
+	// entries of actors in other organizations are left out, nil reads every organization
+	OrganizationID *uuid.UUID
+	Limit          int
+	Offset         int
+}
+
+const (
+	AuthEventLogin           = "login"
+	AuthEventMFAVerification = "mfa_verification"
+	AuthEventTokenRefresh    = "token_refresh"
+	AuthEventLogout          = "logout"
+
+	AuthOutcomeSuccess     = "success"
+	AuthOutcomeFailure     = "failure"
+	AuthOutcomeLocked      = "locked"
+	AuthOutcomeMFARequired = "mfa_required"
+)
+
+// AuthEvent is one authentication attempt, UserID is nil when the caller couldn't be identified
+type AuthEvent struct {
+	UserID     *uuid.UUID
+	EventType  string
+	Method     string
+	Outcome    string
+	Identifier string
+	IPAddress  string
+	UserAgent  string
+	Reason     string
+}
+
+type AuthEventRes struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	UserID     *uuid.UUID `json:"user_id,omitempty" db:"user_id"`
+	Username   *string    `json:"username,omitempty" db:"username"`
+	EventType  string     `json:"event_type" db:"event_type"`
+	Method     string     `json:"method" db:"method"`
+	Outcome    string     `json:"outcome" db:"outcome"`
+	Identifier *string    `json:"identifier,omitempty" db:"identifier"`
+	IPAddress  *string    `json:"ip_address,omitempty" db:"ip_address"`
+	UserAgent  *string    `json:"user_agent,omitempty" db:"user_agent"`
+	Reason     *string    `json:"reason,omitempty" db:"reason"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+type AuthEventFilter struct {
+	UserID         string
+	IPAddress      string
+	EventType      string
+	Outcome        string
+	From           *time.Time
+	To             *time.Time
+	OrganizationID *uuid.UUID
+	Limit          int
+	Offset         int
+}
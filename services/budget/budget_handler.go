@@ -0,0 +1,136 @@
+package budgetservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type BudgetHandler struct {
+	Service        BudgetService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewBudgetHandler(service BudgetService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *BudgetHandler {
+	return &BudgetHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+func (h *BudgetHandler) CreateBudget(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in CreateBudget", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	creatorID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in CreateBudget", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	var req CreateBudgetReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "validation failed")
+		return
+	}
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in CreateBudget", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	budget, err := h.Service.CreateBudget(r.Context(), req, creatorID, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to create budget", zap.String("departmentID", req.DepartmentID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to create budget")
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, budget)
+}
+
+// GetBudgets lists the budgets of the departments in scope with how much of each was spent, on=date
+// keeps the budgets running that day
+func (h *BudgetHandler) GetBudgets(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var filter BudgetFilter
+	if val := query.Get("department_id"); val != "" {
+		departmentID, err := uuid.Parse(val)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, "invalid department_id")
+			return
+		}
+		filter.DepartmentID = &departmentID
+	}
+	if val := query.Get("on"); val != "" {
+		on, err := time.Parse(time.DateOnly, val)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, "on must be a YYYY-MM-DD date")
+			return
+		}
+		filter.On = &on
+	}
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in GetBudgets", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+	filter.Scope = scope
+
+	budgets, err := h.Service.GetBudgets(r.Context(), filter)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch budgets", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch budgets")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"budgets": budgets, "limit": filter.Limit, "offset": filter.Offset})
+}
+
+func (h *BudgetHandler) DeleteBudget(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in DeleteBudget", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	deleterID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in DeleteBudget", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid budget id")
+		return
+	}
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in DeleteBudget", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	if err := h.Service.DeleteBudget(r.Context(), id, deleterID, scope); err != nil {
+		h.Logger.GetLogger().Error("Failed to delete budget", zap.String("budgetID", id.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to delete budget")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "budget deleted"})
+}
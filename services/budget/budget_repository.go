@@ -0,0 +1,181 @@
+package budgetservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type BudgetRepository interface {
+	// InsertBudget sets the budget of a department in scope, ErrDepartmentNotFound when there is none
+	// and ErrBudgetOverlap when the department has a budget for part of the period
+	InsertBudget(ctx context.Context, req CreateBudgetReq, createdBy uuid.UUID, scope models.DepartmentScope) (uuid.UUID, error)
+	GetBudget(ctx context.Context, id uuid.UUID) (Budget, error)
+	GetBudgets(ctx context.Context, filter BudgetFilter) ([]Budget, error)
+	// ArchiveBudget removes a budget of a department in scope, ErrBudgetNotFound otherwise
+	ArchiveBudget(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) error
+	SetAlertLevel(ctx context.Context, id uuid.UUID, level int) error
+	// GetBudgetWatcherIDs returns the users who may read budgets, admins and the department's own
+	GetBudgetWatcherIDs(ctx context.Context, departmentID uuid.UUID) ([]uuid.UUID, error)
+}
+
+type PostgresBudgetRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewBudgetRepository(db *sqlx.DB, log providers.ZapLoggerProvider) BudgetRepository {
+	return &PostgresBudgetRepository{
+		DB:     db,
+		Logger: log,
+	}
+}
+
+// budgetQuery sums what was spent of each budget, merged duplicates are left out so an asset is
+// only counted once
+const budgetQuery = `
+	SELECT b.id, b.department_id, d.name AS department_name, b.period_start, b.period_end, b.amount::float8 AS amount,
+		b.notes, b.alert_level, b.created_by, b.created_at,
+		acq.acquisitions, COALESCE(acq.cost, 0)::float8 AS acquisition_cost,
+		svc.services, COALESCE(svc.cost, 0)::float8 AS service_cost
+	FROM department_budgets b
+	JOIN departments d ON d.id = b.department_id
+	CROSS JOIN LATERAL (
+		SELECT count(*) AS acquisitions, sum(a.purchase_cost) AS cost
+		FROM assets a
+		WHERE a.department_id = b.department_id AND a.merged_into IS NULL AND a.purchase_cost IS NOT NULL
+		AND a.purchase_date BETWEEN b.period_start AND b.period_end
+	) acq
+	CROSS JOIN LATERAL (
+		SELECT count(*) AS services, sum(s.cost) AS cost
+		FROM asset_service_all s
+		JOIN assets a ON a.id = s.asset_id
+		WHERE a.department_id = b.department_id AND a.merged_into IS NULL AND s.archived_at IS NULL AND s.cost IS NOT NULL
+		AND s.service_end::date BETWEEN b.period_start AND b.period_end
+	) svc
+	WHERE b.archived_at IS NULL`
+
+func (r *PostgresBudgetRepository) InsertBudget(ctx context.Context, req CreateBudgetReq, createdBy uuid.UUID, scope models.DepartmentScope) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := utils.WithTransaction(ctx, r.DB, func(ctx context.Context) error {
+		var department *uuid.UUID
+		// the department row is locked so two overlapping budgets can't be set at once
+		err := utils.Conn(ctx, r.DB).GetContext(ctx, &department, `
+			SELECT d.id FROM departments d
+			WHERE d.id = $1 AND ($2::uuid IS NULL OR d.organization_id = $2)
+			FOR UPDATE
+		`, req.DepartmentID, scope.OrganizationID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrDepartmentNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch budget department: %w", err)
+		}
+		var overlaps bool
+		err = utils.Conn(ctx, r.DB).GetContext(ctx, &overlaps, `
+			SELECT EXISTS (
+				SELECT 1 FROM department_budgets
+				WHERE department_id = $1 AND archived_at IS NULL
+				AND period_start <= $3::date AND period_end >= $2::date
+			)
+		`, req.DepartmentID, req.PeriodStart, req.PeriodEnd)
+		if err != nil {
+			return fmt.Errorf("failed to check budget periods: %w", err)
+		}
+		if overlaps {
+			return ErrBudgetOverlap
+		}
+		return utils.Conn(ctx, r.DB).GetContext(ctx, &id, `
+			INSERT INTO department_budgets (department_id, period_start, period_end, amount, notes, created_by)
+			VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+			RETURNING id
+		`, req.DepartmentID, req.PeriodStart, req.PeriodEnd, req.Amount, req.Notes, createdBy)
+	})
+	if err != nil {
+		var serviceErr *models.ServiceError
+		if !errors.As(err, &serviceErr) {
+			r.Logger.GetLogger().Error("failed to insert budget", zap.String("department_id", req.DepartmentID.String()), zap.Error(err))
+		}
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+func (r *PostgresBudgetRepository) GetBudget(ctx context.Context, id uuid.UUID) (Budget, error) {
+	var budget Budget
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &budget, budgetQuery+` AND b.id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Budget{}, ErrBudgetNotFound
+		}
+		return Budget{}, fmt.Errorf("failed to fetch budget: %w", err)
+	}
+	return budget, nil
+}
+
+func (r *PostgresBudgetRepository) GetBudgets(ctx context.Context, filter BudgetFilter) ([]Budget, error) {
+	budgets := make([]Budget, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &budgets, budgetQuery+`
+		AND ($1::uuid IS NULL OR b.department_id = $1)
+		AND ($2::date IS NULL OR $2::date BETWEEN b.period_start AND b.period_end)
+		AND ($3 OR b.department_id IS NOT DISTINCT FROM $4)
+		AND ($5::uuid IS NULL OR d.organization_id = $5)
+		ORDER BY b.period_start DESC, d.name
+		LIMIT NULLIF($6, 0) OFFSET $7
+	`, filter.DepartmentID, filter.On, filter.Scope.AllDepartments, filter.Scope.DepartmentID, filter.Scope.OrganizationID,
+		filter.Limit, filter.Offset)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch budgets", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch budgets: %w", err)
+	}
+	return budgets, nil
+}
+
+func (r *PostgresBudgetRepository) ArchiveBudget(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) error {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE department_budgets b SET archived_at = now()
+		FROM departments d
+		WHERE b.id = $1 AND b.archived_at IS NULL AND d.id = b.department_id
+		AND ($2::uuid IS NULL OR d.organization_id = $2)
+	`, id, scope.OrganizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to archive budget", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to archive budget: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrBudgetNotFound
+	}
+	return nil
+}
+
+func (r *PostgresBudgetRepository) SetAlertLevel(ctx context.Context, id uuid.UUID, level int) error {
+	if _, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `UPDATE department_budgets SET alert_level = $2 WHERE id = $1`, id, level); err != nil {
+		return fmt.Errorf("failed to record budget alert: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresBudgetRepository) GetBudgetWatcherIDs(ctx context.Context, departmentID uuid.UUID) ([]uuid.UUID, error) {
+	watcherIDs := []uuid.UUID{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &watcherIDs, `
+		SELECT DISTINCT u.id
+		FROM users u
+		JOIN user_roles ur ON ur.user_id = u.id AND ur.archived_at IS NULL
+		JOIN role_permissions rp ON rp.role = ur.role AND rp.archived_at IS NULL
+		WHERE u.archived_at IS NULL
+		AND rp.permission = $1
+		AND (ur.role = 'admin' OR u.department_id = $2)
+	`, string(models.BudgetReadPermission), departmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch budget watchers: %w", err)
+	}
+	return watcherIDs, nil
+}
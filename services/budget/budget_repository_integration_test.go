@@ -0,0 +1,74 @@
+//go:build integration
+
+package budgetservice
+
+import (
+	"asset/database/seed"
+	"asset/database/testdb"
+	"asset/models"
+	"asset/providers"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestBudgets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewBudgetRepository(db, logger)
+	ctx := context.Background()
+	adminID, engineering := seed.ID("user:admin"), seed.ID("department:engineering")
+	all := models.DepartmentScope{AllDepartments: true}
+
+	// both laptops were bought and the monitor serviced in 2023, the operations laptop doesn't count
+	_, err := db.Exec(`UPDATE assets SET purchase_cost = CASE serial_no WHEN 'SEED-LAP-0001' THEN 2000 WHEN 'SEED-LAP-0002' THEN 1500 ELSE 900 END
+		WHERE serial_no IN ('SEED-LAP-0001', 'SEED-LAP-0002', 'SEED-LAP-0003')`)
+	require.NoError(t, err)
+	_, err = db.Exec(`UPDATE asset_service SET cost = 300 WHERE id = $1`, seed.ID("service:monitor-1-dead-pixels"))
+	require.NoError(t, err)
+
+	id, err := repo.InsertBudget(ctx, CreateBudgetReq{DepartmentID: engineering, PeriodStart: "2023-01-01", PeriodEnd: "2023-12-31", Amount: 4000}, adminID, all)
+	require.NoError(t, err)
+	budget, err := repo.GetBudget(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Engineering", budget.DepartmentName)
+	assert.Equal(t, 2, budget.Acquisitions)
+	assert.Equal(t, 3500.0, budget.AcquisitionCost)
+	assert.Equal(t, 1, budget.Services)
+	assert.Equal(t, 300.0, budget.ServiceCost)
+
+	_, err = repo.InsertBudget(ctx, CreateBudgetReq{DepartmentID: engineering, PeriodStart: "2023-12-01", PeriodEnd: "2024-03-31", Amount: 100}, adminID, all)
+	assert.ErrorIs(t, err, ErrBudgetOverlap)
+
+	on := time.Date(2023, time.June, 1, 0, 0, 0, 0, time.UTC)
+	budgets, err := repo.GetBudgets(ctx, BudgetFilter{On: &on, Scope: all})
+	require.NoError(t, err)
+	require.Len(t, budgets, 1)
+	assert.Equal(t, id, budgets[0].ID)
+
+	// an operations manager doesn't see engineering's budget
+	operations := seed.ID("department:operations")
+	budgets, err = repo.GetBudgets(ctx, BudgetFilter{Scope: models.DepartmentScope{DepartmentID: &operations}})
+	require.NoError(t, err)
+	assert.Empty(t, budgets)
+
+	watcherIDs, err := repo.GetBudgetWatcherIDs(ctx, engineering)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{adminID, seed.ID("user:asset-manager")}, watcherIDs)
+
+	require.NoError(t, repo.SetAlertLevel(ctx, id, AlertApproaching))
+	require.NoError(t, repo.ArchiveBudget(ctx, id, all))
+	assert.ErrorIs(t, repo.ArchiveBudget(ctx, id, all), ErrBudgetNotFound)
+	_, err = repo.GetBudget(ctx, id)
+	assert.ErrorIs(t, err, ErrBudgetNotFound)
+}
@@ -0,0 +1,184 @@
+package budgetservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/audit"
+	"asset/services/notification"
+	"asset/utils"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// BudgetService keeps what each department may spend in a period and how much of it went to buying
+// and servicing its assets, the department is alerted once when it nears the limit and once when
+// it is over
+type BudgetService interface {
+	CreateBudget(ctx context.Context, req CreateBudgetReq, createdBy uuid.UUID, scope models.DepartmentScope) (Budget, error)
+	GetBudgets(ctx context.Context, filter BudgetFilter) ([]Budget, error)
+	DeleteBudget(ctx context.Context, id, deletedBy uuid.UUID, scope models.DepartmentScope) error
+	// CheckBudgets is run by the check_department_budgets job, it alerts on the current budgets
+	CheckBudgets(ctx context.Context) error
+}
+
+var (
+	ErrBudgetNotFound     = models.NewServiceError(http.StatusNotFound, "budget_not_found", "no budget with that id")
+	ErrDepartmentNotFound = models.NewServiceError(http.StatusNotFound, "department_not_found", "no such department in your organization")
+	ErrBudgetOverlap      = models.NewServiceError(http.StatusConflict, "budget_overlap", "the department already has a budget for part of that period")
+	ErrBudgetPeriod       = models.NewServiceError(http.StatusBadRequest, "invalid_budget_period", "period_end can't be before period_start")
+)
+
+type budgetServiceStruct struct {
+	repo         BudgetRepository
+	audit        auditservice.AuditService
+	notifier     notificationservice.NotificationService
+	logger       providers.ZapLoggerProvider
+	alertPercent int
+}
+
+// NewBudgetService alerts a department once alertPercent of its budget is spent
+func NewBudgetService(repo BudgetRepository, audit auditservice.AuditService, notifier notificationservice.NotificationService, logger providers.ZapLoggerProvider, alertPercent int) BudgetService {
+	return &budgetServiceStruct{
+		repo:         repo,
+		audit:        audit,
+		notifier:     notifier,
+		logger:       logger,
+		alertPercent: alertPercent,
+	}
+}
+
+func (s *budgetServiceStruct) CreateBudget(ctx context.Context, req CreateBudgetReq, createdBy uuid.UUID, scope models.DepartmentScope) (Budget, error) {
+	// both are validated dates, so they compare as strings
+	if req.PeriodEnd < req.PeriodStart {
+		return Budget{}, ErrBudgetPeriod
+	}
+	req.Amount = math.Round(req.Amount*100) / 100
+	id, err := s.repo.InsertBudget(ctx, req, createdBy, scope)
+	if err != nil {
+		return Budget{}, err
+	}
+	budget, err := s.repo.GetBudget(ctx, id)
+	if err != nil {
+		return Budget{}, err
+	}
+	budget = s.consumption(budget)
+	s.record(ctx, createdBy, "budget.created", budget.ID, budget)
+	return s.localize(ctx, budget), nil
+}
+
+func (s *budgetServiceStruct) GetBudgets(ctx context.Context, filter BudgetFilter) ([]Budget, error) {
+	budgets, err := s.repo.GetBudgets(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range budgets {
+		budgets[i] = s.localize(ctx, s.consumption(budgets[i]))
+	}
+	return budgets, nil
+}
+
+func (s *budgetServiceStruct) DeleteBudget(ctx context.Context, id, deletedBy uuid.UUID, scope models.DepartmentScope) error {
+	if err := s.repo.ArchiveBudget(ctx, id, scope); err != nil {
+		return err
+	}
+	s.record(ctx, deletedBy, "budget.deleted", id, nil)
+	return nil
+}
+
+// CheckBudgets alerts the watchers of every budget that reached a higher alert level than it was
+// last alerted at. A failed alert is retried on the next run
+func (s *budgetServiceStruct) CheckBudgets(ctx context.Context) error {
+	today := time.Now()
+	budgets, err := s.repo.GetBudgets(ctx, BudgetFilter{On: &today, Scope: models.DepartmentScope{AllDepartments: true}})
+	if err != nil {
+		return err
+	}
+	alerted := 0
+	for _, budget := range budgets {
+		budget = s.consumption(budget)
+		level := alertLevels[budget.Status]
+		if level <= budget.AlertLevel {
+			continue
+		}
+		if err := s.alert(ctx, budget); err != nil {
+			s.logger.GetLogger().Error("budget alert not sent", zap.String("budgetID", budget.ID.String()), zap.Error(err))
+			continue
+		}
+		if err := s.repo.SetAlertLevel(ctx, budget.ID, level); err != nil {
+			return err
+		}
+		alerted++
+	}
+	if alerted > 0 {
+		s.logger.GetLogger().Info("budget alerts sent", zap.Int("budgets", alerted))
+	}
+	return nil
+}
+
+var alertLevels = map[string]int{
+	BudgetWithin:      AlertNone,
+	BudgetApproaching: AlertApproaching,
+	BudgetExceeded:    AlertExceeded,
+}
+
+func (s *budgetServiceStruct) alert(ctx context.Context, budget Budget) error {
+	watcherIDs, err := s.repo.GetBudgetWatcherIDs(ctx, budget.DepartmentID)
+	if err != nil {
+		return err
+	}
+	if len(watcherIDs) == 0 {
+		return nil
+	}
+	title := fmt.Sprintf("%s is nearing its budget", budget.DepartmentName)
+	if budget.Status == BudgetExceeded {
+		title = fmt.Sprintf("%s is over its budget", budget.DepartmentName)
+	}
+	return s.notifier.Notify(ctx, watcherIDs, notificationservice.Notification{
+		Category: notificationservice.CategoryApproval,
+		Title:    title,
+		Body: fmt.Sprintf("%.2f of %.2f spent (%.0f%%) from %s to %s", budget.Spent, budget.Amount, budget.PercentUsed,
+			budget.PeriodStart.Format(time.DateOnly), budget.PeriodEnd.Format(time.DateOnly)),
+		EntityType: "budget",
+		EntityID:   budget.ID.String(),
+	})
+}
+
+// consumption fills in how much of the budget is spent and what that means for its status
+func (s *budgetServiceStruct) consumption(budget Budget) Budget {
+	budget.Spent = math.Round((budget.AcquisitionCost+budget.ServiceCost)*100) / 100
+	budget.Remaining = math.Round((budget.Amount-budget.Spent)*100) / 100
+	budget.PercentUsed = math.Round(budget.Spent/budget.Amount*10000) / 100
+	switch {
+	case budget.Spent > budget.Amount:
+		budget.Status = BudgetExceeded
+	case budget.PercentUsed >= float64(s.alertPercent):
+		budget.Status = BudgetApproaching
+	default:
+		budget.Status = BudgetWithin
+	}
+	return budget
+}
+
+func (s *budgetServiceStruct) record(ctx context.Context, actorID uuid.UUID, action string, id uuid.UUID, value interface{}) {
+	if err := s.audit.Record(ctx, auditservice.AuditEntry{
+		ActorID:    &actorID,
+		Action:     action,
+		EntityType: "budget",
+		EntityID:   id.String(),
+		NewValue:   value,
+	}); err != nil {
+		s.logger.GetLogger().Warn("failed to audit budget", zap.String("action", action), zap.String("budgetID", id.String()), zap.Error(err))
+	}
+}
+
+// localize only moves created_at, the period is made of plain dates
+func (s *budgetServiceStruct) localize(ctx context.Context, budget Budget) Budget {
+	budget.CreatedAt = utils.InLocation(budget.CreatedAt, utils.LocationFromContext(ctx))
+	return budget
+}
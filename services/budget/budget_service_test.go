@@ -0,0 +1,75 @@
+package budgetservice
+
+import (
+	"asset/models"
+	"asset/services/audit"
+	"asset/services/notification"
+	"asset/services/testutil"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T) (BudgetService, *MockBudgetRepository, *auditservice.MockAuditService, *notificationservice.MockNotificationService) {
+	ctrl := testutil.Controller(t)
+	repo := NewMockBudgetRepository(ctrl)
+	audit := auditservice.NewMockAuditService(ctrl)
+	notifier := notificationservice.NewMockNotificationService(ctrl)
+	return NewBudgetService(repo, audit, notifier, testutil.Logger(ctrl), 80), repo, audit, notifier
+}
+
+func TestCreateBudgetPeriod(t *testing.T) {
+	svc, _, _, _ := newTestService(t)
+	_, err := svc.CreateBudget(context.Background(), CreateBudgetReq{DepartmentID: uuid.New(), PeriodStart: "2026-07-01", PeriodEnd: "2026-06-30", Amount: 100},
+		uuid.New(), models.DepartmentScope{AllDepartments: true})
+	assert.ErrorIs(t, err, ErrBudgetPeriod)
+}
+
+func TestGetBudgetsConsumption(t *testing.T) {
+	svc, repo, _, _ := newTestService(t)
+	ctx := context.Background()
+	filter := BudgetFilter{Scope: models.DepartmentScope{AllDepartments: true}}
+	repo.EXPECT().GetBudgets(ctx, filter).Return([]Budget{
+		{Amount: 1000, AcquisitionCost: 500, ServiceCost: 100.5},
+		{Amount: 1000, AcquisitionCost: 700, ServiceCost: 100},
+		{Amount: 1000, AcquisitionCost: 1000, ServiceCost: 0.01},
+	}, nil)
+
+	budgets, err := svc.GetBudgets(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{600.5, 800, 1000.01}, []float64{budgets[0].Spent, budgets[1].Spent, budgets[2].Spent})
+	assert.Equal(t, 399.5, budgets[0].Remaining)
+	assert.Equal(t, 60.05, budgets[0].PercentUsed)
+	assert.Equal(t, []string{BudgetWithin, BudgetApproaching, BudgetExceeded}, []string{budgets[0].Status, budgets[1].Status, budgets[2].Status})
+}
+
+// each level is alerted once, a budget already alerted as approaching is only alerted again once over
+func TestCheckBudgets(t *testing.T) {
+	svc, repo, _, notifier := newTestService(t)
+	ctx := context.Background()
+	departmentID, managerID := uuid.New(), uuid.New()
+	within := Budget{ID: uuid.New(), DepartmentID: departmentID, Amount: 1000, AcquisitionCost: 100}
+	alerted := Budget{ID: uuid.New(), DepartmentID: departmentID, Amount: 1000, AcquisitionCost: 900, AlertLevel: AlertApproaching}
+	over := Budget{ID: uuid.New(), DepartmentID: departmentID, DepartmentName: "Engineering", Amount: 1000, AcquisitionCost: 900, ServiceCost: 200, AlertLevel: AlertApproaching}
+
+	repo.EXPECT().GetBudgets(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, filter BudgetFilter) ([]Budget, error) {
+		require.NotNil(t, filter.On)
+		assert.WithinDuration(t, time.Now(), *filter.On, time.Minute)
+		assert.True(t, filter.Scope.AllDepartments)
+		return []Budget{within, alerted, over}, nil
+	})
+	repo.EXPECT().GetBudgetWatcherIDs(ctx, departmentID).Return([]uuid.UUID{managerID}, nil)
+	notifier.EXPECT().Notify(ctx, []uuid.UUID{managerID}, gomock.Any()).DoAndReturn(func(_ context.Context, _ []uuid.UUID, n notificationservice.Notification) error {
+		assert.Equal(t, "Engineering is over its budget", n.Title)
+		assert.Equal(t, over.ID.String(), n.EntityID)
+		return nil
+	})
+	repo.EXPECT().SetAlertLevel(ctx, over.ID, AlertExceeded).Return(nil)
+
+	require.NoError(t, svc.CheckBudgets(ctx))
+}
@@ -0,0 +1,65 @@
+package budgetservice
+
+import (
+	"asset/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// alert levels of a budget, each is alerted once per budget
+const (
+	AlertNone        = 0
+	AlertApproaching = 1
+	AlertExceeded    = 2
+)
+
+// how much of a budget was spent, as its Status
+const (
+	BudgetWithin      = "within"
+	BudgetApproaching = "approaching"
+	BudgetExceeded    = "exceeded"
+)
+
+// CreateBudgetReq sets what a department may spend from PeriodStart to PeriodEnd, both included
+type CreateBudgetReq struct {
+	DepartmentID uuid.UUID `json:"department_id" validate:"required"`
+	PeriodStart  string    `json:"period_start" validate:"required,datetime=2006-01-02"`
+	PeriodEnd    string    `json:"period_end" validate:"required,datetime=2006-01-02"`
+	Amount       float64   `json:"amount" validate:"required,gt=0,lte=1000000000"`
+	Notes        string    `json:"notes,omitempty" validate:"max=500"`
+}
+
+// BudgetFilter narrows the budgets to the departments in Scope, On keeps the budgets whose period
+// covers that day. A Limit of 0 lists them all
+type BudgetFilter struct {
+	DepartmentID *uuid.UUID
+	On           *time.Time
+	Limit        int
+	Offset       int
+	Scope        models.DepartmentScope
+}
+
+// Budget is a department's budget with what was spent of it. Assets bought in the period count with
+// their purchase cost and services that ended in it with their cost, for the assets the department
+// has now
+type Budget struct {
+	ID              uuid.UUID `json:"id" db:"id"`
+	DepartmentID    uuid.UUID `json:"department_id" db:"department_id"`
+	DepartmentName  string    `json:"department_name" db:"department_name"`
+	PeriodStart     time.Time `json:"period_start" db:"period_start"`
+	PeriodEnd       time.Time `json:"period_end" db:"period_end"`
+	Amount          float64   `json:"amount" db:"amount"`
+	Notes           *string   `json:"notes,omitempty" db:"notes"`
+	AlertLevel      int       `json:"-" db:"alert_level"`
+	CreatedBy       uuid.UUID `json:"created_by" db:"created_by"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	Acquisitions    int       `json:"acquisitions" db:"acquisitions"`
+	AcquisitionCost float64   `json:"acquisition_cost" db:"acquisition_cost"`
+	Services        int       `json:"services" db:"services"`
+	ServiceCost     float64   `json:"service_cost" db:"service_cost"`
+	Spent           float64   `json:"spent" db:"-"`
+	Remaining       float64   `json:"remaining" db:"-"`
+	PercentUsed     float64   `json:"percent_used" db:"-"`
+	Status          string    `json:"status" db:"-"`
+}
@@ -0,0 +1,250 @@
+package bulkservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/user"
+	"asset/utils"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type BulkHandler struct {
+	Service        BulkService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewBulkHandler(service BulkService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *BulkHandler {
+	return &BulkHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+// SubmitEmployeeExport queues an export with the same filters and formats as the direct export
+func (h *BulkHandler) SubmitEmployeeExport(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("SubmitEmployeeExport request received")
+	callerID, ok := h.caller(w, r, "SubmitEmployeeExport")
+	if !ok {
+		return
+	}
+	format, err := utils.ParseExportFormat(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Invalid export format", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "format must be csv or xlsx")
+		return
+	}
+	params := EmployeeExportParams{Format: format, Filter: userservice.ParseEmployeeFilter(r)}
+	if params.Filter.Scope, err = h.AuthMiddleware.GetDepartmentScope(r); err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in SubmitEmployeeExport", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	res, err := h.Service.SubmitEmployeeExport(r.Context(), params, callerID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to queue employee export", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to queue employee export")
+		return
+	}
+	utils.RespondJSON(w, http.StatusAccepted, res)
+}
+
+// SubmitAssetImport queues a text/csv body of assets, one per row
+func (h *BulkHandler) SubmitAssetImport(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("SubmitAssetImport request received")
+	callerID, ok := h.caller(w, r, "SubmitAssetImport")
+	if !ok {
+		return
+	}
+	header, rows, err := utils.ParseCSVBody(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Invalid csv in SubmitAssetImport", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid csv")
+		return
+	}
+	params := AssetImportParams{Header: header, Rows: rows}
+	if params.Scope, err = h.AuthMiddleware.GetDepartmentScope(r); err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in SubmitAssetImport", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	res, err := h.Service.SubmitAssetImport(r.Context(), params, callerID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to queue asset import", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to queue asset import")
+		return
+	}
+	utils.RespondJSON(w, http.StatusAccepted, res)
+}
+
+func (h *BulkHandler) SubmitAssetAssign(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("SubmitAssetAssign request received")
+	callerID, ok := h.caller(w, r, "SubmitAssetAssign")
+	if !ok {
+		return
+	}
+	var req AssetAssignReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in SubmitAssetAssign", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in SubmitAssetAssign", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+	params := AssetAssignParams{Items: req.Items}
+	var err error
+	if params.Scope, err = h.AuthMiddleware.GetDepartmentScope(r); err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in SubmitAssetAssign", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	res, err := h.Service.SubmitAssetAssign(r.Context(), params, callerID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to queue asset assign", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to queue asset assign")
+		return
+	}
+	utils.RespondJSON(w, http.StatusAccepted, res)
+}
+
+// SubmitAccountingExport queues the fixed asset journal of ?from=2026-07&to=2026-09 in ?format=quickbooks
+// or netsuite, the previous month when no months are given
+func (h *BulkHandler) SubmitAccountingExport(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("SubmitAccountingExport request received")
+	callerID, ok := h.caller(w, r, "SubmitAccountingExport")
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	params := AccountingExportParams{Format: query.Get("format"), From: query.Get("from"), To: query.Get("to")}
+
+	res, err := h.Service.SubmitAccountingExport(r.Context(), params, callerID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to queue accounting export", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to queue accounting export")
+		return
+	}
+	utils.RespondJSON(w, http.StatusAccepted, res)
+}
+
+// SubmitSnapshotExport queues an archive of every user, asset, assignment, service and audit record
+func (h *BulkHandler) SubmitSnapshotExport(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("SubmitSnapshotExport request received")
+	callerID, ok := h.caller(w, r, "SubmitSnapshotExport")
+	if !ok {
+		return
+	}
+
+	res, err := h.Service.SubmitSnapshotExport(r.Context(), callerID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to queue snapshot export", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to queue snapshot export")
+		return
+	}
+	utils.RespondJSON(w, http.StatusAccepted, res)
+}
+
+// GetJob reports progress and item errors, callers see their own jobs and job managers the jobs of
+// their organization
+func (h *BulkHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetBulkJob request received")
+	access, ok := h.jobAccess(w, r, "GetBulkJob")
+	if !ok {
+		return
+	}
+	jobID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid id")
+		return
+	}
+
+	job, err := h.Service.GetJob(r.Context(), jobID, access)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch bulk job", zap.String("id", jobID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch bulk job")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, job)
+}
+
+// DownloadResult sends the export, or the per item outcomes of an import or assign. A result kept in
+// object storage is a redirect to a presigned link
+func (h *BulkHandler) DownloadResult(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("DownloadBulkJobResult request received")
+	access, ok := h.jobAccess(w, r, "DownloadBulkJobResult")
+	if !ok {
+		return
+	}
+	jobID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid id")
+		return
+	}
+
+	result, err := h.Service.GetResult(r.Context(), jobID, access)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch bulk job result", zap.String("id", jobID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch bulk job result")
+		return
+	}
+	if result.URL != "" {
+		http.Redirect(w, r, result.URL, http.StatusFound)
+		return
+	}
+	utils.RespondFile(w, result.ContentType, result.Filename, result.Data)
+}
+
+// caller returns who is asking
+func (h *BulkHandler) caller(w http.ResponseWriter, r *http.Request, handler string) (uuid.UUID, bool) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return uuid.Nil, false
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in "+handler, zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return uuid.Nil, false
+	}
+	return userUUID, true
+}
+
+// jobAccess resolves which jobs the caller may read, job.manage only counts for an api key scoped
+// to it and only reaches the organization the caller may act on
+func (h *BulkHandler) jobAccess(w http.ResponseWriter, r *http.Request, handler string) (JobAccess, bool) {
+	callerID, ok := h.caller(w, r, handler)
+	if !ok {
+		return JobAccess{}, false
+	}
+	access := JobAccess{CallerID: callerID}
+	var err error
+	if access.Manager, err = h.AuthMiddleware.CallerHasPermission(r, models.JobManagePermission); err != nil {
+		h.Logger.GetLogger().Error("Failed to check job permission in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return JobAccess{}, false
+	}
+	if !access.Manager {
+		return access, true
+	}
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return JobAccess{}, false
+	}
+	access.OrganizationID = scope.OrganizationID
+	return access, true
+}
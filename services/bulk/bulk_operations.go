@@ -0,0 +1,291 @@
+package bulkservice
+
+import (
+	"asset/models"
+	"asset/services/accounting"
+	"asset/services/user"
+	"asset/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// columns an asset import must have, department_id, config, purchase_cost, salvage_value and
+// useful_life_months are optional. config holds the type specific fields as json, like
+// {"processor":"i7","ram":"16GB","os":"linux"} for a laptop
+var assetImportRequired = []string{"brand", "model", "serial_no", "type", "owned_by", "purchase_date", "warranty_start", "warranty_expire"}
+
+var (
+	assetImportOutcomeHeader = []string{"row", "serial_no", "status", "error"}
+	assetAssignOutcomeHeader = []string{"item", "asset_id", "user_id", "status", "error"}
+)
+
+const (
+	outcomeSucceeded = "succeeded"
+	outcomeFailed    = "failed"
+)
+
+func (s *bulkServiceStruct) runEmployeeExport(ctx context.Context, job BulkJob, p *progress) (*Result, error) {
+	var params EmployeeExportParams
+	if err := json.Unmarshal(job.Params, &params); err != nil {
+		return nil, fmt.Errorf("failed to decode export params: %w", err)
+	}
+	rows, err := s.users.ExportEmployees(ctx, params.Filter)
+	if err != nil {
+		return nil, err
+	}
+	p.setTotal(len(rows))
+
+	var buf bytes.Buffer
+	if err := utils.WriteExport(&buf, params.Format, userservice.EmployeeExportHeader, rows); err != nil {
+		return nil, fmt.Errorf("failed to write employee export: %w", err)
+	}
+	p.Processed, p.Succeeded = len(rows), len(rows)
+	return &Result{
+		Data:        buf.Bytes(),
+		ContentType: utils.ExportContentType(params.Format),
+		Filename:    fmt.Sprintf("employees_%s.%s", job.CreatedAt.Format("20060102"), utils.ExportExtension(params.Format)),
+	}, nil
+}
+
+// runAccountingExport writes the journal csv, the months are read again so an export queued with
+// the default period keeps the month it was queued in
+func (s *bulkServiceStruct) runAccountingExport(ctx context.Context, job BulkJob, p *progress) (*Result, error) {
+	var params AccountingExportParams
+	if err := json.Unmarshal(job.Params, &params); err != nil {
+		return nil, fmt.Errorf("failed to decode accounting export params: %w", err)
+	}
+	period, err := accountingservice.ParsePeriod(params.From, params.To, job.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	format, err := accountingservice.ParseFormat(params.Format)
+	if err != nil {
+		return nil, err
+	}
+	header, rows, err := s.accounting.ExportJournal(ctx, period, format)
+	if err != nil {
+		return nil, err
+	}
+	p.setTotal(len(rows))
+
+	var buf bytes.Buffer
+	if err := utils.WriteCSV(&buf, header, rows); err != nil {
+		return nil, fmt.Errorf("failed to write accounting export: %w", err)
+	}
+	p.Processed, p.Succeeded = len(rows), len(rows)
+	return &Result{
+		Data:        buf.Bytes(),
+		ContentType: utils.ExportContentType(utils.ExportFormatCSV),
+		Filename:    fmt.Sprintf("fixed_assets_%s_%s_%s.csv", format, period.From.Format("200601"), period.To.AddDate(0, -1, 0).Format("200601")),
+	}, nil
+}
+
+// runSnapshotExport counts every exported row as an item, the archive is the result
+func (s *bulkServiceStruct) runSnapshotExport(ctx context.Context, job BulkJob, p *progress) (*Result, error) {
+	archive, err := s.snapshots.Export(ctx, job.CreatedBy)
+	if err != nil {
+		return nil, err
+	}
+	rows := 0
+	for _, table := range archive.Manifest.Tables {
+		rows += table.Rows
+	}
+	p.setTotal(rows)
+	p.Processed, p.Succeeded = rows, rows
+	return &Result{
+		Data:        archive.Data,
+		ContentType: "application/zip",
+		Filename:    fmt.Sprintf("snapshot_%s.zip", archive.Manifest.CreatedAt.Format("20060102_150405")),
+	}, nil
+}
+
+// runAssetImport adds one asset per row, the result lists the outcome of every row. Row numbers
+// count the header so they match what a spreadsheet shows
+func (s *bulkServiceStruct) runAssetImport(ctx context.Context, job BulkJob, p *progress) (*Result, error) {
+	var params AssetImportParams
+	if err := json.Unmarshal(job.Params, &params); err != nil {
+		return nil, fmt.Errorf("failed to decode import params: %w", err)
+	}
+	outcomes := make([][]string, 0, len(params.Rows))
+	for i, row := range params.Rows {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		line := i + 2
+		req, err := assetFromRow(params.Header, row)
+		if err == nil {
+			err = utils.ValidateStruct(req)
+		}
+		if err == nil {
+			err = s.assets.AddAssetWithConfig(ctx, req, job.CreatedBy, params.Scope)
+		}
+		if err != nil {
+			message := p.fail(line, req.SerialNo, err)
+			outcomes = append(outcomes, []string{strconv.Itoa(line), req.SerialNo, outcomeFailed, message})
+			continue
+		}
+		p.succeed()
+		outcomes = append(outcomes, []string{strconv.Itoa(line), req.SerialNo, outcomeSucceeded, ""})
+	}
+	return outcomeResult(job, "asset_import", assetImportOutcomeHeader, outcomes)
+}
+
+func (s *bulkServiceStruct) runAssetAssign(ctx context.Context, job BulkJob, p *progress) (*Result, error) {
+	var params AssetAssignParams
+	if err := json.Unmarshal(job.Params, &params); err != nil {
+		return nil, fmt.Errorf("failed to decode assign params: %w", err)
+	}
+	outcomes := make([][]string, 0, len(params.Items))
+	for i, item := range params.Items {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		err := s.assignItem(ctx, item, job.CreatedBy, params.Scope)
+		if err != nil {
+			message := p.fail(i+1, item.AssetID, err)
+			outcomes = append(outcomes, []string{strconv.Itoa(i + 1), item.AssetID, item.UserID, outcomeFailed, message})
+			continue
+		}
+		p.succeed()
+		outcomes = append(outcomes, []string{strconv.Itoa(i + 1), item.AssetID, item.UserID, outcomeSucceeded, ""})
+	}
+	return outcomeResult(job, "asset_assign", assetAssignOutcomeHeader, outcomes)
+}
+
+func (s *bulkServiceStruct) assignItem(ctx context.Context, item AssetAssignItem, managerID uuid.UUID, scope models.DepartmentScope) error {
+	assetID, err := uuid.Parse(item.AssetID)
+	if err != nil {
+		return invalidItem("asset_id is not a valid id")
+	}
+	userID, err := uuid.Parse(item.UserID)
+	if err != nil {
+		return invalidItem("user_id is not a valid id")
+	}
+	return s.assets.AssignAsset(ctx, assetID, userID, managerID, nil, nil, scope)
+}
+
+// outcomeResult is the csv of per item outcomes import and assign jobs leave for download
+func outcomeResult(job BulkJob, name string, header []string, outcomes [][]string) (*Result, error) {
+	var buf bytes.Buffer
+	if err := utils.WriteCSV(&buf, header, outcomes); err != nil {
+		return nil, fmt.Errorf("failed to write %s outcomes: %w", name, err)
+	}
+	return &Result{
+		Data:        buf.Bytes(),
+		ContentType: utils.ExportContentType(utils.ExportFormatCSV),
+		Filename:    fmt.Sprintf("%s_%s.csv", name, job.ID.String()),
+	}, nil
+}
+
+func assetFromRow(header, row []string) (models.AddAssetWithConfigReq, error) {
+	values := make(map[string]string, len(header))
+	for i, column := range header {
+		if i < len(row) {
+			values[column] = strings.TrimSpace(row[i])
+		}
+	}
+	req := models.AddAssetWithConfigReq{
+		AssetReq: models.AssetReq{
+			Brand:    values["brand"],
+			Model:    values["model"],
+			SerialNo: values["serial_no"],
+			Type:     values["type"],
+			OwnedBy:  values["owned_by"],
+		},
+		Config: json.RawMessage("{}"),
+	}
+	dates := []struct {
+		column string
+		target *time.Time
+	}{
+		{"purchase_date", &req.PurchaseDate},
+		{"warranty_start", &req.WarrantyStart},
+		{"warranty_expire", &req.WarrantyExpire},
+	}
+	for _, date := range dates {
+		parsed, err := parseImportDate(values[date.column])
+		if err != nil {
+			return req, invalidItem(date.column + " must be a date like 2024-01-31")
+		}
+		*date.target = parsed
+	}
+	if department := values["department_id"]; department != "" {
+		departmentID, err := uuid.Parse(department)
+		if err != nil {
+			return req, invalidItem("department_id is not a valid id")
+		}
+		req.DepartmentID = &departmentID
+	}
+	amounts := []struct {
+		column string
+		target **float64
+	}{
+		{"purchase_cost", &req.PurchaseCost},
+		{"salvage_value", &req.SalvageValue},
+	}
+	for _, amount := range amounts {
+		if values[amount.column] == "" {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(values[amount.column], 64)
+		if err != nil {
+			return req, invalidItem(amount.column + " must be a number like 85000.50")
+		}
+		*amount.target = &parsed
+	}
+	if life := values["useful_life_months"]; life != "" {
+		months, err := strconv.Atoi(life)
+		if err != nil {
+			return req, invalidItem("useful_life_months must be a whole number of months")
+		}
+		req.UsefulLifeMonths = &months
+	}
+	if config := values["config"]; config != "" {
+		if !json.Valid([]byte(config)) {
+			return req, invalidItem("config must be a json object")
+		}
+		req.Config = json.RawMessage(config)
+	}
+	return req, nil
+}
+
+// parseImportDate takes plain dates as spreadsheets write them or full RFC 3339 times
+func parseImportDate(value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.DateOnly, value); err == nil {
+		return parsed, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func invalidItem(message string) error {
+	return models.NewServiceError(http.StatusBadRequest, models.CodeBadRequest, message)
+}
+
+// describeInputError covers what the request got wrong without a service error, failed validation
+// and duplicates caught by a unique index
+func describeInputError(err error) (string, string, bool) {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		messages := make([]string, 0, len(validationErrs))
+		for _, fieldErr := range utils.FieldErrors(validationErrs) {
+			messages = append(messages, fieldErr.Message)
+		}
+		return models.CodeValidationFailed, strings.Join(messages, "; "), true
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return models.CodeAlreadyExists, "already exists", true
+	}
+	return "", "", false
+}
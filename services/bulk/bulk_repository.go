@@ -0,0 +1,216 @@
+package bulkservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type BulkRepository interface {
+	InsertJob(ctx context.Context, kind string, params []byte, createdBy uuid.UUID, total int) (uuid.UUID, error)
+	GetJob(ctx context.Context, id uuid.UUID) (BulkJob, error)
+	ClaimNextJob(ctx context.Context, instance string) (BulkJob, bool, error)
+	UpdateProgress(ctx context.Context, id uuid.UUID, p Progress) error
+	CompleteJob(ctx context.Context, id uuid.UUID, p Progress, result *Result) error
+	FailJob(ctx context.Context, id uuid.UUID, p Progress, reason string) error
+	GetResult(ctx context.Context, id uuid.UUID) (Result, error)
+	FailStaleJobs(ctx context.Context, staleBefore time.Time) (int64, error)
+	// DeleteFinishedJobsBefore returns how many jobs it deleted and the object storage keys of their results
+	DeleteFinishedJobsBefore(ctx context.Context, before time.Time) (int64, []string, error)
+}
+
+type PostgresBulkRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewBulkRepository(db *sqlx.DB, log providers.ZapLoggerProvider) BulkRepository {
+	return &PostgresBulkRepository{DB: db, Logger: log}
+}
+
+const bulkJobColumns = `
+	id, kind, status, params, created_by, total, processed, succeeded, failed, errors, error,
+	(result IS NOT NULL OR result_key IS NOT NULL) AS has_result, result_content_type, result_filename,
+	created_at, started_at, updated_at, finished_at,
+	(SELECT u.organization_id FROM users u WHERE u.id = bulk_jobs.created_by) AS organization_id`
+
+func (r *PostgresBulkRepository) InsertJob(ctx context.Context, kind string, params []byte, createdBy uuid.UUID, total int) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &id, `
+		INSERT INTO bulk_jobs (kind, params, created_by, total)
+		VALUES ($1, $2::jsonb, $3, $4)
+		RETURNING id
+	`, kind, string(params), createdBy, total)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert bulk job", zap.String("kind", kind), zap.Error(err))
+		return uuid.Nil, fmt.Errorf("failed to insert bulk job: %w", err)
+	}
+	return id, nil
+}
+
+func (r *PostgresBulkRepository) GetJob(ctx context.Context, id uuid.UUID) (BulkJob, error) {
+	var job BulkJob
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &job, `SELECT `+bulkJobColumns+` FROM bulk_jobs WHERE id = $1`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return job, ErrBulkJobNotFound
+	}
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch bulk job", zap.String("id", id.String()), zap.Error(err))
+		return job, fmt.Errorf("failed to fetch bulk job: %w", err)
+	}
+	return job, nil
+}
+
+// ClaimNextJob marks the oldest queued job as running on this instance, SKIP LOCKED lets every
+// instance's workers poll the same queue without taking the same job
+func (r *PostgresBulkRepository) ClaimNextJob(ctx context.Context, instance string) (BulkJob, bool, error) {
+	var job BulkJob
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &job, `
+		UPDATE bulk_jobs SET status = 'running', instance = $1, started_at = now(), updated_at = now()
+		WHERE id = (
+			SELECT id FROM bulk_jobs
+			WHERE status = 'queued'
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+bulkJobColumns, instance)
+	if errors.Is(err, sql.ErrNoRows) {
+		return job, false, nil
+	}
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to claim bulk job", zap.Error(err))
+		return job, false, fmt.Errorf("failed to claim bulk job: %w", err)
+	}
+	return job, true, nil
+}
+
+func (r *PostgresBulkRepository) UpdateProgress(ctx context.Context, id uuid.UUID, p Progress) error {
+	itemErrors, err := json.Marshal(p.Errors)
+	if err != nil {
+		return err
+	}
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE bulk_jobs
+		SET total = $2, processed = $3, succeeded = $4, failed = $5, errors = $6::jsonb, updated_at = now()
+		WHERE id = $1
+	`, id, p.Total, p.Processed, p.Succeeded, p.Failed, string(itemErrors))
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to update bulk job progress", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to update bulk job progress: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresBulkRepository) CompleteJob(ctx context.Context, id uuid.UUID, p Progress, result *Result) error {
+	itemErrors, err := json.Marshal(p.Errors)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	var contentType, filename, key *string
+	if result != nil {
+		data, contentType, filename = result.Data, &result.ContentType, &result.Filename
+		if result.Key != "" {
+			key = &result.Key
+		}
+	}
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE bulk_jobs
+		SET status = 'completed', total = $2, processed = $3, succeeded = $4, failed = $5, errors = $6::jsonb,
+			result = $7, result_content_type = $8, result_filename = $9, result_key = $10, updated_at = now(), finished_at = now()
+		WHERE id = $1
+	`, id, p.Total, p.Processed, p.Succeeded, p.Failed, string(itemErrors), data, contentType, filename, key)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to complete bulk job", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to complete bulk job: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresBulkRepository) FailJob(ctx context.Context, id uuid.UUID, p Progress, reason string) error {
+	itemErrors, err := json.Marshal(p.Errors)
+	if err != nil {
+		return err
+	}
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE bulk_jobs
+		SET status = 'failed', total = $2, processed = $3, succeeded = $4, failed = $5, errors = $6::jsonb,
+			error = $7, updated_at = now(), finished_at = now()
+		WHERE id = $1
+	`, id, p.Total, p.Processed, p.Succeeded, p.Failed, string(itemErrors), reason)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to mark bulk job failed", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to mark bulk job failed: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresBulkRepository) GetResult(ctx context.Context, id uuid.UUID) (Result, error) {
+	var row struct {
+		Data        []byte  `db:"result"`
+		ContentType *string `db:"result_content_type"`
+		Filename    *string `db:"result_filename"`
+		Key         *string `db:"result_key"`
+	}
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &row, `
+		SELECT result, result_content_type, result_filename, result_key FROM bulk_jobs
+		WHERE id = $1 AND (result IS NOT NULL OR result_key IS NOT NULL)
+	`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Result{}, ErrResultNotReady
+	}
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch bulk job result", zap.String("id", id.String()), zap.Error(err))
+		return Result{}, fmt.Errorf("failed to fetch bulk job result: %w", err)
+	}
+	result := Result{Data: row.Data}
+	if row.ContentType != nil {
+		result.ContentType = *row.ContentType
+	}
+	if row.Filename != nil {
+		result.Filename = *row.Filename
+	}
+	if row.Key != nil {
+		result.Key = *row.Key
+	}
+	return result, nil
+}
+
+// FailStaleJobs fails running jobs whose worker stopped reporting progress, it was restarted or died
+func (r *PostgresBulkRepository) FailStaleJobs(ctx context.Context, staleBefore time.Time) (int64, error) {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE bulk_jobs SET status = 'failed', error = 'worker stopped before the job finished', finished_at = now()
+		WHERE status = 'running' AND updated_at < $1
+	`, staleBefore)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fail stale bulk jobs", zap.Error(err))
+		return 0, fmt.Errorf("failed to fail stale bulk jobs: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func (r *PostgresBulkRepository) DeleteFinishedJobsBefore(ctx context.Context, before time.Time) (int64, []string, error) {
+	var keys []*string
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &keys, `DELETE FROM bulk_jobs WHERE finished_at < $1 RETURNING result_key`, before)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to purge bulk jobs", zap.Time("before", before), zap.Error(err))
+		return 0, nil, fmt.Errorf("failed to purge bulk jobs: %w", err)
+	}
+	stored := make([]string, 0)
+	for _, key := range keys {
+		if key != nil {
+			stored = append(stored, *key)
+		}
+	}
+	return int64(len(keys)), stored, nil
+}
@@ -0,0 +1,460 @@
+package bulkservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/accounting"
+	"asset/services/asset"
+	"asset/services/department"
+	"asset/services/snapshot"
+	"asset/services/user"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type BulkService interface {
+	SubmitEmployeeExport(ctx context.Context, params EmployeeExportParams, createdBy uuid.UUID) (SubmitRes, error)
+	SubmitAssetImport(ctx context.Context, params AssetImportParams, createdBy uuid.UUID) (SubmitRes, error)
+	SubmitAssetAssign(ctx context.Context, params AssetAssignParams, createdBy uuid.UUID) (SubmitRes, error)
+	SubmitAccountingExport(ctx context.Context, params AccountingExportParams, createdBy uuid.UUID) (SubmitRes, error)
+	SubmitSnapshotExport(ctx context.Context, createdBy uuid.UUID) (SubmitRes, error)
+	GetJob(ctx context.Context, id uuid.UUID, access JobAccess) (BulkJobRes, error)
+	GetResult(ctx context.Context, id uuid.UUID, access JobAccess) (Result, error)
+	StartWorkers(size int)
+	StopWorkers(ctx context.Context)
+	FailStaleJobs(ctx context.Context) error
+	PurgeJobs(ctx context.Context, retention time.Duration) error
+}
+
+var (
+	ErrBulkJobNotFound = models.NewServiceError(http.StatusNotFound, "bulk_job_not_found", "bulk job not found")
+	ErrResultNotReady  = models.NewServiceError(http.StatusConflict, "result_not_ready", "the job has no result to download yet")
+	ErrTooManyItems    = models.NewServiceError(http.StatusBadRequest, "too_many_items", fmt.Sprintf("a bulk job takes at most %d items", maxBulkItems))
+	ErrMissingColumns  = models.NewServiceError(http.StatusBadRequest, "missing_columns", "the csv is missing required columns")
+)
+
+const (
+	maxBulkItems = 5000
+	// item errors kept on the job, the counters still count every failure
+	maxItemErrors = 500
+	// progress is written every this many items or flushInterval, whichever comes first
+	flushEvery    = 100
+	flushInterval = 2 * time.Second
+	// idle workers look for queued jobs this often, a submit on the same instance wakes them at once
+	pollInterval = 2 * time.Second
+	// running jobs that didn't report progress for this long lost their worker
+	staleAfter = 10 * time.Minute
+)
+
+type bulkServiceStruct struct {
+	repo   BulkRepository
+	users  userservice.UserService
+	assets assetservice.AssetService
+	// department counts are refreshed after jobs that import or assign assets
+	departments departmentservice.DepartmentService
+	accounting  accountingservice.AccountingService
+	snapshots   snapshotservice.SnapshotService
+	// results are kept in the bucket and downloaded from it, nil keeps them on the job row
+	storage  providers.StorageProvider
+	logger   providers.ZapLoggerProvider
+	instance string
+
+	wake chan struct{}
+	// cancel stops workers from claiming jobs, cancelRuns stops the jobs they are running
+	cancel     context.CancelFunc
+	cancelRuns context.CancelFunc
+	wg         sync.WaitGroup
+}
+
+func NewBulkService(repo BulkRepository, users userservice.UserService, assets assetservice.AssetService, departments departmentservice.DepartmentService, accounting accountingservice.AccountingService, snapshots snapshotservice.SnapshotService, storage providers.StorageProvider, logger providers.ZapLoggerProvider) BulkService {
+	instance, err := os.Hostname()
+	if err != nil || instance == "" {
+		instance = "unknown"
+	}
+	return &bulkServiceStruct{
+		repo:        repo,
+		users:       users,
+		assets:      assets,
+		departments: departments,
+		accounting:  accounting,
+		snapshots:   snapshots,
+		storage:     storage,
+		logger:      logger,
+		instance:    fmt.Sprintf("%s-%d", instance, os.Getpid()),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+func (s *bulkServiceStruct) SubmitEmployeeExport(ctx context.Context, params EmployeeExportParams, createdBy uuid.UUID) (SubmitRes, error) {
+	return s.submit(ctx, KindEmployeeExport, params, createdBy, 0)
+}
+
+// SubmitAssetImport checks the header up front, rows are validated one by one by the worker so a bad
+// row is reported without rejecting the file
+func (s *bulkServiceStruct) SubmitAssetImport(ctx context.Context, params AssetImportParams, createdBy uuid.UUID) (SubmitRes, error) {
+	if len(params.Rows) > maxBulkItems {
+		return SubmitRes{}, ErrTooManyItems
+	}
+	present := make(map[string]bool, len(params.Header))
+	for _, column := range params.Header {
+		present[column] = true
+	}
+	missing := make([]string, 0)
+	for _, column := range assetImportRequired {
+		if !present[column] {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return SubmitRes{}, ErrMissingColumns.WithDetails(map[string][]string{"missing": missing})
+	}
+	return s.submit(ctx, KindAssetImport, params, createdBy, len(params.Rows))
+}
+
+func (s *bulkServiceStruct) SubmitAssetAssign(ctx context.Context, params AssetAssignParams, createdBy uuid.UUID) (SubmitRes, error) {
+	if len(params.Items) > maxBulkItems {
+		return SubmitRes{}, ErrTooManyItems
+	}
+	return s.submit(ctx, KindAssetAssign, params, createdBy, len(params.Items))
+}
+
+// SubmitAccountingExport checks the period and format up front, the journal is built by the worker
+func (s *bulkServiceStruct) SubmitAccountingExport(ctx context.Context, params AccountingExportParams, createdBy uuid.UUID) (SubmitRes, error) {
+	if _, err := accountingservice.ParsePeriod(params.From, params.To, time.Now()); err != nil {
+		return SubmitRes{}, err
+	}
+	if _, err := accountingservice.ParseFormat(params.Format); err != nil {
+		return SubmitRes{}, err
+	}
+	return s.submit(ctx, KindAccountingExport, params, createdBy, 0)
+}
+
+func (s *bulkServiceStruct) SubmitSnapshotExport(ctx context.Context, createdBy uuid.UUID) (SubmitRes, error) {
+	return s.submit(ctx, KindSnapshotExport, struct{}{}, createdBy, 0)
+}
+
+func (s *bulkServiceStruct) submit(ctx context.Context, kind string, params interface{}, createdBy uuid.UUID, total int) (SubmitRes, error) {
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return SubmitRes{}, fmt.Errorf("failed to encode %s params: %w", kind, err)
+	}
+	id, err := s.repo.InsertJob(ctx, kind, encoded, createdBy, total)
+	if err != nil {
+		return SubmitRes{}, err
+	}
+	s.logger.GetLogger().Info("bulk job queued", zap.String("id", id.String()), zap.String("kind", kind), zap.Int("items", total))
+	// a free worker here picks it up right away, otherwise the next poll anywhere does
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return SubmitRes{JobID: id, Status: StatusQueued, StatusURL: "/api/v1/jobs/" + id.String()}, nil
+}
+
+// GetJob answers not found for jobs the access doesn't reach
+func (s *bulkServiceStruct) GetJob(ctx context.Context, id uuid.UUID, access JobAccess) (BulkJobRes, error) {
+	job, err := s.repo.GetJob(ctx, id)
+	if err != nil {
+		return BulkJobRes{}, err
+	}
+	if !access.canRead(job) {
+		return BulkJobRes{}, ErrBulkJobNotFound
+	}
+	res := BulkJobRes{
+		ID:         job.ID,
+		Kind:       job.Kind,
+		Status:     job.Status,
+		Total:      job.Total,
+		Processed:  job.Processed,
+		Succeeded:  job.Succeeded,
+		Failed:     job.Failed,
+		Errors:     make([]ItemError, 0),
+		Error:      job.Error,
+		CreatedAt:  job.CreatedAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+	}
+	if len(job.Errors) > 0 {
+		if err := json.Unmarshal(job.Errors, &res.Errors); err != nil {
+			return BulkJobRes{}, fmt.Errorf("failed to decode item errors: %w", err)
+		}
+	}
+	if job.HasResult {
+		res.ResultURL = "/api/v1/jobs/" + job.ID.String() + "/result"
+	}
+	return res, nil
+}
+
+func (s *bulkServiceStruct) GetResult(ctx context.Context, id uuid.UUID, access JobAccess) (Result, error) {
+	job, err := s.repo.GetJob(ctx, id)
+	if err != nil {
+		return Result{}, err
+	}
+	if !access.canRead(job) {
+		return Result{}, ErrBulkJobNotFound
+	}
+	result, err := s.repo.GetResult(ctx, id)
+	if err != nil || result.Key == "" {
+		return result, err
+	}
+	if s.storage == nil {
+		return Result{}, fmt.Errorf("result of job %s is in object storage, which is not configured", id)
+	}
+	result.URL, err = s.storage.PresignDownload(ctx, result.Key, result.Filename)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to sign result download: %w", err)
+	}
+	return result, nil
+}
+
+// StartWorkers runs size workers that take queued jobs oldest first, every instance runs its own
+func (s *bulkServiceStruct) StartWorkers(size int) {
+	ctx, cancel := context.WithCancel(context.Background())
+	runCtx, cancelRuns := context.WithCancel(context.Background())
+	s.cancel, s.cancelRuns = cancel, cancelRuns
+	for i := 0; i < size; i++ {
+		s.wg.Add(1)
+		go s.work(ctx, runCtx)
+	}
+	s.logger.GetLogger().Info("bulk job workers started", zap.Int("workers", size))
+}
+
+// StopWorkers lets the workers finish the jobs they are running, jobs still running when ctx ends are
+// failed with what they got through
+func (s *bulkServiceStruct) StopWorkers(ctx context.Context) {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.logger.GetLogger().Warn("bulk jobs still running at the shutdown deadline, stopping them")
+		s.cancelRuns()
+		<-done
+	}
+	s.cancelRuns()
+	s.logger.GetLogger().Info("bulk job workers stopped")
+}
+
+// work claims jobs until ctx ends and runs them with runCtx
+func (s *bulkServiceStruct) work(ctx, runCtx context.Context) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		job, claimed, err := s.repo.ClaimNextJob(ctx, s.instance)
+		if err != nil && ctx.Err() == nil {
+			s.logger.GetLogger().Error("failed to claim bulk job", zap.Error(err))
+		}
+		if claimed {
+			s.process(runCtx, job)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *bulkServiceStruct) process(ctx context.Context, job BulkJob) {
+	s.logger.GetLogger().Info("bulk job started", zap.String("id", job.ID.String()), zap.String("kind", job.Kind))
+	progress := newProgress(ctx, s, job)
+
+	var result *Result
+	var err error
+	func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				s.logger.GetLogger().Error("panic recovered in bulk job", zap.String("id", job.ID.String()), zap.Any("recover_info", rec))
+				err = fmt.Errorf("panic: %v", rec)
+			}
+		}()
+		switch job.Kind {
+		case KindEmployeeExport:
+			result, err = s.runEmployeeExport(ctx, job, progress)
+		case KindAssetImport:
+			result, err = s.runAssetImport(ctx, job, progress)
+		case KindAssetAssign:
+			result, err = s.runAssetAssign(ctx, job, progress)
+		case KindAccountingExport:
+			result, err = s.runAccountingExport(ctx, job, progress)
+		case KindSnapshotExport:
+			result, err = s.runSnapshotExport(ctx, job, progress)
+		default:
+			err = fmt.Errorf("unknown bulk job kind %q", job.Kind)
+		}
+	}()
+
+	// the final state is written even when shutdown cancelled ctx
+	finishCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// even a failed job may have changed some items, exports change none
+	if job.Kind != KindEmployeeExport && job.Kind != KindAccountingExport && job.Kind != KindSnapshotExport {
+		if refreshErr := s.departments.RefreshStats(finishCtx); refreshErr != nil {
+			s.logger.GetLogger().Warn("failed to refresh department stats after bulk job", zap.String("id", job.ID.String()), zap.Error(refreshErr))
+		}
+	}
+	if err != nil {
+		reason := "the job failed, see the item errors for what was done"
+		if ctx.Err() != nil {
+			reason = fmt.Sprintf("stopped by a server shutdown after %d of %d items", progress.Processed, progress.Total)
+		}
+		s.logger.GetLogger().Error("bulk job failed", zap.String("id", job.ID.String()), zap.String("kind", job.Kind), zap.Error(err))
+		if failErr := s.repo.FailJob(finishCtx, job.ID, progress.Progress, reason); failErr != nil {
+			s.logger.GetLogger().Error("failed to record bulk job failure", zap.String("id", job.ID.String()), zap.Error(failErr))
+		}
+		return
+	}
+	if result != nil && s.storage != nil {
+		s.storeResult(job, result)
+	}
+	if err := s.repo.CompleteJob(finishCtx, job.ID, progress.Progress, result); err != nil {
+		s.logger.GetLogger().Error("failed to complete bulk job", zap.String("id", job.ID.String()), zap.Error(err))
+		return
+	}
+	s.logger.GetLogger().Info("bulk job completed", zap.String("id", job.ID.String()), zap.String("kind", job.Kind),
+		zap.Int("succeeded", progress.Succeeded), zap.Int("failed", progress.Failed))
+}
+
+// storeResult moves the file into object storage, if the upload fails it stays on the job row
+func (s *bulkServiceStruct) storeResult(job BulkJob, result *Result) {
+	key := "jobs/" + job.ID.String() + "/" + result.Filename
+	// the provider bounds the upload itself, shutdown shouldn't leave the file behind
+	if err := s.storage.Put(context.Background(), key, result.ContentType, result.Data); err != nil {
+		s.logger.GetLogger().Warn("failed to store bulk job result, keeping it in the database", zap.String("id", job.ID.String()), zap.Error(err))
+		return
+	}
+	result.Key, result.Data = key, nil
+}
+
+// FailStaleJobs is run by the background job, it fails jobs whose instance went away mid run
+func (s *bulkServiceStruct) FailStaleJobs(ctx context.Context) error {
+	failed, err := s.repo.FailStaleJobs(ctx, time.Now().Add(-staleAfter))
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		s.logger.GetLogger().Warn("failed bulk jobs that lost their worker", zap.Int64("jobs", failed))
+	}
+	return nil
+}
+
+// PurgeJobs deletes finished jobs and their results after the retention period, a result that
+// fails to delete from object storage is only logged
+func (s *bulkServiceStruct) PurgeJobs(ctx context.Context, retention time.Duration) error {
+	before := time.Now().Add(-retention)
+	deleted, keys, err := s.repo.DeleteFinishedJobsBefore(ctx, before)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if s.storage == nil {
+			break
+		}
+		if err := s.storage.Delete(ctx, key); err != nil {
+			s.logger.GetLogger().Warn("failed to delete bulk job result", zap.String("key", key), zap.Error(err))
+		}
+	}
+	s.logger.GetLogger().Info("purged bulk jobs", zap.Int64("deleted", deleted), zap.Time("before", before))
+	return nil
+}
+
+// Progress is what a job got through so far
+type Progress struct {
+	Total     int
+	Processed int
+	Succeeded int
+	Failed    int
+	Errors    []ItemError
+}
+
+// progress counts items and writes the counters back now and then so GET /api/jobs/{id} can follow
+type progress struct {
+	Progress
+	ctx       context.Context
+	service   *bulkServiceStruct
+	jobID     uuid.UUID
+	unflushed int
+	flushedAt time.Time
+}
+
+func newProgress(ctx context.Context, service *bulkServiceStruct, job BulkJob) *progress {
+	return &progress{
+		Progress:  Progress{Total: job.Total, Errors: make([]ItemError, 0)},
+		ctx:       ctx,
+		service:   service,
+		jobID:     job.ID,
+		flushedAt: time.Now(),
+	}
+}
+
+func (p *progress) succeed() {
+	p.Processed++
+	p.Succeeded++
+	p.advance()
+}
+
+// fail counts the item as failed and returns the message clients get for it
+func (p *progress) fail(item int, key string, err error) string {
+	p.Processed++
+	p.Failed++
+	code, message := p.service.describeItemError(err)
+	if len(p.Errors) < maxItemErrors {
+		p.Errors = append(p.Errors, ItemError{Item: item, Key: key, Code: code, Message: message})
+	}
+	p.advance()
+	return message
+}
+
+func (p *progress) setTotal(total int) {
+	p.Total = total
+	p.flush()
+}
+
+func (p *progress) advance() {
+	p.unflushed++
+	if p.unflushed >= flushEvery || time.Since(p.flushedAt) >= flushInterval {
+		p.flush()
+	}
+}
+
+// flush failures only delay what clients see, the job carries on
+func (p *progress) flush() {
+	if err := p.service.repo.UpdateProgress(p.ctx, p.jobID, p.Progress); err != nil && p.ctx.Err() == nil {
+		p.service.logger.GetLogger().Warn("failed to update bulk job progress", zap.String("id", p.jobID.String()), zap.Error(err))
+	}
+	p.unflushed = 0
+	p.flushedAt = time.Now()
+}
+
+// describeItemError keeps service errors and validation messages, anything else is logged and
+// reported generically like a 500 would be
+func (s *bulkServiceStruct) describeItemError(err error) (string, string) {
+	var serviceErr *models.ServiceError
+	if errors.As(err, &serviceErr) {
+		return serviceErr.Code, serviceErr.Message
+	}
+	if code, message, ok := describeInputError(err); ok {
+		return code, message
+	}
+	s.logger.GetLogger().Error("bulk job item failed", zap.Error(err))
+	return models.CodeInternal, "failed to process the item"
+}
@@ -0,0 +1,39 @@
+package bulkservice
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobAccess(t *testing.T) {
+	caller, creator := uuid.New(), uuid.New()
+	own, other := uuid.New(), uuid.New()
+	job := BulkJob{ID: uuid.New(), CreatedBy: creator, OrganizationID: &own}
+
+	tests := []struct {
+		name     string
+		access   JobAccess
+		job      BulkJob
+		expected bool
+	}{
+		{name: "own job", access: JobAccess{CallerID: creator}, job: job, expected: true},
+		{name: "someone else's job", access: JobAccess{CallerID: caller}, job: job},
+		{name: "manager of the job's organization", access: JobAccess{CallerID: caller, Manager: true, OrganizationID: &own}, job: job, expected: true},
+		{name: "manager of another organization", access: JobAccess{CallerID: caller, Manager: true, OrganizationID: &other}, job: job},
+		{name: "manager of every organization", access: JobAccess{CallerID: caller, Manager: true}, job: job, expected: true},
+		{
+			// a job whose creator isn't a user belongs to no organization
+			name:   "organization manager and a job without one",
+			access: JobAccess{CallerID: caller, Manager: true, OrganizationID: &own},
+			job:    BulkJob{ID: uuid.New(), CreatedBy: creator},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.access.canRead(tc.job))
+		})
+	}
+}
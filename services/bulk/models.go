@@ -0,0 +1,143 @@
+package bulkservice
+
+import (
+	"asset/models"
+	"asset/services/user"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	KindEmployeeExport = "employee_export"
+	KindAssetImport    = "asset_import"
+	KindAssetAssign    = "asset_assign"
+	// fixed asset journal of a period for quickbooks or netsuite
+	KindAccountingExport = "accounting_export"
+	// archive of every table for recovery drills and copying an environment
+	KindSnapshotExport = "snapshot_export"
+
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+// BulkJob is a queued or finished bulk operation, Params is what the request asked for and is only
+// read by the worker
+type BulkJob struct {
+	ID                uuid.UUID       `db:"id"`
+	Kind              string          `db:"kind"`
+	Status            string          `db:"status"`
+	Params            json.RawMessage `db:"params"`
+	CreatedBy         uuid.UUID       `db:"created_by"`
+	Total             int             `db:"total"`
+	Processed         int             `db:"processed"`
+	Succeeded         int             `db:"succeeded"`
+	Failed            int             `db:"failed"`
+	Errors            json.RawMessage `db:"errors"`
+	Error             *string         `db:"error"`
+	HasResult         bool            `db:"has_result"`
+	ResultContentType *string         `db:"result_content_type"`
+	ResultFilename    *string         `db:"result_filename"`
+	CreatedAt         time.Time       `db:"created_at"`
+	StartedAt         *time.Time      `db:"started_at"`
+	UpdatedAt         time.Time       `db:"updated_at"`
+	FinishedAt        *time.Time      `db:"finished_at"`
+	// the organization of whoever created the job
+	OrganizationID *uuid.UUID `db:"organization_id"`
+}
+
+// JobAccess is who reads a job. Callers reach their own jobs, job managers also reach the jobs
+// created in OrganizationID, or every job when it is nil
+type JobAccess struct {
+	CallerID       uuid.UUID
+	Manager        bool
+	OrganizationID *uuid.UUID
+}
+
+func (a JobAccess) canRead(job BulkJob) bool {
+	if job.CreatedBy == a.CallerID {
+		return true
+	}
+	if !a.Manager {
+		return false
+	}
+	return a.OrganizationID == nil || (job.OrganizationID != nil && *job.OrganizationID == *a.OrganizationID)
+}
+
+// BulkJobRes is the progress of a job as clients see it
+type BulkJobRes struct {
+	ID         uuid.UUID   `json:"id"`
+	Kind       string      `json:"kind"`
+	Status     string      `json:"status"`
+	Total      int         `json:"total"`
+	Processed  int         `json:"processed"`
+	Succeeded  int         `json:"succeeded"`
+	Failed     int         `json:"failed"`
+	Errors     []ItemError `json:"errors"`
+	Error      *string     `json:"error,omitempty"`
+	ResultURL  string      `json:"result_url,omitempty"`
+	CreatedAt  time.Time   `json:"created_at"`
+	StartedAt  *time.Time  `json:"started_at,omitempty"`
+	FinishedAt *time.Time  `json:"finished_at,omitempty"`
+}
+
+// ItemError is why one row or item failed, Item is its 1 based position in the request
+type ItemError struct {
+	Item    int    `json:"item"`
+	Key     string `json:"key,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// SubmitRes answers every submit with where to follow the job
+type SubmitRes struct {
+	JobID     uuid.UUID `json:"job_id"`
+	Status    string    `json:"status"`
+	StatusURL string    `json:"status_url"`
+}
+
+// Result is the file a job leaves for download. With object storage the file is kept under Key and
+// downloaded from URL, otherwise Data is kept on the job row
+type Result struct {
+	Data        []byte
+	ContentType string
+	Filename    string
+	Key         string
+	URL         string
+}
+
+type EmployeeExportParams struct {
+	Format string                     `json:"format"`
+	Filter userservice.EmployeeFilter `json:"filter"`
+}
+
+type AssetImportParams struct {
+	Header []string               `json:"header"`
+	Rows   [][]string             `json:"rows"`
+	Scope  models.DepartmentScope `json:"scope"`
+}
+
+// AssetAssignItem ids are checked per item so one bad id doesn't reject the batch
+type AssetAssignItem struct {
+	AssetID string `json:"asset_id"`
+	UserID  string `json:"user_id"`
+}
+
+type AssetAssignReq struct {
+	Items []AssetAssignItem `json:"items" validate:"required,min=1"`
+}
+
+type AssetAssignParams struct {
+	Items []AssetAssignItem      `json:"items"`
+	Scope models.DepartmentScope `json:"scope"`
+}
+
+// AccountingExportParams months are like 2026-09, both included
+type AccountingExportParams struct {
+	Format string `json:"format"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
@@ -0,0 +1,166 @@
+package chargeservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type ChargeHandler struct {
+	Service        ChargeService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewChargeHandler(service ChargeService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *ChargeHandler {
+	return &ChargeHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+func (h *ChargeHandler) CreateCharge(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in CreateCharge", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	creatorID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in CreateCharge", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	var req CreateChargeReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "validation failed")
+		return
+	}
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in CreateCharge", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	charge, err := h.Service.CreateCharge(r.Context(), req, creatorID, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to create charge", zap.String("employeeID", req.EmployeeID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to create charge")
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, charge)
+}
+
+// GetCharges lists the charges of the employees in scope newest first, settled=false lists what is
+// still owed
+func (h *ChargeHandler) GetCharges(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var filter ChargeFilter
+	if val := query.Get("user_id"); val != "" {
+		employeeID, err := uuid.Parse(val)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, "invalid user_id")
+			return
+		}
+		filter.EmployeeID = &employeeID
+	}
+	if val := query.Get("settled"); val != "" {
+		settled, err := strconv.ParseBool(val)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, "invalid settled")
+			return
+		}
+		filter.Settled = &settled
+	}
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in GetCharges", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+	filter.Scope = scope
+
+	charges, err := h.Service.GetCharges(r.Context(), filter)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch charges", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch charges")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"charges": charges, "limit": filter.Limit, "offset": filter.Offset})
+}
+
+func (h *ChargeHandler) SettleCharge(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in SettleCharge", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	settlerID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in SettleCharge", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	var req SettleChargeReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "validation failed")
+		return
+	}
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in SettleCharge", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	charge, err := h.Service.SettleCharge(r.Context(), req, settlerID, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to settle charge", zap.String("chargeID", req.ID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to settle charge")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, charge)
+}
+
+// GetEmployeeCharges is shown on the employee detail page, every charge of the employee with what is
+// still owed
+func (h *ChargeHandler) GetEmployeeCharges(w http.ResponseWriter, r *http.Request) {
+	employeeID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid user_id")
+		return
+	}
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in GetEmployeeCharges", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	res, err := h.Service.GetEmployeeCharges(r.Context(), employeeID, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch employee charges", zap.String("employeeID", employeeID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch employee charges")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, res)
+}
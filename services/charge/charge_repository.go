@@ -0,0 +1,117 @@
+package chargeservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type ChargeRepository interface {
+	// InsertCharge charges an employee in scope, ErrChargeTarget when there is none or the assignment
+	// isn't theirs
+	InsertCharge(ctx context.Context, req CreateChargeReq, createdBy uuid.UUID, scope models.DepartmentScope) (uuid.UUID, error)
+	GetCharge(ctx context.Context, id uuid.UUID) (Charge, error)
+	GetCharges(ctx context.Context, filter ChargeFilter) ([]Charge, error)
+	// SettleCharge settles an unsettled charge of an employee in scope, ErrChargeNotFound otherwise
+	SettleCharge(ctx context.Context, req SettleChargeReq, settledBy uuid.UUID, scope models.DepartmentScope) error
+}
+
+type PostgresChargeRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewChargeRepository(db *sqlx.DB, log providers.ZapLoggerProvider) ChargeRepository {
+	return &PostgresChargeRepository{
+		DB:     db,
+		Logger: log,
+	}
+}
+
+const chargeQuery = `
+	SELECT c.id, c.employee_id, u.username AS employee_name, c.assignment_id, c.asset_id, a.serial_no, c.kind,
+		c.amount::float8 AS amount, c.reason, c.created_by, c.created_at, c.settled_at IS NOT NULL AS settled,
+		c.settled_at, c.settled_by, c.settlement_note
+	FROM employee_charges c
+	JOIN users u ON u.id = c.employee_id
+	LEFT JOIN assets a ON a.id = c.asset_id`
+
+func (r *PostgresChargeRepository) InsertCharge(ctx context.Context, req CreateChargeReq, createdBy uuid.UUID, scope models.DepartmentScope) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &id, `
+		INSERT INTO employee_charges (employee_id, assignment_id, asset_id, kind, amount, reason, created_by)
+		SELECT u.id, aa.id, aa.asset_id, $3, $4, $5, $6
+		FROM users u
+		LEFT JOIN asset_assign_all aa ON aa.id = $2 AND aa.employee_id = u.id
+		WHERE u.id = $1
+		AND ($2::uuid IS NULL OR aa.id IS NOT NULL)
+		AND ($7 OR u.department_id IS NOT DISTINCT FROM $8)
+		AND ($9::uuid IS NULL OR u.organization_id = $9)
+		RETURNING id
+	`, req.EmployeeID, req.AssignmentID, req.Kind, req.Amount, req.Reason, createdBy,
+		scope.AllDepartments, scope.DepartmentID, scope.OrganizationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, ErrChargeTarget
+		}
+		r.Logger.GetLogger().Error("failed to insert charge", zap.String("employee_id", req.EmployeeID.String()), zap.Error(err))
+		return uuid.Nil, fmt.Errorf("failed to insert charge: %w", err)
+	}
+	return id, nil
+}
+
+func (r *PostgresChargeRepository) GetCharge(ctx context.Context, id uuid.UUID) (Charge, error) {
+	var charge Charge
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &charge, chargeQuery+` WHERE c.id = $1`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Charge{}, ErrChargeNotFound
+		}
+		return Charge{}, fmt.Errorf("failed to fetch charge: %w", err)
+	}
+	return charge, nil
+}
+
+func (r *PostgresChargeRepository) GetCharges(ctx context.Context, filter ChargeFilter) ([]Charge, error) {
+	charges := make([]Charge, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &charges, chargeQuery+`
+		WHERE ($1::uuid IS NULL OR c.employee_id = $1)
+		AND ($2::boolean IS NULL OR (c.settled_at IS NOT NULL) = $2)
+		AND ($3 OR u.department_id IS NOT DISTINCT FROM $4)
+		AND ($5::uuid IS NULL OR u.organization_id = $5)
+		ORDER BY c.created_at DESC, c.id
+		LIMIT NULLIF($6, 0) OFFSET $7
+	`, filter.EmployeeID, filter.Settled, filter.Scope.AllDepartments, filter.Scope.DepartmentID, filter.Scope.OrganizationID,
+		filter.Limit, filter.Offset)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch charges", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch charges: %w", err)
+	}
+	return charges, nil
+}
+
+func (r *PostgresChargeRepository) SettleCharge(ctx context.Context, req SettleChargeReq, settledBy uuid.UUID, scope models.DepartmentScope) error {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE employee_charges c SET settled_at = now(), settled_by = $2, settlement_note = NULLIF($3, '')
+		FROM users u
+		WHERE c.id = $1 AND c.settled_at IS NULL AND u.id = c.employee_id
+		AND ($4 OR u.department_id IS NOT DISTINCT FROM $5)
+		AND ($6::uuid IS NULL OR u.organization_id = $6)
+	`, req.ID, settledBy, req.Note, scope.AllDepartments, scope.DepartmentID, scope.OrganizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to settle charge", zap.String("id", req.ID.String()), zap.Error(err))
+		return fmt.Errorf("failed to settle charge: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrChargeNotFound
+	}
+	return nil
+}
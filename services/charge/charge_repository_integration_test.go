@@ -0,0 +1,72 @@
+//go:build integration
+
+package chargeservice
+
+import (
+	"asset/database/seed"
+	"asset/database/testdb"
+	"asset/models"
+	"asset/providers"
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCharges(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewChargeRepository(db, logger)
+	ctx := context.Background()
+	adminID, developer, designer := seed.ID("user:admin"), seed.ID("user:developer"), seed.ID("user:designer")
+	all := models.DepartmentScope{AllDepartments: true}
+	assignmentID := seed.ID("assignment:laptop-2-designer")
+
+	// the assignment has to be the employee's
+	_, err := repo.InsertCharge(ctx, CreateChargeReq{EmployeeID: developer, AssignmentID: &assignmentID, Kind: ChargeDamaged, Amount: 50, Reason: "dent"}, adminID, all)
+	assert.ErrorIs(t, err, ErrChargeTarget)
+
+	id, err := repo.InsertCharge(ctx, CreateChargeReq{EmployeeID: designer, AssignmentID: &assignmentID, Kind: ChargeDamaged, Amount: 49.99, Reason: "cracked lid"}, adminID, all)
+	require.NoError(t, err)
+	charge, err := repo.GetCharge(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, seed.ID("asset:laptop-2"), *charge.AssetID)
+	assert.Equal(t, 49.99, charge.Amount)
+	assert.False(t, charge.Settled)
+
+	_, err = repo.InsertCharge(ctx, CreateChargeReq{EmployeeID: developer, Kind: ChargeLost, Amount: 15, Reason: "mouse lost"}, adminID, all)
+	require.NoError(t, err)
+
+	// an operations manager doesn't see engineering's charges
+	operations := seed.ID("department:operations")
+	scoped := models.DepartmentScope{DepartmentID: &operations}
+	_, err = repo.InsertCharge(ctx, CreateChargeReq{EmployeeID: developer, Kind: ChargeLost, Amount: 15, Reason: "mouse lost"}, adminID, scoped)
+	assert.ErrorIs(t, err, ErrChargeTarget)
+	charges, err := repo.GetCharges(ctx, ChargeFilter{Scope: scoped})
+	require.NoError(t, err)
+	assert.Empty(t, charges)
+	assert.ErrorIs(t, repo.SettleCharge(ctx, SettleChargeReq{ID: id}, adminID, scoped), ErrChargeNotFound)
+
+	require.NoError(t, repo.SettleCharge(ctx, SettleChargeReq{ID: id, Note: "deducted from salary"}, adminID, all))
+	assert.ErrorIs(t, repo.SettleCharge(ctx, SettleChargeReq{ID: id}, adminID, all), ErrChargeNotFound)
+
+	unsettled := false
+	charges, err = repo.GetCharges(ctx, ChargeFilter{Settled: &unsettled, Scope: all, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, charges, 1)
+	assert.Equal(t, developer, charges[0].EmployeeID)
+
+	charges, err = repo.GetCharges(ctx, ChargeFilter{EmployeeID: &designer, Scope: all})
+	require.NoError(t, err)
+	require.Len(t, charges, 1)
+	assert.True(t, charges[0].Settled)
+	assert.Equal(t, "deducted from salary", *charges[0].SettlementNote)
+	assert.Equal(t, adminID, *charges[0].SettledBy)
+}
@@ -0,0 +1,131 @@
+package chargeservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/audit"
+	"asset/services/notification"
+	"asset/utils"
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ChargeService records what employees owe for lost or damaged equipment, finance lists and settles
+// the charges
+type ChargeService interface {
+	CreateCharge(ctx context.Context, req CreateChargeReq, createdBy uuid.UUID, scope models.DepartmentScope) (Charge, error)
+	GetCharges(ctx context.Context, filter ChargeFilter) ([]Charge, error)
+	SettleCharge(ctx context.Context, req SettleChargeReq, settledBy uuid.UUID, scope models.DepartmentScope) (Charge, error)
+	GetEmployeeCharges(ctx context.Context, employeeID uuid.UUID, scope models.DepartmentScope) (EmployeeChargesRes, error)
+}
+
+var (
+	ErrChargeTarget   = models.NewServiceError(http.StatusNotFound, "charge_target_not_found", "no such employee in your scope, or the assignment isn't theirs")
+	ErrChargeNotFound = models.NewServiceError(http.StatusNotFound, "charge_not_found", "no unsettled charge with that id")
+)
+
+type chargeServiceStruct struct {
+	repo     ChargeRepository
+	audit    auditservice.AuditService
+	notifier notificationservice.NotificationService
+	logger   providers.ZapLoggerProvider
+}
+
+func NewChargeService(repo ChargeRepository, audit auditservice.AuditService, notifier notificationservice.NotificationService, logger providers.ZapLoggerProvider) ChargeService {
+	return &chargeServiceStruct{
+		repo:     repo,
+		audit:    audit,
+		notifier: notifier,
+		logger:   logger,
+	}
+}
+
+// CreateCharge rounds the amount to cents and tells the employee they were charged
+func (s *chargeServiceStruct) CreateCharge(ctx context.Context, req CreateChargeReq, createdBy uuid.UUID, scope models.DepartmentScope) (Charge, error) {
+	req.Amount = math.Round(req.Amount*100) / 100
+	id, err := s.repo.InsertCharge(ctx, req, createdBy, scope)
+	if err != nil {
+		return Charge{}, err
+	}
+	charge, err := s.repo.GetCharge(ctx, id)
+	if err != nil {
+		return Charge{}, err
+	}
+	s.record(ctx, createdBy, "charge.created", charge)
+	if err := s.notifier.Notify(ctx, []uuid.UUID{charge.EmployeeID}, notificationservice.Notification{
+		Category:   notificationservice.CategoryIncident,
+		Title:      "You were charged for equipment",
+		Body:       fmt.Sprintf("%.2f for %s equipment: %s", charge.Amount, charge.Kind, charge.Reason),
+		EntityType: "charge",
+		EntityID:   charge.ID.String(),
+	}); err != nil {
+		s.logger.GetLogger().Error("charge not notified", zap.String("chargeID", charge.ID.String()), zap.Error(err))
+	}
+	return s.localize(ctx, charge), nil
+}
+
+func (s *chargeServiceStruct) GetCharges(ctx context.Context, filter ChargeFilter) ([]Charge, error) {
+	charges, err := s.repo.GetCharges(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range charges {
+		charges[i] = s.localize(ctx, charges[i])
+	}
+	return charges, nil
+}
+
+func (s *chargeServiceStruct) SettleCharge(ctx context.Context, req SettleChargeReq, settledBy uuid.UUID, scope models.DepartmentScope) (Charge, error) {
+	if err := s.repo.SettleCharge(ctx, req, settledBy, scope); err != nil {
+		return Charge{}, err
+	}
+	charge, err := s.repo.GetCharge(ctx, req.ID)
+	if err != nil {
+		return Charge{}, err
+	}
+	s.record(ctx, settledBy, "charge.settled", charge)
+	return s.localize(ctx, charge), nil
+}
+
+// GetEmployeeCharges lists every charge of the employee, newest first
+func (s *chargeServiceStruct) GetEmployeeCharges(ctx context.Context, employeeID uuid.UUID, scope models.DepartmentScope) (EmployeeChargesRes, error) {
+	charges, err := s.GetCharges(ctx, ChargeFilter{EmployeeID: &employeeID, Scope: scope})
+	if err != nil {
+		return EmployeeChargesRes{}, err
+	}
+	res := EmployeeChargesRes{UserID: employeeID, Charges: charges}
+	for _, charge := range charges {
+		if !charge.Settled {
+			res.Outstanding += charge.Amount
+		}
+	}
+	res.Outstanding = math.Round(res.Outstanding*100) / 100
+	return res, nil
+}
+
+func (s *chargeServiceStruct) record(ctx context.Context, actorID uuid.UUID, action string, charge Charge) {
+	if err := s.audit.Record(ctx, auditservice.AuditEntry{
+		ActorID:    &actorID,
+		Action:     action,
+		EntityType: "charge",
+		EntityID:   charge.ID.String(),
+		NewValue:   charge,
+	}); err != nil {
+		s.logger.GetLogger().Warn("failed to audit charge", zap.String("action", action), zap.String("chargeID", charge.ID.String()), zap.Error(err))
+	}
+}
+
+func (s *chargeServiceStruct) localize(ctx context.Context, charge Charge) Charge {
+	loc := utils.LocationFromContext(ctx)
+	charge.CreatedAt = utils.InLocation(charge.CreatedAt, loc)
+	if charge.SettledAt != nil {
+		settled := utils.InLocation(*charge.SettledAt, loc)
+		charge.SettledAt = &settled
+	}
+	return charge
+}
@@ -0,0 +1,85 @@
+package chargeservice
+
+import (
+	"asset/models"
+	"asset/services/audit"
+	"asset/services/notification"
+	"asset/services/testutil"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T) (ChargeService, *MockChargeRepository, *auditservice.MockAuditService, *notificationservice.MockNotificationService) {
+	ctrl := testutil.Controller(t)
+	repo := NewMockChargeRepository(ctrl)
+	audit := auditservice.NewMockAuditService(ctrl)
+	notifier := notificationservice.NewMockNotificationService(ctrl)
+	return NewChargeService(repo, audit, notifier, testutil.Logger(ctrl)), repo, audit, notifier
+}
+
+// the amount is kept to cents, the charge is audited and the employee told even when the audit fails
+func TestCreateCharge(t *testing.T) {
+	svc, repo, audit, notifier := newTestService(t)
+	ctx := context.Background()
+	financeID, employeeID, chargeID := uuid.New(), uuid.New(), uuid.New()
+	scope := models.DepartmentScope{AllDepartments: true}
+	req := CreateChargeReq{EmployeeID: employeeID, Kind: ChargeLost, Amount: 120.456, Reason: "charger lost"}
+
+	repo.EXPECT().InsertCharge(ctx, CreateChargeReq{EmployeeID: employeeID, Kind: ChargeLost, Amount: 120.46, Reason: "charger lost"}, financeID, scope).Return(chargeID, nil)
+	repo.EXPECT().GetCharge(ctx, chargeID).Return(Charge{ID: chargeID, EmployeeID: employeeID, Kind: ChargeLost, Amount: 120.46, Reason: "charger lost"}, nil)
+	audit.EXPECT().Record(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, entry auditservice.AuditEntry) error {
+		assert.Equal(t, "charge.created", entry.Action)
+		assert.Equal(t, &financeID, entry.ActorID)
+		return errors.New("audit down")
+	})
+	notifier.EXPECT().Notify(ctx, []uuid.UUID{employeeID}, gomock.Any()).DoAndReturn(func(_ context.Context, _ []uuid.UUID, n notificationservice.Notification) error {
+		assert.Equal(t, "120.46 for lost equipment: charger lost", n.Body)
+		return nil
+	})
+
+	charge, err := svc.CreateCharge(ctx, req, financeID, scope)
+	require.NoError(t, err)
+	assert.Equal(t, chargeID, charge.ID)
+}
+
+func TestCreateChargeOutOfScope(t *testing.T) {
+	svc, repo, _, _ := newTestService(t)
+	ctx := context.Background()
+	repo.EXPECT().InsertCharge(ctx, gomock.Any(), gomock.Any(), gomock.Any()).Return(uuid.Nil, ErrChargeTarget)
+
+	_, err := svc.CreateCharge(ctx, CreateChargeReq{EmployeeID: uuid.New(), Kind: ChargeDamaged, Amount: 10, Reason: "dent"}, uuid.New(), models.DepartmentScope{})
+	assert.ErrorIs(t, err, ErrChargeTarget)
+}
+
+func TestSettleChargeNotFound(t *testing.T) {
+	svc, repo, _, _ := newTestService(t)
+	ctx := context.Background()
+	req := SettleChargeReq{ID: uuid.New()}
+	repo.EXPECT().SettleCharge(ctx, req, gomock.Any(), gomock.Any()).Return(ErrChargeNotFound)
+
+	_, err := svc.SettleCharge(ctx, req, uuid.New(), models.DepartmentScope{AllDepartments: true})
+	assert.ErrorIs(t, err, ErrChargeNotFound)
+}
+
+// only unsettled charges are owed
+func TestGetEmployeeChargesOutstanding(t *testing.T) {
+	svc, repo, _, _ := newTestService(t)
+	ctx := context.Background()
+	employeeID := uuid.New()
+	scope := models.DepartmentScope{AllDepartments: true}
+	repo.EXPECT().GetCharges(ctx, ChargeFilter{EmployeeID: &employeeID, Scope: scope}).Return([]Charge{
+		{Amount: 10.1}, {Amount: 20.2}, {Amount: 500, Settled: true},
+	}, nil)
+
+	res, err := svc.GetEmployeeCharges(ctx, employeeID, scope)
+	require.NoError(t, err)
+	assert.Equal(t, employeeID, res.UserID)
+	assert.Equal(t, 30.3, res.Outstanding)
+	assert.Len(t, res.Charges, 3)
+}
@@ -0,0 +1,63 @@
+package chargeservice
+
+import (
+	"asset/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ChargeLost    = "lost"
+	ChargeDamaged = "damaged"
+)
+
+// CreateChargeReq charges an employee for equipment, AssignmentID is the assignment it was lost or
+// damaged under and has to be one of the employee's
+type CreateChargeReq struct {
+	EmployeeID   uuid.UUID  `json:"employee_id" validate:"required"`
+	AssignmentID *uuid.UUID `json:"assignment_id,omitempty"`
+	Kind         string     `json:"kind" validate:"required,oneof=lost damaged"`
+	Amount       float64    `json:"amount" validate:"required,gt=0,lte=10000000"`
+	Reason       string     `json:"reason" validate:"required,max=500"`
+}
+
+type SettleChargeReq struct {
+	ID   uuid.UUID `json:"id" validate:"required"`
+	Note string    `json:"note" validate:"max=500"`
+}
+
+// ChargeFilter narrows the charges to the employees in Scope, a Limit of 0 lists them all
+type ChargeFilter struct {
+	EmployeeID *uuid.UUID
+	Settled    *bool
+	Limit      int
+	Offset     int
+	Scope      models.DepartmentScope
+}
+
+// Charge is what an employee owes for lost or damaged equipment, it is settled once SettledAt is set
+type Charge struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	EmployeeID     uuid.UUID  `json:"employee_id" db:"employee_id"`
+	EmployeeName   string     `json:"employee_name" db:"employee_name"`
+	AssignmentID   *uuid.UUID `json:"assignment_id,omitempty" db:"assignment_id"`
+	AssetID        *uuid.UUID `json:"asset_id,omitempty" db:"asset_id"`
+	SerialNo       *string    `json:"serial_no,omitempty" db:"serial_no"`
+	Kind           string     `json:"kind" db:"kind"`
+	Amount         float64    `json:"amount" db:"amount"`
+	Reason         string     `json:"reason" db:"reason"`
+	CreatedBy      uuid.UUID  `json:"created_by" db:"created_by"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	Settled        bool       `json:"settled" db:"settled"`
+	SettledAt      *time.Time `json:"settled_at,omitempty" db:"settled_at"`
+	SettledBy      *uuid.UUID `json:"settled_by,omitempty" db:"settled_by"`
+	SettlementNote *string    `json:"settlement_note,omitempty" db:"settlement_note"`
+}
+
+// EmployeeChargesRes is what the employee detail page shows, Outstanding sums the unsettled charges
+type EmployeeChargesRes struct {
+	UserID      uuid.UUID `json:"user_id"`
+	Outstanding float64   `json:"outstanding"`
+	Charges     []Charge  `json:"charges"`
+}
@@ -0,0 +1,121 @@
+package departmentservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type DepartmentHandler struct {
+	Service        DepartmentService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewDepartmentHandler(service DepartmentService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *DepartmentHandler {
+	return &DepartmentHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+func (h *DepartmentHandler) GetDepartments(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetDepartments request received")
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve scope in GetDepartments", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve organization")
+		return
+	}
+	departments, err := h.Service.GetDepartments(r.Context(), scope.OrganizationID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch departments", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch departments")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"departments": departments})
+}
+
+func (h *DepartmentHandler) CreateDepartment(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("CreateDepartment request received")
+	adminID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in CreateDepartment", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	var req CreateDepartmentReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in CreateDepartment", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in CreateDepartment", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	adminUUID, err := uuid.Parse(adminID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse adminID in CreateDepartment", zap.String("adminID", adminID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	departmentID, err := h.Service.CreateDepartment(r.Context(), req, adminUUID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to create department", zap.String("name", req.Name), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to create department")
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	utils.JSON.NewEncoder(w).Encode(map[string]interface{}{"message": "department created successfully", "department_id": departmentID})
+}
+
+func (h *DepartmentHandler) SetUserDepartment(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("SetUserDepartment request received")
+	adminID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in SetUserDepartment", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	var req SetUserDepartmentReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in SetUserDepartment", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in SetUserDepartment", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	adminUUID, err := uuid.Parse(adminID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse adminID in SetUserDepartment", zap.String("adminID", adminID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve scope in SetUserDepartment", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve organization")
+		return
+	}
+	if err := h.Service.SetUserDepartment(r.Context(), req, adminUUID, scope.OrganizationID); err != nil {
+		h.Logger.GetLogger().Error("Failed to set user department", zap.String("userID", req.UserID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to set user department")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "user department updated successfully"})
+}
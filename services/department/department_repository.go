@@ -0,0 +1,118 @@
+package departmentservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type DepartmentRepository interface {
+	CreateDepartment(ctx context.Context, req CreateDepartmentReq, createdBy uuid.UUID) (uuid.UUID, error)
+	// organizationID limits the reads to one organization, nil reads every organization
+	GetDepartments(ctx context.Context, organizationID *uuid.UUID) ([]DepartmentRes, error)
+	IsDepartmentExists(ctx context.Context, departmentID uuid.UUID, organizationID *uuid.UUID) (bool, error)
+	GetUserDepartment(ctx context.Context, userID uuid.UUID, organizationID *uuid.UUID) (*uuid.UUID, error)
+	UpdateUserDepartment(ctx context.Context, userID uuid.UUID, departmentID *uuid.UUID, updatedBy uuid.UUID) error
+	RefreshDepartmentStats(ctx context.Context) error
+}
+
+type PostgresDepartmentRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewDepartmentRepository(db *sqlx.DB, log providers.ZapLoggerProvider) DepartmentRepository {
+	return &PostgresDepartmentRepository{DB: db, Logger: log}
+}
+
+func (r *PostgresDepartmentRepository) CreateDepartment(ctx context.Context, req CreateDepartmentReq, createdBy uuid.UUID) (uuid.UUID, error) {
+	r.Logger.GetLogger().Info("creating department", zap.String("name", req.Name))
+	var id uuid.UUID
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &id, `
+		INSERT INTO departments (name, location, created_by)
+		VALUES ($1, NULLIF($2, ''), $3)
+		RETURNING id
+	`, req.Name, req.Location, createdBy)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to create department", zap.String("name", req.Name), zap.Error(err))
+		return uuid.Nil, fmt.Errorf("failed to create department: %w", err)
+	}
+	return id, nil
+}
+
+func (r *PostgresDepartmentRepository) GetDepartments(ctx context.Context, organizationID *uuid.UUID) ([]DepartmentRes, error) {
+	r.Logger.GetLogger().Info("fetching departments")
+	departments := make([]DepartmentRes, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &departments, `
+		SELECT
+			d.id, d.name, d.location, d.created_at,
+			COALESCE(ds.member_count, 0) AS member_count,
+			COALESCE(ds.asset_count, 0) AS asset_count
+		FROM departments d
+		LEFT JOIN department_stats ds ON ds.department_id = d.id
+		WHERE d.archived_at IS NULL
+		AND ($1::uuid IS NULL OR d.organization_id = $1)
+		ORDER BY d.name
+	`, organizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch departments", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch departments: %w", err)
+	}
+	return departments, nil
+}
+
+// RefreshDepartmentStats recomputes the counts GetDepartments reads, reads go on while it runs
+func (r *PostgresDepartmentRepository) RefreshDepartmentStats(ctx context.Context) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `REFRESH MATERIALIZED VIEW CONCURRENTLY department_stats`)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to refresh department stats", zap.Error(err))
+		return fmt.Errorf("failed to refresh department stats: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresDepartmentRepository) IsDepartmentExists(ctx context.Context, departmentID uuid.UUID, organizationID *uuid.UUID) (bool, error) {
+	var exists bool
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &exists, `
+		SELECT EXISTS(
+			SELECT 1 FROM departments
+			WHERE id = $1 AND archived_at IS NULL AND ($2::uuid IS NULL OR organization_id = $2)
+		)
+	`, departmentID, organizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to check department existence", zap.String("department_id", departmentID.String()), zap.Error(err))
+		return false, fmt.Errorf("failed to check department existence: %w", err)
+	}
+	return exists, nil
+}
+
+func (r *PostgresDepartmentRepository) GetUserDepartment(ctx context.Context, userID uuid.UUID, organizationID *uuid.UUID) (*uuid.UUID, error) {
+	var departmentID *uuid.UUID
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &departmentID, `
+		SELECT department_id FROM users
+		WHERE id = $1 AND archived_at IS NULL AND ($2::uuid IS NULL OR organization_id = $2)
+		FOR UPDATE
+	`, userID, organizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch user department", zap.String("user_id", userID.String()), zap.Error(err))
+		return nil, err
+	}
+	return departmentID, nil
+}
+
+func (r *PostgresDepartmentRepository) UpdateUserDepartment(ctx context.Context, userID uuid.UUID, departmentID *uuid.UUID, updatedBy uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE users SET department_id = $1, updated_by = $2
+		WHERE id = $3 AND archived_at IS NULL
+	`, departmentID, updatedBy, userID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to update user department", zap.String("user_id", userID.String()), zap.Error(err))
+		return fmt.Errorf("failed to update user department: %w", err)
+	}
+	return nil
+}
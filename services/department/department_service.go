@@ -0,0 +1,116 @@
+package departmentservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/audit"
+	"asset/services/event"
+	"asset/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type DepartmentService interface {
+	CreateDepartment(ctx context.Context, req CreateDepartmentReq, adminID uuid.UUID) (uuid.UUID, error)
+	// organizationID is the caller's scope.OrganizationID, nil for a platform admin
+	GetDepartments(ctx context.Context, organizationID *uuid.UUID) ([]DepartmentRes, error)
+	SetUserDepartment(ctx context.Context, req SetUserDepartmentReq, adminID uuid.UUID, organizationID *uuid.UUID) error
+	// RefreshStats recomputes the member and asset counts of the departments, the background job runs
+	// it and bulk jobs call it when they finish
+	RefreshStats(ctx context.Context) error
+}
+
+type departmentServiceStruct struct {
+	repo   DepartmentRepository
+	db     *sqlx.DB
+	logger providers.ZapLoggerProvider
+	audit  auditservice.AuditService
+	events eventservice.EventService
+	cache  providers.UserCacheProvider
+}
+
+var (
+	ErrDepartmentNotFound = models.NewServiceError(http.StatusNotFound, "department_not_found", "department not found")
+	ErrUserNotFound       = models.NewServiceError(http.StatusNotFound, "user_not_found", "user not found")
+)
+
+func NewDepartmentService(repo DepartmentRepository, db *sqlx.DB, logger providers.ZapLoggerProvider, audit auditservice.AuditService, events eventservice.EventService, cache providers.UserCacheProvider) DepartmentService {
+	return &departmentServiceStruct{repo: repo, db: db, logger: logger, audit: audit, events: events, cache: cache}
+}
+
+func (s *departmentServiceStruct) CreateDepartment(ctx context.Context, req CreateDepartmentReq, adminID uuid.UUID) (uuid.UUID, error) {
+	id, err := s.repo.CreateDepartment(ctx, req, adminID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	s.logger.GetLogger().Info("department created", zap.String("departmentID", id.String()), zap.String("name", req.Name))
+	return id, nil
+}
+
+// GetDepartments counts members and assets as of the last RefreshStats
+func (s *departmentServiceStruct) GetDepartments(ctx context.Context, organizationID *uuid.UUID) ([]DepartmentRes, error) {
+	return s.repo.GetDepartments(ctx, organizationID)
+}
+
+func (s *departmentServiceStruct) RefreshStats(ctx context.Context) error {
+	return s.repo.RefreshDepartmentStats(ctx)
+}
+
+// SetUserDepartment only moves users and departments of the caller's organization, others look missing
+func (s *departmentServiceStruct) SetUserDepartment(ctx context.Context, req SetUserDepartmentReq, adminID uuid.UUID, organizationID *uuid.UUID) (err error) {
+	userID := uuid.MustParse(req.UserID)
+	var departmentID *uuid.UUID
+	if req.DepartmentID != "" {
+		id := uuid.MustParse(req.DepartmentID)
+		exists, err := s.repo.IsDepartmentExists(ctx, id, organizationID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrDepartmentNotFound
+		}
+		departmentID = &id
+	}
+
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		previous, err := s.repo.GetUserDepartment(ctx, userID, organizationID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrUserNotFound
+			}
+			return fmt.Errorf("failed to fetch user department: %w", err)
+		}
+		if err = s.repo.UpdateUserDepartment(ctx, userID, departmentID, adminID); err != nil {
+			return err
+		}
+		if err = s.events.Emit(ctx, eventservice.DomainEvent{
+			Type:          eventservice.UserDepartmentChanged,
+			AggregateType: eventservice.AggregateUser,
+			AggregateID:   userID,
+			ActorID:       &adminID,
+			Data:          map[string]interface{}{"user_id": userID, "previous_department_id": previous, "department_id": departmentID},
+		}); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, auditservice.AuditEntry{
+			ActorID:    &adminID,
+			Action:     "user.department_changed",
+			EntityType: "user",
+			EntityID:   userID.String(),
+			OldValue:   map[string]interface{}{"department_id": previous},
+			NewValue:   map[string]interface{}{"department_id": departmentID},
+		})
+	})
+	if err != nil {
+		return err
+	}
+	s.cache.InvalidateUsers(ctx, userID)
+	return nil
+}
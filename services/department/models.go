@@ -0,0 +1,27 @@
+package departmentservice
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreateDepartmentReq struct {
+	Name     string `json:"name" validate:"required"`
+	Location string `json:"location,omitempty"`
+}
+
+type DepartmentRes struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Location    *string   `json:"location,omitempty" db:"location"`
+	MemberCount int       `json:"member_count" db:"member_count"`
+	AssetCount  int       `json:"asset_count" db:"asset_count"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// moves a user to a department, an empty department_id removes the user from any department
+type SetUserDepartmentReq struct {
+	UserID       string `json:"user_id" validate:"required,uuid"`
+	DepartmentID string `json:"department_id,omitempty" validate:"omitempty,uuid"`
+}
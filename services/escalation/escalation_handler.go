@@ -0,0 +1,60 @@
+package escalationservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+type EscalationHandler struct {
+	Service        EscalationService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewEscalationHandler(service EscalationService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *EscalationHandler {
+	return &EscalationHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+// GetEscalations lists the active escalations of the departments in scope, latest first.
+// resolved=true lists the resolved ones too
+func (h *EscalationHandler) GetEscalations(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := EscalationFilter{Kind: query.Get("kind")}
+	if filter.Kind != "" && filter.Kind != KindOverdueReturn && filter.Kind != KindPendingApproval {
+		utils.RespondError(w, http.StatusBadRequest, errors.New("kind must be overdue_return or pending_approval"), "invalid kind")
+		return
+	}
+	if val := query.Get("resolved"); val != "" {
+		resolved, err := strconv.ParseBool(val)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, "invalid resolved")
+			return
+		}
+		filter.Resolved = resolved
+	}
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in GetEscalations", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+	filter.Scope = scope
+
+	escalations, err := h.Service.GetEscalations(r.Context(), filter)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch escalations", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch escalations")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"escalations": escalations, "limit": filter.Limit, "offset": filter.Offset})
+}
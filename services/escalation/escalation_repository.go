@@ -0,0 +1,189 @@
+package escalationservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type EscalationRepository interface {
+	// GetOverdueReturns lists the open return requests overdue for afterDays that the step at level
+	// hasn't fired for, a request is overdue overdueDays after it was raised
+	GetOverdueReturns(ctx context.Context, overdueDays, afterDays, level int) ([]EscalationItem, error)
+	// GetPendingApprovals lists the purchase requests and orders waiting for a decision for afterDays
+	// that the step at level hasn't fired for in the stage they wait in
+	GetPendingApprovals(ctx context.Context, afterDays, level int) ([]EscalationItem, error)
+	// InsertEscalation records the step at level fired for the item, false when it already was
+	InsertEscalation(ctx context.Context, kind string, item EscalationItem, level int, target string) (bool, error)
+	// ResolveEscalations closes the escalations of items that left the stage they waited in
+	ResolveEscalations(ctx context.Context) (int64, error)
+	GetEscalations(ctx context.Context, filter EscalationFilter) ([]Escalation, error)
+	// GetManagerIDs returns the employee managers of the department, admins aren't included
+	GetManagerIDs(ctx context.Context, departmentID *uuid.UUID) ([]uuid.UUID, error)
+	GetAdminIDs(ctx context.Context) ([]uuid.UUID, error)
+}
+
+type PostgresEscalationRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewEscalationRepository(db *sqlx.DB, log providers.ZapLoggerProvider) EscalationRepository {
+	return &PostgresEscalationRepository{
+		DB:     db,
+		Logger: log,
+	}
+}
+
+func (r *PostgresEscalationRepository) GetOverdueReturns(ctx context.Context, overdueDays, afterDays, level int) ([]EscalationItem, error) {
+	items := make([]EscalationItem, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &items, `
+		SELECT 'return_request' AS entity_type, rr.id AS entity_id, rr.status AS stage, a.department_id,
+			u.username || ' has not returned ' || a.brand || ' ' || a.model || ' (' || a.serial_no || ')' AS summary,
+			rr.created_at + make_interval(days => $1) AS waiting_since
+		FROM asset_return_requests rr
+		JOIN assets a ON a.id = rr.asset_id
+		JOIN users u ON u.id = rr.employee_id
+		WHERE rr.status = 'open'
+		AND rr.created_at + make_interval(days => $1) <= now() - make_interval(days => $2)
+		AND NOT EXISTS (
+			SELECT 1 FROM escalations e
+			WHERE e.entity_type = 'return_request' AND e.entity_id = rr.id AND e.stage = rr.status AND e.level = $3
+		)
+		ORDER BY rr.created_at
+	`, overdueDays, afterDays, level)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch overdue returns to escalate: %w", err)
+	}
+	return items, nil
+}
+
+// a purchase request waits for finance from when it was reviewed
+func (r *PostgresEscalationRepository) GetPendingApprovals(ctx context.Context, afterDays, level int) ([]EscalationItem, error) {
+	items := make([]EscalationItem, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &items, `
+		SELECT * FROM (
+			SELECT 'purchase_request' AS entity_type, pr.id AS entity_id, pr.status AS stage, pr.department_id,
+				pr.number || ' for ' || pr.quantity::text || ' ' || pr.type::text || ' asked by ' || u.username ||
+					CASE WHEN pr.status = 'pending_review' THEN ' waits for review' ELSE ' waits for finance' END AS summary,
+				CASE WHEN pr.status = 'pending_finance' THEN COALESCE(pr.reviewed_at, pr.created_at) ELSE pr.created_at END AS waiting_since
+			FROM purchase_requests pr
+			JOIN users u ON u.id = pr.requested_by
+			WHERE pr.status IN ('pending_review', 'pending_finance')
+			UNION ALL
+			SELECT 'purchase_order', po.id, po.status, po.department_id,
+				po.number || ' from ' || po.vendor || ' waits for approval', po.created_at
+			FROM purchase_orders po
+			WHERE po.status = 'pending_approval'
+		) pending
+		WHERE pending.waiting_since <= now() - make_interval(days => $1)
+		AND NOT EXISTS (
+			SELECT 1 FROM escalations e
+			WHERE e.entity_type = pending.entity_type AND e.entity_id = pending.entity_id
+			AND e.stage = pending.stage AND e.level = $2
+		)
+		ORDER BY pending.waiting_since
+	`, afterDays, level)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pending approvals to escalate: %w", err)
+	}
+	return items, nil
+}
+
+func (r *PostgresEscalationRepository) InsertEscalation(ctx context.Context, kind string, item EscalationItem, level int, target string) (bool, error) {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO escalations (kind, entity_type, entity_id, stage, level, target, department_id, summary, waiting_since)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (entity_type, entity_id, stage, level) DO NOTHING
+	`, kind, item.EntityType, item.EntityID, item.Stage, level, target, item.DepartmentID, item.Summary, item.WaitingSince)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert escalation", zap.String("entityID", item.EntityID.String()), zap.Int("level", level), zap.Error(err))
+		return false, fmt.Errorf("failed to insert escalation: %w", err)
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to insert escalation: %w", err)
+	}
+	return inserted > 0, nil
+}
+
+func (r *PostgresEscalationRepository) ResolveEscalations(ctx context.Context) (int64, error) {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE escalations e SET resolved_at = now()
+		WHERE e.resolved_at IS NULL
+		AND NOT EXISTS (
+			SELECT 1 FROM asset_return_requests rr
+			WHERE e.entity_type = 'return_request' AND rr.id = e.entity_id AND rr.status = e.stage
+			UNION ALL
+			SELECT 1 FROM purchase_requests pr
+			WHERE e.entity_type = 'purchase_request' AND pr.id = e.entity_id AND pr.status = e.stage
+			UNION ALL
+			SELECT 1 FROM purchase_orders po
+			WHERE e.entity_type = 'purchase_order' AND po.id = e.entity_id AND po.status = e.stage
+		)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve escalations: %w", err)
+	}
+	resolved, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve escalations: %w", err)
+	}
+	return resolved, nil
+}
+
+func (r *PostgresEscalationRepository) GetEscalations(ctx context.Context, filter EscalationFilter) ([]Escalation, error) {
+	escalations := make([]Escalation, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &escalations, `
+		SELECT e.id, e.kind, e.entity_type, e.entity_id, e.stage, e.level, e.target, e.department_id,
+			d.name AS department_name, e.summary, e.waiting_since,
+			extract(day FROM COALESCE(e.resolved_at, now()) - e.waiting_since)::int AS days_waiting,
+			e.escalated_at, e.resolved_at
+		FROM escalations e
+		LEFT JOIN departments d ON d.id = e.department_id
+		WHERE ($1 OR e.resolved_at IS NULL)
+		AND ($2 = '' OR e.kind = $2)
+		AND ($3 OR e.department_id IS NOT DISTINCT FROM $4)
+		AND ($5::uuid IS NULL OR d.organization_id = $5)
+		ORDER BY e.escalated_at DESC, e.level DESC
+		LIMIT NULLIF($6, 0) OFFSET $7
+	`, filter.Resolved, filter.Kind, filter.Scope.AllDepartments, filter.Scope.DepartmentID, filter.Scope.OrganizationID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch escalations: %w", err)
+	}
+	return escalations, nil
+}
+
+func (r *PostgresEscalationRepository) GetManagerIDs(ctx context.Context, departmentID *uuid.UUID) ([]uuid.UUID, error) {
+	managerIDs := []uuid.UUID{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &managerIDs, `
+		SELECT DISTINCT u.id
+		FROM users u
+		JOIN user_roles ur ON ur.user_id = u.id AND ur.archived_at IS NULL
+		WHERE u.archived_at IS NULL AND ur.role = 'employee_manager'
+		AND $1::uuid IS NOT NULL AND u.department_id = $1
+	`, departmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch employee managers: %w", err)
+	}
+	return managerIDs, nil
+}
+
+func (r *PostgresEscalationRepository) GetAdminIDs(ctx context.Context) ([]uuid.UUID, error) {
+	adminIDs := []uuid.UUID{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &adminIDs, `
+		SELECT DISTINCT u.id
+		FROM users u
+		JOIN user_roles ur ON ur.user_id = u.id AND ur.archived_at IS NULL
+		WHERE u.archived_at IS NULL AND ur.role = 'admin'
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch admins: %w", err)
+	}
+	return adminIDs, nil
+}
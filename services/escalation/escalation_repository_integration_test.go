@@ -0,0 +1,81 @@
+//go:build integration
+
+package escalationservice
+
+import (
+	"asset/database/seed"
+	"asset/database/testdb"
+	"asset/models"
+	"asset/providers"
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestEscalations(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewEscalationRepository(db, logger)
+	ctx := context.Background()
+	engineering := seed.ID("department:engineering")
+
+	// the intern was asked to return their laptop 20 days ago, overdue for 6 days
+	var requestID uuid.UUID
+	require.NoError(t, db.Get(&requestID, `
+		INSERT INTO asset_return_requests (asset_id, employee_id, reason, created_at)
+		VALUES ($1, $2, 'leaving', now() - interval '20 days') RETURNING id
+	`, seed.ID("asset:laptop-2"), seed.ID("user:intern")))
+
+	items, err := repo.GetOverdueReturns(ctx, 14, 3, 1)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, requestID, items[0].EntityID)
+	item := items[0]
+	items, err = repo.GetOverdueReturns(ctx, 14, 7, 2)
+	require.NoError(t, err)
+	assert.Empty(t, items, "not overdue long enough for the admins")
+
+	inserted, err := repo.InsertEscalation(ctx, KindOverdueReturn, item, 1, models.EscalateManager)
+	require.NoError(t, err)
+	assert.True(t, inserted)
+	inserted, err = repo.InsertEscalation(ctx, KindOverdueReturn, item, 1, models.EscalateManager)
+	require.NoError(t, err)
+	assert.False(t, inserted, "fired once")
+	items, err = repo.GetOverdueReturns(ctx, 14, 3, 1)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+
+	managerIDs, err := repo.GetManagerIDs(ctx, &engineering)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{seed.ID("user:employee-manager")}, managerIDs)
+	managerIDs, err = repo.GetManagerIDs(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, managerIDs)
+
+	escalations, err := repo.GetEscalations(ctx, EscalationFilter{Scope: models.DepartmentScope{AllDepartments: true}})
+	require.NoError(t, err)
+	require.Len(t, escalations, 1)
+	assert.Equal(t, 6, escalations[0].DaysWaiting)
+
+	// the laptop came back, the escalation is resolved and drops off the list
+	_, err = db.Exec(`UPDATE asset_return_requests SET status = 'completed', resolved_at = now() WHERE id = $1`, requestID)
+	require.NoError(t, err)
+	resolved, err := repo.ResolveEscalations(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, resolved)
+	escalations, err = repo.GetEscalations(ctx, EscalationFilter{Scope: models.DepartmentScope{AllDepartments: true}})
+	require.NoError(t, err)
+	assert.Empty(t, escalations)
+	escalations, err = repo.GetEscalations(ctx, EscalationFilter{Resolved: true, Kind: KindOverdueReturn, Scope: models.DepartmentScope{AllDepartments: true}})
+	require.NoError(t, err)
+	assert.Len(t, escalations, 1)
+}
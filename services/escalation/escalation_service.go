@@ -0,0 +1,139 @@
+package escalationservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/notification"
+	"asset/utils"
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// EscalationService walks items left waiting up their escalation chain: once an item has waited as
+// long as a step asks, the step's target is told once. The chains come from the jobs config, days of
+// overdue returns count from when the return became overdue
+type EscalationService interface {
+	EscalateOverdueReturns(ctx context.Context) error
+	EscalatePendingApprovals(ctx context.Context) error
+	GetEscalations(ctx context.Context, filter EscalationFilter) ([]Escalation, error)
+}
+
+type escalationServiceStruct struct {
+	repo     EscalationRepository
+	db       *sqlx.DB
+	notifier notificationservice.NotificationService
+	logger   providers.ZapLoggerProvider
+	config   models.JobsConfig
+}
+
+func NewEscalationService(repo EscalationRepository, db *sqlx.DB, notifier notificationservice.NotificationService, logger providers.ZapLoggerProvider, config models.JobsConfig) EscalationService {
+	return &escalationServiceStruct{
+		repo:     repo,
+		db:       db,
+		notifier: notifier,
+		logger:   logger,
+		config:   config,
+	}
+}
+
+// EscalateOverdueReturns is run by the check_overdue_returns job after the first overdue reminder
+func (s *escalationServiceStruct) EscalateOverdueReturns(ctx context.Context) error {
+	return s.escalate(ctx, KindOverdueReturn, s.config.OverdueReturnEscalations, func(afterDays, level int) ([]EscalationItem, error) {
+		return s.repo.GetOverdueReturns(ctx, s.config.ReturnOverdueDays, afterDays, level)
+	})
+}
+
+func (s *escalationServiceStruct) EscalatePendingApprovals(ctx context.Context) error {
+	return s.escalate(ctx, KindPendingApproval, s.config.PendingApprovalEscalations, func(afterDays, level int) ([]EscalationItem, error) {
+		return s.repo.GetPendingApprovals(ctx, afterDays, level)
+	})
+}
+
+func (s *escalationServiceStruct) GetEscalations(ctx context.Context, filter EscalationFilter) ([]Escalation, error) {
+	escalations, err := s.repo.GetEscalations(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	loc := utils.LocationFromContext(ctx)
+	for i := range escalations {
+		escalations[i].WaitingSince = utils.InLocation(escalations[i].WaitingSince, loc)
+		escalations[i].EscalatedAt = utils.InLocation(escalations[i].EscalatedAt, loc)
+		if escalations[i].ResolvedAt != nil {
+			resolved := utils.InLocation(*escalations[i].ResolvedAt, loc)
+			escalations[i].ResolvedAt = &resolved
+		}
+	}
+	return escalations, nil
+}
+
+// escalate closes the escalations of items that moved on and fires every step an item has reached,
+// an item that waited past several steps since the last run is escalated to all of them
+func (s *escalationServiceStruct) escalate(ctx context.Context, kind string, steps []models.EscalationStep, pending func(afterDays, level int) ([]EscalationItem, error)) error {
+	resolved, err := s.repo.ResolveEscalations(ctx)
+	if err != nil {
+		return err
+	}
+	escalated := 0
+	for i, step := range steps {
+		level := i + 1
+		items, err := pending(step.AfterDays, level)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := s.fire(ctx, kind, item, step, level, len(steps)); err != nil {
+				s.logger.GetLogger().Error("failed to escalate", zap.String("kind", kind), zap.String("entityID", item.EntityID.String()), zap.Int("level", level), zap.Error(err))
+				continue
+			}
+			escalated++
+		}
+	}
+	if resolved > 0 || escalated > 0 {
+		s.logger.GetLogger().Info("escalations checked", zap.String("kind", kind), zap.Int64("resolved", resolved), zap.Int("escalated", escalated))
+	}
+	return nil
+}
+
+// fire records the step and tells its target in one transaction, a department without employee
+// managers escalates to the admins
+func (s *escalationServiceStruct) fire(ctx context.Context, kind string, item EscalationItem, step models.EscalationStep, level, levels int) error {
+	var recipients []uuid.UUID
+	var err error
+	if step.Notify == models.EscalateManager {
+		if recipients, err = s.repo.GetManagerIDs(ctx, item.DepartmentID); err != nil {
+			return err
+		}
+	}
+	if len(recipients) == 0 {
+		if recipients, err = s.repo.GetAdminIDs(ctx); err != nil {
+			return err
+		}
+	}
+
+	days := int(math.Floor(time.Since(item.WaitingSince).Hours() / 24))
+	n := notificationservice.Notification{
+		Category:   notificationservice.CategoryOverdue,
+		Title:      "Overdue return escalated",
+		Body:       fmt.Sprintf("%s, overdue for %d days. Escalated to you, step %d of %d", item.Summary, days, level, levels),
+		EntityType: item.EntityType,
+		EntityID:   item.EntityID.String(),
+	}
+	if kind == KindPendingApproval {
+		n.Category = notificationservice.CategoryApproval
+		n.Title = "Pending approval escalated"
+		n.Body = fmt.Sprintf("%s, for %d days. Escalated to you, step %d of %d", item.Summary, days, level, levels)
+	}
+	return utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		inserted, err := s.repo.InsertEscalation(ctx, kind, item, level, step.Notify)
+		if err != nil || !inserted {
+			return err
+		}
+		return s.notifier.Notify(ctx, recipients, n)
+	})
+}
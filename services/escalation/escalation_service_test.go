@@ -0,0 +1,104 @@
+package escalationservice
+
+import (
+	"asset/models"
+	"asset/services/notification"
+	"asset/services/testutil"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testConfig = models.JobsConfig{
+	ReturnOverdueDays: 14,
+	OverdueReturnEscalations: []models.EscalationStep{
+		{AfterDays: 3, Notify: models.EscalateManager},
+		{AfterDays: 7, Notify: models.EscalateAdmin},
+	},
+	PendingApprovalEscalations: []models.EscalationStep{
+		{AfterDays: 2, Notify: models.EscalateManager},
+	},
+}
+
+func newTestService(t *testing.T) (EscalationService, *MockEscalationRepository, *notificationservice.MockNotificationService, sqlmock.Sqlmock) {
+	ctrl := testutil.Controller(t)
+	db, mock := testutil.DB(t)
+	repo := NewMockEscalationRepository(ctrl)
+	notifier := notificationservice.NewMockNotificationService(ctrl)
+	return NewEscalationService(repo, db, notifier, testutil.Logger(ctrl), testConfig), repo, notifier, mock
+}
+
+// a return overdue for 8 days reached both steps, the manager and then the admins are told
+func TestEscalateOverdueReturns(t *testing.T) {
+	svc, repo, notifier, db := newTestService(t)
+	ctx := context.Background()
+	departmentID, managerID, adminID := uuid.New(), uuid.New(), uuid.New()
+	item := EscalationItem{EntityType: "return_request", EntityID: uuid.New(), Stage: "open", DepartmentID: &departmentID,
+		Summary: "intern has not returned Dell XPS", WaitingSince: time.Now().Add(-8*24*time.Hour - time.Hour)}
+
+	repo.EXPECT().ResolveEscalations(gomock.Any()).Return(int64(0), nil)
+	repo.EXPECT().GetOverdueReturns(gomock.Any(), 14, 3, 1).Return([]EscalationItem{item}, nil)
+	repo.EXPECT().GetManagerIDs(gomock.Any(), &departmentID).Return([]uuid.UUID{managerID}, nil)
+	db.ExpectBegin()
+	repo.EXPECT().InsertEscalation(gomock.Any(), KindOverdueReturn, item, 1, models.EscalateManager).Return(true, nil)
+	notifier.EXPECT().Notify(gomock.Any(), []uuid.UUID{managerID}, gomock.Any()).DoAndReturn(func(_ context.Context, _ []uuid.UUID, n notificationservice.Notification) error {
+		assert.Equal(t, notificationservice.CategoryOverdue, n.Category)
+		assert.Equal(t, "intern has not returned Dell XPS, overdue for 8 days. Escalated to you, step 1 of 2", n.Body)
+		return nil
+	})
+	db.ExpectCommit()
+	repo.EXPECT().GetOverdueReturns(gomock.Any(), 14, 7, 2).Return([]EscalationItem{item}, nil)
+	repo.EXPECT().GetAdminIDs(gomock.Any()).Return([]uuid.UUID{adminID}, nil)
+	db.ExpectBegin()
+	repo.EXPECT().InsertEscalation(gomock.Any(), KindOverdueReturn, item, 2, models.EscalateAdmin).Return(true, nil)
+	notifier.EXPECT().Notify(gomock.Any(), []uuid.UUID{adminID}, gomock.Any()).Return(nil)
+	db.ExpectCommit()
+
+	require.NoError(t, svc.EscalateOverdueReturns(ctx))
+	assert.NoError(t, db.ExpectationsWereMet())
+}
+
+func TestEscalatePendingApprovals(t *testing.T) {
+	item := EscalationItem{EntityType: "purchase_order", EntityID: uuid.New(), Stage: "pending_approval",
+		Summary: "PO-000001 from Dell waits for approval", WaitingSince: time.Now().Add(-3 * 24 * time.Hour)}
+	adminID := uuid.New()
+
+	t.Run("a department without managers escalates to the admins", func(t *testing.T) {
+		svc, repo, notifier, db := newTestService(t)
+		repo.EXPECT().ResolveEscalations(gomock.Any()).Return(int64(1), nil)
+		repo.EXPECT().GetPendingApprovals(gomock.Any(), 2, 1).Return([]EscalationItem{item}, nil)
+		repo.EXPECT().GetManagerIDs(gomock.Any(), nil).Return([]uuid.UUID{}, nil)
+		repo.EXPECT().GetAdminIDs(gomock.Any()).Return([]uuid.UUID{adminID}, nil)
+		db.ExpectBegin()
+		repo.EXPECT().InsertEscalation(gomock.Any(), KindPendingApproval, item, 1, models.EscalateManager).Return(true, nil)
+		notifier.EXPECT().Notify(gomock.Any(), []uuid.UUID{adminID}, gomock.Any()).DoAndReturn(func(_ context.Context, _ []uuid.UUID, n notificationservice.Notification) error {
+			assert.Equal(t, notificationservice.CategoryApproval, n.Category)
+			assert.Equal(t, "Pending approval escalated", n.Title)
+			return nil
+		})
+		db.ExpectCommit()
+
+		require.NoError(t, svc.EscalatePendingApprovals(context.Background()))
+		assert.NoError(t, db.ExpectationsWereMet())
+	})
+
+	t.Run("a step another instance already fired notifies nobody", func(t *testing.T) {
+		svc, repo, _, db := newTestService(t)
+		repo.EXPECT().ResolveEscalations(gomock.Any()).Return(int64(0), nil)
+		repo.EXPECT().GetPendingApprovals(gomock.Any(), 2, 1).Return([]EscalationItem{item}, nil)
+		repo.EXPECT().GetManagerIDs(gomock.Any(), nil).Return([]uuid.UUID{}, nil)
+		repo.EXPECT().GetAdminIDs(gomock.Any()).Return([]uuid.UUID{adminID}, nil)
+		db.ExpectBegin()
+		repo.EXPECT().InsertEscalation(gomock.Any(), KindPendingApproval, item, 1, models.EscalateManager).Return(false, nil)
+		db.ExpectCommit()
+
+		require.NoError(t, svc.EscalatePendingApprovals(context.Background()))
+		assert.NoError(t, db.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,52 @@
+package escalationservice
+
+import (
+	"asset/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	KindOverdueReturn   = "overdue_return"
+	KindPendingApproval = "pending_approval"
+)
+
+// EscalationItem is something left waiting that a step of a chain may fire for, Stage is the status
+// it waits in and WaitingSince when it started waiting there
+type EscalationItem struct {
+	EntityType   string     `db:"entity_type"`
+	EntityID     uuid.UUID  `db:"entity_id"`
+	Stage        string     `db:"stage"`
+	DepartmentID *uuid.UUID `db:"department_id"`
+	Summary      string     `db:"summary"`
+	WaitingSince time.Time  `db:"waiting_since"`
+}
+
+// EscalationFilter narrows escalations to the departments in Scope, resolved ones are left out
+// unless Resolved is set. A Limit of 0 lists them all
+type EscalationFilter struct {
+	Kind     string
+	Resolved bool
+	Limit    int
+	Offset   int
+	Scope    models.DepartmentScope
+}
+
+// Escalation is a step fired for an item, Level counts from 1 along the chain
+type Escalation struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	Kind           string     `json:"kind" db:"kind"`
+	EntityType     string     `json:"entity_type" db:"entity_type"`
+	EntityID       uuid.UUID  `json:"entity_id" db:"entity_id"`
+	Stage          string     `json:"stage" db:"stage"`
+	Level          int        `json:"level" db:"level"`
+	Target         string     `json:"target" db:"target"`
+	DepartmentID   *uuid.UUID `json:"department_id,omitempty" db:"department_id"`
+	DepartmentName *string    `json:"department_name,omitempty" db:"department_name"`
+	Summary        string     `json:"summary" db:"summary"`
+	WaitingSince   time.Time  `json:"waiting_since" db:"waiting_since"`
+	DaysWaiting    int        `json:"days_waiting" db:"days_waiting"`
+	EscalatedAt    time.Time  `json:"escalated_at" db:"escalated_at"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+}
@@ -0,0 +1,108 @@
+package esignservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type ESignHandler struct {
+	Service        ESignService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewESignHandler(service ESignService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *ESignHandler {
+	return &ESignHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+// dropboxSignAck is the body dropbox sign expects back, it retries callbacks answered with anything else
+const dropboxSignAck = "Hello API Event Received"
+
+// Webhook takes envelope updates from the signature service. It is called without a session, the
+// provider checks the callback is signed. A failure answers 500 so the service retries the callback
+func (h *ESignHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := h.Service.ApplyWebhook(r.Context(), r.Header, body); err != nil {
+		if errors.Is(err, models.ErrSignatureWebhookUnauthorized) {
+			utils.RespondError(w, http.StatusUnauthorized, err, "invalid webhook signature")
+			return
+		}
+		h.Logger.GetLogger().Error("Failed to apply e-signature webhook", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to apply e-signature update")
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(dropboxSignAck))
+}
+
+// GetSignatures lists the handover and return signatures of an asset
+func (h *ESignHandler) GetSignatures(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetSignatures request received")
+	assetID, err := uuid.Parse(r.URL.Query().Get("asset_id"))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid asset id")
+		return
+	}
+	scope, ok := h.scope(w, r, "GetSignatures")
+	if !ok {
+		return
+	}
+	signatures, err := h.Service.GetSignatures(r.Context(), assetID, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch signatures", zap.String("asset_id", assetID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch signatures")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"asset_id":   assetID,
+		"signatures": signatures,
+	})
+}
+
+// ResendSignature sends a failed or declined document again on the next run
+func (h *ESignHandler) ResendSignature(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("ResendSignature request received")
+	id := r.URL.Query().Get("id")
+	signatureID, err := uuid.Parse(id)
+	if err != nil {
+		h.Logger.GetLogger().Error("Invalid id in ResendSignature", zap.String("id", id), zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid id")
+		return
+	}
+	scope, ok := h.scope(w, r, "ResendSignature")
+	if !ok {
+		return
+	}
+	if err := h.Service.Resend(r.Context(), signatureID, scope); err != nil {
+		h.Logger.GetLogger().Error("Failed to resend signature", zap.String("id", id), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resend signature")
+		return
+	}
+	utils.RespondJSON(w, http.StatusAccepted, map[string]interface{}{"message": "the document is sent again on the next run"})
+}
+
+func (h *ESignHandler) scope(w http.ResponseWriter, r *http.Request, handler string) (models.DepartmentScope, bool) {
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return scope, false
+	}
+	return scope, true
+}
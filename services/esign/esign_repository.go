@@ -0,0 +1,234 @@
+package esignservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type ESignRepository interface {
+	QueueHandover(ctx context.Context, assetID, employeeID uuid.UUID) error
+	QueueReturn(ctx context.Context, assetID, employeeID uuid.UUID) error
+	ClaimDueSignatures(ctx context.Context, limit int, lease time.Duration) ([]dueSignature, error)
+	MarkSent(ctx context.Context, id uuid.UUID, attempts int, envelopeID, documentKey string) error
+	MarkFailed(ctx context.Context, id uuid.UUID, attempts int, lastError string, nextAttemptAt *time.Time) error
+	GetSignatureByEnvelope(ctx context.Context, envelopeID string) (HandoverSignature, error)
+	MarkCompleted(ctx context.Context, id uuid.UUID, signedKey string) error
+	MarkDeclined(ctx context.Context, id uuid.UUID) error
+	GetAssetSignatures(ctx context.Context, assetID uuid.UUID) ([]HandoverSignature, error)
+	GetSignature(ctx context.Context, id uuid.UUID) (HandoverSignature, error)
+	RequeueSignature(ctx context.Context, id uuid.UUID) error
+	IsAssetInScope(ctx context.Context, assetID uuid.UUID, scope models.DepartmentScope) (bool, error)
+}
+
+type PostgresESignRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewESignRepository(db *sqlx.DB, log providers.ZapLoggerProvider) ESignRepository {
+	return &PostgresESignRepository{DB: db, Logger: log}
+}
+
+const signatureColumns = `id, assignment_id, asset_id, employee_id, kind, status, document_key, signed_key, attempts,
+	last_error, created_at, sent_at, completed_at`
+
+// QueueHandover queues the open assignment of the asset to the employee, run in the transaction that
+// assigned it
+func (r *PostgresESignRepository) QueueHandover(ctx context.Context, assetID, employeeID uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO handover_signatures (assignment_id, asset_id, employee_id, kind)
+		SELECT id, asset_id, employee_id, 'handover' FROM asset_assign
+		WHERE asset_id = $1 AND employee_id = $2 AND returned_at IS NULL AND archived_at IS NULL
+		ON CONFLICT (assignment_id, kind) DO NOTHING
+	`, assetID, employeeID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to queue handover signature", zap.String("asset_id", assetID.String()), zap.Error(err))
+		return fmt.Errorf("failed to queue handover signature: %w", err)
+	}
+	return nil
+}
+
+// QueueReturn queues the assignment of the asset to the employee that was returned last
+func (r *PostgresESignRepository) QueueReturn(ctx context.Context, assetID, employeeID uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO handover_signatures (assignment_id, asset_id, employee_id, kind)
+		SELECT id, asset_id, employee_id, 'return' FROM asset_assign
+		WHERE asset_id = $1 AND employee_id = $2 AND returned_at IS NOT NULL
+		ORDER BY returned_at DESC
+		LIMIT 1
+		ON CONFLICT (assignment_id, kind) DO NOTHING
+	`, assetID, employeeID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to queue return signature", zap.String("asset_id", assetID.String()), zap.Error(err))
+		return fmt.Errorf("failed to queue return signature: %w", err)
+	}
+	return nil
+}
+
+// ClaimDueSignatures hides the claimed signatures from other runs for lease. The assignment is read
+// through asset_assign_all in case it was moved to history meanwhile
+func (r *PostgresESignRepository) ClaimDueSignatures(ctx context.Context, limit int, lease time.Duration) ([]dueSignature, error) {
+	due := make([]dueSignature, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &due, `
+		WITH due AS (
+			SELECT id FROM handover_signatures
+			WHERE status = 'pending' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			UPDATE handover_signatures s
+			SET next_attempt_at = now() + make_interval(secs => $2)
+			FROM due
+			WHERE s.id = due.id
+			RETURNING s.id, s.kind, s.attempts, s.assignment_id, s.asset_id, s.employee_id
+		)
+		SELECT c.id, c.kind, c.attempts, a.brand, a.model, a.serial_no, a.type::text AS asset_type,
+			u.username AS employee_name, u.email AS employee_email,
+			aa.assigned_at, aa.returned_at, aa.return_reason
+		FROM claimed c
+		JOIN assets a ON a.id = c.asset_id
+		JOIN users u ON u.id = c.employee_id
+		LEFT JOIN asset_assign_all aa ON aa.id = c.assignment_id
+	`, limit, lease.Seconds())
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to claim signatures", zap.Error(err))
+		return nil, fmt.Errorf("failed to claim signatures: %w", err)
+	}
+	return due, nil
+}
+
+func (r *PostgresESignRepository) MarkSent(ctx context.Context, id uuid.UUID, attempts int, envelopeID, documentKey string) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE handover_signatures
+		SET status = 'sent', attempts = $2, envelope_id = $3, document_key = $4, last_error = NULL, sent_at = now()
+		WHERE id = $1
+	`, id, attempts, envelopeID, documentKey)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to mark signature sent", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to update signature: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed schedules the next attempt, a nil nextAttemptAt gives up on the signature
+func (r *PostgresESignRepository) MarkFailed(ctx context.Context, id uuid.UUID, attempts int, lastError string, nextAttemptAt *time.Time) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE handover_signatures
+		SET status = CASE WHEN $4::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+			attempts = $2, last_error = $3,
+			next_attempt_at = COALESCE($4, next_attempt_at)
+		WHERE id = $1
+	`, id, attempts, lastError, nextAttemptAt)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to mark signature failed", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to update signature: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresESignRepository) GetSignatureByEnvelope(ctx context.Context, envelopeID string) (HandoverSignature, error) {
+	var signature HandoverSignature
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &signature, `
+		SELECT `+signatureColumns+` FROM handover_signatures WHERE envelope_id = $1
+	`, envelopeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return signature, ErrSignatureNotFound
+	}
+	if err != nil {
+		return signature, fmt.Errorf("failed to fetch signature: %w", err)
+	}
+	return signature, nil
+}
+
+func (r *PostgresESignRepository) MarkCompleted(ctx context.Context, id uuid.UUID, signedKey string) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE handover_signatures
+		SET status = 'completed', signed_key = $2, completed_at = now()
+		WHERE id = $1
+	`, id, signedKey)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to mark signature completed", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to update signature: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresESignRepository) MarkDeclined(ctx context.Context, id uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE handover_signatures SET status = 'declined' WHERE id = $1 AND status = 'sent'
+	`, id)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to mark signature declined", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to update signature: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresESignRepository) GetAssetSignatures(ctx context.Context, assetID uuid.UUID) ([]HandoverSignature, error) {
+	signatures := []HandoverSignature{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &signatures, `
+		SELECT `+signatureColumns+` FROM handover_signatures
+		WHERE asset_id = $1
+		ORDER BY created_at DESC
+	`, assetID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch asset signatures", zap.String("asset_id", assetID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch asset signatures: %w", err)
+	}
+	return signatures, nil
+}
+
+func (r *PostgresESignRepository) GetSignature(ctx context.Context, id uuid.UUID) (HandoverSignature, error) {
+	var signature HandoverSignature
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &signature, `
+		SELECT `+signatureColumns+` FROM handover_signatures WHERE id = $1
+	`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return signature, ErrSignatureNotFound
+	}
+	if err != nil {
+		return signature, fmt.Errorf("failed to fetch signature: %w", err)
+	}
+	return signature, nil
+}
+
+// RequeueSignature sends the document again as a new envelope on the next run
+func (r *PostgresESignRepository) RequeueSignature(ctx context.Context, id uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE handover_signatures
+		SET status = 'pending', attempts = 0, envelope_id = NULL, last_error = NULL, next_attempt_at = now()
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to requeue signature", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to update signature: %w", err)
+	}
+	return nil
+}
+
+// IsAssetInScope reports whether the asset exists and belongs to the caller's department and organization
+func (r *PostgresESignRepository) IsAssetInScope(ctx context.Context, assetID uuid.UUID, scope models.DepartmentScope) (bool, error) {
+	var inScope bool
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &inScope, `
+		SELECT EXISTS (
+			SELECT 1 FROM assets
+			WHERE id = $1 AND archived_at IS NULL AND ($2 OR department_id IS NOT DISTINCT FROM $3)
+			AND ($4::uuid IS NULL OR organization_id = $4)
+		)
+	`, assetID, scope.AllDepartments, scope.DepartmentID, scope.OrganizationID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check asset department: %w", err)
+	}
+	return inScope, nil
+}
@@ -0,0 +1,45 @@
+//go:build integration
+
+package esignservice
+
+import (
+	"asset/database/seed"
+	"asset/database/testdb"
+	"asset/models"
+	"asset/providers"
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestIsAssetInScopeOtherOrganization(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewESignRepository(db, logger)
+	ctx := context.Background()
+	assetID := seed.ID("asset:laptop-1")
+
+	var other uuid.UUID
+	require.NoError(t, db.GetContext(ctx, &other, `
+		INSERT INTO organizations (name, slug) VALUES ('Other', 'other') RETURNING id
+	`))
+	_, err := db.ExecContext(ctx, `UPDATE assets SET organization_id = $1 WHERE id = $2`, other, assetID)
+	require.NoError(t, err)
+
+	inScope, err := repo.IsAssetInScope(ctx, assetID, models.DepartmentScope{AllDepartments: true, OrganizationID: &models.DefaultOrganizationID})
+	require.NoError(t, err)
+	assert.False(t, inScope)
+
+	inScope, err = repo.IsAssetInScope(ctx, assetID, models.DepartmentScope{AllDepartments: true, OrganizationID: &other})
+	require.NoError(t, err)
+	assert.True(t, inScope)
+}
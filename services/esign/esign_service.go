@@ -0,0 +1,304 @@
+package esignservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ESignService has employees sign for the assets handed to them and handed back. Emit queues the
+// document of an assignment or return in the caller's transaction, SendPending renders and sends the
+// queue from a background job and ApplyWebhook keeps the signed pdf once the employee signed
+type ESignService interface {
+	Emit(ctx context.Context, event Event) error
+	SendPending(ctx context.Context) error
+	ApplyWebhook(ctx context.Context, header http.Header, body []byte) error
+	GetSignatures(ctx context.Context, assetID uuid.UUID, scope models.DepartmentScope) ([]HandoverSignature, error)
+	Resend(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) error
+}
+
+type esignServiceStruct struct {
+	repo   ESignRepository
+	logger providers.ZapLoggerProvider
+	// nil without ESIGN_PROVIDER, nothing is queued then. The server refuses to start with a provider
+	// and no object storage, so storage is set whenever esign is
+	esign   providers.ESignProvider
+	storage providers.StorageProvider
+}
+
+func NewESignService(repo ESignRepository, logger providers.ZapLoggerProvider, esign providers.ESignProvider, storage providers.StorageProvider) ESignService {
+	return &esignServiceStruct{repo: repo, logger: logger, esign: esign, storage: storage}
+}
+
+const (
+	// documents sent per run, at most sendConcurrency at a time
+	sendBatchSize   = 20
+	sendConcurrency = 4
+	// a claimed signature is hidden from other runs for this long
+	sendLease = 10 * time.Minute
+	// how much of a failure is kept in last_error
+	signatureErrorLimit = 500
+)
+
+// retryBackoff is the wait after each failed send, a signature that fails once more than it has
+// entries is given up on until someone resends it
+var retryBackoff = []time.Duration{time.Minute, 10 * time.Minute, time.Hour, 6 * time.Hour}
+
+var (
+	ErrESignOff               = models.NewServiceError(http.StatusNotFound, "esign_off", "e-signature is not configured")
+	ErrSignatureNotFound      = models.NewServiceError(http.StatusNotFound, "signature_not_found", "signature not found")
+	ErrSignatureNotResendable = models.NewServiceError(http.StatusConflict, "signature_not_resendable", "only failed or declined signatures can be sent again")
+)
+
+// Emit takes the transaction of the assignment or return, so no document goes out for a change that
+// rolled back
+func (s *esignServiceStruct) Emit(ctx context.Context, event Event) error {
+	if s.esign == nil || !slices.Contains(SignedEvents, event.Type) {
+		return nil
+	}
+	employeeID, ok := dataID(event.Data, "employee_id")
+	if !ok {
+		return nil
+	}
+	if event.Type == EventAssetAssigned {
+		return s.repo.QueueHandover(ctx, event.AggregateID, employeeID)
+	}
+	return s.repo.QueueReturn(ctx, event.AggregateID, employeeID)
+}
+
+// dataID reads an id of event data, emitted in process as uuid.UUID or decoded from json as string
+func dataID(data map[string]interface{}, key string) (uuid.UUID, bool) {
+	switch value := data[key].(type) {
+	case uuid.UUID:
+		return value, true
+	case *uuid.UUID:
+		return *value, value != nil
+	case string:
+		id, err := uuid.Parse(value)
+		return id, err == nil
+	}
+	return uuid.Nil, false
+}
+
+// SendPending sends the documents that are due, failures are rescheduled rather than returned so one
+// bad address doesn't fail the job
+func (s *esignServiceStruct) SendPending(ctx context.Context) error {
+	if s.esign == nil {
+		return nil
+	}
+	due, err := s.repo.ClaimDueSignatures(ctx, sendBatchSize, sendLease)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, sendConcurrency)
+	for _, signature := range due {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			s.send(ctx, signature)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+func (s *esignServiceStruct) send(ctx context.Context, signature dueSignature) {
+	attempts := signature.Attempts + 1
+	documentKey := documentKey(signature.ID, "document.pdf")
+	envelopeID, err := s.sendDocument(ctx, signature, documentKey)
+	if err == nil {
+		if err := s.repo.MarkSent(ctx, signature.ID, attempts, envelopeID, documentKey); err != nil {
+			s.logger.GetLogger().Error("failed to record sent signature", zap.String("id", signature.ID.String()), zap.String("envelope_id", envelopeID), zap.Error(err))
+		}
+		return
+	}
+
+	var next *time.Time
+	if attempts <= len(retryBackoff) {
+		at := time.Now().Add(retryBackoff[attempts-1])
+		next = &at
+		s.logger.GetLogger().Info("signature request failed, will retry", zap.String("id", signature.ID.String()), zap.Int("attempts", attempts), zap.Time("next_attempt_at", at), zap.Error(err))
+	} else {
+		s.logger.GetLogger().Warn("signature request given up", zap.String("id", signature.ID.String()), zap.Int("attempts", attempts), zap.Error(err))
+	}
+	reason := err.Error()
+	if len(reason) > signatureErrorLimit {
+		reason = reason[:signatureErrorLimit]
+	}
+	if err := s.repo.MarkFailed(ctx, signature.ID, attempts, reason, next); err != nil {
+		s.logger.GetLogger().Error("failed to record signature failure", zap.String("id", signature.ID.String()), zap.Error(err))
+	}
+}
+
+// sendDocument keeps the generated pdf next to the signed one it becomes, then sends it
+func (s *esignServiceStruct) sendDocument(ctx context.Context, signature dueSignature, key string) (string, error) {
+	title, lines := composeDocument(signature)
+	document := utils.TextPDF(title, lines)
+	if err := s.storage.Put(ctx, key, "application/pdf", document); err != nil {
+		return "", fmt.Errorf("failed to store signature document: %w", err)
+	}
+	message := "Please sign to confirm you received the asset."
+	if signature.Kind == KindReturn {
+		message = "Please sign to confirm you handed back the asset."
+	}
+	return s.esign.Send(ctx, models.SignatureRequest{
+		Title:       title,
+		Message:     message,
+		SignerName:  signature.EmployeeName,
+		SignerEmail: signature.EmployeeEmail,
+		Filename:    signature.Kind + ".pdf",
+		Document:    document,
+	})
+}
+
+// composeDocument writes the handover or return form, it ends on the "Signature:" line the signature
+// services place the signature on
+func composeDocument(signature dueSignature) (string, []string) {
+	label := signature.Brand + " " + signature.Model + " (" + signature.SerialNo + ")"
+	title := "Asset handover: " + label
+	if signature.Kind == KindReturn {
+		title = "Asset return: " + label
+	}
+	lines := []string{
+		"Employee: " + signature.EmployeeName + " <" + signature.EmployeeEmail + ">",
+		"Asset: " + signature.Brand + " " + signature.Model,
+		"Serial number: " + signature.SerialNo,
+	}
+	if signature.AssetType != nil {
+		lines = append(lines, "Type: "+*signature.AssetType)
+	}
+	if signature.AssignedAt != nil {
+		lines = append(lines, "Assigned on: "+signature.AssignedAt.Format("2 January 2006"))
+	}
+	if signature.Kind == KindReturn {
+		if signature.ReturnedAt != nil {
+			lines = append(lines, "Returned on: "+signature.ReturnedAt.Format("2 January 2006"))
+		}
+		if signature.ReturnReason != nil && *signature.ReturnReason != "" {
+			lines = append(lines, "Reason: "+*signature.ReturnReason)
+		}
+		lines = append(lines, "", "I confirm I have handed the asset above back together with its accessories.")
+	} else {
+		lines = append(lines, "", "I confirm I have received the asset above in working order. I will look after it, use it",
+			"for work and hand it back when asked or when I leave.")
+	}
+	lines = append(lines, "", "", "Name: "+signature.EmployeeName, "", "", "Signature:")
+	return title, lines
+}
+
+// documentKey is where the files of a signature are kept
+func documentKey(id uuid.UUID, name string) string {
+	return "signatures/" + id.String() + "/" + name
+}
+
+// ApplyWebhook records what the signature service reports. Envelopes the asset manager didn't send
+// are ignored, the account may be shared with other senders
+func (s *esignServiceStruct) ApplyWebhook(ctx context.Context, header http.Header, body []byte) error {
+	if s.esign == nil {
+		return ErrESignOff
+	}
+	event, err := s.esign.ParseWebhook(ctx, header, body)
+	if err != nil {
+		return err
+	}
+	if event.EnvelopeID == "" || event.Status == "" || event.Status == models.SignatureStatusSent {
+		return nil
+	}
+	signature, err := s.repo.GetSignatureByEnvelope(ctx, event.EnvelopeID)
+	if errors.Is(err, ErrSignatureNotFound) {
+		s.logger.GetLogger().Debug("ignoring webhook for unknown envelope", zap.String("envelope_id", event.EnvelopeID))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if signature.Status != SignatureSent {
+		return nil
+	}
+
+	if event.Status == models.SignatureStatusDeclined {
+		s.logger.GetLogger().Info("signature declined", zap.String("id", signature.ID.String()), zap.String("employee_id", signature.EmployeeID.String()))
+		return s.repo.MarkDeclined(ctx, signature.ID)
+	}
+	signed, err := s.esign.DownloadSigned(ctx, event.EnvelopeID)
+	if err != nil {
+		return err
+	}
+	signedKey := documentKey(signature.ID, "signed.pdf")
+	if err := s.storage.Put(ctx, signedKey, "application/pdf", signed); err != nil {
+		return fmt.Errorf("failed to store signed document: %w", err)
+	}
+	if err := s.repo.MarkCompleted(ctx, signature.ID, signedKey); err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("signature completed", zap.String("id", signature.ID.String()), zap.String("kind", signature.Kind), zap.String("asset_id", signature.AssetID.String()))
+	return nil
+}
+
+// GetSignatures lists the signatures of an asset with download urls of its documents, newest first
+func (s *esignServiceStruct) GetSignatures(ctx context.Context, assetID uuid.UUID, scope models.DepartmentScope) ([]HandoverSignature, error) {
+	if s.esign == nil {
+		return nil, ErrESignOff
+	}
+	inScope, err := s.repo.IsAssetInScope(ctx, assetID, scope)
+	if err != nil {
+		return nil, err
+	}
+	if !inScope {
+		return nil, models.ErrOutOfScope
+	}
+	signatures, err := s.repo.GetAssetSignatures(ctx, assetID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range signatures {
+		name := signatures[i].Kind + "-" + signatures[i].CreatedAt.Format("2006-01-02")
+		if key := signatures[i].DocumentKey; key != nil {
+			if signatures[i].DocumentURL, err = s.storage.PresignDownload(ctx, *key, name+".pdf"); err != nil {
+				return nil, fmt.Errorf("failed to sign document download: %w", err)
+			}
+		}
+		if key := signatures[i].SignedKey; key != nil {
+			if signatures[i].SignedURL, err = s.storage.PresignDownload(ctx, *key, name+"-signed.pdf"); err != nil {
+				return nil, fmt.Errorf("failed to sign document download: %w", err)
+			}
+		}
+	}
+	return signatures, nil
+}
+
+// Resend sends a failed or declined document again in a new envelope
+func (s *esignServiceStruct) Resend(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) error {
+	if s.esign == nil {
+		return ErrESignOff
+	}
+	signature, err := s.repo.GetSignature(ctx, id)
+	if err != nil {
+		return err
+	}
+	inScope, err := s.repo.IsAssetInScope(ctx, signature.AssetID, scope)
+	if err != nil {
+		return err
+	}
+	if !inScope {
+		return models.ErrOutOfScope
+	}
+	if signature.Status != SignatureFailed && signature.Status != SignatureDeclined {
+		return ErrSignatureNotResendable
+	}
+	return s.repo.RequeueSignature(ctx, id)
+}
@@ -0,0 +1,191 @@
+package esignservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/testutil"
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(ctrl *gomock.Controller) (ESignService, *MockESignRepository, *providers.MockESignProvider, *providers.MockStorageProvider) {
+	repo := NewMockESignRepository(ctrl)
+	esign := providers.NewMockESignProvider(ctrl)
+	storage := providers.NewMockStorageProvider(ctrl)
+	return NewESignService(repo, testutil.Logger(ctrl), esign, storage), repo, esign, storage
+}
+
+func TestEmit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	assetID := uuid.New()
+	employeeID := uuid.New()
+	service, repo, _, _ := newTestService(ctrl)
+
+	repo.EXPECT().QueueHandover(ctx, assetID, employeeID).Return(nil)
+	assert.NoError(t, service.Emit(ctx, Event{Type: EventAssetAssigned, AggregateID: assetID, Data: map[string]interface{}{"employee_id": employeeID}}))
+
+	repo.EXPECT().QueueReturn(ctx, assetID, employeeID).Return(nil)
+	assert.NoError(t, service.Emit(ctx, Event{Type: EventAssetReturned, AggregateID: assetID, Data: map[string]interface{}{"employee_id": employeeID.String()}}))
+
+	// neither other events nor ones without an employee queue anything
+	assert.NoError(t, service.Emit(ctx, Event{Type: "asset.serviced", AggregateID: assetID, Data: map[string]interface{}{"employee_id": employeeID}}))
+	assert.NoError(t, service.Emit(ctx, Event{Type: EventAssetAssigned, AggregateID: assetID, Data: map[string]interface{}{}}))
+
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	off := NewESignService(repo, logger, nil, nil)
+	assert.NoError(t, off.Emit(ctx, Event{Type: EventAssetAssigned, AggregateID: assetID, Data: map[string]interface{}{"employee_id": employeeID}}))
+}
+
+func TestSendPending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	assignedAt := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	reason := "leaving the company"
+	handover := dueSignature{ID: uuid.New(), Kind: KindHandover, Brand: "Dell", Model: "XPS", SerialNo: "SN-1", EmployeeName: "Asha Rao", EmployeeEmail: "asha@example.com", AssignedAt: &assignedAt}
+	failing := dueSignature{ID: uuid.New(), Kind: KindReturn, Attempts: 1, Brand: "Dell", Model: "XPS", SerialNo: "SN-2", EmployeeName: "Ravi", EmployeeEmail: "ravi@example.com", ReturnReason: &reason}
+	exhausted := dueSignature{ID: uuid.New(), Kind: KindHandover, Attempts: len(retryBackoff), Brand: "Dell", Model: "XPS", SerialNo: "SN-3"}
+
+	service, repo, esign, storage := newTestService(ctrl)
+	repo.EXPECT().ClaimDueSignatures(ctx, sendBatchSize, sendLease).Return([]dueSignature{handover, failing, exhausted}, nil)
+
+	// documents go out concurrently, so each call is told apart by its arguments
+	handoverKey := "signatures/" + handover.ID.String() + "/document.pdf"
+	exhaustedKey := "signatures/" + exhausted.ID.String() + "/document.pdf"
+	storage.EXPECT().Put(ctx, handoverKey, "application/pdf", gomock.Any()).Return(nil)
+	storage.EXPECT().Put(ctx, "signatures/"+failing.ID.String()+"/document.pdf", "application/pdf", gomock.Any()).Return(errors.New("bucket unavailable"))
+	storage.EXPECT().Put(ctx, exhaustedKey, "application/pdf", gomock.Any()).Return(nil)
+	esign.EXPECT().Send(ctx, gomock.Any()).Times(2).DoAndReturn(func(_ context.Context, req models.SignatureRequest) (string, error) {
+		if req.SignerEmail == "" {
+			return "", errors.New("invalid email")
+		}
+		assert.Equal(t, "Asset handover: Dell XPS (SN-1)", req.Title)
+		assert.Equal(t, "asha@example.com", req.SignerEmail)
+		assert.Equal(t, "handover.pdf", req.Filename)
+		assert.True(t, bytes.HasPrefix(req.Document, []byte("%PDF-")))
+		assert.Contains(t, string(req.Document), "(Assigned on: 2 March 2026) Tj")
+		assert.Contains(t, string(req.Document), "(Signature:) Tj")
+		return "env-1", nil
+	})
+	repo.EXPECT().MarkSent(ctx, handover.ID, 1, "env-1", handoverKey).Return(nil)
+	repo.EXPECT().MarkFailed(ctx, failing.ID, 2, "failed to store signature document: bucket unavailable", gomock.Not(gomock.Nil())).DoAndReturn(
+		func(_ context.Context, _ uuid.UUID, _ int, _ string, next *time.Time) error {
+			assert.WithinDuration(t, time.Now().Add(retryBackoff[1]), *next, time.Minute)
+			return nil
+		})
+	repo.EXPECT().MarkFailed(ctx, exhausted.ID, len(retryBackoff)+1, "invalid email", (*time.Time)(nil)).Return(nil)
+
+	assert.NoError(t, service.SendPending(ctx))
+}
+
+func TestComposeReturnDocument(t *testing.T) {
+	reason := "leaving the company"
+	title, lines := composeDocument(dueSignature{Kind: KindReturn, Brand: "Dell", Model: "XPS", SerialNo: "SN-2", EmployeeName: "Ravi", EmployeeEmail: "ravi@example.com", ReturnReason: &reason})
+	assert.Equal(t, "Asset return: Dell XPS (SN-2)", title)
+	assert.Contains(t, lines, "Reason: leaving the company")
+	assert.Equal(t, "Signature:", lines[len(lines)-1])
+}
+
+func TestApplyWebhook(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	header := http.Header{}
+	body := []byte(`{}`)
+	sent := HandoverSignature{ID: uuid.New(), AssetID: uuid.New(), Kind: KindHandover, Status: SignatureSent}
+	completed := HandoverSignature{ID: uuid.New(), Status: SignatureCompleted}
+
+	tests := []struct {
+		name       string
+		setupMocks func(repo *MockESignRepository, esign *providers.MockESignProvider, storage *providers.MockStorageProvider)
+		wantErr    error
+	}{
+		{
+			name: "signed document is kept",
+			setupMocks: func(repo *MockESignRepository, esign *providers.MockESignProvider, storage *providers.MockStorageProvider) {
+				esign.EXPECT().ParseWebhook(ctx, header, body).Return(models.SignatureEvent{EnvelopeID: "env-1", Status: models.SignatureStatusCompleted}, nil)
+				repo.EXPECT().GetSignatureByEnvelope(ctx, "env-1").Return(sent, nil)
+				esign.EXPECT().DownloadSigned(ctx, "env-1").Return([]byte("%PDF-1.4 signed"), nil)
+				signedKey := "signatures/" + sent.ID.String() + "/signed.pdf"
+				storage.EXPECT().Put(ctx, signedKey, "application/pdf", []byte("%PDF-1.4 signed")).Return(nil)
+				repo.EXPECT().MarkCompleted(ctx, sent.ID, signedKey).Return(nil)
+			},
+		},
+		{
+			name: "declined",
+			setupMocks: func(repo *MockESignRepository, esign *providers.MockESignProvider, storage *providers.MockStorageProvider) {
+				esign.EXPECT().ParseWebhook(ctx, header, body).Return(models.SignatureEvent{EnvelopeID: "env-1", Status: models.SignatureStatusDeclined}, nil)
+				repo.EXPECT().GetSignatureByEnvelope(ctx, "env-1").Return(sent, nil)
+				repo.EXPECT().MarkDeclined(ctx, sent.ID).Return(nil)
+			},
+		},
+		{
+			name: "repeated callback for a completed envelope",
+			setupMocks: func(repo *MockESignRepository, esign *providers.MockESignProvider, storage *providers.MockStorageProvider) {
+				esign.EXPECT().ParseWebhook(ctx, header, body).Return(models.SignatureEvent{EnvelopeID: "env-2", Status: models.SignatureStatusCompleted}, nil)
+				repo.EXPECT().GetSignatureByEnvelope(ctx, "env-2").Return(completed, nil)
+			},
+		},
+		{
+			name: "envelope sent by someone else",
+			setupMocks: func(repo *MockESignRepository, esign *providers.MockESignProvider, storage *providers.MockStorageProvider) {
+				esign.EXPECT().ParseWebhook(ctx, header, body).Return(models.SignatureEvent{EnvelopeID: "env-3", Status: models.SignatureStatusCompleted}, nil)
+				repo.EXPECT().GetSignatureByEnvelope(ctx, "env-3").Return(HandoverSignature{}, ErrSignatureNotFound)
+			},
+		},
+		{
+			name: "unsigned callback",
+			setupMocks: func(repo *MockESignRepository, esign *providers.MockESignProvider, storage *providers.MockStorageProvider) {
+				esign.EXPECT().ParseWebhook(ctx, header, body).Return(models.SignatureEvent{}, models.ErrSignatureWebhookUnauthorized)
+			},
+			wantErr: models.ErrSignatureWebhookUnauthorized,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			service, repo, esign, storage := newTestService(ctrl)
+			tc.setupMocks(repo, esign, storage)
+			err := service.ApplyWebhook(ctx, header, body)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestResend(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	scope := models.DepartmentScope{AllDepartments: true}
+	declined := HandoverSignature{ID: uuid.New(), AssetID: uuid.New(), Status: SignatureDeclined}
+	sent := HandoverSignature{ID: uuid.New(), AssetID: uuid.New(), Status: SignatureSent}
+	service, repo, _, _ := newTestService(ctrl)
+
+	repo.EXPECT().GetSignature(ctx, declined.ID).Return(declined, nil)
+	repo.EXPECT().IsAssetInScope(ctx, declined.AssetID, scope).Return(true, nil)
+	repo.EXPECT().RequeueSignature(ctx, declined.ID).Return(nil)
+	require.NoError(t, service.Resend(ctx, declined.ID, scope))
+
+	repo.EXPECT().GetSignature(ctx, sent.ID).Return(sent, nil)
+	repo.EXPECT().IsAssetInScope(ctx, sent.AssetID, scope).Return(true, nil)
+	assert.ErrorIs(t, service.Resend(ctx, sent.ID, scope), ErrSignatureNotResendable)
+}
@@ -0,0 +1,74 @@
+package esignservice
+
+import (
+	"asset/services/webhook"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// domain events that queue a document for signature, the event service hands these on to Emit
+const (
+	EventAssetAssigned = webhookservice.EventAssetAssigned
+	EventAssetReturned = webhookservice.EventAssetReturned
+)
+
+var SignedEvents = []string{EventAssetAssigned, EventAssetReturned}
+
+// what the employee signs for, receiving the asset or giving it back
+const (
+	KindHandover = "handover"
+	KindReturn   = "return"
+)
+
+// states of a signature, sent to declined follow the signature service's webhooks
+const (
+	SignaturePending   = "pending"
+	SignatureSent      = "sent"
+	SignatureCompleted = "completed"
+	SignatureDeclined  = "declined"
+	SignatureFailed    = "failed"
+)
+
+// Event is a domain event as the event service hands it on, Data holds ids as uuid.UUID or string
+type Event struct {
+	Type        string
+	AggregateID uuid.UUID
+	Data        map[string]interface{}
+}
+
+// HandoverSignature is the signing of one assignment's handover or return document, the urls are
+// presigned downloads of the documents that exist so far
+type HandoverSignature struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	AssignmentID uuid.UUID  `json:"assignment_id" db:"assignment_id"`
+	AssetID      uuid.UUID  `json:"asset_id" db:"asset_id"`
+	EmployeeID   uuid.UUID  `json:"employee_id" db:"employee_id"`
+	Kind         string     `json:"kind" db:"kind"`
+	Status       string     `json:"status" db:"status"`
+	DocumentKey  *string    `json:"-" db:"document_key"`
+	SignedKey    *string    `json:"-" db:"signed_key"`
+	Attempts     int        `json:"attempts" db:"attempts"`
+	LastError    *string    `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	SentAt       *time.Time `json:"sent_at,omitempty" db:"sent_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+	DocumentURL  string     `json:"document_url,omitempty" db:"-"`
+	SignedURL    string     `json:"signed_url,omitempty" db:"-"`
+}
+
+// dueSignature is a claimed signature with what its document says
+type dueSignature struct {
+	ID            uuid.UUID  `db:"id"`
+	Kind          string     `db:"kind"`
+	Attempts      int        `db:"attempts"`
+	Brand         string     `db:"brand"`
+	Model         string     `db:"model"`
+	SerialNo      string     `db:"serial_no"`
+	AssetType     *string    `db:"asset_type"`
+	EmployeeName  string     `db:"employee_name"`
+	EmployeeEmail string     `db:"employee_email"`
+	AssignedAt    *time.Time `db:"assigned_at"`
+	ReturnedAt    *time.Time `db:"returned_at"`
+	ReturnReason  *string    `db:"return_reason"`
+}
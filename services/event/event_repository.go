@@ -0,0 +1,119 @@
+package eventservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type EventRepository interface {
+	InsertEvent(ctx context.Context, envelope EventEnvelope, payload string) error
+	Notify(ctx context.Context, channel, payload string) error
+	TryLockPublisher(ctx context.Context) (bool, error)
+	GetUnpublishedEvents(ctx context.Context, limit int) ([]pendingEvent, error)
+	MarkEventsPublished(ctx context.Context, ids []uuid.UUID) error
+	MarkEventPublishFailed(ctx context.Context, id uuid.UUID, lastError string) error
+	DeletePublishedEventsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type PostgresEventRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewEventRepository(db *sqlx.DB, log providers.ZapLoggerProvider) EventRepository {
+	return &PostgresEventRepository{DB: db, Logger: log}
+}
+
+// InsertEvent runs in the transaction of ctx so the event commits with the change it describes
+func (r *PostgresEventRepository) InsertEvent(ctx context.Context, envelope EventEnvelope, payload string) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO domain_events (id, type, aggregate_type, aggregate_id, actor_id, payload, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6::jsonb, $7)
+	`, envelope.ID, envelope.Type, envelope.AggregateType, envelope.AggregateID, envelope.ActorID, payload, envelope.OccurredAt)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert domain event", zap.String("type", envelope.Type), zap.Error(err))
+		return fmt.Errorf("failed to insert domain event: %w", err)
+	}
+	return nil
+}
+
+// Notify is delivered to listeners when the transaction of ctx commits and dropped if it rolls back
+func (r *PostgresEventRepository) Notify(ctx context.Context, channel, payload string) error {
+	if _, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `SELECT pg_notify($1, $2)`, channel, payload); err != nil {
+		r.Logger.GetLogger().Error("failed to notify domain event", zap.String("channel", channel), zap.Error(err))
+		return fmt.Errorf("failed to notify domain event: %w", err)
+	}
+	return nil
+}
+
+// TryLockPublisher makes one instance the publisher until the transaction ends, two publishers could
+// otherwise send the events of one aggregate out of order
+func (r *PostgresEventRepository) TryLockPublisher(ctx context.Context) (bool, error) {
+	var locked bool
+	if err := utils.Conn(ctx, r.DB).GetContext(ctx, &locked, `SELECT pg_try_advisory_xact_lock(hashtext('domain_events_publisher'))`); err != nil {
+		return false, fmt.Errorf("failed to lock domain event publisher: %w", err)
+	}
+	return locked, nil
+}
+
+func (r *PostgresEventRepository) GetUnpublishedEvents(ctx context.Context, limit int) ([]pendingEvent, error) {
+	events := make([]pendingEvent, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &events, `
+		SELECT id, type, aggregate_id, payload
+		FROM domain_events
+		WHERE published_at IS NULL
+		ORDER BY occurred_at, id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch unpublished domain events", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch unpublished domain events: %w", err)
+	}
+	return events, nil
+}
+
+func (r *PostgresEventRepository) MarkEventsPublished(ctx context.Context, ids []uuid.UUID) error {
+	idStrs := make([]string, len(ids))
+	for i, id := range ids {
+		idStrs[i] = id.String()
+	}
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE domain_events SET published_at = now(), attempts = attempts + 1, last_error = NULL
+		WHERE id = ANY($1::uuid[])
+	`, pq.Array(idStrs))
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to mark domain events published", zap.Int("count", len(ids)), zap.Error(err))
+		return fmt.Errorf("failed to mark domain events published: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresEventRepository) MarkEventPublishFailed(ctx context.Context, id uuid.UUID, lastError string) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE domain_events SET attempts = attempts + 1, last_error = $2
+		WHERE id = $1
+	`, id, lastError)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to record domain event publish failure", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to update domain event: %w", err)
+	}
+	return nil
+}
+
+// DeletePublishedEventsBefore only removes events the broker already has, pending ones are kept whatever their age
+func (r *PostgresEventRepository) DeletePublishedEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `DELETE FROM domain_events WHERE published_at IS NOT NULL AND published_at < $1`, before)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to purge published domain events", zap.Time("before", before), zap.Error(err))
+		return 0, fmt.Errorf("failed to purge published domain events: %w", err)
+	}
+	return res.RowsAffected()
+}
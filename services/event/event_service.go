@@ -0,0 +1,158 @@
+package eventservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/esign"
+	"asset/services/push"
+	"asset/services/sheets"
+	"asset/services/webhook"
+	"asset/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// EventService is where services report state changes. Emit writes the event to the outbox in the
+// caller's transaction, notifies asset events to live listeners and queues the asset for synced
+// sheets, and hands the types webhooks subscribe to on to the webhook service, the ones phones are told
+// about on to the push service and assignments and returns on to the e-signature service.
+// PublishPending sends the outbox to the broker from a background job
+type EventService interface {
+	Emit(ctx context.Context, event DomainEvent) error
+	PublishPending(ctx context.Context) error
+	PurgePublished(ctx context.Context, retention time.Duration) error
+}
+
+type eventServiceStruct struct {
+	repo   EventRepository
+	db     *sqlx.DB
+	logger providers.ZapLoggerProvider
+	// nil when no broker is configured, events then only reach webhooks
+	publisher providers.EventPublisher
+	webhooks  webhookservice.WebhookService
+	push      pushservice.PushService
+	sheets    sheetsservice.SheetsService
+	esign     esignservice.ESignService
+}
+
+func NewEventService(repo EventRepository, db *sqlx.DB, logger providers.ZapLoggerProvider, publisher providers.EventPublisher, webhooks webhookservice.WebhookService, push pushservice.PushService, sheets sheetsservice.SheetsService, esign esignservice.ESignService) EventService {
+	return &eventServiceStruct{repo: repo, db: db, logger: logger, publisher: publisher, webhooks: webhooks, push: push, sheets: sheets, esign: esign}
+}
+
+const (
+	eventSource = "asset-manager"
+	// events published per run, a failure stops the run so later events of the same aggregate wait
+	publishBatchSize  = 100
+	publishErrorLimit = 500
+	// postgres refuses notify payloads from 8000 bytes, larger events go out without their data
+	notifyPayloadLimit = 7900
+)
+
+func (s *eventServiceStruct) Emit(ctx context.Context, event DomainEvent) error {
+	envelope := EventEnvelope{
+		ID:            uuid.New(),
+		Type:          event.Type,
+		Version:       eventSchemaVersion,
+		Source:        eventSource,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		ActorID:       event.ActorID,
+		OccurredAt:    time.Now().UTC(),
+		Data:          event.Data,
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return fmt.Errorf("failed to marshal domain event: %w", err)
+	}
+	if s.publisher != nil {
+		if err := s.repo.InsertEvent(ctx, envelope, string(payload)); err != nil {
+			return err
+		}
+	}
+	if event.AggregateType == AggregateAsset {
+		if len(payload) > notifyPayloadLimit {
+			envelope.Data = nil
+			if payload, err = json.Marshal(envelope); err != nil {
+				return fmt.Errorf("failed to marshal domain event: %w", err)
+			}
+		}
+		if err := s.repo.Notify(ctx, AssetChangesChannel, string(payload)); err != nil {
+			return err
+		}
+		if err := s.sheets.Enqueue(ctx, event.AggregateID); err != nil {
+			return err
+		}
+	}
+	if slices.Contains(webhookservice.KnownEvents, event.Type) {
+		if err := s.webhooks.Emit(ctx, webhookservice.Event{Type: event.Type, AggregateType: event.AggregateType, AggregateID: event.AggregateID, Data: event.Data}); err != nil {
+			return err
+		}
+	}
+	if slices.Contains(pushservice.PushedEvents, event.Type) {
+		if err := s.push.Emit(ctx, pushservice.Event{Type: event.Type, AggregateID: event.AggregateID, ActorID: event.ActorID, Data: event.Data}); err != nil {
+			return err
+		}
+	}
+	if slices.Contains(esignservice.SignedEvents, event.Type) {
+		return s.esign.Emit(ctx, esignservice.Event{Type: event.Type, AggregateID: event.AggregateID, Data: event.Data})
+	}
+	return nil
+}
+
+// PublishPending sends unpublished events oldest first. Only one instance publishes at a time, the
+// others skip the run
+func (s *eventServiceStruct) PublishPending(ctx context.Context) error {
+	if s.publisher == nil {
+		return nil
+	}
+	return utils.WithTransactionOnce(ctx, s.db, func(ctx context.Context) error {
+		locked, err := s.repo.TryLockPublisher(ctx)
+		if err != nil || !locked {
+			return err
+		}
+		pending, err := s.repo.GetUnpublishedEvents(ctx, publishBatchSize)
+		if err != nil {
+			return err
+		}
+
+		published := make([]uuid.UUID, 0, len(pending))
+		for _, event := range pending {
+			msg := models.BrokerMessage{ID: event.ID.String(), Type: event.Type, Key: event.AggregateID.String(), Body: event.Payload}
+			if publishErr := s.publisher.Publish(ctx, msg); publishErr != nil {
+				s.logger.GetLogger().Warn("failed to publish domain event, will retry", zap.String("id", event.ID.String()), zap.String("type", event.Type), zap.Error(publishErr))
+				reason := publishErr.Error()
+				if len(reason) > publishErrorLimit {
+					reason = reason[:publishErrorLimit]
+				}
+				if err = s.repo.MarkEventPublishFailed(ctx, event.ID, reason); err != nil {
+					return err
+				}
+				break
+			}
+			published = append(published, event.ID)
+		}
+		if len(published) == 0 {
+			return nil
+		}
+		s.logger.GetLogger().Debug("domain events published", zap.Int("count", len(published)))
+		return s.repo.MarkEventsPublished(ctx, published)
+	})
+}
+
+// PurgePublished deletes events published longer ago than the retention period
+func (s *eventServiceStruct) PurgePublished(ctx context.Context, retention time.Duration) error {
+	before := time.Now().Add(-retention)
+	deleted, err := s.repo.DeletePublishedEventsBefore(ctx, before)
+	if err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("purged published domain events", zap.Int64("deleted", deleted), zap.Time("before", before))
+	return nil
+}
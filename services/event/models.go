@@ -0,0 +1,71 @@
+package eventservice
+
+import (
+	"asset/services/push"
+	"asset/services/webhook"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// domain event types, the ones webhooks can subscribe to keep the webhook names and the ones only
+// phones are told about keep the push names
+const (
+	AssetCreated         = "asset.created"
+	AssetUpdated         = "asset.updated"
+	AssetDeleted         = "asset.deleted"
+	AssetMerged          = "asset.merged"
+	AssetAssigned        = webhookservice.EventAssetAssigned
+	AssetReturned        = webhookservice.EventAssetReturned
+	AssetServiced        = webhookservice.EventAssetServiced
+	AssetReturnRequested = pushservice.EventAssetReturnRequested
+
+	UserCreated            = webhookservice.EventUserCreated
+	UserUpdated            = "user.updated"
+	UserDeleted            = "user.deleted"
+	UserRoleChanged        = webhookservice.EventUserRoleChanged
+	UserDepartmentChanged  = "user.department_changed"
+	UserRoleGrantRequested = pushservice.EventRoleGrantRequested
+	UserRoleGrantDecided   = pushservice.EventRoleGrantDecided
+)
+
+const (
+	AggregateAsset = "asset"
+	AggregateUser  = "user"
+)
+
+// AssetChangesChannel is the postgres channel asset events are notified on as they commit, with the
+// envelope as payload
+const AssetChangesChannel = "asset_changes"
+
+// eventSchemaVersion goes out with every event, it changes when a type's data changes incompatibly
+const eventSchemaVersion = 1
+
+// DomainEvent is what services emit for a state change, ActorID is nil when the caller isn't known
+type DomainEvent struct {
+	Type          string
+	AggregateType string
+	AggregateID   uuid.UUID
+	ActorID       *uuid.UUID
+	Data          map[string]interface{}
+}
+
+// EventEnvelope is the published json, consumers dedupe on ID and order per aggregate on OccurredAt
+type EventEnvelope struct {
+	ID            uuid.UUID              `json:"id"`
+	Type          string                 `json:"type"`
+	Version       int                    `json:"version"`
+	Source        string                 `json:"source"`
+	AggregateType string                 `json:"aggregate_type"`
+	AggregateID   uuid.UUID              `json:"aggregate_id"`
+	ActorID       *uuid.UUID             `json:"actor_id,omitempty"`
+	OccurredAt    time.Time              `json:"occurred_at"`
+	Data          map[string]interface{} `json:"data"`
+}
+
+type pendingEvent struct {
+	ID          uuid.UUID `db:"id"`
+	Type        string    `db:"type"`
+	AggregateID uuid.UUID `db:"aggregate_id"`
+	Payload     []byte    `db:"payload"`
+}
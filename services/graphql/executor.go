@@ -0,0 +1,487 @@
+package graphqlservice
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// maxQueryDepth caps how far a query can nest, employee → assets → assignments → employee loops
+// would otherwise let one request walk the whole inventory
+const maxQueryDepth = 8
+
+// executor resolves one operation a level at a time. Every field resolver gets all parents of the
+// level at once, which is where the loaders batch their lookups
+type executor struct {
+	schema    *schema
+	doc       *document
+	variables map[string]interface{}
+	req       *resolveRequest
+	errors    []GraphQLError
+}
+
+func (e *executor) run(ctx context.Context, op *operation) *orderedMap {
+	root := e.schema.objects[e.schema.query]
+	return e.executeSet(ctx, root.name, []interface{}{struct{}{}}, op.selectionSet, nil)[0]
+}
+
+func (e *executor) executeSet(ctx context.Context, typeName string, parents []interface{}, set []selection, path []interface{}) []*orderedMap {
+	results := make([]*orderedMap, len(parents))
+	if union, ok := e.schema.unions[typeName]; ok {
+		groups := map[string][]int{}
+		var order []string
+		for i, parent := range parents {
+			concrete := union.resolveType(parent)
+			if _, seen := groups[concrete]; !seen {
+				order = append(order, concrete)
+			}
+			groups[concrete] = append(groups[concrete], i)
+		}
+		for _, concrete := range order {
+			indexes := groups[concrete]
+			group := make([]interface{}, len(indexes))
+			for j, i := range indexes {
+				group[j] = parents[i]
+			}
+			for j, result := range e.executeSet(ctx, concrete, group, set, path) {
+				results[indexes[j]] = result
+			}
+		}
+		return results
+	}
+
+	object := e.schema.objects[typeName]
+	for i := range results {
+		results[i] = &orderedMap{}
+	}
+	for _, field := range e.collectFields(typeName, set, map[string]bool{}) {
+		first := field.selections[0]
+		if first.name == "__typename" {
+			for _, result := range results {
+				result.set(field.key, typeName)
+			}
+			continue
+		}
+
+		def := object.fields[first.name]
+		fieldPath := append(append([]interface{}{}, path...), field.key)
+		args, err := e.coerceArguments(def, first.arguments)
+		var values []interface{}
+		if err == nil {
+			values, err = def.resolve(ctx, e.req, parents, args)
+		}
+		if err == nil && len(values) != len(parents) {
+			err = fmt.Errorf("resolver for %s.%s returned %d values for %d parents", typeName, first.name, len(values), len(parents))
+		}
+		if err != nil {
+			e.errors = append(e.errors, GraphQLError{Message: err.Error(), Path: fieldPath})
+			for _, result := range results {
+				result.set(field.key, nil)
+			}
+			continue
+		}
+
+		var subSet []selection
+		for _, sel := range field.selections {
+			subSet = append(subSet, sel.selectionSet...)
+		}
+		completed := e.complete(ctx, def.typ, values, subSet, fieldPath)
+		for i, result := range results {
+			result.set(field.key, completed[i])
+		}
+	}
+	return results
+}
+
+// complete turns resolved values into output, the objects of a level are flattened across parents
+// so their own fields resolve in one batch
+func (e *executor) complete(ctx context.Context, typ string, values []interface{}, set []selection, path []interface{}) []interface{} {
+	out := make([]interface{}, len(values))
+	typ = strings.TrimSuffix(typ, "!")
+	if strings.HasPrefix(typ, "[") {
+		var flat []interface{}
+		lengths := make([]int, len(values))
+		for i, v := range values {
+			if isNil(v) {
+				lengths[i] = -1
+				continue
+			}
+			items := v.([]interface{})
+			lengths[i] = len(items)
+			flat = append(flat, items...)
+		}
+		done := e.complete(ctx, typ[1:len(typ)-1], flat, set, path)
+		for i, n := range lengths {
+			switch {
+			case n < 0:
+			case n == 0:
+				out[i] = []interface{}{}
+			default:
+				out[i], done = done[:n:n], done[n:]
+			}
+		}
+		return out
+	}
+
+	if !e.schema.isComposite(typ) {
+		for i, v := range values {
+			if !isNil(v) {
+				out[i] = v
+			}
+		}
+		return out
+	}
+
+	var objects []interface{}
+	var indexes []int
+	for i, v := range values {
+		if !isNil(v) {
+			objects = append(objects, v)
+			indexes = append(indexes, i)
+		}
+	}
+	if len(objects) == 0 {
+		return out
+	}
+	for j, result := range e.executeSet(ctx, typ, objects, set, path) {
+		out[indexes[j]] = result
+	}
+	return out
+}
+
+type collectedField struct {
+	key        string
+	selections []selection
+}
+
+// collectFields merges the fields selected on typeName, fragments included, keyed by response name
+// in the order they first appear
+func (e *executor) collectFields(typeName string, set []selection, visited map[string]bool) []collectedField {
+	var fields []collectedField
+	index := map[string]int{}
+	var walk func(set []selection)
+	walk = func(set []selection) {
+		for _, sel := range set {
+			if !e.included(sel.directives) {
+				continue
+			}
+			switch {
+			case sel.spread != "":
+				if visited[sel.spread] {
+					continue
+				}
+				visited[sel.spread] = true
+				frag := e.doc.fragments[sel.spread]
+				if e.schema.typeApplies(frag.typeCondition, typeName) {
+					walk(frag.selectionSet)
+				}
+			case sel.inline:
+				if sel.typeCondition == "" || e.schema.typeApplies(sel.typeCondition, typeName) {
+					walk(sel.selectionSet)
+				}
+			default:
+				key := sel.responseKey()
+				if i, ok := index[key]; ok {
+					fields[i].selections = append(fields[i].selections, sel)
+					continue
+				}
+				index[key] = len(fields)
+				fields = append(fields, collectedField{key: key, selections: []selection{sel}})
+			}
+		}
+	}
+	walk(set)
+	return fields
+}
+
+func (e *executor) included(directives []directive) bool {
+	for _, d := range directives {
+		arg, ok := d.arguments["if"]
+		if !ok {
+			continue
+		}
+		v, err := e.resolveValue(arg)
+		if err != nil {
+			continue
+		}
+		on, _ := v.(bool)
+		if (d.name == "skip" && on) || (d.name == "include" && !on) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *executor) coerceArguments(def *fieldDef, given map[string]value) (map[string]interface{}, error) {
+	args := map[string]interface{}{}
+	for _, arg := range def.args {
+		raw, ok := given[arg.name]
+		var v interface{}
+		if ok {
+			var err error
+			if v, err = e.resolveValue(raw); err != nil {
+				return nil, err
+			}
+		}
+		if v == nil {
+			if strings.HasSuffix(arg.typ, "!") {
+				return nil, fmt.Errorf("argument %q of type %s is required", arg.name, arg.typ)
+			}
+			if arg.defaultValue != nil {
+				args[arg.name] = arg.defaultValue
+			}
+			continue
+		}
+		coerced, err := coerceInput(strings.TrimSuffix(arg.typ, "!"), v)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: %w", arg.name, err)
+		}
+		args[arg.name] = coerced
+	}
+	return args, nil
+}
+
+// resolveValue turns a literal or variable into plain go values, as if it had been decoded from json
+func (e *executor) resolveValue(v value) (interface{}, error) {
+	switch v.kind {
+	case variableValue:
+		resolved, ok := e.variables[v.raw]
+		if !ok {
+			return nil, fmt.Errorf("variable $%s is not defined by the operation", v.raw)
+		}
+		return resolved, nil
+	case intValue, floatValue:
+		f, err := strconv.ParseFloat(v.raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", v.raw)
+		}
+		return f, nil
+	case stringValue, enumValue:
+		return v.raw, nil
+	case booleanValue:
+		return v.raw == "true", nil
+	case listValue:
+		list := make([]interface{}, 0, len(v.list))
+		for _, item := range v.list {
+			resolved, err := e.resolveValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, resolved)
+		}
+		return list, nil
+	case objectValue:
+		object := map[string]interface{}{}
+		for name, field := range v.fields {
+			resolved, err := e.resolveValue(field)
+			if err != nil {
+				return nil, err
+			}
+			object[name] = resolved
+		}
+		return object, nil
+	}
+	return nil, nil
+}
+
+func coerceInput(typ string, v interface{}) (interface{}, error) {
+	if strings.HasPrefix(typ, "[") {
+		inner := strings.TrimSuffix(typ[1:len(typ)-1], "!")
+		items, ok := v.([]interface{})
+		if !ok {
+			// a single value where a list is expected counts as a list of one
+			items = []interface{}{v}
+		}
+		switch inner {
+		case "String", "ID":
+			out := make([]string, 0, len(items))
+			for _, item := range items {
+				s, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("expected a list of %s", inner)
+				}
+				out = append(out, s)
+			}
+			return out, nil
+		}
+		return nil, fmt.Errorf("unsupported list type %s", typ)
+	}
+	switch typ {
+	case "String", "ID":
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	case "Int":
+		if f, ok := v.(float64); ok && f == float64(int(f)) {
+			return int(f), nil
+		}
+	case "Boolean":
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("expected a value of type %s", typ)
+}
+
+// validate checks the operation against the schema before anything is resolved, so a bad query
+// never runs half its lookups
+func (e *executor) validate(op *operation) error {
+	if op.kind != "query" {
+		return fmt.Errorf("only queries are supported, use the rest api for %s operations", op.kind)
+	}
+	for _, frag := range e.doc.fragments {
+		if !e.schema.isComposite(frag.typeCondition) {
+			return fmt.Errorf("fragment %q is on unknown type %q", frag.name, frag.typeCondition)
+		}
+	}
+	return e.validateSet(e.schema.query, op.selectionSet, 1, map[string]bool{})
+}
+
+func (e *executor) validateSet(typeName string, set []selection, depth int, inFragments map[string]bool) error {
+	if depth > maxQueryDepth {
+		return fmt.Errorf("query is nested deeper than %d levels", maxQueryDepth)
+	}
+	for _, sel := range set {
+		switch {
+		case sel.spread != "":
+			frag, ok := e.doc.fragments[sel.spread]
+			if !ok {
+				return fmt.Errorf("unknown fragment %q", sel.spread)
+			}
+			if inFragments[sel.spread] {
+				return fmt.Errorf("fragment %q spreads itself", sel.spread)
+			}
+			if !e.schema.possible(frag.typeCondition, typeName) {
+				return fmt.Errorf("fragment %q on %s can never apply to %s", sel.spread, frag.typeCondition, typeName)
+			}
+			inFragments[sel.spread] = true
+			err := e.validateSet(frag.typeCondition, frag.selectionSet, depth, inFragments)
+			delete(inFragments, sel.spread)
+			if err != nil {
+				return err
+			}
+		case sel.inline:
+			target := typeName
+			if sel.typeCondition != "" {
+				if !e.schema.possible(sel.typeCondition, typeName) {
+					return fmt.Errorf("inline fragment on %s can never apply to %s", sel.typeCondition, typeName)
+				}
+				target = sel.typeCondition
+			}
+			if err := e.validateSet(target, sel.selectionSet, depth, inFragments); err != nil {
+				return err
+			}
+		default:
+			if sel.name == "__typename" {
+				if sel.selectionSet != nil {
+					return fmt.Errorf("field __typename can't have a selection set")
+				}
+				continue
+			}
+			object, ok := e.schema.objects[typeName]
+			if !ok {
+				return fmt.Errorf("can't query field %q on union %s, use an inline fragment", sel.name, typeName)
+			}
+			def, ok := object.fields[sel.name]
+			if !ok {
+				return fmt.Errorf("can't query field %q on type %s", sel.name, typeName)
+			}
+			for name := range sel.arguments {
+				if !def.hasArgument(name) {
+					return fmt.Errorf("unknown argument %q on field %s.%s", name, typeName, sel.name)
+				}
+			}
+			named := namedType(def.typ)
+			if e.schema.isComposite(named) {
+				if sel.selectionSet == nil {
+					return fmt.Errorf("field %s.%s of type %s needs a selection set", typeName, sel.name, def.typ)
+				}
+				if err := e.validateSet(named, sel.selectionSet, depth+1, inFragments); err != nil {
+					return err
+				}
+			} else if sel.selectionSet != nil {
+				return fmt.Errorf("field %s.%s is a scalar and can't have a selection set", typeName, sel.name)
+			}
+		}
+	}
+	return nil
+}
+
+// namedType strips list and non-null wrappers, "[Asset!]!" is an Asset
+func namedType(typ string) string {
+	return strings.Trim(typ, "[]!")
+}
+
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// orderedMap keeps the response keys in query order, clients diff responses and expect the order
+// they asked for
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *orderedMap) set(key string, v interface{}) {
+	if m.values == nil {
+		m.values = map[string]interface{}{}
+	}
+	if _, exists := m.values[key]; !exists {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = v
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		v, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+var errNoOperation = errors.New("operationName is required when the document has more than one operation")
+
+func selectOperation(doc *document, name string) (*operation, error) {
+	if name == "" {
+		if len(doc.operations) > 1 {
+			return nil, errNoOperation
+		}
+		return doc.operations[0], nil
+	}
+	for _, op := range doc.operations {
+		if op.name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
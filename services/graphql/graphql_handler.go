@@ -0,0 +1,60 @@
+package graphqlservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+type GraphQLHandler struct {
+	Service        GraphQLService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewGraphQLHandler(service GraphQLService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *GraphQLHandler {
+	return &GraphQLHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+// Query runs one graphql operation. Queries that can't run answer 400 with the graphql errors shape
+// graphql clients expect, field errors come back with 200 next to the partial data
+func (h *GraphQLHandler) Query(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GraphQL query request received")
+	var req GraphQLRequest
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in GraphQL query", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in GraphQL query", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "query is required")
+		return
+	}
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in GraphQL query", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	res, err := h.Service.Execute(r.Context(), req, scope)
+	if err != nil {
+		h.Logger.GetLogger().Warn("GraphQL query rejected", zap.Error(err))
+		utils.RespondJSON(w, http.StatusBadRequest, GraphQLResponse{Errors: []GraphQLError{{Message: err.Error()}}})
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, res)
+}
+
+// Schema serves the schema in SDL for client code generation
+func (h *GraphQLHandler) Schema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(h.Service.Schema()))
+}
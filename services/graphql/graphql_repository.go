@@ -0,0 +1,151 @@
+package graphqlservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// GraphQLRepository holds the batch lookups behind the loaders, each takes every key of a query
+// level and answers with one statement
+type GraphQLRepository interface {
+	GetEmployeesByIDs(ctx context.Context, ids []string, scope models.DepartmentScope) ([]Employee, error)
+	GetAssetsByIDs(ctx context.Context, ids []string, scope models.DepartmentScope) ([]Asset, error)
+	GetAssignmentsByEmployeeIDs(ctx context.Context, employeeIDs []string, scope models.DepartmentScope) ([]Assignment, error)
+	GetAssignmentsByAssetIDs(ctx context.Context, assetIDs []string) ([]Assignment, error)
+	GetAssetConfigs(ctx context.Context, assetType string, assetIDs []string) (map[string]interface{}, error)
+}
+
+type PostgresGraphQLRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewGraphQLRepository(db *sqlx.DB, log providers.ZapLoggerProvider) GraphQLRepository {
+	return &PostgresGraphQLRepository{DB: db, Logger: log}
+}
+
+func (r *PostgresGraphQLRepository) GetEmployeesByIDs(ctx context.Context, ids []string, scope models.DepartmentScope) ([]Employee, error) {
+	employees := make([]Employee, 0, len(ids))
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &employees, `
+		SELECT u.id, u.username, u.email, u.contact_no, ut.type AS employee_type,
+			u.designation, u.location, u.date_of_joining
+		FROM users u
+		LEFT JOIN user_type ut ON ut.user_id = u.id AND ut.archived_at IS NULL
+		WHERE u.id = ANY($1::uuid[]) AND u.archived_at IS NULL
+		AND ($2 OR u.department_id IS NOT DISTINCT FROM $3)
+		AND ($4::uuid IS NULL OR u.organization_id = $4)
+	`, pq.Array(ids), scope.AllDepartments, scope.DepartmentID, scope.OrganizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch employees by ids", zap.Int("count", len(ids)), zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch employees: %w", err)
+	}
+	return employees, nil
+}
+
+func (r *PostgresGraphQLRepository) GetAssetsByIDs(ctx context.Context, ids []string, scope models.DepartmentScope) ([]Asset, error) {
+	assets := make([]Asset, 0, len(ids))
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &assets, `
+		SELECT id, brand, model, serial_no, type, owned_by, status, purchase_date, warranty_start, warranty_expire, department_id
+		FROM assets
+		WHERE id = ANY($1::uuid[]) AND archived_at IS NULL
+		AND ($2 OR department_id IS NOT DISTINCT FROM $3)
+		AND ($4::uuid IS NULL OR organization_id = $4)
+	`, pq.Array(ids), scope.AllDepartments, scope.DepartmentID, scope.OrganizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch assets by ids", zap.Int("count", len(ids)), zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch assets: %w", err)
+	}
+	return assets, nil
+}
+
+const assignmentColumns = `
+	SELECT aa.id, aa.asset_id, aa.employee_id, aa.assigned_at, aa.returned_at, aa.return_reason
+	FROM asset_assign_all aa
+	JOIN assets a ON a.id = aa.asset_id
+`
+
+// GetAssignmentsByEmployeeIDs leaves out assignments of assets outside the caller's department or organization
+func (r *PostgresGraphQLRepository) GetAssignmentsByEmployeeIDs(ctx context.Context, employeeIDs []string, scope models.DepartmentScope) ([]Assignment, error) {
+	assignments := make([]Assignment, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &assignments, assignmentColumns+`
+		WHERE aa.employee_id = ANY($1::uuid[]) AND aa.archived_at IS NULL
+		AND ($2 OR a.department_id IS NOT DISTINCT FROM $3)
+		AND ($4::uuid IS NULL OR a.organization_id = $4)
+		ORDER BY aa.assigned_at DESC
+	`, pq.Array(employeeIDs), scope.AllDepartments, scope.DepartmentID, scope.OrganizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch assignments by employee", zap.Int("count", len(employeeIDs)), zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch assignments: %w", err)
+	}
+	return assignments, nil
+}
+
+func (r *PostgresGraphQLRepository) GetAssignmentsByAssetIDs(ctx context.Context, assetIDs []string) ([]Assignment, error) {
+	assignments := make([]Assignment, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &assignments, assignmentColumns+`
+		WHERE aa.asset_id = ANY($1::uuid[]) AND aa.archived_at IS NULL
+		ORDER BY aa.assigned_at DESC
+	`, pq.Array(assetIDs))
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch assignments by asset", zap.Int("count", len(assetIDs)), zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch assignments: %w", err)
+	}
+	return assignments, nil
+}
+
+// GetAssetConfigs reads the config table of one asset type, keyed by asset id
+func (r *PostgresGraphQLRepository) GetAssetConfigs(ctx context.Context, assetType string, assetIDs []string) (map[string]interface{}, error) {
+	switch assetType {
+	case "laptop":
+		return selectConfigs[models.Laptop_config_res](ctx, r.DB, "laptop_config", "processor, ram, os", assetIDs)
+	case "mouse":
+		return selectConfigs[models.Mouse_config_res](ctx, r.DB, "mouse_config", "dpi", assetIDs)
+	case "monitor":
+		return selectConfigs[models.Monitor_config_res](ctx, r.DB, "monitor_config", "display, resolution, port", assetIDs)
+	case "mobile":
+		return selectConfigs[models.Mobile_config_res](ctx, r.DB, "mobile_config", "processor, ram, os, imei_1, imei_2, COALESCE(imei_flag, '')", assetIDs)
+	case "hard_disk":
+		return selectConfigs[models.Hard_disk_config_res](ctx, r.DB, "hard_disk_config", "type, storage", assetIDs)
+	case "pen_drive":
+		return selectConfigs[models.Pen_drive_config_res](ctx, r.DB, "pendrive_config", "version, storage", assetIDs)
+	case "sim":
+		return selectConfigs[models.Sim_config_res](ctx, r.DB, "sim_config", "number, carrier, plan, monthly_cost::float8, renewal_date, mobile_asset_id", assetIDs)
+	case "accessory":
+		return selectConfigs[models.Accessories_config_res](ctx, r.DB, "accessories_config", "type, additional_info", assetIDs)
+	}
+	return map[string]interface{}{}, nil
+}
+
+// selectConfigs scans columns into the fields of T in declaration order, the config models list
+// their fields in column order
+func selectConfigs[T any](ctx context.Context, db *sqlx.DB, table, columns string, assetIDs []string) (map[string]interface{}, error) {
+	rows, err := db.QueryContext(ctx, `SELECT asset_id, `+columns+` FROM `+table+` WHERE asset_id = ANY($1::uuid[])`, pq.Array(assetIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	configs := map[string]interface{}{}
+	for rows.Next() {
+		var assetID string
+		var config T
+		v := reflect.ValueOf(&config).Elem()
+		dest := []interface{}{&assetID}
+		for i := 0; i < v.NumField(); i++ {
+			dest = append(dest, v.Field(i).Addr().Interface())
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+		configs[assetID] = config
+	}
+	return configs, rows.Err()
+}
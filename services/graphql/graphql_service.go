@@ -0,0 +1,85 @@
+package graphqlservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/asset"
+	"asset/services/user"
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// GraphQLService answers read queries over employees, assets, assignments and timelines. Lists and
+// timelines come from the user and asset services, relations between them from batched lookups
+type GraphQLService interface {
+	Execute(ctx context.Context, req GraphQLRequest, scope models.DepartmentScope) (GraphQLResponse, error)
+	Schema() string
+}
+
+type graphqlServiceStruct struct {
+	repo         GraphQLRepository
+	userService  userservice.UserService
+	assetService assetservice.AssetService
+	logger       providers.ZapLoggerProvider
+}
+
+func NewGraphQLService(repo GraphQLRepository, userService userservice.UserService, assetService assetservice.AssetService, logger providers.ZapLoggerProvider) GraphQLService {
+	return &graphqlServiceStruct{repo: repo, userService: userService, assetService: assetService, logger: logger}
+}
+
+// Execute returns an error when the query can't run at all, failures of single fields come back in
+// the response next to the data that did resolve
+func (s *graphqlServiceStruct) Execute(ctx context.Context, req GraphQLRequest, scope models.DepartmentScope) (GraphQLResponse, error) {
+	doc, err := parseDocument(req.Query)
+	if err != nil {
+		return GraphQLResponse{}, err
+	}
+	op, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return GraphQLResponse{}, err
+	}
+	variables, err := operationVariables(op, req.Variables)
+	if err != nil {
+		return GraphQLResponse{}, err
+	}
+
+	exec := &executor{schema: graph, doc: doc, variables: variables, req: s.newResolveRequest(scope)}
+	if err := exec.validate(op); err != nil {
+		return GraphQLResponse{}, err
+	}
+	data := exec.run(ctx, op)
+	if len(exec.errors) > 0 {
+		s.logger.GetLogger().Warn("graphql query resolved with errors", zap.String("operation", op.name), zap.Any("errors", exec.errors))
+	}
+	return GraphQLResponse{Data: data, Errors: exec.errors}, nil
+}
+
+func (s *graphqlServiceStruct) Schema() string {
+	return graph.sdl()
+}
+
+// operationVariables applies the declared defaults, arguments coerce the values when they're used
+func operationVariables(op *operation, given map[string]interface{}) (map[string]interface{}, error) {
+	variables := make(map[string]interface{}, len(op.variables))
+	for _, def := range op.variables {
+		v, ok := given[def.name]
+		if !ok || v == nil {
+			if def.defaultValue.kind != nullValue {
+				defaults := &executor{}
+				resolved, err := defaults.resolveValue(def.defaultValue)
+				if err != nil {
+					return nil, err
+				}
+				v = resolved
+			}
+		}
+		if v == nil && strings.HasSuffix(def.typ, "!") {
+			return nil, fmt.Errorf("variable $%s of type %s is required", def.name, def.typ)
+		}
+		variables[def.name] = v
+	}
+	return variables, nil
+}
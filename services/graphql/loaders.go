@@ -0,0 +1,138 @@
+package graphqlservice
+
+import (
+	"asset/models"
+	"context"
+	"strings"
+)
+
+type batchFunc func(ctx context.Context, keys []string) (map[string]interface{}, error)
+
+// loader caches one kind of lookup for the lifetime of a request. Execution walks the query a level
+// at a time on one goroutine, so a level's keys arrive together and need no scheduling or locking
+type loader struct {
+	fetch batchFunc
+	cache map[string]interface{}
+}
+
+func newLoader(fetch batchFunc) *loader {
+	return &loader{fetch: fetch, cache: map[string]interface{}{}}
+}
+
+// loadMany fetches the keys not seen yet in one call, keys the fetch doesn't return map to nil
+func (l *loader) loadMany(ctx context.Context, keys []string) (map[string]interface{}, error) {
+	var missing []string
+	queued := map[string]bool{}
+	for _, key := range keys {
+		if _, cached := l.cache[key]; !cached && !queued[key] {
+			queued[key] = true
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		fetched, err := l.fetch(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range missing {
+			l.cache[key] = fetched[key]
+		}
+	}
+	found := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		found[key] = l.cache[key]
+	}
+	return found, nil
+}
+
+// prime stores a value another lookup already produced, so loading it by id later is free
+func (l *loader) prime(key string, v interface{}) {
+	if _, cached := l.cache[key]; !cached {
+		l.cache[key] = v
+	}
+}
+
+// resolveRequest is the state of one graphql request: the caller's department scope and the loaders
+// every resolver shares
+type resolveRequest struct {
+	service               *graphqlServiceStruct
+	scope                 models.DepartmentScope
+	employees             *loader
+	assets                *loader
+	assignmentsByEmployee *loader
+	assignmentsByAsset    *loader
+	configs               *loader
+}
+
+func (s *graphqlServiceStruct) newResolveRequest(scope models.DepartmentScope) *resolveRequest {
+	req := &resolveRequest{service: s, scope: scope}
+	req.employees = newLoader(func(ctx context.Context, ids []string) (map[string]interface{}, error) {
+		employees, err := s.repo.GetEmployeesByIDs(ctx, ids, scope)
+		if err != nil {
+			return nil, err
+		}
+		found := make(map[string]interface{}, len(employees))
+		for i := range employees {
+			found[employees[i].ID] = &employees[i]
+		}
+		return found, nil
+	})
+	req.assets = newLoader(func(ctx context.Context, ids []string) (map[string]interface{}, error) {
+		assets, err := s.repo.GetAssetsByIDs(ctx, ids, scope)
+		if err != nil {
+			return nil, err
+		}
+		found := make(map[string]interface{}, len(assets))
+		for i := range assets {
+			found[assets[i].ID] = &assets[i]
+		}
+		return found, nil
+	})
+	req.assignmentsByEmployee = newLoader(func(ctx context.Context, ids []string) (map[string]interface{}, error) {
+		assignments, err := s.repo.GetAssignmentsByEmployeeIDs(ctx, ids, scope)
+		if err != nil {
+			return nil, err
+		}
+		return groupAssignments(assignments, func(a *Assignment) string { return a.EmployeeID }), nil
+	})
+	req.assignmentsByAsset = newLoader(func(ctx context.Context, ids []string) (map[string]interface{}, error) {
+		assignments, err := s.repo.GetAssignmentsByAssetIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		return groupAssignments(assignments, func(a *Assignment) string { return a.AssetID }), nil
+	})
+	// config keys are "<asset type>:<asset id>", each type lives in its own table
+	req.configs = newLoader(func(ctx context.Context, keys []string) (map[string]interface{}, error) {
+		byType := map[string][]string{}
+		for _, key := range keys {
+			assetType, id, _ := strings.Cut(key, ":")
+			byType[assetType] = append(byType[assetType], id)
+		}
+		found := map[string]interface{}{}
+		for assetType, ids := range byType {
+			configs, err := s.repo.GetAssetConfigs(ctx, assetType, ids)
+			if err != nil {
+				return nil, err
+			}
+			for id, config := range configs {
+				found[assetType+":"+id] = config
+			}
+		}
+		return found, nil
+	})
+	return req
+}
+
+func groupAssignments(assignments []Assignment, key func(*Assignment) string) map[string]interface{} {
+	grouped := map[string][]*Assignment{}
+	for i := range assignments {
+		k := key(&assignments[i])
+		grouped[k] = append(grouped[k], &assignments[i])
+	}
+	found := make(map[string]interface{}, len(grouped))
+	for k, list := range grouped {
+		found[k] = list
+	}
+	return found
+}
@@ -0,0 +1,62 @@
+package graphqlservice
+
+import (
+	"asset/models"
+	"time"
+)
+
+type GraphQLRequest struct {
+	Query         string                 `json:"query" validate:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type GraphQLError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// GraphQLResponse carries partial data next to the errors of the fields that failed
+type GraphQLResponse struct {
+	Data   interface{}    `json:"data,omitempty"`
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+// Employee is the graph view of a user, json tags name the fields of the schema
+type Employee struct {
+	ID            string     `json:"id" db:"id"`
+	Username      string     `json:"username" db:"username"`
+	Email         string     `json:"email" db:"email"`
+	ContactNo     *string    `json:"contact_no" db:"contact_no"`
+	EmployeeType  *string    `json:"type" db:"employee_type"`
+	Designation   *string    `json:"designation" db:"designation"`
+	Location      *string    `json:"location" db:"location"`
+	DateOfJoining *time.Time `json:"date_of_joining" db:"date_of_joining"`
+}
+
+// Asset keeps the config the asset service already loaded, assets fetched by id get theirs from
+// the config loader
+type Asset struct {
+	models.AssetWithConfigRes
+}
+
+type Assignment struct {
+	ID           string     `json:"id" db:"id"`
+	AssetID      string     `json:"asset_id" db:"asset_id"`
+	EmployeeID   string     `json:"employee_id" db:"employee_id"`
+	AssignedAt   time.Time  `json:"assigned_at" db:"assigned_at"`
+	ReturnedAt   *time.Time `json:"returned_at" db:"returned_at"`
+	ReturnReason *string    `json:"return_reason" db:"return_reason"`
+}
+
+// the asset filters match on these with = ANY, so an empty filter has to list every value
+var (
+	assetStatuses   = models.AssetStatuses
+	assetOwnerships = models.AssetOwnerships
+	assetTypes      = models.AssetTypes
+)
+
+const (
+	defaultListLimit = 10
+	maxListLimit     = 100
+)
@@ -0,0 +1,533 @@
+package graphqlservice
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// the executable subset of the graphql grammar: queries with variables, aliases, arguments,
+// fragments and the skip/include directives. Mutations go through the rest api
+
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+type operation struct {
+	kind         string
+	name         string
+	variables    []variableDef
+	selectionSet []selection
+}
+
+type variableDef struct {
+	name         string
+	typ          string
+	defaultValue value
+}
+
+type fragment struct {
+	name          string
+	typeCondition string
+	selectionSet  []selection
+}
+
+// selection is a field, a fragment spread (spread set) or an inline fragment (typeCondition and
+// selectionSet set without a name)
+type selection struct {
+	alias         string
+	name          string
+	arguments     map[string]value
+	directives    []directive
+	selectionSet  []selection
+	spread        string
+	inline        bool
+	typeCondition string
+}
+
+func (s selection) responseKey() string {
+	if s.alias != "" {
+		return s.alias
+	}
+	return s.name
+}
+
+type directive struct {
+	name      string
+	arguments map[string]value
+}
+
+type valueKind int
+
+const (
+	variableValue valueKind = iota
+	intValue
+	floatValue
+	stringValue
+	booleanValue
+	nullValue
+	enumValue
+	listValue
+	objectValue
+)
+
+type value struct {
+	kind   valueKind
+	raw    string
+	list   []value
+	fields map[string]value
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "..."):
+			tokens = append(tokens, token{kind: tokenPunct, value: "...", pos: i})
+			i += 3
+		case strings.ContainsRune("!$():=@[]{}|", rune(c)):
+			tokens = append(tokens, token{kind: tokenPunct, value: string(c), pos: i})
+			i++
+		case c == '_' || isLetter(c):
+			start := i
+			for i < len(src) && (src[i] == '_' || isLetter(src[i]) || isDigit(src[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenName, value: src[start:i], pos: start})
+		case c == '-' || isDigit(c):
+			start := i
+			kind := tokenInt
+			i++
+			for i < len(src) && isDigit(src[i]) {
+				i++
+			}
+			if i < len(src) && src[i] == '.' {
+				kind = tokenFloat
+				i++
+				for i < len(src) && isDigit(src[i]) {
+					i++
+				}
+			}
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				kind = tokenFloat
+				i++
+				if i < len(src) && (src[i] == '+' || src[i] == '-') {
+					i++
+				}
+				for i < len(src) && isDigit(src[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, token{kind: kind, value: src[start:i], pos: start})
+		case c == '"':
+			start := i
+			end := i + 1
+			for end < len(src) && src[end] != '"' {
+				if src[end] == '\\' {
+					end++
+				}
+				if end < len(src) && src[end] == '\n' {
+					return nil, fmt.Errorf("syntax error: unterminated string at %d", start)
+				}
+				end++
+			}
+			if end >= len(src) {
+				return nil, fmt.Errorf("syntax error: unterminated string at %d", start)
+			}
+			unquoted, err := strconv.Unquote(src[start : end+1])
+			if err != nil {
+				return nil, fmt.Errorf("syntax error: invalid string at %d", start)
+			}
+			tokens = append(tokens, token{kind: tokenString, value: unquoted, pos: start})
+			i = end + 1
+		default:
+			return nil, fmt.Errorf("syntax error: unexpected character %q at %d", c, i)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(src)}), nil
+}
+
+func isLetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func parseDocument(src string) (*document, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	doc := &document{fragments: map[string]*fragment{}}
+	for p.peek().kind != tokenEOF {
+		switch {
+		case p.peekPunct("{"):
+			set, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selectionSet: set})
+		case p.peekName("fragment"):
+			frag, err := p.fragmentDefinition()
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := doc.fragments[frag.name]; exists {
+				return nil, fmt.Errorf("fragment %q is defined more than once", frag.name)
+			}
+			doc.fragments[frag.name] = frag
+		case p.peekName("query"), p.peekName("mutation"), p.peekName("subscription"):
+			op, err := p.operationDefinition()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("document has no operation")
+	}
+	return doc, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) peekPunct(v string) bool {
+	t := p.peek()
+	return t.kind == tokenPunct && t.value == v
+}
+
+func (p *parser) peekName(v string) bool {
+	t := p.peek()
+	return t.kind == tokenName && t.value == v
+}
+
+func (p *parser) unexpected() error {
+	t := p.peek()
+	if t.kind == tokenEOF {
+		return fmt.Errorf("syntax error: unexpected end of document")
+	}
+	return fmt.Errorf("syntax error: unexpected %q at %d", t.value, t.pos)
+}
+
+func (p *parser) expect(v string) error {
+	if !p.peekPunct(v) {
+		return p.unexpected()
+	}
+	p.next()
+	return nil
+}
+
+func (p *parser) name() (string, error) {
+	if p.peek().kind != tokenName {
+		return "", p.unexpected()
+	}
+	return p.next().value, nil
+}
+
+func (p *parser) operationDefinition() (*operation, error) {
+	op := &operation{kind: p.next().value}
+	if p.peek().kind == tokenName {
+		op.name = p.next().value
+	}
+	if p.peekPunct("(") {
+		p.next()
+		for !p.peekPunct(")") {
+			if err := p.expect("$"); err != nil {
+				return nil, err
+			}
+			def := variableDef{}
+			var err error
+			if def.name, err = p.name(); err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if def.typ, err = p.typeRef(); err != nil {
+				return nil, err
+			}
+			if p.peekPunct("=") {
+				p.next()
+				if def.defaultValue, err = p.value(true); err != nil {
+					return nil, err
+				}
+			} else {
+				def.defaultValue = value{kind: nullValue}
+			}
+			op.variables = append(op.variables, def)
+		}
+		p.next()
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	set, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selectionSet = set
+	return op, nil
+}
+
+// typeRef keeps the written type, variables are coerced by the arguments they are passed to
+func (p *parser) typeRef() (string, error) {
+	var ref string
+	if p.peekPunct("[") {
+		p.next()
+		inner, err := p.typeRef()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expect("]"); err != nil {
+			return "", err
+		}
+		ref = "[" + inner + "]"
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return "", err
+		}
+		ref = name
+	}
+	if p.peekPunct("!") {
+		p.next()
+		ref += "!"
+	}
+	return ref, nil
+}
+
+func (p *parser) fragmentDefinition() (*fragment, error) {
+	p.next()
+	frag := &fragment{}
+	var err error
+	if frag.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if !p.peekName("on") {
+		return nil, p.unexpected()
+	}
+	p.next()
+	if frag.typeCondition, err = p.name(); err != nil {
+		return nil, err
+	}
+	if _, err := p.directives(); err != nil {
+		return nil, err
+	}
+	if frag.selectionSet, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return frag, nil
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var set []selection
+	for !p.peekPunct("}") {
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, sel)
+	}
+	p.next()
+	if len(set) == 0 {
+		return nil, fmt.Errorf("syntax error: empty selection set")
+	}
+	return set, nil
+}
+
+func (p *parser) selection() (selection, error) {
+	var sel selection
+	var err error
+	if p.peekPunct("...") {
+		p.next()
+		if p.peek().kind == tokenName && !p.peekName("on") {
+			sel.spread = p.next().value
+			sel.directives, err = p.directives()
+			return sel, err
+		}
+		sel.inline = true
+		if p.peekName("on") {
+			p.next()
+			if sel.typeCondition, err = p.name(); err != nil {
+				return sel, err
+			}
+		}
+		if sel.directives, err = p.directives(); err != nil {
+			return sel, err
+		}
+		sel.selectionSet, err = p.selectionSet()
+		return sel, err
+	}
+
+	if sel.name, err = p.name(); err != nil {
+		return sel, err
+	}
+	if p.peekPunct(":") {
+		p.next()
+		sel.alias = sel.name
+		if sel.name, err = p.name(); err != nil {
+			return sel, err
+		}
+	}
+	if p.peekPunct("(") {
+		if sel.arguments, err = p.arguments(false); err != nil {
+			return sel, err
+		}
+	}
+	if sel.directives, err = p.directives(); err != nil {
+		return sel, err
+	}
+	if p.peekPunct("{") {
+		sel.selectionSet, err = p.selectionSet()
+	}
+	return sel, err
+}
+
+func (p *parser) arguments(constant bool) (map[string]value, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	args := map[string]value{}
+	for !p.peekPunct(")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if _, exists := args[name]; exists {
+			return nil, fmt.Errorf("argument %q is given more than once", name)
+		}
+		if args[name], err = p.value(constant); err != nil {
+			return nil, err
+		}
+	}
+	p.next()
+	return args, nil
+}
+
+func (p *parser) directives() ([]directive, error) {
+	var directives []directive
+	for p.peekPunct("@") {
+		p.next()
+		d := directive{}
+		var err error
+		if d.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if p.peekPunct("(") {
+			if d.arguments, err = p.arguments(false); err != nil {
+				return nil, err
+			}
+		}
+		directives = append(directives, d)
+	}
+	return directives, nil
+}
+
+func (p *parser) value(constant bool) (value, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokenInt:
+		p.next()
+		return value{kind: intValue, raw: t.value}, nil
+	case tokenFloat:
+		p.next()
+		return value{kind: floatValue, raw: t.value}, nil
+	case tokenString:
+		p.next()
+		return value{kind: stringValue, raw: t.value}, nil
+	case tokenName:
+		p.next()
+		switch t.value {
+		case "true", "false":
+			return value{kind: booleanValue, raw: t.value}, nil
+		case "null":
+			return value{kind: nullValue}, nil
+		}
+		return value{kind: enumValue, raw: t.value}, nil
+	case tokenPunct:
+		switch t.value {
+		case "$":
+			if constant {
+				return value{}, fmt.Errorf("syntax error: variable not allowed at %d", t.pos)
+			}
+			p.next()
+			name, err := p.name()
+			return value{kind: variableValue, raw: name}, err
+		case "[":
+			p.next()
+			list := value{kind: listValue}
+			for !p.peekPunct("]") {
+				item, err := p.value(constant)
+				if err != nil {
+					return value{}, err
+				}
+				list.list = append(list.list, item)
+			}
+			p.next()
+			return list, nil
+		case "{":
+			p.next()
+			object := value{kind: objectValue, fields: map[string]value{}}
+			for !p.peekPunct("}") {
+				name, err := p.name()
+				if err != nil {
+					return value{}, err
+				}
+				if err := p.expect(":"); err != nil {
+					return value{}, err
+				}
+				if object.fields[name], err = p.value(constant); err != nil {
+					return value{}, err
+				}
+			}
+			p.next()
+			return object, nil
+		}
+	}
+	return value{}, p.unexpected()
+}
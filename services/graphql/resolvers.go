@@ -0,0 +1,414 @@
+package graphqlservice
+
+import (
+	"asset/models"
+	"asset/services/user"
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+var graph = newSchema()
+
+var configTypeNames = map[reflect.Type]string{
+	reflect.TypeOf(models.Laptop_config_res{}):      "LaptopConfig",
+	reflect.TypeOf(models.Mouse_config_res{}):       "MouseConfig",
+	reflect.TypeOf(models.Monitor_config_res{}):     "MonitorConfig",
+	reflect.TypeOf(models.Mobile_config_res{}):      "MobileConfig",
+	reflect.TypeOf(models.Hard_disk_config_res{}):   "HardDiskConfig",
+	reflect.TypeOf(models.Pen_drive_config_res{}):   "PenDriveConfig",
+	reflect.TypeOf(models.Sim_config_res{}):         "SimConfig",
+	reflect.TypeOf(models.Accessories_config_res{}): "AccessoryConfig",
+}
+
+func newSchema() *schema {
+	s := newSchemaBuilder("Query")
+
+	pageArgs := []argDef{{name: "limit", typ: "Int", defaultValue: defaultListLimit}, {name: "offset", typ: "Int", defaultValue: 0}}
+	s.object("Query").
+		field("employees", "[Employee!]!", resolveEmployees, append([]argDef{
+			{name: "search", typ: "String"},
+			{name: "type", typ: "[String!]"},
+			{name: "role", typ: "[String!]"},
+			{name: "assetStatus", typ: "[String!]"},
+		}, pageArgs...)...).
+		field("employee", "Employee", resolveEmployee, argDef{name: "id", typ: "ID!"}).
+		field("assets", "[Asset!]!", resolveAssets, append([]argDef{
+			{name: "search", typ: "String"},
+			{name: "status", typ: "[String!]"},
+			{name: "ownedBy", typ: "[String!]"},
+			{name: "type", typ: "[String!]"},
+		}, pageArgs...)...).
+		field("asset", "Asset", resolveAsset, argDef{name: "id", typ: "ID!"})
+
+	s.structObject("Employee", Employee{}).
+		field("assets", "[Asset!]!", resolveEmployeeAssets).
+		field("assignments", "[Assignment!]!", resolveEmployeeAssignments).
+		field("timeline", "[EmployeeTimelineEvent!]!", resolveEmployeeTimeline,
+			argDef{name: "limit", typ: "Int", defaultValue: 20}, argDef{name: "offset", typ: "Int", defaultValue: 0})
+
+	s.structObject("Asset", Asset{}).
+		field("config", "AssetConfig", resolveAssetConfig).
+		field("assignee", "Employee", resolveAssetAssignee).
+		field("assignments", "[Assignment!]!", resolveAssetAssignments).
+		field("timeline", "[AssetTimelineEvent!]!", resolveAssetTimeline)
+
+	s.structObject("Assignment", Assignment{}).
+		field("asset", "Asset", resolveAssignmentAsset).
+		field("employee", "Employee", resolveAssignmentEmployee)
+
+	s.structObject("EmployeeTimelineEvent", userservice.UserTimelineRes{})
+	s.structObject("AssetTimelineEvent", models.AssetTimelineEvent{})
+
+	union := &unionDef{name: "AssetConfig"}
+	for t, name := range configTypeNames {
+		s.structObject(name, reflect.New(t).Elem().Interface())
+		union.types = append(union.types, name)
+	}
+	sort.Strings(union.types)
+	union.resolveType = func(v interface{}) string { return configTypeNames[reflect.TypeOf(v)] }
+	s.unions[union.name] = union
+	return s
+}
+
+func stringsArg(args map[string]interface{}, name string) []string {
+	v, _ := args[name].([]string)
+	return v
+}
+
+func pageArg(args map[string]interface{}) (int, int, error) {
+	limit, _ := args["limit"].(int)
+	offset, _ := args["offset"].(int)
+	if limit < 1 || limit > maxListLimit {
+		return 0, 0, fmt.Errorf("limit must be between 1 and %d", maxListLimit)
+	}
+	if offset < 0 {
+		return 0, 0, fmt.Errorf("offset can't be negative")
+	}
+	return limit, offset, nil
+}
+
+func resolveEmployees(ctx context.Context, req *resolveRequest, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	limit, offset, err := pageArg(args)
+	if err != nil {
+		return nil, err
+	}
+	search, _ := args["search"].(string)
+	employees, err := req.service.userService.GetEmployeesWithFilters(ctx, userservice.EmployeeFilter{
+		IsSearchText: search != "",
+		SearchText:   search,
+		Type:         stringsArg(args, "type"),
+		Role:         stringsArg(args, "role"),
+		AssetStatus:  stringsArg(args, "assetStatus"),
+		Limit:        limit,
+		Offset:       offset,
+		Scope:        req.scope,
+	})
+	if err != nil {
+		return nil, err
+	}
+	list := make([]interface{}, 0, len(employees))
+	for _, e := range employees {
+		employee := &Employee{
+			ID:            e.ID,
+			Username:      e.Username,
+			Email:         e.Email,
+			ContactNo:     e.ContactNo,
+			Designation:   e.Designation,
+			Location:      e.Location,
+			DateOfJoining: e.DateOfJoining,
+		}
+		if e.EmployeeType != "" {
+			employeeType := e.EmployeeType
+			employee.EmployeeType = &employeeType
+		}
+		req.employees.prime(employee.ID, employee)
+		list = append(list, employee)
+	}
+	return []interface{}{list}, nil
+}
+
+func resolveEmployee(ctx context.Context, req *resolveRequest, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	id, err := uuid.Parse(args["id"].(string))
+	if err != nil {
+		return nil, fmt.Errorf("invalid employee id")
+	}
+	found, err := req.employees.loadMany(ctx, []string{id.String()})
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{found[id.String()]}, nil
+}
+
+func resolveAssets(ctx context.Context, req *resolveRequest, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	limit, offset, err := pageArg(args)
+	if err != nil {
+		return nil, err
+	}
+	filter := models.AssetFilter{
+		Status:  stringsArg(args, "status"),
+		OwnedBy: stringsArg(args, "ownedBy"),
+		Type:    stringsArg(args, "type"),
+		Scope:   req.scope,
+		Limit:   limit,
+		Offset:  offset,
+	}
+	if search, _ := args["search"].(string); search != "" {
+		filter.IsSearchText = true
+		filter.SearchText = "%" + search + "%"
+	}
+	if filter.Status == nil {
+		filter.Status = assetStatuses
+	}
+	if filter.OwnedBy == nil {
+		filter.OwnedBy = assetOwnerships
+	}
+	if filter.Type == nil {
+		filter.Type = assetTypes
+	}
+	assets, err := req.service.assetService.GetAllAssetsWithFilters(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]interface{}, 0, len(assets))
+	for _, a := range assets {
+		asset := &Asset{AssetWithConfigRes: a}
+		req.assets.prime(asset.ID, asset)
+		list = append(list, asset)
+	}
+	return []interface{}{list}, nil
+}
+
+func resolveAsset(ctx context.Context, req *resolveRequest, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	id, err := uuid.Parse(args["id"].(string))
+	if err != nil {
+		return nil, fmt.Errorf("invalid asset id")
+	}
+	found, err := req.assets.loadMany(ctx, []string{id.String()})
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{found[id.String()]}, nil
+}
+
+// assignmentsOf loads the assignment history of every parent in one batch
+func assignmentsOf(ctx context.Context, l *loader, ids []string) ([][]*Assignment, error) {
+	found, err := l.loadMany(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	lists := make([][]*Assignment, len(ids))
+	for i, id := range ids {
+		lists[i], _ = found[id].([]*Assignment)
+	}
+	return lists, nil
+}
+
+func employeeIDs(parents []interface{}) []string {
+	ids := make([]string, len(parents))
+	for i, parent := range parents {
+		ids[i] = parent.(*Employee).ID
+	}
+	return ids
+}
+
+func assetIDs(parents []interface{}) []string {
+	ids := make([]string, len(parents))
+	for i, parent := range parents {
+		ids[i] = parent.(*Asset).ID
+	}
+	return ids
+}
+
+func resolveEmployeeAssignments(ctx context.Context, req *resolveRequest, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	lists, err := assignmentsOf(ctx, req.assignmentsByEmployee, employeeIDs(parents))
+	if err != nil {
+		return nil, err
+	}
+	return assignmentValues(lists), nil
+}
+
+func resolveAssetAssignments(ctx context.Context, req *resolveRequest, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	lists, err := assignmentsOf(ctx, req.assignmentsByAsset, assetIDs(parents))
+	if err != nil {
+		return nil, err
+	}
+	return assignmentValues(lists), nil
+}
+
+func assignmentValues(lists [][]*Assignment) []interface{} {
+	values := make([]interface{}, len(lists))
+	for i, list := range lists {
+		items := make([]interface{}, len(list))
+		for j, a := range list {
+			items[j] = a
+		}
+		values[i] = items
+	}
+	return values
+}
+
+// resolveEmployeeAssets follows the open assignments, the assets of all employees load in one query
+func resolveEmployeeAssets(ctx context.Context, req *resolveRequest, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	lists, err := assignmentsOf(ctx, req.assignmentsByEmployee, employeeIDs(parents))
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, list := range lists {
+		for _, a := range list {
+			if a.ReturnedAt == nil {
+				ids = append(ids, a.AssetID)
+			}
+		}
+	}
+	assets, err := req.assets.loadMany(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(parents))
+	for i, list := range lists {
+		items := []interface{}{}
+		for _, a := range list {
+			if asset := assets[a.AssetID]; a.ReturnedAt == nil && asset != nil {
+				items = append(items, asset)
+			}
+		}
+		values[i] = items
+	}
+	return values, nil
+}
+
+func resolveAssetAssignee(ctx context.Context, req *resolveRequest, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	lists, err := assignmentsOf(ctx, req.assignmentsByAsset, assetIDs(parents))
+	if err != nil {
+		return nil, err
+	}
+	current := make([]string, len(lists))
+	var ids []string
+	for i, list := range lists {
+		for _, a := range list {
+			if a.ReturnedAt == nil {
+				current[i] = a.EmployeeID
+				ids = append(ids, a.EmployeeID)
+				break
+			}
+		}
+	}
+	employees, err := req.employees.loadMany(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(parents))
+	for i, id := range current {
+		if id != "" {
+			values[i] = employees[id]
+		}
+	}
+	return values, nil
+}
+
+func resolveAssetConfig(ctx context.Context, req *resolveRequest, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	values := make([]interface{}, len(parents))
+	var keys []string
+	for i, parent := range parents {
+		asset := parent.(*Asset)
+		// assets listed through the asset service come with their config
+		if asset.Config != nil {
+			values[i] = asset.Config
+			continue
+		}
+		keys = append(keys, asset.Type+":"+asset.ID)
+	}
+	if len(keys) == 0 {
+		return values, nil
+	}
+	configs, err := req.configs.loadMany(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+	for i, parent := range parents {
+		asset := parent.(*Asset)
+		if values[i] == nil {
+			values[i] = configs[asset.Type+":"+asset.ID]
+		}
+	}
+	return values, nil
+}
+
+func resolveAssignmentAsset(ctx context.Context, req *resolveRequest, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	ids := make([]string, len(parents))
+	for i, parent := range parents {
+		ids[i] = parent.(*Assignment).AssetID
+	}
+	return loadByID(ctx, req.assets, ids)
+}
+
+func resolveAssignmentEmployee(ctx context.Context, req *resolveRequest, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	ids := make([]string, len(parents))
+	for i, parent := range parents {
+		ids[i] = parent.(*Assignment).EmployeeID
+	}
+	return loadByID(ctx, req.employees, ids)
+}
+
+func loadByID(ctx context.Context, l *loader, ids []string) ([]interface{}, error) {
+	found, err := l.loadMany(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(ids))
+	for i, id := range ids {
+		values[i] = found[id]
+	}
+	return values, nil
+}
+
+// timelines go through the services one parent at a time, so their scope checks and paging stay
+// in one place
+func resolveEmployeeTimeline(ctx context.Context, req *resolveRequest, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	limit, offset, err := pageArg(args)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(parents))
+	for i, parent := range parents {
+		id, err := uuid.Parse(parent.(*Employee).ID)
+		if err != nil {
+			return nil, err
+		}
+		timeline, err := req.service.userService.GetEmployeeTimeline(ctx, id, limit, offset, req.scope)
+		if err != nil {
+			return nil, err
+		}
+		items := make([]interface{}, len(timeline))
+		for j := range timeline {
+			items[j] = timeline[j]
+		}
+		values[i] = items
+	}
+	return values, nil
+}
+
+func resolveAssetTimeline(ctx context.Context, req *resolveRequest, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+	values := make([]interface{}, len(parents))
+	for i, parent := range parents {
+		id, err := uuid.Parse(parent.(*Asset).ID)
+		if err != nil {
+			return nil, err
+		}
+		timeline, err := req.service.assetService.GetAssetTimeline(ctx, id, req.scope)
+		if err != nil {
+			return nil, err
+		}
+		items := make([]interface{}, len(timeline))
+		for j := range timeline {
+			items[j] = timeline[j]
+		}
+		values[i] = items
+	}
+	return values, nil
+}
@@ -0,0 +1,222 @@
+package graphqlservice
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// resolverFunc answers a field for every parent of a level at once, one value per parent in order
+type resolverFunc func(ctx context.Context, req *resolveRequest, parents []interface{}, args map[string]interface{}) ([]interface{}, error)
+
+type argDef struct {
+	name         string
+	typ          string
+	defaultValue interface{}
+}
+
+type fieldDef struct {
+	typ     string
+	args    []argDef
+	resolve resolverFunc
+}
+
+func (f *fieldDef) hasArgument(name string) bool {
+	for _, arg := range f.args {
+		if arg.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+type objectDef struct {
+	name   string
+	order  []string
+	fields map[string]*fieldDef
+}
+
+func (o *objectDef) field(name, typ string, resolve resolverFunc, args ...argDef) *objectDef {
+	if _, exists := o.fields[name]; !exists {
+		o.order = append(o.order, name)
+	}
+	o.fields[name] = &fieldDef{typ: typ, args: args, resolve: resolve}
+	return o
+}
+
+type unionDef struct {
+	name        string
+	types       []string
+	resolveType func(interface{}) string
+}
+
+type schema struct {
+	query   string
+	objects map[string]*objectDef
+	unions  map[string]*unionDef
+}
+
+func newSchemaBuilder(query string) *schema {
+	return &schema{query: query, objects: map[string]*objectDef{}, unions: map[string]*unionDef{}}
+}
+
+func (s *schema) object(name string) *objectDef {
+	object := &objectDef{name: name, fields: map[string]*fieldDef{}}
+	s.objects[name] = object
+	return object
+}
+
+// structObject declares an object whose scalar fields mirror the json tags of sample, snake_case
+// tags become camelCase fields
+func (s *schema) structObject(name string, sample interface{}) *objectDef {
+	object := s.object(name)
+	t := reflect.TypeOf(sample)
+	for _, field := range reflect.VisibleFields(t) {
+		if field.Anonymous || !field.IsExported() {
+			continue
+		}
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		typ := scalarType(tag, field.Type)
+		if typ == "" {
+			continue
+		}
+		index := field.Index
+		object.field(camelCase(tag), typ, func(ctx context.Context, req *resolveRequest, parents []interface{}, args map[string]interface{}) ([]interface{}, error) {
+			values := make([]interface{}, len(parents))
+			for i, parent := range parents {
+				v := reflect.Indirect(reflect.ValueOf(parent)).FieldByIndex(index)
+				if v.Kind() == reflect.Ptr {
+					if v.IsNil() {
+						continue
+					}
+					v = v.Elem()
+				}
+				values[i] = v.Interface()
+			}
+			return values, nil
+		})
+	}
+	return object
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(uuid.UUID{})
+)
+
+func scalarType(tag string, t reflect.Type) string {
+	nonNull := "!"
+	if t.Kind() == reflect.Ptr {
+		nonNull = ""
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return "Time" + nonNull
+	case t == uuidType:
+		return "ID" + nonNull
+	}
+	switch t.Kind() {
+	case reflect.String:
+		if tag == "id" || strings.HasSuffix(tag, "_id") {
+			return "ID" + nonNull
+		}
+		return "String" + nonNull
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return "Int" + nonNull
+	case reflect.Float32, reflect.Float64:
+		return "Float" + nonNull
+	case reflect.Bool:
+		return "Boolean" + nonNull
+	}
+	return ""
+}
+
+func camelCase(tag string) string {
+	parts := strings.Split(tag, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+func (s *schema) isComposite(name string) bool {
+	_, object := s.objects[name]
+	_, union := s.unions[name]
+	return object || union
+}
+
+// typeApplies tells whether a fragment on condition applies to an object of typeName
+func (s *schema) typeApplies(condition, typeName string) bool {
+	if condition == typeName {
+		return true
+	}
+	if union, ok := s.unions[condition]; ok {
+		for _, member := range union.types {
+			if member == typeName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// possible tells whether a fragment on condition can ever match inside a selection on parent
+func (s *schema) possible(condition, parent string) bool {
+	return s.typeApplies(condition, parent) || s.typeApplies(parent, condition)
+}
+
+// sdl renders the schema for clients generating their types, introspection isn't served
+func (s *schema) sdl() string {
+	var b strings.Builder
+	b.WriteString("scalar Time\n")
+
+	names := make([]string, 0, len(s.objects))
+	for name := range s.objects {
+		if name != s.query {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range append([]string{s.query}, names...) {
+		object := s.objects[name]
+		b.WriteString("\ntype " + name + " {\n")
+		for _, fieldName := range object.order {
+			field := object.fields[fieldName]
+			b.WriteString("  " + fieldName)
+			if len(field.args) > 0 {
+				args := make([]string, 0, len(field.args))
+				for _, arg := range field.args {
+					def := arg.name + ": " + arg.typ
+					if v, ok := arg.defaultValue.(int); ok {
+						def += " = " + strconv.Itoa(v)
+					}
+					args = append(args, def)
+				}
+				b.WriteString("(" + strings.Join(args, ", ") + ")")
+			}
+			b.WriteString(": " + field.typ + "\n")
+		}
+		b.WriteString("}\n")
+	}
+
+	unions := make([]string, 0, len(s.unions))
+	for name := range s.unions {
+		unions = append(unions, name)
+	}
+	sort.Strings(unions)
+	for _, name := range unions {
+		b.WriteString("\nunion " + name + " = " + strings.Join(s.unions[name].types, " | ") + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,83 @@
+package integrityservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type IntegrityHandler struct {
+	Service        IntegrityService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewIntegrityHandler(service IntegrityService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *IntegrityHandler {
+	return &IntegrityHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+// RunCheck runs the integrity checks now, with repair=true the issues safe to fix are fixed. Callers
+// bound to an organization only check and repair its rows
+func (h *IntegrityHandler) RunCheck(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("RunCheck request received")
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in RunCheck", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	adminID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in RunCheck", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+	repair := false
+	if value := r.URL.Query().Get("repair"); value != "" {
+		if repair, err = strconv.ParseBool(value); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, "invalid repair")
+			return
+		}
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in RunCheck", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	report, err := h.Service.Check(r.Context(), repair, &adminID, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Integrity check failed", zap.Bool("repair", repair), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to check data integrity")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, report)
+}
+
+func (h *IntegrityHandler) GetReports(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetReports request received")
+	limit, offset := utils.GetPageLimitAndOffset(r)
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in GetReports", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+	reports, err := h.Service.GetReports(r.Context(), scope, limit, offset)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch integrity reports", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch integrity reports")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"reports": reports, "limit": limit, "offset": offset})
+}
@@ -0,0 +1,156 @@
+package integrityservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type IntegrityRepository interface {
+	// FindIssues runs every check and returns the inconsistent rows, orphaned configs first. With an
+	// organization only its rows are checked, orphaned configs belong to none and are left out
+	FindIssues(ctx context.Context, organizationID *uuid.UUID) ([]IntegrityIssue, error)
+	// RepairIssue fixes one repairable issue, a row that was fixed in the meantime is left alone.
+	// repairedBy is nil for scheduled runs
+	RepairIssue(ctx context.Context, issue IntegrityIssue, repairedBy *uuid.UUID) error
+	InsertIntegrityRun(ctx context.Context, report IntegrityReport) error
+	// GetIntegrityRuns only returns the runs of organizationID when it is set
+	GetIntegrityRuns(ctx context.Context, organizationID *uuid.UUID, limit, offset int) ([]IntegrityRun, error)
+}
+
+type PostgresIntegrityRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewIntegrityRepository(db *sqlx.DB, log providers.ZapLoggerProvider) IntegrityRepository {
+	return &PostgresIntegrityRepository{
+		DB:     db,
+		Logger: log,
+	}
+}
+
+// issuesQuery selects check_name, table_name, row_id and detail of every inconsistent row, of the
+// organization in $1 when it is set
+var issuesQuery = func() string {
+	var checks []string
+	tables := make([]string, 0, len(configTables))
+	for table := range configTables {
+		tables = append(tables, table)
+	}
+	slices.Sort(tables)
+	for _, table := range tables {
+		checks = append(checks, fmt.Sprintf(`
+		SELECT '%[3]s' AS check_name, '%[1]s' AS table_name, c.id AS row_id, 'config has no asset' AS detail,
+			NULL::uuid AS organization_id
+		FROM %[1]s c WHERE c.archived_at IS NULL AND c.asset_id IS NULL`, table, configTables[table], CheckConfigWithoutAsset))
+		checks = append(checks, fmt.Sprintf(`
+		SELECT '%[3]s', '%[1]s', c.id, 'asset ' || a.serial_no || ' is a ' || a.type::text || ', not a %[2]s',
+			a.organization_id
+		FROM %[1]s c JOIN assets a ON a.id = c.asset_id
+		WHERE c.archived_at IS NULL AND a.type <> '%[2]s'`, table, configTables[table], CheckConfigTypeMismatch))
+	}
+	checks = append(checks, `
+		SELECT '`+CheckAssignmentArchivedAsset+`', 'asset_assign', aa.id, 'asset ' || a.serial_no || ' was archived while assigned',
+			aa.organization_id
+		FROM asset_assign aa JOIN assets a ON a.id = aa.asset_id
+		WHERE aa.returned_at IS NULL AND aa.archived_at IS NULL AND a.archived_at IS NOT NULL`, `
+		SELECT '`+CheckAssignmentArchivedUser+`', 'asset_assign', aa.id, 'asset ' || a.serial_no || ' is still assigned to archived user ' || u.email,
+			aa.organization_id
+		FROM asset_assign aa JOIN assets a ON a.id = aa.asset_id JOIN users u ON u.id = aa.employee_id
+		WHERE aa.returned_at IS NULL AND aa.archived_at IS NULL AND u.archived_at IS NOT NULL`, `
+		SELECT '`+CheckUserWithoutRole+`', 'users', u.id, u.email, u.organization_id
+		FROM users u
+		WHERE u.archived_at IS NULL AND u.auth_provider <> 'service_account'
+		AND NOT EXISTS (SELECT 1 FROM user_roles ur WHERE ur.user_id = u.id AND ur.archived_at IS NULL)`, `
+		SELECT '`+CheckUserWithoutType+`', 'users', u.id, u.email, u.organization_id
+		FROM users u
+		WHERE u.archived_at IS NULL AND u.auth_provider <> 'service_account'
+		AND NOT EXISTS (SELECT 1 FROM user_type ut WHERE ut.user_id = u.id AND ut.archived_at IS NULL)`)
+	return `
+	SELECT check_name, table_name, row_id, detail FROM (` + strings.Join(checks, "\n\t\tUNION ALL") + `
+	) issues
+	WHERE $1::uuid IS NULL OR organization_id = $1`
+}()
+
+func (r *PostgresIntegrityRepository) FindIssues(ctx context.Context, organizationID *uuid.UUID) ([]IntegrityIssue, error) {
+	issues := make([]IntegrityIssue, 0)
+	if err := utils.Conn(ctx, r.DB).SelectContext(ctx, &issues, issuesQuery, organizationID); err != nil {
+		r.Logger.GetLogger().Error("failed to run integrity checks", zap.Error(err))
+		return nil, fmt.Errorf("failed to run integrity checks: %w", err)
+	}
+	return issues, nil
+}
+
+func (r *PostgresIntegrityRepository) RepairIssue(ctx context.Context, issue IntegrityIssue, repairedBy *uuid.UUID) error {
+	var query string
+	args := []interface{}{issue.RowID}
+	switch issue.Check {
+	case CheckConfigWithoutAsset:
+		// the table comes from our own query, it is still only trusted when it is a known one
+		if _, ok := configTables[issue.Table]; !ok {
+			return fmt.Errorf("unknown config table %q", issue.Table)
+		}
+		query = `UPDATE ` + issue.Table + ` SET archived_at = now() WHERE id = $1 AND asset_id IS NULL AND archived_at IS NULL`
+	case CheckAssignmentArchivedAsset:
+		query = `
+			UPDATE asset_assign aa SET returned_at = now(), return_reason = 'asset archived'
+			FROM assets a
+			WHERE aa.id = $1 AND a.id = aa.asset_id AND a.archived_at IS NOT NULL
+			AND aa.returned_at IS NULL AND aa.archived_at IS NULL`
+	case CheckUserWithoutRole:
+		// like a self registration, the user is their own creator when no one ran the check
+		query = `
+			INSERT INTO user_roles (role, user_id, created_by)
+			SELECT 'employee', u.id, COALESCE($2, u.id) FROM users u
+			WHERE u.id = $1 AND u.archived_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM user_roles ur WHERE ur.user_id = u.id AND ur.archived_at IS NULL)`
+		args = append(args, repairedBy)
+	default:
+		return fmt.Errorf("%s issues can't be repaired automatically", issue.Check)
+	}
+	if _, err := utils.Conn(ctx, r.DB).ExecContext(ctx, query, args...); err != nil {
+		r.Logger.GetLogger().Error("failed to repair integrity issue", zap.String("check", issue.Check), zap.String("row_id", issue.RowID.String()), zap.Error(err))
+		return fmt.Errorf("failed to repair %s: %w", issue.Check, err)
+	}
+	return nil
+}
+
+func (r *PostgresIntegrityRepository) InsertIntegrityRun(ctx context.Context, report IntegrityReport) error {
+	payload, err := utils.JSON.Marshal(report)
+	if err != nil {
+		return err
+	}
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO integrity_runs (id, repair, issues, repaired, triggered_by, report, started_at, finished_at, organization_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, report.ID, report.Repair, len(report.Issues), report.Repaired, report.TriggeredBy, payload, report.StartedAt, report.FinishedAt, report.OrganizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert integrity run", zap.String("run_id", report.ID.String()), zap.Error(err))
+		return fmt.Errorf("failed to save integrity report: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresIntegrityRepository) GetIntegrityRuns(ctx context.Context, organizationID *uuid.UUID, limit, offset int) ([]IntegrityRun, error) {
+	runs := make([]IntegrityRun, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &runs, `
+		SELECT id, repair, triggered_by, report, started_at, finished_at
+		FROM integrity_runs
+		WHERE $3::uuid IS NULL OR organization_id = $3
+		ORDER BY started_at DESC
+		LIMIT $1 OFFSET $2
+	`, limit, offset, organizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch integrity runs", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch integrity runs: %w", err)
+	}
+	return runs, nil
+}
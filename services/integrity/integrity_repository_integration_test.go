@@ -0,0 +1,126 @@
+//go:build integration
+
+package integrityservice
+
+import (
+	"asset/database/seed"
+	"asset/database/testdb"
+	"asset/models"
+	"asset/providers"
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func issuesOf(issues []IntegrityIssue, check string) []uuid.UUID {
+	ids := make([]uuid.UUID, 0)
+	for _, issue := range issues {
+		if issue.Check == check {
+			ids = append(ids, issue.RowID)
+		}
+	}
+	return ids
+}
+
+func TestFindAndRepairIssues(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewIntegrityRepository(db, logger)
+	ctx := context.Background()
+
+	// the seed is consistent
+	issues, err := repo.FindIssues(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+
+	var orphanID, mismatchID, assetID, assignmentID, userID uuid.UUID
+	require.NoError(t, db.Get(&orphanID, `INSERT INTO mouse_config (dpi) VALUES ('1600') RETURNING id`))
+	require.NoError(t, db.Get(&mismatchID, `INSERT INTO mouse_config (asset_id, dpi) VALUES ($1, '800') RETURNING id`, seed.ID("asset:monitor-1")))
+	require.NoError(t, db.Get(&assetID, `
+		INSERT INTO assets (brand, model, serial_no, type, archived_at) VALUES ('Dell', 'XPS 13', 'ORPHAN-1', 'laptop', now()) RETURNING id`))
+	require.NoError(t, db.Get(&assignmentID, `
+		INSERT INTO asset_assign (asset_id, employee_id, assigned_by) VALUES ($1, $2, $3) RETURNING id`,
+		assetID, seed.ID("user:designer"), seed.ID("user:asset-manager")))
+	require.NoError(t, db.Get(&userID, `
+		INSERT INTO users (username, email) VALUES ('Half Made', 'half.made@remotestate.com') RETURNING id`))
+
+	issues, err = repo.FindIssues(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{orphanID}, issuesOf(issues, CheckConfigWithoutAsset))
+	assert.Equal(t, []uuid.UUID{mismatchID}, issuesOf(issues, CheckConfigTypeMismatch))
+	assert.Equal(t, []uuid.UUID{assignmentID}, issuesOf(issues, CheckAssignmentArchivedAsset))
+	assert.Equal(t, []uuid.UUID{userID}, issuesOf(issues, CheckUserWithoutRole))
+	assert.Equal(t, []uuid.UUID{userID}, issuesOf(issues, CheckUserWithoutType))
+
+	for _, issue := range issues {
+		if repairable[issue.Check] {
+			require.NoError(t, repo.RepairIssue(ctx, issue, nil))
+		}
+	}
+	issues, err = repo.FindIssues(ctx, nil)
+	require.NoError(t, err)
+	assert.Empty(t, issuesOf(issues, CheckConfigWithoutAsset))
+	assert.Empty(t, issuesOf(issues, CheckAssignmentArchivedAsset))
+	assert.Empty(t, issuesOf(issues, CheckUserWithoutRole))
+	assert.Len(t, issues, 2)
+
+	var createdBy uuid.UUID
+	require.NoError(t, db.Get(&createdBy, `SELECT created_by FROM user_roles WHERE user_id = $1 AND role = 'employee'`, userID))
+	assert.Equal(t, userID, createdBy)
+}
+
+// a caller bound to an organization only sees, and repairs, its own rows
+func TestFindIssuesOtherOrganization(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewIntegrityRepository(db, logger)
+	ctx := context.Background()
+
+	var other, otherUserID, defaultUserID, orphanID uuid.UUID
+	require.NoError(t, db.Get(&other, `INSERT INTO organizations (name, slug) VALUES ('Other', 'other') RETURNING id`))
+	require.NoError(t, db.Get(&otherUserID, `
+		INSERT INTO users (username, email, organization_id) VALUES ('Other Half', 'half@other.com', $1) RETURNING id`, other))
+	require.NoError(t, db.Get(&defaultUserID, `
+		INSERT INTO users (username, email) VALUES ('Half Made', 'half.made@remotestate.com') RETURNING id`))
+	require.NoError(t, db.Get(&orphanID, `INSERT INTO mouse_config (dpi) VALUES ('1600') RETURNING id`))
+
+	issues, err := repo.FindIssues(ctx, &other)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{otherUserID}, issuesOf(issues, CheckUserWithoutRole))
+	assert.Empty(t, issuesOf(issues, CheckConfigWithoutAsset))
+	for _, issue := range issues {
+		assert.NotContains(t, issue.Detail, "remotestate.com")
+	}
+
+	issues, err = repo.FindIssues(ctx, &models.DefaultOrganizationID)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{defaultUserID}, issuesOf(issues, CheckUserWithoutRole))
+
+	issues, err = repo.FindIssues(ctx, nil)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{otherUserID, defaultUserID}, issuesOf(issues, CheckUserWithoutRole))
+	assert.Equal(t, []uuid.UUID{orphanID}, issuesOf(issues, CheckConfigWithoutAsset))
+
+	report := IntegrityReport{ID: uuid.New(), OrganizationID: &other, Issues: []IntegrityIssue{}}
+	require.NoError(t, repo.InsertIntegrityRun(ctx, report))
+	runs, err := repo.GetIntegrityRuns(ctx, &models.DefaultOrganizationID, 10, 0)
+	require.NoError(t, err)
+	assert.Empty(t, runs)
+	runs, err = repo.GetIntegrityRuns(ctx, &other, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, report.ID, runs[0].ID)
+}
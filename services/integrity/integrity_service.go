@@ -0,0 +1,100 @@
+package integrityservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/audit"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// IntegrityService looks for rows left inconsistent by partial failures. Every check is saved as a
+// report, with repair the issues safe to fix are fixed and audited
+type IntegrityService interface {
+	// Check only looks at the rows of the scope's organization when it has one
+	Check(ctx context.Context, repair bool, triggeredBy *uuid.UUID, scope models.DepartmentScope) (IntegrityReport, error)
+	// RunScheduled is run by the check_data_integrity job, it repairs when auto repair is on
+	RunScheduled(ctx context.Context) error
+	GetReports(ctx context.Context, scope models.DepartmentScope, limit, offset int) ([]IntegrityReport, error)
+}
+
+type integrityServiceStruct struct {
+	repo       IntegrityRepository
+	audit      auditservice.AuditService
+	logger     providers.ZapLoggerProvider
+	autoRepair bool
+}
+
+func NewIntegrityService(repo IntegrityRepository, audit auditservice.AuditService, logger providers.ZapLoggerProvider, autoRepair bool) IntegrityService {
+	return &integrityServiceStruct{
+		repo:       repo,
+		audit:      audit,
+		logger:     logger,
+		autoRepair: autoRepair,
+	}
+}
+
+func (s *integrityServiceStruct) Check(ctx context.Context, repair bool, triggeredBy *uuid.UUID, scope models.DepartmentScope) (IntegrityReport, error) {
+	report := IntegrityReport{ID: uuid.New(), Repair: repair, TriggeredBy: triggeredBy, OrganizationID: scope.OrganizationID, StartedAt: time.Now()}
+	issues, err := s.repo.FindIssues(ctx, scope.OrganizationID)
+	if err != nil {
+		return IntegrityReport{}, err
+	}
+	for i := range issues {
+		issue := &issues[i]
+		issue.Repairable = repairable[issue.Check]
+		if !repair || !issue.Repairable {
+			continue
+		}
+		if err := s.repo.RepairIssue(ctx, *issue, triggeredBy); err != nil {
+			issue.Error = err.Error()
+			continue
+		}
+		issue.Repaired = true
+		report.Repaired++
+		if err := s.audit.Record(ctx, auditservice.AuditEntry{
+			ActorID:    triggeredBy,
+			Action:     "integrity.repaired",
+			EntityType: issue.Table,
+			EntityID:   issue.RowID.String(),
+			NewValue:   map[string]interface{}{"check": issue.Check, "run_id": report.ID},
+		}); err != nil {
+			s.logger.GetLogger().Warn("failed to audit integrity repair", zap.String("rowID", issue.RowID.String()), zap.Error(err))
+		}
+	}
+	report.Issues = issues
+	report.FinishedAt = time.Now()
+	if err := s.repo.InsertIntegrityRun(ctx, report); err != nil {
+		return IntegrityReport{}, err
+	}
+	if len(issues) > 0 {
+		s.logger.GetLogger().Warn("integrity check found issues", zap.String("runID", report.ID.String()), zap.Int("issues", len(issues)), zap.Int("repaired", report.Repaired))
+	}
+	return report, nil
+}
+
+func (s *integrityServiceStruct) RunScheduled(ctx context.Context) error {
+	_, err := s.Check(ctx, s.autoRepair, nil, models.DepartmentScope{AllDepartments: true})
+	return err
+}
+
+func (s *integrityServiceStruct) GetReports(ctx context.Context, scope models.DepartmentScope, limit, offset int) ([]IntegrityReport, error) {
+	runs, err := s.repo.GetIntegrityRuns(ctx, scope.OrganizationID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]IntegrityReport, 0, len(runs))
+	for _, run := range runs {
+		var report IntegrityReport
+		if err := json.Unmarshal(run.Report, &report); err != nil {
+			s.logger.GetLogger().Error("failed to decode integrity report", zap.String("runID", run.ID.String()), zap.Error(err))
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
@@ -0,0 +1,91 @@
+package integrityservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/audit"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCheckRepairsSafeIssues(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	adminID := uuid.New()
+	orgID := uuid.New()
+	repo := NewMockIntegrityRepository(ctrl)
+	audit := auditservice.NewMockAuditService(ctrl)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	orphan := IntegrityIssue{Check: CheckConfigWithoutAsset, Table: "laptop_config", RowID: uuid.New()}
+	noRole := IntegrityIssue{Check: CheckUserWithoutRole, Table: "users", RowID: uuid.New()}
+	leaver := IntegrityIssue{Check: CheckAssignmentArchivedUser, Table: "asset_assign", RowID: uuid.New()}
+	repo.EXPECT().FindIssues(ctx, &orgID).Return([]IntegrityIssue{orphan, noRole, leaver}, nil)
+	repo.EXPECT().RepairIssue(ctx, IntegrityIssue{Check: orphan.Check, Table: orphan.Table, RowID: orphan.RowID, Repairable: true}, &adminID).Return(nil)
+	repo.EXPECT().RepairIssue(ctx, IntegrityIssue{Check: noRole.Check, Table: noRole.Table, RowID: noRole.RowID, Repairable: true}, &adminID).Return(errors.New("deadlock detected"))
+	audit.EXPECT().Record(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, entry auditservice.AuditEntry) error {
+		assert.Equal(t, "integrity.repaired", entry.Action)
+		assert.Equal(t, orphan.RowID.String(), entry.EntityID)
+		return nil
+	})
+	repo.EXPECT().InsertIntegrityRun(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, report IntegrityReport) error {
+		assert.Equal(t, &orgID, report.OrganizationID)
+		return nil
+	})
+
+	scope := models.DepartmentScope{AllDepartments: true, OrganizationID: &orgID}
+	report, err := NewIntegrityService(repo, audit, logger, false).Check(ctx, true, &adminID, scope)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Repaired)
+	require.Len(t, report.Issues, 3)
+	assert.True(t, report.Issues[0].Repaired)
+	assert.False(t, report.Issues[1].Repaired)
+	assert.Equal(t, "deadlock detected", report.Issues[1].Error)
+	// an archived user may still hold the asset, it is only reported
+	assert.False(t, report.Issues[2].Repairable)
+	assert.False(t, report.Issues[2].Repaired)
+}
+
+// scheduled runs only report unless auto repair is on
+func TestRunScheduledReportsOnly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	repo := NewMockIntegrityRepository(ctrl)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	repo.EXPECT().FindIssues(ctx, (*uuid.UUID)(nil)).Return([]IntegrityIssue{{Check: CheckConfigWithoutAsset, Table: "mouse_config", RowID: uuid.New()}}, nil)
+	repo.EXPECT().InsertIntegrityRun(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, report IntegrityReport) error {
+		assert.False(t, report.Repair)
+		assert.Nil(t, report.TriggeredBy)
+		assert.Nil(t, report.OrganizationID)
+		assert.True(t, report.Issues[0].Repairable)
+		assert.Zero(t, report.Repaired)
+		return nil
+	})
+
+	svc := NewIntegrityService(repo, auditservice.NewMockAuditService(ctrl), logger, false)
+	require.NoError(t, svc.RunScheduled(ctx))
+
+	report := IntegrityReport{ID: uuid.New(), Issues: []IntegrityIssue{}}
+	payload, err := json.Marshal(report)
+	require.NoError(t, err)
+	repo.EXPECT().GetIntegrityRuns(ctx, (*uuid.UUID)(nil), 10, 0).Return([]IntegrityRun{{ID: report.ID, Report: payload}}, nil)
+	reports, err := svc.GetReports(ctx, models.DepartmentScope{AllDepartments: true}, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, report.ID, reports[0].ID)
+}
@@ -0,0 +1,72 @@
+package integrityservice
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// a type config row whose asset_id was never set
+	CheckConfigWithoutAsset = "config_without_asset"
+	// a type config row of an asset of another type
+	CheckConfigTypeMismatch = "config_type_mismatch"
+	// an open assignment of an archived asset
+	CheckAssignmentArchivedAsset = "open_assignment_archived_asset"
+	// an open assignment of an archived user, the asset may still be with them so it is only reported
+	CheckAssignmentArchivedUser = "open_assignment_archived_user"
+	CheckUserWithoutRole        = "user_without_role"
+	CheckUserWithoutType        = "user_without_type"
+)
+
+// repairable are the checks whose fix can't lose anything: orphaned configs are archived, open
+// assignments of archived assets are closed and users without a role get the employee role
+var repairable = map[string]bool{
+	CheckConfigWithoutAsset:      true,
+	CheckAssignmentArchivedAsset: true,
+	CheckUserWithoutRole:         true,
+}
+
+// configTables maps each type config table to the asset type its rows belong to
+var configTables = map[string]string{
+	"laptop_config":      "laptop",
+	"mouse_config":       "mouse",
+	"monitor_config":     "monitor",
+	"hard_disk_config":   "hard_disk",
+	"pendrive_config":    "pen_drive",
+	"mobile_config":      "mobile",
+	"sim_config":         "sim",
+	"accessories_config": "accessory",
+}
+
+// IntegrityIssue is one inconsistent row, Table and RowID locate it
+type IntegrityIssue struct {
+	Check      string    `json:"check" db:"check_name"`
+	Table      string    `json:"table" db:"table_name"`
+	RowID      uuid.UUID `json:"row_id" db:"row_id"`
+	Detail     string    `json:"detail" db:"detail"`
+	Repairable bool      `json:"repairable"`
+	Repaired   bool      `json:"repaired"`
+	Error      string    `json:"error,omitempty"`
+}
+
+type IntegrityReport struct {
+	ID          uuid.UUID  `json:"id"`
+	Repair      bool       `json:"repair"`
+	TriggeredBy *uuid.UUID `json:"triggered_by,omitempty"`
+	// OrganizationID is the organization checked, nil when every organization was
+	OrganizationID *uuid.UUID       `json:"organization_id,omitempty"`
+	Issues         []IntegrityIssue `json:"issues"`
+	Repaired       int              `json:"repaired"`
+	StartedAt      time.Time        `json:"started_at"`
+	FinishedAt     time.Time        `json:"finished_at"`
+}
+
+type IntegrityRun struct {
+	ID          uuid.UUID  `db:"id"`
+	Repair      bool       `db:"repair"`
+	TriggeredBy *uuid.UUID `db:"triggered_by"`
+	Report      []byte     `db:"report"`
+	StartedAt   time.Time  `db:"started_at"`
+	FinishedAt  time.Time  `db:"finished_at"`
+}
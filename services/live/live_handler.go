@@ -0,0 +1,97 @@
+package liveservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type LiveHandler struct {
+	Service        LiveService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewLiveHandler(service LiveService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *LiveHandler {
+	return &LiveHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+const (
+	// a comment line this often keeps proxies from closing an idle stream
+	heartbeatInterval = 25 * time.Second
+	// each write moves the deadline, the server's write timeout would otherwise end the stream
+	streamWriteTimeout = 30 * time.Second
+	// EventSource clients wait this long before reconnecting
+	reconnectDelay = 3 * time.Second
+)
+
+// StreamAssetUpdates is a server-sent event stream of asset and assignment changes in the caller's
+// department scope. Clients fetch the assets once, then apply updates and fetch again on resync or
+// reconnect
+func (h *LiveHandler) StreamAssetUpdates(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("StreamAssetUpdates request received")
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department in StreamAssetUpdates", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	updates, unsubscribe := h.Service.Subscribe(scope)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	stream := http.NewResponseController(w)
+	write := func(frame string) error {
+		stream.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if _, err := fmt.Fprint(w, frame); err != nil {
+			return err
+		}
+		return stream.Flush()
+	}
+	if err := write(fmt.Sprintf("retry: %d\n\n", reconnectDelay.Milliseconds())); err != nil {
+		h.Logger.GetLogger().Error("Failed to open asset update stream", zap.Error(err))
+		return
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		var frame string
+		select {
+		case <-r.Context().Done():
+			return
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			if update.Type == ResyncEvent {
+				frame = "event: " + ResyncEvent + "\ndata: {}\n\n"
+				break
+			}
+			data, err := utils.JSON.Marshal(update)
+			if err != nil {
+				h.Logger.GetLogger().Error("Failed to marshal asset update", zap.Error(err))
+				continue
+			}
+			frame = fmt.Sprintf("id: %s\nevent: %s\ndata: %s\n\n", update.ID, update.Type, data)
+		case <-heartbeat.C:
+			frame = ": ping\n\n"
+		}
+		if err := write(frame); err != nil {
+			h.Logger.GetLogger().Debug("asset update stream closed", zap.Error(err))
+			return
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package liveservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+type LiveRepository interface {
+	GetAssetDepartment(ctx context.Context, assetID uuid.UUID) (AssetDepartment, error)
+}
+
+type PostgresLiveRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewLiveRepository(db *sqlx.DB, log providers.ZapLoggerProvider) LiveRepository {
+	return &PostgresLiveRepository{DB: db, Logger: log}
+}
+
+// GetAssetDepartment includes archived assets, a deleted asset's update goes to its department too
+func (r *PostgresLiveRepository) GetAssetDepartment(ctx context.Context, assetID uuid.UUID) (AssetDepartment, error) {
+	var department AssetDepartment
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &department, `SELECT department_id, organization_id FROM assets WHERE id = $1`, assetID)
+	if err != nil {
+		return department, fmt.Errorf("failed to fetch asset department: %w", err)
+	}
+	return department, nil
+}
@@ -0,0 +1,149 @@
+package liveservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/event"
+	"context"
+	"encoding/json"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// LiveService fans committed asset changes out to the open streams of this instance. Listen follows
+// the postgres channel every instance notifies on, so a change made through any instance reaches all
+// streams. Each stream only gets the assets its department scope can read
+type LiveService interface {
+	Subscribe(scope models.DepartmentScope) (<-chan AssetUpdate, func())
+	Listen(ctx context.Context) error
+	// CloseStreams ends every open stream, shutdown calls it so streams don't hold the server open
+	CloseStreams()
+}
+
+type liveServiceStruct struct {
+	repo   LiveRepository
+	db     providers.DBProvider
+	logger providers.ZapLoggerProvider
+
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+}
+
+type subscriber struct {
+	scope   models.DepartmentScope
+	updates chan AssetUpdate
+}
+
+func NewLiveService(repo LiveRepository, db providers.DBProvider, logger providers.ZapLoggerProvider) LiveService {
+	return &liveServiceStruct{repo: repo, db: db, logger: logger, subscribers: make(map[*subscriber]struct{})}
+}
+
+// updates a stream may fall behind by, a slower stream is closed and its client reconnects
+const subscriberBuffer = 32
+
+// Subscribe returns the stream's updates and the func to call when the client goes away. The channel
+// is closed when the stream fell behind or listening stopped
+func (s *liveServiceStruct) Subscribe(scope models.DepartmentScope) (<-chan AssetUpdate, func()) {
+	sub := &subscriber{scope: scope, updates: make(chan AssetUpdate, subscriberBuffer)}
+	s.mu.Lock()
+	s.subscribers[sub] = struct{}{}
+	s.mu.Unlock()
+	return sub.updates, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.drop(sub)
+	}
+}
+
+// Listen runs until ctx ends, streams are then closed so their clients reconnect
+// and fetch what they missed
+func (s *liveServiceStruct) Listen(ctx context.Context) error {
+	payloads, err := s.db.Listen(ctx, eventservice.AssetChangesChannel)
+	if err != nil {
+		return err
+	}
+	defer s.CloseStreams()
+
+	for payload := range payloads {
+		if payload == "" {
+			s.logger.GetLogger().Warn("asset changes listener reconnected, asking streams to resync")
+			s.broadcast(AssetUpdate{Type: ResyncEvent}, nil)
+			continue
+		}
+		var envelope eventservice.EventEnvelope
+		if err := json.Unmarshal([]byte(payload), &envelope); err != nil {
+			s.logger.GetLogger().Warn("invalid asset change notification", zap.Error(err))
+			continue
+		}
+		update := AssetUpdate{
+			ID:         envelope.ID,
+			Type:       envelope.Type,
+			AssetID:    envelope.AggregateID,
+			ActorID:    envelope.ActorID,
+			OccurredAt: envelope.OccurredAt,
+			Data:       envelope.Data,
+		}
+		// without the department only streams that see every department of every organization get the update
+		department, err := s.repo.GetAssetDepartment(ctx, update.AssetID)
+		if err != nil {
+			s.logger.GetLogger().Warn("failed to resolve department of asset change", zap.String("assetID", update.AssetID.String()), zap.Error(err))
+			s.broadcast(update, nil)
+			continue
+		}
+		s.broadcast(update, &department)
+	}
+	return nil
+}
+
+// broadcast takes a nil department when the asset's isn't known
+func (s *liveServiceStruct) broadcast(update AssetUpdate, department *AssetDepartment) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subscribers {
+		if update.Type != ResyncEvent && !inScope(sub.scope, department) {
+			continue
+		}
+		select {
+		case sub.updates <- update:
+		default:
+			s.logger.GetLogger().Debug("closing live stream that fell behind")
+			s.drop(sub)
+		}
+	}
+}
+
+// inScope matches the scope filter of the asset queries, a manager without a department sees the
+// assets without one
+func inScope(scope models.DepartmentScope, department *AssetDepartment) bool {
+	if department == nil {
+		return scope.Unrestricted()
+	}
+	if scope.OrganizationID != nil && *scope.OrganizationID != department.OrganizationID {
+		return false
+	}
+	if scope.AllDepartments {
+		return true
+	}
+	if scope.DepartmentID == nil || department.DepartmentID == nil {
+		return scope.DepartmentID == nil && department.DepartmentID == nil
+	}
+	return *scope.DepartmentID == *department.DepartmentID
+}
+
+func (s *liveServiceStruct) CloseStreams() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subscribers {
+		s.drop(sub)
+	}
+}
+
+// drop expects s.mu held and is a no-op for a subscriber already dropped
+func (s *liveServiceStruct) drop(sub *subscriber) {
+	if _, ok := s.subscribers[sub]; !ok {
+		return
+	}
+	delete(s.subscribers, sub)
+	close(sub.updates)
+}
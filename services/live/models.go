@@ -0,0 +1,26 @@
+package liveservice
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ResyncEvent tells clients updates may have been missed and they should fetch the assets again
+const ResyncEvent = "resync"
+
+// AssetDepartment is where an asset belongs, streams only get the updates of assets their scope reaches
+type AssetDepartment struct {
+	DepartmentID   *uuid.UUID `db:"department_id"`
+	OrganizationID uuid.UUID  `db:"organization_id"`
+}
+
+// AssetUpdate is one server-sent event, Type is the domain event type like asset.assigned
+type AssetUpdate struct {
+	ID         uuid.UUID              `json:"id"`
+	Type       string                 `json:"type"`
+	AssetID    uuid.UUID              `json:"asset_id"`
+	ActorID    *uuid.UUID             `json:"actor_id,omitempty"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
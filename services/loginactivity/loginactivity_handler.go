@@ -0,0 +1,169 @@
+package loginactivityservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type LoginActivityHandler struct {
+	Service        LoginActivityService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewLoginActivityHandler(service LoginActivityService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *LoginActivityHandler {
+	return &LoginActivityHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+// GetMyLogins lists the caller's recent logins, latest first. flagged=true only lists flagged ones
+func (h *LoginActivityHandler) GetMyLogins(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.callerID(w, r, "GetMyLogins")
+	if !ok {
+		return
+	}
+	filter, ok := h.parseFilter(w, r)
+	if !ok {
+		return
+	}
+	filter.UserID = &userID
+	logins, err := h.Service.GetLogins(r.Context(), filter)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch logins", zap.String("userID", userID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch logins")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"logins": logins, "limit": filter.Limit, "offset": filter.Offset})
+}
+
+// GetLogins lists the logins of the users in the caller's departments for review, optionally of one user_id
+func (h *LoginActivityHandler) GetLogins(w http.ResponseWriter, r *http.Request) {
+	filter, ok := h.parseFilter(w, r)
+	if !ok {
+		return
+	}
+	if val := r.URL.Query().Get("user_id"); val != "" {
+		userID, err := uuid.Parse(val)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, "invalid user_id")
+			return
+		}
+		filter.UserID = &userID
+	}
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in GetLogins", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+	filter.Scope = scope
+
+	logins, err := h.Service.GetLogins(r.Context(), filter)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch logins", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch logins")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"logins": logins, "limit": filter.Limit, "offset": filter.Offset})
+}
+
+func (h *LoginActivityHandler) ConfirmLogin(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.callerID(w, r, "ConfirmLogin")
+	if !ok {
+		return
+	}
+	loginID, ok := h.loginID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.Service.ConfirmLogin(r.Context(), userID, loginID); err != nil {
+		h.respondReviewError(w, err, "failed to confirm login")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "login confirmed"})
+}
+
+// DenyLogin signs the caller out everywhere, including the session they deny it from
+func (h *LoginActivityHandler) DenyLogin(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.callerID(w, r, "DenyLogin")
+	if !ok {
+		return
+	}
+	loginID, ok := h.loginID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.Service.DenyLogin(r.Context(), userID, loginID); err != nil {
+		h.respondReviewError(w, err, "failed to deny login")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "login denied, you were signed out everywhere. Change your password before signing in again"})
+}
+
+func (h *LoginActivityHandler) callerID(w http.ResponseWriter, r *http.Request, handlerName string) (uuid.UUID, bool) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in "+handlerName, zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return uuid.Nil, false
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in "+handlerName, zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return uuid.Nil, false
+	}
+	return userUUID, true
+}
+
+func (h *LoginActivityHandler) loginID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id := r.URL.Query().Get("id")
+	loginID, err := uuid.Parse(id)
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid id")
+		return uuid.Nil, false
+	}
+	return loginID, true
+}
+
+func (h *LoginActivityHandler) parseFilter(w http.ResponseWriter, r *http.Request) (LoginFilter, bool) {
+	query := r.URL.Query()
+	filter := LoginFilter{ReviewStatus: query.Get("status")}
+	switch filter.ReviewStatus {
+	case "", ReviewNotRequired, ReviewPending, ReviewConfirmed, ReviewDenied:
+	default:
+		utils.RespondError(w, http.StatusBadRequest, errors.New("status must be not_required, pending, confirmed or denied"), "invalid status")
+		return filter, false
+	}
+	if val := query.Get("flagged"); val != "" {
+		flagged, err := strconv.ParseBool(val)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, "invalid flagged")
+			return filter, false
+		}
+		filter.FlaggedOnly = flagged
+	}
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+	return filter, true
+}
+
+func (h *LoginActivityHandler) respondReviewError(w http.ResponseWriter, err error, message string) {
+	h.Logger.GetLogger().Error("Failed to review login", zap.Error(err))
+	switch {
+	case errors.Is(err, ErrLoginNotFound):
+		utils.RespondError(w, http.StatusNotFound, err, err.Error())
+	case errors.Is(err, ErrLoginAlreadyReviewed):
+		utils.RespondError(w, http.StatusConflict, err, err.Error())
+	default:
+		utils.RespondError(w, http.StatusInternalServerError, err, message)
+	}
+}
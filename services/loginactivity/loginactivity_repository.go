@@ -0,0 +1,189 @@
+package loginactivityservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type LoginActivityRepository interface {
+	// GetLastLogin returns the user's latest login that wasn't denied, sql.ErrNoRows before the first one
+	GetLastLogin(ctx context.Context, userID uuid.UUID) (Login, error)
+	// TouchDevice records the user signed in from the device now, true when it was never seen before
+	TouchDevice(ctx context.Context, userID uuid.UUID, fingerprint, userAgent string) (uuid.UUID, bool, error)
+	// HasLoggedInFrom reports whether an earlier login of the user that wasn't denied came from the country
+	HasLoggedInFrom(ctx context.Context, userID uuid.UUID, country string) (bool, error)
+	InsertLogin(ctx context.Context, login newLogin) (uuid.UUID, error)
+	GetLogin(ctx context.Context, id uuid.UUID) (Login, error)
+	GetLogins(ctx context.Context, filter LoginFilter) ([]Login, error)
+	// ReviewLogin sets the review status of a login not reviewed yet, false when it already was
+	ReviewLogin(ctx context.Context, id uuid.UUID, status string) (bool, error)
+	// ForgetDevice drops a device so the next login from it is new again
+	ForgetDevice(ctx context.Context, deviceID uuid.UUID) error
+	GetFirebaseUID(ctx context.Context, userID uuid.UUID) (string, error)
+	// GetReviewerIDs returns the active users holding login.review
+	GetReviewerIDs(ctx context.Context) ([]uuid.UUID, error)
+}
+
+type PostgresLoginActivityRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewLoginActivityRepository(db *sqlx.DB, log providers.ZapLoggerProvider) LoginActivityRepository {
+	return &PostgresLoginActivityRepository{
+		DB:     db,
+		Logger: log,
+	}
+}
+
+const loginColumns = `l.id, l.user_id, u.username, l.device_id, l.method, l.ip_address, l.user_agent, l.country, l.city,
+	l.latitude, l.longitude, l.anomalies, l.travel_speed, l.review_status, l.reviewed_at, l.created_at`
+
+func (r *PostgresLoginActivityRepository) GetLastLogin(ctx context.Context, userID uuid.UUID) (Login, error) {
+	var login Login
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &login, `
+		SELECT `+loginColumns+`
+		FROM user_logins l
+		JOIN users u ON u.id = l.user_id
+		WHERE l.user_id = $1 AND l.review_status <> 'denied'
+		ORDER BY l.created_at DESC
+		LIMIT 1
+	`, userID)
+	return login, err
+}
+
+func (r *PostgresLoginActivityRepository) TouchDevice(ctx context.Context, userID uuid.UUID, fingerprint, userAgent string) (uuid.UUID, bool, error) {
+	var device struct {
+		ID       uuid.UUID `db:"id"`
+		Inserted bool      `db:"inserted"`
+	}
+	// xmax is only 0 for a row this statement inserted
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &device, `
+		INSERT INTO user_login_devices (user_id, fingerprint, user_agent)
+		VALUES ($1, $2, NULLIF($3, ''))
+		ON CONFLICT (user_id, fingerprint) DO UPDATE SET last_seen_at = now(), user_agent = EXCLUDED.user_agent
+		RETURNING id, (xmax = 0) AS inserted
+	`, userID, fingerprint, userAgent)
+	if err != nil {
+		return uuid.Nil, false, fmt.Errorf("failed to record login device: %w", err)
+	}
+	return device.ID, device.Inserted, nil
+}
+
+func (r *PostgresLoginActivityRepository) HasLoggedInFrom(ctx context.Context, userID uuid.UUID, country string) (bool, error) {
+	var exists bool
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &exists, `
+		SELECT EXISTS (
+			SELECT 1 FROM user_logins WHERE user_id = $1 AND country = $2 AND review_status <> 'denied'
+		)
+	`, userID, country)
+	if err != nil {
+		return false, fmt.Errorf("failed to check login countries: %w", err)
+	}
+	return exists, nil
+}
+
+func (r *PostgresLoginActivityRepository) InsertLogin(ctx context.Context, login newLogin) (uuid.UUID, error) {
+	var latitude, longitude *float64
+	if login.Location.Country != "" {
+		latitude, longitude = &login.Location.Latitude, &login.Location.Longitude
+	}
+	var id uuid.UUID
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &id, `
+		INSERT INTO user_logins (user_id, device_id, method, ip_address, user_agent, country, city, latitude, longitude,
+			anomalies, travel_speed, review_status, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), $8, $9, $10, $11, $12, $13)
+		RETURNING id
+	`, login.UserID, login.DeviceID, login.Method, login.IPAddress, login.UserAgent, login.Location.Country, login.Location.City,
+		latitude, longitude, pq.StringArray(login.Anomalies), login.TravelSpeed, login.ReviewStatus, login.CreatedAt)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to insert login: %w", err)
+	}
+	return id, nil
+}
+
+func (r *PostgresLoginActivityRepository) GetLogin(ctx context.Context, id uuid.UUID) (Login, error) {
+	var login Login
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &login, `
+		SELECT `+loginColumns+`
+		FROM user_logins l
+		JOIN users u ON u.id = l.user_id
+		WHERE l.id = $1
+	`, id)
+	return login, err
+}
+
+func (r *PostgresLoginActivityRepository) GetLogins(ctx context.Context, filter LoginFilter) ([]Login, error) {
+	logins := make([]Login, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &logins, `
+		SELECT `+loginColumns+`
+		FROM user_logins l
+		JOIN users u ON u.id = l.user_id
+		WHERE ($1::uuid IS NULL OR l.user_id = $1)
+		AND (NOT $2 OR l.anomalies <> '{}')
+		AND ($3 = '' OR l.review_status = $3)
+		AND ($4 OR u.department_id IS NOT DISTINCT FROM $5)
+		AND ($6::uuid IS NULL OR u.organization_id = $6)
+		ORDER BY l.created_at DESC
+		LIMIT NULLIF($7, 0) OFFSET $8
+	`, filter.UserID, filter.FlaggedOnly, filter.ReviewStatus, filter.Scope.AllDepartments, filter.Scope.DepartmentID,
+		filter.Scope.OrganizationID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch logins: %w", err)
+	}
+	return logins, nil
+}
+
+func (r *PostgresLoginActivityRepository) ReviewLogin(ctx context.Context, id uuid.UUID, status string) (bool, error) {
+	result, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE user_logins SET review_status = $2, reviewed_at = now()
+		WHERE id = $1 AND review_status IN ('not_required', 'pending')
+	`, id, status)
+	if err != nil {
+		return false, fmt.Errorf("failed to review login: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func (r *PostgresLoginActivityRepository) ForgetDevice(ctx context.Context, deviceID uuid.UUID) error {
+	if _, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `DELETE FROM user_login_devices WHERE id = $1`, deviceID); err != nil {
+		return fmt.Errorf("failed to forget login device: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresLoginActivityRepository) GetFirebaseUID(ctx context.Context, userID uuid.UUID) (string, error) {
+	var uid *string
+	if err := utils.Conn(ctx, r.DB).GetContext(ctx, &uid, `SELECT firebase_uid FROM users WHERE id = $1`, userID); err != nil {
+		return "", fmt.Errorf("failed to fetch firebase uid: %w", err)
+	}
+	if uid == nil {
+		return "", nil
+	}
+	return *uid, nil
+}
+
+func (r *PostgresLoginActivityRepository) GetReviewerIDs(ctx context.Context) ([]uuid.UUID, error) {
+	reviewerIDs := []uuid.UUID{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &reviewerIDs, `
+		SELECT DISTINCT u.id
+		FROM users u
+		JOIN user_roles ur ON ur.user_id = u.id AND ur.archived_at IS NULL
+		JOIN role_permissions rp ON rp.role = ur.role AND rp.permission = 'login.review' AND rp.archived_at IS NULL
+		WHERE u.archived_at IS NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch login reviewers: %w", err)
+	}
+	return reviewerIDs, nil
+}
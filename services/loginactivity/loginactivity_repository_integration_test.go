@@ -0,0 +1,103 @@
+//go:build integration
+
+package loginactivityservice
+
+import (
+	"asset/database/seed"
+	"asset/database/testdb"
+	"asset/models"
+	"asset/providers"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestLoginActivity(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewLoginActivityRepository(db, logger)
+	ctx := context.Background()
+	intern := seed.ID("user:intern")
+
+	_, err := repo.GetLastLogin(ctx, intern)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	deviceID, isNew, err := repo.TouchDevice(ctx, intern, "laptop", "Chrome")
+	require.NoError(t, err)
+	assert.True(t, isNew)
+	again, isNew, err := repo.TouchDevice(ctx, intern, "laptop", "Chrome")
+	require.NoError(t, err)
+	assert.False(t, isNew)
+	assert.Equal(t, deviceID, again)
+
+	// a quiet login from Pune, then a flagged one from Berlin
+	puneID, err := repo.InsertLogin(ctx, newLogin{UserID: intern, DeviceID: deviceID, Method: "email", IPAddress: "49.36.10.1",
+		Location:  models.GeoLocation{Country: "IN", City: "Pune", Latitude: 18.52, Longitude: 73.85},
+		Anomalies: []string{}, ReviewStatus: ReviewNotRequired, CreatedAt: time.Now().Add(-time.Hour)})
+	require.NoError(t, err)
+	speed := 6400.0
+	berlinID, err := repo.InsertLogin(ctx, newLogin{UserID: intern, DeviceID: deviceID, Method: "email", IPAddress: "85.214.1.1",
+		Location:  models.GeoLocation{Country: "DE", City: "Berlin", Latitude: 52.52, Longitude: 13.40},
+		Anomalies: []string{AnomalyNewCountry, AnomalyImpossibleTravel}, TravelSpeed: &speed, ReviewStatus: ReviewPending, CreatedAt: time.Now()})
+	require.NoError(t, err)
+
+	last, err := repo.GetLastLogin(ctx, intern)
+	require.NoError(t, err)
+	assert.Equal(t, berlinID, last.ID)
+	assert.Equal(t, "DE", *last.Country)
+	assert.InDelta(t, 52.52, *last.Latitude, 0.001)
+	seen, err := repo.HasLoggedInFrom(ctx, intern, "IN")
+	require.NoError(t, err)
+	assert.True(t, seen)
+
+	logins, err := repo.GetLogins(ctx, LoginFilter{UserID: &intern, Scope: models.DepartmentScope{AllDepartments: true}})
+	require.NoError(t, err)
+	require.Len(t, logins, 2)
+	assert.Equal(t, []uuid.UUID{berlinID, puneID}, []uuid.UUID{logins[0].ID, logins[1].ID})
+	flagged, err := repo.GetLogins(ctx, LoginFilter{FlaggedOnly: true, Scope: models.DepartmentScope{AllDepartments: true}})
+	require.NoError(t, err)
+	require.Len(t, flagged, 1)
+	assert.Equal(t, []string{AnomalyNewCountry, AnomalyImpossibleTravel}, []string(flagged[0].Anomalies))
+	assert.Equal(t, "Ishan Verma", flagged[0].Username)
+	operations := seed.ID("department:operations")
+	flagged, err = repo.GetLogins(ctx, LoginFilter{FlaggedOnly: true, Scope: models.DepartmentScope{DepartmentID: &operations}})
+	require.NoError(t, err)
+	assert.Empty(t, flagged, "the intern is in engineering")
+
+	reviewed, err := repo.ReviewLogin(ctx, berlinID, ReviewDenied)
+	require.NoError(t, err)
+	assert.True(t, reviewed)
+	reviewed, err = repo.ReviewLogin(ctx, berlinID, ReviewConfirmed)
+	require.NoError(t, err)
+	assert.False(t, reviewed, "reviewed once")
+	last, err = repo.GetLastLogin(ctx, intern)
+	require.NoError(t, err)
+	assert.Equal(t, puneID, last.ID, "denied logins aren't the baseline")
+	seen, err = repo.HasLoggedInFrom(ctx, intern, "DE")
+	require.NoError(t, err)
+	assert.False(t, seen)
+
+	require.NoError(t, repo.ForgetDevice(ctx, deviceID))
+	_, isNew, err = repo.TouchDevice(ctx, intern, "laptop", "Chrome")
+	require.NoError(t, err)
+	assert.True(t, isNew)
+	login, err := repo.GetLogin(ctx, puneID)
+	require.NoError(t, err)
+	assert.Nil(t, login.DeviceID)
+
+	reviewerIDs, err := repo.GetReviewerIDs(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, reviewerIDs, seed.ID("user:admin"))
+	assert.NotContains(t, reviewerIDs, intern)
+}
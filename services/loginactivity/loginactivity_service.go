@@ -0,0 +1,366 @@
+package loginactivityservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/audit"
+	"asset/services/notification"
+	"asset/utils"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// LoginActivityService keeps the devices and places each user signs in from. A completed login from a
+// new device, a new country or further from the previous one than anyone can travel is flagged, the
+// user is told and can confirm it or deny it, which signs them out everywhere
+type LoginActivityService interface {
+	// RecordLogin is called once a login completed, failing to record it must not fail the login
+	RecordLogin(ctx context.Context, attempt LoginAttempt) error
+	GetLogins(ctx context.Context, filter LoginFilter) ([]Login, error)
+	ConfirmLogin(ctx context.Context, userID, loginID uuid.UUID) error
+	DenyLogin(ctx context.Context, userID, loginID uuid.UUID) error
+}
+
+var (
+	ErrLoginNotFound        = models.NewServiceError(http.StatusNotFound, "login_not_found", "login not found")
+	ErrLoginAlreadyReviewed = models.NewServiceError(http.StatusConflict, "login_already_reviewed", "login was already confirmed or denied")
+)
+
+// geo ip locations are only good to a city or two, closer logins are never impossible travel
+const minTravelDistanceKm = 500
+
+type loginActivityServiceStruct struct {
+	repo LoginActivityRepository
+	db   *sqlx.DB
+	// nil when LOGIN_GEO_LOOKUP_URL is unset, logins then have no location
+	geo      providers.GeoIPProvider
+	auth     providers.AuthMiddlewareService
+	notifier notificationservice.NotificationService
+	audit    auditservice.AuditService
+	logger   providers.ZapLoggerProvider
+	config   models.LoginAnomalyConfig
+}
+
+func NewLoginActivityService(repo LoginActivityRepository, db *sqlx.DB, geo providers.GeoIPProvider, auth providers.AuthMiddlewareService, notifier notificationservice.NotificationService, audit auditservice.AuditService, logger providers.ZapLoggerProvider, config models.LoginAnomalyConfig) LoginActivityService {
+	return &loginActivityServiceStruct{
+		repo:     repo,
+		db:       db,
+		geo:      geo,
+		auth:     auth,
+		notifier: notifier,
+		audit:    audit,
+		logger:   logger,
+		config:   config,
+	}
+}
+
+func (s *loginActivityServiceStruct) RecordLogin(ctx context.Context, attempt LoginAttempt) error {
+	var location models.GeoLocation
+	if s.geo != nil && attempt.IPAddress != "" {
+		var err error
+		if location, err = s.geo.Locate(ctx, attempt.IPAddress); err != nil {
+			s.logger.GetLogger().Warn("failed to locate login", zap.String("ip", attempt.IPAddress), zap.Error(err))
+		}
+	}
+	login := newLogin{
+		UserID:       attempt.UserID,
+		Method:       attempt.Method,
+		IPAddress:    attempt.IPAddress,
+		UserAgent:    attempt.UserAgent,
+		Location:     location,
+		Anomalies:    []string{},
+		ReviewStatus: ReviewNotRequired,
+		CreatedAt:    time.Now(),
+	}
+
+	var id uuid.UUID
+	err := utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		previous, err := s.repo.GetLastLogin(ctx, attempt.UserID)
+		firstLogin := errors.Is(err, sql.ErrNoRows)
+		if err != nil && !firstLogin {
+			return fmt.Errorf("failed to fetch the previous login: %w", err)
+		}
+		deviceID, newDevice, err := s.repo.TouchDevice(ctx, attempt.UserID, deviceFingerprint(attempt.UserAgent), attempt.UserAgent)
+		if err != nil {
+			return err
+		}
+		login.DeviceID = deviceID
+
+		if !firstLogin {
+			if newDevice {
+				login.Anomalies = append(login.Anomalies, AnomalyNewDevice)
+			}
+			if location.Country != "" {
+				seen, err := s.repo.HasLoggedInFrom(ctx, attempt.UserID, location.Country)
+				if err != nil {
+					return err
+				}
+				if !seen {
+					login.Anomalies = append(login.Anomalies, AnomalyNewCountry)
+				}
+				if speed, ok := s.impossibleTravel(previous, location, login.CreatedAt); ok {
+					login.Anomalies = append(login.Anomalies, AnomalyImpossibleTravel)
+					login.TravelSpeed = &speed
+				}
+			}
+		}
+		if len(login.Anomalies) > 0 {
+			login.ReviewStatus = ReviewPending
+		}
+		id, err = s.repo.InsertLogin(ctx, login)
+		return err
+	})
+	if err != nil {
+		s.logger.GetLogger().Error("failed to record login", zap.String("userID", attempt.UserID.String()), zap.Error(err))
+		return err
+	}
+	if len(login.Anomalies) == 0 {
+		return nil
+	}
+
+	s.logger.GetLogger().Warn("login flagged", zap.String("userID", attempt.UserID.String()), zap.String("loginID", id.String()), zap.Strings("anomalies", login.Anomalies))
+	place, reasons := describePlace(location, attempt.IPAddress), describeAnomalies(login)
+	if err := s.notifier.Notify(ctx, []uuid.UUID{attempt.UserID}, notificationservice.Notification{
+		Category:   notificationservice.CategorySecurity,
+		Title:      "New sign-in to your account",
+		Body:       fmt.Sprintf("You signed in from %s, %s. If it was you, confirm it under your recent logins, otherwise deny it to sign out everywhere.", place, reasons),
+		EntityType: "login",
+		EntityID:   id.String(),
+		Urgent:     true,
+	}); err != nil {
+		s.logger.GetLogger().Error("failed to notify user of flagged login", zap.String("loginID", id.String()), zap.Error(err))
+	}
+	s.notifyReviewers(ctx, id, "Flagged login", func(username string) string {
+		return fmt.Sprintf("%s signed in from %s, %s.", username, place, reasons)
+	})
+	return nil
+}
+
+// impossibleTravel returns the km/h needed to get from the previous login to location by now
+func (s *loginActivityServiceStruct) impossibleTravel(previous Login, location models.GeoLocation, now time.Time) (float64, bool) {
+	if previous.Latitude == nil || previous.Longitude == nil || s.config.MaxTravelSpeed <= 0 {
+		return 0, false
+	}
+	distance := distanceKm(*previous.Latitude, *previous.Longitude, location.Latitude, location.Longitude)
+	if distance < minTravelDistanceKm {
+		return 0, false
+	}
+	// logins in the same minute would otherwise need an infinite speed
+	hours := math.Max(now.Sub(previous.CreatedAt).Hours(), 1.0/60)
+	speed := math.Round(distance / hours)
+	return speed, speed > s.config.MaxTravelSpeed
+}
+
+func (s *loginActivityServiceStruct) GetLogins(ctx context.Context, filter LoginFilter) ([]Login, error) {
+	logins, err := s.repo.GetLogins(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	loc := utils.LocationFromContext(ctx)
+	for i := range logins {
+		logins[i].CreatedAt = utils.InLocation(logins[i].CreatedAt, loc)
+		if logins[i].ReviewedAt != nil {
+			reviewed := utils.InLocation(*logins[i].ReviewedAt, loc)
+			logins[i].ReviewedAt = &reviewed
+		}
+	}
+	return logins, nil
+}
+
+func (s *loginActivityServiceStruct) ConfirmLogin(ctx context.Context, userID, loginID uuid.UUID) error {
+	if _, err := s.ownLogin(ctx, userID, loginID); err != nil {
+		return err
+	}
+	err := utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		reviewed, err := s.repo.ReviewLogin(ctx, loginID, ReviewConfirmed)
+		if err != nil {
+			return err
+		}
+		if !reviewed {
+			return ErrLoginAlreadyReviewed
+		}
+		return s.audit.Record(ctx, auditservice.AuditEntry{
+			ActorID:    &userID,
+			Action:     "auth.login_confirmed",
+			EntityType: "login",
+			EntityID:   loginID.String(),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("login confirmed", zap.String("userID", userID.String()), zap.String("loginID", loginID.String()))
+	return nil
+}
+
+// DenyLogin signs the user out everywhere first, a denied login whose session lives on would only be
+// a false sense of safety. The device is forgotten so a later login from it is flagged again
+func (s *loginActivityServiceStruct) DenyLogin(ctx context.Context, userID, loginID uuid.UUID) error {
+	login, err := s.ownLogin(ctx, userID, loginID)
+	if err != nil {
+		return err
+	}
+	if login.ReviewStatus != ReviewNotRequired && login.ReviewStatus != ReviewPending {
+		return ErrLoginAlreadyReviewed
+	}
+	firebaseUID, err := s.repo.GetFirebaseUID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.auth.RevokeUserTokens(ctx, userID.String(), firebaseUID); err != nil {
+		s.logger.GetLogger().Error("failed to revoke tokens of denied login", zap.String("userID", userID.String()), zap.Error(err))
+		return err
+	}
+
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		reviewed, err := s.repo.ReviewLogin(ctx, loginID, ReviewDenied)
+		if err != nil {
+			return err
+		}
+		if !reviewed {
+			return ErrLoginAlreadyReviewed
+		}
+		if login.DeviceID != nil {
+			if err := s.repo.ForgetDevice(ctx, *login.DeviceID); err != nil {
+				return err
+			}
+		}
+		return s.audit.Record(ctx, auditservice.AuditEntry{
+			ActorID:    &userID,
+			Action:     "auth.login_denied",
+			EntityType: "login",
+			EntityID:   loginID.String(),
+			NewValue:   map[string]interface{}{"ip_address": login.IPAddress, "country": login.Country, "anomalies": login.Anomalies},
+		})
+	})
+	if err != nil {
+		return err
+	}
+	s.logger.GetLogger().Warn("login denied, user signed out everywhere", zap.String("userID", userID.String()), zap.String("loginID", loginID.String()))
+	s.notifyReviewers(ctx, loginID, "Login denied", func(username string) string {
+		return fmt.Sprintf("%s denied a login from %s and was signed out everywhere.", username, describeLoginPlace(login))
+	})
+	return nil
+}
+
+func (s *loginActivityServiceStruct) ownLogin(ctx context.Context, userID, loginID uuid.UUID) (Login, error) {
+	login, err := s.repo.GetLogin(ctx, loginID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Login{}, ErrLoginNotFound
+		}
+		return Login{}, err
+	}
+	if login.UserID != userID {
+		return Login{}, ErrLoginNotFound
+	}
+	return login, nil
+}
+
+// notifyReviewers tells the holders of login.review when NotifySecurity is on, the user themselves is left out
+func (s *loginActivityServiceStruct) notifyReviewers(ctx context.Context, loginID uuid.UUID, title string, body func(username string) string) {
+	if !s.config.NotifySecurity {
+		return
+	}
+	login, err := s.repo.GetLogin(ctx, loginID)
+	if err != nil {
+		s.logger.GetLogger().Error("failed to fetch login for reviewers", zap.String("loginID", loginID.String()), zap.Error(err))
+		return
+	}
+	reviewerIDs, err := s.repo.GetReviewerIDs(ctx)
+	if err != nil {
+		s.logger.GetLogger().Error("failed to fetch login reviewers", zap.Error(err))
+		return
+	}
+	reviewerIDs = slices.DeleteFunc(reviewerIDs, func(id uuid.UUID) bool { return id == login.UserID })
+	if len(reviewerIDs) == 0 {
+		return
+	}
+	if err := s.notifier.Notify(ctx, reviewerIDs, notificationservice.Notification{
+		Category:   notificationservice.CategorySecurity,
+		Title:      title,
+		Body:       body(login.Username),
+		EntityType: "login",
+		EntityID:   loginID.String(),
+		Urgent:     true,
+	}); err != nil {
+		s.logger.GetLogger().Error("failed to notify login reviewers", zap.String("loginID", loginID.String()), zap.Error(err))
+	}
+}
+
+// version numbers change with every browser update, without them a device stays the same device
+var userAgentVersions = regexp.MustCompile(`\d+([._]\d+)*`)
+
+func deviceFingerprint(userAgent string) string {
+	normalized := strings.ToLower(strings.TrimSpace(userAgentVersions.ReplaceAllString(userAgent, "")))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// describeLoginPlace is describePlace for a stored login
+func describeLoginPlace(login Login) string {
+	var location models.GeoLocation
+	if login.Country != nil {
+		location.Country = *login.Country
+	}
+	if login.City != nil {
+		location.City = *login.City
+	}
+	ip := ""
+	if login.IPAddress != nil {
+		ip = *login.IPAddress
+	}
+	return describePlace(location, ip)
+}
+
+func describePlace(location models.GeoLocation, ip string) string {
+	place := "an unknown location"
+	switch {
+	case location.City != "" && location.Country != "":
+		place = location.City + ", " + location.Country
+	case location.Country != "":
+		place = location.Country
+	}
+	if ip != "" {
+		place += " (" + ip + ")"
+	}
+	return place
+}
+
+func describeAnomalies(login newLogin) string {
+	reasons := make([]string, 0, len(login.Anomalies))
+	for _, anomaly := range login.Anomalies {
+		switch anomaly {
+		case AnomalyNewDevice:
+			reasons = append(reasons, "on a device you haven't used before")
+		case AnomalyNewCountry:
+			reasons = append(reasons, "in a country you haven't signed in from before")
+		case AnomalyImpossibleTravel:
+			reasons = append(reasons, fmt.Sprintf("too far from your previous login to travel there in time (%.0f km/h)", *login.TravelSpeed))
+		}
+	}
+	return strings.Join(reasons, " and ")
+}
+
+// distanceKm is the great circle distance between two points
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat, dLon := toRad(lat2-lat1), toRad(lon2-lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
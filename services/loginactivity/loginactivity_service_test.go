@@ -0,0 +1,186 @@
+package loginactivityservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/audit"
+	"asset/services/notification"
+	"asset/services/testutil"
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testDeps struct {
+	repo     *MockLoginActivityRepository
+	geo      *providers.MockGeoIPProvider
+	auth     *providers.MockAuthMiddlewareService
+	notifier *notificationservice.MockNotificationService
+	audit    *auditservice.MockAuditService
+	db       sqlmock.Sqlmock
+}
+
+func newTestService(t *testing.T, config models.LoginAnomalyConfig) (LoginActivityService, testDeps) {
+	ctrl := testutil.Controller(t)
+	db, mock := testutil.DB(t)
+	deps := testDeps{
+		repo:     NewMockLoginActivityRepository(ctrl),
+		geo:      providers.NewMockGeoIPProvider(ctrl),
+		auth:     providers.NewMockAuthMiddlewareService(ctrl),
+		notifier: notificationservice.NewMockNotificationService(ctrl),
+		audit:    auditservice.NewMockAuditService(ctrl),
+		db:       mock,
+	}
+	return NewLoginActivityService(deps.repo, db, deps.geo, deps.auth, deps.notifier, deps.audit, testutil.Logger(ctrl), config), deps
+}
+
+var (
+	pune   = models.GeoLocation{Country: "IN", City: "Pune", Latitude: 18.52, Longitude: 73.85}
+	berlin = models.GeoLocation{Country: "DE", City: "Berlin", Latitude: 52.52, Longitude: 13.40}
+)
+
+const chromeOnMac = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36"
+
+// the first login sets the baseline, nothing about it is flagged
+func TestRecordFirstLogin(t *testing.T) {
+	svc, deps := newTestService(t, models.LoginAnomalyConfig{MaxTravelSpeed: 900})
+	userID := uuid.New()
+
+	deps.geo.EXPECT().Locate(gomock.Any(), "49.36.10.1").Return(pune, nil)
+	deps.db.ExpectBegin()
+	deps.repo.EXPECT().GetLastLogin(gomock.Any(), userID).Return(Login{}, sql.ErrNoRows)
+	deps.repo.EXPECT().TouchDevice(gomock.Any(), userID, deviceFingerprint(chromeOnMac), chromeOnMac).Return(uuid.New(), true, nil)
+	deps.repo.EXPECT().InsertLogin(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, login newLogin) (uuid.UUID, error) {
+		assert.Empty(t, login.Anomalies)
+		assert.Equal(t, ReviewNotRequired, login.ReviewStatus)
+		assert.Equal(t, pune, login.Location)
+		return uuid.New(), nil
+	})
+	deps.db.ExpectCommit()
+
+	require.NoError(t, svc.RecordLogin(context.Background(), LoginAttempt{UserID: userID, Method: "email", IPAddress: "49.36.10.1", UserAgent: chromeOnMac}))
+	assert.NoError(t, deps.db.ExpectationsWereMet())
+}
+
+// an hour after signing in from Pune the user signs in from Berlin on another device, which is a new
+// device, a new country and about 6400 km/h
+func TestRecordImpossibleTravel(t *testing.T) {
+	svc, deps := newTestService(t, models.LoginAnomalyConfig{MaxTravelSpeed: 900, NotifySecurity: true})
+	userID, reviewerID, loginID := uuid.New(), uuid.New(), uuid.New()
+	previous := Login{UserID: userID, Country: &pune.Country, Latitude: &pune.Latitude, Longitude: &pune.Longitude, CreatedAt: time.Now().Add(-time.Hour)}
+
+	deps.geo.EXPECT().Locate(gomock.Any(), "85.214.1.1").Return(berlin, nil)
+	deps.db.ExpectBegin()
+	deps.repo.EXPECT().GetLastLogin(gomock.Any(), userID).Return(previous, nil)
+	deps.repo.EXPECT().TouchDevice(gomock.Any(), userID, gomock.Any(), "Firefox").Return(uuid.New(), true, nil)
+	deps.repo.EXPECT().HasLoggedInFrom(gomock.Any(), userID, "DE").Return(false, nil)
+	deps.repo.EXPECT().InsertLogin(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, login newLogin) (uuid.UUID, error) {
+		assert.Equal(t, []string{AnomalyNewDevice, AnomalyNewCountry, AnomalyImpossibleTravel}, login.Anomalies)
+		assert.Equal(t, ReviewPending, login.ReviewStatus)
+		require.NotNil(t, login.TravelSpeed)
+		assert.InDelta(t, 6400, *login.TravelSpeed, 100)
+		return loginID, nil
+	})
+	deps.db.ExpectCommit()
+	deps.notifier.EXPECT().Notify(gomock.Any(), []uuid.UUID{userID}, gomock.Any()).DoAndReturn(func(_ context.Context, _ []uuid.UUID, n notificationservice.Notification) error {
+		assert.Equal(t, notificationservice.CategorySecurity, n.Category)
+		assert.True(t, n.Urgent)
+		assert.Equal(t, loginID.String(), n.EntityID)
+		assert.Contains(t, n.Body, "Berlin, DE (85.214.1.1)")
+		assert.Contains(t, n.Body, "on a device you haven't used before")
+		return nil
+	})
+	deps.repo.EXPECT().GetLogin(gomock.Any(), loginID).Return(Login{ID: loginID, UserID: userID, Username: "intern"}, nil)
+	deps.repo.EXPECT().GetReviewerIDs(gomock.Any()).Return([]uuid.UUID{reviewerID, userID}, nil)
+	deps.notifier.EXPECT().Notify(gomock.Any(), []uuid.UUID{reviewerID}, gomock.Any()).DoAndReturn(func(_ context.Context, _ []uuid.UUID, n notificationservice.Notification) error {
+		assert.Contains(t, n.Body, "intern signed in from Berlin, DE")
+		return nil
+	})
+
+	require.NoError(t, svc.RecordLogin(context.Background(), LoginAttempt{UserID: userID, Method: "email", IPAddress: "85.214.1.1", UserAgent: "Firefox"}))
+	assert.NoError(t, deps.db.ExpectationsWereMet())
+}
+
+// a failed lookup loses the location, the login is still recorded and checked for a new device
+func TestRecordLoginWithoutLocation(t *testing.T) {
+	svc, deps := newTestService(t, models.LoginAnomalyConfig{MaxTravelSpeed: 900})
+	userID := uuid.New()
+
+	deps.geo.EXPECT().Locate(gomock.Any(), "85.214.1.1").Return(models.GeoLocation{}, assert.AnError)
+	deps.db.ExpectBegin()
+	deps.repo.EXPECT().GetLastLogin(gomock.Any(), userID).Return(Login{UserID: userID, CreatedAt: time.Now().Add(-time.Hour)}, nil)
+	deps.repo.EXPECT().TouchDevice(gomock.Any(), userID, gomock.Any(), chromeOnMac).Return(uuid.New(), false, nil)
+	deps.repo.EXPECT().InsertLogin(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, login newLogin) (uuid.UUID, error) {
+		assert.Empty(t, login.Anomalies)
+		assert.Empty(t, login.Location.Country)
+		return uuid.New(), nil
+	})
+	deps.db.ExpectCommit()
+
+	require.NoError(t, svc.RecordLogin(context.Background(), LoginAttempt{UserID: userID, Method: "email", IPAddress: "85.214.1.1", UserAgent: chromeOnMac}))
+}
+
+func TestDenyLogin(t *testing.T) {
+	svc, deps := newTestService(t, models.LoginAnomalyConfig{})
+	userID, loginID, deviceID := uuid.New(), uuid.New(), uuid.New()
+	login := Login{ID: loginID, UserID: userID, DeviceID: &deviceID, ReviewStatus: ReviewPending}
+
+	deps.repo.EXPECT().GetLogin(gomock.Any(), loginID).Return(login, nil)
+	deps.repo.EXPECT().GetFirebaseUID(gomock.Any(), userID).Return("firebase-1", nil)
+	deps.auth.EXPECT().RevokeUserTokens(gomock.Any(), userID.String(), "firebase-1").Return(nil)
+	deps.db.ExpectBegin()
+	deps.repo.EXPECT().ReviewLogin(gomock.Any(), loginID, ReviewDenied).Return(true, nil)
+	deps.repo.EXPECT().ForgetDevice(gomock.Any(), deviceID).Return(nil)
+	deps.audit.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, entry auditservice.AuditEntry) error {
+		assert.Equal(t, "auth.login_denied", entry.Action)
+		assert.Equal(t, &userID, entry.ActorID)
+		return nil
+	})
+	deps.db.ExpectCommit()
+
+	require.NoError(t, svc.DenyLogin(context.Background(), userID, loginID))
+	assert.NoError(t, deps.db.ExpectationsWereMet())
+}
+
+func TestReviewLoginErrors(t *testing.T) {
+	svc, deps := newTestService(t, models.LoginAnomalyConfig{})
+	userID, loginID := uuid.New(), uuid.New()
+	ctx := context.Background()
+
+	// someone else's login looks like no login at all
+	deps.repo.EXPECT().GetLogin(gomock.Any(), loginID).Return(Login{ID: loginID, UserID: uuid.New(), ReviewStatus: ReviewPending}, nil)
+	assert.ErrorIs(t, svc.DenyLogin(ctx, userID, loginID), ErrLoginNotFound)
+
+	deps.repo.EXPECT().GetLogin(gomock.Any(), loginID).Return(Login{}, sql.ErrNoRows)
+	assert.ErrorIs(t, svc.ConfirmLogin(ctx, userID, loginID), ErrLoginNotFound)
+
+	// tokens aren't revoked again for a login that was already denied
+	deps.repo.EXPECT().GetLogin(gomock.Any(), loginID).Return(Login{ID: loginID, UserID: userID, ReviewStatus: ReviewDenied}, nil)
+	assert.ErrorIs(t, svc.DenyLogin(ctx, userID, loginID), ErrLoginAlreadyReviewed)
+
+	deps.repo.EXPECT().GetLogin(gomock.Any(), loginID).Return(Login{ID: loginID, UserID: userID, ReviewStatus: ReviewConfirmed}, nil)
+	deps.db.ExpectBegin()
+	deps.repo.EXPECT().ReviewLogin(gomock.Any(), loginID, ReviewConfirmed).Return(false, nil)
+	deps.db.ExpectRollback()
+	assert.ErrorIs(t, svc.ConfirmLogin(ctx, userID, loginID), ErrLoginAlreadyReviewed)
+}
+
+// browser updates don't make a new device, another browser does
+func TestDeviceFingerprint(t *testing.T) {
+	updated := "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/127.0.6533.72 Safari/537.36"
+	firefox := "Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:128.0) Gecko/20100101 Firefox/128.0"
+	assert.Equal(t, deviceFingerprint(chromeOnMac), deviceFingerprint(updated))
+	assert.NotEqual(t, deviceFingerprint(chromeOnMac), deviceFingerprint(firefox))
+}
+
+func TestDistanceKm(t *testing.T) {
+	assert.InDelta(t, 6400, distanceKm(pune.Latitude, pune.Longitude, berlin.Latitude, berlin.Longitude), 100)
+	assert.Zero(t, distanceKm(pune.Latitude, pune.Longitude, pune.Latitude, pune.Longitude))
+}
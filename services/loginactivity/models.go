@@ -0,0 +1,77 @@
+package loginactivityservice
+
+import (
+	"asset/models"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// why a login was flagged, a user's first login sets the baseline and is never flagged
+const (
+	AnomalyNewDevice        = "new_device"
+	AnomalyNewCountry       = "new_country"
+	AnomalyImpossibleTravel = "impossible_travel"
+)
+
+// review statuses, only flagged logins wait for the user but any login can be confirmed or denied once
+const (
+	ReviewNotRequired = "not_required"
+	ReviewPending     = "pending"
+	ReviewConfirmed   = "confirmed"
+	ReviewDenied      = "denied"
+)
+
+// LoginAttempt is a login that just completed, Method is how the user signed in
+type LoginAttempt struct {
+	UserID    uuid.UUID
+	Method    string
+	IPAddress string
+	UserAgent string
+}
+
+type newLogin struct {
+	UserID       uuid.UUID
+	DeviceID     uuid.UUID
+	Method       string
+	IPAddress    string
+	UserAgent    string
+	Location     models.GeoLocation
+	Anomalies    []string
+	TravelSpeed  *float64
+	ReviewStatus string
+	CreatedAt    time.Time
+}
+
+// LoginFilter narrows logins to a user or to the departments in Scope. FlaggedOnly leaves out the
+// logins nothing was wrong with, a Limit of 0 lists them all
+type LoginFilter struct {
+	UserID       *uuid.UUID
+	FlaggedOnly  bool
+	ReviewStatus string
+	Limit        int
+	Offset       int
+	Scope        models.DepartmentScope
+}
+
+// Login is a completed login, the location is empty when the ip couldn't be located. TravelSpeed is
+// the km/h from the previous login and only set for impossible travel
+type Login struct {
+	ID           uuid.UUID      `json:"id" db:"id"`
+	UserID       uuid.UUID      `json:"user_id" db:"user_id"`
+	Username     string         `json:"username" db:"username"`
+	DeviceID     *uuid.UUID     `json:"device_id,omitempty" db:"device_id"`
+	Method       string         `json:"method" db:"method"`
+	IPAddress    *string        `json:"ip_address,omitempty" db:"ip_address"`
+	UserAgent    *string        `json:"user_agent,omitempty" db:"user_agent"`
+	Country      *string        `json:"country,omitempty" db:"country"`
+	City         *string        `json:"city,omitempty" db:"city"`
+	Latitude     *float64       `json:"latitude,omitempty" db:"latitude"`
+	Longitude    *float64       `json:"longitude,omitempty" db:"longitude"`
+	Anomalies    pq.StringArray `json:"anomalies" db:"anomalies"`
+	TravelSpeed  *float64       `json:"travel_speed,omitempty" db:"travel_speed"`
+	ReviewStatus string         `json:"review_status" db:"review_status"`
+	ReviewedAt   *time.Time     `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	CreatedAt    time.Time      `json:"created_at" db:"created_at"`
+}
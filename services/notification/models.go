@@ -0,0 +1,136 @@
+package notificationservice
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// notification categories, used by clients to group and filter notifications
+const (
+	CategoryAssignment      = "assignment"
+	CategoryOverdue         = "overdue"
+	CategoryWarranty        = "warranty"
+	CategoryEmployeeEndDate = "employee_end_date"
+	CategoryReturnRequest   = "return_request"
+	CategoryIncident        = "incident"
+	CategoryApproval        = "approval"
+	CategoryAnnouncement    = "announcement"
+	CategorySecurity        = "security"
+)
+
+// delivery channels a user can switch on or off per category, everything is on until the user opts out
+const (
+	ChannelInApp = "in_app"
+	ChannelEmail = "email"
+	ChannelSlack = "slack"
+	ChannelPush  = "push"
+)
+
+// how often the emails of BatchedCategories are sent as one digest, off sends each on its own
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+var (
+	Categories = []string{CategoryAssignment, CategoryOverdue, CategoryWarranty, CategoryEmployeeEndDate, CategoryReturnRequest, CategoryIncident, CategoryApproval, CategoryAnnouncement, CategorySecurity}
+	Channels   = []string{ChannelInApp, ChannelEmail, ChannelSlack, ChannelPush}
+	// low priority categories, their emails wait for the recipient's digest unless Urgent
+	BatchedCategories = []string{CategoryAssignment, CategoryWarranty, CategoryEmployeeEndDate}
+)
+
+// Notification is what other services send, one row is stored per recipient. Urgent emails it right
+// away even when its category is batched into digests, for notifications with a deadline. InAppOnly
+// queues no email at all
+type Notification struct {
+	Category   string
+	Title      string
+	Body       string
+	EntityType string
+	EntityID   string
+	Urgent     bool
+	InAppOnly  bool
+}
+
+type NotificationRes struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	Category   string     `json:"category" db:"category"`
+	Title      string     `json:"title" db:"title"`
+	Body       string     `json:"body" db:"body"`
+	EntityType *string    `json:"entity_type,omitempty" db:"entity_type"`
+	EntityID   *string    `json:"entity_id,omitempty" db:"entity_id"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	ReadAt     *time.Time `json:"read_at,omitempty" db:"read_at"`
+}
+
+type NotificationFilter struct {
+	UnreadOnly bool
+	Limit      int
+	Offset     int
+}
+
+type PreferenceRes struct {
+	Category string `json:"category" db:"category"`
+	Channel  string `json:"channel" db:"channel"`
+	Enabled  bool   `json:"enabled" db:"enabled"`
+}
+
+type UpdatePreferencesReq struct {
+	Preferences []PreferenceReq `json:"preferences" validate:"required,min=1,dive"`
+}
+
+type PreferenceReq struct {
+	Category string `json:"category" validate:"required,oneof=assignment overdue warranty employee_end_date return_request incident approval announcement security"`
+	Channel  string `json:"channel" validate:"required,oneof=in_app email slack push"`
+	Enabled  *bool  `json:"enabled" validate:"required"`
+}
+
+// DigestSettingsRes is how the caller gets the emails of BatchedCategories
+type DigestSettingsRes struct {
+	Frequency         string     `json:"frequency" db:"frequency"`
+	BatchedCategories []string   `json:"batched_categories" db:"-"`
+	LastSentAt        *time.Time `json:"last_sent_at,omitempty" db:"last_sent_at"`
+}
+
+type UpdateDigestSettingsReq struct {
+	Frequency string `json:"frequency" validate:"required,oneof=off daily weekly"`
+}
+
+// PendingEmail is a queued email with the address of its recipient, LocalTime is when it happened
+// in the recipient's time zone
+type PendingEmail struct {
+	ID        uuid.UUID `db:"id"`
+	UserID    uuid.UUID `db:"user_id"`
+	Email     string    `db:"email"`
+	Category  string    `db:"category"`
+	Title     string    `db:"title"`
+	Body      string    `db:"body"`
+	LocalTime string    `db:"local_time"`
+}
+
+// BroadcastReq is an announcement for the users holding any of Roles in any of DepartmentIDs, an
+// empty list doesn't narrow the recipients down. Email mails it to them right away as well
+type BroadcastReq struct {
+	Title         string      `json:"title" validate:"required,max=200"`
+	Body          string      `json:"body" validate:"required,max=5000"`
+	Roles         []string    `json:"roles" validate:"omitempty,dive,oneof=admin org_admin asset_manager employee_manager employee"`
+	DepartmentIDs []uuid.UUID `json:"department_ids"`
+	Email         bool        `json:"email"`
+}
+
+type Broadcast struct {
+	ID             uuid.UUID      `json:"id" db:"id"`
+	Title          string         `json:"title" db:"title"`
+	Body           string         `json:"body" db:"body"`
+	Roles          pq.StringArray `json:"roles" db:"roles"`
+	DepartmentIDs  pq.StringArray `json:"department_ids" db:"department_ids"`
+	OrganizationID *uuid.UUID     `json:"organization_id,omitempty" db:"organization_id"`
+	Email          bool           `json:"email" db:"email"`
+	Recipients     int            `json:"recipients" db:"recipients"`
+	SentBy         uuid.UUID      `json:"sent_by" db:"sent_by"`
+	SentByName     string         `json:"sent_by_name" db:"sent_by_name"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,264 @@
+package notificationservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type NotificationHandler struct {
+	Service        NotificationService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewNotificationHandler(service NotificationService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *NotificationHandler {
+	return &NotificationHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+func (h *NotificationHandler) GetMyNotifications(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetMyNotifications request received")
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in GetMyNotifications", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in GetMyNotifications", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	filter := NotificationFilter{UnreadOnly: r.URL.Query().Get("unread") == "true"}
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+
+	notifications, err := h.Service.GetNotifications(r.Context(), userUUID, filter)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch notifications", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch notifications")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"notifications": notifications})
+}
+
+// MarkNotificationsRead marks the notification given by ?id= as read, or all of the caller's notifications when no id is given
+func (h *NotificationHandler) MarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("MarkNotificationsRead request received")
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in MarkNotificationsRead", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in MarkNotificationsRead", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	var notificationID *uuid.UUID
+	if id := r.URL.Query().Get("id"); id != "" {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			h.Logger.GetLogger().Error("Invalid id in MarkNotificationsRead", zap.String("id", id), zap.Error(err))
+			utils.RespondError(w, http.StatusBadRequest, err, "invalid id")
+			return
+		}
+		notificationID = &parsed
+	}
+
+	updated, err := h.Service.MarkRead(r.Context(), userUUID, notificationID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to mark notifications read", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to mark notifications read")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "notifications marked as read", "updated": updated})
+}
+
+func (h *NotificationHandler) GetMyPreferences(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetMyPreferences request received")
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in GetMyPreferences", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in GetMyPreferences", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	prefs, err := h.Service.GetPreferences(r.Context(), userUUID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch notification preferences", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch notification preferences")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"preferences": prefs})
+}
+
+// UpdateMyPreferences switches individual category/channel combinations on or off, entries not sent are left as they are
+func (h *NotificationHandler) UpdateMyPreferences(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("UpdateMyPreferences request received")
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in UpdateMyPreferences", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in UpdateMyPreferences", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	var req UpdatePreferencesReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in UpdateMyPreferences", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Validation failed in UpdateMyPreferences", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	if err := h.Service.UpdatePreferences(r.Context(), userUUID, req); err != nil {
+		h.Logger.GetLogger().Error("Failed to update notification preferences", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to update notification preferences")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "notification preferences updated"})
+}
+
+// GetMyDigestSettings tells how the caller gets the emails of the batched categories
+func (h *NotificationHandler) GetMyDigestSettings(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in GetMyDigestSettings", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in GetMyDigestSettings", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	settings, err := h.Service.GetDigestSettings(r.Context(), userUUID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch digest settings", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch digest settings")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, settings)
+}
+
+// UpdateMyDigestSettings sets whether the emails of the batched categories come daily, weekly or one by one
+func (h *NotificationHandler) UpdateMyDigestSettings(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in UpdateMyDigestSettings", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in UpdateMyDigestSettings", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	var req UpdateDigestSettingsReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	if err := h.Service.UpdateDigestSettings(r.Context(), userUUID, req); err != nil {
+		h.Logger.GetLogger().Error("Failed to update digest settings", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to update digest settings")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "digest settings updated"})
+}
+
+// SendBroadcast sends an announcement in-app, and by email when asked, to the users holding the
+// roles in the departments of the request, everyone in the caller's organization by default
+func (h *NotificationHandler) SendBroadcast(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in SendBroadcast", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in SendBroadcast", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in SendBroadcast", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	var req BroadcastReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	broadcast, err := h.Service.Broadcast(r.Context(), userUUID, scope, req)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to broadcast announcement", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to broadcast announcement")
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{"message": "announcement sent", "broadcast": broadcast})
+}
+
+// GetBroadcasts lists the announcements sent in the caller's organization, latest first
+func (h *NotificationHandler) GetBroadcasts(w http.ResponseWriter, r *http.Request) {
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in GetBroadcasts", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+	limit, offset := utils.GetPageLimitAndOffset(r)
+
+	broadcasts, err := h.Service.GetBroadcasts(r.Context(), scope, limit, offset)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch broadcasts", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch broadcasts")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"broadcasts": broadcasts, "limit": limit, "offset": offset})
+}
@@ -0,0 +1,318 @@
+package notificationservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type NotificationRepository interface {
+	InsertNotifications(ctx context.Context, userIDs []uuid.UUID, n Notification) error
+	GetNotifications(ctx context.Context, userID uuid.UUID, filter NotificationFilter) ([]NotificationRes, error)
+	MarkRead(ctx context.Context, userID uuid.UUID, notificationID *uuid.UUID) (int64, error)
+	GetPreferences(ctx context.Context, userID uuid.UUID) ([]PreferenceRes, error)
+	UpsertPreferences(ctx context.Context, userID uuid.UUID, prefs []PreferenceReq) error
+	// QueueEmails queues an email of n for the recipients who didn't switch email off for the
+	// category, batched ones go to the digest of recipients who get one
+	QueueEmails(ctx context.Context, userIDs []uuid.UUID, n Notification, batched bool) error
+	GetDigestSettings(ctx context.Context, userID uuid.UUID) (DigestSettingsRes, error)
+	UpsertDigestSettings(ctx context.Context, userID uuid.UUID, frequency string) error
+	// GetPendingEmails returns up to limit queued emails that aren't for a digest, oldest first
+	GetPendingEmails(ctx context.Context, limit int) ([]PendingEmail, error)
+	// GetDueDigestEmails returns the queued digest emails of users whose digest is due, by user and
+	// oldest first. A digest is due from hour o'clock in the user's time zone, every day or on mondays
+	// for weekly ones, when none went out since
+	GetDueDigestEmails(ctx context.Context, hour int) ([]PendingEmail, error)
+	MarkEmailsSent(ctx context.Context, ids []uuid.UUID) error
+	// MarkEmailsFailed counts a failed send of the emails, they are retried until maxEmailAttempts
+	MarkEmailsFailed(ctx context.Context, ids []uuid.UUID) error
+	// MarkDigestSent marks the emails of a digest sent and records when the user's digest went out
+	MarkDigestSent(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) error
+	// GetBroadcastRecipients returns the active users of the organization, every organization when
+	// nil, holding any of the roles in any of the departments of req
+	GetBroadcastRecipients(ctx context.Context, req BroadcastReq, organizationID *uuid.UUID) ([]uuid.UUID, error)
+	InsertBroadcast(ctx context.Context, req BroadcastReq, organizationID *uuid.UUID, recipients int, sentBy uuid.UUID) (Broadcast, error)
+	// GetBroadcasts lists the broadcasts sent in the organization, all of them when nil, latest first
+	GetBroadcasts(ctx context.Context, organizationID *uuid.UUID, limit, offset int) ([]Broadcast, error)
+}
+
+// maxEmailAttempts is how many times a queued email is tried before it is given up on
+const maxEmailAttempts = 5
+
+type PostgresNotificationRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewNotificationRepository(db *sqlx.DB, log providers.ZapLoggerProvider) NotificationRepository {
+	return &PostgresNotificationRepository{DB: db, Logger: log}
+}
+
+// InsertNotifications runs in the transaction of ctx when there is one, so notifications commit with the change they describe,
+// recipients who switched off in-app notifications for the category are skipped
+func (r *PostgresNotificationRepository) InsertNotifications(ctx context.Context, userIDs []uuid.UUID, n Notification) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO notifications (user_id, category, title, body, entity_type, entity_id)
+		SELECT DISTINCT recipient, $2, $3, $4, NULLIF($5, ''), NULLIF($6, '')
+		FROM unnest($1::uuid[]) AS recipient
+		WHERE NOT EXISTS (
+			SELECT 1 FROM notification_preferences p
+			WHERE p.user_id = recipient AND p.category = $2 AND p.channel = $7 AND NOT p.enabled
+		)
+	`, pq.Array(userIDs), n.Category, n.Title, n.Body, n.EntityType, n.EntityID, ChannelInApp)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert notifications", zap.String("category", n.Category), zap.Int("recipients", len(userIDs)), zap.Error(err))
+		return fmt.Errorf("failed to insert notifications: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresNotificationRepository) GetNotifications(ctx context.Context, userID uuid.UUID, filter NotificationFilter) ([]NotificationRes, error) {
+	notifications := make([]NotificationRes, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &notifications, `
+		SELECT id, category, title, body, entity_type, entity_id, created_at, read_at
+		FROM notifications
+		WHERE user_id = $1
+		AND (NOT $2 OR read_at IS NULL)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`, userID, filter.UnreadOnly, filter.Limit, filter.Offset)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch notifications", zap.String("user_id", userID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch notifications: %w", err)
+	}
+	return notifications, nil
+}
+
+// MarkRead marks a single notification as read, or all of the user's notifications when notificationID is nil
+func (r *PostgresNotificationRepository) MarkRead(ctx context.Context, userID uuid.UUID, notificationID *uuid.UUID) (int64, error) {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE notifications SET read_at = now()
+		WHERE user_id = $1 AND read_at IS NULL
+		AND ($2::uuid IS NULL OR id = $2)
+	`, userID, notificationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to mark notifications read", zap.String("user_id", userID.String()), zap.Error(err))
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// GetPreferences returns only what the user has explicitly set, missing entries are enabled
+func (r *PostgresNotificationRepository) GetPreferences(ctx context.Context, userID uuid.UUID) ([]PreferenceRes, error) {
+	prefs := make([]PreferenceRes, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &prefs, `
+		SELECT category, channel, enabled FROM notification_preferences WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch notification preferences", zap.String("user_id", userID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch notification preferences: %w", err)
+	}
+	return prefs, nil
+}
+
+func (r *PostgresNotificationRepository) UpsertPreferences(ctx context.Context, userID uuid.UUID, prefs []PreferenceReq) error {
+	for _, pref := range prefs {
+		_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+			INSERT INTO notification_preferences (user_id, category, channel, enabled)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id, category, channel) DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = now()
+		`, userID, pref.Category, pref.Channel, *pref.Enabled)
+		if err != nil {
+			r.Logger.GetLogger().Error("failed to save notification preference", zap.String("user_id", userID.String()), zap.String("category", pref.Category), zap.String("channel", pref.Channel), zap.Error(err))
+			return fmt.Errorf("failed to save notification preference: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *PostgresNotificationRepository) QueueEmails(ctx context.Context, userIDs []uuid.UUID, n Notification, batched bool) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO notification_emails (user_id, category, title, body, entity_type, entity_id, digest)
+		SELECT DISTINCT recipient, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $8 AND COALESCE(s.frequency, 'daily') <> 'off'
+		FROM unnest($1::uuid[]) AS recipient
+		LEFT JOIN notification_digest_settings s ON s.user_id = recipient
+		WHERE NOT EXISTS (
+			SELECT 1 FROM notification_preferences p
+			WHERE p.user_id = recipient AND p.category = $2 AND p.channel = $7 AND NOT p.enabled
+		)
+	`, pq.Array(userIDs), n.Category, n.Title, n.Body, n.EntityType, n.EntityID, ChannelEmail, batched)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to queue notification emails", zap.String("category", n.Category), zap.Int("recipients", len(userIDs)), zap.Error(err))
+		return fmt.Errorf("failed to queue notification emails: %w", err)
+	}
+	return nil
+}
+
+// GetDigestSettings returns the daily default for users who never changed it
+func (r *PostgresNotificationRepository) GetDigestSettings(ctx context.Context, userID uuid.UUID) (DigestSettingsRes, error) {
+	settings := DigestSettingsRes{Frequency: DigestDaily}
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &settings, `
+		SELECT frequency, last_sent_at FROM notification_digest_settings WHERE user_id = $1
+	`, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		r.Logger.GetLogger().Error("failed to fetch digest settings", zap.String("user_id", userID.String()), zap.Error(err))
+		return DigestSettingsRes{}, fmt.Errorf("failed to fetch digest settings: %w", err)
+	}
+	return settings, nil
+}
+
+func (r *PostgresNotificationRepository) UpsertDigestSettings(ctx context.Context, userID uuid.UUID, frequency string) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO notification_digest_settings (user_id, frequency)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET frequency = EXCLUDED.frequency, updated_at = now()
+	`, userID, frequency)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to save digest settings", zap.String("user_id", userID.String()), zap.Error(err))
+		return fmt.Errorf("failed to save digest settings: %w", err)
+	}
+	return nil
+}
+
+const pendingEmailColumns = `e.id, e.user_id, u.email, e.category, e.title, e.body,
+	to_char(e.created_at AT TIME ZONE COALESCE(NULLIF(u.timezone, ''), 'UTC'), 'Dy DD Mon HH24:MI') AS local_time`
+
+func (r *PostgresNotificationRepository) GetPendingEmails(ctx context.Context, limit int) ([]PendingEmail, error) {
+	emails := make([]PendingEmail, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &emails, `
+		SELECT `+pendingEmailColumns+`
+		FROM notification_emails e
+		JOIN users u ON u.id = e.user_id AND u.archived_at IS NULL
+		WHERE NOT e.digest AND e.sent_at IS NULL AND e.attempts < $1
+		ORDER BY e.created_at
+		LIMIT $2
+	`, maxEmailAttempts, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pending notification emails: %w", err)
+	}
+	return emails, nil
+}
+
+// the digest of a user who switched digests off since is sent daily, so nothing queued is lost
+func (r *PostgresNotificationRepository) GetDueDigestEmails(ctx context.Context, hour int) ([]PendingEmail, error) {
+	emails := make([]PendingEmail, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &emails, `
+		SELECT `+pendingEmailColumns+`
+		FROM notification_emails e
+		JOIN users u ON u.id = e.user_id AND u.archived_at IS NULL
+		LEFT JOIN notification_digest_settings s ON s.user_id = e.user_id
+		CROSS JOIN LATERAL (
+			SELECT COALESCE(NULLIF(u.timezone, ''), 'UTC') AS zone,
+				now() AT TIME ZONE COALESCE(NULLIF(u.timezone, ''), 'UTC') AS local_now
+		) tz
+		CROSS JOIN LATERAL (
+			SELECT date_trunc(CASE WHEN s.frequency = 'weekly' THEN 'week' ELSE 'day' END, tz.local_now) + make_interval(hours => $1) AS due_at
+		) d
+		WHERE e.digest AND e.sent_at IS NULL AND e.attempts < $2
+		AND tz.local_now >= d.due_at
+		AND (s.last_sent_at IS NULL OR s.last_sent_at AT TIME ZONE tz.zone < d.due_at)
+		ORDER BY e.user_id, e.created_at
+	`, hour, maxEmailAttempts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch due digest emails: %w", err)
+	}
+	return emails, nil
+}
+
+func (r *PostgresNotificationRepository) MarkEmailsSent(ctx context.Context, ids []uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE notification_emails SET sent_at = now() WHERE id = ANY($1)
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to mark notification emails sent: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresNotificationRepository) MarkEmailsFailed(ctx context.Context, ids []uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE notification_emails SET attempts = attempts + 1 WHERE id = ANY($1)
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to count failed notification emails: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresNotificationRepository) MarkDigestSent(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) error {
+	if err := r.MarkEmailsSent(ctx, ids); err != nil {
+		return err
+	}
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO notification_digest_settings (user_id, last_sent_at)
+		VALUES ($1, now())
+		ON CONFLICT (user_id) DO UPDATE SET last_sent_at = EXCLUDED.last_sent_at
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to record digest sent: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresNotificationRepository) GetBroadcastRecipients(ctx context.Context, req BroadcastReq, organizationID *uuid.UUID) ([]uuid.UUID, error) {
+	userIDs := []uuid.UUID{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &userIDs, `
+		SELECT u.id
+		FROM users u
+		WHERE u.archived_at IS NULL
+		AND ($1::uuid IS NULL OR u.organization_id = $1)
+		AND (cardinality($2::uuid[]) = 0 OR u.department_id = ANY($2))
+		AND (cardinality($3::text[]) = 0 OR EXISTS (
+			SELECT 1 FROM user_roles ur
+			WHERE ur.user_id = u.id AND ur.archived_at IS NULL AND ur.role::text = ANY($3)
+		))
+		ORDER BY u.id
+	`, organizationID, pq.Array(req.DepartmentIDs), pq.Array(req.Roles))
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch broadcast recipients", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch broadcast recipients: %w", err)
+	}
+	return userIDs, nil
+}
+
+func (r *PostgresNotificationRepository) InsertBroadcast(ctx context.Context, req BroadcastReq, organizationID *uuid.UUID, recipients int, sentBy uuid.UUID) (Broadcast, error) {
+	var broadcast Broadcast
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &broadcast, `
+		WITH b AS (
+			INSERT INTO broadcasts (title, body, roles, department_ids, organization_id, email, recipients, sent_by)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING *
+		)
+		SELECT b.id, b.title, b.body, b.roles, b.department_ids::text[] AS department_ids, b.organization_id,
+			b.email, b.recipients, b.sent_by, u.username AS sent_by_name, b.created_at
+		FROM b
+		JOIN users u ON u.id = b.sent_by
+	`, req.Title, req.Body, pq.Array(req.Roles), pq.Array(req.DepartmentIDs), organizationID, req.Email, recipients, sentBy)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert broadcast", zap.String("sent_by", sentBy.String()), zap.Error(err))
+		return Broadcast{}, fmt.Errorf("failed to insert broadcast: %w", err)
+	}
+	return broadcast, nil
+}
+
+func (r *PostgresNotificationRepository) GetBroadcasts(ctx context.Context, organizationID *uuid.UUID, limit, offset int) ([]Broadcast, error) {
+	broadcasts := make([]Broadcast, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &broadcasts, `
+		SELECT b.id, b.title, b.body, b.roles, b.department_ids::text[] AS department_ids, b.organization_id,
+			b.email, b.recipients, b.sent_by, u.username AS sent_by_name, b.created_at
+		FROM broadcasts b
+		JOIN users u ON u.id = b.sent_by
+		WHERE $1::uuid IS NULL OR b.organization_id = $1
+		ORDER BY b.created_at DESC
+		LIMIT $2 OFFSET $3
+	`, organizationID, limit, offset)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch broadcasts", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch broadcasts: %w", err)
+	}
+	return broadcasts, nil
+}
@@ -0,0 +1,97 @@
+//go:build integration
+
+package notificationservice
+
+import (
+	"asset/database/seed"
+	"asset/database/testdb"
+	"asset/providers"
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNotificationEmails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewNotificationRepository(db, logger)
+	ctx := context.Background()
+	developerID, designerID, internID := seed.ID("user:developer"), seed.ID("user:designer"), seed.ID("user:intern")
+	recipients := []uuid.UUID{developerID, designerID, internID}
+
+	// the designer mails everything one by one, the intern switched warranty emails off
+	require.NoError(t, repo.UpsertDigestSettings(ctx, designerID, DigestOff))
+	off := false
+	require.NoError(t, repo.UpsertPreferences(ctx, internID, []PreferenceReq{{Category: CategoryWarranty, Channel: ChannelEmail, Enabled: &off}}))
+	settings, err := repo.GetDigestSettings(ctx, developerID)
+	require.NoError(t, err)
+	assert.Equal(t, DigestDaily, settings.Frequency)
+
+	require.NoError(t, repo.QueueEmails(ctx, recipients, Notification{Category: CategoryWarranty, Title: "Warranty ends soon", Body: "SEED-LAP-0001"}, true))
+	pending, err := repo.GetPendingEmails(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, designerID, pending[0].UserID)
+
+	// every seeded user is on utc, so the daily digest is due from 00:00
+	due, err := repo.GetDueDigestEmails(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, due, 1)
+	assert.Equal(t, developerID, due[0].UserID)
+	assert.Equal(t, "Warranty ends soon", due[0].Title)
+
+	require.NoError(t, repo.MarkDigestSent(ctx, developerID, []uuid.UUID{due[0].ID}))
+	require.NoError(t, repo.QueueEmails(ctx, []uuid.UUID{developerID}, Notification{Category: CategoryAssignment, Title: "Asset assigned"}, true))
+	due, err = repo.GetDueDigestEmails(ctx, 0)
+	require.NoError(t, err)
+	assert.Empty(t, due, "one digest a day")
+
+	require.NoError(t, repo.MarkEmailsFailed(ctx, []uuid.UUID{pending[0].ID}))
+	require.NoError(t, repo.MarkEmailsSent(ctx, []uuid.UUID{pending[0].ID}))
+	pending, err = repo.GetPendingEmails(ctx, 10)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestBroadcasts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewNotificationRepository(db, logger)
+	ctx := context.Background()
+	operations := seed.ID("department:operations")
+
+	everyone, err := repo.GetBroadcastRecipients(ctx, BroadcastReq{}, nil)
+	require.NoError(t, err)
+	assert.Contains(t, everyone, seed.ID("user:intern"))
+	managers, err := repo.GetBroadcastRecipients(ctx, BroadcastReq{Roles: []string{"employee_manager"}}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{seed.ID("user:employee-manager")}, managers)
+	inOperations, err := repo.GetBroadcastRecipients(ctx, BroadcastReq{DepartmentIDs: []uuid.UUID{operations}}, nil)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{seed.ID("user:admin"), seed.ID("user:freelancer")}, inOperations)
+
+	req := BroadcastReq{Title: "Asset verification", Body: "Starts Monday", DepartmentIDs: []uuid.UUID{operations}, Email: true}
+	broadcast, err := repo.InsertBroadcast(ctx, req, nil, len(inOperations), seed.ID("user:admin"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{operations.String()}, []string(broadcast.DepartmentIDs))
+	assert.Equal(t, "Asha Admin", broadcast.SentByName)
+
+	broadcasts, err := repo.GetBroadcasts(ctx, nil, 10, 0)
+	require.NoError(t, err)
+	require.Len(t, broadcasts, 1)
+	assert.Equal(t, broadcast.ID, broadcasts[0].ID)
+	assert.Equal(t, 2, broadcasts[0].Recipients)
+}
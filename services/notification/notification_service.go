@@ -0,0 +1,297 @@
+package notificationservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type NotificationService interface {
+	Notify(ctx context.Context, userIDs []uuid.UUID, n Notification) error
+	GetNotifications(ctx context.Context, userID uuid.UUID, filter NotificationFilter) ([]NotificationRes, error)
+	MarkRead(ctx context.Context, userID uuid.UUID, notificationID *uuid.UUID) (int64, error)
+	GetPreferences(ctx context.Context, userID uuid.UUID) ([]PreferenceRes, error)
+	UpdatePreferences(ctx context.Context, userID uuid.UUID, req UpdatePreferencesReq) error
+	GetDigestSettings(ctx context.Context, userID uuid.UUID) (DigestSettingsRes, error)
+	UpdateDigestSettings(ctx context.Context, userID uuid.UUID, req UpdateDigestSettingsReq) error
+	SendPendingEmails(ctx context.Context) error
+	SendDigests(ctx context.Context) error
+	// Broadcast sends an announcement to the users req targets within the organization of scope
+	Broadcast(ctx context.Context, senderID uuid.UUID, scope models.DepartmentScope, req BroadcastReq) (Broadcast, error)
+	GetBroadcasts(ctx context.Context, scope models.DepartmentScope, limit, offset int) ([]Broadcast, error)
+}
+
+var ErrNoRecipients = models.NewServiceError(http.StatusUnprocessableEntity, "no_recipients", "no active user holds those roles in those departments")
+
+const (
+	// emails sent per run of the send_notification_emails job, the rest wait for the next run
+	emailBatchSize = 200
+	// digests go out from this hour in the recipient's time zone
+	digestHour = 8
+)
+
+type notificationServiceStruct struct {
+	repo   NotificationRepository
+	db     *sqlx.DB
+	email  providers.EmailProvider
+	logger providers.ZapLoggerProvider
+}
+
+func NewNotificationService(repo NotificationRepository, db *sqlx.DB, email providers.EmailProvider, logger providers.ZapLoggerProvider) NotificationService {
+	return &notificationServiceStruct{repo: repo, db: db, email: email, logger: logger}
+}
+
+// Notify stores the in-app notifications and queues their emails in the same transaction, the
+// emails of batched categories wait for the recipients' digests
+func (s *notificationServiceStruct) Notify(ctx context.Context, userIDs []uuid.UUID, n Notification) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	err := utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		if err := s.repo.InsertNotifications(ctx, userIDs, n); err != nil {
+			return err
+		}
+		if n.InAppOnly {
+			return nil
+		}
+		return s.repo.QueueEmails(ctx, userIDs, n, !n.Urgent && slices.Contains(BatchedCategories, n.Category))
+	})
+	if err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("notification sent", zap.String("category", n.Category), zap.Int("recipients", len(userIDs)))
+	return nil
+}
+
+func (s *notificationServiceStruct) GetNotifications(ctx context.Context, userID uuid.UUID, filter NotificationFilter) ([]NotificationRes, error) {
+	return s.repo.GetNotifications(ctx, userID, filter)
+}
+
+func (s *notificationServiceStruct) MarkRead(ctx context.Context, userID uuid.UUID, notificationID *uuid.UUID) (int64, error) {
+	return s.repo.MarkRead(ctx, userID, notificationID)
+}
+
+// GetPreferences returns every category and channel combination, filling in the enabled default for unset ones
+func (s *notificationServiceStruct) GetPreferences(ctx context.Context, userID uuid.UUID) ([]PreferenceRes, error) {
+	saved, err := s.repo.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	enabled := make(map[string]bool, len(saved))
+	for _, pref := range saved {
+		enabled[pref.Category+"/"+pref.Channel] = pref.Enabled
+	}
+
+	prefs := make([]PreferenceRes, 0, len(Categories)*len(Channels))
+	for _, category := range Categories {
+		for _, channel := range Channels {
+			on, ok := enabled[category+"/"+channel]
+			prefs = append(prefs, PreferenceRes{Category: category, Channel: channel, Enabled: !ok || on})
+		}
+	}
+	return prefs, nil
+}
+
+func (s *notificationServiceStruct) UpdatePreferences(ctx context.Context, userID uuid.UUID, req UpdatePreferencesReq) error {
+	err := utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		return s.repo.UpsertPreferences(ctx, userID, req.Preferences)
+	})
+	if err != nil {
+		s.logger.GetLogger().Error("failed to update notification preferences", zap.String("user_id", userID.String()), zap.Error(err))
+		return err
+	}
+	s.logger.GetLogger().Info("notification preferences updated", zap.String("user_id", userID.String()), zap.Int("count", len(req.Preferences)))
+	return nil
+}
+
+func (s *notificationServiceStruct) GetDigestSettings(ctx context.Context, userID uuid.UUID) (DigestSettingsRes, error) {
+	settings, err := s.repo.GetDigestSettings(ctx, userID)
+	if err != nil {
+		return DigestSettingsRes{}, err
+	}
+	settings.BatchedCategories = BatchedCategories
+	if settings.LastSentAt != nil {
+		sent := utils.InLocation(*settings.LastSentAt, utils.LocationFromContext(ctx))
+		settings.LastSentAt = &sent
+	}
+	return settings, nil
+}
+
+// UpdateDigestSettings only changes how later emails are queued, what waits for a digest already is
+// still sent with it
+func (s *notificationServiceStruct) UpdateDigestSettings(ctx context.Context, userID uuid.UUID, req UpdateDigestSettingsReq) error {
+	if err := s.repo.UpsertDigestSettings(ctx, userID, req.Frequency); err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("notification digest settings updated", zap.String("user_id", userID.String()), zap.String("frequency", req.Frequency))
+	return nil
+}
+
+// SendPendingEmails mails the queued emails that don't wait for a digest one by one, a failed one is
+// retried on the next run
+func (s *notificationServiceStruct) SendPendingEmails(ctx context.Context) error {
+	emails, err := s.repo.GetPendingEmails(ctx, emailBatchSize)
+	if err != nil {
+		return err
+	}
+	var sent, failed []uuid.UUID
+	for _, email := range emails {
+		body := fmt.Sprintf("%s\r\n\r\nYou get this email because email notifications are on for %s, you can switch them off in your notification preferences.",
+			email.Body, categoryLabel(email.Category))
+		if err := s.email.Send(ctx, email.Email, email.Title, body); err != nil {
+			s.logger.GetLogger().Warn("failed to email notification", zap.String("id", email.ID.String()), zap.Error(err))
+			failed = append(failed, email.ID)
+			continue
+		}
+		sent = append(sent, email.ID)
+	}
+	if err := s.markEmails(ctx, sent, failed); err != nil {
+		return err
+	}
+	if len(emails) > 0 {
+		s.logger.GetLogger().Info("notification emails sent", zap.Int("sent", len(sent)), zap.Int("failed", len(failed)))
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to send %d of %d notification emails", len(failed), len(emails))
+	}
+	return nil
+}
+
+// SendDigests assembles the queued emails of every user whose digest is due into one email
+func (s *notificationServiceStruct) SendDigests(ctx context.Context) error {
+	emails, err := s.repo.GetDueDigestEmails(ctx, digestHour)
+	if err != nil {
+		return err
+	}
+	digests, failed := 0, 0
+	for start := 0; start < len(emails); {
+		end := start + 1
+		for end < len(emails) && emails[end].UserID == emails[start].UserID {
+			end++
+		}
+		batch := emails[start:end]
+		start = end
+
+		ids := make([]uuid.UUID, len(batch))
+		for i, email := range batch {
+			ids[i] = email.ID
+		}
+		subject, body := assembleDigest(batch)
+		if err := s.email.Send(ctx, batch[0].Email, subject, body); err != nil {
+			s.logger.GetLogger().Warn("failed to email notification digest", zap.String("user_id", batch[0].UserID.String()), zap.Error(err))
+			failed++
+			if err := s.repo.MarkEmailsFailed(ctx, ids); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := s.repo.MarkDigestSent(ctx, batch[0].UserID, ids); err != nil {
+			return err
+		}
+		digests++
+	}
+	if digests > 0 || failed > 0 {
+		s.logger.GetLogger().Info("notification digests sent", zap.Int("digests", digests), zap.Int("failed", failed))
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to send %d notification digests", failed)
+	}
+	return nil
+}
+
+func (s *notificationServiceStruct) markEmails(ctx context.Context, sent, failed []uuid.UUID) error {
+	if len(sent) > 0 {
+		if err := s.repo.MarkEmailsSent(ctx, sent); err != nil {
+			return err
+		}
+	}
+	if len(failed) > 0 {
+		return s.repo.MarkEmailsFailed(ctx, failed)
+	}
+	return nil
+}
+
+// assembleDigest lists the emails of one user grouped by category, in the order of Categories
+// Broadcast records the announcement and notifies its recipients in one transaction, the emails of
+// an emailed one skip the digests
+func (s *notificationServiceStruct) Broadcast(ctx context.Context, senderID uuid.UUID, scope models.DepartmentScope, req BroadcastReq) (Broadcast, error) {
+	recipients, err := s.repo.GetBroadcastRecipients(ctx, req, scope.OrganizationID)
+	if err != nil {
+		return Broadcast{}, err
+	}
+	if len(recipients) == 0 {
+		return Broadcast{}, ErrNoRecipients
+	}
+
+	var broadcast Broadcast
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		if broadcast, err = s.repo.InsertBroadcast(ctx, req, scope.OrganizationID, len(recipients), senderID); err != nil {
+			return err
+		}
+		return s.Notify(ctx, recipients, Notification{
+			Category:   CategoryAnnouncement,
+			Title:      req.Title,
+			Body:       req.Body,
+			EntityType: "broadcast",
+			EntityID:   broadcast.ID.String(),
+			Urgent:     true,
+			InAppOnly:  !req.Email,
+		})
+	})
+	if err != nil {
+		return Broadcast{}, err
+	}
+	broadcast.CreatedAt = utils.InLocation(broadcast.CreatedAt, utils.LocationFromContext(ctx))
+	s.logger.GetLogger().Info("announcement broadcast", zap.String("broadcast_id", broadcast.ID.String()), zap.String("sent_by", senderID.String()), zap.Int("recipients", len(recipients)), zap.Bool("email", req.Email))
+	return broadcast, nil
+}
+
+func (s *notificationServiceStruct) GetBroadcasts(ctx context.Context, scope models.DepartmentScope, limit, offset int) ([]Broadcast, error) {
+	broadcasts, err := s.repo.GetBroadcasts(ctx, scope.OrganizationID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	loc := utils.LocationFromContext(ctx)
+	for i := range broadcasts {
+		broadcasts[i].CreatedAt = utils.InLocation(broadcasts[i].CreatedAt, loc)
+	}
+	return broadcasts, nil
+}
+
+func assembleDigest(emails []PendingEmail) (string, string) {
+	byCategory := make(map[string][]PendingEmail)
+	for _, email := range emails {
+		byCategory[email.Category] = append(byCategory[email.Category], email)
+	}
+	updates := fmt.Sprintf("%d updates", len(emails))
+	if len(emails) == 1 {
+		updates = "1 update"
+	}
+	var body strings.Builder
+	body.WriteString("Here is what happened since your last digest, " + updates + ".\r\n")
+	for _, category := range Categories {
+		if len(byCategory[category]) == 0 {
+			continue
+		}
+		body.WriteString("\r\n" + categoryLabel(category) + "\r\n")
+		for _, email := range byCategory[category] {
+			body.WriteString(fmt.Sprintf("- %s  %s: %s\r\n", email.LocalTime, email.Title, email.Body))
+		}
+	}
+	body.WriteString("\r\nYou can get these daily, weekly or one by one in your notification digest settings.")
+	return "Your asset digest: " + updates, body.String()
+}
+
+func categoryLabel(category string) string {
+	label := strings.ReplaceAll(category, "_", " ")
+	return strings.ToUpper(label[:1]) + label[1:]
+}
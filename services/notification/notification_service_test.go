@@ -0,0 +1,108 @@
+package notificationservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/testutil"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T) (NotificationService, *MockNotificationRepository, *providers.MockEmailProvider, sqlmock.Sqlmock) {
+	ctrl := testutil.Controller(t)
+	db, mock := testutil.DB(t)
+	repo := NewMockNotificationRepository(ctrl)
+	email := providers.NewMockEmailProvider(ctrl)
+	return NewNotificationService(repo, db, email, testutil.Logger(ctrl)), repo, email, mock
+}
+
+func TestNotifyQueuesEmails(t *testing.T) {
+	userIDs := []uuid.UUID{uuid.New()}
+	for name, tc := range map[string]struct {
+		n       Notification
+		batched bool
+	}{
+		"low priority waits for the digest": {n: Notification{Category: CategoryWarranty, Title: "Warranty ends soon"}, batched: true},
+		"urgent skips the digest":           {n: Notification{Category: CategoryAssignment, Title: "Claim your laptop", Urgent: true}},
+		"high priority is sent on its own":  {n: Notification{Category: CategoryOverdue, Title: "Return overdue"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			svc, repo, _, db := newTestService(t)
+			db.ExpectBegin()
+			repo.EXPECT().InsertNotifications(gomock.Any(), userIDs, tc.n).Return(nil)
+			repo.EXPECT().QueueEmails(gomock.Any(), userIDs, tc.n, tc.batched).Return(nil)
+			db.ExpectCommit()
+
+			require.NoError(t, svc.Notify(context.Background(), userIDs, tc.n))
+			assert.NoError(t, db.ExpectationsWereMet())
+		})
+	}
+}
+
+// one digest goes out per user, a failed one is counted for a retry and the others still go out
+func TestSendDigests(t *testing.T) {
+	svc, repo, email, _ := newTestService(t)
+	ctx := context.Background()
+	dev, designer := uuid.New(), uuid.New()
+	emails := []PendingEmail{
+		{ID: uuid.New(), UserID: dev, Email: "dev@example.com", Category: CategoryWarranty, Title: "Warranty ends soon", Body: "SEED-LAP-0001 on 1 Nov", LocalTime: "Mon 12 Oct 09:00"},
+		{ID: uuid.New(), UserID: dev, Email: "dev@example.com", Category: CategoryAssignment, Title: "Asset assigned", Body: "SEED-MOU-0001 is yours", LocalTime: "Tue 13 Oct 10:30"},
+		{ID: uuid.New(), UserID: designer, Email: "designer@example.com", Category: CategoryAssignment, Title: "Asset assigned", Body: "SEED-MON-0001 is yours", LocalTime: "Tue 13 Oct 11:00"},
+	}
+
+	repo.EXPECT().GetDueDigestEmails(ctx, digestHour).Return(emails, nil)
+	email.EXPECT().Send(ctx, "dev@example.com", "Your asset digest: 2 updates", gomock.Any()).DoAndReturn(func(_ context.Context, _, _, body string) error {
+		// grouped in the order of Categories, assignments first
+		assert.Less(t, strings.Index(body, "SEED-MOU-0001"), strings.Index(body, "SEED-LAP-0001"))
+		assert.Contains(t, body, "- Mon 12 Oct 09:00  Warranty ends soon: SEED-LAP-0001 on 1 Nov")
+		return nil
+	})
+	repo.EXPECT().MarkDigestSent(ctx, dev, []uuid.UUID{emails[0].ID, emails[1].ID}).Return(nil)
+	email.EXPECT().Send(ctx, "designer@example.com", "Your asset digest: 1 update", gomock.Any()).Return(errors.New("mailbox full"))
+	repo.EXPECT().MarkEmailsFailed(ctx, []uuid.UUID{emails[2].ID}).Return(nil)
+
+	assert.EqualError(t, svc.SendDigests(ctx), "failed to send 1 notification digests")
+}
+
+func TestBroadcast(t *testing.T) {
+	orgID, senderID := uuid.New(), uuid.New()
+	scope := models.DepartmentScope{AllDepartments: true, OrganizationID: &orgID}
+	req := BroadcastReq{Title: "Asset verification", Body: "Annual asset verification starts Monday", Roles: []string{"employee"}}
+
+	t.Run("in-app only unless emailed", func(t *testing.T) {
+		svc, repo, _, db := newTestService(t)
+		recipients := []uuid.UUID{uuid.New(), uuid.New()}
+		broadcast := Broadcast{ID: uuid.New(), Title: req.Title, Recipients: 2}
+		repo.EXPECT().GetBroadcastRecipients(gomock.Any(), req, &orgID).Return(recipients, nil)
+		db.ExpectBegin()
+		repo.EXPECT().InsertBroadcast(gomock.Any(), req, &orgID, 2, senderID).Return(broadcast, nil)
+		repo.EXPECT().InsertNotifications(gomock.Any(), recipients, gomock.Any()).DoAndReturn(func(_ context.Context, _ []uuid.UUID, n Notification) error {
+			assert.Equal(t, CategoryAnnouncement, n.Category)
+			assert.Equal(t, broadcast.ID.String(), n.EntityID)
+			assert.True(t, n.InAppOnly)
+			return nil
+		})
+		db.ExpectCommit()
+
+		got, err := svc.Broadcast(context.Background(), senderID, scope, req)
+		require.NoError(t, err)
+		assert.Equal(t, broadcast.ID, got.ID)
+		assert.NoError(t, db.ExpectationsWereMet())
+	})
+
+	t.Run("nobody matches", func(t *testing.T) {
+		svc, repo, _, _ := newTestService(t)
+		repo.EXPECT().GetBroadcastRecipients(gomock.Any(), req, &orgID).Return([]uuid.UUID{}, nil)
+
+		_, err := svc.Broadcast(context.Background(), senderID, scope, req)
+		assert.ErrorIs(t, err, ErrNoRecipients)
+	})
+}
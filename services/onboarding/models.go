@@ -0,0 +1,21 @@
+package onboardingservice
+
+import (
+	"asset/models"
+
+	"github.com/google/uuid"
+)
+
+type CreateTemplateReq struct {
+	Name         string                     `json:"name" validate:"required"`
+	EmployeeType string                     `json:"employee_type" validate:"required,employee_type"`
+	Role         string                     `json:"role,omitempty"`
+	DepartmentID *uuid.UUID                 `json:"department_id,omitempty"`
+	Items        []models.OnboardingKitItem `json:"items" validate:"required,min=1,dive"`
+}
+
+type templateItemRow struct {
+	TemplateID uuid.UUID `db:"template_id"`
+	AssetType  string    `db:"asset_type"`
+	Quantity   int       `db:"quantity"`
+}
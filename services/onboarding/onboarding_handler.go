@@ -0,0 +1,104 @@
+package onboardingservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type OnboardingHandler struct {
+	Service        OnboardingService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewOnboardingHandler(service OnboardingService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *OnboardingHandler {
+	return &OnboardingHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+func (h *OnboardingHandler) GetTemplates(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetTemplates request received")
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in GetTemplates", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+	templates, err := h.Service.GetTemplates(r.Context(), scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch onboarding templates", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch onboarding templates")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"templates": templates})
+}
+
+func (h *OnboardingHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("CreateTemplate request received")
+	adminID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in CreateTemplate", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	var req CreateTemplateReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in CreateTemplate", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in CreateTemplate", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	adminUUID, err := uuid.Parse(adminID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse adminID in CreateTemplate", zap.String("adminID", adminID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	templateID, err := h.Service.CreateTemplate(r.Context(), req, adminUUID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to create onboarding template", zap.String("name", req.Name), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to create onboarding template")
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	utils.JSON.NewEncoder(w).Encode(map[string]interface{}{"message": "onboarding template created successfully", "template_id": templateID})
+}
+
+func (h *OnboardingHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("DeleteTemplate request received")
+	id := r.URL.Query().Get("id")
+	templateID, err := uuid.Parse(id)
+	if err != nil {
+		h.Logger.GetLogger().Error("Invalid id in DeleteTemplate", zap.String("id", id), zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid id")
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in DeleteTemplate", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	if err := h.Service.DeleteTemplate(r.Context(), templateID, scope); err != nil {
+		h.Logger.GetLogger().Error("Failed to delete onboarding template", zap.String("id", id), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to delete onboarding template")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "onboarding template deleted successfully"})
+}
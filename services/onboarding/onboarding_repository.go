@@ -0,0 +1,123 @@
+package onboardingservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type OnboardingRepository interface {
+	InsertTemplate(ctx context.Context, req CreateTemplateReq, createdBy uuid.UUID) (uuid.UUID, error)
+	InsertTemplateItems(ctx context.Context, templateID uuid.UUID, items []models.OnboardingKitItem) error
+	// GetTemplates and ArchiveTemplate only reach the templates of organizationID when it is set, a
+	// template takes the organization of its creator on insert
+	GetTemplates(ctx context.Context, organizationID *uuid.UUID) ([]models.OnboardingTemplate, error)
+	ArchiveTemplate(ctx context.Context, templateID uuid.UUID, organizationID *uuid.UUID) error
+	IsRoleExists(ctx context.Context, role string) (bool, error)
+}
+
+type PostgresOnboardingRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewOnboardingRepository(db *sqlx.DB, log providers.ZapLoggerProvider) OnboardingRepository {
+	return &PostgresOnboardingRepository{DB: db, Logger: log}
+}
+
+func (r *PostgresOnboardingRepository) InsertTemplate(ctx context.Context, req CreateTemplateReq, createdBy uuid.UUID) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &id, `
+		INSERT INTO onboarding_templates (name, employee_type, role, department_id, created_by)
+		VALUES ($1, $2, NULLIF($3, ''), $4, $5)
+		RETURNING id
+	`, req.Name, req.EmployeeType, req.Role, req.DepartmentID, createdBy)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert onboarding template", zap.String("name", req.Name), zap.Error(err))
+		return uuid.Nil, fmt.Errorf("failed to insert onboarding template: %w", err)
+	}
+	return id, nil
+}
+
+func (r *PostgresOnboardingRepository) InsertTemplateItems(ctx context.Context, templateID uuid.UUID, items []models.OnboardingKitItem) error {
+	for _, item := range items {
+		_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+			INSERT INTO onboarding_template_items (template_id, asset_type, quantity)
+			VALUES ($1, $2, $3)
+		`, templateID, item.AssetType, item.Quantity)
+		if err != nil {
+			r.Logger.GetLogger().Error("failed to insert onboarding template item", zap.String("template_id", templateID.String()), zap.String("asset_type", item.AssetType), zap.Error(err))
+			return fmt.Errorf("failed to insert onboarding template item: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *PostgresOnboardingRepository) GetTemplates(ctx context.Context, organizationID *uuid.UUID) ([]models.OnboardingTemplate, error) {
+	templates := make([]models.OnboardingTemplate, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &templates, `
+		SELECT id, name, employee_type, role, department_id, organization_id, created_at
+		FROM onboarding_templates
+		WHERE archived_at IS NULL AND ($1::uuid IS NULL OR organization_id = $1)
+		ORDER BY name
+	`, organizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch onboarding templates", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch onboarding templates: %w", err)
+	}
+
+	var rows []templateItemRow
+	err = utils.Conn(ctx, r.DB).SelectContext(ctx, &rows, `
+		SELECT i.template_id, i.asset_type, i.quantity
+		FROM onboarding_template_items i
+		JOIN onboarding_templates t ON t.id = i.template_id AND t.archived_at IS NULL
+		WHERE $1::uuid IS NULL OR t.organization_id = $1
+		ORDER BY i.asset_type
+	`, organizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch onboarding template items", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch onboarding template items: %w", err)
+	}
+
+	items := make(map[uuid.UUID][]models.OnboardingKitItem)
+	for _, row := range rows {
+		items[row.TemplateID] = append(items[row.TemplateID], models.OnboardingKitItem{AssetType: row.AssetType, Quantity: row.Quantity})
+	}
+	for i := range templates {
+		templates[i].Items = items[templates[i].ID]
+	}
+	return templates, nil
+}
+
+func (r *PostgresOnboardingRepository) ArchiveTemplate(ctx context.Context, templateID uuid.UUID, organizationID *uuid.UUID) error {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE onboarding_templates SET archived_at = now()
+		WHERE id = $1 AND archived_at IS NULL AND ($2::uuid IS NULL OR organization_id = $2)
+	`, templateID, organizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to archive onboarding template", zap.String("template_id", templateID.String()), zap.Error(err))
+		return fmt.Errorf("failed to archive onboarding template: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrTemplateNotFound
+	}
+	return nil
+}
+
+func (r *PostgresOnboardingRepository) IsRoleExists(ctx context.Context, role string) (bool, error) {
+	var exists bool
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &exists, `
+		SELECT EXISTS(SELECT 1 FROM roles WHERE name = $1 AND archived_at IS NULL)
+	`, role)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to check role existence", zap.String("role", role), zap.Error(err))
+		return false, fmt.Errorf("failed to check role existence: %w", err)
+	}
+	return exists, nil
+}
@@ -0,0 +1,69 @@
+//go:build integration
+
+package onboardingservice
+
+import (
+	"asset/database/seed"
+	"asset/database/testdb"
+	"asset/models"
+	"asset/providers"
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTemplatesOtherOrganization(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewOnboardingRepository(db, logger)
+	ctx := context.Background()
+
+	var other, otherAdminID uuid.UUID
+	require.NoError(t, db.GetContext(ctx, &other, `
+		INSERT INTO organizations (name, slug) VALUES ('Other', 'other') RETURNING id
+	`))
+	require.NoError(t, db.GetContext(ctx, &otherAdminID, `
+		INSERT INTO users (username, email, organization_id) VALUES ('Other Admin', 'admin@other.com', $1) RETURNING id
+	`, other))
+
+	req := CreateTemplateReq{Name: "Engineer", EmployeeType: "full_time"}
+	items := []models.OnboardingKitItem{{AssetType: "laptop", Quantity: 1}}
+	otherID, err := repo.InsertTemplate(ctx, req, otherAdminID)
+	require.NoError(t, err)
+	require.NoError(t, repo.InsertTemplateItems(ctx, otherID, items))
+	// the same name in another organization
+	defaultID, err := repo.InsertTemplate(ctx, req, seed.ID("user:admin"))
+	require.NoError(t, err)
+
+	templates, err := repo.GetTemplates(ctx, &other)
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+	assert.Equal(t, otherID, templates[0].ID)
+	assert.Equal(t, other, templates[0].OrganizationID)
+	assert.Equal(t, items, templates[0].Items)
+
+	templates, err = repo.GetTemplates(ctx, &models.DefaultOrganizationID)
+	require.NoError(t, err)
+	for _, template := range templates {
+		assert.NotEqual(t, otherID, template.ID)
+	}
+	templates, err = repo.GetTemplates(ctx, nil)
+	require.NoError(t, err)
+	ids := make([]uuid.UUID, 0, len(templates))
+	for _, template := range templates {
+		ids = append(ids, template.ID)
+	}
+	assert.Subset(t, ids, []uuid.UUID{otherID, defaultID})
+
+	assert.ErrorIs(t, repo.ArchiveTemplate(ctx, otherID, &models.DefaultOrganizationID), ErrTemplateNotFound)
+	assert.NoError(t, repo.ArchiveTemplate(ctx, otherID, &other))
+}
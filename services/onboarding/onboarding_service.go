@@ -0,0 +1,76 @@
+package onboardingservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type OnboardingService interface {
+	CreateTemplate(ctx context.Context, req CreateTemplateReq, adminID uuid.UUID) (uuid.UUID, error)
+	GetTemplates(ctx context.Context, scope models.DepartmentScope) ([]models.OnboardingTemplate, error)
+	DeleteTemplate(ctx context.Context, templateID uuid.UUID, scope models.DepartmentScope) error
+}
+
+type onboardingServiceStruct struct {
+	repo   OnboardingRepository
+	db     *sqlx.DB
+	logger providers.ZapLoggerProvider
+}
+
+var (
+	ErrTemplateNotFound = models.NewServiceError(http.StatusNotFound, "onboarding_template_not_found", "onboarding template not found")
+	ErrDuplicateKitItem = models.NewServiceError(http.StatusBadRequest, "duplicate_kit_item", "duplicate asset type in kit")
+	ErrUnknownRole      = models.NewServiceError(http.StatusBadRequest, "unknown_role", "role does not exist")
+)
+
+func NewOnboardingService(repo OnboardingRepository, db *sqlx.DB, logger providers.ZapLoggerProvider) OnboardingService {
+	return &onboardingServiceStruct{repo: repo, db: db, logger: logger}
+}
+
+func (s *onboardingServiceStruct) CreateTemplate(ctx context.Context, req CreateTemplateReq, adminID uuid.UUID) (id uuid.UUID, err error) {
+	seen := make(map[string]bool)
+	for _, item := range req.Items {
+		if seen[item.AssetType] {
+			return uuid.Nil, ErrDuplicateKitItem.WithDetails(map[string]string{"asset_type": item.AssetType})
+		}
+		seen[item.AssetType] = true
+	}
+	if req.Role != "" {
+		exists, err := s.repo.IsRoleExists(ctx, req.Role)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		if !exists {
+			return uuid.Nil, ErrUnknownRole.WithDetails(map[string]string{"role": req.Role})
+		}
+	}
+
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		var err error
+		if id, err = s.repo.InsertTemplate(ctx, req, adminID); err != nil {
+			return err
+		}
+		return s.repo.InsertTemplateItems(ctx, id, req.Items)
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	s.logger.GetLogger().Info("onboarding template created", zap.String("templateID", id.String()), zap.String("name", req.Name))
+	return id, nil
+}
+
+func (s *onboardingServiceStruct) GetTemplates(ctx context.Context, scope models.DepartmentScope) ([]models.OnboardingTemplate, error) {
+	return s.repo.GetTemplates(ctx, scope.OrganizationID)
+}
+
+// DeleteTemplate treats a template of another organization as missing
+func (s *onboardingServiceStruct) DeleteTemplate(ctx context.Context, templateID uuid.UUID, scope models.DepartmentScope) error {
+	return s.repo.ArchiveTemplate(ctx, templateID, scope.OrganizationID)
+}
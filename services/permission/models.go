@@ -0,0 +1,126 @@
+package permissionservice
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type PermissionRes struct {
+	Name        string  `json:"name" db:"name"`
+	Description *string `json:"description,omitempty" db:"description"`
+}
+
+type RolePermissionRow struct {
+	Role       string `db:"role"`
+	Permission string `db:"permission"`
+}
+
+// permission matrix, role -> granted permissions
+type PermissionMatrixRes struct {
+	Permissions []PermissionRes     `json:"permissions"`
+	Roles       map[string][]string `json:"roles"`
+}
+
+type UserPermissionsRes struct {
+	UserID         string   `json:"user_id"`
+	Roles          []string `json:"roles"`
+	DelegatedRoles []string `json:"delegated_roles"`
+	Permissions    []string `json:"permissions"`
+}
+
+// basic profile of the caller, returned with /me
+type MeProfile struct {
+	Username       string     `json:"username" db:"username"`
+	Email          string     `json:"email" db:"email"`
+	Type           *string    `json:"type,omitempty" db:"type"`
+	DepartmentID   *uuid.UUID `json:"department_id,omitempty" db:"department_id"`
+	DepartmentName *string    `json:"department_name,omitempty" db:"department_name"`
+}
+
+type MeRes struct {
+	UserPermissionsRes
+	TokenExpiresAt time.Time `json:"token_expires_at"`
+	Profile        MeProfile `json:"profile"`
+}
+
+type RoleRes struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Description *string   `json:"description,omitempty" db:"description"`
+	IsSystem    bool      `json:"is_system" db:"is_system"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	Permissions []string  `json:"permissions"`
+}
+
+type CreateRoleReq struct {
+	Name        string   `json:"name" validate:"required,min=3,max=50"`
+	Description string   `json:"description,omitempty"`
+	Permissions []string `json:"permissions" validate:"required,min=1,dive,required"`
+}
+
+type UpdateRoleReq struct {
+	Name        string   `json:"name" validate:"required"`
+	Description *string  `json:"description,omitempty"`
+	Permissions []string `json:"permissions,omitempty" validate:"omitempty,dive,required"`
+}
+
+const (
+	RoleUpdateApplied         = "applied"
+	RoleUpdatePendingApproval = "pending_approval"
+)
+
+// UpdateRoleRes says whether the new permissions apply or wait for a second admin
+type UpdateRoleRes struct {
+	Status     string     `json:"status"`
+	ApprovalID *uuid.UUID `json:"approval_id,omitempty"`
+}
+
+// role update approvals, privileged permissions for a role in use only apply once another admin approved them
+const (
+	RoleUpdatePending  = "pending"
+	RoleUpdateApproved = "approved"
+	RoleUpdateRejected = "rejected"
+)
+
+type RoleUpdateApprovalRes struct {
+	ID                  uuid.UUID      `json:"id" db:"id"`
+	Role                string         `json:"role" db:"role"`
+	Permissions         pq.StringArray `json:"permissions" db:"permissions"`
+	PreviousPermissions pq.StringArray `json:"previous_permissions" db:"previous_permissions"`
+	Status              string         `json:"status" db:"status"`
+	RequestedBy         uuid.UUID      `json:"requested_by" db:"requested_by"`
+	RequestedAt         time.Time      `json:"requested_at" db:"requested_at"`
+	DecidedBy           *uuid.UUID     `json:"decided_by,omitempty" db:"decided_by"`
+	DecidedAt           *time.Time     `json:"decided_at,omitempty" db:"decided_at"`
+	DecisionNote        *string        `json:"decision_note,omitempty" db:"decision_note"`
+}
+
+type RoleUpdateDecisionReq struct {
+	ID   string `json:"id" validate:"required,uuid"`
+	Note string `json:"note" validate:"max=500"`
+}
+
+type CreateDelegationReq struct {
+	DelegateID string     `json:"delegate_id" validate:"required,uuid"`
+	Role       string     `json:"role" validate:"required"`
+	StartsAt   *time.Time `json:"starts_at,omitempty"`
+	EndsAt     time.Time  `json:"ends_at" validate:"required"`
+	Reason     string     `json:"reason,omitempty" validate:"max=255"`
+}
+
+type DelegationRes struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	DelegatorID   uuid.UUID  `json:"delegator_id" db:"delegator_id"`
+	DelegatorName *string    `json:"delegator_name,omitempty" db:"delegator_name"`
+	DelegateID    uuid.UUID  `json:"delegate_id" db:"delegate_id"`
+	DelegateName  *string    `json:"delegate_name,omitempty" db:"delegate_name"`
+	Role          string     `json:"role" db:"role"`
+	Reason        *string    `json:"reason,omitempty" db:"reason"`
+	StartsAt      time.Time  `json:"starts_at" db:"starts_at"`
+	EndsAt        time.Time  `json:"ends_at" db:"ends_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	ExpiredAt     *time.Time `json:"expired_at,omitempty" db:"expired_at"`
+}
@@ -0,0 +1,482 @@
+package permissionservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type PermissionHandler struct {
+	Service        PermissionService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewPermissionHandler(service PermissionService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *PermissionHandler {
+	return &PermissionHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+// GetMyPermissions returns the permissions granted to the caller's roles, so clients can hide actions they can't perform
+func (h *PermissionHandler) GetMyPermissions(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetMyPermissions request received")
+	userID, roles, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in GetMyPermissions", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in GetMyPermissions", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+	delegatedRoles, err := h.Service.GetDelegatedRoles(r.Context(), userUUID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch delegated roles", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch permissions")
+		return
+	}
+
+	permissions, err := h.Service.GetPermissionsByRoles(r.Context(), append(slices.Clone(roles), delegatedRoles...))
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch permissions", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch permissions")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, UserPermissionsRes{
+		UserID:         userID,
+		Roles:          roles,
+		DelegatedRoles: delegatedRoles,
+		Permissions:    permissions,
+	})
+}
+
+// GetMe describes the authenticated caller, so clients don't need to decode the access token themselves
+func (h *PermissionHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetMe request received")
+	userID, roles, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in GetMe", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	expiresAt, err := h.AuthMiddleware.GetTokenExpiryFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Token expiry missing in GetMe", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in GetMe", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+	profile, err := h.Service.GetUserProfile(r.Context(), userUUID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch user profile", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch user profile")
+		return
+	}
+	delegatedRoles, err := h.Service.GetDelegatedRoles(r.Context(), userUUID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch delegated roles", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch permissions")
+		return
+	}
+	permissions, err := h.Service.GetPermissionsByRoles(r.Context(), append(slices.Clone(roles), delegatedRoles...))
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch permissions", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch permissions")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, MeRes{
+		UserPermissionsRes: UserPermissionsRes{
+			UserID:         userID,
+			Roles:          roles,
+			DelegatedRoles: delegatedRoles,
+			Permissions:    permissions,
+		},
+		TokenExpiresAt: expiresAt,
+		Profile:        profile,
+	})
+}
+
+func (h *PermissionHandler) GetPermissionMatrix(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetPermissionMatrix request received")
+	matrix, err := h.Service.GetPermissionMatrix(r.Context())
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch permission matrix", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch permission matrix")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, matrix)
+}
+
+func (h *PermissionHandler) GetRoles(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetRoles request received")
+	roles, err := h.Service.GetRoles(r.Context())
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch roles", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch roles")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"roles": roles})
+}
+
+// GetEnums lists the values accepted for roles, employee types and the asset enums so clients don't
+// keep their own copies
+func (h *PermissionHandler) GetEnums(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.Service.GetRoles(r.Context())
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch roles for enums", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch roles")
+		return
+	}
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = role.Name
+	}
+	utils.RespondJSON(w, http.StatusOK, models.EnumsRes{
+		Roles:              names,
+		EmployeeTypes:      models.EmployeeTypes,
+		AssetTypes:         models.AssetTypes,
+		AssetStatuses:      models.AssetStatuses,
+		Ownerships:         models.AssetOwnerships,
+		HandoverConditions: models.HandoverConditions,
+	})
+}
+
+func (h *PermissionHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("CreateRole request received")
+	adminID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in CreateRole", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	var req CreateRoleReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in CreateRole", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in CreateRole", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	adminUUID, err := uuid.Parse(adminID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse adminID in CreateRole", zap.String("adminID", adminID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	roleID, err := h.Service.CreateRole(r.Context(), req, adminUUID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to create role", zap.String("role", req.Name), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to create role")
+		return
+	}
+	h.Logger.GetLogger().Info("Role created successfully", zap.String("role", req.Name))
+	h.reloadPolicies(r, "CreateRole")
+	w.WriteHeader(http.StatusCreated)
+	utils.JSON.NewEncoder(w).Encode(map[string]interface{}{"message": "role created successfully", "role_id": roleID})
+}
+
+func (h *PermissionHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("UpdateRole request received")
+	adminID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in UpdateRole", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	var req UpdateRoleReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in UpdateRole", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in UpdateRole", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	adminUUID, err := uuid.Parse(adminID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse adminID in UpdateRole", zap.String("adminID", adminID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	res, err := h.Service.UpdateRole(r.Context(), req, adminUUID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to update role", zap.String("role", req.Name), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to update role")
+		return
+	}
+	if res.Status == RoleUpdatePendingApproval {
+		h.Logger.GetLogger().Info("Role update waiting for approval", zap.String("role", req.Name), zap.String("approvalID", res.ApprovalID.String()))
+		utils.RespondJSON(w, http.StatusAccepted, map[string]interface{}{"message": "role update needs approval from another admin", "status": res.Status, "approval_id": res.ApprovalID})
+		return
+	}
+	h.Logger.GetLogger().Info("Role updated successfully", zap.String("role", req.Name))
+	h.reloadPolicies(r, "UpdateRole")
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "role updated successfully", "status": res.Status})
+}
+
+// GetRoleUpdateApprovals lists privileged role updates, pending ones unless ?status= asks for another state or "all"
+func (h *PermissionHandler) GetRoleUpdateApprovals(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetRoleUpdateApprovals request received")
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = RoleUpdatePending
+	case "all":
+		status = ""
+	case RoleUpdatePending, RoleUpdateApproved, RoleUpdateRejected:
+	default:
+		utils.RespondError(w, http.StatusBadRequest, fmt.Errorf("unknown status: %s", status), "invalid status")
+		return
+	}
+	limit, offset := utils.GetPageLimitAndOffset(r)
+	approvals, err := h.Service.GetRoleUpdateApprovals(r.Context(), status, limit, offset)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch role update approvals", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch role update approvals")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"approvals": approvals, "limit": limit, "offset": offset})
+}
+
+func (h *PermissionHandler) ApproveRoleUpdate(w http.ResponseWriter, r *http.Request) {
+	if h.decideRoleUpdate(w, r, "ApproveRoleUpdate", h.Service.ApproveRoleUpdate, "role update approved") {
+		h.reloadPolicies(r, "ApproveRoleUpdate")
+	}
+}
+
+func (h *PermissionHandler) RejectRoleUpdate(w http.ResponseWriter, r *http.Request) {
+	h.decideRoleUpdate(w, r, "RejectRoleUpdate", h.Service.RejectRoleUpdate, "role update rejected")
+}
+
+// decideRoleUpdate reports whether the decision was recorded, the response is written either way
+func (h *PermissionHandler) decideRoleUpdate(w http.ResponseWriter, r *http.Request, handler string, decide func(ctx context.Context, id, adminID uuid.UUID, note string) error, message string) bool {
+	h.Logger.GetLogger().Info(handler + " request received")
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return false
+	}
+	adminID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in "+handler, zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return false
+	}
+	var req RoleUpdateDecisionReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input body in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input body")
+		return false
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return false
+	}
+	if err := decide(r.Context(), uuid.MustParse(req.ID), adminID, req.Note); err != nil {
+		h.Logger.GetLogger().Error("Failed to decide role update in "+handler, zap.String("id", req.ID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to decide role update")
+		return false
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": message})
+	return true
+}
+
+func (h *PermissionHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("DeleteRole request received")
+	adminID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in DeleteRole", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		h.Logger.GetLogger().Error("Missing name in DeleteRole request")
+		utils.RespondError(w, http.StatusBadRequest, fmt.Errorf("name is required"), "invalid role name")
+		return
+	}
+
+	adminUUID, err := uuid.Parse(adminID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse adminID in DeleteRole", zap.String("adminID", adminID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	if err := h.Service.DeleteRole(r.Context(), name, adminUUID); err != nil {
+		h.Logger.GetLogger().Error("Failed to delete role", zap.String("role", name), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to delete role")
+		return
+	}
+	h.Logger.GetLogger().Info("Role deleted successfully", zap.String("role", name))
+	h.reloadPolicies(r, "DeleteRole")
+	w.WriteHeader(http.StatusOK)
+	utils.JSON.NewEncoder(w).Encode(map[string]string{"message": "role deleted successfully"})
+}
+
+func (h *PermissionHandler) CreateDelegation(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("CreateDelegation request received")
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in CreateDelegation", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	var req CreateDelegationReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in CreateDelegation", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in CreateDelegation", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in CreateDelegation", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	id, err := h.Service.CreateDelegation(r.Context(), req, userUUID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to create delegation", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to create delegation")
+		return
+	}
+	h.Logger.GetLogger().Info("Delegation created", zap.String("id", id.String()))
+	w.WriteHeader(http.StatusCreated)
+	utils.JSON.NewEncoder(w).Encode(map[string]interface{}{"message": "role delegated successfully", "id": id})
+}
+
+func (h *PermissionHandler) GetMyDelegations(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetMyDelegations request received")
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in GetMyDelegations", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in GetMyDelegations", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+	delegations, err := h.Service.GetDelegations(r.Context(), userUUID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch delegations", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch delegations")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"delegations": delegations})
+}
+
+// RevokeDelegation ends one of the caller's own delegations
+func (h *PermissionHandler) RevokeDelegation(w http.ResponseWriter, r *http.Request) {
+	h.revokeDelegation(w, r, false)
+}
+
+// RevokeAnyDelegation ends a delegation made by anyone, it is only mounted under the admin routes
+func (h *PermissionHandler) RevokeAnyDelegation(w http.ResponseWriter, r *http.Request) {
+	h.revokeDelegation(w, r, true)
+}
+
+func (h *PermissionHandler) revokeDelegation(w http.ResponseWriter, r *http.Request, anyDelegator bool) {
+	h.Logger.GetLogger().Info("RevokeDelegation request received", zap.Bool("anyDelegator", anyDelegator))
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in RevokeDelegation", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	id := r.URL.Query().Get("id")
+	delegationID, err := uuid.Parse(id)
+	if err != nil {
+		h.Logger.GetLogger().Error("Invalid id in RevokeDelegation", zap.String("id", id), zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid id")
+		return
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in RevokeDelegation", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in RevokeDelegation", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	if err := h.Service.RevokeDelegation(r.Context(), delegationID, userUUID, anyDelegator, scope); err != nil {
+		h.Logger.GetLogger().Error("Failed to revoke delegation", zap.String("id", id), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to revoke delegation")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	utils.JSON.NewEncoder(w).Encode(map[string]string{"message": "delegation revoked successfully"})
+}
+
+// ReloadPolicies re-reads the role permissions into the policy engine, for changes made
+// directly in the database or through another instance that shouldn't wait for the periodic reload
+func (h *PermissionHandler) ReloadPolicies(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("ReloadPolicies request received")
+	summary, err := h.AuthMiddleware.ReloadPolicies(r.Context())
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to reload policies", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to reload policies")
+		return
+	}
+	h.Logger.GetLogger().Info("Policies reloaded", zap.Int("roles", summary.Roles), zap.Int("grants", summary.Grants))
+	utils.RespondJSON(w, http.StatusOK, summary)
+}
+
+// reloadPolicies makes a role change take effect on this instance right away, the change is
+// already committed so a failure only delays it until the next periodic reload
+func (h *PermissionHandler) reloadPolicies(r *http.Request, handlerName string) {
+	if _, err := h.AuthMiddleware.ReloadPolicies(r.Context()); err != nil {
+		h.Logger.GetLogger().Warn("Failed to reload policies after "+handlerName, zap.Error(err))
+	}
+}
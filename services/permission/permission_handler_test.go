@@ -0,0 +1,46 @@
+package permissionservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// a role still held by users is a conflict the caller can resolve, not a server error
+func TestDeleteRoleHandlerRoleInUse(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	adminID := uuid.New()
+	repo := NewMockPermissionRepository(ctrl)
+	repo.EXPECT().GetRoleByName(gomock.Any(), "asset_clerk").Return(RoleRes{Name: "asset_clerk"}, nil)
+	repo.EXPECT().GetPermissionsByRoles(gomock.Any(), []string{"asset_clerk"}).Return([]string{"asset.manage"}, nil)
+	repo.EXPECT().GetUserPermissions(gomock.Any(), adminID).Return(orgAdminPermissions, nil)
+	repo.EXPECT().CountUsersWithRole(gomock.Any(), "asset_clerk").Return(2, nil)
+	auth := providers.NewMockAuthMiddlewareService(ctrl)
+	auth.EXPECT().GetUserAndRolesFromContext(gomock.Any()).Return(adminID.String(), []string{"org_admin"}, nil)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	handler := NewPermissionHandler(&permissionServiceStruct{repo: repo, logger: logger}, auth, logger)
+	req := httptest.NewRequest(http.MethodDelete, "/api/admin/roles/remove?name=asset_clerk", nil)
+	rr := httptest.NewRecorder()
+	handler.DeleteRole(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	var body struct {
+		utils.ClientError
+		Details map[string]int `json:"details"`
+	}
+	require.NoError(t, utils.JSON.NewDecoder(rr.Body).Decode(&body))
+	assert.Equal(t, "role_in_use", body.Code)
+	assert.Equal(t, 2, body.Details["users"])
+}
@@ -0,0 +1,514 @@
+package permissionservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type PermissionRepository interface {
+	GetAllPermissions(ctx context.Context) ([]PermissionRes, error)
+	GetRolePermissions(ctx context.Context) ([]RolePermissionRow, error)
+	GetPermissionsByRoles(ctx context.Context, roles []string) ([]string, error)
+	// GetUserPermissions returns what the user's own roles grant, delegated roles aren't counted
+	GetUserPermissions(ctx context.Context, userID uuid.UUID) ([]string, error)
+	GetAllRoles(ctx context.Context) ([]RoleRes, error)
+	GetRoleByName(ctx context.Context, name string) (RoleRes, error)
+	CountExistingPermissions(ctx context.Context, permissions []string) (int, error)
+	CountUsersWithRole(ctx context.Context, name string) (int, error)
+	// IsRoleInUse reports whether anyone holds the role, has it delegated or is waiting to be given it
+	IsRoleInUse(ctx context.Context, name string) (bool, error)
+	InsertRole(ctx context.Context, req CreateRoleReq, createdBy uuid.UUID) (uuid.UUID, error)
+	UpdateRoleDescription(ctx context.Context, name string, description *string, updatedBy uuid.UUID) error
+	ReplaceRolePermissions(ctx context.Context, name string, permissions []string, createdBy uuid.UUID) error
+	ArchiveRole(ctx context.Context, name string, archivedBy uuid.UUID) error
+	InsertRoleUpdateApproval(ctx context.Context, role string, permissions, previousPermissions []string, requestedBy uuid.UUID) (uuid.UUID, error)
+	HasPendingRoleUpdate(ctx context.Context, role string) (bool, error)
+	GetRoleUpdateApprovals(ctx context.Context, status string, limit, offset int) ([]RoleUpdateApprovalRes, error)
+	GetRoleUpdateApprovalForUpdate(ctx context.Context, id uuid.UUID) (RoleUpdateApprovalRes, error)
+	DecideRoleUpdateApproval(ctx context.Context, id uuid.UUID, status string, decidedBy uuid.UUID, note string) error
+	UserHasRole(ctx context.Context, userID uuid.UUID, role string) (bool, error)
+	// IsActiveColleague reports whether the user is active and in the same organization as colleagueID
+	IsActiveColleague(ctx context.Context, userID, colleagueID uuid.UUID) (bool, error)
+	GetUserProfile(ctx context.Context, userID uuid.UUID) (MeProfile, error)
+	InsertDelegation(ctx context.Context, delegatorID, delegateID uuid.UUID, req CreateDelegationReq) (uuid.UUID, error)
+	// GetDelegationByID only finds delegations made by users of organizationID when it is set
+	GetDelegationByID(ctx context.Context, id uuid.UUID, organizationID *uuid.UUID) (DelegationRes, error)
+	GetDelegationsForUser(ctx context.Context, userID uuid.UUID) ([]DelegationRes, error)
+	RevokeDelegation(ctx context.Context, id, revokedBy uuid.UUID) error
+	GetActiveDelegatedRoles(ctx context.Context, userID uuid.UUID) ([]string, error)
+	ExpireDelegations(ctx context.Context) ([]DelegationRes, error)
+}
+
+type PostgresPermissionRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewPermissionRepository(db *sqlx.DB, log providers.ZapLoggerProvider) PermissionRepository {
+	return &PostgresPermissionRepository{DB: db, Logger: log}
+}
+
+func (r *PostgresPermissionRepository) GetAllPermissions(ctx context.Context) ([]PermissionRes, error) {
+	r.Logger.GetLogger().Info("fetching all permissions")
+	permissions := make([]PermissionRes, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &permissions, `
+		SELECT name, description FROM permissions
+		ORDER BY name
+	`)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch permissions", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch permissions: %w", err)
+	}
+	return permissions, nil
+}
+
+func (r *PostgresPermissionRepository) GetRolePermissions(ctx context.Context) ([]RolePermissionRow, error) {
+	r.Logger.GetLogger().Info("fetching role permission matrix")
+	rows := make([]RolePermissionRow, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &rows, `
+		SELECT role, permission FROM role_permissions
+		WHERE archived_at IS NULL
+		ORDER BY role, permission
+	`)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch role permissions", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch role permissions: %w", err)
+	}
+	return rows, nil
+}
+
+func (r *PostgresPermissionRepository) GetPermissionsByRoles(ctx context.Context, roles []string) ([]string, error) {
+	r.Logger.GetLogger().Info("fetching permissions for roles", zap.Strings("roles", roles))
+	permissions := make([]string, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &permissions, `
+		SELECT DISTINCT permission FROM role_permissions
+		WHERE role = ANY($1) AND archived_at IS NULL
+		ORDER BY permission
+	`, pq.Array(roles))
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch permissions for roles", zap.Strings("roles", roles), zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch permissions for roles: %w", err)
+	}
+	return permissions, nil
+}
+
+func (r *PostgresPermissionRepository) GetUserPermissions(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	permissions := make([]string, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &permissions, `
+		SELECT DISTINCT rp.permission FROM user_roles ur
+		JOIN role_permissions rp ON rp.role = ur.role AND rp.archived_at IS NULL
+		WHERE ur.user_id = $1 AND ur.archived_at IS NULL
+		ORDER BY rp.permission
+	`, userID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch user permissions", zap.String("user_id", userID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch user permissions: %w", err)
+	}
+	return permissions, nil
+}
+
+func (r *PostgresPermissionRepository) GetAllRoles(ctx context.Context) ([]RoleRes, error) {
+	r.Logger.GetLogger().Info("fetching all roles")
+	roles := make([]RoleRes, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &roles, `
+		SELECT id, name, description, is_system, created_at FROM roles
+		WHERE archived_at IS NULL
+		ORDER BY is_system DESC, name
+	`)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch roles", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch roles: %w", err)
+	}
+	return roles, nil
+}
+
+func (r *PostgresPermissionRepository) GetRoleByName(ctx context.Context, name string) (RoleRes, error) {
+	r.Logger.GetLogger().Info("fetching role by name", zap.String("role", name))
+	var role RoleRes
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &role, `
+		SELECT id, name, description, is_system, created_at FROM roles
+		WHERE name = $1 AND archived_at IS NULL
+	`, name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.Logger.GetLogger().Warn("role not found", zap.String("role", name))
+			return role, fmt.Errorf("%w: %s", ErrRoleNotFound, name)
+		}
+		r.Logger.GetLogger().Error("failed to fetch role", zap.String("role", name), zap.Error(err))
+		return role, fmt.Errorf("failed to fetch role: %w", err)
+	}
+	return role, nil
+}
+
+func (r *PostgresPermissionRepository) CountExistingPermissions(ctx context.Context, permissions []string) (int, error) {
+	var count int
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &count, `
+		SELECT COUNT(*) FROM permissions WHERE name = ANY($1)
+	`, pq.Array(permissions))
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to validate permissions", zap.Strings("permissions", permissions), zap.Error(err))
+		return 0, fmt.Errorf("failed to validate permissions: %w", err)
+	}
+	return count, nil
+}
+
+func (r *PostgresPermissionRepository) CountUsersWithRole(ctx context.Context, name string) (int, error) {
+	var count int
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &count, `
+		SELECT COUNT(*) FROM user_roles WHERE role = $1 AND archived_at IS NULL
+	`, name)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to count users with role", zap.String("role", name), zap.Error(err))
+		return 0, fmt.Errorf("failed to count users with role: %w", err)
+	}
+	return count, nil
+}
+
+func (r *PostgresPermissionRepository) IsRoleInUse(ctx context.Context, name string) (bool, error) {
+	var inUse bool
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &inUse, `
+		SELECT EXISTS (SELECT 1 FROM user_roles WHERE role = $1 AND archived_at IS NULL)
+			OR EXISTS (SELECT 1 FROM role_delegations WHERE role = $1 AND revoked_at IS NULL AND expired_at IS NULL)
+			OR EXISTS (SELECT 1 FROM scheduled_role_changes WHERE role = $1 AND status = 'pending')
+			OR EXISTS (SELECT 1 FROM role_grant_approvals WHERE role = $1 AND status = 'pending')
+	`, name)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to check whether the role is in use", zap.String("role", name), zap.Error(err))
+		return false, fmt.Errorf("failed to check whether the role is in use: %w", err)
+	}
+	return inUse, nil
+}
+
+func (r *PostgresPermissionRepository) InsertRole(ctx context.Context, req CreateRoleReq, createdBy uuid.UUID) (uuid.UUID, error) {
+	r.Logger.GetLogger().Info("inserting new role", zap.String("role", req.Name), zap.String("created_by", createdBy.String()))
+	var roleID uuid.UUID
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &roleID, `
+		INSERT INTO roles (name, description, created_by)
+		VALUES ($1, NULLIF($2, ''), $3)
+		RETURNING id
+	`, req.Name, req.Description, createdBy)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert role", zap.String("role", req.Name), zap.Error(err))
+		return uuid.Nil, fmt.Errorf("failed to insert role: %w", err)
+	}
+	return roleID, nil
+}
+
+func (r *PostgresPermissionRepository) UpdateRoleDescription(ctx context.Context, name string, description *string, updatedBy uuid.UUID) error {
+	r.Logger.GetLogger().Info("updating role description", zap.String("role", name))
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE roles SET description = $2, updated_at = now(), updated_by = $3
+		WHERE name = $1 AND archived_at IS NULL
+	`, name, description, updatedBy)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to update role", zap.String("role", name), zap.Error(err))
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresPermissionRepository) ReplaceRolePermissions(ctx context.Context, name string, permissions []string, createdBy uuid.UUID) error {
+	r.Logger.GetLogger().Info("replacing role permissions", zap.String("role", name), zap.Strings("permissions", permissions))
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE role_permissions SET archived_at = now()
+		WHERE role = $1 AND archived_at IS NULL
+	`, name)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to archive role permissions", zap.String("role", name), zap.Error(err))
+		return fmt.Errorf("failed to archive role permissions: %w", err)
+	}
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO role_permissions (role, permission, created_by)
+		SELECT $1, UNNEST($2::text[]), $3
+	`, name, pq.Array(permissions), createdBy)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert role permissions", zap.String("role", name), zap.Error(err))
+		return fmt.Errorf("failed to insert role permissions: %w", err)
+	}
+	return nil
+}
+
+// ArchiveRole also ends what is still pending for the role, rows only name the role so a new role
+// with the same name would otherwise pick up the delegations, scheduled changes and grant requests
+func (r *PostgresPermissionRepository) ArchiveRole(ctx context.Context, name string, archivedBy uuid.UUID) error {
+	r.Logger.GetLogger().Info("archiving role", zap.String("role", name), zap.String("archived_by", archivedBy.String()))
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE role_permissions SET archived_at = now()
+		WHERE role = $1 AND archived_at IS NULL
+	`, name)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to archive role permissions", zap.String("role", name), zap.Error(err))
+		return fmt.Errorf("failed to archive role permissions: %w", err)
+	}
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE role_delegations SET revoked_at = now(), revoked_by = $2
+		WHERE role = $1 AND revoked_at IS NULL AND expired_at IS NULL
+	`, name, archivedBy)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to revoke role delegations", zap.String("role", name), zap.Error(err))
+		return fmt.Errorf("failed to revoke role delegations: %w", err)
+	}
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE scheduled_role_changes SET status = 'cancelled', cancelled_at = now()
+		WHERE role = $1 AND status = 'pending'
+	`, name)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to cancel scheduled role changes", zap.String("role", name), zap.Error(err))
+		return fmt.Errorf("failed to cancel scheduled role changes: %w", err)
+	}
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE role_grant_approvals SET status = 'rejected', decided_by = $2, decided_at = now(), decision_note = 'role deleted'
+		WHERE role = $1 AND status = 'pending'
+	`, name, archivedBy)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to reject role grant requests", zap.String("role", name), zap.Error(err))
+		return fmt.Errorf("failed to reject role grant requests: %w", err)
+	}
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE role_update_approvals SET status = 'rejected', decided_by = $2, decided_at = now(), decision_note = 'role deleted'
+		WHERE role = $1 AND status = 'pending'
+	`, name, archivedBy)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to reject role update requests", zap.String("role", name), zap.Error(err))
+		return fmt.Errorf("failed to reject role update requests: %w", err)
+	}
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE roles SET archived_at = now(), archived_by = $2, updated_at = now(), updated_by = $2
+		WHERE name = $1 AND archived_at IS NULL
+	`, name, archivedBy)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to archive role", zap.String("role", name), zap.Error(err))
+		return fmt.Errorf("failed to archive role: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresPermissionRepository) InsertRoleUpdateApproval(ctx context.Context, role string, permissions, previousPermissions []string, requestedBy uuid.UUID) (uuid.UUID, error) {
+	r.Logger.GetLogger().Info("inserting role update approval", zap.String("role", role), zap.Strings("permissions", permissions))
+	var id uuid.UUID
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &id, `
+		INSERT INTO role_update_approvals (role, permissions, previous_permissions, requested_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, role, pq.Array(permissions), pq.Array(previousPermissions), requestedBy)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert role update approval", zap.String("role", role), zap.Error(err))
+		return uuid.Nil, fmt.Errorf("failed to request role update approval: %w", err)
+	}
+	return id, nil
+}
+
+func (r *PostgresPermissionRepository) HasPendingRoleUpdate(ctx context.Context, role string) (bool, error) {
+	var pending bool
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &pending, `
+		SELECT EXISTS (
+			SELECT 1 FROM role_update_approvals WHERE role = $1 AND status = 'pending'
+		)
+	`, role)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to check pending role updates", zap.String("role", role), zap.Error(err))
+		return false, fmt.Errorf("failed to check pending role updates: %w", err)
+	}
+	return pending, nil
+}
+
+// GetRoleUpdateApprovals lists approvals newest first, an empty status lists all of them. Roles are
+// shared by every organization so there is no scope to filter by
+func (r *PostgresPermissionRepository) GetRoleUpdateApprovals(ctx context.Context, status string, limit, offset int) ([]RoleUpdateApprovalRes, error) {
+	approvals := make([]RoleUpdateApprovalRes, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &approvals, `
+		SELECT id, role, permissions, previous_permissions, status, requested_by, requested_at,
+			decided_by, decided_at, decision_note
+		FROM role_update_approvals
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY requested_at DESC
+		LIMIT $2 OFFSET $3
+	`, status, limit, offset)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch role update approvals", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch role update approvals: %w", err)
+	}
+	return approvals, nil
+}
+
+// GetRoleUpdateApprovalForUpdate locks the row so two admins deciding at once can't both succeed
+func (r *PostgresPermissionRepository) GetRoleUpdateApprovalForUpdate(ctx context.Context, id uuid.UUID) (RoleUpdateApprovalRes, error) {
+	var approval RoleUpdateApprovalRes
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &approval, `
+		SELECT id, role, permissions, previous_permissions, status, requested_by, requested_at,
+			decided_by, decided_at, decision_note
+		FROM role_update_approvals
+		WHERE id = $1
+		FOR UPDATE
+	`, id)
+	return approval, err
+}
+
+func (r *PostgresPermissionRepository) DecideRoleUpdateApproval(ctx context.Context, id uuid.UUID, status string, decidedBy uuid.UUID, note string) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE role_update_approvals
+		SET status = $2, decided_by = $3, decided_at = now(), decision_note = NULLIF($4, '')
+		WHERE id = $1 AND status = 'pending'
+	`, id, status, decidedBy, note)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to record role update decision", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to record role update decision: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresPermissionRepository) UserHasRole(ctx context.Context, userID uuid.UUID, role string) (bool, error) {
+	var exists bool
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &exists, `
+		SELECT EXISTS(SELECT 1 FROM user_roles WHERE user_id = $1 AND role = $2 AND archived_at IS NULL)
+	`, userID, role)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to check user role", zap.String("user_id", userID.String()), zap.String("role", role), zap.Error(err))
+		return false, fmt.Errorf("failed to check user role: %w", err)
+	}
+	return exists, nil
+}
+
+func (r *PostgresPermissionRepository) IsActiveColleague(ctx context.Context, userID, colleagueID uuid.UUID) (bool, error) {
+	var exists bool
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &exists, `
+		SELECT EXISTS(
+			SELECT 1 FROM users u
+			JOIN users colleague ON colleague.id = $2 AND colleague.organization_id = u.organization_id
+			WHERE u.id = $1 AND u.archived_at IS NULL
+		)
+	`, userID, colleagueID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to check user", zap.String("user_id", userID.String()), zap.Error(err))
+		return false, fmt.Errorf("failed to check user: %w", err)
+	}
+	return exists, nil
+}
+
+func (r *PostgresPermissionRepository) GetUserProfile(ctx context.Context, userID uuid.UUID) (MeProfile, error) {
+	var profile MeProfile
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &profile, `
+		SELECT u.username, u.email, ut.type, u.department_id, d.name AS department_name
+		FROM users u
+		LEFT JOIN user_type ut ON ut.user_id = u.id AND ut.archived_at IS NULL
+		LEFT JOIN departments d ON d.id = u.department_id
+		WHERE u.id = $1 AND u.archived_at IS NULL
+	`, userID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch user profile", zap.String("user_id", userID.String()), zap.Error(err))
+		return profile, fmt.Errorf("failed to fetch user profile: %w", err)
+	}
+	return profile, nil
+}
+
+func (r *PostgresPermissionRepository) InsertDelegation(ctx context.Context, delegatorID, delegateID uuid.UUID, req CreateDelegationReq) (uuid.UUID, error) {
+	r.Logger.GetLogger().Info("inserting role delegation", zap.String("delegator_id", delegatorID.String()), zap.String("delegate_id", delegateID.String()), zap.String("role", req.Role))
+	var id uuid.UUID
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &id, `
+		INSERT INTO role_delegations (delegator_id, delegate_id, role, reason, starts_at, ends_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+		RETURNING id
+	`, delegatorID, delegateID, req.Role, req.Reason, req.StartsAt, req.EndsAt)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert role delegation", zap.Error(err))
+		return uuid.Nil, fmt.Errorf("failed to insert role delegation: %w", err)
+	}
+	return id, nil
+}
+
+const delegationSelect = `
+	SELECT
+		rd.id, rd.delegator_id, delegator.username AS delegator_name,
+		rd.delegate_id, delegate.username AS delegate_name,
+		rd.role, rd.reason, rd.starts_at, rd.ends_at, rd.created_at, rd.revoked_at, rd.expired_at
+	FROM role_delegations rd
+	LEFT JOIN users delegator ON delegator.id = rd.delegator_id
+	LEFT JOIN users delegate ON delegate.id = rd.delegate_id
+`
+
+func (r *PostgresPermissionRepository) GetDelegationByID(ctx context.Context, id uuid.UUID, organizationID *uuid.UUID) (DelegationRes, error) {
+	var delegation DelegationRes
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &delegation, delegationSelect+`
+		WHERE rd.id = $1 AND ($2::uuid IS NULL OR delegator.organization_id = $2)
+	`, id, organizationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return delegation, ErrDelegationNotFound
+		}
+		r.Logger.GetLogger().Error("failed to fetch delegation", zap.String("id", id.String()), zap.Error(err))
+		return delegation, fmt.Errorf("failed to fetch delegation: %w", err)
+	}
+	return delegation, nil
+}
+
+func (r *PostgresPermissionRepository) GetDelegationsForUser(ctx context.Context, userID uuid.UUID) ([]DelegationRes, error) {
+	delegations := make([]DelegationRes, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &delegations, delegationSelect+`
+		WHERE rd.delegator_id = $1 OR rd.delegate_id = $1
+		ORDER BY rd.starts_at DESC
+	`, userID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch delegations", zap.String("user_id", userID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch delegations: %w", err)
+	}
+	return delegations, nil
+}
+
+func (r *PostgresPermissionRepository) RevokeDelegation(ctx context.Context, id, revokedBy uuid.UUID) error {
+	result, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE role_delegations SET revoked_at = now(), revoked_by = $2
+		WHERE id = $1 AND revoked_at IS NULL AND expired_at IS NULL
+	`, id, revokedBy)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to revoke delegation", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to revoke delegation: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrDelegationInactive
+	}
+	return nil
+}
+
+func (r *PostgresPermissionRepository) GetActiveDelegatedRoles(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	roles := make([]string, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &roles, `
+		SELECT DISTINCT d.role FROM role_delegations d
+		JOIN users delegator ON delegator.id = d.delegator_id AND delegator.archived_at IS NULL
+		JOIN users delegate ON delegate.id = d.delegate_id AND delegate.archived_at IS NULL
+		WHERE d.delegate_id = $1 AND d.revoked_at IS NULL AND now() BETWEEN d.starts_at AND d.ends_at
+		AND EXISTS (
+			SELECT 1 FROM user_roles ur
+			WHERE ur.user_id = d.delegator_id AND ur.role = d.role AND ur.archived_at IS NULL
+		)
+	`, userID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch delegated roles", zap.String("user_id", userID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch delegated roles: %w", err)
+	}
+	return roles, nil
+}
+
+// ExpireDelegations stamps expired_at on delegations past their end, access is already cut off by the
+// time check in the middleware, this only records when it happened
+func (r *PostgresPermissionRepository) ExpireDelegations(ctx context.Context) ([]DelegationRes, error) {
+	expired := make([]DelegationRes, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &expired, `
+		UPDATE role_delegations SET expired_at = now()
+		WHERE revoked_at IS NULL AND expired_at IS NULL AND ends_at <= now()
+		RETURNING id, delegator_id, delegate_id, role, reason, starts_at, ends_at, created_at, revoked_at, expired_at
+	`)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to expire delegations", zap.Error(err))
+		return nil, fmt.Errorf("failed to expire delegations: %w", err)
+	}
+	return expired, nil
+}
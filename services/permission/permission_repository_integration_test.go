@@ -0,0 +1,103 @@
+//go:build integration
+
+package permissionservice
+
+import (
+	"asset/database/seed"
+	"asset/database/testdb"
+	"asset/providers"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRoleCRUD(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewPermissionRepository(db, logger)
+	ctx := context.Background()
+	adminID := seed.ID("user:admin")
+
+	req := CreateRoleReq{Name: "site_lead", Description: "runs a site", Permissions: []string{"asset.manage"}}
+	roleID, err := repo.InsertRole(ctx, req, adminID)
+	require.NoError(t, err)
+	require.NoError(t, repo.ReplaceRolePermissions(ctx, req.Name, req.Permissions, adminID))
+
+	role, err := repo.GetRoleByName(ctx, req.Name)
+	require.NoError(t, err)
+	assert.Equal(t, roleID, role.ID)
+	require.NotNil(t, role.Description)
+	assert.Equal(t, "runs a site", *role.Description)
+	assert.False(t, role.IsSystem)
+	permissions, err := repo.GetPermissionsByRoles(ctx, []string{req.Name})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"asset.manage"}, permissions)
+
+	// an active name can't be taken twice
+	_, err = repo.InsertRole(ctx, req, adminID)
+	require.Error(t, err)
+
+	description := "leads a site"
+	require.NoError(t, repo.UpdateRoleDescription(ctx, req.Name, &description, adminID))
+	require.NoError(t, repo.ReplaceRolePermissions(ctx, req.Name, []string{"asset.manage", "user.manage"}, adminID))
+	role, err = repo.GetRoleByName(ctx, req.Name)
+	require.NoError(t, err)
+	assert.Equal(t, description, *role.Description)
+	permissions, err = repo.GetPermissionsByRoles(ctx, []string{req.Name})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"asset.manage", "user.manage"}, permissions)
+
+	// what is still pending for the role ends with it
+	var delegationID, changeID, approvalID uuid.UUID
+	require.NoError(t, db.Get(&delegationID, `
+		INSERT INTO role_delegations (delegator_id, delegate_id, role, starts_at, ends_at)
+		VALUES ($1, $2, $3, now(), now() + INTERVAL '1 day') RETURNING id`, adminID, seed.ID("user:developer"), req.Name))
+	require.NoError(t, db.Get(&changeID, `
+		INSERT INTO scheduled_role_changes (user_id, role, effective_from, created_by)
+		VALUES ($1, $2, $3, $4) RETURNING id`, seed.ID("user:designer"), req.Name, time.Now().Add(24*time.Hour), adminID))
+	require.NoError(t, db.Get(&approvalID, `
+		INSERT INTO role_grant_approvals (user_id, role, requested_by)
+		VALUES ($1, $2, $3) RETURNING id`, seed.ID("user:intern"), req.Name, adminID))
+
+	require.NoError(t, repo.ArchiveRole(ctx, req.Name, adminID))
+	_, err = repo.GetRoleByName(ctx, req.Name)
+	assert.ErrorIs(t, err, ErrRoleNotFound)
+	roles, err := repo.GetAllRoles(ctx)
+	require.NoError(t, err)
+	for _, r := range roles {
+		assert.NotEqual(t, req.Name, r.Name)
+	}
+
+	var revoked bool
+	require.NoError(t, db.Get(&revoked, `SELECT revoked_at IS NOT NULL FROM role_delegations WHERE id = $1`, delegationID))
+	assert.True(t, revoked)
+	var status string
+	require.NoError(t, db.Get(&status, `SELECT status FROM scheduled_role_changes WHERE id = $1`, changeID))
+	assert.Equal(t, "cancelled", status)
+	require.NoError(t, db.Get(&status, `SELECT status FROM role_grant_approvals WHERE id = $1`, approvalID))
+	assert.Equal(t, "rejected", status)
+
+	// the archived role keeps its row, the name is free for a new role that starts without its permissions
+	newID, err := repo.InsertRole(ctx, req, adminID)
+	require.NoError(t, err)
+	assert.NotEqual(t, roleID, newID)
+	role, err = repo.GetRoleByName(ctx, req.Name)
+	require.NoError(t, err)
+	assert.Equal(t, newID, role.ID)
+	permissions, err = repo.GetPermissionsByRoles(ctx, []string{req.Name})
+	require.NoError(t, err)
+	assert.Empty(t, permissions)
+	var archived int
+	require.NoError(t, db.Get(&archived, `SELECT COUNT(*) FROM roles WHERE name = $1 AND archived_at IS NOT NULL`, req.Name))
+	assert.Equal(t, 1, archived)
+}
@@ -0,0 +1,590 @@
+package permissionservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	cacheprovider "asset/providers/cacheProvider"
+	"asset/services/audit"
+	"asset/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type PermissionService interface {
+	GetPermissionMatrix(ctx context.Context) (PermissionMatrixRes, error)
+	GetPermissionsByRoles(ctx context.Context, roles []string) ([]string, error)
+	GetRoles(ctx context.Context) ([]RoleRes, error)
+	CreateRole(ctx context.Context, req CreateRoleReq, adminID uuid.UUID) (uuid.UUID, error)
+	UpdateRole(ctx context.Context, req UpdateRoleReq, adminID uuid.UUID) (UpdateRoleRes, error)
+	GetRoleUpdateApprovals(ctx context.Context, status string, limit, offset int) ([]RoleUpdateApprovalRes, error)
+	ApproveRoleUpdate(ctx context.Context, id, adminID uuid.UUID, note string) error
+	RejectRoleUpdate(ctx context.Context, id, adminID uuid.UUID, note string) error
+	DeleteRole(ctx context.Context, name string, adminID uuid.UUID) error
+	GetDelegatedRoles(ctx context.Context, userID uuid.UUID) ([]string, error)
+	GetUserProfile(ctx context.Context, userID uuid.UUID) (MeProfile, error)
+	CreateDelegation(ctx context.Context, req CreateDelegationReq, delegatorID uuid.UUID) (uuid.UUID, error)
+	GetDelegations(ctx context.Context, userID uuid.UUID) ([]DelegationRes, error)
+	RevokeDelegation(ctx context.Context, id, userID uuid.UUID, anyDelegator bool, scope models.DepartmentScope) error
+	ExpireDelegations(ctx context.Context) error
+}
+
+type permissionServiceStruct struct {
+	repo   PermissionRepository
+	db     *sqlx.DB
+	logger providers.ZapLoggerProvider
+	audit  auditservice.AuditService
+	// role changes drop the cached enums
+	responses providers.ResponseCacheProvider
+}
+
+func NewPermissionService(repo PermissionRepository, db *sqlx.DB, logger providers.ZapLoggerProvider, audit auditservice.AuditService, responses providers.ResponseCacheProvider) PermissionService {
+	return &permissionServiceStruct{repo: repo, db: db, logger: logger, audit: audit, responses: responses}
+}
+
+// delegations are meant for cover during leave, not as a permanent grant
+const maxDelegationPeriod = 90 * 24 * time.Hour
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+var (
+	ErrRoleNotFound           = models.NewServiceError(http.StatusNotFound, "role_not_found", "role not found")
+	ErrUnknownPermission      = models.NewServiceError(http.StatusBadRequest, "unknown_permission", "one or more permissions do not exist")
+	ErrDuplicatePermission    = models.NewServiceError(http.StatusBadRequest, "duplicate_permission", "duplicate permissions in request")
+	ErrInvalidRoleName        = models.NewServiceError(http.StatusBadRequest, "invalid_role_name", "role name must be lowercase letters, digits or underscores")
+	ErrSystemRoleImmutable    = models.NewServiceError(http.StatusForbidden, "system_role_immutable", "permissions of system roles cannot be changed")
+	ErrSystemRoleUndeletable  = models.NewServiceError(http.StatusForbidden, "system_role_undeletable", "system roles cannot be deleted")
+	ErrRoleInUse              = models.NewServiceError(http.StatusConflict, "role_in_use", "role is assigned to users, reassign them first")
+	ErrRoleWithoutPermissions = models.NewServiceError(http.StatusBadRequest, "role_without_permissions", "role must have at least one permission")
+	ErrPermissionNotHeld      = models.NewServiceError(http.StatusForbidden, "permission_not_held", "roles can only be given permissions you hold yourself")
+	ErrSelfDelegation         = models.NewServiceError(http.StatusBadRequest, "self_delegation", "cannot delegate a role to yourself")
+	ErrInvalidDelegationTime  = models.NewServiceError(http.StatusBadRequest, "invalid_delegation_window", "ends_at must be in the future and after starts_at")
+	ErrDelegateNotFound       = models.NewServiceError(http.StatusNotFound, "delegate_not_found", "delegate user not found")
+	ErrDelegationNotFound     = models.NewServiceError(http.StatusNotFound, "delegation_not_found", "delegation not found")
+	ErrDelegationInactive     = models.NewServiceError(http.StatusConflict, "delegation_inactive", "delegation is already revoked or expired")
+	ErrNotDelegator           = models.NewServiceError(http.StatusForbidden, "not_delegator", "only the delegator can revoke a delegation, role managers use the admin route")
+	ErrPrivilegedDelegation   = models.NewServiceError(http.StatusForbidden, "privileged_role_not_delegable", "roles that can manage roles, organizations, config or restores are granted with approval, not delegated")
+	ErrRoleUpdatePending      = models.NewServiceError(http.StatusConflict, "role_update_pending", "a permission change for this role is already waiting for approval")
+	ErrRoleUpdateNotFound     = models.NewServiceError(http.StatusNotFound, "role_update_not_found", "role update approval not found")
+	ErrRoleUpdateDecided      = models.NewServiceError(http.StatusConflict, "role_update_decided", "role update approval has already been decided")
+	ErrRoleUpdateSelfApproval = models.NewServiceError(http.StatusForbidden, "role_update_self_approval", "a role update must be approved by an admin other than the requester")
+	ErrRoleUpdateApprover     = models.NewServiceError(http.StatusForbidden, "role_update_needs_platform_admin", "privileged role updates are decided by an admin holding organization.manage")
+)
+
+func (s *permissionServiceStruct) GetPermissionMatrix(ctx context.Context) (PermissionMatrixRes, error) {
+	s.logger.GetLogger().Info("building permission matrix")
+	permissions, err := s.repo.GetAllPermissions(ctx)
+	if err != nil {
+		s.logger.GetLogger().Error("failed to get permissions", zap.Error(err))
+		return PermissionMatrixRes{}, err
+	}
+
+	rows, err := s.repo.GetRolePermissions(ctx)
+	if err != nil {
+		s.logger.GetLogger().Error("failed to get role permissions", zap.Error(err))
+		return PermissionMatrixRes{}, err
+	}
+
+	matrix := PermissionMatrixRes{
+		Permissions: permissions,
+		Roles:       make(map[string][]string),
+	}
+	for _, row := range rows {
+		matrix.Roles[row.Role] = append(matrix.Roles[row.Role], row.Permission)
+	}
+	s.logger.GetLogger().Info("permission matrix built", zap.Int("roles", len(matrix.Roles)))
+	return matrix, nil
+}
+
+func (s *permissionServiceStruct) GetPermissionsByRoles(ctx context.Context, roles []string) ([]string, error) {
+	if len(roles) == 0 {
+		return []string{}, nil
+	}
+	permissions, err := s.repo.GetPermissionsByRoles(ctx, roles)
+	if err != nil {
+		s.logger.GetLogger().Error("failed to get permissions by roles", zap.Strings("roles", roles), zap.Error(err))
+		return nil, err
+	}
+	return permissions, nil
+}
+
+func (s *permissionServiceStruct) GetRoles(ctx context.Context) ([]RoleRes, error) {
+	s.logger.GetLogger().Info("fetching roles with permissions")
+	roles, err := s.repo.GetAllRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.repo.GetRolePermissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	permissionsByRole := make(map[string][]string)
+	for _, row := range rows {
+		permissionsByRole[row.Role] = append(permissionsByRole[row.Role], row.Permission)
+	}
+	for i := range roles {
+		roles[i].Permissions = permissionsByRole[roles[i].Name]
+		if roles[i].Permissions == nil {
+			roles[i].Permissions = []string{}
+		}
+	}
+	return roles, nil
+}
+
+func (s *permissionServiceStruct) validatePermissions(ctx context.Context, permissions []string) error {
+	unique := make(map[string]struct{}, len(permissions))
+	for _, permission := range permissions {
+		unique[permission] = struct{}{}
+	}
+	count, err := s.repo.CountExistingPermissions(ctx, permissions)
+	if err != nil {
+		return err
+	}
+	if count != len(unique) {
+		return ErrUnknownPermission
+	}
+	if count != len(permissions) {
+		return ErrDuplicatePermission
+	}
+	return nil
+}
+
+// checkHeldPermissions refuses permissions the caller's own roles don't grant, so nobody hands a role
+// more than they hold themselves. The role routes also need organization.manage, roles are shared by
+// every organization
+func (s *permissionServiceStruct) checkHeldPermissions(ctx context.Context, adminID uuid.UUID, permissions []string) error {
+	held, err := s.repo.GetUserPermissions(ctx, adminID)
+	if err != nil {
+		return err
+	}
+	missing := make([]string, 0)
+	for _, permission := range permissions {
+		if !slices.Contains(held, permission) {
+			missing = append(missing, permission)
+		}
+	}
+	if len(missing) > 0 {
+		s.logger.GetLogger().Warn("role permissions beyond the caller's", zap.String("adminID", adminID.String()), zap.Strings("missing", missing))
+		return ErrPermissionNotHeld.WithDetails(map[string][]string{"permissions": missing})
+	}
+	return nil
+}
+
+// checkRoleWithinReach refuses changes to a role that grants more than the caller holds
+func (s *permissionServiceStruct) checkRoleWithinReach(ctx context.Context, adminID uuid.UUID, name string) error {
+	current, err := s.repo.GetPermissionsByRoles(ctx, []string{name})
+	if err != nil {
+		return err
+	}
+	return s.checkHeldPermissions(ctx, adminID, current)
+}
+
+func (s *permissionServiceStruct) CreateRole(ctx context.Context, req CreateRoleReq, adminID uuid.UUID) (roleID uuid.UUID, err error) {
+	s.logger.GetLogger().Info("create role", zap.String("role", req.Name), zap.String("adminID", adminID.String()))
+	if !roleNamePattern.MatchString(req.Name) {
+		return uuid.Nil, ErrInvalidRoleName
+	}
+	if _, err := s.repo.GetRoleByName(ctx, req.Name); err == nil {
+		s.logger.GetLogger().Warn("role already exists", zap.String("role", req.Name))
+		return uuid.Nil, fmt.Errorf("role already exists: %s", req.Name)
+	}
+	if err := s.validatePermissions(ctx, req.Permissions); err != nil {
+		return uuid.Nil, err
+	}
+	if err := s.checkHeldPermissions(ctx, adminID, req.Permissions); err != nil {
+		return uuid.Nil, err
+	}
+
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		var err error
+		if roleID, err = s.repo.InsertRole(ctx, req, adminID); err != nil {
+			return err
+		}
+		return s.repo.ReplaceRolePermissions(ctx, req.Name, req.Permissions, adminID)
+	})
+	if err != nil {
+		s.logger.GetLogger().Error("failed to create role", zap.String("role", req.Name), zap.Error(err))
+		return uuid.Nil, err
+	}
+	// the role names are part of the cached enums
+	s.responses.Invalidate(ctx, cacheprovider.ResponseEnums)
+	s.logger.GetLogger().Info("role created", zap.String("role", req.Name), zap.String("roleID", roleID.String()))
+	return roleID, nil
+}
+
+func (s *permissionServiceStruct) UpdateRole(ctx context.Context, req UpdateRoleReq, adminID uuid.UUID) (UpdateRoleRes, error) {
+	s.logger.GetLogger().Info("update role", zap.String("role", req.Name), zap.String("adminID", adminID.String()))
+	role, err := s.repo.GetRoleByName(ctx, req.Name)
+	if err != nil {
+		return UpdateRoleRes{}, err
+	}
+	// system roles keep their permissions so an admin can't lock everyone out
+	if role.IsSystem && req.Permissions != nil {
+		return UpdateRoleRes{}, ErrSystemRoleImmutable
+	}
+	if req.Permissions != nil {
+		if len(req.Permissions) == 0 {
+			return UpdateRoleRes{}, ErrRoleWithoutPermissions
+		}
+		if err := s.validatePermissions(ctx, req.Permissions); err != nil {
+			return UpdateRoleRes{}, err
+		}
+		if err := s.checkHeldPermissions(ctx, adminID, req.Permissions); err != nil {
+			return UpdateRoleRes{}, err
+		}
+	}
+	current, err := s.repo.GetPermissionsByRoles(ctx, []string{req.Name})
+	if err != nil {
+		return UpdateRoleRes{}, err
+	}
+	if err := s.checkHeldPermissions(ctx, adminID, current); err != nil {
+		return UpdateRoleRes{}, err
+	}
+
+	if req.Permissions != nil {
+		// an approved request would overwrite whatever was changed in the meantime
+		pending, err := s.repo.HasPendingRoleUpdate(ctx, req.Name)
+		if err != nil {
+			return UpdateRoleRes{}, err
+		}
+		if pending {
+			return UpdateRoleRes{}, ErrRoleUpdatePending
+		}
+		// making a role in use privileged grants it to everyone holding it, so it needs a second
+		// admin just like granting a privileged role to one user does
+		added := make([]string, 0)
+		for _, permission := range req.Permissions {
+			if !slices.Contains(current, permission) {
+				added = append(added, permission)
+			}
+		}
+		if models.HasPrivilegedPermission(added) {
+			inUse, err := s.repo.IsRoleInUse(ctx, req.Name)
+			if err != nil {
+				return UpdateRoleRes{}, err
+			}
+			if inUse {
+				approvalID, err := s.requestRoleUpdate(ctx, req, current, adminID)
+				if err != nil {
+					return UpdateRoleRes{}, err
+				}
+				return UpdateRoleRes{Status: RoleUpdatePendingApproval, ApprovalID: &approvalID}, nil
+			}
+		}
+	}
+
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		if req.Description != nil {
+			if err := s.repo.UpdateRoleDescription(ctx, req.Name, req.Description, adminID); err != nil {
+				return err
+			}
+		}
+		if req.Permissions != nil {
+			return s.repo.ReplaceRolePermissions(ctx, req.Name, req.Permissions, adminID)
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.GetLogger().Error("failed to update role", zap.String("role", req.Name), zap.Error(err))
+		return UpdateRoleRes{}, err
+	}
+	s.logger.GetLogger().Info("role updated", zap.String("role", req.Name))
+	return UpdateRoleRes{Status: RoleUpdateApplied}, nil
+}
+
+// requestRoleUpdate parks the new permissions in role_update_approvals, a description change still
+// applies right away. The permissions only change once another admin approves through ApproveRoleUpdate
+func (s *permissionServiceStruct) requestRoleUpdate(ctx context.Context, req UpdateRoleReq, current []string, adminID uuid.UUID) (id uuid.UUID, err error) {
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		if req.Description != nil {
+			if err := s.repo.UpdateRoleDescription(ctx, req.Name, req.Description, adminID); err != nil {
+				return err
+			}
+		}
+		var err error
+		if id, err = s.repo.InsertRoleUpdateApproval(ctx, req.Name, req.Permissions, current, adminID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, auditservice.AuditEntry{
+			ActorID:    &adminID,
+			Action:     "role.update_requested",
+			EntityType: "role",
+			EntityID:   req.Name,
+			OldValue:   map[string]interface{}{"permissions": current},
+			NewValue:   map[string]interface{}{"permissions": req.Permissions, "approval_id": id},
+		})
+	})
+	if err != nil {
+		s.logger.GetLogger().Error("failed to request role update approval", zap.String("role", req.Name), zap.Error(err))
+		return uuid.Nil, err
+	}
+	s.logger.GetLogger().Info("role update waiting for approval", zap.String("id", id.String()), zap.String("role", req.Name))
+	return id, nil
+}
+
+func (s *permissionServiceStruct) GetRoleUpdateApprovals(ctx context.Context, status string, limit, offset int) ([]RoleUpdateApprovalRes, error) {
+	return s.repo.GetRoleUpdateApprovals(ctx, status, limit, offset)
+}
+
+// ApproveRoleUpdate applies pending permissions. Roles are shared by every organization, so the approver
+// has to hold organization.manage and be someone other than the admin who asked for the change
+func (s *permissionServiceStruct) ApproveRoleUpdate(ctx context.Context, id, adminID uuid.UUID, note string) error {
+	approval, err := s.decideRoleUpdate(ctx, id, adminID, RoleUpdateApproved, note, func(ctx context.Context, approval RoleUpdateApprovalRes) error {
+		return s.repo.ReplaceRolePermissions(ctx, approval.Role, approval.Permissions, adminID)
+	})
+	if err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("role update approved", zap.String("id", id.String()), zap.String("role", approval.Role), zap.String("approvedBy", adminID.String()))
+	return nil
+}
+
+// RejectRoleUpdate drops a pending change, the requesting admin may also withdraw their own request
+func (s *permissionServiceStruct) RejectRoleUpdate(ctx context.Context, id, adminID uuid.UUID, note string) error {
+	approval, err := s.decideRoleUpdate(ctx, id, adminID, RoleUpdateRejected, note, nil)
+	if err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("role update rejected", zap.String("id", id.String()), zap.String("role", approval.Role), zap.String("rejectedBy", adminID.String()))
+	return nil
+}
+
+// decideRoleUpdate records the decision, runs apply for approvals and writes the audit entry in one transaction
+func (s *permissionServiceStruct) decideRoleUpdate(ctx context.Context, id, adminID uuid.UUID, status, note string, apply func(ctx context.Context, approval RoleUpdateApprovalRes) error) (approval RoleUpdateApprovalRes, err error) {
+	held, err := s.repo.GetUserPermissions(ctx, adminID)
+	if err != nil {
+		return RoleUpdateApprovalRes{}, err
+	}
+	platformAdmin := slices.Contains(held, string(models.OrganizationManagePermission))
+
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		var err error
+		approval, err = s.repo.GetRoleUpdateApprovalForUpdate(ctx, id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrRoleUpdateNotFound
+			}
+			return err
+		}
+		if approval.Status != RoleUpdatePending {
+			return ErrRoleUpdateDecided
+		}
+		withdrawn := status == RoleUpdateRejected && approval.RequestedBy == adminID
+		if !platformAdmin && !withdrawn {
+			return ErrRoleUpdateApprover
+		}
+		if status == RoleUpdateApproved && approval.RequestedBy == adminID {
+			return ErrRoleUpdateSelfApproval
+		}
+
+		if err = s.repo.DecideRoleUpdateApproval(ctx, id, status, adminID, note); err != nil {
+			return err
+		}
+		if apply != nil {
+			if err = apply(ctx, approval); err != nil {
+				return err
+			}
+		}
+		decision := map[string]interface{}{
+			"permissions":  approval.Permissions,
+			"approval_id":  approval.ID,
+			"requested_by": approval.RequestedBy,
+			"note":         note,
+		}
+		if status == RoleUpdateRejected {
+			decision["withdrawn"] = withdrawn
+		}
+		return s.audit.Record(ctx, auditservice.AuditEntry{
+			ActorID:    &adminID,
+			Action:     "role.update_" + status,
+			EntityType: "role",
+			EntityID:   approval.Role,
+			OldValue:   map[string]interface{}{"permissions": approval.PreviousPermissions},
+			NewValue:   decision,
+		})
+	})
+	if err != nil {
+		s.logger.GetLogger().Error("failed to decide role update", zap.String("id", id.String()), zap.Error(err))
+	}
+	return approval, err
+}
+
+func (s *permissionServiceStruct) DeleteRole(ctx context.Context, name string, adminID uuid.UUID) (err error) {
+	s.logger.GetLogger().Info("delete role", zap.String("role", name), zap.String("adminID", adminID.String()))
+	role, err := s.repo.GetRoleByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	if role.IsSystem {
+		return ErrSystemRoleUndeletable
+	}
+	if err := s.checkRoleWithinReach(ctx, adminID, name); err != nil {
+		return err
+	}
+	count, err := s.repo.CountUsersWithRole(ctx, name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrRoleInUse.WithDetails(map[string]int{"users": count})
+	}
+
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		return s.repo.ArchiveRole(ctx, name, adminID)
+	})
+	if err != nil {
+		s.logger.GetLogger().Error("failed to delete role", zap.String("role", name), zap.Error(err))
+		return err
+	}
+	s.responses.Invalidate(ctx, cacheprovider.ResponseEnums)
+	s.logger.GetLogger().Info("role deleted", zap.String("role", name))
+	return nil
+}
+
+func (s *permissionServiceStruct) GetDelegatedRoles(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	return s.repo.GetActiveDelegatedRoles(ctx, userID)
+}
+
+func (s *permissionServiceStruct) GetUserProfile(ctx context.Context, userID uuid.UUID) (MeProfile, error) {
+	return s.repo.GetUserProfile(ctx, userID)
+}
+
+func (s *permissionServiceStruct) CreateDelegation(ctx context.Context, req CreateDelegationReq, delegatorID uuid.UUID) (id uuid.UUID, err error) {
+	s.logger.GetLogger().Info("create delegation", zap.String("delegatorID", delegatorID.String()), zap.String("delegateID", req.DelegateID), zap.String("role", req.Role))
+	delegateID, err := uuid.Parse(req.DelegateID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if delegateID == delegatorID {
+		return uuid.Nil, ErrSelfDelegation
+	}
+
+	now := time.Now()
+	if req.StartsAt == nil {
+		req.StartsAt = &now
+	}
+	if !req.EndsAt.After(*req.StartsAt) || !req.EndsAt.After(now) {
+		return uuid.Nil, ErrInvalidDelegationTime
+	}
+	if req.EndsAt.Sub(*req.StartsAt) > maxDelegationPeriod {
+		return uuid.Nil, fmt.Errorf("delegation cannot be longer than %d days", int(maxDelegationPeriod.Hours()/24))
+	}
+
+	// only directly assigned roles can be delegated, a delegate can't pass the role on again
+	hasRole, err := s.repo.UserHasRole(ctx, delegatorID, req.Role)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if !hasRole {
+		return uuid.Nil, fmt.Errorf("you do not have the role: %s", req.Role)
+	}
+	// a delegation skips the second admin a privileged grant needs
+	permissions, err := s.repo.GetPermissionsByRoles(ctx, []string{req.Role})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if models.HasPrivilegedPermission(permissions) {
+		return uuid.Nil, ErrPrivilegedDelegation
+	}
+	// the delegate has to work in the delegator's organization
+	active, err := s.repo.IsActiveColleague(ctx, delegateID, delegatorID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if !active {
+		return uuid.Nil, ErrDelegateNotFound
+	}
+
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		var err error
+		if id, err = s.repo.InsertDelegation(ctx, delegatorID, delegateID, req); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, auditservice.AuditEntry{
+			ActorID:    &delegatorID,
+			Action:     "role_delegation.created",
+			EntityType: "role_delegation",
+			EntityID:   id.String(),
+			NewValue:   req,
+		})
+	})
+	if err != nil {
+		s.logger.GetLogger().Error("failed to create delegation", zap.Error(err))
+		return uuid.Nil, err
+	}
+	s.logger.GetLogger().Info("delegation created", zap.String("id", id.String()))
+	return id, nil
+}
+
+func (s *permissionServiceStruct) GetDelegations(ctx context.Context, userID uuid.UUID) ([]DelegationRes, error) {
+	return s.repo.GetDelegationsForUser(ctx, userID)
+}
+
+// anyDelegator is set by the admin route, the caller may then revoke the delegation of anyone in their scope
+func (s *permissionServiceStruct) RevokeDelegation(ctx context.Context, id, userID uuid.UUID, anyDelegator bool, scope models.DepartmentScope) (err error) {
+	s.logger.GetLogger().Info("revoke delegation", zap.String("id", id.String()), zap.String("userID", userID.String()))
+	delegation, err := s.repo.GetDelegationByID(ctx, id, scope.OrganizationID)
+	if err != nil {
+		return err
+	}
+	if delegation.DelegatorID != userID && !anyDelegator {
+		return ErrNotDelegator
+	}
+
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		if err := s.repo.RevokeDelegation(ctx, id, userID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, auditservice.AuditEntry{
+			ActorID:    &userID,
+			Action:     "role_delegation.revoked",
+			EntityType: "role_delegation",
+			EntityID:   id.String(),
+			OldValue:   delegation,
+		})
+	})
+	if err != nil {
+		s.logger.GetLogger().Error("failed to revoke delegation", zap.String("id", id.String()), zap.Error(err))
+	}
+	return err
+}
+
+// ExpireDelegations is run by the background job
+func (s *permissionServiceStruct) ExpireDelegations(ctx context.Context) error {
+	var expired []DelegationRes
+	err := utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		var err error
+		if expired, err = s.repo.ExpireDelegations(ctx); err != nil {
+			return err
+		}
+		for _, delegation := range expired {
+			err = s.audit.Record(ctx, auditservice.AuditEntry{
+				Action:     "role_delegation.expired",
+				EntityType: "role_delegation",
+				EntityID:   delegation.ID.String(),
+				OldValue:   delegation,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(expired) > 0 {
+		s.logger.GetLogger().Info("delegations expired", zap.Int("count", len(expired)))
+	}
+	return nil
+}
@@ -0,0 +1,714 @@
+package permissionservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	cacheprovider "asset/providers/cacheProvider"
+	"asset/services/audit"
+	"asset/utils"
+	"context"
+	"database/sql"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// inTx matches the context of a call made inside a transaction
+var inTx gomock.Matcher = txContextMatcher{}
+
+type txContextMatcher struct{}
+
+func (txContextMatcher) Matches(x interface{}) bool {
+	ctx, ok := x.(context.Context)
+	if !ok {
+		return false
+	}
+	_, ok = utils.TxFromContext(ctx)
+	return ok
+}
+
+func (txContextMatcher) String() string {
+	return "is a context carrying a transaction"
+}
+
+// the permissions migration 000060 leaves an org_admin with
+var orgAdminPermissions = []string{"asset.manage", "role.manage", "user.manage"}
+
+func TestCreateRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	adminID := uuid.New()
+	roleID := uuid.New()
+
+	tests := []struct {
+		name        string
+		req         CreateRoleReq
+		setupMocks  func(repo *MockPermissionRepository, responses *providers.MockResponseCacheProvider, db sqlmock.Sqlmock)
+		expectedErr error
+	}{
+		{
+			name: "permissions the caller holds",
+			req:  CreateRoleReq{Name: "asset_clerk", Permissions: []string{"asset.manage"}},
+			setupMocks: func(repo *MockPermissionRepository, responses *providers.MockResponseCacheProvider, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetRoleByName(ctx, "asset_clerk").Return(RoleRes{}, ErrRoleNotFound)
+				repo.EXPECT().CountExistingPermissions(ctx, []string{"asset.manage"}).Return(1, nil)
+				repo.EXPECT().GetUserPermissions(ctx, adminID).Return(orgAdminPermissions, nil)
+				db.ExpectBegin()
+				repo.EXPECT().InsertRole(inTx, gomock.Any(), adminID).Return(roleID, nil)
+				repo.EXPECT().ReplaceRolePermissions(inTx, "asset_clerk", []string{"asset.manage"}, adminID).Return(nil)
+				db.ExpectCommit()
+				responses.EXPECT().Invalidate(ctx, cacheprovider.ResponseEnums)
+			},
+		},
+		{
+			name: "org_admin can't mint organization.manage",
+			req:  CreateRoleReq{Name: "tenant_owner", Permissions: []string{"asset.manage", "organization.manage"}},
+			setupMocks: func(repo *MockPermissionRepository, responses *providers.MockResponseCacheProvider, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetRoleByName(ctx, "tenant_owner").Return(RoleRes{}, ErrRoleNotFound)
+				repo.EXPECT().CountExistingPermissions(ctx, gomock.Any()).Return(2, nil)
+				repo.EXPECT().GetUserPermissions(ctx, adminID).Return(orgAdminPermissions, nil)
+			},
+			expectedErr: ErrPermissionNotHeld,
+		},
+		{
+			name: "org_admin can't mint snapshot.restore",
+			req:  CreateRoleReq{Name: "restorer", Permissions: []string{"snapshot.restore"}},
+			setupMocks: func(repo *MockPermissionRepository, responses *providers.MockResponseCacheProvider, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetRoleByName(ctx, "restorer").Return(RoleRes{}, ErrRoleNotFound)
+				repo.EXPECT().CountExistingPermissions(ctx, gomock.Any()).Return(1, nil)
+				repo.EXPECT().GetUserPermissions(ctx, adminID).Return(orgAdminPermissions, nil)
+			},
+			expectedErr: ErrPermissionNotHeld,
+		},
+		{
+			name: "unknown permission",
+			req:  CreateRoleReq{Name: "auditor", Permissions: []string{"audit.everything"}},
+			setupMocks: func(repo *MockPermissionRepository, responses *providers.MockResponseCacheProvider, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetRoleByName(ctx, "auditor").Return(RoleRes{}, ErrRoleNotFound)
+				repo.EXPECT().CountExistingPermissions(ctx, gomock.Any()).Return(0, nil)
+			},
+			expectedErr: ErrUnknownPermission,
+		},
+		{
+			name: "invalid name",
+			req:  CreateRoleReq{Name: "Asset Clerk", Permissions: []string{"asset.manage"}},
+			setupMocks: func(repo *MockPermissionRepository, responses *providers.MockResponseCacheProvider, db sqlmock.Sqlmock) {
+			},
+			expectedErr: ErrInvalidRoleName,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			mockRepo := NewMockPermissionRepository(ctrl)
+			mockResponses := providers.NewMockResponseCacheProvider(ctrl)
+			mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+			mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+			tc.setupMocks(mockRepo, mockResponses, mock)
+
+			service := &permissionServiceStruct{repo: mockRepo, db: sqlx.NewDb(db, "postgres"), logger: mockLogger, responses: mockResponses}
+			id, err := service.CreateRole(ctx, tc.req, adminID)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, roleID, id)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUpdateRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	adminID := uuid.New()
+	approvalID := uuid.New()
+	description := "front desk"
+
+	tests := []struct {
+		name           string
+		req            UpdateRoleReq
+		setupMocks     func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock)
+		expectedStatus string
+		expectedErr    error
+	}{
+		{
+			name: "permissions the caller holds",
+			req:  UpdateRoleReq{Name: "asset_clerk", Permissions: []string{"asset.manage", "user.manage"}},
+			setupMocks: func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetRoleByName(ctx, "asset_clerk").Return(RoleRes{Name: "asset_clerk"}, nil)
+				repo.EXPECT().CountExistingPermissions(ctx, gomock.Any()).Return(2, nil)
+				repo.EXPECT().GetUserPermissions(ctx, adminID).Return(orgAdminPermissions, nil).Times(2)
+				repo.EXPECT().GetPermissionsByRoles(ctx, []string{"asset_clerk"}).Return([]string{"asset.manage"}, nil)
+				repo.EXPECT().HasPendingRoleUpdate(ctx, "asset_clerk").Return(false, nil)
+				db.ExpectBegin()
+				repo.EXPECT().ReplaceRolePermissions(inTx, "asset_clerk", []string{"asset.manage", "user.manage"}, adminID).Return(nil)
+				db.ExpectCommit()
+			},
+			expectedStatus: RoleUpdateApplied,
+		},
+		{
+			name: "privileged permission for a role in use waits for approval",
+			req:  UpdateRoleReq{Name: "asset_clerk", Description: &description, Permissions: []string{"asset.manage", "role.manage"}},
+			setupMocks: func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetRoleByName(ctx, "asset_clerk").Return(RoleRes{Name: "asset_clerk"}, nil)
+				repo.EXPECT().CountExistingPermissions(ctx, gomock.Any()).Return(2, nil)
+				repo.EXPECT().GetUserPermissions(ctx, adminID).Return(orgAdminPermissions, nil).Times(2)
+				repo.EXPECT().GetPermissionsByRoles(ctx, []string{"asset_clerk"}).Return([]string{"asset.manage"}, nil)
+				repo.EXPECT().HasPendingRoleUpdate(ctx, "asset_clerk").Return(false, nil)
+				repo.EXPECT().IsRoleInUse(ctx, "asset_clerk").Return(true, nil)
+				db.ExpectBegin()
+				repo.EXPECT().UpdateRoleDescription(inTx, "asset_clerk", &description, adminID).Return(nil)
+				repo.EXPECT().InsertRoleUpdateApproval(inTx, "asset_clerk", []string{"asset.manage", "role.manage"}, []string{"asset.manage"}, adminID).Return(approvalID, nil)
+				audit.EXPECT().Record(inTx, gomock.Any()).Return(nil)
+				db.ExpectCommit()
+			},
+			expectedStatus: RoleUpdatePendingApproval,
+		},
+		{
+			name: "privileged permission for a role nobody holds",
+			req:  UpdateRoleReq{Name: "asset_clerk", Permissions: []string{"asset.manage", "role.manage"}},
+			setupMocks: func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetRoleByName(ctx, "asset_clerk").Return(RoleRes{Name: "asset_clerk"}, nil)
+				repo.EXPECT().CountExistingPermissions(ctx, gomock.Any()).Return(2, nil)
+				repo.EXPECT().GetUserPermissions(ctx, adminID).Return(orgAdminPermissions, nil).Times(2)
+				repo.EXPECT().GetPermissionsByRoles(ctx, []string{"asset_clerk"}).Return([]string{"asset.manage"}, nil)
+				repo.EXPECT().HasPendingRoleUpdate(ctx, "asset_clerk").Return(false, nil)
+				repo.EXPECT().IsRoleInUse(ctx, "asset_clerk").Return(false, nil)
+				db.ExpectBegin()
+				repo.EXPECT().ReplaceRolePermissions(inTx, "asset_clerk", []string{"asset.manage", "role.manage"}, adminID).Return(nil)
+				db.ExpectCommit()
+			},
+			expectedStatus: RoleUpdateApplied,
+		},
+		{
+			name: "privileged permission the role already has",
+			req:  UpdateRoleReq{Name: "role_clerk", Permissions: []string{"role.manage"}},
+			setupMocks: func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetRoleByName(ctx, "role_clerk").Return(RoleRes{Name: "role_clerk"}, nil)
+				repo.EXPECT().CountExistingPermissions(ctx, gomock.Any()).Return(1, nil)
+				repo.EXPECT().GetUserPermissions(ctx, adminID).Return(orgAdminPermissions, nil).Times(2)
+				repo.EXPECT().GetPermissionsByRoles(ctx, []string{"role_clerk"}).Return([]string{"asset.manage", "role.manage"}, nil)
+				repo.EXPECT().HasPendingRoleUpdate(ctx, "role_clerk").Return(false, nil)
+				db.ExpectBegin()
+				repo.EXPECT().ReplaceRolePermissions(inTx, "role_clerk", []string{"role.manage"}, adminID).Return(nil)
+				db.ExpectCommit()
+			},
+			expectedStatus: RoleUpdateApplied,
+		},
+		{
+			name: "change already waiting for approval",
+			req:  UpdateRoleReq{Name: "asset_clerk", Permissions: []string{"asset.manage", "user.manage"}},
+			setupMocks: func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetRoleByName(ctx, "asset_clerk").Return(RoleRes{Name: "asset_clerk"}, nil)
+				repo.EXPECT().CountExistingPermissions(ctx, gomock.Any()).Return(2, nil)
+				repo.EXPECT().GetUserPermissions(ctx, adminID).Return(orgAdminPermissions, nil).Times(2)
+				repo.EXPECT().GetPermissionsByRoles(ctx, []string{"asset_clerk"}).Return([]string{"asset.manage"}, nil)
+				repo.EXPECT().HasPendingRoleUpdate(ctx, "asset_clerk").Return(true, nil)
+			},
+			expectedErr: ErrRoleUpdatePending,
+		},
+		{
+			name: "adding a permission the caller lacks",
+			req:  UpdateRoleReq{Name: "asset_clerk", Permissions: []string{"asset.manage", "organization.manage"}},
+			setupMocks: func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetRoleByName(ctx, "asset_clerk").Return(RoleRes{Name: "asset_clerk"}, nil)
+				repo.EXPECT().CountExistingPermissions(ctx, gomock.Any()).Return(2, nil)
+				repo.EXPECT().GetUserPermissions(ctx, adminID).Return(orgAdminPermissions, nil)
+			},
+			expectedErr: ErrPermissionNotHeld,
+		},
+		{
+			name: "role above the caller",
+			req:  UpdateRoleReq{Name: "platform_ops", Description: &description},
+			setupMocks: func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetRoleByName(ctx, "platform_ops").Return(RoleRes{Name: "platform_ops"}, nil)
+				repo.EXPECT().GetPermissionsByRoles(ctx, []string{"platform_ops"}).Return([]string{"organization.manage"}, nil)
+				repo.EXPECT().GetUserPermissions(ctx, adminID).Return(orgAdminPermissions, nil)
+			},
+			expectedErr: ErrPermissionNotHeld,
+		},
+		{
+			name: "system role permissions",
+			req:  UpdateRoleReq{Name: "admin", Permissions: []string{"asset.manage"}},
+			setupMocks: func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetRoleByName(ctx, "admin").Return(RoleRes{Name: "admin", IsSystem: true}, nil)
+			},
+			expectedErr: ErrSystemRoleImmutable,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			mockRepo := NewMockPermissionRepository(ctrl)
+			mockAudit := auditservice.NewMockAuditService(ctrl)
+			mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+			mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+			tc.setupMocks(mockRepo, mockAudit, mock)
+
+			service := &permissionServiceStruct{repo: mockRepo, db: sqlx.NewDb(db, "postgres"), logger: mockLogger, audit: mockAudit}
+			res, err := service.UpdateRole(ctx, tc.req, adminID)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedStatus, res.Status)
+				if tc.expectedStatus == RoleUpdatePendingApproval {
+					assert.Equal(t, &approvalID, res.ApprovalID)
+				}
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDecideRoleUpdate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	requesterID := uuid.New()
+	platformAdminID := uuid.New()
+	orgAdminID := uuid.New()
+	approvalID := uuid.New()
+	platformAdminPermissions := append(slices.Clone(orgAdminPermissions), "organization.manage")
+	pending := RoleUpdateApprovalRes{
+		ID:                  approvalID,
+		Role:                "asset_clerk",
+		Permissions:         []string{"asset.manage", "role.manage"},
+		PreviousPermissions: []string{"asset.manage"},
+		Status:              RoleUpdatePending,
+		RequestedBy:         requesterID,
+	}
+
+	tests := []struct {
+		name        string
+		adminID     uuid.UUID
+		approve     bool
+		setupMocks  func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock)
+		expectedErr error
+	}{
+		{
+			name:    "platform admin approves",
+			adminID: platformAdminID,
+			approve: true,
+			setupMocks: func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetUserPermissions(ctx, platformAdminID).Return(platformAdminPermissions, nil)
+				db.ExpectBegin()
+				repo.EXPECT().GetRoleUpdateApprovalForUpdate(inTx, approvalID).Return(pending, nil)
+				repo.EXPECT().DecideRoleUpdateApproval(inTx, approvalID, RoleUpdateApproved, platformAdminID, "").Return(nil)
+				repo.EXPECT().ReplaceRolePermissions(inTx, "asset_clerk", []string{"asset.manage", "role.manage"}, platformAdminID).Return(nil)
+				audit.EXPECT().Record(inTx, gomock.Any()).Return(nil)
+				db.ExpectCommit()
+			},
+		},
+		{
+			name:    "requester approves their own change",
+			adminID: requesterID,
+			approve: true,
+			setupMocks: func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetUserPermissions(ctx, requesterID).Return(platformAdminPermissions, nil)
+				db.ExpectBegin()
+				repo.EXPECT().GetRoleUpdateApprovalForUpdate(inTx, approvalID).Return(pending, nil)
+				db.ExpectRollback()
+			},
+			expectedErr: ErrRoleUpdateSelfApproval,
+		},
+		{
+			name:    "org admin approves",
+			adminID: orgAdminID,
+			approve: true,
+			setupMocks: func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetUserPermissions(ctx, orgAdminID).Return(orgAdminPermissions, nil)
+				db.ExpectBegin()
+				repo.EXPECT().GetRoleUpdateApprovalForUpdate(inTx, approvalID).Return(pending, nil)
+				db.ExpectRollback()
+			},
+			expectedErr: ErrRoleUpdateApprover,
+		},
+		{
+			name:    "org admin withdraws their own request",
+			adminID: requesterID,
+			setupMocks: func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetUserPermissions(ctx, requesterID).Return(orgAdminPermissions, nil)
+				db.ExpectBegin()
+				repo.EXPECT().GetRoleUpdateApprovalForUpdate(inTx, approvalID).Return(pending, nil)
+				repo.EXPECT().DecideRoleUpdateApproval(inTx, approvalID, RoleUpdateRejected, requesterID, "").Return(nil)
+				audit.EXPECT().Record(inTx, gomock.Any()).Return(nil)
+				db.ExpectCommit()
+			},
+		},
+		{
+			name:    "already decided",
+			adminID: platformAdminID,
+			approve: true,
+			setupMocks: func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {
+				decided := pending
+				decided.Status = RoleUpdateRejected
+				repo.EXPECT().GetUserPermissions(ctx, platformAdminID).Return(platformAdminPermissions, nil)
+				db.ExpectBegin()
+				repo.EXPECT().GetRoleUpdateApprovalForUpdate(inTx, approvalID).Return(decided, nil)
+				db.ExpectRollback()
+			},
+			expectedErr: ErrRoleUpdateDecided,
+		},
+		{
+			name:    "unknown approval",
+			adminID: platformAdminID,
+			approve: true,
+			setupMocks: func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetUserPermissions(ctx, platformAdminID).Return(platformAdminPermissions, nil)
+				db.ExpectBegin()
+				repo.EXPECT().GetRoleUpdateApprovalForUpdate(inTx, approvalID).Return(RoleUpdateApprovalRes{}, sql.ErrNoRows)
+				db.ExpectRollback()
+			},
+			expectedErr: ErrRoleUpdateNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			mockRepo := NewMockPermissionRepository(ctrl)
+			mockAudit := auditservice.NewMockAuditService(ctrl)
+			mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+			mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+			tc.setupMocks(mockRepo, mockAudit, mock)
+
+			service := &permissionServiceStruct{repo: mockRepo, db: sqlx.NewDb(db, "postgres"), logger: mockLogger, audit: mockAudit}
+			if tc.approve {
+				err = service.ApproveRoleUpdate(ctx, approvalID, tc.adminID, "")
+			} else {
+				err = service.RejectRoleUpdate(ctx, approvalID, tc.adminID, "")
+			}
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestDeleteRole(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	adminID := uuid.New()
+
+	tests := []struct {
+		name        string
+		role        string
+		setupMocks  func(repo *MockPermissionRepository, responses *providers.MockResponseCacheProvider, db sqlmock.Sqlmock)
+		expectedErr error
+		errContains string
+	}{
+		{
+			name: "unassigned custom role",
+			role: "asset_clerk",
+			setupMocks: func(repo *MockPermissionRepository, responses *providers.MockResponseCacheProvider, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetRoleByName(ctx, "asset_clerk").Return(RoleRes{Name: "asset_clerk"}, nil)
+				repo.EXPECT().GetPermissionsByRoles(ctx, []string{"asset_clerk"}).Return([]string{"asset.manage"}, nil)
+				repo.EXPECT().GetUserPermissions(ctx, adminID).Return(orgAdminPermissions, nil)
+				repo.EXPECT().CountUsersWithRole(ctx, "asset_clerk").Return(0, nil)
+				db.ExpectBegin()
+				repo.EXPECT().ArchiveRole(inTx, "asset_clerk", adminID).Return(nil)
+				db.ExpectCommit()
+				responses.EXPECT().Invalidate(ctx, cacheprovider.ResponseEnums)
+			},
+		},
+		{
+			name: "role still assigned",
+			role: "asset_clerk",
+			setupMocks: func(repo *MockPermissionRepository, responses *providers.MockResponseCacheProvider, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetRoleByName(ctx, "asset_clerk").Return(RoleRes{Name: "asset_clerk"}, nil)
+				repo.EXPECT().GetPermissionsByRoles(ctx, []string{"asset_clerk"}).Return([]string{"asset.manage"}, nil)
+				repo.EXPECT().GetUserPermissions(ctx, adminID).Return(orgAdminPermissions, nil)
+				repo.EXPECT().CountUsersWithRole(ctx, "asset_clerk").Return(2, nil)
+			},
+			expectedErr: ErrRoleInUse,
+		},
+		{
+			name: "role above the caller",
+			role: "platform_ops",
+			setupMocks: func(repo *MockPermissionRepository, responses *providers.MockResponseCacheProvider, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetRoleByName(ctx, "platform_ops").Return(RoleRes{Name: "platform_ops"}, nil)
+				repo.EXPECT().GetPermissionsByRoles(ctx, []string{"platform_ops"}).Return([]string{"organization.manage"}, nil)
+				repo.EXPECT().GetUserPermissions(ctx, adminID).Return(orgAdminPermissions, nil)
+			},
+			expectedErr: ErrPermissionNotHeld,
+		},
+		{
+			name: "system role",
+			role: "employee",
+			setupMocks: func(repo *MockPermissionRepository, responses *providers.MockResponseCacheProvider, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetRoleByName(ctx, "employee").Return(RoleRes{Name: "employee", IsSystem: true}, nil)
+			},
+			expectedErr: ErrSystemRoleUndeletable,
+		},
+		{
+			name: "already deleted",
+			role: "asset_clerk",
+			setupMocks: func(repo *MockPermissionRepository, responses *providers.MockResponseCacheProvider, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetRoleByName(ctx, "asset_clerk").Return(RoleRes{}, ErrRoleNotFound)
+			},
+			expectedErr: ErrRoleNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			mockRepo := NewMockPermissionRepository(ctrl)
+			mockResponses := providers.NewMockResponseCacheProvider(ctrl)
+			mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+			mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+			tc.setupMocks(mockRepo, mockResponses, mock)
+
+			service := &permissionServiceStruct{repo: mockRepo, db: sqlx.NewDb(db, "postgres"), logger: mockLogger, responses: mockResponses}
+			err = service.DeleteRole(ctx, tc.role, adminID)
+			switch {
+			case tc.expectedErr != nil:
+				assert.ErrorIs(t, err, tc.expectedErr)
+			case tc.errContains != "":
+				assert.ErrorContains(t, err, tc.errContains)
+			default:
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCreateDelegation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	delegatorID := uuid.New()
+	delegateID := uuid.New()
+	delegationID := uuid.New()
+	endsAt := time.Now().Add(7 * 24 * time.Hour)
+
+	tests := []struct {
+		name        string
+		req         CreateDelegationReq
+		setupMocks  func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock)
+		expectedErr error
+		errContains string
+	}{
+		{
+			name: "delegate in the delegator's organization",
+			req:  CreateDelegationReq{DelegateID: delegateID.String(), Role: "asset_manager", EndsAt: endsAt},
+			setupMocks: func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {
+				repo.EXPECT().UserHasRole(ctx, delegatorID, "asset_manager").Return(true, nil)
+				repo.EXPECT().GetPermissionsByRoles(ctx, []string{"asset_manager"}).Return([]string{"asset.read", "asset.assign"}, nil)
+				repo.EXPECT().IsActiveColleague(ctx, delegateID, delegatorID).Return(true, nil)
+				db.ExpectBegin()
+				repo.EXPECT().InsertDelegation(inTx, delegatorID, delegateID, gomock.Any()).Return(delegationID, nil)
+				audit.EXPECT().Record(inTx, gomock.Any()).Return(nil)
+				db.ExpectCommit()
+			},
+		},
+		{
+			name: "delegate in another organization",
+			req:  CreateDelegationReq{DelegateID: delegateID.String(), Role: "asset_manager", EndsAt: endsAt},
+			setupMocks: func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {
+				repo.EXPECT().UserHasRole(ctx, delegatorID, "asset_manager").Return(true, nil)
+				repo.EXPECT().GetPermissionsByRoles(ctx, []string{"asset_manager"}).Return([]string{"asset.read", "asset.assign"}, nil)
+				repo.EXPECT().IsActiveColleague(ctx, delegateID, delegatorID).Return(false, nil)
+			},
+			expectedErr: ErrDelegateNotFound,
+		},
+		{
+			name: "custom role holding role.manage",
+			req:  CreateDelegationReq{DelegateID: delegateID.String(), Role: "it_lead", EndsAt: endsAt},
+			setupMocks: func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {
+				repo.EXPECT().UserHasRole(ctx, delegatorID, "it_lead").Return(true, nil)
+				repo.EXPECT().GetPermissionsByRoles(ctx, []string{"it_lead"}).Return([]string{"asset.read", "role.manage"}, nil)
+			},
+			expectedErr: ErrPrivilegedDelegation,
+		},
+		{
+			name:        "delegating to yourself",
+			req:         CreateDelegationReq{DelegateID: delegatorID.String(), Role: "asset_manager", EndsAt: endsAt},
+			setupMocks:  func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {},
+			expectedErr: ErrSelfDelegation,
+		},
+		{
+			name:        "window already over",
+			req:         CreateDelegationReq{DelegateID: delegateID.String(), Role: "asset_manager", EndsAt: time.Now().Add(-time.Hour)},
+			setupMocks:  func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {},
+			expectedErr: ErrInvalidDelegationTime,
+		},
+		{
+			name:        "ends before it starts",
+			req:         CreateDelegationReq{DelegateID: delegateID.String(), Role: "asset_manager", StartsAt: &endsAt, EndsAt: endsAt.Add(-time.Hour)},
+			setupMocks:  func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {},
+			expectedErr: ErrInvalidDelegationTime,
+		},
+		{
+			name:        "longer than 90 days",
+			req:         CreateDelegationReq{DelegateID: delegateID.String(), Role: "asset_manager", EndsAt: time.Now().Add(91 * 24 * time.Hour)},
+			setupMocks:  func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {},
+			errContains: "longer than 90 days",
+		},
+		{
+			name: "delegator doesn't hold the role",
+			req:  CreateDelegationReq{DelegateID: delegateID.String(), Role: "asset_manager", EndsAt: endsAt},
+			setupMocks: func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {
+				repo.EXPECT().UserHasRole(ctx, delegatorID, "asset_manager").Return(false, nil)
+			},
+			errContains: "you do not have the role: asset_manager",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			mockRepo := NewMockPermissionRepository(ctrl)
+			mockAudit := auditservice.NewMockAuditService(ctrl)
+			mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+			mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+			tc.setupMocks(mockRepo, mockAudit, mock)
+
+			service := &permissionServiceStruct{repo: mockRepo, db: sqlx.NewDb(db, "postgres"), logger: mockLogger, audit: mockAudit}
+			id, err := service.CreateDelegation(ctx, tc.req, delegatorID)
+			switch {
+			case tc.expectedErr != nil:
+				assert.ErrorIs(t, err, tc.expectedErr)
+			case tc.errContains != "":
+				assert.ErrorContains(t, err, tc.errContains)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, delegationID, id)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestRevokeDelegation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	delegatorID := uuid.New()
+	adminID := uuid.New()
+	delegationID := uuid.New()
+	orgID := uuid.New()
+	orgScope := models.DepartmentScope{AllDepartments: true, OrganizationID: &orgID}
+	delegation := DelegationRes{ID: delegationID, DelegatorID: delegatorID, DelegateID: uuid.New(), Role: "asset_manager"}
+
+	tests := []struct {
+		name         string
+		userID       uuid.UUID
+		anyDelegator bool
+		scope        models.DepartmentScope
+		setupMocks   func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock)
+		expectedErr  error
+	}{
+		{
+			name:   "delegator revokes their own",
+			userID: delegatorID,
+			setupMocks: func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetDelegationByID(ctx, delegationID, (*uuid.UUID)(nil)).Return(delegation, nil)
+				db.ExpectBegin()
+				repo.EXPECT().RevokeDelegation(inTx, delegationID, delegatorID).Return(nil)
+				audit.EXPECT().Record(inTx, gomock.Any()).Return(nil)
+				db.ExpectCommit()
+			},
+		},
+		{
+			name:   "someone else on the self-service route",
+			userID: adminID,
+			setupMocks: func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetDelegationByID(ctx, delegationID, (*uuid.UUID)(nil)).Return(delegation, nil)
+			},
+			expectedErr: ErrNotDelegator,
+		},
+		{
+			name:         "role manager on the admin route",
+			userID:       adminID,
+			anyDelegator: true,
+			scope:        orgScope,
+			setupMocks: func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetDelegationByID(ctx, delegationID, &orgID).Return(delegation, nil)
+				db.ExpectBegin()
+				repo.EXPECT().RevokeDelegation(inTx, delegationID, adminID).Return(nil)
+				audit.EXPECT().Record(inTx, gomock.Any()).Return(nil)
+				db.ExpectCommit()
+			},
+		},
+		{
+			name:         "role manager of another organization",
+			userID:       adminID,
+			anyDelegator: true,
+			scope:        orgScope,
+			setupMocks: func(repo *MockPermissionRepository, audit *auditservice.MockAuditService, db sqlmock.Sqlmock) {
+				repo.EXPECT().GetDelegationByID(ctx, delegationID, &orgID).Return(DelegationRes{}, ErrDelegationNotFound)
+			},
+			expectedErr: ErrDelegationNotFound,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
+			mockRepo := NewMockPermissionRepository(ctrl)
+			mockAudit := auditservice.NewMockAuditService(ctrl)
+			mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+			mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+			tc.setupMocks(mockRepo, mockAudit, mock)
+
+			service := &permissionServiceStruct{repo: mockRepo, db: sqlx.NewDb(db, "postgres"), logger: mockLogger, audit: mockAudit}
+			err = service.RevokeDelegation(ctx, delegationID, tc.userID, tc.anyDelegator, tc.scope)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package privacyservice
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ExportFormatJSON = "json"
+	ExportFormatZIP  = "zip"
+)
+
+// SubjectExport is everything stored about a person for a subject access request. Each section is the
+// json of one kind of record, the zip archive holds a file per section
+type SubjectExport struct {
+	UserID     uuid.UUID                  `json:"user_id"`
+	ExportedAt time.Time                  `json:"exported_at"`
+	Sections   map[string]json.RawMessage `json:"sections"`
+}
+
+// subjectSection reads one section as json, the query takes the user id as $1 and returns a single
+// json value
+type subjectSection struct {
+	Name  string
+	Query string
+}
+
+// SubjectState is the part of a user erasing them depends on
+type SubjectState struct {
+	Email        string     `db:"email"`
+	FirebaseUID  *string    `db:"firebase_uid"`
+	ArchivedAt   *time.Time `db:"archived_at"`
+	AnonymizedAt *time.Time `db:"anonymized_at"`
+}
+
+const (
+	RetentionArchivedUsers     = "archived_users"
+	RetentionAuditEntries      = "audit_entries"
+	RetentionClosedAssignments = "closed_assignments"
+)
+
+// RetentionReport is what the purge_expired_records job removes on its next run, DryRun is set when
+// the job only reports it
+type RetentionReport struct {
+	DryRun      bool            `json:"dry_run"`
+	GeneratedAt time.Time       `json:"generated_at"`
+	Policies    []RetentionItem `json:"policies"`
+}
+
+// RetentionItem is one configured policy, the records older than Before it applies Action to. With
+// the delete action users that are still referred to are anonymized instead
+type RetentionItem struct {
+	Records string    `json:"records"`
+	Action  string    `json:"action"`
+	Years   int       `json:"years"`
+	Before  time.Time `json:"before"`
+	Count   int64     `json:"count"`
+}
+
+// Zip packs the export as an archive with export.json describing it and a json file per section
+func (e SubjectExport) Zip() ([]byte, error) {
+	names := make([]string, 0, len(e.Sections))
+	for name := range e.Sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	manifest, err := json.MarshalIndent(map[string]interface{}{"user_id": e.UserID, "exported_at": e.ExportedAt, "sections": names}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{"export.json": manifest}
+	for _, name := range names {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, e.Sections[name], "", "  "); err != nil {
+			return nil, err
+		}
+		files[name+".json"] = indented.Bytes()
+	}
+	for _, name := range append([]string{"export"}, names...) {
+		file, err := archive.CreateHeader(&zip.FileHeader{Name: name + ".json", Method: zip.Deflate, Modified: e.ExportedAt})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := file.Write(files[name+".json"]); err != nil {
+			return nil, err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
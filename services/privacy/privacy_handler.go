@@ -0,0 +1,148 @@
+package privacyservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type PrivacyHandler struct {
+	Service        PrivacyService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewPrivacyHandler(service PrivacyService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *PrivacyHandler {
+	return &PrivacyHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+// ExportUserData downloads everything stored about the user as one json document, or with format=zip
+// as an archive with a json file per section
+func (h *PrivacyHandler) ExportUserData(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("ExportUserData request received")
+	actorID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in ExportUserData", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	actorUUID, err := uuid.Parse(actorID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in ExportUserData", zap.String("userID", actorID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+	query := r.URL.Query()
+	userID, err := uuid.Parse(query.Get("user_id"))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid user id")
+		return
+	}
+	format := query.Get("format")
+	if format == "" {
+		format = ExportFormatJSON
+	}
+	if format != ExportFormatJSON && format != ExportFormatZIP {
+		utils.RespondError(w, http.StatusBadRequest, fmt.Errorf("unsupported export format %q", format), "format must be json or zip")
+		return
+	}
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in ExportUserData", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	export, err := h.Service.ExportSubject(r.Context(), userID, actorUUID, scope)
+	if err != nil {
+		if errors.Is(err, models.ErrOutOfScope) {
+			utils.RespondError(w, http.StatusForbidden, err, "user belongs to another department")
+			return
+		}
+		h.Logger.GetLogger().Error("Failed to export user data", zap.String("userID", userID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to export user data")
+		return
+	}
+
+	filename := "user-" + userID.String()
+	if format == ExportFormatZIP {
+		archive, err := export.Zip()
+		if err != nil {
+			h.Logger.GetLogger().Error("Failed to pack user data export", zap.String("userID", userID.String()), zap.Error(err))
+			utils.RespondError(w, http.StatusInternalServerError, err, "failed to export user data")
+			return
+		}
+		utils.RespondFile(w, "application/zip", filename+".zip", archive)
+		return
+	}
+	document, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to export user data")
+		return
+	}
+	utils.RespondFile(w, "application/json", filename+".json", document)
+}
+
+// AnonymizeUser scrubs the name, email and contact details of an archived user, their assignment
+// history stays under the user id
+func (h *PrivacyHandler) AnonymizeUser(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("AnonymizeUser request received")
+	actorID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in AnonymizeUser", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	actorUUID, err := uuid.Parse(actorID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in AnonymizeUser", zap.String("userID", actorID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+	userID, err := uuid.Parse(r.URL.Query().Get("user_id"))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid user id")
+		return
+	}
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in AnonymizeUser", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	if err := h.Service.AnonymizeUser(r.Context(), userID, actorUUID, scope); err != nil {
+		if errors.Is(err, models.ErrOutOfScope) {
+			utils.RespondError(w, http.StatusForbidden, err, "user belongs to another department")
+			return
+		}
+		h.Logger.GetLogger().Error("Failed to anonymize user", zap.String("userID", userID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to anonymize user")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "user anonymized successfully"})
+}
+
+// GetRetentionPreview reports what the retention policies would purge if the job ran now, nothing is
+// changed
+func (h *PrivacyHandler) GetRetentionPreview(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetRetentionPreview request received")
+	report, err := h.Service.PreviewRetention(r.Context())
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to preview retention", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to preview retention")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,330 @@
+package privacyservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type PrivacyRepository interface {
+	// GetSubjectSections returns the json of every section of subjectSections, ErrSubjectNotFound when
+	// the user never existed
+	GetSubjectSections(ctx context.Context, userID uuid.UUID) (map[string]json.RawMessage, error)
+	// GetSubjectState returns what erasing the user needs to know, ErrSubjectNotFound when the user
+	// never existed
+	GetSubjectState(ctx context.Context, userID uuid.UUID) (SubjectState, error)
+	// CountArchivedUsersBefore counts users archived before the cutoff that weren't anonymized yet
+	CountArchivedUsersBefore(ctx context.Context, before time.Time) (int64, error)
+	GetArchivedUsersBefore(ctx context.Context, before time.Time, limit int) ([]uuid.UUID, error)
+	// AnonymizeUser scrubs the personal data of an archived user, the row and what refers to it stay
+	AnonymizeUser(ctx context.Context, userID uuid.UUID) error
+	// DeleteUser removes an archived user with their personal records, false when other records still
+	// refer to the user and nothing was removed
+	DeleteUser(ctx context.Context, userID uuid.UUID) (bool, error)
+	CountAuditLogsBefore(ctx context.Context, before time.Time) (int64, error)
+	DeleteAuditLogsBefore(ctx context.Context, before time.Time) (int64, error)
+	// CountClosedAssignmentsBefore counts assignments returned before the cutoff, live and moved to history
+	CountClosedAssignmentsBefore(ctx context.Context, before time.Time) (int64, error)
+	DeleteClosedAssignmentsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type PostgresPrivacyRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewPrivacyRepository(db *sqlx.DB, log providers.ZapLoggerProvider) PrivacyRepository {
+	return &PostgresPrivacyRepository{DB: db, Logger: log}
+}
+
+// profileSection is read first, the user's absence ends the export
+var profileSection = subjectSection{Name: "profile", Query: `
+	SELECT to_jsonb(p) FROM (
+		SELECT u.id, u.username, u.email, u.contact_no, u.designation, u.location, u.date_of_joining, u.end_date,
+			u.emergency_contact_name, u.emergency_contact_no, u.auth_provider, d.name AS department,
+			u.email_verified_at, u.contact_verified_at, u.created_at, u.archived_at
+		FROM users u
+		LEFT JOIN departments d ON d.id = u.department_id
+		WHERE u.id = $1
+	) p`}
+
+// subjectSections are the records kept about a user besides the profile. Secrets are left out: device
+// tokens, the mfa secret and backup codes, api keys and the tokens of pending email changes
+var subjectSections = []subjectSection{
+	{Name: "employee_types", Query: `
+		SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.created_at), '[]') FROM (
+			SELECT type, created_at, last_updated_at, archived_at FROM user_type WHERE user_id = $1
+		) t`},
+	{Name: "assignments", Query: `
+		SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.assigned_at), '[]') FROM (
+			SELECT aa.asset_id, a.brand, a.model, a.serial_no, a.type, aa.assigned_at, aa.returned_at, aa.return_reason, aa.archived_at
+			FROM asset_assign_all aa
+			JOIN assets a ON a.id = aa.asset_id
+			WHERE aa.employee_id = $1
+		) t`},
+	{Name: "return_requests", Query: `
+		SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.created_at), '[]') FROM (
+			SELECT rr.asset_id, a.serial_no, rr.reason, rr.status, rr.created_at, rr.resolved_at
+			FROM asset_return_requests rr
+			JOIN assets a ON a.id = rr.asset_id
+			WHERE rr.employee_id = $1
+		) t`},
+	{Name: "signatures", Query: `
+		SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.created_at), '[]') FROM (
+			SELECT asset_id, kind, status, created_at, sent_at, completed_at FROM handover_signatures WHERE employee_id = $1
+		) t`},
+	{Name: "theft_reports", Query: `
+		SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.reported_at), '[]') FROM (
+			SELECT asset_id, note, reported_at FROM asset_theft_reports WHERE reported_by = $1
+		) t`},
+	{Name: "role_delegations", Query: `
+		SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.created_at), '[]') FROM (
+			SELECT delegator_id, delegate_id, role, reason, starts_at, ends_at, created_at, revoked_at, expired_at
+			FROM role_delegations
+			WHERE delegator_id = $1 OR delegate_id = $1
+		) t`},
+	{Name: "audit_entries", Query: `
+		SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.created_at), '[]') FROM (
+			SELECT id, actor_id, action, entity_type, entity_id, old_value, new_value, created_at
+			FROM audit_logs
+			WHERE actor_id = $1 OR (entity_type = 'user' AND entity_id = $1::text)
+		) t`},
+	{Name: "auth_events", Query: `
+		SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.created_at), '[]') FROM (
+			SELECT event_type, method, outcome, identifier, ip_address, user_agent, reason, created_at
+			FROM auth_events
+			WHERE user_id = $1
+		) t`},
+	{Name: "identities", Query: `
+		SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.created_at), '[]') FROM (
+			SELECT provider, issuer, subject, email, created_at, last_login_at FROM user_identities WHERE user_id = $1
+		) t`},
+	{Name: "mfa", Query: `
+		SELECT COALESCE((
+			SELECT to_jsonb(t) FROM (
+				SELECT m.created_at, m.enabled_at,
+					(SELECT count(*) FROM user_mfa_backup_codes b WHERE b.user_id = m.user_id AND b.used_at IS NULL) AS unused_backup_codes
+				FROM user_mfa m
+				WHERE m.user_id = $1
+			) t
+		), 'null'::jsonb)`},
+	{Name: "notifications", Query: `
+		SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.created_at), '[]') FROM (
+			SELECT category, title, body, entity_type, entity_id, created_at, read_at FROM notifications WHERE user_id = $1
+		) t`},
+	{Name: "notification_preferences", Query: `
+		SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.category, t.channel), '[]') FROM (
+			SELECT category, channel, enabled, updated_at FROM notification_preferences WHERE user_id = $1
+		) t`},
+	{Name: "push_notifications", Query: `
+		SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.created_at), '[]') FROM (
+			SELECT event_type, title, body, status, created_at, sent_at FROM push_notifications WHERE user_id = $1
+		) t`},
+	{Name: "devices", Query: `
+		SELECT COALESCE(jsonb_agg(to_jsonb(t) ORDER BY t.created_at), '[]') FROM (
+			SELECT platform, created_at, last_seen_at FROM device_tokens WHERE user_id = $1
+		) t`},
+}
+
+func (r *PostgresPrivacyRepository) GetSubjectSections(ctx context.Context, userID uuid.UUID) (map[string]json.RawMessage, error) {
+	sections := make(map[string]json.RawMessage, len(subjectSections)+1)
+	conn := utils.Conn(ctx, r.DB)
+	for _, section := range append([]subjectSection{profileSection}, subjectSections...) {
+		var data []byte
+		err := conn.GetContext(ctx, &data, section.Query, userID)
+		if section.Name == profileSection.Name && errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSubjectNotFound
+		}
+		if err != nil {
+			r.Logger.GetLogger().Error("failed to read subject data", zap.String("section", section.Name), zap.String("userID", userID.String()), zap.Error(err))
+			return nil, fmt.Errorf("failed to read %s of user: %w", section.Name, err)
+		}
+		sections[section.Name] = data
+	}
+	return sections, nil
+}
+
+// personalRecords are deleted with the personal data of a user, they are only about the user and no
+// other record refers to them
+var personalRecords = []string{
+	`DELETE FROM user_identities WHERE user_id = $1`,
+	`DELETE FROM user_mfa_backup_codes WHERE user_id = $1`,
+	`DELETE FROM user_mfa WHERE user_id = $1`,
+	`DELETE FROM device_tokens WHERE user_id = $1`,
+	`DELETE FROM push_notifications WHERE user_id = $1`,
+	`DELETE FROM notifications WHERE user_id = $1`,
+	`DELETE FROM notification_preferences WHERE user_id = $1`,
+	`DELETE FROM email_change_requests WHERE user_id = $1`,
+	`DELETE FROM auth_events WHERE user_id = $1`,
+}
+
+func (r *PostgresPrivacyRepository) GetSubjectState(ctx context.Context, userID uuid.UUID) (SubjectState, error) {
+	var state SubjectState
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &state, `
+		SELECT email, firebase_uid, archived_at, anonymized_at FROM users WHERE id = $1
+	`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return SubjectState{}, ErrSubjectNotFound
+	}
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch user state", zap.String("userID", userID.String()), zap.Error(err))
+		return SubjectState{}, fmt.Errorf("failed to fetch user state: %w", err)
+	}
+	return state, nil
+}
+
+func (r *PostgresPrivacyRepository) CountArchivedUsersBefore(ctx context.Context, before time.Time) (int64, error) {
+	var count int64
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &count, `
+		SELECT count(*) FROM users WHERE archived_at < $1 AND anonymized_at IS NULL
+	`, before)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to count expired users", zap.Time("before", before), zap.Error(err))
+		return 0, fmt.Errorf("failed to count expired users: %w", err)
+	}
+	return count, nil
+}
+
+func (r *PostgresPrivacyRepository) GetArchivedUsersBefore(ctx context.Context, before time.Time, limit int) ([]uuid.UUID, error) {
+	ids := []uuid.UUID{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &ids, `
+		SELECT id FROM users
+		WHERE archived_at < $1 AND anonymized_at IS NULL
+		ORDER BY archived_at
+		LIMIT $2
+	`, before, limit)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch expired users", zap.Time("before", before), zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch expired users: %w", err)
+	}
+	return ids, nil
+}
+
+// AnonymizeUser keeps the user id as a pseudonym, the name becomes one derived from it. The values
+// audited about the user are cleared since they hold the old profile
+func (r *PostgresPrivacyRepository) AnonymizeUser(ctx context.Context, userID uuid.UUID) error {
+	return utils.WithTransaction(ctx, r.DB, func(ctx context.Context) error {
+		conn := utils.Conn(ctx, r.DB)
+		for _, query := range personalRecords {
+			if _, err := conn.ExecContext(ctx, query, userID); err != nil {
+				r.Logger.GetLogger().Error("failed to delete personal records", zap.String("userID", userID.String()), zap.Error(err))
+				return fmt.Errorf("failed to delete personal records of user: %w", err)
+			}
+		}
+		_, err := conn.ExecContext(ctx, `
+			UPDATE audit_logs SET old_value = NULL, new_value = NULL
+			WHERE entity_type = 'user' AND entity_id = $1::text
+		`, userID)
+		if err != nil {
+			r.Logger.GetLogger().Error("failed to clear audited user values", zap.String("userID", userID.String()), zap.Error(err))
+			return fmt.Errorf("failed to clear audited user values: %w", err)
+		}
+		_, err = conn.ExecContext(ctx, `
+			UPDATE users
+			SET username = 'former-employee-' || left(id::text, 8),
+				email = 'anonymized-' || id || '@invalid',
+				contact_no = NULL,
+				emergency_contact_name = NULL,
+				emergency_contact_no = NULL,
+				designation = NULL,
+				location = NULL,
+				date_of_joining = NULL,
+				firebase_uid = NULL,
+				anonymized_at = now()
+			WHERE id = $1 AND archived_at IS NOT NULL
+		`, userID)
+		if err != nil {
+			r.Logger.GetLogger().Error("failed to anonymize user", zap.String("userID", userID.String()), zap.Error(err))
+			return fmt.Errorf("failed to anonymize user: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteUser rolls back on the first foreign key violation, assignments, assets added and most
+// other records keep a user around. It runs in a transaction of its own, the violation would abort
+// one ctx carries
+func (r *PostgresPrivacyRepository) DeleteUser(ctx context.Context, userID uuid.UUID) (bool, error) {
+	queries := slices.Concat(personalRecords, []string{
+		`DELETE FROM user_roles WHERE user_id = $1`,
+		`DELETE FROM user_type WHERE user_id = $1`,
+		`DELETE FROM users WHERE id = $1 AND archived_at IS NOT NULL`,
+	})
+	err := utils.WithTransaction(ctx, r.DB, func(ctx context.Context) error {
+		conn := utils.Conn(ctx, r.DB)
+		for _, query := range queries {
+			if _, err := conn.ExecContext(ctx, query, userID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		return false, nil
+	}
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to delete user", zap.String("userID", userID.String()), zap.Error(err))
+		return false, fmt.Errorf("failed to delete user: %w", err)
+	}
+	return true, nil
+}
+
+func (r *PostgresPrivacyRepository) CountAuditLogsBefore(ctx context.Context, before time.Time) (int64, error) {
+	var count int64
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &count, `SELECT count(*) FROM audit_logs WHERE created_at < $1`, before)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to count expired audit logs", zap.Time("before", before), zap.Error(err))
+		return 0, fmt.Errorf("failed to count expired audit logs: %w", err)
+	}
+	return count, nil
+}
+
+func (r *PostgresPrivacyRepository) DeleteAuditLogsBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `DELETE FROM audit_logs WHERE created_at < $1`, before)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to purge audit logs", zap.Time("before", before), zap.Error(err))
+		return 0, fmt.Errorf("failed to purge audit logs: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func (r *PostgresPrivacyRepository) CountClosedAssignmentsBefore(ctx context.Context, before time.Time) (int64, error) {
+	var count int64
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &count, `SELECT count(*) FROM asset_assign_all WHERE returned_at < $1`, before)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to count expired assignments", zap.Time("before", before), zap.Error(err))
+		return 0, fmt.Errorf("failed to count expired assignments: %w", err)
+	}
+	return count, nil
+}
+
+// DeleteClosedAssignmentsBefore deletes from both the live and the history table, assignments are
+// only moved once they are ASSIGNMENT_HISTORY_MONTHS old
+func (r *PostgresPrivacyRepository) DeleteClosedAssignmentsBefore(ctx context.Context, before time.Time) (int64, error) {
+	var count int64
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &count, `
+		WITH live AS (
+			DELETE FROM asset_assign WHERE returned_at < $1 RETURNING 1
+		), history AS (
+			DELETE FROM asset_assign_history WHERE returned_at < $1 RETURNING 1
+		)
+		SELECT (SELECT count(*) FROM live) + (SELECT count(*) FROM history)
+	`, before)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to purge assignments", zap.Time("before", before), zap.Error(err))
+		return 0, fmt.Errorf("failed to purge assignments: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,89 @@
+//go:build integration
+
+package privacyservice
+
+import (
+	"asset/database/seed"
+	"asset/database/testdb"
+	"asset/providers"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// a user only their own records refer to is deleted, one with an assignment is left alone and then
+// anonymized
+func TestPurgeArchivedUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewPrivacyRepository(db, logger)
+	ctx := context.Background()
+	archivedAt := time.Now().AddDate(-3, 0, 0)
+	bare, referenced := archivedUser(t, db, archivedAt), archivedUser(t, db, archivedAt)
+	_, err := db.Exec(`
+		INSERT INTO asset_assign_history (id, asset_id, employee_id, assigned_at, returned_at, return_reason)
+		VALUES ($1, $2, $3, $4, $4, 'left')
+	`, uuid.New(), seed.ID("asset:accessory-1"), referenced, archivedAt)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Exec(`DELETE FROM asset_assign_history WHERE employee_id = $1`, referenced) })
+
+	ids, err := repo.GetArchivedUsersBefore(ctx, time.Now().AddDate(-2, 0, 0), 100)
+	require.NoError(t, err)
+	assert.Subset(t, ids, []uuid.UUID{bare, referenced})
+
+	deleted, err := repo.DeleteUser(ctx, bare)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	deleted, err = repo.DeleteUser(ctx, referenced)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	require.NoError(t, repo.AnonymizeUser(ctx, referenced))
+	var user struct {
+		Username     string     `db:"username"`
+		Email        string     `db:"email"`
+		ContactNo    *string    `db:"contact_no"`
+		AnonymizedAt *time.Time `db:"anonymized_at"`
+	}
+	require.NoError(t, db.Get(&user, `SELECT username, email, contact_no, anonymized_at FROM users WHERE id = $1`, referenced))
+	assert.Equal(t, "former-employee-"+referenced.String()[:8], user.Username)
+	assert.Equal(t, "anonymized-"+referenced.String()+"@invalid", user.Email)
+	assert.Nil(t, user.ContactNo)
+	assert.NotNil(t, user.AnonymizedAt)
+
+	var remaining int
+	require.NoError(t, db.Get(&remaining, `SELECT count(*) FROM users WHERE id = ANY($1)`, []string{bare.String(), referenced.String()}))
+	assert.Equal(t, 1, remaining)
+	ids, err = repo.GetArchivedUsersBefore(ctx, time.Now().AddDate(-2, 0, 0), 100)
+	require.NoError(t, err)
+	assert.NotContains(t, ids, referenced)
+}
+
+// archivedUser adds a user archived at archivedAt with a role of their own
+func archivedUser(t *testing.T, db *sqlx.DB, archivedAt time.Time) uuid.UUID {
+	t.Helper()
+	id := uuid.New()
+	_, err := db.Exec(`
+		INSERT INTO users (id, username, email, contact_no, created_by, archived_at)
+		VALUES ($1, 'Gone Person', $2, $3, $4, $5)
+	`, id, id.String()+"@example.com", id.String()[:10], seed.ID("user:admin"), archivedAt)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO user_roles (role, user_id, created_by) VALUES ('employee', $1, $2)`, id, seed.ID("user:admin"))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM user_roles WHERE user_id = $1`, id)
+		db.Exec(`DELETE FROM users WHERE id = $1`, id)
+	})
+	return id
+}
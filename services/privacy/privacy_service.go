@@ -0,0 +1,285 @@
+package privacyservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/audit"
+	"asset/services/user"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	firebaseauth "firebase.google.com/go/v4/auth"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// PrivacyService answers subject access requests, an export gathers what is stored about a person
+// from every table that keeps it along with what the cache holds about them
+type PrivacyService interface {
+	ExportSubject(ctx context.Context, userID, actorID uuid.UUID, scope models.DepartmentScope) (SubjectExport, error)
+	// AnonymizeUser scrubs the personal data of an archived user on request, their assignments stay
+	// under the user id
+	AnonymizeUser(ctx context.Context, userID, actorID uuid.UUID, scope models.DepartmentScope) error
+	// PreviewRetention counts what the retention policies would purge now without touching it
+	PreviewRetention(ctx context.Context) (RetentionReport, error)
+	// ApplyRetention is run by the purge_expired_records job
+	ApplyRetention(ctx context.Context) error
+}
+
+var (
+	ErrSubjectNotFound    = models.NewServiceError(http.StatusNotFound, "user_not_found", "user not found")
+	ErrSubjectNotArchived = models.NewServiceError(http.StatusConflict, "user_not_archived", "only archived users can be anonymized")
+	ErrSubjectAnonymized  = models.NewServiceError(http.StatusConflict, "user_anonymized", "user is already anonymized")
+)
+
+const (
+	// timelinePageSize is how many timeline events are read at a time
+	timelinePageSize = 500
+	// retentionBatchSize is how many expired users are read at a time
+	retentionBatchSize = 100
+)
+
+type privacyServiceStruct struct {
+	repo     PrivacyRepository
+	users    userservice.UserService
+	cache    providers.UserCacheProvider
+	audit    auditservice.AuditService
+	firebase providers.FirebaseProvider
+	logger   providers.ZapLoggerProvider
+	// retention is how long archived and closed records are kept
+	retention models.RetentionPolicy
+}
+
+func NewPrivacyService(repo PrivacyRepository, users userservice.UserService, cache providers.UserCacheProvider, audit auditservice.AuditService, firebase providers.FirebaseProvider, logger providers.ZapLoggerProvider, retention models.RetentionPolicy) PrivacyService {
+	return &privacyServiceStruct{repo: repo, users: users, cache: cache, audit: audit, firebase: firebase, logger: logger, retention: retention}
+}
+
+// ExportSubject checks the user is in the caller's department before reading anything, the export
+// itself is recorded in the audit log
+func (s *privacyServiceStruct) ExportSubject(ctx context.Context, userID, actorID uuid.UUID, scope models.DepartmentScope) (SubjectExport, error) {
+	roles, err := s.users.GetRoleHistory(ctx, userID, scope)
+	if err != nil {
+		return SubjectExport{}, err
+	}
+	sections, err := s.repo.GetSubjectSections(ctx, userID)
+	if err != nil {
+		return SubjectExport{}, err
+	}
+	timeline, err := s.timeline(ctx, userID, scope)
+	if err != nil {
+		return SubjectExport{}, err
+	}
+
+	var profile struct {
+		Email string `json:"email"`
+	}
+	if err := json.Unmarshal(sections[profileSection.Name], &profile); err != nil {
+		return SubjectExport{}, fmt.Errorf("failed to read exported profile: %w", err)
+	}
+	cached, err := s.cache.Entries(ctx, userID, profile.Email)
+	if err != nil {
+		return SubjectExport{}, err
+	}
+
+	for name, value := range map[string]interface{}{"roles": roles, "timeline": timeline, "cached_entries": cached} {
+		if sections[name], err = json.Marshal(value); err != nil {
+			return SubjectExport{}, fmt.Errorf("failed to encode %s: %w", name, err)
+		}
+	}
+
+	if err := s.audit.Record(ctx, auditservice.AuditEntry{
+		ActorID:    &actorID,
+		Action:     "user.data_exported",
+		EntityType: "user",
+		EntityID:   userID.String(),
+	}); err != nil {
+		return SubjectExport{}, err
+	}
+	s.logger.GetLogger().Info("user data exported", zap.String("userID", userID.String()), zap.String("actorID", actorID.String()), zap.Int("sections", len(sections)))
+	return SubjectExport{UserID: userID, ExportedAt: time.Now().UTC(), Sections: sections}, nil
+}
+
+// timeline reads every page of the user's timeline, newest first like the timeline endpoint
+func (s *privacyServiceStruct) timeline(ctx context.Context, userID uuid.UUID, scope models.DepartmentScope) ([]userservice.UserTimelineRes, error) {
+	timeline := []userservice.UserTimelineRes{}
+	for offset := 0; ; offset += timelinePageSize {
+		page, err := s.users.GetEmployeeTimeline(ctx, userID, timelinePageSize, offset, scope)
+		if err != nil {
+			return nil, err
+		}
+		timeline = append(timeline, page...)
+		if len(page) < timelinePageSize {
+			return timeline, nil
+		}
+	}
+}
+
+// retentionPolicies lists the policies with a number of years set, the cutoffs are counted back from now
+func (s *privacyServiceStruct) retentionPolicies(now time.Time) []RetentionItem {
+	var items []RetentionItem
+	add := func(records, action string, years int) {
+		if years > 0 {
+			items = append(items, RetentionItem{Records: records, Action: action, Years: years, Before: now.AddDate(-years, 0, 0)})
+		}
+	}
+	add(RetentionClosedAssignments, models.RetentionDelete, s.retention.ClosedAssignmentYears)
+	add(RetentionAuditEntries, models.RetentionDelete, s.retention.AuditLogYears)
+	add(RetentionArchivedUsers, s.retention.ArchivedUserAction, s.retention.ArchivedUserYears)
+	return items
+}
+
+func (s *privacyServiceStruct) PreviewRetention(ctx context.Context) (RetentionReport, error) {
+	now := time.Now().UTC()
+	report := RetentionReport{DryRun: s.retention.DryRun, GeneratedAt: now, Policies: []RetentionItem{}}
+	for _, item := range s.retentionPolicies(now) {
+		var err error
+		switch item.Records {
+		case RetentionClosedAssignments:
+			item.Count, err = s.repo.CountClosedAssignmentsBefore(ctx, item.Before)
+		case RetentionAuditEntries:
+			item.Count, err = s.repo.CountAuditLogsBefore(ctx, item.Before)
+		case RetentionArchivedUsers:
+			item.Count, err = s.repo.CountArchivedUsersBefore(ctx, item.Before)
+		}
+		if err != nil {
+			return RetentionReport{}, err
+		}
+		report.Policies = append(report.Policies, item)
+	}
+	return report, nil
+}
+
+// ApplyRetention purges assignments and audit entries before users, those rows are what keeps a user
+// from being deleted. In a dry run the preview is only logged
+func (s *privacyServiceStruct) ApplyRetention(ctx context.Context) error {
+	if s.retention.DryRun {
+		report, err := s.PreviewRetention(ctx)
+		if err != nil {
+			return err
+		}
+		for _, item := range report.Policies {
+			s.logger.GetLogger().Info("retention dry run", zap.String("records", item.Records), zap.String("action", item.Action), zap.Time("before", item.Before), zap.Int64("count", item.Count))
+		}
+		return nil
+	}
+	for _, item := range s.retentionPolicies(time.Now().UTC()) {
+		var err error
+		switch item.Records {
+		case RetentionClosedAssignments:
+			item.Count, err = s.repo.DeleteClosedAssignmentsBefore(ctx, item.Before)
+		case RetentionAuditEntries:
+			item.Count, err = s.repo.DeleteAuditLogsBefore(ctx, item.Before)
+		case RetentionArchivedUsers:
+			item.Count, err = s.purgeUsers(ctx, item)
+		}
+		if err != nil {
+			return err
+		}
+		s.logger.GetLogger().Info("purged expired records", zap.String("records", item.Records), zap.String("action", item.Action), zap.Time("before", item.Before), zap.Int64("count", item.Count))
+	}
+	return nil
+}
+
+// purgeUsers deletes or anonymizes the users archived before the cutoff a batch at a time, each is
+// recorded in the audit log
+func (s *privacyServiceStruct) purgeUsers(ctx context.Context, item RetentionItem) (int64, error) {
+	var purged int64
+	for {
+		ids, err := s.repo.GetArchivedUsersBefore(ctx, item.Before, retentionBatchSize)
+		if err != nil {
+			return purged, err
+		}
+		for _, id := range ids {
+			state, err := s.repo.GetSubjectState(ctx, id)
+			if err != nil {
+				return purged, err
+			}
+			action, err := s.erase(ctx, id, state, item.Action == models.RetentionDelete)
+			if err != nil {
+				return purged, err
+			}
+			if err := s.audit.Record(ctx, auditservice.AuditEntry{Action: action, EntityType: "user", EntityID: id.String()}); err != nil {
+				return purged, err
+			}
+			purged++
+		}
+		if len(ids) < retentionBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// AnonymizeUser checks the user is in the caller's department like ExportSubject, active users are
+// archived through the delete routes first so their assets are returned
+func (s *privacyServiceStruct) AnonymizeUser(ctx context.Context, userID, actorID uuid.UUID, scope models.DepartmentScope) error {
+	if _, err := s.users.GetRoleHistory(ctx, userID, scope); err != nil {
+		return err
+	}
+	state, err := s.repo.GetSubjectState(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if state.ArchivedAt == nil {
+		return ErrSubjectNotArchived
+	}
+	if state.AnonymizedAt != nil {
+		return ErrSubjectAnonymized
+	}
+	action, err := s.erase(ctx, userID, state, false)
+	if err != nil {
+		return err
+	}
+	if err := s.audit.Record(ctx, auditservice.AuditEntry{
+		ActorID:    &actorID,
+		Action:     action,
+		EntityType: "user",
+		EntityID:   userID.String(),
+	}); err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("user anonymized", zap.String("userID", userID.String()), zap.String("actorID", actorID.String()))
+	return nil
+}
+
+// erase deletes the firebase account of the user and then deletes or anonymizes the row, the firebase
+// uid is gone after that. What is cached under the id and the old address is dropped. It returns the
+// action to audit
+func (s *privacyServiceStruct) erase(ctx context.Context, userID uuid.UUID, state SubjectState, tryDelete bool) (string, error) {
+	uid := ""
+	if state.FirebaseUID != nil {
+		uid = *state.FirebaseUID
+	} else {
+		// accounts made by email sign up aren't linked by uid
+		found, err := s.firebase.GetAuthUserID(ctx, state.Email)
+		if err != nil && !firebaseauth.IsUserNotFound(err) {
+			return "", fmt.Errorf("failed to look up firebase user: %w", err)
+		}
+		uid = found
+	}
+	if uid != "" {
+		if err := s.firebase.DeleteAuthUser(ctx, uid); err != nil && !firebaseauth.IsUserNotFound(err) {
+			s.logger.GetLogger().Error("failed to delete firebase user", zap.String("userID", userID.String()), zap.Error(err))
+			return "", fmt.Errorf("failed to delete firebase user: %w", err)
+		}
+	}
+
+	action := "user.anonymized"
+	deleted := false
+	if tryDelete {
+		var err error
+		if deleted, err = s.repo.DeleteUser(ctx, userID); err != nil {
+			return "", err
+		}
+	}
+	if deleted {
+		action = "user.deleted"
+	} else if err := s.repo.AnonymizeUser(ctx, userID); err != nil {
+		return "", err
+	}
+	s.cache.InvalidateUsers(ctx, userID)
+	s.cache.InvalidateEmails(ctx, state.Email)
+	return action, nil
+}
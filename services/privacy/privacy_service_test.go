@@ -0,0 +1,244 @@
+package privacyservice
+
+import (
+	"archive/zip"
+	"asset/models"
+	"asset/providers"
+	"asset/services/audit"
+	"asset/services/user"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestExportSubject(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	userID, adminID := uuid.New(), uuid.New()
+	scope := models.DepartmentScope{AllDepartments: true}
+
+	repo := NewMockPrivacyRepository(ctrl)
+	users := userservice.NewMockUserService(ctrl)
+	cache := providers.NewMockUserCacheProvider(ctrl)
+	audit := auditservice.NewMockAuditService(ctrl)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	users.EXPECT().GetRoleHistory(ctx, userID, scope).Return([]userservice.RoleHistoryRes{{Role: "employee"}}, nil)
+	repo.EXPECT().GetSubjectSections(ctx, userID).Return(map[string]json.RawMessage{
+		"profile":     json.RawMessage(`{"email": "sam@example.com"}`),
+		"assignments": json.RawMessage(`[]`),
+	}, nil)
+	// the timeline is read page by page until a short one
+	fullPage := make([]userservice.UserTimelineRes, timelinePageSize)
+	users.EXPECT().GetEmployeeTimeline(ctx, userID, timelinePageSize, 0, scope).Return(fullPage, nil)
+	users.EXPECT().GetEmployeeTimeline(ctx, userID, timelinePageSize, timelinePageSize, scope).Return([]userservice.UserTimelineRes{{EventType: "assigned"}}, nil)
+	cache.EXPECT().Entries(ctx, userID, "sam@example.com").Return(map[string]string{"user:dashboard:x": "{}"}, nil)
+	audit.EXPECT().Record(ctx, auditservice.AuditEntry{ActorID: &adminID, Action: "user.data_exported", EntityType: "user", EntityID: userID.String()}).Return(nil)
+
+	svc := NewPrivacyService(repo, users, cache, audit, providers.NewMockFirebaseProvider(ctrl), logger, models.RetentionPolicy{})
+	export, err := svc.ExportSubject(ctx, userID, adminID, scope)
+	require.NoError(t, err)
+	assert.Equal(t, userID, export.UserID)
+	assert.ElementsMatch(t, []string{"profile", "assignments", "roles", "timeline", "cached_entries"}, keys(export.Sections))
+	var timeline []userservice.UserTimelineRes
+	require.NoError(t, json.Unmarshal(export.Sections["timeline"], &timeline))
+	assert.Len(t, timeline, timelinePageSize+1)
+	assert.JSONEq(t, `{"user:dashboard:x": "{}"}`, string(export.Sections["cached_entries"]))
+
+	archive, err := export.Zip()
+	require.NoError(t, err)
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	require.NoError(t, err)
+	var names []string
+	for _, file := range reader.File {
+		names = append(names, file.Name)
+	}
+	assert.Equal(t, []string{"export.json", "assignments.json", "cached_entries.json", "profile.json", "roles.json", "timeline.json"}, names)
+	profile, err := reader.File[3].Open()
+	require.NoError(t, err)
+	data, err := io.ReadAll(profile)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"email": "sam@example.com"}`, string(data))
+}
+
+// a user outside the caller's department is refused before anything is read
+func TestExportSubjectOutOfScope(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	userID := uuid.New()
+	department := uuid.New()
+	scope := models.DepartmentScope{DepartmentID: &department}
+
+	users := userservice.NewMockUserService(ctrl)
+	users.EXPECT().GetRoleHistory(ctx, userID, scope).Return(nil, models.ErrOutOfScope)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+
+	svc := NewPrivacyService(NewMockPrivacyRepository(ctrl), users, providers.NewMockUserCacheProvider(ctrl), auditservice.NewMockAuditService(ctrl), providers.NewMockFirebaseProvider(ctrl), logger, models.RetentionPolicy{})
+	_, err := svc.ExportSubject(ctx, userID, uuid.New(), scope)
+	assert.ErrorIs(t, err, models.ErrOutOfScope)
+}
+
+// only policies with a number of years are reported, each counted from its own cutoff
+func TestPreviewRetention(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	repo := NewMockPrivacyRepository(ctrl)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	var userCutoff time.Time
+	repo.EXPECT().CountAuditLogsBefore(ctx, gomock.Any()).Return(int64(40), nil)
+	repo.EXPECT().CountArchivedUsersBefore(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, before time.Time) (int64, error) {
+		userCutoff = before
+		return 3, nil
+	})
+
+	policy := models.RetentionPolicy{ArchivedUserYears: 2, ArchivedUserAction: models.RetentionDelete, AuditLogYears: 5, DryRun: true}
+	svc := NewPrivacyService(repo, userservice.NewMockUserService(ctrl), providers.NewMockUserCacheProvider(ctrl), auditservice.NewMockAuditService(ctrl), providers.NewMockFirebaseProvider(ctrl), logger, policy)
+	report, err := svc.PreviewRetention(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	require.Len(t, report.Policies, 2)
+	assert.Equal(t, RetentionItem{Records: RetentionAuditEntries, Action: models.RetentionDelete, Years: 5, Before: report.Policies[0].Before, Count: 40}, report.Policies[0])
+	assert.Equal(t, RetentionItem{Records: RetentionArchivedUsers, Action: models.RetentionDelete, Years: 2, Before: userCutoff, Count: 3}, report.Policies[1])
+	assert.WithinDuration(t, time.Now().AddDate(-2, 0, 0), userCutoff, time.Minute)
+}
+
+// users that can't be deleted are anonymized, every user purged is audited
+func TestApplyRetention(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	deletable, referenced := uuid.New(), uuid.New()
+	repo := NewMockPrivacyRepository(ctrl)
+	audit := auditservice.NewMockAuditService(ctrl)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	gomock.InOrder(
+		repo.EXPECT().DeleteClosedAssignmentsBefore(ctx, gomock.Any()).Return(int64(12), nil),
+		repo.EXPECT().GetArchivedUsersBefore(ctx, gomock.Any(), retentionBatchSize).Return([]uuid.UUID{deletable, referenced}, nil),
+	)
+	firebase := providers.NewMockFirebaseProvider(ctrl)
+	cache := providers.NewMockUserCacheProvider(ctrl)
+	uid := "firebase-uid"
+	repo.EXPECT().GetSubjectState(ctx, deletable).Return(SubjectState{Email: "gone@example.com", FirebaseUID: &uid}, nil)
+	firebase.EXPECT().DeleteAuthUser(ctx, uid).Return(nil)
+	repo.EXPECT().DeleteUser(ctx, deletable).Return(true, nil)
+	// signed up by email, the firebase account is found by address and was already removed
+	repo.EXPECT().GetSubjectState(ctx, referenced).Return(SubjectState{Email: "kept@example.com"}, nil)
+	firebase.EXPECT().GetAuthUserID(ctx, "kept@example.com").Return("", nil)
+	cache.EXPECT().InvalidateUsers(ctx, gomock.Any()).Times(2)
+	cache.EXPECT().InvalidateEmails(ctx, "gone@example.com")
+	cache.EXPECT().InvalidateEmails(ctx, "kept@example.com")
+	audit.EXPECT().Record(ctx, auditservice.AuditEntry{Action: "user.deleted", EntityType: "user", EntityID: deletable.String()}).Return(nil)
+	repo.EXPECT().DeleteUser(ctx, referenced).Return(false, nil)
+	repo.EXPECT().AnonymizeUser(ctx, referenced).Return(nil)
+	audit.EXPECT().Record(ctx, auditservice.AuditEntry{Action: "user.anonymized", EntityType: "user", EntityID: referenced.String()}).Return(nil)
+
+	policy := models.RetentionPolicy{ArchivedUserYears: 2, ArchivedUserAction: models.RetentionDelete, ClosedAssignmentYears: 3}
+	svc := NewPrivacyService(repo, userservice.NewMockUserService(ctrl), cache, audit, firebase, logger, policy)
+	require.NoError(t, svc.ApplyRetention(ctx))
+}
+
+// a dry run only counts
+func TestApplyRetentionDryRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	repo := NewMockPrivacyRepository(ctrl)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+	repo.EXPECT().CountArchivedUsersBefore(ctx, gomock.Any()).Return(int64(3), nil)
+
+	policy := models.RetentionPolicy{ArchivedUserYears: 2, ArchivedUserAction: models.RetentionAnonymize, DryRun: true}
+	svc := NewPrivacyService(repo, userservice.NewMockUserService(ctrl), providers.NewMockUserCacheProvider(ctrl), auditservice.NewMockAuditService(ctrl), providers.NewMockFirebaseProvider(ctrl), logger, policy)
+	require.NoError(t, svc.ApplyRetention(ctx))
+}
+
+// the firebase account goes before the row loses its uid, the caches under the old address are dropped
+func TestAnonymizeUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	userID, adminID := uuid.New(), uuid.New()
+	scope := models.DepartmentScope{AllDepartments: true}
+	archivedAt := time.Now().AddDate(0, -1, 0)
+	uid := "firebase-uid"
+
+	repo := NewMockPrivacyRepository(ctrl)
+	users := userservice.NewMockUserService(ctrl)
+	cache := providers.NewMockUserCacheProvider(ctrl)
+	audit := auditservice.NewMockAuditService(ctrl)
+	firebase := providers.NewMockFirebaseProvider(ctrl)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	users.EXPECT().GetRoleHistory(ctx, userID, scope).Return(nil, nil)
+	repo.EXPECT().GetSubjectState(ctx, userID).Return(SubjectState{Email: "sam@example.com", FirebaseUID: &uid, ArchivedAt: &archivedAt}, nil)
+	gomock.InOrder(
+		firebase.EXPECT().DeleteAuthUser(ctx, uid).Return(nil),
+		repo.EXPECT().AnonymizeUser(ctx, userID).Return(nil),
+	)
+	cache.EXPECT().InvalidateUsers(ctx, userID)
+	cache.EXPECT().InvalidateEmails(ctx, "sam@example.com")
+	audit.EXPECT().Record(ctx, auditservice.AuditEntry{ActorID: &adminID, Action: "user.anonymized", EntityType: "user", EntityID: userID.String()}).Return(nil)
+
+	svc := NewPrivacyService(repo, users, cache, audit, firebase, logger, models.RetentionPolicy{})
+	require.NoError(t, svc.AnonymizeUser(ctx, userID, adminID, scope))
+}
+
+// active users are archived first and anonymizing is done once
+func TestAnonymizeUserRefused(t *testing.T) {
+	archivedAt := time.Now()
+	tests := []struct {
+		name  string
+		state SubjectState
+		err   error
+	}{
+		{name: "active", state: SubjectState{Email: "sam@example.com"}, err: ErrSubjectNotArchived},
+		{name: "anonymized", state: SubjectState{Email: "x@invalid", ArchivedAt: &archivedAt, AnonymizedAt: &archivedAt}, err: ErrSubjectAnonymized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.Background()
+			userID := uuid.New()
+			scope := models.DepartmentScope{AllDepartments: true}
+			repo := NewMockPrivacyRepository(ctrl)
+			users := userservice.NewMockUserService(ctrl)
+			users.EXPECT().GetRoleHistory(ctx, userID, scope).Return(nil, nil)
+			repo.EXPECT().GetSubjectState(ctx, userID).Return(tt.state, nil)
+
+			svc := NewPrivacyService(repo, users, providers.NewMockUserCacheProvider(ctrl), auditservice.NewMockAuditService(ctrl), providers.NewMockFirebaseProvider(ctrl), providers.NewMockZapLoggerProvider(ctrl), models.RetentionPolicy{})
+			assert.ErrorIs(t, svc.AnonymizeUser(ctx, userID, uuid.New(), scope), tt.err)
+		})
+	}
+}
+
+func keys(sections map[string]json.RawMessage) []string {
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,168 @@
+package procurementservice
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	OrderPendingApproval   = "pending_approval"
+	OrderApproved          = "approved"
+	OrderRejected          = "rejected"
+	OrderPartiallyReceived = "partially_received"
+	OrderReceived          = "received"
+)
+
+type OrderLineReq struct {
+	Brand   string `json:"brand" validate:"required,max=100"`
+	Model   string `json:"model" validate:"required,max=100"`
+	Type    string `json:"type" validate:"required,asset_type"`
+	OwnedBy string `json:"owned_by" validate:"omitempty,asset_ownership"`
+	// Quantity is how many units are ordered, each one becomes an asset when it is received
+	Quantity int     `json:"quantity" validate:"required,gt=0,lte=1000"`
+	UnitCost float64 `json:"unit_cost" validate:"gte=0"`
+	// WarrantyMonths counts from the day the units are received, 12 when left out
+	WarrantyMonths int `json:"warranty_months" validate:"omitempty,gt=0,lte=120"`
+	// Config pre-fills the configuration of every asset received on the line, in the shape the
+	// asset's type takes when it is added by hand
+	Config json.RawMessage `json:"config"`
+}
+
+type CreateOrderReq struct {
+	Vendor string         `json:"vendor" validate:"required,max=200"`
+	Notes  string         `json:"notes" validate:"max=2000"`
+	Lines  []OrderLineReq `json:"lines" validate:"required,min=1,max=50,dive"`
+	// DepartmentID receives the assets, a scoped manager always orders for their own department
+	DepartmentID *uuid.UUID `json:"department_id,omitempty"`
+}
+
+type DecideOrderReq struct {
+	Note string `json:"note" validate:"max=2000"`
+}
+
+type ReceiveLineReq struct {
+	LineID    uuid.UUID `json:"line_id" validate:"required"`
+	SerialNos []string  `json:"serial_nos" validate:"required,min=1,max=1000,dive,required,max=100"`
+}
+
+// ReceiveOrderReq records the units that arrived, an order can be received over several deliveries
+type ReceiveOrderReq struct {
+	ID uuid.UUID `json:"id" validate:"required"`
+	// ReceivedOn is the purchase date of the new assets, today when left out
+	ReceivedOn *time.Time       `json:"received_on,omitempty"`
+	Lines      []ReceiveLineReq `json:"lines" validate:"required,min=1,dive"`
+}
+
+type ReceiveOrderRes struct {
+	Order   Order `json:"order"`
+	Created int   `json:"created"`
+}
+
+type OrderFilter struct {
+	Status string
+	Limit  int
+	Offset int
+}
+
+type Order struct {
+	ID           uuid.UUID   `json:"id" db:"id"`
+	Number       string      `json:"number" db:"number"`
+	Vendor       string      `json:"vendor" db:"vendor"`
+	Notes        *string     `json:"notes,omitempty" db:"notes"`
+	Status       string      `json:"status" db:"status"`
+	DepartmentID *uuid.UUID  `json:"department_id,omitempty" db:"department_id"`
+	CreatedBy    uuid.UUID   `json:"created_by" db:"created_by"`
+	CreatedAt    time.Time   `json:"created_at" db:"created_at"`
+	DecidedBy    *uuid.UUID  `json:"decided_by,omitempty" db:"decided_by"`
+	DecidedAt    *time.Time  `json:"decided_at,omitempty" db:"decided_at"`
+	DecisionNote *string     `json:"decision_note,omitempty" db:"decision_note"`
+	ExternalID   *string     `json:"external_id,omitempty" db:"external_id"`
+	PushError    *string     `json:"push_error,omitempty" db:"push_error"`
+	PushedAt     *time.Time  `json:"pushed_at,omitempty" db:"pushed_at"`
+	Lines        []OrderLine `json:"lines" db:"-"`
+}
+
+type OrderLine struct {
+	ID               uuid.UUID       `json:"id" db:"id"`
+	OrderID          uuid.UUID       `json:"-" db:"purchase_order_id"`
+	LineNo           int             `json:"line_no" db:"line_no"`
+	Brand            string          `json:"brand" db:"brand"`
+	Model            string          `json:"model" db:"model"`
+	Type             string          `json:"type" db:"type"`
+	OwnedBy          string          `json:"owned_by" db:"owned_by"`
+	Quantity         int             `json:"quantity" db:"quantity"`
+	UnitCost         float64         `json:"unit_cost" db:"unit_cost"`
+	WarrantyMonths   int             `json:"warranty_months" db:"warranty_months"`
+	Config           json.RawMessage `json:"config" db:"config"`
+	ReceivedQuantity int             `json:"received_quantity" db:"received_quantity"`
+}
+
+// a purchase request is reviewed by an asset manager, then approved by finance and ordered
+const (
+	RequestPendingReview  = "pending_review"
+	RequestPendingFinance = "pending_finance"
+	RequestApproved       = "approved"
+	RequestRejected       = "rejected"
+	RequestOrdered        = "ordered"
+)
+
+// CreatePurchaseRequestReq is sent by an employee for equipment that isn't in stock, EstimatedCost
+// is per unit
+type CreatePurchaseRequestReq struct {
+	Type          string  `json:"type" validate:"required,asset_type"`
+	Quantity      int     `json:"quantity" validate:"omitempty,gt=0,lte=100"`
+	Justification string  `json:"justification" validate:"required,max=2000"`
+	EstimatedCost float64 `json:"estimated_cost" validate:"gte=0"`
+}
+
+// ConvertRequestReq raises a purchase order for an approved request, the order has one line for the
+// requested type and quantity. UnitCost is the request's estimated cost when left out
+type ConvertRequestReq struct {
+	ID             uuid.UUID       `json:"id" validate:"required"`
+	Vendor         string          `json:"vendor" validate:"required,max=200"`
+	Brand          string          `json:"brand" validate:"required,max=100"`
+	Model          string          `json:"model" validate:"required,max=100"`
+	UnitCost       *float64        `json:"unit_cost,omitempty" validate:"omitempty,gte=0"`
+	WarrantyMonths int             `json:"warranty_months" validate:"omitempty,gt=0,lte=120"`
+	Config         json.RawMessage `json:"config"`
+}
+
+// RequestApprover is who decides a request and at which stages they may, Review is
+// procurement.manage and Finance is procurement.approve
+type RequestApprover struct {
+	ID      uuid.UUID
+	Review  bool
+	Finance bool
+}
+
+// PurchaseRequestFilter narrows the list, RequestedBy is set for an employee's own requests
+type PurchaseRequestFilter struct {
+	Status      string
+	RequestedBy *uuid.UUID
+	Limit       int
+	Offset      int
+}
+
+type PurchaseRequest struct {
+	ID              uuid.UUID  `json:"id" db:"id"`
+	Number          string     `json:"number" db:"number"`
+	RequestedBy     uuid.UUID  `json:"requested_by" db:"requested_by"`
+	RequesterName   string     `json:"requester_name" db:"requester_name"`
+	DepartmentID    *uuid.UUID `json:"department_id,omitempty" db:"department_id"`
+	Type            string     `json:"type" db:"type"`
+	Quantity        int        `json:"quantity" db:"quantity"`
+	Justification   string     `json:"justification" db:"justification"`
+	EstimatedCost   float64    `json:"estimated_cost" db:"estimated_cost"`
+	Status          string     `json:"status" db:"status"`
+	ReviewedBy      *uuid.UUID `json:"reviewed_by,omitempty" db:"reviewed_by"`
+	ReviewedAt      *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+	ReviewNote      *string    `json:"review_note,omitempty" db:"review_note"`
+	DecidedBy       *uuid.UUID `json:"decided_by,omitempty" db:"decided_by"`
+	DecidedAt       *time.Time `json:"decided_at,omitempty" db:"decided_at"`
+	DecisionNote    *string    `json:"decision_note,omitempty" db:"decision_note"`
+	PurchaseOrderID *uuid.UUID `json:"purchase_order_id,omitempty" db:"purchase_order_id"`
+	OrganizationID  uuid.UUID  `json:"-" db:"organization_id"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,329 @@
+package procurementservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type ProcurementHandler struct {
+	Service        ProcurementService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewProcurementHandler(service ProcurementService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *ProcurementHandler {
+	return &ProcurementHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+// GetOrders lists the purchase orders of the caller's department newest first, status narrows it down
+func (h *ProcurementHandler) GetOrders(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetPurchaseOrders request received")
+	scope, ok := h.scope(w, r, "GetPurchaseOrders")
+	if !ok {
+		return
+	}
+	filter := OrderFilter{Status: r.URL.Query().Get("status")}
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+
+	orders, err := h.Service.GetOrders(r.Context(), filter, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch purchase orders", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch purchase orders")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"orders": orders})
+}
+
+func (h *ProcurementHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("CreatePurchaseOrder request received")
+	userID, scope, ok := h.callerAndScope(w, r, "CreatePurchaseOrder")
+	if !ok {
+		return
+	}
+	var req CreateOrderReq
+	if !h.parse(w, r, &req, "CreatePurchaseOrder") {
+		return
+	}
+
+	order, err := h.Service.CreateOrder(r.Context(), req, userID, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to create purchase order", zap.String("vendor", req.Vendor), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to create purchase order")
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+		"message": "purchase order raised, it waits for approval",
+		"order":   order,
+	})
+}
+
+func (h *ProcurementHandler) ApproveOrder(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, "ApprovePurchaseOrder", true)
+}
+
+func (h *ProcurementHandler) RejectOrder(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, "RejectPurchaseOrder", false)
+}
+
+func (h *ProcurementHandler) decide(w http.ResponseWriter, r *http.Request, handler string, approve bool) {
+	h.Logger.GetLogger().Info(handler + " request received")
+	approverID, scope, ok := h.callerAndScope(w, r, handler)
+	if !ok {
+		return
+	}
+	id := r.URL.Query().Get("id")
+	orderID, err := uuid.Parse(id)
+	if err != nil {
+		h.Logger.GetLogger().Error("Invalid id in "+handler, zap.String("id", id), zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid id")
+		return
+	}
+	// the note is optional, so is the body
+	var req DecideOrderReq
+	if r.ContentLength != 0 && !h.parse(w, r, &req, handler) {
+		return
+	}
+
+	if approve {
+		err = h.Service.ApproveOrder(r.Context(), orderID, approverID, req.Note, scope)
+	} else {
+		err = h.Service.RejectOrder(r.Context(), orderID, approverID, req.Note, scope)
+	}
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to decide purchase order", zap.String("id", id), zap.Bool("approve", approve), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to decide purchase order")
+		return
+	}
+	message := "purchase order rejected"
+	if approve {
+		message = "purchase order approved"
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": message})
+}
+
+// ReceiveOrder records a delivery, each serial number becomes an asset
+func (h *ProcurementHandler) ReceiveOrder(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("ReceivePurchaseOrder request received")
+	userID, scope, ok := h.callerAndScope(w, r, "ReceivePurchaseOrder")
+	if !ok {
+		return
+	}
+	var req ReceiveOrderReq
+	if !h.parse(w, r, &req, "ReceivePurchaseOrder") {
+		return
+	}
+
+	res, err := h.Service.ReceiveOrder(r.Context(), req, userID, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to receive purchase order", zap.String("id", req.ID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to receive purchase order")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, res)
+}
+
+// CreateRequest asks for equipment that isn't in stock, the caller's asset managers review it
+func (h *ProcurementHandler) CreateRequest(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("CreatePurchaseRequest request received")
+	userID, _, ok := h.callerAndScope(w, r, "CreatePurchaseRequest")
+	if !ok {
+		return
+	}
+	var req CreatePurchaseRequestReq
+	if !h.parse(w, r, &req, "CreatePurchaseRequest") {
+		return
+	}
+
+	request, err := h.Service.CreateRequest(r.Context(), req, userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to create purchase request", zap.String("userID", userID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to create purchase request")
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+		"message": "purchase request raised, it waits for review",
+		"request": request,
+	})
+}
+
+// GetMyRequests lists the caller's own purchase requests newest first
+func (h *ProcurementHandler) GetMyRequests(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetMyPurchaseRequests request received")
+	userID, _, ok := h.callerAndScope(w, r, "GetMyPurchaseRequests")
+	if !ok {
+		return
+	}
+	filter := PurchaseRequestFilter{Status: r.URL.Query().Get("status"), RequestedBy: &userID}
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+
+	requests, err := h.Service.GetRequests(r.Context(), filter, models.DepartmentScope{AllDepartments: true})
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch purchase requests", zap.String("userID", userID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch purchase requests")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"requests": requests})
+}
+
+// GetRequests lists the purchase requests of the caller's department for asset managers and finance,
+// status narrows it down
+func (h *ProcurementHandler) GetRequests(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetPurchaseRequests request received")
+	_, scope, ok := h.requestApprover(w, r, "GetPurchaseRequests")
+	if !ok {
+		return
+	}
+	filter := PurchaseRequestFilter{Status: r.URL.Query().Get("status")}
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+
+	requests, err := h.Service.GetRequests(r.Context(), filter, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch purchase requests", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch purchase requests")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"requests": requests})
+}
+
+func (h *ProcurementHandler) ApproveRequest(w http.ResponseWriter, r *http.Request) {
+	h.decideRequest(w, r, "ApprovePurchaseRequest", true)
+}
+
+func (h *ProcurementHandler) RejectRequest(w http.ResponseWriter, r *http.Request) {
+	h.decideRequest(w, r, "RejectPurchaseRequest", false)
+}
+
+// decideRequest decides the stage the request is at, review for asset managers and approval for finance
+func (h *ProcurementHandler) decideRequest(w http.ResponseWriter, r *http.Request, handler string, approve bool) {
+	h.Logger.GetLogger().Info(handler + " request received")
+	approver, scope, ok := h.requestApprover(w, r, handler)
+	if !ok {
+		return
+	}
+	id := r.URL.Query().Get("id")
+	requestID, err := uuid.Parse(id)
+	if err != nil {
+		h.Logger.GetLogger().Error("Invalid id in "+handler, zap.String("id", id), zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid id")
+		return
+	}
+	// the note is optional, so is the body
+	var req DecideOrderReq
+	if r.ContentLength != 0 && !h.parse(w, r, &req, handler) {
+		return
+	}
+
+	var request PurchaseRequest
+	if approve {
+		request, err = h.Service.ApproveRequest(r.Context(), requestID, approver, req.Note, scope)
+	} else {
+		request, err = h.Service.RejectRequest(r.Context(), requestID, approver, req.Note, scope)
+	}
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to decide purchase request", zap.String("id", id), zap.Bool("approve", approve), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to decide purchase request")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"request": request})
+}
+
+// ConvertRequest raises the purchase order of an approved request
+func (h *ProcurementHandler) ConvertRequest(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("ConvertPurchaseRequest request received")
+	userID, scope, ok := h.callerAndScope(w, r, "ConvertPurchaseRequest")
+	if !ok {
+		return
+	}
+	var req ConvertRequestReq
+	if !h.parse(w, r, &req, "ConvertPurchaseRequest") {
+		return
+	}
+
+	order, err := h.Service.ConvertRequest(r.Context(), req, userID, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to convert purchase request", zap.String("id", req.ID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to convert purchase request")
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+		"message": "purchase order raised, it waits for approval",
+		"order":   order,
+	})
+}
+
+func (h *ProcurementHandler) parse(w http.ResponseWriter, r *http.Request, req interface{}, handler string) bool {
+	if err := utils.ParseJSONBody(r, req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return false
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Validation failed in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return false
+	}
+	return true
+}
+
+func (h *ProcurementHandler) scope(w http.ResponseWriter, r *http.Request, handler string) (models.DepartmentScope, bool) {
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return scope, false
+	}
+	return scope, true
+}
+
+func (h *ProcurementHandler) callerAndScope(w http.ResponseWriter, r *http.Request, handler string) (uuid.UUID, models.DepartmentScope, bool) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return uuid.Nil, models.DepartmentScope{}, false
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in "+handler, zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return uuid.Nil, models.DepartmentScope{}, false
+	}
+	scope, ok := h.scope(w, r, handler)
+	return userUUID, scope, ok
+}
+
+// requestApprover resolves the stages of a purchase request the caller may decide, callers with
+// neither procurement.manage nor procurement.approve, or an api key scoped to neither, are turned away
+func (h *ProcurementHandler) requestApprover(w http.ResponseWriter, r *http.Request, handler string) (RequestApprover, models.DepartmentScope, bool) {
+	userID, scope, ok := h.callerAndScope(w, r, handler)
+	if !ok {
+		return RequestApprover{}, scope, false
+	}
+	approver := RequestApprover{ID: userID}
+	var err error
+	if approver.Review, err = h.AuthMiddleware.CallerHasPermission(r, models.ProcurementManagePermission); err != nil {
+		h.Logger.GetLogger().Error("Failed to check permissions in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to check permissions")
+		return RequestApprover{}, scope, false
+	}
+	if approver.Finance, err = h.AuthMiddleware.CallerHasPermission(r, models.ProcurementApprovePermission); err != nil {
+		h.Logger.GetLogger().Error("Failed to check permissions in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to check permissions")
+		return RequestApprover{}, scope, false
+	}
+	if !approver.Review && !approver.Finance {
+		utils.RespondError(w, http.StatusForbidden, errors.New("missing permission"), "procurement.manage or procurement.approve is required")
+		return RequestApprover{}, scope, false
+	}
+	return approver, scope, true
+}
@@ -0,0 +1,320 @@
+package procurementservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type ProcurementRepository interface {
+	InsertOrder(ctx context.Context, order Order) (uuid.UUID, error)
+	GetOrders(ctx context.Context, filter OrderFilter, scope models.DepartmentScope) ([]Order, error)
+	GetOrder(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) (Order, error)
+	DecideOrder(ctx context.Context, id uuid.UUID, status string, decidedBy uuid.UUID, note string) error
+	AddReceived(ctx context.Context, lineID uuid.UUID, count int) error
+	SetOrderStatus(ctx context.Context, id uuid.UUID, status string) error
+	GetOrdersToPush(ctx context.Context, limit int) ([]Order, error)
+	MarkPushed(ctx context.Context, id uuid.UUID, externalID string) error
+	MarkPushFailed(ctx context.Context, id uuid.UUID, reason string) error
+	InsertRequest(ctx context.Context, req CreatePurchaseRequestReq, requestedBy uuid.UUID) (uuid.UUID, error)
+	GetRequests(ctx context.Context, filter PurchaseRequestFilter, scope models.DepartmentScope) ([]PurchaseRequest, error)
+	GetRequest(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) (PurchaseRequest, error)
+	ReviewRequest(ctx context.Context, id uuid.UUID, status string, reviewedBy uuid.UUID, note string) error
+	DecideRequest(ctx context.Context, id uuid.UUID, status string, decidedBy uuid.UUID, note string) error
+	MarkRequestOrdered(ctx context.Context, id, orderID uuid.UUID) error
+	// GetApproverIDs returns the users of the organization whose role grants permission, admins and the
+	// department's own managers
+	GetApproverIDs(ctx context.Context, permission models.Permission, organizationID uuid.UUID, departmentID *uuid.UUID) ([]uuid.UUID, error)
+}
+
+type PostgresProcurementRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewProcurementRepository(db *sqlx.DB, log providers.ZapLoggerProvider) ProcurementRepository {
+	return &PostgresProcurementRepository{DB: db, Logger: log}
+}
+
+const orderColumns = `id, number, vendor, notes, status, department_id, created_by, created_at, decided_by, decided_at,
+	decision_note, external_id, push_error, pushed_at`
+
+// InsertOrder writes the order and its lines, numbered in the order given
+func (r *PostgresProcurementRepository) InsertOrder(ctx context.Context, order Order) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &id, `
+		INSERT INTO purchase_orders (vendor, notes, department_id, created_by, organization_id)
+		SELECT $1, NULLIF($2, ''), $3, u.id, u.organization_id FROM users u WHERE u.id = $4
+		RETURNING id
+	`, order.Vendor, order.Notes, order.DepartmentID, order.CreatedBy)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert purchase order", zap.String("vendor", order.Vendor), zap.Error(err))
+		return uuid.Nil, fmt.Errorf("failed to insert purchase order: %w", err)
+	}
+	for i, line := range order.Lines {
+		_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+			INSERT INTO purchase_order_lines (purchase_order_id, line_no, brand, model, type, owned_by, quantity, unit_cost, warranty_months, config)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		`, id, i+1, line.Brand, line.Model, line.Type, line.OwnedBy, line.Quantity, line.UnitCost, line.WarrantyMonths, string(line.Config))
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("failed to insert purchase order line: %w", err)
+		}
+	}
+	return id, nil
+}
+
+func (r *PostgresProcurementRepository) GetOrders(ctx context.Context, filter OrderFilter, scope models.DepartmentScope) ([]Order, error) {
+	orders := []Order{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &orders, `
+		SELECT `+orderColumns+`
+		FROM purchase_orders
+		WHERE ($1 = '' OR status = $1)
+		AND ($2 OR department_id IS NOT DISTINCT FROM $3)
+		AND ($6::uuid IS NULL OR organization_id = $6)
+		ORDER BY created_at DESC
+		LIMIT $4 OFFSET $5
+	`, filter.Status, scope.AllDepartments, scope.DepartmentID, filter.Limit, filter.Offset, scope.OrganizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch purchase orders", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch purchase orders: %w", err)
+	}
+	return orders, r.loadLines(ctx, orders)
+}
+
+// GetOrder locks the order until the caller's transaction ends, so two deliveries of the same order
+// can't both receive its last units
+func (r *PostgresProcurementRepository) GetOrder(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) (Order, error) {
+	var order Order
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &order, `
+		SELECT `+orderColumns+`
+		FROM purchase_orders
+		WHERE id = $1 AND ($2 OR department_id IS NOT DISTINCT FROM $3)
+		AND ($4::uuid IS NULL OR organization_id = $4)
+		FOR UPDATE
+	`, id, scope.AllDepartments, scope.DepartmentID, scope.OrganizationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return order, ErrOrderNotFound
+	}
+	if err != nil {
+		return order, fmt.Errorf("failed to fetch purchase order: %w", err)
+	}
+	orders := []Order{order}
+	if err := r.loadLines(ctx, orders); err != nil {
+		return order, err
+	}
+	return orders[0], nil
+}
+
+func (r *PostgresProcurementRepository) loadLines(ctx context.Context, orders []Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	ids := make([]string, len(orders))
+	byID := make(map[uuid.UUID]int, len(orders))
+	for i, order := range orders {
+		ids[i] = order.ID.String()
+		byID[order.ID] = i
+		orders[i].Lines = []OrderLine{}
+	}
+	var lines []OrderLine
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &lines, `
+		SELECT id, purchase_order_id, line_no, brand, model, type, owned_by, quantity, unit_cost, warranty_months, config, received_quantity
+		FROM purchase_order_lines
+		WHERE purchase_order_id = ANY($1::uuid[])
+		ORDER BY purchase_order_id, line_no
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("failed to fetch purchase order lines: %w", err)
+	}
+	for _, line := range lines {
+		i := byID[line.OrderID]
+		orders[i].Lines = append(orders[i].Lines, line)
+	}
+	return nil
+}
+
+func (r *PostgresProcurementRepository) DecideOrder(ctx context.Context, id uuid.UUID, status string, decidedBy uuid.UUID, note string) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE purchase_orders
+		SET status = $2, decided_by = $3, decided_at = now(), decision_note = NULLIF($4, '')
+		WHERE id = $1
+	`, id, status, decidedBy, note)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to record purchase order decision", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to record purchase order decision: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresProcurementRepository) AddReceived(ctx context.Context, lineID uuid.UUID, count int) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE purchase_order_lines SET received_quantity = received_quantity + $2 WHERE id = $1
+	`, lineID, count)
+	if err != nil {
+		return fmt.Errorf("failed to record received units: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresProcurementRepository) SetOrderStatus(ctx context.Context, id uuid.UUID, status string) error {
+	if _, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `UPDATE purchase_orders SET status = $2 WHERE id = $1`, id, status); err != nil {
+		return fmt.Errorf("failed to update purchase order status: %w", err)
+	}
+	return nil
+}
+
+// GetOrdersToPush returns approved orders the e-procurement system hasn't been given yet, the ones
+// that never failed to push first
+func (r *PostgresProcurementRepository) GetOrdersToPush(ctx context.Context, limit int) ([]Order, error) {
+	var orders []Order
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &orders, `
+		SELECT `+orderColumns+`
+		FROM purchase_orders
+		WHERE external_id IS NULL AND status IN ('approved', 'partially_received', 'received')
+		ORDER BY push_error IS NOT NULL, decided_at
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch purchase orders to push: %w", err)
+	}
+	return orders, r.loadLines(ctx, orders)
+}
+
+func (r *PostgresProcurementRepository) MarkPushed(ctx context.Context, id uuid.UUID, externalID string) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE purchase_orders SET external_id = $2, pushed_at = now(), push_error = NULL WHERE id = $1
+	`, id, externalID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to record pushed purchase order", zap.String("id", id.String()), zap.String("external_id", externalID), zap.Error(err))
+		return fmt.Errorf("failed to record pushed purchase order: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresProcurementRepository) MarkPushFailed(ctx context.Context, id uuid.UUID, reason string) error {
+	if _, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `UPDATE purchase_orders SET push_error = $2 WHERE id = $1`, id, reason); err != nil {
+		return fmt.Errorf("failed to record purchase order push failure: %w", err)
+	}
+	return nil
+}
+
+const requestColumns = `pr.id, pr.number, pr.requested_by, u.username AS requester_name, pr.department_id, pr.type, pr.quantity,
+	pr.justification, pr.estimated_cost, pr.status, pr.reviewed_by, pr.reviewed_at, pr.review_note, pr.decided_by, pr.decided_at,
+	pr.decision_note, pr.purchase_order_id, pr.organization_id, pr.created_at`
+
+// InsertRequest files the request under the requester's current department and organization
+func (r *PostgresProcurementRepository) InsertRequest(ctx context.Context, req CreatePurchaseRequestReq, requestedBy uuid.UUID) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &id, `
+		INSERT INTO purchase_requests (requested_by, department_id, organization_id, type, quantity, justification, estimated_cost)
+		SELECT u.id, u.department_id, u.organization_id, $2, $3, $4, $5 FROM users u WHERE u.id = $1
+		RETURNING id
+	`, requestedBy, req.Type, req.Quantity, req.Justification, req.EstimatedCost)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert purchase request", zap.String("requested_by", requestedBy.String()), zap.Error(err))
+		return uuid.Nil, fmt.Errorf("failed to insert purchase request: %w", err)
+	}
+	return id, nil
+}
+
+func (r *PostgresProcurementRepository) GetRequests(ctx context.Context, filter PurchaseRequestFilter, scope models.DepartmentScope) ([]PurchaseRequest, error) {
+	requests := []PurchaseRequest{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &requests, `
+		SELECT `+requestColumns+`
+		FROM purchase_requests pr
+		JOIN users u ON u.id = pr.requested_by
+		WHERE ($1 = '' OR pr.status = $1)
+		AND ($2::uuid IS NULL OR pr.requested_by = $2)
+		AND ($3 OR pr.department_id IS NOT DISTINCT FROM $4)
+		AND ($7::uuid IS NULL OR pr.organization_id = $7)
+		ORDER BY pr.created_at DESC
+		LIMIT $5 OFFSET $6
+	`, filter.Status, filter.RequestedBy, scope.AllDepartments, scope.DepartmentID, filter.Limit, filter.Offset, scope.OrganizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch purchase requests", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch purchase requests: %w", err)
+	}
+	return requests, nil
+}
+
+// GetRequest locks the request until the caller's transaction ends, so it is decided or ordered once
+func (r *PostgresProcurementRepository) GetRequest(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) (PurchaseRequest, error) {
+	var request PurchaseRequest
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &request, `
+		SELECT `+requestColumns+`
+		FROM purchase_requests pr
+		JOIN users u ON u.id = pr.requested_by
+		WHERE pr.id = $1 AND ($2 OR pr.department_id IS NOT DISTINCT FROM $3)
+		AND ($4::uuid IS NULL OR pr.organization_id = $4)
+		FOR UPDATE OF pr
+	`, id, scope.AllDepartments, scope.DepartmentID, scope.OrganizationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return request, ErrRequestNotFound
+	}
+	if err != nil {
+		return request, fmt.Errorf("failed to fetch purchase request: %w", err)
+	}
+	return request, nil
+}
+
+func (r *PostgresProcurementRepository) ReviewRequest(ctx context.Context, id uuid.UUID, status string, reviewedBy uuid.UUID, note string) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE purchase_requests
+		SET status = $2, reviewed_by = $3, reviewed_at = now(), review_note = NULLIF($4, '')
+		WHERE id = $1
+	`, id, status, reviewedBy, note)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to record purchase request review", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to record purchase request review: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresProcurementRepository) DecideRequest(ctx context.Context, id uuid.UUID, status string, decidedBy uuid.UUID, note string) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE purchase_requests
+		SET status = $2, decided_by = $3, decided_at = now(), decision_note = NULLIF($4, '')
+		WHERE id = $1
+	`, id, status, decidedBy, note)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to record purchase request decision", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to record purchase request decision: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresProcurementRepository) MarkRequestOrdered(ctx context.Context, id, orderID uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE purchase_requests SET status = 'ordered', purchase_order_id = $2 WHERE id = $1
+	`, id, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to record purchase request order: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresProcurementRepository) GetApproverIDs(ctx context.Context, permission models.Permission, organizationID uuid.UUID, departmentID *uuid.UUID) ([]uuid.UUID, error) {
+	approverIDs := []uuid.UUID{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &approverIDs, `
+		SELECT DISTINCT u.id
+		FROM users u
+		JOIN user_roles ur ON ur.user_id = u.id AND ur.archived_at IS NULL
+		JOIN role_permissions rp ON rp.role = ur.role AND rp.archived_at IS NULL
+		WHERE u.archived_at IS NULL AND u.organization_id = $3
+		AND rp.permission = $1
+		AND (ur.role = 'admin' OR u.department_id IS NOT DISTINCT FROM $2)
+	`, string(permission), departmentID, organizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s approvers: %w", permission, err)
+	}
+	return approverIDs, nil
+}
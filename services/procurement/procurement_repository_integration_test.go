@@ -0,0 +1,76 @@
+//go:build integration
+
+package procurementservice
+
+import (
+	"asset/database/seed"
+	"asset/database/testdb"
+	"asset/models"
+	"asset/providers"
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// orders and requests belong to the organization of whoever raised them, an admin of another
+// organization neither sees them nor is asked to approve them
+func TestProcurementOtherOrganization(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewProcurementRepository(db, logger)
+	ctx := context.Background()
+	admin := seed.ID("user:admin")
+
+	var other uuid.UUID
+	require.NoError(t, db.GetContext(ctx, &other, `
+		INSERT INTO organizations (name, slug) VALUES ('Other', 'other') RETURNING id
+	`))
+	_, err := db.ExecContext(ctx, `UPDATE users SET organization_id = $1 WHERE id = $2`, other, admin)
+	require.NoError(t, err)
+
+	orderID, err := repo.InsertOrder(ctx, Order{Vendor: "Other Supplies", CreatedBy: admin})
+	require.NoError(t, err)
+	requestID, err := repo.InsertRequest(ctx, CreatePurchaseRequestReq{Type: "laptop", Quantity: 1, Justification: "new hire", EstimatedCost: 1200}, admin)
+	require.NoError(t, err)
+
+	own := models.DepartmentScope{AllDepartments: true, OrganizationID: &models.DefaultOrganizationID}
+	theirs := models.DepartmentScope{AllDepartments: true, OrganizationID: &other}
+
+	orders, err := repo.GetOrders(ctx, OrderFilter{Limit: 1000}, own)
+	require.NoError(t, err)
+	assert.False(t, slices.ContainsFunc(orders, func(o Order) bool { return o.ID == orderID }))
+	_, err = repo.GetOrder(ctx, orderID, own)
+	assert.ErrorIs(t, err, ErrOrderNotFound)
+	requests, err := repo.GetRequests(ctx, PurchaseRequestFilter{Limit: 1000}, own)
+	require.NoError(t, err)
+	assert.False(t, slices.ContainsFunc(requests, func(r PurchaseRequest) bool { return r.ID == requestID }))
+	_, err = repo.GetRequest(ctx, requestID, own)
+	assert.ErrorIs(t, err, ErrRequestNotFound)
+
+	orders, err = repo.GetOrders(ctx, OrderFilter{Limit: 1000}, theirs)
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	assert.Equal(t, orderID, orders[0].ID)
+	_, err = repo.GetOrder(ctx, orderID, theirs)
+	require.NoError(t, err)
+	request, err := repo.GetRequest(ctx, requestID, theirs)
+	require.NoError(t, err)
+	assert.Equal(t, other, request.OrganizationID)
+
+	approvers, err := repo.GetApproverIDs(ctx, models.ProcurementApprovePermission, models.DefaultOrganizationID, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, approvers, admin)
+	approvers, err = repo.GetApproverIDs(ctx, models.ProcurementApprovePermission, other, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{admin}, approvers)
+}
@@ -0,0 +1,460 @@
+package procurementservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/asset"
+	"asset/services/notification"
+	"asset/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// ProcurementService raises purchase orders for new assets. An order is approved by someone other
+// than whoever raised it, pushed to the e-procurement system by a background job when one is
+// configured, and receiving it creates the assets pre-filled from its lines with the order as
+// their purchase order. Employees ask for equipment through purchase requests, which are reviewed by
+// an asset manager and approved by finance before they become an order
+type ProcurementService interface {
+	CreateOrder(ctx context.Context, req CreateOrderReq, userID uuid.UUID, scope models.DepartmentScope) (Order, error)
+	GetOrders(ctx context.Context, filter OrderFilter, scope models.DepartmentScope) ([]Order, error)
+	ApproveOrder(ctx context.Context, id, approverID uuid.UUID, note string, scope models.DepartmentScope) error
+	RejectOrder(ctx context.Context, id, approverID uuid.UUID, note string, scope models.DepartmentScope) error
+	ReceiveOrder(ctx context.Context, req ReceiveOrderReq, userID uuid.UUID, scope models.DepartmentScope) (ReceiveOrderRes, error)
+	PushApproved(ctx context.Context) error
+	CreateRequest(ctx context.Context, req CreatePurchaseRequestReq, employeeID uuid.UUID) (PurchaseRequest, error)
+	GetRequests(ctx context.Context, filter PurchaseRequestFilter, scope models.DepartmentScope) ([]PurchaseRequest, error)
+	ApproveRequest(ctx context.Context, id uuid.UUID, approver RequestApprover, note string, scope models.DepartmentScope) (PurchaseRequest, error)
+	RejectRequest(ctx context.Context, id uuid.UUID, approver RequestApprover, note string, scope models.DepartmentScope) (PurchaseRequest, error)
+	ConvertRequest(ctx context.Context, req ConvertRequestReq, userID uuid.UUID, scope models.DepartmentScope) (Order, error)
+}
+
+var (
+	ErrOrderNotFound       = models.NewServiceError(http.StatusNotFound, "purchase_order_not_found", "purchase order not found")
+	ErrOrderNotPending     = models.NewServiceError(http.StatusConflict, "purchase_order_decided", "the purchase order was already approved or rejected")
+	ErrSelfApproval        = models.NewServiceError(http.StatusForbidden, "purchase_order_self_approval", "a purchase order needs the approval of someone other than whoever raised it")
+	ErrOrderNotReceivable  = models.NewServiceError(http.StatusConflict, "purchase_order_not_receivable", "only approved purchase orders that are still open can be received")
+	ErrUnknownLine         = models.NewServiceError(http.StatusBadRequest, "purchase_order_line_not_found", "a line isn't part of this purchase order")
+	ErrOverReceived        = models.NewServiceError(http.StatusBadRequest, "purchase_order_over_received", "more units received than the line ordered")
+	ErrInvalidLineConfig   = models.NewServiceError(http.StatusBadRequest, "invalid_line_config", "a line's config must be a json object")
+	ErrRequestNotFound     = models.NewServiceError(http.StatusNotFound, "purchase_request_not_found", "purchase request not found")
+	ErrRequestNotPending   = models.NewServiceError(http.StatusConflict, "purchase_request_decided", "the purchase request was already approved or rejected")
+	ErrRequestNotApprover  = models.NewServiceError(http.StatusForbidden, "purchase_request_wrong_stage", "the purchase request waits for another approver, asset managers review it and finance approves it")
+	ErrRequestSelfApproval = models.NewServiceError(http.StatusForbidden, "purchase_request_self_approval", "a purchase request can't be reviewed or approved by whoever asked for it")
+	ErrRequestNotApproved  = models.NewServiceError(http.StatusConflict, "purchase_request_not_approved", "only approved purchase requests that weren't ordered yet can become a purchase order")
+)
+
+const (
+	defaultWarrantyMonths = 12
+	// orders pushed per run
+	pushBatchSize = 20
+	// how much of a failure is kept in push_error
+	pushErrorLimit = 500
+)
+
+type procurementServiceStruct struct {
+	repo   ProcurementRepository
+	db     *sqlx.DB
+	assets assetservice.AssetService
+	logger providers.ZapLoggerProvider
+	// nil when PROCUREMENT_SYSTEM is unset, orders then stay in the asset manager
+	procurement providers.ProcurementProvider
+	notifier    notificationservice.NotificationService
+}
+
+func NewProcurementService(repo ProcurementRepository, db *sqlx.DB, assets assetservice.AssetService, notifier notificationservice.NotificationService, logger providers.ZapLoggerProvider, procurement providers.ProcurementProvider) ProcurementService {
+	return &procurementServiceStruct{repo: repo, db: db, assets: assets, notifier: notifier, logger: logger, procurement: procurement}
+}
+
+func (s *procurementServiceStruct) CreateOrder(ctx context.Context, req CreateOrderReq, userID uuid.UUID, scope models.DepartmentScope) (Order, error) {
+	order := Order{Vendor: req.Vendor, DepartmentID: req.DepartmentID, CreatedBy: userID}
+	if req.Notes != "" {
+		order.Notes = &req.Notes
+	}
+	// scoped managers only order for their own department
+	if !scope.AllDepartments {
+		order.DepartmentID = scope.DepartmentID
+	}
+	for _, line := range req.Lines {
+		config := bytes.TrimSpace(line.Config)
+		if len(config) == 0 || bytes.Equal(config, []byte("null")) {
+			config = []byte("{}")
+		}
+		var object map[string]interface{}
+		if err := json.Unmarshal(config, &object); err != nil {
+			return Order{}, ErrInvalidLineConfig
+		}
+		orderLine := OrderLine{
+			Brand:          line.Brand,
+			Model:          line.Model,
+			Type:           line.Type,
+			OwnedBy:        line.OwnedBy,
+			Quantity:       line.Quantity,
+			UnitCost:       line.UnitCost,
+			WarrantyMonths: line.WarrantyMonths,
+			Config:         config,
+		}
+		if orderLine.OwnedBy == "" {
+			orderLine.OwnedBy = "remotestate"
+		}
+		if orderLine.WarrantyMonths == 0 {
+			orderLine.WarrantyMonths = defaultWarrantyMonths
+		}
+		order.Lines = append(order.Lines, orderLine)
+	}
+
+	var created Order
+	err := utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		id, err := s.repo.InsertOrder(ctx, order)
+		if err != nil {
+			return err
+		}
+		created, err = s.repo.GetOrder(ctx, id, models.DepartmentScope{AllDepartments: true})
+		return err
+	})
+	if err != nil {
+		return Order{}, err
+	}
+	s.logger.GetLogger().Info("purchase order raised", zap.String("number", created.Number), zap.String("vendor", created.Vendor),
+		zap.Int("lines", len(created.Lines)), zap.String("userID", userID.String()))
+	return created, nil
+}
+
+func (s *procurementServiceStruct) GetOrders(ctx context.Context, filter OrderFilter, scope models.DepartmentScope) ([]Order, error) {
+	return s.repo.GetOrders(ctx, filter, scope)
+}
+
+func (s *procurementServiceStruct) ApproveOrder(ctx context.Context, id, approverID uuid.UUID, note string, scope models.DepartmentScope) error {
+	return s.decide(ctx, id, approverID, OrderApproved, note, scope)
+}
+
+func (s *procurementServiceStruct) RejectOrder(ctx context.Context, id, approverID uuid.UUID, note string, scope models.DepartmentScope) error {
+	return s.decide(ctx, id, approverID, OrderRejected, note, scope)
+}
+
+// decide approves or rejects a pending order, whoever raised it can do neither
+func (s *procurementServiceStruct) decide(ctx context.Context, id, approverID uuid.UUID, status, note string, scope models.DepartmentScope) error {
+	err := utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		order, err := s.repo.GetOrder(ctx, id, scope)
+		if err != nil {
+			return err
+		}
+		if order.Status != OrderPendingApproval {
+			return ErrOrderNotPending
+		}
+		if order.CreatedBy == approverID {
+			return ErrSelfApproval
+		}
+		return s.repo.DecideOrder(ctx, id, status, approverID, note)
+	})
+	if err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("purchase order decided", zap.String("id", id.String()), zap.String("status", status), zap.String("approverID", approverID.String()))
+	return nil
+}
+
+// ReceiveOrder creates an asset for every serial number delivered, in one transaction so a duplicate
+// serial number leaves the order as it was. The assets go to the order's department whoever receives it
+func (s *procurementServiceStruct) ReceiveOrder(ctx context.Context, req ReceiveOrderReq, userID uuid.UUID, scope models.DepartmentScope) (ReceiveOrderRes, error) {
+	receivedOn := time.Now().UTC().Truncate(24 * time.Hour)
+	if req.ReceivedOn != nil {
+		receivedOn = *req.ReceivedOn
+	}
+	var res ReceiveOrderRes
+	err := utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		res = ReceiveOrderRes{}
+		order, err := s.repo.GetOrder(ctx, req.ID, scope)
+		if err != nil {
+			return err
+		}
+		if order.Status != OrderApproved && order.Status != OrderPartiallyReceived {
+			return ErrOrderNotReceivable
+		}
+		lines := make(map[uuid.UUID]*OrderLine, len(order.Lines))
+		for i := range order.Lines {
+			lines[order.Lines[i].ID] = &order.Lines[i]
+		}
+
+		for _, received := range req.Lines {
+			line, ok := lines[received.LineID]
+			if !ok {
+				return ErrUnknownLine
+			}
+			if line.ReceivedQuantity+len(received.SerialNos) > line.Quantity {
+				return ErrOverReceived
+			}
+			for _, serialNo := range received.SerialNos {
+				if err := s.assets.AddAssetWithConfig(ctx, assetFromLine(order, *line, serialNo, receivedOn), userID, models.DepartmentScope{AllDepartments: true}); err != nil {
+					return fmt.Errorf("failed to add asset %s of %s: %w", serialNo, order.Number, err)
+				}
+			}
+			if err := s.repo.AddReceived(ctx, line.ID, len(received.SerialNos)); err != nil {
+				return err
+			}
+			line.ReceivedQuantity += len(received.SerialNos)
+			res.Created += len(received.SerialNos)
+		}
+
+		status := OrderReceived
+		for _, line := range order.Lines {
+			if line.ReceivedQuantity < line.Quantity {
+				status = OrderPartiallyReceived
+			}
+		}
+		if err := s.repo.SetOrderStatus(ctx, order.ID, status); err != nil {
+			return err
+		}
+		order.Status = status
+		res.Order = order
+		return nil
+	})
+	if err != nil {
+		return ReceiveOrderRes{}, err
+	}
+	s.logger.GetLogger().Info("purchase order received", zap.String("number", res.Order.Number), zap.Int("assets", res.Created),
+		zap.String("status", res.Order.Status), zap.String("userID", userID.String()))
+	return res, nil
+}
+
+// assetFromLine is the asset a unit of the line becomes, bought on the day it arrived
+func assetFromLine(order Order, line OrderLine, serialNo string, receivedOn time.Time) models.AddAssetWithConfigReq {
+	cost := line.UnitCost
+	orderID := order.ID
+	return models.AddAssetWithConfigReq{
+		AssetReq: models.AssetReq{
+			Brand:           line.Brand,
+			Model:           line.Model,
+			SerialNo:        serialNo,
+			PurchaseDate:    receivedOn,
+			OwnedBy:         line.OwnedBy,
+			Type:            line.Type,
+			WarrantyStart:   receivedOn,
+			WarrantyExpire:  receivedOn.AddDate(0, line.WarrantyMonths, 0),
+			DepartmentID:    order.DepartmentID,
+			PurchaseCost:    &cost,
+			PurchaseOrderID: &orderID,
+		},
+		Config: line.Config,
+	}
+}
+
+// PushApproved gives approved orders to the e-procurement system. A failure is recorded on the order
+// and retried on the next run rather than failing the job
+func (s *procurementServiceStruct) PushApproved(ctx context.Context) error {
+	if s.procurement == nil {
+		return nil
+	}
+	orders, err := s.repo.GetOrdersToPush(ctx, pushBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, order := range orders {
+		push := models.PurchaseOrderPush{Number: order.Number, Vendor: order.Vendor}
+		for _, line := range order.Lines {
+			push.Lines = append(push.Lines, models.PurchaseOrderPushLine{
+				Description: fmt.Sprintf("%s %s %s", line.Brand, line.Model, line.Type),
+				PartNumber:  line.Model,
+				Quantity:    line.Quantity,
+				UnitCost:    line.UnitCost,
+			})
+		}
+		externalID, pushErr := s.procurement.CreatePurchaseOrder(ctx, push)
+		if pushErr != nil {
+			s.logger.GetLogger().Warn("failed to push purchase order, will retry", zap.String("number", order.Number), zap.Error(pushErr))
+			reason := pushErr.Error()
+			if len(reason) > pushErrorLimit {
+				reason = reason[:pushErrorLimit]
+			}
+			if err := s.repo.MarkPushFailed(ctx, order.ID, reason); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := s.repo.MarkPushed(ctx, order.ID, externalID); err != nil {
+			return err
+		}
+		s.logger.GetLogger().Info("purchase order pushed", zap.String("number", order.Number), zap.String("external_id", externalID))
+	}
+	return nil
+}
+
+func (s *procurementServiceStruct) CreateRequest(ctx context.Context, req CreatePurchaseRequestReq, employeeID uuid.UUID) (PurchaseRequest, error) {
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+	req.EstimatedCost = math.Round(req.EstimatedCost*100) / 100
+	var request PurchaseRequest
+	err := utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		id, err := s.repo.InsertRequest(ctx, req, employeeID)
+		if err != nil {
+			return err
+		}
+		request, err = s.repo.GetRequest(ctx, id, models.DepartmentScope{AllDepartments: true})
+		return err
+	})
+	if err != nil {
+		return PurchaseRequest{}, err
+	}
+	s.logger.GetLogger().Info("purchase request raised", zap.String("number", request.Number), zap.String("type", request.Type), zap.String("employeeID", employeeID.String()))
+	s.notifyApprovers(ctx, request, models.ProcurementManagePermission, "Purchase request to review")
+	return request, nil
+}
+
+func (s *procurementServiceStruct) GetRequests(ctx context.Context, filter PurchaseRequestFilter, scope models.DepartmentScope) ([]PurchaseRequest, error) {
+	return s.repo.GetRequests(ctx, filter, scope)
+}
+
+func (s *procurementServiceStruct) ApproveRequest(ctx context.Context, id uuid.UUID, approver RequestApprover, note string, scope models.DepartmentScope) (PurchaseRequest, error) {
+	return s.decideRequest(ctx, id, approver, true, note, scope)
+}
+
+func (s *procurementServiceStruct) RejectRequest(ctx context.Context, id uuid.UUID, approver RequestApprover, note string, scope models.DepartmentScope) (PurchaseRequest, error) {
+	return s.decideRequest(ctx, id, approver, false, note, scope)
+}
+
+// decideRequest moves the request through its stages. A pending review is decided by an asset
+// manager and an approved review waits for finance, the requester decides neither
+func (s *procurementServiceStruct) decideRequest(ctx context.Context, id uuid.UUID, approver RequestApprover, approve bool, note string, scope models.DepartmentScope) (PurchaseRequest, error) {
+	var request PurchaseRequest
+	err := utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		var err error
+		if request, err = s.repo.GetRequest(ctx, id, scope); err != nil {
+			return err
+		}
+		if request.RequestedBy == approver.ID {
+			return ErrRequestSelfApproval
+		}
+		status := RequestRejected
+		switch request.Status {
+		case RequestPendingReview:
+			if !approver.Review {
+				return ErrRequestNotApprover
+			}
+			if approve {
+				status = RequestPendingFinance
+			}
+			err = s.repo.ReviewRequest(ctx, id, status, approver.ID, note)
+		case RequestPendingFinance:
+			if !approver.Finance {
+				return ErrRequestNotApprover
+			}
+			if approve {
+				status = RequestApproved
+			}
+			err = s.repo.DecideRequest(ctx, id, status, approver.ID, note)
+		default:
+			return ErrRequestNotPending
+		}
+		if err != nil {
+			return err
+		}
+		request.Status = status
+		return nil
+	})
+	if err != nil {
+		return PurchaseRequest{}, err
+	}
+	s.logger.GetLogger().Info("purchase request decided", zap.String("number", request.Number), zap.String("status", request.Status), zap.String("approverID", approver.ID.String()))
+
+	switch request.Status {
+	case RequestPendingFinance:
+		s.notifyApprovers(ctx, request, models.ProcurementApprovePermission, "Purchase request to approve")
+	case RequestApproved:
+		s.notifyRequester(ctx, request, "Your purchase request was approved", "it will be ordered")
+		s.notifyApprovers(ctx, request, models.ProcurementManagePermission, "Purchase request to order")
+	case RequestRejected:
+		reason := "no reason was given"
+		if note != "" {
+			reason = note
+		}
+		s.notifyRequester(ctx, request, "Your purchase request was rejected", reason)
+	}
+	return request, nil
+}
+
+// ConvertRequest raises the purchase order of an approved request, the order goes to the request's
+// department and still needs its own approval
+func (s *procurementServiceStruct) ConvertRequest(ctx context.Context, req ConvertRequestReq, userID uuid.UUID, scope models.DepartmentScope) (Order, error) {
+	var order Order
+	var request PurchaseRequest
+	err := utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		var err error
+		if request, err = s.repo.GetRequest(ctx, req.ID, scope); err != nil {
+			return err
+		}
+		if request.Status != RequestApproved {
+			return ErrRequestNotApproved
+		}
+		unitCost := request.EstimatedCost
+		if req.UnitCost != nil {
+			unitCost = *req.UnitCost
+		}
+		order, err = s.CreateOrder(ctx, CreateOrderReq{
+			Vendor: req.Vendor,
+			Notes:  fmt.Sprintf("Purchase request %s by %s: %s", request.Number, request.RequesterName, request.Justification),
+			Lines: []OrderLineReq{{
+				Brand:          req.Brand,
+				Model:          req.Model,
+				Type:           request.Type,
+				Quantity:       request.Quantity,
+				UnitCost:       unitCost,
+				WarrantyMonths: req.WarrantyMonths,
+				Config:         req.Config,
+			}},
+			DepartmentID: request.DepartmentID,
+		}, userID, scope)
+		if err != nil {
+			return err
+		}
+		return s.repo.MarkRequestOrdered(ctx, request.ID, order.ID)
+	})
+	if err != nil {
+		return Order{}, err
+	}
+	s.logger.GetLogger().Info("purchase request ordered", zap.String("number", request.Number), zap.String("order", order.Number))
+	s.notifyRequester(ctx, request, "Your purchase request was ordered", "purchase order "+order.Number+" was raised")
+	return order, nil
+}
+
+// notifyApprovers tells whoever decides the request's next step, a failure is only logged
+func (s *procurementServiceStruct) notifyApprovers(ctx context.Context, request PurchaseRequest, permission models.Permission, title string) {
+	approverIDs, err := s.repo.GetApproverIDs(ctx, permission, request.OrganizationID, request.DepartmentID)
+	if err != nil {
+		s.logger.GetLogger().Error("purchase request approvers not found", zap.String("number", request.Number), zap.Error(err))
+		return
+	}
+	approverIDs = slices.DeleteFunc(approverIDs, func(id uuid.UUID) bool { return id == request.RequestedBy })
+	if len(approverIDs) == 0 {
+		s.logger.GetLogger().Warn("no one to notify about purchase request", zap.String("number", request.Number), zap.String("permission", string(permission)))
+		return
+	}
+	s.notify(ctx, approverIDs, request, title, fmt.Sprintf("%s asks for %d %s at about %.2f each: %s",
+		request.RequesterName, request.Quantity, request.Type, request.EstimatedCost, request.Justification))
+}
+
+func (s *procurementServiceStruct) notifyRequester(ctx context.Context, request PurchaseRequest, title, detail string) {
+	s.notify(ctx, []uuid.UUID{request.RequestedBy}, request, title, fmt.Sprintf("%s for %d %s, %s", request.Number, request.Quantity, request.Type, detail))
+}
+
+func (s *procurementServiceStruct) notify(ctx context.Context, userIDs []uuid.UUID, request PurchaseRequest, title, body string) {
+	if err := s.notifier.Notify(ctx, userIDs, notificationservice.Notification{
+		Category:   notificationservice.CategoryApproval,
+		Title:      title,
+		Body:       body,
+		EntityType: "purchase_request",
+		EntityID:   request.ID.String(),
+	}); err != nil {
+		s.logger.GetLogger().Error("purchase request not notified", zap.String("number", request.Number), zap.Error(err))
+	}
+}
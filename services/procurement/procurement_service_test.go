@@ -0,0 +1,330 @@
+package procurementservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/asset"
+	"asset/services/notification"
+	"asset/services/testutil"
+	"asset/utils"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inTx matches the context of a call made inside a transaction
+var inTx gomock.Matcher = txContextMatcher{}
+
+type txContextMatcher struct{}
+
+func (txContextMatcher) Matches(x interface{}) bool {
+	ctx, ok := x.(context.Context)
+	if !ok {
+		return false
+	}
+	_, ok = utils.TxFromContext(ctx)
+	return ok
+}
+
+func (txContextMatcher) String() string {
+	return "is a context carrying a transaction"
+}
+
+func newTestService(t *testing.T, ctrl *gomock.Controller, procurement providers.ProcurementProvider) (*procurementServiceStruct, *MockProcurementRepository, *assetservice.MockAssetService, sqlmock.Sqlmock) {
+	db, mock := testutil.DB(t)
+	repo := NewMockProcurementRepository(ctrl)
+	assets := assetservice.NewMockAssetService(ctrl)
+	service := NewProcurementService(repo, db, assets, nil, testutil.Logger(ctrl), procurement).(*procurementServiceStruct)
+	return service, repo, assets, mock
+}
+
+func TestReceiveOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	userID := uuid.New()
+	departmentID := uuid.New()
+	receivedOn := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	laptops := OrderLine{ID: uuid.New(), Brand: "Dell", Model: "Latitude 5440", Type: "laptop", OwnedBy: "remotestate", Quantity: 3, UnitCost: 84000,
+		WarrantyMonths: 36, Config: json.RawMessage(`{"processor":"i7","ram":"16GB","os":"windows"}`), ReceivedQuantity: 1}
+	mice := OrderLine{ID: uuid.New(), Brand: "Logitech", Model: "M185", Type: "mouse", OwnedBy: "remotestate", Quantity: 2, UnitCost: 900, WarrantyMonths: 12, Config: json.RawMessage(`{}`)}
+	order := func() Order {
+		return Order{ID: uuid.New(), Number: "PO-000042", Status: OrderApproved, DepartmentID: &departmentID, Lines: []OrderLine{laptops, mice}}
+	}
+
+	t.Run("delivery creates an asset per serial number", func(t *testing.T) {
+		service, repo, assets, db := newTestService(t, ctrl, nil)
+		po := order()
+		db.ExpectBegin()
+		repo.EXPECT().GetOrder(inTx, po.ID, models.DepartmentScope{}).Return(po, nil)
+		var created []models.AddAssetWithConfigReq
+		assets.EXPECT().AddAssetWithConfig(inTx, gomock.Any(), userID, models.DepartmentScope{AllDepartments: true}).Times(2).DoAndReturn(
+			func(_ context.Context, req models.AddAssetWithConfigReq, _ uuid.UUID, _ models.DepartmentScope) error {
+				created = append(created, req)
+				return nil
+			})
+		repo.EXPECT().AddReceived(inTx, laptops.ID, 2).Return(nil)
+		repo.EXPECT().SetOrderStatus(inTx, po.ID, OrderPartiallyReceived).Return(nil)
+		db.ExpectCommit()
+
+		res, err := service.ReceiveOrder(ctx, ReceiveOrderReq{ID: po.ID, ReceivedOn: &receivedOn, Lines: []ReceiveLineReq{{LineID: laptops.ID, SerialNos: []string{"SN-2", "SN-3"}}}}, userID, models.DepartmentScope{})
+		require.NoError(t, err)
+		assert.Equal(t, 2, res.Created)
+		assert.Equal(t, OrderPartiallyReceived, res.Order.Status)
+		require.Len(t, created, 2)
+		assert.Equal(t, "SN-3", created[1].SerialNo)
+		assert.Equal(t, "Latitude 5440", created[1].Model)
+		assert.Equal(t, &departmentID, created[1].DepartmentID)
+		assert.Equal(t, po.ID, *created[1].PurchaseOrderID)
+		assert.Equal(t, 84000.0, *created[1].PurchaseCost)
+		assert.Equal(t, receivedOn.AddDate(3, 0, 0), created[1].WarrantyExpire)
+		assert.JSONEq(t, `{"processor":"i7","ram":"16GB","os":"windows"}`, string(created[1].Config))
+		assert.NoError(t, db.ExpectationsWereMet())
+	})
+
+	t.Run("last units close the order", func(t *testing.T) {
+		service, repo, assets, db := newTestService(t, ctrl, nil)
+		po := order()
+		po.Status = OrderPartiallyReceived
+		po.Lines[0].ReceivedQuantity = 3
+		db.ExpectBegin()
+		repo.EXPECT().GetOrder(inTx, po.ID, models.DepartmentScope{}).Return(po, nil)
+		assets.EXPECT().AddAssetWithConfig(inTx, gomock.Any(), userID, gomock.Any()).Times(2).Return(nil)
+		repo.EXPECT().AddReceived(inTx, mice.ID, 2).Return(nil)
+		repo.EXPECT().SetOrderStatus(inTx, po.ID, OrderReceived).Return(nil)
+		db.ExpectCommit()
+
+		res, err := service.ReceiveOrder(ctx, ReceiveOrderReq{ID: po.ID, Lines: []ReceiveLineReq{{LineID: mice.ID, SerialNos: []string{"M-1", "M-2"}}}}, userID, models.DepartmentScope{})
+		require.NoError(t, err)
+		assert.Equal(t, OrderReceived, res.Order.Status)
+	})
+
+	t.Run("more units than ordered", func(t *testing.T) {
+		service, repo, _, db := newTestService(t, ctrl, nil)
+		po := order()
+		db.ExpectBegin()
+		repo.EXPECT().GetOrder(inTx, po.ID, models.DepartmentScope{}).Return(po, nil)
+		db.ExpectRollback()
+
+		_, err := service.ReceiveOrder(ctx, ReceiveOrderReq{ID: po.ID, Lines: []ReceiveLineReq{{LineID: laptops.ID, SerialNos: []string{"SN-2", "SN-3", "SN-4"}}}}, userID, models.DepartmentScope{})
+		assert.ErrorIs(t, err, ErrOverReceived)
+	})
+
+	t.Run("order waiting for approval", func(t *testing.T) {
+		service, repo, _, db := newTestService(t, ctrl, nil)
+		po := order()
+		po.Status = OrderPendingApproval
+		db.ExpectBegin()
+		repo.EXPECT().GetOrder(inTx, po.ID, models.DepartmentScope{}).Return(po, nil)
+		db.ExpectRollback()
+
+		_, err := service.ReceiveOrder(ctx, ReceiveOrderReq{ID: po.ID, Lines: []ReceiveLineReq{{LineID: mice.ID, SerialNos: []string{"M-1"}}}}, userID, models.DepartmentScope{})
+		assert.ErrorIs(t, err, ErrOrderNotReceivable)
+	})
+}
+
+func TestApproveOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	raisedBy := uuid.New()
+	approverID := uuid.New()
+	scope := models.DepartmentScope{AllDepartments: true}
+	orderID := uuid.New()
+
+	service, repo, _, db := newTestService(t, ctrl, nil)
+	db.ExpectBegin()
+	repo.EXPECT().GetOrder(inTx, orderID, scope).Return(Order{ID: orderID, Status: OrderPendingApproval, CreatedBy: raisedBy}, nil)
+	repo.EXPECT().DecideOrder(inTx, orderID, OrderApproved, approverID, "within budget").Return(nil)
+	db.ExpectCommit()
+	assert.NoError(t, service.ApproveOrder(ctx, orderID, approverID, "within budget", scope))
+
+	// whoever raised the order can't approve it
+	db.ExpectBegin()
+	repo.EXPECT().GetOrder(inTx, orderID, scope).Return(Order{ID: orderID, Status: OrderPendingApproval, CreatedBy: raisedBy}, nil)
+	db.ExpectRollback()
+	assert.ErrorIs(t, service.ApproveOrder(ctx, orderID, raisedBy, "", scope), ErrSelfApproval)
+
+	db.ExpectBegin()
+	repo.EXPECT().GetOrder(inTx, orderID, scope).Return(Order{ID: orderID, Status: OrderApproved, CreatedBy: raisedBy}, nil)
+	db.ExpectRollback()
+	assert.ErrorIs(t, service.RejectOrder(ctx, orderID, approverID, "", scope), ErrOrderNotPending)
+	assert.NoError(t, db.ExpectationsWereMet())
+}
+
+// newRequestTestService is newTestService with a notifier, purchase requests notify every step
+func newRequestTestService(t *testing.T, ctrl *gomock.Controller) (*procurementServiceStruct, *MockProcurementRepository, *notificationservice.MockNotificationService, sqlmock.Sqlmock) {
+	service, repo, _, db := newTestService(t, ctrl, nil)
+	notifier := notificationservice.NewMockNotificationService(ctrl)
+	service.notifier = notifier
+	return service, repo, notifier, db
+}
+
+func TestDecideRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	employeeID, managerID, financeID := uuid.New(), uuid.New(), uuid.New()
+	departmentID := uuid.New()
+	scope := models.DepartmentScope{DepartmentID: &departmentID}
+	request := func(status string) PurchaseRequest {
+		return PurchaseRequest{ID: uuid.New(), Number: "PR-000007", RequestedBy: employeeID, RequesterName: "Dev Sharma", DepartmentID: &departmentID,
+			OrganizationID: models.DefaultOrganizationID, Type: "monitor", Quantity: 1, Justification: "second screen", EstimatedCost: 15000, Status: status}
+	}
+
+	t.Run("review sends the request to finance", func(t *testing.T) {
+		service, repo, notifier, db := newRequestTestService(t, ctrl)
+		pr := request(RequestPendingReview)
+		db.ExpectBegin()
+		repo.EXPECT().GetRequest(inTx, pr.ID, scope).Return(pr, nil)
+		repo.EXPECT().ReviewRequest(inTx, pr.ID, RequestPendingFinance, managerID, "needed").Return(nil)
+		db.ExpectCommit()
+		repo.EXPECT().GetApproverIDs(ctx, models.ProcurementApprovePermission, models.DefaultOrganizationID, &departmentID).Return([]uuid.UUID{financeID, employeeID}, nil)
+		notifier.EXPECT().Notify(ctx, []uuid.UUID{financeID}, gomock.Any()).DoAndReturn(func(_ context.Context, _ []uuid.UUID, n notificationservice.Notification) error {
+			assert.Equal(t, notificationservice.CategoryApproval, n.Category)
+			assert.Equal(t, pr.ID.String(), n.EntityID)
+			return nil
+		})
+
+		got, err := service.ApproveRequest(ctx, pr.ID, RequestApprover{ID: managerID, Review: true}, "needed", scope)
+		require.NoError(t, err)
+		assert.Equal(t, RequestPendingFinance, got.Status)
+		assert.NoError(t, db.ExpectationsWereMet())
+	})
+
+	t.Run("finance stage needs procurement.approve", func(t *testing.T) {
+		service, repo, _, db := newRequestTestService(t, ctrl)
+		pr := request(RequestPendingFinance)
+		db.ExpectBegin()
+		repo.EXPECT().GetRequest(inTx, pr.ID, scope).Return(pr, nil)
+		db.ExpectRollback()
+
+		_, err := service.ApproveRequest(ctx, pr.ID, RequestApprover{ID: managerID, Review: true}, "", scope)
+		assert.ErrorIs(t, err, ErrRequestNotApprover)
+	})
+
+	t.Run("requester can't approve their own request", func(t *testing.T) {
+		service, repo, _, db := newRequestTestService(t, ctrl)
+		pr := request(RequestPendingReview)
+		db.ExpectBegin()
+		repo.EXPECT().GetRequest(inTx, pr.ID, scope).Return(pr, nil)
+		db.ExpectRollback()
+
+		_, err := service.ApproveRequest(ctx, pr.ID, RequestApprover{ID: employeeID, Review: true, Finance: true}, "", scope)
+		assert.ErrorIs(t, err, ErrRequestSelfApproval)
+	})
+
+	t.Run("finance rejection tells the requester", func(t *testing.T) {
+		service, repo, notifier, db := newRequestTestService(t, ctrl)
+		pr := request(RequestPendingFinance)
+		db.ExpectBegin()
+		repo.EXPECT().GetRequest(inTx, pr.ID, scope).Return(pr, nil)
+		repo.EXPECT().DecideRequest(inTx, pr.ID, RequestRejected, financeID, "over budget").Return(nil)
+		db.ExpectCommit()
+		notifier.EXPECT().Notify(ctx, []uuid.UUID{employeeID}, gomock.Any()).DoAndReturn(func(_ context.Context, _ []uuid.UUID, n notificationservice.Notification) error {
+			assert.Contains(t, n.Body, "over budget")
+			return nil
+		})
+
+		got, err := service.RejectRequest(ctx, pr.ID, RequestApprover{ID: financeID, Finance: true}, "over budget", scope)
+		require.NoError(t, err)
+		assert.Equal(t, RequestRejected, got.Status)
+	})
+
+	t.Run("decided request", func(t *testing.T) {
+		service, repo, _, db := newRequestTestService(t, ctrl)
+		pr := request(RequestOrdered)
+		db.ExpectBegin()
+		repo.EXPECT().GetRequest(inTx, pr.ID, scope).Return(pr, nil)
+		db.ExpectRollback()
+
+		_, err := service.RejectRequest(ctx, pr.ID, RequestApprover{ID: financeID, Review: true, Finance: true}, "", scope)
+		assert.ErrorIs(t, err, ErrRequestNotPending)
+	})
+}
+
+func TestConvertRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	employeeID, managerID := uuid.New(), uuid.New()
+	departmentID := uuid.New()
+	scope := models.DepartmentScope{AllDepartments: true}
+	pr := PurchaseRequest{ID: uuid.New(), Number: "PR-000007", RequestedBy: employeeID, RequesterName: "Dev Sharma", DepartmentID: &departmentID,
+		Type: "monitor", Quantity: 2, Justification: "second screen", EstimatedCost: 15000, Status: RequestApproved}
+	req := ConvertRequestReq{ID: pr.ID, Vendor: "Dell India", Brand: "Dell", Model: "P2422H"}
+
+	t.Run("approved request becomes a purchase order", func(t *testing.T) {
+		service, repo, notifier, db := newRequestTestService(t, ctrl)
+		orderID := uuid.New()
+		db.ExpectBegin()
+		repo.EXPECT().GetRequest(inTx, pr.ID, scope).Return(pr, nil)
+		repo.EXPECT().InsertOrder(inTx, gomock.Any()).DoAndReturn(func(_ context.Context, order Order) (uuid.UUID, error) {
+			assert.Equal(t, "Dell India", order.Vendor)
+			assert.Equal(t, &departmentID, order.DepartmentID)
+			require.Len(t, order.Lines, 1)
+			assert.Equal(t, "monitor", order.Lines[0].Type)
+			assert.Equal(t, 2, order.Lines[0].Quantity)
+			assert.Equal(t, 15000.0, order.Lines[0].UnitCost, "the estimate is the unit cost when none is given")
+			return orderID, nil
+		})
+		repo.EXPECT().GetOrder(inTx, orderID, scope).Return(Order{ID: orderID, Number: "PO-000012", Status: OrderPendingApproval}, nil)
+		repo.EXPECT().MarkRequestOrdered(inTx, pr.ID, orderID).Return(nil)
+		db.ExpectCommit()
+		notifier.EXPECT().Notify(ctx, []uuid.UUID{employeeID}, gomock.Any()).Return(nil)
+
+		order, err := service.ConvertRequest(ctx, req, managerID, scope)
+		require.NoError(t, err)
+		assert.Equal(t, "PO-000012", order.Number)
+		assert.NoError(t, db.ExpectationsWereMet())
+	})
+
+	t.Run("request waiting for finance", func(t *testing.T) {
+		service, repo, _, db := newRequestTestService(t, ctrl)
+		pending := pr
+		pending.Status = RequestPendingFinance
+		db.ExpectBegin()
+		repo.EXPECT().GetRequest(inTx, pr.ID, scope).Return(pending, nil)
+		db.ExpectRollback()
+
+		_, err := service.ConvertRequest(ctx, req, managerID, scope)
+		assert.ErrorIs(t, err, ErrRequestNotApproved)
+	})
+}
+
+func TestPushApproved(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	coupa := providers.NewMockProcurementProvider(ctrl)
+	service, repo, _, _ := newTestService(t, ctrl, coupa)
+	pushed := Order{ID: uuid.New(), Number: "PO-000001", Vendor: "Dell India", Lines: []OrderLine{{Brand: "Dell", Model: "Latitude 5440", Type: "laptop", Quantity: 5, UnitCost: 84000}}}
+	failing := Order{ID: uuid.New(), Number: "PO-000002", Vendor: "Unknown supplier"}
+
+	repo.EXPECT().GetOrdersToPush(ctx, pushBatchSize).Return([]Order{pushed, failing}, nil)
+	coupa.EXPECT().CreatePurchaseOrder(ctx, models.PurchaseOrderPush{Number: "PO-000001", Vendor: "Dell India", Lines: []models.PurchaseOrderPushLine{
+		{Description: "Dell Latitude 5440 laptop", PartNumber: "Latitude 5440", Quantity: 5, UnitCost: 84000},
+	}}).Return("3117", nil)
+	repo.EXPECT().MarkPushed(ctx, pushed.ID, "3117").Return(nil)
+	coupa.EXPECT().CreatePurchaseOrder(ctx, gomock.Any()).Return("", errors.New("coupa returned 422: supplier not found"))
+	repo.EXPECT().MarkPushFailed(ctx, failing.ID, "coupa returned 422: supplier not found").Return(nil)
+
+	assert.NoError(t, service.PushApproved(ctx))
+
+	// without an e-procurement system nothing is pushed
+	offline, _, _, _ := newTestService(t, ctrl, nil)
+	assert.NoError(t, offline.PushApproved(ctx))
+}
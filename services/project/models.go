@@ -0,0 +1,108 @@
+package projectservice
+
+import (
+	"asset/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateProjectReq opens a project of a department, dates are YYYY-MM-DD
+type CreateProjectReq struct {
+	Name         string    `json:"name" validate:"required,max=200"`
+	Client       string    `json:"client,omitempty" validate:"max=200"`
+	DepartmentID uuid.UUID `json:"department_id" validate:"required"`
+	StartsOn     string    `json:"starts_on" validate:"required,datetime=2006-01-02"`
+	EndsOn       string    `json:"ends_on,omitempty" validate:"omitempty,datetime=2006-01-02"`
+	Notes        string    `json:"notes,omitempty" validate:"max=500"`
+}
+
+// AllocateAssetReq lends an asset to a project from StartsOn, EndsOn is when it is planned back.
+// DailyRate is what the client is billed a day, an allocation without one isn't billed
+type AllocateAssetReq struct {
+	ProjectID uuid.UUID `json:"project_id" validate:"required"`
+	AssetID   uuid.UUID `json:"asset_id" validate:"required"`
+	StartsOn  string    `json:"starts_on" validate:"required,datetime=2006-01-02"`
+	EndsOn    string    `json:"ends_on,omitempty" validate:"omitempty,datetime=2006-01-02"`
+	DailyRate *float64  `json:"daily_rate,omitempty" validate:"omitempty,gte=0,lte=1000000"`
+}
+
+// ReleaseAssetReq brings an allocated asset back, ReleasedOn is today when left out
+type ReleaseAssetReq struct {
+	AllocationID uuid.UUID `json:"allocation_id" validate:"required"`
+	ReleasedOn   string    `json:"released_on,omitempty" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// ProjectFilter narrows the projects to the departments in Scope, closed ones are left out unless
+// Closed is set. A Limit of 0 lists them all
+type ProjectFilter struct {
+	Search string
+	Closed bool
+	Limit  int
+	Offset int
+	Scope  models.DepartmentScope
+}
+
+// BillingFilter bills the allocations of a project for the days From to To, both included
+type BillingFilter struct {
+	ProjectID uuid.UUID
+	From      time.Time
+	To        time.Time
+	Scope     models.DepartmentScope
+}
+
+// Project is closed once ClosedAt is set, ActiveAssets counts the assets it holds now
+type Project struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	Name           string     `json:"name" db:"name"`
+	Client         *string    `json:"client,omitempty" db:"client"`
+	DepartmentID   uuid.UUID  `json:"department_id" db:"department_id"`
+	DepartmentName string     `json:"department_name" db:"department_name"`
+	StartsOn       time.Time  `json:"starts_on" db:"starts_on"`
+	EndsOn         *time.Time `json:"ends_on,omitempty" db:"ends_on"`
+	Notes          *string    `json:"notes,omitempty" db:"notes"`
+	ActiveAssets   int        `json:"active_assets" db:"active_assets"`
+	CreatedBy      uuid.UUID  `json:"created_by" db:"created_by"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	ClosedAt       *time.Time `json:"closed_at,omitempty" db:"archived_at"`
+}
+
+// Allocation is an asset lent to a project, it is active while ReleasedOn is nil
+type Allocation struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	ProjectID   uuid.UUID  `json:"project_id" db:"project_id"`
+	ProjectName string     `json:"project_name" db:"project_name"`
+	AssetID     uuid.UUID  `json:"asset_id" db:"asset_id"`
+	SerialNo    string     `json:"serial_no" db:"serial_no"`
+	AssetType   string     `json:"asset_type" db:"asset_type"`
+	StartsOn    time.Time  `json:"starts_on" db:"starts_on"`
+	EndsOn      *time.Time `json:"ends_on,omitempty" db:"ends_on"`
+	DailyRate   *float64   `json:"daily_rate,omitempty" db:"daily_rate"`
+	AllocatedBy uuid.UUID  `json:"allocated_by" db:"allocated_by"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	ReleasedOn  *time.Time `json:"released_on,omitempty" db:"released_on"`
+	ReleasedBy  *uuid.UUID `json:"released_by,omitempty" db:"released_by"`
+}
+
+// BillingLine is what one allocation is billed in the window, the days it overlaps it times its rate
+type BillingLine struct {
+	AllocationID uuid.UUID `json:"allocation_id" db:"allocation_id"`
+	AssetID      uuid.UUID `json:"asset_id" db:"asset_id"`
+	SerialNo     string    `json:"serial_no" db:"serial_no"`
+	AssetType    string    `json:"asset_type" db:"asset_type"`
+	From         time.Time `json:"from" db:"billed_from"`
+	To           time.Time `json:"to" db:"billed_to"`
+	Days         int       `json:"days" db:"days"`
+	DailyRate    float64   `json:"daily_rate" db:"daily_rate"`
+	Amount       float64   `json:"amount" db:"amount"`
+}
+
+// ProjectBillingRes bills a project's client for the equipment lent to it in the window
+type ProjectBillingRes struct {
+	ProjectID uuid.UUID     `json:"project_id"`
+	Client    *string       `json:"client,omitempty"`
+	From      string        `json:"from"`
+	To        string        `json:"to"`
+	Total     float64       `json:"total"`
+	Lines     []BillingLine `json:"lines"`
+}
@@ -0,0 +1,276 @@
+package projectservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type ProjectHandler struct {
+	Service        ProjectService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewProjectHandler(service ProjectService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *ProjectHandler {
+	return &ProjectHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in CreateProject", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	creatorID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in CreateProject", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	var req CreateProjectReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "validation failed")
+		return
+	}
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in CreateProject", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	project, err := h.Service.CreateProject(r.Context(), req, creatorID, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to create project", zap.String("name", req.Name), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to create project")
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, project)
+}
+
+// GetProjects lists the projects of the departments in scope, newest first. closed=true lists the
+// closed ones too
+func (h *ProjectHandler) GetProjects(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := ProjectFilter{Search: query.Get("search")}
+	if val := query.Get("closed"); val != "" {
+		closed, err := strconv.ParseBool(val)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, "invalid closed")
+			return
+		}
+		filter.Closed = closed
+	}
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in GetProjects", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+	filter.Scope = scope
+
+	projects, err := h.Service.GetProjects(r.Context(), filter)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch projects", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch projects")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"projects": projects, "limit": filter.Limit, "offset": filter.Offset})
+}
+
+func (h *ProjectHandler) CloseProject(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in CloseProject", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	closerID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in CloseProject", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid project id")
+		return
+	}
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in CloseProject", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	if err := h.Service.CloseProject(r.Context(), id, closerID, scope); err != nil {
+		h.Logger.GetLogger().Error("Failed to close project", zap.String("projectID", id.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to close project")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "project closed"})
+}
+
+func (h *ProjectHandler) AllocateAsset(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in AllocateAsset", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	allocatorID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in AllocateAsset", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	var req AllocateAssetReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "validation failed")
+		return
+	}
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in AllocateAsset", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	allocation, err := h.Service.AllocateAsset(r.Context(), req, allocatorID, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to allocate asset", zap.String("assetID", req.AssetID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to allocate asset")
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, allocation)
+}
+
+func (h *ProjectHandler) ReleaseAsset(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in ReleaseAsset", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	releaserID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in ReleaseAsset", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	var req ReleaseAssetReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "validation failed")
+		return
+	}
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in ReleaseAsset", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	allocation, err := h.Service.ReleaseAsset(r.Context(), req, releaserID, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to release asset", zap.String("allocationID", req.AllocationID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to release asset")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, allocation)
+}
+
+// GetAllocations lists the assets a project held by start, active=true only the ones it holds now
+func (h *ProjectHandler) GetAllocations(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	projectID, err := uuid.Parse(query.Get("id"))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid project id")
+		return
+	}
+	var active bool
+	if val := query.Get("active"); val != "" {
+		if active, err = strconv.ParseBool(val); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, "invalid active")
+			return
+		}
+	}
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in GetAllocations", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	allocations, err := h.Service.GetAllocations(r.Context(), projectID, active, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch allocations", zap.String("projectID", projectID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch allocations")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"project_id": projectID, "allocations": allocations})
+}
+
+// GetBilling bills a project for the days from and to, both included. It covers this month up to
+// today by default
+func (h *ProjectHandler) GetBilling(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	projectID, err := uuid.Parse(query.Get("id"))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid project id")
+		return
+	}
+	today := utils.CalendarDate(time.Now(), utils.LocationFromContext(r.Context()))
+	filter := BillingFilter{ProjectID: projectID, From: today.AddDate(0, 0, 1-today.Day()), To: today}
+	if val := query.Get("from"); val != "" {
+		if filter.From, err = time.Parse(time.DateOnly, val); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, "from must be a YYYY-MM-DD date")
+			return
+		}
+	}
+	if val := query.Get("to"); val != "" {
+		if filter.To, err = time.Parse(time.DateOnly, val); err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, "to must be a YYYY-MM-DD date")
+			return
+		}
+	}
+	if filter.Scope, err = h.AuthMiddleware.GetDepartmentScope(r); err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in GetBilling", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	res, err := h.Service.GetBilling(r.Context(), filter)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to bill project", zap.String("projectID", projectID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to bill project")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, res)
+}
@@ -0,0 +1,274 @@
+package projectservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type ProjectRepository interface {
+	// InsertProject opens a project of a department in scope, ErrDepartmentNotFound when there is none
+	// and ErrProjectExists when the department has an open project of that name
+	InsertProject(ctx context.Context, req CreateProjectReq, createdBy uuid.UUID, scope models.DepartmentScope) (uuid.UUID, error)
+	// GetProject returns a project of a department in scope, ErrProjectNotFound otherwise
+	GetProject(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) (Project, error)
+	GetProjects(ctx context.Context, filter ProjectFilter) ([]Project, error)
+	// CloseProject closes an open project in scope, ErrProjectHasAssets while it still holds assets
+	CloseProject(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) error
+	// InsertAllocation lends an asset in scope to an open project in scope, ErrAssetAllocated when
+	// the asset is on a project already
+	InsertAllocation(ctx context.Context, req AllocateAssetReq, allocatedBy uuid.UUID, scope models.DepartmentScope) (uuid.UUID, error)
+	// GetAllocation returns an allocation of a project in scope, ErrAllocationNotFound otherwise
+	GetAllocation(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) (Allocation, error)
+	// GetAllocations lists the allocations of a project by start, active leaves out the released ones
+	GetAllocations(ctx context.Context, projectID uuid.UUID, active bool) ([]Allocation, error)
+	// ReleaseAllocation ends an active allocation, ErrAllocationNotFound when it was released already
+	ReleaseAllocation(ctx context.Context, id uuid.UUID, releasedOn string, releasedBy uuid.UUID) error
+	// GetBillingLines bills the rated allocations of the project for the days they overlap the
+	// window, active ones up to today
+	GetBillingLines(ctx context.Context, filter BillingFilter) ([]BillingLine, error)
+}
+
+type PostgresProjectRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewProjectRepository(db *sqlx.DB, log providers.ZapLoggerProvider) ProjectRepository {
+	return &PostgresProjectRepository{
+		DB:     db,
+		Logger: log,
+	}
+}
+
+const projectQuery = `
+	SELECT p.id, p.name, p.client, p.department_id, d.name AS department_name, p.starts_on, p.ends_on, p.notes,
+		(SELECT count(*) FROM project_assets pa WHERE pa.project_id = p.id AND pa.released_on IS NULL) AS active_assets,
+		p.created_by, p.created_at, p.archived_at
+	FROM projects p
+	JOIN departments d ON d.id = p.department_id`
+
+const allocationQuery = `
+	SELECT pa.id, pa.project_id, p.name AS project_name, pa.asset_id, a.serial_no, a.type::text AS asset_type,
+		pa.starts_on, pa.ends_on, pa.daily_rate::float8 AS daily_rate, pa.allocated_by, pa.created_at,
+		pa.released_on, pa.released_by
+	FROM project_assets pa
+	JOIN projects p ON p.id = pa.project_id
+	JOIN departments d ON d.id = p.department_id
+	JOIN assets a ON a.id = pa.asset_id`
+
+func (r *PostgresProjectRepository) InsertProject(ctx context.Context, req CreateProjectReq, createdBy uuid.UUID, scope models.DepartmentScope) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &id, `
+		INSERT INTO projects (name, client, department_id, starts_on, ends_on, notes, created_by)
+		SELECT $1, NULLIF($2, ''), d.id, $4, NULLIF($5, '')::date, NULLIF($6, ''), $7
+		FROM departments d
+		WHERE d.id = $3
+		AND ($8 OR d.id IS NOT DISTINCT FROM $9)
+		AND ($10::uuid IS NULL OR d.organization_id = $10)
+		RETURNING id
+	`, req.Name, req.Client, req.DepartmentID, req.StartsOn, req.EndsOn, req.Notes, createdBy,
+		scope.AllDepartments, scope.DepartmentID, scope.OrganizationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, ErrDepartmentNotFound
+		}
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_projects_name" {
+			return uuid.Nil, ErrProjectExists
+		}
+		r.Logger.GetLogger().Error("failed to insert project", zap.String("name", req.Name), zap.Error(err))
+		return uuid.Nil, fmt.Errorf("failed to insert project: %w", err)
+	}
+	return id, nil
+}
+
+func (r *PostgresProjectRepository) GetProject(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) (Project, error) {
+	var project Project
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &project, projectQuery+`
+		WHERE p.id = $1
+		AND ($2 OR p.department_id IS NOT DISTINCT FROM $3)
+		AND ($4::uuid IS NULL OR d.organization_id = $4)
+	`, id, scope.AllDepartments, scope.DepartmentID, scope.OrganizationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Project{}, ErrProjectNotFound
+		}
+		return Project{}, fmt.Errorf("failed to fetch project: %w", err)
+	}
+	return project, nil
+}
+
+func (r *PostgresProjectRepository) GetProjects(ctx context.Context, filter ProjectFilter) ([]Project, error) {
+	projects := make([]Project, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &projects, projectQuery+`
+		WHERE ($1 OR p.archived_at IS NULL)
+		AND ($2 = '' OR p.name ILIKE '%' || $2 || '%' OR p.client ILIKE '%' || $2 || '%')
+		AND ($3 OR p.department_id IS NOT DISTINCT FROM $4)
+		AND ($5::uuid IS NULL OR d.organization_id = $5)
+		ORDER BY p.starts_on DESC, p.name
+		LIMIT NULLIF($6, 0) OFFSET $7
+	`, filter.Closed, filter.Search, filter.Scope.AllDepartments, filter.Scope.DepartmentID, filter.Scope.OrganizationID,
+		filter.Limit, filter.Offset)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch projects", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch projects: %w", err)
+	}
+	return projects, nil
+}
+
+func (r *PostgresProjectRepository) CloseProject(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) error {
+	return utils.WithTransaction(ctx, r.DB, func(ctx context.Context) error {
+		// the project row is locked so no asset is allocated to it while it closes
+		var active int
+		err := utils.Conn(ctx, r.DB).GetContext(ctx, &active, `
+			SELECT (SELECT count(*) FROM project_assets pa WHERE pa.project_id = p.id AND pa.released_on IS NULL)
+			FROM projects p
+			JOIN departments d ON d.id = p.department_id
+			WHERE p.id = $1 AND p.archived_at IS NULL
+			AND ($2 OR p.department_id IS NOT DISTINCT FROM $3)
+			AND ($4::uuid IS NULL OR d.organization_id = $4)
+			FOR UPDATE OF p
+		`, id, scope.AllDepartments, scope.DepartmentID, scope.OrganizationID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrProjectNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch project: %w", err)
+		}
+		if active > 0 {
+			return ErrProjectHasAssets
+		}
+		if _, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `UPDATE projects SET archived_at = now() WHERE id = $1`, id); err != nil {
+			return fmt.Errorf("failed to close project: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *PostgresProjectRepository) InsertAllocation(ctx context.Context, req AllocateAssetReq, allocatedBy uuid.UUID, scope models.DepartmentScope) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := utils.WithTransaction(ctx, r.DB, func(ctx context.Context) error {
+		var projectID uuid.UUID
+		err := utils.Conn(ctx, r.DB).GetContext(ctx, &projectID, `
+			SELECT p.id FROM projects p
+			JOIN departments d ON d.id = p.department_id
+			WHERE p.id = $1 AND p.archived_at IS NULL
+			AND ($2 OR p.department_id IS NOT DISTINCT FROM $3)
+			AND ($4::uuid IS NULL OR d.organization_id = $4)
+			FOR SHARE OF p
+		`, req.ProjectID, scope.AllDepartments, scope.DepartmentID, scope.OrganizationID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrProjectNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch project: %w", err)
+		}
+		err = utils.Conn(ctx, r.DB).GetContext(ctx, &id, `
+			INSERT INTO project_assets (project_id, asset_id, starts_on, ends_on, daily_rate, allocated_by)
+			SELECT $1, a.id, $3, NULLIF($4, '')::date, $5, $6
+			FROM assets a
+			WHERE a.id = $2 AND a.archived_at IS NULL AND a.merged_into IS NULL
+			AND ($7 OR a.department_id IS NOT DISTINCT FROM $8)
+			AND ($9::uuid IS NULL OR a.organization_id = $9)
+			RETURNING id
+		`, req.ProjectID, req.AssetID, req.StartsOn, req.EndsOn, req.DailyRate, allocatedBy,
+			scope.AllDepartments, scope.DepartmentID, scope.OrganizationID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAssetNotFound
+		}
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_project_assets_asset" {
+			return ErrAssetAllocated
+		}
+		return err
+	})
+	if err != nil {
+		var serviceErr *models.ServiceError
+		if !errors.As(err, &serviceErr) {
+			r.Logger.GetLogger().Error("failed to allocate asset", zap.String("asset_id", req.AssetID.String()), zap.Error(err))
+			return uuid.Nil, fmt.Errorf("failed to allocate asset: %w", err)
+		}
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+func (r *PostgresProjectRepository) GetAllocation(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) (Allocation, error) {
+	var allocation Allocation
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &allocation, allocationQuery+`
+		WHERE pa.id = $1
+		AND ($2 OR p.department_id IS NOT DISTINCT FROM $3)
+		AND ($4::uuid IS NULL OR d.organization_id = $4)
+	`, id, scope.AllDepartments, scope.DepartmentID, scope.OrganizationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Allocation{}, ErrAllocationNotFound
+		}
+		return Allocation{}, fmt.Errorf("failed to fetch allocation: %w", err)
+	}
+	return allocation, nil
+}
+
+func (r *PostgresProjectRepository) GetAllocations(ctx context.Context, projectID uuid.UUID, active bool) ([]Allocation, error) {
+	allocations := make([]Allocation, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &allocations, allocationQuery+`
+		WHERE pa.project_id = $1 AND (NOT $2 OR pa.released_on IS NULL)
+		ORDER BY pa.starts_on, a.serial_no
+	`, projectID, active)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch allocations", zap.String("project_id", projectID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch allocations: %w", err)
+	}
+	return allocations, nil
+}
+
+func (r *PostgresProjectRepository) ReleaseAllocation(ctx context.Context, id uuid.UUID, releasedOn string, releasedBy uuid.UUID) error {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE project_assets SET released_on = $2, released_by = $3
+		WHERE id = $1 AND released_on IS NULL
+	`, id, releasedOn, releasedBy)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to release allocation", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to release allocation: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAllocationNotFound
+	}
+	return nil
+}
+
+func (r *PostgresProjectRepository) GetBillingLines(ctx context.Context, filter BillingFilter) ([]BillingLine, error) {
+	lines := make([]BillingLine, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &lines, `
+		SELECT allocation_id, asset_id, serial_no, asset_type, billed_from, billed_to, days, daily_rate,
+			(days * daily_rate)::float8 AS amount
+		FROM (
+			SELECT pa.id AS allocation_id, pa.asset_id, a.serial_no, a.type::text AS asset_type,
+				GREATEST(pa.starts_on, $2::date) AS billed_from,
+				LEAST(COALESCE(pa.released_on, current_date), $3::date) AS billed_to,
+				LEAST(COALESCE(pa.released_on, current_date), $3::date) - GREATEST(pa.starts_on, $2::date) + 1 AS days,
+				pa.daily_rate::float8 AS daily_rate
+			FROM project_assets pa
+			JOIN assets a ON a.id = pa.asset_id
+			WHERE pa.project_id = $1 AND pa.daily_rate IS NOT NULL
+		) l
+		WHERE days > 0
+		ORDER BY billed_from, serial_no
+	`, filter.ProjectID, filter.From.Format(time.DateOnly), filter.To.Format(time.DateOnly))
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to bill project", zap.String("project_id", filter.ProjectID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to bill project: %w", err)
+	}
+	return lines, nil
+}
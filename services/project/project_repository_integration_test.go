@@ -0,0 +1,93 @@
+//go:build integration
+
+package projectservice
+
+import (
+	"asset/database/seed"
+	"asset/database/testdb"
+	"asset/models"
+	"asset/providers"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestProjects(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewProjectRepository(db, logger)
+	ctx := context.Background()
+	managerID, engineering := seed.ID("user:asset-manager"), seed.ID("department:engineering")
+	all := models.DepartmentScope{AllDepartments: true}
+	scoped := models.DepartmentScope{DepartmentID: &engineering}
+
+	projectID, err := repo.InsertProject(ctx, CreateProjectReq{Name: "Acme demo lab", Client: "Acme", DepartmentID: engineering, StartsOn: "2025-09-01"}, managerID, scoped)
+	require.NoError(t, err)
+	_, err = repo.InsertProject(ctx, CreateProjectReq{Name: "acme DEMO lab", DepartmentID: engineering, StartsOn: "2025-09-01"}, managerID, all)
+	assert.ErrorIs(t, err, ErrProjectExists)
+	_, err = repo.InsertProject(ctx, CreateProjectReq{Name: "Ops rollout", DepartmentID: seed.ID("department:operations"), StartsOn: "2025-09-01"}, managerID, scoped)
+	assert.ErrorIs(t, err, ErrDepartmentNotFound)
+
+	// the laptop stays assigned to its employee while it is on the project
+	rate := 20.0
+	req := AllocateAssetReq{ProjectID: projectID, AssetID: seed.ID("asset:laptop-2"), StartsOn: "2025-09-10", DailyRate: &rate}
+	allocationID, err := repo.InsertAllocation(ctx, req, managerID, scoped)
+	require.NoError(t, err)
+	_, err = repo.InsertAllocation(ctx, req, managerID, scoped)
+	assert.ErrorIs(t, err, ErrAssetAllocated)
+	_, err = repo.InsertAllocation(ctx, AllocateAssetReq{ProjectID: projectID, AssetID: seed.ID("asset:laptop-3"), StartsOn: "2025-09-10"}, managerID, scoped)
+	assert.ErrorIs(t, err, ErrAssetNotFound)
+	_, err = repo.InsertAllocation(ctx, AllocateAssetReq{ProjectID: projectID, AssetID: seed.ID("asset:mouse-1"), StartsOn: "2025-09-10"}, managerID, scoped)
+	require.NoError(t, err)
+
+	project, err := repo.GetProject(ctx, projectID, scoped)
+	require.NoError(t, err)
+	assert.Equal(t, 2, project.ActiveAssets)
+	assert.ErrorIs(t, repo.CloseProject(ctx, projectID, all), ErrProjectHasAssets)
+
+	// only the rated laptop is billed, for the 21 days of september it was out
+	lines, err := repo.GetBillingLines(ctx, BillingFilter{ProjectID: projectID,
+		From: time.Date(2025, time.September, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2025, time.September, 30, 0, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	require.Len(t, lines, 1)
+	assert.Equal(t, allocationID, lines[0].AllocationID)
+	assert.Equal(t, 21, lines[0].Days)
+	assert.Equal(t, 420.0, lines[0].Amount)
+
+	require.NoError(t, repo.ReleaseAllocation(ctx, allocationID, "2025-09-19", managerID))
+	assert.ErrorIs(t, repo.ReleaseAllocation(ctx, allocationID, "2025-09-19", managerID), ErrAllocationNotFound)
+	lines, err = repo.GetBillingLines(ctx, BillingFilter{ProjectID: projectID,
+		From: time.Date(2025, time.September, 15, 0, 0, 0, 0, time.UTC), To: time.Date(2025, time.September, 30, 0, 0, 0, 0, time.UTC)})
+	require.NoError(t, err)
+	require.Len(t, lines, 1)
+	assert.Equal(t, 5, lines[0].Days)
+
+	active, err := repo.GetAllocations(ctx, projectID, true)
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, "SEED-MOU-0001", active[0].SerialNo)
+	require.NoError(t, repo.ReleaseAllocation(ctx, active[0].ID, "2025-09-20", managerID))
+
+	// the laptop can go to another project once released
+	otherID, err := repo.InsertProject(ctx, CreateProjectReq{Name: "Beta pilot", DepartmentID: engineering, StartsOn: "2025-09-20"}, managerID, all)
+	require.NoError(t, err)
+	_, err = repo.InsertAllocation(ctx, AllocateAssetReq{ProjectID: otherID, AssetID: seed.ID("asset:laptop-2"), StartsOn: "2025-09-20"}, managerID, all)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.CloseProject(ctx, projectID, all))
+	_, err = repo.InsertAllocation(ctx, AllocateAssetReq{ProjectID: projectID, AssetID: seed.ID("asset:monitor-1"), StartsOn: "2025-09-20"}, managerID, all)
+	assert.ErrorIs(t, err, ErrProjectNotFound)
+	projects, err := repo.GetProjects(ctx, ProjectFilter{Scope: all})
+	require.NoError(t, err)
+	require.Len(t, projects, 1)
+	assert.Equal(t, otherID, projects[0].ID)
+}
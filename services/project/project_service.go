@@ -0,0 +1,203 @@
+package projectservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/audit"
+	"asset/utils"
+	"context"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ProjectService tracks the shared equipment lent to client projects and what the clients are
+// billed for it. An allocation is kept apart from the asset's assignment, so an asset can be on a
+// project with or without an employee holding it
+type ProjectService interface {
+	CreateProject(ctx context.Context, req CreateProjectReq, createdBy uuid.UUID, scope models.DepartmentScope) (Project, error)
+	GetProjects(ctx context.Context, filter ProjectFilter) ([]Project, error)
+	CloseProject(ctx context.Context, id, closedBy uuid.UUID, scope models.DepartmentScope) error
+	AllocateAsset(ctx context.Context, req AllocateAssetReq, allocatedBy uuid.UUID, scope models.DepartmentScope) (Allocation, error)
+	ReleaseAsset(ctx context.Context, req ReleaseAssetReq, releasedBy uuid.UUID, scope models.DepartmentScope) (Allocation, error)
+	GetAllocations(ctx context.Context, projectID uuid.UUID, active bool, scope models.DepartmentScope) ([]Allocation, error)
+	GetBilling(ctx context.Context, filter BillingFilter) (ProjectBillingRes, error)
+}
+
+var (
+	ErrProjectNotFound    = models.NewServiceError(http.StatusNotFound, "project_not_found", "no such project in your scope, or it is closed")
+	ErrProjectExists      = models.NewServiceError(http.StatusConflict, "project_exists", "the department already has an open project of that name")
+	ErrProjectHasAssets   = models.NewServiceError(http.StatusConflict, "project_has_assets", "release the project's assets before closing it")
+	ErrProjectDates       = models.NewServiceError(http.StatusBadRequest, "invalid_project_dates", "ends_on can't be before starts_on")
+	ErrDepartmentNotFound = models.NewServiceError(http.StatusNotFound, "department_not_found", "no such department in your scope")
+	ErrAssetNotFound      = models.NewServiceError(http.StatusNotFound, "asset_not_found", "no such asset in your scope")
+	ErrAssetAllocated     = models.NewServiceError(http.StatusConflict, "asset_allocated", "the asset is on a project already, release it first")
+	ErrAllocationNotFound = models.NewServiceError(http.StatusNotFound, "allocation_not_found", "no active allocation with that id in your scope")
+	ErrReleaseDate        = models.NewServiceError(http.StatusBadRequest, "invalid_release_date", "released_on can't be before the allocation starts")
+	ErrBillingWindow      = models.NewServiceError(http.StatusBadRequest, "invalid_billing_window", "from can't be after to")
+)
+
+type projectServiceStruct struct {
+	repo   ProjectRepository
+	audit  auditservice.AuditService
+	logger providers.ZapLoggerProvider
+}
+
+func NewProjectService(repo ProjectRepository, audit auditservice.AuditService, logger providers.ZapLoggerProvider) ProjectService {
+	return &projectServiceStruct{
+		repo:   repo,
+		audit:  audit,
+		logger: logger,
+	}
+}
+
+func (s *projectServiceStruct) CreateProject(ctx context.Context, req CreateProjectReq, createdBy uuid.UUID, scope models.DepartmentScope) (Project, error) {
+	// validated dates compare as strings
+	if req.EndsOn != "" && req.EndsOn < req.StartsOn {
+		return Project{}, ErrProjectDates
+	}
+	id, err := s.repo.InsertProject(ctx, req, createdBy, scope)
+	if err != nil {
+		return Project{}, err
+	}
+	project, err := s.repo.GetProject(ctx, id, scope)
+	if err != nil {
+		return Project{}, err
+	}
+	s.record(ctx, createdBy, "project.created", "project", id, project)
+	return s.localizeProject(ctx, project), nil
+}
+
+func (s *projectServiceStruct) GetProjects(ctx context.Context, filter ProjectFilter) ([]Project, error) {
+	projects, err := s.repo.GetProjects(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range projects {
+		projects[i] = s.localizeProject(ctx, projects[i])
+	}
+	return projects, nil
+}
+
+func (s *projectServiceStruct) CloseProject(ctx context.Context, id, closedBy uuid.UUID, scope models.DepartmentScope) error {
+	if err := s.repo.CloseProject(ctx, id, scope); err != nil {
+		return err
+	}
+	s.record(ctx, closedBy, "project.closed", "project", id, nil)
+	return nil
+}
+
+func (s *projectServiceStruct) AllocateAsset(ctx context.Context, req AllocateAssetReq, allocatedBy uuid.UUID, scope models.DepartmentScope) (Allocation, error) {
+	if req.EndsOn != "" && req.EndsOn < req.StartsOn {
+		return Allocation{}, ErrProjectDates
+	}
+	if req.DailyRate != nil {
+		rate := math.Round(*req.DailyRate*100) / 100
+		req.DailyRate = &rate
+	}
+	id, err := s.repo.InsertAllocation(ctx, req, allocatedBy, scope)
+	if err != nil {
+		return Allocation{}, err
+	}
+	allocation, err := s.repo.GetAllocation(ctx, id, scope)
+	if err != nil {
+		return Allocation{}, err
+	}
+	s.record(ctx, allocatedBy, "project.asset_allocated", "asset", allocation.AssetID, allocation)
+	return s.localizeAllocation(ctx, allocation), nil
+}
+
+// ReleaseAsset brings the asset back on ReleasedOn, or today in the caller's time zone
+func (s *projectServiceStruct) ReleaseAsset(ctx context.Context, req ReleaseAssetReq, releasedBy uuid.UUID, scope models.DepartmentScope) (Allocation, error) {
+	if req.ReleasedOn == "" {
+		req.ReleasedOn = utils.CalendarDate(time.Now(), utils.LocationFromContext(ctx)).Format(time.DateOnly)
+	}
+	allocation, err := s.repo.GetAllocation(ctx, req.AllocationID, scope)
+	if err != nil {
+		return Allocation{}, err
+	}
+	if allocation.ReleasedOn != nil {
+		return Allocation{}, ErrAllocationNotFound
+	}
+	if req.ReleasedOn < allocation.StartsOn.Format(time.DateOnly) {
+		return Allocation{}, ErrReleaseDate
+	}
+	if err := s.repo.ReleaseAllocation(ctx, req.AllocationID, req.ReleasedOn, releasedBy); err != nil {
+		return Allocation{}, err
+	}
+	allocation, err = s.repo.GetAllocation(ctx, req.AllocationID, scope)
+	if err != nil {
+		return Allocation{}, err
+	}
+	s.record(ctx, releasedBy, "project.asset_released", "asset", allocation.AssetID, allocation)
+	return s.localizeAllocation(ctx, allocation), nil
+}
+
+func (s *projectServiceStruct) GetAllocations(ctx context.Context, projectID uuid.UUID, active bool, scope models.DepartmentScope) ([]Allocation, error) {
+	if _, err := s.repo.GetProject(ctx, projectID, scope); err != nil {
+		return nil, err
+	}
+	allocations, err := s.repo.GetAllocations(ctx, projectID, active)
+	if err != nil {
+		return nil, err
+	}
+	for i := range allocations {
+		allocations[i] = s.localizeAllocation(ctx, allocations[i])
+	}
+	return allocations, nil
+}
+
+// GetBilling bills every rated allocation for its days in the window, closed projects can still be
+// billed for the time they ran
+func (s *projectServiceStruct) GetBilling(ctx context.Context, filter BillingFilter) (ProjectBillingRes, error) {
+	if filter.From.After(filter.To) {
+		return ProjectBillingRes{}, ErrBillingWindow
+	}
+	project, err := s.repo.GetProject(ctx, filter.ProjectID, filter.Scope)
+	if err != nil {
+		return ProjectBillingRes{}, err
+	}
+	lines, err := s.repo.GetBillingLines(ctx, filter)
+	if err != nil {
+		return ProjectBillingRes{}, err
+	}
+	res := ProjectBillingRes{ProjectID: project.ID, Client: project.Client, From: filter.From.Format(time.DateOnly), To: filter.To.Format(time.DateOnly), Lines: lines}
+	for i := range lines {
+		lines[i].Amount = math.Round(lines[i].Amount*100) / 100
+		res.Total += lines[i].Amount
+	}
+	res.Total = math.Round(res.Total*100) / 100
+	s.logger.GetLogger().Info("project billed", zap.String("projectID", project.ID.String()), zap.Int("lines", len(lines)))
+	return res, nil
+}
+
+func (s *projectServiceStruct) record(ctx context.Context, actorID uuid.UUID, action, entityType string, entityID uuid.UUID, value interface{}) {
+	if err := s.audit.Record(ctx, auditservice.AuditEntry{
+		ActorID:    &actorID,
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID.String(),
+		NewValue:   value,
+	}); err != nil {
+		s.logger.GetLogger().Warn("failed to audit project change", zap.String("action", action), zap.String("entityID", entityID.String()), zap.Error(err))
+	}
+}
+
+// the dates of projects and allocations are plain days, only the timestamps are moved
+func (s *projectServiceStruct) localizeProject(ctx context.Context, project Project) Project {
+	loc := utils.LocationFromContext(ctx)
+	project.CreatedAt = utils.InLocation(project.CreatedAt, loc)
+	if project.ClosedAt != nil {
+		closed := utils.InLocation(*project.ClosedAt, loc)
+		project.ClosedAt = &closed
+	}
+	return project
+}
+
+func (s *projectServiceStruct) localizeAllocation(ctx context.Context, allocation Allocation) Allocation {
+	allocation.CreatedAt = utils.InLocation(allocation.CreatedAt, utils.LocationFromContext(ctx))
+	return allocation
+}
@@ -0,0 +1,110 @@
+package projectservice
+
+import (
+	"asset/models"
+	"asset/services/audit"
+	"asset/services/testutil"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T) (ProjectService, *MockProjectRepository, *auditservice.MockAuditService) {
+	ctrl := testutil.Controller(t)
+	repo := NewMockProjectRepository(ctrl)
+	audit := auditservice.NewMockAuditService(ctrl)
+	return NewProjectService(repo, audit, testutil.Logger(ctrl)), repo, audit
+}
+
+// the rate is kept to cents and the allocation audited on the asset
+func TestAllocateAsset(t *testing.T) {
+	svc, repo, audit := newTestService(t)
+	ctx := context.Background()
+	managerID, assetID, allocationID := uuid.New(), uuid.New(), uuid.New()
+	scope := models.DepartmentScope{AllDepartments: true}
+	rate, rounded := 12.345, 12.35
+	req := AllocateAssetReq{ProjectID: uuid.New(), AssetID: assetID, StartsOn: "2026-10-01", DailyRate: &rate}
+
+	repo.EXPECT().InsertAllocation(ctx, gomock.Any(), managerID, scope).DoAndReturn(func(_ context.Context, got AllocateAssetReq, _ uuid.UUID, _ models.DepartmentScope) (uuid.UUID, error) {
+		assert.Equal(t, rounded, *got.DailyRate)
+		return allocationID, nil
+	})
+	repo.EXPECT().GetAllocation(ctx, allocationID, scope).Return(Allocation{ID: allocationID, AssetID: assetID, DailyRate: &rounded}, nil)
+	audit.EXPECT().Record(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, entry auditservice.AuditEntry) error {
+		assert.Equal(t, "project.asset_allocated", entry.Action)
+		assert.Equal(t, assetID.String(), entry.EntityID)
+		return nil
+	})
+
+	allocation, err := svc.AllocateAsset(ctx, req, managerID, scope)
+	require.NoError(t, err)
+	assert.Equal(t, allocationID, allocation.ID)
+}
+
+func TestAllocateAssetDates(t *testing.T) {
+	svc, _, _ := newTestService(t)
+	_, err := svc.AllocateAsset(context.Background(), AllocateAssetReq{ProjectID: uuid.New(), AssetID: uuid.New(), StartsOn: "2026-10-01", EndsOn: "2026-09-30"},
+		uuid.New(), models.DepartmentScope{AllDepartments: true})
+	assert.ErrorIs(t, err, ErrProjectDates)
+}
+
+func TestReleaseAsset(t *testing.T) {
+	scope := models.DepartmentScope{AllDepartments: true}
+	started := time.Date(2026, time.October, 5, 0, 0, 0, 0, time.UTC)
+
+	t.Run("before the start", func(t *testing.T) {
+		svc, repo, _ := newTestService(t)
+		ctx := context.Background()
+		id := uuid.New()
+		repo.EXPECT().GetAllocation(ctx, id, scope).Return(Allocation{ID: id, StartsOn: started}, nil)
+
+		_, err := svc.ReleaseAsset(ctx, ReleaseAssetReq{AllocationID: id, ReleasedOn: "2026-10-04"}, uuid.New(), scope)
+		assert.ErrorIs(t, err, ErrReleaseDate)
+	})
+
+	t.Run("released already", func(t *testing.T) {
+		svc, repo, _ := newTestService(t)
+		ctx := context.Background()
+		id := uuid.New()
+		repo.EXPECT().GetAllocation(ctx, id, scope).Return(Allocation{ID: id, StartsOn: started, ReleasedOn: &started}, nil)
+
+		_, err := svc.ReleaseAsset(ctx, ReleaseAssetReq{AllocationID: id}, uuid.New(), scope)
+		assert.ErrorIs(t, err, ErrAllocationNotFound)
+	})
+
+	t.Run("today by default", func(t *testing.T) {
+		svc, repo, audit := newTestService(t)
+		ctx := context.Background()
+		id, managerID := uuid.New(), uuid.New()
+		repo.EXPECT().GetAllocation(ctx, id, scope).Return(Allocation{ID: id, StartsOn: started}, nil).Times(2)
+		repo.EXPECT().ReleaseAllocation(ctx, id, time.Now().Format(time.DateOnly), managerID).Return(nil)
+		audit.EXPECT().Record(ctx, gomock.Any()).Return(nil)
+
+		_, err := svc.ReleaseAsset(ctx, ReleaseAssetReq{AllocationID: id}, managerID, scope)
+		require.NoError(t, err)
+	})
+}
+
+func TestGetBilling(t *testing.T) {
+	svc, repo, _ := newTestService(t)
+	ctx := context.Background()
+	client := "Acme"
+	filter := BillingFilter{ProjectID: uuid.New(), From: time.Date(2026, time.September, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2026, time.September, 30, 0, 0, 0, 0, time.UTC)}
+	repo.EXPECT().GetProject(ctx, filter.ProjectID, filter.Scope).Return(Project{ID: filter.ProjectID, Client: &client}, nil)
+	repo.EXPECT().GetBillingLines(ctx, filter).Return([]BillingLine{{Days: 30, DailyRate: 10.5, Amount: 315}, {Days: 3, DailyRate: 0.333, Amount: 0.999}}, nil)
+
+	res, err := svc.GetBilling(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, "2026-09-01", res.From)
+	assert.Equal(t, 316.0, res.Total)
+	assert.Equal(t, &client, res.Client)
+
+	filter.From, filter.To = filter.To, filter.From
+	_, err = svc.GetBilling(ctx, filter)
+	assert.ErrorIs(t, err, ErrBillingWindow)
+}
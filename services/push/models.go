@@ -0,0 +1,68 @@
+package pushservice
+
+import (
+	"asset/services/webhook"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// domain events that reach phones, the event service hands these on to Emit
+const (
+	EventAssetAssigned        = webhookservice.EventAssetAssigned
+	EventAssetReturnRequested = "asset.return_requested"
+	EventRoleGrantRequested   = "user.role_grant_requested"
+	EventRoleGrantDecided     = "user.role_grant_decided"
+)
+
+var PushedEvents = []string{EventAssetAssigned, EventAssetReturnRequested, EventRoleGrantRequested, EventRoleGrantDecided}
+
+const (
+	PlatformAndroid = "android"
+	PlatformIOS     = "ios"
+	PlatformWeb     = "web"
+)
+
+const (
+	PushPending = "pending"
+	PushSent    = "sent"
+	PushDead    = "dead"
+)
+
+// Event is a domain event as the event service hands it on, Data holds ids as uuid.UUID or string
+type Event struct {
+	Type        string
+	AggregateID uuid.UUID
+	ActorID     *uuid.UUID
+	Data        map[string]interface{}
+}
+
+type RegisterDeviceReq struct {
+	Token    string `json:"token" validate:"required,max=4096"`
+	Platform string `json:"platform" validate:"required,oneof=android ios web"`
+}
+
+type UnregisterDeviceReq struct {
+	Token string `json:"token" validate:"required,max=4096"`
+}
+
+// duePush is a claimed notification with the tokens of its recipient at the time of the claim
+type duePush struct {
+	ID        uuid.UUID      `db:"id"`
+	UserID    uuid.UUID      `db:"user_id"`
+	EventType string         `db:"event_type"`
+	Title     string         `db:"title"`
+	Body      string         `db:"body"`
+	Data      []byte         `db:"data"`
+	Attempts  int            `db:"attempts"`
+	Tokens    pq.StringArray `db:"tokens"`
+}
+
+// newPush is queued once for each recipient
+type newPush struct {
+	Category  string
+	EventType string
+	Title     string
+	Body      string
+	Data      string
+}
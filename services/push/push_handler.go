@@ -0,0 +1,96 @@
+package pushservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type PushHandler struct {
+	Service        PushService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewPushHandler(service PushService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *PushHandler {
+	return &PushHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+// RegisterDevice is called by the app on every start, the fcm token can change between runs
+func (h *PushHandler) RegisterDevice(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("RegisterDevice request received")
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in RegisterDevice", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in RegisterDevice", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	var req RegisterDeviceReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in RegisterDevice", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Validation failed in RegisterDevice", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	if err := h.Service.RegisterDevice(r.Context(), userUUID, req); err != nil {
+		h.Logger.GetLogger().Error("Failed to register device", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to register device")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "device registered"})
+}
+
+// UnregisterDevice is called on sign out so the next user of the device isn't sent someone else's alerts
+func (h *PushHandler) UnregisterDevice(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("UnregisterDevice request received")
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in UnregisterDevice", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in UnregisterDevice", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	var req UnregisterDeviceReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in UnregisterDevice", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Validation failed in UnregisterDevice", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	if err := h.Service.UnregisterDevice(r.Context(), userUUID, req.Token); err != nil {
+		h.Logger.GetLogger().Error("Failed to unregister device", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to unregister device")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "device unregistered"})
+}
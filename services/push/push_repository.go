@@ -0,0 +1,182 @@
+package pushservice
+
+import (
+	"asset/providers"
+	"asset/services/notification"
+	"asset/utils"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type PushRepository interface {
+	UpsertDevice(ctx context.Context, userID uuid.UUID, token, platform string, keep int) error
+	DeleteDevice(ctx context.Context, userID uuid.UUID, token string) (int64, error)
+	DeleteTokens(ctx context.Context, tokens []string) error
+	GetAssetLabel(ctx context.Context, assetID uuid.UUID) (string, error)
+	GetAdminIDs(ctx context.Context) ([]uuid.UUID, error)
+	EnqueuePush(ctx context.Context, userIDs []uuid.UUID, push newPush) (int64, error)
+	ClaimDuePush(ctx context.Context, limit int, lease time.Duration) ([]duePush, error)
+	MarkSent(ctx context.Context, id uuid.UUID, attempts int) error
+	MarkFailed(ctx context.Context, id uuid.UUID, attempts int, lastError string, nextAttemptAt *time.Time) error
+}
+
+type PostgresPushRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewPushRepository(db *sqlx.DB, log providers.ZapLoggerProvider) PushRepository {
+	return &PostgresPushRepository{DB: db, Logger: log}
+}
+
+// UpsertDevice registers the token for the user and forgets the user's least recently seen devices
+// beyond keep
+func (r *PostgresPushRepository) UpsertDevice(ctx context.Context, userID uuid.UUID, token, platform string, keep int) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO device_tokens (token, user_id, platform)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (token) DO UPDATE
+		SET user_id = EXCLUDED.user_id, platform = EXCLUDED.platform, last_seen_at = now()
+	`, token, userID, platform)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to register device token", zap.String("user_id", userID.String()), zap.Error(err))
+		return fmt.Errorf("failed to register device token: %w", err)
+	}
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		DELETE FROM device_tokens
+		WHERE user_id = $1 AND token NOT IN (
+			SELECT token FROM device_tokens WHERE user_id = $1 ORDER BY last_seen_at DESC LIMIT $2
+		)
+	`, userID, keep)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to trim device tokens", zap.String("user_id", userID.String()), zap.Error(err))
+		return fmt.Errorf("failed to trim device tokens: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresPushRepository) DeleteDevice(ctx context.Context, userID uuid.UUID, token string) (int64, error) {
+	result, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `DELETE FROM device_tokens WHERE token = $1 AND user_id = $2`, token, userID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to remove device token", zap.String("user_id", userID.String()), zap.Error(err))
+		return 0, fmt.Errorf("failed to remove device token: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// DeleteTokens drops tokens firebase reported as no longer registered, whoever they belong to
+func (r *PostgresPushRepository) DeleteTokens(ctx context.Context, tokens []string) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `DELETE FROM device_tokens WHERE token = ANY($1)`, pq.Array(tokens))
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to drop invalid device tokens", zap.Int("tokens", len(tokens)), zap.Error(err))
+		return fmt.Errorf("failed to drop invalid device tokens: %w", err)
+	}
+	return nil
+}
+
+// GetAssetLabel names the asset the way notifications do, brand model (serial)
+func (r *PostgresPushRepository) GetAssetLabel(ctx context.Context, assetID uuid.UUID) (string, error) {
+	var label string
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &label, `
+		SELECT brand || ' ' || model || ' (' || serial_no || ')' FROM assets WHERE id = $1
+	`, assetID)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch asset: %w", err)
+	}
+	return label, nil
+}
+
+func (r *PostgresPushRepository) GetAdminIDs(ctx context.Context) ([]uuid.UUID, error) {
+	adminIDs := []uuid.UUID{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &adminIDs, `
+		SELECT DISTINCT u.id
+		FROM users u
+		JOIN user_roles ur ON ur.user_id = u.id AND ur.archived_at IS NULL
+		WHERE u.archived_at IS NULL AND ur.role = 'admin'
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch admins: %w", err)
+	}
+	return adminIDs, nil
+}
+
+// EnqueuePush queues the notification for every recipient with a registered device, recipients who
+// switched off push for the category are skipped
+func (r *PostgresPushRepository) EnqueuePush(ctx context.Context, userIDs []uuid.UUID, push newPush) (int64, error) {
+	result, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO push_notifications (user_id, event_type, title, body, data)
+		SELECT DISTINCT d.user_id, $2, $3, $4, $5::jsonb
+		FROM device_tokens d
+		WHERE d.user_id = ANY($1::uuid[])
+		AND NOT EXISTS (
+			SELECT 1 FROM notification_preferences p
+			WHERE p.user_id = d.user_id AND p.category = $6 AND p.channel = $7 AND NOT p.enabled
+		)
+	`, pq.Array(userIDs), push.EventType, push.Title, push.Body, push.Data, push.Category, notificationservice.ChannelPush)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to enqueue push notifications", zap.String("event_type", push.EventType), zap.Error(err))
+		return 0, fmt.Errorf("failed to enqueue push notifications: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// ClaimDuePush pushes next_attempt_at of the claimed rows past the lease, another instance running
+// the same job skips them while they are in flight
+func (r *PostgresPushRepository) ClaimDuePush(ctx context.Context, limit int, lease time.Duration) ([]duePush, error) {
+	due := make([]duePush, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &due, `
+		WITH due AS (
+			SELECT id FROM push_notifications
+			WHERE status = 'pending' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE push_notifications p
+		SET next_attempt_at = now() + make_interval(secs => $2)
+		FROM due
+		WHERE p.id = due.id
+		RETURNING p.id, p.user_id, p.event_type, p.title, p.body, p.data, p.attempts,
+			ARRAY(SELECT token FROM device_tokens d WHERE d.user_id = p.user_id) AS tokens
+	`, limit, lease.Seconds())
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to claim push notifications", zap.Error(err))
+		return nil, fmt.Errorf("failed to claim push notifications: %w", err)
+	}
+	return due, nil
+}
+
+func (r *PostgresPushRepository) MarkSent(ctx context.Context, id uuid.UUID, attempts int) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE push_notifications
+		SET status = 'sent', attempts = $2, last_error = NULL, sent_at = now()
+		WHERE id = $1
+	`, id, attempts)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to mark push notification sent", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to update push notification: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed schedules the next attempt, a nil nextAttemptAt dead-letters the notification
+func (r *PostgresPushRepository) MarkFailed(ctx context.Context, id uuid.UUID, attempts int, lastError string, nextAttemptAt *time.Time) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE push_notifications
+		SET status = CASE WHEN $4::timestamptz IS NULL THEN 'dead' ELSE 'pending' END,
+			attempts = $2, last_error = $3,
+			next_attempt_at = COALESCE($4, next_attempt_at)
+		WHERE id = $1
+	`, id, attempts, lastError, nextAttemptAt)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to mark push notification failed", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to update push notification: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package pushservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/notification"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// PushService fans domain events out to the phones of the people they concern. Emit queues one
+// notification per recipient in the caller's transaction, DeliverPending sends the queue from a
+// background job
+type PushService interface {
+	RegisterDevice(ctx context.Context, userID uuid.UUID, req RegisterDeviceReq) error
+	UnregisterDevice(ctx context.Context, userID uuid.UUID, token string) error
+	Emit(ctx context.Context, event Event) error
+	DeliverPending(ctx context.Context) error
+}
+
+type pushServiceStruct struct {
+	repo   PushRepository
+	logger providers.ZapLoggerProvider
+	// nil with fake auth, devices can still register but nothing is queued
+	push providers.PushProvider
+}
+
+func NewPushService(repo PushRepository, logger providers.ZapLoggerProvider, push providers.PushProvider) PushService {
+	return &pushServiceStruct{repo: repo, logger: logger, push: push}
+}
+
+const (
+	// devices kept per user, registering another forgets the one seen least recently
+	devicesPerUser = 10
+	// notifications sent per run, at most pushConcurrency at a time
+	pushBatchSize   = 100
+	pushConcurrency = 8
+	// a claimed notification is hidden from other runs for this long
+	pushLease = 5 * time.Minute
+	// how much of a failure is kept in last_error
+	pushErrorLimit = 500
+)
+
+// retryBackoff is the wait after each failed attempt, a notification that fails once more than it has
+// entries is dead-lettered. One that arrives hours late is noise, so it gives up much sooner than a webhook
+var retryBackoff = []time.Duration{30 * time.Second, 2 * time.Minute, 10 * time.Minute}
+
+var ErrDeviceNotFound = models.NewServiceError(http.StatusNotFound, "device_not_found", "device token not registered for this user")
+
+// errNoDevices dead-letters a notification straight away, there is nowhere to retry it
+var errNoDevices = errors.New("no registered devices")
+
+func (s *pushServiceStruct) RegisterDevice(ctx context.Context, userID uuid.UUID, req RegisterDeviceReq) error {
+	if err := s.repo.UpsertDevice(ctx, userID, req.Token, req.Platform, devicesPerUser); err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("device registered for push", zap.String("userID", userID.String()), zap.String("platform", req.Platform))
+	return nil
+}
+
+func (s *pushServiceStruct) UnregisterDevice(ctx context.Context, userID uuid.UUID, token string) error {
+	removed, err := s.repo.DeleteDevice(ctx, userID, token)
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrDeviceNotFound
+	}
+	s.logger.GetLogger().Info("device unregistered from push", zap.String("userID", userID.String()))
+	return nil
+}
+
+// Emit takes the transaction of the change the event describes, so nobody is told about a change
+// that rolled back
+func (s *pushServiceStruct) Emit(ctx context.Context, event Event) error {
+	if s.push == nil || !slices.Contains(PushedEvents, event.Type) {
+		return nil
+	}
+	recipients, push, err := s.compose(ctx, event)
+	if err != nil || len(recipients) == 0 {
+		return err
+	}
+	queued, err := s.repo.EnqueuePush(ctx, recipients, push)
+	if err != nil {
+		return err
+	}
+	if queued > 0 {
+		s.logger.GetLogger().Debug("push notifications queued", zap.String("type", event.Type), zap.Int64("recipients", queued))
+	}
+	return nil
+}
+
+// compose decides who hears about the event and what they read
+func (s *pushServiceStruct) compose(ctx context.Context, event Event) ([]uuid.UUID, newPush, error) {
+	push := newPush{EventType: event.Type}
+	data := map[string]string{"type": event.Type}
+	var recipients []uuid.UUID
+
+	switch event.Type {
+	case EventAssetAssigned, EventAssetReturnRequested:
+		employeeID, ok := dataID(event.Data, "employee_id")
+		if !ok {
+			return nil, push, nil
+		}
+		label, err := s.repo.GetAssetLabel(ctx, event.AggregateID)
+		if err != nil {
+			return nil, push, err
+		}
+		recipients = []uuid.UUID{employeeID}
+		data["asset_id"] = event.AggregateID.String()
+		if event.Type == EventAssetAssigned {
+			push.Category = notificationservice.CategoryAssignment
+			push.Title = "Asset assigned"
+			push.Body = label + " was assigned to you"
+		} else {
+			push.Category = notificationservice.CategoryReturnRequest
+			push.Title = "Asset return requested"
+			push.Body = fmt.Sprintf("Please return %s: %v", label, event.Data["reason"])
+		}
+
+	case EventRoleGrantRequested:
+		adminIDs, err := s.repo.GetAdminIDs(ctx)
+		if err != nil {
+			return nil, push, err
+		}
+		// neither the requester nor the user receiving the role may approve it
+		for _, adminID := range adminIDs {
+			if adminID != event.AggregateID && (event.ActorID == nil || adminID != *event.ActorID) {
+				recipients = append(recipients, adminID)
+			}
+		}
+		push.Category = notificationservice.CategoryApproval
+		push.Title = "Role grant waiting for approval"
+		push.Body = fmt.Sprintf("A grant of the %v role needs a second admin", event.Data["role"])
+		data["approval_id"] = fmt.Sprint(event.Data["approval_id"])
+
+	case EventRoleGrantDecided:
+		requestedBy, ok := dataID(event.Data, "requested_by")
+		// a withdrawn request needs no news
+		if !ok || (event.ActorID != nil && requestedBy == *event.ActorID) {
+			return nil, push, nil
+		}
+		recipients = []uuid.UUID{requestedBy}
+		push.Category = notificationservice.CategoryApproval
+		push.Title = fmt.Sprintf("Role grant %v", event.Data["status"])
+		push.Body = fmt.Sprintf("Your grant of the %v role was %v", event.Data["role"], event.Data["status"])
+		data["approval_id"] = fmt.Sprint(event.Data["approval_id"])
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, push, fmt.Errorf("failed to marshal push data: %w", err)
+	}
+	push.Data = string(body)
+	return recipients, push, nil
+}
+
+// dataID reads an id of event data, emitted in process as uuid.UUID or decoded from json as string
+func dataID(data map[string]interface{}, key string) (uuid.UUID, bool) {
+	switch value := data[key].(type) {
+	case uuid.UUID:
+		return value, true
+	case *uuid.UUID:
+		return *value, value != nil
+	case string:
+		id, err := uuid.Parse(value)
+		return id, err == nil
+	}
+	return uuid.Nil, false
+}
+
+// DeliverPending sends the notifications that are due, failures are rescheduled or dead-lettered
+// rather than returned so one bad device doesn't fail the job
+func (s *pushServiceStruct) DeliverPending(ctx context.Context) error {
+	if s.push == nil {
+		return nil
+	}
+	due, err := s.repo.ClaimDuePush(ctx, pushBatchSize, pushLease)
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, pushConcurrency)
+	for _, notification := range due {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			s.deliver(ctx, notification)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+func (s *pushServiceStruct) deliver(ctx context.Context, notification duePush) {
+	attempts := notification.Attempts + 1
+	err := s.send(ctx, notification)
+	if err == nil {
+		if err := s.repo.MarkSent(ctx, notification.ID, attempts); err != nil {
+			s.logger.GetLogger().Error("failed to record push notification", zap.String("id", notification.ID.String()), zap.Error(err))
+		}
+		return
+	}
+
+	var next *time.Time
+	if attempts <= len(retryBackoff) && !errors.Is(err, errNoDevices) {
+		at := time.Now().Add(retryBackoff[attempts-1])
+		next = &at
+	}
+	if next == nil {
+		s.logger.GetLogger().Warn("push notification dead-lettered", zap.String("id", notification.ID.String()), zap.String("userID", notification.UserID.String()), zap.Int("attempts", attempts), zap.Error(err))
+	} else {
+		s.logger.GetLogger().Info("push notification failed, will retry", zap.String("id", notification.ID.String()), zap.Int("attempts", attempts), zap.Time("next_attempt_at", *next), zap.Error(err))
+	}
+	reason := err.Error()
+	if len(reason) > pushErrorLimit {
+		reason = reason[:pushErrorLimit]
+	}
+	if err := s.repo.MarkFailed(ctx, notification.ID, attempts, reason, next); err != nil {
+		s.logger.GetLogger().Error("failed to record push notification failure", zap.String("id", notification.ID.String()), zap.Error(err))
+	}
+}
+
+// send reaches every device of the recipient and forgets the tokens firebase turned down
+func (s *pushServiceStruct) send(ctx context.Context, notification duePush) error {
+	if len(notification.Tokens) == 0 {
+		return errNoDevices
+	}
+	data := map[string]string{}
+	if err := json.Unmarshal(notification.Data, &data); err != nil {
+		return fmt.Errorf("failed to read push data: %w", err)
+	}
+	invalid, err := s.push.Send(ctx, notification.Tokens, models.PushMessage{Title: notification.Title, Body: notification.Body, Data: data})
+	if len(invalid) > 0 {
+		if err := s.repo.DeleteTokens(ctx, invalid); err != nil {
+			s.logger.GetLogger().Error("failed to drop invalid device tokens", zap.String("userID", notification.UserID.String()), zap.Error(err))
+		} else {
+			s.logger.GetLogger().Info("dropped unregistered device tokens", zap.String("userID", notification.UserID.String()), zap.Int("tokens", len(invalid)))
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if len(invalid) == len(notification.Tokens) {
+		return errNoDevices
+	}
+	return nil
+}
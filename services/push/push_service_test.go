@@ -0,0 +1,142 @@
+package pushservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/notification"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestEmit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	assetID := uuid.New()
+	employeeID := uuid.New()
+	requesterID := uuid.New()
+	userID := uuid.New()
+	otherAdminID := uuid.New()
+	approvalID := uuid.New()
+
+	tests := []struct {
+		name       string
+		event      Event
+		setupMocks func(repo *MockPushRepository)
+	}{
+		{
+			name:  "assignment reaches the employee",
+			event: Event{Type: EventAssetAssigned, AggregateID: assetID, Data: map[string]interface{}{"employee_id": employeeID}},
+			setupMocks: func(repo *MockPushRepository) {
+				repo.EXPECT().GetAssetLabel(ctx, assetID).Return("Dell XPS (SN-1)", nil)
+				repo.EXPECT().EnqueuePush(ctx, []uuid.UUID{employeeID}, gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ []uuid.UUID, push newPush) (int64, error) {
+						assert.Equal(t, notificationservice.CategoryAssignment, push.Category)
+						assert.Equal(t, "Dell XPS (SN-1) was assigned to you", push.Body)
+						assert.JSONEq(t, `{"type":"asset.assigned","asset_id":"`+assetID.String()+`"}`, push.Data)
+						return 1, nil
+					})
+			},
+		},
+		{
+			name:  "return request read back from json",
+			event: Event{Type: EventAssetReturnRequested, AggregateID: assetID, Data: map[string]interface{}{"employee_id": employeeID.String(), "reason": "employee end date reached"}},
+			setupMocks: func(repo *MockPushRepository) {
+				repo.EXPECT().GetAssetLabel(ctx, assetID).Return("Dell XPS (SN-1)", nil)
+				repo.EXPECT().EnqueuePush(ctx, []uuid.UUID{employeeID}, gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ []uuid.UUID, push newPush) (int64, error) {
+						assert.Equal(t, notificationservice.CategoryReturnRequest, push.Category)
+						assert.Equal(t, "Please return Dell XPS (SN-1): employee end date reached", push.Body)
+						return 1, nil
+					})
+			},
+		},
+		{
+			name:  "grant request skips the requester and the user",
+			event: Event{Type: EventRoleGrantRequested, AggregateID: userID, ActorID: &requesterID, Data: map[string]interface{}{"role": "admin", "approval_id": approvalID}},
+			setupMocks: func(repo *MockPushRepository) {
+				repo.EXPECT().GetAdminIDs(ctx).Return([]uuid.UUID{requesterID, userID, otherAdminID}, nil)
+				repo.EXPECT().EnqueuePush(ctx, []uuid.UUID{otherAdminID}, gomock.Any()).Return(int64(1), nil)
+			},
+		},
+		{
+			name:  "decision reaches the requester",
+			event: Event{Type: EventRoleGrantDecided, AggregateID: userID, ActorID: &otherAdminID, Data: map[string]interface{}{"role": "admin", "status": "approved", "requested_by": requesterID}},
+			setupMocks: func(repo *MockPushRepository) {
+				repo.EXPECT().EnqueuePush(ctx, []uuid.UUID{requesterID}, gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ []uuid.UUID, push newPush) (int64, error) {
+						assert.Equal(t, "Your grant of the admin role was approved", push.Body)
+						return 1, nil
+					})
+			},
+		},
+		{
+			name:       "withdrawn request",
+			event:      Event{Type: EventRoleGrantDecided, AggregateID: userID, ActorID: &requesterID, Data: map[string]interface{}{"role": "admin", "status": "rejected", "requested_by": requesterID}},
+			setupMocks: func(repo *MockPushRepository) {},
+		},
+		{
+			name:       "event phones aren't told about",
+			event:      Event{Type: "asset.returned", AggregateID: assetID, Data: map[string]interface{}{"employee_id": employeeID}},
+			setupMocks: func(repo *MockPushRepository) {},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := NewMockPushRepository(ctrl)
+			logger := providers.NewMockZapLoggerProvider(ctrl)
+			logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+			tc.setupMocks(repo)
+
+			service := NewPushService(repo, logger, providers.NewMockPushProvider(ctrl))
+			assert.NoError(t, service.Emit(ctx, tc.event))
+		})
+	}
+}
+
+func TestDeliverPending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	sent := duePush{ID: uuid.New(), UserID: uuid.New(), Title: "Asset assigned", Body: "Dell XPS (SN-1) was assigned to you", Data: []byte(`{"type":"asset.assigned"}`), Tokens: []string{"phone", "old-phone"}}
+	failing := duePush{ID: uuid.New(), UserID: uuid.New(), Data: []byte(`{}`), Attempts: 1, Tokens: []string{"tablet"}}
+	exhausted := duePush{ID: uuid.New(), UserID: uuid.New(), Data: []byte(`{}`), Attempts: len(retryBackoff), Tokens: []string{"laptop"}}
+	uninstalled := duePush{ID: uuid.New(), UserID: uuid.New(), Data: []byte(`{}`)}
+
+	repo := NewMockPushRepository(ctrl)
+	push := providers.NewMockPushProvider(ctrl)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	repo.EXPECT().ClaimDuePush(ctx, pushBatchSize, pushLease).Return([]duePush{sent, failing, exhausted, uninstalled}, nil)
+
+	push.EXPECT().Send(ctx, sent.Tokens, models.PushMessage{Title: sent.Title, Body: sent.Body, Data: map[string]string{"type": "asset.assigned"}}).Return([]string{"old-phone"}, nil)
+	repo.EXPECT().DeleteTokens(ctx, []string{"old-phone"}).Return(nil)
+	repo.EXPECT().MarkSent(ctx, sent.ID, 1).Return(nil)
+
+	push.EXPECT().Send(ctx, failing.Tokens, gomock.Any()).Return(nil, errors.New("unavailable"))
+	repo.EXPECT().MarkFailed(ctx, failing.ID, 2, "unavailable", gomock.Not(gomock.Nil())).DoAndReturn(
+		func(_ context.Context, _ uuid.UUID, _ int, _ string, next *time.Time) error {
+			assert.WithinDuration(t, time.Now().Add(retryBackoff[1]), *next, time.Minute)
+			return nil
+		})
+
+	push.EXPECT().Send(ctx, exhausted.Tokens, gomock.Any()).Return(nil, errors.New("unavailable"))
+	repo.EXPECT().MarkFailed(ctx, exhausted.ID, len(retryBackoff)+1, "unavailable", (*time.Time)(nil)).Return(nil)
+
+	// nothing to send to, dead-lettered on the first attempt
+	repo.EXPECT().MarkFailed(ctx, uninstalled.ID, 1, errNoDevices.Error(), (*time.Time)(nil)).Return(nil)
+
+	service := NewPushService(repo, logger, push)
+	assert.NoError(t, service.DeliverPending(ctx))
+}
@@ -0,0 +1,106 @@
+package reportservice
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// reports a schedule can send
+const (
+	ReportInventorySummary = "inventory_summary"
+	ReportOverdueReturns   = "overdue_returns"
+	ReportAssetsInService  = "assets_in_service"
+)
+
+const (
+	FormatCSV = "csv"
+	FormatPDF = "pdf"
+)
+
+// ReportFilter narrows the assets a report covers, empty fields match everything. Days is how long a
+// return request has been open or an asset in service before it is listed, the summary ignores it
+type ReportFilter struct {
+	Type         []string   `json:"type,omitempty" validate:"omitempty,dive,asset_type"`
+	OwnedBy      []string   `json:"owned_by,omitempty" validate:"omitempty,dive,asset_ownership"`
+	DepartmentID *uuid.UUID `json:"department_id,omitempty"`
+	Days         int        `json:"days,omitempty" validate:"min=0,max=3650"`
+}
+
+func (f ReportFilter) Value() (driver.Value, error) {
+	payload, err := json.Marshal(f)
+	return string(payload), err
+}
+
+func (f *ReportFilter) Scan(src interface{}) error {
+	data, ok := src.([]byte)
+	if !ok {
+		return errors.New("report filter is not jsonb")
+	}
+	return json.Unmarshal(data, f)
+}
+
+// ReportScheduleReq creates a schedule or replaces one. Schedule is a five field cron expression in
+// UTC or @daily, @weekly and the like
+type ReportScheduleReq struct {
+	Name       string       `json:"name" validate:"required,max=200"`
+	Report     string       `json:"report" validate:"required,oneof=inventory_summary overdue_returns assets_in_service"`
+	Format     string       `json:"format" validate:"required,oneof=csv pdf"`
+	Schedule   string       `json:"schedule" validate:"required,max=100"`
+	Filter     ReportFilter `json:"filter"`
+	Recipients []string     `json:"recipients" validate:"required,min=1,max=20,dive,email"`
+}
+
+type ReportSchedule struct {
+	ID         uuid.UUID      `json:"id" db:"id"`
+	Name       string         `json:"name" db:"name"`
+	Report     string         `json:"report" db:"report"`
+	Format     string         `json:"format" db:"format"`
+	Schedule   string         `json:"schedule" db:"schedule"`
+	Filter     ReportFilter   `json:"filter" db:"filter"`
+	Recipients pq.StringArray `json:"recipients" db:"recipients"`
+	CreatedBy  uuid.UUID      `json:"created_by" db:"created_by"`
+	// the organization the report covers, nil for every organization
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty" db:"organization_id"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+	NextRunAt      time.Time  `json:"next_run_at" db:"next_run_at"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty" db:"last_run_at"`
+	LastError      *string    `json:"last_error,omitempty" db:"last_error"`
+}
+
+// reportTable is a report as rows under a header, the way it is written to csv or pdf
+type reportTable struct {
+	Title  string
+	Header []string
+	Rows   [][]string
+}
+
+type summaryRow struct {
+	Type   string `db:"type"`
+	Status string `db:"status"`
+	Count  int    `db:"count"`
+}
+
+type overdueReturnRow struct {
+	Brand         string     `db:"brand"`
+	Model         string     `db:"model"`
+	SerialNo      string     `db:"serial_no"`
+	EmployeeName  string     `db:"employee_name"`
+	EmployeeEmail string     `db:"employee_email"`
+	EndDate       *time.Time `db:"end_date"`
+	Reason        string     `db:"reason"`
+	RequestedAt   time.Time  `db:"requested_at"`
+}
+
+type inServiceRow struct {
+	Brand        string    `db:"brand"`
+	Model        string    `db:"model"`
+	SerialNo     string    `db:"serial_no"`
+	Reason       string    `db:"reason"`
+	ServiceStart time.Time `db:"service_start"`
+}
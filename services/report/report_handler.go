@@ -0,0 +1,153 @@
+package reportservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type ReportHandler struct {
+	Service        ReportService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewReportHandler(service ReportService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *ReportHandler {
+	return &ReportHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+func (h *ReportHandler) GetSchedules(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetReportSchedules request received")
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve scope in GetReportSchedules", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve organization")
+		return
+	}
+	schedules, err := h.Service.GetSchedules(r.Context(), scope.OrganizationID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch report schedules", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch report schedules")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"schedules": schedules})
+}
+
+func (h *ReportHandler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("CreateReportSchedule request received")
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in CreateReportSchedule", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in CreateReportSchedule", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+	req, ok := h.parseReq(w, r, "CreateReportSchedule")
+	if !ok {
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve scope in CreateReportSchedule", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve organization")
+		return
+	}
+	schedule, err := h.Service.CreateSchedule(r.Context(), req, userUUID, scope.OrganizationID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to create report schedule", zap.String("name", req.Name), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to create report schedule")
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+		"message":  "report schedule created",
+		"schedule": schedule,
+	})
+}
+
+func (h *ReportHandler) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("UpdateReportSchedule request received")
+	scheduleID, ok := h.scheduleID(w, r, "UpdateReportSchedule")
+	if !ok {
+		return
+	}
+	req, ok := h.parseReq(w, r, "UpdateReportSchedule")
+	if !ok {
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve scope in UpdateReportSchedule", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve organization")
+		return
+	}
+	schedule, err := h.Service.UpdateSchedule(r.Context(), scheduleID, req, scope.OrganizationID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to update report schedule", zap.String("id", scheduleID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to update report schedule")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":  "report schedule updated",
+		"schedule": schedule,
+	})
+}
+
+func (h *ReportHandler) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("DeleteReportSchedule request received")
+	scheduleID, ok := h.scheduleID(w, r, "DeleteReportSchedule")
+	if !ok {
+		return
+	}
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve scope in DeleteReportSchedule", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve organization")
+		return
+	}
+	if err := h.Service.DeleteSchedule(r.Context(), scheduleID, scope.OrganizationID); err != nil {
+		h.Logger.GetLogger().Error("Failed to delete report schedule", zap.String("id", scheduleID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to delete report schedule")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "report schedule deleted"})
+}
+
+func (h *ReportHandler) parseReq(w http.ResponseWriter, r *http.Request, handler string) (ReportScheduleReq, bool) {
+	var req ReportScheduleReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return req, false
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Validation failed in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return req, false
+	}
+	return req, true
+}
+
+func (h *ReportHandler) scheduleID(w http.ResponseWriter, r *http.Request, handler string) (uuid.UUID, bool) {
+	id := r.URL.Query().Get("id")
+	scheduleID, err := uuid.Parse(id)
+	if err != nil {
+		h.Logger.GetLogger().Error("Invalid id in "+handler, zap.String("id", id), zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid id")
+		return uuid.Nil, false
+	}
+	return scheduleID, true
+}
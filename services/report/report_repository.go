@@ -0,0 +1,237 @@
+package reportservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type ReportRepository interface {
+	InsertSchedule(ctx context.Context, schedule ReportSchedule) (ReportSchedule, error)
+	// organizationID leaves out schedules of other organizations, nil reads every organization
+	GetSchedules(ctx context.Context, organizationID *uuid.UUID) ([]ReportSchedule, error)
+	GetSchedule(ctx context.Context, id uuid.UUID, organizationID *uuid.UUID) (ReportSchedule, error)
+	UpdateSchedule(ctx context.Context, schedule ReportSchedule) (ReportSchedule, error)
+	ArchiveSchedule(ctx context.Context, id uuid.UUID, organizationID *uuid.UUID) error
+	ClaimDueSchedules(ctx context.Context, limit int, lease time.Duration) ([]ReportSchedule, error)
+	RecordRun(ctx context.Context, id uuid.UUID, nextRunAt time.Time, runErr *string) error
+	GetInventorySummary(ctx context.Context, filter ReportFilter, organizationID *uuid.UUID) ([]summaryRow, error)
+	GetOverdueReturns(ctx context.Context, filter ReportFilter, organizationID *uuid.UUID, limit int) ([]overdueReturnRow, error)
+	GetAssetsInService(ctx context.Context, filter ReportFilter, organizationID *uuid.UUID, limit int) ([]inServiceRow, error)
+}
+
+type PostgresReportRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewReportRepository(db *sqlx.DB, log providers.ZapLoggerProvider) ReportRepository {
+	return &PostgresReportRepository{DB: db, Logger: log}
+}
+
+const scheduleColumns = `id, name, report, format, schedule, filter, recipients, created_by, organization_id, created_at,
+	updated_at, next_run_at, last_run_at, last_error`
+
+func (r *PostgresReportRepository) InsertSchedule(ctx context.Context, schedule ReportSchedule) (ReportSchedule, error) {
+	var created ReportSchedule
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &created, `
+		INSERT INTO report_schedules (name, report, format, schedule, filter, recipients, created_by, organization_id, next_run_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING `+scheduleColumns,
+		schedule.Name, schedule.Report, schedule.Format, schedule.Schedule, schedule.Filter, schedule.Recipients, schedule.CreatedBy,
+		schedule.OrganizationID, schedule.NextRunAt)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert report schedule", zap.String("name", schedule.Name), zap.Error(err))
+		return created, fmt.Errorf("failed to insert report schedule: %w", err)
+	}
+	return created, nil
+}
+
+func (r *PostgresReportRepository) GetSchedules(ctx context.Context, organizationID *uuid.UUID) ([]ReportSchedule, error) {
+	schedules := []ReportSchedule{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &schedules, `
+		SELECT `+scheduleColumns+`
+		FROM report_schedules
+		WHERE archived_at IS NULL AND ($1::uuid IS NULL OR organization_id = $1)
+		ORDER BY created_at DESC
+	`, organizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch report schedules", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch report schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+func (r *PostgresReportRepository) GetSchedule(ctx context.Context, id uuid.UUID, organizationID *uuid.UUID) (ReportSchedule, error) {
+	var schedule ReportSchedule
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &schedule, `
+		SELECT `+scheduleColumns+`
+		FROM report_schedules
+		WHERE id = $1 AND archived_at IS NULL AND ($2::uuid IS NULL OR organization_id = $2)
+	`, id, organizationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return schedule, ErrScheduleNotFound
+	}
+	if err != nil {
+		return schedule, fmt.Errorf("failed to fetch report schedule: %w", err)
+	}
+	return schedule, nil
+}
+
+// UpdateSchedule replaces what a schedule sends and when, the last run is kept. The schedule has to be
+// in schedule.OrganizationID when that is set, the organization it covers is never changed
+func (r *PostgresReportRepository) UpdateSchedule(ctx context.Context, schedule ReportSchedule) (ReportSchedule, error) {
+	var updated ReportSchedule
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &updated, `
+		UPDATE report_schedules
+		SET name = $2, report = $3, format = $4, schedule = $5, filter = $6, recipients = $7, next_run_at = $8,
+			updated_at = now()
+		WHERE id = $1 AND archived_at IS NULL AND ($9::uuid IS NULL OR organization_id = $9)
+		RETURNING `+scheduleColumns,
+		schedule.ID, schedule.Name, schedule.Report, schedule.Format, schedule.Schedule, schedule.Filter, schedule.Recipients, schedule.NextRunAt,
+		schedule.OrganizationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return updated, ErrScheduleNotFound
+	}
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to update report schedule", zap.String("id", schedule.ID.String()), zap.Error(err))
+		return updated, fmt.Errorf("failed to update report schedule: %w", err)
+	}
+	return updated, nil
+}
+
+func (r *PostgresReportRepository) ArchiveSchedule(ctx context.Context, id uuid.UUID, organizationID *uuid.UUID) error {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE report_schedules SET archived_at = now()
+		WHERE id = $1 AND archived_at IS NULL AND ($2::uuid IS NULL OR organization_id = $2)
+	`, id, organizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to archive report schedule", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to archive report schedule: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrScheduleNotFound
+	}
+	return nil
+}
+
+// ClaimDueSchedules hides the claimed schedules from other runs for lease, RecordRun moves them on to
+// their next run
+func (r *PostgresReportRepository) ClaimDueSchedules(ctx context.Context, limit int, lease time.Duration) ([]ReportSchedule, error) {
+	due := make([]ReportSchedule, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &due, `
+		WITH due AS (
+			SELECT id FROM report_schedules
+			WHERE archived_at IS NULL AND next_run_at <= now()
+			ORDER BY next_run_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE report_schedules s
+		SET next_run_at = now() + make_interval(secs => $2)
+		FROM due
+		WHERE s.id = due.id
+		RETURNING s.id, s.name, s.report, s.format, s.schedule, s.filter, s.recipients, s.created_by, s.organization_id,
+			s.created_at, s.updated_at, s.next_run_at, s.last_run_at, s.last_error
+	`,
+		limit, lease.Seconds())
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to claim report schedules", zap.Error(err))
+		return nil, fmt.Errorf("failed to claim report schedules: %w", err)
+	}
+	return due, nil
+}
+
+func (r *PostgresReportRepository) RecordRun(ctx context.Context, id uuid.UUID, nextRunAt time.Time, runErr *string) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE report_schedules SET next_run_at = $2, last_run_at = now(), last_error = $3 WHERE id = $1
+	`, id, nextRunAt, runErr)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to record report run", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to record report run: %w", err)
+	}
+	return nil
+}
+
+// assetFilter is the condition the filter and the schedule's organization put on assets a, its arguments
+// start at $1
+const assetFilter = `(cardinality($1::text[]) = 0 OR a.type::text = ANY($1))
+		AND (cardinality($2::text[]) = 0 OR a.owned_by::text = ANY($2))
+		AND ($3::uuid IS NULL OR a.department_id = $3)
+		AND ($4::uuid IS NULL OR a.organization_id = $4)`
+
+func filterArgs(filter ReportFilter, organizationID *uuid.UUID) []interface{} {
+	return []interface{}{pq.Array(nonNil(filter.Type)), pq.Array(nonNil(filter.OwnedBy)), filter.DepartmentID, organizationID}
+}
+
+func (r *PostgresReportRepository) GetInventorySummary(ctx context.Context, filter ReportFilter, organizationID *uuid.UUID) ([]summaryRow, error) {
+	rows := []summaryRow{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &rows, `
+		SELECT COALESCE(a.type::text, '') AS type, a.status::text AS status, count(*) AS count
+		FROM assets a
+		WHERE a.archived_at IS NULL AND `+assetFilter+`
+		GROUP BY 1, 2
+		ORDER BY 1, 2
+	`, filterArgs(filter, organizationID)...)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch inventory summary", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch inventory summary: %w", err)
+	}
+	return rows, nil
+}
+
+// GetOverdueReturns lists the return requests open for more than filter.Days days, oldest first
+func (r *PostgresReportRepository) GetOverdueReturns(ctx context.Context, filter ReportFilter, organizationID *uuid.UUID, limit int) ([]overdueReturnRow, error) {
+	rows := []overdueReturnRow{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &rows, `
+		SELECT a.brand, a.model, a.serial_no, u.username AS employee_name, u.email AS employee_email, u.end_date,
+			rr.reason, rr.created_at AS requested_at
+		FROM asset_return_requests rr
+		JOIN assets a ON a.id = rr.asset_id
+		JOIN users u ON u.id = rr.employee_id
+		WHERE rr.status = 'open' AND rr.created_at < now() - make_interval(days => $5) AND `+assetFilter+`
+		ORDER BY rr.created_at
+		LIMIT $6
+	`, append(filterArgs(filter, organizationID), filter.Days, limit)...)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch overdue returns", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch overdue returns: %w", err)
+	}
+	return rows, nil
+}
+
+// GetAssetsInService lists the assets in service for more than filter.Days days, longest first
+func (r *PostgresReportRepository) GetAssetsInService(ctx context.Context, filter ReportFilter, organizationID *uuid.UUID, limit int) ([]inServiceRow, error) {
+	rows := []inServiceRow{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &rows, `
+		SELECT a.brand, a.model, a.serial_no, s.reason, s.service_start
+		FROM asset_service s
+		JOIN assets a ON a.id = s.asset_id
+		WHERE s.service_end IS NULL AND s.archived_at IS NULL AND a.archived_at IS NULL
+		AND s.service_start < now() - make_interval(days => $5) AND `+assetFilter+`
+		ORDER BY s.service_start
+		LIMIT $6
+	`, append(filterArgs(filter, organizationID), filter.Days, limit)...)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch assets in service", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch assets in service: %w", err)
+	}
+	return rows, nil
+}
+
+// nonNil keeps pq.Array from sending NULL, which cardinality() doesn't take as empty
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,257 @@
+package reportservice
+
+import (
+	"asset/jobs"
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ReportService keeps the schedules admins set up and emails their reports from a background job,
+// each run covers the assets as they are when it runs. organizationID is the caller's
+// scope.OrganizationID, a schedule only reports on its organization and others can't see or change it
+type ReportService interface {
+	CreateSchedule(ctx context.Context, req ReportScheduleReq, userID uuid.UUID, organizationID *uuid.UUID) (ReportSchedule, error)
+	GetSchedules(ctx context.Context, organizationID *uuid.UUID) ([]ReportSchedule, error)
+	UpdateSchedule(ctx context.Context, id uuid.UUID, req ReportScheduleReq, organizationID *uuid.UUID) (ReportSchedule, error)
+	DeleteSchedule(ctx context.Context, id uuid.UUID, organizationID *uuid.UUID) error
+	SendDue(ctx context.Context) error
+}
+
+var (
+	ErrScheduleNotFound = models.NewServiceError(http.StatusNotFound, "report_schedule_not_found", "report schedule not found")
+	ErrInvalidSchedule  = models.NewServiceError(http.StatusBadRequest, "invalid_report_schedule", "schedule must be a five field cron expression or one of @hourly, @daily, @weekly, @monthly")
+)
+
+const (
+	// schedules sent per run, the rest wait for the next run
+	reportBatchSize = 20
+	// a claimed schedule is hidden from other runs for this long
+	reportLease = 10 * time.Minute
+	// rows a listing report holds, a report that reaches it says so
+	reportRowLimit = 5000
+	// how much of a failure is kept in last_error
+	reportErrorLimit = 500
+)
+
+type reportServiceStruct struct {
+	repo   ReportRepository
+	logger providers.ZapLoggerProvider
+	email  providers.EmailProvider
+}
+
+func NewReportService(repo ReportRepository, logger providers.ZapLoggerProvider, email providers.EmailProvider) ReportService {
+	return &reportServiceStruct{repo: repo, logger: logger, email: email}
+}
+
+func (s *reportServiceStruct) CreateSchedule(ctx context.Context, req ReportScheduleReq, userID uuid.UUID, organizationID *uuid.UUID) (ReportSchedule, error) {
+	next, err := nextRun(req.Schedule, time.Now())
+	if err != nil {
+		return ReportSchedule{}, err
+	}
+	created, err := s.repo.InsertSchedule(ctx, ReportSchedule{
+		Name:       req.Name,
+		Report:     req.Report,
+		Format:     req.Format,
+		Schedule:   strings.TrimSpace(req.Schedule),
+		Filter:     req.Filter,
+		Recipients: req.Recipients,
+		CreatedBy:  userID,
+		NextRunAt:  next,
+
+		OrganizationID: organizationID,
+	})
+	if err != nil {
+		return ReportSchedule{}, err
+	}
+	s.logger.GetLogger().Info("report schedule created", zap.String("id", created.ID.String()), zap.String("report", created.Report),
+		zap.Time("next_run_at", created.NextRunAt), zap.String("userID", userID.String()))
+	return created, nil
+}
+
+func (s *reportServiceStruct) GetSchedules(ctx context.Context, organizationID *uuid.UUID) ([]ReportSchedule, error) {
+	return s.repo.GetSchedules(ctx, organizationID)
+}
+
+// UpdateSchedule counts the next run from now, so a changed schedule doesn't send a run it skipped
+func (s *reportServiceStruct) UpdateSchedule(ctx context.Context, id uuid.UUID, req ReportScheduleReq, organizationID *uuid.UUID) (ReportSchedule, error) {
+	next, err := nextRun(req.Schedule, time.Now())
+	if err != nil {
+		return ReportSchedule{}, err
+	}
+	updated, err := s.repo.UpdateSchedule(ctx, ReportSchedule{
+		ID:         id,
+		Name:       req.Name,
+		Report:     req.Report,
+		Format:     req.Format,
+		Schedule:   strings.TrimSpace(req.Schedule),
+		Filter:     req.Filter,
+		Recipients: req.Recipients,
+		NextRunAt:  next,
+
+		OrganizationID: organizationID,
+	})
+	if err != nil {
+		return ReportSchedule{}, err
+	}
+	s.logger.GetLogger().Info("report schedule updated", zap.String("id", id.String()), zap.Time("next_run_at", updated.NextRunAt))
+	return updated, nil
+}
+
+func (s *reportServiceStruct) DeleteSchedule(ctx context.Context, id uuid.UUID, organizationID *uuid.UUID) error {
+	if err := s.repo.ArchiveSchedule(ctx, id, organizationID); err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("report schedule deleted", zap.String("id", id.String()))
+	return nil
+}
+
+// SendDue sends the reports whose time has come. A failed report is recorded on its schedule and
+// moved on to its next run instead of failing the job, so one bad schedule doesn't hold up the others
+func (s *reportServiceStruct) SendDue(ctx context.Context) error {
+	due, err := s.repo.ClaimDueSchedules(ctx, reportBatchSize, reportLease)
+	if err != nil {
+		return err
+	}
+	for _, schedule := range due {
+		var reason *string
+		if sendErr := s.send(ctx, schedule); sendErr != nil {
+			s.logger.GetLogger().Warn("scheduled report failed", zap.String("id", schedule.ID.String()), zap.String("report", schedule.Report), zap.Error(sendErr))
+			message := sendErr.Error()
+			if len(message) > reportErrorLimit {
+				message = message[:reportErrorLimit]
+			}
+			reason = &message
+		}
+		// the schedule was checked when it was saved, a bad one only shows up here after a manual edit
+		next, err := nextRun(schedule.Schedule, time.Now())
+		if err != nil {
+			next = time.Now().Add(24 * time.Hour)
+		}
+		if err := s.repo.RecordRun(ctx, schedule.ID, next, reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *reportServiceStruct) send(ctx context.Context, schedule ReportSchedule) error {
+	table, err := s.buildReport(ctx, schedule)
+	if err != nil {
+		return err
+	}
+	attachment, err := render(table, schedule.Format)
+	if err != nil {
+		return err
+	}
+	subject := schedule.Name + " - " + time.Now().UTC().Format("2 Jan 2006")
+	body := fmt.Sprintf("%s is attached, %d rows.\r\n\r\nThis report is sent on the schedule \"%s\", an admin can change or remove it under report schedules.",
+		table.Title, len(table.Rows), schedule.Schedule)
+	var failed []string
+	for _, recipient := range schedule.Recipients {
+		if err := s.email.SendWithAttachments(ctx, recipient, subject, body, []models.EmailAttachment{attachment}); err != nil {
+			s.logger.GetLogger().Warn("failed to email scheduled report", zap.String("id", schedule.ID.String()), zap.String("to", recipient), zap.Error(err))
+			failed = append(failed, recipient)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to email report to %s", strings.Join(failed, ", "))
+	}
+	s.logger.GetLogger().Info("scheduled report sent", zap.String("id", schedule.ID.String()), zap.String("report", schedule.Report),
+		zap.Int("rows", len(table.Rows)), zap.Int("recipients", len(schedule.Recipients)))
+	return nil
+}
+
+func (s *reportServiceStruct) buildReport(ctx context.Context, schedule ReportSchedule) (reportTable, error) {
+	filter := schedule.Filter
+	switch schedule.Report {
+	case ReportInventorySummary:
+		rows, err := s.repo.GetInventorySummary(ctx, filter, schedule.OrganizationID)
+		if err != nil {
+			return reportTable{}, err
+		}
+		table := reportTable{Title: "Inventory summary", Header: []string{"Type", "Status", "Assets"}}
+		for _, row := range rows {
+			table.Rows = append(table.Rows, []string{row.Type, row.Status, strconv.Itoa(row.Count)})
+		}
+		return table, nil
+	case ReportOverdueReturns:
+		rows, err := s.repo.GetOverdueReturns(ctx, filter, schedule.OrganizationID, reportRowLimit)
+		if err != nil {
+			return reportTable{}, err
+		}
+		table := reportTable{
+			Title:  fmt.Sprintf("Return requests open for more than %d days", filter.Days),
+			Header: []string{"Brand", "Model", "Serial No", "Employee", "Email", "End Date", "Reason", "Requested On", "Days Open"},
+		}
+		for _, row := range rows {
+			endDate := ""
+			if row.EndDate != nil {
+				endDate = row.EndDate.Format(time.DateOnly)
+			}
+			table.Rows = append(table.Rows, []string{row.Brand, row.Model, row.SerialNo, row.EmployeeName, row.EmployeeEmail, endDate,
+				row.Reason, row.RequestedAt.Format(time.DateOnly), strconv.Itoa(daysSince(row.RequestedAt))})
+		}
+		return table, nil
+	case ReportAssetsInService:
+		rows, err := s.repo.GetAssetsInService(ctx, filter, schedule.OrganizationID, reportRowLimit)
+		if err != nil {
+			return reportTable{}, err
+		}
+		table := reportTable{
+			Title:  fmt.Sprintf("Assets in service for more than %d days", filter.Days),
+			Header: []string{"Brand", "Model", "Serial No", "Reason", "In Service Since", "Days In Service"},
+		}
+		for _, row := range rows {
+			table.Rows = append(table.Rows, []string{row.Brand, row.Model, row.SerialNo, row.Reason,
+				row.ServiceStart.Format(time.DateOnly), strconv.Itoa(daysSince(row.ServiceStart))})
+		}
+		return table, nil
+	}
+	return reportTable{}, fmt.Errorf("unknown report %q", schedule.Report)
+}
+
+// render writes the report as a csv sheet or as a pdf with a line per row
+func render(table reportTable, format string) (models.EmailAttachment, error) {
+	name := strings.ReplaceAll(strings.ToLower(table.Title), " ", "_")
+	if format == FormatPDF {
+		lines := []string{strings.Join(table.Header, " | "), ""}
+		for _, row := range table.Rows {
+			lines = append(lines, strings.Join(row, " | "))
+		}
+		if len(table.Rows) == 0 {
+			lines = append(lines, "Nothing to report.")
+		}
+		if len(table.Rows) >= reportRowLimit {
+			lines = append(lines, "", fmt.Sprintf("Only the first %d rows are listed.", reportRowLimit))
+		}
+		return models.EmailAttachment{Filename: name + ".pdf", ContentType: "application/pdf", Data: utils.TextPDF(table.Title, lines)}, nil
+	}
+	var buf bytes.Buffer
+	if err := utils.WriteCSV(&buf, table.Header, table.Rows); err != nil {
+		return models.EmailAttachment{}, fmt.Errorf("failed to write report csv: %w", err)
+	}
+	return models.EmailAttachment{Filename: name + ".csv", ContentType: "text/csv", Data: buf.Bytes()}, nil
+}
+
+func nextRun(expr string, from time.Time) (time.Time, error) {
+	schedule, err := jobs.ParseSchedule(strings.TrimSpace(expr))
+	if err != nil {
+		return time.Time{}, ErrInvalidSchedule
+	}
+	return schedule.Next(from), nil
+}
+
+func daysSince(t time.Time) int {
+	return int(time.Since(t).Hours() / 24)
+}
@@ -0,0 +1,107 @@
+package reportservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestSendDue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	summary := ReportSchedule{ID: uuid.New(), Name: "Weekly inventory", Report: ReportInventorySummary, Format: FormatCSV,
+		Schedule: "@weekly", Recipients: []string{"it@example.com"}}
+	orgID := uuid.New()
+	overdue := ReportSchedule{ID: uuid.New(), Name: "Overdue returns", Report: ReportOverdueReturns, Format: FormatPDF,
+		Schedule: "0 9 * * 1", Filter: ReportFilter{Days: 14}, Recipients: []string{"ops@example.com", "bounce@example.com"},
+		OrganizationID: &orgID}
+
+	repo := NewMockReportRepository(ctrl)
+	email := providers.NewMockEmailProvider(ctrl)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	repo.EXPECT().ClaimDueSchedules(ctx, reportBatchSize, reportLease).Return([]ReportSchedule{summary, overdue}, nil)
+
+	// the summary goes out as csv
+	repo.EXPECT().GetInventorySummary(ctx, summary.Filter, (*uuid.UUID)(nil)).Return([]summaryRow{{Type: "laptop", Status: "assigned", Count: 3}}, nil)
+	email.EXPECT().SendWithAttachments(ctx, "it@example.com", gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _, subject, _ string, attachments []models.EmailAttachment) error {
+			assert.True(t, strings.HasPrefix(subject, "Weekly inventory - "))
+			assert.Len(t, attachments, 1)
+			assert.Equal(t, "inventory_summary.csv", attachments[0].Filename)
+			assert.Equal(t, "Type,Status,Assets\nlaptop,assigned,3\n", string(attachments[0].Data))
+			return nil
+		})
+	repo.EXPECT().RecordRun(ctx, summary.ID, gomock.Any(), (*string)(nil)).DoAndReturn(
+		func(_ context.Context, _ uuid.UUID, next time.Time, _ *string) error {
+			assert.Equal(t, time.Sunday, next.Weekday())
+			return nil
+		})
+
+	// one address failing is recorded on the schedule, the others still get the pdf. The report only
+	// covers the organization of the schedule
+	requested := time.Now().AddDate(0, 0, -20)
+	repo.EXPECT().GetOverdueReturns(ctx, overdue.Filter, &orgID, reportRowLimit).Return([]overdueReturnRow{
+		{Brand: "Dell", Model: "XPS", SerialNo: "SN1", EmployeeName: "Sam", EmployeeEmail: "sam@example.com", Reason: "leaving", RequestedAt: requested},
+	}, nil)
+	email.EXPECT().SendWithAttachments(ctx, "ops@example.com", gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _, _, _ string, attachments []models.EmailAttachment) error {
+			assert.Equal(t, "application/pdf", attachments[0].ContentType)
+			assert.True(t, strings.HasPrefix(string(attachments[0].Data), "%PDF-"))
+			assert.Contains(t, string(attachments[0].Data), "Dell | XPS | SN1 | Sam")
+			return nil
+		})
+	email.EXPECT().SendWithAttachments(ctx, "bounce@example.com", gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("mailbox unavailable"))
+	repo.EXPECT().RecordRun(ctx, overdue.ID, gomock.Any(), gomock.Not(gomock.Nil())).DoAndReturn(
+		func(_ context.Context, _ uuid.UUID, next time.Time, reason *string) error {
+			assert.Equal(t, "failed to email report to bounce@example.com", *reason)
+			assert.Equal(t, 9, next.Hour())
+			return nil
+		})
+
+	svc := NewReportService(repo, logger, email)
+	assert.NoError(t, svc.SendDue(ctx))
+}
+
+func TestCreateSchedule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	userID := uuid.New()
+	orgID := uuid.New()
+	repo := NewMockReportRepository(ctrl)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+	svc := NewReportService(repo, logger, providers.NewMockEmailProvider(ctrl))
+
+	req := ReportScheduleReq{Name: "Service", Report: ReportAssetsInService, Format: FormatCSV, Schedule: "not a cron", Recipients: []string{"it@example.com"}}
+	_, err := svc.CreateSchedule(ctx, req, userID, nil)
+	assert.ErrorIs(t, err, ErrInvalidSchedule)
+
+	req.Schedule = " @daily "
+	repo.EXPECT().InsertSchedule(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, schedule ReportSchedule) (ReportSchedule, error) {
+		assert.Equal(t, "@daily", schedule.Schedule)
+		assert.Equal(t, userID, schedule.CreatedBy)
+		assert.Equal(t, &orgID, schedule.OrganizationID)
+		assert.True(t, schedule.NextRunAt.After(time.Now()))
+		assert.Equal(t, 0, schedule.NextRunAt.Hour())
+		schedule.ID = uuid.New()
+		return schedule, nil
+	})
+	created, err := svc.CreateSchedule(ctx, req, userID, &orgID)
+	assert.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, created.ID)
+}
@@ -0,0 +1,17 @@
+package schedulerservice
+
+import "asset/models"
+
+type JobRunFilter struct {
+	Job    string
+	Status string
+	Limit  int
+	Offset int
+}
+
+// JobRes is a registered job with the latest run recorded anywhere in the cluster
+type JobRes struct {
+	models.JobInfo
+	// LastRecordedRun can come from another instance, LastRun is only this one's
+	LastRecordedRun *models.JobRun `json:"last_recorded_run,omitempty"`
+}
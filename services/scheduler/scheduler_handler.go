@@ -0,0 +1,92 @@
+package schedulerservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type SchedulerHandler struct {
+	Service        SchedulerService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewSchedulerHandler(service SchedulerService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *SchedulerHandler {
+	return &SchedulerHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+// GetJobs lists the background jobs with their schedule, next run and latest runs
+func (h *SchedulerHandler) GetJobs(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetJobs request received")
+	jobs, err := h.Service.GetJobs(r.Context())
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch jobs", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch jobs")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"jobs": jobs})
+}
+
+// TriggerJob starts a run of the job named by name on the instance serving the request
+func (h *SchedulerHandler) TriggerJob(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("TriggerJob request received")
+	adminID, ok := h.callerID(w, r, "TriggerJob")
+	if !ok {
+		return
+	}
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		utils.RespondError(w, http.StatusBadRequest, nil, "name is required")
+		return
+	}
+
+	if err := h.Service.TriggerJob(r.Context(), name, adminID); err != nil {
+		h.Logger.GetLogger().Error("Failed to trigger job", zap.String("job", name), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to trigger job")
+		return
+	}
+	utils.RespondJSON(w, http.StatusAccepted, map[string]interface{}{"message": "job started, its outcome shows up in the run history"})
+}
+
+// GetRuns lists recorded runs newest first, filtered by job and status
+func (h *SchedulerHandler) GetRuns(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetJobRuns request received")
+	query := r.URL.Query()
+	filter := JobRunFilter{
+		Job:    query.Get("job"),
+		Status: query.Get("status"),
+	}
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+
+	runs, err := h.Service.GetRuns(r.Context(), filter)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch job runs", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch job runs")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"runs": runs})
+}
+
+func (h *SchedulerHandler) callerID(w http.ResponseWriter, r *http.Request, handler string) (uuid.UUID, bool) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return uuid.Nil, false
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in "+handler, zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return uuid.Nil, false
+	}
+	return userUUID, true
+}
@@ -0,0 +1,83 @@
+package schedulerservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type SchedulerRepository interface {
+	RecordRun(ctx context.Context, run models.JobRun) error
+	GetRuns(ctx context.Context, filter JobRunFilter) ([]models.JobRun, error)
+	GetLatestRuns(ctx context.Context) ([]models.JobRun, error)
+	DeleteRunsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+type PostgresSchedulerRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewSchedulerRepository(db *sqlx.DB, log providers.ZapLoggerProvider) SchedulerRepository {
+	return &PostgresSchedulerRepository{DB: db, Logger: log}
+}
+
+// RecordRun is called by the job runner after every run it keeps history of
+func (r *PostgresSchedulerRepository) RecordRun(ctx context.Context, run models.JobRun) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO job_runs (job, trigger, instance, status, error, started_at, finished_at, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, run.Job, run.Trigger, run.Instance, run.Status, run.Error, run.StartedAt, run.FinishedAt, run.DurationMS)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to record job run", zap.String("job", run.Job), zap.Error(err))
+		return fmt.Errorf("failed to record job run: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresSchedulerRepository) GetRuns(ctx context.Context, filter JobRunFilter) ([]models.JobRun, error) {
+	runs := make([]models.JobRun, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &runs, `
+		SELECT id, job, trigger, instance, status, error, started_at, finished_at, duration_ms
+		FROM job_runs
+		WHERE ($1 = '' OR job = $1)
+		AND ($2 = '' OR status = $2)
+		ORDER BY started_at DESC
+		LIMIT $3 OFFSET $4
+	`, filter.Job, filter.Status, filter.Limit, filter.Offset)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch job runs", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch job runs: %w", err)
+	}
+	return runs, nil
+}
+
+// GetLatestRuns returns the most recent recorded run of every job
+func (r *PostgresSchedulerRepository) GetLatestRuns(ctx context.Context) ([]models.JobRun, error) {
+	runs := make([]models.JobRun, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &runs, `
+		SELECT DISTINCT ON (job) id, job, trigger, instance, status, error, started_at, finished_at, duration_ms
+		FROM job_runs
+		ORDER BY job, started_at DESC
+	`)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch latest job runs", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch latest job runs: %w", err)
+	}
+	return runs, nil
+}
+
+func (r *PostgresSchedulerRepository) DeleteRunsBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `DELETE FROM job_runs WHERE started_at < $1`, before)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to purge job runs", zap.Time("before", before), zap.Error(err))
+		return 0, fmt.Errorf("failed to purge job runs: %w", err)
+	}
+	return res.RowsAffected()
+}
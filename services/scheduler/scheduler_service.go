@@ -0,0 +1,91 @@
+package schedulerservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/audit"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type SchedulerService interface {
+	GetJobs(ctx context.Context) ([]JobRes, error)
+	TriggerJob(ctx context.Context, name string, adminID uuid.UUID) error
+	GetRuns(ctx context.Context, filter JobRunFilter) ([]models.JobRun, error)
+	PurgeRuns(ctx context.Context, retention time.Duration) error
+}
+
+// JobRunner is the part of jobs.Runner the service drives
+type JobRunner interface {
+	Jobs() []models.JobInfo
+	Trigger(name string) error
+}
+
+type schedulerServiceStruct struct {
+	repo   SchedulerRepository
+	runner JobRunner
+	db     *sqlx.DB
+	logger providers.ZapLoggerProvider
+	audit  auditservice.AuditService
+}
+
+func NewSchedulerService(repo SchedulerRepository, runner JobRunner, db *sqlx.DB, logger providers.ZapLoggerProvider, audit auditservice.AuditService) SchedulerService {
+	return &schedulerServiceStruct{repo: repo, runner: runner, db: db, logger: logger, audit: audit}
+}
+
+// GetJobs lists the jobs registered on this instance, history failing to load only leaves out the
+// recorded runs
+func (s *schedulerServiceStruct) GetJobs(ctx context.Context) ([]JobRes, error) {
+	latest := make(map[string]models.JobRun)
+	runs, err := s.repo.GetLatestRuns(ctx)
+	if err != nil {
+		s.logger.GetLogger().Warn("failed to load job history, listing jobs without it", zap.Error(err))
+	}
+	for _, run := range runs {
+		latest[run.Job] = run
+	}
+
+	jobs := make([]JobRes, 0)
+	for _, info := range s.runner.Jobs() {
+		job := JobRes{JobInfo: info}
+		if run, ok := latest[info.Name]; ok {
+			job.LastRecordedRun = &run
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// TriggerJob starts a run on this instance and returns without waiting for it, the outcome shows up
+// in the run history
+func (s *schedulerServiceStruct) TriggerJob(ctx context.Context, name string, adminID uuid.UUID) error {
+	if err := s.runner.Trigger(name); err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("background job triggered", zap.String("job", name), zap.String("adminID", adminID.String()))
+	return s.audit.Record(ctx, auditservice.AuditEntry{
+		ActorID:    &adminID,
+		Action:     "job.triggered",
+		EntityType: "job",
+		EntityID:   name,
+	})
+}
+
+func (s *schedulerServiceStruct) GetRuns(ctx context.Context, filter JobRunFilter) ([]models.JobRun, error) {
+	return s.repo.GetRuns(ctx, filter)
+}
+
+// PurgeRuns deletes runs older than the retention period
+func (s *schedulerServiceStruct) PurgeRuns(ctx context.Context, retention time.Duration) error {
+	before := time.Now().Add(-retention)
+	deleted, err := s.repo.DeleteRunsBefore(ctx, before)
+	if err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("purged job runs", zap.Int64("deleted", deleted), zap.Time("before", before))
+	return nil
+}
@@ -0,0 +1,40 @@
+package searchservice
+
+import (
+	"asset/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ResultTypeEmployee = "employee"
+	ResultTypeAsset    = "asset"
+)
+
+// SearchFilter is one query from the search box. Employees without user.read find colleagues the way
+// the directory shows them, without contact numbers, and without asset.read only the assets
+// assigned to them
+type SearchFilter struct {
+	Query     string
+	Limit     int
+	UserID    uuid.UUID
+	Scope     models.DepartmentScope
+	UserRead  bool
+	AssetRead bool
+}
+
+// SearchResult is one employee or asset, Title and Subtitle are what the search box shows and
+// MatchedOn the field that scored. Score is 3 for an exact match, 2 for a prefix and 1 for a substring
+type SearchResult struct {
+	Type      string    `json:"type" db:"type"`
+	ID        uuid.UUID `json:"id" db:"id"`
+	Title     string    `json:"title" db:"title"`
+	Subtitle  string    `json:"subtitle" db:"subtitle"`
+	MatchedOn string    `json:"matched_on" db:"matched_on"`
+	Score     int       `json:"score" db:"score"`
+}
+
+type SearchRes struct {
+	Query   string         `json:"query"`
+	Results []SearchResult `json:"results"`
+}
@@ -0,0 +1,75 @@
+package searchservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type SearchHandler struct {
+	Service        SearchService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewSearchHandler(service SearchService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *SearchHandler {
+	return &SearchHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+// Search looks up employees and assets matching q in one call, what the caller may read decides
+// which fields and records are searched. An api key only searches what its scopes allow
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in Search", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in Search", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	filter := SearchFilter{Query: r.URL.Query().Get("q"), UserID: userUUID}
+	if val := r.URL.Query().Get("limit"); val != "" {
+		filter.Limit, err = strconv.Atoi(val)
+		if err != nil || filter.Limit <= 0 {
+			utils.RespondError(w, http.StatusBadRequest, err, "limit must be a positive number")
+			return
+		}
+	}
+	if filter.UserRead, err = h.AuthMiddleware.CallerHasPermission(r, models.UserReadPermission); err != nil {
+		h.Logger.GetLogger().Error("Failed to check permissions in Search", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to check permissions")
+		return
+	}
+	if filter.AssetRead, err = h.AuthMiddleware.CallerHasPermission(r, models.AssetReadPermission); err != nil {
+		h.Logger.GetLogger().Error("Failed to check permissions in Search", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to check permissions")
+		return
+	}
+	if filter.Scope, err = h.AuthMiddleware.GetDepartmentScope(r); err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in Search", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	res, err := h.Service.Search(r.Context(), filter)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to search", zap.String("query", filter.Query), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to search")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, res)
+}
@@ -0,0 +1,95 @@
+package searchservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type SearchRepository interface {
+	// SearchEmployees matches username, email and, for user.read within the caller's departments,
+	// contact number
+	SearchEmployees(ctx context.Context, filter SearchFilter) ([]SearchResult, error)
+	// SearchAssets matches brand, model, serial number and the asset id, which is what asset tags
+	// encode, exactly
+	SearchAssets(ctx context.Context, filter SearchFilter) ([]SearchResult, error)
+}
+
+type PostgresSearchRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewSearchRepository(db *sqlx.DB, log providers.ZapLoggerProvider) SearchRepository {
+	return &PostgresSearchRepository{
+		DB:     db,
+		Logger: log,
+	}
+}
+
+// bestMatch scores every field of the lateral values list f(field, value) against $1 and keeps the
+// best, fields in exactOnly only score on an exact match
+const bestMatch = `
+	SELECT f.field, CASE
+		WHEN lower(f.value) = lower($1) THEN 3
+		WHEN f.field = ANY(%[1]s) THEN 0
+		WHEN f.value ILIKE $1 || '%%' THEN 2
+		WHEN f.value ILIKE '%%' || $1 || '%%' THEN 1
+		ELSE 0
+	END AS score
+	FROM (VALUES %[2]s) f(field, value)
+	WHERE f.value IS NOT NULL
+	ORDER BY score DESC
+	LIMIT 1`
+
+var employeeSearchQuery = `
+	SELECT 'employee' AS type, u.id, u.username AS title, u.email AS subtitle, m.field AS matched_on, m.score
+	FROM users u
+	CROSS JOIN LATERAL (` + fmt.Sprintf(bestMatch, `'{}'::text[]`, `('username', u.username), ('email', u.email),
+		('contact_no', CASE WHEN $2 AND ($3 OR u.department_id IS NOT DISTINCT FROM $4) THEN u.contact_no END)`) + `) m
+	WHERE m.score > 0
+	AND u.archived_at IS NULL AND u.anonymized_at IS NULL AND u.auth_provider <> 'service_account'
+	AND ($5::uuid IS NULL OR u.organization_id = $5)
+	ORDER BY m.score DESC, u.username, u.id
+	LIMIT $6`
+
+var assetSearchQuery = `
+	SELECT 'asset' AS type, a.id, a.brand || ' ' || a.model AS title, a.serial_no AS subtitle, m.field AS matched_on, m.score
+	FROM assets a
+	CROSS JOIN LATERAL (` + fmt.Sprintf(bestMatch, `'{id}'::text[]`, `('brand', a.brand), ('model', a.model),
+		('serial_no', a.serial_no), ('id', a.id::text)`) + `) m
+	WHERE m.score > 0 AND a.archived_at IS NULL
+	AND ($5::uuid IS NULL OR a.organization_id = $5)
+	AND CASE WHEN $2 THEN ($3 OR a.department_id IS NOT DISTINCT FROM $4)
+		ELSE EXISTS (
+			SELECT 1 FROM asset_assign aa
+			WHERE aa.asset_id = a.id AND aa.employee_id = $7 AND aa.returned_at IS NULL AND aa.archived_at IS NULL
+		) END
+	ORDER BY m.score DESC, title, a.id
+	LIMIT $6`
+
+func (r *PostgresSearchRepository) SearchEmployees(ctx context.Context, filter SearchFilter) ([]SearchResult, error) {
+	results := make([]SearchResult, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &results, employeeSearchQuery, filter.Query, filter.UserRead,
+		filter.Scope.AllDepartments, filter.Scope.DepartmentID, filter.Scope.OrganizationID, filter.Limit)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to search employees", zap.Error(err))
+		return nil, fmt.Errorf("failed to search employees: %w", err)
+	}
+	return results, nil
+}
+
+func (r *PostgresSearchRepository) SearchAssets(ctx context.Context, filter SearchFilter) ([]SearchResult, error) {
+	results := make([]SearchResult, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &results, assetSearchQuery, filter.Query, filter.AssetRead,
+		filter.Scope.AllDepartments, filter.Scope.DepartmentID, filter.Scope.OrganizationID, filter.Limit, filter.UserID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to search assets", zap.Error(err))
+		return nil, fmt.Errorf("failed to search assets: %w", err)
+	}
+	return results, nil
+}
@@ -0,0 +1,103 @@
+//go:build integration
+
+package searchservice
+
+import (
+	"asset/database/seed"
+	"asset/database/testdb"
+	"asset/models"
+	"asset/providers"
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func resultIDs(results []SearchResult) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(results))
+	for _, result := range results {
+		ids = append(ids, result.ID)
+	}
+	return ids
+}
+
+func TestSearchAssets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	repo := NewSearchRepository(testdb.Seeded(t), logger)
+	ctx := context.Background()
+	engineering := seed.ID("department:engineering")
+	all := SearchFilter{Query: "dell", Limit: 10, UserID: seed.ID("user:admin"), AssetRead: true, Scope: models.DepartmentScope{AllDepartments: true}}
+
+	results, err := repo.SearchAssets(ctx, all)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{seed.ID("asset:laptop-3"), seed.ID("asset:monitor-1")}, resultIDs(results))
+	assert.Equal(t, ResultTypeAsset, results[0].Type)
+	assert.Equal(t, "brand", results[0].MatchedOn)
+
+	scoped := all
+	scoped.Scope = models.DepartmentScope{DepartmentID: &engineering}
+	results, err = repo.SearchAssets(ctx, scoped)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{seed.ID("asset:monitor-1")}, resultIDs(results))
+
+	// the id only matches in full
+	all.Query = seed.ID("asset:laptop-1").String()
+	results, err = repo.SearchAssets(ctx, all)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "id", results[0].MatchedOn)
+	assert.Equal(t, 3, results[0].Score)
+	all.Query = all.Query[:8]
+	results, err = repo.SearchAssets(ctx, all)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	// without asset.read only what is assigned to the caller shows up
+	own := SearchFilter{Query: "seed-lap", Limit: 10, UserID: seed.ID("user:developer"), Scope: models.DepartmentScope{DepartmentID: &engineering}}
+	results, err = repo.SearchAssets(ctx, own)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{seed.ID("asset:laptop-1")}, resultIDs(results))
+}
+
+func TestSearchEmployees(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	repo := NewSearchRepository(testdb.Seeded(t), logger)
+	ctx := context.Background()
+	filter := SearchFilter{Query: "dev.sharma@remotestate.com", Limit: 10, Scope: models.DepartmentScope{AllDepartments: true}}
+
+	results, err := repo.SearchEmployees(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, SearchResult{Type: ResultTypeEmployee, ID: seed.ID("user:developer"), Title: "Dev Sharma",
+		Subtitle: "dev.sharma@remotestate.com", MatchedOn: "email", Score: 3}, results[0])
+
+	// contact numbers are only searched with user.read
+	filter.Query = "9000000004"
+	results, err = repo.SearchEmployees(ctx, filter)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	filter.UserRead = true
+	results, err = repo.SearchEmployees(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{seed.ID("user:developer")}, resultIDs(results))
+	assert.Equal(t, "contact_no", results[0].MatchedOn)
+
+	// and only within the caller's departments
+	operations := seed.ID("department:operations")
+	filter.Scope = models.DepartmentScope{DepartmentID: &operations}
+	results, err = repo.SearchEmployees(ctx, filter)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
@@ -0,0 +1,70 @@
+package searchservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+// SearchService backs the single search box, employees and assets are searched separately and merged
+// into one ranked list
+type SearchService interface {
+	Search(ctx context.Context, filter SearchFilter) (SearchRes, error)
+}
+
+var ErrQueryTooShort = models.NewServiceError(http.StatusBadRequest, "search_query_too_short", "search query must be at least 2 characters")
+
+const (
+	minQueryLength     = 2
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+)
+
+type searchServiceStruct struct {
+	repo   SearchRepository
+	logger providers.ZapLoggerProvider
+}
+
+func NewSearchService(repo SearchRepository, logger providers.ZapLoggerProvider) SearchService {
+	return &searchServiceStruct{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (s *searchServiceStruct) Search(ctx context.Context, filter SearchFilter) (SearchRes, error) {
+	filter.Query = strings.TrimSpace(filter.Query)
+	if utf8.RuneCountInString(filter.Query) < minQueryLength {
+		return SearchRes{}, ErrQueryTooShort
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultSearchLimit
+	}
+	filter.Limit = min(filter.Limit, maxSearchLimit)
+
+	employees, err := s.repo.SearchEmployees(ctx, filter)
+	if err != nil {
+		return SearchRes{}, err
+	}
+	assets, err := s.repo.SearchAssets(ctx, filter)
+	if err != nil {
+		return SearchRes{}, err
+	}
+
+	// each kind is already ranked, a stable sort keeps that order between equal scores
+	results := append(employees, assets...)
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > filter.Limit {
+		results = results[:filter.Limit]
+	}
+	s.logger.GetLogger().Info("search done", zap.Int("employees", len(employees)), zap.Int("assets", len(assets)))
+	return SearchRes{Query: filter.Query, Results: results}, nil
+}
@@ -0,0 +1,61 @@
+package searchservice
+
+import (
+	"asset/providers"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSearchMergesByScore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	repo := NewMockSearchRepository(ctrl)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	employee := SearchResult{Type: ResultTypeEmployee, ID: uuid.New(), Title: "Dell Fan", Score: 2}
+	exact := SearchResult{Type: ResultTypeAsset, ID: uuid.New(), Title: "Dell", Score: 3}
+	prefix := SearchResult{Type: ResultTypeAsset, ID: uuid.New(), Title: "Dell Latitude", Score: 2}
+	want := SearchFilter{Query: "dell", Limit: 2}
+	repo.EXPECT().SearchEmployees(ctx, want).Return([]SearchResult{employee}, nil)
+	repo.EXPECT().SearchAssets(ctx, want).Return([]SearchResult{exact, prefix}, nil)
+
+	res, err := NewSearchService(repo, logger).Search(ctx, SearchFilter{Query: "  dell ", Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, "dell", res.Query)
+	// employees come before assets of the same score and the limit applies to the merged list
+	assert.Equal(t, []SearchResult{exact, employee}, res.Results)
+}
+
+func TestSearchLimits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	repo := NewMockSearchRepository(ctrl)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+	svc := NewSearchService(repo, logger)
+
+	_, err := svc.Search(ctx, SearchFilter{Query: " a "})
+	assert.ErrorIs(t, err, ErrQueryTooShort)
+
+	repo.EXPECT().SearchEmployees(ctx, SearchFilter{Query: "ab", Limit: defaultSearchLimit}).Return(nil, nil)
+	repo.EXPECT().SearchAssets(ctx, SearchFilter{Query: "ab", Limit: defaultSearchLimit}).Return(nil, nil)
+	_, err = svc.Search(ctx, SearchFilter{Query: "ab"})
+	require.NoError(t, err)
+
+	failure := errors.New("connection refused")
+	repo.EXPECT().SearchEmployees(ctx, SearchFilter{Query: "ab", Limit: maxSearchLimit}).Return(nil, failure)
+	_, err = svc.Search(ctx, SearchFilter{Query: "ab", Limit: 500})
+	assert.ErrorIs(t, err, failure)
+}
@@ -0,0 +1,58 @@
+package serviceaccountservice
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type CreateServiceAccountReq struct {
+	Name        string   `json:"name" validate:"required,max=100"`
+	Description string   `json:"description" validate:"max=500"`
+	Roles       []string `json:"roles" validate:"required,min=1,dive,required"`
+}
+
+type UpdateServiceAccountRolesReq struct {
+	Roles []string `json:"roles" validate:"required,min=1,dive,required"`
+}
+
+type ServiceAccountRes struct {
+	ID              uuid.UUID      `json:"id" db:"id"`
+	Name            string         `json:"name" db:"name"`
+	OrganizationID  uuid.UUID      `json:"organization_id" db:"organization_id"`
+	Description     *string        `json:"description,omitempty" db:"description"`
+	ClientID        string         `json:"client_id" db:"client_id"`
+	Roles           pq.StringArray `json:"roles" db:"roles"`
+	CreatedBy       uuid.UUID      `json:"created_by" db:"created_by"`
+	CreatedAt       time.Time      `json:"created_at" db:"created_at"`
+	SecretRotatedAt time.Time      `json:"secret_rotated_at" db:"secret_rotated_at"`
+	LastTokenAt     *time.Time     `json:"last_token_at,omitempty" db:"last_token_at"`
+	DisabledAt      *time.Time     `json:"disabled_at,omitempty" db:"disabled_at"`
+}
+
+// ServiceAccountSecretRes is the only response that carries the client secret
+type ServiceAccountSecretRes struct {
+	ServiceAccountRes
+	ClientSecret string `json:"client_secret"`
+}
+
+// TokenReq is the oauth client credentials grant
+type TokenReq struct {
+	GrantType    string `json:"grant_type" validate:"required,eq=client_credentials"`
+	ClientID     string `json:"client_id" validate:"required"`
+	ClientSecret string `json:"client_secret" validate:"required"`
+}
+
+type TokenRes struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+type serviceAccountCredentials struct {
+	ID         uuid.UUID      `db:"id"`
+	SecretHash string         `db:"secret_hash"`
+	Roles      pq.StringArray `db:"roles"`
+	DisabledAt *time.Time     `db:"disabled_at"`
+}
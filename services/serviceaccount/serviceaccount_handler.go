@@ -0,0 +1,244 @@
+package serviceaccountservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type ServiceAccountHandler struct {
+	Service        ServiceAccountService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewServiceAccountHandler(service ServiceAccountService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *ServiceAccountHandler {
+	return &ServiceAccountHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+func (h *ServiceAccountHandler) GetServiceAccounts(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetServiceAccounts request received")
+	scope, ok := h.scope(w, r, "GetServiceAccounts")
+	if !ok {
+		return
+	}
+	accounts, err := h.Service.GetServiceAccounts(r.Context(), scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch service accounts", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch service accounts")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"service_accounts": accounts})
+}
+
+// CreateServiceAccount returns the client secret, it is only ever shown in this response
+func (h *ServiceAccountHandler) CreateServiceAccount(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("CreateServiceAccount request received")
+	adminID, ok := h.currentUserID(w, r, "CreateServiceAccount")
+	if !ok {
+		return
+	}
+	var req CreateServiceAccountReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in CreateServiceAccount", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in CreateServiceAccount", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	scope, ok := h.scope(w, r, "CreateServiceAccount")
+	if !ok {
+		return
+	}
+	account, err := h.Service.CreateServiceAccount(r.Context(), req, adminID, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to create service account", zap.String("name", req.Name), zap.Error(err))
+		h.respondServiceError(w, err, "failed to create service account")
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+		"message":         "service account created, store the client secret now as it won't be shown again",
+		"service_account": account,
+	})
+}
+
+func (h *ServiceAccountHandler) UpdateServiceAccountRoles(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("UpdateServiceAccountRoles request received")
+	adminID, ok := h.currentUserID(w, r, "UpdateServiceAccountRoles")
+	if !ok {
+		return
+	}
+	id, ok := h.accountID(w, r, "UpdateServiceAccountRoles")
+	if !ok {
+		return
+	}
+	var req UpdateServiceAccountRolesReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in UpdateServiceAccountRoles", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in UpdateServiceAccountRoles", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	scope, ok := h.scope(w, r, "UpdateServiceAccountRoles")
+	if !ok {
+		return
+	}
+	if err := h.Service.UpdateServiceAccountRoles(r.Context(), id, req, adminID, scope); err != nil {
+		h.Logger.GetLogger().Error("Failed to update service account roles", zap.String("id", id.String()), zap.Error(err))
+		h.respondServiceError(w, err, "failed to update service account roles")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "service account roles updated, tokens issued before were revoked"})
+}
+
+func (h *ServiceAccountHandler) RotateServiceAccountSecret(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("RotateServiceAccountSecret request received")
+	adminID, ok := h.currentUserID(w, r, "RotateServiceAccountSecret")
+	if !ok {
+		return
+	}
+	id, ok := h.accountID(w, r, "RotateServiceAccountSecret")
+	if !ok {
+		return
+	}
+
+	scope, ok := h.scope(w, r, "RotateServiceAccountSecret")
+	if !ok {
+		return
+	}
+	account, err := h.Service.RotateServiceAccountSecret(r.Context(), id, adminID, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to rotate service account secret", zap.String("id", id.String()), zap.Error(err))
+		h.respondServiceError(w, err, "failed to rotate service account secret")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":         "secret rotated, store it now as it won't be shown again",
+		"service_account": account,
+	})
+}
+
+func (h *ServiceAccountHandler) DisableServiceAccount(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("DisableServiceAccount request received")
+	adminID, ok := h.currentUserID(w, r, "DisableServiceAccount")
+	if !ok {
+		return
+	}
+	id, ok := h.accountID(w, r, "DisableServiceAccount")
+	if !ok {
+		return
+	}
+
+	scope, ok := h.scope(w, r, "DisableServiceAccount")
+	if !ok {
+		return
+	}
+	if err := h.Service.DisableServiceAccount(r.Context(), id, adminID, scope); err != nil {
+		h.Logger.GetLogger().Error("Failed to disable service account", zap.String("id", id.String()), zap.Error(err))
+		h.respondServiceError(w, err, "failed to disable service account")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "service account disabled successfully"})
+}
+
+// Token is the client credentials grant for service accounts
+func (h *ServiceAccountHandler) Token(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("Token request received")
+	var req TokenReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in Token", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in Token", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "grant_type must be client_credentials with client_id and client_secret")
+		return
+	}
+
+	res, err := h.Service.IssueToken(r.Context(), req, utils.GetClientInfo(r))
+	if err != nil {
+		h.Logger.GetLogger().Warn("Failed to issue service account token", zap.String("clientID", req.ClientID), zap.Error(err))
+		switch {
+		case errors.Is(err, ErrInvalidClientCredentials):
+			utils.RespondError(w, http.StatusUnauthorized, err, err.Error())
+		case errors.Is(err, ErrServiceAccountDisabled):
+			utils.RespondError(w, http.StatusForbidden, err, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, err, "failed to issue token")
+		}
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, res)
+}
+
+func (h *ServiceAccountHandler) respondServiceError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, ErrServiceAccountNotFound):
+		utils.RespondError(w, http.StatusNotFound, err, err.Error())
+	case errors.Is(err, ErrServiceAccountDisabled):
+		utils.RespondError(w, http.StatusConflict, err, err.Error())
+	case errors.Is(err, ErrUnknownRole):
+		utils.RespondError(w, http.StatusBadRequest, err, err.Error())
+	case errors.Is(err, ErrRoleNotBindable):
+		utils.RespondError(w, http.StatusForbidden, err, err.Error())
+	default:
+		utils.RespondError(w, http.StatusInternalServerError, err, message)
+	}
+}
+
+func (h *ServiceAccountHandler) currentUserID(w http.ResponseWriter, r *http.Request, name string) (uuid.UUID, bool) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in "+name, zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return uuid.Nil, false
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in "+name, zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return uuid.Nil, false
+	}
+	return userUUID, true
+}
+
+// scope is the caller's organization, service accounts of other organizations are invisible to them
+func (h *ServiceAccountHandler) scope(w http.ResponseWriter, r *http.Request, name string) (models.DepartmentScope, bool) {
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in "+name, zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return models.DepartmentScope{}, false
+	}
+	return scope, true
+}
+
+func (h *ServiceAccountHandler) accountID(w http.ResponseWriter, r *http.Request, name string) (uuid.UUID, bool) {
+	id := r.URL.Query().Get("id")
+	accountID, err := uuid.Parse(id)
+	if err != nil {
+		h.Logger.GetLogger().Error("Invalid id in "+name, zap.String("id", id), zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid id")
+		return uuid.Nil, false
+	}
+	return accountID, true
+}
@@ -0,0 +1,207 @@
+package serviceaccountservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type ServiceAccountRepository interface {
+	// InsertServiceAccount puts the account in organizationID, or in the creator's organization when it is nil
+	InsertServiceAccount(ctx context.Context, req CreateServiceAccountReq, clientID, secretHash string, createdBy uuid.UUID, organizationID *uuid.UUID) (uuid.UUID, error)
+	// GetServiceAccounts and GetServiceAccountByID only find accounts of organizationID when it is set
+	GetServiceAccounts(ctx context.Context, organizationID *uuid.UUID) ([]ServiceAccountRes, error)
+	GetServiceAccountByID(ctx context.Context, id uuid.UUID, organizationID *uuid.UUID) (ServiceAccountRes, error)
+	GetCredentials(ctx context.Context, clientID string) (serviceAccountCredentials, error)
+	GetUnknownRoles(ctx context.Context, roles []string) ([]string, error)
+	ReplaceRoleBindings(ctx context.Context, id uuid.UUID, roles []string, updatedBy uuid.UUID) error
+	RotateSecret(ctx context.Context, id uuid.UUID, secretHash string, organizationID *uuid.UUID) error
+	DisableServiceAccount(ctx context.Context, id, disabledBy uuid.UUID, organizationID *uuid.UUID) error
+	TouchLastToken(ctx context.Context, id uuid.UUID)
+}
+
+type PostgresServiceAccountRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewServiceAccountRepository(db *sqlx.DB, log providers.ZapLoggerProvider) ServiceAccountRepository {
+	return &PostgresServiceAccountRepository{DB: db, Logger: log}
+}
+
+// InsertServiceAccount creates the users row the account acts as and its credentials, the email
+// is under .invalid so no mailbox and no sign in flow can ever reach it. Without an organization the
+// users_organization trigger puts the account in the creator's
+func (r *PostgresServiceAccountRepository) InsertServiceAccount(ctx context.Context, req CreateServiceAccountReq, clientID, secretHash string, createdBy uuid.UUID, organizationID *uuid.UUID) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &id, `
+		INSERT INTO users (username, email, auth_provider, created_by, organization_id)
+		VALUES ($1, $2, 'service_account', $3, COALESCE($4::uuid, $5::uuid))
+		RETURNING id
+	`, req.Name, clientID+"@service-accounts.invalid", createdBy, organizationID, models.DefaultOrganizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert service account user", zap.String("clientID", clientID), zap.Error(err))
+		return uuid.Nil, fmt.Errorf("failed to insert service account: %w", err)
+	}
+	var description *string
+	if req.Description != "" {
+		description = &req.Description
+	}
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO service_accounts (user_id, description, client_id, secret_hash, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+	`, id, description, clientID, secretHash, createdBy)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert service account credentials", zap.String("clientID", clientID), zap.Error(err))
+		return uuid.Nil, fmt.Errorf("failed to insert service account: %w", err)
+	}
+	return id, nil
+}
+
+const serviceAccountColumns = `
+	SELECT u.id, u.username AS name, u.organization_id, sa.description, sa.client_id,
+		COALESCE(array_agg(ur.role ORDER BY ur.role) FILTER (WHERE ur.role IS NOT NULL), '{}') AS roles,
+		sa.created_by, sa.created_at, sa.secret_rotated_at, sa.last_token_at, sa.disabled_at
+	FROM service_accounts sa
+	JOIN users u ON u.id = sa.user_id
+	LEFT JOIN user_roles ur ON ur.user_id = u.id AND ur.archived_at IS NULL
+`
+
+const serviceAccountGroupBy = ` GROUP BY u.id, u.username, sa.user_id`
+
+func (r *PostgresServiceAccountRepository) GetServiceAccounts(ctx context.Context, organizationID *uuid.UUID) ([]ServiceAccountRes, error) {
+	accounts := make([]ServiceAccountRes, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &accounts, serviceAccountColumns+`
+		WHERE ($1::uuid IS NULL OR u.organization_id = $1)`+serviceAccountGroupBy+` ORDER BY sa.created_at DESC`, organizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch service accounts", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch service accounts: %w", err)
+	}
+	return accounts, nil
+}
+
+func (r *PostgresServiceAccountRepository) GetServiceAccountByID(ctx context.Context, id uuid.UUID, organizationID *uuid.UUID) (ServiceAccountRes, error) {
+	var account ServiceAccountRes
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &account, serviceAccountColumns+`
+		WHERE sa.user_id = $1 AND ($2::uuid IS NULL OR u.organization_id = $2)`+serviceAccountGroupBy, id, organizationID)
+	if err != nil {
+		return ServiceAccountRes{}, fmt.Errorf("failed to fetch service account: %w", err)
+	}
+	return account, nil
+}
+
+func (r *PostgresServiceAccountRepository) GetCredentials(ctx context.Context, clientID string) (serviceAccountCredentials, error) {
+	var creds serviceAccountCredentials
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &creds, `
+		SELECT sa.user_id AS id, sa.secret_hash, sa.disabled_at,
+			COALESCE(array_agg(ur.role ORDER BY ur.role) FILTER (WHERE ur.role IS NOT NULL), '{}') AS roles
+		FROM service_accounts sa
+		LEFT JOIN user_roles ur ON ur.user_id = sa.user_id AND ur.archived_at IS NULL
+		WHERE sa.client_id = $1
+		GROUP BY sa.user_id
+	`, clientID)
+	if err != nil {
+		return serviceAccountCredentials{}, fmt.Errorf("failed to fetch service account credentials: %w", err)
+	}
+	return creds, nil
+}
+
+// GetUnknownRoles returns the roles that don't exist or are archived
+func (r *PostgresServiceAccountRepository) GetUnknownRoles(ctx context.Context, roles []string) ([]string, error) {
+	unknown := make([]string, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &unknown, `
+		SELECT requested FROM unnest($1::text[]) AS requested
+		WHERE NOT EXISTS (SELECT 1 FROM roles WHERE name = requested AND archived_at IS NULL)
+	`, pq.Array(roles))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check roles: %w", err)
+	}
+	return unknown, nil
+}
+
+// ReplaceRoleBindings archives the bindings not in roles and adds the missing ones, bindings that
+// stay keep their history
+func (r *PostgresServiceAccountRepository) ReplaceRoleBindings(ctx context.Context, id uuid.UUID, roles []string, updatedBy uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE user_roles SET archived_at = now(), archived_by = $3
+		WHERE user_id = $1 AND archived_at IS NULL AND NOT (role = ANY($2))
+	`, id, pq.Array(roles), updatedBy)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to archive service account roles", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to update role bindings: %w", err)
+	}
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO user_roles (user_id, role, created_by)
+		SELECT $1, requested, $3 FROM unnest($2::text[]) AS requested
+		ON CONFLICT (user_id, role) WHERE archived_at IS NULL DO NOTHING
+	`, id, pq.Array(roles), updatedBy)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert service account roles", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to update role bindings: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresServiceAccountRepository) RotateSecret(ctx context.Context, id uuid.UUID, secretHash string, organizationID *uuid.UUID) error {
+	result, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE service_accounts sa SET secret_hash = $2, secret_rotated_at = now()
+		FROM users u
+		WHERE sa.user_id = $1 AND u.id = sa.user_id AND sa.disabled_at IS NULL
+		AND ($3::uuid IS NULL OR u.organization_id = $3)
+	`, id, secretHash, organizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to rotate service account secret", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to rotate secret: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrServiceAccountDisabled
+	}
+	return nil
+}
+
+// DisableServiceAccount archives the users row along with the credentials, so it drops out of
+// role lookups and can't be used as an actor anymore
+func (r *PostgresServiceAccountRepository) DisableServiceAccount(ctx context.Context, id, disabledBy uuid.UUID, organizationID *uuid.UUID) error {
+	result, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE service_accounts sa SET disabled_at = now(), disabled_by = $2
+		FROM users u
+		WHERE sa.user_id = $1 AND u.id = sa.user_id AND sa.disabled_at IS NULL
+		AND ($3::uuid IS NULL OR u.organization_id = $3)
+	`, id, disabledBy, organizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to disable service account", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to disable service account: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrServiceAccountDisabled
+	}
+	if _, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `UPDATE users SET archived_at = now(), archived_by = $2 WHERE id = $1`, id, disabledBy); err != nil {
+		return fmt.Errorf("failed to disable service account: %w", err)
+	}
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE user_roles SET archived_at = now(), archived_by = $2
+		WHERE user_id = $1 AND archived_at IS NULL
+	`, id, disabledBy)
+	if err != nil {
+		return fmt.Errorf("failed to disable service account: %w", err)
+	}
+	return nil
+}
+
+// TouchLastToken is written at most once a minute, like api_keys.last_used_at
+func (r *PostgresServiceAccountRepository) TouchLastToken(ctx context.Context, id uuid.UUID) {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE service_accounts SET last_token_at = now()
+		WHERE user_id = $1 AND (last_token_at IS NULL OR last_token_at < now() - INTERVAL '1 minute')
+	`, id)
+	if err != nil {
+		r.Logger.GetLogger().Warn("failed to update service account last token time", zap.String("id", id.String()), zap.Error(err))
+	}
+}
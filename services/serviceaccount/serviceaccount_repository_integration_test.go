@@ -0,0 +1,59 @@
+//go:build integration
+
+package serviceaccountservice
+
+import (
+	"asset/database/seed"
+	"asset/database/testdb"
+	"asset/models"
+	"asset/providers"
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestServiceAccountsOtherOrganization(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewServiceAccountRepository(db, logger)
+	ctx := context.Background()
+	adminID := seed.ID("user:admin")
+
+	var other uuid.UUID
+	require.NoError(t, db.GetContext(ctx, &other, `
+		INSERT INTO organizations (name, slug) VALUES ('Other', 'other') RETURNING id
+	`))
+	id, err := repo.InsertServiceAccount(ctx, CreateServiceAccountReq{Name: "other-sync"}, "other-client", "hash", adminID, &other)
+	require.NoError(t, err)
+
+	account, err := repo.GetServiceAccountByID(ctx, id, &other)
+	require.NoError(t, err)
+	assert.Equal(t, other, account.OrganizationID)
+
+	_, err = repo.GetServiceAccountByID(ctx, id, &models.DefaultOrganizationID)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	accounts, err := repo.GetServiceAccounts(ctx, &models.DefaultOrganizationID)
+	require.NoError(t, err)
+	for _, account := range accounts {
+		assert.NotEqual(t, id, account.ID)
+	}
+	assert.ErrorIs(t, repo.RotateSecret(ctx, id, "new-hash", &models.DefaultOrganizationID), ErrServiceAccountDisabled)
+	assert.ErrorIs(t, repo.DisableServiceAccount(ctx, id, adminID, &models.DefaultOrganizationID), ErrServiceAccountDisabled)
+
+	// without an organization the account joins the creator's
+	id, err = repo.InsertServiceAccount(ctx, CreateServiceAccountReq{Name: "default-sync"}, "default-client", "hash", adminID, nil)
+	require.NoError(t, err)
+	account, err = repo.GetServiceAccountByID(ctx, id, &models.DefaultOrganizationID)
+	require.NoError(t, err)
+	assert.Equal(t, models.DefaultOrganizationID, account.OrganizationID)
+}
@@ -0,0 +1,287 @@
+package serviceaccountservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/providers/middlewareprovider"
+	"asset/services/audit"
+	"asset/utils"
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type ServiceAccountService interface {
+	CreateServiceAccount(ctx context.Context, req CreateServiceAccountReq, adminID uuid.UUID, scope models.DepartmentScope) (ServiceAccountSecretRes, error)
+	GetServiceAccounts(ctx context.Context, scope models.DepartmentScope) ([]ServiceAccountRes, error)
+	UpdateServiceAccountRoles(ctx context.Context, id uuid.UUID, req UpdateServiceAccountRolesReq, adminID uuid.UUID, scope models.DepartmentScope) error
+	RotateServiceAccountSecret(ctx context.Context, id, adminID uuid.UUID, scope models.DepartmentScope) (ServiceAccountSecretRes, error)
+	DisableServiceAccount(ctx context.Context, id, adminID uuid.UUID, scope models.DepartmentScope) error
+	IssueToken(ctx context.Context, req TokenReq, client models.ClientInfo) (TokenRes, error)
+}
+
+type serviceAccountServiceStruct struct {
+	repo           ServiceAccountRepository
+	db             *sqlx.DB
+	logger         providers.ZapLoggerProvider
+	AuthMiddleware providers.AuthMiddlewareService
+	audit          auditservice.AuditService
+}
+
+func NewServiceAccountService(repo ServiceAccountRepository, db *sqlx.DB, logger providers.ZapLoggerProvider, AuthMiddleware providers.AuthMiddlewareService, audit auditservice.AuditService) ServiceAccountService {
+	return &serviceAccountServiceStruct{repo: repo, db: db, logger: logger, AuthMiddleware: AuthMiddleware, audit: audit}
+}
+
+var (
+	ErrServiceAccountNotFound   = models.NewServiceError(http.StatusNotFound, "service_account_not_found", "service account not found")
+	ErrServiceAccountDisabled   = models.NewServiceError(http.StatusForbidden, "service_account_disabled", "service account is disabled")
+	ErrInvalidClientCredentials = models.NewServiceError(http.StatusUnauthorized, "invalid_client_credentials", "invalid client credentials")
+	ErrUnknownRole              = models.NewServiceError(http.StatusBadRequest, "unknown_role", "role does not exist")
+	// privileged grants need a second admin's approval, binding the role to an account would skip that
+	ErrRoleNotBindable = models.NewServiceError(http.StatusForbidden, "role_not_bindable", "role can't be bound to a service account")
+)
+
+// CreateServiceAccount puts the account in the caller's organization, it only ever acts within it
+func (s *serviceAccountServiceStruct) CreateServiceAccount(ctx context.Context, req CreateServiceAccountReq, adminID uuid.UUID, scope models.DepartmentScope) (res ServiceAccountSecretRes, err error) {
+	roles, err := s.checkRoles(ctx, req.Roles)
+	if err != nil {
+		return ServiceAccountSecretRes{}, err
+	}
+	clientID, secret, err := utils.GenerateClientCredentials()
+	if err != nil {
+		return ServiceAccountSecretRes{}, fmt.Errorf("failed to generate client credentials: %w", err)
+	}
+
+	var account ServiceAccountRes
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		id, err := s.repo.InsertServiceAccount(ctx, req, clientID, utils.HashAPIKey(secret), adminID, scope.OrganizationID)
+		if err != nil {
+			return err
+		}
+		if err = s.repo.ReplaceRoleBindings(ctx, id, roles, adminID); err != nil {
+			return err
+		}
+		if account, err = s.repo.GetServiceAccountByID(ctx, id, nil); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, auditservice.AuditEntry{
+			ActorID:    &adminID,
+			Action:     "service_account.created",
+			EntityType: "service_account",
+			EntityID:   id.String(),
+			NewValue:   map[string]interface{}{"name": req.Name, "client_id": clientID, "roles": roles, "organization_id": account.OrganizationID},
+		})
+	})
+	if err != nil {
+		return ServiceAccountSecretRes{}, err
+	}
+	s.logger.GetLogger().Info("service account created", zap.String("id", account.ID.String()), zap.String("clientID", clientID), zap.String("organizationID", account.OrganizationID.String()))
+	return ServiceAccountSecretRes{ServiceAccountRes: account, ClientSecret: secret}, nil
+}
+
+func (s *serviceAccountServiceStruct) GetServiceAccounts(ctx context.Context, scope models.DepartmentScope) ([]ServiceAccountRes, error) {
+	return s.repo.GetServiceAccounts(ctx, scope.OrganizationID)
+}
+
+// UpdateServiceAccountRoles replaces the account's role bindings, tokens already issued carry the
+// old roles so they are revoked and the integration fetches a new one
+func (s *serviceAccountServiceStruct) UpdateServiceAccountRoles(ctx context.Context, id uuid.UUID, req UpdateServiceAccountRolesReq, adminID uuid.UUID, scope models.DepartmentScope) error {
+	account, err := s.getActiveAccount(ctx, id, scope)
+	if err != nil {
+		return err
+	}
+	roles, err := s.checkRoles(ctx, req.Roles)
+	if err != nil {
+		return err
+	}
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		if err := s.repo.ReplaceRoleBindings(ctx, id, roles, adminID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, auditservice.AuditEntry{
+			ActorID:    &adminID,
+			Action:     "service_account.roles_updated",
+			EntityType: "service_account",
+			EntityID:   id.String(),
+			OldValue:   map[string]interface{}{"roles": account.Roles},
+			NewValue:   map[string]interface{}{"roles": roles},
+		})
+	})
+	if err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("service account roles updated", zap.String("id", id.String()), zap.Strings("roles", roles))
+	s.revokeTokens(ctx, id)
+	return nil
+}
+
+// RotateServiceAccountSecret replaces the secret, the old one stops working right away
+func (s *serviceAccountServiceStruct) RotateServiceAccountSecret(ctx context.Context, id, adminID uuid.UUID, scope models.DepartmentScope) (ServiceAccountSecretRes, error) {
+	account, err := s.getActiveAccount(ctx, id, scope)
+	if err != nil {
+		return ServiceAccountSecretRes{}, err
+	}
+	_, secret, err := utils.GenerateClientCredentials()
+	if err != nil {
+		return ServiceAccountSecretRes{}, fmt.Errorf("failed to generate client secret: %w", err)
+	}
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		if err := s.repo.RotateSecret(ctx, id, utils.HashAPIKey(secret), scope.OrganizationID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, auditservice.AuditEntry{
+			ActorID:    &adminID,
+			Action:     "service_account.secret_rotated",
+			EntityType: "service_account",
+			EntityID:   id.String(),
+			NewValue:   map[string]interface{}{"client_id": account.ClientID},
+		})
+	})
+	if err != nil {
+		return ServiceAccountSecretRes{}, err
+	}
+	s.logger.GetLogger().Info("service account secret rotated", zap.String("id", id.String()))
+	s.revokeTokens(ctx, id)
+	account.SecretRotatedAt = time.Now()
+	return ServiceAccountSecretRes{ServiceAccountRes: account, ClientSecret: secret}, nil
+}
+
+func (s *serviceAccountServiceStruct) DisableServiceAccount(ctx context.Context, id, adminID uuid.UUID, scope models.DepartmentScope) error {
+	account, err := s.getActiveAccount(ctx, id, scope)
+	if err != nil {
+		return err
+	}
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		if err := s.repo.DisableServiceAccount(ctx, id, adminID, scope.OrganizationID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, auditservice.AuditEntry{
+			ActorID:    &adminID,
+			Action:     "service_account.disabled",
+			EntityType: "service_account",
+			EntityID:   id.String(),
+			OldValue:   account,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("service account disabled", zap.String("id", id.String()))
+	s.revokeTokens(ctx, id)
+	return nil
+}
+
+// IssueToken trades client credentials for an access token. There is no refresh token, the
+// integration asks again with its credentials once the token expires
+func (s *serviceAccountServiceStruct) IssueToken(ctx context.Context, req TokenReq, client models.ClientInfo) (TokenRes, error) {
+	event := auditservice.AuthEvent{
+		EventType:  auditservice.AuthEventLogin,
+		Method:     "client_credentials",
+		Identifier: req.ClientID,
+		IPAddress:  client.IP,
+		UserAgent:  client.UserAgent,
+	}
+	res, err := s.issueToken(ctx, req, &event)
+	event.Outcome = auditservice.AuthOutcomeSuccess
+	if err != nil {
+		event.Outcome = auditservice.AuthOutcomeFailure
+		event.Reason = err.Error()
+	}
+	if recordErr := s.audit.RecordAuthEvent(ctx, event); recordErr != nil {
+		s.logger.GetLogger().Warn("failed to record auth event", zap.String("clientID", req.ClientID), zap.Error(recordErr))
+	}
+	return res, err
+}
+
+func (s *serviceAccountServiceStruct) issueToken(ctx context.Context, req TokenReq, event *auditservice.AuthEvent) (TokenRes, error) {
+	creds, err := s.repo.GetCredentials(ctx, req.ClientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return TokenRes{}, ErrInvalidClientCredentials
+		}
+		return TokenRes{}, err
+	}
+	event.UserID = &creds.ID
+	if subtle.ConstantTimeCompare([]byte(creds.SecretHash), []byte(utils.HashAPIKey(req.ClientSecret))) != 1 {
+		return TokenRes{}, ErrInvalidClientCredentials
+	}
+	if creds.DisabledAt != nil {
+		return TokenRes{}, ErrServiceAccountDisabled
+	}
+
+	token, err := s.AuthMiddleware.GenerateServiceAccountJWT(creds.ID.String(), creds.Roles)
+	if err != nil {
+		s.logger.GetLogger().Error("failed to sign service account token", zap.String("id", creds.ID.String()), zap.Error(err))
+		return TokenRes{}, err
+	}
+	expiresAt, err := middlewareprovider.GetTokenExpiry(token)
+	if err != nil {
+		return TokenRes{}, err
+	}
+	s.repo.TouchLastToken(ctx, creds.ID)
+	s.logger.GetLogger().Info("service account token issued", zap.String("id", creds.ID.String()))
+	return TokenRes{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(time.Until(expiresAt).Seconds()),
+	}, nil
+}
+
+// getActiveAccount treats accounts of another organization as missing
+func (s *serviceAccountServiceStruct) getActiveAccount(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) (ServiceAccountRes, error) {
+	account, err := s.repo.GetServiceAccountByID(ctx, id, scope.OrganizationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return account, ErrServiceAccountNotFound
+		}
+		return account, err
+	}
+	if account.DisabledAt != nil {
+		return account, ErrServiceAccountDisabled
+	}
+	return account, nil
+}
+
+// checkRoles dedupes the requested roles and makes sure each can be bound
+func (s *serviceAccountServiceStruct) checkRoles(ctx context.Context, requested []string) ([]string, error) {
+	roles := make([]string, 0, len(requested))
+	for _, role := range requested {
+		if !slices.Contains(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+	unknown, err := s.repo.GetUnknownRoles(ctx, roles)
+	if err != nil {
+		return nil, err
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRole, unknown[0])
+	}
+	for _, role := range roles {
+		privileged, err := s.AuthMiddleware.HasPermission(ctx, []string{role}, models.PrivilegedPermissions...)
+		if err != nil {
+			return nil, err
+		}
+		if privileged {
+			return nil, fmt.Errorf("%w: %s", ErrRoleNotBindable, role)
+		}
+	}
+	return roles, nil
+}
+
+// revokeTokens runs after the change is committed, a failure is only logged and the tokens
+// expire on their own
+func (s *serviceAccountServiceStruct) revokeTokens(ctx context.Context, id uuid.UUID) {
+	if err := s.AuthMiddleware.RevokeUserTokens(ctx, id.String()); err != nil {
+		s.logger.GetLogger().Error("failed to revoke service account tokens", zap.String("id", id.String()), zap.Error(err))
+	}
+}
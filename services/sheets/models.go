@@ -0,0 +1,84 @@
+package sheetsservice
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SheetFilter is the asset list filter a sync is saved with, empty fields match everything
+type SheetFilter struct {
+	Search  string   `json:"search,omitempty" validate:"max=200"`
+	Status  []string `json:"status,omitempty" validate:"omitempty,dive,max=50"`
+	OwnedBy []string `json:"owned_by,omitempty" validate:"omitempty,dive,asset_ownership"`
+	Type    []string `json:"type,omitempty" validate:"omitempty,dive,asset_type"`
+}
+
+func (f SheetFilter) Value() (driver.Value, error) {
+	return json.Marshal(f)
+}
+
+func (f *SheetFilter) Scan(src interface{}) error {
+	data, ok := src.([]byte)
+	if !ok {
+		return errors.New("sheet filter is not jsonb")
+	}
+	return json.Unmarshal(data, f)
+}
+
+type CreateSheetSyncReq struct {
+	Name string `json:"name" validate:"required,max=200"`
+	// the id or the whole address of the spreadsheet as the browser shows it
+	Spreadsheet string      `json:"spreadsheet" validate:"required,max=2000"`
+	SheetName   string      `json:"sheet_name" validate:"required,max=100"`
+	Filter      SheetFilter `json:"filter"`
+}
+
+type SheetSync struct {
+	ID             uuid.UUID   `json:"id" db:"id"`
+	Name           string      `json:"name" db:"name"`
+	SpreadsheetID  string      `json:"spreadsheet_id" db:"spreadsheet_id"`
+	SheetName      string      `json:"sheet_name" db:"sheet_name"`
+	Filter         SheetFilter `json:"filter" db:"filter"`
+	AllDepartments bool        `json:"-" db:"all_departments"`
+	DepartmentID   *uuid.UUID  `json:"department_id,omitempty" db:"department_id"`
+	OrganizationID *uuid.UUID  `json:"-" db:"organization_id"`
+	CreatedBy      uuid.UUID   `json:"created_by" db:"created_by"`
+	CreatedAt      time.Time   `json:"created_at" db:"created_at"`
+	NeedsFullSync  bool        `json:"needs_full_sync" db:"needs_full_sync"`
+	LastSyncedAt   *time.Time  `json:"last_synced_at,omitempty" db:"last_synced_at"`
+	LastError      *string     `json:"last_error,omitempty" db:"last_error"`
+}
+
+// sheetRow is an asset as the sheet shows it
+type sheetRow struct {
+	ID             uuid.UUID  `db:"id"`
+	Brand          string     `db:"brand"`
+	Model          string     `db:"model"`
+	SerialNo       string     `db:"serial_no"`
+	Type           string     `db:"type"`
+	OwnedBy        string     `db:"owned_by"`
+	Status         string     `db:"status"`
+	Department     string     `db:"department"`
+	AssignedTo     string     `db:"assigned_to"`
+	PurchaseDate   *time.Time `db:"purchase_date"`
+	WarrantyExpire *time.Time `db:"warranty_expire"`
+}
+
+// sheetHeader is the first row of every synced tab, the asset id in column A is how rows are found again
+var sheetHeader = []string{"Asset ID", "Brand", "Model", "Serial No", "Type", "Owned By", "Status", "Department", "Assigned To", "Purchase Date", "Warranty Expires"}
+
+func (row sheetRow) cells() []string {
+	return []string{row.ID.String(), row.Brand, row.Model, row.SerialNo, row.Type, row.OwnedBy, row.Status, row.Department, row.AssignedTo,
+		formatDate(row.PurchaseDate), formatDate(row.WarrantyExpire)}
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,135 @@
+package sheetsservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type SheetsHandler struct {
+	Service        SheetsService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewSheetsHandler(service SheetsService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *SheetsHandler {
+	return &SheetsHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+// GetSyncs lists the syncs of the caller's department with the account spreadsheets are shared with
+func (h *SheetsHandler) GetSyncs(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetSheetSyncs request received")
+	scope, ok := h.scope(w, r, "GetSheetSyncs")
+	if !ok {
+		return
+	}
+	syncs, err := h.Service.GetSyncs(r.Context(), scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch sheet syncs", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch sheet syncs")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"syncs": syncs, "share_with": h.Service.Account()})
+}
+
+func (h *SheetsHandler) CreateSync(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("CreateSheetSync request received")
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in CreateSheetSync", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in CreateSheetSync", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+	scope, ok := h.scope(w, r, "CreateSheetSync")
+	if !ok {
+		return
+	}
+
+	var req CreateSheetSyncReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in CreateSheetSync", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Validation failed in CreateSheetSync", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	sync, err := h.Service.CreateSync(r.Context(), req, userUUID, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to create sheet sync", zap.String("spreadsheet", req.Spreadsheet), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to create sheet sync")
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+		"message": "sheet sync created, the sheet fills on the next run",
+		"sync":    sync,
+	})
+}
+
+func (h *SheetsHandler) DeleteSync(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("DeleteSheetSync request received")
+	syncID, scope, ok := h.syncAndScope(w, r, "DeleteSheetSync")
+	if !ok {
+		return
+	}
+	if err := h.Service.DeleteSync(r.Context(), syncID, scope); err != nil {
+		h.Logger.GetLogger().Error("Failed to delete sheet sync", zap.String("id", syncID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to delete sheet sync")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "sheet sync deleted, the sheet keeps its rows"})
+}
+
+// Resync rewrites the whole sheet on the next run
+func (h *SheetsHandler) Resync(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("ResyncSheet request received")
+	syncID, scope, ok := h.syncAndScope(w, r, "ResyncSheet")
+	if !ok {
+		return
+	}
+	if err := h.Service.Resync(r.Context(), syncID, scope); err != nil {
+		h.Logger.GetLogger().Error("Failed to request sheet resync", zap.String("id", syncID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to request sheet resync")
+		return
+	}
+	utils.RespondJSON(w, http.StatusAccepted, map[string]interface{}{"message": "the sheet is rewritten on the next run"})
+}
+
+func (h *SheetsHandler) scope(w http.ResponseWriter, r *http.Request, handler string) (models.DepartmentScope, bool) {
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return scope, false
+	}
+	return scope, true
+}
+
+func (h *SheetsHandler) syncAndScope(w http.ResponseWriter, r *http.Request, handler string) (uuid.UUID, models.DepartmentScope, bool) {
+	id := r.URL.Query().Get("id")
+	syncID, err := uuid.Parse(id)
+	if err != nil {
+		h.Logger.GetLogger().Error("Invalid id in "+handler, zap.String("id", id), zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid id")
+		return uuid.Nil, models.DepartmentScope{}, false
+	}
+	scope, ok := h.scope(w, r, handler)
+	return syncID, scope, ok
+}
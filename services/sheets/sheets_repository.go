@@ -0,0 +1,237 @@
+package sheetsservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type SheetsRepository interface {
+	InsertSync(ctx context.Context, sync SheetSync) (SheetSync, error)
+	GetSyncs(ctx context.Context, scope models.DepartmentScope) ([]SheetSync, error)
+	GetSync(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) (SheetSync, error)
+	ArchiveSync(ctx context.Context, id uuid.UUID) error
+	RequestFullSync(ctx context.Context, id uuid.UUID) error
+	EnqueueAssetChange(ctx context.Context, assetID uuid.UUID) error
+	GetSyncsToRun(ctx context.Context) ([]SheetSync, error)
+	ClaimChanges(ctx context.Context, syncID uuid.UUID, limit int) ([]uuid.UUID, error)
+	ClearChanges(ctx context.Context, syncID uuid.UUID) error
+	GetSheetRows(ctx context.Context, sync SheetSync, assetIDs []uuid.UUID, limit int) ([]sheetRow, error)
+	RecordSync(ctx context.Context, id uuid.UUID, syncErr *string) error
+}
+
+type PostgresSheetsRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewSheetsRepository(db *sqlx.DB, log providers.ZapLoggerProvider) SheetsRepository {
+	return &PostgresSheetsRepository{DB: db, Logger: log}
+}
+
+const syncColumns = `id, name, spreadsheet_id, sheet_name, filter, all_departments, department_id, organization_id, created_by,
+	created_at, needs_full_sync, last_synced_at, last_error`
+
+func (r *PostgresSheetsRepository) InsertSync(ctx context.Context, sync SheetSync) (SheetSync, error) {
+	var created SheetSync
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &created, `
+		INSERT INTO sheet_syncs (name, spreadsheet_id, sheet_name, filter, all_departments, department_id, organization_id, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING `+syncColumns,
+		sync.Name, sync.SpreadsheetID, sync.SheetName, sync.Filter, sync.AllDepartments, sync.DepartmentID, sync.OrganizationID, sync.CreatedBy)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return created, ErrSheetAlreadySynced
+		}
+		r.Logger.GetLogger().Error("failed to insert sheet sync", zap.String("spreadsheet_id", sync.SpreadsheetID), zap.Error(err))
+		return created, fmt.Errorf("failed to insert sheet sync: %w", err)
+	}
+	return created, nil
+}
+
+// GetSyncs lists the syncs of the caller's department, or every sync of their organization for whoever sees every department
+func (r *PostgresSheetsRepository) GetSyncs(ctx context.Context, scope models.DepartmentScope) ([]SheetSync, error) {
+	syncs := []SheetSync{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &syncs, `
+		SELECT `+syncColumns+`
+		FROM sheet_syncs
+		WHERE archived_at IS NULL AND ($1 OR department_id IS NOT DISTINCT FROM $2)
+		AND ($3::uuid IS NULL OR organization_id = $3)
+		ORDER BY created_at DESC
+	`, scope.AllDepartments, scope.DepartmentID, scope.OrganizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch sheet syncs", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch sheet syncs: %w", err)
+	}
+	return syncs, nil
+}
+
+func (r *PostgresSheetsRepository) GetSync(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) (SheetSync, error) {
+	var sync SheetSync
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &sync, `
+		SELECT `+syncColumns+`
+		FROM sheet_syncs
+		WHERE id = $1 AND archived_at IS NULL AND ($2 OR department_id IS NOT DISTINCT FROM $3)
+		AND ($4::uuid IS NULL OR organization_id = $4)
+	`, id, scope.AllDepartments, scope.DepartmentID, scope.OrganizationID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return sync, ErrSheetSyncNotFound
+	}
+	if err != nil {
+		return sync, fmt.Errorf("failed to fetch sheet sync: %w", err)
+	}
+	return sync, nil
+}
+
+// ArchiveSync stops the sync, the sheet keeps what was last written to it
+func (r *PostgresSheetsRepository) ArchiveSync(ctx context.Context, id uuid.UUID) error {
+	if _, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `DELETE FROM sheet_sync_changes WHERE sync_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to drop queued sheet changes: %w", err)
+	}
+	if _, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `UPDATE sheet_syncs SET archived_at = now() WHERE id = $1`, id); err != nil {
+		r.Logger.GetLogger().Error("failed to archive sheet sync", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to archive sheet sync: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresSheetsRepository) RequestFullSync(ctx context.Context, id uuid.UUID) error {
+	if _, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `UPDATE sheet_syncs SET needs_full_sync = true WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to request full sheet sync: %w", err)
+	}
+	return nil
+}
+
+// EnqueueAssetChange queues the asset for every active sync, whether it matches a filter is decided when
+// the sync runs since the change may have moved it into or out of one
+func (r *PostgresSheetsRepository) EnqueueAssetChange(ctx context.Context, assetID uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO sheet_sync_changes (sync_id, asset_id)
+		SELECT id, $1 FROM sheet_syncs WHERE archived_at IS NULL
+		ON CONFLICT (sync_id, asset_id) DO NOTHING
+	`, assetID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to queue asset change for sheets", zap.String("asset_id", assetID.String()), zap.Error(err))
+		return fmt.Errorf("failed to queue asset change for sheets: %w", err)
+	}
+	return nil
+}
+
+// GetSyncsToRun returns the syncs with queued changes or waiting for a full sync
+func (r *PostgresSheetsRepository) GetSyncsToRun(ctx context.Context) ([]SheetSync, error) {
+	var syncs []SheetSync
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &syncs, `
+		SELECT `+syncColumns+`
+		FROM sheet_syncs s
+		WHERE archived_at IS NULL
+		AND (needs_full_sync OR EXISTS (SELECT 1 FROM sheet_sync_changes c WHERE c.sync_id = s.id))
+		ORDER BY last_synced_at NULLS FIRST
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch sheet syncs to run: %w", err)
+	}
+	return syncs, nil
+}
+
+// ClaimChanges takes up to limit queued assets off the sync's queue, oldest first
+func (r *PostgresSheetsRepository) ClaimChanges(ctx context.Context, syncID uuid.UUID, limit int) ([]uuid.UUID, error) {
+	var assetIDs []uuid.UUID
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &assetIDs, `
+		DELETE FROM sheet_sync_changes
+		WHERE sync_id = $1 AND asset_id IN (
+			SELECT asset_id FROM sheet_sync_changes WHERE sync_id = $1 ORDER BY queued_at LIMIT $2
+		)
+		RETURNING asset_id
+	`, syncID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim sheet changes: %w", err)
+	}
+	return assetIDs, nil
+}
+
+func (r *PostgresSheetsRepository) ClearChanges(ctx context.Context, syncID uuid.UUID) error {
+	if _, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `DELETE FROM sheet_sync_changes WHERE sync_id = $1`, syncID); err != nil {
+		return fmt.Errorf("failed to clear sheet changes: %w", err)
+	}
+	return nil
+}
+
+// GetSheetRows returns the assets matching the sync's filter and scope, only those of assetIDs when it
+// isn't nil, in the order they were added
+func (r *PostgresSheetsRepository) GetSheetRows(ctx context.Context, sync SheetSync, assetIDs []uuid.UUID, limit int) ([]sheetRow, error) {
+	search := ""
+	if sync.Filter.Search != "" {
+		search = "%" + sync.Filter.Search + "%"
+	}
+	rows := []sheetRow{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &rows, `
+		SELECT a.id, a.brand, a.model, a.serial_no, COALESCE(a.type::text, '') AS type, a.owned_by::text AS owned_by,
+			a.status::text AS status, COALESCE(d.name, '') AS department, COALESCE(u.email, '') AS assigned_to,
+			a.purchase_date, a.warranty_expire
+		FROM assets a
+		LEFT JOIN departments d ON d.id = a.department_id
+		LEFT JOIN asset_assign aa ON aa.asset_id = a.id AND aa.returned_at IS NULL AND aa.archived_at IS NULL
+		LEFT JOIN users u ON u.id = aa.employee_id
+		WHERE a.archived_at IS NULL
+		AND ($1::uuid[] IS NULL OR a.id = ANY($1))
+		AND ($2 = '' OR a.brand ILIKE $2 OR a.model ILIKE $2 OR a.serial_no ILIKE $2)
+		AND (cardinality($3::text[]) = 0 OR a.status::text = ANY($3))
+		AND (cardinality($4::text[]) = 0 OR a.owned_by::text = ANY($4))
+		AND (cardinality($5::text[]) = 0 OR a.type::text = ANY($5))
+		AND ($6 OR a.department_id IS NOT DISTINCT FROM $7)
+		AND ($9::uuid IS NULL OR a.organization_id = $9)
+		ORDER BY a.added_at, a.id
+		LIMIT $8
+	`, assetIDArray(assetIDs), search, pq.Array(nonNil(sync.Filter.Status)), pq.Array(nonNil(sync.Filter.OwnedBy)), pq.Array(nonNil(sync.Filter.Type)),
+		sync.AllDepartments, sync.DepartmentID, limit, sync.OrganizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch sheet rows", zap.String("sync_id", sync.ID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch sheet rows: %w", err)
+	}
+	return rows, nil
+}
+
+// RecordSync notes the outcome of a run, a failed one leaves the sheet in an unknown state so the next
+// run rewrites it
+func (r *PostgresSheetsRepository) RecordSync(ctx context.Context, id uuid.UUID, syncErr *string) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE sheet_syncs
+		SET last_synced_at = CASE WHEN $2::text IS NULL THEN now() ELSE last_synced_at END,
+			needs_full_sync = CASE WHEN $2::text IS NULL THEN false ELSE true END,
+			last_error = $2
+		WHERE id = $1
+	`, id, syncErr)
+	if err != nil {
+		return fmt.Errorf("failed to record sheet sync: %w", err)
+	}
+	return nil
+}
+
+// assetIDArray is null for a nil slice, which GetSheetRows reads as every asset
+func assetIDArray(ids []uuid.UUID) interface{} {
+	if ids == nil {
+		return nil
+	}
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = id.String()
+	}
+	return pq.Array(values)
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,57 @@
+//go:build integration
+
+package sheetsservice
+
+import (
+	"asset/database/seed"
+	"asset/database/testdb"
+	"asset/models"
+	"asset/providers"
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// a sync made in one organization is hidden from the others and only writes that organization's assets
+func TestSheetSyncOtherOrganization(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewSheetsRepository(db, logger)
+	ctx := context.Background()
+
+	var other uuid.UUID
+	require.NoError(t, db.GetContext(ctx, &other, `
+		INSERT INTO organizations (name, slug) VALUES ('Other', 'other') RETURNING id
+	`))
+	_, err := db.ExecContext(ctx, `UPDATE assets SET organization_id = $1 WHERE id = $2`, other, seed.ID("asset:laptop-1"))
+	require.NoError(t, err)
+
+	sync, err := repo.InsertSync(ctx, SheetSync{
+		Name: "Other inventory", SpreadsheetID: "sheet-other", SheetName: "Assets",
+		AllDepartments: true, OrganizationID: &other, CreatedBy: seed.ID("user:admin"),
+	})
+	require.NoError(t, err)
+
+	own := models.DepartmentScope{AllDepartments: true, OrganizationID: &models.DefaultOrganizationID}
+	syncs, err := repo.GetSyncs(ctx, own)
+	require.NoError(t, err)
+	assert.Empty(t, syncs)
+	_, err = repo.GetSync(ctx, sync.ID, own)
+	assert.ErrorIs(t, err, ErrSheetSyncNotFound)
+
+	found, err := repo.GetSync(ctx, sync.ID, models.DepartmentScope{AllDepartments: true, OrganizationID: &other})
+	require.NoError(t, err)
+	rows, err := repo.GetSheetRows(ctx, found, nil, 1000)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, seed.ID("asset:laptop-1"), rows[0].ID)
+}
@@ -0,0 +1,255 @@
+package sheetsservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// SheetsService keeps google sheets in step with saved asset filters. A sync rewrites the whole tab
+// when it is set up, then the assets the event service reports as changed are updated in place,
+// appended or blanked out by a background job
+type SheetsService interface {
+	// Account is the service account spreadsheets are shared with, empty when syncing is off
+	Account() string
+	CreateSync(ctx context.Context, req CreateSheetSyncReq, userID uuid.UUID, scope models.DepartmentScope) (SheetSync, error)
+	GetSyncs(ctx context.Context, scope models.DepartmentScope) ([]SheetSync, error)
+	DeleteSync(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) error
+	Resync(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) error
+	Enqueue(ctx context.Context, assetID uuid.UUID) error
+	SyncPending(ctx context.Context) error
+}
+
+var (
+	ErrSheetsOff          = models.NewServiceError(http.StatusServiceUnavailable, "sheets_off", "google sheets sync is not configured")
+	ErrSheetSyncNotFound  = models.NewServiceError(http.StatusNotFound, "sheet_sync_not_found", "sheet sync not found")
+	ErrSheetAlreadySynced = models.NewServiceError(http.StatusConflict, "sheet_already_synced", "this tab is already kept in sync by another filter")
+	ErrSheetUnreachable   = models.NewServiceError(http.StatusBadRequest, "sheet_unreachable", "the spreadsheet or tab can't be opened, share it with the service account as an editor")
+	ErrSheetTooLarge      = models.NewServiceError(http.StatusUnprocessableEntity, "sheet_too_large", "the filter matches more assets than a synced sheet may hold")
+)
+
+const (
+	// queued assets handled per sync and run, the rest wait for the next run
+	sheetChangeBatch = 500
+	// how much of a failure is kept in last_error
+	sheetErrorLimit = 500
+)
+
+// spreadsheetURL picks the id out of https://docs.google.com/spreadsheets/d/<id>/edit
+var spreadsheetURL = regexp.MustCompile(`/spreadsheets/d/([a-zA-Z0-9_-]+)`)
+
+type sheetsServiceStruct struct {
+	repo   SheetsRepository
+	cfg    models.SheetsConfig
+	logger providers.ZapLoggerProvider
+	// nil when SHEETS_SYNC_ENABLED is off, changes are then not queued
+	sheets providers.SheetsProvider
+}
+
+func NewSheetsService(repo SheetsRepository, cfg models.SheetsConfig, logger providers.ZapLoggerProvider, sheets providers.SheetsProvider) SheetsService {
+	return &sheetsServiceStruct{repo: repo, cfg: cfg, logger: logger, sheets: sheets}
+}
+
+func (s *sheetsServiceStruct) Account() string {
+	if s.sheets == nil {
+		return ""
+	}
+	return s.sheets.Account()
+}
+
+// CreateSync reads the tab first, so a spreadsheet that wasn't shared is refused rather than failing
+// in the background. The first run fills it
+func (s *sheetsServiceStruct) CreateSync(ctx context.Context, req CreateSheetSyncReq, userID uuid.UUID, scope models.DepartmentScope) (SheetSync, error) {
+	if s.sheets == nil {
+		return SheetSync{}, ErrSheetsOff
+	}
+	sync := SheetSync{
+		Name:           req.Name,
+		SpreadsheetID:  parseSpreadsheetID(req.Spreadsheet),
+		SheetName:      req.SheetName,
+		Filter:         req.Filter,
+		AllDepartments: scope.AllDepartments,
+		DepartmentID:   scope.DepartmentID,
+		OrganizationID: scope.OrganizationID,
+		CreatedBy:      userID,
+	}
+	if _, err := s.sheets.ReadKeys(ctx, sync.SpreadsheetID, sync.SheetName); err != nil {
+		s.logger.GetLogger().Info("spreadsheet for sheet sync unreachable", zap.String("spreadsheet_id", sync.SpreadsheetID), zap.Error(err))
+		return SheetSync{}, ErrSheetUnreachable
+	}
+	created, err := s.repo.InsertSync(ctx, sync)
+	if err != nil {
+		return SheetSync{}, err
+	}
+	s.logger.GetLogger().Info("sheet sync created", zap.String("id", created.ID.String()), zap.String("spreadsheet_id", created.SpreadsheetID),
+		zap.String("sheet", created.SheetName), zap.String("userID", userID.String()))
+	return created, nil
+}
+
+func (s *sheetsServiceStruct) GetSyncs(ctx context.Context, scope models.DepartmentScope) ([]SheetSync, error) {
+	return s.repo.GetSyncs(ctx, scope)
+}
+
+func (s *sheetsServiceStruct) DeleteSync(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) error {
+	if _, err := s.repo.GetSync(ctx, id, scope); err != nil {
+		return err
+	}
+	if err := s.repo.ArchiveSync(ctx, id); err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("sheet sync deleted", zap.String("id", id.String()))
+	return nil
+}
+
+// Resync rewrites the whole tab on the next run, for sheets someone edited by hand
+func (s *sheetsServiceStruct) Resync(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) error {
+	if s.sheets == nil {
+		return ErrSheetsOff
+	}
+	if _, err := s.repo.GetSync(ctx, id, scope); err != nil {
+		return err
+	}
+	return s.repo.RequestFullSync(ctx, id)
+}
+
+// Enqueue takes the transaction of the change, a change that rolls back isn't synced
+func (s *sheetsServiceStruct) Enqueue(ctx context.Context, assetID uuid.UUID) error {
+	if s.sheets == nil {
+		return nil
+	}
+	return s.repo.EnqueueAssetChange(ctx, assetID)
+}
+
+// SyncPending runs every sync with queued changes. A failed sync is recorded and rewritten in full on
+// the next run instead of failing the job, so one unshared sheet doesn't hold up the others
+func (s *sheetsServiceStruct) SyncPending(ctx context.Context) error {
+	if s.sheets == nil {
+		return nil
+	}
+	syncs, err := s.repo.GetSyncsToRun(ctx)
+	if err != nil {
+		return err
+	}
+	for _, sync := range syncs {
+		var syncErr error
+		if sync.NeedsFullSync {
+			syncErr = s.fullSync(ctx, sync)
+		} else {
+			syncErr = s.syncChanges(ctx, sync)
+		}
+		var reason *string
+		if syncErr != nil {
+			s.logger.GetLogger().Warn("sheet sync failed, the next run rewrites the sheet", zap.String("id", sync.ID.String()),
+				zap.String("spreadsheet_id", sync.SpreadsheetID), zap.Error(syncErr))
+			message := syncErr.Error()
+			if len(message) > sheetErrorLimit {
+				message = message[:sheetErrorLimit]
+			}
+			reason = &message
+		}
+		if err := s.repo.RecordSync(ctx, sync.ID, reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fullSync replaces the tab with the header and every matching asset. The queue is cleared first, a
+// change queued while the rows are read is then picked up again by the next run
+func (s *sheetsServiceStruct) fullSync(ctx context.Context, sync SheetSync) error {
+	if err := s.repo.ClearChanges(ctx, sync.ID); err != nil {
+		return err
+	}
+	rows, err := s.repo.GetSheetRows(ctx, sync, nil, s.cfg.MaxRows+1)
+	if err != nil {
+		return err
+	}
+	if len(rows) > s.cfg.MaxRows {
+		return fmt.Errorf("%w, at most %d", ErrSheetTooLarge, s.cfg.MaxRows)
+	}
+	values := make([][]string, 0, len(rows)+1)
+	values = append(values, sheetHeader)
+	for _, row := range rows {
+		values = append(values, row.cells())
+	}
+	if err := s.sheets.ReplaceAll(ctx, sync.SpreadsheetID, sync.SheetName, values); err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("sheet rewritten", zap.String("id", sync.ID.String()), zap.Int("rows", len(rows)))
+	return nil
+}
+
+// syncChanges finds the rows of the changed assets by the id in column A. A matching asset is updated
+// in place or appended, one that no longer matches has its row blanked out so the rows under it keep
+// their place, a full sync closes the gaps
+func (s *sheetsServiceStruct) syncChanges(ctx context.Context, sync SheetSync) error {
+	assetIDs, err := s.repo.ClaimChanges(ctx, sync.ID, sheetChangeBatch)
+	if err != nil || len(assetIDs) == 0 {
+		return err
+	}
+	keys, err := s.sheets.ReadKeys(ctx, sync.SpreadsheetID, sync.SheetName)
+	if err != nil {
+		return err
+	}
+	// an emptied tab is filled again from scratch
+	if len(keys) == 0 {
+		return s.fullSync(ctx, sync)
+	}
+	rows, err := s.repo.GetSheetRows(ctx, sync, assetIDs, len(assetIDs))
+	if err != nil {
+		return err
+	}
+
+	rowNumbers := make(map[string]int, len(keys))
+	for i, key := range keys {
+		if key != "" {
+			rowNumbers[key] = i + 1
+		}
+	}
+	matched := make(map[uuid.UUID]sheetRow, len(rows))
+	for _, row := range rows {
+		matched[row.ID] = row
+	}
+
+	updates := map[int][]string{}
+	var appends [][]string
+	for _, assetID := range assetIDs {
+		row, matches := matched[assetID]
+		number, onSheet := rowNumbers[assetID.String()]
+		switch {
+		case matches && onSheet:
+			updates[number] = row.cells()
+		case matches:
+			appends = append(appends, row.cells())
+		case onSheet:
+			updates[number] = make([]string, len(sheetHeader))
+		}
+	}
+	if len(appends)+len(rowNumbers)-1 > s.cfg.MaxRows {
+		return fmt.Errorf("%w, at most %d", ErrSheetTooLarge, s.cfg.MaxRows)
+	}
+	if err := s.sheets.UpdateRows(ctx, sync.SpreadsheetID, sync.SheetName, updates); err != nil {
+		return err
+	}
+	if err := s.sheets.AppendRows(ctx, sync.SpreadsheetID, sync.SheetName, appends); err != nil {
+		return err
+	}
+	s.logger.GetLogger().Debug("sheet synced", zap.String("id", sync.ID.String()), zap.Int("updated", len(updates)), zap.Int("appended", len(appends)))
+	return nil
+}
+
+// parseSpreadsheetID accepts the id or the address of the spreadsheet
+func parseSpreadsheetID(spreadsheet string) string {
+	spreadsheet = strings.TrimSpace(spreadsheet)
+	if match := spreadsheetURL.FindStringSubmatch(spreadsheet); match != nil {
+		return match[1]
+	}
+	return spreadsheet
+}
@@ -0,0 +1,119 @@
+package sheetsservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestSyncPending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	cfg := models.SheetsConfig{Enabled: true, Timeout: time.Second, MaxRows: 100}
+	fresh := SheetSync{ID: uuid.New(), SpreadsheetID: "book", SheetName: "Laptops", NeedsFullSync: true}
+	live := SheetSync{ID: uuid.New(), SpreadsheetID: "book", SheetName: "Monitors"}
+	unshared := SheetSync{ID: uuid.New(), SpreadsheetID: "private", SheetName: "Assets"}
+
+	onSheet := sheetRow{ID: uuid.New(), Brand: "Dell", Status: "assigned"}
+	added := sheetRow{ID: uuid.New(), Brand: "HP", Status: "available"}
+	leftFilter := uuid.New()
+	purchased := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	repo := NewMockSheetsRepository(ctrl)
+	sheets := providers.NewMockSheetsProvider(ctrl)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	repo.EXPECT().GetSyncsToRun(ctx).Return([]SheetSync{fresh, live, unshared}, nil)
+
+	// a new sync gets the header and every matching asset
+	repo.EXPECT().ClearChanges(ctx, fresh.ID).Return(nil)
+	repo.EXPECT().GetSheetRows(ctx, fresh, nil, cfg.MaxRows+1).Return([]sheetRow{{ID: onSheet.ID, Brand: "Dell", PurchaseDate: &purchased}}, nil)
+	sheets.EXPECT().ReplaceAll(ctx, "book", "Laptops", [][]string{sheetHeader, {onSheet.ID.String(), "Dell", "", "", "", "", "", "", "", "2026-03-01", ""}}).Return(nil)
+	repo.EXPECT().RecordSync(ctx, fresh.ID, (*string)(nil)).Return(nil)
+
+	// changed assets are updated in place, appended or blanked out
+	repo.EXPECT().ClaimChanges(ctx, live.ID, sheetChangeBatch).Return([]uuid.UUID{onSheet.ID, added.ID, leftFilter}, nil)
+	sheets.EXPECT().ReadKeys(ctx, "book", "Monitors").Return([]string{"Asset ID", leftFilter.String(), "", onSheet.ID.String()}, nil)
+	repo.EXPECT().GetSheetRows(ctx, live, []uuid.UUID{onSheet.ID, added.ID, leftFilter}, 3).Return([]sheetRow{onSheet, added}, nil)
+	sheets.EXPECT().UpdateRows(ctx, "book", "Monitors", map[int][]string{2: make([]string, len(sheetHeader)), 4: onSheet.cells()}).Return(nil)
+	sheets.EXPECT().AppendRows(ctx, "book", "Monitors", [][]string{added.cells()}).Return(nil)
+	repo.EXPECT().RecordSync(ctx, live.ID, (*string)(nil)).Return(nil)
+
+	// a failure is recorded and doesn't stop the run
+	repo.EXPECT().ClaimChanges(ctx, unshared.ID, sheetChangeBatch).Return([]uuid.UUID{added.ID}, nil)
+	sheets.EXPECT().ReadKeys(ctx, "private", "Assets").Return(nil, errors.New("permission denied"))
+	repo.EXPECT().RecordSync(ctx, unshared.ID, gomock.Not(gomock.Nil())).DoAndReturn(
+		func(_ context.Context, _ uuid.UUID, reason *string) error {
+			assert.Equal(t, "permission denied", *reason)
+			return nil
+		})
+
+	service := NewSheetsService(repo, cfg, logger, sheets)
+	assert.NoError(t, service.SyncPending(ctx))
+}
+
+func TestFullSyncTooLarge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	sync := SheetSync{ID: uuid.New(), SpreadsheetID: "book", SheetName: "Assets", NeedsFullSync: true}
+	repo := NewMockSheetsRepository(ctrl)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	repo.EXPECT().GetSyncsToRun(ctx).Return([]SheetSync{sync}, nil)
+	repo.EXPECT().ClearChanges(ctx, sync.ID).Return(nil)
+	repo.EXPECT().GetSheetRows(ctx, sync, nil, 2).Return([]sheetRow{{ID: uuid.New()}, {ID: uuid.New()}}, nil)
+	repo.EXPECT().RecordSync(ctx, sync.ID, gomock.Not(gomock.Nil())).Return(nil)
+
+	// nothing is written to the sheet
+	service := NewSheetsService(repo, models.SheetsConfig{Enabled: true, MaxRows: 1}, logger, providers.NewMockSheetsProvider(ctrl))
+	assert.NoError(t, service.SyncPending(ctx))
+}
+
+func TestCreateSync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	userID := uuid.New()
+	departmentID := uuid.New()
+	scope := models.DepartmentScope{DepartmentID: &departmentID}
+	req := CreateSheetSyncReq{Name: "Laptops", Spreadsheet: "https://docs.google.com/spreadsheets/d/1AbC-d_9/edit#gid=0", SheetName: "Stock", Filter: SheetFilter{Type: []string{"laptop"}}}
+
+	repo := NewMockSheetsRepository(ctrl)
+	sheets := providers.NewMockSheetsProvider(ctrl)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+	service := NewSheetsService(repo, models.SheetsConfig{Enabled: true}, logger, sheets)
+
+	sheets.EXPECT().ReadKeys(ctx, "1AbC-d_9", "Stock").Return(nil, nil)
+	repo.EXPECT().InsertSync(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, sync SheetSync) (SheetSync, error) {
+		assert.Equal(t, "1AbC-d_9", sync.SpreadsheetID)
+		assert.Equal(t, &departmentID, sync.DepartmentID)
+		assert.Equal(t, userID, sync.CreatedBy)
+		sync.ID = uuid.New()
+		return sync, nil
+	})
+	_, err := service.CreateSync(ctx, req, userID, scope)
+	assert.NoError(t, err)
+
+	sheets.EXPECT().ReadKeys(ctx, "1AbC-d_9", "Stock").Return(nil, errors.New("404"))
+	_, err = service.CreateSync(ctx, req, userID, scope)
+	assert.ErrorIs(t, err, ErrSheetUnreachable)
+
+	_, err = NewSheetsService(repo, models.SheetsConfig{}, logger, nil).CreateSync(ctx, req, userID, scope)
+	assert.ErrorIs(t, err, ErrSheetsOff)
+}
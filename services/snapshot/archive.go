@@ -0,0 +1,154 @@
+package snapshotservice
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+const manifestName = "manifest.json"
+
+func tableEntryName(table string) string {
+	return "tables/" + table + ".jsonl"
+}
+
+// archiveWriter writes the rows of each table into its own entry of the zip and keeps their counts
+// and checksums for the manifest, which is written last by close
+type archiveWriter struct {
+	zip      *zip.Writer
+	manifest *Manifest
+	entry    io.Writer
+	hash     hash.Hash
+	current  *TableManifest
+}
+
+func newArchiveWriter(w io.Writer, manifest *Manifest) *archiveWriter {
+	return &archiveWriter{zip: zip.NewWriter(w), manifest: manifest}
+}
+
+func (a *archiveWriter) BeginTable(name string) error {
+	a.endTable()
+	entry, err := a.zip.Create(tableEntryName(name))
+	if err != nil {
+		return fmt.Errorf("failed to add %s to snapshot: %w", name, err)
+	}
+	a.hash = sha256.New()
+	a.entry = io.MultiWriter(entry, a.hash)
+	a.manifest.Tables = append(a.manifest.Tables, TableManifest{Name: name})
+	a.current = &a.manifest.Tables[len(a.manifest.Tables)-1]
+	return nil
+}
+
+func (a *archiveWriter) WriteRow(row []byte) error {
+	if _, err := a.entry.Write(append(row, '\n')); err != nil {
+		return fmt.Errorf("failed to write %s row to snapshot: %w", a.current.Name, err)
+	}
+	a.current.Rows++
+	return nil
+}
+
+func (a *archiveWriter) endTable() {
+	if a.current != nil {
+		a.current.SHA256 = hex.EncodeToString(a.hash.Sum(nil))
+	}
+}
+
+// close writes the manifest and finishes the zip
+func (a *archiveWriter) close() error {
+	a.endTable()
+	entry, err := a.zip.Create(manifestName)
+	if err != nil {
+		return fmt.Errorf("failed to add manifest to snapshot: %w", err)
+	}
+	encoder := json.NewEncoder(entry)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(a.manifest); err != nil {
+		return fmt.Errorf("failed to write snapshot manifest: %w", err)
+	}
+	return a.zip.Close()
+}
+
+// errUnpackedTooLarge stops reading an archive that unpacks to more than it is allowed to
+var errUnpackedTooLarge = errors.New("the archive unpacks to more than the size limit")
+
+// readArchive reads the manifest and the rows of every table it lists, a table whose row count or
+// checksum doesn't match the manifest fails the whole archive. Unpacked entries count against
+// maxUnpacked so a small archive can't expand without bound
+func readArchive(data []byte, maxUnpacked int64) (Manifest, []TableRows, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return Manifest{}, nil, fmt.Errorf("not a zip archive: %w", err)
+	}
+	entries := make(map[string]*zip.File, len(reader.File))
+	for _, file := range reader.File {
+		entries[file.Name] = file
+	}
+	budget := maxUnpacked
+
+	manifestFile, ok := entries[manifestName]
+	if !ok {
+		return Manifest{}, nil, errors.New("the archive has no manifest.json")
+	}
+	raw, err := readEntry(manifestFile, &budget)
+	if err != nil {
+		return Manifest{}, nil, err
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return Manifest{}, nil, fmt.Errorf("invalid manifest.json: %w", err)
+	}
+
+	tables := make([]TableRows, 0, len(manifest.Tables))
+	for _, table := range manifest.Tables {
+		file, ok := entries[tableEntryName(table.Name)]
+		if !ok {
+			return manifest, nil, fmt.Errorf("the archive has no rows for %s", table.Name)
+		}
+		raw, err := readEntry(file, &budget)
+		if err != nil {
+			return manifest, nil, err
+		}
+		sum := sha256.Sum256(raw)
+		if hex.EncodeToString(sum[:]) != table.SHA256 {
+			return manifest, nil, fmt.Errorf("the checksum of %s doesn't match the manifest", table.Name)
+		}
+		rows := make([]json.RawMessage, 0, table.Rows)
+		for _, line := range bytes.Split(raw, []byte("\n")) {
+			if len(line) == 0 {
+				continue
+			}
+			if !json.Valid(line) {
+				return manifest, nil, fmt.Errorf("%s has a row that isn't json", table.Name)
+			}
+			rows = append(rows, line)
+		}
+		if len(rows) != table.Rows {
+			return manifest, nil, fmt.Errorf("%s has %d rows, the manifest says %d", table.Name, len(rows), table.Rows)
+		}
+		tables = append(tables, TableRows{Name: table.Name, Rows: rows})
+	}
+	return manifest, tables, nil
+}
+
+func readEntry(file *zip.File, budget *int64) ([]byte, error) {
+	entry, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", file.Name, err)
+	}
+	defer entry.Close()
+	raw, err := io.ReadAll(io.LimitReader(entry, *budget+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file.Name, err)
+	}
+	*budget -= int64(len(raw))
+	if *budget < 0 {
+		return nil, errUnpackedTooLarge
+	}
+	return raw, nil
+}
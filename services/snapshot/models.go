@@ -0,0 +1,77 @@
+package snapshotservice
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// formatVersion is bumped when the layout of the archive changes, archives of another version are
+// refused instead of half read
+const formatVersion = 1
+
+// Manifest is manifest.json of an archive, every table is stored as tables/<name>.jsonl with one
+// row per line and the manifest holds its row count and sha256
+type Manifest struct {
+	FormatVersion int       `json:"format_version"`
+	SnapshotID    uuid.UUID `json:"snapshot_id"`
+	CreatedAt     time.Time `json:"created_at"`
+	CreatedBy     uuid.UUID `json:"created_by"`
+	// the last migration applied when the snapshot was taken, restore needs the same one
+	SchemaVersion uint            `json:"schema_version"`
+	Tables        []TableManifest `json:"tables"`
+}
+
+type TableManifest struct {
+	Name   string `json:"name"`
+	Rows   int    `json:"rows"`
+	SHA256 string `json:"sha256"`
+}
+
+// Archive is an exported snapshot, Data is the zip
+type Archive struct {
+	Data     []byte
+	Manifest Manifest
+}
+
+// TableRows are the rows of one table as row_to_json wrote them
+type TableRows struct {
+	Name string
+	Rows []json.RawMessage
+}
+
+// RestoredUser is a user whose cached reads are dropped after a restore, whether the restore added
+// or removed them
+type RestoredUser struct {
+	ID    uuid.UUID `db:"id"`
+	Email string    `db:"email"`
+}
+
+// UploadRes is where the archive to restore is PUT, Key is then passed to the restore
+type UploadRes struct {
+	Key       string            `json:"key"`
+	UploadURL string            `json:"upload_url"`
+	Method    string            `json:"method"`
+	Headers   map[string]string `json:"headers"`
+}
+
+// RestoreReq restores the archive uploaded under Key. Confirm has to repeat the snapshot id of the
+// archive, with DryRun the archive is only checked. The tables a dry run lists as cascaded aren't in
+// the archive and are emptied by the restore, each one has to be named in Discard
+type RestoreReq struct {
+	Key     string   `json:"key" validate:"required"`
+	Confirm string   `json:"confirm"`
+	DryRun  bool     `json:"dry_run"`
+	Discard []string `json:"discard,omitempty"`
+}
+
+type RestoreRes struct {
+	SnapshotID    uuid.UUID       `json:"snapshot_id"`
+	CreatedAt     time.Time       `json:"created_at"`
+	SchemaVersion uint            `json:"schema_version"`
+	DryRun        bool            `json:"dry_run"`
+	Tables        []TableManifest `json:"tables"`
+	// CascadedTables point at the restored tables but aren't in the archive, the restore empties them
+	CascadedTables []string `json:"cascaded_tables"`
+}
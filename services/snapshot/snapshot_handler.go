@@ -0,0 +1,72 @@
+package snapshotservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type SnapshotHandler struct {
+	Service        SnapshotService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewSnapshotHandler(service SnapshotService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *SnapshotHandler {
+	return &SnapshotHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+// CreateUpload signs the url the archive to restore is PUT to
+func (h *SnapshotHandler) CreateUpload(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("CreateSnapshotUpload request received")
+	res, err := h.Service.CreateUpload(r.Context())
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to sign snapshot upload", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to sign snapshot upload")
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, res)
+}
+
+// Restore checks the uploaded archive and, unless dry_run is set, replaces the data with it
+func (h *SnapshotHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("RestoreSnapshot request received")
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in RestoreSnapshot", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	actorID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in RestoreSnapshot", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+	var req RestoreReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in RestoreSnapshot", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in RestoreSnapshot", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	res, err := h.Service.Restore(r.Context(), req, actorID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to restore snapshot", zap.String("key", req.Key), zap.Bool("dryRun", req.DryRun), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to restore snapshot")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, res)
+}
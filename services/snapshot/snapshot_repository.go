@@ -0,0 +1,260 @@
+package snapshotservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type SnapshotRepository interface {
+	// ExportTables reads the tables in order in one read only repeatable read transaction, so every
+	// table is read as of the same moment. It returns the schema version of that moment
+	ExportTables(ctx context.Context, tables []string, w TableWriter) (uint, error)
+	GetSchemaVersion(ctx context.Context) (uint, error)
+	// GetCascadedTables returns the tables outside the given ones that point at them, directly or
+	// through each other. Emptying the tables empties these too
+	GetCascadedTables(ctx context.Context, tables []string) ([]string, error)
+	// RestoreTables empties the tables and the discarded ones and loads the rows in one transaction.
+	// It fails when another table points at them. It returns the users from before and after the restore
+	RestoreTables(ctx context.Context, tables []TableRows, discard []string) ([]RestoredUser, error)
+}
+
+// TableWriter receives the exported rows, every table is begun even when it has none
+type TableWriter interface {
+	BeginTable(name string) error
+	WriteRow(row []byte) error
+}
+
+// restoreBatchSize is how many rows go into one insert
+const restoreBatchSize = 500
+
+type PostgresSnapshotRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewSnapshotRepository(db *sqlx.DB, log providers.ZapLoggerProvider) SnapshotRepository {
+	return &PostgresSnapshotRepository{
+		DB:     db,
+		Logger: log,
+	}
+}
+
+// foreignKey is one column of a foreign key between the restored tables
+type foreignKey struct {
+	Table      string `db:"table_name"`
+	Column     string `db:"column_name"`
+	Referenced string `db:"referenced_table"`
+}
+
+const foreignKeysQuery = `
+	SELECT c.conrelid::regclass::text AS table_name, a.attname AS column_name, c.confrelid::regclass::text AS referenced_table
+	FROM pg_constraint c
+	JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = ANY(c.conkey)
+	WHERE c.contype = 'f' AND c.conrelid::regclass::text = ANY($1)`
+
+func (r *PostgresSnapshotRepository) ExportTables(ctx context.Context, tables []string, w TableWriter) (uint, error) {
+	tx, err := r.DB.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin snapshot transaction: %w", err)
+	}
+	// nothing is written, the transaction only pins what the reads see
+	defer tx.Rollback()
+
+	var version uint
+	if err := tx.GetContext(ctx, &version, `SELECT version FROM schema_migrations LIMIT 1`); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	for _, table := range tables {
+		if err := w.BeginTable(table); err != nil {
+			return 0, err
+		}
+		if err := exportTable(ctx, tx, table, w); err != nil {
+			return 0, err
+		}
+	}
+	return version, nil
+}
+
+func exportTable(ctx context.Context, tx *sqlx.Tx, table string, w TableWriter) error {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`SELECT row_to_json(t)::text FROM %s t ORDER BY t.id`, table))
+	if err != nil {
+		return fmt.Errorf("failed to export %s: %w", table, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var row []byte
+		if err := rows.Scan(&row); err != nil {
+			return fmt.Errorf("failed to scan %s row: %w", table, err)
+		}
+		if err := w.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to export %s: %w", table, err)
+	}
+	return nil
+}
+
+func (r *PostgresSnapshotRepository) GetSchemaVersion(ctx context.Context) (uint, error) {
+	var version uint
+	if err := utils.Conn(ctx, r.DB).GetContext(ctx, &version, `SELECT version FROM schema_migrations LIMIT 1`); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+func (r *PostgresSnapshotRepository) GetCascadedTables(ctx context.Context, tables []string) ([]string, error) {
+	cascaded := make([]string, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &cascaded, `
+		WITH RECURSIVE referencing(name) AS (
+			SELECT c.conrelid::regclass::text FROM pg_constraint c
+			WHERE c.contype = 'f' AND c.confrelid::regclass::text = ANY($1)
+			UNION
+			SELECT c.conrelid::regclass::text FROM pg_constraint c
+			JOIN referencing ref ON c.confrelid::regclass::text = ref.name
+			WHERE c.contype = 'f'
+		)
+		SELECT name FROM referencing WHERE name <> ALL($1) ORDER BY name`, pq.Array(tables))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tables pointing at the snapshot: %w", err)
+	}
+	return cascaded, nil
+}
+
+// RestoreTables loads the tables in the order given with their triggers off, so the rows come back
+// exactly as exported. Columns pointing at their own table or a later one are loaded empty and set
+// once every table is in. The dashboard summaries the triggers would have kept are rebuilt at the end
+func (r *PostgresSnapshotRepository) RestoreTables(ctx context.Context, tables []TableRows, discard []string) ([]RestoredUser, error) {
+	names := make([]string, len(tables))
+	for i, table := range tables {
+		names[i] = table.Name
+	}
+	var users []RestoredUser
+	err := utils.WithTransactionOnce(ctx, r.DB, func(ctx context.Context) error {
+		conn := utils.Conn(ctx, r.DB)
+		if err := conn.SelectContext(ctx, &users, `SELECT id, email FROM users`); err != nil {
+			return fmt.Errorf("failed to read users before restore: %w", err)
+		}
+		var fks []foreignKey
+		if err := conn.SelectContext(ctx, &fks, foreignKeysQuery, pq.Array(names)); err != nil {
+			return fmt.Errorf("failed to read foreign keys: %w", err)
+		}
+		deferred := deferredColumns(names, fks)
+
+		// no cascade, a table pointing in that wasn't named makes this fail instead of being emptied
+		if _, err := conn.ExecContext(ctx, "TRUNCATE "+strings.Join(append(slices.Clone(names), discard...), ", ")+" RESTRICT"); err != nil {
+			return fmt.Errorf("failed to empty tables: %w", err)
+		}
+		for _, name := range names {
+			if _, err := conn.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s DISABLE TRIGGER USER`, name)); err != nil {
+				return fmt.Errorf("failed to disable triggers of %s: %w", name, err)
+			}
+		}
+		for _, table := range tables {
+			query := fmt.Sprintf(`
+				INSERT INTO %[1]s
+				SELECT * FROM jsonb_populate_recordset(NULL::%[1]s, (SELECT jsonb_agg(e - $2::text[]) FROM jsonb_array_elements($1::jsonb) e))`, table.Name)
+			for batch := range slices.Chunk(table.Rows, restoreBatchSize) {
+				if _, err := conn.ExecContext(ctx, query, jsonArray(batch), pq.Array(deferred[table.Name])); err != nil {
+					return fmt.Errorf("failed to restore %s: %w", table.Name, err)
+				}
+			}
+		}
+		for _, table := range tables {
+			columns := deferred[table.Name]
+			if len(columns) == 0 {
+				continue
+			}
+			set := make([]string, len(columns))
+			for i, column := range columns {
+				set[i] = fmt.Sprintf("%[1]s = r.%[1]s", pq.QuoteIdentifier(column))
+			}
+			query := fmt.Sprintf(`
+				UPDATE %[1]s t SET %[2]s
+				FROM jsonb_populate_recordset(NULL::%[1]s, $1::jsonb) r
+				WHERE t.id = r.id`, table.Name, strings.Join(set, ", "))
+			for batch := range slices.Chunk(table.Rows, restoreBatchSize) {
+				if _, err := conn.ExecContext(ctx, query, jsonArray(batch)); err != nil {
+					return fmt.Errorf("failed to restore references of %s: %w", table.Name, err)
+				}
+			}
+		}
+		for _, name := range names {
+			if _, err := conn.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %s ENABLE TRIGGER USER`, name)); err != nil {
+				return fmt.Errorf("failed to enable triggers of %s: %w", name, err)
+			}
+		}
+
+		if _, err := conn.ExecContext(ctx, `SELECT refresh_user_dashboard_summary(id) FROM users`); err != nil {
+			return fmt.Errorf("failed to rebuild dashboard summaries: %w", err)
+		}
+		// new orders are numbered after the restored ones
+		if _, err := conn.ExecContext(ctx, `
+			SELECT setval('purchase_order_number_seq', COALESCE(MAX(substring(number FROM '^PO-(\d+)$')::bigint), 0) + 1, false)
+			FROM purchase_orders`); err != nil {
+			return fmt.Errorf("failed to move purchase order numbers: %w", err)
+		}
+		var restored []RestoredUser
+		if err := conn.SelectContext(ctx, &restored, `SELECT id, email FROM users`); err != nil {
+			return fmt.Errorf("failed to read restored users: %w", err)
+		}
+		users = append(users, restored...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// deferredColumns are the foreign key columns of each table that point at the table itself or at
+// one restored after it, they can only be set once both are loaded. Every table gets a list, a nil
+// one would be passed to the insert as NULL
+func deferredColumns(order []string, fks []foreignKey) map[string][]string {
+	position := make(map[string]int, len(order))
+	deferred := make(map[string][]string, len(order))
+	for i, name := range order {
+		position[name] = i
+		deferred[name] = []string{}
+	}
+	for _, fk := range fks {
+		table, ok := position[fk.Table]
+		if !ok {
+			continue
+		}
+		referenced, ok := position[fk.Referenced]
+		if !ok || referenced < table || slices.Contains(deferred[fk.Table], fk.Column) {
+			continue
+		}
+		deferred[fk.Table] = append(deferred[fk.Table], fk.Column)
+	}
+	for _, columns := range deferred {
+		slices.Sort(columns)
+	}
+	return deferred
+}
+
+// jsonArray joins json rows into one array for jsonb_populate_recordset
+func jsonArray(rows []json.RawMessage) string {
+	var buf bytes.Buffer
+	buf.WriteByte('[')
+	for i, row := range rows {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(row)
+	}
+	buf.WriteByte(']')
+	return buf.String()
+}
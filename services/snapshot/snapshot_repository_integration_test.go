@@ -0,0 +1,150 @@
+//go:build integration
+
+package snapshotservice
+
+import (
+	"asset/database/seed"
+	"asset/database/testdb"
+	"asset/providers"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// collectedTables keeps the exported rows in memory the way a restore reads them back
+type collectedTables struct {
+	tables []TableRows
+}
+
+func (c *collectedTables) BeginTable(name string) error {
+	c.tables = append(c.tables, TableRows{Name: name, Rows: []json.RawMessage{}})
+	return nil
+}
+
+func (c *collectedTables) WriteRow(row []byte) error {
+	last := &c.tables[len(c.tables)-1]
+	last.Rows = append(last.Rows, row)
+	return nil
+}
+
+func TestExportAndRestoreTables(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewSnapshotRepository(db, logger)
+	ctx := context.Background()
+
+	var exported collectedTables
+	version, err := repo.ExportTables(ctx, snapshotTables, &exported)
+	require.NoError(t, err)
+	current, err := repo.GetSchemaVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, current, version)
+	require.Len(t, exported.tables, len(snapshotTables))
+
+	counts := func() map[string]int {
+		result := make(map[string]int, len(snapshotTables))
+		for _, table := range snapshotTables {
+			var count int
+			require.NoError(t, db.Get(&count, `SELECT count(*) FROM `+table))
+			result[table] = count
+		}
+		return result
+	}
+	before := counts()
+	for _, table := range exported.tables {
+		assert.Len(t, table.Rows, before[table.Name], table.Name)
+	}
+	var referenced int
+	require.NoError(t, db.Get(&referenced, `
+		SELECT (SELECT count(*) FROM users WHERE created_by IS NOT NULL) + (SELECT count(*) FROM departments WHERE created_by IS NOT NULL)`))
+
+	// changes made after the snapshot are gone after the restore
+	_, err = db.Exec(`INSERT INTO users (username, email) VALUES ('After Snapshot', 'after.snapshot@remotestate.com')`)
+	require.NoError(t, err)
+	_, err = db.Exec(`UPDATE users SET username = 'Renamed' WHERE id = $1`, seed.ID("user:intern"))
+	require.NoError(t, err)
+
+	cascaded, err := repo.GetCascadedTables(ctx, snapshotTables)
+	require.NoError(t, err)
+	users, err := repo.RestoreTables(ctx, exported.tables, cascaded)
+	require.NoError(t, err)
+	emails := make([]string, len(users))
+	for i, user := range users {
+		emails[i] = user.Email
+	}
+	assert.Contains(t, emails, "after.snapshot@remotestate.com")
+
+	assert.Equal(t, before, counts())
+	var username string
+	require.NoError(t, db.Get(&username, `SELECT username FROM users WHERE id = $1`, seed.ID("user:intern")))
+	assert.Equal(t, "Ishan Verma", username)
+	var restoredReferences int
+	require.NoError(t, db.Get(&restoredReferences, `
+		SELECT (SELECT count(*) FROM users WHERE created_by IS NOT NULL) + (SELECT count(*) FROM departments WHERE created_by IS NOT NULL)`))
+	assert.Equal(t, referenced, restoredReferences)
+
+	// the summaries the triggers keep are rebuilt for every restored user
+	var summaries int
+	require.NoError(t, db.Get(&summaries, `SELECT count(*) FROM user_dashboard_summary`))
+	assert.Equal(t, before["users"], summaries)
+}
+
+func TestRestoreTablesNamesWhatItEmpties(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewSnapshotRepository(db, logger)
+	ctx := context.Background()
+
+	var exported collectedTables
+	_, err := repo.ExportTables(ctx, snapshotTables, &exported)
+	require.NoError(t, err)
+
+	// rows outside the snapshot that point at users
+	_, err = db.Exec(`
+		INSERT INTO role_delegations (delegator_id, delegate_id, role, starts_at, ends_at)
+		VALUES ($1, $2, 'employee', now(), now() + interval '1 day')`, seed.ID("user:admin"), seed.ID("user:intern"))
+	require.NoError(t, err)
+	_, err = db.Exec(`
+		INSERT INTO scheduled_role_changes (user_id, role, effective_from, created_by)
+		VALUES ($1, 'employee', now() + interval '1 day', $2)`, seed.ID("user:intern"), seed.ID("user:admin"))
+	require.NoError(t, err)
+
+	cascaded, err := repo.GetCascadedTables(ctx, snapshotTables)
+	require.NoError(t, err)
+	assert.Contains(t, cascaded, "role_delegations")
+	assert.Contains(t, cascaded, "scheduled_role_changes")
+	for _, table := range snapshotTables {
+		assert.NotContains(t, cascaded, table)
+	}
+
+	count := func(table string) int {
+		var n int
+		require.NoError(t, db.Get(&n, `SELECT count(*) FROM `+table))
+		return n
+	}
+
+	// leaving a table out fails the whole restore and keeps its rows
+	delegations, scheduled := count("role_delegations"), count("scheduled_role_changes")
+	_, err = repo.RestoreTables(ctx, exported.tables, nil)
+	require.Error(t, err)
+	assert.Equal(t, delegations, count("role_delegations"))
+	assert.Equal(t, scheduled, count("scheduled_role_changes"))
+
+	_, err = repo.RestoreTables(ctx, exported.tables, cascaded)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count("role_delegations"))
+	assert.Equal(t, 0, count("scheduled_role_changes"))
+}
@@ -0,0 +1,297 @@
+package snapshotservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	cacheprovider "asset/providers/cacheProvider"
+	"asset/services/audit"
+	"asset/services/department"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// SnapshotService exports the whole database, every organization in it, into one archive and restores
+// such an archive over the whole database, for recovery drills and for copying an environment. Secrets
+// like mfa seeds, api keys, identities and sessions are never exported, a restore leaves them empty
+type SnapshotService interface {
+	// Export is run by the snapshot_export bulk job
+	Export(ctx context.Context, createdBy uuid.UUID) (Archive, error)
+	// CreateUpload signs the url an archive is uploaded to before it is restored
+	CreateUpload(ctx context.Context) (UploadRes, error)
+	// Restore replaces every exported table with the rows of the uploaded archive, once it is checked
+	// against its manifest and the schema of the database. Tables outside the archive that point at
+	// it are emptied, so the caller has to name each of them
+	Restore(ctx context.Context, req RestoreReq, actorID uuid.UUID) (RestoreRes, error)
+}
+
+var (
+	ErrRestoreOff         = models.NewServiceError(http.StatusNotFound, "snapshot_restore_off", "restoring snapshots is not enabled on this server")
+	ErrSnapshotStorageOff = models.NewServiceError(http.StatusNotFound, "snapshot_storage_off", "object storage for snapshot archives is not configured")
+	ErrInvalidSnapshotKey = models.NewServiceError(http.StatusBadRequest, "invalid_snapshot_key", "the key isn't a snapshot upload")
+	ErrSnapshotNotFound   = models.NewServiceError(http.StatusNotFound, "snapshot_not_found", "no archive was uploaded under the key")
+	ErrSnapshotTooLarge   = models.NewServiceError(http.StatusRequestEntityTooLarge, "snapshot_too_large", "the archive is over the size limit")
+	ErrInvalidSnapshot    = models.NewServiceError(http.StatusBadRequest, "invalid_snapshot", "the archive is not a valid snapshot")
+	ErrSchemaMismatch     = models.NewServiceError(http.StatusConflict, "schema_mismatch", "the snapshot was taken at another schema version")
+	ErrConfirmMismatch    = models.NewServiceError(http.StatusBadRequest, "confirm_mismatch", "confirm must be the snapshot id of the archive")
+	ErrDiscardMissing     = models.NewServiceError(http.StatusConflict, "discard_missing", "the restore empties tables that aren't in the archive, name each of them in discard")
+)
+
+const (
+	uploadPrefix       = "snapshots/uploads/"
+	archiveContentType = "application/zip"
+	// rows compress well, an archive may unpack to this many times its size before it is refused
+	unpackedFactor = 20
+)
+
+// snapshotTables are exported and restored in this order, a table comes after the tables its
+// foreign keys point at wherever the references aren't circular
+var snapshotTables = []string{
+	"organizations",
+	"departments",
+	"users",
+	"roles",
+	"role_permissions",
+	"user_type",
+	"user_roles",
+	"purchase_orders",
+	"purchase_order_lines",
+	"assets",
+	"laptop_config",
+	"mouse_config",
+	"monitor_config",
+	"hard_disk_config",
+	"pendrive_config",
+	"mobile_config",
+	"sim_config",
+	"accessories_config",
+	"asset_assign",
+	"asset_assign_history",
+	"asset_service",
+	"asset_service_history",
+	"asset_return_requests",
+	"audit_logs",
+}
+
+type snapshotServiceStruct struct {
+	repo SnapshotRepository
+	// department counts are refreshed after a restore
+	departments departmentservice.DepartmentService
+	cache       providers.UserCacheProvider
+	responses   providers.ResponseCacheProvider
+	audit       auditservice.AuditService
+	// archives to restore are uploaded to the bucket, nil disables restore
+	storage providers.StorageProvider
+	logger  providers.ZapLoggerProvider
+	config  models.SnapshotConfig
+}
+
+func NewSnapshotService(repo SnapshotRepository, departments departmentservice.DepartmentService, cache providers.UserCacheProvider, responses providers.ResponseCacheProvider, audit auditservice.AuditService, storage providers.StorageProvider, logger providers.ZapLoggerProvider, config models.SnapshotConfig) SnapshotService {
+	return &snapshotServiceStruct{
+		repo:        repo,
+		departments: departments,
+		cache:       cache,
+		responses:   responses,
+		audit:       audit,
+		storage:     storage,
+		logger:      logger,
+		config:      config,
+	}
+}
+
+func (s *snapshotServiceStruct) Export(ctx context.Context, createdBy uuid.UUID) (Archive, error) {
+	manifest := Manifest{
+		FormatVersion: formatVersion,
+		SnapshotID:    uuid.New(),
+		CreatedAt:     time.Now().UTC(),
+		CreatedBy:     createdBy,
+	}
+	var buf bytes.Buffer
+	writer := newArchiveWriter(&buf, &manifest)
+	version, err := s.repo.ExportTables(ctx, snapshotTables, writer)
+	if err != nil {
+		return Archive{}, err
+	}
+	manifest.SchemaVersion = version
+	if err := writer.close(); err != nil {
+		return Archive{}, err
+	}
+
+	if err := s.audit.Record(ctx, auditservice.AuditEntry{
+		ActorID:    &createdBy,
+		Action:     "snapshot.exported",
+		EntityType: "snapshot",
+		EntityID:   manifest.SnapshotID.String(),
+		NewValue:   map[string]interface{}{"schema_version": manifest.SchemaVersion, "rows": rowCounts(manifest.Tables)},
+	}); err != nil {
+		s.logger.GetLogger().Warn("failed to audit snapshot export", zap.String("snapshotID", manifest.SnapshotID.String()), zap.Error(err))
+	}
+	return Archive{Data: buf.Bytes(), Manifest: manifest}, nil
+}
+
+func (s *snapshotServiceStruct) CreateUpload(ctx context.Context) (UploadRes, error) {
+	if err := s.checkRestore(); err != nil {
+		return UploadRes{}, err
+	}
+	key := uploadPrefix + uuid.New().String() + ".zip"
+	uploadURL, err := s.storage.PresignUpload(ctx, key, archiveContentType)
+	if err != nil {
+		return UploadRes{}, fmt.Errorf("failed to sign snapshot upload: %w", err)
+	}
+	return UploadRes{
+		Key:       key,
+		UploadURL: uploadURL,
+		Method:    http.MethodPut,
+		Headers:   map[string]string{"Content-Type": archiveContentType},
+	}, nil
+}
+
+// Restore checks the whole archive before anything is replaced, a dry run stops there. The caches
+// of every user from before and after are dropped once the restore committed
+func (s *snapshotServiceStruct) Restore(ctx context.Context, req RestoreReq, actorID uuid.UUID) (RestoreRes, error) {
+	if err := s.checkRestore(); err != nil {
+		return RestoreRes{}, err
+	}
+	if !strings.HasPrefix(req.Key, uploadPrefix) || strings.Contains(req.Key, "..") {
+		return RestoreRes{}, ErrInvalidSnapshotKey
+	}
+	data, err := s.readUpload(ctx, req.Key)
+	if err != nil {
+		return RestoreRes{}, err
+	}
+	manifest, tables, err := readArchive(data, s.config.MaxArchiveBytes*unpackedFactor)
+	if err != nil {
+		return RestoreRes{}, ErrInvalidSnapshot.WithDetails(map[string]string{"reason": err.Error()})
+	}
+	if manifest.FormatVersion != formatVersion {
+		return RestoreRes{}, ErrInvalidSnapshot.WithDetails(map[string]string{"reason": fmt.Sprintf("format version %d isn't supported", manifest.FormatVersion)})
+	}
+	names := make([]string, len(tables))
+	for i, table := range tables {
+		names[i] = table.Name
+	}
+	if !slices.Equal(names, snapshotTables) {
+		return RestoreRes{}, ErrInvalidSnapshot.WithDetails(map[string]string{"reason": "the archive doesn't hold the tables a snapshot is made of"})
+	}
+	version, err := s.repo.GetSchemaVersion(ctx)
+	if err != nil {
+		return RestoreRes{}, err
+	}
+	if version != manifest.SchemaVersion {
+		return RestoreRes{}, ErrSchemaMismatch.WithDetails(map[string]uint{"snapshot": manifest.SchemaVersion, "database": version})
+	}
+
+	cascaded, err := s.repo.GetCascadedTables(ctx, snapshotTables)
+	if err != nil {
+		return RestoreRes{}, err
+	}
+
+	res := RestoreRes{
+		SnapshotID:     manifest.SnapshotID,
+		CreatedAt:      manifest.CreatedAt,
+		SchemaVersion:  manifest.SchemaVersion,
+		DryRun:         req.DryRun,
+		Tables:         manifest.Tables,
+		CascadedTables: cascaded,
+	}
+	if req.DryRun {
+		return res, nil
+	}
+	if req.Confirm != manifest.SnapshotID.String() {
+		return RestoreRes{}, ErrConfirmMismatch
+	}
+	// the rows of these tables are lost, nobody should find that out after the fact
+	missing := make([]string, 0)
+	for _, table := range cascaded {
+		if !slices.Contains(req.Discard, table) {
+			missing = append(missing, table)
+		}
+	}
+	if len(missing) > 0 {
+		return RestoreRes{}, ErrDiscardMissing.WithDetails(map[string][]string{"tables": missing})
+	}
+
+	// only the tables read from the catalog reach the truncate, never the names from the request
+	users, err := s.repo.RestoreTables(ctx, tables, cascaded)
+	if err != nil {
+		return RestoreRes{}, err
+	}
+	ids := make([]uuid.UUID, len(users))
+	emails := make([]string, len(users))
+	for i, user := range users {
+		ids[i], emails[i] = user.ID, user.Email
+	}
+	s.cache.InvalidateUsers(ctx, ids...)
+	s.cache.InvalidateEmails(ctx, emails...)
+	s.responses.Invalidate(ctx, cacheprovider.ResponseEnums, cacheprovider.ResponseAssetTypes, cacheprovider.ResponseDirectory)
+	if err := s.departments.RefreshStats(ctx); err != nil {
+		s.logger.GetLogger().Warn("failed to refresh department stats after snapshot restore", zap.Error(err))
+	}
+
+	// the restored data may not hold whoever restored it, so they are only named in the entry
+	if err := s.audit.Record(ctx, auditservice.AuditEntry{
+		Action:     "snapshot.restored",
+		EntityType: "snapshot",
+		EntityID:   manifest.SnapshotID.String(),
+		NewValue:   map[string]interface{}{"restored_by": actorID, "key": req.Key, "schema_version": manifest.SchemaVersion, "rows": rowCounts(manifest.Tables), "discarded": cascaded},
+	}); err != nil {
+		s.logger.GetLogger().Warn("failed to audit snapshot restore", zap.String("snapshotID", manifest.SnapshotID.String()), zap.Error(err))
+	}
+	s.logger.GetLogger().Info("snapshot restored", zap.String("snapshotID", manifest.SnapshotID.String()), zap.String("restoredBy", actorID.String()))
+	return res, nil
+}
+
+func (s *snapshotServiceStruct) checkRestore() error {
+	if !s.config.RestoreEnabled {
+		return ErrRestoreOff
+	}
+	if s.storage == nil {
+		return ErrSnapshotStorageOff
+	}
+	return nil
+}
+
+// readUpload reads the uploaded archive, its size is checked before and while reading since a
+// presigned put can't be capped
+func (s *snapshotServiceStruct) readUpload(ctx context.Context, key string) ([]byte, error) {
+	size, err := s.storage.Stat(ctx, key)
+	if errors.Is(err, models.ErrObjectNotFound) {
+		return nil, ErrSnapshotNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check snapshot upload: %w", err)
+	}
+	if size > s.config.MaxArchiveBytes {
+		return nil, ErrSnapshotTooLarge
+	}
+	body, err := s.storage.Open(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open snapshot upload: %w", err)
+	}
+	defer body.Close()
+	data, err := io.ReadAll(io.LimitReader(body, s.config.MaxArchiveBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot upload: %w", err)
+	}
+	if int64(len(data)) > s.config.MaxArchiveBytes {
+		return nil, ErrSnapshotTooLarge
+	}
+	return data, nil
+}
+
+func rowCounts(tables []TableManifest) map[string]int {
+	counts := make(map[string]int, len(tables))
+	for _, table := range tables {
+		counts[table.Name] = table.Rows
+	}
+	return counts
+}
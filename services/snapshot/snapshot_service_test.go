@@ -0,0 +1,280 @@
+package snapshotservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	cacheprovider "asset/providers/cacheProvider"
+	"asset/services/audit"
+	"asset/services/department"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const testKey = uploadPrefix + "archive.zip"
+
+type snapshotMocks struct {
+	repo        *MockSnapshotRepository
+	departments *departmentservice.MockDepartmentService
+	cache       *providers.MockUserCacheProvider
+	responses   *providers.MockResponseCacheProvider
+	audit       *auditservice.MockAuditService
+	storage     *providers.MockStorageProvider
+	service     SnapshotService
+}
+
+func newSnapshotMocks(t *testing.T, config models.SnapshotConfig) snapshotMocks {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+	m := snapshotMocks{
+		repo:        NewMockSnapshotRepository(ctrl),
+		departments: departmentservice.NewMockDepartmentService(ctrl),
+		cache:       providers.NewMockUserCacheProvider(ctrl),
+		responses:   providers.NewMockResponseCacheProvider(ctrl),
+		audit:       auditservice.NewMockAuditService(ctrl),
+		storage:     providers.NewMockStorageProvider(ctrl),
+	}
+	m.service = NewSnapshotService(m.repo, m.departments, m.cache, m.responses, m.audit, m.storage, logger, config)
+	return m
+}
+
+var restoreConfig = models.SnapshotConfig{RestoreEnabled: true, MaxArchiveBytes: 1 << 20}
+
+// exportRows stands in for the database, every table has one row except users with two
+func exportRows(_ context.Context, tables []string, w TableWriter) (uint, error) {
+	for _, table := range tables {
+		if err := w.BeginTable(table); err != nil {
+			return 0, err
+		}
+		if err := w.WriteRow([]byte(`{"id":"` + uuid.NewString() + `","table":"` + table + `"}`)); err != nil {
+			return 0, err
+		}
+		if table == "users" {
+			if err := w.WriteRow([]byte(`{"id":"` + uuid.NewString() + `","email":"admin@example.com"}`)); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return 84, nil
+}
+
+func exportArchive(t *testing.T) Archive {
+	m := newSnapshotMocks(t, restoreConfig)
+	m.repo.EXPECT().ExportTables(gomock.Any(), snapshotTables, gomock.Any()).DoAndReturn(exportRows)
+	m.audit.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil)
+	archive, err := m.service.Export(context.Background(), uuid.New())
+	require.NoError(t, err)
+	return archive
+}
+
+// expectUpload serves data as the archive uploaded under testKey
+func expectUpload(m snapshotMocks, data []byte) {
+	m.storage.EXPECT().Stat(gomock.Any(), testKey).Return(int64(len(data)), nil)
+	m.storage.EXPECT().Open(gomock.Any(), testKey).Return(io.NopCloser(bytes.NewReader(data)), nil)
+}
+
+func TestExportWritesManifestAndTables(t *testing.T) {
+	m := newSnapshotMocks(t, restoreConfig)
+	createdBy := uuid.New()
+	m.repo.EXPECT().ExportTables(gomock.Any(), snapshotTables, gomock.Any()).DoAndReturn(exportRows)
+	m.audit.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, entry auditservice.AuditEntry) error {
+		assert.Equal(t, "snapshot.exported", entry.Action)
+		assert.Equal(t, &createdBy, entry.ActorID)
+		return nil
+	})
+
+	archive, err := m.service.Export(context.Background(), createdBy)
+	require.NoError(t, err)
+	assert.Equal(t, uint(84), archive.Manifest.SchemaVersion)
+	assert.Equal(t, createdBy, archive.Manifest.CreatedBy)
+	require.Len(t, archive.Manifest.Tables, len(snapshotTables))
+
+	manifest, tables, err := readArchive(archive.Data, 1<<20)
+	require.NoError(t, err)
+	assert.Equal(t, archive.Manifest.SnapshotID, manifest.SnapshotID)
+	require.Len(t, tables, len(snapshotTables))
+	for i, table := range tables {
+		assert.Equal(t, snapshotTables[i], table.Name)
+		assert.Equal(t, manifest.Tables[i].Rows, len(table.Rows))
+	}
+	require.Len(t, tables[2].Rows, 2)
+	var user struct {
+		Email string `json:"email"`
+	}
+	require.NoError(t, json.Unmarshal(tables[2].Rows[1], &user))
+	assert.Equal(t, "admin@example.com", user.Email)
+}
+
+func TestReadArchiveRejectsTamperedAndOversized(t *testing.T) {
+	manifest := Manifest{FormatVersion: formatVersion, SnapshotID: uuid.New(), CreatedAt: time.Now()}
+	var buf bytes.Buffer
+	writer := newArchiveWriter(&buf, &manifest)
+	require.NoError(t, writer.BeginTable("users"))
+	require.NoError(t, writer.WriteRow([]byte(`{"id":1}`)))
+	require.NoError(t, writer.close())
+
+	_, _, err := readArchive(buf.Bytes(), 1<<20)
+	require.NoError(t, err)
+	_, _, err = readArchive(buf.Bytes(), 16)
+	assert.ErrorIs(t, err, errUnpackedTooLarge)
+	_, _, err = readArchive([]byte("not a zip"), 1<<20)
+	assert.Error(t, err)
+
+	// a manifest counting more rows than the archive holds
+	tampered := Manifest{FormatVersion: formatVersion, SnapshotID: uuid.New()}
+	var other bytes.Buffer
+	writer = newArchiveWriter(&other, &tampered)
+	require.NoError(t, writer.BeginTable("users"))
+	require.NoError(t, writer.WriteRow([]byte(`{"id":1}`)))
+	writer.endTable()
+	tampered.Tables[0].Rows = 2
+	writer.current = nil
+	require.NoError(t, writer.close())
+	_, _, err = readArchive(other.Bytes(), 1<<20)
+	assert.ErrorContains(t, err, "the manifest says 2")
+}
+
+func TestRestoreOffWithoutFlag(t *testing.T) {
+	m := newSnapshotMocks(t, models.SnapshotConfig{MaxArchiveBytes: 1 << 20})
+	_, err := m.service.Restore(context.Background(), RestoreReq{Key: testKey}, uuid.New())
+	assert.ErrorIs(t, err, ErrRestoreOff)
+	_, err = m.service.CreateUpload(context.Background())
+	assert.ErrorIs(t, err, ErrRestoreOff)
+}
+
+func TestRestoreRejectsKeysOutsideUploads(t *testing.T) {
+	m := newSnapshotMocks(t, restoreConfig)
+	for _, key := range []string{"attachments/a/b/c.pdf", uploadPrefix + "../jobs/x/result.zip"} {
+		_, err := m.service.Restore(context.Background(), RestoreReq{Key: key}, uuid.New())
+		assert.ErrorIs(t, err, ErrInvalidSnapshotKey, key)
+	}
+}
+
+func TestRestoreRejectsOtherSchemaVersion(t *testing.T) {
+	archive := exportArchive(t)
+	m := newSnapshotMocks(t, restoreConfig)
+	expectUpload(m, archive.Data)
+	m.repo.EXPECT().GetSchemaVersion(gomock.Any()).Return(uint(85), nil)
+
+	_, err := m.service.Restore(context.Background(), RestoreReq{Key: testKey, Confirm: archive.Manifest.SnapshotID.String()}, uuid.New())
+	var serviceErr *models.ServiceError
+	require.True(t, errors.As(err, &serviceErr))
+	assert.Equal(t, ErrSchemaMismatch.Code, serviceErr.Code)
+	assert.Equal(t, map[string]uint{"snapshot": 84, "database": 85}, serviceErr.Details)
+}
+
+// cascadedTables stands in for the tables outside the snapshot that point at it
+var cascadedTables = []string{"employee_charges", "role_delegations"}
+
+func TestRestoreDryRunOnlyChecks(t *testing.T) {
+	archive := exportArchive(t)
+	m := newSnapshotMocks(t, restoreConfig)
+	expectUpload(m, archive.Data)
+	m.repo.EXPECT().GetSchemaVersion(gomock.Any()).Return(uint(84), nil)
+	m.repo.EXPECT().GetCascadedTables(gomock.Any(), snapshotTables).Return(cascadedTables, nil)
+
+	res, err := m.service.Restore(context.Background(), RestoreReq{Key: testKey, DryRun: true}, uuid.New())
+	require.NoError(t, err)
+	assert.True(t, res.DryRun)
+	assert.Equal(t, archive.Manifest.SnapshotID, res.SnapshotID)
+	assert.Len(t, res.Tables, len(snapshotTables))
+	assert.Equal(t, cascadedTables, res.CascadedTables)
+}
+
+func TestRestoreNeedsConfirmation(t *testing.T) {
+	archive := exportArchive(t)
+	m := newSnapshotMocks(t, restoreConfig)
+	expectUpload(m, archive.Data)
+	m.repo.EXPECT().GetSchemaVersion(gomock.Any()).Return(uint(84), nil)
+	m.repo.EXPECT().GetCascadedTables(gomock.Any(), snapshotTables).Return(cascadedTables, nil)
+
+	_, err := m.service.Restore(context.Background(), RestoreReq{Key: testKey, Confirm: uuid.NewString()}, uuid.New())
+	assert.ErrorIs(t, err, ErrConfirmMismatch)
+}
+
+func TestRestoreRefusesUnnamedCascadedTables(t *testing.T) {
+	archive := exportArchive(t)
+	m := newSnapshotMocks(t, restoreConfig)
+	expectUpload(m, archive.Data)
+	m.repo.EXPECT().GetSchemaVersion(gomock.Any()).Return(uint(84), nil)
+	m.repo.EXPECT().GetCascadedTables(gomock.Any(), snapshotTables).Return(cascadedTables, nil)
+
+	req := RestoreReq{Key: testKey, Confirm: archive.Manifest.SnapshotID.String(), Discard: []string{"employee_charges", "users; DROP TABLE assets"}}
+	_, err := m.service.Restore(context.Background(), req, uuid.New())
+	var serviceErr *models.ServiceError
+	require.True(t, errors.As(err, &serviceErr))
+	assert.Equal(t, ErrDiscardMissing.Code, serviceErr.Code)
+	assert.Equal(t, map[string][]string{"tables": {"role_delegations"}}, serviceErr.Details)
+}
+
+func TestRestoreReplacesDataAndDropsCaches(t *testing.T) {
+	archive := exportArchive(t)
+	m := newSnapshotMocks(t, restoreConfig)
+	actorID := uuid.New()
+	before := RestoredUser{ID: uuid.New(), Email: "gone@example.com"}
+	after := RestoredUser{ID: uuid.New(), Email: "admin@example.com"}
+	expectUpload(m, archive.Data)
+	m.repo.EXPECT().GetSchemaVersion(gomock.Any()).Return(uint(84), nil)
+	m.repo.EXPECT().GetCascadedTables(gomock.Any(), snapshotTables).Return(cascadedTables, nil)
+	// the discarded tables come from the catalog, the extra name in the request never reaches the truncate
+	m.repo.EXPECT().RestoreTables(gomock.Any(), gomock.Any(), cascadedTables).DoAndReturn(func(_ context.Context, tables []TableRows, _ []string) ([]RestoredUser, error) {
+		require.Len(t, tables, len(snapshotTables))
+		assert.Equal(t, "users", tables[2].Name)
+		assert.Len(t, tables[2].Rows, 2)
+		return []RestoredUser{before, after}, nil
+	})
+	m.cache.EXPECT().InvalidateUsers(gomock.Any(), before.ID, after.ID)
+	m.cache.EXPECT().InvalidateEmails(gomock.Any(), before.Email, after.Email)
+	m.responses.EXPECT().Invalidate(gomock.Any(), cacheprovider.ResponseEnums, cacheprovider.ResponseAssetTypes, cacheprovider.ResponseDirectory)
+	m.departments.EXPECT().RefreshStats(gomock.Any()).Return(nil)
+	m.audit.EXPECT().Record(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, entry auditservice.AuditEntry) error {
+		assert.Equal(t, "snapshot.restored", entry.Action)
+		assert.Nil(t, entry.ActorID)
+		assert.Equal(t, archive.Manifest.SnapshotID.String(), entry.EntityID)
+		assert.Equal(t, cascadedTables, entry.NewValue.(map[string]interface{})["discarded"])
+		return nil
+	})
+
+	req := RestoreReq{Key: testKey, Confirm: archive.Manifest.SnapshotID.String(), Discard: append([]string{"audit_logs"}, cascadedTables...)}
+	res, err := m.service.Restore(context.Background(), req, actorID)
+	require.NoError(t, err)
+	assert.False(t, res.DryRun)
+	assert.Equal(t, uint(84), res.SchemaVersion)
+}
+
+func TestRestoreRefusesOversizedUpload(t *testing.T) {
+	m := newSnapshotMocks(t, restoreConfig)
+	m.storage.EXPECT().Stat(gomock.Any(), testKey).Return(int64(2<<20), nil)
+	_, err := m.service.Restore(context.Background(), RestoreReq{Key: testKey}, uuid.New())
+	assert.ErrorIs(t, err, ErrSnapshotTooLarge)
+}
+
+func TestDeferredColumns(t *testing.T) {
+	order := []string{"departments", "users", "assets"}
+	fks := []foreignKey{
+		{Table: "departments", Column: "created_by", Referenced: "users"},
+		{Table: "users", Column: "department_id", Referenced: "departments"},
+		{Table: "users", Column: "created_by", Referenced: "users"},
+		{Table: "users", Column: "archived_by", Referenced: "users"},
+		{Table: "assets", Column: "added_by", Referenced: "users"},
+		{Table: "assets", Column: "merged_into", Referenced: "assets"},
+		{Table: "role_permissions", Column: "permission", Referenced: "permissions"},
+	}
+	assert.Equal(t, map[string][]string{
+		"departments": {"created_by"},
+		"users":       {"archived_by", "created_by"},
+		"assets":      {"merged_into"},
+	}, deferredColumns(order, fks))
+}
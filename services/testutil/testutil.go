@@ -0,0 +1,41 @@
+// Package testutil has the setup the service unit tests share, each package's newTestService only
+// adds the mocks of its own dependencies
+package testutil
+
+import (
+	"asset/providers"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang/mock/gomock"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// Controller returns a mock controller whose expectations are checked when the test ends
+func Controller(t *testing.T) *gomock.Controller {
+	t.Helper()
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+	return ctrl
+}
+
+// Logger returns a logger provider that discards everything, services log on most paths so it
+// takes any number of calls
+func Logger(ctrl *gomock.Controller) *providers.MockZapLoggerProvider {
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+	return logger
+}
+
+// DB returns a database for services that open their own transactions, the queries and the
+// begin and commit are expected on the returned mock. It is closed when the test ends
+func DB(t *testing.T) (*sqlx.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return sqlx.NewDb(db, "sqlmock"), mock
+}
@@ -0,0 +1,46 @@
+package trashservice
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TrashAssets      = "assets"
+	TrashUsers       = "users"
+	TrashRoles       = "roles"
+	TrashAssignments = "assignments"
+)
+
+// TrashFilter narrows one kind of archived record, From and To bound when it was archived
+type TrashFilter struct {
+	Kind           string
+	Search         string
+	ArchivedBy     *uuid.UUID
+	From           *time.Time
+	To             *time.Time
+	OrganizationID *uuid.UUID
+	Limit          int
+	Offset         int
+}
+
+// TrashItem is one archived record. Name and Details are what identifies it for its kind, serial
+// number for assets, email for users, description for roles and the asset and employee for
+// assignments. ArchivedBy is empty for records archived by a scheduled job or before it was kept
+type TrashItem struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	Name           string     `json:"name" db:"name"`
+	Details        string     `json:"details" db:"details"`
+	ArchivedAt     time.Time  `json:"archived_at" db:"archived_at"`
+	ArchivedBy     *uuid.UUID `json:"archived_by,omitempty" db:"archived_by"`
+	ArchivedByName *string    `json:"archived_by_name,omitempty" db:"archived_by_name"`
+}
+
+type TrashRes struct {
+	Kind   string      `json:"kind"`
+	Items  []TrashItem `json:"items"`
+	Total  int         `json:"total"`
+	Limit  int         `json:"limit"`
+	Offset int         `json:"offset"`
+}
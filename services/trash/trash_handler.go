@@ -0,0 +1,70 @@
+package trashservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type TrashHandler struct {
+	Service        TrashService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewTrashHandler(service TrashService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *TrashHandler {
+	return &TrashHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+// GetTrash lists archived records of the kind in the path, search matches the name and details,
+// archived_by is a user id and from and to are RFC 3339 times
+func (h *TrashHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetTrash request received")
+	query := r.URL.Query()
+	filter := TrashFilter{Kind: chi.URLParam(r, "kind"), Search: query.Get("search")}
+	if val := query.Get("archived_by"); val != "" {
+		archivedBy, err := uuid.Parse(val)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, "invalid archived_by")
+			return
+		}
+		filter.ArchivedBy = &archivedBy
+	}
+	for param, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, param+" must be an RFC 3339 time")
+			return
+		}
+		*target = &parsed
+	}
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve scope in GetTrash", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve organization")
+		return
+	}
+	filter.OrganizationID = scope.OrganizationID
+
+	res, err := h.Service.ListTrash(r.Context(), filter)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to list trash", zap.String("kind", filter.Kind), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to list archived records")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, res)
+}
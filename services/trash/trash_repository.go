@@ -0,0 +1,87 @@
+package trashservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+type TrashRepository interface {
+	// ListArchived returns a page of archived records of filter.Kind newest first with how many
+	// match in all
+	ListArchived(ctx context.Context, filter TrashFilter) ([]TrashItem, int, error)
+}
+
+type PostgresTrashRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewTrashRepository(db *sqlx.DB, log providers.ZapLoggerProvider) TrashRepository {
+	return &PostgresTrashRepository{
+		DB:     db,
+		Logger: log,
+	}
+}
+
+// trashSources select the archived rows of each kind as id, name, details, archived_at, archived_by
+// and organization_id, roles are shared by every organization
+var trashSources = map[string]string{
+	TrashAssets: `
+		SELECT a.id, a.brand || ' ' || a.model AS name, a.serial_no AS details, a.archived_at, a.archived_by, a.organization_id
+		FROM assets a WHERE a.archived_at IS NOT NULL`,
+	TrashUsers: `
+		SELECT u.id, u.username AS name, u.email AS details, u.archived_at, u.archived_by, u.organization_id
+		FROM users u WHERE u.archived_at IS NOT NULL`,
+	TrashRoles: `
+		SELECT ro.id, ro.name, COALESCE(ro.description, '') AS details, ro.archived_at, ro.archived_by, NULL::uuid AS organization_id
+		FROM roles ro WHERE ro.archived_at IS NOT NULL`,
+	TrashAssignments: `
+		SELECT aa.id, a.serial_no AS name, e.username AS details, aa.archived_at::timestamptz AS archived_at, aa.archived_by, aa.organization_id
+		FROM asset_assign aa
+		JOIN assets a ON a.id = aa.asset_id
+		JOIN users e ON e.id = aa.employee_id
+		WHERE aa.archived_at IS NOT NULL`,
+}
+
+const trashQuery = `
+	WITH trash AS (%s)
+	SELECT t.id, t.name, t.details, t.archived_at, t.archived_by, archiver.username AS archived_by_name,
+		count(*) OVER () AS total
+	FROM trash t
+	LEFT JOIN users archiver ON archiver.id = t.archived_by
+	WHERE ($1 = '' OR t.name ILIKE '%%' || $1 || '%%' OR t.details ILIKE '%%' || $1 || '%%')
+	AND ($2::uuid IS NULL OR t.archived_by = $2)
+	AND ($3::timestamptz IS NULL OR t.archived_at >= $3)
+	AND ($4::timestamptz IS NULL OR t.archived_at < $4)
+	AND ($5::uuid IS NULL OR t.organization_id IS NULL OR t.organization_id = $5)
+	ORDER BY t.archived_at DESC, t.id
+	LIMIT $6 OFFSET $7`
+
+func (r *PostgresTrashRepository) ListArchived(ctx context.Context, filter TrashFilter) ([]TrashItem, int, error) {
+	source, ok := trashSources[filter.Kind]
+	if !ok {
+		return nil, 0, fmt.Errorf("unknown trash kind %q", filter.Kind)
+	}
+	rows := make([]struct {
+		TrashItem
+		Total int `db:"total"`
+	}, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &rows, fmt.Sprintf(trashQuery, source), filter.Search, filter.ArchivedBy,
+		filter.From, filter.To, filter.OrganizationID, filter.Limit, filter.Offset)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to list archived records", zap.String("kind", filter.Kind), zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to list archived %s: %w", filter.Kind, err)
+	}
+	items := make([]TrashItem, 0, len(rows))
+	total := 0
+	for _, row := range rows {
+		items = append(items, row.TrashItem)
+		total = row.Total
+	}
+	return items, total, nil
+}
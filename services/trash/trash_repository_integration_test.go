@@ -0,0 +1,69 @@
+//go:build integration
+
+package trashservice
+
+import (
+	"asset/database/seed"
+	"asset/database/testdb"
+	"asset/providers"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestListArchived(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewTrashRepository(db, logger)
+	ctx := context.Background()
+	adminID := seed.ID("user:admin")
+
+	var assetID, roleID uuid.UUID
+	require.NoError(t, db.Get(&assetID, `
+		INSERT INTO assets (brand, model, serial_no, type, archived_at, archived_by)
+		VALUES ('Dell', 'XPS 13', 'TRASH-1', 'laptop', now() - INTERVAL '1 day', $1) RETURNING id`, adminID))
+	require.NoError(t, db.Get(&roleID, `
+		INSERT INTO roles (name, description, archived_at) VALUES ('trash-auditor', 'reads the trash', now()) RETURNING id`))
+
+	filter := TrashFilter{Kind: TrashAssets, Search: "trash-1", Limit: 10}
+	items, total, err := repo.ListArchived(ctx, filter)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, assetID, items[0].ID)
+	assert.Equal(t, "Dell XPS 13", items[0].Name)
+	assert.Equal(t, &adminID, items[0].ArchivedBy)
+	require.NotNil(t, items[0].ArchivedByName)
+	assert.Equal(t, "Asha Admin", *items[0].ArchivedByName)
+
+	// archived_by and the window narrow it down
+	other := seed.ID("user:asset-manager")
+	filter.ArchivedBy = &other
+	items, _, err = repo.ListArchived(ctx, filter)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+	filter.ArchivedBy = nil
+	since := time.Now().Add(-time.Hour)
+	filter.From = &since
+	items, _, err = repo.ListArchived(ctx, filter)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+
+	// no one is recorded for the role
+	items, _, err = repo.ListArchived(ctx, TrashFilter{Kind: TrashRoles, Search: "trash-auditor", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, roleID, items[0].ID)
+	assert.Equal(t, "reads the trash", items[0].Details)
+	assert.Nil(t, items[0].ArchivedBy)
+}
@@ -0,0 +1,47 @@
+package trashservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"context"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// TrashService lets admins see what was archived, by whom and when, before restoring or purging it
+type TrashService interface {
+	ListTrash(ctx context.Context, filter TrashFilter) (TrashRes, error)
+}
+
+var (
+	ErrUnknownTrashKind = models.NewServiceError(http.StatusNotFound, "unknown_trash_kind", "trash kind must be assets, users, roles or assignments")
+	ErrInvalidWindow    = models.NewServiceError(http.StatusBadRequest, "invalid_window", "from must be before to")
+)
+
+type trashServiceStruct struct {
+	repo   TrashRepository
+	logger providers.ZapLoggerProvider
+}
+
+func NewTrashService(repo TrashRepository, logger providers.ZapLoggerProvider) TrashService {
+	return &trashServiceStruct{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+func (s *trashServiceStruct) ListTrash(ctx context.Context, filter TrashFilter) (TrashRes, error) {
+	if _, ok := trashSources[filter.Kind]; !ok {
+		return TrashRes{}, ErrUnknownTrashKind
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return TrashRes{}, ErrInvalidWindow
+	}
+	items, total, err := s.repo.ListArchived(ctx, filter)
+	if err != nil {
+		return TrashRes{}, err
+	}
+	s.logger.GetLogger().Debug("trash listed", zap.String("kind", filter.Kind), zap.Int("total", total))
+	return TrashRes{Kind: filter.Kind, Items: items, Total: total, Limit: filter.Limit, Offset: filter.Offset}, nil
+}
@@ -0,0 +1,39 @@
+package trashservice
+
+import (
+	"asset/providers"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestListTrash(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	repo := NewMockTrashRepository(ctrl)
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+	svc := NewTrashService(repo, logger)
+
+	_, err := svc.ListTrash(ctx, TrashFilter{Kind: "departments"})
+	assert.ErrorIs(t, err, ErrUnknownTrashKind)
+
+	from, to := time.Now(), time.Now().Add(-time.Hour)
+	_, err = svc.ListTrash(ctx, TrashFilter{Kind: TrashUsers, From: &from, To: &to})
+	assert.ErrorIs(t, err, ErrInvalidWindow)
+
+	filter := TrashFilter{Kind: TrashUsers, Limit: 1, Offset: 1}
+	item := TrashItem{ID: uuid.New(), Name: "Farah Khan", ArchivedAt: to}
+	repo.EXPECT().ListArchived(ctx, filter).Return([]TrashItem{item}, 2, nil)
+	res, err := svc.ListTrash(ctx, filter)
+	require.NoError(t, err)
+	assert.Equal(t, TrashRes{Kind: TrashUsers, Items: []TrashItem{item}, Total: 2, Limit: 1, Offset: 1}, res)
+}
@@ -5,13 +5,14 @@
 package userservice
 
 import (
+	models "asset/models"
 	providers "asset/providers"
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
-	sqlx "github.com/jmoiron/sqlx"
 )
 
 // MockUserRepository is a mock of UserRepository interface.
@@ -37,6 +38,177 @@ func (m *MockUserRepository) EXPECT() *MockUserRepositoryMockRecorder {
 	return m.recorder
 }
 
+// ApplyEmailChange mocks base method.
+func (m *MockUserRepository) ApplyEmailChange(ctx context.Context, change EmailChangeRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyEmailChange", ctx, change)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApplyEmailChange indicates an expected call of ApplyEmailChange.
+func (mr *MockUserRepositoryMockRecorder) ApplyEmailChange(ctx, change interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyEmailChange", reflect.TypeOf((*MockUserRepository)(nil).ApplyEmailChange), ctx, change)
+}
+
+// ArchivePendingEmailChanges mocks base method.
+func (m *MockUserRepository) ArchivePendingEmailChanges(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchivePendingEmailChanges", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ArchivePendingEmailChanges indicates an expected call of ArchivePendingEmailChanges.
+func (mr *MockUserRepositoryMockRecorder) ArchivePendingEmailChanges(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchivePendingEmailChanges", reflect.TypeOf((*MockUserRepository)(nil).ArchivePendingEmailChanges), ctx, userID)
+}
+
+// ArchivePendingInvites mocks base method.
+func (m *MockUserRepository) ArchivePendingInvites(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchivePendingInvites", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ArchivePendingInvites indicates an expected call of ArchivePendingInvites.
+func (mr *MockUserRepositoryMockRecorder) ArchivePendingInvites(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchivePendingInvites", reflect.TypeOf((*MockUserRepository)(nil).ArchivePendingInvites), ctx, email)
+}
+
+// ArchiveUserRoles mocks base method.
+func (m *MockUserRepository) ArchiveUserRoles(ctx context.Context, userID, archivedBy uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchiveUserRoles", ctx, userID, archivedBy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ArchiveUserRoles indicates an expected call of ArchiveUserRoles.
+func (mr *MockUserRepositoryMockRecorder) ArchiveUserRoles(ctx, userID, archivedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchiveUserRoles", reflect.TypeOf((*MockUserRepository)(nil).ArchiveUserRoles), ctx, userID, archivedBy)
+}
+
+// AssignKitAssets mocks base method.
+func (m *MockUserRepository) AssignKitAssets(ctx context.Context, userID uuid.UUID, item models.OnboardingKitItem, departmentID *uuid.UUID, assignedBy uuid.UUID) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignKitAssets", ctx, userID, item, departmentID, assignedBy)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AssignKitAssets indicates an expected call of AssignKitAssets.
+func (mr *MockUserRepositoryMockRecorder) AssignKitAssets(ctx, userID, item, departmentID, assignedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignKitAssets", reflect.TypeOf((*MockUserRepository)(nil).AssignKitAssets), ctx, userID, item, departmentID, assignedBy)
+}
+
+// CancelScheduledRoleChange mocks base method.
+func (m *MockUserRepository) CancelScheduledRoleChange(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelScheduledRoleChange", ctx, id, scope)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelScheduledRoleChange indicates an expected call of CancelScheduledRoleChange.
+func (mr *MockUserRepositoryMockRecorder) CancelScheduledRoleChange(ctx, id, scope interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelScheduledRoleChange", reflect.TypeOf((*MockUserRepository)(nil).CancelScheduledRoleChange), ctx, id, scope)
+}
+
+// CheckContactOTP mocks base method.
+func (m *MockUserRepository) CheckContactOTP(ctx context.Context, contactNo, codeHash string, maxAttempts int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckContactOTP", ctx, contactNo, codeHash, maxAttempts)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckContactOTP indicates an expected call of CheckContactOTP.
+func (mr *MockUserRepositoryMockRecorder) CheckContactOTP(ctx, contactNo, codeHash, maxAttempts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckContactOTP", reflect.TypeOf((*MockUserRepository)(nil).CheckContactOTP), ctx, contactNo, codeHash, maxAttempts)
+}
+
+// ClearLoginFailures mocks base method.
+func (m *MockUserRepository) ClearLoginFailures(ctx context.Context, subject string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ClearLoginFailures", ctx, subject)
+}
+
+// ClearLoginFailures indicates an expected call of ClearLoginFailures.
+func (mr *MockUserRepositoryMockRecorder) ClearLoginFailures(ctx, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearLoginFailures", reflect.TypeOf((*MockUserRepository)(nil).ClearLoginFailures), ctx, subject)
+}
+
+// ClearLoginLock mocks base method.
+func (m *MockUserRepository) ClearLoginLock(ctx context.Context, subject string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearLoginLock", ctx, subject)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ClearLoginLock indicates an expected call of ClearLoginLock.
+func (mr *MockUserRepositoryMockRecorder) ClearLoginLock(ctx, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearLoginLock", reflect.TypeOf((*MockUserRepository)(nil).ClearLoginLock), ctx, subject)
+}
+
+// ConsumeBackupCode mocks base method.
+func (m *MockUserRepository) ConsumeBackupCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeBackupCode", ctx, userID, codeHash)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeBackupCode indicates an expected call of ConsumeBackupCode.
+func (mr *MockUserRepositoryMockRecorder) ConsumeBackupCode(ctx, userID, codeHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeBackupCode", reflect.TypeOf((*MockUserRepository)(nil).ConsumeBackupCode), ctx, userID, codeHash)
+}
+
+// ConsumeMFAStep mocks base method.
+func (m *MockUserRepository) ConsumeMFAStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeMFAStep", ctx, userID, step)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeMFAStep indicates an expected call of ConsumeMFAStep.
+func (mr *MockUserRepositoryMockRecorder) ConsumeMFAStep(ctx, userID, step interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeMFAStep", reflect.TypeOf((*MockUserRepository)(nil).ConsumeMFAStep), ctx, userID, step)
+}
+
+// ConsumeMagicLink mocks base method.
+func (m *MockUserRepository) ConsumeMagicLink(ctx context.Context, linkID string, userID uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeMagicLink", ctx, linkID, userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeMagicLink indicates an expected call of ConsumeMagicLink.
+func (mr *MockUserRepositoryMockRecorder) ConsumeMagicLink(ctx, linkID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeMagicLink", reflect.TypeOf((*MockUserRepository)(nil).ConsumeMagicLink), ctx, linkID, userID)
+}
+
 // CreateFirebaseUser mocks base method.
 func (m *MockUserRepository) CreateFirebaseUser(ctx context.Context, name, email string) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
@@ -53,47 +225,176 @@ func (mr *MockUserRepositoryMockRecorder) CreateFirebaseUser(ctx, name, email in
 }
 
 // CreateNewEmployee mocks base method.
-func (m *MockUserRepository) CreateNewEmployee(ctx context.Context, tx *sqlx.Tx, req ManagerRegisterReq, managerUUID uuid.UUID) (uuid.UUID, error) {
+func (m *MockUserRepository) CreateNewEmployee(ctx context.Context, req ManagerRegisterReq, managerUUID uuid.UUID) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateNewEmployee", ctx, tx, req, managerUUID)
+	ret := m.ctrl.Call(m, "CreateNewEmployee", ctx, req, managerUUID)
 	ret0, _ := ret[0].(uuid.UUID)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateNewEmployee indicates an expected call of CreateNewEmployee.
-func (mr *MockUserRepositoryMockRecorder) CreateNewEmployee(ctx, tx, req, managerUUID interface{}) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) CreateNewEmployee(ctx, req, managerUUID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewEmployee", reflect.TypeOf((*MockUserRepository)(nil).CreateNewEmployee), ctx, req, managerUUID)
+}
+
+// DecideRoleGrantApproval mocks base method.
+func (m *MockUserRepository) DecideRoleGrantApproval(ctx context.Context, id uuid.UUID, status string, decidedBy uuid.UUID, note string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DecideRoleGrantApproval", ctx, id, status, decidedBy, note)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DecideRoleGrantApproval indicates an expected call of DecideRoleGrantApproval.
+func (mr *MockUserRepositoryMockRecorder) DecideRoleGrantApproval(ctx, id, status, decidedBy, note interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecideRoleGrantApproval", reflect.TypeOf((*MockUserRepository)(nil).DecideRoleGrantApproval), ctx, id, status, decidedBy, note)
+}
+
+// DeleteContactOTP mocks base method.
+func (m *MockUserRepository) DeleteContactOTP(ctx context.Context, contactNo string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DeleteContactOTP", ctx, contactNo)
+}
+
+// DeleteContactOTP indicates an expected call of DeleteContactOTP.
+func (mr *MockUserRepositoryMockRecorder) DeleteContactOTP(ctx, contactNo interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateNewEmployee", reflect.TypeOf((*MockUserRepository)(nil).CreateNewEmployee), ctx, tx, req, managerUUID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteContactOTP", reflect.TypeOf((*MockUserRepository)(nil).DeleteContactOTP), ctx, contactNo)
 }
 
 // DeleteUserByID mocks base method.
-func (m *MockUserRepository) DeleteUserByID(ctx context.Context, userID uuid.UUID) error {
+func (m *MockUserRepository) DeleteUserByID(ctx context.Context, userID uuid.UUID, archivedBy *uuid.UUID) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUserByID", ctx, userID)
+	ret := m.ctrl.Call(m, "DeleteUserByID", ctx, userID, archivedBy)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteUserByID indicates an expected call of DeleteUserByID.
-func (mr *MockUserRepositoryMockRecorder) DeleteUserByID(ctx, userID interface{}) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) DeleteUserByID(ctx, userID, archivedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserByID", reflect.TypeOf((*MockUserRepository)(nil).DeleteUserByID), ctx, userID, archivedBy)
+}
+
+// DeleteUserMFA mocks base method.
+func (m *MockUserRepository) DeleteUserMFA(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserMFA", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUserMFA indicates an expected call of DeleteUserMFA.
+func (mr *MockUserRepositoryMockRecorder) DeleteUserMFA(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserMFA", reflect.TypeOf((*MockUserRepository)(nil).DeleteUserMFA), ctx, userID)
+}
+
+// EnableMFA mocks base method.
+func (m *MockUserRepository) EnableMFA(ctx context.Context, userID uuid.UUID, step int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableMFA", ctx, userID, step)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableMFA indicates an expected call of EnableMFA.
+func (mr *MockUserRepositoryMockRecorder) EnableMFA(ctx, userID, step interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUserByID", reflect.TypeOf((*MockUserRepository)(nil).DeleteUserByID), ctx, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableMFA", reflect.TypeOf((*MockUserRepository)(nil).EnableMFA), ctx, userID, step)
 }
 
 // GetCurrentUserRole mocks base method.
-func (m *MockUserRepository) GetCurrentUserRole(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID) (string, error) {
+func (m *MockUserRepository) GetCurrentUserRole(ctx context.Context, userID uuid.UUID) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCurrentUserRole", ctx, tx, userID)
+	ret := m.ctrl.Call(m, "GetCurrentUserRole", ctx, userID)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetCurrentUserRole indicates an expected call of GetCurrentUserRole.
-func (mr *MockUserRepositoryMockRecorder) GetCurrentUserRole(ctx, tx, userID interface{}) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) GetCurrentUserRole(ctx, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrentUserRole", reflect.TypeOf((*MockUserRepository)(nil).GetCurrentUserRole), ctx, tx, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCurrentUserRole", reflect.TypeOf((*MockUserRepository)(nil).GetCurrentUserRole), ctx, userID)
+}
+
+// GetDepartmentManagerIDs mocks base method.
+func (m *MockUserRepository) GetDepartmentManagerIDs(ctx context.Context, organizationID uuid.UUID, departmentID *uuid.UUID) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDepartmentManagerIDs", ctx, organizationID, departmentID)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDepartmentManagerIDs indicates an expected call of GetDepartmentManagerIDs.
+func (mr *MockUserRepositoryMockRecorder) GetDepartmentManagerIDs(ctx, organizationID, departmentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDepartmentManagerIDs", reflect.TypeOf((*MockUserRepository)(nil).GetDepartmentManagerIDs), ctx, organizationID, departmentID)
+}
+
+// GetDirectory mocks base method.
+func (m *MockUserRepository) GetDirectory(ctx context.Context, filter DirectoryFilter) ([]DirectoryEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDirectory", ctx, filter)
+	ret0, _ := ret[0].([]DirectoryEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDirectory indicates an expected call of GetDirectory.
+func (mr *MockUserRepositoryMockRecorder) GetDirectory(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDirectory", reflect.TypeOf((*MockUserRepository)(nil).GetDirectory), ctx, filter)
+}
+
+// GetDirectoryLinkedUsers mocks base method.
+func (m *MockUserRepository) GetDirectoryLinkedUsers(ctx context.Context, issuer string) ([]DirectoryLinkedUser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDirectoryLinkedUsers", ctx, issuer)
+	ret0, _ := ret[0].([]DirectoryLinkedUser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDirectoryLinkedUsers indicates an expected call of GetDirectoryLinkedUsers.
+func (mr *MockUserRepositoryMockRecorder) GetDirectoryLinkedUsers(ctx, issuer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDirectoryLinkedUsers", reflect.TypeOf((*MockUserRepository)(nil).GetDirectoryLinkedUsers), ctx, issuer)
+}
+
+// GetDirectorySyncRuns mocks base method.
+func (m *MockUserRepository) GetDirectorySyncRuns(ctx context.Context, limit, offset int) ([]DirectorySyncRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDirectorySyncRuns", ctx, limit, offset)
+	ret0, _ := ret[0].([]DirectorySyncRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDirectorySyncRuns indicates an expected call of GetDirectorySyncRuns.
+func (mr *MockUserRepositoryMockRecorder) GetDirectorySyncRuns(ctx, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDirectorySyncRuns", reflect.TypeOf((*MockUserRepository)(nil).GetDirectorySyncRuns), ctx, limit, offset)
+}
+
+// GetDueRoleChanges mocks base method.
+func (m *MockUserRepository) GetDueRoleChanges(ctx context.Context) ([]ScheduledRoleChangeRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueRoleChanges", ctx)
+	ret0, _ := ret[0].([]ScheduledRoleChangeRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueRoleChanges indicates an expected call of GetDueRoleChanges.
+func (mr *MockUserRepositoryMockRecorder) GetDueRoleChanges(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueRoleChanges", reflect.TypeOf((*MockUserRepository)(nil).GetDueRoleChanges), ctx)
 }
 
 // GetEmailByUserID mocks base method.
@@ -111,165 +412,980 @@ func (mr *MockUserRepositoryMockRecorder) GetEmailByUserID(ctx, userId interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmailByUserID", reflect.TypeOf((*MockUserRepository)(nil).GetEmailByUserID), ctx, userId)
 }
 
-// GetFilteredEmployeesWithAssets mocks base method.
-func (m *MockUserRepository) GetFilteredEmployeesWithAssets(ctx context.Context, filter EmployeeFilter) ([]EmployeeResponseModel, error) {
+// GetEmailChangeForUpdate mocks base method.
+func (m *MockUserRepository) GetEmailChangeForUpdate(ctx context.Context, requestID uuid.UUID) (EmailChangeRequest, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetFilteredEmployeesWithAssets", ctx, filter)
-	ret0, _ := ret[0].([]EmployeeResponseModel)
+	ret := m.ctrl.Call(m, "GetEmailChangeForUpdate", ctx, requestID)
+	ret0, _ := ret[0].(EmailChangeRequest)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetFilteredEmployeesWithAssets indicates an expected call of GetFilteredEmployeesWithAssets.
-func (mr *MockUserRepositoryMockRecorder) GetFilteredEmployeesWithAssets(ctx, filter interface{}) *gomock.Call {
+// GetEmailChangeForUpdate indicates an expected call of GetEmailChangeForUpdate.
+func (mr *MockUserRepositoryMockRecorder) GetEmailChangeForUpdate(ctx, requestID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFilteredEmployeesWithAssets", reflect.TypeOf((*MockUserRepository)(nil).GetFilteredEmployeesWithAssets), ctx, filter)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmailChangeForUpdate", reflect.TypeOf((*MockUserRepository)(nil).GetEmailChangeForUpdate), ctx, requestID)
 }
 
-// GetFirebase mocks base method.
-func (m *MockUserRepository) GetFirebase() providers.FirebaseProvider {
+// GetEmailVerificationStatus mocks base method.
+func (m *MockUserRepository) GetEmailVerificationStatus(ctx context.Context, email string) (EmailVerificationStatus, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetFirebase")
-	ret0, _ := ret[0].(providers.FirebaseProvider)
-	return ret0
+	ret := m.ctrl.Call(m, "GetEmailVerificationStatus", ctx, email)
+	ret0, _ := ret[0].(EmailVerificationStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// GetFirebase indicates an expected call of GetFirebase.
-func (mr *MockUserRepositoryMockRecorder) GetFirebase() *gomock.Call {
+// GetEmailVerificationStatus indicates an expected call of GetEmailVerificationStatus.
+func (mr *MockUserRepositoryMockRecorder) GetEmailVerificationStatus(ctx, email interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFirebase", reflect.TypeOf((*MockUserRepository)(nil).GetFirebase))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmailVerificationStatus", reflect.TypeOf((*MockUserRepository)(nil).GetEmailVerificationStatus), ctx, email)
 }
 
-// GetUserAssetTimeline mocks base method.
-func (m *MockUserRepository) GetUserAssetTimeline(ctx context.Context, userID uuid.UUID) ([]UserTimelineRes, error) {
+// GetEmployeeByID mocks base method.
+func (m *MockUserRepository) GetEmployeeByID(ctx context.Context, userID uuid.UUID) (EmployeeRes, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserAssetTimeline", ctx, userID)
-	ret0, _ := ret[0].([]UserTimelineRes)
+	ret := m.ctrl.Call(m, "GetEmployeeByID", ctx, userID)
+	ret0, _ := ret[0].(EmployeeRes)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserAssetTimeline indicates an expected call of GetUserAssetTimeline.
-func (mr *MockUserRepositoryMockRecorder) GetUserAssetTimeline(ctx, userID interface{}) *gomock.Call {
+// GetEmployeeByID indicates an expected call of GetEmployeeByID.
+func (mr *MockUserRepositoryMockRecorder) GetEmployeeByID(ctx, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserAssetTimeline", reflect.TypeOf((*MockUserRepository)(nil).GetUserAssetTimeline), ctx, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmployeeByID", reflect.TypeOf((*MockUserRepository)(nil).GetEmployeeByID), ctx, userID)
 }
 
-// GetUserByEmail mocks base method.
-func (m *MockUserRepository) GetUserByEmail(ctx context.Context, userEmail string) (uuid.UUID, error) {
+// GetEmployeeType mocks base method.
+func (m *MockUserRepository) GetEmployeeType(ctx context.Context, userID uuid.UUID) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByEmail", ctx, userEmail)
-	ret0, _ := ret[0].(uuid.UUID)
+	ret := m.ctrl.Call(m, "GetEmployeeType", ctx, userID)
+	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserByEmail indicates an expected call of GetUserByEmail.
-func (mr *MockUserRepositoryMockRecorder) GetUserByEmail(ctx, userEmail interface{}) *gomock.Call {
+// GetEmployeeType indicates an expected call of GetEmployeeType.
+func (mr *MockUserRepositoryMockRecorder) GetEmployeeType(ctx, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockUserRepository)(nil).GetUserByEmail), ctx, userEmail)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmployeeType", reflect.TypeOf((*MockUserRepository)(nil).GetEmployeeType), ctx, userID)
 }
 
-// GetUserDashboardById mocks base method.
-func (m *MockUserRepository) GetUserDashboardById(ctx context.Context, userID uuid.UUID) (UserDashboardRes, error) {
+// GetEmployeesEndingWithin mocks base method.
+func (m *MockUserRepository) GetEmployeesEndingWithin(ctx context.Context, days int) ([]EndingEmployeeRes, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserDashboardById", ctx, userID)
-	ret0, _ := ret[0].(UserDashboardRes)
+	ret := m.ctrl.Call(m, "GetEmployeesEndingWithin", ctx, days)
+	ret0, _ := ret[0].([]EndingEmployeeRes)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserDashboardById indicates an expected call of GetUserDashboardById.
-func (mr *MockUserRepositoryMockRecorder) GetUserDashboardById(ctx, userID interface{}) *gomock.Call {
+// GetEmployeesEndingWithin indicates an expected call of GetEmployeesEndingWithin.
+func (mr *MockUserRepositoryMockRecorder) GetEmployeesEndingWithin(ctx, days interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserDashboardById", reflect.TypeOf((*MockUserRepository)(nil).GetUserDashboardById), ctx, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmployeesEndingWithin", reflect.TypeOf((*MockUserRepository)(nil).GetEmployeesEndingWithin), ctx, days)
 }
 
-// GetUserRoleById mocks base method.
-func (m *MockUserRepository) GetUserRoleById(ctx context.Context, userId uuid.UUID) (string, error) {
+// GetEmployeesForExport mocks base method.
+func (m *MockUserRepository) GetEmployeesForExport(ctx context.Context, filter EmployeeFilter) ([]EmployeeExportRow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserRoleById", ctx, userId)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "GetEmployeesForExport", ctx, filter)
+	ret0, _ := ret[0].([]EmployeeExportRow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserRoleById indicates an expected call of GetUserRoleById.
-func (mr *MockUserRepositoryMockRecorder) GetUserRoleById(ctx, userId interface{}) *gomock.Call {
+// GetEmployeesForExport indicates an expected call of GetEmployeesForExport.
+func (mr *MockUserRepositoryMockRecorder) GetEmployeesForExport(ctx, filter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRoleById", reflect.TypeOf((*MockUserRepository)(nil).GetUserRoleById), ctx, userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmployeesForExport", reflect.TypeOf((*MockUserRepository)(nil).GetEmployeesForExport), ctx, filter)
 }
 
-// InsertIntoUser mocks base method.
-func (m *MockUserRepository) InsertIntoUser(ctx context.Context, tx *sqlx.Tx, username, email, firebasetoken string) (uuid.UUID, error) {
+// GetEmployeesPastEndDate mocks base method.
+func (m *MockUserRepository) GetEmployeesPastEndDate(ctx context.Context) ([]EndingEmployeeRes, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InsertIntoUser", ctx, tx, username, email, firebasetoken)
-	ret0, _ := ret[0].(uuid.UUID)
+	ret := m.ctrl.Call(m, "GetEmployeesPastEndDate", ctx)
+	ret0, _ := ret[0].([]EndingEmployeeRes)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// InsertIntoUser indicates an expected call of InsertIntoUser.
-func (mr *MockUserRepositoryMockRecorder) InsertIntoUser(ctx, tx, username, email, firebasetoken interface{}) *gomock.Call {
+// GetEmployeesPastEndDate indicates an expected call of GetEmployeesPastEndDate.
+func (mr *MockUserRepositoryMockRecorder) GetEmployeesPastEndDate(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertIntoUser", reflect.TypeOf((*MockUserRepository)(nil).InsertIntoUser), ctx, tx, username, email, firebasetoken)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmployeesPastEndDate", reflect.TypeOf((*MockUserRepository)(nil).GetEmployeesPastEndDate), ctx)
 }
 
-// InsertIntoUserRole mocks base method.
-func (m *MockUserRepository) InsertIntoUserRole(ctx context.Context, tx *sqlx.Tx, userId uuid.UUID, role string, createdBy uuid.UUID) error {
+// GetExpiredRoleChanges mocks base method.
+func (m *MockUserRepository) GetExpiredRoleChanges(ctx context.Context) ([]ScheduledRoleChangeRes, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InsertIntoUserRole", ctx, tx, userId, role, createdBy)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetExpiredRoleChanges", ctx)
+	ret0, _ := ret[0].([]ScheduledRoleChangeRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// InsertIntoUserRole indicates an expected call of InsertIntoUserRole.
-func (mr *MockUserRepositoryMockRecorder) InsertIntoUserRole(ctx, tx, userId, role, createdBy interface{}) *gomock.Call {
+// GetExpiredRoleChanges indicates an expected call of GetExpiredRoleChanges.
+func (mr *MockUserRepositoryMockRecorder) GetExpiredRoleChanges(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertIntoUserRole", reflect.TypeOf((*MockUserRepository)(nil).InsertIntoUserRole), ctx, tx, userId, role, createdBy)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExpiredRoleChanges", reflect.TypeOf((*MockUserRepository)(nil).GetExpiredRoleChanges), ctx)
 }
 
-// InsertIntoUserType mocks base method.
-func (m *MockUserRepository) InsertIntoUserType(ctx context.Context, tx *sqlx.Tx, userId uuid.UUID, employeeType string, createdBy uuid.UUID) error {
+// GetFilteredEmployeesWithAssets mocks base method.
+func (m *MockUserRepository) GetFilteredEmployeesWithAssets(ctx context.Context, filter EmployeeFilter) ([]EmployeeResponseModel, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InsertIntoUserType", ctx, tx, userId, employeeType, createdBy)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "GetFilteredEmployeesWithAssets", ctx, filter)
+	ret0, _ := ret[0].([]EmployeeResponseModel)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// InsertIntoUserType indicates an expected call of InsertIntoUserType.
-func (mr *MockUserRepositoryMockRecorder) InsertIntoUserType(ctx, tx, userId, employeeType, createdBy interface{}) *gomock.Call {
+// GetFilteredEmployeesWithAssets indicates an expected call of GetFilteredEmployeesWithAssets.
+func (mr *MockUserRepositoryMockRecorder) GetFilteredEmployeesWithAssets(ctx, filter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertIntoUserType", reflect.TypeOf((*MockUserRepository)(nil).InsertIntoUserType), ctx, tx, userId, employeeType, createdBy)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFilteredEmployeesWithAssets", reflect.TypeOf((*MockUserRepository)(nil).GetFilteredEmployeesWithAssets), ctx, filter)
 }
 
-// InsertUserRole mocks base method.
-func (m *MockUserRepository) InsertUserRole(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, role string, createdBy uuid.UUID) error {
+// GetFirebase mocks base method.
+func (m *MockUserRepository) GetFirebase() providers.FirebaseProvider {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InsertUserRole", ctx, tx, userID, role, createdBy)
-	ret0, _ := ret[0].(error)
+	ret := m.ctrl.Call(m, "GetFirebase")
+	ret0, _ := ret[0].(providers.FirebaseProvider)
 	return ret0
 }
 
-// InsertUserRole indicates an expected call of InsertUserRole.
-func (mr *MockUserRepositoryMockRecorder) InsertUserRole(ctx, tx, userID, role, createdBy interface{}) *gomock.Call {
+// GetFirebase indicates an expected call of GetFirebase.
+func (mr *MockUserRepositoryMockRecorder) GetFirebase() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFirebase", reflect.TypeOf((*MockUserRepository)(nil).GetFirebase))
+}
+
+// GetFirebaseRoleClaims mocks base method.
+func (m *MockUserRepository) GetFirebaseRoleClaims(ctx context.Context, userID uuid.UUID) (FirebaseRoleClaims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFirebaseRoleClaims", ctx, userID)
+	ret0, _ := ret[0].(FirebaseRoleClaims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFirebaseRoleClaims indicates an expected call of GetFirebaseRoleClaims.
+func (mr *MockUserRepositoryMockRecorder) GetFirebaseRoleClaims(ctx, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertUserRole", reflect.TypeOf((*MockUserRepository)(nil).InsertUserRole), ctx, tx, userID, role, createdBy)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFirebaseRoleClaims", reflect.TypeOf((*MockUserRepository)(nil).GetFirebaseRoleClaims), ctx, userID)
 }
 
-// IsUserExists mocks base method.
-func (m *MockUserRepository) IsUserExists(ctx context.Context, tx *sqlx.Tx, email string) (bool, error) {
+// GetFirebaseUID mocks base method.
+func (m *MockUserRepository) GetFirebaseUID(ctx context.Context, userID uuid.UUID) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsUserExists", ctx, tx, email)
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "GetFirebaseUID", ctx, userID)
+	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// IsUserExists indicates an expected call of IsUserExists.
-func (mr *MockUserRepositoryMockRecorder) IsUserExists(ctx, tx, email interface{}) *gomock.Call {
+// GetFirebaseUID indicates an expected call of GetFirebaseUID.
+func (mr *MockUserRepositoryMockRecorder) GetFirebaseUID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFirebaseUID", reflect.TypeOf((*MockUserRepository)(nil).GetFirebaseUID), ctx, userID)
+}
+
+// GetInviteForUpdate mocks base method.
+func (m *MockUserRepository) GetInviteForUpdate(ctx context.Context, inviteID uuid.UUID) (UserInvite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInviteForUpdate", ctx, inviteID)
+	ret0, _ := ret[0].(UserInvite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInviteForUpdate indicates an expected call of GetInviteForUpdate.
+func (mr *MockUserRepositoryMockRecorder) GetInviteForUpdate(ctx, inviteID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInviteForUpdate", reflect.TypeOf((*MockUserRepository)(nil).GetInviteForUpdate), ctx, inviteID)
+}
+
+// GetLoginLockedUntil mocks base method.
+func (m *MockUserRepository) GetLoginLockedUntil(ctx context.Context, subjects ...string) time.Time {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx}
+	for _, a := range subjects {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetLoginLockedUntil", varargs...)
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// GetLoginLockedUntil indicates an expected call of GetLoginLockedUntil.
+func (mr *MockUserRepositoryMockRecorder) GetLoginLockedUntil(ctx interface{}, subjects ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx}, subjects...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginLockedUntil", reflect.TypeOf((*MockUserRepository)(nil).GetLoginLockedUntil), varargs...)
+}
+
+// GetMFAAttempts mocks base method.
+func (m *MockUserRepository) GetMFAAttempts(ctx context.Context, userID uuid.UUID) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMFAAttempts", ctx, userID)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// GetMFAAttempts indicates an expected call of GetMFAAttempts.
+func (mr *MockUserRepositoryMockRecorder) GetMFAAttempts(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMFAAttempts", reflect.TypeOf((*MockUserRepository)(nil).GetMFAAttempts), ctx, userID)
+}
+
+// GetOnboardingTemplate mocks base method.
+func (m *MockUserRepository) GetOnboardingTemplate(ctx context.Context, templateID uuid.UUID, organizationID *uuid.UUID) (models.OnboardingTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOnboardingTemplate", ctx, templateID, organizationID)
+	ret0, _ := ret[0].(models.OnboardingTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOnboardingTemplate indicates an expected call of GetOnboardingTemplate.
+func (mr *MockUserRepositoryMockRecorder) GetOnboardingTemplate(ctx, templateID, organizationID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOnboardingTemplate", reflect.TypeOf((*MockUserRepository)(nil).GetOnboardingTemplate), ctx, templateID, organizationID)
+}
+
+// GetPendingRoleChanges mocks base method.
+func (m *MockUserRepository) GetPendingRoleChanges(ctx context.Context, userID uuid.UUID) ([]ScheduledRoleChangeRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingRoleChanges", ctx, userID)
+	ret0, _ := ret[0].([]ScheduledRoleChangeRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingRoleChanges indicates an expected call of GetPendingRoleChanges.
+func (mr *MockUserRepositoryMockRecorder) GetPendingRoleChanges(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingRoleChanges", reflect.TypeOf((*MockUserRepository)(nil).GetPendingRoleChanges), ctx, userID)
+}
+
+// GetRecentlyActiveUserIDs mocks base method.
+func (m *MockUserRepository) GetRecentlyActiveUserIDs(ctx context.Context, since time.Time, limit int) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecentlyActiveUserIDs", ctx, since, limit)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecentlyActiveUserIDs indicates an expected call of GetRecentlyActiveUserIDs.
+func (mr *MockUserRepositoryMockRecorder) GetRecentlyActiveUserIDs(ctx, since, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecentlyActiveUserIDs", reflect.TypeOf((*MockUserRepository)(nil).GetRecentlyActiveUserIDs), ctx, since, limit)
+}
+
+// GetRoleGrantApprovalForUpdate mocks base method.
+func (m *MockUserRepository) GetRoleGrantApprovalForUpdate(ctx context.Context, id uuid.UUID) (RoleGrantApprovalRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoleGrantApprovalForUpdate", ctx, id)
+	ret0, _ := ret[0].(RoleGrantApprovalRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoleGrantApprovalForUpdate indicates an expected call of GetRoleGrantApprovalForUpdate.
+func (mr *MockUserRepositoryMockRecorder) GetRoleGrantApprovalForUpdate(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoleGrantApprovalForUpdate", reflect.TypeOf((*MockUserRepository)(nil).GetRoleGrantApprovalForUpdate), ctx, id)
+}
+
+// GetRoleGrantApprovals mocks base method.
+func (m *MockUserRepository) GetRoleGrantApprovals(ctx context.Context, status string, limit, offset int, scope models.DepartmentScope) ([]RoleGrantApprovalRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoleGrantApprovals", ctx, status, limit, offset, scope)
+	ret0, _ := ret[0].([]RoleGrantApprovalRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoleGrantApprovals indicates an expected call of GetRoleGrantApprovals.
+func (mr *MockUserRepositoryMockRecorder) GetRoleGrantApprovals(ctx, status, limit, offset, scope interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoleGrantApprovals", reflect.TypeOf((*MockUserRepository)(nil).GetRoleGrantApprovals), ctx, status, limit, offset, scope)
+}
+
+// GetScheduledRoleChanges mocks base method.
+func (m *MockUserRepository) GetScheduledRoleChanges(ctx context.Context, userID *uuid.UUID, limit, offset int, scope models.DepartmentScope) ([]ScheduledRoleChangeRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduledRoleChanges", ctx, userID, limit, offset, scope)
+	ret0, _ := ret[0].([]ScheduledRoleChangeRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduledRoleChanges indicates an expected call of GetScheduledRoleChanges.
+func (mr *MockUserRepositoryMockRecorder) GetScheduledRoleChanges(ctx, userID, limit, offset, scope interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduledRoleChanges", reflect.TypeOf((*MockUserRepository)(nil).GetScheduledRoleChanges), ctx, userID, limit, offset, scope)
+}
+
+// GetUserByEmail mocks base method.
+func (m *MockUserRepository) GetUserByEmail(ctx context.Context, userEmail string) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByEmail", ctx, userEmail)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByEmail indicates an expected call of GetUserByEmail.
+func (mr *MockUserRepositoryMockRecorder) GetUserByEmail(ctx, userEmail interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockUserRepository)(nil).GetUserByEmail), ctx, userEmail)
+}
+
+// GetUserDashboardById mocks base method.
+func (m *MockUserRepository) GetUserDashboardById(ctx context.Context, userID uuid.UUID) (UserDashboardRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserDashboardById", ctx, userID)
+	ret0, _ := ret[0].(UserDashboardRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserDashboardById indicates an expected call of GetUserDashboardById.
+func (mr *MockUserRepositoryMockRecorder) GetUserDashboardById(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserDashboardById", reflect.TypeOf((*MockUserRepository)(nil).GetUserDashboardById), ctx, userID)
+}
+
+// GetUserIDByIdentity mocks base method.
+func (m *MockUserRepository) GetUserIDByIdentity(ctx context.Context, issuer, subject string) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserIDByIdentity", ctx, issuer, subject)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserIDByIdentity indicates an expected call of GetUserIDByIdentity.
+func (mr *MockUserRepositoryMockRecorder) GetUserIDByIdentity(ctx, issuer, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIDByIdentity", reflect.TypeOf((*MockUserRepository)(nil).GetUserIDByIdentity), ctx, issuer, subject)
+}
+
+// GetUserMFA mocks base method.
+func (m *MockUserRepository) GetUserMFA(ctx context.Context, userID uuid.UUID) (UserMFA, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserMFA", ctx, userID)
+	ret0, _ := ret[0].(UserMFA)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserMFA indicates an expected call of GetUserMFA.
+func (mr *MockUserRepositoryMockRecorder) GetUserMFA(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserMFA", reflect.TypeOf((*MockUserRepository)(nil).GetUserMFA), ctx, userID)
+}
+
+// GetUserRoleAndEmail mocks base method.
+func (m *MockUserRepository) GetUserRoleAndEmail(ctx context.Context, userID uuid.UUID) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRoleAndEmail", ctx, userID)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetUserRoleAndEmail indicates an expected call of GetUserRoleAndEmail.
+func (mr *MockUserRepositoryMockRecorder) GetUserRoleAndEmail(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRoleAndEmail", reflect.TypeOf((*MockUserRepository)(nil).GetUserRoleAndEmail), ctx, userID)
+}
+
+// GetUserRoleById mocks base method.
+func (m *MockUserRepository) GetUserRoleById(ctx context.Context, userId uuid.UUID) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRoleById", ctx, userId)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserRoleById indicates an expected call of GetUserRoleById.
+func (mr *MockUserRepositoryMockRecorder) GetUserRoleById(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRoleById", reflect.TypeOf((*MockUserRepository)(nil).GetUserRoleById), ctx, userId)
+}
+
+// GetUserRoleHistory mocks base method.
+func (m *MockUserRepository) GetUserRoleHistory(ctx context.Context, userID uuid.UUID) ([]RoleHistoryRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRoleHistory", ctx, userID)
+	ret0, _ := ret[0].([]RoleHistoryRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserRoleHistory indicates an expected call of GetUserRoleHistory.
+func (mr *MockUserRepositoryMockRecorder) GetUserRoleHistory(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRoleHistory", reflect.TypeOf((*MockUserRepository)(nil).GetUserRoleHistory), ctx, userID)
+}
+
+// GetUserTimeline mocks base method.
+func (m *MockUserRepository) GetUserTimeline(ctx context.Context, userID uuid.UUID, limit, offset int) ([]UserTimelineRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserTimeline", ctx, userID, limit, offset)
+	ret0, _ := ret[0].([]UserTimelineRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserTimeline indicates an expected call of GetUserTimeline.
+func (mr *MockUserRepositoryMockRecorder) GetUserTimeline(ctx, userID, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserTimeline", reflect.TypeOf((*MockUserRepository)(nil).GetUserTimeline), ctx, userID, limit, offset)
+}
+
+// HasPendingRoleGrant mocks base method.
+func (m *MockUserRepository) HasPendingRoleGrant(ctx context.Context, userID uuid.UUID, role string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasPendingRoleGrant", ctx, userID, role)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasPendingRoleGrant indicates an expected call of HasPendingRoleGrant.
+func (mr *MockUserRepositoryMockRecorder) HasPendingRoleGrant(ctx, userID, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasPendingRoleGrant", reflect.TypeOf((*MockUserRepository)(nil).HasPendingRoleGrant), ctx, userID, role)
+}
+
+// IncrementLoginFailures mocks base method.
+func (m *MockUserRepository) IncrementLoginFailures(ctx context.Context, subject string, window time.Duration) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementLoginFailures", ctx, subject, window)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrementLoginFailures indicates an expected call of IncrementLoginFailures.
+func (mr *MockUserRepositoryMockRecorder) IncrementLoginFailures(ctx, subject, window interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementLoginFailures", reflect.TypeOf((*MockUserRepository)(nil).IncrementLoginFailures), ctx, subject, window)
+}
+
+// IncrementLoginLockouts mocks base method.
+func (m *MockUserRepository) IncrementLoginLockouts(ctx context.Context, subject string, decay time.Duration) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementLoginLockouts", ctx, subject, decay)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrementLoginLockouts indicates an expected call of IncrementLoginLockouts.
+func (mr *MockUserRepositoryMockRecorder) IncrementLoginLockouts(ctx, subject, decay interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementLoginLockouts", reflect.TypeOf((*MockUserRepository)(nil).IncrementLoginLockouts), ctx, subject, decay)
+}
+
+// IncrementMFAAttempts mocks base method.
+func (m *MockUserRepository) IncrementMFAAttempts(ctx context.Context, userID uuid.UUID, window time.Duration) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementMFAAttempts", ctx, userID, window)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrementMFAAttempts indicates an expected call of IncrementMFAAttempts.
+func (mr *MockUserRepositoryMockRecorder) IncrementMFAAttempts(ctx, userID, window interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementMFAAttempts", reflect.TypeOf((*MockUserRepository)(nil).IncrementMFAAttempts), ctx, userID, window)
+}
+
+// IncrementMagicLinkRequests mocks base method.
+func (m *MockUserRepository) IncrementMagicLinkRequests(ctx context.Context, email string, window time.Duration) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementMagicLinkRequests", ctx, email, window)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrementMagicLinkRequests indicates an expected call of IncrementMagicLinkRequests.
+func (mr *MockUserRepositoryMockRecorder) IncrementMagicLinkRequests(ctx, email, window interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementMagicLinkRequests", reflect.TypeOf((*MockUserRepository)(nil).IncrementMagicLinkRequests), ctx, email, window)
+}
+
+// InsertDirectorySyncRun mocks base method.
+func (m *MockUserRepository) InsertDirectorySyncRun(ctx context.Context, report DirectorySyncReport) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertDirectorySyncRun", ctx, report)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertDirectorySyncRun indicates an expected call of InsertDirectorySyncRun.
+func (mr *MockUserRepositoryMockRecorder) InsertDirectorySyncRun(ctx, report interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertDirectorySyncRun", reflect.TypeOf((*MockUserRepository)(nil).InsertDirectorySyncRun), ctx, report)
+}
+
+// InsertEmailChangeRequest mocks base method.
+func (m *MockUserRepository) InsertEmailChangeRequest(ctx context.Context, userID uuid.UUID, newEmail string, requestedBy uuid.UUID, expiresAt time.Time) (EmailChangeRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertEmailChangeRequest", ctx, userID, newEmail, requestedBy, expiresAt)
+	ret0, _ := ret[0].(EmailChangeRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertEmailChangeRequest indicates an expected call of InsertEmailChangeRequest.
+func (mr *MockUserRepositoryMockRecorder) InsertEmailChangeRequest(ctx, userID, newEmail, requestedBy, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertEmailChangeRequest", reflect.TypeOf((*MockUserRepository)(nil).InsertEmailChangeRequest), ctx, userID, newEmail, requestedBy, expiresAt)
+}
+
+// InsertIntoUser mocks base method.
+func (m *MockUserRepository) InsertIntoUser(ctx context.Context, username, email, firebasetoken string) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertIntoUser", ctx, username, email, firebasetoken)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertIntoUser indicates an expected call of InsertIntoUser.
+func (mr *MockUserRepositoryMockRecorder) InsertIntoUser(ctx, username, email, firebasetoken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertIntoUser", reflect.TypeOf((*MockUserRepository)(nil).InsertIntoUser), ctx, username, email, firebasetoken)
+}
+
+// InsertIntoUserRole mocks base method.
+func (m *MockUserRepository) InsertIntoUserRole(ctx context.Context, userId uuid.UUID, role string, createdBy uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertIntoUserRole", ctx, userId, role, createdBy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertIntoUserRole indicates an expected call of InsertIntoUserRole.
+func (mr *MockUserRepositoryMockRecorder) InsertIntoUserRole(ctx, userId, role, createdBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertIntoUserRole", reflect.TypeOf((*MockUserRepository)(nil).InsertIntoUserRole), ctx, userId, role, createdBy)
+}
+
+// InsertIntoUserType mocks base method.
+func (m *MockUserRepository) InsertIntoUserType(ctx context.Context, userId uuid.UUID, employeeType string, createdBy uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertIntoUserType", ctx, userId, employeeType, createdBy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertIntoUserType indicates an expected call of InsertIntoUserType.
+func (mr *MockUserRepositoryMockRecorder) InsertIntoUserType(ctx, userId, employeeType, createdBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertIntoUserType", reflect.TypeOf((*MockUserRepository)(nil).InsertIntoUserType), ctx, userId, employeeType, createdBy)
+}
+
+// InsertInvite mocks base method.
+func (m *MockUserRepository) InsertInvite(ctx context.Context, req InviteEmployeeReq, invitedBy uuid.UUID, expiresAt time.Time) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertInvite", ctx, req, invitedBy, expiresAt)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertInvite indicates an expected call of InsertInvite.
+func (mr *MockUserRepositoryMockRecorder) InsertInvite(ctx, req, invitedBy, expiresAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertInvite", reflect.TypeOf((*MockUserRepository)(nil).InsertInvite), ctx, req, invitedBy, expiresAt)
+}
+
+// InsertRoleGrantApproval mocks base method.
+func (m *MockUserRepository) InsertRoleGrantApproval(ctx context.Context, userID uuid.UUID, role, previousRole string, requestedBy uuid.UUID) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertRoleGrantApproval", ctx, userID, role, previousRole, requestedBy)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertRoleGrantApproval indicates an expected call of InsertRoleGrantApproval.
+func (mr *MockUserRepositoryMockRecorder) InsertRoleGrantApproval(ctx, userID, role, previousRole, requestedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertRoleGrantApproval", reflect.TypeOf((*MockUserRepository)(nil).InsertRoleGrantApproval), ctx, userID, role, previousRole, requestedBy)
+}
+
+// InsertScheduledRoleChange mocks base method.
+func (m *MockUserRepository) InsertScheduledRoleChange(ctx context.Context, req ScheduleRoleChangeReq, createdBy uuid.UUID) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertScheduledRoleChange", ctx, req, createdBy)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertScheduledRoleChange indicates an expected call of InsertScheduledRoleChange.
+func (mr *MockUserRepositoryMockRecorder) InsertScheduledRoleChange(ctx, req, createdBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertScheduledRoleChange", reflect.TypeOf((*MockUserRepository)(nil).InsertScheduledRoleChange), ctx, req, createdBy)
+}
+
+// InsertUserRole mocks base method.
+func (m *MockUserRepository) InsertUserRole(ctx context.Context, userID uuid.UUID, role string, createdBy uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertUserRole", ctx, userID, role, createdBy)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertUserRole indicates an expected call of InsertUserRole.
+func (mr *MockUserRepositoryMockRecorder) InsertUserRole(ctx, userID, role, createdBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertUserRole", reflect.TypeOf((*MockUserRepository)(nil).InsertUserRole), ctx, userID, role, createdBy)
+}
+
+// InvalidateUserCache mocks base method.
+func (m *MockUserRepository) InvalidateUserCache(ctx context.Context, userID uuid.UUID, emails ...string) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, userID}
+	for _, a := range emails {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "InvalidateUserCache", varargs...)
+}
+
+// InvalidateUserCache indicates an expected call of InvalidateUserCache.
+func (mr *MockUserRepositoryMockRecorder) InvalidateUserCache(ctx, userID interface{}, emails ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, userID}, emails...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateUserCache", reflect.TypeOf((*MockUserRepository)(nil).InvalidateUserCache), varargs...)
+}
+
+// IsEmailVerified mocks base method.
+func (m *MockUserRepository) IsEmailVerified(ctx context.Context, userID uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsEmailVerified", ctx, userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsEmailVerified indicates an expected call of IsEmailVerified.
+func (mr *MockUserRepositoryMockRecorder) IsEmailVerified(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsEmailVerified", reflect.TypeOf((*MockUserRepository)(nil).IsEmailVerified), ctx, userID)
+}
+
+// IsMFARequiredForRole mocks base method.
+func (m *MockUserRepository) IsMFARequiredForRole(ctx context.Context, role string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsMFARequiredForRole", ctx, role)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsMFARequiredForRole indicates an expected call of IsMFARequiredForRole.
+func (mr *MockUserRepositoryMockRecorder) IsMFARequiredForRole(ctx, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsMFARequiredForRole", reflect.TypeOf((*MockUserRepository)(nil).IsMFARequiredForRole), ctx, role)
+}
+
+// IsRoleExists mocks base method.
+func (m *MockUserRepository) IsRoleExists(ctx context.Context, role string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsRoleExists", ctx, role)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsRoleExists indicates an expected call of IsRoleExists.
+func (mr *MockUserRepositoryMockRecorder) IsRoleExists(ctx, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsRoleExists", reflect.TypeOf((*MockUserRepository)(nil).IsRoleExists), ctx, role)
+}
+
+// IsUserExists mocks base method.
+func (m *MockUserRepository) IsUserExists(ctx context.Context, email string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsUserExists", ctx, email)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsUserExists indicates an expected call of IsUserExists.
+func (mr *MockUserRepositoryMockRecorder) IsUserExists(ctx, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsUserExists", reflect.TypeOf((*MockUserRepository)(nil).IsUserExists), ctx, email)
+}
+
+// IsUserInScope mocks base method.
+func (m *MockUserRepository) IsUserInScope(ctx context.Context, userID uuid.UUID, scope models.DepartmentScope) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsUserInScope", ctx, userID, scope)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsUserInScope indicates an expected call of IsUserInScope.
+func (mr *MockUserRepositoryMockRecorder) IsUserInScope(ctx, userID, scope interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsUserInScope", reflect.TypeOf((*MockUserRepository)(nil).IsUserInScope), ctx, userID, scope)
+}
+
+// LinkIdentity mocks base method.
+func (m *MockUserRepository) LinkIdentity(ctx context.Context, userID uuid.UUID, identity models.OIDCIdentity) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkIdentity", ctx, userID, identity)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LinkIdentity indicates an expected call of LinkIdentity.
+func (mr *MockUserRepositoryMockRecorder) LinkIdentity(ctx, userID, identity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkIdentity", reflect.TypeOf((*MockUserRepository)(nil).LinkIdentity), ctx, userID, identity)
+}
+
+// ListFirebaseRoleClaims mocks base method.
+func (m *MockUserRepository) ListFirebaseRoleClaims(ctx context.Context, afterID uuid.UUID, limit int) ([]FirebaseRoleClaims, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListFirebaseRoleClaims", ctx, afterID, limit)
+	ret0, _ := ret[0].([]FirebaseRoleClaims)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListFirebaseRoleClaims indicates an expected call of ListFirebaseRoleClaims.
+func (mr *MockUserRepositoryMockRecorder) ListFirebaseRoleClaims(ctx, afterID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFirebaseRoleClaims", reflect.TypeOf((*MockUserRepository)(nil).ListFirebaseRoleClaims), ctx, afterID, limit)
+}
+
+// LockLogin mocks base method.
+func (m *MockUserRepository) LockLogin(ctx context.Context, subject string, until time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockLogin", ctx, subject, until)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockLogin indicates an expected call of LockLogin.
+func (mr *MockUserRepositoryMockRecorder) LockLogin(ctx, subject, until interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockLogin", reflect.TypeOf((*MockUserRepository)(nil).LockLogin), ctx, subject, until)
+}
+
+// MarkContactVerified mocks base method.
+func (m *MockUserRepository) MarkContactVerified(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkContactVerified", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkContactVerified indicates an expected call of MarkContactVerified.
+func (mr *MockUserRepositoryMockRecorder) MarkContactVerified(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkContactVerified", reflect.TypeOf((*MockUserRepository)(nil).MarkContactVerified), ctx, userID)
+}
+
+// MarkEmailVerified mocks base method.
+func (m *MockUserRepository) MarkEmailVerified(ctx context.Context, userID uuid.UUID, email string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEmailVerified", ctx, userID, email)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MarkEmailVerified indicates an expected call of MarkEmailVerified.
+func (mr *MockUserRepositoryMockRecorder) MarkEmailVerified(ctx, userID, email interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEmailVerified", reflect.TypeOf((*MockUserRepository)(nil).MarkEmailVerified), ctx, userID, email)
+}
+
+// MarkEndDateWarned mocks base method.
+func (m *MockUserRepository) MarkEndDateWarned(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkEndDateWarned", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkEndDateWarned indicates an expected call of MarkEndDateWarned.
+func (mr *MockUserRepositoryMockRecorder) MarkEndDateWarned(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkEndDateWarned", reflect.TypeOf((*MockUserRepository)(nil).MarkEndDateWarned), ctx, userID)
+}
+
+// MarkInviteAccepted mocks base method.
+func (m *MockUserRepository) MarkInviteAccepted(ctx context.Context, inviteID, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkInviteAccepted", ctx, inviteID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkInviteAccepted indicates an expected call of MarkInviteAccepted.
+func (mr *MockUserRepositoryMockRecorder) MarkInviteAccepted(ctx, inviteID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkInviteAccepted", reflect.TypeOf((*MockUserRepository)(nil).MarkInviteAccepted), ctx, inviteID, userID)
+}
+
+// MarkRoleChangeApplied mocks base method.
+func (m *MockUserRepository) MarkRoleChangeApplied(ctx context.Context, id uuid.UUID, previousRole string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRoleChangeApplied", ctx, id, previousRole)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRoleChangeApplied indicates an expected call of MarkRoleChangeApplied.
+func (mr *MockUserRepositoryMockRecorder) MarkRoleChangeApplied(ctx, id, previousRole interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRoleChangeApplied", reflect.TypeOf((*MockUserRepository)(nil).MarkRoleChangeApplied), ctx, id, previousRole)
+}
+
+// MarkRoleChangeReverted mocks base method.
+func (m *MockUserRepository) MarkRoleChangeReverted(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRoleChangeReverted", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRoleChangeReverted indicates an expected call of MarkRoleChangeReverted.
+func (mr *MockUserRepositoryMockRecorder) MarkRoleChangeReverted(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRoleChangeReverted", reflect.TypeOf((*MockUserRepository)(nil).MarkRoleChangeReverted), ctx, id)
+}
+
+// OpenEndDateReturnRequests mocks base method.
+func (m *MockUserRepository) OpenEndDateReturnRequests(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenEndDateReturnRequests", ctx, userID)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OpenEndDateReturnRequests indicates an expected call of OpenEndDateReturnRequests.
+func (mr *MockUserRepositoryMockRecorder) OpenEndDateReturnRequests(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenEndDateReturnRequests", reflect.TypeOf((*MockUserRepository)(nil).OpenEndDateReturnRequests), ctx, userID)
+}
+
+// ReplaceBackupCodes mocks base method.
+func (m *MockUserRepository) ReplaceBackupCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceBackupCodes", ctx, userID, codeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceBackupCodes indicates an expected call of ReplaceBackupCodes.
+func (mr *MockUserRepositoryMockRecorder) ReplaceBackupCodes(ctx, userID, codeHashes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceBackupCodes", reflect.TypeOf((*MockUserRepository)(nil).ReplaceBackupCodes), ctx, userID, codeHashes)
+}
+
+// ResetMFAAttempts mocks base method.
+func (m *MockUserRepository) ResetMFAAttempts(ctx context.Context, userID uuid.UUID) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "ResetMFAAttempts", ctx, userID)
+}
+
+// ResetMFAAttempts indicates an expected call of ResetMFAAttempts.
+func (mr *MockUserRepositoryMockRecorder) ResetMFAAttempts(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetMFAAttempts", reflect.TypeOf((*MockUserRepository)(nil).ResetMFAAttempts), ctx, userID)
+}
+
+// SavePendingMFA mocks base method.
+func (m *MockUserRepository) SavePendingMFA(ctx context.Context, userID uuid.UUID, encryptedSecret string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SavePendingMFA", ctx, userID, encryptedSecret)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SavePendingMFA indicates an expected call of SavePendingMFA.
+func (mr *MockUserRepositoryMockRecorder) SavePendingMFA(ctx, userID, encryptedSecret interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SavePendingMFA", reflect.TypeOf((*MockUserRepository)(nil).SavePendingMFA), ctx, userID, encryptedSecret)
+}
+
+// SetTimezone mocks base method.
+func (m *MockUserRepository) SetTimezone(ctx context.Context, userID uuid.UUID, timezone *string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTimezone", ctx, userID, timezone)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetTimezone indicates an expected call of SetTimezone.
+func (mr *MockUserRepositoryMockRecorder) SetTimezone(ctx, userID, timezone interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTimezone", reflect.TypeOf((*MockUserRepository)(nil).SetTimezone), ctx, userID, timezone)
+}
+
+// StoreContactOTP mocks base method.
+func (m *MockUserRepository) StoreContactOTP(ctx context.Context, contactNo, codeHash string, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreContactOTP", ctx, contactNo, codeHash, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreContactOTP indicates an expected call of StoreContactOTP.
+func (mr *MockUserRepositoryMockRecorder) StoreContactOTP(ctx, contactNo, codeHash, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreContactOTP", reflect.TypeOf((*MockUserRepository)(nil).StoreContactOTP), ctx, contactNo, codeHash, ttl)
+}
+
+// StoreMagicLink mocks base method.
+func (m *MockUserRepository) StoreMagicLink(ctx context.Context, linkID string, userID uuid.UUID, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreMagicLink", ctx, linkID, userID, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreMagicLink indicates an expected call of StoreMagicLink.
+func (mr *MockUserRepositoryMockRecorder) StoreMagicLink(ctx, linkID, userID, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreMagicLink", reflect.TypeOf((*MockUserRepository)(nil).StoreMagicLink), ctx, linkID, userID, ttl)
+}
+
+// StreamEmployeesForExport mocks base method.
+func (m *MockUserRepository) StreamEmployeesForExport(ctx context.Context, filter EmployeeFilter, fn func(EmployeeExportRow) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamEmployeesForExport", ctx, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamEmployeesForExport indicates an expected call of StreamEmployeesForExport.
+func (mr *MockUserRepositoryMockRecorder) StreamEmployeesForExport(ctx, filter, fn interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsUserExists", reflect.TypeOf((*MockUserRepository)(nil).IsUserExists), ctx, tx, email)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamEmployeesForExport", reflect.TypeOf((*MockUserRepository)(nil).StreamEmployeesForExport), ctx, filter, fn)
 }
 
 // UpdateEmployeeInfo mocks base method.
@@ -287,15 +1403,15 @@ func (mr *MockUserRepositoryMockRecorder) UpdateEmployeeInfo(ctx, req, adminUUID
 }
 
 // UpdateUserRole mocks base method.
-func (m *MockUserRepository) UpdateUserRole(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, newRole string, updatedBy uuid.UUID) error {
+func (m *MockUserRepository) UpdateUserRole(ctx context.Context, userID uuid.UUID, newRole string, updatedBy uuid.UUID) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateUserRole", ctx, tx, userID, newRole, updatedBy)
+	ret := m.ctrl.Call(m, "UpdateUserRole", ctx, userID, newRole, updatedBy)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateUserRole indicates an expected call of UpdateUserRole.
-func (mr *MockUserRepositoryMockRecorder) UpdateUserRole(ctx, tx, userID, newRole, updatedBy interface{}) *gomock.Call {
+func (mr *MockUserRepositoryMockRecorder) UpdateUserRole(ctx, userID, newRole, updatedBy interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserRole", reflect.TypeOf((*MockUserRepository)(nil).UpdateUserRole), ctx, tx, userID, newRole, updatedBy)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserRole", reflect.TypeOf((*MockUserRepository)(nil).UpdateUserRole), ctx, userID, newRole, updatedBy)
 }
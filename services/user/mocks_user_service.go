@@ -5,6 +5,7 @@
 package userservice
 
 import (
+	models "asset/models"
 	context "context"
 	reflect "reflect"
 
@@ -35,46 +36,207 @@ func (m *MockUserService) EXPECT() *MockUserServiceMockRecorder {
 	return m.recorder
 }
 
-// ChangeUserRole mocks base method.
-func (m *MockUserService) ChangeUserRole(ctx context.Context, req UpdateUserRoleReq, adminID uuid.UUID) error {
+// AcceptInvite mocks base method.
+func (m *MockUserService) AcceptInvite(ctx context.Context, req AcceptInviteReq) (uuid.UUID, *models.OnboardingRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AcceptInvite", ctx, req)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(*models.OnboardingRes)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AcceptInvite indicates an expected call of AcceptInvite.
+func (mr *MockUserServiceMockRecorder) AcceptInvite(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AcceptInvite", reflect.TypeOf((*MockUserService)(nil).AcceptInvite), ctx, req)
+}
+
+// ActivateMFA mocks base method.
+func (m *MockUserService) ActivateMFA(ctx context.Context, userID uuid.UUID, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ActivateMFA", ctx, userID, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ActivateMFA indicates an expected call of ActivateMFA.
+func (mr *MockUserServiceMockRecorder) ActivateMFA(ctx, userID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActivateMFA", reflect.TypeOf((*MockUserService)(nil).ActivateMFA), ctx, userID, code)
+}
+
+// ApplyScheduledRoleChanges mocks base method.
+func (m *MockUserService) ApplyScheduledRoleChanges(ctx context.Context) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ChangeUserRole", ctx, req, adminID)
+	ret := m.ctrl.Call(m, "ApplyScheduledRoleChanges", ctx)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// ApplyScheduledRoleChanges indicates an expected call of ApplyScheduledRoleChanges.
+func (mr *MockUserServiceMockRecorder) ApplyScheduledRoleChanges(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyScheduledRoleChanges", reflect.TypeOf((*MockUserService)(nil).ApplyScheduledRoleChanges), ctx)
+}
+
+// ApproveRoleGrant mocks base method.
+func (m *MockUserService) ApproveRoleGrant(ctx context.Context, id uuid.UUID, decider RoleGrantDecider, note string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApproveRoleGrant", ctx, id, decider, note)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApproveRoleGrant indicates an expected call of ApproveRoleGrant.
+func (mr *MockUserServiceMockRecorder) ApproveRoleGrant(ctx, id, decider, note interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproveRoleGrant", reflect.TypeOf((*MockUserService)(nil).ApproveRoleGrant), ctx, id, decider, note)
+}
+
+// CancelScheduledRoleChange mocks base method.
+func (m *MockUserService) CancelScheduledRoleChange(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelScheduledRoleChange", ctx, id, scope)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CancelScheduledRoleChange indicates an expected call of CancelScheduledRoleChange.
+func (mr *MockUserServiceMockRecorder) CancelScheduledRoleChange(ctx, id, scope interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelScheduledRoleChange", reflect.TypeOf((*MockUserService)(nil).CancelScheduledRoleChange), ctx, id, scope)
+}
+
+// ChangeUserRole mocks base method.
+func (m *MockUserService) ChangeUserRole(ctx context.Context, req UpdateUserRoleReq, adminID uuid.UUID, scope models.DepartmentScope) (ChangeUserRoleRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeUserRole", ctx, req, adminID, scope)
+	ret0, _ := ret[0].(ChangeUserRoleRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // ChangeUserRole indicates an expected call of ChangeUserRole.
-func (mr *MockUserServiceMockRecorder) ChangeUserRole(ctx, req, adminID interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) ChangeUserRole(ctx, req, adminID, scope interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeUserRole", reflect.TypeOf((*MockUserService)(nil).ChangeUserRole), ctx, req, adminID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeUserRole", reflect.TypeOf((*MockUserService)(nil).ChangeUserRole), ctx, req, adminID, scope)
 }
 
-// CreateFirstAdmin mocks base method.
-func (m *MockUserService) CreateFirstAdmin() bool {
+// ConfirmEmail mocks base method.
+func (m *MockUserService) ConfirmEmail(ctx context.Context, req ConfirmEmailReq) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateFirstAdmin")
-	ret0, _ := ret[0].(bool)
+	ret := m.ctrl.Call(m, "ConfirmEmail", ctx, req)
+	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// CreateFirstAdmin indicates an expected call of CreateFirstAdmin.
-func (mr *MockUserServiceMockRecorder) CreateFirstAdmin() *gomock.Call {
+// ConfirmEmail indicates an expected call of ConfirmEmail.
+func (mr *MockUserServiceMockRecorder) ConfirmEmail(ctx, req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateFirstAdmin", reflect.TypeOf((*MockUserService)(nil).CreateFirstAdmin))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmEmail", reflect.TypeOf((*MockUserService)(nil).ConfirmEmail), ctx, req)
+}
+
+// ConfirmEmailChange mocks base method.
+func (m *MockUserService) ConfirmEmailChange(ctx context.Context, req ConfirmEmailReq) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmEmailChange", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmEmailChange indicates an expected call of ConfirmEmailChange.
+func (mr *MockUserServiceMockRecorder) ConfirmEmailChange(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmEmailChange", reflect.TypeOf((*MockUserService)(nil).ConfirmEmailChange), ctx, req)
+}
+
+// CreateAdmin mocks base method.
+func (m *MockUserService) CreateAdmin(ctx context.Context, req CreateAdminReq) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAdmin", ctx, req)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAdmin indicates an expected call of CreateAdmin.
+func (mr *MockUserServiceMockRecorder) CreateAdmin(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAdmin", reflect.TypeOf((*MockUserService)(nil).CreateAdmin), ctx, req)
 }
 
 // DeleteUser mocks base method.
-func (m *MockUserService) DeleteUser(ctx context.Context, userID uuid.UUID, managerRole string) error {
+func (m *MockUserService) DeleteUser(ctx context.Context, userID, actorID uuid.UUID, privileged bool, scope models.DepartmentScope) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUser", ctx, userID, managerRole)
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, userID, actorID, privileged, scope)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteUser indicates an expected call of DeleteUser.
-func (mr *MockUserServiceMockRecorder) DeleteUser(ctx, userID, managerRole interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) DeleteUser(ctx, userID, actorID, privileged, scope interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserService)(nil).DeleteUser), ctx, userID, actorID, privileged, scope)
+}
+
+// DisableMFA mocks base method.
+func (m *MockUserService) DisableMFA(ctx context.Context, userID uuid.UUID, code string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableMFA", ctx, userID, code)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DisableMFA indicates an expected call of DisableMFA.
+func (mr *MockUserServiceMockRecorder) DisableMFA(ctx, userID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableMFA", reflect.TypeOf((*MockUserService)(nil).DisableMFA), ctx, userID, code)
+}
+
+// EnrollMFA mocks base method.
+func (m *MockUserService) EnrollMFA(ctx context.Context, userID uuid.UUID) (MFAEnrollmentRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollMFA", ctx, userID)
+	ret0, _ := ret[0].(MFAEnrollmentRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollMFA indicates an expected call of EnrollMFA.
+func (mr *MockUserServiceMockRecorder) EnrollMFA(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollMFA", reflect.TypeOf((*MockUserService)(nil).EnrollMFA), ctx, userID)
+}
+
+// EnrollMFAForChallenge mocks base method.
+func (m *MockUserService) EnrollMFAForChallenge(ctx context.Context, req MFATokenReq) (MFAEnrollmentRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnrollMFAForChallenge", ctx, req)
+	ret0, _ := ret[0].(MFAEnrollmentRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnrollMFAForChallenge indicates an expected call of EnrollMFAForChallenge.
+func (mr *MockUserServiceMockRecorder) EnrollMFAForChallenge(ctx, req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUserService)(nil).DeleteUser), ctx, userID, managerRole)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnrollMFAForChallenge", reflect.TypeOf((*MockUserService)(nil).EnrollMFAForChallenge), ctx, req)
+}
+
+// ExportEmployees mocks base method.
+func (m *MockUserService) ExportEmployees(ctx context.Context, filter EmployeeFilter) ([][]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportEmployees", ctx, filter)
+	ret0, _ := ret[0].([][]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportEmployees indicates an expected call of ExportEmployees.
+func (mr *MockUserServiceMockRecorder) ExportEmployees(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportEmployees", reflect.TypeOf((*MockUserService)(nil).ExportEmployees), ctx, filter)
 }
 
 // FirebaseUserRegistration mocks base method.
@@ -92,6 +254,20 @@ func (mr *MockUserServiceMockRecorder) FirebaseUserRegistration(ctx, idToken int
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FirebaseUserRegistration", reflect.TypeOf((*MockUserService)(nil).FirebaseUserRegistration), ctx, idToken)
 }
 
+// ForceLogout mocks base method.
+func (m *MockUserService) ForceLogout(ctx context.Context, adminID, userID uuid.UUID, reason string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForceLogout", ctx, adminID, userID, reason)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForceLogout indicates an expected call of ForceLogout.
+func (mr *MockUserServiceMockRecorder) ForceLogout(ctx, adminID, userID, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceLogout", reflect.TypeOf((*MockUserService)(nil).ForceLogout), ctx, adminID, userID, reason)
+}
+
 // GetDashboard mocks base method.
 func (m *MockUserService) GetDashboard(ctx context.Context, userID uuid.UUID) (UserDashboardRes, error) {
 	m.ctrl.T.Helper()
@@ -107,19 +283,64 @@ func (mr *MockUserServiceMockRecorder) GetDashboard(ctx, userID interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDashboard", reflect.TypeOf((*MockUserService)(nil).GetDashboard), ctx, userID)
 }
 
+// GetDashboardSummary mocks base method.
+func (m *MockUserService) GetDashboardSummary(ctx context.Context, userID uuid.UUID) (DashboardSummaryRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDashboardSummary", ctx, userID)
+	ret0, _ := ret[0].(DashboardSummaryRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDashboardSummary indicates an expected call of GetDashboardSummary.
+func (mr *MockUserServiceMockRecorder) GetDashboardSummary(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDashboardSummary", reflect.TypeOf((*MockUserService)(nil).GetDashboardSummary), ctx, userID)
+}
+
+// GetDirectory mocks base method.
+func (m *MockUserService) GetDirectory(ctx context.Context, filter DirectoryFilter) ([]DirectoryEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDirectory", ctx, filter)
+	ret0, _ := ret[0].([]DirectoryEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDirectory indicates an expected call of GetDirectory.
+func (mr *MockUserServiceMockRecorder) GetDirectory(ctx, filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDirectory", reflect.TypeOf((*MockUserService)(nil).GetDirectory), ctx, filter)
+}
+
+// GetDirectorySyncReports mocks base method.
+func (m *MockUserService) GetDirectorySyncReports(ctx context.Context, limit, offset int) ([]DirectorySyncReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDirectorySyncReports", ctx, limit, offset)
+	ret0, _ := ret[0].([]DirectorySyncReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDirectorySyncReports indicates an expected call of GetDirectorySyncReports.
+func (mr *MockUserServiceMockRecorder) GetDirectorySyncReports(ctx, limit, offset interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDirectorySyncReports", reflect.TypeOf((*MockUserService)(nil).GetDirectorySyncReports), ctx, limit, offset)
+}
+
 // GetEmployeeTimeline mocks base method.
-func (m *MockUserService) GetEmployeeTimeline(ctx context.Context, userID uuid.UUID) ([]UserTimelineRes, error) {
+func (m *MockUserService) GetEmployeeTimeline(ctx context.Context, userID uuid.UUID, limit, offset int, scope models.DepartmentScope) ([]UserTimelineRes, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetEmployeeTimeline", ctx, userID)
+	ret := m.ctrl.Call(m, "GetEmployeeTimeline", ctx, userID, limit, offset, scope)
 	ret0, _ := ret[0].([]UserTimelineRes)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetEmployeeTimeline indicates an expected call of GetEmployeeTimeline.
-func (mr *MockUserServiceMockRecorder) GetEmployeeTimeline(ctx, userID interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) GetEmployeeTimeline(ctx, userID, limit, offset, scope interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmployeeTimeline", reflect.TypeOf((*MockUserService)(nil).GetEmployeeTimeline), ctx, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmployeeTimeline", reflect.TypeOf((*MockUserService)(nil).GetEmployeeTimeline), ctx, userID, limit, offset, scope)
 }
 
 // GetEmployeesWithFilters mocks base method.
@@ -137,21 +358,137 @@ func (mr *MockUserServiceMockRecorder) GetEmployeesWithFilters(ctx, filter inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmployeesWithFilters", reflect.TypeOf((*MockUserService)(nil).GetEmployeesWithFilters), ctx, filter)
 }
 
-// GoogleAuth mocks base method.
-func (m *MockUserService) GoogleAuth(ctx context.Context, idToken string) (uuid.UUID, string, string, error) {
+// GetRoleGrantApprovals mocks base method.
+func (m *MockUserService) GetRoleGrantApprovals(ctx context.Context, status string, limit, offset int, scope models.DepartmentScope) ([]RoleGrantApprovalRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoleGrantApprovals", ctx, status, limit, offset, scope)
+	ret0, _ := ret[0].([]RoleGrantApprovalRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoleGrantApprovals indicates an expected call of GetRoleGrantApprovals.
+func (mr *MockUserServiceMockRecorder) GetRoleGrantApprovals(ctx, status, limit, offset, scope interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoleGrantApprovals", reflect.TypeOf((*MockUserService)(nil).GetRoleGrantApprovals), ctx, status, limit, offset, scope)
+}
+
+// GetRoleHistory mocks base method.
+func (m *MockUserService) GetRoleHistory(ctx context.Context, userID uuid.UUID, scope models.DepartmentScope) ([]RoleHistoryRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoleHistory", ctx, userID, scope)
+	ret0, _ := ret[0].([]RoleHistoryRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoleHistory indicates an expected call of GetRoleHistory.
+func (mr *MockUserServiceMockRecorder) GetRoleHistory(ctx, userID, scope interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoleHistory", reflect.TypeOf((*MockUserService)(nil).GetRoleHistory), ctx, userID, scope)
+}
+
+// GetScheduledRoleChanges mocks base method.
+func (m *MockUserService) GetScheduledRoleChanges(ctx context.Context, userID *uuid.UUID, limit, offset int, scope models.DepartmentScope) ([]ScheduledRoleChangeRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetScheduledRoleChanges", ctx, userID, limit, offset, scope)
+	ret0, _ := ret[0].([]ScheduledRoleChangeRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetScheduledRoleChanges indicates an expected call of GetScheduledRoleChanges.
+func (mr *MockUserServiceMockRecorder) GetScheduledRoleChanges(ctx, userID, limit, offset, scope interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetScheduledRoleChanges", reflect.TypeOf((*MockUserService)(nil).GetScheduledRoleChanges), ctx, userID, limit, offset, scope)
+}
+
+// GetUserIDByEmail mocks base method.
+func (m *MockUserService) GetUserIDByEmail(ctx context.Context, email string) (uuid.UUID, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GoogleAuth", ctx, idToken)
+	ret := m.ctrl.Call(m, "GetUserIDByEmail", ctx, email)
 	ret0, _ := ret[0].(uuid.UUID)
-	ret1, _ := ret[1].(string)
-	ret2, _ := ret[2].(string)
-	ret3, _ := ret[3].(error)
-	return ret0, ret1, ret2, ret3
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// GoogleAuth indicates an expected call of GoogleAuth.
-func (mr *MockUserServiceMockRecorder) GoogleAuth(ctx, idToken interface{}) *gomock.Call {
+// GetUserIDByEmail indicates an expected call of GetUserIDByEmail.
+func (mr *MockUserServiceMockRecorder) GetUserIDByEmail(ctx, email interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GoogleAuth", reflect.TypeOf((*MockUserService)(nil).GoogleAuth), ctx, idToken)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserIDByEmail", reflect.TypeOf((*MockUserService)(nil).GetUserIDByEmail), ctx, email)
+}
+
+// InviteEmployee mocks base method.
+func (m *MockUserService) InviteEmployee(ctx context.Context, req InviteEmployeeReq, managerID uuid.UUID, scope models.DepartmentScope) (InviteRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InviteEmployee", ctx, req, managerID, scope)
+	ret0, _ := ret[0].(InviteRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InviteEmployee indicates an expected call of InviteEmployee.
+func (mr *MockUserServiceMockRecorder) InviteEmployee(ctx, req, managerID, scope interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InviteEmployee", reflect.TypeOf((*MockUserService)(nil).InviteEmployee), ctx, req, managerID, scope)
+}
+
+// Logout mocks base method.
+func (m *MockUserService) Logout(ctx context.Context, userID uuid.UUID, req LogoutReq, client models.ClientInfo) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", ctx, userID, req, client)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockUserServiceMockRecorder) Logout(ctx, userID, req, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockUserService)(nil).Logout), ctx, userID, req, client)
+}
+
+// MagicLinkLogin mocks base method.
+func (m *MockUserService) MagicLinkLogin(ctx context.Context, req MagicLinkLoginReq, client models.ClientInfo) (LoginRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MagicLinkLogin", ctx, req, client)
+	ret0, _ := ret[0].(LoginRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MagicLinkLogin indicates an expected call of MagicLinkLogin.
+func (mr *MockUserServiceMockRecorder) MagicLinkLogin(ctx, req, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MagicLinkLogin", reflect.TypeOf((*MockUserService)(nil).MagicLinkLogin), ctx, req, client)
+}
+
+// OIDCLogin mocks base method.
+func (m *MockUserService) OIDCLogin(ctx context.Context, provider, idToken string, client models.ClientInfo) (LoginRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OIDCLogin", ctx, provider, idToken, client)
+	ret0, _ := ret[0].(LoginRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// OIDCLogin indicates an expected call of OIDCLogin.
+func (mr *MockUserServiceMockRecorder) OIDCLogin(ctx, provider, idToken, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OIDCLogin", reflect.TypeOf((*MockUserService)(nil).OIDCLogin), ctx, provider, idToken, client)
+}
+
+// ProcessEmployeeEndDates mocks base method.
+func (m *MockUserService) ProcessEmployeeEndDates(ctx context.Context, warningDays int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProcessEmployeeEndDates", ctx, warningDays)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ProcessEmployeeEndDates indicates an expected call of ProcessEmployeeEndDates.
+func (mr *MockUserServiceMockRecorder) ProcessEmployeeEndDates(ctx, warningDays interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessEmployeeEndDates", reflect.TypeOf((*MockUserService)(nil).ProcessEmployeeEndDates), ctx, warningDays)
 }
 
 // PublicRegister mocks base method.
@@ -170,48 +507,293 @@ func (mr *MockUserServiceMockRecorder) PublicRegister(ctx, req interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublicRegister", reflect.TypeOf((*MockUserService)(nil).PublicRegister), ctx, req)
 }
 
+// ReconcileFirebaseClaims mocks base method.
+func (m *MockUserService) ReconcileFirebaseClaims(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileFirebaseClaims", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReconcileFirebaseClaims indicates an expected call of ReconcileFirebaseClaims.
+func (mr *MockUserServiceMockRecorder) ReconcileFirebaseClaims(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileFirebaseClaims", reflect.TypeOf((*MockUserService)(nil).ReconcileFirebaseClaims), ctx)
+}
+
+// RefreshTokens mocks base method.
+func (m *MockUserService) RefreshTokens(ctx context.Context, req RefreshTokenReq, client models.ClientInfo) (RefreshTokenRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshTokens", ctx, req, client)
+	ret0, _ := ret[0].(RefreshTokenRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RefreshTokens indicates an expected call of RefreshTokens.
+func (mr *MockUserServiceMockRecorder) RefreshTokens(ctx, req, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshTokens", reflect.TypeOf((*MockUserService)(nil).RefreshTokens), ctx, req, client)
+}
+
+// RegenerateBackupCodes mocks base method.
+func (m *MockUserService) RegenerateBackupCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegenerateBackupCodes", ctx, userID, code)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegenerateBackupCodes indicates an expected call of RegenerateBackupCodes.
+func (mr *MockUserServiceMockRecorder) RegenerateBackupCodes(ctx, userID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegenerateBackupCodes", reflect.TypeOf((*MockUserService)(nil).RegenerateBackupCodes), ctx, userID, code)
+}
+
 // RegisterEmployeeByManager mocks base method.
-func (m *MockUserService) RegisterEmployeeByManager(ctx context.Context, req ManagerRegisterReq, managerID uuid.UUID) (uuid.UUID, error) {
+func (m *MockUserService) RegisterEmployeeByManager(ctx context.Context, req ManagerRegisterReq, managerID uuid.UUID, scope models.DepartmentScope) (uuid.UUID, *models.OnboardingRes, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RegisterEmployeeByManager", ctx, req, managerID)
+	ret := m.ctrl.Call(m, "RegisterEmployeeByManager", ctx, req, managerID, scope)
 	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(*models.OnboardingRes)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RegisterEmployeeByManager indicates an expected call of RegisterEmployeeByManager.
+func (mr *MockUserServiceMockRecorder) RegisterEmployeeByManager(ctx, req, managerID, scope interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterEmployeeByManager", reflect.TypeOf((*MockUserService)(nil).RegisterEmployeeByManager), ctx, req, managerID, scope)
+}
+
+// RejectRoleGrant mocks base method.
+func (m *MockUserService) RejectRoleGrant(ctx context.Context, id uuid.UUID, decider RoleGrantDecider, note string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectRoleGrant", ctx, id, decider, note)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RejectRoleGrant indicates an expected call of RejectRoleGrant.
+func (mr *MockUserServiceMockRecorder) RejectRoleGrant(ctx, id, decider, note interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectRoleGrant", reflect.TypeOf((*MockUserService)(nil).RejectRoleGrant), ctx, id, decider, note)
+}
+
+// RequestEmailChange mocks base method.
+func (m *MockUserService) RequestEmailChange(ctx context.Context, userID uuid.UUID, newEmail string, requestedBy uuid.UUID, scope models.DepartmentScope) (EmailChangeRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestEmailChange", ctx, userID, newEmail, requestedBy, scope)
+	ret0, _ := ret[0].(EmailChangeRes)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// RegisterEmployeeByManager indicates an expected call of RegisterEmployeeByManager.
-func (mr *MockUserServiceMockRecorder) RegisterEmployeeByManager(ctx, req, managerID interface{}) *gomock.Call {
+// RequestEmailChange indicates an expected call of RequestEmailChange.
+func (mr *MockUserServiceMockRecorder) RequestEmailChange(ctx, userID, newEmail, requestedBy, scope interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterEmployeeByManager", reflect.TypeOf((*MockUserService)(nil).RegisterEmployeeByManager), ctx, req, managerID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestEmailChange", reflect.TypeOf((*MockUserService)(nil).RequestEmailChange), ctx, userID, newEmail, requestedBy, scope)
 }
 
-// UpdateEmployee mocks base method.
-func (m *MockUserService) UpdateEmployee(ctx context.Context, req UpdateEmployeeReq, managerID uuid.UUID) error {
+// RequestMagicLink mocks base method.
+func (m *MockUserService) RequestMagicLink(ctx context.Context, req PublicUserReq) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestMagicLink", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequestMagicLink indicates an expected call of RequestMagicLink.
+func (mr *MockUserServiceMockRecorder) RequestMagicLink(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestMagicLink", reflect.TypeOf((*MockUserService)(nil).RequestMagicLink), ctx, req)
+}
+
+// ResendVerification mocks base method.
+func (m *MockUserService) ResendVerification(ctx context.Context, req PublicUserReq) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResendVerification", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResendVerification indicates an expected call of ResendVerification.
+func (mr *MockUserServiceMockRecorder) ResendVerification(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResendVerification", reflect.TypeOf((*MockUserService)(nil).ResendVerification), ctx, req)
+}
+
+// ResetMFA mocks base method.
+func (m *MockUserService) ResetMFA(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetMFA", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetMFA indicates an expected call of ResetMFA.
+func (mr *MockUserServiceMockRecorder) ResetMFA(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetMFA", reflect.TypeOf((*MockUserService)(nil).ResetMFA), ctx, userID)
+}
+
+// ResetUserRoles mocks base method.
+func (m *MockUserService) ResetUserRoles(ctx context.Context, userID uuid.UUID, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetUserRoles", ctx, userID, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetUserRoles indicates an expected call of ResetUserRoles.
+func (mr *MockUserServiceMockRecorder) ResetUserRoles(ctx, userID, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetUserRoles", reflect.TypeOf((*MockUserService)(nil).ResetUserRoles), ctx, userID, role)
+}
+
+// ScheduleRoleChange mocks base method.
+func (m *MockUserService) ScheduleRoleChange(ctx context.Context, req ScheduleRoleChangeReq, adminID uuid.UUID, scope models.DepartmentScope) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ScheduleRoleChange", ctx, req, adminID, scope)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ScheduleRoleChange indicates an expected call of ScheduleRoleChange.
+func (mr *MockUserServiceMockRecorder) ScheduleRoleChange(ctx, req, adminID, scope interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ScheduleRoleChange", reflect.TypeOf((*MockUserService)(nil).ScheduleRoleChange), ctx, req, adminID, scope)
+}
+
+// SendContactOTP mocks base method.
+func (m *MockUserService) SendContactOTP(ctx context.Context, req ContactOTPReq) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendContactOTP", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendContactOTP indicates an expected call of SendContactOTP.
+func (mr *MockUserServiceMockRecorder) SendContactOTP(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendContactOTP", reflect.TypeOf((*MockUserService)(nil).SendContactOTP), ctx, req)
+}
+
+// SetTimezone mocks base method.
+func (m *MockUserService) SetTimezone(ctx context.Context, userID uuid.UUID, timezone string) (TimezoneRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTimezone", ctx, userID, timezone)
+	ret0, _ := ret[0].(TimezoneRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetTimezone indicates an expected call of SetTimezone.
+func (mr *MockUserServiceMockRecorder) SetTimezone(ctx, userID, timezone interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTimezone", reflect.TypeOf((*MockUserService)(nil).SetTimezone), ctx, userID, timezone)
+}
+
+// StreamEmployees mocks base method.
+func (m *MockUserService) StreamEmployees(ctx context.Context, filter EmployeeFilter, fn func(EmployeeExportRow) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamEmployees", ctx, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamEmployees indicates an expected call of StreamEmployees.
+func (mr *MockUserServiceMockRecorder) StreamEmployees(ctx, filter, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamEmployees", reflect.TypeOf((*MockUserService)(nil).StreamEmployees), ctx, filter, fn)
+}
+
+// SyncDirectory mocks base method.
+func (m *MockUserService) SyncDirectory(ctx context.Context, dryRun bool, triggeredBy *uuid.UUID) (DirectorySyncReport, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateEmployee", ctx, req, managerID)
+	ret := m.ctrl.Call(m, "SyncDirectory", ctx, dryRun, triggeredBy)
+	ret0, _ := ret[0].(DirectorySyncReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncDirectory indicates an expected call of SyncDirectory.
+func (mr *MockUserServiceMockRecorder) SyncDirectory(ctx, dryRun, triggeredBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncDirectory", reflect.TypeOf((*MockUserService)(nil).SyncDirectory), ctx, dryRun, triggeredBy)
+}
+
+// UnlockLogin mocks base method.
+func (m *MockUserService) UnlockLogin(ctx context.Context, adminID uuid.UUID, req UnlockLoginReq) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockLogin", ctx, adminID, req)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// UnlockLogin indicates an expected call of UnlockLogin.
+func (mr *MockUserServiceMockRecorder) UnlockLogin(ctx, adminID, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockLogin", reflect.TypeOf((*MockUserService)(nil).UnlockLogin), ctx, adminID, req)
+}
+
+// UpdateEmployee mocks base method.
+func (m *MockUserService) UpdateEmployee(ctx context.Context, req UpdateEmployeeReq, managerID uuid.UUID, scope models.DepartmentScope) (EmployeeRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEmployee", ctx, req, managerID, scope)
+	ret0, _ := ret[0].(EmployeeRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // UpdateEmployee indicates an expected call of UpdateEmployee.
-func (mr *MockUserServiceMockRecorder) UpdateEmployee(ctx, req, managerID interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) UpdateEmployee(ctx, req, managerID, scope interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEmployee", reflect.TypeOf((*MockUserService)(nil).UpdateEmployee), ctx, req, managerID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEmployee", reflect.TypeOf((*MockUserService)(nil).UpdateEmployee), ctx, req, managerID, scope)
 }
 
 // UserLogin mocks base method.
-func (m *MockUserService) UserLogin(ctx context.Context, req PublicUserReq) (uuid.UUID, string, string, error) {
+func (m *MockUserService) UserLogin(ctx context.Context, req PublicUserReq, client models.ClientInfo) (LoginRes, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UserLogin", ctx, req)
-	ret0, _ := ret[0].(uuid.UUID)
-	ret1, _ := ret[1].(string)
-	ret2, _ := ret[2].(string)
-	ret3, _ := ret[3].(error)
-	return ret0, ret1, ret2, ret3
+	ret := m.ctrl.Call(m, "UserLogin", ctx, req, client)
+	ret0, _ := ret[0].(LoginRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // UserLogin indicates an expected call of UserLogin.
-func (mr *MockUserServiceMockRecorder) UserLogin(ctx, req interface{}) *gomock.Call {
+func (mr *MockUserServiceMockRecorder) UserLogin(ctx, req, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserLogin", reflect.TypeOf((*MockUserService)(nil).UserLogin), ctx, req, client)
+}
+
+// VerifyMFAChallenge mocks base method.
+func (m *MockUserService) VerifyMFAChallenge(ctx context.Context, req MFAChallengeReq, client models.ClientInfo) (LoginRes, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyMFAChallenge", ctx, req, client)
+	ret0, _ := ret[0].(LoginRes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyMFAChallenge indicates an expected call of VerifyMFAChallenge.
+func (mr *MockUserServiceMockRecorder) VerifyMFAChallenge(ctx, req, client interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyMFAChallenge", reflect.TypeOf((*MockUserService)(nil).VerifyMFAChallenge), ctx, req, client)
+}
+
+// WarmDashboardCache mocks base method.
+func (m *MockUserService) WarmDashboardCache(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WarmDashboardCache", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WarmDashboardCache indicates an expected call of WarmDashboardCache.
+func (mr *MockUserServiceMockRecorder) WarmDashboardCache(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserLogin", reflect.TypeOf((*MockUserService)(nil).UserLogin), ctx, req)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WarmDashboardCache", reflect.TypeOf((*MockUserService)(nil).WarmDashboardCache), ctx)
 }
@@ -1,6 +1,7 @@
 package userservice
 
 import (
+	"asset/models"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 	"time"
@@ -14,38 +15,269 @@ type ManagerRegisterReq struct {
 	Username  string `json:"username" validate:"required"`
 	Email     string `json:"email" validate:"required,email"`
 	ContactNo string `json:"contact_no" validate:"required"`
-	Type      string `json:"type" validate:"required,oneof=full_time intern freelancer"`
+	Type      string `json:"type" validate:"required,employee_type"`
+	ProfileFields
+	// ignored for department scoped managers, the manager's own department is used instead
+	DepartmentID *uuid.UUID `json:"department_id,omitempty"`
+	// last working day, only for interns and freelancers
+	EndDate *time.Time `json:"end_date,omitempty"`
+	// optional onboarding template, sets the role and department and hands out its asset kit
+	TemplateID *uuid.UUID `json:"template_id,omitempty"`
+}
+
+// InviteEmployeeReq is what a manager fills in, the employee adds their name and contact number when accepting
+type InviteEmployeeReq struct {
+	Email string `json:"email" validate:"required,email"`
+	Type  string `json:"type" validate:"required,employee_type"`
+	ProfileFields
+	// ignored for department scoped managers, the manager's own department is used instead
+	DepartmentID *uuid.UUID `json:"department_id,omitempty"`
+	EndDate      *time.Time `json:"end_date,omitempty"`
+	TemplateID   *uuid.UUID `json:"template_id,omitempty"`
+}
+
+type AcceptInviteReq struct {
+	Token     string `json:"token" validate:"required"`
+	Username  string `json:"username" validate:"required"`
+	ContactNo string `json:"contact_no" validate:"required"`
+	// code texted to the contact number, required when SMS_VERIFY_CONTACT_NO is on
+	OTP string `json:"otp,omitempty"`
+}
+
+type ContactOTPReq struct {
+	ContactNo string `json:"contact_no" validate:"required"`
+}
+
+type ConfirmEmailReq struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type MagicLinkLoginReq struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ChangeEmailReq struct {
+	NewEmail string `json:"new_email" validate:"required,email"`
+}
+
+// an empty timezone goes back to UTC
+type TimezoneReq struct {
+	Timezone string `json:"timezone" validate:"max=64"`
+}
+
+type TimezoneRes struct {
+	Timezone *string `json:"timezone"`
+}
+
+type ManagerChangeEmailReq struct {
+	UserID   uuid.UUID `json:"user_id" validate:"required"`
+	NewEmail string    `json:"new_email" validate:"required,email"`
+}
+
+type EmailChangeRes struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	OldEmail  string    `json:"old_email" db:"old_email"`
+	NewEmail  string    `json:"new_email" db:"new_email"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+}
+
+type EmailChangeRequest struct {
+	EmailChangeRes
+	UserID      uuid.UUID  `db:"user_id"`
+	RequestedBy uuid.UUID  `db:"requested_by"`
+	ConfirmedAt *time.Time `db:"confirmed_at"`
+	ArchivedAt  *time.Time `db:"archived_at"`
+}
+
+type EmailVerificationStatus struct {
+	ID       uuid.UUID `db:"id"`
+	Verified bool      `db:"verified"`
+}
+
+type InviteRes struct {
+	ID        uuid.UUID `json:"id"`
+	Email     string    `json:"email"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type UserInvite struct {
+	ID         uuid.UUID  `db:"id"`
+	Email      string     `db:"email"`
+	Payload    []byte     `db:"payload"`
+	InvitedBy  uuid.UUID  `db:"invited_by"`
+	ExpiresAt  time.Time  `db:"expires_at"`
+	AcceptedAt *time.Time `db:"accepted_at"`
+	ArchivedAt *time.Time `db:"archived_at"`
 }
 
 type EmployeeResponseModel struct {
-	ID             string         `json:"id" db:"id"`
-	Username       string         `json:"username" db:"username"`
-	Email          string         `json:"email" db:"email"`
-	ContactNo      *string        `json:"contact_no" db:"contact_no"`
-	EmployeeType   string         `json:"type" db:"employee_type"`
+	ID           string  `json:"id" db:"id"`
+	Username     string  `json:"username" db:"username"`
+	Email        string  `json:"email" db:"email"`
+	ContactNo    *string `json:"contact_no" db:"contact_no"`
+	EmployeeType string  `json:"type" db:"employee_type"`
+	ProfileRes
 	AssignedAssets pq.StringArray `json:"assigned_assets" db:"assigned_assets"`
 }
 
+// one row of the hr employee export, the ndjson stream sends it as is
+type EmployeeExportRow struct {
+	ID           string  `json:"id" db:"id"`
+	Username     string  `json:"username" db:"username"`
+	Email        string  `json:"email" db:"email"`
+	ContactNo    *string `json:"contact_no,omitempty" db:"contact_no"`
+	EmployeeType *string `json:"type,omitempty" db:"employee_type"`
+	ProfileRes
+	Roles        pq.StringArray `json:"roles" db:"roles"`
+	AssetSerials pq.StringArray `json:"asset_serials" db:"asset_serials"`
+}
+
+// optional profile fields accepted on registration and update
+type ProfileFields struct {
+	Designation          string     `json:"designation,omitempty"`
+	Location             string     `json:"location,omitempty"`
+	DateOfJoining        *time.Time `json:"date_of_joining,omitempty"`
+	EmergencyContactName string     `json:"emergency_contact_name,omitempty"`
+	EmergencyContactNo   string     `json:"emergency_contact_no,omitempty"`
+}
+
+type ProfileRes struct {
+	Designation          *string    `json:"designation,omitempty" db:"designation"`
+	Location             *string    `json:"location,omitempty" db:"location"`
+	DateOfJoining        *time.Time `json:"date_of_joining,omitempty" db:"date_of_joining"`
+	EmergencyContactName *string    `json:"emergency_contact_name,omitempty" db:"emergency_contact_name"`
+	EmergencyContactNo   *string    `json:"emergency_contact_no,omitempty" db:"emergency_contact_no"`
+}
+
+// CreateAdminReq is what assetctl create-admin takes
+type CreateAdminReq struct {
+	Username string `validate:"required"`
+	Email    string `validate:"required,email"`
+	Type     string `validate:"required,employee_type"`
+}
+
 type UpdateUserRoleReq struct {
 	UserID string `json:"user_id" validate:"required,uuid"`
-	Role   string `json:"role" validate:"required,oneof=admin asset_manager employee_manager user"`
+	Role   string `json:"role" validate:"required"`
+}
+
+const (
+	RoleChangeApplied         = "applied"
+	RoleChangePendingApproval = "pending_approval"
+)
+
+// ChangeUserRoleRes says whether the change is done or waits for a second admin
+type ChangeUserRoleRes struct {
+	Status     string     `json:"status"`
+	ApprovalID *uuid.UUID `json:"approval_id,omitempty"`
+}
+
+// role grant approvals, privileged role grants only apply once another admin approved them
+const (
+	RoleGrantPending  = "pending"
+	RoleGrantApproved = "approved"
+	RoleGrantRejected = "rejected"
+)
+
+type RoleGrantApprovalRes struct {
+	ID           uuid.UUID  `json:"id" db:"id"`
+	UserID       uuid.UUID  `json:"user_id" db:"user_id"`
+	Role         string     `json:"role" db:"role"`
+	PreviousRole *string    `json:"previous_role,omitempty" db:"previous_role"`
+	Status       string     `json:"status" db:"status"`
+	RequestedBy  uuid.UUID  `json:"requested_by" db:"requested_by"`
+	RequestedAt  time.Time  `json:"requested_at" db:"requested_at"`
+	DecidedBy    *uuid.UUID `json:"decided_by,omitempty" db:"decided_by"`
+	DecidedAt    *time.Time `json:"decided_at,omitempty" db:"decided_at"`
+	DecisionNote *string    `json:"decision_note,omitempty" db:"decision_note"`
+}
+
+// RoleGrantDecider is the admin approving or rejecting a grant, their scope limits them to grants for
+// users of their own organization
+type RoleGrantDecider struct {
+	ID    uuid.UUID
+	Roles []string
+	Scope models.DepartmentScope
+}
+
+type RoleGrantDecisionReq struct {
+	ID   string `json:"id" validate:"required,uuid"`
+	Note string `json:"note" validate:"max=500"`
 }
 
 type UpdateEmployeeReq struct {
-	UserID    uuid.UUID `json:"user_id" validate:"required"`
-	Username  string    `json:"username,omitempty"`
-	Email     string    `json:"email,omitempty"`
-	ContactNo string    `json:"contact_no,omitempty"`
+	UserID    uuid.UUID  `json:"user_id" validate:"required"`
+	Username  string     `json:"username,omitempty"`
+	Email     string     `json:"email,omitempty"`
+	ContactNo string     `json:"contact_no,omitempty"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
+	// what the employee's badge or id card scans as at a kiosk
+	BadgeID string `json:"badge_id,omitempty" validate:"max=64"`
+	ProfileFields
+	// fields to set back to NULL, empty values above still mean "leave as is"
+	Clear []string `json:"clear,omitempty" validate:"omitempty,dive,oneof=contact_no end_date designation location date_of_joining emergency_contact_name emergency_contact_no badge_id"`
+}
+
+// the employee as stored after an update
+type EmployeeRes struct {
+	ID           string     `json:"id" db:"id"`
+	Username     string     `json:"username" db:"username"`
+	Email        string     `json:"email" db:"email"`
+	ContactNo    *string    `json:"contact_no" db:"contact_no"`
+	Type         *string    `json:"type" db:"type"`
+	DepartmentID *string    `json:"department_id" db:"department_id"`
+	EndDate      *time.Time `json:"end_date" db:"end_date"`
+	BadgeID      *string    `json:"badge_id,omitempty" db:"badge_id"`
+	ProfileRes
 }
 
+// one event in the employee history, only the fields relevant to the event type are set.
+// event_type is one of asset_assigned, asset_returned, role_granted, role_revoked, type_changed,
+// department_changed, asset_service_started or asset_service_ended
 type UserTimelineRes struct {
-	AssetID      string     `json:"asset_id" db:"asset_id"`
-	Brand        string     `json:"brand" db:"brand"`
-	Model        string     `json:"model" db:"model"`
-	SerialNo     string     `json:"serial_no" db:"serial_no"`
-	AssignedAt   time.Time  `json:"assigned_at" db:"assigned_at"`
-	ReturnedAt   *time.Time `json:"returned_at,omitempty" db:"returned_at"`
-	ReturnReason *string    `json:"return_reason,omitempty" db:"return_reason"`
+	EventType  string    `json:"event_type" db:"event_type"`
+	OccurredAt time.Time `json:"occurred_at" db:"occurred_at"`
+	AssetID    *string   `json:"asset_id,omitempty" db:"asset_id"`
+	Brand      *string   `json:"brand,omitempty" db:"brand"`
+	Model      *string   `json:"model,omitempty" db:"model"`
+	SerialNo   *string   `json:"serial_no,omitempty" db:"serial_no"`
+	FromValue  *string   `json:"from_value,omitempty" db:"from_value"`
+	ToValue    *string   `json:"to_value,omitempty" db:"to_value"`
+	Reason     *string   `json:"reason,omitempty" db:"reason"`
+	ActorID    *string   `json:"actor_id,omitempty" db:"actor_id"`
+	ActorName  *string   `json:"actor_name,omitempty" db:"actor_name"`
+	// only on asset_assigned events
+	Metadata *models.AssignmentMetadata `json:"metadata,omitempty" db:"metadata"`
+}
+
+// role grants and revocations, revoked_at is empty for the active role
+type RoleHistoryRes struct {
+	Role          string     `json:"role" db:"role"`
+	GrantedAt     time.Time  `json:"granted_at" db:"granted_at"`
+	GrantedBy     *string    `json:"granted_by,omitempty" db:"granted_by"`
+	GrantedByName *string    `json:"granted_by_name,omitempty" db:"granted_by_name"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokedBy     *string    `json:"revoked_by,omitempty" db:"revoked_by"`
+	RevokedByName *string    `json:"revoked_by_name,omitempty" db:"revoked_by_name"`
+}
+
+type ScheduleRoleChangeReq struct {
+	UserID        string     `json:"user_id" validate:"required,uuid"`
+	Role          string     `json:"role" validate:"required"`
+	EffectiveFrom time.Time  `json:"effective_from" validate:"required"`
+	EffectiveTo   *time.Time `json:"effective_to,omitempty"`
+}
+
+type ScheduledRoleChangeRes struct {
+	ID            uuid.UUID  `json:"id" db:"id"`
+	UserID        uuid.UUID  `json:"user_id" db:"user_id"`
+	Role          string     `json:"role" db:"role"`
+	PreviousRole  *string    `json:"previous_role,omitempty" db:"previous_role"`
+	EffectiveFrom time.Time  `json:"effective_from" db:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to,omitempty" db:"effective_to"`
+	Status        string     `json:"status" db:"status"`
+	CreatedBy     uuid.UUID  `json:"created_by" db:"created_by"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
 }
 
 // /search using filters
@@ -55,19 +287,50 @@ type EmployeeFilter struct {
 	Type         []string
 	Role         []string
 	AssetStatus  []string
+	Designation  []string
+	Location     []string
 	Limit        int
 	Offset       int
+	Scope        models.DepartmentScope
+}
+
+// DirectoryFilter narrows the company directory, organization comes from the caller and isn't
+// taken from the query
+type DirectoryFilter struct {
+	Search         string
+	DepartmentID   *uuid.UUID
+	OrganizationID *uuid.UUID
+	// a platform admin has no organization in scope outside a subdomain, the directory then lists
+	// the caller's own organization
+	CallerID uuid.UUID
+	Limit    int
+	Offset   int
+}
+
+// DirectoryEntry is what every employee may see about a colleague, nothing about their assets,
+// contact numbers or employment
+type DirectoryEntry struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Username    string    `json:"username" db:"username"`
+	Email       string    `json:"email" db:"email"`
+	Department  *string   `json:"department,omitempty" db:"department"`
+	Designation *string   `json:"designation,omitempty" db:"designation"`
 }
 
 // user dashboard
 type UserDashboardRes struct {
-	ID             string         `json:"id" db:"id"`
-	Username       string         `json:"username" db:"username"`
-	Email          string         `json:"email" db:"email"`
-	ContactNo      *string        `json:"contact_no,omitempty" db:"contact_no"`
-	Type           *string        `json:"type,omitempty" db:"type"`
+	ID        string  `json:"id" db:"id"`
+	Username  string  `json:"username" db:"username"`
+	Email     string  `json:"email" db:"email"`
+	ContactNo *string `json:"contact_no,omitempty" db:"contact_no"`
+	// set once the number was confirmed with a texted code, cleared when it changes
+	ContactVerifiedAt *time.Time `json:"contact_verified_at,omitempty" db:"contact_verified_at"`
+	Type              *string    `json:"type,omitempty" db:"type"`
+	ProfileRes
 	Roles          []string       `json:"roles"`
 	AssignedAssets []AssetDetails `json:"assigned_assets"`
+	// not cached with the rest of the dashboard, filled by the service on every request
+	PendingRoleChanges []ScheduledRoleChangeRes `json:"pending_role_changes,omitempty"`
 }
 type AssetDetails struct {
 	ID         uuid.UUID `json:"id" db:"id"`
@@ -79,3 +342,154 @@ type AssetDetails struct {
 	OwnedBy    string    `json:"owned_by" db:"owned_by"`
 	AssignedAt time.Time `json:"assigned_at" db:"assigned_at"`
 }
+
+// slim dashboard for the mobile app, counts and just enough of each asset to list it
+type DashboardSummaryRes struct {
+	AssetCount         int            `json:"asset_count"`
+	AssetsByType       map[string]int `json:"assets_by_type"`
+	AssetsByStatus     map[string]int `json:"assets_by_status"`
+	PendingRoleChanges int            `json:"pending_role_changes"`
+	Assets             []AssetSummary `json:"assets"`
+}
+
+type AssetSummary struct {
+	ID    uuid.UUID `json:"id"`
+	Type  string    `json:"type"`
+	Model string    `json:"model"`
+}
+
+// employee with an end date and the number of assets they still hold
+type EndingEmployeeRes struct {
+	UserID            uuid.UUID  `db:"user_id"`
+	Username          string     `db:"username"`
+	EndDate           time.Time  `db:"end_date"`
+	DepartmentID      *uuid.UUID `db:"department_id"`
+	OrganizationID    uuid.UUID  `db:"organization_id"`
+	OutstandingAssets int        `db:"outstanding_assets"`
+}
+
+// login result, tokens stay empty while an mfa challenge is pending
+type LoginRes struct {
+	UserID                uuid.UUID `json:"user_id"`
+	AccessToken           string    `json:"access_token,omitempty"`
+	RefreshToken          string    `json:"refresh_token,omitempty"`
+	MFARequired           bool      `json:"mfa_required,omitempty"`
+	MFAEnrollmentRequired bool      `json:"mfa_enrollment_required,omitempty"`
+	MFAToken              string    `json:"mfa_token,omitempty"`
+	// set instead of the tokens in cookie mode
+	CSRFToken string `json:"csrf_token,omitempty"`
+}
+
+type RefreshTokenReq struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// IntrospectTokenReq takes the token as json or, as RFC 7662 has it, as a form field
+type IntrospectTokenReq struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type LogoutReq struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type RefreshTokenRes struct {
+	AccessToken          string    `json:"access_token,omitempty"`
+	RefreshToken         string    `json:"refresh_token,omitempty"`
+	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+	// set instead of the tokens in cookie mode
+	CSRFToken string `json:"csrf_token,omitempty"`
+}
+
+type MFATokenReq struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+}
+
+// code is either a totp code or one of the backup codes
+type MFAChallengeReq struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type MFACodeReq struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type ResetMFAReq struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+}
+
+type ForceLogoutReq struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+	Reason string `json:"reason" validate:"omitempty,max=255"`
+}
+
+// at least one of email and ip is required
+type UnlockLoginReq struct {
+	Email string `json:"email" validate:"required_without=IP,omitempty,email"`
+	IP    string `json:"ip" validate:"required_without=Email,omitempty,ip"`
+}
+
+// secret and backup codes are only ever shown once, at enrollment
+type MFAEnrollmentRes struct {
+	Secret          string   `json:"secret"`
+	ProvisioningURI string   `json:"provisioning_uri"`
+	BackupCodes     []string `json:"backup_codes"`
+}
+
+type UserMFA struct {
+	Secret       string     `db:"secret"`
+	EnabledAt    *time.Time `db:"enabled_at"`
+	LastUsedStep *int64     `db:"last_used_step"`
+}
+
+// DirectoryLinkedUser is an active user whose identity came from the directory
+type DirectoryLinkedUser struct {
+	UserID  uuid.UUID `db:"user_id"`
+	Subject string    `db:"subject"`
+	Email   string    `db:"email"`
+}
+
+// DirectorySyncChange is one line of a sync report, UserID is empty for users a dry run would create
+type DirectorySyncChange struct {
+	UserID   *uuid.UUID `json:"user_id,omitempty"`
+	Email    string     `json:"email"`
+	FromRole string     `json:"from_role,omitempty"`
+	ToRole   string     `json:"to_role,omitempty"`
+	Error    string     `json:"error,omitempty"`
+}
+
+type DirectorySyncReport struct {
+	ID             uuid.UUID             `json:"id"`
+	Provider       string                `json:"provider"`
+	DryRun         bool                  `json:"dry_run"`
+	Status         string                `json:"status"`
+	TriggeredBy    *uuid.UUID            `json:"triggered_by,omitempty"`
+	DirectoryUsers int                   `json:"directory_users"`
+	Created        []DirectorySyncChange `json:"created"`
+	Linked         []DirectorySyncChange `json:"linked"`
+	RoleChanges    []DirectorySyncChange `json:"role_changes"`
+	Archived       []DirectorySyncChange `json:"archived"`
+	Errors         []DirectorySyncChange `json:"errors"`
+	StartedAt      time.Time             `json:"started_at"`
+	FinishedAt     time.Time             `json:"finished_at"`
+}
+
+type DirectorySyncRun struct {
+	ID          uuid.UUID  `db:"id"`
+	Provider    string     `db:"provider"`
+	DryRun      bool       `db:"dry_run"`
+	Status      string     `db:"status"`
+	TriggeredBy *uuid.UUID `db:"triggered_by"`
+	Report      []byte     `db:"report"`
+	StartedAt   time.Time  `db:"started_at"`
+	FinishedAt  time.Time  `db:"finished_at"`
+}
+
+// FirebaseRoleClaims is the role and its permissions as pushed to a user's firebase custom claims
+type FirebaseRoleClaims struct {
+	UserID      uuid.UUID      `db:"user_id"`
+	FirebaseUID string         `db:"firebase_uid"`
+	Role        string         `db:"role"`
+	Permissions pq.StringArray `db:"permissions"`
+}
@@ -1,16 +1,20 @@
 package userservice
 
 import (
+	"asset/models"
 	"asset/providers"
+	"asset/providers/middlewareprovider"
+	oidcprovider "asset/providers/oidcProvider"
 	"asset/utils"
-	"encoding/json"
+	"context"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
-	jsoniter "github.com/json-iterator/go"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
@@ -20,30 +24,31 @@ type UserHandler struct {
 	AuthMiddleware providers.AuthMiddlewareService
 	Logger         providers.ZapLoggerProvider
 	firebase       providers.FirebaseProvider
+	// nil when saml login isn't configured
+	saml providers.SAMLProvider
+	// tokens go out as cookies instead of in the body when enabled
+	cookies models.AuthCookieConfig
 }
 
-func NewUserHandler(service UserService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider, firebase providers.FirebaseProvider) *UserHandler {
+func NewUserHandler(service UserService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider, firebase providers.FirebaseProvider, saml providers.SAMLProvider, cookies models.AuthCookieConfig) *UserHandler {
 	return &UserHandler{
 		Service:        service,
 		AuthMiddleware: auth,
 		Logger:         log,
 		firebase:       firebase,
+		saml:           saml,
+		cookies:        cookies,
 	}
 }
 
 func (h *UserHandler) ChangeUserRole(w http.ResponseWriter, r *http.Request) {
 	h.Logger.GetLogger().Info("ChangeUserRole request received")
-	adminID, roles, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	adminID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
 	if err != nil {
 		h.Logger.GetLogger().Error("Unauthorized access attempt in ChangeUserRole", zap.Error(err))
 		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
 		return
 	}
-	if len(roles) == 0 || roles[0] != "admin" {
-		h.Logger.GetLogger().Warn("Forbidden access attempt in ChangeUserRole", zap.String("adminID", adminID), zap.Any("roles", roles))
-		utils.RespondError(w, http.StatusForbidden, fmt.Errorf("unauthorized"), "only admin can update roles")
-		return
-	}
 
 	var req UpdateUserRoleReq
 	if err := utils.ParseJSONBody(r, &req); err != nil {
@@ -51,313 +56,1630 @@ func (h *UserHandler) ChangeUserRole(w http.ResponseWriter, r *http.Request) {
 		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
 		return
 	}
-	if err := validator.New().Struct(req); err != nil {
+	if err := utils.ValidateStruct(req); err != nil {
 		h.Logger.GetLogger().Error("Invalid role input in ChangeUserRole", zap.Error(err))
 		utils.RespondError(w, http.StatusBadRequest, err, "invalid role input")
 		return
 	}
 
-	adminUUID, err := uuid.Parse(adminID)
+	adminUUID, err := uuid.Parse(adminID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse adminID in ChangeUserRole", zap.String("adminID", adminID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in ChangeUserRole", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	h.Logger.GetLogger().Info("Attempting to change user role", zap.String("targetUserID", req.UserID), zap.String("newRole", req.Role), zap.String("adminID", adminID))
+	res, err := h.Service.ChangeUserRole(r.Context(), req, adminUUID, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to change user role", zap.Error(err))
+		if errors.Is(err, ErrRoleGrantPending) {
+			utils.RespondError(w, http.StatusConflict, err, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to change user role")
+		return
+	}
+	if res.Status == RoleChangePendingApproval {
+		h.Logger.GetLogger().Info("Role grant waiting for approval", zap.String("targetUserID", req.UserID), zap.String("approvalID", res.ApprovalID.String()))
+		utils.RespondJSON(w, http.StatusAccepted, map[string]interface{}{"message": "role grant needs approval from another admin", "status": res.Status, "approval_id": res.ApprovalID})
+		return
+	}
+	h.Logger.GetLogger().Info("User role changed successfully", zap.String("targetUserID", req.UserID))
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "user role changed successfully", "status": res.Status})
+}
+
+// GetRoleGrantApprovals lists privileged role grants, pending ones unless ?status= asks for another state or "all"
+func (h *UserHandler) GetRoleGrantApprovals(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetRoleGrantApprovals request received")
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "":
+		status = RoleGrantPending
+	case "all":
+		status = ""
+	case RoleGrantPending, RoleGrantApproved, RoleGrantRejected:
+	default:
+		utils.RespondError(w, http.StatusBadRequest, fmt.Errorf("unknown status: %s", status), "invalid status")
+		return
+	}
+	limit, offset := utils.GetPageLimitAndOffset(r)
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in GetRoleGrantApprovals", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+	approvals, err := h.Service.GetRoleGrantApprovals(r.Context(), status, limit, offset, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch role grant approvals", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch role grant approvals")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"approvals": approvals, "limit": limit, "offset": offset})
+}
+
+func (h *UserHandler) ApproveRoleGrant(w http.ResponseWriter, r *http.Request) {
+	h.decideRoleGrant(w, r, "ApproveRoleGrant", h.Service.ApproveRoleGrant, "role grant approved")
+}
+
+func (h *UserHandler) RejectRoleGrant(w http.ResponseWriter, r *http.Request) {
+	h.decideRoleGrant(w, r, "RejectRoleGrant", h.Service.RejectRoleGrant, "role grant rejected")
+}
+
+func (h *UserHandler) decideRoleGrant(w http.ResponseWriter, r *http.Request, handler string, decide func(ctx context.Context, id uuid.UUID, decider RoleGrantDecider, note string) error, message string) {
+	h.Logger.GetLogger().Info(handler + " request received")
+	userID, roles, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	adminID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in "+handler, zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+	var req RoleGrantDecisionReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input body in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+	decider := RoleGrantDecider{ID: adminID, Roles: roles, Scope: scope}
+	if err := decide(r.Context(), uuid.MustParse(req.ID), decider, req.Note); err != nil {
+		h.Logger.GetLogger().Error("Failed to decide role grant in "+handler, zap.String("id", req.ID), zap.Error(err))
+		switch {
+		case errors.Is(err, ErrRoleGrantNotFound):
+			utils.RespondError(w, http.StatusNotFound, err, err.Error())
+		case errors.Is(err, ErrRoleGrantDecided):
+			utils.RespondError(w, http.StatusConflict, err, err.Error())
+		case errors.Is(err, ErrRoleGrantSelfApproval), errors.Is(err, ErrRoleGrantApprover):
+			utils.RespondError(w, http.StatusForbidden, err, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, err, "failed to decide role grant")
+		}
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": message})
+}
+
+func (h *UserHandler) ScheduleRoleChange(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("ScheduleRoleChange request received")
+	adminID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in ScheduleRoleChange", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	var req ScheduleRoleChangeReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in ScheduleRoleChange", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in ScheduleRoleChange", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	adminUUID, err := uuid.Parse(adminID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse adminID in ScheduleRoleChange", zap.String("adminID", adminID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in ScheduleRoleChange", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	id, err := h.Service.ScheduleRoleChange(r.Context(), req, adminUUID, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to schedule role change", zap.String("targetUserID", req.UserID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to schedule role change")
+		return
+	}
+	h.Logger.GetLogger().Info("Role change scheduled", zap.String("targetUserID", req.UserID), zap.String("id", id.String()))
+	w.WriteHeader(http.StatusCreated)
+	utils.JSON.NewEncoder(w).Encode(map[string]interface{}{"message": "role change scheduled successfully", "id": id})
+}
+
+// GetScheduledRoleChanges lists the pending changes an admin can cancel, ?user_id= narrows it to one user
+func (h *UserHandler) GetScheduledRoleChanges(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetScheduledRoleChanges request received")
+	var userID *uuid.UUID
+	if raw := r.URL.Query().Get("user_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			h.Logger.GetLogger().Error("Invalid user_id in GetScheduledRoleChanges", zap.String("user_id", raw), zap.Error(err))
+			utils.RespondError(w, http.StatusBadRequest, err, "invalid user_id")
+			return
+		}
+		userID = &id
+	}
+	limit, offset := utils.GetPageLimitAndOffset(r)
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in GetScheduledRoleChanges", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+	changes, err := h.Service.GetScheduledRoleChanges(r.Context(), userID, limit, offset, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch scheduled role changes", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch scheduled role changes")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"changes": changes, "limit": limit, "offset": offset})
+}
+
+func (h *UserHandler) CancelScheduledRoleChange(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("CancelScheduledRoleChange request received")
+	id := r.URL.Query().Get("id")
+	changeID, err := uuid.Parse(id)
+	if err != nil {
+		h.Logger.GetLogger().Error("Invalid id in CancelScheduledRoleChange", zap.String("id", id), zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid id")
+		return
+	}
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in CancelScheduledRoleChange", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+	if err := h.Service.CancelScheduledRoleChange(r.Context(), changeID, scope); err != nil {
+		h.Logger.GetLogger().Error("Failed to cancel scheduled role change", zap.String("id", id), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to cancel scheduled role change")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	utils.JSON.NewEncoder(w).Encode(map[string]string{"message": "scheduled role change cancelled"})
+}
+
+func (h *UserHandler) GetEmployeesWithFilters(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetEmployeesWithFilters request received")
+	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in GetEmployeesWithFilters", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	filter := ParseEmployeeFilter(r)
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+	filter.Scope, err = h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in GetEmployeesWithFilters", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	h.Logger.GetLogger().Debug("Fetching employees with filters", zap.Any("filter", filter))
+	employees, err := h.Service.GetEmployeesWithFilters(r.Context(), filter)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch employee data with filters", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch employee data")
+		return
+	}
+
+	h.Logger.GetLogger().Info("Successfully fetched employees with filters", zap.Int("count", len(employees)))
+	w.WriteHeader(http.StatusOK)
+	utils.JSON.NewEncoder(w).Encode(map[string]interface{}{"employees": employees})
+}
+
+// GetDirectory is the company directory every signed in employee may read, always limited to the
+// caller's own organization
+func (h *UserHandler) GetDirectory(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in GetDirectory", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	callerID, err := uuid.Parse(userID)
+	if err != nil {
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	filter := DirectoryFilter{Search: r.URL.Query().Get("search"), CallerID: callerID}
+	if val := r.URL.Query().Get("department_id"); val != "" {
+		departmentID, err := uuid.Parse(val)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, "invalid department id")
+			return
+		}
+		filter.DepartmentID = &departmentID
+	}
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve scope in GetDirectory", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve organization")
+		return
+	}
+	filter.OrganizationID = scope.OrganizationID
+
+	entries, err := h.Service.GetDirectory(r.Context(), filter)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch directory", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch directory")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"employees": entries})
+}
+
+// ParseEmployeeFilter reads the filters shared by the employee list and exports from the query
+func ParseEmployeeFilter(r *http.Request) EmployeeFilter {
+	filter := EmployeeFilter{
+		SearchText:   r.URL.Query().Get("search"),
+		IsSearchText: r.URL.Query().Get("search") != "",
+		Type:         strings.Split(r.URL.Query().Get("type"), ","),
+		Role:         strings.Split(r.URL.Query().Get("role"), ","),
+		AssetStatus:  strings.Split(r.URL.Query().Get("asset_status"), ","),
+	}
+	if val := r.URL.Query().Get("designation"); val != "" {
+		filter.Designation = strings.Split(val, ",")
+	}
+	if val := r.URL.Query().Get("location"); val != "" {
+		filter.Location = strings.Split(val, ",")
+	}
+	return filter
+}
+
+func (h *UserHandler) ExportEmployees(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("ExportEmployees request received")
+	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in ExportEmployees", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	format, err := utils.ParseExportFormat(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Invalid export format", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "format must be csv or xlsx")
+		return
+	}
+
+	filter := ParseEmployeeFilter(r)
+	filter.Scope, err = h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in ExportEmployees", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	rows, err := h.Service.ExportEmployees(r.Context(), filter)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to export employees", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to export employees")
+		return
+	}
+	filename := "employees_" + time.Now().Format("20060102")
+	if err := utils.RespondExport(w, format, filename, EmployeeExportHeader, rows); err != nil {
+		// headers are already sent so the client just gets a truncated file
+		h.Logger.GetLogger().Error("Failed to write employee export", zap.Error(err))
+		return
+	}
+	h.Logger.GetLogger().Info("Successfully exported employees", zap.String("format", format), zap.Int("count", len(rows)))
+}
+
+// StreamEmployees is the employee export as newline delimited json, rows are written as they are read
+// so any number of employees fits in the memory of a few
+func (h *UserHandler) StreamEmployees(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("StreamEmployees request received")
+	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in StreamEmployees", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	filter := ParseEmployeeFilter(r)
+	filter.Scope, err = h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in StreamEmployees", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	stream := utils.NewNDJSONWriter(w, "employees_"+time.Now().Format("20060102"))
+	err = h.Service.StreamEmployees(r.Context(), filter, func(row EmployeeExportRow) error {
+		return stream.Write(row)
+	})
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to stream employees", zap.Int("sent", stream.Rows()), zap.Error(err))
+	}
+	stream.Close(err, "failed to export employees")
+	h.Logger.GetLogger().Info("Streamed employees", zap.Int("count", stream.Rows()))
+}
+
+func (h *UserHandler) GetEmployeeTimeline(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetEmployeeTimeline request received")
+	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in GetEmployeeTimeline", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		h.Logger.GetLogger().Error("Missing user_id in GetEmployeeTimeline request")
+		utils.RespondError(w, http.StatusBadRequest, fmt.Errorf("user_id is required"), "invalid user id")
+		return
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Invalid user ID format in GetEmployeeTimeline", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid user id")
+		return
+	}
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in GetEmployeeTimeline", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+	limit, offset := utils.GetPageLimitAndOffset(r)
+	h.Logger.GetLogger().Debug("Fetching timeline for user", zap.String("userID", userID), zap.Int("limit", limit), zap.Int("offset", offset))
+	timeline, err := h.Service.GetEmployeeTimeline(r.Context(), userUUID, limit, offset, scope)
+	if err != nil {
+		if errors.Is(err, models.ErrOutOfScope) {
+			utils.RespondError(w, http.StatusForbidden, err, "user belongs to another department")
+			return
+		}
+		h.Logger.GetLogger().Error("Failed to fetch timeline for user", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch timeline")
+		return
+	}
+	h.Logger.GetLogger().Info("Successfully fetched employee timeline", zap.String("userID", userID))
+	w.WriteHeader(http.StatusOK)
+	utils.JSON.NewEncoder(w).Encode(map[string]interface{}{"user_id": userID, "timeline": timeline, "limit": limit, "offset": offset})
+}
+
+func (h *UserHandler) GetRoleHistory(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetRoleHistory request received")
+	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in GetRoleHistory", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		h.Logger.GetLogger().Error("Missing user_id in GetRoleHistory request")
+		utils.RespondError(w, http.StatusBadRequest, fmt.Errorf("user_id is required"), "invalid user id")
+		return
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Invalid user ID format in GetRoleHistory", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid user id")
+		return
+	}
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in GetRoleHistory", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+	history, err := h.Service.GetRoleHistory(r.Context(), userUUID, scope)
+	if err != nil {
+		if errors.Is(err, models.ErrOutOfScope) {
+			utils.RespondError(w, http.StatusForbidden, err, "user belongs to another department")
+			return
+		}
+		h.Logger.GetLogger().Error("Failed to fetch role history", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch role history")
+		return
+	}
+	h.Logger.GetLogger().Info("Successfully fetched role history", zap.String("userID", userID), zap.Int("count", len(history)))
+	w.WriteHeader(http.StatusOK)
+	utils.JSON.NewEncoder(w).Encode(map[string]interface{}{"user_id": userID, "history": history})
+}
+
+func (h *UserHandler) PublicRegister(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("PublicRegister request received")
+	var req PublicUserReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Error parsing request body in PublicRegister", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+	h.Logger.GetLogger().Debug("PublicRegister request body parsed", zap.String("email", req.Email))
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in PublicRegister", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+	h.Logger.GetLogger().Info("Attempting public registration", zap.String("email", req.Email))
+	userID, firebaseUserID, err := h.Service.PublicRegister(r.Context(), req)
+	if err != nil {
+		h.Logger.GetLogger().Error("Public registration failed", zap.String("email", req.Email), zap.Error(err))
+		if errors.Is(err, ErrEmailDomainNotAllowed) {
+			utils.RespondError(w, http.StatusForbidden, err, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	h.Logger.GetLogger().Info("Public registration successful", zap.String("userID", userID.String()))
+	w.WriteHeader(http.StatusCreated)
+	utils.JSON.NewEncoder(w).Encode(map[string]interface{}{"message": "account created successfully", "userId": userID,
+		"firebaseUID": firebaseUserID})
+}
+
+func (h *UserHandler) RegisterEmployeeByManager(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("RegisterEmployeeByManager request received")
+	managerID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in RegisterEmployeeByManager", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	var req ManagerRegisterReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input body in RegisterEmployeeByManager", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in RegisterEmployeeByManager", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+	managerUUID, err := uuid.Parse(managerID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse managerID in RegisterEmployeeByManager", zap.String("managerID", managerID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in RegisterEmployeeByManager", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	h.Logger.GetLogger().Info("Attempting to register employee by manager", zap.String("managerID", managerID), zap.String("employeeEmail", req.Email))
+	userID, onboarding, err := h.Service.RegisterEmployeeByManager(r.Context(), req, managerUUID, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to register employee by manager", zap.String("managerID", managerID), zap.Error(err))
+		if errors.Is(err, ErrEndDateNotAllowed) || errors.Is(err, ErrTemplateNotFound) || errors.Is(err, ErrTemplateTypeMismatch) || errors.Is(err, ErrInvalidPhoneNumber) {
+			utils.RespondError(w, http.StatusBadRequest, err, err.Error())
+			return
+		}
+		if errors.Is(err, models.ErrOutOfScope) {
+			utils.RespondError(w, http.StatusForbidden, err, "onboarding template belongs to another department")
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to register employee")
+		return
+	}
+	h.Logger.GetLogger().Info("Employee registered successfully by manager", zap.String("managerID", managerID), zap.String("userID", userID.String()))
+	response := map[string]interface{}{
+		"user UUID": userID,
+	}
+	if onboarding != nil {
+		response["onboarding"] = onboarding
+	}
+	w.WriteHeader(http.StatusCreated)
+	utils.JSON.NewEncoder(w).Encode(response)
+}
+
+func (h *UserHandler) ConfirmEmail(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("ConfirmEmail request received")
+	var req ConfirmEmailReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input body in ConfirmEmail", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in ConfirmEmail", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	if err := h.Service.ConfirmEmail(r.Context(), req); err != nil {
+		h.Logger.GetLogger().Error("Failed to confirm email", zap.Error(err))
+		if errors.Is(err, ErrVerificationInvalid) {
+			utils.RespondError(w, http.StatusBadRequest, err, err.Error())
+			return
+		}
+		if errors.Is(err, ErrVerificationExpired) {
+			utils.RespondError(w, http.StatusGone, err, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to verify email")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "email verified"})
+}
+
+// RefreshToken hands out a new access and refresh token pair, the old refresh token stops working
+func (h *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	refreshToken, ok := h.sessionRefreshToken(w, r, "RefreshToken")
+	if !ok {
+		return
+	}
+
+	res, err := h.Service.RefreshTokens(r.Context(), RefreshTokenReq{RefreshToken: refreshToken}, utils.GetClientInfo(r))
+	if err != nil {
+		if errors.Is(err, ErrSessionExpired) {
+			if h.cookies.Enabled {
+				middlewareprovider.ClearAuthCookies(w, h.cookies)
+			}
+			utils.RespondError(w, http.StatusUnauthorized, err, ErrSessionExpired.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to refresh tokens")
+		return
+	}
+	if res.CSRFToken, err = h.deliverTokens(w, &res.AccessToken, &res.RefreshToken); err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to set session cookies")
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	utils.RespondJSON(w, http.StatusOK, res)
+}
+
+// Logout ends the caller's session, other sessions of the same user stay signed in
+func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.currentUserID(w, r, "Logout")
+	if !ok {
+		return
+	}
+	refreshToken, ok := h.sessionRefreshToken(w, r, "Logout")
+	if !ok {
+		return
+	}
+
+	if err := h.Service.Logout(r.Context(), userID, LogoutReq{RefreshToken: refreshToken}, utils.GetClientInfo(r)); err != nil {
+		if errors.Is(err, ErrRefreshTokenMismatch) {
+			utils.RespondError(w, http.StatusBadRequest, err, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to log out")
+		return
+	}
+	if h.cookies.Enabled {
+		middlewareprovider.ClearAuthCookies(w, h.cookies)
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "logged out"})
+}
+
+// sessionRefreshToken reads the refresh token from its cookie in cookie mode, from the body otherwise.
+// A cookie is sent along by the browser on its own, so using it needs the csrf header too
+func (h *UserHandler) sessionRefreshToken(w http.ResponseWriter, r *http.Request, handlerName string) (string, bool) {
+	if h.cookies.Enabled {
+		if token := middlewareprovider.CookieValue(r, middlewareprovider.RefreshTokenCookie); token != "" {
+			if !middlewareprovider.ValidCSRF(r) {
+				utils.RespondError(w, http.StatusForbidden, errors.New("missing or invalid csrf token"), "missing or invalid csrf token")
+				return "", false
+			}
+			return token, true
+		}
+	}
+	var req RefreshTokenReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input body in "+handlerName, zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input body")
+		return "", false
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return "", false
+	}
+	return req.RefreshToken, true
+}
+
+// respondLogin answers a finished login step, or the mfa challenge when one is still due
+func (h *UserHandler) respondLogin(w http.ResponseWriter, res LoginRes) {
+	var err error
+	if res.CSRFToken, err = h.deliverTokens(w, &res.AccessToken, &res.RefreshToken); err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to set session cookies")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, res)
+}
+
+// deliverTokens moves the tokens into httpOnly cookies in cookie mode and returns the csrf token
+// that replaces them in the body. Outside cookie mode, or without tokens yet, nothing changes
+func (h *UserHandler) deliverTokens(w http.ResponseWriter, accessToken, refreshToken *string) (string, error) {
+	if !h.cookies.Enabled || *accessToken == "" {
+		return "", nil
+	}
+	csrfToken, err := middlewareprovider.SetAuthCookies(w, h.cookies, *accessToken, *refreshToken)
+	if err != nil {
+		h.Logger.GetLogger().Error("failed to set session cookies", zap.Error(err))
+		return "", err
+	}
+	*accessToken, *refreshToken = "", ""
+	return csrfToken, nil
+}
+
+// ResendVerification always answers the same way so it can't be used to find registered emails
+func (h *UserHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("ResendVerification request received")
+	var req PublicUserReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input body in ResendVerification", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in ResendVerification", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	if err := h.Service.ResendVerification(r.Context(), req); err != nil {
+		h.Logger.GetLogger().Error("Failed to resend verification email", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resend verification email")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "if the email is registered and unverified, a new link has been sent"})
+}
+
+// ChangeMyEmail starts an email change for the caller
+func (h *UserHandler) ChangeMyEmail(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("ChangeMyEmail request received")
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in ChangeMyEmail", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in ChangeMyEmail", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	var req ChangeEmailReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input body in ChangeMyEmail", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in ChangeMyEmail", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	res, err := h.Service.RequestEmailChange(r.Context(), userUUID, req.NewEmail, userUUID, models.DepartmentScope{AllDepartments: true})
+	if err != nil {
+		h.respondEmailChangeError(w, err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusAccepted, res)
+}
+
+// SetMyTimezone stores the caller's timezone, dates they pick are read in it and timelines come back in it
+func (h *UserHandler) SetMyTimezone(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in SetMyTimezone", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in SetMyTimezone", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	var req TimezoneReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	res, err := h.Service.SetTimezone(r.Context(), userUUID, req.Timezone)
+	if err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to set timezone")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, res)
+}
+
+// ChangeEmployeeEmail lets a manager start an email change for an employee in their scope,
+// the employee still has to confirm from the new address
+func (h *UserHandler) ChangeEmployeeEmail(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("ChangeEmployeeEmail request received")
+	managerID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in ChangeEmployeeEmail", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	managerUUID, err := uuid.Parse(managerID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse managerID in ChangeEmployeeEmail", zap.String("managerID", managerID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	var req ManagerChangeEmailReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input body in ChangeEmployeeEmail", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in ChangeEmployeeEmail", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in ChangeEmployeeEmail", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	res, err := h.Service.RequestEmailChange(r.Context(), req.UserID, req.NewEmail, managerUUID, scope)
+	if err != nil {
+		h.respondEmailChangeError(w, err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusAccepted, res)
+}
+
+func (h *UserHandler) respondEmailChangeError(w http.ResponseWriter, err error) {
+	h.Logger.GetLogger().Error("Failed to request email change", zap.Error(err))
+	switch {
+	case errors.Is(err, ErrEmailUnchanged):
+		utils.RespondError(w, http.StatusBadRequest, err, err.Error())
+	case errors.Is(err, ErrUserAlreadyExists):
+		utils.RespondError(w, http.StatusConflict, err, "email is already in use")
+	case errors.Is(err, models.ErrOutOfScope):
+		utils.RespondError(w, http.StatusForbidden, err, "user belongs to another department")
+	default:
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to request email change")
+	}
+}
+
+// ConfirmEmailChange is public, the signed token sent to the new address authorizes it
+func (h *UserHandler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("ConfirmEmailChange request received")
+	var req ConfirmEmailReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input body in ConfirmEmailChange", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in ConfirmEmailChange", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	if err := h.Service.ConfirmEmailChange(r.Context(), req); err != nil {
+		h.Logger.GetLogger().Error("Failed to confirm email change", zap.Error(err))
+		switch {
+		case errors.Is(err, ErrVerificationInvalid):
+			utils.RespondError(w, http.StatusBadRequest, err, err.Error())
+		case errors.Is(err, ErrVerificationExpired):
+			utils.RespondError(w, http.StatusGone, err, err.Error())
+		case errors.Is(err, ErrUserAlreadyExists):
+			utils.RespondError(w, http.StatusConflict, err, "email is already in use")
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, err, "failed to change email")
+		}
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "email changed"})
+}
+
+// InviteEmployee emails a registration link instead of creating the account straight away
+func (h *UserHandler) InviteEmployee(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("InviteEmployee request received")
+	managerID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in InviteEmployee", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	var req InviteEmployeeReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input body in InviteEmployee", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in InviteEmployee", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+	managerUUID, err := uuid.Parse(managerID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse managerID in InviteEmployee", zap.String("managerID", managerID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in InviteEmployee", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	invite, err := h.Service.InviteEmployee(r.Context(), req, managerUUID, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to invite employee", zap.String("managerID", managerID), zap.Error(err))
+		if errors.Is(err, ErrEndDateNotAllowed) || errors.Is(err, ErrTemplateNotFound) || errors.Is(err, ErrTemplateTypeMismatch) || errors.Is(err, ErrInvalidPhoneNumber) {
+			utils.RespondError(w, http.StatusBadRequest, err, err.Error())
+			return
+		}
+		if errors.Is(err, ErrUserAlreadyExists) {
+			utils.RespondError(w, http.StatusConflict, err, "user already exists")
+			return
+		}
+		if errors.Is(err, models.ErrOutOfScope) {
+			utils.RespondError(w, http.StatusForbidden, err, "onboarding template belongs to another department")
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to invite employee")
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, invite)
+}
+
+// AcceptInvite is public, the signed invite token is what authorizes the registration
+func (h *UserHandler) AcceptInvite(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("AcceptInvite request received")
+	var req AcceptInviteReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input body in AcceptInvite", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in AcceptInvite", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	userID, onboarding, err := h.Service.AcceptInvite(r.Context(), req)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to accept invite", zap.Error(err))
+		if errors.Is(err, ErrInviteInvalid) || errors.Is(err, ErrTemplateNotFound) || errors.Is(err, ErrTemplateTypeMismatch) || errors.Is(err, ErrInvalidPhoneNumber) {
+			utils.RespondError(w, http.StatusBadRequest, err, err.Error())
+			return
+		}
+		if errors.Is(err, ErrInviteExpired) {
+			utils.RespondError(w, http.StatusGone, err, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to accept invite")
+		return
+	}
+	response := map[string]interface{}{
+		"user UUID": userID,
+	}
+	if onboarding != nil {
+		response["onboarding"] = onboarding
+	}
+	w.WriteHeader(http.StatusCreated)
+	utils.JSON.NewEncoder(w).Encode(response)
+}
+
+// SendContactOTP texts the code AcceptInvite asks for when contact numbers are verified
+func (h *UserHandler) SendContactOTP(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("SendContactOTP request received")
+	var req ContactOTPReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input body in SendContactOTP", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in SendContactOTP", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+	if err := h.Service.SendContactOTP(r.Context(), req); err != nil {
+		h.Logger.GetLogger().Error("Failed to send contact otp", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to send verification code")
+		return
+	}
+	utils.RespondJSON(w, http.StatusAccepted, map[string]interface{}{"message": "a verification code is on its way"})
+}
+
+func (h *UserHandler) UpdateEmployee(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("UpdateEmployee request received")
+	managerID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in UpdateEmployee", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+	managerUUID, err := uuid.Parse(managerID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse managerID in UpdateEmployee", zap.String("managerID", managerID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return
+	}
+
+	var req UpdateEmployeeReq
+	if err := utils.JSON.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in UpdateEmployee", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in UpdateEmployee", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+	if req.Username == "" && req.Email == "" && req.ContactNo == "" && req.EndDate == nil && req.ProfileFields == (ProfileFields{}) && len(req.Clear) == 0 {
+		h.Logger.GetLogger().Warn("No update fields provided in UpdateEmployee request")
+		utils.RespondError(w, http.StatusBadRequest, nil, "at least one field must be provided for update")
+		return
+	}
+
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in UpdateEmployee", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	h.Logger.GetLogger().Info("Attempting to update employee")
+	employee, err := h.Service.UpdateEmployee(r.Context(), req, managerUUID, scope)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to update employee")
+		if errors.Is(err, ErrEndDateNotAllowed) || errors.Is(err, ErrEmailChangeViaUpdate) || errors.Is(err, ErrInvalidPhoneNumber) || errors.Is(err, ErrUpdateFieldConflict) {
+			utils.RespondError(w, http.StatusBadRequest, err, err.Error())
+			return
+		}
+		if errors.Is(err, models.ErrOutOfScope) {
+			utils.RespondError(w, http.StatusForbidden, err, "user belongs to another department")
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to update employee")
+		return
+	}
+	h.Logger.GetLogger().Info("Employee updated successfully")
+	w.WriteHeader(http.StatusOK)
+	utils.JSON.NewEncoder(w).Encode(map[string]interface{}{"message": "employee updated successfully", "employee": employee})
+}
+
+func (h *UserHandler) UserLogin(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("UserLogin request received")
+	var req PublicUserReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in UserLogin (parsing body)", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in UserLogin (validation)", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+	h.Logger.GetLogger().Info("Attempting user login", zap.String("email", req.Email))
+	res, err := h.Service.UserLogin(r.Context(), req, utils.GetClientInfo(r))
+	if err != nil {
+		h.Logger.GetLogger().Error("User login failed", zap.String("email", req.Email), zap.Error(err))
+		if respondLoginLocked(w, err) {
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "login failed")
+		return
+	}
+	h.Logger.GetLogger().Info("User login successful", zap.String("userID", res.UserID.String()), zap.Bool("mfaRequired", res.MFARequired))
+	h.respondLogin(w, res)
+}
+
+// RequestMagicLink always answers the same way so it can't be used to find out which emails have accounts
+func (h *UserHandler) RequestMagicLink(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("RequestMagicLink request received")
+	var req PublicUserReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input body in RequestMagicLink", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in RequestMagicLink", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+	if err := h.Service.RequestMagicLink(r.Context(), req); err != nil {
+		h.Logger.GetLogger().Error("Failed to send magic link", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to send sign-in link")
+		return
+	}
+	utils.RespondJSON(w, http.StatusAccepted, map[string]interface{}{"message": "if the email belongs to an account, a sign-in link is on its way"})
+}
+
+func (h *UserHandler) MagicLinkLogin(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("MagicLinkLogin request received")
+	var req MagicLinkLoginReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input body in MagicLinkLogin", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in MagicLinkLogin", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+	res, err := h.Service.MagicLinkLogin(r.Context(), req, utils.GetClientInfo(r))
+	if err != nil {
+		h.Logger.GetLogger().Error("Magic link login failed", zap.Error(err))
+		switch {
+		case errors.Is(err, ErrMagicLinkInvalid):
+			utils.RespondError(w, http.StatusUnauthorized, err, err.Error())
+		case errors.Is(err, ErrMagicLinkExpired):
+			utils.RespondError(w, http.StatusGone, err, err.Error())
+		default:
+			utils.RespondError(w, http.StatusInternalServerError, err, "magic link login failed")
+		}
+		return
+	}
+	h.Logger.GetLogger().Info("Magic link login successful", zap.String("userID", res.UserID.String()), zap.Bool("mfaRequired", res.MFARequired))
+	h.respondLogin(w, res)
+}
+
+func (h *UserHandler) GetUserDashboard(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetUserDashboard request received")
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in GetUserDashboard", zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
 	if err != nil {
-		h.Logger.GetLogger().Error("Failed to parse adminID in ChangeUserRole", zap.String("adminID", adminID), zap.Error(err))
-		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		h.Logger.GetLogger().Error("Invalid user ID format in GetUserDashboard", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "invalid user id")
 		return
 	}
 
-	h.Logger.GetLogger().Info("Attempting to change user role", zap.String("targetUserID", req.UserID), zap.String("newRole", req.Role), zap.String("adminID", adminID))
-	err = h.Service.ChangeUserRole(r.Context(), req, adminUUID)
+	h.Logger.GetLogger().Debug("Fetching dashboard for user", zap.String("userID", userID))
+	dashboard, err := h.Service.GetDashboard(r.Context(), userUUID)
 	if err != nil {
-		h.Logger.GetLogger().Error("Failed to change user role", zap.Error(err))
-		utils.RespondError(w, http.StatusInternalServerError, err, err.Error())
+		h.Logger.GetLogger().Error("Failed to fetch dashboard data", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch dashboard data")
 		return
 	}
-	h.Logger.GetLogger().Info("User role changed successfully", zap.String("targetUserID", req.UserID))
+
+	h.Logger.GetLogger().Info("Successfully fetched user dashboard", zap.String("userID", userID))
 	w.WriteHeader(http.StatusOK)
-	jsoniter.NewEncoder(w).Encode(map[string]string{"message": "user role changed successfully"})
+	utils.JSON.NewEncoder(w).Encode(dashboard)
 }
 
-func (h *UserHandler) GetEmployeesWithFilters(w http.ResponseWriter, r *http.Request) {
-	h.Logger.GetLogger().Info("GetEmployeesWithFilters request received")
-	_, roles, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+// GetUserDashboardSummary is the slim mobile dashboard, clients may reuse it for a minute and then
+// revalidate with the ETag
+func (h *UserHandler) GetUserDashboardSummary(w http.ResponseWriter, r *http.Request) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
 	if err != nil {
-		h.Logger.GetLogger().Error("Unauthorized access attempt in GetEmployeesWithFilters", zap.Error(err))
+		h.Logger.GetLogger().Error("Unauthorized access attempt in GetUserDashboardSummary", zap.Error(err))
 		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
 		return
 	}
-	if len(roles) == 0 || (roles[0] != "admin" && roles[0] != "employee_manager") {
-		h.Logger.GetLogger().Warn("Forbidden access attempt in GetEmployeesWithFilters", zap.Any("roles", roles))
-		utils.RespondError(w, http.StatusForbidden, nil, "only admin and employee manager can access")
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Invalid user ID format in GetUserDashboardSummary", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "invalid user id")
 		return
 	}
 
-	filter := EmployeeFilter{
-		SearchText:   r.URL.Query().Get("search"),
-		IsSearchText: r.URL.Query().Get("search") != "",
-		Type:         strings.Split(r.URL.Query().Get("type"), ","),
-		Role:         strings.Split(r.URL.Query().Get("role"), ","),
-		AssetStatus:  strings.Split(r.URL.Query().Get("asset_status"), ","),
-	}
-	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
-
-	h.Logger.GetLogger().Debug("Fetching employees with filters", zap.Any("filter", filter))
-	employees, err := h.Service.GetEmployeesWithFilters(r.Context(), filter)
+	summary, err := h.Service.GetDashboardSummary(r.Context(), userUUID)
 	if err != nil {
-		h.Logger.GetLogger().Error("Failed to fetch employee data with filters", zap.Error(err))
-		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch employee data")
+		h.Logger.GetLogger().Error("Failed to fetch dashboard summary", zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch dashboard summary")
 		return
 	}
+	w.Header().Set("Cache-Control", "private, max-age=60")
+	utils.RespondJSON(w, http.StatusOK, summary)
+}
 
-	h.Logger.GetLogger().Info("Successfully fetched employees with filters", zap.Int("count", len(employees)))
-	w.WriteHeader(http.StatusOK)
-	jsoniter.NewEncoder(w).Encode(map[string]interface{}{"employees": employees})
+// GoogleAuth is the v2 login, google by default and microsoft or github with the provider query param.
+// Github sends its oauth access token as the bearer token since it has no id tokens
+func (h *UserHandler) GoogleAuth(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GoogleAuth request received")
+	provider := oidcprovider.GoogleProviderName
+	switch requested := r.URL.Query().Get("provider"); requested {
+	case "", oidcprovider.GoogleProviderName:
+	case oidcprovider.MicrosoftProviderName, oidcprovider.GitHubProviderName:
+		provider = requested
+	default:
+		utils.RespondError(w, http.StatusBadRequest, ErrOIDCProviderUnknown, "provider must be google, microsoft or github")
+		return
+	}
+	h.oidcLogin(w, r, provider)
 }
 
-func (h *UserHandler) GetEmployeeTimeline(w http.ResponseWriter, r *http.Request) {
-	h.Logger.GetLogger().Info("GetEmployeeTimeline request received")
-	_, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
-	if err != nil {
-		h.Logger.GetLogger().Error("Unauthorized access attempt in GetEmployeeTimeline", zap.Error(err))
-		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+// OIDCLogin signs in with an id token of the provider named in the provider query param
+func (h *UserHandler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("OIDCLogin request received")
+	provider := r.URL.Query().Get("provider")
+	if provider == "" {
+		utils.RespondError(w, http.StatusBadRequest, errors.New("missing provider"), "provider is required")
 		return
 	}
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		h.Logger.GetLogger().Error("Missing user_id in GetEmployeeTimeline request")
-		utils.RespondError(w, http.StatusBadRequest, fmt.Errorf("user_id is required"), "invalid user id")
+	h.oidcLogin(w, r, provider)
+}
+
+func (h *UserHandler) oidcLogin(w http.ResponseWriter, r *http.Request, provider string) {
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		h.Logger.GetLogger().Warn("missing bearer token in oidc login request", zap.String("provider", provider))
+		utils.RespondError(w, http.StatusBadRequest, fmt.Errorf("missing bearer token"), "unauthorized")
 		return
 	}
-	userUUID, err := uuid.Parse(userID)
+	idToken := strings.TrimPrefix(authHeader, "Bearer ")
+	h.respondOIDCLogin(w, r, provider, idToken)
+}
+
+func (h *UserHandler) respondOIDCLogin(w http.ResponseWriter, r *http.Request, provider, rawToken string) {
+	res, err := h.Service.OIDCLogin(r.Context(), provider, rawToken, utils.GetClientInfo(r))
 	if err != nil {
-		h.Logger.GetLogger().Error("Invalid user ID format in GetEmployeeTimeline", zap.String("userID", userID), zap.Error(err))
-		utils.RespondError(w, http.StatusBadRequest, err, "invalid user id")
+		h.Logger.GetLogger().Error("OIDC authentication failed", zap.String("provider", provider), zap.Error(err))
+		if respondLoginLocked(w, err) {
+			return
+		}
+		switch {
+		case errors.Is(err, ErrOIDCProviderUnknown):
+			utils.RespondError(w, http.StatusBadRequest, err, err.Error())
+		case errors.Is(err, ErrEmailNotVerified), errors.Is(err, ErrEmailDomainNotAllowed):
+			utils.RespondError(w, http.StatusForbidden, err, err.Error())
+		default:
+			utils.RespondError(w, http.StatusUnauthorized, err, provider+" auth failed")
+		}
+		return
+	}
+
+	h.Logger.GetLogger().Info("OIDC authentication successful", zap.String("provider", provider), zap.String("userID", res.UserID.String()), zap.Bool("mfaRequired", res.MFARequired))
+	h.respondLogin(w, res)
+}
+
+// SAMLMetadata serves the service provider metadata the idp admin registers
+func (h *UserHandler) SAMLMetadata(w http.ResponseWriter, r *http.Request) {
+	if h.saml == nil {
+		utils.RespondError(w, http.StatusNotFound, errors.New("saml not configured"), "saml login is not enabled")
 		return
 	}
-	h.Logger.GetLogger().Debug("Fetching timeline for user", zap.String("userID", userID))
-	timeline, err := h.Service.GetEmployeeTimeline(r.Context(), userUUID)
+	metadata, err := h.saml.Metadata()
 	if err != nil {
-		h.Logger.GetLogger().Error("Failed to fetch timeline for user", zap.String("userID", userID), zap.Error(err))
-		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch timeline")
+		h.Logger.GetLogger().Error("failed to build saml metadata", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to build saml metadata")
 		return
 	}
-	h.Logger.GetLogger().Info("Successfully fetched employee timeline", zap.String("userID", userID))
+	w.Header().Set("Content-Type", "application/samlmetadata+xml")
 	w.WriteHeader(http.StatusOK)
-	jsoniter.NewEncoder(w).Encode(map[string]interface{}{"user_id": userID, "timeline": timeline})
+	w.Write(metadata)
 }
 
-func (h *UserHandler) PublicRegister(w http.ResponseWriter, r *http.Request) {
-	h.Logger.GetLogger().Info("PublicRegister request received")
-	var req PublicUserReq
-	if err := utils.ParseJSONBody(r, &req); err != nil {
-		h.Logger.GetLogger().Error("Error parsing request body in PublicRegister", zap.Error(err))
-		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+// JWKS publishes the keys access tokens are verified with, so other services can check them without the secret
+// IntrospectToken tells gateways whether a token they were handed is active, the caller needs
+// token.introspect. An unusable token gets 200 with active false, as RFC 7662 asks
+func (h *UserHandler) IntrospectToken(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("IntrospectToken request received")
+	var req IntrospectTokenReq
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		req.Token = r.PostFormValue("token")
+	} else if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in IntrospectToken", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
 		return
 	}
-	h.Logger.GetLogger().Debug("PublicRegister request body parsed", zap.String("email", req.Email))
-	if err := validator.New().Struct(req); err != nil {
-		h.Logger.GetLogger().Error("Invalid input in PublicRegister", zap.Error(err))
-		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "token is required")
 		return
 	}
-	h.Logger.GetLogger().Info("Attempting public registration", zap.String("email", req.Email))
-	userID, firebaseUserID, err := h.Service.PublicRegister(r.Context(), req)
+
+	res, err := h.AuthMiddleware.IntrospectToken(r.Context(), req.Token)
 	if err != nil {
-		h.Logger.GetLogger().Error("Public registration failed", zap.String("email", req.Email), zap.Error(err))
-		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		h.Logger.GetLogger().Error("Failed to introspect token", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to introspect token")
 		return
 	}
+	w.Header().Set("Cache-Control", "no-store")
+	utils.RespondJSON(w, http.StatusOK, res)
+}
 
-	h.Logger.GetLogger().Info("Public registration successful", zap.String("userID", userID.String()))
-	w.WriteHeader(http.StatusCreated)
-	jsoniter.NewEncoder(w).Encode(map[string]interface{}{"message": "account created successfully", "userId": userID,
-		"firebaseUID": firebaseUserID})
+func (h *UserHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	utils.RespondJSON(w, http.StatusOK, h.AuthMiddleware.JWKS())
 }
 
-func (h *UserHandler) RegisterEmployeeByManager(w http.ResponseWriter, r *http.Request) {
-	h.Logger.GetLogger().Info("RegisterEmployeeByManager request received")
-	managerID, roles, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+// SAMLLogin redirects to the idp, the optional relay_state comes back untouched with the response
+func (h *UserHandler) SAMLLogin(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("SAMLLogin request received")
+	if h.saml == nil {
+		utils.RespondError(w, http.StatusNotFound, errors.New("saml not configured"), "saml login is not enabled")
+		return
+	}
+	loginURL, err := h.saml.LoginURL(r.Context(), r.URL.Query().Get("relay_state"))
 	if err != nil {
-		h.Logger.GetLogger().Error("Unauthorized access attempt in RegisterEmployeeByManager", zap.Error(err))
-		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		h.Logger.GetLogger().Error("failed to start saml login", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to start saml login")
+		return
+	}
+	http.Redirect(w, r, loginURL, http.StatusFound)
+}
+
+// SAMLACS is the assertion consumer service the idp posts the SAMLResponse form to
+func (h *UserHandler) SAMLACS(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("SAMLACS request received")
+	if h.saml == nil {
+		utils.RespondError(w, http.StatusNotFound, errors.New("saml not configured"), "saml login is not enabled")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid form body")
 		return
 	}
-	if len(roles) == 0 || (roles[0] != "admin" && roles[0] != "employee_manager") {
-		h.Logger.GetLogger().Warn("Forbidden access attempt in RegisterEmployeeByManager", zap.String("managerID", managerID), zap.Any("roles", roles))
-		utils.RespondError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized role"), "unauthorized")
+	samlResponse := r.PostForm.Get("SAMLResponse")
+	if samlResponse == "" {
+		utils.RespondError(w, http.StatusBadRequest, errors.New("missing SAMLResponse"), "SAMLResponse is required")
 		return
 	}
+	h.respondOIDCLogin(w, r, h.saml.Name(), samlResponse)
+}
 
-	var req ManagerRegisterReq
+// VerifyMFA is the second login step, the mfa token from login authorizes it
+func (h *UserHandler) VerifyMFA(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("VerifyMFA request received")
+	var req MFAChallengeReq
 	if err := utils.ParseJSONBody(r, &req); err != nil {
-		h.Logger.GetLogger().Error("Invalid input body in RegisterEmployeeByManager", zap.Error(err))
+		h.Logger.GetLogger().Error("Invalid input body in VerifyMFA", zap.Error(err))
 		utils.RespondError(w, http.StatusBadRequest, err, "invalid input body")
 		return
 	}
-	if err := validator.New().Struct(req); err != nil {
-		h.Logger.GetLogger().Error("Invalid input in RegisterEmployeeByManager", zap.Error(err))
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in VerifyMFA", zap.Error(err))
 		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
 		return
 	}
-	managerUUID, err := uuid.Parse(managerID)
+	res, err := h.Service.VerifyMFAChallenge(r.Context(), req, utils.GetClientInfo(r))
 	if err != nil {
-		h.Logger.GetLogger().Error("Failed to parse managerID in RegisterEmployeeByManager", zap.String("managerID", managerID), zap.Error(err))
-		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		h.respondMFAError(w, err)
 		return
 	}
+	h.respondLogin(w, res)
+}
 
-	h.Logger.GetLogger().Info("Attempting to register employee by manager", zap.String("managerID", managerID), zap.String("employeeEmail", req.Email))
-	userID, err := h.Service.RegisterEmployeeByManager(r.Context(), req, managerUUID)
+// EnrollMFAChallenge lets a user whose role mandates mfa enroll in the middle of logging in
+func (h *UserHandler) EnrollMFAChallenge(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("EnrollMFAChallenge request received")
+	var req MFATokenReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input body in EnrollMFAChallenge", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in EnrollMFAChallenge", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+	res, err := h.Service.EnrollMFAForChallenge(r.Context(), req)
 	if err != nil {
-		h.Logger.GetLogger().Error("Failed to register employee by manager", zap.String("managerID", managerID), zap.Error(err))
-		utils.RespondError(w, http.StatusInternalServerError, err, err.Error())
+		h.respondMFAError(w, err)
 		return
 	}
-	h.Logger.GetLogger().Info("Employee registered successfully by manager", zap.String("managerID", managerID), zap.String("userID", userID.String()))
-	w.WriteHeader(http.StatusCreated)
-	jsoniter.NewEncoder(w).Encode(map[string]interface{}{
-		"user UUID": userID,
-	})
+	utils.RespondJSON(w, http.StatusOK, res)
 }
 
-func (h *UserHandler) UpdateEmployee(w http.ResponseWriter, r *http.Request) {
-	h.Logger.GetLogger().Info("UpdateEmployee request received")
-	managerID, roles, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+func (h *UserHandler) EnrollMyMFA(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("EnrollMyMFA request received")
+	userUUID, ok := h.currentUserID(w, r, "EnrollMyMFA")
+	if !ok {
+		return
+	}
+	res, err := h.Service.EnrollMFA(r.Context(), userUUID)
 	if err != nil {
-		h.Logger.GetLogger().Error("Unauthorized access attempt in UpdateEmployee", zap.Error(err))
-		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		h.respondMFAError(w, err)
 		return
 	}
-	if len(roles) == 0 || (roles[0] != "admin" && roles[0] != "employee_manager") {
-		h.Logger.GetLogger().Warn("Forbidden access attempt in UpdateEmployee", zap.String("managerID", managerID), zap.Any("roles", roles))
-		utils.RespondError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized role"), "unauthorized")
+	utils.RespondJSON(w, http.StatusOK, res)
+}
+
+func (h *UserHandler) ActivateMyMFA(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("ActivateMyMFA request received")
+	userUUID, ok := h.currentUserID(w, r, "ActivateMyMFA")
+	if !ok {
 		return
 	}
-	managerUUID, err := uuid.Parse(managerID)
-	if err != nil {
-		h.Logger.GetLogger().Error("Failed to parse managerID in UpdateEmployee", zap.String("managerID", managerID), zap.Error(err))
-		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+	req, ok := h.parseMFACode(w, r, "ActivateMyMFA")
+	if !ok {
+		return
+	}
+	if err := h.Service.ActivateMFA(r.Context(), userUUID, req.Code); err != nil {
+		h.respondMFAError(w, err)
 		return
 	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "mfa enabled"})
+}
 
-	var req UpdateEmployeeReq
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.Logger.GetLogger().Error("Invalid request body in UpdateEmployee", zap.Error(err))
-		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+func (h *UserHandler) DisableMyMFA(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("DisableMyMFA request received")
+	userUUID, ok := h.currentUserID(w, r, "DisableMyMFA")
+	if !ok {
 		return
 	}
-	if err := validator.New().Struct(req); err != nil {
-		h.Logger.GetLogger().Error("Invalid input in UpdateEmployee", zap.Error(err))
-		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+	req, ok := h.parseMFACode(w, r, "DisableMyMFA")
+	if !ok {
 		return
 	}
-	if req.Username == "" && req.Email == "" && req.ContactNo == "" {
-		h.Logger.GetLogger().Warn("No update fields provided in UpdateEmployee request")
-		utils.RespondError(w, http.StatusBadRequest, nil, "at least one field must be provided for update")
+	if err := h.Service.DisableMFA(r.Context(), userUUID, req.Code); err != nil {
+		h.respondMFAError(w, err)
 		return
 	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "mfa disabled"})
+}
 
-	h.Logger.GetLogger().Info("Attempting to update employee")
-	if err := h.Service.UpdateEmployee(r.Context(), req, managerUUID); err != nil {
-		h.Logger.GetLogger().Error("Failed to update employee")
-		utils.RespondError(w, http.StatusInternalServerError, err, "failed to update employee")
+func (h *UserHandler) RegenerateMyBackupCodes(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("RegenerateMyBackupCodes request received")
+	userUUID, ok := h.currentUserID(w, r, "RegenerateMyBackupCodes")
+	if !ok {
 		return
 	}
-	h.Logger.GetLogger().Info("Employee updated successfully")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "employee updated successfully"})
+	req, ok := h.parseMFACode(w, r, "RegenerateMyBackupCodes")
+	if !ok {
+		return
+	}
+	codes, err := h.Service.RegenerateBackupCodes(r.Context(), userUUID, req.Code)
+	if err != nil {
+		h.respondMFAError(w, err)
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"backup_codes": codes})
 }
 
-func (h *UserHandler) UserLogin(w http.ResponseWriter, r *http.Request) {
-	h.Logger.GetLogger().Info("UserLogin request received")
-	var req PublicUserReq
+func (h *UserHandler) ResetUserMFA(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("ResetUserMFA request received")
+	if _, ok := h.currentUserID(w, r, "ResetUserMFA"); !ok {
+		return
+	}
+	var req ResetMFAReq
 	if err := utils.ParseJSONBody(r, &req); err != nil {
-		h.Logger.GetLogger().Error("Invalid input in UserLogin (parsing body)", zap.Error(err))
-		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		h.Logger.GetLogger().Error("Invalid input body in ResetUserMFA", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input body")
 		return
 	}
-	if err := validator.New().Struct(req); err != nil {
-		h.Logger.GetLogger().Error("Invalid input in UserLogin (validation)", zap.Error(err))
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in ResetUserMFA", zap.Error(err))
 		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
 		return
 	}
-	h.Logger.GetLogger().Info("Attempting user login", zap.String("email", req.Email))
-	userID, accessToken, refreshToken, err := h.Service.UserLogin(r.Context(), req)
-	if err != nil {
-		h.Logger.GetLogger().Error("User login failed", zap.String("email", req.Email), zap.Error(err))
-		utils.RespondError(w, http.StatusUnauthorized, err, err.Error())
+	if err := h.Service.ResetMFA(r.Context(), uuid.MustParse(req.UserID)); err != nil {
+		h.respondMFAError(w, err)
 		return
 	}
-	h.Logger.GetLogger().Info("User login successful", zap.String("userID", userID.String()))
-	h.Logger.GetLogger().Info("access_token", zap.String("access_token", accessToken))
-	h.Logger.GetLogger().Info("refresh_token", zap.String("refresh_token", refreshToken))
-
-	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
-		"user_id":       userID,
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
-	})
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "mfa reset"})
 }
 
-func (h *UserHandler) GetUserDashboard(w http.ResponseWriter, r *http.Request) {
-	h.Logger.GetLogger().Info("GetUserDashboard request received")
-	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
-	if err != nil {
-		h.Logger.GetLogger().Error("Unauthorized access attempt in GetUserDashboard", zap.Error(err))
-		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+// ForceLogoutUser signs a user out of every session, e.g. after a compromise or termination
+func (h *UserHandler) ForceLogoutUser(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("ForceLogoutUser request received")
+	adminID, ok := h.currentUserID(w, r, "ForceLogoutUser")
+	if !ok {
+		return
+	}
+	var req ForceLogoutReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input body in ForceLogoutUser", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input body")
 		return
 	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in ForceLogoutUser", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+	if err := h.Service.ForceLogout(r.Context(), adminID, uuid.MustParse(req.UserID), req.Reason); err != nil {
+		h.Logger.GetLogger().Error("Failed to force logout user", zap.String("userID", req.UserID), zap.Error(err))
+		if errors.Is(err, ErrUserNotFound) {
+			utils.RespondError(w, http.StatusNotFound, err, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to force logout user")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "user logged out of all sessions"})
+}
 
-	userUUID, err := uuid.Parse(userID)
+// SyncDirectory runs the directory sync now, dry_run=true only reports what would change
+func (h *UserHandler) SyncDirectory(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("SyncDirectory request received")
+	adminID, ok := h.currentUserID(w, r, "SyncDirectory")
+	if !ok {
+		return
+	}
+	dryRun := false
+	if value := r.URL.Query().Get("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			utils.RespondError(w, http.StatusBadRequest, err, "invalid dry_run")
+			return
+		}
+		dryRun = parsed
+	}
+	report, err := h.Service.SyncDirectory(r.Context(), dryRun, &adminID)
 	if err != nil {
-		h.Logger.GetLogger().Error("Invalid user ID format in GetUserDashboard", zap.String("userID", userID), zap.Error(err))
-		utils.RespondError(w, http.StatusUnauthorized, err, "invalid user id")
+		h.Logger.GetLogger().Error("Directory sync failed", zap.Bool("dryRun", dryRun), zap.Error(err))
+		switch {
+		case errors.Is(err, ErrDirectoryNotEnabled):
+			utils.RespondError(w, http.StatusNotFound, err, err.Error())
+		case errors.Is(err, ErrDirectorySyncRunning):
+			utils.RespondError(w, http.StatusConflict, err, err.Error())
+		default:
+			// the failed run is still reported, its errors say what went wrong
+			utils.RespondJSON(w, http.StatusBadGateway, report)
+		}
 		return
 	}
+	utils.RespondJSON(w, http.StatusOK, report)
+}
 
-	h.Logger.GetLogger().Debug("Fetching dashboard for user", zap.String("userID", userID))
-	dashboard, err := h.Service.GetDashboard(r.Context(), userUUID)
+func (h *UserHandler) GetDirectorySyncReports(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetDirectorySyncReports request received")
+	limit, offset := utils.GetPageLimitAndOffset(r)
+	reports, err := h.Service.GetDirectorySyncReports(r.Context(), limit, offset)
 	if err != nil {
-		h.Logger.GetLogger().Error("Failed to fetch dashboard data", zap.String("userID", userID), zap.Error(err))
-		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch dashboard data")
+		h.Logger.GetLogger().Error("Failed to fetch directory sync reports", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch directory sync reports")
 		return
 	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"reports": reports, "limit": limit, "offset": offset})
+}
 
-	h.Logger.GetLogger().Info("Successfully fetched user dashboard", zap.String("userID", userID))
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(dashboard)
+// respondLoginLocked answers 429 with Retry-After when err is a login lockout
+func respondLoginLocked(w http.ResponseWriter, err error) bool {
+	var locked *LoginLockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	retryAfter := int(math.Ceil(locked.RetryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	utils.RespondError(w, http.StatusTooManyRequests, ErrLoginLocked.WithDetails(map[string]int{"retry_after_seconds": retryAfter}), err.Error())
+	return true
 }
 
-func (h *UserHandler) GoogleAuth(w http.ResponseWriter, r *http.Request) {
-	h.Logger.GetLogger().Info("GoogleAuth request received")
-	authHeader := r.Header.Get("Authorization")
-	if !strings.HasPrefix(authHeader, "Bearer ") {
-		h.Logger.GetLogger().Warn("missing bearer token in GoogleAuth request")
-		utils.RespondError(w, http.StatusBadRequest, fmt.Errorf("missing bearer token"), "unauthorized")
+// UnlockLogin lets an admin lift a login lockout on an email or ip before it expires
+func (h *UserHandler) UnlockLogin(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("UnlockLogin request received")
+	adminID, ok := h.currentUserID(w, r, "UnlockLogin")
+	if !ok {
 		return
 	}
-	idToken := strings.TrimPrefix(authHeader, "Bearer ")
-	h.Logger.GetLogger().Debug("Google authentication called")
-	userID, accessToken, refreshToken, err := h.Service.GoogleAuth(r.Context(), idToken)
-	if err != nil {
-		h.Logger.GetLogger().Error("Google authentication failed", zap.Error(err))
-		utils.RespondError(w, http.StatusUnauthorized, err, "google auth failed")
+	var req UnlockLoginReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input body in UnlockLogin", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in UnlockLogin", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
 		return
 	}
+	if err := h.Service.UnlockLogin(r.Context(), adminID, req); err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to unlock login")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "login unlocked"})
+}
 
-	h.Logger.GetLogger().Info("Google authentication successful", zap.String("userID", userID.String()))
-	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
-		"user_id":       userID,
-		"access_token":  accessToken,
-		"refresh_token": refreshToken,
-	})
+func (h *UserHandler) currentUserID(w http.ResponseWriter, r *http.Request, handler string) (uuid.UUID, bool) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return uuid.Nil, false
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in "+handler, zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return uuid.Nil, false
+	}
+	return userUUID, true
 }
 
-func (h *UserHandler) CreateAdmin(w http.ResponseWriter, r *http.Request) {
-	h.Logger.GetLogger().Info("CreateAdmin request received")
-	is := h.Service.CreateFirstAdmin()
-	if !is {
-		jsoniter.NewEncoder(w).Encode(map[string]string{"message": "failed to create admin created"})
-	} else {
-		jsoniter.NewEncoder(w).Encode(map[string]string{"message": "admin created successfully"})
+func (h *UserHandler) parseMFACode(w http.ResponseWriter, r *http.Request, handler string) (MFACodeReq, bool) {
+	var req MFACodeReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input body in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input body")
+		return req, false
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return req, false
+	}
+	return req, true
+}
+
+func (h *UserHandler) respondMFAError(w http.ResponseWriter, err error) {
+	h.Logger.GetLogger().Error("MFA request failed", zap.Error(err))
+	if respondLoginLocked(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, ErrMFAChallengeInvalid), errors.Is(err, ErrMFAInvalidCode):
+		utils.RespondError(w, http.StatusUnauthorized, err, err.Error())
+	case errors.Is(err, ErrMFATooManyAttempts):
+		utils.RespondError(w, http.StatusTooManyRequests, err, err.Error())
+	case errors.Is(err, ErrMFAAlreadyEnabled):
+		utils.RespondError(w, http.StatusConflict, err, err.Error())
+	case errors.Is(err, ErrMFANotEnabled), errors.Is(err, ErrMFANotEnrolled):
+		utils.RespondError(w, http.StatusBadRequest, err, err.Error())
+	case errors.Is(err, ErrMFARequiredByRole):
+		utils.RespondError(w, http.StatusForbidden, err, err.Error())
+	default:
+		utils.RespondError(w, http.StatusInternalServerError, err, "mfa request failed")
 	}
 }
 
@@ -373,35 +1695,41 @@ func (h *UserHandler) PublicRegisterThroughFirebase(w http.ResponseWriter, r *ht
 	idToken := strings.TrimPrefix(authHeader, "Bearer ")
 	resp, err := h.Service.FirebaseUserRegistration(r.Context(), idToken)
 	if err != nil {
-		if strings.Contains(err.Error(), "user already exists") {
-			utils.RespondError(w, http.StatusConflict, err, "user already exists")
-		} else if strings.Contains(err.Error(), "invalid firebase token") {
-			utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
-		} else {
-			utils.RespondError(w, http.StatusInternalServerError, err, "registration failed")
-		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "registration failed")
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	utils.JSON.NewEncoder(w).Encode(map[string]interface{}{
 		"message":     "register through firebase successful",
 		"userId":      resp.UserID,
 		"firebaseUID": resp.FirebaseUID,
 	})
 }
 
+// DeleteUser removes an employee, holders of a privileged role are left to DeletePrivilegedUser
 func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
-	h.Logger.GetLogger().Info("DeleteUser request received")
-	_, roles, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	h.deleteUser(w, r, false)
+}
+
+// DeletePrivilegedUser removes any user including holders of a privileged role, it is only mounted
+// under the admin routes
+func (h *UserHandler) DeletePrivilegedUser(w http.ResponseWriter, r *http.Request) {
+	h.deleteUser(w, r, true)
+}
+
+func (h *UserHandler) deleteUser(w http.ResponseWriter, r *http.Request, privileged bool) {
+	h.Logger.GetLogger().Info("DeleteUser request received", zap.Bool("privileged", privileged))
+	initiatorID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
 	if err != nil {
 		h.Logger.GetLogger().Error("Unauthorized access attempt in DeleteUser", zap.Error(err))
 		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
 		return
 	}
-	if len(roles) == 0 || (roles[0] != "admin" && roles[0] != "asset_manager") {
-		h.Logger.GetLogger().Warn("Forbidden access attempt in DeleteUser", zap.Any("roles", roles))
-		utils.RespondError(w, http.StatusForbidden, nil, "only admin and asset manager can delete users")
+	initiatorUUID, err := uuid.Parse(initiatorID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse initiatorID in DeleteUser", zap.String("initiatorID", initiatorID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
 		return
 	}
 	userID := r.URL.Query().Get("user_id")
@@ -417,16 +1745,31 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.Logger.GetLogger().Info("Attempting to delete user", zap.String("userID", userID), zap.String("initiatingRole", roles[0]))
-	err = h.Service.DeleteUser(r.Context(), userUUID, roles[0])
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in DeleteUser", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+
+	h.Logger.GetLogger().Info("Attempting to delete user", zap.String("userID", userID), zap.String("initiatorID", initiatorID))
+	err = h.Service.DeleteUser(r.Context(), userUUID, initiatorUUID, privileged, scope)
 	if err != nil {
 		h.Logger.GetLogger().Error("Failed to delete user", zap.String("userID", userID), zap.Error(err))
-		utils.RespondError(w, http.StatusInternalServerError, err, err.Error())
+		if errors.Is(err, models.ErrOutOfScope) {
+			utils.RespondError(w, http.StatusForbidden, err, "user belongs to another department")
+			return
+		}
+		if errors.Is(err, ErrPrivilegedTarget) {
+			utils.RespondError(w, http.StatusForbidden, err, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to delete user")
 		return
 	}
 	h.Logger.GetLogger().Info("User deleted successfully", zap.String("userID", userID))
 	w.WriteHeader(http.StatusOK)
-	jsoniter.NewEncoder(w).Encode(map[string]string{"message": "user deleted successfully"})
+	utils.JSON.NewEncoder(w).Encode(map[string]string{"message": "user deleted successfully"})
 }
 
 func (h *UserHandler) RedisTesting(w http.ResponseWriter, r *http.Request) {
@@ -1,16 +1,22 @@
 package userservice
 
 import (
+	"asset/models"
 	"asset/providers"
+	"asset/providers/middlewareprovider"
 	"asset/utils"
 	"bytes"
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
@@ -19,6 +25,41 @@ import (
 	"github.com/golang/mock/gomock"
 )
 
+// employeeRoutePolicy is what the migrations grant on the employee routes, the handlers no longer
+// check roles themselves so their tests go through RequirePermission the way the router puts it
+var employeeRoutePolicy = map[string][]string{
+	"admin":            {"user.read", "user.create", "user.update", "user.delete"},
+	"asset_manager":    {"user.delete"},
+	"employee_manager": {"user.read", "user.create", "user.update"},
+}
+
+// behindPermission puts the real RequirePermission in front of the handler, with the policy read
+// from employeeRoutePolicy and no delegations
+func behindPermission(t *testing.T, permission models.Permission, handler http.HandlerFunc) http.Handler {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	rows := sqlmock.NewRows([]string{"role", "permission"})
+	for role, permissions := range employeeRoutePolicy {
+		for _, permission := range permissions {
+			rows.AddRow(role, permission)
+		}
+	}
+	mock.ExpectQuery("SELECT role, permission FROM role_permissions").WillReturnRows(rows)
+	mock.ExpectQuery("FROM role_delegations").WillReturnRows(sqlmock.NewRows([]string{"role"}))
+	auth := middlewareprovider.NewAuthMiddlewareService(sqlx.NewDb(db, "sqlmock"), nil, models.AuthCookieConfig{}, "")
+	return auth.RequirePermission(permission)(handler)
+}
+
+// withCaller signs the request in the way JWTAuthMiddleware leaves it for RequirePermission
+func withCaller(req *http.Request, userID string, roles []string) *http.Request {
+	ctx := context.WithValue(req.Context(), middlewareprovider.UserContextKey, userID)
+	return req.WithContext(context.WithValue(ctx, middlewareprovider.RolesContextKey, roles))
+}
+
 func TestGetEmployeesWithFilters(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -26,6 +67,7 @@ func TestGetEmployeesWithFilters(t *testing.T) {
 	//mock services
 	mockService := NewMockUserService(ctrl)
 	mockAuth := providers.NewMockAuthMiddlewareService(ctrl)
+	mockAuth.EXPECT().GetDepartmentScope(gomock.Any()).Return(models.DepartmentScope{AllDepartments: true}, nil).AnyTimes()
 	mockLogger := providers.NewMockZapLoggerProvider(ctrl)
 	mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
 
@@ -71,7 +113,7 @@ func TestGetEmployeesWithFilters(t *testing.T) {
 	}{
 		{
 			name:               "success, valid role and filter",
-			queryParams:        "?page =1&limit=10&search=&type=full_time&role=employee&=",
+			queryParams:        "?page=1&limit=10&search=&type=full_time&role=employee",
 			systemUserID:       managerID.String(),
 			authRoles:          []string{"employee_manager"},
 			expectServiceCall:  true,
@@ -89,6 +131,12 @@ func TestGetEmployeesWithFilters(t *testing.T) {
 			authRoles:          []string{"employee"},
 			expectedStatusCode: http.StatusForbidden,
 		},
+		{
+			name:               "forbidden, role without user.read",
+			systemUserID:       managerID.String(),
+			authRoles:          []string{"asset_manager"},
+			expectedStatusCode: http.StatusForbidden,
+		},
 		{
 			name:               "internal server error",
 			queryParams:        "?search=test",
@@ -104,13 +152,19 @@ func TestGetEmployeesWithFilters(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			req := httptest.NewRequest(http.MethodGet, "/api/user/get-employees"+tc.queryParams, nil)
 			respRecorder := httptest.NewRecorder()
-
-			//mock middleware test
 			if tc.authErr != nil {
+				// without a caller the handler itself refuses
 				mockAuth.EXPECT().
 					GetUserAndRolesFromContext(req).
 					Return("", nil, tc.authErr)
-			} else {
+				handler.GetEmployeesWithFilters(respRecorder, req)
+				assert.Equal(t, tc.expectedStatusCode, respRecorder.Code)
+				return
+			}
+			req = withCaller(req, tc.systemUserID, tc.authRoles)
+
+			//mock middleware test
+			if tc.expectedStatusCode != http.StatusForbidden {
 				mockAuth.EXPECT().
 					GetUserAndRolesFromContext(req).
 					Return(tc.systemUserID, tc.authRoles, nil)
@@ -126,6 +180,7 @@ func TestGetEmployeesWithFilters(t *testing.T) {
 					AssetStatus:  strings.Split(req.URL.Query().Get("asset_status"), ","),
 				}
 				expectedFilter.Limit, expectedFilter.Offset = utils.GetPageLimitAndOffset(req)
+				expectedFilter.Scope = models.DepartmentScope{AllDepartments: true}
 
 				mockService.EXPECT().
 					GetEmployeesWithFilters(gomock.Any(), expectedFilter).
@@ -133,7 +188,7 @@ func TestGetEmployeesWithFilters(t *testing.T) {
 			}
 
 			//call handler
-			handler.GetEmployeesWithFilters(respRecorder, req)
+			behindPermission(t, models.UserReadPermission, handler.GetEmployeesWithFilters).ServeHTTP(respRecorder, req)
 
 			assert.Equal(t, tc.expectedStatusCode, respRecorder.Code)
 
@@ -191,14 +246,14 @@ func TestPublicRegisterThroughFirebase(t *testing.T) {
 		{
 			name:               "invalid firebase token",
 			authHeader:         "Bearer " + mockToken,
-			mockServiceErr:     errors.New("invalid firebase token"),
+			mockServiceErr:     ErrInvalidFirebaseToken,
 			expectedStatusCode: http.StatusUnauthorized,
-			expectedMessage:    "unauthorized",
+			expectedMessage:    "invalid firebase token",
 		},
 		{
 			name:               "user already exists",
 			authHeader:         "Bearer " + mockToken,
-			mockServiceErr:     errors.New("user already exists"),
+			mockServiceErr:     ErrUserAlreadyExists,
 			expectedStatusCode: http.StatusConflict,
 			expectedMessage:    "user already exists",
 		},
@@ -341,8 +396,8 @@ func TestUserLoginHandler(t *testing.T) {
 				mockUserService.EXPECT().
 					UserLogin(gomock.Any(), PublicUserReq{
 						Email: "test.user27@remotestate.com",
-					}).
-					Return(uuid.New(), "access_token", "refresh_token", nil)
+					}, gomock.Any()).
+					Return(LoginRes{UserID: uuid.New(), AccessToken: "access_token", RefreshToken: "refresh_token"}, nil)
 			},
 			expectedStatusCode: http.StatusOK,
 			expectResponseFields: map[string]bool{
@@ -369,10 +424,23 @@ func TestUserLoginHandler(t *testing.T) {
 			},
 			mockServiceProvider: func(mockUserService *MockUserService) {
 				mockUserService.EXPECT().
-					UserLogin(gomock.Any(), PublicUserReq{Email: "test.user27@remotestate.com"}).
-					Return(uuid.Nil, "", "", fmt.Errorf("login failed"))
+					UserLogin(gomock.Any(), PublicUserReq{Email: "test.user27@remotestate.com"}, gomock.Any()).
+					Return(LoginRes{}, fmt.Errorf("login failed"))
+			},
+			expectedStatusCode:   http.StatusInternalServerError,
+			expectResponseFields: map[string]bool{},
+		},
+		{
+			name: "locked out",
+			reqBody: PublicUserReq{
+				Email: "test.user27@remotestate.com",
+			},
+			mockServiceProvider: func(mockUserService *MockUserService) {
+				mockUserService.EXPECT().
+					UserLogin(gomock.Any(), PublicUserReq{Email: "test.user27@remotestate.com"}, gomock.Any()).
+					Return(LoginRes{}, &LoginLockedError{RetryAfter: 90 * time.Second})
 			},
-			expectedStatusCode:   http.StatusUnauthorized,
+			expectedStatusCode:   http.StatusTooManyRequests,
 			expectResponseFields: map[string]bool{},
 		},
 	}
@@ -423,6 +491,7 @@ func TestGoogleAuth(t *testing.T) {
 
 	tests := []struct {
 		name               string
+		provider           string
 		idToken            string
 		mockService        func(service *MockUserService, logger *providers.MockZapLoggerProvider)
 		expectedStatusCode int
@@ -436,8 +505,8 @@ func TestGoogleAuth(t *testing.T) {
 			mockService: func(service *MockUserService, logger *providers.MockZapLoggerProvider) {
 				logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
 				service.EXPECT().
-					GoogleAuth(gomock.Any(), idToken).
-					Return(uuid.New(), "access_token", "refresh_token", nil)
+					OIDCLogin(gomock.Any(), "google", idToken, gomock.Any()).
+					Return(LoginRes{UserID: uuid.New(), AccessToken: "access_token", RefreshToken: "refresh_token"}, nil)
 			},
 			expectedStatusCode: http.StatusOK,
 			expectAccessToken:  "access_token",
@@ -458,11 +527,34 @@ func TestGoogleAuth(t *testing.T) {
 				logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
 
 				service.EXPECT().
-					GoogleAuth(gomock.Any(), idToken).
-					Return(uuid.Nil, "", "", errors.New("invalid token"))
+					OIDCLogin(gomock.Any(), "google", idToken, gomock.Any()).
+					Return(LoginRes{}, errors.New("invalid token"))
 			},
 			expectedStatusCode: http.StatusUnauthorized,
 		},
+		{
+			name:     "GitHub access token",
+			provider: "github",
+			idToken:  idToken,
+			mockService: func(service *MockUserService, logger *providers.MockZapLoggerProvider) {
+				logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+				service.EXPECT().
+					OIDCLogin(gomock.Any(), "github", idToken, gomock.Any()).
+					Return(LoginRes{UserID: uuid.New(), AccessToken: "access_token", RefreshToken: "refresh_token"}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectAccessToken:  "access_token",
+			expectRefreshToken: "refresh_token",
+		},
+		{
+			name:     "Unsupported provider",
+			provider: "okta",
+			idToken:  idToken,
+			mockService: func(service *MockUserService, logger *providers.MockZapLoggerProvider) {
+				logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+			},
+			expectedStatusCode: http.StatusBadRequest,
+		},
 	}
 
 	for _, tc := range tests {
@@ -477,7 +569,11 @@ func TestGoogleAuth(t *testing.T) {
 				Logger:  mockLogger,
 			}
 
-			req := httptest.NewRequest(http.MethodPost, "/api/user/google-auth", bytes.NewReader(nil))
+			target := "/api/v2/user/login"
+			if tc.provider != "" {
+				target += "?provider=" + tc.provider
+			}
+			req := httptest.NewRequest(http.MethodPost, target, bytes.NewReader(nil))
 			if tc.idToken != "" {
 				req.Header.Set("Authorization", "Bearer "+tc.idToken)
 			}
@@ -500,12 +596,174 @@ func TestGoogleAuth(t *testing.T) {
 	}
 }
 
+func TestRefreshTokenHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name               string
+		reqBody            interface{}
+		mockService        func(service *MockUserService)
+		expectedStatusCode int
+	}{
+		{
+			name:    "new pair in the body",
+			reqBody: RefreshTokenReq{RefreshToken: "refresh"},
+			mockService: func(service *MockUserService) {
+				service.EXPECT().RefreshTokens(gomock.Any(), RefreshTokenReq{RefreshToken: "refresh"}, gomock.Any()).
+					Return(RefreshTokenRes{AccessToken: "access", RefreshToken: "next", AccessTokenExpiresAt: time.Now().Add(5 * time.Minute)}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "missing refresh token",
+			reqBody:            RefreshTokenReq{},
+			mockService:        func(service *MockUserService) {},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:    "spent or revoked refresh token",
+			reqBody: RefreshTokenReq{RefreshToken: "refresh"},
+			mockService: func(service *MockUserService) {
+				service.EXPECT().RefreshTokens(gomock.Any(), RefreshTokenReq{RefreshToken: "refresh"}, gomock.Any()).
+					Return(RefreshTokenRes{}, fmt.Errorf("%w: reused", ErrSessionExpired))
+			},
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name:    "store failure",
+			reqBody: RefreshTokenReq{RefreshToken: "refresh"},
+			mockService: func(service *MockUserService) {
+				service.EXPECT().RefreshTokens(gomock.Any(), RefreshTokenReq{RefreshToken: "refresh"}, gomock.Any()).
+					Return(RefreshTokenRes{}, errors.New("db down"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockService := NewMockUserService(ctrl)
+			mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+			mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+			tc.mockService(mockService)
+
+			handler := &UserHandler{
+				Service: mockService,
+				Logger:  mockLogger,
+			}
+
+			reqBytes, _ := jsoniter.Marshal(tc.reqBody)
+			req := httptest.NewRequest(http.MethodPost, "/api/auth/refresh", bytes.NewReader(reqBytes))
+			req.Header.Set("Content-Type", "application/json")
+			resRecorder := httptest.NewRecorder()
+
+			handler.RefreshToken(resRecorder, req)
+
+			assert.Equal(t, tc.expectedStatusCode, resRecorder.Code)
+			if tc.expectedStatusCode == http.StatusOK {
+				var result map[string]interface{}
+				assert.NoError(t, jsoniter.Unmarshal(resRecorder.Body.Bytes(), &result))
+				assert.Equal(t, "access", result["access_token"])
+				assert.Equal(t, "next", result["refresh_token"])
+				assert.Contains(t, result, "access_token_expires_at")
+			}
+		})
+	}
+}
+
+func TestRefreshTokenHandlerCookieMode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tests := []struct {
+		name               string
+		csrfHeader         string
+		mockService        func(service *MockUserService)
+		expectedStatusCode int
+	}{
+		{
+			name:       "refresh cookie with matching csrf header",
+			csrfHeader: "csrf",
+			mockService: func(service *MockUserService) {
+				service.EXPECT().RefreshTokens(gomock.Any(), RefreshTokenReq{RefreshToken: "refresh"}, gomock.Any()).
+					Return(RefreshTokenRes{AccessToken: "access", RefreshToken: "next", AccessTokenExpiresAt: time.Now().Add(5 * time.Minute)}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "refresh cookie without csrf header",
+			mockService:        func(service *MockUserService) {},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "refresh cookie with wrong csrf header",
+			csrfHeader:         "other",
+			mockService:        func(service *MockUserService) {},
+			expectedStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockService := NewMockUserService(ctrl)
+			mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+			mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+			tc.mockService(mockService)
+
+			handler := &UserHandler{
+				Service: mockService,
+				Logger:  mockLogger,
+				cookies: models.AuthCookieConfig{Enabled: true, Secure: true, SameSite: http.SameSiteLaxMode},
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/auth/refresh", nil)
+			req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "refresh"})
+			req.AddCookie(&http.Cookie{Name: "csrf_token", Value: "csrf"})
+			if tc.csrfHeader != "" {
+				req.Header.Set("X-CSRF-Token", tc.csrfHeader)
+			}
+			resRecorder := httptest.NewRecorder()
+
+			handler.RefreshToken(resRecorder, req)
+
+			assert.Equal(t, tc.expectedStatusCode, resRecorder.Code)
+			if tc.expectedStatusCode == http.StatusOK {
+				var result map[string]interface{}
+				assert.NoError(t, jsoniter.Unmarshal(resRecorder.Body.Bytes(), &result))
+				assert.NotContains(t, result, "access_token")
+				assert.NotContains(t, result, "refresh_token")
+				assert.NotEmpty(t, result["csrf_token"])
+
+				cookies := map[string]*http.Cookie{}
+				for _, c := range resRecorder.Result().Cookies() {
+					cookies[c.Name] = c
+				}
+				if assert.Contains(t, cookies, "access_token") {
+					assert.Equal(t, "access", cookies["access_token"].Value)
+					assert.True(t, cookies["access_token"].HttpOnly)
+				}
+				if assert.Contains(t, cookies, "refresh_token") {
+					assert.Equal(t, "next", cookies["refresh_token"].Value)
+				}
+				if assert.Contains(t, cookies, "csrf_token") {
+					assert.Equal(t, result["csrf_token"], cookies["csrf_token"].Value)
+					assert.False(t, cookies["csrf_token"].HttpOnly)
+				}
+			}
+		})
+	}
+}
+
 func TestRegisterEmployeeByManager(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockService := NewMockUserService(ctrl)
 	mockAuth := providers.NewMockAuthMiddlewareService(ctrl)
+	mockAuth.EXPECT().GetDepartmentScope(gomock.Any()).Return(models.DepartmentScope{AllDepartments: true}, nil).AnyTimes()
 	mockLogger := providers.NewMockZapLoggerProvider(ctrl)
 	mockLogger.EXPECT().GetLogger().Return(zap.NewExample()).AnyTimes()
 
@@ -547,7 +805,7 @@ func TestRegisterEmployeeByManager(t *testing.T) {
 			},
 			systemUserID:       managerID.String(),
 			authRoles:          []string{"employee"},
-			expectedStatusCode: http.StatusUnauthorized,
+			expectedStatusCode: http.StatusForbidden,
 		},
 		{
 			name: "invalid req body",
@@ -589,18 +847,23 @@ func TestRegisterEmployeeByManager(t *testing.T) {
 			if tc.authErr != nil {
 				mockAuth.EXPECT().GetUserAndRolesFromContext(req).
 					Return("", nil, tc.authErr)
-			} else {
+				handler.RegisterEmployeeByManager(ResponseRecorder, req)
+				assert.Equal(t, tc.expectedStatusCode, ResponseRecorder.Code)
+				return
+			}
+			req = withCaller(req, tc.systemUserID, tc.authRoles)
+			if tc.expectedStatusCode != http.StatusForbidden {
 				mockAuth.EXPECT().GetUserAndRolesFromContext(req).
 					Return(tc.systemUserID, tc.authRoles, nil)
 			}
 
 			if tc.expectServiceCall {
 				mockService.EXPECT().
-					RegisterEmployeeByManager(gomock.Any(), tc.requestBody, gomock.Any()).
-					Return(tc.serviceReturnID, tc.serviceErr)
+					RegisterEmployeeByManager(gomock.Any(), tc.requestBody, gomock.Any(), gomock.Any()).
+					Return(tc.serviceReturnID, nil, tc.serviceErr)
 			}
 
-			handler.RegisterEmployeeByManager(ResponseRecorder, req)
+			behindPermission(t, models.UserCreatePermission, handler.RegisterEmployeeByManager).ServeHTTP(ResponseRecorder, req)
 
 			assert.Equal(t, tc.expectedStatusCode, ResponseRecorder.Code)
 
@@ -614,12 +877,82 @@ func TestRegisterEmployeeByManager(t *testing.T) {
 	}
 }
 
+func TestAcceptInviteHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := NewMockUserService(ctrl)
+	mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+	mockLogger.EXPECT().GetLogger().Return(zap.NewExample()).AnyTimes()
+
+	handler := &UserHandler{
+		Service: mockService,
+		Logger:  mockLogger,
+	}
+
+	userID := uuid.New()
+
+	testCases := []struct {
+		name               string
+		requestBody        AcceptInviteReq
+		expectServiceCall  bool
+		serviceErr         error
+		expectedStatusCode int
+	}{
+		{
+			name:               "success",
+			requestBody:        AcceptInviteReq{Token: "token", Username: "test user40", ContactNo: "9876543210"},
+			expectServiceCall:  true,
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name:               "missing contact number",
+			requestBody:        AcceptInviteReq{Token: "token", Username: "test user41"},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "invite already used",
+			requestBody:        AcceptInviteReq{Token: "token", Username: "test user42", ContactNo: "9876543211"},
+			expectServiceCall:  true,
+			serviceErr:         ErrInviteInvalid,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "invite expired",
+			requestBody:        AcceptInviteReq{Token: "token", Username: "test user43", ContactNo: "9876543212"},
+			expectServiceCall:  true,
+			serviceErr:         ErrInviteExpired,
+			expectedStatusCode: http.StatusGone,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			body, _ := jsoniter.Marshal(tc.requestBody)
+			req := httptest.NewRequest(http.MethodPost, "/api/user/invite/accept", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			ResponseRecorder := httptest.NewRecorder()
+
+			if tc.expectServiceCall {
+				mockService.EXPECT().
+					AcceptInvite(gomock.Any(), tc.requestBody).
+					Return(userID, nil, tc.serviceErr)
+			}
+
+			handler.AcceptInvite(ResponseRecorder, req)
+
+			assert.Equal(t, tc.expectedStatusCode, ResponseRecorder.Code)
+		})
+	}
+}
+
 func TestDeleteUserHandler(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockService := NewMockUserService(ctrl)
 	mockAuth := providers.NewMockAuthMiddlewareService(ctrl)
+	mockAuth.EXPECT().GetDepartmentScope(gomock.Any()).Return(models.DepartmentScope{AllDepartments: true}, nil).AnyTimes()
 	mockLogger := providers.NewMockZapLoggerProvider(ctrl)
 	mockLogger.EXPECT().GetLogger().Return(zap.NewExample()).AnyTimes()
 
@@ -637,6 +970,7 @@ func TestDeleteUserHandler(t *testing.T) {
 		systemUserID       string
 		authRoles          []string
 		authErr            error
+		privileged         bool
 		expectServiceCall  bool
 		serviceErr         error
 		expectedStatusCode int
@@ -670,12 +1004,46 @@ func TestDeleteUserHandler(t *testing.T) {
 			authRoles:          []string{"employee"},
 			expectedStatusCode: http.StatusForbidden,
 		},
+		{
+			// deleting stayed with the asset managers when the role checks became permissions
+			name:               "unauthorized employee manager",
+			queryUserID:        userID.String(),
+			systemUserID:       uuid.New().String(),
+			authRoles:          []string{"employee_manager"},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "asset manager deletes an employee",
+			queryUserID:        userID.String(),
+			systemUserID:       uuid.New().String(),
+			authRoles:          []string{"asset_manager"},
+			expectServiceCall:  true,
+			expectedStatusCode: http.StatusOK,
+		},
 		{
 			name:               "unauthorized due to missing context",
 			queryUserID:        userID.String(),
 			authErr:            errors.New("unauthorized"),
 			expectedStatusCode: http.StatusUnauthorized,
 		},
+		{
+			name:               "admin route deletes a manager",
+			queryUserID:        userID.String(),
+			systemUserID:       uuid.New().String(),
+			authRoles:          []string{"admin"},
+			privileged:         true,
+			expectServiceCall:  true,
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "manager route refuses a privileged target",
+			queryUserID:        userID.String(),
+			systemUserID:       uuid.New().String(),
+			authRoles:          []string{"asset_manager"},
+			expectServiceCall:  true,
+			serviceErr:         ErrPrivilegedTarget,
+			expectedStatusCode: http.StatusForbidden,
+		},
 		{
 			name:               "internal error deleting user",
 			queryUserID:        userID.String(),
@@ -695,18 +1063,27 @@ func TestDeleteUserHandler(t *testing.T) {
 			if tc.authErr != nil {
 				mockAuth.EXPECT().GetUserAndRolesFromContext(req).
 					Return("", nil, tc.authErr)
-			} else {
+				handler.DeleteUser(res, req)
+				assert.Equal(t, tc.expectedStatusCode, res.Code)
+				return
+			}
+			req = withCaller(req, tc.systemUserID, tc.authRoles)
+			if tc.expectedStatusCode != http.StatusForbidden || tc.serviceErr != nil {
 				mockAuth.EXPECT().GetUserAndRolesFromContext(req).
 					Return(tc.systemUserID, tc.authRoles, nil)
 			}
 
 			if tc.expectServiceCall {
 				mockService.EXPECT().
-					DeleteUser(gomock.Any(), gomock.Any(), tc.authRoles[0]).
+					DeleteUser(gomock.Any(), gomock.Any(), uuid.MustParse(tc.systemUserID), tc.privileged, gomock.Any()).
 					Return(tc.serviceErr)
 			}
 
-			handler.DeleteUser(res, req)
+			route := handler.DeleteUser
+			if tc.privileged {
+				route = handler.DeletePrivilegedUser
+			}
+			behindPermission(t, models.UserDeletePermission, route).ServeHTTP(res, req)
 
 			assert.Equal(t, tc.expectedStatusCode, res.Code)
 
@@ -719,3 +1096,260 @@ func TestDeleteUserHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestExportEmployeesHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := NewMockUserService(ctrl)
+	mockAuth := providers.NewMockAuthMiddlewareService(ctrl)
+	mockAuth.EXPECT().GetUserAndRolesFromContext(gomock.Any()).Return(uuid.New().String(), []string{"admin"}, nil).AnyTimes()
+	mockAuth.EXPECT().GetDepartmentScope(gomock.Any()).Return(models.DepartmentScope{AllDepartments: true}, nil).AnyTimes()
+	mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+	mockLogger.EXPECT().GetLogger().Return(zap.NewExample()).AnyTimes()
+
+	handler := &UserHandler{
+		Service:        mockService,
+		Logger:         mockLogger,
+		AuthMiddleware: mockAuth,
+	}
+
+	row := []string{"id-1", "john", "john@remotestate.com", "+919876543210", "full_time", "employee", "", "", "", "", "", "SN-1;SN-2"}
+
+	testCases := []struct {
+		name               string
+		query              string
+		expectServiceCall  bool
+		serviceErr         error
+		expectedStatusCode int
+		expectedType       string
+	}{
+		{
+			name:               "defaults to csv",
+			query:              "?type=full_time",
+			expectServiceCall:  true,
+			expectedStatusCode: http.StatusOK,
+			expectedType:       "text/csv",
+		},
+		{
+			name:               "xlsx export",
+			query:              "?format=xlsx",
+			expectServiceCall:  true,
+			expectedStatusCode: http.StatusOK,
+			expectedType:       "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		},
+		{
+			name:               "unsupported format",
+			query:              "?format=pdf",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "service failure",
+			query:              "",
+			expectServiceCall:  true,
+			serviceErr:         errors.New("db down"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/employee/employees/export"+tc.query, nil)
+			res := httptest.NewRecorder()
+
+			if tc.expectServiceCall {
+				var rows [][]string
+				if tc.serviceErr == nil {
+					rows = [][]string{row}
+				}
+				mockService.EXPECT().ExportEmployees(gomock.Any(), gomock.Any()).Return(rows, tc.serviceErr)
+			}
+
+			handler.ExportEmployees(res, req)
+
+			assert.Equal(t, tc.expectedStatusCode, res.Code)
+			if tc.expectedType != "" {
+				assert.Equal(t, tc.expectedType, res.Header().Get("Content-Type"))
+			}
+			if tc.expectedType == "text/csv" {
+				assert.Equal(t, strings.Join(EmployeeExportHeader, ",")+"\n"+strings.Join(row, ",")+"\n", res.Body.String())
+			}
+		})
+	}
+}
+
+func TestStreamEmployeesHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := NewMockUserService(ctrl)
+	mockAuth := providers.NewMockAuthMiddlewareService(ctrl)
+	mockAuth.EXPECT().GetUserAndRolesFromContext(gomock.Any()).Return(uuid.New().String(), []string{"admin"}, nil).AnyTimes()
+	mockAuth.EXPECT().GetDepartmentScope(gomock.Any()).Return(models.DepartmentScope{AllDepartments: true}, nil).AnyTimes()
+	mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+	mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	handler := &UserHandler{
+		Service:        mockService,
+		Logger:         mockLogger,
+		AuthMiddleware: mockAuth,
+	}
+
+	rows := []EmployeeExportRow{
+		{ID: "id-1", Username: "john", Email: "john@remotestate.com", Roles: []string{"employee"}, AssetSerials: []string{"SN-1"}},
+		{ID: "id-2", Username: "jane", Email: "jane@remotestate.com", Roles: []string{"admin"}, AssetSerials: []string{}},
+	}
+
+	testCases := []struct {
+		name               string
+		sent               int
+		serviceErr         error
+		expectedStatusCode int
+		expectedLines      []string
+	}{
+		{
+			name:               "streams a line per employee",
+			sent:               2,
+			expectedStatusCode: http.StatusOK,
+			expectedLines: []string{
+				`{"id":"id-1","username":"john","email":"john@remotestate.com","roles":["employee"],"asset_serials":["SN-1"]}`,
+				`{"id":"id-2","username":"jane","email":"jane@remotestate.com","roles":["admin"],"asset_serials":[]}`,
+			},
+		},
+		{
+			name:               "no employees",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "failure before the first row",
+			serviceErr:         errors.New("db down"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:               "failure after a row ends with an error line",
+			sent:               1,
+			serviceErr:         errors.New("connection reset"),
+			expectedStatusCode: http.StatusOK,
+			expectedLines: []string{
+				`{"id":"id-1","username":"john","email":"john@remotestate.com","roles":["employee"],"asset_serials":["SN-1"]}`,
+				`{"error":{"code":"internal_error","message":"failed to export employees"}}`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/employee/employees/stream?type=full_time", nil)
+			res := httptest.NewRecorder()
+
+			mockService.EXPECT().StreamEmployees(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ EmployeeFilter, fn func(EmployeeExportRow) error) error {
+					for _, row := range rows[:tc.sent] {
+						if err := fn(row); err != nil {
+							return err
+						}
+					}
+					return tc.serviceErr
+				})
+
+			handler.StreamEmployees(res, req)
+
+			assert.Equal(t, tc.expectedStatusCode, res.Code)
+			if tc.expectedStatusCode != http.StatusOK {
+				return
+			}
+			assert.Equal(t, utils.NDJSONContentType, res.Header().Get("Content-Type"))
+			body := strings.TrimSuffix(res.Body.String(), "\n")
+			if len(tc.expectedLines) == 0 {
+				assert.Empty(t, body)
+				return
+			}
+			assert.Equal(t, tc.expectedLines, strings.Split(body, "\n"))
+		})
+	}
+}
+
+func TestGetScheduledRoleChangesHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orgID := uuid.New()
+	scope := models.DepartmentScope{AllDepartments: true, OrganizationID: &orgID}
+	mockService := NewMockUserService(ctrl)
+	mockAuth := providers.NewMockAuthMiddlewareService(ctrl)
+	mockAuth.EXPECT().GetDepartmentScope(gomock.Any()).Return(scope, nil).AnyTimes()
+	mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+	mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	handler := &UserHandler{
+		Service:        mockService,
+		Logger:         mockLogger,
+		AuthMiddleware: mockAuth,
+	}
+
+	userID := uuid.New()
+	change := ScheduledRoleChangeRes{ID: uuid.New(), UserID: userID, Role: "asset_manager", Status: "pending"}
+
+	testCases := []struct {
+		name               string
+		query              string
+		setupMocks         func()
+		expectedStatusCode int
+		expectedIDs        []uuid.UUID
+	}{
+		{
+			name:  "one user's changes carry the id to cancel by",
+			query: "?user_id=" + userID.String(),
+			setupMocks: func() {
+				mockService.EXPECT().GetScheduledRoleChanges(gomock.Any(), &userID, 10, 0, scope).Return([]ScheduledRoleChangeRes{change}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedIDs:        []uuid.UUID{change.ID},
+		},
+		{
+			name: "every user in scope",
+			setupMocks: func() {
+				mockService.EXPECT().GetScheduledRoleChanges(gomock.Any(), (*uuid.UUID)(nil), 10, 0, scope).Return([]ScheduledRoleChangeRes{}, nil)
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedIDs:        []uuid.UUID{},
+		},
+		{
+			name:               "invalid user id",
+			query:              "?user_id=nope",
+			setupMocks:         func() {},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "service failure",
+			setupMocks: func() {
+				mockService.EXPECT().GetScheduledRoleChanges(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), scope).Return(nil, errors.New("db down"))
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.setupMocks()
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/employee/scheduled-role-changes"+tc.query, nil)
+			res := httptest.NewRecorder()
+
+			handler.GetScheduledRoleChanges(res, req)
+
+			assert.Equal(t, tc.expectedStatusCode, res.Code)
+			if tc.expectedStatusCode != http.StatusOK {
+				return
+			}
+			var body struct {
+				Changes []ScheduledRoleChangeRes `json:"changes"`
+			}
+			assert.NoError(t, jsoniter.Unmarshal(res.Body.Bytes(), &body))
+			ids := make([]uuid.UUID, 0, len(body.Changes))
+			for _, c := range body.Changes {
+				ids = append(ids, c.ID)
+			}
+			assert.Equal(t, tc.expectedIDs, ids)
+		})
+	}
+}
@@ -1,40 +1,124 @@
 package userservice
 
 import (
+	"asset/models"
 	"asset/providers"
+	"asset/providers/cacheProvider"
+	"asset/providers/redisProvider"
+	"asset/utils"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
-	"github.com/go-jose/go-jose/v4/json"
 	"go.uber.org/zap"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
+	"golang.org/x/sync/singleflight"
 )
 
 type UserRepository interface {
-	DeleteUserByID(ctx context.Context, userID uuid.UUID) error
+	// DeleteUserByID archives the user with their roles, archivedBy is nil when no one is behind it
+	DeleteUserByID(ctx context.Context, userID uuid.UUID, archivedBy *uuid.UUID) error
 	GetUserByEmail(ctx context.Context, userEmail string) (uuid.UUID, error)
 	GetUserDashboardById(ctx context.Context, userID uuid.UUID) (UserDashboardRes, error)
 	GetUserRoleById(ctx context.Context, userId uuid.UUID) (string, error)
-	GetUserAssetTimeline(ctx context.Context, userID uuid.UUID) ([]UserTimelineRes, error)
-	IsUserExists(ctx context.Context, tx *sqlx.Tx, email string) (bool, error)
-	CreateNewEmployee(ctx context.Context, tx *sqlx.Tx, req ManagerRegisterReq, managerUUID uuid.UUID) (uuid.UUID, error)
+	GetUserRoleAndEmail(ctx context.Context, userID uuid.UUID) (string, string, error)
+	GetUserTimeline(ctx context.Context, userID uuid.UUID, limit, offset int) ([]UserTimelineRes, error)
+	GetUserRoleHistory(ctx context.Context, userID uuid.UUID) ([]RoleHistoryRes, error)
+	InsertScheduledRoleChange(ctx context.Context, req ScheduleRoleChangeReq, createdBy uuid.UUID) (uuid.UUID, error)
+	GetPendingRoleChanges(ctx context.Context, userID uuid.UUID) ([]ScheduledRoleChangeRes, error)
+	GetScheduledRoleChanges(ctx context.Context, userID *uuid.UUID, limit, offset int, scope models.DepartmentScope) ([]ScheduledRoleChangeRes, error)
+	// CancelScheduledRoleChange only cancels changes for users in scope, others look like missing ones
+	CancelScheduledRoleChange(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) error
+	GetDueRoleChanges(ctx context.Context) ([]ScheduledRoleChangeRes, error)
+	GetExpiredRoleChanges(ctx context.Context) ([]ScheduledRoleChangeRes, error)
+	MarkRoleChangeApplied(ctx context.Context, id uuid.UUID, previousRole string) error
+	MarkRoleChangeReverted(ctx context.Context, id uuid.UUID) error
+	InsertRoleGrantApproval(ctx context.Context, userID uuid.UUID, role, previousRole string, requestedBy uuid.UUID) (uuid.UUID, error)
+	HasPendingRoleGrant(ctx context.Context, userID uuid.UUID, role string) (bool, error)
+	GetRoleGrantApprovals(ctx context.Context, status string, limit, offset int, scope models.DepartmentScope) ([]RoleGrantApprovalRes, error)
+	GetRoleGrantApprovalForUpdate(ctx context.Context, id uuid.UUID) (RoleGrantApprovalRes, error)
+	DecideRoleGrantApproval(ctx context.Context, id uuid.UUID, status string, decidedBy uuid.UUID, note string) error
+	IsUserExists(ctx context.Context, email string) (bool, error)
+	CreateNewEmployee(ctx context.Context, req ManagerRegisterReq, managerUUID uuid.UUID) (uuid.UUID, error)
 	GetFilteredEmployeesWithAssets(ctx context.Context, filter EmployeeFilter) ([]EmployeeResponseModel, error)
+	GetDirectory(ctx context.Context, filter DirectoryFilter) ([]DirectoryEntry, error)
+	GetEmployeesForExport(ctx context.Context, filter EmployeeFilter) ([]EmployeeExportRow, error)
+	StreamEmployeesForExport(ctx context.Context, filter EmployeeFilter, fn func(EmployeeExportRow) error) error
 	UpdateEmployeeInfo(ctx context.Context, req UpdateEmployeeReq, adminUUID uuid.UUID) error
-	GetCurrentUserRole(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID) (string, error)
-	InsertIntoUser(ctx context.Context, tx *sqlx.Tx, username, email string, firebasetoken string) (uuid.UUID, error)
-	InsertIntoUserType(ctx context.Context, tx *sqlx.Tx, userId uuid.UUID, employeeType string, createdBy uuid.UUID) error
-	UpdateUserRole(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, newRole string, updatedBy uuid.UUID) error
-	InsertIntoUserRole(ctx context.Context, tx *sqlx.Tx, userId uuid.UUID, role string, createdBy uuid.UUID) error
-	InsertUserRole(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, role string, createdBy uuid.UUID) error
+	GetEmployeeByID(ctx context.Context, userID uuid.UUID) (EmployeeRes, error)
+	GetUserMFA(ctx context.Context, userID uuid.UUID) (UserMFA, error)
+	SavePendingMFA(ctx context.Context, userID uuid.UUID, encryptedSecret string) (bool, error)
+	EnableMFA(ctx context.Context, userID uuid.UUID, step int64) error
+	ConsumeMFAStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	DeleteUserMFA(ctx context.Context, userID uuid.UUID) error
+	ReplaceBackupCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+	ConsumeBackupCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error)
+	IsMFARequiredForRole(ctx context.Context, role string) (bool, error)
+	GetMFAAttempts(ctx context.Context, userID uuid.UUID) int
+	IncrementMFAAttempts(ctx context.Context, userID uuid.UUID, window time.Duration) (int, error)
+	ResetMFAAttempts(ctx context.Context, userID uuid.UUID)
+	GetCurrentUserRole(ctx context.Context, userID uuid.UUID) (string, error)
+	IsRoleExists(ctx context.Context, role string) (bool, error)
+	IsUserInScope(ctx context.Context, userID uuid.UUID, scope models.DepartmentScope) (bool, error)
+	GetEmployeeType(ctx context.Context, userID uuid.UUID) (string, error)
+	GetEmployeesEndingWithin(ctx context.Context, days int) ([]EndingEmployeeRes, error)
+	GetEmployeesPastEndDate(ctx context.Context) ([]EndingEmployeeRes, error)
+	MarkEndDateWarned(ctx context.Context, userID uuid.UUID) error
+	OpenEndDateReturnRequests(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+	GetDepartmentManagerIDs(ctx context.Context, organizationID uuid.UUID, departmentID *uuid.UUID) ([]uuid.UUID, error)
+	// GetOnboardingTemplate finds no template of another organization when organizationID is set
+	GetOnboardingTemplate(ctx context.Context, templateID uuid.UUID, organizationID *uuid.UUID) (models.OnboardingTemplate, error)
+	AssignKitAssets(ctx context.Context, userID uuid.UUID, item models.OnboardingKitItem, departmentID *uuid.UUID, assignedBy uuid.UUID) ([]uuid.UUID, error)
+	IsEmailVerified(ctx context.Context, userID uuid.UUID) (bool, error)
+	GetEmailVerificationStatus(ctx context.Context, email string) (EmailVerificationStatus, error)
+	MarkEmailVerified(ctx context.Context, userID uuid.UUID, email string) (bool, error)
+	ArchivePendingEmailChanges(ctx context.Context, userID uuid.UUID) error
+	InsertEmailChangeRequest(ctx context.Context, userID uuid.UUID, newEmail string, requestedBy uuid.UUID, expiresAt time.Time) (EmailChangeRes, error)
+	GetEmailChangeForUpdate(ctx context.Context, requestID uuid.UUID) (EmailChangeRequest, error)
+	ApplyEmailChange(ctx context.Context, change EmailChangeRequest) error
+	InvalidateUserCache(ctx context.Context, userID uuid.UUID, emails ...string)
+	ArchivePendingInvites(ctx context.Context, email string) error
+	InsertInvite(ctx context.Context, req InviteEmployeeReq, invitedBy uuid.UUID, expiresAt time.Time) (uuid.UUID, error)
+	GetInviteForUpdate(ctx context.Context, inviteID uuid.UUID) (UserInvite, error)
+	MarkInviteAccepted(ctx context.Context, inviteID, userID uuid.UUID) error
+	InsertIntoUser(ctx context.Context, username, email string, firebasetoken string) (uuid.UUID, error)
+	InsertIntoUserType(ctx context.Context, userId uuid.UUID, employeeType string, createdBy uuid.UUID) error
+	UpdateUserRole(ctx context.Context, userID uuid.UUID, newRole string, updatedBy uuid.UUID) error
+	InsertIntoUserRole(ctx context.Context, userId uuid.UUID, role string, createdBy uuid.UUID) error
+	InsertUserRole(ctx context.Context, userID uuid.UUID, role string, createdBy uuid.UUID) error
+	ArchiveUserRoles(ctx context.Context, userID uuid.UUID, archivedBy uuid.UUID) error
 	CreateFirebaseUser(ctx context.Context, name, email string) (uuid.UUID, error)
 	GetFirebase() providers.FirebaseProvider
 	GetEmailByUserID(ctx context.Context, userId uuid.UUID) (string, error)
+	GetFirebaseUID(ctx context.Context, userID uuid.UUID) (string, error)
+	GetFirebaseRoleClaims(ctx context.Context, userID uuid.UUID) (FirebaseRoleClaims, error)
+	ListFirebaseRoleClaims(ctx context.Context, afterID uuid.UUID, limit int) ([]FirebaseRoleClaims, error)
+	GetUserIDByIdentity(ctx context.Context, issuer, subject string) (uuid.UUID, error)
+	LinkIdentity(ctx context.Context, userID uuid.UUID, identity models.OIDCIdentity) error
+	GetDirectoryLinkedUsers(ctx context.Context, issuer string) ([]DirectoryLinkedUser, error)
+	InsertDirectorySyncRun(ctx context.Context, report DirectorySyncReport) error
+	GetDirectorySyncRuns(ctx context.Context, limit, offset int) ([]DirectorySyncRun, error)
+	GetLoginLockedUntil(ctx context.Context, subjects ...string) time.Time
+	IncrementLoginFailures(ctx context.Context, subject string, window time.Duration) (int, error)
+	IncrementLoginLockouts(ctx context.Context, subject string, decay time.Duration) (int, error)
+	LockLogin(ctx context.Context, subject string, until time.Time) error
+	ClearLoginFailures(ctx context.Context, subject string)
+	ClearLoginLock(ctx context.Context, subject string) error
+	IncrementMagicLinkRequests(ctx context.Context, email string, window time.Duration) (int, error)
+	StoreMagicLink(ctx context.Context, linkID string, userID uuid.UUID, ttl time.Duration) error
+	ConsumeMagicLink(ctx context.Context, linkID string, userID uuid.UUID) (bool, error)
+	StoreContactOTP(ctx context.Context, contactNo, codeHash string, ttl time.Duration) error
+	CheckContactOTP(ctx context.Context, contactNo, codeHash string, maxAttempts int) (bool, error)
+	DeleteContactOTP(ctx context.Context, contactNo string)
+	MarkContactVerified(ctx context.Context, userID uuid.UUID) error
+	SetTimezone(ctx context.Context, userID uuid.UUID, timezone *string) error
+	GetRecentlyActiveUserIDs(ctx context.Context, since time.Time, limit int) ([]uuid.UUID, error)
 }
 
 type PostgresUserRepository struct {
@@ -42,23 +126,96 @@ type PostgresUserRepository struct {
 	Logger   providers.ZapLoggerProvider
 	Firebase providers.FirebaseProvider
 	Redis    providers.RedisProvider
+	// cache ttls are read from it on every write so a config reload applies, the defaults are used without one
+	Config providers.ConfigProvider
+	Cache  providers.UserCacheProvider
+	// concurrent rebuilds of the same cold key share one database read
+	flight singleflight.Group
 }
 
-func NewUserRepository(db *sqlx.DB, log providers.ZapLoggerProvider, firebase providers.FirebaseProvider, redis providers.RedisProvider) UserRepository {
-	return &PostgresUserRepository{DB: db, Logger: log, Firebase: firebase, Redis: redis}
+func NewUserRepository(db *sqlx.DB, log providers.ZapLoggerProvider, firebase providers.FirebaseProvider, redis providers.RedisProvider, cfg providers.ConfigProvider) UserRepository {
+	return &PostgresUserRepository{DB: db, Logger: log, Firebase: firebase, Redis: redis, Config: cfg, Cache: cacheprovider.NewUserCacheProvider(redis, log)}
+}
+
+func (r *PostgresUserRepository) cacheTTLs() models.CacheTTLs {
+	if r.Config == nil {
+		return models.DefaultCacheTTLs
+	}
+	return r.Config.GetCacheTTLs()
+}
+
+// cacheGet reads a cached value of the class, a disabled class always misses
+func (r *PostgresUserRepository) cacheGet(ctx context.Context, class models.CacheClass, key string) (string, bool) {
+	if !r.cacheTTLs().Enabled(class) {
+		return "", false
+	}
+	start := time.Now()
+	cached, err := r.Redis.Get(ctx, key)
+	hit := err == nil && cached != ""
+	cacheprovider.RecordRead(class, hit, time.Since(start))
+	return cached, hit
+}
+
+// rebuildOnce runs load once for concurrent misses of the same key, the others wait for it and share
+// its result. Inside a transaction load runs on its own since what it reads depends on the tx
+func (r *PostgresUserRepository) rebuildOnce(ctx context.Context, class models.CacheClass, key string, load func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	if _, ok := utils.TxFromContext(ctx); ok {
+		cacheprovider.RecordRebuild(class, false)
+		return load(ctx)
+	}
+	value, err, shared := r.flight.Do(key, func() (interface{}, error) {
+		cacheprovider.RecordRebuild(class, false)
+		// the result goes to every waiting request, the one that started it going away must not cancel it
+		return load(context.WithoutCancel(ctx))
+	})
+	if shared {
+		cacheprovider.RecordRebuild(class, true)
+	}
+	return value, err
+}
+
+// cacheEntry is one value cacheSet stores, it lives for the ttl of its class
+type cacheEntry struct {
+	class models.CacheClass
+	key   string
+	value interface{}
+}
+
+// cacheSet stores the entries in one round trip, those of disabled classes are dropped
+func (r *PostgresUserRepository) cacheSet(ctx context.Context, entries ...cacheEntry) error {
+	policy := r.cacheTTLs()
+	batch := make([]providers.RedisEntry, 0, len(entries))
+	for _, entry := range entries {
+		if policy.Enabled(entry.class) {
+			batch = append(batch, providers.RedisEntry{Key: entry.key, Value: entry.value, Expiration: policy.TTL(entry.class)})
+		}
+	}
+	switch len(batch) {
+	case 0:
+		return nil
+	case 1:
+		return r.Redis.Set(ctx, batch[0].Key, batch[0].Value, batch[0].Expiration)
+	}
+	return r.Redis.SetMany(ctx, batch...)
 }
 
 func (r *PostgresUserRepository) GetUserByEmail(ctx context.Context, userEmail string) (uuid.UUID, error) {
 	r.Logger.GetLogger().Info("fetching user by email", zap.String("email", userEmail))
+	notFoundKey := cacheprovider.UserByEmailNotFoundKey(userEmail)
+	if _, ok := r.cacheGet(ctx, models.CacheNotFound, notFoundKey); ok {
+		r.Logger.GetLogger().Debug("user not found, cached", zap.String("email", userEmail))
+		return uuid.Nil, sql.ErrNoRows
+	}
 	var userId uuid.UUID
 
-	err := r.DB.GetContext(ctx, &userId, `
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &userId, `
 		SELECT id FROM users
-		WHERE email = $1 AND archived_at IS NULL
+		WHERE email = $1 AND archived_at IS NULL AND auth_provider <> 'service_account'
 	`, userEmail)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			r.Logger.GetLogger().Warn("no user found for email", zap.String("email", userEmail), zap.Error(err))
+			_ = r.cacheSet(ctx, cacheEntry{class: models.CacheNotFound, key: notFoundKey, value: "1"})
 			return uuid.Nil, sql.ErrNoRows
 		}
 		r.Logger.GetLogger().Error("failed to fetch user by email", zap.String("email", userEmail), zap.Error(err))
@@ -68,75 +225,54 @@ func (r *PostgresUserRepository) GetUserByEmail(ctx context.Context, userEmail s
 	return userId, nil
 }
 
-func (r *PostgresUserRepository) DeleteUserByID(ctx context.Context, userID uuid.UUID) (err error) {
+func (r *PostgresUserRepository) DeleteUserByID(ctx context.Context, userID uuid.UUID, archivedBy *uuid.UUID) error {
 	r.Logger.GetLogger().Info("starting transaction to delete user by id", zap.String("user_id", userID.String()))
-	tx, err := r.DB.BeginTxx(ctx, nil)
-	if err != nil {
-		r.Logger.GetLogger().Error("failed to start transaction for deleteuserbyid", zap.Error(err))
-		return fmt.Errorf("failed to start transaction: %w", err)
-	}
-
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			r.Logger.GetLogger().Error("panic recovered during deleteuserbyid transaction", zap.Any("recover_info", p))
-			panic(p)
-		} else if err != nil {
-			tx.Rollback()
-			r.Logger.GetLogger().Error("rolling back transaction for deleteuserbyid due to error", zap.Error(err))
-		} else {
-			err = tx.Commit()
-			if err != nil {
-				r.Logger.GetLogger().Error("failed to commit transaction for deleteuserbyid", zap.Error(err))
-			} else {
-				r.Logger.GetLogger().Info("transaction committed successfully for deleteuserbyid")
-			}
+	return utils.WithTransaction(ctx, r.DB, func(ctx context.Context) error {
+		var err error
+		var count int
+		r.Logger.GetLogger().Debug("checking for assigned assets before deleting user", zap.String("user_id", userID.String()))
+		err = utils.Conn(ctx, r.DB).GetContext(ctx, &count, `
+			SELECT count(*) FROM asset_assign 
+			WHERE employee_id = $1 AND returned_at IS NULL AND archived_at IS NULL LIMIT 1
+		`, userID)
+		if err != nil {
+			r.Logger.GetLogger().Error("failed to check asset assignment for user deletion", zap.String("user_id", userID.String()), zap.Error(err))
+			return fmt.Errorf("failed to check asset assignment: %w", err)
+		}
+		if count > 0 {
+			r.Logger.GetLogger().Warn("cannot delete user, still has assets assigned", zap.String("user_id", userID.String()))
+			return ErrUserHasAssets
 		}
-	}()
-
-	var count int
-	r.Logger.GetLogger().Debug("checking for assigned assets before deleting user", zap.String("user_id", userID.String()))
-	err = tx.GetContext(ctx, &count, `
-		SELECT count(*) FROM asset_assign 
-		WHERE employee_id = $1 AND returned_at IS NULL AND archived_at IS NULL LIMIT 1
-	`, userID)
-	if err != nil {
-		r.Logger.GetLogger().Error("failed to check asset assignment for user deletion", zap.String("user_id", userID.String()), zap.Error(err))
-		return fmt.Errorf("failed to check asset assignment: %w", err)
-	}
-	if count > 0 {
-		r.Logger.GetLogger().Warn("cannot delete user, still has assets assigned", zap.String("user_id", userID.String()))
-		return fmt.Errorf("cannot delete user, still have asset assigned")
-	}
 
-	r.Logger.GetLogger().Debug("archiving user record", zap.String("user_id", userID.String()))
-	_, err = tx.ExecContext(ctx, `
-		UPDATE users SET archived_at = now() WHERE id = $1 AND archived_at IS NULL
-	`, userID)
-	if err != nil {
-		r.Logger.GetLogger().Error("failed to archive user record", zap.String("user_id", userID.String()), zap.Error(err))
-		return fmt.Errorf("failed to delete user: %w", err)
-	}
+		r.Logger.GetLogger().Debug("archiving user record", zap.String("user_id", userID.String()))
+		_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+			UPDATE users SET archived_at = now(), archived_by = $2 WHERE id = $1 AND archived_at IS NULL
+		`, userID, archivedBy)
+		if err != nil {
+			r.Logger.GetLogger().Error("failed to archive user record", zap.String("user_id", userID.String()), zap.Error(err))
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
 
-	r.Logger.GetLogger().Debug("archiving user roles", zap.String("user_id", userID.String()))
-	_, err = tx.ExecContext(ctx, `
-		UPDATE user_roles SET archived_at = now() WHERE user_id = $1 AND archived_at IS NULL
-	`, userID)
-	if err != nil {
-		r.Logger.GetLogger().Error("failed to archive user roles", zap.String("user_id", userID.String()), zap.Error(err))
-		return fmt.Errorf("failed to delete user roles: %w", err)
-	}
+		r.Logger.GetLogger().Debug("archiving user roles", zap.String("user_id", userID.String()))
+		_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+			UPDATE user_roles SET archived_at = now(), archived_by = $2 WHERE user_id = $1 AND archived_at IS NULL
+		`, userID, archivedBy)
+		if err != nil {
+			r.Logger.GetLogger().Error("failed to archive user roles", zap.String("user_id", userID.String()), zap.Error(err))
+			return fmt.Errorf("failed to delete user roles: %w", err)
+		}
 
-	r.Logger.GetLogger().Debug("archiving user type", zap.String("user_id", userID.String()))
-	_, err = tx.ExecContext(ctx, `
-		UPDATE user_type SET archived_at = now() WHERE user_id = $1 AND archived_at IS NULL
-	`, userID)
-	if err != nil {
-		r.Logger.GetLogger().Error("failed to archive user type", zap.String("user_id", userID.String()), zap.Error(err))
-		return fmt.Errorf("failed to delete user type: %w", err)
-	}
-	r.Logger.GetLogger().Info("user and associated records archived successfully", zap.String("user_id", userID.String()))
-	return nil
+		r.Logger.GetLogger().Debug("archiving user type", zap.String("user_id", userID.String()))
+		_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+			UPDATE user_type SET archived_at = now() WHERE user_id = $1 AND archived_at IS NULL
+		`, userID)
+		if err != nil {
+			r.Logger.GetLogger().Error("failed to archive user type", zap.String("user_id", userID.String()), zap.Error(err))
+			return fmt.Errorf("failed to delete user type: %w", err)
+		}
+		r.Logger.GetLogger().Info("user and associated records archived successfully", zap.String("user_id", userID.String()))
+		return nil
+	})
 }
 
 // //GetUSer
@@ -191,74 +327,60 @@ func (r *PostgresUserRepository) GetUserDashboardById(ctx context.Context, userI
 		r.Logger.GetLogger().Info("total execution time", zap.Int64("duration", elapsed))
 	}()
 
-	RedisCacheKey := fmt.Sprintf("user:dashboard:%s", userID.String())
+	RedisCacheKey := cacheprovider.DashboardKey(userID)
 	//get data if present
-	cachedData, err := r.Redis.Get(ctx, RedisCacheKey)
-	if err == nil && cachedData != "" {
+	if cachedData, ok := r.cacheGet(ctx, models.CacheDashboard, RedisCacheKey); ok {
 		r.Logger.GetLogger().Info("user dashboard found in Redis cache", zap.String("user_id", userID.String()))
-		err = json.Unmarshal([]byte(cachedData), &user)
+		err = utils.JSON.Unmarshal([]byte(cachedData), &user)
 		if err == nil {
 			return user, nil
 		}
 		r.Logger.GetLogger().Warn("failed to unmarshal cached dashboard, fetching from DB", zap.Error(err))
 	}
 
-	r.Logger.GetLogger().Info("starting transaction to get user dashboard by id", zap.String("user_id", userID.String()))
-	tx, err := r.DB.BeginTxx(ctx, nil)
+	// after an invalidation every open dashboard misses at once, one query rebuilds it for all of them
+	value, err := r.rebuildOnce(ctx, models.CacheDashboard, RedisCacheKey, func(ctx context.Context) (interface{}, error) {
+		return r.loadUserDashboard(ctx, userID)
+	})
 	if err != nil {
-		r.Logger.GetLogger().Error("failed to begin transaction", zap.Error(err))
-		return user, fmt.Errorf("failed to begin transaction: %w", err)
+		return user, err
 	}
+	return value.(UserDashboardRes), nil
+}
 
-	defer func() {
-		if p := recover(); p != nil {
-			tx.Rollback()
-			r.Logger.GetLogger().Error("panic recovered", zap.Any("recover_info", p))
-			panic(p)
-		} else if err != nil {
-			tx.Rollback()
-			r.Logger.GetLogger().Error("rolling back transaction", zap.Error(err))
-		} else {
-			err = tx.Commit()
-			if err != nil {
-				r.Logger.GetLogger().Error("failed to commit transaction", zap.Error(err))
-			} else {
-				r.Logger.GetLogger().Info("transaction committed successfully")
-			}
-		}
-	}()
-
-	err = tx.GetContext(ctx, &user, `
-		SELECT u.id, u.username, u.email, u.contact_no, ut.type
+// loadUserDashboard reads the dashboard from the database and caches it
+func (r *PostgresUserRepository) loadUserDashboard(ctx context.Context, userID uuid.UUID) (user UserDashboardRes, err error) {
+	r.Logger.GetLogger().Info("fetching user dashboard", zap.String("user_id", userID.String()))
+	var row struct {
+		UserDashboardRes
+		Roles          pq.StringArray `db:"roles"`
+		AssignedAssets []byte         `db:"assigned_assets"`
+	}
+	// roles, type and assets come from user_dashboard_summary, which triggers keep up to date
+	err = utils.Conn(ctx, r.DB).GetContext(ctx, &row, `
+		SELECT u.id, u.username, u.email, u.contact_no, s.type,
+			u.designation, u.location, u.date_of_joining, u.emergency_contact_name, u.emergency_contact_no,
+			u.contact_verified_at, COALESCE(s.roles, '{}') AS roles, COALESCE(s.assigned_assets, '[]') AS assigned_assets
 		FROM users u
-		LEFT JOIN user_type ut ON ut.user_id = u.id AND ut.archived_at IS NULL
+		LEFT JOIN user_dashboard_summary s ON s.user_id = u.id
 		WHERE u.id = $1 AND u.archived_at IS NULL
 	`, userID)
 	if err != nil {
 		return user, fmt.Errorf("failed to fetch user: %w", err)
 	}
-
-	err = tx.SelectContext(ctx, &user.Roles, `
-		SELECT role FROM user_roles 
-		WHERE user_id = $1 AND archived_at IS NULL
-	`, userID)
-	if err != nil {
-		return user, fmt.Errorf("failed to fetch roles: %w", err)
-	}
-
-	err = tx.SelectContext(ctx, &user.AssignedAssets, `
-		SELECT a.id, a.brand, a.model, a.serial_no, a.type, a.status, a.owned_by
-		FROM assets a
-		INNER JOIN asset_assign aa ON aa.asset_id = a.id
-		WHERE aa.employee_id = $1 AND aa.returned_at IS NULL AND aa.archived_at IS NULL AND a.archived_at IS NULL
-	`, userID)
-	if err != nil {
-		return user, fmt.Errorf("failed to fetch assigned assets: %w", err)
+	user = row.UserDashboardRes
+	user.Roles = row.Roles
+	if err := utils.JSON.Unmarshal(row.AssignedAssets, &user.AssignedAssets); err != nil {
+		return user, fmt.Errorf("failed to decode assigned assets: %w", err)
 	}
 
-	jsonData, err := json.Marshal(user)
+	jsonData, err := utils.JSON.Marshal(user)
 	if err == nil {
-		_ = r.Redis.Set(ctx, RedisCacheKey, jsonData, 5*time.Minute)
+		// the email comes along for free, later lookups by id then skip the database
+		_ = r.cacheSet(ctx,
+			cacheEntry{class: models.CacheDashboard, key: cacheprovider.DashboardKey(userID), value: jsonData},
+			cacheEntry{class: models.CacheUserEmail, key: cacheprovider.UserEmailKey(userID), value: user.Email},
+		)
 		r.Logger.GetLogger().Info("user dashboard cached in Redis", zap.String("user_id", userID.String()))
 		fmt.Println(time.Now().Format(time.RFC3339))
 	}
@@ -334,17 +456,17 @@ func (r *PostgresUserRepository) GetUserDashboardById(ctx context.Context, userI
 func (r *PostgresUserRepository) GetUserRoleById(ctx context.Context, userId uuid.UUID) (string, error) {
 	r.Logger.GetLogger().Info("fetching user role by id", zap.String("user_id", userId.String()))
 
-	redisKey := fmt.Sprintf("user:GetUserRoleById:%s", userId.String())
+	redisKey := cacheprovider.UserRoleKey(userId)
 
 	//getting data from redis if present
-	if cachedData, err := r.Redis.Get(ctx, redisKey); err == nil && cachedData != "" {
+	if cachedData, ok := r.cacheGet(ctx, models.CacheUserRole, redisKey); ok {
 		r.Logger.GetLogger().Info("user role found in Redis cache", zap.String("user_id", userId.String()))
 		return cachedData, nil
 	}
 
 	//if not run db query
 	var userRole string
-	err := r.DB.GetContext(ctx, &userRole, `
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &userRole, `
 		SELECT role FROM user_roles 
 		WHERE user_id = $1 AND archived_at IS NULL
 	`, userId)
@@ -357,7 +479,7 @@ func (r *PostgresUserRepository) GetUserRoleById(ctx context.Context, userId uui
 		return "", fmt.Errorf("failed to fetch user role: %w", err)
 	}
 
-	cacheErr := r.Redis.Set(ctx, redisKey, userRole, 5*time.Minute)
+	cacheErr := r.cacheSet(ctx, cacheEntry{class: models.CacheUserRole, key: redisKey, value: userRole})
 	if cacheErr != nil {
 		r.Logger.GetLogger().Warn("failed to cache user role in Redis", zap.Error(cacheErr))
 	} else {
@@ -367,72 +489,192 @@ func (r *PostgresUserRepository) GetUserRoleById(ctx context.Context, userId uui
 	return userRole, nil
 }
 
-func (r *PostgresUserRepository) GetUserAssetTimeline(ctx context.Context, userID uuid.UUID) ([]UserTimelineRes, error) {
-	r.Logger.GetLogger().Info("fetching user asset timeline", zap.String("user_id", userID.String()))
+// GetUserRoleAndEmail reads both cached values in one round trip, on a miss one query fills both
+func (r *PostgresUserRepository) GetUserRoleAndEmail(ctx context.Context, userID uuid.UUID) (string, string, error) {
+	roleKey, emailKey := cacheprovider.UserRoleKey(userID), cacheprovider.UserEmailKey(userID)
+	// with either class disabled the read would miss anyway
+	if policy := r.cacheTTLs(); policy.Enabled(models.CacheUserRole) && policy.Enabled(models.CacheUserEmail) {
+		start := time.Now()
+		cached, err := r.Redis.MGet(ctx, roleKey, emailKey)
+		elapsed := time.Since(start)
+		cacheprovider.RecordRead(models.CacheUserRole, err == nil && cached[0] != "", elapsed)
+		cacheprovider.RecordRead(models.CacheUserEmail, err == nil && cached[1] != "", elapsed)
+		if err == nil && cached[0] != "" && cached[1] != "" {
+			r.Logger.GetLogger().Info("user role and email found in Redis cache", zap.String("user_id", userID.String()))
+			return cached[0], cached[1], nil
+		}
+	}
+
+	var row struct {
+		Role  string `db:"role"`
+		Email string `db:"email"`
+	}
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &row, `
+		SELECT ur.role, u.email
+		FROM users u
+		JOIN user_roles ur ON ur.user_id = u.id AND ur.archived_at IS NULL
+		WHERE u.id = $1 AND u.archived_at IS NULL
+	`, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			r.Logger.GetLogger().Warn("no role found for user id", zap.String("user_id", userID.String()))
+			return "", "", fmt.Errorf("no role found for user id: %s", userID)
+		}
+		r.Logger.GetLogger().Error("failed to fetch user role and email", zap.String("user_id", userID.String()), zap.Error(err))
+		return "", "", fmt.Errorf("failed to fetch user role and email: %w", err)
+	}
+
+	if err := r.cacheSet(ctx,
+		cacheEntry{class: models.CacheUserRole, key: roleKey, value: row.Role},
+		cacheEntry{class: models.CacheUserEmail, key: emailKey, value: row.Email},
+	); err != nil {
+		r.Logger.GetLogger().Warn("failed to cache user role and email in Redis", zap.Error(err))
+	}
+	return row.Role, row.Email, nil
+}
+
+// GetUserTimeline merges assignments, role and type changes, department moves and service
+// periods of assets the user held into one history, newest first
+func (r *PostgresUserRepository) GetUserTimeline(ctx context.Context, userID uuid.UUID, limit, offset int) ([]UserTimelineRes, error) {
+	r.Logger.GetLogger().Info("fetching user timeline", zap.String("user_id", userID.String()), zap.Int("limit", limit), zap.Int("offset", offset))
 	timeline := make([]UserTimelineRes, 0)
 
 	//generate key
-	redisKey := fmt.Sprintf("user:GetUserAssetTimeline:%s", userID.String())
+	redisKey := r.Cache.TimelineKey(ctx, userID, limit, offset)
 
 	//get data from redis, if preset
-	if cached, err := r.Redis.Get(ctx, redisKey); err == nil && cached != "" {
-		r.Logger.GetLogger().Info("user asset timeline found in Redis cache", zap.String("user_id", userID.String()))
-		if err := json.Unmarshal([]byte(cached), &timeline); err == nil {
+	if cached, ok := r.cacheGet(ctx, models.CacheTimeline, redisKey); ok {
+		r.Logger.GetLogger().Info("user timeline found in Redis cache", zap.String("user_id", userID.String()))
+		err := utils.JSON.Unmarshal([]byte(cached), &timeline)
+		if err == nil {
 			return timeline, nil
 		}
 		r.Logger.GetLogger().Warn("failed to unmarshal cached timeline, falling back to DB", zap.Error(err))
 	}
 
 	//if not present in redis, run query and then store data
-	err := r.DB.SelectContext(ctx, &timeline, `
-		SELECT 
-			a.asset_id,
-			at.brand,
-			at.model,
-			at.serial_no,
-			a.assigned_at,
-			a.returned_at,
-			a.return_reason
-		FROM asset_assign a
-		JOIN assets at ON at.id = a.asset_id
-		WHERE a.employee_id = $1 AND a.archived_at IS NULL
-		ORDER BY a.assigned_at DESC
-	`, userID)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &timeline, `
+		WITH events AS (
+			SELECT 'asset_assigned' AS event_type, aa.assigned_at AS occurred_at,
+				a.id::text AS asset_id, a.brand, a.model, a.serial_no,
+				NULL::text AS from_value, NULL::text AS to_value, NULL::text AS reason,
+				aa.assigned_by AS actor_id,
+				aa.metadata
+			FROM asset_assign_all aa
+			JOIN assets a ON a.id = aa.asset_id
+			WHERE aa.employee_id = $1 AND aa.archived_at IS NULL
+
+			UNION ALL
+			SELECT 'asset_returned', aa.returned_at,
+				a.id::text, a.brand, a.model, a.serial_no,
+				NULL, NULL, aa.return_reason,
+				NULL, NULL
+			FROM asset_assign_all aa
+			JOIN assets a ON a.id = aa.asset_id
+			WHERE aa.employee_id = $1 AND aa.archived_at IS NULL AND aa.returned_at IS NOT NULL
+
+			UNION ALL
+			SELECT 'role_granted', ur.created_at,
+				NULL, NULL, NULL, NULL,
+				NULL, ur.role::text, NULL,
+				ur.created_by, NULL
+			FROM user_roles ur
+			WHERE ur.user_id = $1
+
+			UNION ALL
+			SELECT 'role_revoked', ur.archived_at,
+				NULL, NULL, NULL, NULL,
+				ur.role::text, NULL, NULL,
+				ur.archived_by, NULL
+			FROM user_roles ur
+			WHERE ur.user_id = $1 AND ur.archived_at IS NOT NULL
+
+			UNION ALL
+			SELECT 'type_changed', ut.created_at,
+				NULL, NULL, NULL, NULL,
+				LAG(ut.type::text) OVER (ORDER BY ut.created_at), ut.type::text, NULL,
+				ut.created_by, NULL
+			FROM user_type ut
+			WHERE ut.user_id = $1
+
+			UNION ALL
+			SELECT 'department_changed', al.created_at,
+				NULL, NULL, NULL, NULL,
+				old_dept.name, new_dept.name, NULL,
+				al.actor_id, NULL
+			FROM audit_logs al
+			LEFT JOIN departments old_dept ON old_dept.id::text = al.old_value->>'department_id'
+			LEFT JOIN departments new_dept ON new_dept.id::text = al.new_value->>'department_id'
+			WHERE al.action = 'user.department_changed' AND al.entity_type = 'user' AND al.entity_id = $1::text
+
+			UNION ALL
+			SELECT 'asset_service_started', s.service_start,
+				a.id::text, a.brand, a.model, a.serial_no,
+				NULL, NULL, s.reason,
+				s.created_by, NULL
+			FROM asset_service_all s
+			JOIN asset_assign_all aa ON aa.asset_id = s.asset_id AND aa.employee_id = $1 AND aa.archived_at IS NULL
+				AND s.service_start >= aa.assigned_at AND (aa.returned_at IS NULL OR s.service_start < aa.returned_at)
+			JOIN assets a ON a.id = s.asset_id
+			WHERE s.archived_at IS NULL
+
+			UNION ALL
+			SELECT 'asset_service_ended', s.service_end,
+				a.id::text, a.brand, a.model, a.serial_no,
+				NULL, NULL, s.reason,
+				NULL, NULL
+			FROM asset_service_all s
+			JOIN asset_assign_all aa ON aa.asset_id = s.asset_id AND aa.employee_id = $1 AND aa.archived_at IS NULL
+				AND s.service_start >= aa.assigned_at AND (aa.returned_at IS NULL OR s.service_start < aa.returned_at)
+			JOIN assets a ON a.id = s.asset_id
+			WHERE s.archived_at IS NULL AND s.service_end IS NOT NULL
+		)
+		SELECT e.event_type, e.occurred_at, e.asset_id, e.brand, e.model, e.serial_no,
+			e.from_value, e.to_value, e.reason, e.actor_id::text AS actor_id, actor.username AS actor_name, e.metadata
+		FROM events e
+		LEFT JOIN users actor ON actor.id = e.actor_id
+		ORDER BY e.occurred_at DESC
+		LIMIT $2 OFFSET $3
+	`, userID, limit, offset)
 	if err != nil {
 		r.Logger.GetLogger().Error("failed to get user timeline", zap.String("user_id", userID.String()), zap.Error(err))
 		return nil, fmt.Errorf("failed to get user timeline: %w", err)
 	}
 
 	//store data in cache
-	cacheBytes, err := json.Marshal(timeline)
+	cacheBytes, err := utils.JSON.Marshal(timeline)
 	if err == nil {
-		cacheErr := r.Redis.Set(ctx, redisKey, string(cacheBytes), 5*time.Minute)
+		cacheErr := r.cacheSet(ctx, cacheEntry{class: models.CacheTimeline, key: redisKey, value: string(cacheBytes)})
 		if cacheErr != nil {
-			r.Logger.GetLogger().Warn("failed to cache asset timeline in Redis", zap.Error(cacheErr))
+			r.Logger.GetLogger().Warn("failed to cache user timeline in Redis", zap.Error(cacheErr))
 		} else {
-			r.Logger.GetLogger().Info("cached user asset timeline in Redis", zap.String("user_id", userID.String()))
+			r.Logger.GetLogger().Info("cached user timeline in Redis", zap.String("user_id", userID.String()))
 		}
 	}
 
-	r.Logger.GetLogger().Info("successfully fetched user asset timeline", zap.String("user_id", userID.String()), zap.Int("timeline_entries", len(timeline)))
+	r.Logger.GetLogger().Info("successfully fetched user timeline", zap.String("user_id", userID.String()), zap.Int("timeline_entries", len(timeline)))
 	return timeline, nil
 }
 
-func (r *PostgresUserRepository) CreateNewEmployee(ctx context.Context, tx *sqlx.Tx, req ManagerRegisterReq, managerUUID uuid.UUID) (uuid.UUID, error) {
+func (r *PostgresUserRepository) CreateNewEmployee(ctx context.Context, req ManagerRegisterReq, managerUUID uuid.UUID) (uuid.UUID, error) {
 	r.Logger.GetLogger().Info("creating new employee record", zap.String("email", req.Email), zap.String("manager_id", managerUUID.String()))
 	var userID uuid.UUID
-	err := tx.GetContext(ctx, &userID, `
-		INSERT INTO users (username, email, contact_no, created_by)
-		VALUES ($1, $2, $3, $4)
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &userID, `
+		INSERT INTO users (
+			username, email, contact_no, created_by, department_id, end_date,
+			designation, location, date_of_joining, emergency_contact_name, emergency_contact_no
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), $9, NULLIF($10, ''), NULLIF($11, ''))
 		RETURNING id
-	`, req.Username, req.Email, req.ContactNo, managerUUID)
+	`, req.Username, req.Email, req.ContactNo, managerUUID, req.DepartmentID, req.EndDate,
+		req.Designation, req.Location, req.DateOfJoining, req.EmergencyContactName, req.EmergencyContactNo)
 	if err != nil {
 		r.Logger.GetLogger().Error("failed to insert new employee into users table", zap.Error(err))
 		return uuid.Nil, fmt.Errorf("failed to insert employee: %w", err)
 	}
 	r.Logger.GetLogger().Debug("new employee inserted into users table", zap.String("user_id", userID.String()))
 
-	_, err = tx.ExecContext(ctx, `
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
 		INSERT INTO user_type (user_id, type, created_by)
 		VALUES ($1, $2, $3)
 	`, userID, req.Type, managerUUID)
@@ -442,7 +684,7 @@ func (r *PostgresUserRepository) CreateNewEmployee(ctx context.Context, tx *sqlx
 	}
 	r.Logger.GetLogger().Debug("employee type inserted", zap.String("user_id", userID.String()), zap.String("type", req.Type))
 
-	_, err = tx.ExecContext(ctx, `
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
 		INSERT INTO user_roles (user_id, role, created_by)
 		VALUES ($1, 'employee', $2)
 	`, userID, managerUUID)
@@ -464,6 +706,11 @@ func (r *PostgresUserRepository) GetFilteredEmployeesWithAssets(ctx context.Cont
 		pq.Array(filter.AssetStatus),
 		filter.Limit,
 		filter.Offset,
+		filter.Scope.AllDepartments,
+		filter.Scope.DepartmentID,
+		pq.Array(filter.Designation),
+		pq.Array(filter.Location),
+		filter.Scope.OrganizationID,
 	}
 
 	query := `SELECT
@@ -472,13 +719,18 @@ func (r *PostgresUserRepository) GetFilteredEmployeesWithAssets(ctx context.Cont
     u.email,
     u.contact_no,
     ut.type AS employee_type,
+    u.designation,
+    u.location,
+    u.date_of_joining,
+    u.emergency_contact_name,
+    u.emergency_contact_no,
     COALESCE(array_agg(a.id) FILTER (WHERE a.id IS NOT NULL), '{}') AS assigned_assets
 FROM users u
 LEFT JOIN user_type ut ON u.id = ut.user_id AND ut.archived_at IS NULL
 LEFT JOIN user_roles ur ON u.id = ur.user_id AND ur.archived_at IS NULL
 LEFT JOIN asset_assign aa ON u.id = aa.employee_id AND aa.archived_at IS NULL
 LEFT JOIN assets a ON aa.asset_id = a.id AND a.archived_at IS NULL
-WHERE u.archived_at IS NULL
+WHERE u.archived_at IS NULL AND u.auth_provider <> 'service_account'
 AND (
     $1 OR (
        u.username ILIKE '%' || $2 || '%'
@@ -489,13 +741,17 @@ AND (
 AND ($3::text[] IS NULL OR ut.type::text = ANY($3))
 AND ($4::text[] IS NULL OR ur.role::text = ANY($4))
 AND ($5::text[] IS NULL OR a.status::text = ANY($5) OR a.id IS NULL)
+AND ($8 OR u.department_id IS NOT DISTINCT FROM $9)
+AND ($10::text[] IS NULL OR u.designation = ANY($10))
+AND ($11::text[] IS NULL OR u.location = ANY($11))
+AND ($12::uuid IS NULL OR u.organization_id = $12)
 GROUP BY u.id, ut.type, u.created_at
 ORDER BY u.created_at DESC
 LIMIT $6 OFFSET $7;
     `
 
 	rows := []EmployeeResponseModel{}
-	err := r.DB.SelectContext(ctx, &rows, query, args...)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &rows, query, args...)
 	if err != nil {
 		r.Logger.GetLogger().Error("failed to select filtered employees with assets", zap.Error(err), zap.Any("filter", filter))
 		return nil, err
@@ -504,56 +760,183 @@ LIMIT $6 OFFSET $7;
 	return rows, nil
 }
 
-func (r *PostgresUserRepository) UpdateEmployeeInfo(ctx context.Context, req UpdateEmployeeReq, adminUUID uuid.UUID) error {
-	r.Logger.GetLogger().Info("updating employee information", zap.String("admin_id", adminUUID.String()))
-	query := `UPDATE users SET`
-	args := []interface{}{}
-	argPos := 1
-
-	if req.Username != "" {
-		query += fmt.Sprintf("username = $%d, ", argPos)
-		args = append(args, req.Username)
-		argPos++
-		r.Logger.GetLogger().Debug("updating username", zap.String("username", req.Username))
+// GetDirectory lists active people of one organization by name, service accounts and anonymized
+// users are left out
+func (r *PostgresUserRepository) GetDirectory(ctx context.Context, filter DirectoryFilter) ([]DirectoryEntry, error) {
+	entries := []DirectoryEntry{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &entries, `
+		SELECT u.id, u.username, u.email, d.name AS department, u.designation
+		FROM users u
+		LEFT JOIN departments d ON d.id = u.department_id
+		WHERE u.archived_at IS NULL AND u.anonymized_at IS NULL AND u.auth_provider <> 'service_account'
+		AND ($1 = '' OR u.username ILIKE '%' || $1 || '%' OR u.email ILIKE '%' || $1 || '%' OR u.designation ILIKE '%' || $1 || '%')
+		AND ($2::uuid IS NULL OR u.department_id = $2)
+		AND u.organization_id = COALESCE($3::uuid, (SELECT organization_id FROM users WHERE id = $6))
+		ORDER BY u.username, u.id
+		LIMIT $4 OFFSET $5
+	`, filter.Search, filter.DepartmentID, filter.OrganizationID, filter.Limit, filter.Offset, filter.CallerID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch directory", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch directory: %w", err)
 	}
-	if req.Email != "" {
-		query += fmt.Sprintf("email = $%d, ", argPos)
-		args = append(args, req.Email)
-		argPos++
-		r.Logger.GetLogger().Debug("updating email", zap.String("email", req.Email))
+	return entries, nil
+}
+
+// employeeExportQuery has the filters of the employee list but no pages, with roles and asset
+// serials for reporting
+const employeeExportQuery = `SELECT
+    u.id,
+    u.username,
+    u.email,
+    u.contact_no,
+    ut.type AS employee_type,
+    u.designation,
+    u.location,
+    u.date_of_joining,
+    u.emergency_contact_name,
+    u.emergency_contact_no,
+    COALESCE(array_agg(DISTINCT ur.role::text) FILTER (WHERE ur.role IS NOT NULL), '{}') AS roles,
+    COALESCE(array_agg(DISTINCT a.serial_no) FILTER (WHERE a.id IS NOT NULL), '{}') AS asset_serials
+FROM users u
+LEFT JOIN user_type ut ON u.id = ut.user_id AND ut.archived_at IS NULL
+LEFT JOIN user_roles ur ON u.id = ur.user_id AND ur.archived_at IS NULL
+LEFT JOIN asset_assign aa ON u.id = aa.employee_id AND aa.archived_at IS NULL
+LEFT JOIN assets a ON aa.asset_id = a.id AND a.archived_at IS NULL
+WHERE u.archived_at IS NULL AND u.auth_provider <> 'service_account'
+AND (
+    $1 OR (
+       u.username ILIKE '%' || $2 || '%'
+       OR u.email ILIKE '%' || $2 || '%'
+       OR u.contact_no ILIKE '%' || $2 || '%'
+    )
+)
+AND ($3::text[] IS NULL OR ut.type::text = ANY($3))
+AND ($4::text[] IS NULL OR ur.role::text = ANY($4))
+AND ($5::text[] IS NULL OR a.status::text = ANY($5) OR a.id IS NULL)
+AND ($6 OR u.department_id IS NOT DISTINCT FROM $7)
+AND ($8::text[] IS NULL OR u.designation = ANY($8))
+AND ($9::text[] IS NULL OR u.location = ANY($9))
+AND ($10::uuid IS NULL OR u.organization_id = $10)
+GROUP BY u.id, ut.type, u.created_at
+ORDER BY u.created_at DESC;
+    `
+
+func employeeExportArgs(filter EmployeeFilter) []interface{} {
+	return []interface{}{
+		!filter.IsSearchText,
+		filter.SearchText,
+		pq.Array(filter.Type),
+		pq.Array(filter.Role),
+		pq.Array(filter.AssetStatus),
+		filter.Scope.AllDepartments,
+		filter.Scope.DepartmentID,
+		pq.Array(filter.Designation),
+		pq.Array(filter.Location),
+		filter.Scope.OrganizationID,
 	}
-	if req.ContactNo != "" {
-		query += fmt.Sprintf("contact_no = $%d, ", argPos)
-		args = append(args, req.ContactNo)
-		argPos++
-		r.Logger.GetLogger().Debug("updating contact_no", zap.String("contact_no", req.ContactNo))
+}
+
+func (r *PostgresUserRepository) GetEmployeesForExport(ctx context.Context, filter EmployeeFilter) ([]EmployeeExportRow, error) {
+	r.Logger.GetLogger().Info("fetching employees for export", zap.Any("filter", filter))
+	rows := []EmployeeExportRow{}
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &rows, employeeExportQuery, employeeExportArgs(filter)...)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to select employees for export", zap.Error(err), zap.Any("filter", filter))
+		return nil, err
 	}
+	r.Logger.GetLogger().Info("successfully fetched employees for export", zap.Int("count", len(rows)))
+	return rows, nil
+}
 
-	query += fmt.Sprintf("updated_by = $%d ", argPos)
-	args = append(args, adminUUID)
-	argPos++
+// StreamEmployeesForExport hands the rows of GetEmployeesForExport to fn one at a time as they come
+// from the database, an error from fn stops the query
+func (r *PostgresUserRepository) StreamEmployeesForExport(ctx context.Context, filter EmployeeFilter, fn func(EmployeeExportRow) error) error {
+	r.Logger.GetLogger().Info("streaming employees for export", zap.Any("filter", filter))
+	rows, err := utils.Conn(ctx, r.DB).QueryxContext(ctx, employeeExportQuery, employeeExportArgs(filter)...)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to query employees for export", zap.Error(err), zap.Any("filter", filter))
+		return err
+	}
+	defer rows.Close()
+	count := 0
+	for rows.Next() {
+		var row EmployeeExportRow
+		if err := rows.StructScan(&row); err != nil {
+			return fmt.Errorf("failed to scan employee for export: %w", err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		r.Logger.GetLogger().Error("failed to read employees for export", zap.Error(err), zap.Int("count", count))
+		return err
+	}
+	r.Logger.GetLogger().Info("successfully streamed employees for export", zap.Int("count", count))
+	return nil
+}
 
-	query = strings.TrimSuffix(query, ", ")
-	query += fmt.Sprintf("WHERE id = $%d AND archived_at IS NULL", argPos)
-	args = append(args, req.UserID)
+// updateEmployeeQuery is one fixed statement for every update. An empty or NULL value leaves the
+// column as it is, a column named in $9 is set to NULL, and a new or cleared end date gets a fresh warning
+const updateEmployeeQuery = `
+	UPDATE users SET
+		username = COALESCE(NULLIF($2, ''), username),
+		contact_no = CASE WHEN 'contact_no' = ANY($9::text[]) THEN NULL ELSE COALESCE(NULLIF($3, ''), contact_no) END,
+		contact_verified_at = CASE WHEN 'contact_no' = ANY($9::text[]) OR ($3 <> '' AND $3 IS DISTINCT FROM contact_no) THEN NULL ELSE contact_verified_at END,
+		end_date = CASE WHEN 'end_date' = ANY($9::text[]) THEN NULL ELSE COALESCE($4::date, end_date) END,
+		end_date_warned_at = CASE WHEN 'end_date' = ANY($9::text[]) OR $4::date IS NOT NULL THEN NULL ELSE end_date_warned_at END,
+		designation = CASE WHEN 'designation' = ANY($9::text[]) THEN NULL ELSE COALESCE(NULLIF($5, ''), designation) END,
+		location = CASE WHEN 'location' = ANY($9::text[]) THEN NULL ELSE COALESCE(NULLIF($6, ''), location) END,
+		emergency_contact_name = CASE WHEN 'emergency_contact_name' = ANY($9::text[]) THEN NULL ELSE COALESCE(NULLIF($7, ''), emergency_contact_name) END,
+		emergency_contact_no = CASE WHEN 'emergency_contact_no' = ANY($9::text[]) THEN NULL ELSE COALESCE(NULLIF($8, ''), emergency_contact_no) END,
+		date_of_joining = CASE WHEN 'date_of_joining' = ANY($9::text[]) THEN NULL ELSE COALESCE($10::date, date_of_joining) END,
+		badge_id = CASE WHEN 'badge_id' = ANY($9::text[]) THEN NULL ELSE COALESCE(NULLIF($12, ''), badge_id) END,
+		updated_by = $11
+	WHERE id = $1 AND archived_at IS NULL`
 
-	result, err := r.DB.ExecContext(ctx, query, args...)
+func (r *PostgresUserRepository) UpdateEmployeeInfo(ctx context.Context, req UpdateEmployeeReq, adminUUID uuid.UUID) error {
+	r.Logger.GetLogger().Info("updating employee information", zap.String("admin_id", adminUUID.String()))
+	result, err := utils.Conn(ctx, r.DB).ExecContext(ctx, updateEmployeeQuery,
+		req.UserID, req.Username, req.ContactNo, req.EndDate,
+		req.Designation, req.Location, req.EmergencyContactName, req.EmergencyContactNo,
+		pq.Array(req.Clear), req.DateOfJoining, adminUUID, req.BadgeID)
 	if err != nil {
-		r.Logger.GetLogger().Error("failed to update user in database")
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_users_badge_id" {
+			return ErrBadgeInUse
+		}
+		r.Logger.GetLogger().Error("failed to update user in database", zap.Error(err))
 		return fmt.Errorf("failed to update user: %w", err)
 	}
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
 		r.Logger.GetLogger().Warn("no user found or nothing updated for employee update")
-		return fmt.Errorf("no user found or nothing updated")
+		return ErrUserNotFound
 	}
 	r.Logger.GetLogger().Info("employee information updated successfully")
 	return nil
 }
 
-func (r *PostgresUserRepository) InsertUserRole(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, role string, createdBy uuid.UUID) error {
+func (r *PostgresUserRepository) GetEmployeeByID(ctx context.Context, userID uuid.UUID) (EmployeeRes, error) {
+	var employee EmployeeRes
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &employee, `
+		SELECT u.id, u.username, u.email, u.contact_no, ut.type, u.department_id, u.end_date, u.badge_id,
+			u.designation, u.location, u.date_of_joining, u.emergency_contact_name, u.emergency_contact_no
+		FROM users u
+		LEFT JOIN user_type ut ON ut.user_id = u.id AND ut.archived_at IS NULL
+		WHERE u.id = $1 AND u.archived_at IS NULL
+	`, userID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch employee by id", zap.String("user_id", userID.String()), zap.Error(err))
+		return employee, fmt.Errorf("failed to fetch employee: %w", err)
+	}
+	return employee, nil
+}
+
+func (r *PostgresUserRepository) InsertUserRole(ctx context.Context, userID uuid.UUID, role string, createdBy uuid.UUID) error {
 	r.Logger.GetLogger().Info("inserting new user role", zap.String("user_id", userID.String()), zap.String("role", role), zap.String("created_by", createdBy.String()))
-	_, err := tx.ExecContext(ctx, `
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
 		INSERT INTO user_roles (role, user_id, created_by)
 		VALUES ($1, $2, $3)
 	`, role, userID, createdBy)
@@ -565,22 +948,22 @@ func (r *PostgresUserRepository) InsertUserRole(ctx context.Context, tx *sqlx.Tx
 	return nil
 }
 
-func (r *PostgresUserRepository) UpdateUserRole(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID, newRole string, updatedBy uuid.UUID) error {
+func (r *PostgresUserRepository) UpdateUserRole(ctx context.Context, userID uuid.UUID, newRole string, updatedBy uuid.UUID) error {
 	r.Logger.GetLogger().Info("updating user role", zap.String("user_id", userID.String()), zap.String("new_role", newRole), zap.String("updated_by", updatedBy.String()))
-	currentRole, err := r.GetCurrentUserRole(ctx, tx, userID)
+	currentRole, err := r.GetCurrentUserRole(ctx, userID)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		r.Logger.GetLogger().Error("failed to fetch current role for user role update", zap.String("user_id", userID.String()), zap.Error(err))
 		return fmt.Errorf("failed to fetch current role: %w", err)
 	}
 	if err == nil && currentRole == newRole {
 		r.Logger.GetLogger().Warn("user already has the requested role, no update needed", zap.String("user_id", userID.String()), zap.String("role", newRole))
-		return fmt.Errorf("user already has the role: %s", newRole)
+		return fmt.Errorf("%w: %s", ErrRoleAlreadyAssigned, newRole)
 	}
-	if err := r.ArchiveUserRoles(ctx, tx, userID); err != nil {
+	if err := r.ArchiveUserRoles(ctx, userID, updatedBy); err != nil {
 		r.Logger.GetLogger().Error("failed to archive old user roles before updating", zap.String("user_id", userID.String()), zap.Error(err))
 		return err
 	}
-	if err := r.InsertUserRole(ctx, tx, userID, newRole, updatedBy); err != nil {
+	if err := r.InsertUserRole(ctx, userID, newRole, updatedBy); err != nil {
 		r.Logger.GetLogger().Error("failed to insert new user role after archiving old roles", zap.String("user_id", userID.String()), zap.String("new_role", newRole), zap.Error(err))
 		return err
 	}
@@ -588,11 +971,389 @@ func (r *PostgresUserRepository) UpdateUserRole(ctx context.Context, tx *sqlx.Tx
 	return nil
 }
 
-func (r *PostgresUserRepository) GetCurrentUserRole(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID) (string, error) {
+func (r *PostgresUserRepository) IsRoleExists(ctx context.Context, role string) (bool, error) {
+	r.Logger.GetLogger().Debug("checking if role exists", zap.String("role", role))
+	var exists bool
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &exists, `
+		SELECT EXISTS(SELECT 1 FROM roles WHERE name = $1 AND archived_at IS NULL)
+	`, role)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to check role existence", zap.String("role", role), zap.Error(err))
+		return false, fmt.Errorf("failed to check role existence: %w", err)
+	}
+	return exists, nil
+}
+
+func (r *PostgresUserRepository) IsUserInScope(ctx context.Context, userID uuid.UUID, scope models.DepartmentScope) (bool, error) {
+	r.Logger.GetLogger().Debug("checking user department scope", zap.String("user_id", userID.String()))
+	var inScope bool
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &inScope, `
+		SELECT EXISTS (
+			SELECT 1 FROM users
+			WHERE id = $1 AND archived_at IS NULL
+			AND ($2 OR department_id IS NOT DISTINCT FROM $3)
+			AND ($4::uuid IS NULL OR organization_id = $4)
+		)
+	`, userID, scope.AllDepartments, scope.DepartmentID, scope.OrganizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to check user department scope", zap.String("user_id", userID.String()), zap.Error(err))
+		return false, fmt.Errorf("failed to check user department scope: %w", err)
+	}
+	return inScope, nil
+}
+
+func (r *PostgresUserRepository) GetEmployeeType(ctx context.Context, userID uuid.UUID) (string, error) {
+	var employeeType string
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &employeeType, `
+		SELECT type FROM user_type WHERE user_id = $1 AND archived_at IS NULL
+	`, userID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch employee type", zap.String("user_id", userID.String()), zap.Error(err))
+		return "", err
+	}
+	return employeeType, nil
+}
+
+// GetEmployeesEndingWithin returns employees whose end date falls in the next days and who still hold assets,
+// employees that were already warned for their current end date are skipped
+func (r *PostgresUserRepository) GetEmployeesEndingWithin(ctx context.Context, days int) ([]EndingEmployeeRes, error) {
+	employees := make([]EndingEmployeeRes, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &employees, `
+		SELECT u.id AS user_id, u.username, u.end_date, u.department_id, u.organization_id, count(aa.id) AS outstanding_assets
+		FROM users u
+		JOIN asset_assign aa ON aa.employee_id = u.id AND aa.returned_at IS NULL AND aa.archived_at IS NULL
+		WHERE u.archived_at IS NULL
+		AND u.end_date_warned_at IS NULL
+		AND u.end_date > current_date
+		AND u.end_date <= current_date + $1::int
+		GROUP BY u.id
+	`, days)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch employees ending soon", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch employees ending soon: %w", err)
+	}
+	return employees, nil
+}
+
+// GetEmployeesPastEndDate returns employees whose end date has been reached and who hold assets without an open return request
+func (r *PostgresUserRepository) GetEmployeesPastEndDate(ctx context.Context) ([]EndingEmployeeRes, error) {
+	employees := make([]EndingEmployeeRes, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &employees, `
+		SELECT u.id AS user_id, u.username, u.end_date, u.department_id, u.organization_id, count(aa.id) AS outstanding_assets
+		FROM users u
+		JOIN asset_assign aa ON aa.employee_id = u.id AND aa.returned_at IS NULL AND aa.archived_at IS NULL
+		WHERE u.archived_at IS NULL
+		AND u.end_date <= current_date
+		AND NOT EXISTS (
+			SELECT 1 FROM asset_return_requests rr
+			WHERE rr.asset_id = aa.asset_id AND rr.employee_id = u.id AND rr.status = 'open'
+		)
+		GROUP BY u.id
+	`)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch employees past end date", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch employees past end date: %w", err)
+	}
+	return employees, nil
+}
+
+func (r *PostgresUserRepository) MarkEndDateWarned(ctx context.Context, userID uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE users SET end_date_warned_at = now() WHERE id = $1
+	`, userID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to mark end date warned", zap.String("user_id", userID.String()), zap.Error(err))
+		return fmt.Errorf("failed to mark end date warned: %w", err)
+	}
+	return nil
+}
+
+// OpenEndDateReturnRequests opens a return request for every asset the employee still holds and returns the asset ids,
+// assets that already have an open request are skipped
+func (r *PostgresUserRepository) OpenEndDateReturnRequests(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	assetIDs := make([]uuid.UUID, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &assetIDs, `
+		INSERT INTO asset_return_requests (asset_id, employee_id, reason)
+		SELECT aa.asset_id, aa.employee_id, 'employee end date reached'
+		FROM asset_assign aa
+		WHERE aa.employee_id = $1 AND aa.returned_at IS NULL AND aa.archived_at IS NULL
+		ON CONFLICT (asset_id, employee_id) WHERE status = 'open' DO NOTHING
+		RETURNING asset_id
+	`, userID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to open return requests", zap.String("user_id", userID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to open return requests: %w", err)
+	}
+	return assetIDs, nil
+}
+
+// GetDepartmentManagerIDs returns the asset and employee managers of a department along with the admins of its organization
+func (r *PostgresUserRepository) GetDepartmentManagerIDs(ctx context.Context, organizationID uuid.UUID, departmentID *uuid.UUID) ([]uuid.UUID, error) {
+	managerIDs := make([]uuid.UUID, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &managerIDs, `
+		SELECT DISTINCT u.id
+		FROM users u
+		JOIN user_roles ur ON ur.user_id = u.id AND ur.archived_at IS NULL
+		WHERE u.archived_at IS NULL AND u.organization_id = $2
+		AND (
+			ur.role = 'admin'
+			OR (ur.role IN ('asset_manager', 'employee_manager') AND u.department_id IS NOT DISTINCT FROM $1)
+		)
+	`, departmentID, organizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch department managers", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch department managers: %w", err)
+	}
+	return managerIDs, nil
+}
+
+func (r *PostgresUserRepository) GetOnboardingTemplate(ctx context.Context, templateID uuid.UUID, organizationID *uuid.UUID) (models.OnboardingTemplate, error) {
+	var template models.OnboardingTemplate
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &template, `
+		SELECT id, name, employee_type, role, department_id, organization_id, created_at
+		FROM onboarding_templates
+		WHERE id = $1 AND archived_at IS NULL AND ($2::uuid IS NULL OR organization_id = $2)
+	`, templateID, organizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch onboarding template", zap.String("template_id", templateID.String()), zap.Error(err))
+		return template, err
+	}
+	err = utils.Conn(ctx, r.DB).SelectContext(ctx, &template.Items, `
+		SELECT asset_type, quantity FROM onboarding_template_items
+		WHERE template_id = $1
+		ORDER BY asset_type
+	`, templateID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch onboarding template items", zap.String("template_id", templateID.String()), zap.Error(err))
+		return template, fmt.Errorf("failed to fetch onboarding template items: %w", err)
+	}
+	return template, nil
+}
+
+func (r *PostgresUserRepository) IsEmailVerified(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var verified bool
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &verified, `
+		SELECT email_verified_at IS NOT NULL FROM users WHERE id = $1 AND archived_at IS NULL
+	`, userID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to check email verification", zap.String("user_id", userID.String()), zap.Error(err))
+		return false, fmt.Errorf("failed to check email verification: %w", err)
+	}
+	return verified, nil
+}
+
+func (r *PostgresUserRepository) GetEmailVerificationStatus(ctx context.Context, email string) (EmailVerificationStatus, error) {
+	var status EmailVerificationStatus
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &status, `
+		SELECT id, email_verified_at IS NOT NULL AS verified FROM users WHERE email = $1 AND archived_at IS NULL
+	`, email)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch email verification status", zap.String("email", email), zap.Error(err))
+		return status, err
+	}
+	return status, nil
+}
+
+// MarkEmailVerified only matches while the user still has the given email, false means the address has since changed
+func (r *PostgresUserRepository) MarkEmailVerified(ctx context.Context, userID uuid.UUID, email string) (bool, error) {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE users SET email_verified_at = COALESCE(email_verified_at, now())
+		WHERE id = $1 AND email = $2 AND archived_at IS NULL
+	`, userID, email)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to mark email verified", zap.String("user_id", userID.String()), zap.Error(err))
+		return false, fmt.Errorf("failed to mark email verified: %w", err)
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// ArchivePendingEmailChanges retires earlier change requests so only the latest link works
+func (r *PostgresUserRepository) ArchivePendingEmailChanges(ctx context.Context, userID uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE email_change_requests SET archived_at = now()
+		WHERE user_id = $1 AND confirmed_at IS NULL AND archived_at IS NULL
+	`, userID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to archive pending email changes", zap.String("user_id", userID.String()), zap.Error(err))
+		return fmt.Errorf("failed to archive pending email changes: %w", err)
+	}
+	return nil
+}
+
+// InsertEmailChangeRequest snapshots the current address as old_email, sql.ErrNoRows means the user doesn't exist
+func (r *PostgresUserRepository) InsertEmailChangeRequest(ctx context.Context, userID uuid.UUID, newEmail string, requestedBy uuid.UUID, expiresAt time.Time) (EmailChangeRes, error) {
+	var res EmailChangeRes
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &res, `
+		INSERT INTO email_change_requests (user_id, old_email, new_email, requested_by, expires_at)
+		SELECT id, email, $2, $3, $4 FROM users WHERE id = $1 AND archived_at IS NULL
+		RETURNING id, old_email, new_email, expires_at
+	`, userID, newEmail, requestedBy, expiresAt)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert email change request", zap.String("user_id", userID.String()), zap.Error(err))
+		return res, err
+	}
+	return res, nil
+}
+
+func (r *PostgresUserRepository) GetEmailChangeForUpdate(ctx context.Context, requestID uuid.UUID) (EmailChangeRequest, error) {
+	var change EmailChangeRequest
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &change, `
+		SELECT id, user_id, old_email, new_email, requested_by, expires_at, confirmed_at, archived_at
+		FROM email_change_requests
+		WHERE id = $1
+		FOR UPDATE
+	`, requestID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch email change request", zap.String("request_id", requestID.String()), zap.Error(err))
+		return change, err
+	}
+	return change, nil
+}
+
+// ApplyEmailChange switches the user to the new, already verified address, it fails if the email changed since the request
+func (r *PostgresUserRepository) ApplyEmailChange(ctx context.Context, change EmailChangeRequest) error {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE users SET email = $3, email_verified_at = now(), updated_by = $4
+		WHERE id = $1 AND email = $2 AND archived_at IS NULL
+	`, change.UserID, change.OldEmail, change.NewEmail, change.RequestedBy)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to update user email", zap.String("user_id", change.UserID.String()), zap.Error(err))
+		return fmt.Errorf("failed to update user email: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrEmailChangeStale
+	}
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE email_change_requests SET confirmed_at = now() WHERE id = $1
+	`, change.ID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to confirm email change request", zap.String("request_id", change.ID.String()), zap.Error(err))
+		return fmt.Errorf("failed to confirm email change request: %w", err)
+	}
+	return nil
+}
+
+// GetRecentlyActiveUserIDs returns users who signed in successfully since the given time, the most recent first
+func (r *PostgresUserRepository) GetRecentlyActiveUserIDs(ctx context.Context, since time.Time, limit int) ([]uuid.UUID, error) {
+	userIDs := make([]uuid.UUID, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &userIDs, `
+		SELECT ae.user_id
+		FROM auth_events ae
+		JOIN users u ON u.id = ae.user_id AND u.archived_at IS NULL
+		WHERE ae.outcome = 'success' AND ae.created_at >= $1
+		GROUP BY ae.user_id
+		ORDER BY max(ae.created_at) DESC
+		LIMIT $2
+	`, since, limit)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch recently active users", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch recently active users: %w", err)
+	}
+	return userIDs, nil
+}
+
+// InvalidateUserCache drops cached lookups keyed by the user or their addresses, failures only leave short lived stale entries
+func (r *PostgresUserRepository) InvalidateUserCache(ctx context.Context, userID uuid.UUID, emails ...string) {
+	r.Cache.InvalidateUsers(ctx, userID)
+	r.Cache.InvalidateEmails(ctx, emails...)
+}
+
+// ArchivePendingInvites retires earlier invites for the email so only the latest link works
+func (r *PostgresUserRepository) ArchivePendingInvites(ctx context.Context, email string) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE user_invites SET archived_at = now()
+		WHERE email = $1 AND accepted_at IS NULL AND archived_at IS NULL
+	`, email)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to archive pending invites", zap.String("email", email), zap.Error(err))
+		return fmt.Errorf("failed to archive pending invites: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresUserRepository) InsertInvite(ctx context.Context, req InviteEmployeeReq, invitedBy uuid.UUID, expiresAt time.Time) (uuid.UUID, error) {
+	payload, err := utils.JSON.Marshal(req)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to encode invite: %w", err)
+	}
+	var inviteID uuid.UUID
+	err = utils.Conn(ctx, r.DB).GetContext(ctx, &inviteID, `
+		INSERT INTO user_invites (email, payload, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, req.Email, payload, invitedBy, expiresAt)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert invite", zap.String("email", req.Email), zap.Error(err))
+		return uuid.Nil, fmt.Errorf("failed to insert invite: %w", err)
+	}
+	return inviteID, nil
+}
+
+func (r *PostgresUserRepository) GetInviteForUpdate(ctx context.Context, inviteID uuid.UUID) (UserInvite, error) {
+	var invite UserInvite
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &invite, `
+		SELECT id, email, payload, invited_by, expires_at, accepted_at, archived_at
+		FROM user_invites
+		WHERE id = $1
+		FOR UPDATE
+	`, inviteID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch invite", zap.String("invite_id", inviteID.String()), zap.Error(err))
+		return invite, err
+	}
+	return invite, nil
+}
+
+func (r *PostgresUserRepository) MarkInviteAccepted(ctx context.Context, inviteID, userID uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE user_invites SET accepted_at = now(), accepted_user_id = $2
+		WHERE id = $1
+	`, inviteID, userID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to mark invite accepted", zap.String("invite_id", inviteID.String()), zap.Error(err))
+		return fmt.Errorf("failed to mark invite accepted: %w", err)
+	}
+	return nil
+}
+
+// AssignKitAssets assigns up to item.Quantity available assets of the item's type to the user and returns the assigned ids,
+// assets of the user's department are used along with assets that don't belong to any department
+func (r *PostgresUserRepository) AssignKitAssets(ctx context.Context, userID uuid.UUID, item models.OnboardingKitItem, departmentID *uuid.UUID, assignedBy uuid.UUID) ([]uuid.UUID, error) {
+	assetIDs := make([]uuid.UUID, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &assetIDs, `
+		WITH picked AS (
+			SELECT id FROM assets
+			WHERE type = $1::asset_type AND status = 'available' AND archived_at IS NULL
+			AND (department_id IS NULL OR department_id IS NOT DISTINCT FROM $2)
+			AND organization_id = (SELECT organization_id FROM users WHERE id = $4)
+			ORDER BY added_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		), assigned AS (
+			UPDATE assets SET status = 'assigned'
+			WHERE id IN (SELECT id FROM picked)
+			RETURNING id
+		)
+		INSERT INTO asset_assign (asset_id, employee_id, assigned_by)
+		SELECT id, $4, $5 FROM assigned
+		RETURNING asset_id
+	`, item.AssetType, departmentID, item.Quantity, userID, assignedBy)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to assign kit assets", zap.String("user_id", userID.String()), zap.String("asset_type", item.AssetType), zap.Error(err))
+		return nil, fmt.Errorf("failed to assign kit assets: %w", err)
+	}
+	return assetIDs, nil
+}
+
+func (r *PostgresUserRepository) GetCurrentUserRole(ctx context.Context, userID uuid.UUID) (string, error) {
 	r.Logger.GetLogger().Debug("getting current user role", zap.String("user_id", userID.String()))
 	var role string
 
-	err := tx.GetContext(ctx, &role, `
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &role, `
 		SELECT role FROM user_roles
 		WHERE user_id = $1 AND archived_at IS NULL
 	`, userID)
@@ -604,13 +1365,164 @@ func (r *PostgresUserRepository) GetCurrentUserRole(ctx context.Context, tx *sql
 	return role, nil
 }
 
-func (r *PostgresUserRepository) ArchiveUserRoles(ctx context.Context, tx *sqlx.Tx, userID uuid.UUID) error {
-	r.Logger.GetLogger().Debug("archiving user roles for user", zap.String("user_id", userID.String()))
-	_, err := tx.ExecContext(ctx, `
+func (r *PostgresUserRepository) GetUserRoleHistory(ctx context.Context, userID uuid.UUID) ([]RoleHistoryRes, error) {
+	r.Logger.GetLogger().Info("fetching user role history", zap.String("user_id", userID.String()))
+	history := make([]RoleHistoryRes, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &history, `
+		SELECT
+			ur.role,
+			ur.created_at AS granted_at,
+			ur.created_by AS granted_by,
+			granter.username AS granted_by_name,
+			ur.archived_at AS revoked_at,
+			ur.archived_by AS revoked_by,
+			revoker.username AS revoked_by_name
+		FROM user_roles ur
+		LEFT JOIN users granter ON granter.id = ur.created_by
+		LEFT JOIN users revoker ON revoker.id = ur.archived_by
+		WHERE ur.user_id = $1
+		ORDER BY ur.created_at DESC
+	`, userID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch user role history", zap.String("user_id", userID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch role history: %w", err)
+	}
+	return history, nil
+}
+
+func (r *PostgresUserRepository) InsertScheduledRoleChange(ctx context.Context, req ScheduleRoleChangeReq, createdBy uuid.UUID) (uuid.UUID, error) {
+	r.Logger.GetLogger().Info("inserting scheduled role change", zap.String("user_id", req.UserID), zap.String("role", req.Role), zap.Time("effective_from", req.EffectiveFrom))
+	var id uuid.UUID
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &id, `
+		INSERT INTO scheduled_role_changes (user_id, role, effective_from, effective_to, created_by)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, req.UserID, req.Role, req.EffectiveFrom, req.EffectiveTo, createdBy)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert scheduled role change", zap.String("user_id", req.UserID), zap.Error(err))
+		return uuid.Nil, fmt.Errorf("failed to schedule role change: %w", err)
+	}
+	return id, nil
+}
+
+func (r *PostgresUserRepository) GetPendingRoleChanges(ctx context.Context, userID uuid.UUID) ([]ScheduledRoleChangeRes, error) {
+	changes := make([]ScheduledRoleChangeRes, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &changes, `
+		SELECT id, user_id, role, previous_role, effective_from, effective_to, status, created_by, created_at
+		FROM scheduled_role_changes
+		WHERE user_id = $1 AND status IN ('pending', 'applied')
+		ORDER BY effective_from
+	`, userID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch pending role changes", zap.String("user_id", userID.String()), zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch pending role changes: %w", err)
+	}
+	return changes, nil
+}
+
+// GetScheduledRoleChanges lists the changes for users in scope that can still be cancelled, soonest first,
+// only those of userID when it is set
+func (r *PostgresUserRepository) GetScheduledRoleChanges(ctx context.Context, userID *uuid.UUID, limit, offset int, scope models.DepartmentScope) ([]ScheduledRoleChangeRes, error) {
+	changes := make([]ScheduledRoleChangeRes, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &changes, `
+		SELECT src.id, src.user_id, src.role, src.previous_role, src.effective_from, src.effective_to, src.status,
+			src.created_by, src.created_at
+		FROM scheduled_role_changes src
+		JOIN users u ON u.id = src.user_id
+		WHERE src.status = 'pending'
+		AND ($1::uuid IS NULL OR src.user_id = $1)
+		AND ($4 OR u.department_id IS NOT DISTINCT FROM $5)
+		AND ($6::uuid IS NULL OR u.organization_id = $6)
+		ORDER BY src.effective_from
+		LIMIT $2 OFFSET $3
+	`, userID, limit, offset, scope.AllDepartments, scope.DepartmentID, scope.OrganizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch scheduled role changes", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch scheduled role changes: %w", err)
+	}
+	return changes, nil
+}
+
+func (r *PostgresUserRepository) CancelScheduledRoleChange(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) error {
+	r.Logger.GetLogger().Info("cancelling scheduled role change", zap.String("id", id.String()))
+	result, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE scheduled_role_changes src SET status = 'cancelled', cancelled_at = now()
+		FROM users u
+		WHERE src.id = $1 AND src.status = 'pending' AND u.id = src.user_id
+		AND ($2 OR u.department_id IS NOT DISTINCT FROM $3)
+		AND ($4::uuid IS NULL OR u.organization_id = $4)
+	`, id, scope.AllDepartments, scope.DepartmentID, scope.OrganizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to cancel scheduled role change", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to cancel scheduled role change: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return ErrScheduledChangeGone
+	}
+	return nil
+}
+
+func (r *PostgresUserRepository) GetDueRoleChanges(ctx context.Context) ([]ScheduledRoleChangeRes, error) {
+	changes := make([]ScheduledRoleChangeRes, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &changes, `
+		SELECT id, user_id, role, previous_role, effective_from, effective_to, status, created_by, created_at
+		FROM scheduled_role_changes
+		WHERE status = 'pending' AND effective_from <= now()
+		ORDER BY effective_from
+	`)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch due role changes", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch due role changes: %w", err)
+	}
+	return changes, nil
+}
+
+func (r *PostgresUserRepository) GetExpiredRoleChanges(ctx context.Context) ([]ScheduledRoleChangeRes, error) {
+	changes := make([]ScheduledRoleChangeRes, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &changes, `
+		SELECT id, user_id, role, previous_role, effective_from, effective_to, status, created_by, created_at
+		FROM scheduled_role_changes
+		WHERE status = 'applied' AND effective_to IS NOT NULL AND effective_to <= now()
+		ORDER BY effective_to
+	`)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch expired role changes", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch expired role changes: %w", err)
+	}
+	return changes, nil
+}
+
+func (r *PostgresUserRepository) MarkRoleChangeApplied(ctx context.Context, id uuid.UUID, previousRole string) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE scheduled_role_changes SET status = 'applied', applied_at = now(), previous_role = NULLIF($2, '')
+		WHERE id = $1
+	`, id, previousRole)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to mark role change applied", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to mark role change applied: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresUserRepository) MarkRoleChangeReverted(ctx context.Context, id uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE scheduled_role_changes SET status = 'reverted', reverted_at = now()
+		WHERE id = $1
+	`, id)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to mark role change reverted", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to mark role change reverted: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresUserRepository) ArchiveUserRoles(ctx context.Context, userID uuid.UUID, archivedBy uuid.UUID) error {
+	r.Logger.GetLogger().Debug("archiving user roles for user", zap.String("user_id", userID.String()), zap.String("archived_by", archivedBy.String()))
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
 		UPDATE user_roles
-		SET archived_at = now(), last_updated_at = now()
+		SET archived_at = now(), last_updated_at = now(), archived_by = $2
 		WHERE user_id = $1 AND archived_at IS NULL
-	`, userID)
+	`, userID, archivedBy)
 	if err != nil {
 		r.Logger.GetLogger().Error("failed to archive existing roles", zap.String("user_id", userID.String()), zap.Error(err))
 		return fmt.Errorf("failed to archive existing roles: %w", err)
@@ -619,27 +1531,102 @@ func (r *PostgresUserRepository) ArchiveUserRoles(ctx context.Context, tx *sqlx.
 	return nil
 }
 
-func (r *PostgresUserRepository) IsUserExists(ctx context.Context, tx *sqlx.Tx, email string) (bool, error) {
+func (r *PostgresUserRepository) InsertRoleGrantApproval(ctx context.Context, userID uuid.UUID, role, previousRole string, requestedBy uuid.UUID) (uuid.UUID, error) {
+	r.Logger.GetLogger().Info("inserting role grant approval", zap.String("user_id", userID.String()), zap.String("role", role))
+	var id uuid.UUID
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &id, `
+		INSERT INTO role_grant_approvals (user_id, role, previous_role, requested_by)
+		VALUES ($1, $2, NULLIF($3, ''), $4)
+		RETURNING id
+	`, userID, role, previousRole, requestedBy)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert role grant approval", zap.String("user_id", userID.String()), zap.Error(err))
+		return uuid.Nil, fmt.Errorf("failed to request role grant approval: %w", err)
+	}
+	return id, nil
+}
+
+func (r *PostgresUserRepository) HasPendingRoleGrant(ctx context.Context, userID uuid.UUID, role string) (bool, error) {
+	var pending bool
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &pending, `
+		SELECT EXISTS (
+			SELECT 1 FROM role_grant_approvals WHERE user_id = $1 AND role = $2 AND status = 'pending'
+		)
+	`, userID, role)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to check pending role grants", zap.String("user_id", userID.String()), zap.Error(err))
+		return false, fmt.Errorf("failed to check pending role grants: %w", err)
+	}
+	return pending, nil
+}
+
+// GetRoleGrantApprovals lists approvals for users in scope newest first, an empty status lists all of them
+func (r *PostgresUserRepository) GetRoleGrantApprovals(ctx context.Context, status string, limit, offset int, scope models.DepartmentScope) ([]RoleGrantApprovalRes, error) {
+	approvals := make([]RoleGrantApprovalRes, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &approvals, `
+		SELECT rga.id, rga.user_id, rga.role, rga.previous_role, rga.status, rga.requested_by, rga.requested_at,
+			rga.decided_by, rga.decided_at, rga.decision_note
+		FROM role_grant_approvals rga
+		JOIN users u ON u.id = rga.user_id
+		WHERE ($1 = '' OR rga.status = $1)
+		AND ($4 OR u.department_id IS NOT DISTINCT FROM $5)
+		AND ($6::uuid IS NULL OR u.organization_id = $6)
+		ORDER BY rga.requested_at DESC
+		LIMIT $2 OFFSET $3
+	`, status, limit, offset, scope.AllDepartments, scope.DepartmentID, scope.OrganizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch role grant approvals", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch role grant approvals: %w", err)
+	}
+	return approvals, nil
+}
+
+// GetRoleGrantApprovalForUpdate locks the row so two admins deciding at once can't both succeed
+func (r *PostgresUserRepository) GetRoleGrantApprovalForUpdate(ctx context.Context, id uuid.UUID) (RoleGrantApprovalRes, error) {
+	var approval RoleGrantApprovalRes
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &approval, `
+		SELECT id, user_id, role, previous_role, status, requested_by, requested_at, decided_by, decided_at, decision_note
+		FROM role_grant_approvals
+		WHERE id = $1
+		FOR UPDATE
+	`, id)
+	return approval, err
+}
+
+func (r *PostgresUserRepository) DecideRoleGrantApproval(ctx context.Context, id uuid.UUID, status string, decidedBy uuid.UUID, note string) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE role_grant_approvals
+		SET status = $2, decided_by = $3, decided_at = now(), decision_note = NULLIF($4, '')
+		WHERE id = $1 AND status = 'pending'
+	`, id, status, decidedBy, note)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to record role grant decision", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to record role grant decision: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresUserRepository) IsUserExists(ctx context.Context, email string) (bool, error) {
 	r.Logger.GetLogger().Info("checking if user exists by email", zap.String("email", email))
 
-	redisKey := fmt.Sprintf("user:IsUserExists:%s", email)
+	redisKey := cacheprovider.UserExistsKey(email)
 
 	//get value from cache if present
-	if cached, err := r.Redis.Get(ctx, redisKey); err == nil && cached != "" {
+	if cached, ok := r.cacheGet(ctx, models.CacheUserExists, redisKey); ok {
 		r.Logger.GetLogger().Info("user existence found in Redis cache", zap.String("email", email))
 		return cached == "true", nil
 	}
 
 	//run query and store value in redis
 	var id uuid.UUID
-	err := tx.QueryRowContext(ctx, `
+	err := utils.Conn(ctx, r.DB).QueryRowContext(ctx, `
 		SELECT id FROM users 
 		WHERE email = $1 AND archived_at IS NULL
 	`, email).Scan(&id)
 
 	if err == sql.ErrNoRows {
 		r.Logger.GetLogger().Debug("user does not exist", zap.String("email", email))
-		_ = r.Redis.Set(ctx, redisKey, "false", 10*time.Minute)
+		_ = r.cacheSet(ctx, cacheEntry{class: models.CacheUserExists, key: redisKey, value: "false"})
 		return false, nil
 	}
 	if err != nil {
@@ -648,14 +1635,14 @@ func (r *PostgresUserRepository) IsUserExists(ctx context.Context, tx *sqlx.Tx,
 	}
 
 	r.Logger.GetLogger().Info("user exists", zap.String("user_id", id.String()), zap.String("email", email))
-	_ = r.Redis.Set(ctx, redisKey, "true", 10*time.Minute)
+	_ = r.cacheSet(ctx, cacheEntry{class: models.CacheUserExists, key: redisKey, value: "true"})
 	return true, nil
 }
 
-func (r *PostgresUserRepository) InsertIntoUser(ctx context.Context, tx *sqlx.Tx, username, email string, firebasetoken string) (uuid.UUID, error) {
+func (r *PostgresUserRepository) InsertIntoUser(ctx context.Context, username, email string, firebasetoken string) (uuid.UUID, error) {
 	r.Logger.GetLogger().Info("inserting new user into users table", zap.String("username", username), zap.String("email", email))
 	var id uuid.UUID
-	err := tx.GetContext(ctx, &id, `
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &id, `
 		INSERT INTO users (username, email, firebase_uid)
 		VALUES ($1, $2, $3)
 		RETURNING id
@@ -666,7 +1653,7 @@ func (r *PostgresUserRepository) InsertIntoUser(ctx context.Context, tx *sqlx.Tx
 	}
 	r.Logger.GetLogger().Debug("user inserted, updating created_by field", zap.String("user_id", id.String()))
 
-	_, err = tx.ExecContext(ctx, `
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
 		UPDATE users SET created_by = $1 WHERE id = $1
 	`, id)
 	if err != nil {
@@ -677,9 +1664,9 @@ func (r *PostgresUserRepository) InsertIntoUser(ctx context.Context, tx *sqlx.Tx
 	return id, nil
 }
 
-func (r *PostgresUserRepository) InsertIntoUserRole(ctx context.Context, tx *sqlx.Tx, userId uuid.UUID, role string, createdBy uuid.UUID) error {
+func (r *PostgresUserRepository) InsertIntoUserRole(ctx context.Context, userId uuid.UUID, role string, createdBy uuid.UUID) error {
 	r.Logger.GetLogger().Info("inserting user role", zap.String("user_id", userId.String()), zap.String("role", role), zap.String("created_by", createdBy.String()))
-	_, err := tx.ExecContext(ctx, `
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
 		INSERT INTO user_roles (role, user_id, created_by)
 		VALUES ($1, $2, $3)
 	`, role, userId, createdBy)
@@ -691,9 +1678,9 @@ func (r *PostgresUserRepository) InsertIntoUserRole(ctx context.Context, tx *sql
 	return nil
 }
 
-func (r *PostgresUserRepository) InsertIntoUserType(ctx context.Context, tx *sqlx.Tx, userId uuid.UUID, employeeType string, createdBy uuid.UUID) error {
+func (r *PostgresUserRepository) InsertIntoUserType(ctx context.Context, userId uuid.UUID, employeeType string, createdBy uuid.UUID) error {
 	r.Logger.GetLogger().Info("inserting user type", zap.String("user_id", userId.String()), zap.String("employee_type", employeeType), zap.String("created_by", createdBy.String()))
-	_, err := tx.ExecContext(ctx, `
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
 		INSERT INTO user_type (type, user_id, created_by)
 		VALUES ($1, $2, $3)
 	`, employeeType, userId, createdBy)
@@ -705,17 +1692,139 @@ func (r *PostgresUserRepository) InsertIntoUserType(ctx context.Context, tx *sql
 	return nil
 }
 
+// GetFirebaseUID returns an empty string for users who never signed in with google
+func (r *PostgresUserRepository) GetFirebaseUID(ctx context.Context, userID uuid.UUID) (string, error) {
+	var uid *string
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &uid, `SELECT firebase_uid FROM users WHERE id = $1`, userID)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch firebase uid: %w", err)
+	}
+	if uid == nil {
+		return "", nil
+	}
+	return *uid, nil
+}
+
+// firebaseRoleClaimsQuery selects the claims of active users with a firebase account, callers add the filter
+const firebaseRoleClaimsQuery = `
+	SELECT u.id AS user_id, u.firebase_uid, ur.role,
+		COALESCE(array_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}') AS permissions
+	FROM users u
+	JOIN user_roles ur ON ur.user_id = u.id AND ur.archived_at IS NULL
+	LEFT JOIN role_permissions rp ON rp.role = ur.role AND rp.archived_at IS NULL
+	WHERE u.archived_at IS NULL AND u.firebase_uid IS NOT NULL AND u.firebase_uid <> ''
+`
+
+func (r *PostgresUserRepository) GetFirebaseRoleClaims(ctx context.Context, userID uuid.UUID) (FirebaseRoleClaims, error) {
+	var claims FirebaseRoleClaims
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &claims, firebaseRoleClaimsQuery+`
+		AND u.id = $1
+		GROUP BY u.id, u.firebase_uid, ur.role
+	`, userID)
+	return claims, err
+}
+
+// ListFirebaseRoleClaims pages through users by id, pass the last user id of the previous page as afterID
+func (r *PostgresUserRepository) ListFirebaseRoleClaims(ctx context.Context, afterID uuid.UUID, limit int) ([]FirebaseRoleClaims, error) {
+	claims := make([]FirebaseRoleClaims, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &claims, firebaseRoleClaimsQuery+`
+		AND u.id > $1
+		GROUP BY u.id, u.firebase_uid, ur.role
+		ORDER BY u.id
+		LIMIT $2
+	`, afterID, limit)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to list firebase role claims", zap.Error(err))
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (r *PostgresUserRepository) GetUserIDByIdentity(ctx context.Context, issuer, subject string) (uuid.UUID, error) {
+	var userID uuid.UUID
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &userID, `
+		SELECT ui.user_id FROM user_identities ui
+		JOIN users u ON u.id = ui.user_id AND u.archived_at IS NULL
+		WHERE ui.issuer = $1 AND ui.subject = $2
+	`, issuer, subject)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return userID, nil
+}
+
+// LinkIdentity records the login, relinking the identity when the previous user was archived
+func (r *PostgresUserRepository) LinkIdentity(ctx context.Context, userID uuid.UUID, identity models.OIDCIdentity) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO user_identities (issuer, subject, provider, user_id, email)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		ON CONFLICT (issuer, subject) DO UPDATE
+		SET user_id = EXCLUDED.user_id, email = EXCLUDED.email, last_login_at = now()
+	`, identity.Issuer, identity.Subject, identity.Provider, userID, identity.Email)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to link identity", zap.String("user_id", userID.String()), zap.String("provider", identity.Provider), zap.Error(err))
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+	return nil
+}
+
+// GetDirectoryLinkedUsers lists the active users the directory sync manages
+func (r *PostgresUserRepository) GetDirectoryLinkedUsers(ctx context.Context, issuer string) ([]DirectoryLinkedUser, error) {
+	users := make([]DirectoryLinkedUser, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &users, `
+		SELECT ui.user_id, ui.subject, u.email FROM user_identities ui
+		JOIN users u ON u.id = ui.user_id AND u.archived_at IS NULL
+		WHERE ui.issuer = $1
+	`, issuer)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch directory linked users", zap.String("issuer", issuer), zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch directory linked users: %w", err)
+	}
+	return users, nil
+}
+
+func (r *PostgresUserRepository) InsertDirectorySyncRun(ctx context.Context, report DirectorySyncReport) error {
+	payload, err := utils.JSON.Marshal(report)
+	if err != nil {
+		return err
+	}
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO directory_sync_runs (id, provider, dry_run, status, triggered_by, report, started_at, finished_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, report.ID, report.Provider, report.DryRun, report.Status, report.TriggeredBy, payload, report.StartedAt, report.FinishedAt)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert directory sync run", zap.String("run_id", report.ID.String()), zap.Error(err))
+		return fmt.Errorf("failed to save directory sync report: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresUserRepository) GetDirectorySyncRuns(ctx context.Context, limit, offset int) ([]DirectorySyncRun, error) {
+	runs := make([]DirectorySyncRun, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &runs, `
+		SELECT id, provider, dry_run, status, triggered_by, report, started_at, finished_at
+		FROM directory_sync_runs
+		ORDER BY started_at DESC
+		LIMIT $1 OFFSET $2
+	`, limit, offset)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch directory sync runs", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch directory sync runs: %w", err)
+	}
+	return runs, nil
+}
+
 func (r *PostgresUserRepository) GetEmailByUserID(ctx context.Context, userId uuid.UUID) (string, error) {
-	redisKey := fmt.Sprintf("user:GetEmailByUserID:%s", userId.String())
+	redisKey := cacheprovider.UserEmailKey(userId)
 	//get data from redis, if present
-	if cached, err := r.Redis.Get(ctx, redisKey); err == nil && cached != "" {
+	if cached, ok := r.cacheGet(ctx, models.CacheUserEmail, redisKey); ok {
 		r.Logger.GetLogger().Info("user email found in Redis cache", zap.String("user_id", userId.String()))
 		return cached, nil
 	}
 
 	//if not present for user id , run query and then store in redis cace
 	var userMail string
-	err := r.DB.GetContext(ctx, &userMail, `SELECT email FROM users WHERE id = $1 AND archived_at IS NULL`, userId)
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &userMail, `SELECT email FROM users WHERE id = $1 AND archived_at IS NULL`, userId)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			r.Logger.GetLogger().Warn("no user found with given ID", zap.String("user_id", userId.String()))
@@ -726,7 +1835,7 @@ func (r *PostgresUserRepository) GetEmailByUserID(ctx context.Context, userId uu
 	}
 
 	// Cache result in Redis
-	if err := r.Redis.Set(ctx, redisKey, userMail, 5*time.Minute); err != nil {
+	if err := r.cacheSet(ctx, cacheEntry{class: models.CacheUserEmail, key: redisKey, value: userMail}); err != nil {
 		r.Logger.GetLogger().Warn("failed to cache user email in Redis", zap.Error(err))
 	}
 
@@ -735,50 +1844,429 @@ func (r *PostgresUserRepository) GetEmailByUserID(ctx context.Context, userId uu
 
 func (r *PostgresUserRepository) CreateFirebaseUser(ctx context.Context, name, email string) (userID uuid.UUID, err error) {
 	r.Logger.GetLogger().Info("creating firebase user in postgres repository", zap.String("name", name), zap.String("email", email))
-	tx, err := r.DB.BeginTxx(ctx, nil)
+	err = utils.WithTransaction(ctx, r.DB, func(ctx context.Context) error {
+		var err error
+		userID, err = r.InsertIntoUser(ctx, name, email, "")
+		if err != nil {
+			r.Logger.GetLogger().Error("failed to insert user during firebase user creation", zap.Error(err))
+			return err
+		}
+		err = r.InsertIntoUserRole(ctx, userID, "employee", userID)
+		if err != nil {
+			r.Logger.GetLogger().Error("failed to insert user role during firebase user creation", zap.Error(err))
+			return err
+		}
+		err = r.InsertIntoUserType(ctx, userID, "full_time", userID)
+		if err != nil {
+			r.Logger.GetLogger().Error("failed to insert user type during firebase user creation", zap.Error(err))
+			return err
+		}
+		return nil
+	})
 	if err != nil {
-		r.Logger.GetLogger().Error("failed to begin transaction for createfirebaseuser", zap.Error(err))
 		return uuid.Nil, err
 	}
+	r.Logger.GetLogger().Info("firebase user created successfully in postgres", zap.String("user_id", userID.String()))
+	return userID, nil
+}
 
-	defer func() {
-		if p := recover(); p != nil {
-			_ = tx.Rollback()
-			r.Logger.GetLogger().Error("panic recovered during createfirebaseuser transaction", zap.Any("recover_info", p))
-			panic(p)
-		} else if err != nil {
-			_ = tx.Rollback()
-			r.Logger.GetLogger().Error("rolling back transaction for createfirebaseuser due to error", zap.Error(err))
-		} else {
-			err = tx.Commit()
-			if err != nil {
-				r.Logger.GetLogger().Error("failed to commit transaction for createfirebaseuser", zap.Error(err))
-			} else {
-				r.Logger.GetLogger().Info("transaction committed successfully for createfirebaseuser")
-			}
+func (r *PostgresUserRepository) GetFirebase() providers.FirebaseProvider {
+	r.Logger.GetLogger().Debug("getting firebase provider instance")
+	return r.Firebase
+}
+
+func (r *PostgresUserRepository) GetUserMFA(ctx context.Context, userID uuid.UUID) (UserMFA, error) {
+	var mfa UserMFA
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &mfa, `
+		SELECT secret, enabled_at, last_used_step FROM user_mfa WHERE user_id = $1
+	`, userID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			r.Logger.GetLogger().Error("failed to fetch user mfa", zap.String("user_id", userID.String()), zap.Error(err))
 		}
-	}()
+		return mfa, err
+	}
+	return mfa, nil
+}
 
-	userID, err = r.InsertIntoUser(ctx, tx, name, email, "")
+// SavePendingMFA stores a fresh secret unless mfa is already active, in which case it returns false
+func (r *PostgresUserRepository) SavePendingMFA(ctx context.Context, userID uuid.UUID, encryptedSecret string) (bool, error) {
+	result, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO user_mfa (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+			SET secret = EXCLUDED.secret, last_used_step = NULL, created_at = now()
+			WHERE user_mfa.enabled_at IS NULL
+	`, userID, encryptedSecret)
 	if err != nil {
-		r.Logger.GetLogger().Error("failed to insert user during firebase user creation", zap.Error(err))
-		return uuid.Nil, err
+		r.Logger.GetLogger().Error("failed to save pending mfa", zap.String("user_id", userID.String()), zap.Error(err))
+		return false, fmt.Errorf("failed to save mfa secret: %w", err)
 	}
-	err = r.InsertIntoUserRole(ctx, tx, userID, "employee", userID)
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+func (r *PostgresUserRepository) EnableMFA(ctx context.Context, userID uuid.UUID, step int64) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE user_mfa SET enabled_at = now(), last_used_step = $2
+		WHERE user_id = $1 AND enabled_at IS NULL
+	`, userID, step)
 	if err != nil {
-		r.Logger.GetLogger().Error("failed to insert user role during firebase user creation", zap.Error(err))
-		return uuid.Nil, err
+		r.Logger.GetLogger().Error("failed to enable mfa", zap.String("user_id", userID.String()), zap.Error(err))
+		return fmt.Errorf("failed to enable mfa: %w", err)
 	}
-	err = r.InsertIntoUserType(ctx, tx, userID, "full_time", userID)
+	return nil
+}
+
+// ConsumeMFAStep records the step a code was accepted for, false means that code was already used
+func (r *PostgresUserRepository) ConsumeMFAStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	result, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE user_mfa SET last_used_step = $2
+		WHERE user_id = $1 AND (last_used_step IS NULL OR last_used_step < $2)
+	`, userID, step)
 	if err != nil {
-		r.Logger.GetLogger().Error("failed to insert user type during firebase user creation", zap.Error(err))
-		return uuid.Nil, err
+		r.Logger.GetLogger().Error("failed to record mfa step", zap.String("user_id", userID.String()), zap.Error(err))
+		return false, fmt.Errorf("failed to record mfa step: %w", err)
 	}
-	r.Logger.GetLogger().Info("firebase user created successfully in postgres", zap.String("user_id", userID.String()))
-	return userID, nil
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
 }
 
-func (r *PostgresUserRepository) GetFirebase() providers.FirebaseProvider {
-	r.Logger.GetLogger().Debug("getting firebase provider instance")
-	return r.Firebase
+func (r *PostgresUserRepository) DeleteUserMFA(ctx context.Context, userID uuid.UUID) error {
+	if _, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `DELETE FROM user_mfa_backup_codes WHERE user_id = $1`, userID); err != nil {
+		r.Logger.GetLogger().Error("failed to delete backup codes", zap.String("user_id", userID.String()), zap.Error(err))
+		return fmt.Errorf("failed to delete backup codes: %w", err)
+	}
+	if _, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `DELETE FROM user_mfa WHERE user_id = $1`, userID); err != nil {
+		r.Logger.GetLogger().Error("failed to delete user mfa", zap.String("user_id", userID.String()), zap.Error(err))
+		return fmt.Errorf("failed to delete mfa: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresUserRepository) ReplaceBackupCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	if _, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `DELETE FROM user_mfa_backup_codes WHERE user_id = $1`, userID); err != nil {
+		r.Logger.GetLogger().Error("failed to clear backup codes", zap.String("user_id", userID.String()), zap.Error(err))
+		return fmt.Errorf("failed to clear backup codes: %w", err)
+	}
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		INSERT INTO user_mfa_backup_codes (user_id, code_hash)
+		SELECT $1, unnest($2::text[])
+	`, userID, pq.Array(codeHashes))
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert backup codes", zap.String("user_id", userID.String()), zap.Error(err))
+		return fmt.Errorf("failed to insert backup codes: %w", err)
+	}
+	return nil
+}
+
+// ConsumeBackupCode marks a matching unused code as used, false when nothing matched
+func (r *PostgresUserRepository) ConsumeBackupCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	result, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE user_mfa_backup_codes SET used_at = now()
+		WHERE id = (
+			SELECT id FROM user_mfa_backup_codes
+			WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+			LIMIT 1
+		)
+	`, userID, codeHash)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to consume backup code", zap.String("user_id", userID.String()), zap.Error(err))
+		return false, fmt.Errorf("failed to consume backup code: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+func (r *PostgresUserRepository) IsMFARequiredForRole(ctx context.Context, role string) (bool, error) {
+	var required bool
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &required, `
+		SELECT COALESCE((SELECT mfa_required FROM roles WHERE name = $1 AND archived_at IS NULL), false)
+	`, role)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to check mfa policy for role", zap.String("role", role), zap.Error(err))
+		return false, fmt.Errorf("failed to check mfa policy: %w", err)
+	}
+	return required, nil
+}
+
+func (r *PostgresUserRepository) GetMFAAttempts(ctx context.Context, userID uuid.UUID) int {
+	attempts := 0
+	if cached, err := r.Redis.Get(ctx, fmt.Sprintf("user:mfaAttempts:%s", userID.String())); err == nil && cached != "" {
+		attempts, _ = strconv.Atoi(cached)
+	}
+	return attempts
+}
+
+// IncrementMFAAttempts counts failed codes per user in redis, the window restarts on every failure
+func (r *PostgresUserRepository) IncrementMFAAttempts(ctx context.Context, userID uuid.UUID, window time.Duration) (int, error) {
+	key := fmt.Sprintf("user:mfaAttempts:%s", userID.String())
+	attempts := r.GetMFAAttempts(ctx, userID) + 1
+	if err := r.Redis.Set(ctx, key, strconv.Itoa(attempts), window); err != nil {
+		r.Logger.GetLogger().Warn("failed to store mfa attempts", zap.String("user_id", userID.String()), zap.Error(err))
+		return attempts, err
+	}
+	return attempts, nil
+}
+
+// login lockout state lives in redis, subject is email:<email> or ip:<address>
+//
+//	auth:login_failures:<subject>  failures in the current window
+//	auth:login_lockouts:<subject>  lockouts within the decay period, drives the backoff
+//	auth:login_lock:<subject>      unix time the lock ends
+const incrementWithExpiryScript = `
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return count
+`
+
+func init() {
+	redisprovider.RegisterScript(incrementWithExpiryScript, func(store *redisprovider.MemoryStore, keys []string, args []interface{}) (interface{}, error) {
+		ttl, err := redisprovider.ArgFloat(args[0])
+		if err != nil {
+			return nil, err
+		}
+		count, err := store.Incr(keys[0])
+		if err == nil && count == 1 {
+			store.PExpire(keys[0], time.Duration(ttl)*time.Millisecond)
+		}
+		return count, err
+	})
+	redisprovider.RegisterScript(consumeMagicLinkScript, func(store *redisprovider.MemoryStore, keys []string, args []interface{}) (interface{}, error) {
+		userID, ok := store.Get(keys[0])
+		if !ok {
+			return "", nil
+		}
+		store.Del(keys[0])
+		return userID, nil
+	})
+	redisprovider.RegisterScript(checkContactOTPScript, func(store *redisprovider.MemoryStore, keys []string, args []interface{}) (interface{}, error) {
+		stored, ok := store.Get(keys[0])
+		if !ok {
+			return int64(0), nil
+		}
+		if stored == redisprovider.ArgString(args[0]) {
+			return int64(1), nil
+		}
+		maxAttempts, err := redisprovider.ArgFloat(args[1])
+		if err != nil {
+			return nil, err
+		}
+		ttl, err := redisprovider.ArgFloat(args[2])
+		if err != nil {
+			return nil, err
+		}
+		attempts, err := store.Incr(keys[1])
+		if err != nil {
+			return nil, err
+		}
+		if attempts == 1 {
+			store.PExpire(keys[1], time.Duration(ttl)*time.Millisecond)
+		}
+		if float64(attempts) >= maxAttempts {
+			store.Del(keys[0])
+			store.Del(keys[1])
+		}
+		return int64(0), nil
+	})
+}
+
+// GetLoginLockedUntil returns the latest lock of the subjects, read in one round trip
+func (r *PostgresUserRepository) GetLoginLockedUntil(ctx context.Context, subjects ...string) time.Time {
+	if len(subjects) == 0 {
+		return time.Time{}
+	}
+	keys := make([]string, len(subjects))
+	for i, subject := range subjects {
+		keys[i] = "auth:login_lock:" + subject
+	}
+	values, err := r.Redis.MGet(ctx, keys...)
+	if err != nil {
+		return time.Time{}
+	}
+	var until time.Time
+	for _, value := range values {
+		unix, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		if locked := time.Unix(unix, 0); locked.After(until) {
+			until = locked
+		}
+	}
+	return until
+}
+
+// IncrementLoginFailures counts atomically so parallel guesses can't slip under the limit
+func (r *PostgresUserRepository) IncrementLoginFailures(ctx context.Context, subject string, window time.Duration) (int, error) {
+	return r.incrementWithExpiry(ctx, "auth:login_failures:"+subject, window)
+}
+
+func (r *PostgresUserRepository) IncrementLoginLockouts(ctx context.Context, subject string, decay time.Duration) (int, error) {
+	return r.incrementWithExpiry(ctx, "auth:login_lockouts:"+subject, decay)
+}
+
+func (r *PostgresUserRepository) incrementWithExpiry(ctx context.Context, key string, ttl time.Duration) (int, error) {
+	result, err := r.Redis.Eval(ctx, incrementWithExpiryScript, []string{key}, ttl.Milliseconds())
+	if err != nil {
+		r.Logger.GetLogger().Warn("failed to increment counter", zap.String("key", key), zap.Error(err))
+		return 0, err
+	}
+	count, ok := result.(int64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected counter value %v", result)
+	}
+	return int(count), nil
+}
+
+func (r *PostgresUserRepository) LockLogin(ctx context.Context, subject string, until time.Time) error {
+	if err := r.Redis.Set(ctx, "auth:login_lock:"+subject, strconv.FormatInt(until.Unix(), 10), time.Until(until)); err != nil {
+		r.Logger.GetLogger().Error("failed to lock login", zap.String("subject", subject), zap.Error(err))
+		return err
+	}
+	return r.Redis.Del(ctx, "auth:login_failures:"+subject)
+}
+
+func (r *PostgresUserRepository) ClearLoginFailures(ctx context.Context, subject string) {
+	if err := r.Redis.Del(ctx, "auth:login_failures:"+subject); err != nil {
+		r.Logger.GetLogger().Warn("failed to clear login failures", zap.String("subject", subject), zap.Error(err))
+	}
+}
+
+// ClearLoginLock also forgets earlier lockouts so the next one starts from the base duration again
+func (r *PostgresUserRepository) ClearLoginLock(ctx context.Context, subject string) error {
+	return r.Redis.Del(ctx, "auth:login_lock:"+subject, "auth:login_failures:"+subject, "auth:login_lockouts:"+subject)
+}
+
+func (r *PostgresUserRepository) ResetMFAAttempts(ctx context.Context, userID uuid.UUID) {
+	if err := r.Redis.Del(ctx, fmt.Sprintf("user:mfaAttempts:%s", userID.String())); err != nil {
+		r.Logger.GetLogger().Warn("failed to reset mfa attempts", zap.String("user_id", userID.String()), zap.Error(err))
+	}
+}
+
+// magic links live in redis until used or expired
+//
+//	auth:magic_link:<link id>           user the link was sent to
+//	auth:magic_link_requests:<email>    links sent in the current window
+const consumeMagicLinkScript = `
+local userID = redis.call('GET', KEYS[1])
+if not userID then
+	return ''
+end
+redis.call('DEL', KEYS[1])
+return userID
+`
+
+func (r *PostgresUserRepository) IncrementMagicLinkRequests(ctx context.Context, email string, window time.Duration) (int, error) {
+	return r.incrementWithExpiry(ctx, "auth:magic_link_requests:"+strings.ToLower(email), window)
+}
+
+func (r *PostgresUserRepository) StoreMagicLink(ctx context.Context, linkID string, userID uuid.UUID, ttl time.Duration) error {
+	if err := r.Redis.Set(ctx, "auth:magic_link:"+linkID, userID.String(), ttl); err != nil {
+		r.Logger.GetLogger().Error("failed to store magic link", zap.String("user_id", userID.String()), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// ConsumeMagicLink removes the link in the same step as reading it, so of two parallel
+// exchanges of one link only the first gets true
+func (r *PostgresUserRepository) ConsumeMagicLink(ctx context.Context, linkID string, userID uuid.UUID) (bool, error) {
+	result, err := r.Redis.Eval(ctx, consumeMagicLinkScript, []string{"auth:magic_link:" + linkID})
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to consume magic link", zap.String("user_id", userID.String()), zap.Error(err))
+		return false, err
+	}
+	stored, _ := result.(string)
+	return stored == userID.String(), nil
+}
+
+// texted codes confirming a contact number live in redis until used or expired, the number is the
+// hash tag so the script's two keys share a cluster slot
+//
+//	auth:contact_otp:{<number>}           hash of the code last texted to the number
+//	auth:contact_otp_attempts:{<number>}  wrong codes typed for it
+func contactOTPKeys(contactNo string) (code, attempts string) {
+	return "auth:contact_otp:{" + contactNo + "}", "auth:contact_otp_attempts:{" + contactNo + "}"
+}
+
+const checkContactOTPScript = `
+local stored = redis.call('GET', KEYS[1])
+if not stored then
+	return 0
+end
+if stored == ARGV[1] then
+	return 1
+end
+local attempts = redis.call('INCR', KEYS[2])
+if attempts == 1 then
+	redis.call('PEXPIRE', KEYS[2], ARGV[3])
+end
+if attempts >= tonumber(ARGV[2]) then
+	redis.call('DEL', KEYS[1], KEYS[2])
+end
+return 0
+`
+
+// StoreContactOTP replaces the code of the number, the wrong attempts at an earlier code don't carry over
+func (r *PostgresUserRepository) StoreContactOTP(ctx context.Context, contactNo, codeHash string, ttl time.Duration) error {
+	codeKey, attemptsKey := contactOTPKeys(contactNo)
+	if err := r.Redis.Set(ctx, codeKey, codeHash, ttl); err != nil {
+		r.Logger.GetLogger().Error("failed to store contact otp", zap.Error(err))
+		return err
+	}
+	if err := r.Redis.Del(ctx, attemptsKey); err != nil {
+		r.Logger.GetLogger().Warn("failed to reset contact otp attempts", zap.Error(err))
+	}
+	return nil
+}
+
+// CheckContactOTP leaves a matching code in place so a registration failing for another reason can
+// be retried with it. The code is dropped after maxAttempts wrong ones
+func (r *PostgresUserRepository) CheckContactOTP(ctx context.Context, contactNo, codeHash string, maxAttempts int) (bool, error) {
+	codeKey, attemptsKey := contactOTPKeys(contactNo)
+	result, err := r.Redis.Eval(ctx, checkContactOTPScript, []string{codeKey, attemptsKey}, codeHash, maxAttempts, time.Hour.Milliseconds())
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to check contact otp", zap.Error(err))
+		return false, err
+	}
+	matched, _ := result.(int64)
+	return matched == 1, nil
+}
+
+func (r *PostgresUserRepository) DeleteContactOTP(ctx context.Context, contactNo string) {
+	codeKey, attemptsKey := contactOTPKeys(contactNo)
+	if err := r.Redis.Del(ctx, codeKey, attemptsKey); err != nil {
+		r.Logger.GetLogger().Warn("failed to delete contact otp", zap.Error(err))
+	}
+}
+
+func (r *PostgresUserRepository) MarkContactVerified(ctx context.Context, userID uuid.UUID) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE users SET contact_verified_at = now()
+		WHERE id = $1 AND archived_at IS NULL
+	`, userID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to mark contact verified", zap.String("user_id", userID.String()), zap.Error(err))
+		return fmt.Errorf("failed to mark contact verified: %w", err)
+	}
+	return nil
+}
+
+// SetTimezone stores the IANA timezone responses are converted to, nil goes back to UTC
+func (r *PostgresUserRepository) SetTimezone(ctx context.Context, userID uuid.UUID, timezone *string) error {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE users SET timezone = $2
+		WHERE id = $1 AND archived_at IS NULL
+	`, userID, timezone)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to set user timezone", zap.String("user_id", userID.String()), zap.Error(err))
+		return fmt.Errorf("failed to set user timezone: %w", err)
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return ErrUserNotFound
+	}
+	return nil
 }
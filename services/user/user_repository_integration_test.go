@@ -0,0 +1,305 @@
+//go:build integration
+
+package userservice
+
+import (
+	"asset/database/seed"
+	"asset/database/testdb"
+	"asset/models"
+	"asset/providers"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestDashboardCacheInvalidation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewUserRepository(db, logger, nil, testdb.Redis(t), nil)
+	ctx := context.Background()
+	userID, laptopID := seed.ID("user:developer"), seed.ID("asset:laptop-1")
+	// seeded ids are the same on every run, a dashboard cached by an earlier run must not count
+	repo.InvalidateUserCache(ctx, userID)
+
+	dashboard, err := repo.GetUserDashboardById(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, dashboard.AssignedAssets, 2)
+
+	_, err = db.Exec(`UPDATE asset_assign SET returned_at = now() WHERE asset_id = $1 AND employee_id = $2`, laptopID, userID)
+	require.NoError(t, err)
+
+	cached, err := repo.GetUserDashboardById(ctx, userID)
+	require.NoError(t, err)
+	assert.Len(t, cached.AssignedAssets, 2, "the dashboard should come from the cache until it is invalidated")
+
+	repo.InvalidateUserCache(ctx, userID)
+	fresh, err := repo.GetUserDashboardById(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, fresh.AssignedAssets, 1)
+	assert.Equal(t, seed.ID("asset:mouse-1"), fresh.AssignedAssets[0].ID)
+}
+
+func TestTimelineCacheInvalidation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewUserRepository(db, logger, nil, testdb.Redis(t), nil)
+	ctx := context.Background()
+	userID, laptopID := seed.ID("user:developer"), seed.ID("asset:laptop-1")
+	repo.InvalidateUserCache(ctx, userID)
+
+	// every page is cached under its own key, one invalidation has to retire them all
+	first, err := repo.GetUserTimeline(ctx, userID, 50, 0)
+	require.NoError(t, err)
+	firstPage, err := repo.GetUserTimeline(ctx, userID, 1, 0)
+	require.NoError(t, err)
+
+	_, err = db.Exec(`UPDATE asset_assign SET returned_at = now(), return_reason = 'broken' WHERE asset_id = $1 AND employee_id = $2`, laptopID, userID)
+	require.NoError(t, err)
+
+	cached, err := repo.GetUserTimeline(ctx, userID, 50, 0)
+	require.NoError(t, err)
+	assert.Len(t, cached, len(first), "the timeline should come from the cache until it is invalidated")
+
+	repo.InvalidateUserCache(ctx, userID)
+	fresh, err := repo.GetUserTimeline(ctx, userID, 50, 0)
+	require.NoError(t, err)
+	assert.Len(t, fresh, len(first)+1)
+	freshPage, err := repo.GetUserTimeline(ctx, userID, 1, 0)
+	require.NoError(t, err)
+	require.Len(t, freshPage, 1)
+	assert.Equal(t, "asset_returned", freshPage[0].EventType)
+	assert.NotEqual(t, firstPage[0], freshPage[0])
+}
+
+func TestUpdateEmployeeInfo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewUserRepository(db, logger, nil, testdb.Redis(t), nil)
+	ctx := context.Background()
+	userID, adminID := seed.ID("user:intern"), seed.ID("user:admin")
+	_, err := db.Exec(`UPDATE users SET contact_no = '+911111111111', designation = 'intern', end_date_warned_at = now() WHERE id = $1`, userID)
+	require.NoError(t, err)
+
+	endDate := time.Date(2030, 1, 31, 0, 0, 0, 0, time.UTC)
+	req := UpdateEmployeeReq{UserID: userID, Username: "renamed", EndDate: &endDate, ProfileFields: ProfileFields{Location: "Pune"}, Clear: []string{"contact_no"}}
+	require.NoError(t, repo.UpdateEmployeeInfo(ctx, req, adminID))
+
+	var row struct {
+		Username    string     `db:"username"`
+		ContactNo   *string    `db:"contact_no"`
+		Designation *string    `db:"designation"`
+		Location    *string    `db:"location"`
+		EndDate     *time.Time `db:"end_date"`
+		WarnedAt    *time.Time `db:"end_date_warned_at"`
+	}
+	require.NoError(t, db.Get(&row, `SELECT username, contact_no, designation, location, end_date, end_date_warned_at FROM users WHERE id = $1`, userID))
+	assert.Equal(t, "renamed", row.Username)
+	assert.Nil(t, row.ContactNo, "a cleared column should be NULL")
+	require.NotNil(t, row.Designation)
+	assert.Equal(t, "intern", *row.Designation, "an empty value should leave the column as it is")
+	require.NotNil(t, row.Location)
+	assert.Equal(t, "Pune", *row.Location)
+	require.NotNil(t, row.EndDate)
+	assert.True(t, endDate.Equal(*row.EndDate))
+	assert.Nil(t, row.WarnedAt, "a new end date should be warned about again")
+
+	err = repo.UpdateEmployeeInfo(ctx, UpdateEmployeeReq{UserID: seed.ID("user:missing"), Username: "nobody"}, adminID)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+// benchEmployees is the size of the bulk dataset the benchmarks run on
+const benchEmployees = 2000
+
+// BenchmarkGetFilteredEmployeesWithAssets runs the filter combinations the employee screen sends over
+// the bulk seed, one page of 50 at a time
+func BenchmarkGetFilteredEmployeesWithAssets(b *testing.B) {
+	ctrl := gomock.NewController(b)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Bulk(b, benchEmployees)
+	repo := NewUserRepository(db, logger, nil, testdb.Redis(b), nil)
+	ctx := context.Background()
+	engineering := seed.ID("department:engineering")
+	all := models.DepartmentScope{AllDepartments: true}
+	filters := []struct {
+		name   string
+		filter EmployeeFilter
+	}{
+		{"all", EmployeeFilter{Scope: all}},
+		{"search", EmployeeFilter{IsSearchText: true, SearchText: "employee1", Scope: all}},
+		{"type_role", EmployeeFilter{Type: []string{"full_time"}, Role: []string{"employee"}, Scope: all}},
+		{"asset_status", EmployeeFilter{AssetStatus: []string{"assigned"}, Scope: all}},
+		{"designation_location", EmployeeFilter{Designation: []string{"QA Engineer"}, Location: []string{"Noida"}, Scope: all}},
+		{"department", EmployeeFilter{Scope: models.DepartmentScope{DepartmentID: &engineering}}},
+	}
+	for _, f := range filters {
+		filter := f.filter
+		filter.Limit = 50
+		b.Run(f.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				filter.Offset = (i * filter.Limit) % benchEmployees
+				if _, err := repo.GetFilteredEmployeesWithAssets(ctx, filter); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkGetUserDashboardById reads dashboards of bulk employees from the summary table with the
+// cache emptied before every read, and again once they are cached
+func BenchmarkGetUserDashboardById(b *testing.B) {
+	ctrl := gomock.NewController(b)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Bulk(b, benchEmployees)
+	repo := NewUserRepository(db, logger, nil, testdb.Redis(b), nil)
+	ctx := context.Background()
+	userID := func(i int) uuid.UUID {
+		return seed.ID(fmt.Sprintf("user:bulk-%d", i%benchEmployees+1))
+	}
+
+	b.Run("cold", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			repo.InvalidateUserCache(ctx, userID(i))
+			b.StartTimer()
+			if _, err := repo.GetUserDashboardById(ctx, userID(i)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("cached", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := repo.GetUserDashboardById(ctx, userID(i)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestGetDirectoryLeavesOutAnonymized(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewUserRepository(db, logger, nil, testdb.Redis(t), nil)
+	ctx := context.Background()
+
+	// without an organization in scope the caller's own one is listed
+	entries, err := repo.GetDirectory(ctx, DirectoryFilter{Search: "backend dev", CallerID: seed.ID("user:admin"), Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, seed.ID("user:developer"), entries[0].ID)
+	assert.Equal(t, "Dev Sharma", entries[0].Username)
+	require.NotNil(t, entries[0].Department)
+
+	_, err = db.Exec(`UPDATE users SET anonymized_at = now() WHERE id = $1`, seed.ID("user:developer"))
+	require.NoError(t, err)
+	entries, err = repo.GetDirectory(ctx, DirectoryFilter{Search: "backend dev", CallerID: seed.ID("user:admin"), Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	other := uuid.New()
+	entries, err = repo.GetDirectory(ctx, DirectoryFilter{OrganizationID: &other, CallerID: seed.ID("user:admin"), Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+	// nor is everyone listed for a caller who isn't a user
+	entries, err = repo.GetDirectory(ctx, DirectoryFilter{CallerID: uuid.New(), Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestRedisScriptParity(t *testing.T) {
+	t.Run("increment with expiry", func(t *testing.T) {
+		keys := []string{"failures"}
+		testdb.ScriptParity(t, incrementWithExpiryScript, nil,
+			testdb.ScriptCall{Keys: keys, Args: []interface{}{int64(60_000)}},
+			testdb.ScriptCall{Keys: keys, Args: []interface{}{int64(60_000)}},
+		)
+	})
+	t.Run("consume magic link", func(t *testing.T) {
+		keys := []string{"link"}
+		testdb.ScriptParity(t, consumeMagicLinkScript, map[string]string{"link": uuid.NewString()},
+			testdb.ScriptCall{Keys: keys},
+			testdb.ScriptCall{Keys: keys},
+		)
+	})
+	t.Run("check contact otp", func(t *testing.T) {
+		keys := []string{"otp", "otp-attempts"}
+		testdb.ScriptParity(t, checkContactOTPScript, map[string]string{"otp": "123456"},
+			testdb.ScriptCall{Keys: keys, Args: []interface{}{"000000", 3, 60_000}},
+			testdb.ScriptCall{Keys: keys, Args: []interface{}{"123456", 3, 60_000}},
+			testdb.ScriptCall{Keys: keys, Args: []interface{}{"111111", 3, 60_000}},
+			// the third wrong code drops the otp and its attempts
+			testdb.ScriptCall{Keys: keys, Args: []interface{}{"222222", 3, 60_000}},
+			testdb.ScriptCall{Keys: keys, Args: []interface{}{"123456", 3, 60_000}},
+		)
+	})
+}
+
+// a new employee's kit comes from their own organization's assets and only the managers of that
+// organization hear about them
+func TestOnboardingOtherOrganization(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewUserRepository(db, logger, nil, testdb.Redis(t), nil)
+	ctx := context.Background()
+	admin, intern := seed.ID("user:admin"), seed.ID("user:intern")
+
+	var other uuid.UUID
+	require.NoError(t, db.GetContext(ctx, &other, `
+		INSERT INTO organizations (name, slug) VALUES ('Other', 'other') RETURNING id
+	`))
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO assets (brand, model, serial_no, type, status, organization_id) VALUES
+			('SanDisk', 'Ultra', 'KIT-OWN', 'pen_drive', 'available', $1),
+			('SanDisk', 'Ultra', 'KIT-OTHER', 'pen_drive', 'available', $2)`, models.DefaultOrganizationID, other)
+	require.NoError(t, err)
+	var theirs uuid.UUID
+	require.NoError(t, db.GetContext(ctx, &theirs, `SELECT id FROM assets WHERE serial_no = 'KIT-OTHER'`))
+	_, err = db.ExecContext(ctx, `UPDATE users SET organization_id = $1, department_id = NULL WHERE id IN ($2, $3)`, other, admin, intern)
+	require.NoError(t, err)
+
+	assigned, err := repo.AssignKitAssets(ctx, intern, models.OnboardingKitItem{AssetType: "pen_drive", Quantity: 5}, nil, admin)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{theirs}, assigned)
+
+	engineering := seed.ID("department:engineering")
+	managers, err := repo.GetDepartmentManagerIDs(ctx, models.DefaultOrganizationID, &engineering)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{seed.ID("user:asset-manager"), seed.ID("user:employee-manager")}, managers)
+
+	managers, err = repo.GetDepartmentManagerIDs(ctx, other, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{admin}, managers)
+}
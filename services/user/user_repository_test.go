@@ -2,9 +2,11 @@ package userservice
 
 import (
 	"asset/providers"
+	"asset/utils"
 	"context"
 	"database/sql"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -25,10 +27,12 @@ func TestGetUserByEmail(t *testing.T) {
 	ctx := context.Background()
 	email := "test.user@remotestate.com"
 	userID := uuid.MustParse("5f62831e-44c5-46c4-bede-0d5e3253cc16")
+	notFoundKey := "user:GetUserByEmail:notfound:" + email
 
 	tests := []struct {
 		name           string
 		mockSetup      func(mock sqlmock.Sqlmock)
+		redisSetup     func(redis *providers.MockRedisProvider)
 		inputEmail     string
 		expectedUserID uuid.UUID
 		expectedErr    error
@@ -38,10 +42,13 @@ func TestGetUserByEmail(t *testing.T) {
 			inputEmail: email,
 			mockSetup: func(mock sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{"id"}).AddRow(userID)
-				mock.ExpectQuery(`SELECT id FROM users WHERE email = \$1 AND archived_at IS NULL$`).
+				mock.ExpectQuery(`SELECT id FROM users WHERE email = \$1 AND archived_at IS NULL AND auth_provider <> 'service_account'$`).
 					WithArgs(email).
 					WillReturnRows(rows)
 			},
+			redisSetup: func(redis *providers.MockRedisProvider) {
+				redis.EXPECT().Get(ctx, notFoundKey).Return("", errors.New("redis: nil"))
+			},
 			expectedUserID: userID,
 			expectedErr:    nil,
 		},
@@ -49,10 +56,24 @@ func TestGetUserByEmail(t *testing.T) {
 			name:       "user not found",
 			inputEmail: email,
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery(`SELECT id FROM users WHERE email = \$1 AND archived_at IS NULL$`).
+				mock.ExpectQuery(`SELECT id FROM users WHERE email = \$1 AND archived_at IS NULL AND auth_provider <> 'service_account'$`).
 					WithArgs(email).
 					WillReturnError(sql.ErrNoRows)
 			},
+			redisSetup: func(redis *providers.MockRedisProvider) {
+				redis.EXPECT().Get(ctx, notFoundKey).Return("", errors.New("redis: nil"))
+				redis.EXPECT().Set(ctx, notFoundKey, "1", time.Minute).Return(nil)
+			},
+			expectedUserID: uuid.Nil,
+			expectedErr:    sql.ErrNoRows,
+		},
+		{
+			name:       "cached miss skips the query",
+			inputEmail: email,
+			mockSetup:  func(mock sqlmock.Sqlmock) {},
+			redisSetup: func(redis *providers.MockRedisProvider) {
+				redis.EXPECT().Get(ctx, notFoundKey).Return("1", nil)
+			},
 			expectedUserID: uuid.Nil,
 			expectedErr:    sql.ErrNoRows,
 		},
@@ -60,10 +81,13 @@ func TestGetUserByEmail(t *testing.T) {
 			name:       "query error",
 			inputEmail: email,
 			mockSetup: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery(`SELECT id FROM users WHERE email = \$1 AND archived_at IS NULL$`).
+				mock.ExpectQuery(`SELECT id FROM users WHERE email = \$1 AND archived_at IS NULL AND auth_provider <> 'service_account'$`).
 					WithArgs(email).
 					WillReturnError(errors.New("db error"))
 			},
+			redisSetup: func(redis *providers.MockRedisProvider) {
+				redis.EXPECT().Get(ctx, notFoundKey).Return("", errors.New("redis: nil"))
+			},
 			expectedUserID: uuid.Nil,
 			expectedErr:    errors.New("db error"),
 		},
@@ -80,9 +104,13 @@ func TestGetUserByEmail(t *testing.T) {
 			mockLogger := providers.NewMockZapLoggerProvider(ctrl)
 			mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
 
+			mockRedis := providers.NewMockRedisProvider(ctrl)
+			tc.redisSetup(mockRedis)
+
 			repo := &PostgresUserRepository{
 				DB:     sqlxDB,
 				Logger: mockLogger,
+				Redis:  mockRedis,
 			}
 
 			tc.mockSetup(mock)
@@ -172,24 +200,17 @@ func TestGetUserDashboardById(t *testing.T) {
 
 		mockRedis := providers.NewMockRedisProvider(ctrl)
 		mockRedis.EXPECT().Get(ctx, "user:dashboard:"+userID.String()).Return("", errors.New("user not found"))
-		mockRedis.EXPECT().Set(ctx, "user:dashboard:"+userID.String(), gomock.Any(), 5*time.Minute).Return(nil)
-
-		rowsUser := sqlmock.NewRows([]string{"id", "username", "email", "contact_no", "type"}).
-			AddRow(userID.String(), expectedUser.Username, expectedUser.Email, contactNo, userType)
+		mockRedis.EXPECT().SetMany(gomock.Any(),
+			gomock.Any(),
+			providers.RedisEntry{Key: "user:GetEmailByUserID:" + userID.String(), Value: expectedUser.Email, Expiration: 5 * time.Minute},
+		).Return(nil)
 
-		rowsRoles := sqlmock.NewRows([]string{"role"}).AddRow("employee")
+		assetsJSON, _ := jsoniter.Marshal(expectedUser.AssignedAssets)
+		rowsUser := sqlmock.NewRows([]string{"id", "username", "email", "contact_no", "type", "roles", "assigned_assets"}).
+			AddRow(userID.String(), expectedUser.Username, expectedUser.Email, contactNo, userType, "{employee}", assetsJSON)
 
-		rowsAssets := sqlmock.NewRows([]string{"id", "brand", "model", "serial_no", "type", "status", "owned_by"}).
-			AddRow(expectedUser.AssignedAssets[0].ID, "Lenovo", "Thinkpad", "LN1234567", "Laptop", "assigned", "company")
-
-		mock.ExpectBegin()
-		mock.ExpectQuery(`SELECT u.id, u.username, u.email, u.contact_no, ut.type`).
+		mock.ExpectQuery(`SELECT u.id, u.username, u.email, u.contact_no, s.type`).
 			WithArgs(userID).WillReturnRows(rowsUser)
-		mock.ExpectQuery(`SELECT role FROM user_roles`).
-			WithArgs(userID).WillReturnRows(rowsRoles)
-		mock.ExpectQuery(`SELECT a.id, a.brand, a.model, a.serial_no, a.type, a.status, a.owned_by`).
-			WithArgs(userID).WillReturnRows(rowsAssets)
-		mock.ExpectCommit()
 
 		repo := &PostgresUserRepository{
 			DB:     sqlxDB,
@@ -216,11 +237,62 @@ func TestGetUserDashboardById(t *testing.T) {
 			t.Errorf("there were unfulfilled expectations: %s", err)
 		}
 	})
+
+	t.Run("concurrent misses share one rebuild", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		sqlxDB := sqlx.NewDb(db, "postgres")
+
+		mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+		mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+		const callers = 20
+		var arrived sync.WaitGroup
+		arrived.Add(callers)
+		mockRedis := providers.NewMockRedisProvider(ctrl)
+		mockRedis.EXPECT().Get(ctx, "user:dashboard:"+userID.String()).DoAndReturn(func(context.Context, string) (string, error) {
+			// every caller misses before the rebuild starts
+			arrived.Done()
+			arrived.Wait()
+			return "", errors.New("redis: nil")
+		}).Times(callers)
+		mockRedis.EXPECT().SetMany(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+		assetsJSON, _ := jsoniter.Marshal(expectedUser.AssignedAssets)
+		mock.ExpectQuery(`SELECT u.id, u.username, u.email, u.contact_no, s.type`).
+			WithArgs(userID).
+			WillDelayFor(50 * time.Millisecond).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "contact_no", "type", "roles", "assigned_assets"}).
+				AddRow(userID.String(), expectedUser.Username, expectedUser.Email, contactNo, userType, "{employee}", assetsJSON))
+
+		repo := &PostgresUserRepository{
+			DB:     sqlxDB,
+			Logger: mockLogger,
+			Redis:  mockRedis,
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < callers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				result, err := repo.GetUserDashboardById(ctx, userID)
+				assert.NoError(t, err)
+				assert.Equal(t, expectedUser.Username, result.Username)
+			}()
+		}
+		wg.Wait()
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
 
 func TestDeleteUserByID(t *testing.T) {
 	ctx := context.Background()
-	userID := uuid.New()
+	userID, adminID := uuid.New(), uuid.New()
 
 	t.Run("successfully deletes user", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
@@ -240,12 +312,12 @@ func TestDeleteUserByID(t *testing.T) {
 			WithArgs(userID).
 			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
 
-		mock.ExpectExec(`UPDATE users SET archived_at = now()`).
-			WithArgs(userID).
+		mock.ExpectExec(`UPDATE users SET archived_at = now\(\), archived_by = \$2`).
+			WithArgs(userID, &adminID).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
-		mock.ExpectExec(`UPDATE user_roles SET archived_at = now()`).
-			WithArgs(userID).
+		mock.ExpectExec(`UPDATE user_roles SET archived_at = now\(\), archived_by = \$2`).
+			WithArgs(userID, &adminID).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 
 		mock.ExpectExec(`UPDATE user_type SET archived_at = now()`).
@@ -259,7 +331,7 @@ func TestDeleteUserByID(t *testing.T) {
 			Logger: mockLogger,
 		}
 
-		err = repo.DeleteUserByID(ctx, userID)
+		err = repo.DeleteUserByID(ctx, userID, &adminID)
 		assert.NoError(t, err)
 		if err := mock.ExpectationsWereMet(); err != nil {
 			t.Errorf("there were unfulfilled expectations: %s", err)
@@ -291,7 +363,7 @@ func TestDeleteUserByID(t *testing.T) {
 			Logger: mockLogger,
 		}
 
-		err = repo.DeleteUserByID(ctx, userID)
+		err = repo.DeleteUserByID(ctx, userID, &adminID)
 		assert.EqualError(t, err, "cannot delete user, still have asset assigned")
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
@@ -321,7 +393,7 @@ func TestDeleteUserByID(t *testing.T) {
 			Logger: mockLogger,
 		}
 
-		err = repo.DeleteUserByID(ctx, userID)
+		err = repo.DeleteUserByID(ctx, userID, &adminID)
 		assert.Contains(t, err.Error(), "failed to check asset assignment")
 		assert.NoError(t, mock.ExpectationsWereMet())
 	})
@@ -345,8 +417,8 @@ func TestDeleteUserByID(t *testing.T) {
 			DB:     sqlxDB,
 			Logger: mockLogger,
 		}
-		err = repo.DeleteUserByID(ctx, userID)
-		assert.EqualError(t, err, "failed to start transaction: begin tx failed")
+		err = repo.DeleteUserByID(ctx, userID, &adminID)
+		assert.EqualError(t, err, "failed to begin transaction: begin tx failed")
 
 		if err := mock.ExpectationsWereMet(); err != nil {
 			t.Errorf("there were unfulfilled expectations: %s", err)
@@ -376,8 +448,9 @@ func TestCreateNewEmployee(t *testing.T) {
 			name: "successfully creates new employee ",
 			mockSetup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				mock.ExpectQuery(`INSERT INTO users \(username, email, contact_no, created_by\)`).
-					WithArgs(req.Username, req.Email, req.ContactNo, managerID).
+				mock.ExpectQuery(`INSERT INTO users \(\s+username, email, contact_no, created_by, department_id, end_date,\s+designation, location, date_of_joining, emergency_contact_name, emergency_contact_no\s+\)`).
+					WithArgs(req.Username, req.Email, req.ContactNo, managerID, req.DepartmentID, req.EndDate,
+						req.Designation, req.Location, req.DateOfJoining, req.EmergencyContactName, req.EmergencyContactNo).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(newUserID))
 
 				mock.ExpectExec(`INSERT INTO user_type \(user_id, type, created_by\)`).
@@ -395,8 +468,9 @@ func TestCreateNewEmployee(t *testing.T) {
 			name: "failed, error inserting user into users table",
 			mockSetup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				mock.ExpectQuery(`INSERT INTO users \(username, email, contact_no, created_by\)`).
-					WithArgs(req.Username, req.Email, req.ContactNo, managerID).
+				mock.ExpectQuery(`INSERT INTO users \(\s+username, email, contact_no, created_by, department_id, end_date,\s+designation, location, date_of_joining, emergency_contact_name, emergency_contact_no\s+\)`).
+					WithArgs(req.Username, req.Email, req.ContactNo, managerID, req.DepartmentID, req.EndDate,
+						req.Designation, req.Location, req.DateOfJoining, req.EmergencyContactName, req.EmergencyContactNo).
 					WillReturnError(errors.New("insert error"))
 				mock.ExpectRollback()
 			},
@@ -406,8 +480,9 @@ func TestCreateNewEmployee(t *testing.T) {
 			name: "failed, error inserting into user_type",
 			mockSetup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				mock.ExpectQuery(`INSERT INTO users \(username, email, contact_no, created_by\)`).
-					WithArgs(req.Username, req.Email, req.ContactNo, managerID).
+				mock.ExpectQuery(`INSERT INTO users \(\s+username, email, contact_no, created_by, department_id, end_date,\s+designation, location, date_of_joining, emergency_contact_name, emergency_contact_no\s+\)`).
+					WithArgs(req.Username, req.Email, req.ContactNo, managerID, req.DepartmentID, req.EndDate,
+						req.Designation, req.Location, req.DateOfJoining, req.EmergencyContactName, req.EmergencyContactNo).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(newUserID))
 
 				mock.ExpectExec(`INSERT INTO user_type \(user_id, type, created_by\)`).
@@ -421,8 +496,9 @@ func TestCreateNewEmployee(t *testing.T) {
 			name: "failed, error inserting into user_roles",
 			mockSetup: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
-				mock.ExpectQuery(`INSERT INTO users \(username, email, contact_no, created_by\)`).
-					WithArgs(req.Username, req.Email, req.ContactNo, managerID).
+				mock.ExpectQuery(`INSERT INTO users \(\s+username, email, contact_no, created_by, department_id, end_date,\s+designation, location, date_of_joining, emergency_contact_name, emergency_contact_no\s+\)`).
+					WithArgs(req.Username, req.Email, req.ContactNo, managerID, req.DepartmentID, req.EndDate,
+						req.Designation, req.Location, req.DateOfJoining, req.EmergencyContactName, req.EmergencyContactNo).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(newUserID))
 
 				mock.ExpectExec(`INSERT INTO user_type \(user_id, type, created_by\)`).
@@ -461,7 +537,7 @@ func TestCreateNewEmployee(t *testing.T) {
 				Logger: mockLogger,
 			}
 
-			id, err := repo.CreateNewEmployee(ctx, tx, req, managerID)
+			id, err := repo.CreateNewEmployee(utils.ContextWithTx(ctx, tx), req, managerID)
 
 			if tc.expectedErrContains != "" {
 				assert.Error(t, err)
@@ -542,7 +618,7 @@ func TestInsertUserRole(t *testing.T) {
 				Logger: mockLogger,
 			}
 
-			err = repo.InsertUserRole(ctx, tx, userID, role, createdBy)
+			err = repo.InsertUserRole(utils.ContextWithTx(ctx, tx), userID, role, createdBy)
 
 			if tc.expectSuccess {
 				assert.NoError(t, err)
@@ -1,128 +1,910 @@
 package userservice
 
 import (
-	"asset/middlewares"
+	"asset/models"
 	"asset/providers"
+	"asset/providers/middlewareprovider"
+	"asset/services/audit"
+	"asset/services/event"
+	"asset/services/loginactivity"
+	"asset/services/notification"
+	"asset/utils"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	firebaseauth "firebase.google.com/go/v4/auth"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"go.uber.org/zap"
 )
 
 type UserService interface {
-	ChangeUserRole(ctx context.Context, req UpdateUserRoleReq, adminID uuid.UUID) error
-	DeleteUser(ctx context.Context, userID uuid.UUID, managerRole string) error
+	ChangeUserRole(ctx context.Context, req UpdateUserRoleReq, adminID uuid.UUID, scope models.DepartmentScope) (ChangeUserRoleRes, error)
+	GetRoleGrantApprovals(ctx context.Context, status string, limit, offset int, scope models.DepartmentScope) ([]RoleGrantApprovalRes, error)
+	ApproveRoleGrant(ctx context.Context, id uuid.UUID, decider RoleGrantDecider, note string) error
+	RejectRoleGrant(ctx context.Context, id uuid.UUID, decider RoleGrantDecider, note string) error
+	DeleteUser(ctx context.Context, userID, actorID uuid.UUID, privileged bool, scope models.DepartmentScope) error
 	GetEmployeesWithFilters(ctx context.Context, filter EmployeeFilter) ([]EmployeeResponseModel, error)
-	GetEmployeeTimeline(ctx context.Context, userID uuid.UUID) ([]UserTimelineRes, error)
+	GetDirectory(ctx context.Context, filter DirectoryFilter) ([]DirectoryEntry, error)
+	ExportEmployees(ctx context.Context, filter EmployeeFilter) ([][]string, error)
+	StreamEmployees(ctx context.Context, filter EmployeeFilter, fn func(EmployeeExportRow) error) error
+	GetEmployeeTimeline(ctx context.Context, userID uuid.UUID, limit, offset int, scope models.DepartmentScope) ([]UserTimelineRes, error)
+	GetRoleHistory(ctx context.Context, userID uuid.UUID, scope models.DepartmentScope) ([]RoleHistoryRes, error)
+	ScheduleRoleChange(ctx context.Context, req ScheduleRoleChangeReq, adminID uuid.UUID, scope models.DepartmentScope) (uuid.UUID, error)
+	GetScheduledRoleChanges(ctx context.Context, userID *uuid.UUID, limit, offset int, scope models.DepartmentScope) ([]ScheduledRoleChangeRes, error)
+	CancelScheduledRoleChange(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) error
+	ApplyScheduledRoleChanges(ctx context.Context) error
+	ReconcileFirebaseClaims(ctx context.Context) error
+	ProcessEmployeeEndDates(ctx context.Context, warningDays int) error
 	PublicRegister(ctx context.Context, req PublicUserReq) (uuid.UUID, string, error)
-	RegisterEmployeeByManager(ctx context.Context, req ManagerRegisterReq, managerID uuid.UUID) (uuid.UUID, error)
-	UpdateEmployee(ctx context.Context, req UpdateEmployeeReq, managerID uuid.UUID) error
+	RegisterEmployeeByManager(ctx context.Context, req ManagerRegisterReq, managerID uuid.UUID, scope models.DepartmentScope) (uuid.UUID, *models.OnboardingRes, error)
+	InviteEmployee(ctx context.Context, req InviteEmployeeReq, managerID uuid.UUID, scope models.DepartmentScope) (InviteRes, error)
+	AcceptInvite(ctx context.Context, req AcceptInviteReq) (uuid.UUID, *models.OnboardingRes, error)
+	SendContactOTP(ctx context.Context, req ContactOTPReq) error
+	ConfirmEmail(ctx context.Context, req ConfirmEmailReq) error
+	ResendVerification(ctx context.Context, req PublicUserReq) error
+	RequestEmailChange(ctx context.Context, userID uuid.UUID, newEmail string, requestedBy uuid.UUID, scope models.DepartmentScope) (EmailChangeRes, error)
+	ConfirmEmailChange(ctx context.Context, req ConfirmEmailReq) error
+	UpdateEmployee(ctx context.Context, req UpdateEmployeeReq, managerID uuid.UUID, scope models.DepartmentScope) (EmployeeRes, error)
 	GetDashboard(ctx context.Context, userID uuid.UUID) (UserDashboardRes, error)
-	UserLogin(ctx context.Context, req PublicUserReq) (uuid.UUID, string, string, error)
-	GoogleAuth(ctx context.Context, idToken string) (uuid.UUID, string, string, error)
-	CreateFirstAdmin() bool
+	GetDashboardSummary(ctx context.Context, userID uuid.UUID) (DashboardSummaryRes, error)
+	WarmDashboardCache(ctx context.Context) error
+	UserLogin(ctx context.Context, req PublicUserReq, client models.ClientInfo) (LoginRes, error)
+	OIDCLogin(ctx context.Context, provider, idToken string, client models.ClientInfo) (LoginRes, error)
+	RequestMagicLink(ctx context.Context, req PublicUserReq) error
+	MagicLinkLogin(ctx context.Context, req MagicLinkLoginReq, client models.ClientInfo) (LoginRes, error)
+	VerifyMFAChallenge(ctx context.Context, req MFAChallengeReq, client models.ClientInfo) (LoginRes, error)
+	RefreshTokens(ctx context.Context, req RefreshTokenReq, client models.ClientInfo) (RefreshTokenRes, error)
+	Logout(ctx context.Context, userID uuid.UUID, req LogoutReq, client models.ClientInfo) error
+	EnrollMFAForChallenge(ctx context.Context, req MFATokenReq) (MFAEnrollmentRes, error)
+	EnrollMFA(ctx context.Context, userID uuid.UUID) (MFAEnrollmentRes, error)
+	ActivateMFA(ctx context.Context, userID uuid.UUID, code string) error
+	SetTimezone(ctx context.Context, userID uuid.UUID, timezone string) (TimezoneRes, error)
+	DisableMFA(ctx context.Context, userID uuid.UUID, code string) error
+	RegenerateBackupCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	ResetMFA(ctx context.Context, userID uuid.UUID) error
+	ForceLogout(ctx context.Context, adminID, userID uuid.UUID, reason string) error
+	UnlockLogin(ctx context.Context, adminID uuid.UUID, req UnlockLoginReq) error
+	SyncDirectory(ctx context.Context, dryRun bool, triggeredBy *uuid.UUID) (DirectorySyncReport, error)
+	GetDirectorySyncReports(ctx context.Context, limit, offset int) ([]DirectorySyncReport, error)
+	CreateAdmin(ctx context.Context, req CreateAdminReq) (uuid.UUID, error)
+	ResetUserRoles(ctx context.Context, userID uuid.UUID, role string) error
+	GetUserIDByEmail(ctx context.Context, email string) (uuid.UUID, error)
 	FirebaseUserRegistration(ctx context.Context, idToken string) (*FirebaseRegistrationResponse, error)
 }
 
+var (
+	// ErrEndDateNotAllowed is returned when an end date is set on a full time employee
+	ErrEndDateNotAllowed     = models.NewServiceError(http.StatusBadRequest, "end_date_not_allowed", "end_date is only allowed for interns and freelancers")
+	ErrTemplateNotFound      = models.NewServiceError(http.StatusBadRequest, "onboarding_template_not_found", "onboarding template not found")
+	ErrTemplateTypeMismatch  = models.NewServiceError(http.StatusBadRequest, "onboarding_template_type_mismatch", "onboarding template is for a different employee type")
+	ErrUserAlreadyExists     = models.NewServiceError(http.StatusConflict, "user_already_exists", "user already exists")
+	ErrInviteInvalid         = models.NewServiceError(http.StatusBadRequest, "invite_invalid", "invite is invalid or has already been used")
+	ErrInviteExpired         = models.NewServiceError(http.StatusGone, "invite_expired", "invite has expired")
+	ErrEmailNotVerified      = models.NewServiceError(http.StatusForbidden, "email_not_verified", "email address is not verified")
+	ErrVerificationInvalid   = models.NewServiceError(http.StatusBadRequest, "verification_invalid", "verification link is invalid")
+	ErrVerificationExpired   = models.NewServiceError(http.StatusGone, "verification_expired", "verification link has expired")
+	ErrEmailChangeViaUpdate  = models.NewServiceError(http.StatusBadRequest, "email_change_via_update", "email can't be changed here, use the change email flow")
+	ErrEmailUnchanged        = models.NewServiceError(http.StatusBadRequest, "email_unchanged", "new email is the same as the current one")
+	ErrEmailDomainNotAllowed = models.NewServiceError(http.StatusForbidden, "email_domain_not_allowed", "email domain is not allowed to register")
+	ErrInvalidPhoneNumber    = models.NewServiceError(http.StatusBadRequest, "invalid_phone_number", "invalid phone number")
+	ErrUpdateFieldConflict   = models.NewServiceError(http.StatusBadRequest, "update_field_conflict", "a field can't be both set and cleared")
+	ErrMFAAlreadyEnabled     = models.NewServiceError(http.StatusConflict, "mfa_already_enabled", "mfa is already enabled")
+	ErrMFANotEnabled         = models.NewServiceError(http.StatusConflict, "mfa_not_enabled", "mfa is not enabled")
+	ErrMFANotEnrolled        = models.NewServiceError(http.StatusBadRequest, "mfa_not_enrolled", "mfa enrollment has not been started")
+	ErrMFAInvalidCode        = models.NewServiceError(http.StatusUnauthorized, "mfa_invalid_code", "invalid mfa code")
+	ErrMFAChallengeInvalid   = models.NewServiceError(http.StatusUnauthorized, "mfa_challenge_invalid", "mfa challenge is invalid or has expired")
+	ErrMFARequiredByRole     = models.NewServiceError(http.StatusForbidden, "mfa_required", "mfa is mandatory for your role")
+	ErrMFATooManyAttempts    = models.NewServiceError(http.StatusTooManyRequests, "mfa_too_many_attempts", "too many invalid mfa codes, try again later")
+	ErrUserNotFound          = models.NewServiceError(http.StatusNotFound, "user_not_found", "user not found")
+	ErrOIDCProviderUnknown   = models.NewServiceError(http.StatusBadRequest, "unknown_login_provider", "unknown login provider")
+	ErrDirectoryNotEnabled   = models.NewServiceError(http.StatusNotFound, "directory_sync_not_configured", "directory sync is not configured")
+	ErrDirectorySyncRunning  = models.NewServiceError(http.StatusConflict, "directory_sync_running", "a directory sync is already running")
+	ErrLoginLocked           = models.NewServiceError(http.StatusTooManyRequests, "login_locked", "too many failed logins, try again later")
+	ErrSessionExpired        = models.NewServiceError(http.StatusUnauthorized, "session_expired", "session has expired, sign in again")
+	ErrRefreshTokenMismatch  = models.NewServiceError(http.StatusBadRequest, "refresh_token_mismatch", "refresh token belongs to another user")
+	ErrRoleGrantPending      = models.NewServiceError(http.StatusConflict, "role_grant_pending", "an approval for this role is already pending")
+	ErrRoleGrantNotFound     = models.NewServiceError(http.StatusNotFound, "role_grant_not_found", "role grant approval not found")
+	ErrRoleGrantDecided      = models.NewServiceError(http.StatusConflict, "role_grant_decided", "role grant approval has already been decided")
+	ErrRoleGrantSelfApproval = models.NewServiceError(http.StatusForbidden, "role_grant_self_approval", "a role grant must be approved by an admin other than the requester or the user receiving it")
+	ErrRoleGrantNotScheduled = models.NewServiceError(http.StatusBadRequest, "role_grant_not_schedulable", "privileged roles need a second admin's approval and can't be scheduled")
+	ErrRoleGrantApprover     = models.NewServiceError(http.StatusForbidden, "role_grant_needs_platform_admin", "privileged role grants are approved by an admin holding organization.manage")
+	ErrMagicLinkInvalid      = models.NewServiceError(http.StatusUnauthorized, "magic_link_invalid", "sign-in link is invalid or has already been used")
+	ErrMagicLinkExpired      = models.NewServiceError(http.StatusGone, "magic_link_expired", "sign-in link has expired")
+	ErrPrivilegedTarget      = models.NewServiceError(http.StatusForbidden, "privileged_target", "admin and manager accounts are removed through the admin routes")
+	ErrUserHasAssets         = models.NewServiceError(http.StatusConflict, "user_has_assets", "cannot delete user, still have asset assigned")
+	ErrUnknownRole           = models.NewServiceError(http.StatusBadRequest, "unknown_role", "role does not exist")
+	ErrScheduledChangeGone   = models.NewServiceError(http.StatusNotFound, "scheduled_role_change_not_found", "no pending role change found")
+	ErrRoleAlreadyAssigned   = models.NewServiceError(http.StatusConflict, "role_already_assigned", "user already has this role")
+	ErrScheduleStartInPast   = models.NewServiceError(http.StatusBadRequest, "effective_from_in_past", "effective_from must be in the future")
+	ErrScheduleEndBeforeFrom = models.NewServiceError(http.StatusBadRequest, "effective_to_before_from", "effective_to must be after effective_from")
+	ErrInvalidEmailFormat    = models.NewServiceError(http.StatusBadRequest, "invalid_email_format", "invalid email format for username")
+	ErrInvalidLogin          = models.NewServiceError(http.StatusUnauthorized, "invalid_credentials", "invalid email")
+	ErrInvalidFirebaseToken  = models.NewServiceError(http.StatusUnauthorized, "invalid_firebase_token", "invalid firebase token")
+	ErrMissingProfileClaims  = models.NewServiceError(http.StatusBadRequest, "missing_profile_claims", "cannot register without email or display name")
+	ErrEmailChangeStale      = models.NewServiceError(http.StatusConflict, "email_change_stale", "user email changed since the request was made")
+	ErrDirectoryEmpty        = models.NewServiceError(http.StatusConflict, "directory_empty", "directory returned no active users, refusing to archive every synced user")
+	ErrContactOTPOff         = models.NewServiceError(http.StatusNotFound, "contact_otp_off", "contact numbers are not verified by text message")
+	ErrContactOTPRequired    = models.NewServiceError(http.StatusBadRequest, "contact_otp_required", "a code texted to the contact number is required")
+	ErrContactOTPInvalid     = models.NewServiceError(http.StatusBadRequest, "contact_otp_invalid", "code is wrong or has expired")
+	ErrInvalidTimezone       = models.NewServiceError(http.StatusBadRequest, "invalid_timezone", "timezone must be an IANA name like Europe/Berlin")
+	ErrBadgeInUse            = models.NewServiceError(http.StatusConflict, "badge_in_use", "badge id is already given to another employee")
+)
+
+// LoginLockedError tells the caller when to retry, it matches ErrLoginLocked
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginLockedError) Error() string {
+	return ErrLoginLocked.Error()
+}
+
+func (e *LoginLockedError) Unwrap() error {
+	return ErrLoginLocked
+}
+
 type userServiceStruct struct {
 	repo           UserRepository
 	db             *sqlx.DB
 	logger         providers.ZapLoggerProvider
 	firebase       providers.FirebaseProvider
 	AuthMiddleware providers.AuthMiddlewareService
+	notifier       notificationservice.NotificationService
+	mailer         providers.EmailProvider
+	config         providers.ConfigProvider
+	audit          auditservice.AuditService
+	events         eventservice.EventService
+	oidc           map[string]providers.OIDCProvider
+	// nil when no directory is configured
+	directory providers.DirectoryProvider
+	// nil when SMS_PROVIDER is unset, contact numbers are then taken as typed
+	sms providers.SMSProvider
+	// compares completed logins to earlier ones, nil records nothing
+	logins loginactivityservice.LoginActivityService
+	syncMu sync.Mutex
 }
 
-func NewUserService(repo UserRepository, db *sqlx.DB, logger providers.ZapLoggerProvider, firebase providers.FirebaseProvider, AuthMiddleware providers.AuthMiddlewareService) UserService {
-	return &userServiceStruct{repo: repo, db: db, logger: logger, firebase: firebase, AuthMiddleware: AuthMiddleware}
+func NewUserService(repo UserRepository, db *sqlx.DB, logger providers.ZapLoggerProvider, firebase providers.FirebaseProvider, AuthMiddleware providers.AuthMiddlewareService, notifier notificationservice.NotificationService, mailer providers.EmailProvider, config providers.ConfigProvider, audit auditservice.AuditService, events eventservice.EventService, oidcProviders []providers.OIDCProvider, directory providers.DirectoryProvider, sms providers.SMSProvider, logins loginactivityservice.LoginActivityService) UserService {
+	oidc := make(map[string]providers.OIDCProvider, len(oidcProviders))
+	for _, provider := range oidcProviders {
+		oidc[provider.Name()] = provider
+	}
+	return &userServiceStruct{repo: repo, db: db, logger: logger, firebase: firebase, AuthMiddleware: AuthMiddleware, notifier: notifier, mailer: mailer, config: config, audit: audit, events: events, oidc: oidc, directory: directory, sms: sms, logins: logins}
+}
+
+// checkUserScope returns models.ErrOutOfScope when the user is outside the caller's department or organization
+func (s *userServiceStruct) checkUserScope(ctx context.Context, userID uuid.UUID, scope models.DepartmentScope) error {
+	if scope.Unrestricted() {
+		return nil
+	}
+	inScope, err := s.repo.IsUserInScope(ctx, userID, scope)
+	if err != nil {
+		s.logger.GetLogger().Error("failed to check user department scope", zap.String("userID", userID.String()), zap.Error(err))
+		return err
+	}
+	if !inScope {
+		s.logger.GetLogger().Warn("user is outside the caller's department", zap.String("userID", userID.String()))
+		return models.ErrOutOfScope
+	}
+	return nil
 }
 
-func (s *userServiceStruct) ChangeUserRole(ctx context.Context, req UpdateUserRoleReq, adminID uuid.UUID) error {
+func (s *userServiceStruct) ChangeUserRole(ctx context.Context, req UpdateUserRoleReq, adminID uuid.UUID, scope models.DepartmentScope) (ChangeUserRoleRes, error) {
 	s.logger.GetLogger().Info("change user role", zap.String("targetUserID", req.UserID), zap.String("newRole", req.Role), zap.String("adminID", adminID.String()))
-	tx, err := s.db.BeginTxx(ctx, nil)
+	exists, err := s.repo.IsRoleExists(ctx, req.Role)
+	if err != nil {
+		return ChangeUserRoleRes{}, err
+	}
+	if !exists {
+		s.logger.GetLogger().Warn("requested role does not exist", zap.String("role", req.Role))
+		return ChangeUserRoleRes{}, fmt.Errorf("role does not exist: %s", req.Role)
+	}
+	userUUID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		s.logger.GetLogger().Error("failed to parse userID in ChangeUserRole", zap.String("userID", req.UserID), zap.Error(err))
+		return ChangeUserRoleRes{}, err
+	}
+	if err := s.checkUserScope(ctx, userUUID, scope); err != nil {
+		return ChangeUserRoleRes{}, err
+	}
+
+	// nobody gets a privileged role on the word of a single admin
+	privileged, err := s.isPrivilegedRole(ctx, req.Role)
+	if err != nil {
+		return ChangeUserRoleRes{}, err
+	}
+	if privileged {
+		approvalID, err := s.requestRoleGrant(ctx, userUUID, req.Role, adminID)
+		if err != nil {
+			return ChangeUserRoleRes{}, err
+		}
+		return ChangeUserRoleRes{Status: RoleChangePendingApproval, ApprovalID: &approvalID}, nil
+	}
+	if err := s.changeUserRole(ctx, userUUID, req.Role, adminID); err != nil {
+		return ChangeUserRoleRes{}, err
+	}
+	return ChangeUserRoleRes{Status: RoleChangeApplied}, nil
+}
+
+// isPrivilegedRole reports whether the role holds any of models.PrivilegedPermissions, it goes by
+// what the role can do rather than its name so a custom role with role.manage counts too
+func (s *userServiceStruct) isPrivilegedRole(ctx context.Context, role string) (bool, error) {
+	privileged, err := s.AuthMiddleware.HasPermission(ctx, []string{role}, models.PrivilegedPermissions...)
 	if err != nil {
-		s.logger.GetLogger().Error("failed to begin transaction for ChangeUserRole", zap.Error(err))
+		s.logger.GetLogger().Error("failed to read the role's permissions", zap.String("role", role), zap.Error(err))
+		return false, err
+	}
+	return privileged, nil
+}
+
+func (s *userServiceStruct) changeUserRole(ctx context.Context, userID uuid.UUID, role string, adminID uuid.UUID) error {
+	if err := utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		err := s.repo.UpdateUserRole(ctx, userID, role, adminID)
+		if err != nil {
+			if errors.Is(err, ErrRoleAlreadyAssigned) {
+				s.logger.GetLogger().Warn("user already has the requested role", zap.String("userID", userID.String()), zap.String("role", role))
+				return ErrRoleAlreadyAssigned
+			}
+			s.logger.GetLogger().Error("Failed to update user role in repository", zap.String("userID", userID.String()), zap.Error(err))
+			return err
+		}
+		s.logger.GetLogger().Info("User role updated successfully", zap.String("userID", userID.String()), zap.String("newRole", role))
+		return s.emitRoleChanged(ctx, userID, &adminID, role, "admin")
+	}); err != nil {
 		return err
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			s.logger.GetLogger().Error("panic recovered during ChangeUserRole transaction", zap.Any("recover_info", r))
-			tx.Rollback()
-		} else if err != nil {
-			s.logger.GetLogger().Error("rolling back transaction for ChangeUserRole", zap.Error(err))
-			tx.Rollback()
-		} else {
-			if commitErr := tx.Commit(); commitErr != nil {
-				s.logger.GetLogger().Error("failed to commit transaction for ChangeUserRole", zap.Error(commitErr))
-			} else {
-				s.logger.GetLogger().Info("transaction committed successfully for ChangeUserRole")
+	s.roleChanged(ctx, userID)
+	return nil
+}
+
+// requestRoleGrant parks the grant in role_grant_approvals, the role only changes once another
+// admin approves it through ApproveRoleGrant
+func (s *userServiceStruct) requestRoleGrant(ctx context.Context, userID uuid.UUID, role string, adminID uuid.UUID) (id uuid.UUID, err error) {
+	pending, err := s.repo.HasPendingRoleGrant(ctx, userID, role)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if pending {
+		return uuid.Nil, ErrRoleGrantPending
+	}
+
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		currentRole, err := s.repo.GetCurrentUserRole(ctx, userID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if currentRole == role {
+			return ErrRoleAlreadyAssigned
+		}
+		if id, err = s.repo.InsertRoleGrantApproval(ctx, userID, role, currentRole, adminID); err != nil {
+			return err
+		}
+		s.logger.GetLogger().Info("role grant waiting for approval", zap.String("id", id.String()), zap.String("userID", userID.String()), zap.String("role", role))
+		err = s.audit.Record(ctx, auditservice.AuditEntry{
+			ActorID:    &adminID,
+			Action:     "user.role_grant_requested",
+			EntityType: "user",
+			EntityID:   userID.String(),
+			OldValue:   map[string]interface{}{"role": currentRole},
+			NewValue:   map[string]interface{}{"role": role, "approval_id": id},
+		})
+		if err != nil {
+			return err
+		}
+		return s.emit(ctx, eventservice.UserRoleGrantRequested, userID, &adminID, map[string]interface{}{"role": role, "approval_id": id, "requested_by": adminID})
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+func (s *userServiceStruct) GetRoleGrantApprovals(ctx context.Context, status string, limit, offset int, scope models.DepartmentScope) ([]RoleGrantApprovalRes, error) {
+	return s.repo.GetRoleGrantApprovals(ctx, status, limit, offset, scope)
+}
+
+// ApproveRoleGrant applies a pending grant. The approver has to be someone other than the admin
+// who asked for it and the user receiving it, and has to hold organization.manage so two org_admins
+// can't make each other admin
+func (s *userServiceStruct) ApproveRoleGrant(ctx context.Context, id uuid.UUID, decider RoleGrantDecider, note string) error {
+	adminID := decider.ID
+	platformAdmin, err := s.AuthMiddleware.HasPermission(ctx, decider.Roles, models.OrganizationManagePermission)
+	if err != nil {
+		return err
+	}
+	if !platformAdmin {
+		return ErrRoleGrantApprover
+	}
+	approval, err := s.decideRoleGrant(ctx, id, decider, RoleGrantApproved, note, func(ctx context.Context, approval RoleGrantApprovalRes) error {
+		err := s.repo.UpdateUserRole(ctx, approval.UserID, approval.Role, adminID)
+		if err != nil {
+			if errors.Is(err, ErrRoleAlreadyAssigned) {
+				return nil
+			}
+			return err
+		}
+		return s.emitRoleChanged(ctx, approval.UserID, &adminID, approval.Role, "role_grant_approval")
+	})
+	if err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("role grant approved", zap.String("id", id.String()), zap.String("userID", approval.UserID.String()), zap.String("approvedBy", adminID.String()))
+	s.roleChanged(ctx, approval.UserID)
+	return nil
+}
+
+// RejectRoleGrant drops a pending grant, the requesting admin may also withdraw their own request
+func (s *userServiceStruct) RejectRoleGrant(ctx context.Context, id uuid.UUID, decider RoleGrantDecider, note string) error {
+	adminID := decider.ID
+	approval, err := s.decideRoleGrant(ctx, id, decider, RoleGrantRejected, note, nil)
+	if err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("role grant rejected", zap.String("id", id.String()), zap.String("userID", approval.UserID.String()), zap.String("rejectedBy", adminID.String()))
+	return nil
+}
+
+// decideRoleGrant records the decision, runs apply for approvals and writes the audit entry in one transaction
+func (s *userServiceStruct) decideRoleGrant(ctx context.Context, id uuid.UUID, decider RoleGrantDecider, status, note string, apply func(ctx context.Context, approval RoleGrantApprovalRes) error) (approval RoleGrantApprovalRes, err error) {
+	adminID := decider.ID
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		var err error
+		approval, err = s.repo.GetRoleGrantApprovalForUpdate(ctx, id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrRoleGrantNotFound
+			}
+			return err
+		}
+		// grants for users of another organization look like missing ones
+		if err := s.checkUserScope(ctx, approval.UserID, decider.Scope); err != nil {
+			if errors.Is(err, models.ErrOutOfScope) {
+				return ErrRoleGrantNotFound
+			}
+			return err
+		}
+		if approval.Status != RoleGrantPending {
+			return ErrRoleGrantDecided
+		}
+		if status == RoleGrantApproved && (approval.RequestedBy == adminID || approval.UserID == adminID) {
+			return ErrRoleGrantSelfApproval
+		}
+
+		if err = s.repo.DecideRoleGrantApproval(ctx, id, status, adminID, note); err != nil {
+			return err
+		}
+		if apply != nil {
+			if err = apply(ctx, approval); err != nil {
+				return err
+			}
+		}
+		previousRole := ""
+		if approval.PreviousRole != nil {
+			previousRole = *approval.PreviousRole
+		}
+		decision := map[string]interface{}{
+			"role":         approval.Role,
+			"approval_id":  approval.ID,
+			"requested_by": approval.RequestedBy,
+			"note":         note,
+		}
+		if status == RoleGrantRejected {
+			decision["withdrawn"] = approval.RequestedBy == adminID
+		}
+		err = s.audit.Record(ctx, auditservice.AuditEntry{
+			ActorID:    &adminID,
+			Action:     "user.role_grant_" + status,
+			EntityType: "user",
+			EntityID:   approval.UserID.String(),
+			OldValue:   map[string]interface{}{"role": previousRole},
+			NewValue:   decision,
+		})
+		if err != nil {
+			return err
+		}
+		return s.emit(ctx, eventservice.UserRoleGrantDecided, approval.UserID, &adminID, map[string]interface{}{
+			"role": approval.Role, "approval_id": approval.ID, "requested_by": approval.RequestedBy, "status": status,
+		})
+	})
+	return approval, err
+}
+
+// revokeSessions signs the user out everywhere, google sessions issued before oidc login used the firebase
+// uid as token subject so it is revoked too. Failures are logged, the change that triggered this has already been committed
+func (s *userServiceStruct) revokeSessions(ctx context.Context, userID uuid.UUID, firebaseUID string) {
+	if firebaseUID == "" {
+		uid, err := s.repo.GetFirebaseUID(ctx, userID)
+		if err != nil {
+			s.logger.GetLogger().Warn("failed to look up firebase uid for token revocation", zap.String("userID", userID.String()), zap.Error(err))
+		}
+		firebaseUID = uid
+	}
+	if err := s.AuthMiddleware.RevokeUserTokens(ctx, userID.String(), firebaseUID); err != nil {
+		s.logger.GetLogger().Error("failed to revoke user tokens", zap.String("userID", userID.String()), zap.Error(err))
+		return
+	}
+	s.logger.GetLogger().Info("user tokens revoked", zap.String("userID", userID.String()))
+}
+
+const (
+	roleClaim        = "role"
+	permissionsClaim = "permissions"
+	// firebase rejects custom claims over this many bytes of json
+	firebaseMaxClaimsSize = 1000
+	// GetUsers accepts at most 100 identifiers
+	firebaseClaimsBatchSize = 100
+)
+
+// roleChanged runs after a role change is committed, tokens carry the old role so the user signs in
+// again, and firebase clients get the new role in their custom claims
+func (s *userServiceStruct) roleChanged(ctx context.Context, userID uuid.UUID) {
+	s.repo.InvalidateUserCache(ctx, userID)
+	s.revokeSessions(ctx, userID, "")
+	s.syncRoleClaims(ctx, userID)
+}
+
+// emit reports a change of one user, exec is the transaction of the change when it has one
+func (s *userServiceStruct) emit(ctx context.Context, eventType string, userID uuid.UUID, actorID *uuid.UUID, data map[string]interface{}) error {
+	data["user_id"] = userID
+	return s.events.Emit(ctx, eventservice.DomainEvent{
+		Type:          eventType,
+		AggregateType: eventservice.AggregateUser,
+		AggregateID:   userID,
+		ActorID:       actorID,
+		Data:          data,
+	})
+}
+
+// emitUserCreated and emitRoleChanged take a source saying what made the change: a flow like "admin"
+// or "self_registration", or the name of the identity provider
+func (s *userServiceStruct) emitUserCreated(ctx context.Context, userID uuid.UUID, actorID *uuid.UUID, email, role, source string) error {
+	return s.emit(ctx, eventservice.UserCreated, userID, actorID, map[string]interface{}{"email": email, "role": role, "source": source})
+}
+
+func (s *userServiceStruct) emitRoleChanged(ctx context.Context, userID uuid.UUID, actorID *uuid.UUID, role, source string) error {
+	return s.emit(ctx, eventservice.UserRoleChanged, userID, actorID, map[string]interface{}{"role": role, "source": source})
+}
+
+// syncRoleClaims pushes the user's role to firebase custom claims. Failures are only logged,
+// ReconcileFirebaseClaims picks up whatever was missed
+func (s *userServiceStruct) syncRoleClaims(ctx context.Context, userID uuid.UUID) {
+	desired, err := s.repo.GetFirebaseRoleClaims(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			s.logger.GetLogger().Warn("failed to load role claims", zap.String("userID", userID.String()), zap.Error(err))
+		}
+		return
+	}
+	record, err := s.firebase.GetUserByUID(ctx, desired.FirebaseUID)
+	if err != nil {
+		s.logger.GetLogger().Warn("failed to get firebase user for role claims", zap.String("userID", userID.String()), zap.Error(err))
+		return
+	}
+	if _, err := s.pushRoleClaims(ctx, record, desired); err != nil {
+		s.logger.GetLogger().Warn("failed to set firebase role claims", zap.String("userID", userID.String()), zap.Error(err))
+	}
+}
+
+// ReconcileFirebaseClaims is run by the background job, it corrects claims that drifted from the database,
+// e.g. after a sync failure, a directory sync or a change to a role's permissions
+func (s *userServiceStruct) ReconcileFirebaseClaims(ctx context.Context) error {
+	checked, updated := 0, 0
+	after := uuid.Nil
+	for {
+		page, err := s.repo.ListFirebaseRoleClaims(ctx, after, firebaseClaimsBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(page) == 0 {
+			break
+		}
+		uids := make([]string, 0, len(page))
+		for _, desired := range page {
+			uids = append(uids, desired.FirebaseUID)
+		}
+		records, err := s.firebase.GetUsersByUID(ctx, uids)
+		if err != nil {
+			return err
+		}
+		byUID := make(map[string]*firebaseauth.UserRecord, len(records))
+		for _, record := range records {
+			byUID[record.UID] = record
+		}
+		for _, desired := range page {
+			record, ok := byUID[desired.FirebaseUID]
+			if !ok {
+				continue
 			}
+			checked++
+			changed, err := s.pushRoleClaims(ctx, record, desired)
+			if err != nil {
+				s.logger.GetLogger().Warn("failed to set firebase role claims", zap.String("userID", desired.UserID.String()), zap.Error(err))
+				continue
+			}
+			if changed {
+				updated++
+			}
+		}
+		if len(page) < firebaseClaimsBatchSize {
+			break
+		}
+		after = page[len(page)-1].UserID
+	}
+	s.logger.GetLogger().Info("firebase role claims reconciled", zap.Int("checked", checked), zap.Int("updated", updated))
+	return nil
+}
+
+// pushRoleClaims sets the role and permissions claims when they differ from what firebase holds, claims
+// set by anything else are kept. Firebase caps custom claims at 1000 bytes, when the permissions don't
+// fit only the role is sent
+func (s *userServiceStruct) pushRoleClaims(ctx context.Context, record *firebaseauth.UserRecord, desired FirebaseRoleClaims) (bool, error) {
+	permissions := []string(desired.Permissions)
+	if permissions == nil {
+		permissions = []string{}
+	}
+	if claimsInSync(record.CustomClaims, desired.Role, permissions) {
+		return false, nil
+	}
+	claims := make(map[string]interface{}, len(record.CustomClaims)+2)
+	for key, value := range record.CustomClaims {
+		claims[key] = value
+	}
+	claims[roleClaim] = desired.Role
+	claims[permissionsClaim] = permissions
+	if encoded, err := json.Marshal(claims); err == nil && len(encoded) > firebaseMaxClaimsSize {
+		s.logger.GetLogger().Warn("role permissions don't fit in firebase claims, sending the role only", zap.String("userID", desired.UserID.String()), zap.String("role", desired.Role))
+		delete(claims, permissionsClaim)
+		if claimsInSync(record.CustomClaims, desired.Role, nil) {
+			return false, nil
+		}
+	}
+	if err := s.firebase.SetCustomUserClaims(ctx, record.UID, claims); err != nil {
+		return false, err
+	}
+	s.logger.GetLogger().Info("firebase role claims updated", zap.String("userID", desired.UserID.String()), zap.String("role", desired.Role))
+	return true, nil
+}
+
+// claimsInSync compares against claims decoded from json, where the permissions come back as []interface{}.
+// nil permissions expect the claim to be absent
+func claimsInSync(current map[string]interface{}, role string, permissions []string) bool {
+	if current[roleClaim] != role {
+		return false
+	}
+	got, present := current[permissionsClaim].([]interface{})
+	if permissions == nil {
+		_, present = current[permissionsClaim]
+		return !present
+	}
+	if !present || len(got) != len(permissions) {
+		return false
+	}
+	for i, permission := range permissions {
+		if got[i] != permission {
+			return false
+		}
+	}
+	return true
+}
+
+// ForceLogout revokes every token the user holds right away, unlike revokeSessions a failure is returned
+// since the admin needs to know the user may still be signed in
+func (s *userServiceStruct) ForceLogout(ctx context.Context, adminID, userID uuid.UUID, reason string) error {
+	firebaseUID, err := s.repo.GetFirebaseUID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrUserNotFound
 		}
-	}()
+		s.logger.GetLogger().Error("failed to look up user for force logout", zap.String("userID", userID.String()), zap.Error(err))
+		return err
+	}
+	if err := s.AuthMiddleware.RevokeUserTokens(ctx, userID.String(), firebaseUID); err != nil {
+		s.logger.GetLogger().Error("failed to revoke user tokens", zap.String("userID", userID.String()), zap.Error(err))
+		return err
+	}
+	s.logger.GetLogger().Info("user force logged out", zap.String("userID", userID.String()), zap.String("adminID", adminID.String()))
+
+	// the tokens are already revoked, a missing audit row shouldn't report the logout as failed
+	if err := s.audit.Record(ctx, auditservice.AuditEntry{
+		ActorID:    &adminID,
+		Action:     "user.force_logout",
+		EntityType: "user",
+		EntityID:   userID.String(),
+		NewValue:   map[string]interface{}{"reason": reason},
+	}); err != nil {
+		s.logger.GetLogger().Warn("failed to audit force logout", zap.String("userID", userID.String()), zap.Error(err))
+	}
+	event := newAuthEvent(auditservice.AuthEventLogout, "forced", models.ClientInfo{})
+	event.UserID = &userID
+	event.Reason = reason
+	s.recordAuthEvent(ctx, event, nil)
+	return nil
+}
 
+func (s *userServiceStruct) ScheduleRoleChange(ctx context.Context, req ScheduleRoleChangeReq, adminID uuid.UUID, scope models.DepartmentScope) (uuid.UUID, error) {
+	s.logger.GetLogger().Info("schedule role change", zap.String("targetUserID", req.UserID), zap.String("role", req.Role), zap.Time("effectiveFrom", req.EffectiveFrom))
+	if !req.EffectiveFrom.After(time.Now()) {
+		return uuid.Nil, ErrScheduleStartInPast
+	}
+	if req.EffectiveTo != nil && !req.EffectiveTo.After(req.EffectiveFrom) {
+		return uuid.Nil, ErrScheduleEndBeforeFrom
+	}
+	exists, err := s.repo.IsRoleExists(ctx, req.Role)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if !exists {
+		s.logger.GetLogger().Warn("requested role does not exist", zap.String("role", req.Role))
+		return uuid.Nil, ErrUnknownRole.WithDetails(map[string]string{"role": req.Role})
+	}
 	userUUID, err := uuid.Parse(req.UserID)
 	if err != nil {
-		s.logger.GetLogger().Error("failed to parse userID in ChangeUserRole", zap.String("userID", req.UserID), zap.Error(err))
+		return uuid.Nil, err
+	}
+	if err := s.checkUserScope(ctx, userUUID, scope); err != nil {
+		return uuid.Nil, err
+	}
+	// the scheduler would apply it without anyone approving
+	privileged, err := s.isPrivilegedRole(ctx, req.Role)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if privileged {
+		return uuid.Nil, ErrRoleGrantNotScheduled
+	}
+	return s.repo.InsertScheduledRoleChange(ctx, req, adminID)
+}
+
+func (s *userServiceStruct) GetScheduledRoleChanges(ctx context.Context, userID *uuid.UUID, limit, offset int, scope models.DepartmentScope) ([]ScheduledRoleChangeRes, error) {
+	return s.repo.GetScheduledRoleChanges(ctx, userID, limit, offset, scope)
+}
+
+func (s *userServiceStruct) CancelScheduledRoleChange(ctx context.Context, id uuid.UUID, scope models.DepartmentScope) error {
+	s.logger.GetLogger().Info("cancel scheduled role change", zap.String("id", id.String()))
+	return s.repo.CancelScheduledRoleChange(ctx, id, scope)
+}
+
+// ApplyScheduledRoleChanges is run by the background job, it applies changes whose effective_from
+// has passed and reverts applied changes whose effective_to has passed
+func (s *userServiceStruct) ApplyScheduledRoleChanges(ctx context.Context) error {
+	due, err := s.repo.GetDueRoleChanges(ctx)
+	if err != nil {
+		return err
+	}
+	for _, change := range due {
+		if err := s.applyRoleChange(ctx, change); err != nil {
+			s.logger.GetLogger().Error("failed to apply scheduled role change", zap.String("id", change.ID.String()), zap.Error(err))
+		}
+	}
+
+	expired, err := s.repo.GetExpiredRoleChanges(ctx)
+	if err != nil {
+		return err
+	}
+	for _, change := range expired {
+		if err := s.revertRoleChange(ctx, change); err != nil {
+			s.logger.GetLogger().Error("failed to revert scheduled role change", zap.String("id", change.ID.String()), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+func (s *userServiceStruct) applyRoleChange(ctx context.Context, change ScheduledRoleChangeRes) error {
+	// the role may have picked up a privileged permission since the change was scheduled
+	privileged, err := s.isPrivilegedRole(ctx, change.Role)
+	if err != nil {
 		return err
 	}
+	if privileged {
+		s.logger.GetLogger().Warn("scheduled role change now grants a privileged role, cancelling it", zap.String("id", change.ID.String()), zap.String("role", change.Role))
+		return s.repo.CancelScheduledRoleChange(ctx, change.ID, models.DepartmentScope{AllDepartments: true})
+	}
+	if err := utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		previousRole, err := s.repo.GetCurrentUserRole(ctx, change.UserID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		err = s.repo.UpdateUserRole(ctx, change.UserID, change.Role, change.CreatedBy)
+		if err != nil && !errors.Is(err, ErrRoleAlreadyAssigned) {
+			return err
+		}
+		if err = s.repo.MarkRoleChangeApplied(ctx, change.ID, previousRole); err != nil {
+			return err
+		}
+		if previousRole != change.Role {
+			if err = s.emitRoleChanged(ctx, change.UserID, &change.CreatedBy, change.Role, "scheduled_change"); err != nil {
+				return err
+			}
+		}
+		s.logger.GetLogger().Info("scheduled role change applied", zap.String("id", change.ID.String()), zap.String("userID", change.UserID.String()), zap.String("role", change.Role))
+		return nil
+	}); err != nil {
+		return err
+	}
+	s.roleChanged(ctx, change.UserID)
+	return nil
+}
+
+func (s *userServiceStruct) revertRoleChange(ctx context.Context, change ScheduledRoleChangeRes) error {
+	previousRole := string(models.EmployeeRole)
+	if change.PreviousRole != nil {
+		previousRole = *change.PreviousRole
+	}
+
+	if err := utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		err := s.repo.UpdateUserRole(ctx, change.UserID, previousRole, change.CreatedBy)
+		if err != nil {
+			if !errors.Is(err, ErrRoleAlreadyAssigned) {
+				return err
+			}
+		} else if err = s.emitRoleChanged(ctx, change.UserID, &change.CreatedBy, previousRole, "scheduled_change_reverted"); err != nil {
+			return err
+		}
+		if err = s.repo.MarkRoleChangeReverted(ctx, change.ID); err != nil {
+			return err
+		}
+		s.logger.GetLogger().Info("scheduled role change reverted", zap.String("id", change.ID.String()), zap.String("userID", change.UserID.String()), zap.String("role", previousRole))
+		return nil
+	}); err != nil {
+		return err
+	}
+	s.roleChanged(ctx, change.UserID)
+	return nil
+}
 
-	err = s.repo.UpdateUserRole(ctx, tx, userUUID, req.Role, adminID)
+// ProcessEmployeeEndDates is run by the background job, it warns managers warningDays before an employee's
+// end date about assets still held and opens return requests for those assets once the end date is reached
+func (s *userServiceStruct) ProcessEmployeeEndDates(ctx context.Context, warningDays int) error {
+	ending, err := s.repo.GetEmployeesEndingWithin(ctx, warningDays)
 	if err != nil {
-		if strings.Contains(err.Error(), "already has the role") {
-			s.logger.GetLogger().Warn("user already has the requested role", zap.String("userID", req.UserID), zap.String("role", req.Role))
-			return errors.New("user already has this role")
+		return err
+	}
+	for _, employee := range ending {
+		if err := s.warnEndDate(ctx, employee); err != nil {
+			s.logger.GetLogger().Error("failed to warn about employee end date", zap.String("userID", employee.UserID.String()), zap.Error(err))
 		}
-		s.logger.GetLogger().Error("Failed to update user role in repository", zap.String("userID", req.UserID), zap.Error(err))
+	}
+
+	ended, err := s.repo.GetEmployeesPastEndDate(ctx)
+	if err != nil {
 		return err
 	}
-	s.logger.GetLogger().Info("User role updated successfully", zap.String("userID", req.UserID), zap.String("newRole", req.Role))
+	for _, employee := range ended {
+		if err := s.openEndDateReturnRequests(ctx, employee); err != nil {
+			s.logger.GetLogger().Error("failed to open end date return requests", zap.String("userID", employee.UserID.String()), zap.Error(err))
+		}
+	}
 	return nil
 }
 
-func (s *userServiceStruct) DeleteUser(ctx context.Context, userID uuid.UUID, managerRole string) error {
-	s.logger.GetLogger().Info("inside delete user", zap.String("userID", userID.String()), zap.String("managerRole", managerRole))
-	userRole, err := s.repo.GetUserRoleById(ctx, userID)
+func (s *userServiceStruct) warnEndDate(ctx context.Context, employee EndingEmployeeRes) error {
+	managerIDs, err := s.repo.GetDepartmentManagerIDs(ctx, employee.OrganizationID, employee.DepartmentID)
 	if err != nil {
-		s.logger.GetLogger().Error("failed to get user role by ID for deletion", zap.String("userID", userID.String()), zap.Error(err))
 		return err
 	}
-	s.logger.GetLogger().Debug("retrieved user role for deletion target", zap.String("userID", userID.String()), zap.String("userRole", userRole))
 
-	if managerRole != "admin" && (userRole == "admin" || userRole == "asset_manager" || userRole == "inventory_manager") {
-		s.logger.GetLogger().Warn("unauthorized attempt to delete privileged user role", zap.String("managerRole", managerRole), zap.String("targetUserRole", userRole))
-		return errors.New("only admin can delete admin or manager roles")
+	return utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		if err := s.repo.MarkEndDateWarned(ctx, employee.UserID); err != nil {
+			return err
+		}
+		err := s.notifier.Notify(ctx, managerIDs, notificationservice.Notification{
+			Category:   notificationservice.CategoryEmployeeEndDate,
+			Title:      "Employee leaving soon",
+			Body:       fmt.Sprintf("%s leaves on %s and still holds %d asset(s)", employee.Username, employee.EndDate.Format(time.DateOnly), employee.OutstandingAssets),
+			EntityType: "user",
+			EntityID:   employee.UserID.String(),
+		})
+		if err != nil {
+			return err
+		}
+		s.logger.GetLogger().Info("employee end date warning sent", zap.String("userID", employee.UserID.String()), zap.Int("recipients", len(managerIDs)))
+		return nil
+	})
+}
+
+func (s *userServiceStruct) openEndDateReturnRequests(ctx context.Context, employee EndingEmployeeRes) error {
+	managerIDs, err := s.repo.GetDepartmentManagerIDs(ctx, employee.OrganizationID, employee.DepartmentID)
+	if err != nil {
+		return err
 	}
 
-	userEmail, err := s.repo.GetEmailByUserID(ctx, userID)
+	return utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		assetIDs, err := s.repo.OpenEndDateReturnRequests(ctx, employee.UserID)
+		if err != nil {
+			return err
+		}
+		if len(assetIDs) == 0 {
+			return nil
+		}
+		for _, assetID := range assetIDs {
+			err = s.events.Emit(ctx, eventservice.DomainEvent{
+				Type:          eventservice.AssetReturnRequested,
+				AggregateType: eventservice.AggregateAsset,
+				AggregateID:   assetID,
+				Data:          map[string]interface{}{"asset_id": assetID, "employee_id": employee.UserID, "reason": "employee end date reached"},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		body := fmt.Sprintf("%s reached their end date, %d asset(s) need to be returned", employee.Username, len(assetIDs))
+		err = s.notifier.Notify(ctx, append(managerIDs, employee.UserID), notificationservice.Notification{
+			Category:   notificationservice.CategoryReturnRequest,
+			Title:      "Asset return requested",
+			Body:       body,
+			EntityType: "user",
+			EntityID:   employee.UserID.String(),
+		})
+		if err != nil {
+			return err
+		}
+		s.logger.GetLogger().Info("end date return requests opened", zap.String("userID", employee.UserID.String()), zap.Int("assets", len(assetIDs)))
+		return nil
+	})
+}
+
+// DeleteUser removes the user from firebase and the database. privileged is decided by the
+// route the request came through, only the admin routes may remove holders of a privileged role
+func (s *userServiceStruct) DeleteUser(ctx context.Context, userID, actorID uuid.UUID, privileged bool, scope models.DepartmentScope) error {
+	s.logger.GetLogger().Info("inside delete user", zap.String("userID", userID.String()), zap.Bool("privileged", privileged))
+	if err := s.checkUserScope(ctx, userID, scope); err != nil {
+		return err
+	}
+	userRole, userEmail, err := s.repo.GetUserRoleAndEmail(ctx, userID)
 	if err != nil {
-		s.logger.GetLogger().Error("failed to get user email for deletion", zap.String("userID", userID.String()), zap.Error(err))
-		return errors.New("failed to get user email from user table")
+		s.logger.GetLogger().Error("failed to get user role and email for deletion", zap.String("userID", userID.String()), zap.Error(err))
+		return err
+	}
+	s.logger.GetLogger().Debug("retrieved user role for deletion target", zap.String("userID", userID.String()), zap.String("userRole", userRole))
+
+	if !privileged {
+		privilegedTarget, err := s.isPrivilegedRole(ctx, userRole)
+		if err != nil {
+			return err
+		}
+		if privilegedTarget {
+			s.logger.GetLogger().Warn("privileged user deletion outside the admin routes", zap.String("userID", userID.String()), zap.String("targetUserRole", userRole))
+			return ErrPrivilegedTarget
+		}
 	}
 
 	firebaseUserRecords, err := s.firebase.GetUserByEmail(ctx, userEmail)
 	if err != nil {
 		s.logger.GetLogger().Error("failed to get user UID from firebase user table", zap.String("userID", userID.String()), zap.Error(err))
+		if errors.Is(err, models.ErrFirebaseUnavailable) {
+			return err
+		}
 		return errors.New("failed to get user UID from firebase user table")
 	}
 	s.logger.GetLogger().Info("userRecords from firebase", zap.Any("firebaseUserRecords", firebaseUserRecords))
 	err = s.firebase.DeleteAuthUser(ctx, firebaseUserRecords.UID)
 	if err != nil {
 		s.logger.GetLogger().Error("failed to delete auth user from firebase", zap.String("userID", userID.String()), zap.Error(err))
+		if errors.Is(err, models.ErrFirebaseUnavailable) {
+			return err
+		}
 		return errors.New("failed to delete auth user from firebase")
 	}
-	err = s.repo.DeleteUserByID(ctx, userID)
+	err = s.repo.DeleteUserByID(ctx, userID, &actorID)
 	if err != nil {
 		s.logger.GetLogger().Error("failed to delete user by ID", zap.String("userID", userID.String()), zap.Error(err))
 		return err
 	}
+	s.repo.InvalidateUserCache(ctx, userID, userEmail)
+	s.revokeSessions(ctx, userID, firebaseUserRecords.UID)
 	s.logger.GetLogger().Info("user deleted successfully", zap.String("userID", userID.String()))
-	return nil
+	return s.emit(ctx, eventservice.UserDeleted, userID, nil, map[string]interface{}{"email": userEmail})
 }
 
 func (s *userServiceStruct) GetEmployeesWithFilters(ctx context.Context, filter EmployeeFilter) ([]EmployeeResponseModel, error) {
@@ -136,409 +918,2184 @@ func (s *userServiceStruct) GetEmployeesWithFilters(ctx context.Context, filter
 	return employees, nil
 }
 
-func (s *userServiceStruct) GetEmployeeTimeline(ctx context.Context, userID uuid.UUID) ([]UserTimelineRes, error) {
-	s.logger.GetLogger().Info("fetching employee timeline", zap.String("userID", userID.String()))
-	timeline, err := s.repo.GetUserAssetTimeline(ctx, userID)
-	if err != nil {
-		s.logger.GetLogger().Error("failed to get user asset timeline", zap.String("userID", userID.String()), zap.Error(err))
-		return nil, err
+// directoryMaxLimit caps a directory page, the directory is open to everyone and shouldn't be
+// scraped in one request
+const directoryMaxLimit = 100
+
+func (s *userServiceStruct) GetDirectory(ctx context.Context, filter DirectoryFilter) ([]DirectoryEntry, error) {
+	if filter.Limit <= 0 || filter.Limit > directoryMaxLimit {
+		filter.Limit = directoryMaxLimit
 	}
-	s.logger.GetLogger().Info("successfully fetched employee timeline", zap.String("userID", userID.String()), zap.Int("timelineEvents", len(timeline)))
-	return timeline, nil
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	return s.repo.GetDirectory(ctx, filter)
 }
 
-func (s *userServiceStruct) PublicRegister(ctx context.Context, req PublicUserReq) (uuid.UUID, string, error) {
-	s.logger.GetLogger().Info("starting public registration service", zap.String("email", req.Email))
+// column order of the employee export, rows from ExportEmployees line up with it
+var EmployeeExportHeader = []string{
+	"id", "username", "email", "contact_no", "type", "roles", "designation", "location",
+	"date_of_joining", "emergency_contact_name", "emergency_contact_no", "asset_serials",
+}
 
-	tx, err := s.db.BeginTxx(ctx, nil)
+func (s *userServiceStruct) ExportEmployees(ctx context.Context, filter EmployeeFilter) ([][]string, error) {
+	s.logger.GetLogger().Info("exporting employees", zap.Any("filter", filter))
+	employees, err := s.repo.GetEmployeesForExport(ctx, filter)
 	if err != nil {
-		s.logger.GetLogger().Error("failed to begin transaction for PublicRegister", zap.Error(err))
+		s.logger.GetLogger().Error("failed to get employees for export", zap.Error(err))
+		return nil, err
+	}
+	deref := func(v *string) string {
+		if v == nil {
+			return ""
+		}
+		return *v
+	}
+	rows := make([][]string, 0, len(employees))
+	for _, e := range employees {
+		joined := ""
+		if e.DateOfJoining != nil {
+			joined = e.DateOfJoining.Format("2006-01-02")
+		}
+		rows = append(rows, []string{
+			e.ID,
+			e.Username,
+			e.Email,
+			deref(e.ContactNo),
+			deref(e.EmployeeType),
+			strings.Join(e.Roles, ";"),
+			deref(e.Designation),
+			deref(e.Location),
+			joined,
+			deref(e.EmergencyContactName),
+			deref(e.EmergencyContactNo),
+			strings.Join(e.AssetSerials, ";"),
+		})
+	}
+	s.logger.GetLogger().Info("successfully exported employees", zap.Int("count", len(rows)))
+	return rows, nil
+}
+
+// StreamEmployees hands the employees of the export to fn one by one, without holding them all
+func (s *userServiceStruct) StreamEmployees(ctx context.Context, filter EmployeeFilter, fn func(EmployeeExportRow) error) error {
+	s.logger.GetLogger().Info("streaming employees", zap.Any("filter", filter))
+	if err := s.repo.StreamEmployeesForExport(ctx, filter, fn); err != nil {
+		s.logger.GetLogger().Error("failed to stream employees", zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+func (s *userServiceStruct) GetEmployeeTimeline(ctx context.Context, userID uuid.UUID, limit, offset int, scope models.DepartmentScope) ([]UserTimelineRes, error) {
+	s.logger.GetLogger().Info("fetching employee timeline", zap.String("userID", userID.String()))
+	if err := s.checkUserScope(ctx, userID, scope); err != nil {
+		return nil, err
+	}
+	timeline, err := s.repo.GetUserTimeline(ctx, userID, limit, offset)
+	if err != nil {
+		s.logger.GetLogger().Error("failed to get user timeline", zap.String("userID", userID.String()), zap.Error(err))
+		return nil, err
+	}
+	loc := utils.LocationFromContext(ctx)
+	for i := range timeline {
+		timeline[i].OccurredAt = utils.InLocation(timeline[i].OccurredAt, loc)
+	}
+	s.logger.GetLogger().Info("successfully fetched employee timeline", zap.String("userID", userID.String()), zap.Int("timelineEvents", len(timeline)))
+	return timeline, nil
+}
+
+func (s *userServiceStruct) GetRoleHistory(ctx context.Context, userID uuid.UUID, scope models.DepartmentScope) ([]RoleHistoryRes, error) {
+	s.logger.GetLogger().Info("fetching role history", zap.String("userID", userID.String()))
+	if err := s.checkUserScope(ctx, userID, scope); err != nil {
+		return nil, err
+	}
+	history, err := s.repo.GetUserRoleHistory(ctx, userID)
+	if err != nil {
+		s.logger.GetLogger().Error("failed to get user role history", zap.String("userID", userID.String()), zap.Error(err))
+		return nil, err
+	}
+	return history, nil
+}
+
+func (s *userServiceStruct) PublicRegister(ctx context.Context, req PublicUserReq) (uuid.UUID, string, error) {
+	s.logger.GetLogger().Info("starting public registration service", zap.String("email", req.Email))
+
+	var userID uuid.UUID
+	var firebaseUID string
+	err := utils.WithTransactionOnce(ctx, s.db, func(ctx context.Context) error {
+		//validate email
+		if err := s.checkEmailDomain(req.Email); err != nil {
+			return err
+		}
+		splitEmail := strings.Split(req.Email, "@")
+
+		//extract username from email
+		usernameParts := strings.Split(splitEmail[0], ".")
+		if len(usernameParts) != 2 || usernameParts[0] == "" || usernameParts[1] == "" {
+			s.logger.GetLogger().Warn("Invalid email format for username extraction in PublicRegister", zap.String("email", req.Email))
+			return ErrInvalidEmailFormat
+		}
+		username := usernameParts[0] + " " + usernameParts[1]
+		s.logger.GetLogger().Debug("Parsed username from email", zap.String("username", username))
+
+		//checking firebase db
+		existingUID, err := s.firebase.GetAuthUserID(ctx, req.Email)
+		if err != nil && !firebaseauth.IsUserNotFound(err) {
+			s.logger.GetLogger().Error("Failed to check Firebase user", zap.String("email", req.Email), zap.Error(err))
+			return fmt.Errorf("firebase lookup failed: %w", err)
+		}
+		if existingUID != "" {
+			s.logger.GetLogger().Warn("User already exists in Firebase", zap.String("firebaseUID", existingUID))
+			return ErrUserAlreadyExists
+		}
+
+		//create user in Firebase
+		firebaseUserRecord, err := s.firebase.CreateUser(ctx, req.Email)
+		if err != nil {
+			s.logger.GetLogger().Error("Failed to create user in Firebase", zap.Error(err))
+			return fmt.Errorf("firebase creation failed: %w", err)
+		}
+		s.logger.GetLogger().Info("Firebase user created", zap.String("firebaseUID", firebaseUserRecord.UID))
+
+		//check if user already exist in our db
+		exists, err := s.repo.IsUserExists(ctx, req.Email)
+		if err != nil {
+			s.logger.GetLogger().Error("Failed to check if user exists in PublicRegister", zap.Error(err))
+			return err
+		}
+		if exists {
+			s.logger.GetLogger().Warn("User already registered during public registration attempt in postgresSQL database", zap.String("email", req.Email))
+			return ErrUserAlreadyExists
+		}
+
+		// Insert user into your DB
+		userID, err = s.repo.InsertIntoUser(ctx, username, req.Email, firebaseUserRecord.UID)
+		if err != nil {
+			s.logger.GetLogger().Error("Failed to insert into users table during PublicRegister", zap.Error(err))
+			return err
+		}
+		s.logger.GetLogger().Info("New user inserted into users table", zap.String("userID", userID.String()))
+
+		if err = s.repo.InsertIntoUserRole(ctx, userID, "employee", userID); err != nil {
+			s.logger.GetLogger().Error("Failed to insert user role during PublicRegister", zap.Error(err), zap.String("userID", userID.String()))
+			return err
+		}
+		s.logger.GetLogger().Debug("Assigned user role 'employee'", zap.String("userID", userID.String()))
+
+		if err = s.repo.InsertIntoUserType(ctx, userID, "full_time", userID); err != nil {
+			s.logger.GetLogger().Error("Failed to insert user type during PublicRegister", zap.Error(err), zap.String("userID", userID.String()))
+			return err
+		}
+		s.logger.GetLogger().Debug("Assigned user type 'full_time'", zap.String("userID", userID.String()))
+
+		if err = s.emitUserCreated(ctx, userID, &userID, req.Email, string(models.EmployeeRole), "self_registration"); err != nil {
+			return err
+		}
+		firebaseUID = firebaseUserRecord.UID
+		return nil
+	})
+	if err != nil {
+		s.logger.GetLogger().Error("public registration rolled back", zap.String("email", req.Email), zap.Error(err))
 		return uuid.Nil, "", err
 	}
-	defer func() {
-		if r := recover(); r != nil {
-			s.logger.GetLogger().Error("panic recovered in PublicRegister", zap.Any("recover_info", r))
-			tx.Rollback()
-		} else if err != nil {
-			s.logger.GetLogger().Error("rolling back transaction for PublicRegister due to error", zap.Error(err))
-			tx.Rollback()
+	s.repo.InvalidateUserCache(ctx, userID, req.Email)
+	s.sendVerificationEmail(ctx, userID, req.Email)
+	s.logger.GetLogger().Info("Public registration completed successfully", zap.String("userID", userID.String()))
+	return userID, firebaseUID, nil
+}
+
+//func (s *userService) PublicRegister(ctx context.Context, req PublicUserReq) (uuid.UUID, error) {
+//	s.logger.GetLogger().Info("starting public registration service", zap.String("email", req.Email))
+//	tx, err := s.db.BeginTxx(ctx, nil)
+//	if err != nil {
+//		s.logger.GetLogger().Error("failed to begin transaction for PublicRegister", zap.Error(err))
+//		return uuid.Nil, err
+//	}
+//	defer func() {
+//		if r := recover(); r != nil {
+//			s.logger.GetLogger().Error("panic recovered in PublicRegister", zap.Any("recover_info", r))
+//			tx.Rollback()
+//		} else if err != nil {
+//			s.logger.GetLogger().Error("rolling back transaction for PublicRegister due to error", zap.Error(err))
+//			tx.Rollback()
+//		} else {
+//			if commitErr := tx.Commit(); commitErr != nil {
+//				s.logger.GetLogger().Error("failed to commit transaction for PublicRegister", zap.Error(commitErr))
+//			} else {
+//				s.logger.GetLogger().Info("transaction committed successfully for PublicRegister")
+//			}
+//		}
+//	}()
+//
+//	splitEmail := strings.Split(req.Email, "@")
+//	if len(splitEmail) != 2 || splitEmail[1] != "remotestate.com" {
+//		s.logger.GetLogger().Warn("Invalid email domain for public registration", zap.String("email", req.Email))
+//		return uuid.Nil, errors.New("only remotestate.com domain is valid")
+//	}
+//
+//	usernameParts := strings.Split(splitEmail[0], ".")
+//	if len(usernameParts) != 2 || usernameParts[0] == "" || usernameParts[1] == "" {
+//		s.logger.GetLogger().Warn("Invalid email format for username extraction in PublicRegister", zap.String("email", req.Email))
+//		return uuid.Nil, errors.New("invalid email format for username")
+//	}
+//	username := usernameParts[0] + " " + usernameParts[1]
+//
+//	s.logger.GetLogger().Debug("Parsed username from email", zap.String("username", username))
+//
+//	exists, err := s.repo.IsUserExists(ctx, req.Email)
+//	if err != nil {
+//		s.logger.GetLogger().Error("Failed to check if user exists in PublicRegister", zap.Error(err))
+//		return uuid.Nil, err
+//	}
+//	if exists {
+//		s.logger.GetLogger().Warn("User already registered during public registration attempt", zap.String("email", req.Email))
+//		return uuid.Nil, errors.New("email already registered...")
+//	}
+//
+//	userID, err := s.repo.InsertIntoUser(ctx, username, req.Email)
+//	if err != nil {
+//		s.logger.GetLogger().Error("Failed to insert into users table during PublicRegister", zap.Error(err))
+//		return uuid.Nil, err
+//	}
+//	s.logger.GetLogger().Info("New user inserted into users table", zap.String("userID", userID.String()))
+//
+//	if err = s.repo.InsertIntoUserRole(ctx, userID, "employee", userID); err != nil {
+//		s.logger.GetLogger().Error("Failed to insert user role during PublicRegister", zap.Error(err), zap.String("userID", userID.String()))
+//		return uuid.Nil, err
+//	}
+//	s.logger.GetLogger().Debug("Assigned user role 'employee'", zap.String("userID", userID.String()))
+//
+//	if err = s.repo.InsertIntoUserType(ctx, userID, "full_time", userID); err != nil {
+//		s.logger.GetLogger().Error("Failed to insert user type during PublicRegister", zap.Error(err), zap.String("userID", userID.String()))
+//		return uuid.Nil, err
+//	}
+//	s.logger.GetLogger().Debug("Assigned user type 'full_time'", zap.String("userID", userID.String()))
+//	s.logger.GetLogger().Info("Public registration completed successfully", zap.String("userID", userID.String()))
+//	return userID, nil
+//}
+
+func (s *userServiceStruct) RegisterEmployeeByManager(ctx context.Context, req ManagerRegisterReq, managerID uuid.UUID, scope models.DepartmentScope) (uuid.UUID, *models.OnboardingRes, error) {
+	s.logger.GetLogger().Info("Starting employee registration by manager", zap.String("managerID", managerID.String()), zap.String("employeeEmail", req.Email))
+	if err := s.normalizeContactNumbers(&req.ContactNo, &req.ProfileFields); err != nil {
+		return uuid.Nil, nil, err
+	}
+	// scoped managers can only register employees into their own department
+	if !scope.AllDepartments {
+		req.DepartmentID = scope.DepartmentID
+	}
+	if req.EndDate != nil && req.Type == "full_time" {
+		return uuid.Nil, nil, ErrEndDateNotAllowed
+	}
+
+	var template *models.OnboardingTemplate
+	if req.TemplateID != nil {
+		t, err := s.getOnboardingTemplate(ctx, *req.TemplateID, req.Type, scope)
+		if err != nil {
+			return uuid.Nil, nil, err
+		}
+		if req.DepartmentID == nil {
+			req.DepartmentID = t.DepartmentID
+		}
+		template = &t
+	}
+
+	var userID uuid.UUID
+	var onboarding *models.OnboardingRes
+	err := utils.WithTransactionOnce(ctx, s.db, func(ctx context.Context) error {
+		var err error
+		userID, onboarding, err = s.createEmployee(ctx, req, managerID, template)
+		return err
+	})
+	if err != nil {
+		s.logger.GetLogger().Error("Rolling back transaction for RegisterEmployeeByManager due to error", zap.Error(err))
+		return uuid.Nil, nil, err
+	}
+	s.repo.InvalidateUserCache(ctx, userID, req.Email)
+	s.sendVerificationEmail(ctx, userID, req.Email)
+	return userID, onboarding, nil
+}
+
+// createEmployee creates the firebase and database accounts and applies the onboarding template when one is given
+func (s *userServiceStruct) createEmployee(ctx context.Context, req ManagerRegisterReq, managerID uuid.UUID, template *models.OnboardingTemplate) (uuid.UUID, *models.OnboardingRes, error) {
+	// Create Firebase user
+	userRecord, err := s.firebase.CreateUser(ctx, req.Email)
+	if err != nil {
+		s.logger.GetLogger().Error("Failed to create Firebase user", zap.Error(err))
+		return uuid.Nil, nil, fmt.Errorf("firebase user creation failed: %w", err)
+	}
+	s.logger.GetLogger().Info("Firebase user created", zap.String("firebaseUID", userRecord.UID))
+
+	userID, err := s.repo.CreateNewEmployee(ctx, req, managerID)
+	if err != nil {
+		s.logger.GetLogger().Error("Failed to create new employee in repository", zap.Error(err), zap.String("managerID", managerID.String()))
+		return uuid.Nil, nil, err
+	}
+	s.logger.GetLogger().Info("Employee registered successfully by manager", zap.String("managerID", managerID.String()), zap.String("employeeID", userID.String()))
+
+	role := string(models.EmployeeRole)
+	var onboarding *models.OnboardingRes
+	if template != nil {
+		onboarding, err = s.applyOnboardingTemplate(ctx, userID, *template, req.DepartmentID, managerID)
+		if err != nil {
+			s.logger.GetLogger().Error("Failed to apply onboarding template", zap.Error(err), zap.String("templateID", template.ID.String()))
+			return uuid.Nil, nil, err
+		}
+		if onboarding.Role != "" {
+			role = onboarding.Role
+		}
+	}
+	if err = s.emitUserCreated(ctx, userID, &managerID, req.Email, role, "manager"); err != nil {
+		return uuid.Nil, nil, err
+	}
+	return userID, onboarding, nil
+}
+
+// InviteEmployee records the invite and emails a signed link, the account is only created once the employee accepts
+func (s *userServiceStruct) InviteEmployee(ctx context.Context, req InviteEmployeeReq, managerID uuid.UUID, scope models.DepartmentScope) (res InviteRes, err error) {
+	s.logger.GetLogger().Info("Inviting employee", zap.String("managerID", managerID.String()), zap.String("employeeEmail", req.Email))
+	if err = s.normalizeContactNumbers(nil, &req.ProfileFields); err != nil {
+		return res, err
+	}
+	if !scope.AllDepartments {
+		req.DepartmentID = scope.DepartmentID
+	}
+	if req.EndDate != nil && req.Type == "full_time" {
+		return res, ErrEndDateNotAllowed
+	}
+	if req.TemplateID != nil {
+		template, err := s.getOnboardingTemplate(ctx, *req.TemplateID, req.Type, scope)
+		if err != nil {
+			return res, err
+		}
+		if req.DepartmentID == nil {
+			req.DepartmentID = template.DepartmentID
+		}
+	}
+
+	err = utils.WithTransactionOnce(ctx, s.db, func(ctx context.Context) error {
+		exists, err := s.repo.IsUserExists(ctx, req.Email)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrUserAlreadyExists
+		}
+		if err = s.repo.ArchivePendingInvites(ctx, req.Email); err != nil {
+			return err
+		}
+
+		expiresAt := time.Now().Add(s.config.GetInviteTTL())
+		inviteID, err := s.repo.InsertInvite(ctx, req, managerID, expiresAt)
+		if err != nil {
+			return err
+		}
+		token, err := middlewareprovider.GenerateInviteToken(inviteID.String(), expiresAt)
+		if err != nil {
+			s.logger.GetLogger().Error("Failed to sign invite token", zap.String("inviteID", inviteID.String()), zap.Error(err))
+			return err
+		}
+
+		// the invite is rolled back if the email can't be sent, so the manager can simply retry
+		body := fmt.Sprintf("You have been invited to join the asset manager.\n\nComplete your registration here: %s/invite/accept?token=%s\n\nThis link expires on %s.",
+			s.config.GetAppBaseURL(), token, expiresAt.Format(time.RFC1123))
+		if err = s.mailer.Send(ctx, req.Email, "You're invited to the asset manager", body); err != nil {
+			return err
+		}
+
+		s.logger.GetLogger().Info("Employee invited", zap.String("managerID", managerID.String()), zap.String("inviteID", inviteID.String()))
+		res = InviteRes{ID: inviteID, Email: req.Email, ExpiresAt: expiresAt}
+		return nil
+	})
+	if err != nil {
+		return InviteRes{}, err
+	}
+	return res, nil
+}
+
+// AcceptInvite completes a pending invite, the account is created on behalf of the manager who sent it
+func (s *userServiceStruct) AcceptInvite(ctx context.Context, req AcceptInviteReq) (userID uuid.UUID, onboarding *models.OnboardingRes, err error) {
+	if err = s.normalizeContactNumbers(&req.ContactNo, nil); err != nil {
+		return uuid.Nil, nil, err
+	}
+	verifyContact := s.contactOTPOn()
+	if verifyContact {
+		if err = s.checkContactOTP(ctx, req.ContactNo, req.OTP); err != nil {
+			return uuid.Nil, nil, err
+		}
+	}
+	inviteIDStr, err := middlewareprovider.ParseInviteToken(req.Token)
+	if err != nil {
+		s.logger.GetLogger().Warn("Invalid invite token", zap.Error(err))
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return uuid.Nil, nil, ErrInviteExpired
+		}
+		return uuid.Nil, nil, ErrInviteInvalid
+	}
+	inviteID, err := uuid.Parse(inviteIDStr)
+	if err != nil {
+		return uuid.Nil, nil, ErrInviteInvalid
+	}
+
+	var email string
+	err = utils.WithTransactionOnce(ctx, s.db, func(ctx context.Context) error {
+		invite, err := s.repo.GetInviteForUpdate(ctx, inviteID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrInviteInvalid
+			}
+			return err
+		}
+		if invite.AcceptedAt != nil || invite.ArchivedAt != nil {
+			return ErrInviteInvalid
+		}
+		if time.Now().After(invite.ExpiresAt) {
+			return ErrInviteExpired
+		}
+		email = invite.Email
+
+		var payload InviteEmployeeReq
+		if err = json.Unmarshal(invite.Payload, &payload); err != nil {
+			s.logger.GetLogger().Error("Failed to decode invite payload", zap.String("inviteID", inviteID.String()), zap.Error(err))
+			return err
+		}
+		registerReq := ManagerRegisterReq{
+			Username:      req.Username,
+			Email:         invite.Email,
+			ContactNo:     req.ContactNo,
+			Type:          payload.Type,
+			ProfileFields: payload.ProfileFields,
+			DepartmentID:  payload.DepartmentID,
+			EndDate:       payload.EndDate,
+			TemplateID:    payload.TemplateID,
+		}
+
+		// department access was checked when the invite was sent
+		var template *models.OnboardingTemplate
+		if registerReq.TemplateID != nil {
+			t, err := s.getOnboardingTemplate(ctx, *registerReq.TemplateID, registerReq.Type, models.DepartmentScope{AllDepartments: true})
+			if err != nil {
+				return err
+			}
+			template = &t
+		}
+
+		userID, onboarding, err = s.createEmployee(ctx, registerReq, invite.InvitedBy, template)
+		if err != nil {
+			return err
+		}
+		if err = s.repo.MarkInviteAccepted(ctx, inviteID, userID); err != nil {
+			return err
+		}
+		// following the emailed invite link already proves the address
+		if _, err = s.repo.MarkEmailVerified(ctx, userID, invite.Email); err != nil {
+			return err
+		}
+		if verifyContact {
+			if err = s.repo.MarkContactVerified(ctx, userID); err != nil {
+				return err
+			}
+		}
+		s.logger.GetLogger().Info("Invite accepted", zap.String("inviteID", inviteID.String()), zap.String("userID", userID.String()))
+		return nil
+	})
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	if verifyContact {
+		s.repo.DeleteContactOTP(ctx, req.ContactNo)
+	}
+	s.repo.InvalidateUserCache(ctx, userID, email)
+	return userID, onboarding, nil
+}
+
+// contactOTPMaxAttempts wrong codes drop the texted one, a new code has to be requested after them
+const contactOTPMaxAttempts = 5
+
+func (s *userServiceStruct) contactOTPOn() bool {
+	return s.sms != nil && s.config.GetSMSConfig().VerifyContactNo
+}
+
+// SendContactOTP texts a code confirming the number, it is typed back when accepting the invite.
+// The provider caps the messages per number, the route's ip limit the numbers per client
+func (s *userServiceStruct) SendContactOTP(ctx context.Context, req ContactOTPReq) error {
+	if !s.contactOTPOn() {
+		return ErrContactOTPOff
+	}
+	if err := s.normalizeContactNumbers(&req.ContactNo, nil); err != nil {
+		return err
+	}
+	code, err := utils.GenerateOTP()
+	if err != nil {
+		return err
+	}
+	ttl := s.config.GetSMSConfig().OTPTTL
+	data := map[string]string{"code": code, "minutes": strconv.Itoa(int(ttl.Minutes()))}
+	// stored only once sent, a refused message leaves the earlier code working
+	if err = s.sms.Send(ctx, req.ContactNo, models.SMSTemplateOTP, data); err != nil {
+		s.logger.GetLogger().Warn("Failed to text contact otp", zap.Error(err))
+		return err
+	}
+	return s.repo.StoreContactOTP(ctx, req.ContactNo, utils.HashBackupCode(code), ttl)
+}
+
+// checkContactOTP leaves the code in place, AcceptInvite drops it once the account exists
+func (s *userServiceStruct) checkContactOTP(ctx context.Context, contactNo, code string) error {
+	if code == "" {
+		return ErrContactOTPRequired
+	}
+	matched, err := s.repo.CheckContactOTP(ctx, contactNo, utils.HashBackupCode(code), contactOTPMaxAttempts)
+	if err != nil {
+		return err
+	}
+	if !matched {
+		return ErrContactOTPInvalid
+	}
+	return nil
+}
+
+// getOnboardingTemplate loads a template of the caller's organization and checks it fits the employee
+// type and the caller's department
+func (s *userServiceStruct) getOnboardingTemplate(ctx context.Context, templateID uuid.UUID, employeeType string, scope models.DepartmentScope) (models.OnboardingTemplate, error) {
+	template, err := s.repo.GetOnboardingTemplate(ctx, templateID, scope.OrganizationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return template, ErrTemplateNotFound
+		}
+		return template, err
+	}
+	if template.EmployeeType != employeeType {
+		return template, ErrTemplateTypeMismatch
+	}
+	if !scope.AllDepartments && template.DepartmentID != nil &&
+		(scope.DepartmentID == nil || *scope.DepartmentID != *template.DepartmentID) {
+		return template, models.ErrOutOfScope
+	}
+	return template, nil
+}
+
+// applyOnboardingTemplate grants the template role and hands out its kit from available stock,
+// kit items that can't be fulfilled are reported instead of failing the registration
+func (s *userServiceStruct) applyOnboardingTemplate(ctx context.Context, userID uuid.UUID, template models.OnboardingTemplate, departmentID *uuid.UUID, managerID uuid.UUID) (*models.OnboardingRes, error) {
+	res := &models.OnboardingRes{
+		TemplateID:     template.ID,
+		AssignedAssets: make([]uuid.UUID, 0),
+		Unfulfilled:    make([]models.KitShortfall, 0),
+	}
+	if template.Role != nil && *template.Role != string(models.EmployeeRole) {
+		if err := s.repo.UpdateUserRole(ctx, userID, *template.Role, managerID); err != nil {
+			return nil, err
+		}
+		res.Role = *template.Role
+	}
+	for _, item := range template.Items {
+		assetIDs, err := s.repo.AssignKitAssets(ctx, userID, item, departmentID, managerID)
+		if err != nil {
+			return nil, err
+		}
+		for _, assetID := range assetIDs {
+			err = s.events.Emit(ctx, eventservice.DomainEvent{
+				Type:          eventservice.AssetAssigned,
+				AggregateType: eventservice.AggregateAsset,
+				AggregateID:   assetID,
+				ActorID:       &managerID,
+				Data:          map[string]interface{}{"asset_id": assetID, "employee_id": userID, "assigned_by": managerID, "onboarding_template_id": template.ID},
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		res.AssignedAssets = append(res.AssignedAssets, assetIDs...)
+		if len(assetIDs) < item.Quantity {
+			res.Unfulfilled = append(res.Unfulfilled, models.KitShortfall{
+				AssetType: item.AssetType,
+				Requested: item.Quantity,
+				Assigned:  len(assetIDs),
+			})
+		}
+	}
+	s.logger.GetLogger().Info("onboarding template applied", zap.String("userID", userID.String()), zap.Int("assigned", len(res.AssignedAssets)), zap.Int("unfulfilled", len(res.Unfulfilled)))
+	return res, nil
+}
+
+func (s *userServiceStruct) UpdateEmployee(ctx context.Context, req UpdateEmployeeReq, managerID uuid.UUID, scope models.DepartmentScope) (EmployeeRes, error) {
+	s.logger.GetLogger().Info("Attempting to update employee information")
+	// changing the address silently would break the firebase link, it has its own verified flow
+	if req.Email != "" {
+		return EmployeeRes{}, ErrEmailChangeViaUpdate
+	}
+	if err := checkClearConflicts(req); err != nil {
+		return EmployeeRes{}, err
+	}
+	if err := s.normalizeContactNumbers(&req.ContactNo, &req.ProfileFields); err != nil {
+		return EmployeeRes{}, err
+	}
+	if err := s.checkUserScope(ctx, req.UserID, scope); err != nil {
+		return EmployeeRes{}, err
+	}
+	if req.EndDate != nil {
+		employeeType, err := s.repo.GetEmployeeType(ctx, req.UserID)
+		if err != nil {
+			s.logger.GetLogger().Error("failed to fetch employee type for end date update", zap.String("userID", req.UserID.String()), zap.Error(err))
+			return EmployeeRes{}, err
+		}
+		if employeeType == "full_time" {
+			return EmployeeRes{}, ErrEndDateNotAllowed
+		}
+	}
+	err := s.repo.UpdateEmployeeInfo(ctx, req, managerID)
+	if err != nil {
+		s.logger.GetLogger().Error("failed to update employee information in repository")
+		return EmployeeRes{}, err
+	}
+	s.repo.InvalidateUserCache(ctx, req.UserID)
+	employee, err := s.repo.GetEmployeeByID(ctx, req.UserID)
+	if err != nil {
+		s.logger.GetLogger().Error("failed to fetch employee after update", zap.String("userID", req.UserID.String()), zap.Error(err))
+		return EmployeeRes{}, err
+	}
+	s.logger.GetLogger().Info("employee information updated successfully")
+	if err := s.emit(ctx, eventservice.UserUpdated, req.UserID, &managerID, map[string]interface{}{"changes": req}); err != nil {
+		return EmployeeRes{}, err
+	}
+	return employee, nil
+}
+
+// checkClearConflicts rejects requests that set a field and clear it at the same time
+func checkClearConflicts(req UpdateEmployeeReq) error {
+	set := map[string]bool{
+		"contact_no":             req.ContactNo != "",
+		"end_date":               req.EndDate != nil,
+		"designation":            req.Designation != "",
+		"location":               req.Location != "",
+		"date_of_joining":        req.DateOfJoining != nil,
+		"emergency_contact_name": req.EmergencyContactName != "",
+		"emergency_contact_no":   req.EmergencyContactNo != "",
+		"badge_id":               req.BadgeID != "",
+	}
+	for _, field := range req.Clear {
+		if set[field] {
+			return fmt.Errorf("%w: %s", ErrUpdateFieldConflict, field)
+		}
+	}
+	return nil
+}
+
+// normalizeContactNumbers rewrites the given numbers to E.164 in place, empty numbers are left alone
+func (s *userServiceStruct) normalizeContactNumbers(contactNo *string, profile *ProfileFields) error {
+	numbers := make([]*string, 0, 2)
+	if contactNo != nil {
+		numbers = append(numbers, contactNo)
+	}
+	if profile != nil {
+		numbers = append(numbers, &profile.EmergencyContactNo)
+	}
+	for _, number := range numbers {
+		if *number == "" {
+			continue
+		}
+		normalized, err := utils.NormalizePhoneNumber(*number, s.config.GetDefaultCountryCode())
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPhoneNumber, err)
+		}
+		*number = normalized
+	}
+	return nil
+}
+
+// checkEmailDomain applies the configured registration domains, existing accounts are never affected by it
+func (s *userServiceStruct) checkEmailDomain(email string) error {
+	allowed := s.config.GetAllowedEmailDomains()
+	if len(allowed) == 0 {
+		return nil
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 || !slices.Contains(allowed, strings.ToLower(email[at+1:])) {
+		s.logger.GetLogger().Warn("Email domain not allowed for registration", zap.String("email", email))
+		return ErrEmailDomainNotAllowed
+	}
+	return nil
+}
+
+// checkEmailVerified blocks login for unverified users when verification is required by config
+func (s *userServiceStruct) checkEmailVerified(ctx context.Context, userID uuid.UUID) error {
+	if !s.config.RequireEmailVerification() {
+		return nil
+	}
+	verified, err := s.repo.IsEmailVerified(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !verified {
+		s.logger.GetLogger().Warn("Login blocked, email not verified", zap.String("userID", userID.String()))
+		return ErrEmailNotVerified
+	}
+	return nil
+}
+
+// sendVerificationEmail is best effort, a failed send is logged and the user can ask for a new link
+func (s *userServiceStruct) sendVerificationEmail(ctx context.Context, userID uuid.UUID, email string) {
+	expiresAt := time.Now().Add(s.config.GetEmailVerificationTTL())
+	token, err := middlewareprovider.GenerateEmailVerificationToken(userID.String(), email, expiresAt)
+	if err != nil {
+		s.logger.GetLogger().Error("Failed to sign verification token", zap.String("userID", userID.String()), zap.Error(err))
+		return
+	}
+	body := fmt.Sprintf("Please confirm your email address: %s/verify-email?token=%s\n\nThis link expires on %s.",
+		s.config.GetAppBaseURL(), token, expiresAt.Format(time.RFC1123))
+	if err := s.mailer.Send(ctx, email, "Confirm your email address", body); err != nil {
+		s.logger.GetLogger().Error("Failed to send verification email", zap.String("userID", userID.String()), zap.Error(err))
+	}
+}
+
+func (s *userServiceStruct) ConfirmEmail(ctx context.Context, req ConfirmEmailReq) error {
+	userIDStr, email, err := middlewareprovider.ParseEmailVerificationToken(req.Token)
+	if err != nil {
+		s.logger.GetLogger().Warn("Invalid verification token", zap.Error(err))
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return ErrVerificationExpired
+		}
+		return ErrVerificationInvalid
+	}
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return ErrVerificationInvalid
+	}
+	updated, err := s.repo.MarkEmailVerified(ctx, userID, email)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrVerificationInvalid
+	}
+	s.logger.GetLogger().Info("Email verified", zap.String("userID", userID.String()))
+	return nil
+}
+
+// ResendVerification never reports whether the email exists, unknown and already verified addresses are ignored
+func (s *userServiceStruct) ResendVerification(ctx context.Context, req PublicUserReq) error {
+	status, err := s.repo.GetEmailVerificationStatus(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	if status.Verified {
+		return nil
+	}
+	s.sendVerificationEmail(ctx, status.ID, req.Email)
+	return nil
+}
+
+// RequestEmailChange sends a confirmation link to the new address, nothing changes until it is confirmed
+func (s *userServiceStruct) RequestEmailChange(ctx context.Context, userID uuid.UUID, newEmail string, requestedBy uuid.UUID, scope models.DepartmentScope) (res EmailChangeRes, err error) {
+	s.logger.GetLogger().Info("Email change requested", zap.String("userID", userID.String()), zap.String("requestedBy", requestedBy.String()))
+	if err = s.checkUserScope(ctx, userID, scope); err != nil {
+		return res, err
+	}
+
+	err = utils.WithTransactionOnce(ctx, s.db, func(ctx context.Context) error {
+		exists, err := s.repo.IsUserExists(ctx, newEmail)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrUserAlreadyExists
+		}
+		if err = s.repo.ArchivePendingEmailChanges(ctx, userID); err != nil {
+			return err
+		}
+		expiresAt := time.Now().Add(s.config.GetEmailVerificationTTL())
+		res, err = s.repo.InsertEmailChangeRequest(ctx, userID, newEmail, requestedBy, expiresAt)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrUserNotFound
+			}
+			return err
+		}
+		if strings.EqualFold(res.OldEmail, newEmail) {
+			return ErrEmailUnchanged
+		}
+
+		token, err := middlewareprovider.GenerateEmailChangeToken(res.ID.String(), expiresAt)
+		if err != nil {
+			s.logger.GetLogger().Error("Failed to sign email change token", zap.String("requestID", res.ID.String()), zap.Error(err))
+			return err
+		}
+		body := fmt.Sprintf("Confirm your new email address: %s/change-email/confirm?token=%s\n\nThis link expires on %s.",
+			s.config.GetAppBaseURL(), token, expiresAt.Format(time.RFC1123))
+		if err = s.mailer.Send(ctx, newEmail, "Confirm your new email address", body); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return res, err
+	}
+
+	// heads up to the current address, in case the change wasn't made by the user
+	notice := fmt.Sprintf("A change of your asset manager email to %s was requested. If this wasn't expected, contact your administrator.", newEmail)
+	if err := s.mailer.Send(ctx, res.OldEmail, "Email change requested", notice); err != nil {
+		s.logger.GetLogger().Warn("Failed to notify old address of email change", zap.String("userID", userID.String()), zap.Error(err))
+	}
+	return res, nil
+}
+
+// ConfirmEmailChange moves the account and its firebase user to the new address, the old one stays on the request as history
+func (s *userServiceStruct) ConfirmEmailChange(ctx context.Context, req ConfirmEmailReq) error {
+	requestIDStr, err := middlewareprovider.ParseEmailChangeToken(req.Token)
+	if err != nil {
+		s.logger.GetLogger().Warn("Invalid email change token", zap.Error(err))
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return ErrVerificationExpired
+		}
+		return ErrVerificationInvalid
+	}
+	requestID, err := uuid.Parse(requestIDStr)
+	if err != nil {
+		return ErrVerificationInvalid
+	}
+
+	change, err := s.applyEmailChange(ctx, requestID)
+	if err != nil {
+		return err
+	}
+	s.repo.InvalidateUserCache(ctx, change.UserID, change.OldEmail, change.NewEmail)
+	s.logger.GetLogger().Info("Email changed", zap.String("userID", change.UserID.String()), zap.String("requestID", change.ID.String()))
+	return nil
+}
+
+func (s *userServiceStruct) applyEmailChange(ctx context.Context, requestID uuid.UUID) (change EmailChangeRequest, err error) {
+	err = utils.WithTransactionOnce(ctx, s.db, func(ctx context.Context) error {
+		var err error
+		change, err = s.repo.GetEmailChangeForUpdate(ctx, requestID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrVerificationInvalid
+			}
+			return err
+		}
+		if change.ConfirmedAt != nil || change.ArchivedAt != nil {
+			return ErrVerificationInvalid
+		}
+		if time.Now().After(change.ExpiresAt) {
+			return ErrVerificationExpired
+		}
+		exists, err := s.repo.IsUserExists(ctx, change.NewEmail)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return ErrUserAlreadyExists
+		}
+		if err = s.repo.ApplyEmailChange(ctx, change); err != nil {
+			return err
+		}
+
+		// firebase goes last so a failure there rolls the database change back
+		firebaseUID, err := s.firebase.GetAuthUserID(ctx, change.OldEmail)
+		if err != nil {
+			if !firebaseauth.IsUserNotFound(err) {
+				s.logger.GetLogger().Error("Failed to look up firebase user for email change", zap.String("userID", change.UserID.String()), zap.Error(err))
+				return fmt.Errorf("firebase lookup failed: %w", err)
+			}
+			s.logger.GetLogger().Warn("No firebase user for old email, skipping firebase update", zap.String("userID", change.UserID.String()))
+			return nil
+		}
+		if err = s.firebase.UpdateUserEmail(ctx, firebaseUID, change.NewEmail); err != nil {
+			s.logger.GetLogger().Error("Failed to update firebase email", zap.String("userID", change.UserID.String()), zap.Error(err))
+			return fmt.Errorf("firebase email update failed: %w", err)
+		}
+		return nil
+	})
+	return change, err
+}
+
+func (s *userServiceStruct) GetDashboard(ctx context.Context, userID uuid.UUID) (UserDashboardRes, error) {
+	s.logger.GetLogger().Info("Fetching user dashboard data", zap.String("userID", userID.String()))
+	dashboard, err := s.repo.GetUserDashboardById(ctx, userID)
+	if err != nil {
+		s.logger.GetLogger().Error("Failed to get user dashboard by ID", zap.String("userID", userID.String()), zap.Error(err))
+		return UserDashboardRes{}, err
+	}
+	pending, err := s.repo.GetPendingRoleChanges(ctx, userID)
+	if err != nil {
+		s.logger.GetLogger().Warn("Failed to get pending role changes for dashboard", zap.String("userID", userID.String()), zap.Error(err))
+	} else if len(pending) > 0 {
+		dashboard.PendingRoleChanges = pending
+	}
+	loc := utils.LocationFromContext(ctx)
+	for i := range dashboard.AssignedAssets {
+		dashboard.AssignedAssets[i].AssignedAt = utils.InLocation(dashboard.AssignedAssets[i].AssignedAt, loc)
+	}
+	s.logger.GetLogger().Info("Successfully fetched user dashboard data", zap.String("userID", userID.String()))
+	return dashboard, nil
+}
+
+// GetDashboardSummary is built from the same cached dashboard, only the counts and the id, type and
+// model of each asset are kept so mobile clients download as little as possible
+func (s *userServiceStruct) GetDashboardSummary(ctx context.Context, userID uuid.UUID) (DashboardSummaryRes, error) {
+	dashboard, err := s.repo.GetUserDashboardById(ctx, userID)
+	if err != nil {
+		s.logger.GetLogger().Error("Failed to get user dashboard for summary", zap.String("userID", userID.String()), zap.Error(err))
+		return DashboardSummaryRes{}, err
+	}
+	summary := DashboardSummaryRes{
+		AssetCount:     len(dashboard.AssignedAssets),
+		AssetsByType:   map[string]int{},
+		AssetsByStatus: map[string]int{},
+		Assets:         make([]AssetSummary, 0, len(dashboard.AssignedAssets)),
+	}
+	for _, a := range dashboard.AssignedAssets {
+		summary.AssetsByType[a.Type]++
+		summary.AssetsByStatus[a.Status]++
+		summary.Assets = append(summary.Assets, AssetSummary{ID: a.ID, Type: a.Type, Model: a.Model})
+	}
+	pending, err := s.repo.GetPendingRoleChanges(ctx, userID)
+	if err != nil {
+		s.logger.GetLogger().Warn("Failed to get pending role changes for dashboard summary", zap.String("userID", userID.String()), zap.Error(err))
+	} else {
+		summary.PendingRoleChanges = len(pending)
+	}
+	return summary, nil
+}
+
+// dashboardWarmLimit caps one warming run, the busiest users come first
+const dashboardWarmLimit = 500
+
+// WarmDashboardCache is run by the background job, it rebuilds the cached dashboards of users active
+// in the last hour so their next visit doesn't wait on the database
+func (s *userServiceStruct) WarmDashboardCache(ctx context.Context) error {
+	userIDs, err := s.repo.GetRecentlyActiveUserIDs(ctx, time.Now().Add(-time.Hour), dashboardWarmLimit)
+	if err != nil {
+		return err
+	}
+	warmed := 0
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.repo.InvalidateUserCache(ctx, userID)
+		if _, err := s.repo.GetUserDashboardById(ctx, userID); err != nil {
+			s.logger.GetLogger().Warn("failed to warm user dashboard", zap.String("userID", userID.String()), zap.Error(err))
+			continue
+		}
+		warmed++
+	}
+	s.logger.GetLogger().Info("user dashboards warmed", zap.Int("warmed", warmed), zap.Int("active", len(userIDs)))
+	return nil
+}
+
+func (s *userServiceStruct) UserLogin(ctx context.Context, req PublicUserReq, client models.ClientInfo) (LoginRes, error) {
+	event := newAuthEvent(auditservice.AuthEventLogin, "email", client)
+	event.Identifier = req.Email
+	res, err := s.userLogin(ctx, req, client.IP, &event)
+	s.recordLoginEvent(ctx, event, res, err)
+	return res, err
+}
+
+func (s *userServiceStruct) userLogin(ctx context.Context, req PublicUserReq, clientIP string, event *auditservice.AuthEvent) (LoginRes, error) {
+	s.logger.GetLogger().Info("Attempting user login", zap.String("email", req.Email))
+	if err := s.checkLoginLock(ctx, req.Email, clientIP); err != nil {
+		return LoginRes{}, err
+	}
+	userID, err := s.repo.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.logger.GetLogger().Warn("Login failed: User not found for email", zap.String("email", req.Email))
+			if lockErr := s.recordLoginFailure(ctx, req.Email, clientIP, nil); lockErr != nil {
+				return LoginRes{}, lockErr
+			}
+			return LoginRes{}, ErrInvalidLogin
+		}
+		s.logger.GetLogger().Error("Failed to get user by email during login", zap.String("email", req.Email), zap.Error(err))
+		return LoginRes{}, err
+	}
+	s.logger.GetLogger().Debug("User found for login", zap.String("userID", userID.String()))
+	event.UserID = &userID
+
+	if err = s.checkEmailVerified(ctx, userID); err != nil {
+		return LoginRes{}, err
+	}
+
+	userRole, err := s.repo.GetUserRoleById(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.logger.GetLogger().Error("Login failed: User exists but no role found", zap.String("userID", userID.String()), zap.Error(err))
+			return LoginRes{}, errors.New("user role not found")
+		}
+		s.logger.GetLogger().Error("Failed to get user role by ID during login", zap.String("userID", userID.String()), zap.Error(err))
+		return LoginRes{}, err
+	}
+	s.logger.GetLogger().Debug("User role retrieved for login", zap.String("userID", userID.String()), zap.String("role", userRole))
+
+	//accessToken, err := middlewares.GenerateJWT(userID.String(), []string{userRole})
+	res, err := s.issueLoginTokens(ctx, userID, userID.String(), userRole)
+	if err != nil {
+		return LoginRes{}, err
+	}
+	if !res.MFARequired && !res.MFAEnrollmentRequired {
+		s.repo.ClearLoginFailures(ctx, loginSubjectEmail(req.Email))
+	}
+	s.logger.GetLogger().Info("User login successful", zap.String("userID", userID.String()), zap.Bool("mfaRequired", res.MFARequired))
+	return res, nil
+}
+
+// RequestMagicLink emails a one-time sign-in link. Like ResendVerification it never reports whether the
+// address has an account, and requests over the per address limit are dropped without telling the caller
+func (s *userServiceStruct) RequestMagicLink(ctx context.Context, req PublicUserReq) error {
+	policy := s.config.GetMagicLinkPolicy()
+	count, err := s.repo.IncrementMagicLinkRequests(ctx, req.Email, policy.RequestWindow)
+	if err != nil {
+		return err
+	}
+	if count > policy.MaxPerEmail {
+		s.logger.GetLogger().Warn("Magic link request over the per email limit", zap.String("email", req.Email), zap.Int("count", count))
+		return nil
+	}
+	userID, err := s.repo.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	// the token carries the stored address, the exchange checks it is still the account's email
+	email, err := s.repo.GetEmailByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	linkID := uuid.NewString()
+	expiresAt := time.Now().Add(policy.TTL)
+	token, err := middlewareprovider.GenerateMagicLinkToken(userID.String(), email, linkID, expiresAt)
+	if err != nil {
+		s.logger.GetLogger().Error("Failed to sign magic link token", zap.String("userID", userID.String()), zap.Error(err))
+		return err
+	}
+	if err := s.repo.StoreMagicLink(ctx, linkID, userID, policy.TTL); err != nil {
+		return err
+	}
+	body := fmt.Sprintf("Use this link to sign in: %s/magic-link?token=%s\n\nThe link works once and expires on %s. If you didn't ask for it, you can ignore this email.",
+		s.config.GetAppBaseURL(), token, expiresAt.Format(time.RFC1123))
+	if err := s.mailer.Send(ctx, email, "Your sign-in link", body); err != nil {
+		s.logger.GetLogger().Error("Failed to send magic link email", zap.String("userID", userID.String()), zap.Error(err))
+		return err
+	}
+	s.logger.GetLogger().Info("Magic link sent", zap.String("userID", userID.String()))
+	return nil
+}
+
+// MagicLinkLogin trades a sign-in link for tokens, mfa still applies like for every other login
+func (s *userServiceStruct) MagicLinkLogin(ctx context.Context, req MagicLinkLoginReq, client models.ClientInfo) (LoginRes, error) {
+	event := newAuthEvent(auditservice.AuthEventLogin, "magic_link", client)
+	res, err := s.magicLinkLogin(ctx, req.Token, &event)
+	s.recordLoginEvent(ctx, event, res, err)
+	return res, err
+}
+
+func (s *userServiceStruct) magicLinkLogin(ctx context.Context, token string, event *auditservice.AuthEvent) (LoginRes, error) {
+	userIDStr, email, linkID, err := middlewareprovider.ParseMagicLinkToken(token)
+	if err != nil {
+		s.logger.GetLogger().Warn("Invalid magic link token", zap.Error(err))
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return LoginRes{}, ErrMagicLinkExpired
+		}
+		return LoginRes{}, ErrMagicLinkInvalid
+	}
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return LoginRes{}, ErrMagicLinkInvalid
+	}
+	event.UserID = &userID
+	event.Identifier = email
+
+	consumed, err := s.repo.ConsumeMagicLink(ctx, linkID, userID)
+	if err != nil {
+		return LoginRes{}, err
+	}
+	if !consumed {
+		return LoginRes{}, ErrMagicLinkInvalid
+	}
+	// opening the link proves the inbox, which is all email verification asks for. No match means the
+	// email changed or the account was archived since the link was sent
+	matched, err := s.repo.MarkEmailVerified(ctx, userID, email)
+	if err != nil {
+		return LoginRes{}, err
+	}
+	if !matched {
+		return LoginRes{}, ErrMagicLinkInvalid
+	}
+
+	userRole, err := s.repo.GetUserRoleById(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return LoginRes{}, errors.New("user role not found")
+		}
+		return LoginRes{}, err
+	}
+	res, err := s.issueLoginTokens(ctx, userID, userID.String(), userRole)
+	if err != nil {
+		return LoginRes{}, err
+	}
+	if !res.MFARequired && !res.MFAEnrollmentRequired {
+		s.repo.ClearLoginFailures(ctx, loginSubjectEmail(email))
+	}
+	s.logger.GetLogger().Info("Magic link login successful", zap.String("userID", userID.String()), zap.Bool("mfaRequired", res.MFARequired))
+	return res, nil
+}
+
+// OIDCLogin signs in with an id token of any configured provider, the first login links the identity
+// to the account with the same email and later logins find it by issuer and subject
+// OIDCLogin only counts failures against the ip, a valid id token proves the account so a locked
+// email doesn't block it
+func (s *userServiceStruct) OIDCLogin(ctx context.Context, providerName, idToken string, client models.ClientInfo) (LoginRes, error) {
+	event := newAuthEvent(auditservice.AuthEventLogin, providerName, client)
+	res, err := s.oidcLogin(ctx, providerName, idToken, client.IP, &event)
+	s.recordLoginEvent(ctx, event, res, err)
+	return res, err
+}
+
+func (s *userServiceStruct) oidcLogin(ctx context.Context, providerName, idToken, clientIP string, event *auditservice.AuthEvent) (LoginRes, error) {
+	s.logger.GetLogger().Info("starting oidc authentication", zap.String("provider", providerName))
+	provider, ok := s.oidc[providerName]
+	if !ok {
+		return LoginRes{}, ErrOIDCProviderUnknown
+	}
+	if err := s.checkLoginLock(ctx, "", clientIP); err != nil {
+		return LoginRes{}, err
+	}
+	identity, err := provider.VerifyIDToken(ctx, idToken)
+	if err != nil {
+		s.logger.GetLogger().Error("invalid id token received during oidc login", zap.String("provider", providerName), zap.Error(err))
+		if errors.Is(err, models.ErrFirebaseUnavailable) {
+			// an outage says nothing about the caller, it must not count towards a lockout
+			return LoginRes{}, err
+		}
+		if lockErr := s.recordLoginFailure(ctx, "", clientIP, nil); lockErr != nil {
+			return LoginRes{}, lockErr
+		}
+		return LoginRes{}, fmt.Errorf("invalid id token: %w", err)
+	}
+	email := identity.Email
+	event.Identifier = email
+	if email == "" {
+		s.logger.GetLogger().Error("email not found in id token", zap.String("provider", providerName), zap.String("subject", identity.Subject))
+		return LoginRes{}, fmt.Errorf("email not found in id token")
+	}
+
+	userID, err := s.repo.GetUserIDByIdentity(ctx, identity.Issuer, identity.Subject)
+	if errors.Is(err, sql.ErrNoRows) {
+		userID, err = s.linkOIDCUser(ctx, identity)
+	}
+	if err != nil {
+		return LoginRes{}, err
+	}
+	event.UserID = &userID
+	if err = s.repo.LinkIdentity(ctx, userID, identity); err != nil {
+		return LoginRes{}, err
+	}
+
+	if identity.EmailVerified {
+		if _, err = s.repo.MarkEmailVerified(ctx, userID, email); err != nil {
+			return LoginRes{}, err
+		}
+	}
+	if err = s.checkEmailVerified(ctx, userID); err != nil {
+		return LoginRes{}, err
+	}
+	if identity.Role != "" {
+		if err = s.syncMappedRole(ctx, userID, identity); err != nil {
+			return LoginRes{}, err
+		}
+	}
+
+	role, err := s.repo.GetUserRoleById(ctx, userID)
+	if err != nil {
+		s.logger.GetLogger().Error("failed to get user role during oidc login", zap.String("userID", userID.String()), zap.Error(err))
+		return LoginRes{}, fmt.Errorf("failed to get role: %w", err)
+	}
+
+	res, err := s.issueLoginTokens(ctx, userID, userID.String(), role)
+	if err != nil {
+		return LoginRes{}, err
+	}
+	s.logger.GetLogger().Info("oidc authentication completed successfully", zap.String("provider", providerName), zap.String("userID", userID.String()), zap.Bool("mfaRequired", res.MFARequired))
+	return res, nil
+}
+
+// linkOIDCUser finds the account for a first time identity by email, or registers one. An existing account
+// is only taken over when the provider vouches for the email, otherwise anyone able to set that address
+// at the provider could sign in as its owner
+func (s *userServiceStruct) linkOIDCUser(ctx context.Context, identity models.OIDCIdentity) (uuid.UUID, error) {
+	userID, err := s.repo.GetUserByEmail(ctx, identity.Email)
+	if err == nil {
+		if !identity.EmailVerified {
+			s.logger.GetLogger().Warn("refusing to link unverified email to existing user", zap.String("provider", identity.Provider), zap.String("email", identity.Email))
+			return uuid.Nil, ErrEmailNotVerified
+		}
+		s.logger.GetLogger().Info("linking identity to existing user", zap.String("provider", identity.Provider), zap.String("userID", userID.String()))
+		return userID, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		s.logger.GetLogger().Error("failed to get user by email during oidc login", zap.String("email", identity.Email), zap.Error(err))
+		return uuid.Nil, err
+	}
+
+	s.logger.GetLogger().Info("user not found, creating new user account", zap.String("provider", identity.Provider), zap.String("email", identity.Email))
+	if err = s.checkEmailDomain(identity.Email); err != nil {
+		return uuid.Nil, err
+	}
+	userID, err = s.repo.CreateFirebaseUser(ctx, identity.Name, identity.Email)
+	if err != nil {
+		s.logger.GetLogger().Error("failed to register new oidc user", zap.String("email", identity.Email), zap.Error(err))
+		return uuid.Nil, fmt.Errorf("failed to register user: %w", err)
+	}
+	s.logger.GetLogger().Info("new user created successfully via oidc login", zap.String("userID", userID.String()))
+	s.repo.InvalidateUserCache(ctx, userID, identity.Email)
+	if err := s.emitUserCreated(ctx, userID, nil, identity.Email, string(models.EmployeeRole), identity.Provider); err != nil {
+		s.logger.GetLogger().Warn("failed to emit user created event", zap.String("userID", userID.String()), zap.Error(err))
+	}
+	return userID, nil
+}
+
+// syncMappedRole keeps the role in line with the provider's groups, the provider is the source of
+// truth for users it maps. Roles it names that don't exist here are ignored
+func (s *userServiceStruct) syncMappedRole(ctx context.Context, userID uuid.UUID, identity models.OIDCIdentity) error {
+	current, err := s.repo.GetUserRoleById(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get role: %w", err)
+	}
+	if current == identity.Role {
+		return nil
+	}
+	exists, err := s.repo.IsRoleExists(ctx, identity.Role)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		s.logger.GetLogger().Warn("oidc role mapping names an unknown role", zap.String("provider", identity.Provider), zap.String("role", identity.Role))
+		return nil
+	}
+
+	if err := utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		if err := s.repo.UpdateUserRole(ctx, userID, identity.Role, userID); err != nil {
+			return err
+		}
+		if err := s.emitRoleChanged(ctx, userID, nil, identity.Role, identity.Provider); err != nil {
+			return err
+		}
+		s.logger.GetLogger().Info("role synced from oidc claims", zap.String("userID", userID.String()), zap.String("from", current), zap.String("to", identity.Role))
+		return s.audit.Record(ctx, auditservice.AuditEntry{
+			Action:     "user.role_synced",
+			EntityType: "user",
+			EntityID:   userID.String(),
+			OldValue:   map[string]interface{}{"role": current},
+			NewValue:   map[string]interface{}{"role": identity.Role, "provider": identity.Provider},
+		})
+	}); err != nil {
+		return err
+	}
+	s.repo.InvalidateUserCache(ctx, userID)
+	s.syncRoleClaims(ctx, userID)
+	return nil
+}
+
+const (
+	directorySyncCompleted = "completed"
+	directorySyncFailed    = "failed"
+)
+
+// SyncDirectory brings users in line with the directory: new directory users are created, users with the
+// same email are linked, mapped groups set the role and linked users who left or were disabled get archived.
+// Users never seen in the directory are left alone. A dry run only fills the report
+func (s *userServiceStruct) SyncDirectory(ctx context.Context, dryRun bool, triggeredBy *uuid.UUID) (DirectorySyncReport, error) {
+	if s.directory == nil {
+		return DirectorySyncReport{}, ErrDirectoryNotEnabled
+	}
+	if !s.syncMu.TryLock() {
+		return DirectorySyncReport{}, ErrDirectorySyncRunning
+	}
+	defer s.syncMu.Unlock()
+
+	report := DirectorySyncReport{
+		ID:          uuid.New(),
+		Provider:    s.directory.Name(),
+		DryRun:      dryRun,
+		Status:      directorySyncCompleted,
+		TriggeredBy: triggeredBy,
+		Created:     make([]DirectorySyncChange, 0),
+		Linked:      make([]DirectorySyncChange, 0),
+		RoleChanges: make([]DirectorySyncChange, 0),
+		Archived:    make([]DirectorySyncChange, 0),
+		Errors:      make([]DirectorySyncChange, 0),
+		StartedAt:   time.Now(),
+	}
+	err := s.syncDirectory(ctx, &report)
+	if err != nil {
+		report.Status = directorySyncFailed
+		report.Errors = append(report.Errors, DirectorySyncChange{Error: err.Error()})
+	}
+	report.FinishedAt = time.Now()
+	if saveErr := s.repo.InsertDirectorySyncRun(ctx, report); saveErr != nil {
+		s.logger.GetLogger().Error("failed to save directory sync report", zap.String("runID", report.ID.String()), zap.Error(saveErr))
+	}
+	s.logger.GetLogger().Info("directory sync finished",
+		zap.String("runID", report.ID.String()), zap.Bool("dryRun", dryRun), zap.String("status", report.Status),
+		zap.Int("created", len(report.Created)), zap.Int("linked", len(report.Linked)), zap.Int("roleChanges", len(report.RoleChanges)),
+		zap.Int("archived", len(report.Archived)), zap.Int("errors", len(report.Errors)))
+	return report, err
+}
+
+func (s *userServiceStruct) syncDirectory(ctx context.Context, report *DirectorySyncReport) error {
+	users, err := s.directory.ListUsers(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list directory users: %w", err)
+	}
+	report.DirectoryUsers = len(users)
+	linked, err := s.repo.GetDirectoryLinkedUsers(ctx, s.directory.Issuer())
+	if err != nil {
+		return err
+	}
+	linkedBySubject := make(map[string]DirectoryLinkedUser, len(linked))
+	for _, user := range linked {
+		linkedBySubject[user.Subject] = user
+	}
+
+	active := make(map[string]bool, len(users))
+	for _, user := range users {
+		if user.Disabled {
+			continue
+		}
+		if user.Email == "" {
+			report.Errors = append(report.Errors, DirectorySyncChange{Email: user.DN, Error: "directory user has no email"})
+			continue
+		}
+		active[user.ID] = true
+		s.syncDirectoryUser(ctx, report, user, linkedBySubject)
+	}
+
+	// an empty result is far more likely a broken filter or bind than everyone leaving
+	if len(active) == 0 && len(linked) > 0 {
+		return ErrDirectoryEmpty
+	}
+	for _, user := range linked {
+		if !active[user.Subject] {
+			s.archiveDirectoryUser(ctx, report, user)
+		}
+	}
+	return nil
+}
+
+func (s *userServiceStruct) syncDirectoryUser(ctx context.Context, report *DirectorySyncReport, user models.DirectoryUser, linked map[string]DirectoryLinkedUser) {
+	identity := models.OIDCIdentity{
+		Provider:      s.directory.Name(),
+		Issuer:        s.directory.Issuer(),
+		Subject:       user.ID,
+		Email:         user.Email,
+		EmailVerified: true,
+		Name:          user.Name,
+		Role:          user.Role,
+	}
+	fail := func(userID *uuid.UUID, err error) {
+		report.Errors = append(report.Errors, DirectorySyncChange{UserID: userID, Email: user.Email, Error: err.Error()})
+	}
+
+	var userID uuid.UUID
+	if existing, ok := linked[user.ID]; ok {
+		userID = existing.UserID
+	} else {
+		existingID, err := s.repo.GetUserByEmail(ctx, user.Email)
+		switch {
+		case err == nil:
+			userID = existingID
+			if !report.DryRun {
+				if err := s.repo.LinkIdentity(ctx, userID, identity); err != nil {
+					fail(&userID, err)
+					return
+				}
+			}
+			report.Linked = append(report.Linked, DirectorySyncChange{UserID: &userID, Email: user.Email})
+		case errors.Is(err, sql.ErrNoRows):
+			if err := s.checkEmailDomain(user.Email); err != nil {
+				fail(nil, err)
+				return
+			}
+			if report.DryRun {
+				report.Created = append(report.Created, DirectorySyncChange{Email: user.Email, ToRole: user.Role})
+				return
+			}
+			if userID, err = s.repo.CreateFirebaseUser(ctx, user.Name, user.Email); err != nil {
+				fail(nil, err)
+				return
+			}
+			s.repo.InvalidateUserCache(ctx, userID, user.Email)
+			if err := s.repo.LinkIdentity(ctx, userID, identity); err != nil {
+				fail(&userID, err)
+				return
+			}
+			if err := s.audit.Record(ctx, auditservice.AuditEntry{
+				ActorID:    report.TriggeredBy,
+				Action:     "user.directory_created",
+				EntityType: "user",
+				EntityID:   userID.String(),
+				NewValue:   map[string]interface{}{"email": user.Email, "provider": identity.Provider, "run_id": report.ID},
+			}); err != nil {
+				s.logger.GetLogger().Warn("failed to audit directory created user", zap.String("userID", userID.String()), zap.Error(err))
+			}
+			if err := s.emitUserCreated(ctx, userID, report.TriggeredBy, user.Email, string(models.EmployeeRole), identity.Provider); err != nil {
+				s.logger.GetLogger().Warn("failed to emit user created event", zap.String("userID", userID.String()), zap.Error(err))
+			}
+			report.Created = append(report.Created, DirectorySyncChange{UserID: &userID, Email: user.Email})
+		default:
+			fail(nil, err)
+			return
+		}
+	}
+
+	if user.Role == "" {
+		return
+	}
+	current, err := s.repo.GetUserRoleById(ctx, userID)
+	if err != nil {
+		fail(&userID, err)
+		return
+	}
+	if current == user.Role {
+		return
+	}
+	exists, err := s.repo.IsRoleExists(ctx, user.Role)
+	if err != nil {
+		fail(&userID, err)
+		return
+	}
+	if !exists {
+		fail(&userID, fmt.Errorf("mapped role %q does not exist", user.Role))
+		return
+	}
+	change := DirectorySyncChange{UserID: &userID, Email: user.Email, FromRole: current, ToRole: user.Role}
+	if !report.DryRun {
+		if err := s.syncMappedRole(ctx, userID, identity); err != nil {
+			fail(&userID, err)
+			return
+		}
+		s.revokeSessions(ctx, userID, "")
+	}
+	report.RoleChanges = append(report.RoleChanges, change)
+}
+
+// archiveDirectoryUser fails for users still holding assets, they stay in the report's errors until returned
+func (s *userServiceStruct) archiveDirectoryUser(ctx context.Context, report *DirectorySyncReport, user DirectoryLinkedUser) {
+	change := DirectorySyncChange{UserID: &user.UserID, Email: user.Email}
+	if report.DryRun {
+		report.Archived = append(report.Archived, change)
+		return
+	}
+	firebaseUID, err := s.repo.GetFirebaseUID(ctx, user.UserID)
+	if err != nil {
+		s.logger.GetLogger().Warn("failed to look up firebase uid for directory archive", zap.String("userID", user.UserID.String()), zap.Error(err))
+	}
+	if err := s.repo.DeleteUserByID(ctx, user.UserID, report.TriggeredBy); err != nil {
+		change.Error = err.Error()
+		report.Errors = append(report.Errors, change)
+		return
+	}
+	if firebaseUID != "" {
+		if err := s.firebase.DeleteAuthUser(ctx, firebaseUID); err != nil {
+			s.logger.GetLogger().Warn("failed to delete firebase user of archived directory user", zap.String("userID", user.UserID.String()), zap.Error(err))
+		}
+	}
+	s.repo.InvalidateUserCache(ctx, user.UserID, user.Email)
+	s.revokeSessions(ctx, user.UserID, firebaseUID)
+	if err := s.audit.Record(ctx, auditservice.AuditEntry{
+		ActorID:    report.TriggeredBy,
+		Action:     "user.directory_archived",
+		EntityType: "user",
+		EntityID:   user.UserID.String(),
+		OldValue:   map[string]interface{}{"email": user.Email},
+		NewValue:   map[string]interface{}{"provider": report.Provider, "run_id": report.ID},
+	}); err != nil {
+		s.logger.GetLogger().Warn("failed to audit directory archived user", zap.String("userID", user.UserID.String()), zap.Error(err))
+	}
+	if err := s.emit(ctx, eventservice.UserDeleted, user.UserID, report.TriggeredBy, map[string]interface{}{"email": user.Email, "provider": report.Provider}); err != nil {
+		s.logger.GetLogger().Warn("failed to emit directory archived user event", zap.String("userID", user.UserID.String()), zap.Error(err))
+	}
+	report.Archived = append(report.Archived, change)
+}
+
+func (s *userServiceStruct) GetDirectorySyncReports(ctx context.Context, limit, offset int) ([]DirectorySyncReport, error) {
+	runs, err := s.repo.GetDirectorySyncRuns(ctx, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]DirectorySyncReport, 0, len(runs))
+	for _, run := range runs {
+		var report DirectorySyncReport
+		if err := json.Unmarshal(run.Report, &report); err != nil {
+			s.logger.GetLogger().Error("failed to decode directory sync report", zap.String("runID", run.ID.String()), zap.Error(err))
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func loginSubjectEmail(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func loginSubjectIP(ip string) string {
+	return "ip:" + ip
+}
+
+// checkLoginLock fails while the email or the ip is locked out, an empty value skips that check
+func (s *userServiceStruct) checkLoginLock(ctx context.Context, email, clientIP string) error {
+	subjects := make([]string, 0, 2)
+	if email != "" {
+		subjects = append(subjects, loginSubjectEmail(email))
+	}
+	if clientIP != "" {
+		subjects = append(subjects, loginSubjectIP(clientIP))
+	}
+	if len(subjects) == 0 {
+		return nil
+	}
+	retryAfter := time.Until(s.repo.GetLoginLockedUntil(ctx, subjects...))
+	if retryAfter > 0 {
+		s.logger.GetLogger().Warn("login refused, locked out", zap.String("email", email), zap.String("ip", clientIP), zap.Duration("retryAfter", retryAfter))
+		return &LoginLockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed login and locks the email or ip once it reaches its limit,
+// the returned error is only set when this failure caused a lockout
+func (s *userServiceStruct) recordLoginFailure(ctx context.Context, email, clientIP string, userID *uuid.UUID) error {
+	policy := s.config.GetLockoutPolicy()
+	var lockedFor time.Duration
+	if email != "" {
+		lockedFor = max(lockedFor, s.countLoginFailure(ctx, loginSubjectEmail(email), policy.MaxFailures, policy, userID))
+	}
+	if clientIP != "" {
+		lockedFor = max(lockedFor, s.countLoginFailure(ctx, loginSubjectIP(clientIP), policy.MaxFailuresPerIP, policy, nil))
+	}
+	if lockedFor > 0 {
+		return &LoginLockedError{RetryAfter: lockedFor}
+	}
+	return nil
+}
+
+// countLoginFailure returns how long the subject is now locked for, zero while it is under its limit.
+// Without redis there is nothing to count against, the rate limiter still applies then
+func (s *userServiceStruct) countLoginFailure(ctx context.Context, subject string, limit int, policy models.LockoutPolicy, userID *uuid.UUID) time.Duration {
+	failures, err := s.repo.IncrementLoginFailures(ctx, subject, policy.FailureWindow)
+	if err != nil || failures < limit {
+		return 0
+	}
+	lockouts, err := s.repo.IncrementLoginLockouts(ctx, subject, policy.LockoutDecay)
+	if err != nil {
+		return 0
+	}
+	duration := policy.BaseLockout
+	for i := 1; i < lockouts && duration < policy.MaxLockout; i++ {
+		duration *= 2
+	}
+	duration = min(duration, policy.MaxLockout)
+	until := time.Now().Add(duration)
+	if err := s.repo.LockLogin(ctx, subject, until); err != nil {
+		return 0
+	}
+	s.logger.GetLogger().Warn("login locked after repeated failures", zap.String("subject", subject), zap.Int("lockouts", lockouts), zap.Duration("duration", duration))
+
+	details := map[string]interface{}{"failures": failures, "lockouts": lockouts, "locked_until": until}
+	entry := auditservice.AuditEntry{Action: "auth.lockout", EntityType: "login", EntityID: subject, NewValue: details}
+	if userID != nil {
+		entry.EntityType = "user"
+		entry.EntityID = userID.String()
+		details["subject"] = subject
+	}
+	if err := s.audit.Record(ctx, entry); err != nil {
+		s.logger.GetLogger().Warn("failed to audit login lockout", zap.String("subject", subject), zap.Error(err))
+	}
+	return duration
+}
+
+// UnlockLogin lifts a lockout early and resets its backoff
+func (s *userServiceStruct) UnlockLogin(ctx context.Context, adminID uuid.UUID, req UnlockLoginReq) error {
+	subjects := make([]string, 0, 2)
+	if req.Email != "" {
+		subjects = append(subjects, loginSubjectEmail(req.Email))
+	}
+	if req.IP != "" {
+		subjects = append(subjects, loginSubjectIP(req.IP))
+	}
+	for _, subject := range subjects {
+		if err := s.repo.ClearLoginLock(ctx, subject); err != nil {
+			s.logger.GetLogger().Error("failed to unlock login", zap.String("subject", subject), zap.Error(err))
+			return err
+		}
+		if err := s.audit.Record(ctx, auditservice.AuditEntry{
+			ActorID:    &adminID,
+			Action:     "auth.unlocked",
+			EntityType: "login",
+			EntityID:   subject,
+		}); err != nil {
+			s.logger.GetLogger().Warn("failed to audit login unlock", zap.String("subject", subject), zap.Error(err))
+		}
+	}
+	s.logger.GetLogger().Info("login unlocked", zap.Strings("subjects", subjects), zap.String("adminID", adminID.String()))
+	return nil
+}
+
+const (
+	mfaChallengeTTL    = 5 * time.Minute
+	mfaBackupCodeCount = 10
+	mfaMaxAttempts     = 5
+)
+
+// issueLoginTokens hands out the session tokens, or a challenge to finish with a code when mfa is
+// enabled for the user or mandatory for their role
+func (s *userServiceStruct) issueLoginTokens(ctx context.Context, userID uuid.UUID, subject, role string) (LoginRes, error) {
+	enrolled := false
+	mfa, err := s.repo.GetUserMFA(ctx, userID)
+	if err == nil {
+		enrolled = mfa.EnabledAt != nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return LoginRes{}, err
+	}
+	required := enrolled
+	if !required {
+		if required, err = s.repo.IsMFARequiredForRole(ctx, role); err != nil {
+			return LoginRes{}, err
+		}
+	}
+	if required {
+		token, err := middlewareprovider.GenerateMFAChallengeToken(userID.String(), subject, time.Now().Add(mfaChallengeTTL))
+		if err != nil {
+			s.logger.GetLogger().Error("failed to generate mfa challenge token", zap.String("userID", userID.String()), zap.Error(err))
+			return LoginRes{}, err
+		}
+		return LoginRes{UserID: userID, MFARequired: true, MFAEnrollmentRequired: !enrolled, MFAToken: token}, nil
+	}
+
+	accessToken, err := s.AuthMiddleware.GenerateJWT(subject, []string{role})
+	if err != nil {
+		s.logger.GetLogger().Error("Failed to generate access token", zap.String("userID", userID.String()), zap.Error(err))
+		return LoginRes{}, err
+	}
+	refreshToken, err := s.AuthMiddleware.GenerateRefreshToken(ctx, subject)
+	if err != nil {
+		s.logger.GetLogger().Error("Failed to generate refresh token", zap.String("userID", userID.String()), zap.Error(err))
+		return LoginRes{}, err
+	}
+	return LoginRes{UserID: userID, AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// RefreshTokens trades a refresh token for a new pair, the presented token can't be used again
+func (s *userServiceStruct) RefreshTokens(ctx context.Context, req RefreshTokenReq, client models.ClientInfo) (RefreshTokenRes, error) {
+	event := newAuthEvent(auditservice.AuthEventTokenRefresh, "refresh_token", client)
+	subject, accessToken, refreshToken, err := s.AuthMiddleware.RefreshTokens(ctx, req.RefreshToken)
+	event.Identifier = subject
+	if userID, parseErr := uuid.Parse(subject); parseErr == nil {
+		event.UserID = &userID
+	}
+	if err != nil {
+		if errors.Is(err, middlewareprovider.ErrInvalidRefreshToken) || errors.Is(err, middlewareprovider.ErrTokenRevoked) || errors.Is(err, middlewareprovider.ErrRefreshTokenReused) {
+			s.logger.GetLogger().Warn("refresh token rejected", zap.Error(err))
+			err = fmt.Errorf("%w: %v", ErrSessionExpired, err)
 		} else {
-			if commitErr := tx.Commit(); commitErr != nil {
-				s.logger.GetLogger().Error("failed to commit transaction for PublicRegister", zap.Error(commitErr))
-			} else {
-				s.logger.GetLogger().Info("transaction committed successfully for PublicRegister")
+			s.logger.GetLogger().Error("failed to refresh tokens", zap.Error(err))
+		}
+		s.recordAuthEvent(ctx, event, err)
+		return RefreshTokenRes{}, err
+	}
+	s.recordAuthEvent(ctx, event, nil)
+
+	expiresAt, err := middlewareprovider.GetTokenExpiry(accessToken)
+	if err != nil {
+		return RefreshTokenRes{}, err
+	}
+	return RefreshTokenRes{
+		AccessToken:          accessToken,
+		RefreshToken:         refreshToken,
+		AccessTokenExpiresAt: expiresAt,
+	}, nil
+}
+
+// Logout ends the session the refresh token belongs to, the access token in hand lapses on its own shortly after
+func (s *userServiceStruct) Logout(ctx context.Context, userID uuid.UUID, req LogoutReq, client models.ClientInfo) error {
+	event := newAuthEvent(auditservice.AuthEventLogout, "self", client)
+	event.UserID = &userID
+	err := s.AuthMiddleware.RevokeRefreshToken(ctx, userID.String(), req.RefreshToken)
+	if errors.Is(err, middlewareprovider.ErrInvalidRefreshToken) {
+		err = ErrRefreshTokenMismatch
+	} else if err != nil {
+		s.logger.GetLogger().Error("failed to log out", zap.String("userID", userID.String()), zap.Error(err))
+	}
+	s.recordAuthEvent(ctx, event, err)
+	return err
+}
+
+func newAuthEvent(eventType, method string, client models.ClientInfo) auditservice.AuthEvent {
+	return auditservice.AuthEvent{EventType: eventType, Method: method, IPAddress: client.IP, UserAgent: client.UserAgent}
+}
+
+// recordLoginEvent records a login step, a login that only got as far as the mfa challenge isn't a success
+// yet. A completed login is also checked against the user's earlier logins
+func (s *userServiceStruct) recordLoginEvent(ctx context.Context, event auditservice.AuthEvent, res LoginRes, err error) {
+	if event.UserID == nil && res.UserID != uuid.Nil {
+		event.UserID = &res.UserID
+	}
+	if err == nil && res.MFARequired {
+		event.Outcome = auditservice.AuthOutcomeMFARequired
+	}
+	s.recordAuthEvent(ctx, event, err)
+	if err != nil || res.MFARequired || event.UserID == nil || s.logins == nil {
+		return
+	}
+	// the error is logged by the login activity service, the login itself went through
+	_ = s.logins.RecordLogin(ctx, loginactivityservice.LoginAttempt{
+		UserID:    *event.UserID,
+		Method:    event.Method,
+		IPAddress: event.IPAddress,
+		UserAgent: event.UserAgent,
+	})
+}
+
+// recordAuthEvent fills the outcome from err when it isn't set, failing to record never fails the attempt itself
+func (s *userServiceStruct) recordAuthEvent(ctx context.Context, event auditservice.AuthEvent, err error) {
+	if event.Outcome == "" {
+		switch {
+		case err == nil:
+			event.Outcome = auditservice.AuthOutcomeSuccess
+		case errors.Is(err, ErrLoginLocked):
+			event.Outcome = auditservice.AuthOutcomeLocked
+		default:
+			event.Outcome = auditservice.AuthOutcomeFailure
+		}
+	}
+	if err != nil {
+		event.Reason = err.Error()
+	}
+	if recordErr := s.audit.RecordAuthEvent(ctx, event); recordErr != nil {
+		s.logger.GetLogger().Warn("failed to record auth event", zap.String("eventType", event.EventType), zap.String("outcome", event.Outcome), zap.Error(recordErr))
+	}
+}
+
+// VerifyMFAChallenge finishes a login, for a pending enrollment the first valid code also activates mfa
+func (s *userServiceStruct) VerifyMFAChallenge(ctx context.Context, req MFAChallengeReq, client models.ClientInfo) (LoginRes, error) {
+	event := newAuthEvent(auditservice.AuthEventMFAVerification, "totp", client)
+	res, err := s.verifyMFAChallenge(ctx, req, client.IP, &event)
+	s.recordLoginEvent(ctx, event, res, err)
+	return res, err
+}
+
+func (s *userServiceStruct) verifyMFAChallenge(ctx context.Context, req MFAChallengeReq, clientIP string, event *auditservice.AuthEvent) (LoginRes, error) {
+	userID, subject, err := s.parseMFAChallenge(req.MFAToken)
+	if err != nil {
+		return LoginRes{}, err
+	}
+	event.UserID = &userID
+	email, err := s.repo.GetEmailByUserID(ctx, userID)
+	if err != nil {
+		return LoginRes{}, err
+	}
+	event.Identifier = email
+	if err = s.checkLoginLock(ctx, email, clientIP); err != nil {
+		return LoginRes{}, err
+	}
+	mfa, err := s.repo.GetUserMFA(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return LoginRes{}, ErrMFANotEnrolled
+		}
+		return LoginRes{}, err
+	}
+	if mfa.EnabledAt == nil {
+		err = s.ActivateMFA(ctx, userID, req.Code)
+	} else {
+		err = s.checkMFACode(ctx, userID, mfa, req.Code, true)
+	}
+	if err != nil {
+		if errors.Is(err, ErrMFAInvalidCode) || errors.Is(err, ErrMFATooManyAttempts) {
+			if lockErr := s.recordLoginFailure(ctx, email, clientIP, &userID); lockErr != nil {
+				return LoginRes{}, lockErr
 			}
 		}
-	}()
-
-	//validate email
-	splitEmail := strings.Split(req.Email, "@")
-	if len(splitEmail) != 2 || splitEmail[1] != "remotestate.com" {
-		s.logger.GetLogger().Warn("Invalid email domain for public registration", zap.String("email", req.Email))
-		return uuid.Nil, "", errors.New("only remotestate.com domain is valid")
+		return LoginRes{}, err
 	}
+	s.repo.ClearLoginFailures(ctx, loginSubjectEmail(email))
 
-	//extract username from email
-	usernameParts := strings.Split(splitEmail[0], ".")
-	if len(usernameParts) != 2 || usernameParts[0] == "" || usernameParts[1] == "" {
-		s.logger.GetLogger().Warn("Invalid email format for username extraction in PublicRegister", zap.String("email", req.Email))
-		return uuid.Nil, "", errors.New("invalid email format for username")
+	role, err := s.repo.GetUserRoleById(ctx, userID)
+	if err != nil {
+		s.logger.GetLogger().Error("failed to get user role after mfa challenge", zap.String("userID", userID.String()), zap.Error(err))
+		return LoginRes{}, err
 	}
-	username := usernameParts[0] + " " + usernameParts[1]
-	s.logger.GetLogger().Debug("Parsed username from email", zap.String("username", username))
-
-	//checking firebase db
-	firebaseUID, err := s.firebase.GetAuthUserID(ctx, req.Email)
-	if err != nil && !firebaseauth.IsUserNotFound(err) {
-		s.logger.GetLogger().Error("Failed to check Firebase user", zap.String("email", req.Email), zap.Error(err))
-		return uuid.Nil, "", fmt.Errorf("firebase lookup failed: %w", err)
+	accessToken, err := s.AuthMiddleware.GenerateJWT(subject, []string{role})
+	if err != nil {
+		return LoginRes{}, err
 	}
-	if firebaseUID != "" {
-		s.logger.GetLogger().Warn("User already exists in Firebase", zap.String("firebaseUID", firebaseUID))
-		return uuid.Nil, "", errors.New("user already exists in Firebase")
+	refreshToken, err := s.AuthMiddleware.GenerateRefreshToken(ctx, subject)
+	if err != nil {
+		return LoginRes{}, err
 	}
+	s.logger.GetLogger().Info("mfa challenge completed", zap.String("userID", userID.String()))
+	return LoginRes{UserID: userID, AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
 
-	//create user in Firebase
-	firebaseUserRecord, err := s.firebase.CreateUser(ctx, req.Email)
+// EnrollMFAForChallenge lets a user whose role mandates mfa enroll before they have a session
+func (s *userServiceStruct) EnrollMFAForChallenge(ctx context.Context, req MFATokenReq) (MFAEnrollmentRes, error) {
+	userID, _, err := s.parseMFAChallenge(req.MFAToken)
 	if err != nil {
-		s.logger.GetLogger().Error("Failed to create user in Firebase", zap.Error(err))
-		return uuid.Nil, "", fmt.Errorf("firebase creation failed: %w", err)
+		return MFAEnrollmentRes{}, err
 	}
-	s.logger.GetLogger().Info("Firebase user created", zap.String("firebaseUID", firebaseUserRecord.UID))
+	return s.EnrollMFA(ctx, userID)
+}
 
-	//check if user already exist in our db
-	exists, err := s.repo.IsUserExists(ctx, tx, req.Email)
+func (s *userServiceStruct) parseMFAChallenge(token string) (uuid.UUID, string, error) {
+	sub, subject, err := middlewareprovider.ParseMFAChallengeToken(token)
 	if err != nil {
-		s.logger.GetLogger().Error("Failed to check if user exists in PublicRegister", zap.Error(err))
-		return uuid.Nil, "", err
+		s.logger.GetLogger().Warn("invalid mfa challenge token", zap.Error(err))
+		return uuid.Nil, "", ErrMFAChallengeInvalid
 	}
-	if exists {
-		s.logger.GetLogger().Warn("User already registered during public registration attempt in postgresSQL database", zap.String("email", req.Email))
-		return uuid.Nil, "", errors.New("email already registered in postgresSQL database")
+	userID, err := uuid.Parse(sub)
+	if err != nil {
+		return uuid.Nil, "", ErrMFAChallengeInvalid
 	}
+	return userID, subject, nil
+}
 
-	// Insert user into your DB
-	userID, err := s.repo.InsertIntoUser(ctx, tx, username, req.Email, firebaseUserRecord.UID)
+// EnrollMFA starts (or restarts) enrollment, mfa only becomes active once ActivateMFA confirms a code
+func (s *userServiceStruct) EnrollMFA(ctx context.Context, userID uuid.UUID) (res MFAEnrollmentRes, err error) {
+	s.logger.GetLogger().Info("starting mfa enrollment", zap.String("userID", userID.String()))
+	email, err := s.repo.GetEmailByUserID(ctx, userID)
 	if err != nil {
-		s.logger.GetLogger().Error("Failed to insert into users table during PublicRegister", zap.Error(err))
-		return uuid.Nil, "", err
+		return res, err
 	}
-	s.logger.GetLogger().Info("New user inserted into users table", zap.String("userID", userID.String()))
-
-	if err = s.repo.InsertIntoUserRole(ctx, tx, userID, "employee", userID); err != nil {
-		s.logger.GetLogger().Error("Failed to insert user role during PublicRegister", zap.Error(err), zap.String("userID", userID.String()))
-		return uuid.Nil, "", err
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return res, fmt.Errorf("failed to generate mfa secret: %w", err)
 	}
-	s.logger.GetLogger().Debug("Assigned user role 'employee'", zap.String("userID", userID.String()))
-
-	if err = s.repo.InsertIntoUserType(ctx, tx, userID, "full_time", userID); err != nil {
-		s.logger.GetLogger().Error("Failed to insert user type during PublicRegister", zap.Error(err), zap.String("userID", userID.String()))
-		return uuid.Nil, "", err
+	encrypted, err := utils.EncryptSecret(s.config.GetMFAEncryptionKey(), secret)
+	if err != nil {
+		return res, fmt.Errorf("failed to encrypt mfa secret: %w", err)
 	}
-	s.logger.GetLogger().Debug("Assigned user type 'full_time'", zap.String("userID", userID.String()))
-
-	s.logger.GetLogger().Info("Public registration completed successfully", zap.String("userID", userID.String()))
-	return userID, firebaseUserRecord.UID, nil
-}
-
-//func (s *userService) PublicRegister(ctx context.Context, req PublicUserReq) (uuid.UUID, error) {
-//	s.logger.GetLogger().Info("starting public registration service", zap.String("email", req.Email))
-//	tx, err := s.db.BeginTxx(ctx, nil)
-//	if err != nil {
-//		s.logger.GetLogger().Error("failed to begin transaction for PublicRegister", zap.Error(err))
-//		return uuid.Nil, err
-//	}
-//	defer func() {
-//		if r := recover(); r != nil {
-//			s.logger.GetLogger().Error("panic recovered in PublicRegister", zap.Any("recover_info", r))
-//			tx.Rollback()
-//		} else if err != nil {
-//			s.logger.GetLogger().Error("rolling back transaction for PublicRegister due to error", zap.Error(err))
-//			tx.Rollback()
-//		} else {
-//			if commitErr := tx.Commit(); commitErr != nil {
-//				s.logger.GetLogger().Error("failed to commit transaction for PublicRegister", zap.Error(commitErr))
-//			} else {
-//				s.logger.GetLogger().Info("transaction committed successfully for PublicRegister")
-//			}
-//		}
-//	}()
-//
-//	splitEmail := strings.Split(req.Email, "@")
-//	if len(splitEmail) != 2 || splitEmail[1] != "remotestate.com" {
-//		s.logger.GetLogger().Warn("Invalid email domain for public registration", zap.String("email", req.Email))
-//		return uuid.Nil, errors.New("only remotestate.com domain is valid")
-//	}
-//
-//	usernameParts := strings.Split(splitEmail[0], ".")
-//	if len(usernameParts) != 2 || usernameParts[0] == "" || usernameParts[1] == "" {
-//		s.logger.GetLogger().Warn("Invalid email format for username extraction in PublicRegister", zap.String("email", req.Email))
-//		return uuid.Nil, errors.New("invalid email format for username")
-//	}
-//	username := usernameParts[0] + " " + usernameParts[1]
-//
-//	s.logger.GetLogger().Debug("Parsed username from email", zap.String("username", username))
-//
-//	exists, err := s.repo.IsUserExists(ctx, tx, req.Email)
-//	if err != nil {
-//		s.logger.GetLogger().Error("Failed to check if user exists in PublicRegister", zap.Error(err))
-//		return uuid.Nil, err
-//	}
-//	if exists {
-//		s.logger.GetLogger().Warn("User already registered during public registration attempt", zap.String("email", req.Email))
-//		return uuid.Nil, errors.New("email already registered...")
-//	}
-//
-//	userID, err := s.repo.InsertIntoUser(ctx, tx, username, req.Email)
-//	if err != nil {
-//		s.logger.GetLogger().Error("Failed to insert into users table during PublicRegister", zap.Error(err))
-//		return uuid.Nil, err
-//	}
-//	s.logger.GetLogger().Info("New user inserted into users table", zap.String("userID", userID.String()))
-//
-//	if err = s.repo.InsertIntoUserRole(ctx, tx, userID, "employee", userID); err != nil {
-//		s.logger.GetLogger().Error("Failed to insert user role during PublicRegister", zap.Error(err), zap.String("userID", userID.String()))
-//		return uuid.Nil, err
-//	}
-//	s.logger.GetLogger().Debug("Assigned user role 'employee'", zap.String("userID", userID.String()))
-//
-//	if err = s.repo.InsertIntoUserType(ctx, tx, userID, "full_time", userID); err != nil {
-//		s.logger.GetLogger().Error("Failed to insert user type during PublicRegister", zap.Error(err), zap.String("userID", userID.String()))
-//		return uuid.Nil, err
-//	}
-//	s.logger.GetLogger().Debug("Assigned user type 'full_time'", zap.String("userID", userID.String()))
-//	s.logger.GetLogger().Info("Public registration completed successfully", zap.String("userID", userID.String()))
-//	return userID, nil
-//}
-
-func (s *userServiceStruct) RegisterEmployeeByManager(ctx context.Context, req ManagerRegisterReq, managerID uuid.UUID) (uuid.UUID, error) {
-	s.logger.GetLogger().Info("Starting employee registration by manager", zap.String("managerID", managerID.String()), zap.String("employeeEmail", req.Email))
-
-	tx, err := s.db.BeginTxx(ctx, nil)
+	codes, err := utils.GenerateBackupCodes(mfaBackupCodeCount)
 	if err != nil {
-		s.logger.GetLogger().Error("Failed to begin transaction for RegisterEmployeeByManager", zap.Error(err))
-		return uuid.Nil, err
+		return res, fmt.Errorf("failed to generate backup codes: %w", err)
 	}
 
-	defer func() {
-		if r := recover(); r != nil {
-			s.logger.GetLogger().Error("Panic recovered during RegisterEmployeeByManager transaction", zap.Any("recover_info", r))
-			_ = tx.Rollback()
-		} else if err != nil {
-			s.logger.GetLogger().Error("Rolling back transaction for RegisterEmployeeByManager due to error", zap.Error(err))
-			_ = tx.Rollback()
-		} else {
-			if commitErr := tx.Commit(); commitErr != nil {
-				s.logger.GetLogger().Error("Failed to commit transaction for RegisterEmployeeByManager", zap.Error(commitErr))
-			} else {
-				s.logger.GetLogger().Info("Transaction committed successfully for RegisterEmployeeByManager")
-			}
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		saved, err := s.repo.SavePendingMFA(ctx, userID, encrypted)
+		if err != nil {
+			return err
 		}
-	}()
-
-	// Create Firebase user
-	userRecord, err := s.firebase.CreateUser(ctx, req.Email)
+		if !saved {
+			return ErrMFAAlreadyEnabled
+		}
+		return s.repo.ReplaceBackupCodes(ctx, userID, hashBackupCodes(codes))
+	})
 	if err != nil {
-		s.logger.GetLogger().Error("Failed to create Firebase user", zap.Error(err))
-		return uuid.Nil, fmt.Errorf("firebase user creation failed: %w", err)
+		return res, err
 	}
-	s.logger.GetLogger().Info("Firebase user created", zap.String("firebaseUID", userRecord.UID))
+	return MFAEnrollmentRes{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(s.config.GetMFAIssuer(), email, secret),
+		BackupCodes:     codes,
+	}, nil
+}
 
-	userID, err := s.repo.CreateNewEmployee(ctx, tx, req, managerID)
-	if err != nil {
-		s.logger.GetLogger().Error("Failed to create new employee in repository", zap.Error(err), zap.String("managerID", managerID.String()))
-		return uuid.Nil, err
+// SetTimezone stores the user's timezone, an empty one goes back to UTC. Dates the user picks are
+// read in it and the timestamps of their timelines and dashboard come back in it
+func (s *userServiceStruct) SetTimezone(ctx context.Context, userID uuid.UUID, timezone string) (TimezoneRes, error) {
+	var stored *string
+	if timezone != "" {
+		// Local would be the server's timezone, not one the user can name
+		loc, err := time.LoadLocation(timezone)
+		if err != nil || timezone == "Local" {
+			return TimezoneRes{}, ErrInvalidTimezone
+		}
+		name := loc.String()
+		stored = &name
 	}
-	s.logger.GetLogger().Info("Employee registered successfully by manager", zap.String("managerID", managerID.String()), zap.String("employeeID", userID.String()))
-
-	return userID, nil
+	if err := s.repo.SetTimezone(ctx, userID, stored); err != nil {
+		s.logger.GetLogger().Error("failed to set timezone", zap.String("userID", userID.String()), zap.Error(err))
+		return TimezoneRes{}, err
+	}
+	return TimezoneRes{Timezone: stored}, nil
 }
 
-func (s *userServiceStruct) UpdateEmployee(ctx context.Context, req UpdateEmployeeReq, managerID uuid.UUID) error {
-	s.logger.GetLogger().Info("Attempting to update employee information")
-	err := s.repo.UpdateEmployeeInfo(ctx, req, managerID)
+func (s *userServiceStruct) ActivateMFA(ctx context.Context, userID uuid.UUID, code string) error {
+	mfa, err := s.repo.GetUserMFA(ctx, userID)
 	if err != nil {
-		s.logger.GetLogger().Error("failed to update employee information in repository")
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrMFANotEnrolled
+		}
 		return err
 	}
-	s.logger.GetLogger().Info("employee information updated successfully")
-	return nil
-}
-
-func (s *userServiceStruct) GetDashboard(ctx context.Context, userID uuid.UUID) (UserDashboardRes, error) {
-	s.logger.GetLogger().Info("Fetching user dashboard data", zap.String("userID", userID.String()))
-	dashboard, err := s.repo.GetUserDashboardById(ctx, userID)
+	if mfa.EnabledAt != nil {
+		return ErrMFAAlreadyEnabled
+	}
+	step, err := s.validateTOTP(ctx, userID, mfa, code)
 	if err != nil {
-		s.logger.GetLogger().Error("Failed to get user dashboard by ID", zap.String("userID", userID.String()), zap.Error(err))
-		return UserDashboardRes{}, err
+		return err
 	}
-	s.logger.GetLogger().Info("Successfully fetched user dashboard data", zap.String("userID", userID.String()))
-	return dashboard, nil
+	if err = s.repo.EnableMFA(ctx, userID, step); err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("mfa enabled", zap.String("userID", userID.String()))
+	return nil
 }
 
-func (s *userServiceStruct) UserLogin(ctx context.Context, req PublicUserReq) (uuid.UUID, string, string, error) {
-	s.logger.GetLogger().Info("Attempting user login", zap.String("email", req.Email))
-	userID, err := s.repo.GetUserByEmail(ctx, req.Email)
+// DisableMFA needs a current code and is refused while the user's role mandates mfa
+func (s *userServiceStruct) DisableMFA(ctx context.Context, userID uuid.UUID, code string) (err error) {
+	mfa, err := s.getEnabledMFA(ctx, userID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			s.logger.GetLogger().Warn("Login failed: User not found for email", zap.String("email", req.Email))
-			return uuid.Nil, "", "", errors.New("invalid email")
-		}
-		s.logger.GetLogger().Error("Failed to get user by email during login", zap.String("email", req.Email), zap.Error(err))
-		return uuid.Nil, "", "", err
+		return err
 	}
-	s.logger.GetLogger().Debug("User found for login", zap.String("userID", userID.String()))
-
-	userRole, err := s.repo.GetUserRoleById(ctx, userID)
+	role, err := s.repo.GetUserRoleById(ctx, userID)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			s.logger.GetLogger().Error("Login failed: User exists but no role found", zap.String("userID", userID.String()), zap.Error(err))
-			return uuid.Nil, "", "", errors.New("user role not found")
-		}
-		s.logger.GetLogger().Error("Failed to get user role by ID during login", zap.String("userID", userID.String()), zap.Error(err))
-		return uuid.Nil, "", "", err
+		return err
 	}
-	s.logger.GetLogger().Debug("User role retrieved for login", zap.String("userID", userID.String()), zap.String("role", userRole))
-
-	//accessToken, err := middlewares.GenerateJWT(userID.String(), []string{userRole})
-	accessToken, err := s.AuthMiddleware.GenerateJWT(userID.String(), []string{userRole})
+	required, err := s.repo.IsMFARequiredForRole(ctx, role)
 	if err != nil {
-		s.logger.GetLogger().Error("Failed to generate access token during login", zap.String("userID", userID.String()), zap.Error(err))
-		return uuid.Nil, "", "", err
+		return err
 	}
-	refreshToken, err := s.AuthMiddleware.GenerateRefreshToken(userID.String())
-	if err != nil {
-		s.logger.GetLogger().Error("Failed to generate refresh token during login", zap.String("userID", userID.String()), zap.Error(err))
-		return uuid.Nil, "", "", err
+	if required {
+		return ErrMFARequiredByRole
 	}
-	s.logger.GetLogger().Info("User login successful, tokens generated", zap.String("userID", userID.String()))
-	return userID, accessToken, refreshToken, nil
+	if err = s.checkMFACode(ctx, userID, mfa, code, false); err != nil {
+		return err
+	}
+	if err = s.deleteMFA(ctx, userID); err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("mfa disabled", zap.String("userID", userID.String()))
+	return nil
 }
 
-func (s *userServiceStruct) GoogleAuth(ctx context.Context, idToken string) (uuid.UUID, string, string, error) {
-	s.logger.GetLogger().Info("Starting Google authentication process")
-	token, err := s.repo.GetFirebase().VerifyIDToken(ctx, idToken)
-	if err != nil {
-		s.logger.GetLogger().Error("invalid ID token received during GoogleAuth", zap.Error(err))
-		return uuid.Nil, "", "", fmt.Errorf("invalid id token: %w", err)
+// ResetMFA is the admin escape hatch for a user who lost both their device and backup codes
+func (s *userServiceStruct) ResetMFA(ctx context.Context, userID uuid.UUID) error {
+	if err := s.deleteMFA(ctx, userID); err != nil {
+		return err
 	}
-	s.logger.GetLogger().Debug("firebase ID token verified successfully", zap.String("UID", token.UID))
+	s.repo.ResetMFAAttempts(ctx, userID)
+	s.logger.GetLogger().Info("mfa reset", zap.String("userID", userID.String()))
+	return nil
+}
+
+func (s *userServiceStruct) deleteMFA(ctx context.Context, userID uuid.UUID) error {
+	return utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		return s.repo.DeleteUserMFA(ctx, userID)
+	})
+}
 
-	userRecord, err := s.repo.GetFirebase().GetUserByUID(ctx, token.UID)
+func (s *userServiceStruct) RegenerateBackupCodes(ctx context.Context, userID uuid.UUID, code string) (codes []string, err error) {
+	mfa, err := s.getEnabledMFA(ctx, userID)
 	if err != nil {
-		s.logger.GetLogger().Error("failed to get user record from Firebase", zap.String("UID", token.UID), zap.Error(err))
-		return uuid.Nil, "", "", fmt.Errorf("failed to get user info from firebase: %w", err)
+		return nil, err
 	}
-	s.logger.GetLogger().Debug("User record retrieved from Firebase", zap.String("email", userRecord.Email), zap.String("displayName", userRecord.DisplayName))
-	fmt.Println("user records data ::", userRecord.UID)
-	email := userRecord.Email
-	if email == "" {
-		s.logger.GetLogger().Error("email not found in Firebase user record", zap.String("UID", token.UID))
-		return uuid.Nil, "", "", fmt.Errorf("email not found in firebase database")
+	if err = s.checkMFACode(ctx, userID, mfa, code, false); err != nil {
+		return nil, err
+	}
+	codes, err = utils.GenerateBackupCodes(mfaBackupCodeCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate backup codes: %w", err)
 	}
 
-	userID, err := s.repo.GetUserByEmail(ctx, email)
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		return s.repo.ReplaceBackupCodes(ctx, userID, hashBackupCodes(codes))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *userServiceStruct) getEnabledMFA(ctx context.Context, userID uuid.UUID) (UserMFA, error) {
+	mfa, err := s.repo.GetUserMFA(ctx, userID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			s.logger.GetLogger().Info("User not found in PostgreSQL, creating new user account", zap.String("email", email))
-			name := userRecord.DisplayName
-			userID, err = s.repo.CreateFirebaseUser(ctx, name, email)
-			if err != nil {
-				s.logger.GetLogger().Error("Failed to register new Firebase user in PostgreSQL", zap.String("email", email), zap.Error(err))
-				return uuid.Nil, "", "", fmt.Errorf("failed to register user: %w", err)
-			}
-			s.logger.GetLogger().Info("new user created successfully via Google Auth", zap.String("userID", userID.String()))
-		} else {
-			s.logger.GetLogger().Error("failed to get user by email from PostgreSQL during GoogleAuth", zap.String("email", email), zap.Error(err))
-			return uuid.Nil, "", "", err
+			return mfa, ErrMFANotEnabled
 		}
-	} else {
-		s.logger.GetLogger().Info("existing user found in PostgreSQL for Google Auth", zap.String("userID", userID.String()))
+		return mfa, err
 	}
+	if mfa.EnabledAt == nil {
+		return mfa, ErrMFANotEnabled
+	}
+	return mfa, nil
+}
 
-	role, err := s.repo.GetUserRoleById(ctx, userID)
+// checkMFACode accepts a totp code, or a backup code when allowBackup is set
+func (s *userServiceStruct) checkMFACode(ctx context.Context, userID uuid.UUID, mfa UserMFA, code string, allowBackup bool) error {
+	code = strings.TrimSpace(code)
+	if allowBackup && (strings.Contains(code, "-") || len(code) > 6) {
+		if err := s.checkMFALocked(ctx, userID); err != nil {
+			return err
+		}
+		used, err := s.repo.ConsumeBackupCode(ctx, userID, utils.HashBackupCode(code))
+		if err != nil {
+			return err
+		}
+		if !used {
+			return s.failMFAAttempt(ctx, userID)
+		}
+		s.repo.ResetMFAAttempts(ctx, userID)
+		s.logger.GetLogger().Info("backup code used", zap.String("userID", userID.String()))
+		return nil
+	}
+	step, err := s.validateTOTP(ctx, userID, mfa, code)
 	if err != nil {
-		s.logger.GetLogger().Error("failed to get user role from user_roles table during GoogleAuth", zap.String("userID", userID.String()), zap.Error(err))
-		return uuid.Nil, "", "", fmt.Errorf("failed to get role: %w", err)
+		return err
 	}
-	s.logger.GetLogger().Debug("user role retrieved for Google Auth", zap.String("userID", userID.String()), zap.String("role", role))
-
-	accessToken, err := middlewares.GenerateJWT(userRecord.UID, []string{role})
+	consumed, err := s.repo.ConsumeMFAStep(ctx, userID, step)
 	if err != nil {
-		s.logger.GetLogger().Error("failed to generate access token for GoogleAuth", zap.String("userID", userID.String()), zap.Error(err))
-		return uuid.Nil, "", "", err
+		return err
 	}
-	s.logger.GetLogger().Debug("access token generated for GoogleAuth", zap.String("userID", userID.String()))
+	if !consumed {
+		return s.failMFAAttempt(ctx, userID)
+	}
+	return nil
+}
 
-	refreshToken, err := middlewares.GenerateRefreshToken(userRecord.UID)
+func (s *userServiceStruct) validateTOTP(ctx context.Context, userID uuid.UUID, mfa UserMFA, code string) (int64, error) {
+	if err := s.checkMFALocked(ctx, userID); err != nil {
+		return 0, err
+	}
+	secret, err := utils.DecryptSecret(s.config.GetMFAEncryptionKey(), mfa.Secret)
 	if err != nil {
-		s.logger.GetLogger().Error("failed to generate refresh token for GoogleAuth", zap.String("userID", userID.String()), zap.Error(err))
-		return uuid.Nil, "", "", err
+		s.logger.GetLogger().Error("failed to decrypt mfa secret", zap.String("userID", userID.String()), zap.Error(err))
+		return 0, fmt.Errorf("failed to read mfa secret: %w", err)
 	}
-	s.logger.GetLogger().Info("google based authentication completed successfully", zap.String("userID", userID.String()))
-	return userID, accessToken, refreshToken, nil
+	step, ok := utils.ValidateTOTP(secret, strings.TrimSpace(code), time.Now())
+	if !ok {
+		return 0, s.failMFAAttempt(ctx, userID)
+	}
+	s.repo.ResetMFAAttempts(ctx, userID)
+	return step, nil
 }
 
-func (s *userServiceStruct) CreateFirstAdmin() bool {
-	const adminEmail = "systemadmin@remotestate.com"
-	const adminUsername = "System Admin"
-	const Role = "admin"
-	const Type = "full_time"
+// checkMFALocked refuses even a correct code once the attempt limit is hit, otherwise the limit wouldn't stop guessing
+func (s *userServiceStruct) checkMFALocked(ctx context.Context, userID uuid.UUID) error {
+	if s.repo.GetMFAAttempts(ctx, userID) >= mfaMaxAttempts {
+		return ErrMFATooManyAttempts
+	}
+	return nil
+}
 
-	var isExist uuid.UUID
-	err := s.db.Get(&isExist, `
-		SELECT id FROM users 
-		WHERE email = $1 AND archived_at IS NULL
-	`, adminEmail)
-	if err == nil {
-		log.Println("user id already exist", isExist)
-		return false
+func (s *userServiceStruct) failMFAAttempt(ctx context.Context, userID uuid.UUID) error {
+	attempts, err := s.repo.IncrementMFAAttempts(ctx, userID, mfaChallengeTTL)
+	if err == nil && attempts >= mfaMaxAttempts {
+		s.logger.GetLogger().Warn("too many invalid mfa codes", zap.String("userID", userID.String()), zap.Int("attempts", attempts))
+		return ErrMFATooManyAttempts
 	}
+	return ErrMFAInvalidCode
+}
 
-	tx, err := s.db.Beginx()
-	if err != nil {
-		log.Println("transaction failed", err)
-		return false
+func hashBackupCodes(codes []string) []string {
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, utils.HashBackupCode(code))
 	}
+	return hashes
+}
 
-	defer func() {
-		if p := recover(); p != nil || err != nil {
-			tx.Rollback()
-		} else {
-			tx.Commit()
+// CreateAdmin adds an admin who signs in later with a google account or a magic link for the email,
+// it is how assetctl makes the first admin of a new install. The admin is recorded as their own creator
+func (s *userServiceStruct) CreateAdmin(ctx context.Context, req CreateAdminReq) (adminID uuid.UUID, err error) {
+	s.logger.GetLogger().Info("creating admin", zap.String("email", req.Email))
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		exists, err := s.repo.IsUserExists(ctx, req.Email)
+		if err != nil {
+			return err
 		}
-	}()
-
-	var adminID uuid.UUID
-	err = tx.Get(&adminID, `
-		INSERT INTO users (username, email)
-		VALUES ($1, $2)
-		RETURNING id
-	`, adminUsername, adminEmail)
+		if exists {
+			return ErrUserAlreadyExists
+		}
+		err = utils.Conn(ctx, s.db).GetContext(ctx, &adminID, `
+			INSERT INTO users (username, email)
+			VALUES ($1, $2)
+			RETURNING id
+		`, req.Username, req.Email)
+		if err != nil {
+			s.logger.GetLogger().Error("failed to insert admin", zap.String("email", req.Email), zap.Error(err))
+			return fmt.Errorf("failed to insert admin: %w", err)
+		}
+		if _, err = utils.Conn(ctx, s.db).ExecContext(ctx, `UPDATE users SET created_by = $1 WHERE id = $1`, adminID); err != nil {
+			return fmt.Errorf("failed to update created_by: %w", err)
+		}
+		if err = s.repo.InsertIntoUserRole(ctx, adminID, string(models.AdminRole), adminID); err != nil {
+			return err
+		}
+		if err = s.repo.InsertIntoUserType(ctx, adminID, req.Type, adminID); err != nil {
+			return err
+		}
+		if err = s.emitUserCreated(ctx, adminID, nil, req.Email, string(models.AdminRole), "assetctl"); err != nil {
+			return err
+		}
+		s.logger.GetLogger().Info("admin created", zap.String("userID", adminID.String()), zap.String("email", req.Email))
+		return s.audit.Record(ctx, auditservice.AuditEntry{
+			Action:     "user.admin_created",
+			EntityType: "user",
+			EntityID:   adminID.String(),
+			NewValue:   map[string]interface{}{"email": req.Email, "role": models.AdminRole, "source": "assetctl"},
+		})
+	})
 	if err != nil {
-		log.Println("failed to create new admin", err)
-		return false
+		return uuid.Nil, err
 	}
+	s.repo.InvalidateUserCache(ctx, adminID, req.Email)
+	return adminID, nil
+}
 
-	_, err = tx.Exec(`
-		INSERT INTO user_roles (role, user_id, created_by)
-		VALUES ($1, $2, $2)
-	`, Role, adminID)
+// ResetUserRoles archives every role of the user and leaves them with role alone. Unlike ChangeUserRole it
+// needs no second admin, it is the way back in for assetctl when the admins are locked out
+func (s *userServiceStruct) ResetUserRoles(ctx context.Context, userID uuid.UUID, role string) error {
+	s.logger.GetLogger().Info("resetting user roles", zap.String("userID", userID.String()), zap.String("role", role))
+	exists, err := s.repo.IsRoleExists(ctx, role)
 	if err != nil {
-		log.Println("failed to assign role", err)
-		return false
+		return err
+	}
+	if !exists {
+		return ErrUnknownRole
+	}
+	// a user left without any role is what a reset is for, the old role is then recorded as empty
+	current, roleErr := s.repo.GetUserRoleById(ctx, userID)
+	if roleErr != nil {
+		s.logger.GetLogger().Warn("no current role found for role reset", zap.String("userID", userID.String()), zap.Error(roleErr))
 	}
 
-	_, err = tx.Exec(`
-		INSERT INTO user_type (type, user_id, created_by)
-		VALUES ($1, $2, $2)
-	`, Type, adminID)
-	if err != nil {
-		log.Println("failed to assign user type", err)
-		return false
+	if err := utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		if err := s.repo.ArchiveUserRoles(ctx, userID, userID); err != nil {
+			return err
+		}
+		if err := s.repo.InsertUserRole(ctx, userID, role, userID); err != nil {
+			return err
+		}
+		if err := s.emitRoleChanged(ctx, userID, nil, role, "assetctl"); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, auditservice.AuditEntry{
+			Action:     "user.roles_reset",
+			EntityType: "user",
+			EntityID:   userID.String(),
+			OldValue:   map[string]interface{}{"role": current},
+			NewValue:   map[string]interface{}{"role": role, "source": "assetctl"},
+		})
+	}); err != nil {
+		return err
 	}
-	log.Println("admin created", adminID)
-	return true
+	s.roleChanged(ctx, userID)
+	return nil
+}
+
+// GetUserIDByEmail returns ErrUserNotFound for unknown or archived users
+func (s *userServiceStruct) GetUserIDByEmail(ctx context.Context, email string) (uuid.UUID, error) {
+	userID, err := s.repo.GetUserByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return uuid.Nil, ErrUserNotFound
+	}
+	return userID, err
 }
 
 type FirebaseRegistrationResponse struct {
@@ -553,7 +3110,10 @@ func (s *userServiceStruct) FirebaseUserRegistration(ctx context.Context, idToke
 	token, err := s.firebase.VerifyIDToken(ctx, idToken)
 	if err != nil {
 		s.logger.GetLogger().Error("Firebase token verification failed", zap.Error(err))
-		return nil, errors.New("invalid firebase token")
+		if errors.Is(err, models.ErrFirebaseUnavailable) {
+			return nil, err
+		}
+		return nil, ErrInvalidFirebaseToken
 	}
 
 	firebaseUID := token.UID
@@ -563,7 +3123,10 @@ func (s *userServiceStruct) FirebaseUserRegistration(ctx context.Context, idToke
 
 	if email == "" || displayName == "" {
 		s.logger.GetLogger().Warn("Missing email or display name in token")
-		return nil, errors.New("cannot register without email or display name")
+		return nil, ErrMissingProfileClaims
+	}
+	if err = s.checkEmailDomain(email); err != nil {
+		return nil, err
 	}
 
 	//create new fireabase recod if not exist
@@ -585,69 +3148,71 @@ func (s *userServiceStruct) FirebaseUserRegistration(ctx context.Context, idToke
 		s.logger.GetLogger().Info("User already exists in Firebase", zap.String("firebaseUID", userRecord.UID))
 	}
 
-	//check if user exists in DB
-	tx, err := s.db.BeginTxx(ctx, nil)
-	if err != nil {
-		s.logger.GetLogger().Error("Failed to begin DB transaction", zap.Error(err))
-		return nil, err
-	}
-	defer func() {
-		if r := recover(); r != nil {
-			s.logger.GetLogger().Error("Panic recovered in FirebaseUserRegistration", zap.Any("recover_info", r))
-			_ = tx.Rollback()
-		} else if err != nil {
-			s.logger.GetLogger().Error("Rolling back transaction", zap.Error(err))
-			_ = tx.Rollback()
-		} else {
-			if commitErr := tx.Commit(); commitErr != nil {
-				s.logger.GetLogger().Error("Failed to commit transaction", zap.Error(commitErr))
-			} else {
-				s.logger.GetLogger().Info("Transaction committed successfully")
-			}
+	var userID uuid.UUID
+	verified, _ := claims["email_verified"].(bool)
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		//check if user exists in DB
+		exists, err := s.repo.IsUserExists(ctx, email)
+		if err != nil {
+			s.logger.GetLogger().Error("Failed to check if user exists in DB", zap.Error(err))
+			return err
+		}
+		if exists {
+			s.logger.GetLogger().Warn("User already exists in DB", zap.String("email", email))
+			return ErrUserAlreadyExists
 		}
-	}()
 
-	exists, err := s.repo.IsUserExists(ctx, tx, email)
-	if err != nil {
-		s.logger.GetLogger().Error("Failed to check if user exists in DB", zap.Error(err))
-		return nil, err
-	}
-	if exists {
-		s.logger.GetLogger().Warn("User already exists in DB", zap.String("email", email))
-		return nil, errors.New("user already exists in postgresSQL database")
-	}
-
-	//dont need this in case of firebase auth login
-	username := displayName
-	if username == "" {
-		splitEmail := strings.Split(email, "@")
-		if len(splitEmail) == 2 {
-			usernameParts := strings.Split(splitEmail[0], ".")
-			if len(usernameParts) == 2 {
-				username = usernameParts[0] + " " + usernameParts[1]
-			} else {
-				username = splitEmail[0]
+		//dont need this in case of firebase auth login
+		username := displayName
+		if username == "" {
+			splitEmail := strings.Split(email, "@")
+			if len(splitEmail) == 2 {
+				usernameParts := strings.Split(splitEmail[0], ".")
+				if len(usernameParts) == 2 {
+					username = usernameParts[0] + " " + usernameParts[1]
+				} else {
+					username = splitEmail[0]
+				}
 			}
 		}
-	}
-	s.logger.GetLogger().Debug("Parsed username", zap.String("username", username))
+		s.logger.GetLogger().Debug("Parsed username", zap.String("username", username))
 
-	//insert user into DB
-	userID, err := s.repo.InsertIntoUser(ctx, tx, username, email, userRecord.UID)
-	if err != nil {
-		s.logger.GetLogger().Error("Failed to insert user into DB", zap.Error(err))
-		return nil, err
-	}
+		//insert user into DB
+		userID, err = s.repo.InsertIntoUser(ctx, username, email, userRecord.UID)
+		if err != nil {
+			s.logger.GetLogger().Error("Failed to insert user into DB", zap.Error(err))
+			return err
+		}
 
-	if err = s.repo.InsertIntoUserRole(ctx, tx, userID, "employee", userID); err != nil {
-		s.logger.GetLogger().Error("Failed to assign user role", zap.Error(err))
-		return nil, err
-	}
+		if err = s.repo.InsertIntoUserRole(ctx, userID, "employee", userID); err != nil {
+			s.logger.GetLogger().Error("Failed to assign user role", zap.Error(err))
+			return err
+		}
+
+		if err = s.repo.InsertIntoUserType(ctx, userID, "full_time", userID); err != nil {
+			s.logger.GetLogger().Error("Failed to assign user type", zap.Error(err))
+			return err
+		}
+		if err = s.emitUserCreated(ctx, userID, &userID, email, string(models.EmployeeRole), "firebase"); err != nil {
+			return err
+		}
 
-	if err = s.repo.InsertIntoUserType(ctx, tx, userID, "full_time", userID); err != nil {
-		s.logger.GetLogger().Error("Failed to assign user type", zap.Error(err))
+		// firebase already verified addresses coming from google sign-in
+		if verified {
+			if _, err = s.repo.MarkEmailVerified(ctx, userID, email); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.GetLogger().Error("Rolling back transaction", zap.Error(err))
 		return nil, err
 	}
+	s.repo.InvalidateUserCache(ctx, userID, email)
+	if !verified {
+		s.sendVerificationEmail(ctx, userID, email)
+	}
 
 	s.logger.GetLogger().Info("Firebase user registration successful", zap.String("userID", userID.String()))
 
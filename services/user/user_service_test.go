@@ -1,19 +1,49 @@
 package userservice
 
 import (
+	"asset/models"
 	"context"
+	"database/sql"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"asset/providers"
+	"asset/providers/middlewareprovider"
+	redisprovider "asset/providers/redisProvider"
+	"asset/services/audit"
+	"asset/services/event"
+	"asset/utils"
 
 	firebaseauth "firebase.google.com/go/v4/auth"
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 )
 
+// inTx matches the context of a call made inside a transaction
+var inTx gomock.Matcher = txContextMatcher{}
+
+type txContextMatcher struct{}
+
+func (txContextMatcher) Matches(x interface{}) bool {
+	ctx, ok := x.(context.Context)
+	if !ok {
+		return false
+	}
+	_, ok = utils.TxFromContext(ctx)
+	return ok
+}
+
+func (txContextMatcher) String() string {
+	return "is a context carrying a transaction"
+}
+
 func TestGetDashboard(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -49,6 +79,9 @@ func TestGetDashboard(t *testing.T) {
 		mockRepo.EXPECT().
 			GetUserDashboardById(ctx, userID).
 			Return(expectedDashboard, nil)
+		mockRepo.EXPECT().
+			GetPendingRoleChanges(ctx, userID).
+			Return([]ScheduledRoleChangeRes{}, nil)
 
 		dashboard, err := service.GetDashboard(ctx, userID)
 
@@ -76,15 +109,27 @@ func TestUpdateEmployee(t *testing.T) {
 	mockLogger := providers.NewMockZapLoggerProvider(ctrl)
 
 	mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+	mockConfig := providers.NewMockConfigProvider(ctrl)
+	mockConfig.EXPECT().GetDefaultCountryCode().Return("91").AnyTimes()
+	mockEvents := eventservice.NewMockEventService(ctrl)
 
 	service := &userServiceStruct{
 		repo:   mockRepo,
 		logger: mockLogger,
+		config: mockConfig,
+		events: mockEvents,
 	}
 
 	ctx := context.Background()
 	managerID := uuid.New()
 	employeeID := uuid.New()
+	mockEvents.EXPECT().Emit(ctx, gomock.Any()).DoAndReturn(
+		func(_ context.Context, event eventservice.DomainEvent) error {
+			assert.Equal(t, eventservice.UserUpdated, event.Type)
+			assert.Equal(t, managerID, *event.ActorID)
+			return nil
+		}).AnyTimes()
+	endDate := time.Now().AddDate(0, 1, 0)
 
 	tests := []struct {
 		name             string
@@ -97,12 +142,13 @@ func TestUpdateEmployee(t *testing.T) {
 			req: UpdateEmployeeReq{
 				UserID:    employeeID,
 				Username:  "test user41",
-				Email:     "test.user41@example.com",
 				ContactNo: "9876543210",
 			},
 			mockRepoBehavior: func() {
 				mockRepo.EXPECT().UpdateEmployeeInfo(ctx, gomock.Any(), managerID).
 					Return(nil)
+				mockRepo.EXPECT().InvalidateUserCache(ctx, employeeID)
+				mockRepo.EXPECT().GetEmployeeByID(ctx, employeeID).Return(EmployeeRes{ID: employeeID.String()}, nil)
 			},
 			expectError: false,
 		},
@@ -111,7 +157,6 @@ func TestUpdateEmployee(t *testing.T) {
 			req: UpdateEmployeeReq{
 				UserID:    employeeID,
 				Username:  "test user41",
-				Email:     "test.user41@example.com",
 				ContactNo: "1234567890",
 			},
 			mockRepoBehavior: func() {
@@ -121,18 +166,100 @@ func TestUpdateEmployee(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "contact number normalized",
+			req: UpdateEmployeeReq{
+				UserID:    employeeID,
+				ContactNo: "098765 43210",
+			},
+			mockRepoBehavior: func() {
+				mockRepo.EXPECT().UpdateEmployeeInfo(ctx, UpdateEmployeeReq{UserID: employeeID, ContactNo: "+919876543210"}, managerID).
+					Return(nil)
+				mockRepo.EXPECT().InvalidateUserCache(ctx, employeeID)
+				mockRepo.EXPECT().GetEmployeeByID(ctx, employeeID).Return(EmployeeRes{ID: employeeID.String()}, nil)
+			},
+			expectError: false,
+		},
+		{
+			name: "garbage contact number",
+			req: UpdateEmployeeReq{
+				UserID:    employeeID,
+				ContactNo: "12345678908976567892intern",
+			},
+			mockRepoBehavior: func() {},
+			expectError:      true,
+		},
+		{
+			name: "email change rejected",
+			req: UpdateEmployeeReq{
+				UserID: employeeID,
+				Email:  "test.user41@example.com",
+			},
+			mockRepoBehavior: func() {},
+			expectError:      true,
+		},
+		{
+			name: "end date on full time employee",
+			req: UpdateEmployeeReq{
+				UserID:  employeeID,
+				EndDate: &endDate,
+			},
+			mockRepoBehavior: func() {
+				mockRepo.EXPECT().GetEmployeeType(ctx, employeeID).Return("full_time", nil)
+			},
+			expectError: true,
+		},
+		{
+			name: "end date on intern",
+			req: UpdateEmployeeReq{
+				UserID:  employeeID,
+				EndDate: &endDate,
+			},
+			mockRepoBehavior: func() {
+				mockRepo.EXPECT().GetEmployeeType(ctx, employeeID).Return("intern", nil)
+				mockRepo.EXPECT().UpdateEmployeeInfo(ctx, gomock.Any(), managerID).Return(nil)
+				mockRepo.EXPECT().InvalidateUserCache(ctx, employeeID)
+				mockRepo.EXPECT().GetEmployeeByID(ctx, employeeID).Return(EmployeeRes{ID: employeeID.String()}, nil)
+			},
+			expectError: false,
+		},
+		{
+			name: "clear contact number",
+			req: UpdateEmployeeReq{
+				UserID: employeeID,
+				Clear:  []string{"contact_no"},
+			},
+			mockRepoBehavior: func() {
+				mockRepo.EXPECT().UpdateEmployeeInfo(ctx, UpdateEmployeeReq{UserID: employeeID, Clear: []string{"contact_no"}}, managerID).
+					Return(nil)
+				mockRepo.EXPECT().InvalidateUserCache(ctx, employeeID)
+				mockRepo.EXPECT().GetEmployeeByID(ctx, employeeID).Return(EmployeeRes{ID: employeeID.String()}, nil)
+			},
+			expectError: false,
+		},
+		{
+			name: "field both set and cleared",
+			req: UpdateEmployeeReq{
+				UserID:    employeeID,
+				ContactNo: "9876543210",
+				Clear:     []string{"contact_no"},
+			},
+			mockRepoBehavior: func() {},
+			expectError:      true,
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			tc.mockRepoBehavior()
 
-			err := service.UpdateEmployee(ctx, tc.req, managerID)
+			employee, err := service.UpdateEmployee(ctx, tc.req, managerID, models.DepartmentScope{AllDepartments: true})
 
 			if tc.expectError {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
+				assert.Equal(t, employeeID.String(), employee.ID)
 			}
 		})
 	}
@@ -143,74 +270,97 @@ func TestDeleteUser(t *testing.T) {
 	defer ctrl.Finish()
 
 	ctx := context.Background()
-	userID := uuid.New()
+	userID, adminID := uuid.New(), uuid.New()
 	userEmail := "test.user@remotestate.com"
 	userUID := "firebase-uid"
 
 	tests := []struct {
 		name             string
-		managerRole      string
-		setupMocks       func(repo *MockUserRepository, firebase *providers.MockFirebaseProvider)
+		privileged       bool
+		setupMocks       func(repo *MockUserRepository, firebase *providers.MockFirebaseProvider, auth *providers.MockAuthMiddlewareService)
+		expectRevoke     bool
 		expectedErrorMsg string
 	}{
 		{
-			name:        "success delete by admin",
-			managerRole: "admin",
-			setupMocks: func(repo *MockUserRepository, firebase *providers.MockFirebaseProvider) {
-				repo.EXPECT().GetUserRoleById(ctx, userID).Return("employee", nil)
-				repo.EXPECT().GetEmailByUserID(ctx, userID).Return(userEmail, nil)
+			name: "success delete of an employee",
+			setupMocks: func(repo *MockUserRepository, firebase *providers.MockFirebaseProvider, auth *providers.MockAuthMiddlewareService) {
+				repo.EXPECT().GetUserRoleAndEmail(ctx, userID).Return("employee", userEmail, nil)
+				auth.EXPECT().HasPermission(ctx, []string{"employee"}, models.PrivilegedPermissions).Return(false, nil)
 				firebase.EXPECT().GetUserByEmail(ctx, userEmail).Return(&firebaseauth.UserRecord{
 					UserInfo: &firebaseauth.UserInfo{
 						UID: userUID,
 					},
 				}, nil)
 				firebase.EXPECT().DeleteAuthUser(ctx, userUID).Return(nil)
-				repo.EXPECT().DeleteUserByID(ctx, userID).Return(nil)
+				repo.EXPECT().DeleteUserByID(ctx, userID, &adminID).Return(nil)
+				repo.EXPECT().InvalidateUserCache(ctx, userID, userEmail)
 			},
+			expectRevoke:     true,
 			expectedErrorMsg: "",
 		},
 
 		{
-			name:        "unauthorized user",
-			managerRole: "employee",
-			setupMocks: func(repo *MockUserRepository, firebase *providers.MockFirebaseProvider) {
-				repo.EXPECT().GetUserRoleById(ctx, userID).Return("admin", nil)
+			name:       "admin route deletes a manager",
+			privileged: true,
+			setupMocks: func(repo *MockUserRepository, firebase *providers.MockFirebaseProvider, auth *providers.MockAuthMiddlewareService) {
+				repo.EXPECT().GetUserRoleAndEmail(ctx, userID).Return("asset_manager", userEmail, nil)
+				firebase.EXPECT().GetUserByEmail(ctx, userEmail).Return(&firebaseauth.UserRecord{
+					UserInfo: &firebaseauth.UserInfo{
+						UID: userUID,
+					},
+				}, nil)
+				firebase.EXPECT().DeleteAuthUser(ctx, userUID).Return(nil)
+				repo.EXPECT().DeleteUserByID(ctx, userID, &adminID).Return(nil)
+				repo.EXPECT().InvalidateUserCache(ctx, userID, userEmail)
 			},
-			expectedErrorMsg: "only admin can delete admin or manager roles",
+			expectRevoke: true,
 		},
 		{
-			name:        "failed to get user role",
-			managerRole: "admin",
-			setupMocks: func(repo *MockUserRepository, firebase *providers.MockFirebaseProvider) {
-				repo.EXPECT().GetUserRoleById(ctx, userID).Return("", errors.New("db error"))
+			name: "manager route can't delete an admin",
+			setupMocks: func(repo *MockUserRepository, firebase *providers.MockFirebaseProvider, auth *providers.MockAuthMiddlewareService) {
+				repo.EXPECT().GetUserRoleAndEmail(ctx, userID).Return("admin", userEmail, nil)
+				auth.EXPECT().HasPermission(ctx, []string{"admin"}, models.PrivilegedPermissions).Return(true, nil)
 			},
-			expectedErrorMsg: "db error",
+			expectedErrorMsg: ErrPrivilegedTarget.Error(),
 		},
 		{
-			name:        "failed to get user email",
-			managerRole: "admin",
-			setupMocks: func(repo *MockUserRepository, firebase *providers.MockFirebaseProvider) {
-				repo.EXPECT().GetUserRoleById(ctx, userID).Return("employee", nil)
-				repo.EXPECT().GetEmailByUserID(ctx, userID).Return("", errors.New("user not found"))
+			// the role's permissions decide, not its name
+			name: "manager route can't delete a custom role holding role.manage",
+			setupMocks: func(repo *MockUserRepository, firebase *providers.MockFirebaseProvider, auth *providers.MockAuthMiddlewareService) {
+				repo.EXPECT().GetUserRoleAndEmail(ctx, userID).Return("it_lead", userEmail, nil)
+				auth.EXPECT().HasPermission(ctx, []string{"it_lead"}, models.PrivilegedPermissions).Return(true, nil)
 			},
-			expectedErrorMsg: "failed to get user email from user table",
+			expectedErrorMsg: ErrPrivilegedTarget.Error(),
 		},
 		{
-			name:        "firebase user not found",
-			managerRole: "admin",
-			setupMocks: func(repo *MockUserRepository, firebase *providers.MockFirebaseProvider) {
-				repo.EXPECT().GetUserRoleById(ctx, userID).Return("employee", nil)
-				repo.EXPECT().GetEmailByUserID(ctx, userID).Return(userEmail, nil)
+			name: "policy fails to load",
+			setupMocks: func(repo *MockUserRepository, firebase *providers.MockFirebaseProvider, auth *providers.MockAuthMiddlewareService) {
+				repo.EXPECT().GetUserRoleAndEmail(ctx, userID).Return("employee", userEmail, nil)
+				auth.EXPECT().HasPermission(ctx, []string{"employee"}, models.PrivilegedPermissions).Return(false, errors.New("db down"))
+			},
+			expectedErrorMsg: "db down",
+		},
+		{
+			name: "failed to get user role and email",
+			setupMocks: func(repo *MockUserRepository, firebase *providers.MockFirebaseProvider, auth *providers.MockAuthMiddlewareService) {
+				repo.EXPECT().GetUserRoleAndEmail(ctx, userID).Return("", "", errors.New("db error"))
+			},
+			expectedErrorMsg: "db error",
+		},
+		{
+			name: "firebase user not found",
+			setupMocks: func(repo *MockUserRepository, firebase *providers.MockFirebaseProvider, auth *providers.MockAuthMiddlewareService) {
+				repo.EXPECT().GetUserRoleAndEmail(ctx, userID).Return("employee", userEmail, nil)
+				auth.EXPECT().HasPermission(ctx, []string{"employee"}, models.PrivilegedPermissions).Return(false, nil)
 				firebase.EXPECT().GetUserByEmail(ctx, userEmail).Return(nil, errors.New("not found"))
 			},
 			expectedErrorMsg: "failed to get user UID from firebase user table",
 		},
 		{
-			name:        "firebase delete failure",
-			managerRole: "admin",
-			setupMocks: func(repo *MockUserRepository, firebase *providers.MockFirebaseProvider) {
-				repo.EXPECT().GetUserRoleById(ctx, userID).Return("employee", nil)
-				repo.EXPECT().GetEmailByUserID(ctx, userID).Return(userEmail, nil)
+			name: "firebase delete failure",
+			setupMocks: func(repo *MockUserRepository, firebase *providers.MockFirebaseProvider, auth *providers.MockAuthMiddlewareService) {
+				repo.EXPECT().GetUserRoleAndEmail(ctx, userID).Return("employee", userEmail, nil)
+				auth.EXPECT().HasPermission(ctx, []string{"employee"}, models.PrivilegedPermissions).Return(false, nil)
 				firebase.EXPECT().GetUserByEmail(ctx, userEmail).Return(&firebaseauth.UserRecord{
 					UserInfo: &firebaseauth.UserInfo{
 						UID: userUID,
@@ -221,18 +371,17 @@ func TestDeleteUser(t *testing.T) {
 			expectedErrorMsg: "failed to delete auth user from firebase",
 		},
 		{
-			name:        "repo delete failure",
-			managerRole: "admin",
-			setupMocks: func(repo *MockUserRepository, firebase *providers.MockFirebaseProvider) {
-				repo.EXPECT().GetUserRoleById(ctx, userID).Return("employee", nil)
-				repo.EXPECT().GetEmailByUserID(ctx, userID).Return(userEmail, nil)
+			name: "repo delete failure",
+			setupMocks: func(repo *MockUserRepository, firebase *providers.MockFirebaseProvider, auth *providers.MockAuthMiddlewareService) {
+				repo.EXPECT().GetUserRoleAndEmail(ctx, userID).Return("employee", userEmail, nil)
+				auth.EXPECT().HasPermission(ctx, []string{"employee"}, models.PrivilegedPermissions).Return(false, nil)
 				firebase.EXPECT().GetUserByEmail(ctx, userEmail).Return(&firebaseauth.UserRecord{
 					UserInfo: &firebaseauth.UserInfo{
 						UID: userUID,
 					},
 				}, nil)
 				firebase.EXPECT().DeleteAuthUser(ctx, userUID).Return(nil)
-				repo.EXPECT().DeleteUserByID(ctx, userID).Return(errors.New("delete failed"))
+				repo.EXPECT().DeleteUserByID(ctx, userID, &adminID).Return(errors.New("delete failed"))
 			},
 			expectedErrorMsg: "delete failed",
 		},
@@ -245,15 +394,32 @@ func TestDeleteUser(t *testing.T) {
 			mockLogger := providers.NewMockZapLoggerProvider(ctrl)
 			mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
 
-			tc.setupMocks(mockRepo, mockFirebase)
+			mockAuth := providers.NewMockAuthMiddlewareService(ctrl)
+
+			tc.setupMocks(mockRepo, mockFirebase, mockAuth)
+			if tc.expectRevoke {
+				mockAuth.EXPECT().RevokeUserTokens(ctx, userID.String(), userUID).Return(nil)
+			}
+
+			mockEvents := eventservice.NewMockEventService(ctrl)
+			if tc.expectRevoke {
+				mockEvents.EXPECT().Emit(ctx, gomock.Any()).DoAndReturn(
+					func(_ context.Context, event eventservice.DomainEvent) error {
+						assert.Equal(t, eventservice.UserDeleted, event.Type)
+						assert.Equal(t, userID, event.AggregateID)
+						return nil
+					})
+			}
 
 			service := &userServiceStruct{
-				repo:     mockRepo,
-				logger:   mockLogger,
-				firebase: mockFirebase,
+				repo:           mockRepo,
+				logger:         mockLogger,
+				firebase:       mockFirebase,
+				AuthMiddleware: mockAuth,
+				events:         mockEvents,
 			}
 
-			err := service.DeleteUser(ctx, userID, tc.managerRole)
+			err := service.DeleteUser(ctx, userID, adminID, tc.privileged, models.DepartmentScope{AllDepartments: true})
 
 			if tc.expectedErrorMsg == "" {
 				assert.NoError(t, err)
@@ -265,96 +431,1527 @@ func TestDeleteUser(t *testing.T) {
 	}
 }
 
-func TestUserLogin(t *testing.T) {
+func TestForceLogout(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	ctx := context.Background()
-	email := "test.user41@remotestate.com"
+	adminID := uuid.New()
 	userID := uuid.New()
-	role := "employee"
-	accessToken := "Access_Token"
-	refreshToken := "Refresh_Token"
 
 	tests := []struct {
-		name         string
-		req          PublicUserReq
-		mockSetups   func(repo *MockUserRepository, authMiddleware *providers.MockAuthMiddlewareService)
-		expectSucess bool
+		name        string
+		setupMocks  func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService, audit *auditservice.MockAuditService)
+		expectedErr error
+		errContains string
 	}{
 		{
-			name: "user login success",
-			req:  PublicUserReq{Email: email},
-			mockSetups: func(repo *MockUserRepository, authMiddleware *providers.MockAuthMiddlewareService) {
-				repo.EXPECT().GetUserByEmail(ctx, email).Return(userID, nil)
-				repo.EXPECT().GetUserRoleById(ctx, userID).Return(role, nil)
-				authMiddleware.EXPECT().GenerateJWT(userID.String(), []string{role}).Return(accessToken, nil)
-				authMiddleware.EXPECT().GenerateRefreshToken(userID.String()).Return(refreshToken, nil)
+			name: "success revokes both subjects and records audit",
+			setupMocks: func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService, audit *auditservice.MockAuditService) {
+				repo.EXPECT().GetFirebaseUID(ctx, userID).Return("firebase-uid", nil)
+				auth.EXPECT().RevokeUserTokens(ctx, userID.String(), "firebase-uid").Return(nil)
+				audit.EXPECT().Record(ctx, gomock.Any()).DoAndReturn(
+					func(_ context.Context, entry auditservice.AuditEntry) error {
+						assert.Equal(t, "user.force_logout", entry.Action)
+						assert.Equal(t, userID.String(), entry.EntityID)
+						assert.Equal(t, adminID, *entry.ActorID)
+						return nil
+					})
+				audit.EXPECT().RecordAuthEvent(ctx, gomock.Any()).DoAndReturn(
+					func(_ context.Context, event auditservice.AuthEvent) error {
+						assert.Equal(t, auditservice.AuthEventLogout, event.EventType)
+						assert.Equal(t, "forced", event.Method)
+						assert.Equal(t, userID, *event.UserID)
+						assert.Equal(t, "laptop stolen", event.Reason)
+						return nil
+					})
 			},
-			expectSucess: true,
 		},
 		{
-			name: "user email not found",
-			req:  PublicUserReq{Email: email},
-			mockSetups: func(repo *MockUserRepository, authMiddleware *providers.MockAuthMiddlewareService) {
-				repo.EXPECT().GetUserByEmail(ctx, email).Return(uuid.Nil, errors.New("user not found"))
+			name: "user not found",
+			setupMocks: func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService, audit *auditservice.MockAuditService) {
+				repo.EXPECT().GetFirebaseUID(ctx, userID).Return("", sql.ErrNoRows)
 			},
-			expectSucess: false,
+			expectedErr: ErrUserNotFound,
 		},
 		{
-			name: "user role not found",
-			req:  PublicUserReq{Email: email},
-			mockSetups: func(repo *MockUserRepository, authMiddleware *providers.MockAuthMiddlewareService) {
-				repo.EXPECT().GetUserByEmail(ctx, email).Return(userID, nil)
-				repo.EXPECT().GetUserRoleById(ctx, userID).Return("", errors.New("user role not found"))
+			name: "revocation failure is returned",
+			setupMocks: func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService, audit *auditservice.MockAuditService) {
+				repo.EXPECT().GetFirebaseUID(ctx, userID).Return("", nil)
+				auth.EXPECT().RevokeUserTokens(ctx, userID.String(), "").Return(errors.New("redis down"))
 			},
-			expectSucess: false,
+			errContains: "redis down",
 		},
 		{
-			name: "failed to generate access token",
-			req:  PublicUserReq{Email: email},
-			mockSetups: func(repo *MockUserRepository, authMiddleware *providers.MockAuthMiddlewareService) {
-				repo.EXPECT().GetUserByEmail(ctx, email).Return(userID, nil)
-				repo.EXPECT().GetUserRoleById(ctx, userID).Return(role, nil)
-				authMiddleware.EXPECT().GenerateJWT(userID.String(), []string{role}).Return("", errors.New("failed to generate access token"))
+			name: "audit failure does not fail the logout",
+			setupMocks: func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService, audit *auditservice.MockAuditService) {
+				repo.EXPECT().GetFirebaseUID(ctx, userID).Return("", nil)
+				auth.EXPECT().RevokeUserTokens(ctx, userID.String(), "").Return(nil)
+				audit.EXPECT().Record(ctx, gomock.Any()).Return(errors.New("insert failed"))
+				audit.EXPECT().RecordAuthEvent(ctx, gomock.Any()).Return(errors.New("insert failed"))
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := NewMockUserRepository(ctrl)
+			mockAuth := providers.NewMockAuthMiddlewareService(ctrl)
+			mockAudit := auditservice.NewMockAuditService(ctrl)
+			mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+			mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+			tc.setupMocks(mockRepo, mockAuth, mockAudit)
+
+			service := &userServiceStruct{
+				repo:           mockRepo,
+				logger:         mockLogger,
+				AuthMiddleware: mockAuth,
+				audit:          mockAudit,
+			}
+
+			err := service.ForceLogout(ctx, adminID, userID, "laptop stolen")
+			switch {
+			case tc.expectedErr != nil:
+				assert.ErrorIs(t, err, tc.expectedErr)
+			case tc.errContains != "":
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tc.errContains)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRoleGrantApproval(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	requesterID := uuid.New()
+	approverID := uuid.New()
+	userID := uuid.New()
+	approvalID := uuid.New()
+	previousRole := "asset_manager"
+	pending := RoleGrantApprovalRes{ID: approvalID, UserID: userID, Role: "admin", PreviousRole: &previousRole, Status: RoleGrantPending, RequestedBy: requesterID}
+	platform := models.DepartmentScope{AllDepartments: true}
+	orgID := uuid.New()
+	orgScope := models.DepartmentScope{AllDepartments: true, OrganizationID: &orgID}
+	platformAdmin := func(id uuid.UUID) RoleGrantDecider {
+		return RoleGrantDecider{ID: id, Roles: []string{"admin"}, Scope: platform}
+	}
+	orgAdmin := RoleGrantDecider{ID: approverID, Roles: []string{"org_admin"}, Scope: orgScope}
+
+	tests := []struct {
+		name        string
+		run         func(s *userServiceStruct) error
+		setupMocks  func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService, audit *auditservice.MockAuditService, events *eventservice.MockEventService, db sqlmock.Sqlmock)
+		expectedErr error
+	}{
+		{
+			name: "admin grant waits for approval",
+			run: func(s *userServiceStruct) error {
+				res, err := s.ChangeUserRole(ctx, UpdateUserRoleReq{UserID: userID.String(), Role: "admin"}, requesterID, platform)
+				assert.Equal(t, RoleChangePendingApproval, res.Status)
+				if assert.NotNil(t, res.ApprovalID) {
+					assert.Equal(t, approvalID, *res.ApprovalID)
+				}
+				return err
+			},
+			setupMocks: func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService, audit *auditservice.MockAuditService, events *eventservice.MockEventService, db sqlmock.Sqlmock) {
+				repo.EXPECT().IsRoleExists(ctx, "admin").Return(true, nil)
+				auth.EXPECT().HasPermission(ctx, []string{"admin"}, models.PrivilegedPermissions).Return(true, nil)
+				repo.EXPECT().HasPendingRoleGrant(ctx, userID, "admin").Return(false, nil)
+				db.ExpectBegin()
+				repo.EXPECT().GetCurrentUserRole(inTx, userID).Return(previousRole, nil)
+				repo.EXPECT().InsertRoleGrantApproval(inTx, userID, "admin", previousRole, requesterID).Return(approvalID, nil)
+				audit.EXPECT().Record(inTx, gomock.Any()).DoAndReturn(
+					func(_ context.Context, entry auditservice.AuditEntry) error {
+						assert.Equal(t, "user.role_grant_requested", entry.Action)
+						assert.Equal(t, requesterID, *entry.ActorID)
+						return nil
+					})
+				events.EXPECT().Emit(inTx, gomock.Any()).DoAndReturn(
+					func(_ context.Context, event eventservice.DomainEvent) error {
+						assert.Equal(t, eventservice.UserRoleGrantRequested, event.Type)
+						assert.Equal(t, approvalID, event.Data["approval_id"])
+						assert.Equal(t, requesterID, event.Data["requested_by"])
+						return nil
+					})
+				db.ExpectCommit()
 			},
-			expectSucess: false,
 		},
 		{
-			name: "failed to generate refresh token",
-			req:  PublicUserReq{Email: email},
-			mockSetups: func(repo *MockUserRepository, authMiddleware *providers.MockAuthMiddlewareService) {
-				repo.EXPECT().GetUserByEmail(ctx, email).Return(userID, nil)
-				repo.EXPECT().GetUserRoleById(ctx, userID).Return(role, nil)
-				authMiddleware.EXPECT().GenerateJWT(userID.String(), []string{role}).Return(accessToken, nil)
-				authMiddleware.EXPECT().GenerateRefreshToken(userID.String()).Return("", errors.New("failed to generate refresh token"))
+			name: "second request for the same grant",
+			run: func(s *userServiceStruct) error {
+				_, err := s.ChangeUserRole(ctx, UpdateUserRoleReq{UserID: userID.String(), Role: "admin"}, requesterID, platform)
+				return err
+			},
+			setupMocks: func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService, audit *auditservice.MockAuditService, events *eventservice.MockEventService, db sqlmock.Sqlmock) {
+				repo.EXPECT().IsRoleExists(ctx, "admin").Return(true, nil)
+				auth.EXPECT().HasPermission(ctx, []string{"admin"}, models.PrivilegedPermissions).Return(true, nil)
+				repo.EXPECT().HasPendingRoleGrant(ctx, userID, "admin").Return(true, nil)
+			},
+			expectedErr: ErrRoleGrantPending,
+		},
+		{
+			name: "custom role holding role.manage waits for approval too",
+			run: func(s *userServiceStruct) error {
+				res, err := s.ChangeUserRole(ctx, UpdateUserRoleReq{UserID: userID.String(), Role: "it_lead"}, requesterID, platform)
+				assert.Equal(t, RoleChangePendingApproval, res.Status)
+				return err
+			},
+			setupMocks: func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService, audit *auditservice.MockAuditService, events *eventservice.MockEventService, db sqlmock.Sqlmock) {
+				repo.EXPECT().IsRoleExists(ctx, "it_lead").Return(true, nil)
+				auth.EXPECT().HasPermission(ctx, []string{"it_lead"}, models.PrivilegedPermissions).Return(true, nil)
+				repo.EXPECT().HasPendingRoleGrant(ctx, userID, "it_lead").Return(false, nil)
+				db.ExpectBegin()
+				repo.EXPECT().GetCurrentUserRole(inTx, userID).Return(previousRole, nil)
+				repo.EXPECT().InsertRoleGrantApproval(inTx, userID, "it_lead", previousRole, requesterID).Return(approvalID, nil)
+				audit.EXPECT().Record(inTx, gomock.Any()).Return(nil)
+				events.EXPECT().Emit(inTx, gomock.Any()).Return(nil)
+				db.ExpectCommit()
+			},
+		},
+		{
+			name: "admin grants can't be scheduled",
+			run: func(s *userServiceStruct) error {
+				_, err := s.ScheduleRoleChange(ctx, ScheduleRoleChangeReq{UserID: userID.String(), Role: "admin", EffectiveFrom: time.Now().Add(time.Hour)}, requesterID, platform)
+				return err
+			},
+			setupMocks: func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService, audit *auditservice.MockAuditService, events *eventservice.MockEventService, db sqlmock.Sqlmock) {
+				repo.EXPECT().IsRoleExists(ctx, "admin").Return(true, nil)
+				auth.EXPECT().HasPermission(ctx, []string{"admin"}, models.PrivilegedPermissions).Return(true, nil)
+			},
+			expectedErr: ErrRoleGrantNotScheduled,
+		},
+		{
+			name: "scheduled change cancelled once its role turned privileged",
+			run: func(s *userServiceStruct) error {
+				return s.applyRoleChange(ctx, ScheduledRoleChangeRes{ID: approvalID, UserID: userID, Role: "it_lead", CreatedBy: requesterID})
+			},
+			setupMocks: func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService, audit *auditservice.MockAuditService, events *eventservice.MockEventService, db sqlmock.Sqlmock) {
+				auth.EXPECT().HasPermission(ctx, []string{"it_lead"}, models.PrivilegedPermissions).Return(true, nil)
+				repo.EXPECT().CancelScheduledRoleChange(ctx, approvalID, platform).Return(nil)
+			},
+		},
+		{
+			name: "second admin approves",
+			run: func(s *userServiceStruct) error {
+				return s.ApproveRoleGrant(ctx, approvalID, platformAdmin(approverID), "confirmed with the cto")
+			},
+			setupMocks: func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService, audit *auditservice.MockAuditService, events *eventservice.MockEventService, db sqlmock.Sqlmock) {
+				auth.EXPECT().HasPermission(ctx, []string{"admin"}, models.OrganizationManagePermission).Return(true, nil)
+				db.ExpectBegin()
+				repo.EXPECT().GetRoleGrantApprovalForUpdate(inTx, approvalID).Return(pending, nil)
+				repo.EXPECT().DecideRoleGrantApproval(inTx, approvalID, RoleGrantApproved, approverID, "confirmed with the cto").Return(nil)
+				repo.EXPECT().UpdateUserRole(inTx, userID, "admin", approverID).Return(nil)
+				events.EXPECT().Emit(inTx, gomock.Any()).DoAndReturn(
+					func(_ context.Context, event eventservice.DomainEvent) error {
+						assert.Equal(t, eventservice.UserRoleChanged, event.Type)
+						assert.Equal(t, "admin", event.Data["role"])
+						return nil
+					})
+				audit.EXPECT().Record(inTx, gomock.Any()).DoAndReturn(
+					func(_ context.Context, entry auditservice.AuditEntry) error {
+						assert.Equal(t, "user.role_grant_approved", entry.Action)
+						assert.Equal(t, approverID, *entry.ActorID)
+						assert.Equal(t, map[string]interface{}{"role": previousRole}, entry.OldValue)
+						assert.Equal(t, requesterID, entry.NewValue.(map[string]interface{})["requested_by"])
+						return nil
+					})
+				events.EXPECT().Emit(inTx, gomock.Any()).DoAndReturn(
+					func(_ context.Context, event eventservice.DomainEvent) error {
+						assert.Equal(t, eventservice.UserRoleGrantDecided, event.Type)
+						assert.Equal(t, RoleGrantApproved, event.Data["status"])
+						assert.Equal(t, requesterID, event.Data["requested_by"])
+						return nil
+					})
+				db.ExpectCommit()
+				repo.EXPECT().InvalidateUserCache(ctx, userID)
+				repo.EXPECT().GetFirebaseUID(ctx, userID).Return("", nil)
+				auth.EXPECT().RevokeUserTokens(ctx, userID.String(), "").Return(nil)
+				repo.EXPECT().GetFirebaseRoleClaims(ctx, userID).Return(FirebaseRoleClaims{}, sql.ErrNoRows)
+			},
+		},
+		{
+			name: "requester can't approve their own grant",
+			run: func(s *userServiceStruct) error {
+				return s.ApproveRoleGrant(ctx, approvalID, platformAdmin(requesterID), "")
+			},
+			setupMocks: func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService, audit *auditservice.MockAuditService, events *eventservice.MockEventService, db sqlmock.Sqlmock) {
+				auth.EXPECT().HasPermission(ctx, []string{"admin"}, models.OrganizationManagePermission).Return(true, nil)
+				db.ExpectBegin()
+				repo.EXPECT().GetRoleGrantApprovalForUpdate(inTx, approvalID).Return(pending, nil)
+				db.ExpectRollback()
+			},
+			expectedErr: ErrRoleGrantSelfApproval,
+		},
+		{
+			name: "user can't approve their own elevation",
+			run: func(s *userServiceStruct) error {
+				return s.ApproveRoleGrant(ctx, approvalID, platformAdmin(userID), "")
+			},
+			setupMocks: func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService, audit *auditservice.MockAuditService, events *eventservice.MockEventService, db sqlmock.Sqlmock) {
+				auth.EXPECT().HasPermission(ctx, []string{"admin"}, models.OrganizationManagePermission).Return(true, nil)
+				db.ExpectBegin()
+				repo.EXPECT().GetRoleGrantApprovalForUpdate(inTx, approvalID).Return(pending, nil)
+				db.ExpectRollback()
+			},
+			expectedErr: ErrRoleGrantSelfApproval,
+		},
+		{
+			name: "already decided",
+			run: func(s *userServiceStruct) error {
+				return s.ApproveRoleGrant(ctx, approvalID, platformAdmin(approverID), "")
+			},
+			setupMocks: func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService, audit *auditservice.MockAuditService, events *eventservice.MockEventService, db sqlmock.Sqlmock) {
+				auth.EXPECT().HasPermission(ctx, []string{"admin"}, models.OrganizationManagePermission).Return(true, nil)
+				decided := pending
+				decided.Status = RoleGrantRejected
+				db.ExpectBegin()
+				repo.EXPECT().GetRoleGrantApprovalForUpdate(inTx, approvalID).Return(decided, nil)
+				db.ExpectRollback()
+			},
+			expectedErr: ErrRoleGrantDecided,
+		},
+		{
+			name: "unknown approval",
+			run: func(s *userServiceStruct) error {
+				return s.RejectRoleGrant(ctx, approvalID, platformAdmin(approverID), "")
+			},
+			setupMocks: func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService, audit *auditservice.MockAuditService, events *eventservice.MockEventService, db sqlmock.Sqlmock) {
+				db.ExpectBegin()
+				repo.EXPECT().GetRoleGrantApprovalForUpdate(inTx, approvalID).Return(RoleGrantApprovalRes{}, sql.ErrNoRows)
+				db.ExpectRollback()
+			},
+			expectedErr: ErrRoleGrantNotFound,
+		},
+		{
+			name: "org_admin can't change roles in another organization",
+			run: func(s *userServiceStruct) error {
+				_, err := s.ChangeUserRole(ctx, UpdateUserRoleReq{UserID: userID.String(), Role: "asset_manager"}, requesterID, orgScope)
+				return err
+			},
+			setupMocks: func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService, audit *auditservice.MockAuditService, events *eventservice.MockEventService, db sqlmock.Sqlmock) {
+				repo.EXPECT().IsRoleExists(ctx, "asset_manager").Return(true, nil)
+				repo.EXPECT().IsUserInScope(ctx, userID, orgScope).Return(false, nil)
+			},
+			expectedErr: models.ErrOutOfScope,
+		},
+		{
+			name: "org_admin can't schedule changes in another organization",
+			run: func(s *userServiceStruct) error {
+				_, err := s.ScheduleRoleChange(ctx, ScheduleRoleChangeReq{UserID: userID.String(), Role: "asset_manager", EffectiveFrom: time.Now().Add(time.Hour)}, requesterID, orgScope)
+				return err
+			},
+			setupMocks: func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService, audit *auditservice.MockAuditService, events *eventservice.MockEventService, db sqlmock.Sqlmock) {
+				repo.EXPECT().IsRoleExists(ctx, "asset_manager").Return(true, nil)
+				repo.EXPECT().IsUserInScope(ctx, userID, orgScope).Return(false, nil)
+			},
+			expectedErr: models.ErrOutOfScope,
+		},
+		{
+			name: "org_admin can't approve admin grants",
+			run: func(s *userServiceStruct) error {
+				return s.ApproveRoleGrant(ctx, approvalID, orgAdmin, "")
+			},
+			setupMocks: func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService, audit *auditservice.MockAuditService, events *eventservice.MockEventService, db sqlmock.Sqlmock) {
+				auth.EXPECT().HasPermission(ctx, []string{"org_admin"}, models.OrganizationManagePermission).Return(false, nil)
+			},
+			expectedErr: ErrRoleGrantApprover,
+		},
+		{
+			name: "grant for another organization looks missing",
+			run: func(s *userServiceStruct) error {
+				return s.RejectRoleGrant(ctx, approvalID, orgAdmin, "")
+			},
+			setupMocks: func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService, audit *auditservice.MockAuditService, events *eventservice.MockEventService, db sqlmock.Sqlmock) {
+				db.ExpectBegin()
+				repo.EXPECT().GetRoleGrantApprovalForUpdate(inTx, approvalID).Return(pending, nil)
+				repo.EXPECT().IsUserInScope(inTx, userID, orgScope).Return(false, nil)
+				db.ExpectRollback()
+			},
+			expectedErr: ErrRoleGrantNotFound,
+		},
+		{
+			name: "requester withdraws their request",
+			run: func(s *userServiceStruct) error {
+				return s.RejectRoleGrant(ctx, approvalID, platformAdmin(requesterID), "wrong user")
+			},
+			setupMocks: func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService, audit *auditservice.MockAuditService, events *eventservice.MockEventService, db sqlmock.Sqlmock) {
+				db.ExpectBegin()
+				repo.EXPECT().GetRoleGrantApprovalForUpdate(inTx, approvalID).Return(pending, nil)
+				repo.EXPECT().DecideRoleGrantApproval(inTx, approvalID, RoleGrantRejected, requesterID, "wrong user").Return(nil)
+				audit.EXPECT().Record(inTx, gomock.Any()).DoAndReturn(
+					func(_ context.Context, entry auditservice.AuditEntry) error {
+						assert.Equal(t, "user.role_grant_rejected", entry.Action)
+						assert.Equal(t, true, entry.NewValue.(map[string]interface{})["withdrawn"])
+						return nil
+					})
+				events.EXPECT().Emit(inTx, gomock.Any()).Return(nil)
+				db.ExpectCommit()
 			},
-			expectSucess: false,
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			defer db.Close()
+
 			mockRepo := NewMockUserRepository(ctrl)
-			mockAuthMiddleware := providers.NewMockAuthMiddlewareService(ctrl)
+			mockAuth := providers.NewMockAuthMiddlewareService(ctrl)
+			mockAudit := auditservice.NewMockAuditService(ctrl)
+			mockEvents := eventservice.NewMockEventService(ctrl)
 			mockLogger := providers.NewMockZapLoggerProvider(ctrl)
 			mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
 
-			tc.mockSetups(mockRepo, mockAuthMiddleware)
+			tc.setupMocks(mockRepo, mockAuth, mockAudit, mockEvents, mock)
 
 			service := &userServiceStruct{
 				repo:           mockRepo,
-				AuthMiddleware: mockAuthMiddleware,
+				db:             sqlx.NewDb(db, "postgres"),
 				logger:         mockLogger,
+				AuthMiddleware: mockAuth,
+				audit:          mockAudit,
+				events:         mockEvents,
 			}
 
-			_, _, _, err := service.UserLogin(ctx, tc.req)
-
-			if tc.expectSucess {
-				assert.NoError(t, err)
+			err = tc.run(service)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
 			} else {
-				assert.Error(t, err)
+				assert.NoError(t, err)
 			}
+			assert.NoError(t, mock.ExpectationsWereMet())
 		})
 	}
 }
+
+func TestSyncDirectory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	adminID := uuid.New()
+	issuer := "ldaps://ldap.example.com/dc=example,dc=com"
+	existingID := uuid.New()
+	leaverID := uuid.New()
+	disabledID := uuid.New()
+	keptID := uuid.New()
+
+	tests := []struct {
+		name        string
+		dryRun      bool
+		directory   bool
+		setupMocks  func(repo *MockUserRepository, dir *providers.MockDirectoryProvider)
+		check       func(t *testing.T, report DirectorySyncReport)
+		expectedErr error
+		expectErr   bool
+	}{
+		{
+			name: "not configured",
+			setupMocks: func(repo *MockUserRepository, dir *providers.MockDirectoryProvider) {
+			},
+			expectedErr: ErrDirectoryNotEnabled,
+		},
+		{
+			name:      "dry run reports every change without writing",
+			dryRun:    true,
+			directory: true,
+			setupMocks: func(repo *MockUserRepository, dir *providers.MockDirectoryProvider) {
+				dir.EXPECT().ListUsers(ctx).Return([]models.DirectoryUser{
+					{ID: "u-new", Email: "new.user@remotestate.com", Role: "employee"},
+					{ID: "u-existing", Email: "existing.user@remotestate.com", Role: "asset_manager"},
+					{ID: "u-disabled", Email: "disabled.user@remotestate.com", Disabled: true},
+				}, nil)
+				repo.EXPECT().GetDirectoryLinkedUsers(ctx, issuer).Return([]DirectoryLinkedUser{
+					{UserID: leaverID, Subject: "u-leaver", Email: "leaver@remotestate.com"},
+					{UserID: disabledID, Subject: "u-disabled", Email: "disabled.user@remotestate.com"},
+				}, nil)
+				repo.EXPECT().GetUserByEmail(ctx, "new.user@remotestate.com").Return(uuid.Nil, sql.ErrNoRows)
+				repo.EXPECT().GetUserByEmail(ctx, "existing.user@remotestate.com").Return(existingID, nil)
+				repo.EXPECT().GetUserRoleById(ctx, existingID).Return("employee", nil)
+				repo.EXPECT().IsRoleExists(ctx, "asset_manager").Return(true, nil)
+				repo.EXPECT().InsertDirectorySyncRun(ctx, gomock.Any()).Return(nil)
+			},
+			check: func(t *testing.T, report DirectorySyncReport) {
+				assert.True(t, report.DryRun)
+				assert.Equal(t, directorySyncCompleted, report.Status)
+				assert.Equal(t, 3, report.DirectoryUsers)
+				assert.Len(t, report.Created, 1)
+				assert.Nil(t, report.Created[0].UserID)
+				assert.Len(t, report.Linked, 1)
+				assert.Equal(t, []DirectorySyncChange{{UserID: &existingID, Email: "existing.user@remotestate.com", FromRole: "employee", ToRole: "asset_manager"}}, report.RoleChanges)
+				assert.ElementsMatch(t, []uuid.UUID{leaverID, disabledID}, []uuid.UUID{*report.Archived[0].UserID, *report.Archived[1].UserID})
+				assert.Empty(t, report.Errors)
+			},
+		},
+		{
+			name:      "empty directory does not archive everyone",
+			directory: true,
+			setupMocks: func(repo *MockUserRepository, dir *providers.MockDirectoryProvider) {
+				dir.EXPECT().ListUsers(ctx).Return([]models.DirectoryUser{}, nil)
+				repo.EXPECT().GetDirectoryLinkedUsers(ctx, issuer).Return([]DirectoryLinkedUser{
+					{UserID: leaverID, Subject: "u-leaver", Email: "leaver@remotestate.com"},
+				}, nil)
+				repo.EXPECT().InsertDirectorySyncRun(ctx, gomock.Any()).Return(nil)
+			},
+			check: func(t *testing.T, report DirectorySyncReport) {
+				assert.Equal(t, directorySyncFailed, report.Status)
+				assert.Empty(t, report.Archived)
+			},
+			expectErr: true,
+		},
+		{
+			name:      "leaver still holding assets is reported, not archived",
+			directory: true,
+			setupMocks: func(repo *MockUserRepository, dir *providers.MockDirectoryProvider) {
+				dir.EXPECT().ListUsers(ctx).Return([]models.DirectoryUser{
+					{ID: "u-kept", Email: "kept@remotestate.com"},
+				}, nil)
+				repo.EXPECT().GetDirectoryLinkedUsers(ctx, issuer).Return([]DirectoryLinkedUser{
+					{UserID: keptID, Subject: "u-kept", Email: "kept@remotestate.com"},
+					{UserID: leaverID, Subject: "u-leaver", Email: "leaver@remotestate.com"},
+				}, nil)
+				repo.EXPECT().GetFirebaseUID(ctx, leaverID).Return("", nil)
+				repo.EXPECT().DeleteUserByID(ctx, leaverID, &adminID).Return(errors.New("cannot delete user, still have asset assigned"))
+				repo.EXPECT().InsertDirectorySyncRun(ctx, gomock.Any()).Return(nil)
+			},
+			check: func(t *testing.T, report DirectorySyncReport) {
+				assert.Equal(t, directorySyncCompleted, report.Status)
+				assert.Empty(t, report.Archived)
+				assert.Len(t, report.Errors, 1)
+				assert.Equal(t, leaverID, *report.Errors[0].UserID)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := NewMockUserRepository(ctrl)
+			mockDirectory := providers.NewMockDirectoryProvider(ctrl)
+			mockDirectory.EXPECT().Name().Return("ldap").AnyTimes()
+			mockDirectory.EXPECT().Issuer().Return(issuer).AnyTimes()
+			mockConfig := providers.NewMockConfigProvider(ctrl)
+			mockConfig.EXPECT().GetAllowedEmailDomains().Return([]string{"remotestate.com"}).AnyTimes()
+			mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+			mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+			tc.setupMocks(mockRepo, mockDirectory)
+
+			service := &userServiceStruct{
+				repo:   mockRepo,
+				logger: mockLogger,
+				config: mockConfig,
+			}
+			if tc.directory {
+				service.directory = mockDirectory
+			}
+
+			report, err := service.SyncDirectory(ctx, tc.dryRun, &adminID)
+			switch {
+			case tc.expectedErr != nil:
+				assert.ErrorIs(t, err, tc.expectedErr)
+			case tc.expectErr:
+				assert.Error(t, err)
+			default:
+				assert.NoError(t, err)
+			}
+			if tc.check != nil {
+				tc.check(t, report)
+			}
+		})
+	}
+}
+
+func TestUserLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	email := "test.user41@remotestate.com"
+	userID := uuid.New()
+	role := "employee"
+	accessToken := "Access_Token"
+	refreshToken := "Refresh_Token"
+
+	tests := []struct {
+		name                string
+		req                 PublicUserReq
+		requireVerification bool
+		mockSetups          func(repo *MockUserRepository, authMiddleware *providers.MockAuthMiddlewareService)
+		expectSucess        bool
+		expectMFA           bool
+		expectedErr         error
+	}{
+		{
+			name: "user login success",
+			req:  PublicUserReq{Email: email},
+			mockSetups: func(repo *MockUserRepository, authMiddleware *providers.MockAuthMiddlewareService) {
+				repo.EXPECT().GetUserByEmail(ctx, email).Return(userID, nil)
+				repo.EXPECT().GetUserRoleById(ctx, userID).Return(role, nil)
+				repo.EXPECT().GetUserMFA(ctx, userID).Return(UserMFA{}, sql.ErrNoRows)
+				repo.EXPECT().IsMFARequiredForRole(ctx, role).Return(false, nil)
+				authMiddleware.EXPECT().GenerateJWT(userID.String(), []string{role}).Return(accessToken, nil)
+				authMiddleware.EXPECT().GenerateRefreshToken(ctx, userID.String()).Return(refreshToken, nil)
+			},
+			expectSucess: true,
+		},
+		{
+			name:                "verified user login when verification is required",
+			req:                 PublicUserReq{Email: email},
+			requireVerification: true,
+			mockSetups: func(repo *MockUserRepository, authMiddleware *providers.MockAuthMiddlewareService) {
+				repo.EXPECT().GetUserByEmail(ctx, email).Return(userID, nil)
+				repo.EXPECT().IsEmailVerified(ctx, userID).Return(true, nil)
+				repo.EXPECT().GetUserRoleById(ctx, userID).Return(role, nil)
+				repo.EXPECT().GetUserMFA(ctx, userID).Return(UserMFA{}, sql.ErrNoRows)
+				repo.EXPECT().IsMFARequiredForRole(ctx, role).Return(false, nil)
+				authMiddleware.EXPECT().GenerateJWT(userID.String(), []string{role}).Return(accessToken, nil)
+				authMiddleware.EXPECT().GenerateRefreshToken(ctx, userID.String()).Return(refreshToken, nil)
+			},
+			expectSucess: true,
+		},
+		{
+			name:                "unverified user blocked",
+			req:                 PublicUserReq{Email: email},
+			requireVerification: true,
+			mockSetups: func(repo *MockUserRepository, authMiddleware *providers.MockAuthMiddlewareService) {
+				repo.EXPECT().GetUserByEmail(ctx, email).Return(userID, nil)
+				repo.EXPECT().IsEmailVerified(ctx, userID).Return(false, nil)
+			},
+			expectSucess: false,
+			expectedErr:  ErrEmailNotVerified,
+		},
+		{
+			name: "role requires mfa but user is not enrolled",
+			req:  PublicUserReq{Email: email},
+			mockSetups: func(repo *MockUserRepository, authMiddleware *providers.MockAuthMiddlewareService) {
+				repo.EXPECT().GetUserByEmail(ctx, email).Return(userID, nil)
+				repo.EXPECT().GetUserRoleById(ctx, userID).Return("admin", nil)
+				repo.EXPECT().GetUserMFA(ctx, userID).Return(UserMFA{}, sql.ErrNoRows)
+				repo.EXPECT().IsMFARequiredForRole(ctx, "admin").Return(true, nil)
+			},
+			expectSucess: true,
+			expectMFA:    true,
+		},
+		{
+			name: "user with mfa enabled gets a challenge",
+			req:  PublicUserReq{Email: email},
+			mockSetups: func(repo *MockUserRepository, authMiddleware *providers.MockAuthMiddlewareService) {
+				enabledAt := time.Now()
+				repo.EXPECT().GetUserByEmail(ctx, email).Return(userID, nil)
+				repo.EXPECT().GetUserRoleById(ctx, userID).Return(role, nil)
+				repo.EXPECT().GetUserMFA(ctx, userID).Return(UserMFA{EnabledAt: &enabledAt}, nil)
+			},
+			expectSucess: true,
+			expectMFA:    true,
+		},
+		{
+			name: "user email not found",
+			req:  PublicUserReq{Email: email},
+			mockSetups: func(repo *MockUserRepository, authMiddleware *providers.MockAuthMiddlewareService) {
+				repo.EXPECT().GetUserByEmail(ctx, email).Return(uuid.Nil, errors.New("user not found"))
+			},
+			expectSucess: false,
+		},
+		{
+			name: "user role not found",
+			req:  PublicUserReq{Email: email},
+			mockSetups: func(repo *MockUserRepository, authMiddleware *providers.MockAuthMiddlewareService) {
+				repo.EXPECT().GetUserByEmail(ctx, email).Return(userID, nil)
+				repo.EXPECT().GetUserRoleById(ctx, userID).Return("", errors.New("user role not found"))
+			},
+			expectSucess: false,
+		},
+		{
+			name: "failed to generate access token",
+			req:  PublicUserReq{Email: email},
+			mockSetups: func(repo *MockUserRepository, authMiddleware *providers.MockAuthMiddlewareService) {
+				repo.EXPECT().GetUserByEmail(ctx, email).Return(userID, nil)
+				repo.EXPECT().GetUserRoleById(ctx, userID).Return(role, nil)
+				repo.EXPECT().GetUserMFA(ctx, userID).Return(UserMFA{}, sql.ErrNoRows)
+				repo.EXPECT().IsMFARequiredForRole(ctx, role).Return(false, nil)
+				authMiddleware.EXPECT().GenerateJWT(userID.String(), []string{role}).Return("", errors.New("failed to generate access token"))
+			},
+			expectSucess: false,
+		},
+		{
+			name: "failed to generate refresh token",
+			req:  PublicUserReq{Email: email},
+			mockSetups: func(repo *MockUserRepository, authMiddleware *providers.MockAuthMiddlewareService) {
+				repo.EXPECT().GetUserByEmail(ctx, email).Return(userID, nil)
+				repo.EXPECT().GetUserRoleById(ctx, userID).Return(role, nil)
+				repo.EXPECT().GetUserMFA(ctx, userID).Return(UserMFA{}, sql.ErrNoRows)
+				repo.EXPECT().IsMFARequiredForRole(ctx, role).Return(false, nil)
+				authMiddleware.EXPECT().GenerateJWT(userID.String(), []string{role}).Return(accessToken, nil)
+				authMiddleware.EXPECT().GenerateRefreshToken(ctx, userID.String()).Return("", errors.New("failed to generate refresh token"))
+			},
+			expectSucess: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := NewMockUserRepository(ctrl)
+			mockAuthMiddleware := providers.NewMockAuthMiddlewareService(ctrl)
+			mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+			mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+			mockConfig := providers.NewMockConfigProvider(ctrl)
+			mockConfig.EXPECT().RequireEmailVerification().Return(tc.requireVerification).AnyTimes()
+
+			tc.mockSetups(mockRepo, mockAuthMiddleware)
+			mockRepo.EXPECT().GetLoginLockedUntil(ctx, gomock.Any()).Return(time.Time{}).AnyTimes()
+			mockRepo.EXPECT().ClearLoginFailures(ctx, gomock.Any()).AnyTimes()
+			mockAudit := auditservice.NewMockAuditService(ctrl)
+			mockAudit.EXPECT().RecordAuthEvent(ctx, gomock.Any()).Return(nil).AnyTimes()
+
+			service := &userServiceStruct{
+				repo:           mockRepo,
+				AuthMiddleware: mockAuthMiddleware,
+				logger:         mockLogger,
+				config:         mockConfig,
+				audit:          mockAudit,
+			}
+
+			res, err := service.UserLogin(ctx, tc.req, models.ClientInfo{})
+
+			if tc.expectSucess {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectMFA, res.MFARequired)
+				if tc.expectMFA {
+					assert.NotEmpty(t, res.MFAToken)
+					assert.Empty(t, res.AccessToken)
+				} else {
+					assert.Equal(t, accessToken, res.AccessToken)
+				}
+			} else {
+				assert.Error(t, err)
+			}
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	email := "Test.User@remotestate.com"
+	clientIP := "10.0.0.1"
+	policy := models.LockoutPolicy{
+		MaxFailures:      5,
+		MaxFailuresPerIP: 20,
+		FailureWindow:    15 * time.Minute,
+		BaseLockout:      time.Minute,
+		MaxLockout:       time.Hour,
+		LockoutDecay:     24 * time.Hour,
+	}
+
+	tests := []struct {
+		name            string
+		setupMocks      func(repo *MockUserRepository, audit *auditservice.MockAuditService)
+		expectLocked    bool
+		minRetryAfter   time.Duration
+		maxRetryAfter   time.Duration
+		expectOtherFail bool
+		expectOutcome   string
+	}{
+		{
+			name: "locked email is refused before the lookup",
+			setupMocks: func(repo *MockUserRepository, audit *auditservice.MockAuditService) {
+				repo.EXPECT().GetLoginLockedUntil(ctx, "email:test.user@remotestate.com", "ip:"+clientIP).Return(time.Now().Add(3 * time.Minute))
+			},
+			expectLocked:  true,
+			minRetryAfter: 2 * time.Minute,
+			maxRetryAfter: 3 * time.Minute,
+			expectOutcome: auditservice.AuthOutcomeLocked,
+		},
+		{
+			name: "failure under the limit is only counted",
+			setupMocks: func(repo *MockUserRepository, audit *auditservice.MockAuditService) {
+				repo.EXPECT().GetLoginLockedUntil(ctx, "email:test.user@remotestate.com", "ip:"+clientIP).Return(time.Time{})
+				repo.EXPECT().GetUserByEmail(ctx, email).Return(uuid.Nil, sql.ErrNoRows)
+				repo.EXPECT().IncrementLoginFailures(ctx, "email:test.user@remotestate.com", policy.FailureWindow).Return(2, nil)
+				repo.EXPECT().IncrementLoginFailures(ctx, "ip:"+clientIP, policy.FailureWindow).Return(2, nil)
+			},
+			expectOtherFail: true,
+			expectOutcome:   auditservice.AuthOutcomeFailure,
+		},
+		{
+			name: "reaching the limit locks with backoff and audits",
+			setupMocks: func(repo *MockUserRepository, audit *auditservice.MockAuditService) {
+				repo.EXPECT().GetLoginLockedUntil(ctx, "email:test.user@remotestate.com", "ip:"+clientIP).Return(time.Time{})
+				repo.EXPECT().GetUserByEmail(ctx, email).Return(uuid.Nil, sql.ErrNoRows)
+				repo.EXPECT().IncrementLoginFailures(ctx, "email:test.user@remotestate.com", policy.FailureWindow).Return(5, nil)
+				repo.EXPECT().IncrementLoginLockouts(ctx, "email:test.user@remotestate.com", policy.LockoutDecay).Return(3, nil)
+				repo.EXPECT().LockLogin(ctx, "email:test.user@remotestate.com", gomock.Any()).Return(nil)
+				repo.EXPECT().IncrementLoginFailures(ctx, "ip:"+clientIP, policy.FailureWindow).Return(5, nil)
+				audit.EXPECT().Record(ctx, gomock.Any()).DoAndReturn(
+					func(_ context.Context, entry auditservice.AuditEntry) error {
+						assert.Equal(t, "auth.lockout", entry.Action)
+						assert.Equal(t, "email:test.user@remotestate.com", entry.EntityID)
+						return nil
+					})
+			},
+			expectLocked:  true,
+			minRetryAfter: 4 * time.Minute,
+			maxRetryAfter: 4 * time.Minute,
+			expectOutcome: auditservice.AuthOutcomeLocked,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := NewMockUserRepository(ctrl)
+			mockAudit := auditservice.NewMockAuditService(ctrl)
+			mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+			mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+			mockConfig := providers.NewMockConfigProvider(ctrl)
+			mockConfig.EXPECT().GetLockoutPolicy().Return(policy).AnyTimes()
+
+			tc.setupMocks(mockRepo, mockAudit)
+			mockAudit.EXPECT().RecordAuthEvent(ctx, gomock.Any()).DoAndReturn(
+				func(_ context.Context, event auditservice.AuthEvent) error {
+					assert.Equal(t, auditservice.AuthEventLogin, event.EventType)
+					assert.Equal(t, tc.expectOutcome, event.Outcome)
+					assert.Equal(t, email, event.Identifier)
+					assert.Equal(t, clientIP, event.IPAddress)
+					assert.Equal(t, "test-agent", event.UserAgent)
+					return nil
+				})
+
+			service := &userServiceStruct{
+				repo:   mockRepo,
+				logger: mockLogger,
+				config: mockConfig,
+				audit:  mockAudit,
+			}
+
+			_, err := service.UserLogin(ctx, PublicUserReq{Email: email}, models.ClientInfo{IP: clientIP, UserAgent: "test-agent"})
+			var locked *LoginLockedError
+			if tc.expectLocked {
+				assert.ErrorIs(t, err, ErrLoginLocked)
+				assert.True(t, errors.As(err, &locked))
+				assert.GreaterOrEqual(t, locked.RetryAfter, tc.minRetryAfter)
+				assert.LessOrEqual(t, locked.RetryAfter, tc.maxRetryAfter)
+			}
+			if tc.expectOtherFail {
+				assert.Error(t, err)
+				assert.False(t, errors.Is(err, ErrLoginLocked))
+			}
+		})
+	}
+}
+
+func TestRefreshTokens(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	userID := uuid.New()
+	client := models.ClientInfo{IP: "10.0.0.1", UserAgent: "test-agent"}
+
+	tests := []struct {
+		name          string
+		setupMocks    func(auth *providers.MockAuthMiddlewareService)
+		expectedErr   error
+		expectOutcome string
+	}{
+		{
+			name: "rotates and records the refresh",
+			setupMocks: func(auth *providers.MockAuthMiddlewareService) {
+				auth.EXPECT().RefreshTokens(ctx, "refresh").Return(userID.String(), "eyJhbGciOiJub25lIn0.eyJleHAiOjQxMDI0NDQ4MDB9.sig", "next", nil)
+			},
+			expectOutcome: auditservice.AuthOutcomeSuccess,
+		},
+		{
+			name: "reused token ends the session",
+			setupMocks: func(auth *providers.MockAuthMiddlewareService) {
+				auth.EXPECT().RefreshTokens(ctx, "refresh").Return(userID.String(), "", "", middlewareprovider.ErrRefreshTokenReused)
+			},
+			expectedErr:   ErrSessionExpired,
+			expectOutcome: auditservice.AuthOutcomeFailure,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockAuth := providers.NewMockAuthMiddlewareService(ctrl)
+			mockAudit := auditservice.NewMockAuditService(ctrl)
+			mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+			mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+			tc.setupMocks(mockAuth)
+			mockAudit.EXPECT().RecordAuthEvent(ctx, gomock.Any()).DoAndReturn(
+				func(_ context.Context, event auditservice.AuthEvent) error {
+					assert.Equal(t, auditservice.AuthEventTokenRefresh, event.EventType)
+					assert.Equal(t, tc.expectOutcome, event.Outcome)
+					assert.Equal(t, userID, *event.UserID)
+					assert.Equal(t, client.IP, event.IPAddress)
+					return nil
+				})
+
+			service := &userServiceStruct{
+				logger:         mockLogger,
+				AuthMiddleware: mockAuth,
+				audit:          mockAudit,
+			}
+
+			res, err := service.RefreshTokens(ctx, RefreshTokenReq{RefreshToken: "refresh"}, client)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "next", res.RefreshToken)
+			assert.Equal(t, int64(4102444800), res.AccessTokenExpiresAt.Unix())
+		})
+	}
+}
+
+func TestOIDCLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	userID := uuid.New()
+	idToken := "id-token"
+	identity := models.OIDCIdentity{
+		Provider: "okta",
+		Issuer:   "https://example.okta.com",
+		Subject:  "00u1abcd",
+		Email:    "test.user@remotestate.com",
+	}
+	verified := identity
+	verified.EmailVerified = true
+	mapped := identity
+	mapped.Role = "employee"
+
+	tests := []struct {
+		name        string
+		provider    string
+		setupMocks  func(repo *MockUserRepository, oidc *providers.MockOIDCProvider, auth *providers.MockAuthMiddlewareService)
+		expectedErr error
+		expectErr   bool
+	}{
+		{
+			name:     "unknown provider",
+			provider: "azure",
+			setupMocks: func(repo *MockUserRepository, oidc *providers.MockOIDCProvider, auth *providers.MockAuthMiddlewareService) {
+			},
+			expectedErr: ErrOIDCProviderUnknown,
+		},
+		{
+			name:     "linked identity signs in with the user id as subject",
+			provider: "okta",
+			setupMocks: func(repo *MockUserRepository, oidc *providers.MockOIDCProvider, auth *providers.MockAuthMiddlewareService) {
+				oidc.EXPECT().VerifyIDToken(ctx, idToken).Return(identity, nil)
+				repo.EXPECT().GetUserIDByIdentity(ctx, identity.Issuer, identity.Subject).Return(userID, nil)
+				repo.EXPECT().LinkIdentity(ctx, userID, identity).Return(nil)
+				repo.EXPECT().GetUserRoleById(ctx, userID).Return("employee", nil)
+				repo.EXPECT().GetUserMFA(ctx, userID).Return(UserMFA{}, sql.ErrNoRows)
+				repo.EXPECT().IsMFARequiredForRole(ctx, "employee").Return(false, nil)
+				auth.EXPECT().GenerateJWT(userID.String(), []string{"employee"}).Return("access", nil)
+				auth.EXPECT().GenerateRefreshToken(ctx, userID.String()).Return("refresh", nil)
+			},
+		},
+		{
+			name:     "first login links a verified email to the existing user",
+			provider: "okta",
+			setupMocks: func(repo *MockUserRepository, oidc *providers.MockOIDCProvider, auth *providers.MockAuthMiddlewareService) {
+				oidc.EXPECT().VerifyIDToken(ctx, idToken).Return(verified, nil)
+				repo.EXPECT().GetUserIDByIdentity(ctx, verified.Issuer, verified.Subject).Return(uuid.Nil, sql.ErrNoRows)
+				repo.EXPECT().GetUserByEmail(ctx, verified.Email).Return(userID, nil)
+				repo.EXPECT().LinkIdentity(ctx, userID, verified).Return(nil)
+				repo.EXPECT().MarkEmailVerified(ctx, userID, verified.Email).Return(true, nil)
+				repo.EXPECT().GetUserRoleById(ctx, userID).Return("employee", nil)
+				repo.EXPECT().GetUserMFA(ctx, userID).Return(UserMFA{}, sql.ErrNoRows)
+				repo.EXPECT().IsMFARequiredForRole(ctx, "employee").Return(false, nil)
+				auth.EXPECT().GenerateJWT(userID.String(), []string{"employee"}).Return("access", nil)
+				auth.EXPECT().GenerateRefreshToken(ctx, userID.String()).Return("refresh", nil)
+			},
+		},
+		{
+			name:     "unverified email is not linked to an existing user",
+			provider: "okta",
+			setupMocks: func(repo *MockUserRepository, oidc *providers.MockOIDCProvider, auth *providers.MockAuthMiddlewareService) {
+				oidc.EXPECT().VerifyIDToken(ctx, idToken).Return(identity, nil)
+				repo.EXPECT().GetUserIDByIdentity(ctx, identity.Issuer, identity.Subject).Return(uuid.Nil, sql.ErrNoRows)
+				repo.EXPECT().GetUserByEmail(ctx, identity.Email).Return(userID, nil)
+			},
+			expectedErr: ErrEmailNotVerified,
+		},
+		{
+			name:     "mapped role already held is left alone",
+			provider: "okta",
+			setupMocks: func(repo *MockUserRepository, oidc *providers.MockOIDCProvider, auth *providers.MockAuthMiddlewareService) {
+				oidc.EXPECT().VerifyIDToken(ctx, idToken).Return(mapped, nil)
+				repo.EXPECT().GetUserIDByIdentity(ctx, mapped.Issuer, mapped.Subject).Return(userID, nil)
+				repo.EXPECT().LinkIdentity(ctx, userID, mapped).Return(nil)
+				repo.EXPECT().GetUserRoleById(ctx, userID).Return("employee", nil).Times(2)
+				repo.EXPECT().GetUserMFA(ctx, userID).Return(UserMFA{}, sql.ErrNoRows)
+				repo.EXPECT().IsMFARequiredForRole(ctx, "employee").Return(false, nil)
+				auth.EXPECT().GenerateJWT(userID.String(), []string{"employee"}).Return("access", nil)
+				auth.EXPECT().GenerateRefreshToken(ctx, userID.String()).Return("refresh", nil)
+			},
+		},
+		{
+			name:     "invalid token",
+			provider: "okta",
+			setupMocks: func(repo *MockUserRepository, oidc *providers.MockOIDCProvider, auth *providers.MockAuthMiddlewareService) {
+				oidc.EXPECT().VerifyIDToken(ctx, idToken).Return(models.OIDCIdentity{}, errors.New("token expired"))
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := NewMockUserRepository(ctrl)
+			mockOIDC := providers.NewMockOIDCProvider(ctrl)
+			mockAuth := providers.NewMockAuthMiddlewareService(ctrl)
+			mockConfig := providers.NewMockConfigProvider(ctrl)
+			mockConfig.EXPECT().RequireEmailVerification().Return(false).AnyTimes()
+			mockConfig.EXPECT().GetLockoutPolicy().Return(models.LockoutPolicy{}).AnyTimes()
+			mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+			mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+			mockAudit := auditservice.NewMockAuditService(ctrl)
+			mockAudit.EXPECT().RecordAuthEvent(ctx, gomock.Any()).Return(nil).AnyTimes()
+
+			tc.setupMocks(mockRepo, mockOIDC, mockAuth)
+
+			service := &userServiceStruct{
+				repo:           mockRepo,
+				logger:         mockLogger,
+				AuthMiddleware: mockAuth,
+				config:         mockConfig,
+				audit:          mockAudit,
+				oidc:           map[string]providers.OIDCProvider{"okta": mockOIDC},
+			}
+
+			res, err := service.OIDCLogin(ctx, tc.provider, idToken, models.ClientInfo{})
+			switch {
+			case tc.expectedErr != nil:
+				assert.ErrorIs(t, err, tc.expectedErr)
+			case tc.expectErr:
+				assert.Error(t, err)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, userID, res.UserID)
+				assert.Equal(t, "access", res.AccessToken)
+			}
+		})
+	}
+}
+
+func TestRegisterEmployeeByManagerWithTemplate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	managerID := uuid.New()
+	templateID := uuid.New()
+	managerDepartment := uuid.New()
+	otherDepartment := uuid.New()
+	organizationID := uuid.New()
+
+	tests := []struct {
+		name        string
+		template    models.OnboardingTemplate
+		templateErr error
+		scope       models.DepartmentScope
+		expectedErr error
+	}{
+		{
+			name:        "template not found",
+			templateErr: sql.ErrNoRows,
+			scope:       models.DepartmentScope{AllDepartments: true},
+			expectedErr: ErrTemplateNotFound,
+		},
+		{
+			// the repository finds no template of another organization
+			name:        "template of another organization",
+			templateErr: sql.ErrNoRows,
+			scope:       models.DepartmentScope{AllDepartments: true, OrganizationID: &organizationID},
+			expectedErr: ErrTemplateNotFound,
+		},
+		{
+			name:        "template for another employee type",
+			template:    models.OnboardingTemplate{ID: templateID, EmployeeType: "full_time"},
+			scope:       models.DepartmentScope{AllDepartments: true},
+			expectedErr: ErrTemplateTypeMismatch,
+		},
+		{
+			name:        "template of another department",
+			template:    models.OnboardingTemplate{ID: templateID, EmployeeType: "intern", DepartmentID: &otherDepartment},
+			scope:       models.DepartmentScope{DepartmentID: &managerDepartment},
+			expectedErr: models.ErrOutOfScope,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := NewMockUserRepository(ctrl)
+			mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+			mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+			mockRepo.EXPECT().GetOnboardingTemplate(ctx, templateID, tc.scope.OrganizationID).Return(tc.template, tc.templateErr)
+			mockConfig := providers.NewMockConfigProvider(ctrl)
+			mockConfig.EXPECT().GetDefaultCountryCode().Return("91").AnyTimes()
+
+			service := &userServiceStruct{
+				repo:   mockRepo,
+				logger: mockLogger,
+				config: mockConfig,
+			}
+
+			req := ManagerRegisterReq{
+				Username:   "intern",
+				Email:      "intern@remotestate.com",
+				ContactNo:  "9876543210",
+				Type:       "intern",
+				TemplateID: &templateID,
+			}
+			_, _, err := service.RegisterEmployeeByManager(ctx, req, managerID, tc.scope)
+
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestRequestMagicLink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	email := "test.user41@remotestate.com"
+	userID := uuid.New()
+	policy := models.MagicLinkPolicy{TTL: 15 * time.Minute, MaxPerEmail: 5, RequestWindow: time.Hour}
+
+	tests := []struct {
+		name        string
+		setupMocks  func(repo *MockUserRepository, mailer *providers.MockEmailProvider)
+		expectedErr error
+	}{
+		{
+			name: "link is stored and mailed",
+			setupMocks: func(repo *MockUserRepository, mailer *providers.MockEmailProvider) {
+				repo.EXPECT().IncrementMagicLinkRequests(ctx, email, policy.RequestWindow).Return(1, nil)
+				repo.EXPECT().GetUserByEmail(ctx, email).Return(userID, nil)
+				repo.EXPECT().GetEmailByUserID(ctx, userID).Return(email, nil)
+				repo.EXPECT().StoreMagicLink(ctx, gomock.Any(), userID, policy.TTL).Return(nil)
+				mailer.EXPECT().Send(ctx, email, gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _, _, body string) error {
+						assert.Contains(t, body, "https://assets.example.com/magic-link?token=")
+						return nil
+					})
+			},
+		},
+		{
+			name: "requests over the limit are dropped silently",
+			setupMocks: func(repo *MockUserRepository, mailer *providers.MockEmailProvider) {
+				repo.EXPECT().IncrementMagicLinkRequests(ctx, email, policy.RequestWindow).Return(6, nil)
+			},
+		},
+		{
+			name: "unknown email is not reported",
+			setupMocks: func(repo *MockUserRepository, mailer *providers.MockEmailProvider) {
+				repo.EXPECT().IncrementMagicLinkRequests(ctx, email, policy.RequestWindow).Return(1, nil)
+				repo.EXPECT().GetUserByEmail(ctx, email).Return(uuid.Nil, sql.ErrNoRows)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := NewMockUserRepository(ctrl)
+			mockMailer := providers.NewMockEmailProvider(ctrl)
+			mockConfig := providers.NewMockConfigProvider(ctrl)
+			mockConfig.EXPECT().GetMagicLinkPolicy().Return(policy).AnyTimes()
+			mockConfig.EXPECT().GetAppBaseURL().Return("https://assets.example.com").AnyTimes()
+			mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+			mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+			tc.setupMocks(mockRepo, mockMailer)
+
+			service := &userServiceStruct{
+				repo:   mockRepo,
+				logger: mockLogger,
+				mailer: mockMailer,
+				config: mockConfig,
+			}
+
+			err := service.RequestMagicLink(ctx, PublicUserReq{Email: email})
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestSendContactOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	number := "+919876543210"
+	smsConfig := models.SMSConfig{VerifyContactNo: true, OTPTTL: 10 * time.Minute}
+
+	tests := []struct {
+		name        string
+		config      models.SMSConfig
+		setupMocks  func(repo *MockUserRepository, sms *providers.MockSMSProvider)
+		expectedErr error
+	}{
+		{
+			name:   "code is texted and its hash stored",
+			config: smsConfig,
+			setupMocks: func(repo *MockUserRepository, sms *providers.MockSMSProvider) {
+				var code string
+				sms.EXPECT().Send(ctx, number, models.SMSTemplateOTP, gomock.Any()).DoAndReturn(
+					func(_ context.Context, _, _ string, data map[string]string) error {
+						code = data["code"]
+						assert.Len(t, code, 6)
+						assert.Equal(t, "10", data["minutes"])
+						return nil
+					})
+				repo.EXPECT().StoreContactOTP(ctx, number, gomock.Any(), smsConfig.OTPTTL).DoAndReturn(
+					func(_ context.Context, _, codeHash string, _ time.Duration) error {
+						assert.Equal(t, utils.HashBackupCode(code), codeHash)
+						return nil
+					})
+			},
+		},
+		{
+			name:   "a refused message keeps the earlier code",
+			config: smsConfig,
+			setupMocks: func(repo *MockUserRepository, sms *providers.MockSMSProvider) {
+				sms.EXPECT().Send(ctx, number, models.SMSTemplateOTP, gomock.Any()).Return(models.ErrSMSRateLimited)
+			},
+			expectedErr: models.ErrSMSRateLimited,
+		},
+		{
+			name:        "verification switched off",
+			config:      models.SMSConfig{OTPTTL: time.Minute},
+			setupMocks:  func(repo *MockUserRepository, sms *providers.MockSMSProvider) {},
+			expectedErr: ErrContactOTPOff,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := NewMockUserRepository(ctrl)
+			mockSMS := providers.NewMockSMSProvider(ctrl)
+			mockConfig := providers.NewMockConfigProvider(ctrl)
+			mockConfig.EXPECT().GetSMSConfig().Return(tc.config).AnyTimes()
+			mockConfig.EXPECT().GetDefaultCountryCode().Return("91").AnyTimes()
+			mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+			mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+			tc.setupMocks(mockRepo, mockSMS)
+
+			service := &userServiceStruct{
+				repo:   mockRepo,
+				logger: mockLogger,
+				config: mockConfig,
+				sms:    mockSMS,
+			}
+
+			err := service.SendContactOTP(ctx, ContactOTPReq{ContactNo: "9876543210"})
+			assert.ErrorIs(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestCheckContactOTP(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	number := "+919876543210"
+	mockRepo := NewMockUserRepository(ctrl)
+	service := &userServiceStruct{repo: mockRepo}
+
+	assert.ErrorIs(t, service.checkContactOTP(ctx, number, ""), ErrContactOTPRequired)
+
+	mockRepo.EXPECT().CheckContactOTP(ctx, number, utils.HashBackupCode("123456"), contactOTPMaxAttempts).Return(true, nil)
+	assert.NoError(t, service.checkContactOTP(ctx, number, " 123456 "))
+
+	mockRepo.EXPECT().CheckContactOTP(ctx, number, utils.HashBackupCode("654321"), contactOTPMaxAttempts).Return(false, nil)
+	assert.ErrorIs(t, service.checkContactOTP(ctx, number, "654321"), ErrContactOTPInvalid)
+}
+
+func TestMagicLinkLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	email := "test.user41@remotestate.com"
+	userID := uuid.New()
+	linkID := uuid.NewString()
+	role := "employee"
+
+	validToken, err := middlewareprovider.GenerateMagicLinkToken(userID.String(), email, linkID, time.Now().Add(15*time.Minute))
+	assert.NoError(t, err)
+	expiredToken, err := middlewareprovider.GenerateMagicLinkToken(userID.String(), email, linkID, time.Now().Add(-time.Minute))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		token       string
+		setupMocks  func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService)
+		expectedErr error
+	}{
+		{
+			name:  "valid link signs the user in",
+			token: validToken,
+			setupMocks: func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService) {
+				repo.EXPECT().ConsumeMagicLink(ctx, linkID, userID).Return(true, nil)
+				repo.EXPECT().MarkEmailVerified(ctx, userID, email).Return(true, nil)
+				repo.EXPECT().GetUserRoleById(ctx, userID).Return(role, nil)
+				repo.EXPECT().GetUserMFA(ctx, userID).Return(UserMFA{}, sql.ErrNoRows)
+				repo.EXPECT().IsMFARequiredForRole(ctx, role).Return(false, nil)
+				auth.EXPECT().GenerateJWT(userID.String(), []string{role}).Return("Access_Token", nil)
+				auth.EXPECT().GenerateRefreshToken(ctx, userID.String()).Return("Refresh_Token", nil)
+				repo.EXPECT().ClearLoginFailures(ctx, loginSubjectEmail(email))
+			},
+		},
+		{
+			name:  "used link is rejected",
+			token: validToken,
+			setupMocks: func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService) {
+				repo.EXPECT().ConsumeMagicLink(ctx, linkID, userID).Return(false, nil)
+			},
+			expectedErr: ErrMagicLinkInvalid,
+		},
+		{
+			name:  "email changed since the link was sent",
+			token: validToken,
+			setupMocks: func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService) {
+				repo.EXPECT().ConsumeMagicLink(ctx, linkID, userID).Return(true, nil)
+				repo.EXPECT().MarkEmailVerified(ctx, userID, email).Return(false, nil)
+			},
+			expectedErr: ErrMagicLinkInvalid,
+		},
+		{
+			name:        "expired link",
+			token:       expiredToken,
+			setupMocks:  func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService) {},
+			expectedErr: ErrMagicLinkExpired,
+		},
+		{
+			name:        "malformed link",
+			token:       "not-a-token",
+			setupMocks:  func(repo *MockUserRepository, auth *providers.MockAuthMiddlewareService) {},
+			expectedErr: ErrMagicLinkInvalid,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := NewMockUserRepository(ctrl)
+			mockAuth := providers.NewMockAuthMiddlewareService(ctrl)
+			mockAudit := auditservice.NewMockAuditService(ctrl)
+			mockAudit.EXPECT().RecordAuthEvent(ctx, gomock.Any()).Return(nil).AnyTimes()
+			mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+			mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+			tc.setupMocks(mockRepo, mockAuth)
+
+			service := &userServiceStruct{
+				repo:           mockRepo,
+				logger:         mockLogger,
+				AuthMiddleware: mockAuth,
+				audit:          mockAudit,
+			}
+
+			res, err := service.MagicLinkLogin(ctx, MagicLinkLoginReq{Token: tc.token}, models.ClientInfo{})
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "Access_Token", res.AccessToken)
+			assert.Equal(t, "Refresh_Token", res.RefreshToken)
+		})
+	}
+}
+
+// the link is single use only at the exchange, the signature stays valid until exp so the middleware
+// has to turn it away on its own
+func TestUsedMagicLinkIsNotABearerToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	email := "test.user42@remotestate.com"
+	userID := uuid.New()
+	linkID := uuid.NewString()
+	token, err := middlewareprovider.GenerateMagicLinkToken(userID.String(), email, linkID, time.Now().Add(15*time.Minute))
+	assert.NoError(t, err)
+
+	mockRepo := NewMockUserRepository(ctrl)
+	mockAuth := providers.NewMockAuthMiddlewareService(ctrl)
+	mockAudit := auditservice.NewMockAuditService(ctrl)
+	mockAudit.EXPECT().RecordAuthEvent(ctx, gomock.Any()).Return(nil).AnyTimes()
+	mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+	mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+	mockRepo.EXPECT().ConsumeMagicLink(ctx, linkID, userID).Return(true, nil)
+	mockRepo.EXPECT().MarkEmailVerified(ctx, userID, email).Return(true, nil)
+	mockRepo.EXPECT().GetUserRoleById(ctx, userID).Return("employee", nil)
+	mockRepo.EXPECT().GetUserMFA(ctx, userID).Return(UserMFA{}, sql.ErrNoRows)
+	mockRepo.EXPECT().IsMFARequiredForRole(ctx, "employee").Return(false, nil)
+	mockAuth.EXPECT().GenerateJWT(userID.String(), []string{"employee"}).Return("Access_Token", nil)
+	mockAuth.EXPECT().GenerateRefreshToken(ctx, userID.String()).Return("Refresh_Token", nil)
+	mockRepo.EXPECT().ClearLoginFailures(ctx, loginSubjectEmail(email))
+
+	service := &userServiceStruct{repo: mockRepo, logger: mockLogger, AuthMiddleware: mockAuth, audit: mockAudit}
+	_, err = service.MagicLinkLogin(ctx, MagicLinkLoginReq{Token: token}, models.ClientInfo{})
+	assert.NoError(t, err)
+
+	auth := middlewareprovider.NewAuthMiddlewareService(nil, redisprovider.NewMemoryRedisProvider(), models.AuthCookieConfig{}, "")
+	reached := false
+	handler := auth.JWTAuthMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/api/users/me", nil)
+	req.Header.Set("Authorization", token)
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+	assert.False(t, reached)
+}
+
+func TestReconcileFirebaseClaims(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	inSyncID, driftedID, missingID := uuid.New(), uuid.New(), uuid.New()
+	page := []FirebaseRoleClaims{
+		{UserID: inSyncID, FirebaseUID: "uid-in-sync", Role: "employee", Permissions: []string{"asset:read"}},
+		{UserID: driftedID, FirebaseUID: "uid-drifted", Role: "asset_manager", Permissions: []string{"asset:read", "asset:write"}},
+		{UserID: missingID, FirebaseUID: "uid-deleted", Role: "employee", Permissions: []string{"asset:read"}},
+	}
+
+	mockRepo := NewMockUserRepository(ctrl)
+	mockFirebase := providers.NewMockFirebaseProvider(ctrl)
+	mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+	mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	mockRepo.EXPECT().ListFirebaseRoleClaims(ctx, uuid.Nil, firebaseClaimsBatchSize).Return(page, nil)
+	mockFirebase.EXPECT().GetUsersByUID(ctx, []string{"uid-in-sync", "uid-drifted", "uid-deleted"}).Return([]*firebaseauth.UserRecord{
+		{UserInfo: &firebaseauth.UserInfo{UID: "uid-in-sync"}, CustomClaims: map[string]interface{}{
+			"role": "employee", "permissions": []interface{}{"asset:read"},
+		}},
+		{UserInfo: &firebaseauth.UserInfo{UID: "uid-drifted"}, CustomClaims: map[string]interface{}{
+			"role": "employee", "permissions": []interface{}{"asset:read"}, "tier": "beta",
+		}},
+	}, nil)
+	// only the drifted user is written, and claims set elsewhere survive
+	mockFirebase.EXPECT().SetCustomUserClaims(ctx, "uid-drifted", map[string]interface{}{
+		"role": "asset_manager", "permissions": []string{"asset:read", "asset:write"}, "tier": "beta",
+	}).Return(nil)
+
+	service := &userServiceStruct{
+		repo:     mockRepo,
+		logger:   mockLogger,
+		firebase: mockFirebase,
+	}
+	assert.NoError(t, service.ReconcileFirebaseClaims(ctx))
+}
+
+func TestClaimsInSync(t *testing.T) {
+	current := map[string]interface{}{"role": "employee", "permissions": []interface{}{"asset:read"}}
+
+	assert.True(t, claimsInSync(current, "employee", []string{"asset:read"}))
+	assert.False(t, claimsInSync(current, "admin", []string{"asset:read"}))
+	assert.False(t, claimsInSync(current, "employee", []string{"asset:read", "asset:write"}))
+	assert.False(t, claimsInSync(current, "employee", nil))
+	assert.True(t, claimsInSync(map[string]interface{}{"role": "employee"}, "employee", nil))
+	assert.False(t, claimsInSync(nil, "employee", []string{}))
+}
+
+func TestSetTimezone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	userID := uuid.New()
+	mockRepo := NewMockUserRepository(ctrl)
+	mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+	mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+	service := &userServiceStruct{repo: mockRepo, logger: mockLogger}
+
+	kolkata := "Asia/Kolkata"
+	mockRepo.EXPECT().SetTimezone(ctx, userID, &kolkata).Return(nil)
+	res, err := service.SetTimezone(ctx, userID, kolkata)
+	assert.NoError(t, err)
+	assert.Equal(t, &kolkata, res.Timezone)
+
+	mockRepo.EXPECT().SetTimezone(ctx, userID, (*string)(nil)).Return(nil)
+	res, err = service.SetTimezone(ctx, userID, "")
+	assert.NoError(t, err)
+	assert.Nil(t, res.Timezone)
+
+	for _, invalid := range []string{"Mars/Olympus", "Local", "../etc/passwd"} {
+		_, err = service.SetTimezone(ctx, userID, invalid)
+		assert.ErrorIs(t, err, ErrInvalidTimezone, invalid)
+	}
+}
+
+func TestGetDashboardSummary(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	userID := uuid.New()
+	mockRepo := NewMockUserRepository(ctrl)
+	mockLogger := providers.NewMockZapLoggerProvider(ctrl)
+	mockLogger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+	service := &userServiceStruct{repo: mockRepo, logger: mockLogger}
+
+	laptop, mouse := uuid.New(), uuid.New()
+	mockRepo.EXPECT().GetUserDashboardById(ctx, userID).Return(UserDashboardRes{
+		AssignedAssets: []AssetDetails{
+			{ID: laptop, Type: "laptop", Model: "XPS 13", SerialNo: "SN1", Status: "assigned"},
+			{ID: mouse, Type: "mouse", Model: "MX Master", SerialNo: "SN2", Status: "sent_for_service"},
+		},
+	}, nil)
+	mockRepo.EXPECT().GetPendingRoleChanges(ctx, userID).Return([]ScheduledRoleChangeRes{{}}, nil)
+
+	res, err := service.GetDashboardSummary(ctx, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, res.AssetCount)
+	assert.Equal(t, map[string]int{"laptop": 1, "mouse": 1}, res.AssetsByType)
+	assert.Equal(t, map[string]int{"assigned": 1, "sent_for_service": 1}, res.AssetsByStatus)
+	assert.Equal(t, 1, res.PendingRoleChanges)
+	assert.Equal(t, []AssetSummary{{ID: laptop, Type: "laptop", Model: "XPS 13"}, {ID: mouse, Type: "mouse", Model: "MX Master"}}, res.Assets)
+}
+
+func TestGetDirectory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockRepo := NewMockUserRepository(ctrl)
+	service := &userServiceStruct{repo: mockRepo}
+	orgID := uuid.New()
+
+	mockRepo.EXPECT().GetDirectory(ctx, DirectoryFilter{Search: "ana", OrganizationID: &orgID, Limit: 20, Offset: 40}).Return([]DirectoryEntry{{Username: "Ana"}}, nil)
+	entries, err := service.GetDirectory(ctx, DirectoryFilter{Search: "ana", OrganizationID: &orgID, Limit: 20, Offset: 40})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// a page is never larger than directoryMaxLimit
+	mockRepo.EXPECT().GetDirectory(ctx, DirectoryFilter{Limit: directoryMaxLimit}).Return([]DirectoryEntry{}, nil)
+	_, err = service.GetDirectory(ctx, DirectoryFilter{Limit: 5000, Offset: -10})
+	assert.NoError(t, err)
+}
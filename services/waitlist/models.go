@@ -0,0 +1,54 @@
+package waitlistservice
+
+import (
+	"asset/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	StatusWaiting = "waiting"
+	StatusOffered = "offered"
+	StatusClaimed = "claimed"
+	StatusExpired = "expired"
+	StatusLeft    = "left"
+)
+
+// JoinWaitlistReq puts the caller in line for an asset type none of which they could be given now
+type JoinWaitlistReq struct {
+	Type string `json:"type" validate:"required,asset_type"`
+	Note string `json:"note,omitempty" validate:"max=500"`
+}
+
+// ClaimOfferReq takes the asset offered on a waitlist entry of the caller
+type ClaimOfferReq struct {
+	ID uuid.UUID `json:"id" validate:"required"`
+}
+
+// WaitlistFilter narrows the open entries to the employees of the departments in Scope. A Limit of
+// 0 lists them all
+type WaitlistFilter struct {
+	Type   string
+	Limit  int
+	Offset int
+	Scope  models.DepartmentScope
+}
+
+// WaitlistEntry is an employee's place in line for a type. Position counts from 1 among the ones
+// still waiting for the type, the asset fields are set once one was offered
+type WaitlistEntry struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	EmployeeID     uuid.UUID  `json:"employee_id" db:"employee_id"`
+	EmployeeName   string     `json:"employee_name" db:"employee_name"`
+	Type           string     `json:"type" db:"type"`
+	Note           *string    `json:"note,omitempty" db:"note"`
+	Status         string     `json:"status" db:"status"`
+	Position       *int       `json:"position,omitempty" db:"position"`
+	AssetID        *uuid.UUID `json:"asset_id,omitempty" db:"asset_id"`
+	SerialNo       *string    `json:"serial_no,omitempty" db:"serial_no"`
+	OfferedAt      *time.Time `json:"offered_at,omitempty" db:"offered_at"`
+	OfferExpiresAt *time.Time `json:"offer_expires_at,omitempty" db:"offer_expires_at"`
+	ClosedAt       *time.Time `json:"closed_at,omitempty" db:"closed_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,150 @@
+package waitlistservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type WaitlistHandler struct {
+	Service        WaitlistService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewWaitlistHandler(service WaitlistService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *WaitlistHandler {
+	return &WaitlistHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+// JoinWaitlist puts the caller in line for a type, 409 when one is available to them already
+func (h *WaitlistHandler) JoinWaitlist(w http.ResponseWriter, r *http.Request) {
+	employeeID, ok := h.caller(w, r, "JoinWaitlist")
+	if !ok {
+		return
+	}
+	var req JoinWaitlistReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "validation failed")
+		return
+	}
+
+	entry, err := h.Service.JoinWaitlist(r.Context(), req, employeeID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to join waitlist", zap.String("employeeID", employeeID.String()), zap.String("type", req.Type), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to join waitlist")
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+		"message": "you are in line, you will be notified when an asset is offered to you",
+		"entry":   entry,
+	})
+}
+
+// GetMyEntries lists the caller's open waitlist entries with their place in line or offer
+func (h *WaitlistHandler) GetMyEntries(w http.ResponseWriter, r *http.Request) {
+	employeeID, ok := h.caller(w, r, "GetMyWaitlistEntries")
+	if !ok {
+		return
+	}
+	entries, err := h.Service.GetMyEntries(r.Context(), employeeID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch waitlist entries", zap.String("employeeID", employeeID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch waitlist entries")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"entries": entries})
+}
+
+func (h *WaitlistHandler) LeaveWaitlist(w http.ResponseWriter, r *http.Request) {
+	employeeID, ok := h.caller(w, r, "LeaveWaitlist")
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(r.URL.Query().Get("id"))
+	if err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid waitlist entry id")
+		return
+	}
+	if err := h.Service.LeaveWaitlist(r.Context(), id, employeeID); err != nil {
+		h.Logger.GetLogger().Error("Failed to leave waitlist", zap.String("entryID", id.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to leave waitlist")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]string{"message": "you left the waitlist"})
+}
+
+// ClaimOffer assigns the asset offered on one of the caller's entries to them
+func (h *WaitlistHandler) ClaimOffer(w http.ResponseWriter, r *http.Request) {
+	employeeID, ok := h.caller(w, r, "ClaimWaitlistOffer")
+	if !ok {
+		return
+	}
+	var req ClaimOfferReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.RespondError(w, http.StatusBadRequest, err, "validation failed")
+		return
+	}
+
+	entry, err := h.Service.ClaimOffer(r.Context(), req.ID, employeeID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to claim waitlist offer", zap.String("entryID", req.ID.String()), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to claim offer")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"message": "the asset is assigned to you",
+		"entry":   entry,
+	})
+}
+
+// GetWaitlist lists who waits for what in the departments in scope, in the order they are served
+func (h *WaitlistHandler) GetWaitlist(w http.ResponseWriter, r *http.Request) {
+	filter := WaitlistFilter{Type: r.URL.Query().Get("type")}
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve department scope in GetWaitlist", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve department")
+		return
+	}
+	filter.Scope = scope
+
+	entries, err := h.Service.GetWaitlist(r.Context(), filter)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch waitlist", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch waitlist")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"entries": entries, "limit": filter.Limit, "offset": filter.Offset})
+}
+
+func (h *WaitlistHandler) caller(w http.ResponseWriter, r *http.Request, op string) (uuid.UUID, bool) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in "+op, zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return uuid.Nil, false
+	}
+	employeeID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in "+op, zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return uuid.Nil, false
+	}
+	return employeeID, true
+}
@@ -0,0 +1,274 @@
+package waitlistservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type WaitlistRepository interface {
+	// CountOfferable counts the assets of the type the employee could be offered right now
+	CountOfferable(ctx context.Context, assetType string, employeeID uuid.UUID) (int, error)
+	// InsertEntry puts the employee in line, ErrAlreadyWaiting when they are in line for the type
+	InsertEntry(ctx context.Context, req JoinWaitlistReq, employeeID uuid.UUID) (uuid.UUID, error)
+	// GetEntry returns an entry of any status, ErrEntryNotFound when there is none
+	GetEntry(ctx context.Context, id uuid.UUID) (WaitlistEntry, error)
+	// GetEmployeeEntries lists the open entries of the employee oldest first
+	GetEmployeeEntries(ctx context.Context, employeeID uuid.UUID) ([]WaitlistEntry, error)
+	// GetWaitlist lists the open entries by type in the order they are served
+	GetWaitlist(ctx context.Context, filter WaitlistFilter) ([]WaitlistEntry, error)
+	// LeaveEntry closes an open entry of the employee, ErrEntryNotFound when they have none by that id
+	LeaveEntry(ctx context.Context, id, employeeID uuid.UUID) error
+	// GetQueue lists the waiting entries of employees still around, in the order they are served
+	GetQueue(ctx context.Context) ([]WaitlistEntry, error)
+	// OfferAsset holds the first asset the entry's employee could be given for them until expiresAt,
+	// false when there is none or the entry isn't waiting anymore
+	OfferAsset(ctx context.Context, id uuid.UUID, expiresAt time.Time) (bool, error)
+	// ExpireOffers closes the offers not claimed in time and returns them
+	ExpireOffers(ctx context.Context) ([]WaitlistEntry, error)
+	// RequeueVoidOffers puts the entries whose offered asset was given away, archived or allocated
+	// back to waiting, in the place they had
+	RequeueVoidOffers(ctx context.Context) (int64, error)
+	// ClaimEntry closes an offered entry as claimed, false when it isn't offered anymore or its asset
+	// can't be given
+	ClaimEntry(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+type PostgresWaitlistRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewWaitlistRepository(db *sqlx.DB, log providers.ZapLoggerProvider) WaitlistRepository {
+	return &PostgresWaitlistRepository{
+		DB:     db,
+		Logger: log,
+	}
+}
+
+// offerableAsset is an asset a of the type that can go to user u: available, of their organization,
+// pooled or of their department, not lent to a project and not offered to someone else
+const offerableAsset = `
+	a.status = 'available' AND a.archived_at IS NULL
+	AND (a.department_id IS NULL OR a.department_id = u.department_id)
+	AND a.organization_id IS NOT DISTINCT FROM u.organization_id
+	AND NOT EXISTS (SELECT 1 FROM project_assets pa WHERE pa.asset_id = a.id AND pa.released_on IS NULL)
+	AND NOT EXISTS (SELECT 1 FROM asset_waitlist o WHERE o.asset_id = a.id AND o.status = 'offered')`
+
+const entryQuery = `
+	SELECT w.id, w.employee_id, u.username AS employee_name, w.type::text AS type, w.note, w.status,
+		CASE WHEN w.status = 'waiting' THEN (
+			SELECT count(*) FROM asset_waitlist q
+			WHERE q.type = w.type AND q.status = 'waiting' AND (q.created_at, q.id) <= (w.created_at, w.id)
+		) END AS position,
+		w.asset_id, a.serial_no, w.offered_at, w.offer_expires_at, w.closed_at, w.created_at
+	FROM asset_waitlist w
+	JOIN users u ON u.id = w.employee_id
+	LEFT JOIN assets a ON a.id = w.asset_id`
+
+func (r *PostgresWaitlistRepository) CountOfferable(ctx context.Context, assetType string, employeeID uuid.UUID) (int, error) {
+	var count int
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &count, `
+		SELECT count(a.id)
+		FROM users u
+		JOIN assets a ON a.type = $1::asset_type
+		WHERE u.id = $2 AND `+offerableAsset, assetType, employeeID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count offerable assets: %w", err)
+	}
+	return count, nil
+}
+
+func (r *PostgresWaitlistRepository) InsertEntry(ctx context.Context, req JoinWaitlistReq, employeeID uuid.UUID) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &id, `
+		INSERT INTO asset_waitlist (employee_id, type, note)
+		VALUES ($1, $2, NULLIF($3, ''))
+		RETURNING id
+	`, employeeID, req.Type, req.Note)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_asset_waitlist_employee" {
+			return uuid.Nil, ErrAlreadyWaiting
+		}
+		r.Logger.GetLogger().Error("failed to insert waitlist entry", zap.String("employeeID", employeeID.String()), zap.String("type", req.Type), zap.Error(err))
+		return uuid.Nil, fmt.Errorf("failed to insert waitlist entry: %w", err)
+	}
+	return id, nil
+}
+
+func (r *PostgresWaitlistRepository) GetEntry(ctx context.Context, id uuid.UUID) (WaitlistEntry, error) {
+	var entry WaitlistEntry
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &entry, entryQuery+`
+		WHERE w.id = $1
+	`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return WaitlistEntry{}, ErrEntryNotFound
+		}
+		return WaitlistEntry{}, fmt.Errorf("failed to fetch waitlist entry: %w", err)
+	}
+	return entry, nil
+}
+
+func (r *PostgresWaitlistRepository) GetEmployeeEntries(ctx context.Context, employeeID uuid.UUID) ([]WaitlistEntry, error) {
+	entries := make([]WaitlistEntry, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &entries, entryQuery+`
+		WHERE w.employee_id = $1 AND w.status IN ('waiting', 'offered')
+		ORDER BY w.created_at, w.id
+	`, employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch waitlist entries: %w", err)
+	}
+	return entries, nil
+}
+
+func (r *PostgresWaitlistRepository) GetWaitlist(ctx context.Context, filter WaitlistFilter) ([]WaitlistEntry, error) {
+	entries := make([]WaitlistEntry, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &entries, entryQuery+`
+		WHERE w.status IN ('waiting', 'offered')
+		AND ($1 = '' OR w.type::text = $1)
+		AND ($2 OR u.department_id IS NOT DISTINCT FROM $3)
+		AND ($4::uuid IS NULL OR u.organization_id = $4)
+		ORDER BY w.type, w.created_at, w.id
+		LIMIT NULLIF($5, 0) OFFSET $6
+	`, filter.Type, filter.Scope.AllDepartments, filter.Scope.DepartmentID, filter.Scope.OrganizationID, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch waitlist: %w", err)
+	}
+	return entries, nil
+}
+
+func (r *PostgresWaitlistRepository) LeaveEntry(ctx context.Context, id, employeeID uuid.UUID) error {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE asset_waitlist
+		SET status = 'left', closed_at = now()
+		WHERE id = $1 AND employee_id = $2 AND status IN ('waiting', 'offered')
+	`, id, employeeID)
+	if err != nil {
+		return fmt.Errorf("failed to leave waitlist: %w", err)
+	}
+	left, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to leave waitlist: %w", err)
+	}
+	if left == 0 {
+		return ErrEntryNotFound
+	}
+	return nil
+}
+
+func (r *PostgresWaitlistRepository) GetQueue(ctx context.Context) ([]WaitlistEntry, error) {
+	entries := make([]WaitlistEntry, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &entries, entryQuery+`
+		WHERE w.status = 'waiting' AND u.archived_at IS NULL
+		ORDER BY w.created_at, w.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch waitlist queue: %w", err)
+	}
+	return entries, nil
+}
+
+func (r *PostgresWaitlistRepository) OfferAsset(ctx context.Context, id uuid.UUID, expiresAt time.Time) (bool, error) {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		WITH pick AS (
+			SELECT a.id
+			FROM asset_waitlist w
+			JOIN users u ON u.id = w.employee_id
+			JOIN assets a ON a.type = w.type
+			WHERE w.id = $1 AND w.status = 'waiting' AND `+offerableAsset+`
+			ORDER BY a.created_at, a.id
+			LIMIT 1
+			FOR UPDATE OF a SKIP LOCKED
+		)
+		UPDATE asset_waitlist w
+		SET status = 'offered', asset_id = pick.id, offered_at = now(), offer_expires_at = $2
+		FROM pick
+		WHERE w.id = $1 AND w.status = 'waiting'
+	`, id, expiresAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "idx_asset_waitlist_offer" {
+			// offered to someone else in the meantime, the next run tries again
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to offer asset: %w", err)
+	}
+	offered, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to offer asset: %w", err)
+	}
+	return offered > 0, nil
+}
+
+func (r *PostgresWaitlistRepository) ExpireOffers(ctx context.Context) ([]WaitlistEntry, error) {
+	var ids []uuid.UUID
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &ids, `
+		UPDATE asset_waitlist
+		SET status = 'expired', closed_at = now()
+		WHERE status = 'offered' AND offer_expires_at <= now()
+		RETURNING id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire waitlist offers: %w", err)
+	}
+	entries := make([]WaitlistEntry, 0, len(ids))
+	if len(ids) == 0 {
+		return entries, nil
+	}
+	err = utils.Conn(ctx, r.DB).SelectContext(ctx, &entries, entryQuery+`
+		WHERE w.id = ANY($1)
+		ORDER BY w.created_at, w.id
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch expired waitlist offers: %w", err)
+	}
+	return entries, nil
+}
+
+func (r *PostgresWaitlistRepository) RequeueVoidOffers(ctx context.Context) (int64, error) {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE asset_waitlist w
+		SET status = 'waiting', asset_id = NULL, offered_at = NULL, offer_expires_at = NULL
+		FROM assets a
+		WHERE a.id = w.asset_id AND w.status = 'offered'
+		AND (a.status <> 'available' OR a.archived_at IS NOT NULL
+			OR EXISTS (SELECT 1 FROM project_assets pa WHERE pa.asset_id = a.id AND pa.released_on IS NULL))
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue void waitlist offers: %w", err)
+	}
+	requeued, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue void waitlist offers: %w", err)
+	}
+	return requeued, nil
+}
+
+func (r *PostgresWaitlistRepository) ClaimEntry(ctx context.Context, id uuid.UUID) (bool, error) {
+	res, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE asset_waitlist w
+		SET status = 'claimed', closed_at = now()
+		FROM assets a
+		WHERE w.id = $1 AND w.status = 'offered' AND w.offer_expires_at > now()
+		AND a.id = w.asset_id AND a.status = 'available' AND a.archived_at IS NULL
+	`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim waitlist offer: %w", err)
+	}
+	claimed, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim waitlist offer: %w", err)
+	}
+	return claimed > 0, nil
+}
@@ -0,0 +1,117 @@
+//go:build integration
+
+package waitlistservice
+
+import (
+	"asset/database/seed"
+	"asset/database/testdb"
+	"asset/models"
+	"asset/providers"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestWaitlist(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	logger := providers.NewMockZapLoggerProvider(ctrl)
+	logger.EXPECT().GetLogger().Return(zap.NewNop()).AnyTimes()
+
+	db := testdb.Seeded(t)
+	repo := NewWaitlistRepository(db, logger)
+	ctx := context.Background()
+	designerID, developerID := seed.ID("user:designer"), seed.ID("user:developer")
+	laptopID := seed.ID("asset:laptop-2")
+	engineering := seed.ID("department:engineering")
+
+	// every seeded laptop is assigned, the monitor is back from service
+	available, err := repo.CountOfferable(ctx, "laptop", designerID)
+	require.NoError(t, err)
+	assert.Zero(t, available)
+	available, err = repo.CountOfferable(ctx, "monitor", designerID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, available)
+
+	designerEntry, err := repo.InsertEntry(ctx, JoinWaitlistReq{Type: "laptop", Note: "mine is slow"}, designerID)
+	require.NoError(t, err)
+	_, err = repo.InsertEntry(ctx, JoinWaitlistReq{Type: "laptop"}, designerID)
+	assert.ErrorIs(t, err, ErrAlreadyWaiting)
+	developerEntry, err := repo.InsertEntry(ctx, JoinWaitlistReq{Type: "laptop"}, developerID)
+	require.NoError(t, err)
+
+	// nothing to offer until the intern returns their laptop, then only the first in line gets it
+	expires := time.Now().Add(time.Hour)
+	offered, err := repo.OfferAsset(ctx, designerEntry, expires)
+	require.NoError(t, err)
+	assert.False(t, offered)
+	_, err = db.Exec(`UPDATE asset_assign SET returned_at = now() WHERE asset_id = $1 AND returned_at IS NULL`, laptopID)
+	require.NoError(t, err)
+	_, err = db.Exec(`UPDATE assets SET status = 'available' WHERE id = $1`, laptopID)
+	require.NoError(t, err)
+
+	queue, err := repo.GetQueue(ctx)
+	require.NoError(t, err)
+	require.Len(t, queue, 2)
+	assert.Equal(t, designerEntry, queue[0].ID)
+	for _, entry := range queue {
+		offered, err = repo.OfferAsset(ctx, entry.ID, expires)
+		require.NoError(t, err)
+		assert.Equal(t, entry.ID == designerEntry, offered)
+	}
+	entry, err := repo.GetEntry(ctx, designerEntry)
+	require.NoError(t, err)
+	assert.Equal(t, StatusOffered, entry.Status)
+	assert.Equal(t, laptopID, *entry.AssetID)
+	assert.Equal(t, "SEED-LAP-0002", *entry.SerialNo)
+
+	waiting, err := repo.GetWaitlist(ctx, WaitlistFilter{Type: "laptop", Scope: models.DepartmentScope{DepartmentID: &engineering}})
+	require.NoError(t, err)
+	require.Len(t, waiting, 2)
+	assert.Nil(t, waiting[0].Position)
+	assert.Equal(t, 1, *waiting[1].Position)
+
+	// the designer lets the offer lapse and the laptop moves on to the developer
+	_, err = db.Exec(`UPDATE asset_waitlist SET offer_expires_at = now() - interval '1 minute' WHERE id = $1`, designerEntry)
+	require.NoError(t, err)
+	claimed, err := repo.ClaimEntry(ctx, designerEntry)
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	expired, err := repo.ExpireOffers(ctx)
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	assert.Equal(t, designerEntry, expired[0].ID)
+	assert.ErrorIs(t, repo.LeaveEntry(ctx, designerEntry, designerID), ErrEntryNotFound)
+	offered, err = repo.OfferAsset(ctx, developerEntry, expires)
+	require.NoError(t, err)
+	assert.True(t, offered)
+
+	// sent for service before the developer claims it, they keep their place
+	_, err = db.Exec(`UPDATE assets SET status = 'sent_for_service' WHERE id = $1`, laptopID)
+	require.NoError(t, err)
+	requeued, err := repo.RequeueVoidOffers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), requeued)
+	entries, err := repo.GetEmployeeEntries(ctx, developerID)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, StatusWaiting, entries[0].Status)
+	assert.Equal(t, 1, *entries[0].Position)
+
+	_, err = db.Exec(`UPDATE assets SET status = 'available' WHERE id = $1`, laptopID)
+	require.NoError(t, err)
+	offered, err = repo.OfferAsset(ctx, developerEntry, expires)
+	require.NoError(t, err)
+	require.True(t, offered)
+	claimed, err = repo.ClaimEntry(ctx, developerEntry)
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	entries, err = repo.GetEmployeeEntries(ctx, developerID)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,223 @@
+package waitlistservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/asset"
+	"asset/services/notification"
+	"asset/utils"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// WaitlistService queues employees for asset types that have none available. The
+// process_asset_waitlist job offers every asset that frees up to the first one in line who could be
+// given it, they have the claim window to take it before it is offered to the next
+type WaitlistService interface {
+	JoinWaitlist(ctx context.Context, req JoinWaitlistReq, employeeID uuid.UUID) (WaitlistEntry, error)
+	GetMyEntries(ctx context.Context, employeeID uuid.UUID) ([]WaitlistEntry, error)
+	LeaveWaitlist(ctx context.Context, id, employeeID uuid.UUID) error
+	ClaimOffer(ctx context.Context, id, employeeID uuid.UUID) (WaitlistEntry, error)
+	GetWaitlist(ctx context.Context, filter WaitlistFilter) ([]WaitlistEntry, error)
+	ProcessWaitlist(ctx context.Context) error
+}
+
+var (
+	ErrAssetsAvailable = models.NewServiceError(http.StatusConflict, "assets_available", "assets of that type are available, ask an asset manager for one instead")
+	ErrAlreadyWaiting  = models.NewServiceError(http.StatusConflict, "already_waiting", "you are already in line for that type")
+	ErrEntryNotFound   = models.NewServiceError(http.StatusNotFound, "waitlist_entry_not_found", "you have no open waitlist entry with that id")
+	ErrNoOffer         = models.NewServiceError(http.StatusConflict, "no_offer", "no asset is offered on that entry yet")
+	ErrOfferExpired    = models.NewServiceError(http.StatusGone, "offer_expired", "the offer expired and went to the next in line")
+	ErrOfferGone       = models.NewServiceError(http.StatusConflict, "offer_gone", "the offered asset can't be given anymore, you keep your place in line")
+)
+
+type waitlistServiceStruct struct {
+	repo        WaitlistRepository
+	db          *sqlx.DB
+	assets      assetservice.AssetService
+	notifier    notificationservice.NotificationService
+	logger      providers.ZapLoggerProvider
+	claimWindow time.Duration
+}
+
+func NewWaitlistService(repo WaitlistRepository, db *sqlx.DB, assets assetservice.AssetService, notifier notificationservice.NotificationService, logger providers.ZapLoggerProvider, claimWindow time.Duration) WaitlistService {
+	return &waitlistServiceStruct{
+		repo:        repo,
+		db:          db,
+		assets:      assets,
+		notifier:    notifier,
+		logger:      logger,
+		claimWindow: claimWindow,
+	}
+}
+
+// JoinWaitlist queues the employee only when there is nothing of the type they could be given now
+func (s *waitlistServiceStruct) JoinWaitlist(ctx context.Context, req JoinWaitlistReq, employeeID uuid.UUID) (WaitlistEntry, error) {
+	available, err := s.repo.CountOfferable(ctx, req.Type, employeeID)
+	if err != nil {
+		return WaitlistEntry{}, err
+	}
+	if available > 0 {
+		return WaitlistEntry{}, ErrAssetsAvailable
+	}
+	id, err := s.repo.InsertEntry(ctx, req, employeeID)
+	if err != nil {
+		return WaitlistEntry{}, err
+	}
+	entry, err := s.repo.GetEntry(ctx, id)
+	if err != nil {
+		return WaitlistEntry{}, err
+	}
+	return s.localize(ctx, entry), nil
+}
+
+func (s *waitlistServiceStruct) GetMyEntries(ctx context.Context, employeeID uuid.UUID) ([]WaitlistEntry, error) {
+	entries, err := s.repo.GetEmployeeEntries(ctx, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		entries[i] = s.localize(ctx, entries[i])
+	}
+	return entries, nil
+}
+
+// LeaveWaitlist gives up the employee's place, an asset offered to them goes to the next in line
+func (s *waitlistServiceStruct) LeaveWaitlist(ctx context.Context, id, employeeID uuid.UUID) error {
+	return s.repo.LeaveEntry(ctx, id, employeeID)
+}
+
+// ClaimOffer assigns the offered asset to the employee, who is recorded as the one who assigned it
+func (s *waitlistServiceStruct) ClaimOffer(ctx context.Context, id, employeeID uuid.UUID) (WaitlistEntry, error) {
+	entry, err := s.repo.GetEntry(ctx, id)
+	if err != nil {
+		return WaitlistEntry{}, err
+	}
+	if entry.EmployeeID != employeeID {
+		return WaitlistEntry{}, ErrEntryNotFound
+	}
+	switch {
+	case entry.Status == StatusExpired, entry.Status == StatusOffered && !entry.OfferExpiresAt.After(time.Now()):
+		return WaitlistEntry{}, ErrOfferExpired
+	case entry.Status == StatusWaiting:
+		return WaitlistEntry{}, ErrNoOffer
+	case entry.Status != StatusOffered:
+		return WaitlistEntry{}, ErrEntryNotFound
+	}
+
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		claimed, err := s.repo.ClaimEntry(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			return ErrOfferGone
+		}
+		return s.assets.AssignAsset(ctx, *entry.AssetID, employeeID, employeeID, nil, nil, models.DepartmentScope{AllDepartments: true})
+	})
+	if errors.Is(err, assetservice.ErrAssetAlreadyAssigned) {
+		err = ErrOfferGone
+	}
+	if err != nil {
+		return WaitlistEntry{}, err
+	}
+	entry, err = s.repo.GetEntry(ctx, id)
+	if err != nil {
+		return WaitlistEntry{}, err
+	}
+	return s.localize(ctx, entry), nil
+}
+
+func (s *waitlistServiceStruct) GetWaitlist(ctx context.Context, filter WaitlistFilter) ([]WaitlistEntry, error) {
+	entries, err := s.repo.GetWaitlist(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		entries[i] = s.localize(ctx, entries[i])
+	}
+	return entries, nil
+}
+
+// ProcessWaitlist expires the offers not claimed in time, puts the ones whose asset went elsewhere
+// back in line and then offers the free assets in the order the employees joined
+func (s *waitlistServiceStruct) ProcessWaitlist(ctx context.Context) error {
+	expired, err := s.repo.ExpireOffers(ctx)
+	if err != nil {
+		return err
+	}
+	for _, entry := range expired {
+		s.notify(ctx, entry, false, "Your waitlist offer expired",
+			fmt.Sprintf("%s %s wasn't claimed in time and went to the next in line, join the waitlist again to wait for another", entry.Type, serialNo(entry)))
+	}
+	requeued, err := s.repo.RequeueVoidOffers(ctx)
+	if err != nil {
+		return err
+	}
+
+	queue, err := s.repo.GetQueue(ctx)
+	if err != nil {
+		return err
+	}
+	offers := 0
+	for _, entry := range queue {
+		offered, err := s.repo.OfferAsset(ctx, entry.ID, time.Now().Add(s.claimWindow))
+		if err != nil {
+			return err
+		}
+		if !offered {
+			continue
+		}
+		offers++
+		entry, err = s.repo.GetEntry(ctx, entry.ID)
+		if err != nil {
+			return err
+		}
+		// the offer can't wait for the employee's digest
+		s.notify(ctx, entry, true, "An asset you waited for is yours to claim",
+			fmt.Sprintf("%s %s is held for you until %s UTC, claim it before then or it goes to the next in line",
+				entry.Type, serialNo(entry), entry.OfferExpiresAt.UTC().Format("2006-01-02 15:04")))
+	}
+	if len(expired) > 0 || requeued > 0 || offers > 0 {
+		s.logger.GetLogger().Info("asset waitlist processed", zap.Int("expired", len(expired)), zap.Int64("requeued", requeued), zap.Int("offered", offers))
+	}
+	return nil
+}
+
+func (s *waitlistServiceStruct) notify(ctx context.Context, entry WaitlistEntry, urgent bool, title, body string) {
+	if err := s.notifier.Notify(ctx, []uuid.UUID{entry.EmployeeID}, notificationservice.Notification{
+		Category:   notificationservice.CategoryAssignment,
+		Title:      title,
+		Body:       body,
+		EntityType: "asset_waitlist",
+		EntityID:   entry.ID.String(),
+		Urgent:     urgent,
+	}); err != nil {
+		s.logger.GetLogger().Error("waitlist entry not notified", zap.String("entryID", entry.ID.String()), zap.Error(err))
+	}
+}
+
+func serialNo(entry WaitlistEntry) string {
+	if entry.SerialNo == nil {
+		return ""
+	}
+	return *entry.SerialNo
+}
+
+func (s *waitlistServiceStruct) localize(ctx context.Context, entry WaitlistEntry) WaitlistEntry {
+	loc := utils.LocationFromContext(ctx)
+	entry.CreatedAt = utils.InLocation(entry.CreatedAt, loc)
+	for _, t := range []*time.Time{entry.OfferedAt, entry.OfferExpiresAt, entry.ClosedAt} {
+		if t != nil {
+			*t = utils.InLocation(*t, loc)
+		}
+	}
+	return entry
+}
@@ -0,0 +1,131 @@
+package waitlistservice
+
+import (
+	"asset/models"
+	"asset/services/asset"
+	"asset/services/notification"
+	"asset/services/testutil"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T) (WaitlistService, *MockWaitlistRepository, *assetservice.MockAssetService, *notificationservice.MockNotificationService, sqlmock.Sqlmock) {
+	ctrl := testutil.Controller(t)
+	db, mock := testutil.DB(t)
+	repo := NewMockWaitlistRepository(ctrl)
+	assets := assetservice.NewMockAssetService(ctrl)
+	notifier := notificationservice.NewMockNotificationService(ctrl)
+	return NewWaitlistService(repo, db, assets, notifier, testutil.Logger(ctrl), 48*time.Hour), repo, assets, notifier, mock
+}
+
+func TestJoinWaitlistWithAssetsAvailable(t *testing.T) {
+	svc, repo, _, _, _ := newTestService(t)
+	ctx := context.Background()
+	employeeID := uuid.New()
+
+	repo.EXPECT().CountOfferable(ctx, "laptop", employeeID).Return(2, nil)
+
+	_, err := svc.JoinWaitlist(ctx, JoinWaitlistReq{Type: "laptop"}, employeeID)
+	assert.ErrorIs(t, err, ErrAssetsAvailable)
+}
+
+func TestClaimOffer(t *testing.T) {
+	ctx := context.Background()
+	employeeID, assetID, id := uuid.New(), uuid.New(), uuid.New()
+	future, past := time.Now().Add(time.Hour), time.Now().Add(-time.Minute)
+	offered := WaitlistEntry{ID: id, EmployeeID: employeeID, Status: StatusOffered, AssetID: &assetID, OfferExpiresAt: &future}
+
+	t.Run("assigns the asset to the employee", func(t *testing.T) {
+		svc, repo, assets, _, db := newTestService(t)
+		repo.EXPECT().GetEntry(ctx, id).Return(offered, nil)
+		db.ExpectBegin()
+		repo.EXPECT().ClaimEntry(gomock.Any(), id).Return(true, nil)
+		assets.EXPECT().AssignAsset(gomock.Any(), assetID, employeeID, employeeID, nil, nil, models.DepartmentScope{AllDepartments: true}).Return(nil)
+		db.ExpectCommit()
+		repo.EXPECT().GetEntry(ctx, id).Return(WaitlistEntry{ID: id, EmployeeID: employeeID, Status: StatusClaimed, AssetID: &assetID}, nil)
+
+		entry, err := svc.ClaimOffer(ctx, id, employeeID)
+		require.NoError(t, err)
+		assert.Equal(t, StatusClaimed, entry.Status)
+		assert.NoError(t, db.ExpectationsWereMet())
+	})
+
+	t.Run("someone else's entry", func(t *testing.T) {
+		svc, repo, _, _, _ := newTestService(t)
+		repo.EXPECT().GetEntry(ctx, id).Return(offered, nil)
+		_, err := svc.ClaimOffer(ctx, id, uuid.New())
+		assert.ErrorIs(t, err, ErrEntryNotFound)
+	})
+
+	t.Run("offer past its window", func(t *testing.T) {
+		svc, repo, _, _, _ := newTestService(t)
+		lapsed := offered
+		lapsed.OfferExpiresAt = &past
+		repo.EXPECT().GetEntry(ctx, id).Return(lapsed, nil)
+		_, err := svc.ClaimOffer(ctx, id, employeeID)
+		assert.ErrorIs(t, err, ErrOfferExpired)
+	})
+
+	t.Run("still waiting", func(t *testing.T) {
+		svc, repo, _, _, _ := newTestService(t)
+		repo.EXPECT().GetEntry(ctx, id).Return(WaitlistEntry{ID: id, EmployeeID: employeeID, Status: StatusWaiting}, nil)
+		_, err := svc.ClaimOffer(ctx, id, employeeID)
+		assert.ErrorIs(t, err, ErrNoOffer)
+	})
+
+	t.Run("asset assigned elsewhere in the meantime", func(t *testing.T) {
+		svc, repo, assets, _, db := newTestService(t)
+		repo.EXPECT().GetEntry(ctx, id).Return(offered, nil)
+		db.ExpectBegin()
+		repo.EXPECT().ClaimEntry(gomock.Any(), id).Return(true, nil)
+		assets.EXPECT().AssignAsset(gomock.Any(), assetID, employeeID, employeeID, nil, nil, gomock.Any()).
+			Return(fmt.Errorf("failed to assign asset: %w", assetservice.ErrAssetAlreadyAssigned))
+		db.ExpectRollback()
+
+		_, err := svc.ClaimOffer(ctx, id, employeeID)
+		assert.ErrorIs(t, err, ErrOfferGone)
+		assert.NoError(t, db.ExpectationsWereMet())
+	})
+}
+
+// lapsed offers are expired before the queue is served, so their asset can go to the next in line
+// in the same run
+func TestProcessWaitlist(t *testing.T) {
+	svc, repo, _, notifier, _ := newTestService(t)
+	ctx := context.Background()
+	first, second := uuid.New(), uuid.New()
+	serial := "LAP-9"
+	expires := time.Now().Add(48 * time.Hour)
+
+	gomock.InOrder(
+		repo.EXPECT().ExpireOffers(ctx).Return([]WaitlistEntry{{ID: uuid.New(), EmployeeID: uuid.New(), Type: "laptop", Status: StatusExpired, SerialNo: &serial}}, nil),
+		repo.EXPECT().RequeueVoidOffers(ctx).Return(int64(0), nil),
+		repo.EXPECT().GetQueue(ctx).Return([]WaitlistEntry{{ID: first}, {ID: second}}, nil),
+	)
+	repo.EXPECT().OfferAsset(ctx, first, gomock.Any()).DoAndReturn(func(_ context.Context, _ uuid.UUID, expiresAt time.Time) (bool, error) {
+		assert.WithinDuration(t, expires, expiresAt, time.Minute)
+		return true, nil
+	})
+	repo.EXPECT().OfferAsset(ctx, second, gomock.Any()).Return(false, nil)
+	repo.EXPECT().GetEntry(ctx, first).Return(WaitlistEntry{ID: first, EmployeeID: uuid.New(), Type: "laptop", Status: StatusOffered, SerialNo: &serial, OfferExpiresAt: &expires}, nil)
+	notifier.EXPECT().Notify(ctx, gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ []uuid.UUID, n notificationservice.Notification) error {
+		assert.Equal(t, "Your waitlist offer expired", n.Title)
+		return nil
+	})
+	notifier.EXPECT().Notify(ctx, gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ []uuid.UUID, n notificationservice.Notification) error {
+		assert.Equal(t, notificationservice.CategoryAssignment, n.Category)
+		assert.Equal(t, first.String(), n.EntityID)
+		assert.True(t, n.Urgent)
+		return nil
+	})
+
+	require.NoError(t, svc.ProcessWaitlist(ctx))
+}
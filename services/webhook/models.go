@@ -0,0 +1,122 @@
+package webhookservice
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// events other services emit, the payload of each is the data map passed to Emit
+const (
+	EventAssetAssigned   = "asset.assigned"
+	EventAssetReturned   = "asset.returned"
+	EventAssetServiced   = "asset.serviced"
+	EventUserCreated     = "user.created"
+	EventUserRoleChanged = "user.role_changed"
+)
+
+var KnownEvents = []string{EventAssetAssigned, EventAssetReturned, EventAssetServiced, EventUserCreated, EventUserRoleChanged}
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// Event is what other services emit, Data ends up under "data" in the delivered body. The event only
+// reaches endpoints of the organization of the asset or user it is about, AggregateType says which
+type Event struct {
+	Type          string
+	AggregateType string
+	AggregateID   uuid.UUID
+	Data          map[string]interface{}
+}
+
+// EventPayload is the json body of every delivery, receivers can dedupe on ID since a delivery is
+// retried until it is acknowledged
+type EventPayload struct {
+	ID        uuid.UUID              `json:"id"`
+	Type      string                 `json:"type"`
+	CreatedAt time.Time              `json:"created_at"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+type CreateEndpointReq struct {
+	URL string `json:"url" validate:"required,url,max=2000"`
+	// empty subscribes to every event, including ones added later
+	Events      []string `json:"events" validate:"omitempty,dive,required"`
+	Description string   `json:"description" validate:"max=500"`
+}
+
+type EndpointRes struct {
+	ID          uuid.UUID      `json:"id" db:"id"`
+	URL         string         `json:"url" db:"url"`
+	Events      pq.StringArray `json:"events" db:"events"`
+	Description *string        `json:"description,omitempty" db:"description"`
+	CreatedBy   uuid.UUID      `json:"created_by" db:"created_by"`
+	// nil for an endpoint receiving the events of every organization
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty" db:"organization_id"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// CreateEndpointRes is the only response that carries the signing secret
+type CreateEndpointRes struct {
+	EndpointRes
+	Secret string `json:"secret"`
+}
+
+// RotateSecretRes carries the new secret once, deliveries are signed with the old one as well until
+// PreviousSecretExpiresAt
+type RotateSecretRes struct {
+	Secret                  string    `json:"secret"`
+	PreviousSecretExpiresAt time.Time `json:"previous_secret_expires_at"`
+}
+
+type DeliveryRes struct {
+	ID             uuid.UUID       `json:"id" db:"id"`
+	EndpointID     uuid.UUID       `json:"endpoint_id" db:"endpoint_id"`
+	EventID        uuid.UUID       `json:"event_id" db:"event_id"`
+	EventType      string          `json:"event_type" db:"event_type"`
+	Payload        json.RawMessage `json:"payload" db:"payload"`
+	Status         string          `json:"status" db:"status"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	LastStatusCode *int            `json:"last_status_code,omitempty" db:"last_status_code"`
+	LastError      *string         `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+type DeliveryFilter struct {
+	EndpointID string
+	Status     string
+	EventType  string
+	// nil lists the deliveries of every organization
+	OrganizationID *uuid.UUID
+	Limit          int
+	Offset         int
+}
+
+// dueDelivery is a claimed delivery together with where and how to send it
+type dueDelivery struct {
+	ID        uuid.UUID `db:"id"`
+	EventID   uuid.UUID `db:"event_id"`
+	EventType string    `db:"event_type"`
+	Payload   []byte    `db:"payload"`
+	Attempts  int       `db:"attempts"`
+	URL       string    `db:"url"`
+	Secret    string    `db:"secret"`
+	// the secret before a rotation, set until the overlap ends
+	PreviousSecret *string `db:"previous_secret"`
+}
+
+type newEndpoint struct {
+	URL            string
+	Secret         string
+	Events         []string
+	Description    string
+	CreatedBy      uuid.UUID
+	OrganizationID *uuid.UUID
+}
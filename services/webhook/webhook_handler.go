@@ -0,0 +1,228 @@
+package webhookservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type WebhookHandler struct {
+	Service        WebhookService
+	AuthMiddleware providers.AuthMiddlewareService
+	Logger         providers.ZapLoggerProvider
+}
+
+func NewWebhookHandler(service WebhookService, auth providers.AuthMiddlewareService, log providers.ZapLoggerProvider) *WebhookHandler {
+	return &WebhookHandler{
+		Service:        service,
+		AuthMiddleware: auth,
+		Logger:         log,
+	}
+}
+
+func (h *WebhookHandler) GetEndpoints(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetWebhookEndpoints request received")
+	organizationID, ok := h.organization(w, r, "GetWebhookEndpoints")
+	if !ok {
+		return
+	}
+	endpoints, err := h.Service.GetEndpoints(r.Context(), organizationID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch webhook endpoints", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch webhook endpoints")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"endpoints": endpoints, "events": KnownEvents})
+}
+
+// CreateEndpoint registers an endpoint, the signing secret is only in this response
+func (h *WebhookHandler) CreateEndpoint(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("CreateWebhookEndpoint request received")
+	adminID, ok := h.callerID(w, r, "CreateWebhookEndpoint")
+	if !ok {
+		return
+	}
+
+	var req CreateEndpointReq
+	if err := utils.ParseJSONBody(r, &req); err != nil {
+		h.Logger.GetLogger().Error("Invalid request body in CreateWebhookEndpoint", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid request body")
+		return
+	}
+	if err := utils.ValidateStruct(req); err != nil {
+		h.Logger.GetLogger().Error("Invalid input in CreateWebhookEndpoint", zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid input")
+		return
+	}
+
+	organizationID, ok := h.organization(w, r, "CreateWebhookEndpoint")
+	if !ok {
+		return
+	}
+	endpoint, err := h.Service.CreateEndpoint(r.Context(), req, adminID, organizationID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to create webhook endpoint", zap.String("url", req.URL), zap.Error(err))
+		if errors.Is(err, ErrUnknownEvent) {
+			utils.RespondError(w, http.StatusBadRequest, err, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to create webhook endpoint")
+		return
+	}
+	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+		"message":  "webhook endpoint created, store the secret now as it won't be shown again",
+		"endpoint": endpoint,
+	})
+}
+
+func (h *WebhookHandler) DeleteEndpoint(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("DeleteWebhookEndpoint request received")
+	adminID, ok := h.callerID(w, r, "DeleteWebhookEndpoint")
+	if !ok {
+		return
+	}
+	id := r.URL.Query().Get("id")
+	endpointID, err := uuid.Parse(id)
+	if err != nil {
+		h.Logger.GetLogger().Error("Invalid id in DeleteWebhookEndpoint", zap.String("id", id), zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid id")
+		return
+	}
+
+	organizationID, ok := h.organization(w, r, "DeleteWebhookEndpoint")
+	if !ok {
+		return
+	}
+	if err := h.Service.DeleteEndpoint(r.Context(), endpointID, adminID, organizationID); err != nil {
+		h.Logger.GetLogger().Error("Failed to delete webhook endpoint", zap.String("id", id), zap.Error(err))
+		if errors.Is(err, ErrEndpointNotFound) {
+			utils.RespondError(w, http.StatusNotFound, err, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to delete webhook endpoint")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "webhook endpoint deleted successfully"})
+}
+
+// RotateSecret gives the endpoint a new secret, only shown in this response. Deliveries are signed with
+// the old one as well for a day so the receiver can switch over
+func (h *WebhookHandler) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("RotateWebhookSecret request received")
+	adminID, ok := h.callerID(w, r, "RotateWebhookSecret")
+	if !ok {
+		return
+	}
+	id := r.URL.Query().Get("id")
+	endpointID, err := uuid.Parse(id)
+	if err != nil {
+		h.Logger.GetLogger().Error("Invalid id in RotateWebhookSecret", zap.String("id", id), zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid id")
+		return
+	}
+
+	organizationID, ok := h.organization(w, r, "RotateWebhookSecret")
+	if !ok {
+		return
+	}
+	res, err := h.Service.RotateSecret(r.Context(), endpointID, adminID, organizationID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to rotate webhook secret", zap.String("id", id), zap.Error(err))
+		if errors.Is(err, ErrEndpointNotFound) {
+			utils.RespondError(w, http.StatusNotFound, err, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to rotate webhook secret")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":  "webhook secret rotated, store the secret now as it won't be shown again",
+		"rotation": res,
+	})
+}
+
+// GetDeliveries lists deliveries newest first, status=dead lists the dead letters
+func (h *WebhookHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("GetWebhookDeliveries request received")
+	organizationID, ok := h.organization(w, r, "GetWebhookDeliveries")
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	filter := DeliveryFilter{
+		EndpointID:     query.Get("endpoint_id"),
+		Status:         query.Get("status"),
+		EventType:      query.Get("event_type"),
+		OrganizationID: organizationID,
+	}
+	filter.Limit, filter.Offset = utils.GetPageLimitAndOffset(r)
+
+	deliveries, err := h.Service.GetDeliveries(r.Context(), filter)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to fetch webhook deliveries", zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to fetch webhook deliveries")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"deliveries": deliveries})
+}
+
+func (h *WebhookHandler) RedeliverDelivery(w http.ResponseWriter, r *http.Request) {
+	h.Logger.GetLogger().Info("RedeliverWebhookDelivery request received")
+	adminID, ok := h.callerID(w, r, "RedeliverWebhookDelivery")
+	if !ok {
+		return
+	}
+	id := r.URL.Query().Get("id")
+	deliveryID, err := uuid.Parse(id)
+	if err != nil {
+		h.Logger.GetLogger().Error("Invalid id in RedeliverWebhookDelivery", zap.String("id", id), zap.Error(err))
+		utils.RespondError(w, http.StatusBadRequest, err, "invalid id")
+		return
+	}
+
+	organizationID, ok := h.organization(w, r, "RedeliverWebhookDelivery")
+	if !ok {
+		return
+	}
+	if err := h.Service.RedeliverDelivery(r.Context(), deliveryID, adminID, organizationID); err != nil {
+		h.Logger.GetLogger().Error("Failed to redeliver webhook delivery", zap.String("id", id), zap.Error(err))
+		if errors.Is(err, ErrDeliveryNotDead) {
+			utils.RespondError(w, http.StatusNotFound, err, err.Error())
+			return
+		}
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to redeliver webhook delivery")
+		return
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"message": "webhook delivery queued for redelivery"})
+}
+
+func (h *WebhookHandler) callerID(w http.ResponseWriter, r *http.Request, handler string) (uuid.UUID, bool) {
+	userID, _, err := h.AuthMiddleware.GetUserAndRolesFromContext(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Unauthorized access attempt in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusUnauthorized, err, "unauthorized")
+		return uuid.Nil, false
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to parse userID in "+handler, zap.String("userID", userID), zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "internal server error")
+		return uuid.Nil, false
+	}
+	return userUUID, true
+}
+
+// organization resolves the organization the caller manages webhooks of, nil for a platform admin
+func (h *WebhookHandler) organization(w http.ResponseWriter, r *http.Request, handler string) (*uuid.UUID, bool) {
+	scope, err := h.AuthMiddleware.GetDepartmentScope(r)
+	if err != nil {
+		h.Logger.GetLogger().Error("Failed to resolve scope in "+handler, zap.Error(err))
+		utils.RespondError(w, http.StatusInternalServerError, err, "failed to resolve organization")
+		return nil, false
+	}
+	return scope.OrganizationID, true
+}
@@ -0,0 +1,215 @@
+package webhookservice
+
+import (
+	"asset/providers"
+	"asset/utils"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+type WebhookRepository interface {
+	InsertEndpoint(ctx context.Context, endpoint newEndpoint) (EndpointRes, error)
+	// a nil organizationID reaches the endpoints of every organization, for platform admins
+	GetEndpoints(ctx context.Context, organizationID *uuid.UUID) ([]EndpointRes, error)
+	ArchiveEndpoint(ctx context.Context, id uuid.UUID, organizationID *uuid.UUID) (int64, error)
+	// RotateSecret keeps the current secret as the previous one until previousExpiresAt
+	RotateSecret(ctx context.Context, id uuid.UUID, secret string, previousExpiresAt time.Time, organizationID *uuid.UUID) (int64, error)
+	EnqueueDeliveries(ctx context.Context, eventID uuid.UUID, event Event, payload string) (int64, error)
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]dueDelivery, error)
+	MarkDelivered(ctx context.Context, id uuid.UUID, attempts, statusCode int) error
+	MarkFailed(ctx context.Context, id uuid.UUID, attempts int, statusCode *int, lastError string, nextAttemptAt *time.Time) error
+	GetDeliveries(ctx context.Context, filter DeliveryFilter) ([]DeliveryRes, error)
+	RequeueDeadDelivery(ctx context.Context, id uuid.UUID, organizationID *uuid.UUID) (int64, error)
+}
+
+type PostgresWebhookRepository struct {
+	DB     *sqlx.DB
+	Logger providers.ZapLoggerProvider
+}
+
+func NewWebhookRepository(db *sqlx.DB, log providers.ZapLoggerProvider) WebhookRepository {
+	return &PostgresWebhookRepository{DB: db, Logger: log}
+}
+
+func (r *PostgresWebhookRepository) InsertEndpoint(ctx context.Context, endpoint newEndpoint) (EndpointRes, error) {
+	var res EndpointRes
+	err := utils.Conn(ctx, r.DB).GetContext(ctx, &res, `
+		INSERT INTO webhook_endpoints (url, secret, events, description, created_by, organization_id)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+		RETURNING id, url, events, description, created_by, organization_id, created_at
+	`, endpoint.URL, endpoint.Secret, pq.Array(endpoint.Events), endpoint.Description, endpoint.CreatedBy, endpoint.OrganizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to insert webhook endpoint", zap.String("url", endpoint.URL), zap.Error(err))
+		return EndpointRes{}, fmt.Errorf("failed to insert webhook endpoint: %w", err)
+	}
+	return res, nil
+}
+
+func (r *PostgresWebhookRepository) GetEndpoints(ctx context.Context, organizationID *uuid.UUID) ([]EndpointRes, error) {
+	endpoints := make([]EndpointRes, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &endpoints, `
+		SELECT id, url, events, description, created_by, organization_id, created_at
+		FROM webhook_endpoints
+		WHERE archived_at IS NULL AND ($1::uuid IS NULL OR organization_id = $1)
+		ORDER BY created_at DESC
+	`, organizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch webhook endpoints", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch webhook endpoints: %w", err)
+	}
+	return endpoints, nil
+}
+
+// ArchiveEndpoint also drops the endpoint's pending deliveries, they would only fail against a url
+// nobody listens on anymore
+func (r *PostgresWebhookRepository) ArchiveEndpoint(ctx context.Context, id uuid.UUID, organizationID *uuid.UUID) (int64, error) {
+	result, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE webhook_endpoints SET archived_at = now()
+		WHERE id = $1 AND archived_at IS NULL AND ($2::uuid IS NULL OR organization_id = $2)
+	`, id, organizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to archive webhook endpoint", zap.String("id", id.String()), zap.Error(err))
+		return 0, fmt.Errorf("failed to archive webhook endpoint: %w", err)
+	}
+	archived, err := result.RowsAffected()
+	if err != nil || archived == 0 {
+		return archived, err
+	}
+	_, err = utils.Conn(ctx, r.DB).ExecContext(ctx, `DELETE FROM webhook_deliveries WHERE endpoint_id = $1 AND status = 'pending'`, id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to drop pending webhook deliveries: %w", err)
+	}
+	return archived, nil
+}
+
+func (r *PostgresWebhookRepository) RotateSecret(ctx context.Context, id uuid.UUID, secret string, previousExpiresAt time.Time, organizationID *uuid.UUID) (int64, error) {
+	result, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE webhook_endpoints SET previous_secret = secret, previous_secret_expires_at = $3, secret = $2
+		WHERE id = $1 AND archived_at IS NULL AND ($4::uuid IS NULL OR organization_id = $4)
+	`, id, secret, previousExpiresAt, organizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to rotate webhook secret", zap.String("id", id.String()), zap.Error(err))
+		return 0, fmt.Errorf("failed to rotate webhook secret: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// EnqueueDeliveries writes one delivery per endpoint subscribed to the event type, in the organization
+// of the asset or user the event is about or covering every organization
+func (r *PostgresWebhookRepository) EnqueueDeliveries(ctx context.Context, eventID uuid.UUID, event Event, payload string) (int64, error) {
+	result, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		WITH event_organization AS (
+			SELECT organization_id FROM assets WHERE id = $4 AND $5::text = 'asset'
+			UNION ALL
+			SELECT organization_id FROM users WHERE id = $4 AND $5::text = 'user'
+		)
+		INSERT INTO webhook_deliveries (endpoint_id, event_id, event_type, payload)
+		SELECT id, $1, $2, $3::jsonb
+		FROM webhook_endpoints
+		WHERE archived_at IS NULL AND (cardinality(events) = 0 OR $2 = ANY(events))
+		AND (organization_id IS NULL OR organization_id IN (SELECT organization_id FROM event_organization))
+	`, eventID, event.Type, payload, event.AggregateID, event.AggregateType)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to enqueue webhook deliveries", zap.String("event_type", event.Type), zap.Error(err))
+		return 0, fmt.Errorf("failed to enqueue webhook deliveries: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// ClaimDueDeliveries pushes next_attempt_at of the claimed rows past the lease, another instance
+// running the same job skips them while they are in flight
+func (r *PostgresWebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]dueDelivery, error) {
+	deliveries := make([]dueDelivery, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &deliveries, `
+		WITH due AS (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = now() + make_interval(secs => $2)
+		FROM due, webhook_endpoints e
+		WHERE d.id = due.id AND e.id = d.endpoint_id
+		RETURNING d.id, d.event_id, d.event_type, d.payload, d.attempts, e.url, e.secret,
+			CASE WHEN e.previous_secret_expires_at > now() THEN e.previous_secret END AS previous_secret
+	`, limit, lease.Seconds())
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to claim webhook deliveries", zap.Error(err))
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (r *PostgresWebhookRepository) MarkDelivered(ctx context.Context, id uuid.UUID, attempts, statusCode int) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = 'delivered', attempts = $2, last_status_code = $3, last_error = NULL, delivered_at = now()
+		WHERE id = $1
+	`, id, attempts, statusCode)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to mark webhook delivery delivered", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed schedules the next attempt, a nil nextAttemptAt dead-letters the delivery
+func (r *PostgresWebhookRepository) MarkFailed(ctx context.Context, id uuid.UUID, attempts int, statusCode *int, lastError string, nextAttemptAt *time.Time) error {
+	_, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = CASE WHEN $5::timestamptz IS NULL THEN 'dead' ELSE 'pending' END,
+			attempts = $2, last_status_code = $3, last_error = $4,
+			next_attempt_at = COALESCE($5, next_attempt_at)
+		WHERE id = $1
+	`, id, attempts, statusCode, lastError, nextAttemptAt)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to mark webhook delivery failed", zap.String("id", id.String()), zap.Error(err))
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresWebhookRepository) GetDeliveries(ctx context.Context, filter DeliveryFilter) ([]DeliveryRes, error) {
+	deliveries := make([]DeliveryRes, 0)
+	err := utils.Conn(ctx, r.DB).SelectContext(ctx, &deliveries, `
+		SELECT d.id, d.endpoint_id, d.event_id, d.event_type, d.payload, d.status, d.attempts, d.next_attempt_at,
+			d.last_status_code, d.last_error, d.created_at, d.delivered_at
+		FROM webhook_deliveries d
+		JOIN webhook_endpoints e ON e.id = d.endpoint_id
+		WHERE ($1 = '' OR d.endpoint_id::text = $1)
+		AND ($2 = '' OR d.status = $2)
+		AND ($3 = '' OR d.event_type = $3)
+		AND ($6::uuid IS NULL OR e.organization_id = $6)
+		ORDER BY d.created_at DESC
+		LIMIT $4 OFFSET $5
+	`, filter.EndpointID, filter.Status, filter.EventType, filter.Limit, filter.Offset, filter.OrganizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to fetch webhook deliveries", zap.Error(err))
+		return nil, fmt.Errorf("failed to fetch webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// RequeueDeadDelivery gives a dead-lettered delivery a fresh set of attempts
+func (r *PostgresWebhookRepository) RequeueDeadDelivery(ctx context.Context, id uuid.UUID, organizationID *uuid.UUID) (int64, error) {
+	result, err := utils.Conn(ctx, r.DB).ExecContext(ctx, `
+		UPDATE webhook_deliveries d
+		SET status = 'pending', attempts = 0, next_attempt_at = now()
+		FROM webhook_endpoints e
+		WHERE d.id = $1 AND d.status = 'dead' AND e.id = d.endpoint_id AND e.archived_at IS NULL
+		AND ($2::uuid IS NULL OR e.organization_id = $2)
+	`, id, organizationID)
+	if err != nil {
+		r.Logger.GetLogger().Error("failed to requeue webhook delivery", zap.String("id", id.String()), zap.Error(err))
+		return 0, fmt.Errorf("failed to requeue webhook delivery: %w", err)
+	}
+	return result.RowsAffected()
+}
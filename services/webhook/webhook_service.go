@@ -0,0 +1,304 @@
+package webhookservice
+
+import (
+	"asset/models"
+	"asset/providers"
+	"asset/services/audit"
+	"asset/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/zap"
+)
+
+// WebhookService keeps downstream systems in sync. Emit queues an event for every subscribed endpoint in
+// the caller's transaction, DeliverPending sends the queue from a background job
+type WebhookService interface {
+	// organizationID is the caller's organization, nil for a platform admin acting on every organization
+	CreateEndpoint(ctx context.Context, req CreateEndpointReq, adminID uuid.UUID, organizationID *uuid.UUID) (CreateEndpointRes, error)
+	GetEndpoints(ctx context.Context, organizationID *uuid.UUID) ([]EndpointRes, error)
+	DeleteEndpoint(ctx context.Context, id, adminID uuid.UUID, organizationID *uuid.UUID) error
+	RotateSecret(ctx context.Context, id, adminID uuid.UUID, organizationID *uuid.UUID) (RotateSecretRes, error)
+	GetDeliveries(ctx context.Context, filter DeliveryFilter) ([]DeliveryRes, error)
+	RedeliverDelivery(ctx context.Context, id, adminID uuid.UUID, organizationID *uuid.UUID) error
+	Emit(ctx context.Context, event Event) error
+	DeliverPending(ctx context.Context) error
+}
+
+type webhookServiceStruct struct {
+	repo   WebhookRepository
+	db     *sqlx.DB
+	logger providers.ZapLoggerProvider
+	audit  auditservice.AuditService
+	client *http.Client
+}
+
+func NewWebhookService(repo WebhookRepository, db *sqlx.DB, logger providers.ZapLoggerProvider, audit auditservice.AuditService) WebhookService {
+	return &webhookServiceStruct{
+		repo:   repo,
+		db:     db,
+		logger: logger,
+		audit:  audit,
+		client: &http.Client{Timeout: deliveryTimeout},
+	}
+}
+
+const (
+	deliveryTimeout = 10 * time.Second
+	// deliveries sent per run, at most deliveryConcurrency at a time
+	deliveryBatchSize   = 50
+	deliveryConcurrency = 8
+	// a claimed delivery is hidden from other runs for this long, well past the time a batch can take
+	deliveryLease = 5 * time.Minute
+	// how much of a failing response is kept in last_error
+	deliveryErrorLimit = 500
+	// deliveries carry a signature with the old secret too for this long after a rotation, longer
+	// than the retries of a delivery signed before it
+	secretRotationOverlap = 24 * time.Hour
+)
+
+// retryBackoff is the wait after each failed attempt, a delivery that fails once more than it has
+// entries is dead-lettered, about 15 hours after it was first sent
+var retryBackoff = []time.Duration{time.Minute, 5 * time.Minute, 30 * time.Minute, 2 * time.Hour, 12 * time.Hour}
+
+var (
+	ErrEndpointNotFound = models.NewServiceError(http.StatusNotFound, "webhook_endpoint_not_found", "webhook endpoint not found")
+	ErrUnknownEvent     = models.NewServiceError(http.StatusBadRequest, "unknown_webhook_event", "unknown webhook event")
+	ErrDeliveryNotDead  = models.NewServiceError(http.StatusNotFound, "webhook_delivery_not_dead", "webhook delivery not found or not dead-lettered")
+)
+
+func (s *webhookServiceStruct) CreateEndpoint(ctx context.Context, req CreateEndpointReq, adminID uuid.UUID, organizationID *uuid.UUID) (res CreateEndpointRes, err error) {
+	events := make([]string, 0, len(req.Events))
+	for _, event := range req.Events {
+		if !slices.Contains(KnownEvents, event) {
+			return CreateEndpointRes{}, fmt.Errorf("%w: %s", ErrUnknownEvent, event)
+		}
+		if !slices.Contains(events, event) {
+			events = append(events, event)
+		}
+	}
+	secret, err := utils.GenerateWebhookSecret()
+	if err != nil {
+		return CreateEndpointRes{}, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	var endpoint EndpointRes
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		var err error
+		endpoint, err = s.repo.InsertEndpoint(ctx, newEndpoint{
+			URL:            req.URL,
+			Secret:         secret,
+			Events:         events,
+			Description:    req.Description,
+			CreatedBy:      adminID,
+			OrganizationID: organizationID,
+		})
+		if err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, auditservice.AuditEntry{
+			ActorID:    &adminID,
+			Action:     "webhook_endpoint.created",
+			EntityType: "webhook_endpoint",
+			EntityID:   endpoint.ID.String(),
+			NewValue:   endpoint,
+		})
+	})
+	if err != nil {
+		return CreateEndpointRes{}, err
+	}
+	s.logger.GetLogger().Info("webhook endpoint created", zap.String("id", endpoint.ID.String()), zap.String("url", endpoint.URL))
+	return CreateEndpointRes{EndpointRes: endpoint, Secret: secret}, nil
+}
+
+func (s *webhookServiceStruct) GetEndpoints(ctx context.Context, organizationID *uuid.UUID) ([]EndpointRes, error) {
+	return s.repo.GetEndpoints(ctx, organizationID)
+}
+
+func (s *webhookServiceStruct) DeleteEndpoint(ctx context.Context, id, adminID uuid.UUID, organizationID *uuid.UUID) error {
+	err := utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		archived, err := s.repo.ArchiveEndpoint(ctx, id, organizationID)
+		if err != nil {
+			return err
+		}
+		if archived == 0 {
+			return ErrEndpointNotFound
+		}
+		return s.audit.Record(ctx, auditservice.AuditEntry{
+			ActorID:    &adminID,
+			Action:     "webhook_endpoint.deleted",
+			EntityType: "webhook_endpoint",
+			EntityID:   id.String(),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	s.logger.GetLogger().Info("webhook endpoint deleted", zap.String("id", id.String()))
+	return nil
+}
+
+// RotateSecret replaces the endpoint's secret, the new one is only in the response
+func (s *webhookServiceStruct) RotateSecret(ctx context.Context, id, adminID uuid.UUID, organizationID *uuid.UUID) (RotateSecretRes, error) {
+	secret, err := utils.GenerateWebhookSecret()
+	if err != nil {
+		return RotateSecretRes{}, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	res := RotateSecretRes{Secret: secret, PreviousSecretExpiresAt: time.Now().Add(secretRotationOverlap).UTC()}
+	err = utils.WithTransaction(ctx, s.db, func(ctx context.Context) error {
+		rotated, err := s.repo.RotateSecret(ctx, id, secret, res.PreviousSecretExpiresAt, organizationID)
+		if err != nil {
+			return err
+		}
+		if rotated == 0 {
+			return ErrEndpointNotFound
+		}
+		return s.audit.Record(ctx, auditservice.AuditEntry{
+			ActorID:    &adminID,
+			Action:     "webhook_endpoint.secret_rotated",
+			EntityType: "webhook_endpoint",
+			EntityID:   id.String(),
+		})
+	})
+	if err != nil {
+		return RotateSecretRes{}, err
+	}
+	s.logger.GetLogger().Info("webhook secret rotated", zap.String("id", id.String()), zap.Time("previous_secret_expires_at", res.PreviousSecretExpiresAt))
+	return res, nil
+}
+
+func (s *webhookServiceStruct) GetDeliveries(ctx context.Context, filter DeliveryFilter) ([]DeliveryRes, error) {
+	return s.repo.GetDeliveries(ctx, filter)
+}
+
+// RedeliverDelivery puts a dead-lettered delivery back in the queue, the next run sends it
+func (s *webhookServiceStruct) RedeliverDelivery(ctx context.Context, id, adminID uuid.UUID, organizationID *uuid.UUID) error {
+	requeued, err := s.repo.RequeueDeadDelivery(ctx, id, organizationID)
+	if err != nil {
+		return err
+	}
+	if requeued == 0 {
+		return ErrDeliveryNotDead
+	}
+	s.logger.GetLogger().Info("webhook delivery requeued", zap.String("id", id.String()))
+	return s.audit.Record(ctx, auditservice.AuditEntry{
+		ActorID:    &adminID,
+		Action:     "webhook_delivery.redelivered",
+		EntityType: "webhook_delivery",
+		EntityID:   id.String(),
+	})
+}
+
+// Emit takes the transaction of the change the event describes, so the event is only sent when the
+// change commits and is never lost when it does
+func (s *webhookServiceStruct) Emit(ctx context.Context, event Event) error {
+	payload := EventPayload{ID: uuid.New(), Type: event.Type, CreatedAt: time.Now().UTC(), Data: event.Data}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook event: %w", err)
+	}
+	queued, err := s.repo.EnqueueDeliveries(ctx, payload.ID, event, string(body))
+	if err != nil {
+		return err
+	}
+	if queued > 0 {
+		s.logger.GetLogger().Debug("webhook event queued", zap.String("type", event.Type), zap.String("event_id", payload.ID.String()), zap.Int64("deliveries", queued))
+	}
+	return nil
+}
+
+// DeliverPending sends the deliveries that are due, failures are rescheduled or dead-lettered
+// rather than returned so one bad endpoint doesn't fail the job
+func (s *webhookServiceStruct) DeliverPending(ctx context.Context) error {
+	due, err := s.repo.ClaimDueDeliveries(ctx, deliveryBatchSize, deliveryLease)
+	if err != nil {
+		return err
+	}
+	if len(due) == 0 {
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, deliveryConcurrency)
+	for _, delivery := range due {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			s.deliver(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
+
+func (s *webhookServiceStruct) deliver(ctx context.Context, delivery dueDelivery) {
+	attempts := delivery.Attempts + 1
+	statusCode, err := s.send(ctx, delivery)
+	if err == nil {
+		if err := s.repo.MarkDelivered(ctx, delivery.ID, attempts, statusCode); err != nil {
+			s.logger.GetLogger().Error("failed to record webhook delivery", zap.String("id", delivery.ID.String()), zap.Error(err))
+		}
+		return
+	}
+
+	var code *int
+	if statusCode != 0 {
+		code = &statusCode
+	}
+	var next *time.Time
+	if attempts <= len(retryBackoff) {
+		at := time.Now().Add(retryBackoff[attempts-1])
+		next = &at
+	}
+	if next == nil {
+		s.logger.GetLogger().Warn("webhook delivery dead-lettered", zap.String("id", delivery.ID.String()), zap.String("url", delivery.URL), zap.Int("attempts", attempts), zap.Error(err))
+	} else {
+		s.logger.GetLogger().Info("webhook delivery failed, will retry", zap.String("id", delivery.ID.String()), zap.Int("attempts", attempts), zap.Time("next_attempt_at", *next), zap.Error(err))
+	}
+	if err := s.repo.MarkFailed(ctx, delivery.ID, attempts, code, err.Error(), next); err != nil {
+		s.logger.GetLogger().Error("failed to record webhook delivery failure", zap.String("id", delivery.ID.String()), zap.Error(err))
+	}
+}
+
+// send posts the payload signed with the endpoint secret, and the previous one during a rotation.
+// Any 2xx acknowledges the delivery
+func (s *webhookServiceStruct) send(ctx context.Context, delivery dueDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "asset-manager-webhooks")
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-ID", delivery.EventID.String())
+	req.Header.Set("X-Webhook-Attempt", strconv.Itoa(delivery.Attempts+1))
+	secrets := []string{delivery.Secret}
+	if delivery.PreviousSecret != nil {
+		secrets = append(secrets, *delivery.PreviousSecret)
+	}
+	req.Header.Set(utils.WebhookSignatureHeader, utils.SignWebhookPayload(delivery.Payload, time.Now(), secrets...))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, deliveryErrorLimit))
+		return resp.StatusCode, nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, deliveryErrorLimit))
+	return resp.StatusCode, fmt.Errorf("endpoint answered %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+}
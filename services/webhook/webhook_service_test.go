@@ -0,0 +1,60 @@
+package webhookservice
+
+import (
+	"asset/utils"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendSignsWithEverySecret(t *testing.T) {
+	previous := "whsec_previous"
+	tests := []struct {
+		name           string
+		previousSecret *string
+		previousValid  bool
+	}{
+		{name: "no rotation"},
+		{name: "during a rotation", previousSecret: &previous, previousValid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delivery := dueDelivery{
+				ID:             uuid.New(),
+				EventID:        uuid.New(),
+				EventType:      "asset.assigned",
+				Payload:        []byte(`{"type":"asset.assigned"}`),
+				Secret:         "whsec_current",
+				PreviousSecret: tt.previousSecret,
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				header := r.Header.Get(utils.WebhookSignatureHeader)
+				assert.NoError(t, utils.VerifyWebhookSignature(delivery.Secret, header, body, time.Now(), utils.DefaultWebhookTolerance))
+				err = utils.VerifyWebhookSignature(previous, header, body, time.Now(), utils.DefaultWebhookTolerance)
+				if tt.previousValid {
+					assert.NoError(t, err)
+				} else {
+					assert.ErrorIs(t, err, utils.ErrWebhookSignatureInvalid)
+				}
+				assert.Equal(t, delivery.EventID.String(), r.Header.Get("X-Webhook-ID"))
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer server.Close()
+			delivery.URL = server.URL
+
+			s := &webhookServiceStruct{client: server.Client()}
+			status, err := s.send(context.Background(), delivery)
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusNoContent, status)
+		})
+	}
+}
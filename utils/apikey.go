@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// api keys look like ak_<prefix>_<secret>, the prefix is stored in clear to find the key
+const apiKeyMarker = "ak"
+
+// GenerateAPIKey returns the full key, which is shown to the caller once, and its prefix
+func GenerateAPIKey() (string, string, error) {
+	prefix := make([]byte, 4)
+	if _, err := rand.Read(prefix); err != nil {
+		return "", "", err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	p := hex.EncodeToString(prefix)
+	return apiKeyMarker + "_" + p + "_" + base64.RawURLEncoding.EncodeToString(secret), p, nil
+}
+
+func ParseAPIKeyPrefix(key string) (string, bool) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyMarker || parts[1] == "" || parts[2] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// HashAPIKey needs no salt or stretching, the key carries 256 random bits
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// service account client ids look like sa_<hex>, the secret is sent next to it and only its hash is kept
+const serviceAccountMarker = "sa"
+
+// GenerateClientCredentials returns a new client id and secret for a service account
+func GenerateClientCredentials() (string, string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	return serviceAccountMarker + "_" + hex.EncodeToString(id), base64.RawURLEncoding.EncodeToString(secret), nil
+}
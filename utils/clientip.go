@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"asset/models"
+	"net"
+	"net/http"
+)
+
+// ClientIP is the address the request came from, proxy headers are only honoured when the
+// router runs chi's RealIP middleware, which rewrites RemoteAddr
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func GetClientInfo(r *http.Request) models.ClientInfo {
+	return models.ClientInfo{IP: ClientIP(r), UserAgent: r.UserAgent()}
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"asset/models"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// JSONCodec encodes and decodes the json bodies handlers read and write and the values cached in redis
+type JSONCodec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	NewEncoder(w io.Writer) JSONEncoder
+	NewDecoder(r io.Reader) JSONDecoder
+}
+
+type JSONEncoder interface {
+	Encode(v interface{}) error
+}
+
+type JSONDecoder interface {
+	Decode(v interface{}) error
+	DisallowUnknownFields()
+}
+
+// JSON is the codec in use, jsoniter unless SetJSONCodec picked another at startup. It's in the
+// standard library compatible mode, so map keys are sorted and html escaped the same either way
+var JSON JSONCodec = jsoniterCodec{}
+
+// SetJSONCodec picks the codec by its JSON_CODEC name, before the server starts serving
+func SetJSONCodec(name string) error {
+	switch name {
+	case "", models.JSONCodecJsoniter:
+		JSON = jsoniterCodec{}
+	case models.JSONCodecStd:
+		JSON = stdCodec{}
+	default:
+		return fmt.Errorf("unknown json codec %q", name)
+	}
+	return nil
+}
+
+type jsoniterCodec struct{}
+
+func (jsoniterCodec) Marshal(v interface{}) ([]byte, error) {
+	return jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(v)
+}
+
+func (jsoniterCodec) Unmarshal(data []byte, v interface{}) error {
+	return jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, v)
+}
+
+func (jsoniterCodec) NewEncoder(w io.Writer) JSONEncoder {
+	return jsoniter.ConfigCompatibleWithStandardLibrary.NewEncoder(w)
+}
+
+func (jsoniterCodec) NewDecoder(r io.Reader) JSONDecoder {
+	return jsoniter.ConfigCompatibleWithStandardLibrary.NewDecoder(r)
+}
+
+type stdCodec struct{}
+
+func (stdCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (stdCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (stdCodec) NewEncoder(w io.Writer) JSONEncoder {
+	return json.NewEncoder(w)
+}
+
+func (stdCodec) NewDecoder(r io.Reader) JSONDecoder {
+	return json.NewDecoder(r)
+}
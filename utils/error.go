@@ -1,31 +1,144 @@
 package utils
 
 import (
-	jsoniter "github.com/json-iterator/go"
+	"asset/models"
+	"context"
+	"database/sql"
+	"errors"
 	"net/http"
-	"time"
 
+	"github.com/go-playground/validator/v10"
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
 
+// RequestIDHeader carries the id of the request on every response, error bodies repeat it
+const RequestIDHeader = "X-Request-ID"
+
+// ClientError is the body of every error response. Code is stable and meant for clients to switch
+// on, Message is for people
 type ClientError struct {
-	Error      string `json:"error"`
-	Message    string `json:"message"`
-	StatusCode int    `json:"status_code"`
-	Timestamp  string `json:"timestamp"`
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
 }
 
+// RespondError answers with statusCode and userMessage unless err says better: a models.ServiceError
+// brings its own status, code and message, failed validation lists the fields, a body over the size
+// limit is a 413, and missing rows, duplicate keys, a serialization failure or deadlock left after the
+// retries of WithTransaction, or an expired request deadline that reach here as server errors become
+// 404, 409, 503 and 504
 func RespondError(w http.ResponseWriter, statusCode int, err error, userMessage string) {
-	logrus.Errorf("status: %d, user_message: %s, internal_error: %+v", statusCode, userMessage, err)
 	clientError := ClientError{
-		Error:      http.StatusText(statusCode),
-		Message:    userMessage,
-		StatusCode: statusCode,
-		Timestamp:  time.Now().Format(time.RFC3339),
+		Code:      codeForStatus(statusCode),
+		Message:   userMessage,
+		RequestID: w.Header().Get(RequestIDHeader),
+	}
+
+	var serviceErr *models.ServiceError
+	var validationErrs validator.ValidationErrors
+	var pqErr *pq.Error
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &serviceErr):
+		if serviceErr.Status != 0 {
+			statusCode = serviceErr.Status
+		}
+		clientError.Code = serviceErr.Code
+		clientError.Message = serviceErr.Message
+		clientError.Details = serviceErr.Details
+	case errors.As(err, &validationErrs):
+		statusCode = http.StatusBadRequest
+		clientError.Code = models.CodeValidationFailed
+		clientError.Details = FieldErrors(validationErrs)
+	case errors.As(err, &maxBytesErr):
+		statusCode = http.StatusRequestEntityTooLarge
+		clientError.Code = models.CodePayloadTooLarge
+		clientError.Message = "request body is too large"
+		clientError.Details = map[string]int64{"limit_bytes": maxBytesErr.Limit}
+	case statusCode >= http.StatusInternalServerError && errors.Is(err, sql.ErrNoRows):
+		statusCode = models.ErrNotFound.Status
+		clientError.Code = models.ErrNotFound.Code
+		clientError.Message = models.ErrNotFound.Message
+	case statusCode >= http.StatusInternalServerError && errors.As(err, &pqErr) && pqErr.Code == "23505":
+		statusCode = models.ErrConflict.Status
+		clientError.Code = models.ErrConflict.Code
+		clientError.Message = models.ErrConflict.Message
+	case statusCode >= http.StatusInternalServerError && IsTransientTxError(err):
+		statusCode = http.StatusServiceUnavailable
+		clientError.Code = models.CodeUnavailable
+		clientError.Message = "the database was busy, retry the request"
+		w.Header().Set("Retry-After", "1")
+	case statusCode >= http.StatusInternalServerError && (errors.Is(err, context.DeadlineExceeded) || isQueryCanceled(err)):
+		statusCode = http.StatusGatewayTimeout
+		clientError.Code = models.CodeTimeout
+		clientError.Message = "request timed out"
 	}
 
+	if statusCode >= http.StatusInternalServerError {
+		recordServerError(w, err)
+	}
+	logrus.Errorf("status: %d, code: %s, request_id: %s, user_message: %s, internal_error: %+v", statusCode, clientError.Code, clientError.RequestID, clientError.Message, err)
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
-	if err := jsoniter.NewEncoder(w).Encode(clientError); err != nil {
+	if err := JSON.NewEncoder(w).Encode(clientError); err != nil {
 		logrus.Errorf("failed to encode/send error response: %+v", err)
 	}
 }
+
+// ServerErrorRecorder is implemented by response writers that want the error behind a 5xx, the
+// error reporting middleware uses it to send the cause and not just the status
+type ServerErrorRecorder interface {
+	RecordServerError(err error)
+}
+
+// recordServerError hands err to the first recorder found while unwrapping w
+func recordServerError(w http.ResponseWriter, err error) {
+	for {
+		if recorder, ok := w.(ServerErrorRecorder); ok {
+			recorder.RecordServerError(err)
+			return
+		}
+		unwrapper, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return
+		}
+		w = unwrapper.Unwrap()
+	}
+}
+
+// codeForStatus is the code of errors that don't bring their own
+func codeForStatus(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest, http.StatusUnsupportedMediaType:
+		return models.CodeBadRequest
+	case http.StatusRequestEntityTooLarge:
+		return models.CodePayloadTooLarge
+	case http.StatusUnauthorized:
+		return models.CodeUnauthorized
+	case http.StatusForbidden:
+		return models.CodeForbidden
+	case http.StatusNotFound:
+		return models.CodeNotFound
+	case http.StatusConflict:
+		return models.CodeConflict
+	case http.StatusTooManyRequests:
+		return models.CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return models.CodeUnavailable
+	case http.StatusGatewayTimeout:
+		return models.CodeTimeout
+	}
+	if statusCode >= http.StatusInternalServerError {
+		return models.CodeInternal
+	}
+	return models.CodeBadRequest
+}
+
+// isQueryCanceled matches a statement postgres cancelled, which is how lib/pq reports a query whose
+// context ran out, and also what statement_timeout raises
+func isQueryCanceled(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "57014"
+}
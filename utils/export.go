@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"archive/zip"
+	"asset/models"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+)
+
+// ParseExportFormat reads the format query param, defaulting to csv
+func ParseExportFormat(r *http.Request) (string, error) {
+	format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+	switch format {
+	case "":
+		return ExportFormatCSV, nil
+	case ExportFormatCSV, ExportFormatXLSX:
+		return format, nil
+	}
+	return "", fmt.Errorf("unsupported export format %q", format)
+}
+
+// RespondExport streams the table as a file download in the given format
+func RespondExport(w http.ResponseWriter, format, filename string, header []string, rows [][]string) error {
+	w.Header().Set("Content-Type", ExportContentType(format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filename, ExportExtension(format)))
+	w.WriteHeader(http.StatusOK)
+	return WriteExport(w, format, header, rows)
+}
+
+// WriteExport writes the table in the given format, csv unless it's xlsx
+func WriteExport(w io.Writer, format string, header []string, rows [][]string) error {
+	if format == ExportFormatXLSX {
+		return WriteXLSX(w, header, rows)
+	}
+	return WriteCSV(w, header, rows)
+}
+
+func ExportContentType(format string) string {
+	if format == ExportFormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv"
+}
+
+func ExportExtension(format string) string {
+	if format == ExportFormatXLSX {
+		return ExportFormatXLSX
+	}
+	return ExportFormatCSV
+}
+
+// RespondFile sends stored file contents as a download
+func RespondFile(w http.ResponseWriter, contentType, filename string, data []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func WriteCSV(w io.Writer, header []string, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+)
+
+// ParseCSVBody reads a text/csv request body, the first record is the header and comes back lower
+// cased and trimmed so columns can be looked up by name
+func ParseCSVBody(r *http.Request) ([]string, [][]string, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "text/csv" {
+		return nil, nil, models.NewServiceError(http.StatusUnsupportedMediaType, models.CodeBadRequest, "body must be text/csv")
+	}
+	cr := csv.NewReader(r.Body)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil, models.NewServiceError(http.StatusBadRequest, models.CodeBadRequest, "csv has no header row")
+	}
+	header := records[0]
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+	return header, records[1:], nil
+}
+
+// WriteXLSX writes a single sheet workbook using inline strings, which keeps
+// it dependency free while still opening cleanly in excel and sheets
+func WriteXLSX(w io.Writer, header []string, rows [][]string) error {
+	zw := zip.NewWriter(w)
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.content); err != nil {
+			return err
+		}
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(sheet, `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return err
+	}
+	if err := writeXLSXRow(sheet, header); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := writeXLSXRow(sheet, row); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func writeXLSXRow(w io.Writer, cells []string) error {
+	if _, err := io.WriteString(w, "<row>"); err != nil {
+		return err
+	}
+	for _, cell := range cells {
+		if _, err := io.WriteString(w, `<c t="inlineStr"><is><t xml:space="preserve">`); err != nil {
+			return err
+		}
+		if err := xml.EscapeText(w, []byte(cell)); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "</t></is></c>"); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "</row>")
+	return err
+}
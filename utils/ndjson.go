@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	NDJSONContentType = "application/x-ndjson"
+	// rows are flushed to the client this often, so it sees progress and the server holds little
+	ndjsonFlushRows = 100
+	// each flush moves the write deadline, the server's write timeout would otherwise cut long exports
+	ndjsonWriteTimeout = 30 * time.Second
+)
+
+// NDJSONWriter streams a download as newline delimited json, one value per line. The status and
+// headers go out with the first row, so an error before it still gets a normal error response
+type NDJSONWriter struct {
+	w        http.ResponseWriter
+	stream   *http.ResponseController
+	encoder  JSONEncoder
+	filename string
+	rows     int
+}
+
+func NewNDJSONWriter(w http.ResponseWriter, filename string) *NDJSONWriter {
+	return &NDJSONWriter{w: w, stream: http.NewResponseController(w), filename: filename}
+}
+
+// Write sends one row
+func (n *NDJSONWriter) Write(row interface{}) error {
+	if n.encoder == nil {
+		n.start()
+	}
+	if err := n.encoder.Encode(row); err != nil {
+		return err
+	}
+	n.rows++
+	if n.rows%ndjsonFlushRows == 0 {
+		return n.flush()
+	}
+	return nil
+}
+
+// Rows is how many rows were written
+func (n *NDJSONWriter) Rows() int {
+	return n.rows
+}
+
+// Close ends the stream. An err before the first row is answered with RespondError, after it the
+// status is already sent and a last line {"error": ...} tells the client the download is incomplete
+func (n *NDJSONWriter) Close(err error, userMessage string) {
+	if n.encoder == nil {
+		if err != nil {
+			RespondError(n.w, http.StatusInternalServerError, err, userMessage)
+			return
+		}
+		// nothing matched, an empty download is still one
+		n.start()
+		return
+	}
+	if err != nil {
+		logrus.Errorf("ndjson stream failed after %d rows, request_id: %s, error: %+v", n.rows, n.w.Header().Get(RequestIDHeader), err)
+		recordServerError(n.w, err)
+		n.encoder.Encode(map[string]ClientError{"error": {
+			Code:      codeForStatus(http.StatusInternalServerError),
+			Message:   userMessage,
+			RequestID: n.w.Header().Get(RequestIDHeader),
+		}})
+	}
+	n.flush()
+}
+
+func (n *NDJSONWriter) start() {
+	n.w.Header().Set("Content-Type", NDJSONContentType)
+	n.w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.ndjson"`, n.filename))
+	n.w.Header().Set("X-Accel-Buffering", "no")
+	n.w.WriteHeader(http.StatusOK)
+	n.encoder = JSON.NewEncoder(n.w)
+	n.stream.SetWriteDeadline(time.Now().Add(ndjsonWriteTimeout))
+}
+
+// flush sends the buffered rows, a writer that can't flush just sends them when its buffer fills
+func (n *NDJSONWriter) flush() error {
+	n.stream.SetWriteDeadline(time.Now().Add(ndjsonWriteTimeout))
+	if err := n.stream.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// a token is fetched again this long before it expires, so a request never starts with one about
+// to lapse
+const tokenLeeway = time.Minute
+
+// OAuthToken is the token response of RFC 6749 section 5.1, the fields the integrations use
+type OAuthToken struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// TokenCache keeps the access token of an integration until shortly before it expires. The zero
+// value is ready to use
+type TokenCache struct {
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// Token returns the cached token or signs in with fetch. fetch runs under the lock, callers arriving
+// meanwhile wait for its token instead of signing in again. A failed fetch isn't cached
+func (c *TokenCache) Token(ctx context.Context, fetch func(ctx context.Context) (OAuthToken, error)) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Now().Add(tokenLeeway).Before(c.expires) {
+		return c.token, nil
+	}
+	token, err := fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	c.token = token.AccessToken
+	c.expires = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	return c.token, nil
+}
+
+// NewClientCredentialsRequest builds the token request of the client credentials grant, scope is
+// left out when empty. The secret goes in the form body like the integrations expect
+func NewClientCredentialsRequest(ctx context.Context, tokenURL, clientID, secret, scope string) (*http.Request, error) {
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {clientID},
+		"client_secret": {secret},
+	}
+	if scope != "" {
+		form.Set("scope", scope)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenCache(t *testing.T) {
+	ctx := context.Background()
+	var fetches atomic.Int32
+	fetchFor := func(expiresIn int) func(ctx context.Context) (OAuthToken, error) {
+		return func(ctx context.Context) (OAuthToken, error) {
+			n := fetches.Add(1)
+			return OAuthToken{AccessToken: "token-" + strconv.Itoa(int(n)), ExpiresIn: expiresIn}, nil
+		}
+	}
+
+	t.Run("kept until it is about to expire", func(t *testing.T) {
+		fetches.Store(0)
+		var cache TokenCache
+		for i := 0; i < 3; i++ {
+			token, err := cache.Token(ctx, fetchFor(3600))
+			require.NoError(t, err)
+			assert.Equal(t, "token-1", token)
+		}
+		assert.EqualValues(t, 1, fetches.Load())
+	})
+
+	t.Run("fetched again within the leeway", func(t *testing.T) {
+		fetches.Store(0)
+		var cache TokenCache
+		// a token living less than the leeway is never reused
+		first, err := cache.Token(ctx, fetchFor(30))
+		require.NoError(t, err)
+		second, err := cache.Token(ctx, fetchFor(30))
+		require.NoError(t, err)
+		assert.NotEqual(t, first, second)
+		assert.EqualValues(t, 2, fetches.Load())
+	})
+
+	t.Run("failures aren't cached", func(t *testing.T) {
+		fetches.Store(0)
+		var cache TokenCache
+		failed := errors.New("sign in failed")
+		_, err := cache.Token(ctx, func(ctx context.Context) (OAuthToken, error) { return OAuthToken{}, failed })
+		assert.ErrorIs(t, err, failed)
+		token, err := cache.Token(ctx, fetchFor(3600))
+		require.NoError(t, err)
+		assert.Equal(t, "token-1", token)
+	})
+
+	t.Run("concurrent callers share one sign in", func(t *testing.T) {
+		fetches.Store(0)
+		var cache TokenCache
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				token, err := cache.Token(ctx, fetchFor(3600))
+				assert.NoError(t, err)
+				assert.Equal(t, "token-1", token)
+			}()
+		}
+		wg.Wait()
+		assert.EqualValues(t, 1, fetches.Load())
+	})
+}
+
+func TestNewClientCredentialsRequest(t *testing.T) {
+	req, err := NewClientCredentialsRequest(context.Background(), "https://idp.example.com/token", "client", "s3cret", "read write")
+	require.NoError(t, err)
+	assert.Equal(t, "POST", req.Method)
+	assert.Equal(t, "application/x-www-form-urlencoded", req.Header.Get("Content-Type"))
+	require.NoError(t, req.ParseForm())
+	assert.Equal(t, url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {"client"},
+		"client_secret": {"s3cret"},
+		"scope":         {"read write"},
+	}, req.PostForm)
+
+	req, err = NewClientCredentialsRequest(context.Background(), "https://idp.example.com/token", "client", "s3cret", "")
+	require.NoError(t, err)
+	require.NoError(t, req.ParseForm())
+	_, ok := req.PostForm["scope"]
+	assert.False(t, ok)
+}
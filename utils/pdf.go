@@ -0,0 +1,107 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+const (
+	// a4 in points, text starts pdfMargin in from the left and top
+	pdfPageWidth   = 595
+	pdfPageHeight  = 842
+	pdfMargin      = 56
+	pdfLeading     = 16
+	pdfLineLength  = 90
+	pdfLinesOnPage = (pdfPageHeight - 2*pdfMargin - 2*pdfLeading) / pdfLeading
+)
+
+// TextPDF renders title and lines as a plain a4 document in helvetica, wrapping long lines and
+// starting new pages as needed. It is for generated paperwork like handover forms, characters
+// outside printable ascii come out as '?'
+func TextPDF(title string, lines []string) []byte {
+	var wrapped []string
+	for _, line := range lines {
+		wrapped = append(wrapped, wrapPDFLine(line)...)
+	}
+	var pages [][]string
+	for len(wrapped) > pdfLinesOnPage {
+		pages = append(pages, wrapped[:pdfLinesOnPage])
+		wrapped = wrapped[pdfLinesOnPage:]
+	}
+	pages = append(pages, wrapped)
+
+	// objects 1 to 3 are the catalog, the page tree and the font, each page adds itself and its content
+	var objects []string
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	objects = append(objects,
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+	)
+	for i, page := range pages {
+		var content strings.Builder
+		top := pdfPageHeight - pdfMargin
+		if i == 0 {
+			fmt.Fprintf(&content, "BT /F1 16 Tf %d %d Td (%s) Tj ET\n", pdfMargin, top, escapePDFText(title))
+		}
+		fmt.Fprintf(&content, "BT /F1 11 Tf %d TL %d %d Td\n", pdfLeading, pdfMargin, top-2*pdfLeading)
+		for _, line := range page {
+			fmt.Fprintf(&content, "(%s) Tj T*\n", escapePDFText(line))
+		}
+		content.WriteString("ET")
+		objects = append(objects,
+			fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+				pdfPageWidth, pdfPageHeight, 5+2*i),
+			fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.String()),
+		)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return out.Bytes()
+}
+
+// wrapPDFLine breaks a line at spaces so it fits the page, an empty line stays a blank line
+func wrapPDFLine(line string) []string {
+	var wrapped []string
+	for len(line) > pdfLineLength {
+		cut := strings.LastIndex(line[:pdfLineLength], " ")
+		if cut <= 0 {
+			cut = pdfLineLength
+		}
+		wrapped = append(wrapped, line[:cut])
+		line = strings.TrimLeft(line[cut:], " ")
+	}
+	return append(wrapped, line)
+}
+
+func escapePDFText(text string) string {
+	var escaped strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			escaped.WriteRune('\\')
+			escaped.WriteRune(r)
+		case r < 32 || r > 126:
+			escaped.WriteRune('?')
+		default:
+			escaped.WriteRune(r)
+		}
+	}
+	return escaped.String()
+}
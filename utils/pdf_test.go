@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTextPDF(t *testing.T) {
+	lines := []string{"Asset: Dell XPS (SN-1)", `Path C:\laptops`, "Employee: Zoë", strings.Repeat("word ", 30)}
+	for i := 0; i < pdfLinesOnPage; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	pdf := TextPDF("Handover", lines)
+
+	require.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+	require.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+	assert.Contains(t, string(pdf), `(Asset: Dell XPS \(SN-1\)) Tj`)
+	assert.Contains(t, string(pdf), `(Path C:\\laptops) Tj`)
+	assert.Contains(t, string(pdf), `(Employee: Zo?) Tj`)
+	assert.Contains(t, string(pdf), "/Count 2")
+
+	// every xref entry points at the start of its object
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	require.NotNil(t, startxref)
+	xref, err := strconv.Atoi(string(startxref[1]))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(pdf[xref:], []byte("xref\n")))
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[xref:], -1)
+	require.Len(t, entries, 7)
+	for i, entry := range entries {
+		offset, err := strconv.Atoi(string(entry[1]))
+		require.NoError(t, err)
+		assert.True(t, bytes.HasPrefix(pdf[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))), "object %d", i+1)
+	}
+}
+
+func TestWrapPDFLine(t *testing.T) {
+	wrapped := wrapPDFLine(strings.Repeat("abcd ", 40))
+	require.Len(t, wrapped, 3)
+	for _, line := range wrapped {
+		assert.LessOrEqual(t, len(line), pdfLineLength)
+	}
+	assert.Equal(t, []string{""}, wrapPDFLine(""))
+	assert.Len(t, wrapPDFLine(strings.Repeat("x", 200)), 3)
+}
@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type locationContextKey struct{}
+
+// requestLocation looks the caller's timezone up the first time the request asks for it, most
+// requests never do
+type requestLocation struct {
+	once    sync.Once
+	resolve func() *time.Location
+	loc     *time.Location
+}
+
+// ContextWithLocation returns ctx carrying the caller's timezone, resolve runs at most once and
+// returns nil when the caller hasn't picked one
+func ContextWithLocation(ctx context.Context, resolve func() *time.Location) context.Context {
+	return context.WithValue(ctx, locationContextKey{}, &requestLocation{resolve: resolve})
+}
+
+// LocationFromContext is the caller's timezone, nil when they haven't picked one or ctx isn't a request
+func LocationFromContext(ctx context.Context) *time.Location {
+	l, ok := ctx.Value(locationContextKey{}).(*requestLocation)
+	if !ok {
+		return nil
+	}
+	l.once.Do(func() { l.loc = l.resolve() })
+	return l.loc
+}
+
+// CalendarDate is the day t falls on in loc as midnight UTC, the form date only fields like
+// purchase_date are stored in. A nil loc takes the day in the offset t came with
+func CalendarDate(t time.Time, loc *time.Location) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	if loc != nil {
+		t = t.In(loc)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// InLocation returns t in loc for responses, left as it is when loc is nil
+func InLocation(t time.Time, loc *time.Location) time.Time {
+	if loc == nil || t.IsZero() {
+		return t
+	}
+	return t.In(loc)
+}
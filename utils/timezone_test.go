@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendarDate(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	assert.NoError(t, err)
+	march1 := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// midnight in Kolkata, sent as is or already converted to UTC by the client
+	picked := time.Date(2024, 3, 1, 0, 0, 0, 0, kolkata)
+	assert.Equal(t, march1, CalendarDate(picked, nil))
+	assert.Equal(t, march1, CalendarDate(picked.UTC(), kolkata))
+	assert.Equal(t, march1.AddDate(0, 0, -1), CalendarDate(picked.UTC(), nil))
+
+	assert.True(t, CalendarDate(time.Time{}, kolkata).IsZero())
+}
+
+func TestLocationFromContext(t *testing.T) {
+	assert.Nil(t, LocationFromContext(context.Background()))
+
+	calls := 0
+	ctx := ContextWithLocation(context.Background(), func() *time.Location {
+		calls++
+		return time.UTC
+	})
+	assert.Equal(t, 0, calls)
+	assert.Equal(t, time.UTC, LocationFromContext(ctx))
+	assert.Equal(t, time.UTC, LocationFromContext(ctx))
+	assert.Equal(t, 1, calls)
+}
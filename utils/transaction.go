@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// a transaction is run at most this many times, waiting txRetryBackoff, then twice that and so on
+// with up to half of it again as jitter so the transactions that collided don't collide again
+const (
+	txMaxAttempts  = 3
+	txRetryBackoff = 20 * time.Millisecond
+)
+
+// DBTX is what repositories query through, *sqlx.DB and *sqlx.Tx both are one
+type DBTX interface {
+	sqlx.ExtContext
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txContextKey struct{}
+
+// ContextWithTx returns ctx carrying tx, the repositories given it run their queries in tx
+func ContextWithTx(ctx context.Context, tx *sqlx.Tx) context.Context {
+	return context.WithValue(ctx, txContextKey{}, tx)
+}
+
+// TxFromContext is the transaction ctx carries, if any
+func TxFromContext(ctx context.Context) (*sqlx.Tx, bool) {
+	tx, ok := ctx.Value(txContextKey{}).(*sqlx.Tx)
+	return tx, ok
+}
+
+// Conn is the transaction ctx carries or else db. Repositories run every query through it, so they
+// take part in whatever transaction the service opened without being told about it
+func Conn(ctx context.Context, db *sqlx.DB) DBTX {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx
+	}
+	return db
+}
+
+// IsTransientTxError reports whether postgres aborted the transaction only because of a concurrent
+// one, a serialization failure or a deadlock. Running it again is expected to succeed
+func IsTransientTxError(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return pqErr.Code == "40001" || pqErr.Code == "40P01"
+}
+
+// WithTransaction runs fn with a ctx carrying a transaction, committing when fn returns nil. A
+// transient error from fn or the commit rolls back and runs fn again in a new transaction, so fn must
+// not have effects outside the database. A panic rolls back and is passed on. Called with a ctx that
+// already carries a transaction, fn joins it and the outermost call commits or retries
+func WithTransaction(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context) error) error {
+	if _, ok := TxFromContext(ctx); ok {
+		return fn(ctx)
+	}
+	backoff := txRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := runTransaction(ctx, db, fn)
+		if err == nil || !IsTransientTxError(err) || attempt == txMaxAttempts {
+			return err
+		}
+		wait := backoff + time.Duration(rand.Int63n(int64(backoff/2)))
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		backoff *= 2
+	}
+}
+
+// WithTransactionOnce is WithTransaction without the retries, for fn that calls firebase, sends
+// mail or publishes and can't be run twice
+func WithTransactionOnce(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context) error) error {
+	if _, ok := TxFromContext(ctx); ok {
+		return fn(ctx)
+	}
+	return runTransaction(ctx, db, fn)
+}
+
+func runTransaction(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context) error) (err error) {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		} else if err != nil {
+			tx.Rollback()
+		} else if err = tx.Commit(); err != nil {
+			err = fmt.Errorf("failed to commit transaction: %w", err)
+		}
+	}()
+	return fn(ContextWithTx(ctx, tx))
+}
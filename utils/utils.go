@@ -1,19 +1,24 @@
 package utils
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	jsoniter "github.com/json-iterator/go"
 	"go.uber.org/zap"
 	"net/http"
 	"time"
 )
 
 func ParseJSONBody(r *http.Request, dst interface{}) error {
-	decoder := jsoniter.NewDecoder(r.Body)
+	decoder := JSON.NewDecoder(r.Body)
 	decoder.DisallowUnknownFields()
 	err := decoder.Decode(dst)
 	if err != nil {
+		// jsoniter flattens reader errors into its message, a body cut off by the size limit keeps
+		// failing with the typed error so RespondError can still answer 413
+		var tooLarge *http.MaxBytesError
+		if _, readErr := r.Body.Read(make([]byte, 1)); errors.As(readErr, &tooLarge) {
+			return tooLarge
+		}
 		return err
 	}
 	return nil
@@ -24,7 +29,7 @@ type Role string
 func RespondJSON(w http.ResponseWriter, statusCode int, payload interface{}) {
 	currentTimeBefore := time.Now()
 	fmt.Print("json time ::", currentTimeBefore)
-	response, err := json.Marshal(payload)
+	response, err := JSON.Marshal(payload)
 	if err != nil {
 		http.Error(w, "Failed to serialize JSON response", http.StatusInternalServerError)
 		return
@@ -0,0 +1,214 @@
+package utils
+
+import (
+	"asset/models"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// validate is shared by every handler, it caches struct metadata and names fields by their json tag
+var validate = newValidator()
+
+// redactedFields never have their value echoed back in a validation error
+var redactedFields = []string{"password", "secret", "token", "otp", "code"}
+
+// FieldError is one failed rule in the details of a validation_failed response. Field is the json
+// path of the value inside the request body, e.g. items[1].asset_type
+type FieldError struct {
+	Field   string      `json:"field"`
+	Rule    string      `json:"rule"`
+	Param   string      `json:"param,omitempty"`
+	Value   interface{} `json:"value,omitempty"`
+	Message string      `json:"message"`
+}
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	// enum fields are checked against the registry in models so the rule and GET /api/meta/enums agree
+	for tag, values := range enumRules {
+		v.RegisterValidation(tag, func(fl validator.FieldLevel) bool {
+			return slices.Contains(values, fl.Field().String())
+		})
+	}
+	return v
+}
+
+// enumRules are the validate tags backed by models.EmployeeTypes and the rest
+var enumRules = map[string][]string{
+	"employee_type":      models.EmployeeTypes,
+	"asset_type":         models.AssetTypes,
+	"asset_status":       models.AssetStatuses,
+	"asset_ownership":    models.AssetOwnerships,
+	"handover_condition": models.HandoverConditions,
+}
+
+// ValidateStruct checks the validate tags of a request body, failures are validator.ValidationErrors
+// which RespondError turns into a list of FieldError
+func ValidateStruct(req interface{}) error {
+	return validate.Struct(req)
+}
+
+// FieldErrors describes each failed rule by json path, rule and the value that was sent
+func FieldErrors(errs validator.ValidationErrors) []FieldError {
+	fields := make([]FieldError, 0, len(errs))
+	for _, fieldErr := range errs {
+		path := fieldErr.Namespace()
+		// the namespace starts with the name of the request struct, which the client never sees
+		if i := strings.Index(path, "."); i >= 0 {
+			path = path[i+1:]
+		}
+		field := FieldError{
+			Field:   path,
+			Rule:    fieldErr.Tag(),
+			Param:   fieldErr.Param(),
+			Message: fieldReason(fieldErr),
+		}
+		if !isRedacted(fieldErr.Field()) {
+			field.Value = providedValue(fieldErr.Value())
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func isRedacted(field string) bool {
+	field = strings.ToLower(field)
+	for _, name := range redactedFields {
+		if strings.Contains(field, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// providedValue keeps scalars only, echoing whole objects or lists back would bloat the response
+func providedValue(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if rv.IsZero() {
+			return nil
+		}
+		return rv.Interface()
+	}
+	return nil
+}
+
+func fieldReason(fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+	switch fieldErr.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "uuid", "uuid4":
+		return "must be a valid uuid"
+	case "url", "http_url":
+		return "must be a valid url"
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(param), ", ")
+	case "employee_type", "asset_type", "asset_status", "asset_ownership":
+		return "must be one of: " + strings.Join(enumRules[fieldErr.Tag()], ", ")
+	case "min":
+		if isLengthKind(fieldErr.Kind()) {
+			return "must have at least " + param + " characters or items"
+		}
+		return "must be at least " + param
+	case "max":
+		if isLengthKind(fieldErr.Kind()) {
+			return "must have at most " + param + " characters or items"
+		}
+		return "must be at most " + param
+	case "len":
+		return "must have exactly " + param + " characters or items"
+	case "gt", "gte", "lt", "lte":
+		return "must be " + map[string]string{"gt": "greater than", "gte": "at least", "lt": "less than", "lte": "at most"}[fieldErr.Tag()] + " " + param
+	case "numeric", "number":
+		return "must be numeric"
+	case "dive":
+		return "has an invalid item"
+	}
+	return "failed the " + fieldErr.Tag() + " rule"
+}
+
+func isLengthKind(kind reflect.Kind) bool {
+	return kind == reflect.String || kind == reflect.Slice || kind == reflect.Map || kind == reflect.Array
+}
+
+// DescribeFields lists the fields of a request struct by json name with the rules of their validate
+// tags, so clients can build a form that passes ValidateStruct
+func DescribeFields(req interface{}) []models.ConfigField {
+	t := reflect.TypeOf(req)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	fields := make([]models.ConfigField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		name := strings.SplitN(structField.Tag.Get("json"), ",", 2)[0]
+		if !structField.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = structField.Name
+		}
+		field := models.ConfigField{Name: name, Type: jsonType(structField.Type.Kind())}
+		for _, rule := range strings.Split(structField.Tag.Get("validate"), ",") {
+			key, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+			switch key {
+			case "", "omitempty":
+			case "required":
+				field.Required = true
+			default:
+				if field.Constraints == nil {
+					field.Constraints = map[string]string{}
+				}
+				field.Constraints[key] = param
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func jsonType(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+	return "string"
+}
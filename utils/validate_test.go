@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"asset/models"
+	"errors"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnumRules(t *testing.T) {
+	type req struct {
+		Type      string   `json:"type" validate:"required,employee_type"`
+		AssetType []string `json:"asset_type" validate:"omitempty,dive,asset_type"`
+	}
+
+	assert.NoError(t, ValidateStruct(req{Type: "intern", AssetType: []string{"laptop", "sim"}}))
+
+	err := ValidateStruct(req{Type: "contractor", AssetType: []string{"laptop", "tablet"}})
+	var errs validator.ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	fields := FieldErrors(errs)
+	require.Len(t, fields, 2)
+	assert.Equal(t, "type", fields[0].Field)
+	assert.Equal(t, "must be one of: full_time, intern, freelancer", fields[0].Message)
+	assert.Equal(t, "asset_type[1]", fields[1].Field)
+	assert.Equal(t, "tablet", fields[1].Value)
+}
+
+func TestDescribeFields(t *testing.T) {
+	type req struct {
+		Name   string `json:"name" validate:"required,max=100"`
+		Count  int    `json:"count,omitempty" validate:"omitempty,gte=0"`
+		Note   string `json:"note"`
+		Hidden string `json:"-"`
+	}
+
+	fields := DescribeFields(req{})
+	require.Len(t, fields, 3)
+	assert.Equal(t, models.ConfigField{Name: "name", Type: "string", Required: true, Constraints: map[string]string{"max": "100"}}, fields[0])
+	assert.Equal(t, models.ConfigField{Name: "count", Type: "integer", Constraints: map[string]string{"gte": "0"}}, fields[1])
+	assert.Equal(t, models.ConfigField{Name: "note", Type: "string"}, fields[2])
+}
+
+func TestAssignmentMetadataRules(t *testing.T) {
+	assert.NoError(t, ValidateStruct(models.AssetAssignReq{}))
+	assert.NoError(t, ValidateStruct(models.AssetAssignReq{Metadata: &models.AssignmentMetadata{Accessories: []string{"charger"}, HandoverCondition: "fair"}}))
+
+	err := ValidateStruct(models.AssetAssignReq{Metadata: &models.AssignmentMetadata{Accessories: []string{""}, HandoverCondition: "broken"}})
+	var errs validator.ValidationErrors
+	require.True(t, errors.As(err, &errs))
+	fields := FieldErrors(errs)
+	require.Len(t, fields, 2)
+	assert.Equal(t, "metadata.accessories[0]", fields[0].Field)
+	assert.Equal(t, "metadata.handover_condition", fields[1].Field)
+}
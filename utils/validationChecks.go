@@ -4,20 +4,17 @@ import (
 	"asset/models"
 	"fmt"
 	"github.com/pkg/errors"
+	"slices"
 	"strings"
 	"time"
 )
 
 func IsAssetTypeValid(assetType string) bool {
-	return assetType == "laptop" || assetType == "mouse" || assetType == "monitor" || assetType == "hard_disk" ||
-		assetType == "pen_drive" ||
-		assetType == "mobile" ||
-		assetType == "sim" ||
-		assetType == "accessory"
+	return slices.Contains(models.AssetTypes, assetType)
 }
 
 func IsOwnershipValid(ownership string) bool {
-	return ownership == "remotestate" || ownership == "client"
+	return slices.Contains(models.AssetOwnerships, ownership)
 }
 
 func AssetValidityCheck(reqModel models.AddAssetWithConfigReq) error {
@@ -46,3 +43,38 @@ func AssetValidityCheck(reqModel models.AddAssetWithConfigReq) error {
 	}
 	return nil
 }
+
+// NormalizePhoneNumber converts a contact number to E.164 (+<country code><number>), numbers without a
+// country code get defaultCountryCode, spaces, dashes, dots and brackets are ignored, anything else is rejected
+func NormalizePhoneNumber(raw, defaultCountryCode string) (string, error) {
+	var digits strings.Builder
+	value := strings.TrimSpace(raw)
+	international := false
+	switch {
+	case strings.HasPrefix(value, "+"):
+		international = true
+		value = value[1:]
+	case strings.HasPrefix(value, "00"):
+		international = true
+		value = value[2:]
+	}
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", fmt.Errorf("invalid character %q in phone number", r)
+		}
+	}
+
+	number := digits.String()
+	if !international {
+		// national numbers may carry a trunk prefix, e.g. 0 in 098765 43210
+		number = defaultCountryCode + strings.TrimLeft(number, "0")
+	}
+	if len(number) < 8 || len(number) > 15 || number[0] == '0' {
+		return "", errors.New("phone number must have 8 to 15 digits including the country code")
+	}
+	return "+" + number, nil
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	body := []byte(`{"id":"evt-1","type":"asset.assigned"}`)
+	sentAt := time.Unix(1_700_000_000, 0)
+	secret, err := GenerateWebhookSecret()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(secret, "whsec_"))
+
+	header := SignWebhookPayload(body, sentAt, secret)
+
+	// a receiver recomputing the hmac over "<t>.<body>" gets the same v1
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("1700000000." + string(body)))
+	assert.Equal(t, "t=1700000000,v1="+hex.EncodeToString(mac.Sum(nil)), header)
+
+	tests := []struct {
+		name    string
+		secret  string
+		header  string
+		body    []byte
+		now     time.Time
+		wantErr error
+	}{
+		{name: "round trip", secret: secret, header: header, body: body, now: sentAt},
+		{name: "within the tolerance after", secret: secret, header: header, body: body, now: sentAt.Add(DefaultWebhookTolerance)},
+		{name: "within the tolerance before", secret: secret, header: header, body: body, now: sentAt.Add(-DefaultWebhookTolerance)},
+		{name: "replayed too late", secret: secret, header: header, body: body, now: sentAt.Add(DefaultWebhookTolerance + time.Second), wantErr: ErrWebhookSignatureExpired},
+		{name: "timestamp in the future", secret: secret, header: header, body: body, now: sentAt.Add(-DefaultWebhookTolerance - time.Second), wantErr: ErrWebhookSignatureExpired},
+		{name: "tampered body", secret: secret, header: header, body: []byte(`{"id":"evt-1","type":"asset.returned"}`), now: sentAt, wantErr: ErrWebhookSignatureInvalid},
+		{
+			name:    "tampered timestamp",
+			secret:  secret,
+			header:  strings.Replace(header, "t=1700000000", "t=1700000100", 1),
+			body:    body,
+			now:     sentAt.Add(100 * time.Second),
+			wantErr: ErrWebhookSignatureInvalid,
+		},
+		{name: "other secret", secret: "whsec_other", header: header, body: body, now: sentAt, wantErr: ErrWebhookSignatureInvalid},
+		{name: "missing timestamp", secret: secret, header: header[strings.Index(header, ",")+1:], body: body, now: sentAt, wantErr: ErrWebhookSignatureInvalid},
+		{name: "missing signature", secret: secret, header: "t=1700000000", body: body, now: sentAt, wantErr: ErrWebhookSignatureInvalid},
+		{name: "unknown scheme only", secret: secret, header: strings.Replace(header, "v1=", "v0=", 1), body: body, now: sentAt, wantErr: ErrWebhookSignatureInvalid},
+		{name: "empty header", secret: secret, body: body, now: sentAt, wantErr: ErrWebhookSignatureInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyWebhookSignature(tt.secret, tt.header, tt.body, tt.now, DefaultWebhookTolerance)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestWebhookSignatureDuringRotation(t *testing.T) {
+	body := []byte(`{"id":"evt-2"}`)
+	sentAt := time.Unix(1_700_000_000, 0)
+	current, previous := "whsec_current", "whsec_previous"
+
+	header := SignWebhookPayload(body, sentAt, current, previous)
+	assert.Equal(t, 2, strings.Count(header, "v1="))
+
+	// a receiver that switched and one that hasn't yet both accept the delivery
+	assert.NoError(t, VerifyWebhookSignature(current, header, body, sentAt, DefaultWebhookTolerance))
+	assert.NoError(t, VerifyWebhookSignature(previous, header, body, sentAt, DefaultWebhookTolerance))
+	assert.ErrorIs(t, VerifyWebhookSignature("whsec_unrelated", header, body, sentAt, DefaultWebhookTolerance), ErrWebhookSignatureInvalid)
+
+	// once the overlap ends only the current secret signs
+	header = SignWebhookPayload(body, sentAt, current)
+	assert.NoError(t, VerifyWebhookSignature(current, header, body, sentAt, DefaultWebhookTolerance))
+	assert.ErrorIs(t, VerifyWebhookSignature(previous, header, body, sentAt, DefaultWebhookTolerance), ErrWebhookSignatureInvalid)
+
+	// entries may come in any order and with spaces after the commas
+	reordered := "v1=" + strings.Split(SignWebhookPayload(body, sentAt, previous), "v1=")[1] + ", t=1700000000"
+	assert.NoError(t, VerifyWebhookSignature(previous, reordered, body, sentAt, DefaultWebhookTolerance))
+}